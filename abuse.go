@@ -0,0 +1,318 @@
+package goa
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// AbuseAllow lets the request through.
+	AbuseAllow AbuseAction = iota
+	// AbuseThrottle rejects the request with a 429 (Too Many Requests) response.
+	AbuseThrottle
+	// AbuseBlock rejects the request with a 403 (Forbidden) response.
+	AbuseBlock
+)
+
+// Parameter anomalies reported in the abuse fingerprints.
+const (
+	// AnomalyLongValue is reported when a parameter value exceeds MaxAbuseParamLength bytes.
+	AnomalyLongValue = "long_value"
+	// AnomalyControlChars is reported when a parameter value contains control characters.
+	AnomalyControlChars = "control_chars"
+	// AnomalyRepeatedParam is reported when a parameter is given more than once.
+	AnomalyRepeatedParam = "repeated_param"
+)
+
+var (
+	// AbuseWindow is the duration of the windows over which the request rates and error ratios
+	// of the abuse fingerprints are computed.
+	AbuseWindow = time.Minute
+
+	// MaxAbuseParamLength is the length above which parameter values are reported as
+	// anomalies.
+	MaxAbuseParamLength = 1024
+)
+
+type (
+	// AbuseAction is the decision made by the abuse detectors.
+	AbuseAction int
+
+	// AbuseFingerprint describes a request made to an abuse-sensitive action and the recent
+	// requests made by the same principal to the abuse-sensitive actions.
+	AbuseFingerprint struct {
+		// Principal is the principal that made the request, see QuotaPrincipal.
+		Principal string
+		// Controller is the name of the controller handling the request.
+		Controller string
+		// Action is the name of the action handling the request.
+		Action string
+		// Requests is the number of requests made by the principal during the current window
+		// including this one.
+		Requests int
+		// Spike is the ratio of Requests to the number of requests made during the previous
+		// window, Requests if there were none.
+		Spike float64
+		// ErrorRatio is the ratio of the requests made during the current window that
+		// produced an error response (status 400 or above), zero if none completed.
+		ErrorRatio float64
+		// Anomalies lists the parameter anomalies of the request, e.g. AnomalyLongValue.
+		Anomalies []string
+	}
+
+	// AbuseDecision is the decision made by an abuse detector.
+	AbuseDecision struct {
+		// Action is AbuseAllow, AbuseThrottle or AbuseBlock.
+		Action AbuseAction
+		// RetryAfter is the delay advertised to throttled clients if not zero.
+		RetryAfter time.Duration
+		// Reason describes the decision, it is logged and used as detail of the block
+		// responses.
+		Reason string
+	}
+
+	// AbuseDetector inspects the fingerprints of the requests made to abuse-sensitive actions.
+	// Detectors may keep their own state, e.g. to block principals for a while, and must be
+	// safe for concurrent use.
+	AbuseDetector interface {
+		// Inspect returns the decision for the request with the given fingerprint. A nil
+		// decision lets the request through.
+		Inspect(ctx context.Context, fp *AbuseFingerprint) (*AbuseDecision, error)
+	}
+
+	// AbuseDetectorFunc is the function counterpart of AbuseDetector.
+	AbuseDetectorFunc func(ctx context.Context, fp *AbuseFingerprint) (*AbuseDecision, error)
+
+	// ThresholdDetector is an AbuseDetector that throttles or blocks the principals whose
+	// fingerprints exceed fixed thresholds. Zero thresholds are ignored.
+	ThresholdDetector struct {
+		// MaxRequests is the maximum number of requests per window.
+		MaxRequests int
+		// MaxSpike is the maximum ratio of the current window requests to the previous
+		// window requests.
+		MaxSpike float64
+		// MaxErrorRatio is the maximum ratio of error responses, it only applies once the
+		// principal made MinRequests requests during the window.
+		MaxErrorRatio float64
+		// MinRequests is the number of requests above which MaxErrorRatio applies.
+		MinRequests int
+		// BlockAnomalies blocks the requests with parameter anomalies if true.
+		BlockAnomalies bool
+		// Block makes the detector block the requests that exceed the thresholds instead
+		// of throttling them.
+		Block bool
+	}
+
+	// abuseTracker keeps the request counts of the principals.
+	abuseTracker struct {
+		mu        sync.Mutex
+		stats     map[string]*abuseStats
+		lastSweep time.Time
+	}
+
+	// abuseStats holds the request counts of a principal.
+	abuseStats struct {
+		start      time.Time
+		requests   int
+		completed  int
+		errors     int
+		prevWindow int
+	}
+)
+
+// Inspect calls f.
+func (f AbuseDetectorFunc) Inspect(ctx context.Context, fp *AbuseFingerprint) (*AbuseDecision, error) {
+	return f(ctx, fp)
+}
+
+// Inspect throttles or blocks the request if the fingerprint exceeds one of the thresholds.
+func (d *ThresholdDetector) Inspect(ctx context.Context, fp *AbuseFingerprint) (*AbuseDecision, error) {
+	action := AbuseThrottle
+	if d.Block {
+		action = AbuseBlock
+	}
+	switch {
+	case d.BlockAnomalies && len(fp.Anomalies) > 0:
+		return &AbuseDecision{Action: AbuseBlock, Reason: "parameter anomalies: " + strings.Join(fp.Anomalies, ", ")}, nil
+	case d.MaxRequests > 0 && fp.Requests > d.MaxRequests:
+		return &AbuseDecision{Action: action, RetryAfter: AbuseWindow, Reason: "too many requests"}, nil
+	case d.MaxSpike > 0 && fp.Spike > d.MaxSpike:
+		return &AbuseDecision{Action: action, RetryAfter: AbuseWindow, Reason: "request rate spike"}, nil
+	case d.MaxErrorRatio > 0 && fp.Requests > d.MinRequests && fp.ErrorRatio > d.MaxErrorRatio:
+		return &AbuseDecision{Action: action, RetryAfter: AbuseWindow, Reason: "too many errors"}, nil
+	}
+	return nil, nil
+}
+
+// SetAbuseDetectors sets the detectors that inspect the requests made to the abuse-sensitive
+// actions. The detectors are invoked in order, the first decision that throttles or blocks the
+// request wins.
+func (service *Service) SetAbuseDetectors(detectors ...AbuseDetector) {
+	service.abuseMu.Lock()
+	defer service.abuseMu.Unlock()
+	service.detectors = detectors
+	if service.abuseStats == nil {
+		service.abuseStats = &abuseTracker{stats: make(map[string]*abuseStats), lastSweep: time.Now()}
+	}
+}
+
+// DetectAbuse returns a handler that computes the fingerprint of the request, feeds it to the
+// detectors set on the service with SetAbuseDetectors and throttles or blocks the request as
+// decided by the detectors before calling h. Throttled requests get a 429 response with a
+// Retry-After header, blocked requests a 403 response. Requests are let through if a detector
+// fails. The response status is recorded to compute the error ratio of the principal. The code
+// generated by goagen wraps the handlers of the actions tagged with the AbuseSensitive DSL with
+// DetectAbuse.
+func DetectAbuse(h Handler) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		service := RequestService(ctx)
+		if service == nil {
+			return h(ctx, rw, req)
+		}
+		service.abuseMu.RLock()
+		detectors, tracker := service.detectors, service.abuseStats
+		service.abuseMu.RUnlock()
+		if len(detectors) == 0 {
+			return h(ctx, rw, req)
+		}
+		principal := QuotaPrincipal(ctx, req)
+		now := time.Now()
+		fp := tracker.fingerprint(principal, now)
+		fp.Controller = ContextController(ctx)
+		fp.Action = ContextAction(ctx)
+		params := req.URL.Query()
+		if r := Request(ctx); r != nil && r.Params != nil {
+			params = r.Params
+		}
+		fp.Anomalies = paramAnomalies(params)
+		for _, d := range detectors {
+			decision, err := d.Inspect(ctx, fp)
+			if err != nil {
+				Error(ctx, "abuse detector", KV{"err", err})
+				continue
+			}
+			if decision == nil || decision.Action == AbuseAllow {
+				continue
+			}
+			Info(ctx, "abuse", KV{"principal", principal}, KV{"action", decision.Action.String()}, KV{"reason", decision.Reason})
+			tracker.record(principal, now, true)
+			if decision.Action == AbuseBlock {
+				return ErrForbidden(decision.Reason)
+			}
+			reject(rw, http.StatusTooManyRequests, decision.RetryAfter)
+			return nil
+		}
+		err := h(ctx, rw, req)
+		status := ErrorStatus(err)
+		if err == nil {
+			if resp := Response(ctx); resp != nil {
+				status = resp.Status
+			}
+		}
+		tracker.record(principal, now, status >= 400)
+		return err
+	}
+}
+
+// String returns the name of the action.
+func (a AbuseAction) String() string {
+	switch a {
+	case AbuseThrottle:
+		return "throttle"
+	case AbuseBlock:
+		return "block"
+	}
+	return "allow"
+}
+
+// fingerprint counts the request and returns the fingerprint of the principal recent requests.
+func (t *abuseTracker) fingerprint(principal string, now time.Time) *AbuseFingerprint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.lastSweep) >= AbuseWindow {
+		for p, s := range t.stats {
+			if now.Sub(s.start) >= 2*AbuseWindow {
+				delete(t.stats, p)
+			}
+		}
+		t.lastSweep = now
+	}
+	s := t.window(principal, now)
+	s.requests++
+	fp := &AbuseFingerprint{Principal: principal, Requests: s.requests, Spike: float64(s.requests)}
+	if s.prevWindow > 0 {
+		fp.Spike = float64(s.requests) / float64(s.prevWindow)
+	}
+	if s.completed > 0 {
+		fp.ErrorRatio = float64(s.errors) / float64(s.completed)
+	}
+	return fp
+}
+
+// record records the outcome of a request made by the principal at the given time.
+func (t *abuseTracker) record(principal string, at time.Time, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.window(principal, at)
+	if at.Before(s.start) {
+		// The window ended while the request was being handled.
+		return
+	}
+	s.completed++
+	if failed {
+		s.errors++
+	}
+}
+
+// window returns the stats of the principal, rolling the window if it ended. t.mu must be held.
+func (t *abuseTracker) window(principal string, now time.Time) *abuseStats {
+	s, ok := t.stats[principal]
+	if !ok {
+		s = &abuseStats{start: now}
+		t.stats[principal] = s
+	}
+	if elapsed := now.Sub(s.start); elapsed >= AbuseWindow {
+		if elapsed < 2*AbuseWindow {
+			s.prevWindow = s.requests
+		} else {
+			s.prevWindow = 0
+		}
+		*s = abuseStats{start: now, prevWindow: s.prevWindow}
+	}
+	return s
+}
+
+// paramAnomalies returns the anomalies of the request path and query string parameters sorted by
+// name.
+func paramAnomalies(params url.Values) []string {
+	var anomalies []string
+	seen := make(map[string]bool)
+	add := func(a string) {
+		if !seen[a] {
+			seen[a] = true
+			anomalies = append(anomalies, a)
+		}
+	}
+	for _, values := range params {
+		if len(values) > 1 {
+			add(AnomalyRepeatedParam)
+		}
+		for _, v := range values {
+			if len(v) > MaxAbuseParamLength {
+				add(AnomalyLongValue)
+			}
+			if strings.IndexFunc(v, unicode.IsControl) >= 0 {
+				add(AnomalyControlChars)
+			}
+		}
+	}
+	sort.Strings(anomalies)
+	return anomalies
+}
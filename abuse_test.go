@@ -0,0 +1,97 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("DetectAbuse", func() {
+	var service *goa.Service
+	var fingerprints []*goa.AbuseFingerprint
+	var status int
+	var handler goa.Handler
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		fingerprints = nil
+		status = 200
+		recorder := goa.AbuseDetectorFunc(func(ctx context.Context, fp *goa.AbuseFingerprint) (*goa.AbuseDecision, error) {
+			fingerprints = append(fingerprints, fp)
+			return nil, nil
+		})
+		service.SetAbuseDetectors(recorder, &goa.ThresholdDetector{MaxRequests: 3, MaxErrorRatio: 0.5, MinRequests: 2})
+		handler = goa.DetectAbuse(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if status >= 400 {
+				return goa.ErrBadRequest("invalid credentials")
+			}
+			rw.WriteHeader(status)
+			return nil
+		})
+	})
+
+	serve := func(remote string, params url.Values) (*httptest.ResponseRecorder, error) {
+		req, _ := http.NewRequest("POST", "/login", nil)
+		req.RemoteAddr = remote
+		rw := httptest.NewRecorder()
+		ctx := goa.NewContext(context.Background(), service, rw, req, params)
+		return rw, handler(ctx, rw, req)
+	}
+
+	It("computes the fingerprints of the principal requests", func() {
+		serve("203.0.113.7:4242", nil)
+		serve("203.0.113.7:4242", url.Values{"user": {"bob", "alice"}, "pass": {"a\x00b"}})
+		serve("198.51.100.1:4242", url.Values{"user": {strings.Repeat("a", goa.MaxAbuseParamLength+1)}})
+		Ω(fingerprints).Should(HaveLen(3))
+		Ω(fingerprints[0].Principal).Should(Equal("ip:203.0.113.7"))
+		Ω(fingerprints[0].Requests).Should(Equal(1))
+		Ω(fingerprints[0].Anomalies).Should(BeEmpty())
+		Ω(fingerprints[1].Requests).Should(Equal(2))
+		Ω(fingerprints[1].Spike).Should(Equal(2.0))
+		Ω(fingerprints[1].Anomalies).Should(Equal([]string{goa.AnomalyControlChars, goa.AnomalyRepeatedParam}))
+		Ω(fingerprints[2].Requests).Should(Equal(1))
+		Ω(fingerprints[2].Anomalies).Should(Equal([]string{goa.AnomalyLongValue}))
+	})
+
+	It("throttles principals that exceed the thresholds", func() {
+		for i := 0; i < 3; i++ {
+			rw, err := serve("203.0.113.7:4242", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(200))
+		}
+		rw, err := serve("203.0.113.7:4242", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Code).Should(Equal(429))
+		Ω(rw.Header().Get("Retry-After")).Should(Equal("60"))
+		rw, _ = serve("198.51.100.1:4242", nil)
+		Ω(rw.Code).Should(Equal(200))
+	})
+
+	It("tracks the error ratio of the principals", func() {
+		status = 401
+		serve("203.0.113.7:4242", nil)
+		serve("203.0.113.7:4242", nil)
+		Ω(fingerprints[1].ErrorRatio).Should(Equal(1.0))
+		rw, err := serve("203.0.113.7:4242", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rw.Code).Should(Equal(429))
+	})
+
+	Context("with a detector that blocks", func() {
+		BeforeEach(func() {
+			service.SetAbuseDetectors(&goa.ThresholdDetector{BlockAnomalies: true})
+		})
+
+		It("rejects the requests with a 403 response", func() {
+			_, err := serve("203.0.113.7:4242", url.Values{"user": {"a\nb"}})
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(403))
+		})
+	})
+})
@@ -0,0 +1,225 @@
+package goa
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// NetworkACL lists the client networks allowed and denied access to actions. Denied networks
+// take precedence over allowed networks, clients are allowed if the ACL does not list allowed
+// networks and they are not denied.
+type NetworkACL struct {
+	allow, deny []*net.IPNet
+}
+
+// NewNetworkACL returns the ACL that allows and denies the given networks, each network is a CIDR
+// (e.g. "10.0.0.0/8") or an IP.
+func NewNetworkACL(allow, deny []string) (*NetworkACL, error) {
+	a, err := parseNetworks(allow)
+	if err != nil {
+		return nil, err
+	}
+	d, err := parseNetworks(deny)
+	if err != nil {
+		return nil, err
+	}
+	return &NetworkACL{allow: a, deny: d}, nil
+}
+
+// MustNetworkACL is like NewNetworkACL but panics if a network is invalid. The code generated by
+// goagen uses it to create the ACLs of the design, the networks of which are validated by goagen.
+func MustNetworkACL(allow, deny []string) *NetworkACL {
+	acl, err := NewNetworkACL(allow, deny)
+	if err != nil {
+		panic(err)
+	}
+	return acl
+}
+
+// Allows returns true if the client with the given IP is allowed access. Clients whose address is
+// unknown (nil) are denied access unless the ACL is empty.
+func (acl *NetworkACL) Allows(ip net.IP) bool {
+	if ip == nil {
+		return len(acl.allow) == 0 && len(acl.deny) == 0
+	}
+	if containsIP(acl.deny, ip) {
+		return false
+	}
+	return len(acl.allow) == 0 || containsIP(acl.allow, ip)
+}
+
+// SetNetworkACL sets the network ACL with the given scope, a nil ACL removes it. The scope is
+// "api" for the ACL of the API, the resource name for resource ACLs and "<resource>#<action>" for
+// action ACLs. The code generated by goagen sets the ACLs of the design when mounting the
+// controllers, calling SetNetworkACL after that overrides them.
+func (service *Service) SetNetworkACL(scope string, acl *NetworkACL) {
+	service.aclMu.Lock()
+	defer service.aclMu.Unlock()
+	if service.networkACLs == nil {
+		service.networkACLs = make(map[string]*NetworkACL)
+	}
+	if acl == nil {
+		delete(service.networkACLs, scope)
+		return
+	}
+	service.networkACLs[scope] = acl
+}
+
+// EnforceNetworkACL returns a handler that rejects the requests made by clients not allowed by the
+// network ACL with the given scope set on the service with SetNetworkACL before calling h. The
+// client address is the one stored in the request context by the TrustProxies middleware if any,
+// the request remote address otherwise. Rejected requests get a 403 response. The code generated
+// by goagen wraps the handlers of the actions that have a network ACL with EnforceNetworkACL.
+func EnforceNetworkACL(h Handler, scope string) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		service := RequestService(ctx)
+		if service == nil {
+			return h(ctx, rw, req)
+		}
+		service.aclMu.RLock()
+		acl := service.networkACLs[scope]
+		service.aclMu.RUnlock()
+		if acl == nil {
+			return h(ctx, rw, req)
+		}
+		ip := ContextClientAddr(ctx)
+		if ip == nil {
+			ip = net.ParseIP(ClientIP(ctx, req))
+		}
+		if !acl.Allows(ip) {
+			return ErrForbidden("client address is not allowed")
+		}
+		return h(ctx, rw, req)
+	}
+}
+
+// TrustProxies returns a middleware that resolves the address of the client that made the request
+// and stores it in the request context, see ContextClientAddr. proxies lists the CIDRs or IPs of
+// the trusted proxies. The client address is the request remote address unless it is a trusted
+// proxy in which case the addresses listed in the Forwarded header (or X-Forwarded-For if there is
+// no Forwarded header) are walked from right to left until one is not a trusted proxy. The address
+// of the last trusted proxy is used if the header does not list the client. Addresses forwarded by
+// untrusted clients are ignored so that they cannot be spoofed.
+//
+// The TokenBucket, SlidingWindow and quota principals as well as the network ACLs use the resolved
+// address, TrustProxies should thus be the first middleware of the service.
+func TrustProxies(proxies ...string) (Middleware, error) {
+	trusted, err := parseNetworks(proxies)
+	if err != nil {
+		return nil, err
+	}
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if ip := resolveClientAddr(req, trusted); ip != nil {
+				ctx = WithClientAddr(ctx, ip)
+			}
+			return h(ctx, rw, req)
+		}
+	}, nil
+}
+
+// WithClientAddr returns a copy of ctx holding the given client address.
+func WithClientAddr(ctx context.Context, ip net.IP) context.Context {
+	return context.WithValue(ctx, clientAddrKey, ip)
+}
+
+// ContextClientAddr returns the client address resolved by the TrustProxies middleware, nil if
+// there is none.
+func ContextClientAddr(ctx context.Context) net.IP {
+	if ip, ok := ctx.Value(clientAddrKey).(net.IP); ok {
+		return ip
+	}
+	return nil
+}
+
+// resolveClientAddr returns the address of the client that made the request given the trusted
+// proxies, nil if the request remote address is not an IP.
+func resolveClientAddr(req *http.Request, trusted []*net.IPNet) net.IP {
+	ip := parseAddr(req.RemoteAddr)
+	if ip == nil || !containsIP(trusted, ip) {
+		return ip
+	}
+	hops := forwardedFor(req.Header)
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := parseAddr(hops[i])
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !containsIP(trusted, ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// forwardedFor returns the addresses listed by the Forwarded header or if there is none the
+// X-Forwarded-For header, from the client to the last proxy.
+func forwardedFor(header http.Header) []string {
+	var hops []string
+	if fwd := header["Forwarded"]; len(fwd) > 0 {
+		for _, elem := range strings.Split(strings.Join(fwd, ","), ",") {
+			for _, pair := range strings.Split(elem, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+					hops = append(hops, strings.Trim(kv[1], `"`))
+				}
+			}
+		}
+		return hops
+	}
+	for _, xff := range header["X-Forwarded-For"] {
+		for _, hop := range strings.Split(xff, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+	return hops
+}
+
+// parseAddr parses an IP optionally followed by a port, IPv6 addresses with a port are enclosed in
+// brackets. It returns nil if addr is not an IP, e.g. "unknown" or an obfuscated identifier.
+func parseAddr(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(strings.Trim(addr, "[]"))
+}
+
+// parseNetworks parses the given CIDRs or IPs, IPs are converted to single address networks.
+func parseNetworks(networks []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, len(networks))
+	for i, n := range networks {
+		if strings.Contains(n, "/") {
+			_, ipnet, err := net.ParseCIDR(n)
+			if err != nil {
+				return nil, err
+			}
+			nets[i] = ipnet
+			continue
+		}
+		ip := net.ParseIP(n)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid network %#v, must be a CIDR or an IP", n)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		nets[i] = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	}
+	return nets, nil
+}
+
+// containsIP returns true if one of the networks contains ip.
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package goa_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("NetworkACL", func() {
+	It("denies the denied networks and allows the allowed networks", func() {
+		acl, err := goa.NewNetworkACL([]string{"10.0.0.0/8", "192.168.1.10"}, []string{"10.13.0.0/16"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(acl.Allows(net.ParseIP("10.1.2.3"))).Should(BeTrue())
+		Ω(acl.Allows(net.ParseIP("192.168.1.10"))).Should(BeTrue())
+		Ω(acl.Allows(net.ParseIP("192.168.1.11"))).Should(BeFalse())
+		Ω(acl.Allows(net.ParseIP("10.13.2.3"))).Should(BeFalse())
+		Ω(acl.Allows(nil)).Should(BeFalse())
+	})
+
+	It("allows the clients that are not denied if there are no allowed networks", func() {
+		acl := goa.MustNetworkACL(nil, []string{"2001:db8::/32"})
+		Ω(acl.Allows(net.ParseIP("2001:db8::1"))).Should(BeFalse())
+		Ω(acl.Allows(net.ParseIP("2001:db9::1"))).Should(BeTrue())
+		Ω(acl.Allows(net.ParseIP("10.1.2.3"))).Should(BeTrue())
+	})
+
+	It("rejects invalid networks", func() {
+		_, err := goa.NewNetworkACL([]string{"10.0.0.0/33"}, nil)
+		Ω(err).Should(HaveOccurred())
+		_, err = goa.NewNetworkACL(nil, []string{"localhost"})
+		Ω(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("TrustProxies", func() {
+	var addr net.IP
+
+	serve := func(remote string, header http.Header) {
+		mw, err := goa.TrustProxies("10.0.0.0/8", "2001:db8::1")
+		Ω(err).ShouldNot(HaveOccurred())
+		h := mw(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			addr = goa.ContextClientAddr(ctx)
+			return nil
+		})
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		Ω(h(context.Background(), httptest.NewRecorder(), req)).ShouldNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		addr = nil
+	})
+
+	It("ignores the addresses forwarded by untrusted clients", func() {
+		serve("203.0.113.7:4242", http.Header{"X-Forwarded-For": {"198.51.100.1"}})
+		Ω(addr.String()).Should(Equal("203.0.113.7"))
+	})
+
+	It("walks the X-Forwarded-For addresses until one is not trusted", func() {
+		serve("10.0.0.1:4242", http.Header{"X-Forwarded-For": {"198.51.100.1, 203.0.113.7", "10.0.0.2"}})
+		Ω(addr.String()).Should(Equal("203.0.113.7"))
+	})
+
+	It("prefers the Forwarded header", func() {
+		serve("[2001:db8::1]:4242", http.Header{
+			"Forwarded":       {`for=198.51.100.1;proto=https, for="[2001:db8:cafe::17]:4711";by=10.0.0.1`},
+			"X-Forwarded-For": {"203.0.113.7"},
+		})
+		Ω(addr.String()).Should(Equal("2001:db8:cafe::17"))
+	})
+
+	It("uses the last trusted proxy if the client is unknown", func() {
+		serve("10.0.0.1:4242", http.Header{"Forwarded": {"for=unknown, for=10.0.0.2"}})
+		Ω(addr.String()).Should(Equal("10.0.0.2"))
+	})
+
+	It("makes ClientIP return the resolved address", func() {
+		ctx := goa.WithClientAddr(context.Background(), net.ParseIP("198.51.100.1"))
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:4242"
+		Ω(goa.ClientIP(ctx, req)).Should(Equal("198.51.100.1"))
+	})
+})
+
+var _ = Describe("EnforceNetworkACL", func() {
+	var service *goa.Service
+	var called bool
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.SetNetworkACL("admin", goa.MustNetworkACL([]string{"10.0.0.0/8"}, nil))
+		called = false
+	})
+
+	serve := func(ctx context.Context, remote string) error {
+		h := goa.EnforceNetworkACL(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called = true
+			return nil
+		}, "admin")
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote
+		rw := httptest.NewRecorder()
+		return h(goa.NewContext(ctx, service, rw, req, nil), rw, req)
+	}
+
+	It("lets allowed clients through", func() {
+		Ω(serve(context.Background(), "10.1.2.3:4242")).ShouldNot(HaveOccurred())
+		Ω(called).Should(BeTrue())
+	})
+
+	It("rejects other clients", func() {
+		err := serve(context.Background(), "203.0.113.7:4242")
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(403))
+		Ω(called).Should(BeFalse())
+	})
+
+	It("uses the resolved client address", func() {
+		ctx := goa.WithClientAddr(context.Background(), net.ParseIP("203.0.113.7"))
+		Ω(serve(ctx, "10.1.2.3:4242")).Should(HaveOccurred())
+		Ω(called).Should(BeFalse())
+	})
+})
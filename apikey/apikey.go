@@ -0,0 +1,121 @@
+// Package apikey manages the lifecycle of the keys accepted by the API key security schemes: a
+// Manager issues, rotates and revokes the keys, validates the keys of the incoming requests and
+// serves the key administration endpoints. Keys are random secrets given to the clients once when
+// they are issued, the Store only records their SHA-256 hash together with the key owner, the
+// scopes granted by the key and its expiry and revocation times.
+//
+// The Mount<Scheme>Keys functions generated by goagen for the API key schemes that define
+// KeyManagement create a manager, use it to validate the keys of the requests made to the actions
+// secured by the scheme and mount the administration endpoints:
+//
+//	keys := app.MountAPIKeyKeys(service, apikey.NewMemoryStore())
+//	admin, _, err := keys.Issue(ctx, "admin", apikey.AdminScope)
+//
+// The administration endpoints require a key granting AdminScope, the generated clients call them
+// with the AdminClient returned by their <Scheme>Keys method.
+package apikey
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrNotFound is the error returned by stores when a key does not exist.
+var ErrNotFound = errors.New("API key not found")
+
+type (
+	// Key describes an issued API key. The key secret is never stored, Hash is the SHA-256 hash
+	// of the secret.
+	Key struct {
+		// ID identifies the key, it is the part of the key value that precedes the secret.
+		ID string `json:"id"`
+		// Owner identifies the owner of the key, it is the principal of the requests
+		// authenticated with the key.
+		Owner string `json:"owner"`
+		// Scopes lists the scopes granted by the key.
+		Scopes []string `json:"scopes,omitempty"`
+		// Hash is the SHA-256 hash of the key secret.
+		Hash []byte `json:"-"`
+		// Created is the key issuance time.
+		Created time.Time `json:"created_at"`
+		// Expires is the key expiry time, nil if the key does not expire.
+		Expires *time.Time `json:"expires_at,omitempty"`
+		// Revoked is the time the key was revoked or - for rotated keys - stops being
+		// accepted, nil if the key is not revoked.
+		Revoked *time.Time `json:"revoked_at,omitempty"`
+		// RotatedTo is the ID of the key issued when the key was rotated.
+		RotatedTo string `json:"rotated_to,omitempty"`
+	}
+
+	// Store is implemented by the key stores.
+	Store interface {
+		// Save records a new key or updates an existing key.
+		Save(ctx context.Context, k *Key) error
+		// Get returns the key with the given ID or ErrNotFound.
+		Get(ctx context.Context, id string) (*Key, error)
+		// List returns the keys of the given owner, all the keys if owner is empty, in
+		// issuance order.
+		List(ctx context.Context, owner string) ([]*Key, error)
+	}
+
+	// MemoryStore is a Store that keeps the keys in memory. It is meant for development and
+	// tests, the keys are lost when the service restarts.
+	MemoryStore struct {
+		mu   sync.RWMutex
+		keys map[string]Key
+		ids  []string
+	}
+)
+
+// Valid returns true if the key is accepted at the given time: it is neither expired nor
+// revoked.
+func (k *Key) Valid(at time.Time) bool {
+	if k.Expires != nil && !at.Before(*k.Expires) {
+		return false
+	}
+	return k.Revoked == nil || at.Before(*k.Revoked)
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: make(map[string]Key)}
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(_ context.Context, k *Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.keys[k.ID]; !ok {
+		s.ids = append(s.ids, k.ID)
+	}
+	s.keys[k.ID] = *k
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, id string) (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.keys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &k, nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(_ context.Context, owner string) ([]*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []*Key
+	for _, id := range s.ids {
+		k := s.keys[id]
+		if owner == "" || k.Owner == owner {
+			keys = append(keys, &k)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,109 @@
+package apikey
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// AdminClient calls the key administration endpoints mounted by Manager.Mount. The client signers
+// must authenticate the requests with a key granting AdminScope.
+type AdminClient struct {
+	*goa.Client
+	// Path is the path of the administration endpoints including the API base path.
+	Path string
+}
+
+// Issue issues a new key for owner that grants the given scopes.
+func (c *AdminClient) Issue(ctx context.Context, owner string, scopes ...string) (*Issued, error) {
+	var issued Issued
+	err := c.do(ctx, "POST", c.Path, nil, &issueRequest{Owner: owner, Scopes: scopes}, &issued)
+	if err != nil {
+		return nil, err
+	}
+	return &issued, nil
+}
+
+// List lists the keys of the given owner, all the keys if owner is empty.
+func (c *AdminClient) List(ctx context.Context, owner string) ([]*Key, error) {
+	var query url.Values
+	if owner != "" {
+		query = url.Values{"owner": {owner}}
+	}
+	var keys []*Key
+	if err := c.do(ctx, "GET", c.Path, query, nil, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Get returns the key with the given ID.
+func (c *AdminClient) Get(ctx context.Context, id string) (*Key, error) {
+	var k Key
+	if err := c.do(ctx, "GET", c.Path+"/"+id, nil, nil, &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// Rotate rotates the key with the given ID and returns the new key.
+func (c *AdminClient) Rotate(ctx context.Context, id string) (*Issued, error) {
+	var issued Issued
+	if err := c.do(ctx, "POST", c.Path+"/"+id+"/rotate", nil, nil, &issued); err != nil {
+		return nil, err
+	}
+	return &issued, nil
+}
+
+// Revoke revokes the key with the given ID.
+func (c *AdminClient) Revoke(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", c.Path+"/"+id, nil, nil, nil)
+}
+
+// do makes a request to the administration endpoints and decodes the response body into res.
+// Error responses are decoded into a goa.HTTPError.
+func (c *AdminClient) do(ctx context.Context, method, path string, query url.Values, payload, res interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	u := url.URL{Host: c.Host, Scheme: c.Scheme, Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		var herr goa.HTTPError
+		if err := json.Unmarshal(b, &herr); err != nil || herr.Status == 0 {
+			return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+		}
+		return &herr
+	}
+	if res == nil || len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, res)
+}
@@ -0,0 +1,324 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// AdminScope is the scope the keys used to call the key administration endpoints must grant.
+const AdminScope = "keys:admin"
+
+// DefaultGrace is the time rotated keys remain valid for by default.
+const DefaultGrace = 24 * time.Hour
+
+type (
+	// Manager issues, rotates, revokes and validates the keys of an API key security scheme.
+	Manager struct {
+		// Scheme is the name of the API key security scheme, it secures the administration
+		// endpoints.
+		Scheme string
+		// Path is the path of the administration endpoints including the API base path.
+		Path string
+		// Store records the keys.
+		Store Store
+		// Prefix is prepended to the issued key values, e.g. "sk_", so that they are easy to
+		// recognize.
+		Prefix string
+		// TTL is the lifetime of the issued keys, zero means the keys do not expire.
+		TTL time.Duration
+		// Grace is the time rotated keys remain valid for so that the clients may switch to
+		// the new key, zero means rotated keys are revoked immediately.
+		Grace time.Duration
+	}
+
+	// Issued is the result of the issuance or rotation of a key. It is the only place where
+	// the key value appears, it cannot be retrieved afterwards.
+	Issued struct {
+		// Value is the key value given to the client.
+		Value string `json:"key"`
+		*Key
+	}
+
+	// issueRequest is the body of the requests that issue keys.
+	issueRequest struct {
+		Owner  string   `json:"owner"`
+		Scopes []string `json:"scopes"`
+	}
+
+	// keyKey is the context key used to store the validated key.
+	keyKey struct{}
+)
+
+// NewManager returns a manager for the keys of the given API key security scheme recorded in
+// store whose administration endpoints are mounted under path. Rotated keys remain valid for
+// DefaultGrace.
+func NewManager(scheme, path string, store Store) *Manager {
+	return &Manager{
+		Scheme: scheme,
+		Path:   strings.TrimSuffix(path, "/"),
+		Store:  store,
+		Grace:  DefaultGrace,
+	}
+}
+
+// Issue issues a new key for owner that grants the given scopes. It returns the key value that
+// must be given to the client and the key description.
+func (m *Manager) Issue(ctx context.Context, owner string, scopes ...string) (string, *Key, error) {
+	if owner == "" {
+		return "", nil, goa.ErrBadRequest("missing key owner")
+	}
+	value, k := m.newKey(owner, scopes)
+	if err := m.Store.Save(ctx, k); err != nil {
+		return "", nil, err
+	}
+	return value, k, nil
+}
+
+// Rotate issues a new key with the same owner and scopes as the key with the given ID. The
+// rotated key remains valid for the manager grace period.
+func (m *Manager) Rotate(ctx context.Context, id string) (string, *Key, error) {
+	old, err := m.Store.Get(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now().UTC()
+	if !old.Valid(now) {
+		return "", nil, goa.ErrBadRequest("key %#v is expired or revoked", id)
+	}
+	value, k := m.newKey(old.Owner, old.Scopes)
+	if err := m.Store.Save(ctx, k); err != nil {
+		return "", nil, err
+	}
+	revoked := now.Add(m.Grace)
+	old.Revoked = &revoked
+	old.RotatedTo = k.ID
+	if err := m.Store.Save(ctx, old); err != nil {
+		return "", nil, err
+	}
+	return value, k, nil
+}
+
+// Revoke revokes the key with the given ID, it is not accepted anymore.
+func (m *Manager) Revoke(ctx context.Context, id string) error {
+	k, err := m.Store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if k.Revoked != nil && !now.Before(*k.Revoked) {
+		return nil
+	}
+	k.Revoked = &now
+	return m.Store.Save(ctx, k)
+}
+
+// Validate validates the given key value and checks that the key grants the scopes required by
+// the request. It implements security.APIKeyValidator so that the API key middleware accepts the
+// keys issued by the manager:
+//
+//	security.APIKey(app.NewAPIKeySecurity(), manager.Validate)
+//
+// The key owner is the principal of the returned context, ContextKey returns the key.
+func (m *Manager) Validate(ctx context.Context, value string) (context.Context, error) {
+	id, secret := parseKey(strings.TrimPrefix(value, m.Prefix))
+	if id == "" {
+		return ctx, goa.ErrUnauthorized("invalid API key")
+	}
+	k, err := m.Store.Get(ctx, id)
+	if err == ErrNotFound {
+		return ctx, goa.ErrUnauthorized("invalid API key")
+	}
+	if err != nil {
+		return ctx, goa.ErrInternal(err)
+	}
+	hash := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare(hash[:], k.Hash) != 1 || !k.Valid(time.Now()) {
+		return ctx, goa.ErrUnauthorized("invalid API key")
+	}
+	ctx = goa.WithPrincipal(ctx, k.Owner)
+	ctx = context.WithValue(ctx, keyKey{}, k)
+	return ctx, goa.ValidateScopes(ctx, k.Scopes)
+}
+
+// ContextKey returns the key validated by Manager.Validate for the request with the given
+// context, nil if there is none.
+func ContextKey(ctx context.Context) *Key {
+	if k := ctx.Value(keyKey{}); k != nil {
+		return k.(*Key)
+	}
+	return nil
+}
+
+// Mount mounts the key administration endpoints on the service: POST requests made to "<path>"
+// issue keys, GET requests list them optionally filtering by the "owner" querystring parameter.
+// GET and DELETE requests made to "<path>/:id" show and revoke the key with the given ID, POST
+// requests made to "<path>/:id/rotate" rotate it. The endpoints are secured by the manager scheme
+// and require a key granting AdminScope, the keys they issue, rotate or revoke may only grant the
+// scopes granted by that key.
+func (m *Manager) Mount(service *goa.Service) {
+	m.handle(service, "POST", m.Path, func(ctx context.Context, req *http.Request, _ url.Values) (int, interface{}, error) {
+		var body issueRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return 0, nil, goa.ErrBadRequest(err)
+		}
+		if err := checkScopes(ctx, body.Scopes); err != nil {
+			return 0, nil, err
+		}
+		value, k, err := m.Issue(ctx, body.Owner, body.Scopes...)
+		if err != nil {
+			return 0, nil, err
+		}
+		goa.Response(ctx).Header().Set("Location", m.Path+"/"+k.ID)
+		return http.StatusCreated, &Issued{Value: value, Key: k}, nil
+	})
+	m.handle(service, "GET", m.Path, func(ctx context.Context, _ *http.Request, params url.Values) (int, interface{}, error) {
+		keys, err := m.Store.List(ctx, params.Get("owner"))
+		if keys == nil {
+			keys = []*Key{}
+		}
+		return http.StatusOK, keys, err
+	})
+	m.handle(service, "GET", m.Path+"/:id", func(ctx context.Context, _ *http.Request, params url.Values) (int, interface{}, error) {
+		k, err := m.Store.Get(ctx, params.Get("id"))
+		return http.StatusOK, k, err
+	})
+	m.handle(service, "POST", m.Path+"/:id/rotate", func(ctx context.Context, _ *http.Request, params url.Values) (int, interface{}, error) {
+		if err := m.checkKey(ctx, params.Get("id")); err != nil {
+			return 0, nil, err
+		}
+		value, k, err := m.Rotate(ctx, params.Get("id"))
+		if err != nil {
+			return 0, nil, err
+		}
+		goa.Response(ctx).Header().Set("Location", m.Path+"/"+k.ID)
+		return http.StatusCreated, &Issued{Value: value, Key: k}, nil
+	})
+	m.handle(service, "DELETE", m.Path+"/:id", func(ctx context.Context, _ *http.Request, params url.Values) (int, interface{}, error) {
+		if err := m.checkKey(ctx, params.Get("id")); err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, m.Revoke(ctx, params.Get("id"))
+	})
+}
+
+// handle mounts an administration endpoint. h returns the response status and body.
+func (m *Manager) handle(service *goa.Service, method, path string, h func(context.Context, *http.Request, url.Values) (int, interface{}, error)) {
+	service.Mux.Handle(method, path, func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+		ctx := goa.NewContext(nil, service, rw, req, params)
+		secured := goa.Secure(m.Scheme, func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			status, body, err := h(ctx, req, params)
+			if err == nil {
+				respond(rw, status, body, nil)
+			}
+			return err
+		}, AdminScope)
+		if err := secured(ctx, rw, req); err != nil {
+			respond(rw, 0, nil, err)
+		}
+	})
+	service.LogInfo("mount", goa.KV{"ctrl", "APIKeys"}, goa.KV{"route", method + " " + path})
+}
+
+// newKey generates a key for owner that grants the given scopes. It returns the key value and
+// description.
+func (m *Manager) newKey(owner string, scopes []string) (string, *Key) {
+	id := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		panic(err) // bug
+	}
+	if _, err := rand.Read(secret); err != nil {
+		panic(err) // bug
+	}
+	k := &Key{
+		ID:      hex.EncodeToString(id),
+		Owner:   owner,
+		Scopes:  scopes,
+		Created: time.Now().UTC(),
+	}
+	if m.TTL > 0 {
+		expires := k.Created.Add(m.TTL)
+		k.Expires = &expires
+	}
+	value := base64.RawURLEncoding.EncodeToString(secret)
+	hash := sha256.Sum256([]byte(value))
+	k.Hash = hash[:]
+	return m.Prefix + k.ID + "." + value, k
+}
+
+// parseKey returns the ID and secret of a key value without prefix, empty strings if the value
+// is malformed.
+func parseKey(value string) (string, string) {
+	i := strings.Index(value, ".")
+	if i <= 0 || i == len(value)-1 {
+		return "", ""
+	}
+	return value[:i], value[i+1:]
+}
+
+// checkKey checks that the key used to make the request with the given context grants all the
+// scopes of the key with the given ID so that administrators may not rotate or revoke keys more
+// powerful than theirs.
+func (m *Manager) checkKey(ctx context.Context, id string) error {
+	k, err := m.Store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return checkScopes(ctx, k.Scopes)
+}
+
+// checkScopes checks that the key used to make the request with the given context grants the
+// given scopes so that administrators may not issue keys more powerful than theirs.
+func checkScopes(ctx context.Context, scopes []string) error {
+	var granted []string
+	if k := ContextKey(ctx); k != nil {
+		granted = k.Scopes
+	}
+	for _, s := range scopes {
+		found := false
+		for _, g := range granted {
+			if g == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return goa.ErrForbidden("scope %#v is not granted by the administrator key", s)
+		}
+	}
+	return nil
+}
+
+// respond writes the response of the administration endpoints. Errors are rendered with the
+// goa.HTTPError JSON representation.
+func respond(rw http.ResponseWriter, status int, body interface{}, err error) {
+	if err != nil {
+		herr, ok := err.(*goa.HTTPError)
+		if !ok {
+			if err == ErrNotFound {
+				herr = goa.ErrNotFound(err)
+			} else {
+				herr = goa.ErrInternal(err)
+			}
+		}
+		status, body = herr.Status, herr
+	}
+	if body == nil {
+		rw.WriteHeader(status)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(body)
+}
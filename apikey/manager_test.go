@@ -0,0 +1,161 @@
+package apikey_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/apikey"
+	"github.com/goadesign/goa/security"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Manager", func() {
+	var service *goa.Service
+	var manager *apikey.Manager
+	var ctx context.Context
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		manager = apikey.NewManager("key", "/keys", apikey.NewMemoryStore())
+		manager.Prefix = "sk_"
+		service.SetSecurityMiddleware("key", security.APIKey(&goa.APIKeySecurity{In: "header", Name: "X-API-Key"}, manager.Validate))
+		manager.Mount(service)
+		service.Mux.Handle("GET", "/bottles", func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+			ctx := goa.NewContext(nil, service, rw, req, params)
+			err := goa.Secure("key", func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				rw.Write([]byte(goa.ContextPrincipal(ctx).(string)))
+				return nil
+			}, "api:read")(ctx, rw, req)
+			if err != nil {
+				rw.WriteHeader(goa.ErrorStatus(err))
+			}
+		})
+		ctx = context.Background()
+	})
+
+	serve := func(key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/bottles", nil)
+		req.Header.Set("X-API-Key", key)
+		rw := httptest.NewRecorder()
+		service.Mux.ServeHTTP(rw, req)
+		return rw
+	}
+
+	It("validates the issued keys and their scopes", func() {
+		key, k, err := manager.Issue(ctx, "alice", "api:read")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(key).Should(HavePrefix("sk_" + k.ID + "."))
+		rw := serve(key)
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Body.String()).Should(Equal("alice"))
+
+		Ω(serve(key + "x").Code).Should(Equal(401))
+		Ω(serve("invalid").Code).Should(Equal(401))
+		Ω(serve("").Code).Should(Equal(401))
+
+		writer, _, err := manager.Issue(ctx, "bob", "api:write")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(serve(writer).Code).Should(Equal(403))
+	})
+
+	It("rejects revoked and expired keys", func() {
+		key, k, err := manager.Issue(ctx, "alice", "api:read")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(manager.Revoke(ctx, k.ID)).ShouldNot(HaveOccurred())
+		Ω(serve(key).Code).Should(Equal(401))
+
+		manager.TTL = time.Nanosecond
+		key, _, err = manager.Issue(ctx, "alice", "api:read")
+		Ω(err).ShouldNot(HaveOccurred())
+		time.Sleep(time.Millisecond)
+		Ω(serve(key).Code).Should(Equal(401))
+	})
+
+	It("keeps rotated keys valid during the grace period", func() {
+		old, k, err := manager.Issue(ctx, "alice", "api:read")
+		Ω(err).ShouldNot(HaveOccurred())
+		key, rotated, err := manager.Rotate(ctx, k.ID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(rotated.Owner).Should(Equal("alice"))
+		Ω(rotated.Scopes).Should(Equal([]string{"api:read"}))
+		Ω(serve(key).Code).Should(Equal(200))
+		Ω(serve(old).Code).Should(Equal(200))
+
+		manager.Grace = 0
+		_, _, err = manager.Rotate(ctx, rotated.ID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(serve(key).Code).Should(Equal(401))
+		_, _, err = manager.Rotate(ctx, rotated.ID)
+		Ω(goa.ErrorStatus(err)).Should(Equal(400))
+	})
+
+	Context("with the administration client", func() {
+		var admin *apikey.AdminClient
+
+		BeforeEach(func() {
+			key, _, err := manager.Issue(ctx, "admin", apikey.AdminScope, "api:read")
+			Ω(err).ShouldNot(HaveOccurred())
+			c := goa.NewLoopbackClient(service)
+			c.Signers = append(c.Signers, &goa.APIKeySigner{Name: "X-API-Key", Key: key})
+			admin = &apikey.AdminClient{Client: c, Path: "/keys"}
+		})
+
+		It("manages the keys", func() {
+			issued, err := admin.Issue(ctx, "alice", "api:read")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(issued.Owner).Should(Equal("alice"))
+			Ω(serve(issued.Value).Code).Should(Equal(200))
+
+			keys, err := admin.List(ctx, "alice")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(keys).Should(HaveLen(1))
+			Ω(keys[0].ID).Should(Equal(issued.ID))
+
+			rotated, err := admin.Rotate(ctx, issued.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			k, err := admin.Get(ctx, issued.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(k.RotatedTo).Should(Equal(rotated.ID))
+
+			Ω(admin.Revoke(ctx, rotated.ID)).ShouldNot(HaveOccurred())
+			Ω(serve(rotated.Value).Code).Should(Equal(401))
+			_, err = admin.Get(ctx, "unknown")
+			Ω(goa.ErrorStatus(err)).Should(Equal(404))
+		})
+
+		It("does not issue keys granting scopes the administrator does not have", func() {
+			_, err := admin.Issue(ctx, "alice", "api:write")
+			Ω(goa.ErrorStatus(err)).Should(Equal(403))
+		})
+
+		It("does not rotate keys granting scopes the administrator does not have", func() {
+			_, k, err := manager.Issue(ctx, "bob", "api:read", "api:write")
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = admin.Rotate(ctx, k.ID)
+			Ω(goa.ErrorStatus(err)).Should(Equal(403))
+			k, err = admin.Get(ctx, k.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(k.RotatedTo).Should(BeEmpty())
+		})
+
+		It("does not revoke keys granting scopes the administrator does not have", func() {
+			key, k, err := manager.Issue(ctx, "bob", "api:read", "api:write")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(goa.ErrorStatus(admin.Revoke(ctx, k.ID))).Should(Equal(403))
+			Ω(serve(key).Code).Should(Equal(200))
+		})
+
+		It("requires the administration scope", func() {
+			key, _, err := manager.Issue(ctx, "alice", "api:read")
+			Ω(err).ShouldNot(HaveOccurred())
+			admin.Signers = []goa.Signer{&goa.APIKeySigner{Name: "X-API-Key", Key: key}}
+			_, err = admin.List(ctx, "")
+			Ω(goa.ErrorStatus(err)).Should(Equal(403))
+		})
+	})
+})
@@ -0,0 +1,227 @@
+package async
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// MediaType is the content type of the responses of the operations endpoints.
+const MediaType = "application/vnd.goa.operation+json"
+
+type (
+	// Job is the function run by an operation. The context given to the job is canceled when
+	// the operation is canceled or times out, the value returned on success is the operation
+	// result.
+	Job func(ctx context.Context) (interface{}, error)
+
+	// Options describes how an operation runs.
+	Options struct {
+		// Cancelable is true if clients can cancel the operation.
+		Cancelable bool
+		// Timeout is the maximum duration of the operation, zero means no limit.
+		Timeout time.Duration
+	}
+
+	// Executor runs the jobs of async actions in the background and records the status of the
+	// corresponding operations in its store.
+	Executor struct {
+		// Store records the operations.
+		Store Store
+		// Path is the path of the operations endpoints including the API base path, the
+		// operation hrefs are built by appending the operation IDs to it.
+		Path string
+
+		mu      sync.Mutex
+		cancels map[string]context.CancelFunc
+		stopped map[string]bool
+	}
+)
+
+// NewExecutor returns an executor that records the operations in store and whose operation hrefs
+// start with path.
+func NewExecutor(store Store, path string) *Executor {
+	return &Executor{
+		Store:   store,
+		Path:    strings.TrimSuffix(path, "/"),
+		cancels: make(map[string]context.CancelFunc),
+		stopped: make(map[string]bool),
+	}
+}
+
+// Start records a pending operation, runs job in the background and returns the operation. The
+// job does not run with ctx as ctx is typically canceled once the request completes, it runs with
+// a context derived from goa.RootContext instead.
+func (e *Executor) Start(ctx context.Context, resource, action string, opts Options, job Job) (*Operation, error) {
+	now := time.Now().UTC()
+	id := newOperationID()
+	op := &Operation{
+		ID:         id,
+		Href:       e.Path + "/" + id,
+		Resource:   resource,
+		Action:     action,
+		Status:     StatusPending,
+		Cancelable: opts.Cancelable,
+		Created:    now,
+		Updated:    now,
+	}
+	if err := e.Store.Create(ctx, op); err != nil {
+		return nil, err
+	}
+	jctx, cancel := context.WithCancel(goa.RootContext)
+	if opts.Timeout > 0 {
+		jctx, cancel = context.WithTimeout(goa.RootContext, opts.Timeout)
+	}
+	jctx = goa.NewLogContext(jctx, goa.KV{"operation", id}, goa.KV{"ctrl", resource}, goa.KV{"action", action})
+	e.mu.Lock()
+	e.cancels[id] = cancel
+	e.mu.Unlock()
+	started := *op
+	go e.run(jctx, cancel, &started, job)
+	return op, nil
+}
+
+// run runs the job and records the operation status changes.
+func (e *Executor) run(ctx context.Context, cancel context.CancelFunc, op *Operation, job Job) {
+	defer func() {
+		cancel()
+		e.mu.Lock()
+		delete(e.cancels, op.ID)
+		delete(e.stopped, op.ID)
+		e.mu.Unlock()
+	}()
+	op.Status = StatusRunning
+	op.Updated = time.Now().UTC()
+	if !e.update(ctx, op) {
+		return
+	}
+	result, err := e.call(ctx, job)
+	switch {
+	case err == nil:
+		op.Status = StatusSucceeded
+		op.Result = result
+	case ctx.Err() == context.DeadlineExceeded:
+		op.Status = StatusFailed
+		op.Error = "operation timed out"
+	default:
+		op.Status = StatusFailed
+		op.Error = err.Error()
+	}
+	op.Updated = time.Now().UTC()
+	e.update(ctx, op)
+	goa.IncrCounter([]string{"goa", "async", op.Resource, op.Action, op.Status}, 1.0)
+}
+
+// call runs the job, recovering from panics.
+func (e *Executor) call(ctx context.Context, job Job) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job(ctx)
+}
+
+// update records the operation unless it was canceled in which case it returns false.
+func (e *Executor) update(ctx context.Context, op *Operation) bool {
+	e.mu.Lock()
+	stopped := e.stopped[op.ID]
+	e.mu.Unlock()
+	if stopped {
+		return false
+	}
+	if err := e.Store.Update(ctx, op); err != nil {
+		goa.Error(ctx, "failed to update operation", goa.KV{"error", err.Error()})
+	}
+	return true
+}
+
+// Get returns the operation with the given ID.
+func (e *Executor) Get(ctx context.Context, id string) (*Operation, error) {
+	return e.Store.Get(ctx, id)
+}
+
+// Cancel cancels the operation with the given ID and returns it. It returns ErrNotCancelable if
+// the operation cannot be canceled and ErrDone if it already completed.
+func (e *Executor) Cancel(ctx context.Context, id string) (*Operation, error) {
+	op, err := e.Store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !op.Cancelable {
+		return op, ErrNotCancelable
+	}
+	if op.Done() {
+		return op, ErrDone
+	}
+	e.mu.Lock()
+	cancel, ok := e.cancels[id]
+	if ok {
+		e.stopped[id] = true
+	}
+	e.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	op.Status = StatusCanceled
+	op.Updated = time.Now().UTC()
+	if err := e.Store.Update(ctx, op); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// Mount mounts the operations endpoints on the service: GET requests made to "<path>/:id" return
+// the operation with the given ID, DELETE requests cancel it.
+func (e *Executor) Mount(service *goa.Service) {
+	service.Mux.Handle("GET", e.Path+"/:id", func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+		op, err := e.Get(req.Context(), params.Get("id"))
+		e.respond(rw, op, err)
+	})
+	service.Mux.Handle("DELETE", e.Path+"/:id", func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+		op, err := e.Cancel(req.Context(), params.Get("id"))
+		e.respond(rw, op, err)
+	})
+}
+
+// respond writes the response of the operations endpoints.
+func (e *Executor) respond(rw http.ResponseWriter, op *Operation, err error) {
+	status := http.StatusOK
+	switch err {
+	case nil:
+	case ErrNotFound:
+		status = http.StatusNotFound
+	case ErrNotCancelable, ErrDone:
+		status = http.StatusConflict
+	default:
+		status = http.StatusInternalServerError
+	}
+	if err != nil {
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rw.WriteHeader(status)
+		fmt.Fprint(rw, err.Error())
+		return
+	}
+	if !op.Done() {
+		rw.Header().Set("Retry-After", "1")
+	}
+	rw.Header().Set("Content-Type", MediaType)
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(op)
+}
+
+// newOperationID returns a random operation ID.
+func newOperationID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,125 @@
+package async_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/async"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Executor", func() {
+	var store *async.MemoryStore
+	var exec *async.Executor
+	var opts async.Options
+
+	status := func(id string) func() string {
+		return func() string {
+			op, err := exec.Get(context.Background(), id)
+			Ω(err).ShouldNot(HaveOccurred())
+			return op.Status
+		}
+	}
+
+	block := func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	BeforeEach(func() {
+		store = async.NewMemoryStore()
+		exec = async.NewExecutor(store, "/api/operations/")
+		opts = async.Options{Cancelable: true}
+	})
+
+	It("runs jobs in the background and records their results", func() {
+		op, err := exec.Start(context.Background(), "reports", "create", opts, func(context.Context) (interface{}, error) {
+			return "done", nil
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(op.Href).Should(Equal("/api/operations/" + op.ID))
+		Ω(op.Resource).Should(Equal("reports"))
+		Ω(op.Action).Should(Equal("create"))
+		Eventually(status(op.ID)).Should(Equal(async.StatusSucceeded))
+		op, _ = exec.Get(context.Background(), op.ID)
+		Ω(op.Result).Should(Equal("done"))
+	})
+
+	It("records job errors and panics", func() {
+		op, _ := exec.Start(context.Background(), "reports", "create", opts, func(context.Context) (interface{}, error) {
+			return nil, errors.New("boom")
+		})
+		Eventually(status(op.ID)).Should(Equal(async.StatusFailed))
+		op, _ = exec.Get(context.Background(), op.ID)
+		Ω(op.Error).Should(Equal("boom"))
+
+		op, _ = exec.Start(context.Background(), "reports", "create", opts, func(context.Context) (interface{}, error) {
+			panic("boom")
+		})
+		Eventually(status(op.ID)).Should(Equal(async.StatusFailed))
+	})
+
+	It("fails operations that time out", func() {
+		opts.Timeout = 10 * time.Millisecond
+		op, _ := exec.Start(context.Background(), "reports", "create", opts, block)
+		Eventually(status(op.ID)).Should(Equal(async.StatusFailed))
+		op, _ = exec.Get(context.Background(), op.ID)
+		Ω(op.Error).Should(Equal("operation timed out"))
+	})
+
+	It("cancels operations", func() {
+		op, _ := exec.Start(context.Background(), "reports", "create", opts, block)
+		canceled, err := exec.Cancel(context.Background(), op.ID)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(canceled.Status).Should(Equal(async.StatusCanceled))
+		Consistently(status(op.ID), "50ms").Should(Equal(async.StatusCanceled))
+		_, err = exec.Cancel(context.Background(), op.ID)
+		Ω(err).Should(Equal(async.ErrDone))
+	})
+
+	It("does not cancel operations that are not cancelable", func() {
+		opts.Cancelable = false
+		op, _ := exec.Start(context.Background(), "reports", "create", opts, func(context.Context) (interface{}, error) {
+			return nil, nil
+		})
+		_, err := exec.Cancel(context.Background(), op.ID)
+		Ω(err).Should(Equal(async.ErrNotCancelable))
+	})
+
+	Context("mounted on a service", func() {
+		var service *goa.Service
+
+		BeforeEach(func() {
+			service = goa.New("test")
+			exec.Mount(service)
+		})
+
+		serve := func(method, path string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, path, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			rw := httptest.NewRecorder()
+			service.Mux.ServeHTTP(rw, req)
+			return rw
+		}
+
+		It("serves the operation status", func() {
+			op, _ := exec.Start(context.Background(), "reports", "create", opts, block)
+			rw := serve("GET", op.Href)
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Header().Get("Content-Type")).Should(Equal(async.MediaType))
+			Ω(rw.Header().Get("Retry-After")).ShouldNot(BeEmpty())
+			Ω(rw.Body.String()).Should(ContainSubstring(op.ID))
+
+			rw = serve("DELETE", op.Href)
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Header().Get("Retry-After")).Should(BeEmpty())
+			Ω(serve("DELETE", op.Href).Code).Should(Equal(409))
+			Ω(serve("GET", "/api/operations/unknown").Code).Should(Equal(404))
+		})
+	})
+})
@@ -0,0 +1,135 @@
+// Package async provides the runtime support for the async actions of goa services. Async
+// actions start long-running operations and respond immediately with 202 Accepted and the href of
+// the operation in the Location header. An Executor runs the operations in the background and
+// records their status in a Store, it also serves the operations endpoints that clients use to
+// poll the status of the operations and to cancel them.
+//
+// The MountOperations function generated by goagen for APIs that define async actions creates and
+// mounts an executor, the contexts of async actions expose a Start method that uses it:
+//
+//	exec := app.MountOperations(service, async.NewMemoryStore())
+//
+//	func (c *ReportController) Export(ctx *app.ExportReportContext) error {
+//		return ctx.Start(c.exec, func(jctx context.Context) (interface{}, error) {
+//			return c.export(jctx)
+//		})
+//	}
+package async
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// List of operation statuses.
+const (
+	// StatusPending is the status of operations that have not started yet.
+	StatusPending = "pending"
+	// StatusRunning is the status of running operations.
+	StatusRunning = "running"
+	// StatusSucceeded is the status of operations that completed successfully.
+	StatusSucceeded = "succeeded"
+	// StatusFailed is the status of operations that completed with an error.
+	StatusFailed = "failed"
+	// StatusCanceled is the status of canceled operations.
+	StatusCanceled = "canceled"
+)
+
+var (
+	// ErrNotFound is the error returned by stores when an operation does not exist.
+	ErrNotFound = errors.New("operation not found")
+	// ErrNotCancelable is the error returned when canceling an operation that cannot be
+	// canceled.
+	ErrNotCancelable = errors.New("operation cannot be canceled")
+	// ErrDone is the error returned when canceling an operation that already completed.
+	ErrDone = errors.New("operation already completed")
+)
+
+type (
+	// Operation describes a long-running operation, it is rendered using the built-in
+	// OperationMedia media type.
+	Operation struct {
+		// ID identifies the operation.
+		ID string `json:"id"`
+		// Href is the API href of the operation.
+		Href string `json:"href"`
+		// Resource is the name of the resource of the action that started the operation.
+		Resource string `json:"resource,omitempty"`
+		// Action is the name of the action that started the operation.
+		Action string `json:"action,omitempty"`
+		// Status is one of StatusPending, StatusRunning, StatusSucceeded, StatusFailed or
+		// StatusCanceled.
+		Status string `json:"status"`
+		// Cancelable is true if the operation can be canceled.
+		Cancelable bool `json:"cancelable"`
+		// Result is the result of the operation once it succeeded.
+		Result interface{} `json:"result,omitempty"`
+		// Error is the error message of the operation once it failed.
+		Error string `json:"error,omitempty"`
+		// Created is the operation creation time.
+		Created time.Time `json:"created_at"`
+		// Updated is the time of the last status change.
+		Updated time.Time `json:"updated_at"`
+	}
+
+	// Store is implemented by the stores that keep track of the operations. Stores shared by
+	// multiple processes make it possible to poll operations from any process, cancellation
+	// only interrupts jobs that run in the process serving the request though.
+	Store interface {
+		// Create records a new operation.
+		Create(ctx context.Context, op *Operation) error
+		// Get returns the operation with the given ID or ErrNotFound.
+		Get(ctx context.Context, id string) (*Operation, error)
+		// Update records the new state of an operation.
+		Update(ctx context.Context, op *Operation) error
+	}
+
+	// MemoryStore is an in-memory Store.
+	MemoryStore struct {
+		mu  sync.RWMutex
+		ops map[string]Operation
+	}
+)
+
+// Done returns true if the operation completed.
+func (op *Operation) Done() bool {
+	return op.Status == StatusSucceeded || op.Status == StatusFailed || op.Status == StatusCanceled
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{ops: make(map[string]Operation)}
+}
+
+// Create implements Store.
+func (s *MemoryStore) Create(_ context.Context, op *Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops[op.ID] = *op
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, id string) (*Operation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	op, ok := s.ops[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &op, nil
+}
+
+// Update implements Store.
+func (s *MemoryStore) Update(_ context.Context, op *Operation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.ops[op.ID]; !ok {
+		return ErrNotFound
+	}
+	s.ops[op.ID] = *op
+	return nil
+}
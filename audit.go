@@ -0,0 +1,333 @@
+package goa
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// AuditEvent describes a request made to an action marked for audit with the Audit DSL.
+	AuditEvent struct {
+		// Time is the time the request was received.
+		Time time.Time `json:"time"`
+		// RequestID is the ID of the request, see RequestID.
+		RequestID string `json:"request_id,omitempty"`
+		// Principal is the name of the request principal, empty if the request is not
+		// authenticated, see WithPrincipal.
+		Principal string `json:"principal,omitempty"`
+		// Resource is the name of the resource exposing the action.
+		Resource string `json:"resource"`
+		// Action is the name of the action.
+		Action string `json:"action"`
+		// Method is the request HTTP method.
+		Method string `json:"method"`
+		// Path is the request path.
+		Path string `json:"path"`
+		// Params contains the path and query string parameters of the request.
+		Params map[string]interface{} `json:"params,omitempty"`
+		// Payload is the request payload.
+		Payload interface{} `json:"payload,omitempty"`
+		// Status is the response status code.
+		Status int `json:"status"`
+		// Outcome is AuditSuccess, AuditDenied or AuditFailure.
+		Outcome string `json:"outcome"`
+		// Error is the message of the error returned by the action if any.
+		Error string `json:"error,omitempty"`
+		// Duration is the time it took to handle the request.
+		Duration time.Duration `json:"duration"`
+	}
+
+	// AuditSink is the interface implemented by the destinations of the audit events. The
+	// goa/audit package provides sinks that write the events to files, syslog, HTTP endpoints
+	// and Kafka.
+	AuditSink interface {
+		// Write records the given events. It must either record all of them or return an
+		// error, the auditor retries writing the same events in the latter case.
+		Write(events []*AuditEvent) error
+	}
+
+	// AuditSinkFunc is an adapter that makes it possible to use a function as an AuditSink.
+	AuditSinkFunc func(events []*AuditEvent) error
+
+	// AuditOptions configures an Auditor, the zero value of each field selects its default.
+	AuditOptions struct {
+		// BufferSize is the number of events buffered while waiting to be written to the
+		// sink, defaults to 1000. Recording an event blocks while the buffer is full.
+		BufferSize int
+		// BatchSize is the maximum number of events written to the sink at once, defaults
+		// to 100.
+		BatchSize int
+		// RetryInterval is the delay between two attempts to write events to the sink,
+		// defaults to one second.
+		RetryInterval time.Duration
+	}
+
+	// Auditor delivers audit events to a sink. Events are buffered and written in batches by a
+	// background goroutine that retries until the sink accepts them: events are never dropped,
+	// recording an event blocks instead when the buffer is full. Close flushes the buffer.
+	Auditor struct {
+		sink      AuditSink
+		opts      AuditOptions
+		events    chan *AuditEvent
+		done      chan struct{}  // Closed once all the events are written
+		aborted   chan struct{}  // Closed when Close gives up on the buffered events
+		abortOnce sync.Once      // Closes aborted
+		pending   sync.WaitGroup // Record calls in progress
+		mu        sync.Mutex     // Protects closed
+		closed    bool
+	}
+)
+
+const (
+	// AuditSuccess is the outcome of requests that succeed.
+	AuditSuccess = "success"
+	// AuditDenied is the outcome of requests rejected with a 401 or 403 response.
+	AuditDenied = "denied"
+	// AuditFailure is the outcome of the other failed requests.
+	AuditFailure = "failure"
+
+	// Redacted replaces the values of the redacted parameters and payload attributes in the
+	// audit events, the logs and the validation errors.
+	Redacted = "[REDACTED]"
+)
+
+// Write calls f(events).
+func (f AuditSinkFunc) Write(events []*AuditEvent) error {
+	return f(events)
+}
+
+// NewAuditor returns an auditor that delivers the events to sink. opts may be nil.
+func NewAuditor(sink AuditSink, opts *AuditOptions) *Auditor {
+	var o AuditOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = 1000
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = time.Second
+	}
+	a := &Auditor{
+		sink:    sink,
+		opts:    o,
+		events:  make(chan *AuditEvent, o.BufferSize),
+		done:    make(chan struct{}),
+		aborted: make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Record queues the event for delivery. It blocks while the buffer is full and returns an error
+// if the auditor is closed.
+func (a *Auditor) Record(event *AuditEvent) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return fmt.Errorf("auditor is closed")
+	}
+	a.pending.Add(1)
+	a.mu.Unlock()
+	defer a.pending.Done()
+	select {
+	case a.events <- event:
+		return nil
+	case <-a.aborted:
+		return fmt.Errorf("auditor is closed")
+	}
+}
+
+// Close stops accepting events and waits until the buffered events are written to the sink. It
+// returns the context error if ctx is done first, the remaining events are lost in this case.
+func (a *Auditor) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		go func() {
+			a.pending.Wait()
+			close(a.events)
+		}()
+	}
+	a.mu.Unlock()
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		a.abortOnce.Do(func() { close(a.aborted) })
+		return ctx.Err()
+	}
+}
+
+// run writes the buffered events to the sink until the auditor is closed.
+func (a *Auditor) run() {
+	defer close(a.done)
+	for event := range a.events {
+		batch := []*AuditEvent{event}
+	fill:
+		for len(batch) < a.opts.BatchSize {
+			select {
+			case e, ok := <-a.events:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, e)
+			default:
+				break fill
+			}
+		}
+		if !a.write(batch) {
+			return
+		}
+	}
+}
+
+// write writes the batch to the sink, retrying until it succeeds. It returns false if the
+// auditor was aborted first.
+func (a *Auditor) write(batch []*AuditEvent) bool {
+	for {
+		err := a.sink.Write(batch)
+		if err == nil {
+			return true
+		}
+		Error(RootContext, "audit", KV{"events", len(batch)}, KV{"err", err})
+		select {
+		case <-time.After(a.opts.RetryInterval):
+		case <-a.aborted:
+			return false
+		}
+	}
+}
+
+// SetAuditor sets the auditor that records the requests made to the actions marked for audit.
+// The events are logged with the service logger if no auditor is set.
+func (service *Service) SetAuditor(a *Auditor) {
+	service.securityMu.Lock()
+	defer service.securityMu.Unlock()
+	service.auditor = a
+}
+
+// Audit returns a handler that calls h and records an audit event with the service auditor once
+// it returns. The values of the parameters and top level payload attributes listed in redact are
+// masked. The code generated by goagen wraps the handlers of the actions marked for audit with
+// Audit.
+func Audit(h Handler, redact ...string) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		start := time.Now()
+		err := h(ctx, rw, req)
+		event := newAuditEvent(ctx, req, start, err, redact)
+		service := RequestService(ctx)
+		if service == nil {
+			return err
+		}
+		service.securityMu.RLock()
+		a := service.auditor
+		service.securityMu.RUnlock()
+		if a == nil {
+			service.LogInfo("audit", KV{"resource", event.Resource}, KV{"action", event.Action},
+				KV{"principal", event.Principal}, KV{"status", event.Status}, KV{"outcome", event.Outcome})
+			return err
+		}
+		if e := a.Record(event); e != nil {
+			service.LogError("audit", KV{"err", e})
+		}
+		return err
+	}
+}
+
+// newAuditEvent builds the audit event of the request made with the given context.
+func newAuditEvent(ctx context.Context, req *http.Request, start time.Time, err error, redact []string) *AuditEvent {
+	event := &AuditEvent{
+		Time:      start,
+		RequestID: ContextRequestID(ctx),
+		Principal: auditPrincipal(ctx),
+		Resource:  ContextController(ctx),
+		Action:    ContextAction(ctx),
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		Duration:  time.Since(start),
+	}
+	mask := make(map[string]bool, len(redact))
+	for _, n := range redact {
+		mask[n] = true
+	}
+	if r := Request(ctx); r != nil {
+		if len(r.Params) > 0 {
+			event.Params = make(map[string]interface{}, len(r.Params))
+			for n, vals := range r.Params {
+				if mask[n] {
+					event.Params[n] = Redacted
+				} else if len(vals) == 1 {
+					event.Params[n] = vals[0]
+				} else {
+					event.Params[n] = vals
+				}
+			}
+		}
+		event.Payload = redactPayload(r.Payload, mask)
+	}
+	switch {
+	case err != nil:
+		event.Status = http.StatusInternalServerError
+		if se, ok := err.(ServiceError); ok {
+			event.Status = se.ResponseStatus()
+		}
+		event.Error = err.Error()
+	case Response(ctx) != nil:
+		event.Status = Response(ctx).Status
+	}
+	switch {
+	case err == nil && event.Status < 400:
+		event.Outcome = AuditSuccess
+	case event.Status == http.StatusUnauthorized || event.Status == http.StatusForbidden:
+		event.Outcome = AuditDenied
+	default:
+		event.Outcome = AuditFailure
+	}
+	return event
+}
+
+// auditPrincipal returns the name of the request principal. Security middleware set the principal
+// on the context they pass to the next handler so the name is read from the log fields that
+// WithPrincipal sets on the request context.
+func auditPrincipal(ctx context.Context) string {
+	if name := principalName(ContextPrincipal(ctx)); name != "" {
+		return name
+	}
+	for _, kv := range LogContext(ctx) {
+		if kv.Key == "principal" {
+			return fmt.Sprint(kv.Value)
+		}
+	}
+	return ""
+}
+
+// redactPayload returns payload with the values of the top level attributes listed in mask
+// replaced. Payloads that are not objects are returned as is.
+func redactPayload(payload interface{}, mask map[string]bool) interface{} {
+	if payload == nil || len(mask) == 0 {
+		return payload
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return payload
+	}
+	for n := range obj {
+		if mask[n] {
+			obj[n] = Redacted
+		}
+	}
+	return obj
+}
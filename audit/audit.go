@@ -0,0 +1,74 @@
+/*
+Package audit contains the sinks that the goa auditor delivers audit events to. The auditor
+records an event for each request made to the actions marked for audit with the Audit DSL:
+
+	sink, err := audit.NewFileSink("/var/log/cellar/audit.log")
+	if err != nil {
+		log.Fatal(err)
+	}
+	auditor := goa.NewAuditor(sink, nil)
+	defer auditor.Close(context.Background())
+	service.SetAuditor(auditor)
+
+The auditor retries writing events until the sink accepts them so that sinks deliver events at
+least once: an event may be written more than once if a sink fails after writing part of a batch.
+*/
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/goadesign/goa"
+)
+
+// FileSink writes the audit events to a file, one JSON document per line.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink returns a sink that appends the events to the file with the given path, the file is
+// created if it does not exist.
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: f}, nil
+}
+
+// Write appends the events to the file and flushes it to stable storage.
+func (s *FileSink) Write(events []*goa.AuditEvent) error {
+	b, err := jsonLines(events)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(b); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// jsonLines encodes the events into JSON documents separated with newlines.
+func jsonLines(events []*goa.AuditEvent) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
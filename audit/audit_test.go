@@ -0,0 +1,80 @@
+package audit_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/audit"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileSink", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "audit")
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("appends one JSON document per event", func() {
+		path := filepath.Join(dir, "audit.log")
+		sink, err := audit.NewFileSink(path)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(sink.Write([]*goa.AuditEvent{{Action: "show"}, {Action: "update"}})).Should(Succeed())
+		Ω(sink.Write([]*goa.AuditEvent{{Action: "delete"}})).Should(Succeed())
+		Ω(sink.Close()).Should(Succeed())
+
+		b, err := ioutil.ReadFile(path)
+		Ω(err).ShouldNot(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		Ω(lines).Should(HaveLen(3))
+		var e goa.AuditEvent
+		Ω(json.Unmarshal([]byte(lines[2]), &e)).Should(Succeed())
+		Ω(e.Action).Should(Equal("delete"))
+	})
+})
+
+var _ = Describe("HTTPSink", func() {
+	var status int
+	var received []*goa.AuditEvent
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		status = 200
+		received = nil
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			Ω(req.Header.Get("Authorization")).Should(Equal("Bearer token"))
+			Ω(json.NewDecoder(req.Body).Decode(&received)).Should(Succeed())
+			rw.WriteHeader(status)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("posts the events", func() {
+		sink := &audit.HTTPSink{URL: server.URL, Header: http.Header{"Authorization": {"Bearer token"}}}
+		Ω(sink.Write([]*goa.AuditEvent{{Action: "show"}})).Should(Succeed())
+		Ω(received).Should(HaveLen(1))
+		Ω(received[0].Action).Should(Equal("show"))
+	})
+
+	It("fails if the endpoint rejects the events", func() {
+		status = 503
+		sink := &audit.HTTPSink{URL: server.URL, Header: http.Header{"Authorization": {"Bearer token"}}}
+		Ω(sink.Write([]*goa.AuditEvent{{Action: "show"}})).ShouldNot(Succeed())
+	})
+})
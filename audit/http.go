@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/goadesign/goa"
+)
+
+// HTTPSink posts the audit events to a HTTP endpoint. Each batch of events is sent as a JSON
+// array in the body of a POST request, responses with a status code other than 2xx are errors.
+type HTTPSink struct {
+	// URL is the URL of the endpoint.
+	URL string
+	// Client is the HTTP client used to send the requests, http.DefaultClient if nil.
+	Client *http.Client
+	// Header contains additional headers set on the requests, e.g. "Authorization".
+	Header http.Header
+}
+
+// Write posts the events to the endpoint.
+func (s *HTTPSink) Write(events []*goa.AuditEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for n, vals := range s.Header {
+		req.Header[n] = vals
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("audit endpoint %s returned %s", s.URL, resp.Status)
+	}
+	return nil
+}
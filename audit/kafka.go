@@ -0,0 +1,30 @@
+package audit
+
+import (
+	"encoding/json"
+
+	"github.com/goadesign/goa"
+)
+
+// KafkaSink sends the audit events to a Kafka topic, one JSON document per message. The messages
+// are keyed by request ID.
+type KafkaSink struct {
+	// Producer sends the messages, see goa.KafkaProducer.
+	Producer goa.KafkaProducer
+	// Topic is the name of the topic.
+	Topic string
+}
+
+// Write sends one message per event to the topic.
+func (s *KafkaSink) Write(events []*goa.AuditEvent) error {
+	for _, e := range events {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if err := s.Producer.SendMessage(s.Topic, []byte(e.RequestID), b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
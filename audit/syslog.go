@@ -0,0 +1,44 @@
+// +build !windows,!plan9
+
+package audit
+
+import (
+	"encoding/json"
+	"log/syslog"
+
+	"github.com/goadesign/goa"
+)
+
+// SyslogSink writes the audit events to the system log, one JSON document per message.
+type SyslogSink struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogSink returns a sink that writes the events to the local syslog daemon using the given
+// priority and tag.
+func NewSyslogSink(priority syslog.Priority, tag string) (*SyslogSink, error) {
+	w, err := syslog.New(priority, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogSink{writer: w}, nil
+}
+
+// Write sends one message per event to the system log.
+func (s *SyslogSink) Write(events []*goa.AuditEvent) error {
+	for _, e := range events {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := s.writer.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the connection to the syslog daemon.
+func (s *SyslogSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,127 @@
+package goa_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// recordingSink is an audit sink that fails the first writes.
+type recordingSink struct {
+	sync.Mutex
+	failures int
+	events   []*goa.AuditEvent
+}
+
+func (s *recordingSink) Write(events []*goa.AuditEvent) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *recordingSink) Events() []*goa.AuditEvent {
+	s.Lock()
+	defer s.Unlock()
+	return s.events
+}
+
+var _ = Describe("Auditor", func() {
+	var sink *recordingSink
+	var auditor *goa.Auditor
+
+	BeforeEach(func() {
+		sink = &recordingSink{failures: 2}
+		auditor = goa.NewAuditor(sink, &goa.AuditOptions{RetryInterval: time.Millisecond})
+	})
+
+	It("retries until the events are delivered", func() {
+		Ω(auditor.Record(&goa.AuditEvent{Action: "show"})).Should(Succeed())
+		Ω(auditor.Record(&goa.AuditEvent{Action: "update"})).Should(Succeed())
+		Ω(auditor.Close(context.Background())).Should(Succeed())
+		Ω(sink.Events()).Should(HaveLen(2))
+		Ω(auditor.Record(&goa.AuditEvent{})).ShouldNot(Succeed())
+	})
+
+	It("gives up once the close context is done", func() {
+		sink.failures = 1000
+		Ω(auditor.Record(&goa.AuditEvent{Action: "show"})).Should(Succeed())
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		Ω(auditor.Close(ctx)).Should(Equal(context.DeadlineExceeded))
+	})
+})
+
+var _ = Describe("Audit", func() {
+	var sink *recordingSink
+	var service *goa.Service
+	var ctx context.Context
+	var req *http.Request
+	var handlerErr error
+
+	BeforeEach(func() {
+		sink = &recordingSink{}
+		service = goa.New("test")
+		service.SetAuditor(goa.NewAuditor(sink, nil))
+		var err error
+		req, err = http.NewRequest("POST", "/accounts/1", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		params := url.Values{"id": {"1"}, "token": {"secret"}}
+		ctx = goa.NewContext(goa.WithLogFields(goa.RootContext), service, &TestResponseWriter{}, req, params)
+		goa.Request(ctx).Payload = map[string]interface{}{"name": "alice", "password": "secret"}
+		handlerErr = nil
+	})
+
+	JustBeforeEach(func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			goa.WithPrincipal(ctx, "alice")
+			if handlerErr == nil {
+				goa.Response(ctx).WriteHeader(201)
+			}
+			return handlerErr
+		}
+		err := goa.Audit(h, "token", "password")(ctx, goa.Response(ctx), req)
+		if handlerErr == nil {
+			Ω(err).ShouldNot(HaveOccurred())
+		} else {
+			Ω(err).Should(Equal(handlerErr))
+		}
+	})
+
+	It("records the request with the redacted values masked", func() {
+		Eventually(sink.Events).Should(HaveLen(1))
+		e := sink.Events()[0]
+		Ω(e.Principal).Should(Equal("alice"))
+		Ω(e.Method).Should(Equal("POST"))
+		Ω(e.Status).Should(Equal(201))
+		Ω(e.Outcome).Should(Equal(goa.AuditSuccess))
+		Ω(e.Params).Should(Equal(map[string]interface{}{"id": "1", "token": "[REDACTED]"}))
+		Ω(e.Payload).Should(Equal(map[string]interface{}{"name": "alice", "password": "[REDACTED]"}))
+	})
+
+	Context("with a request that is denied", func() {
+		BeforeEach(func() {
+			handlerErr = goa.ErrForbidden("nope")
+		})
+
+		It("records the outcome", func() {
+			Eventually(sink.Events).Should(HaveLen(1))
+			e := sink.Events()[0]
+			Ω(e.Status).Should(Equal(403))
+			Ω(e.Outcome).Should(Equal(goa.AuditDenied))
+			Ω(e.Error).ShouldNot(BeEmpty())
+		})
+	})
+})
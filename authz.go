@@ -0,0 +1,120 @@
+package goa
+
+import (
+	"fmt"
+	"net/url"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// AuthorizationRequest describes a request made to an action with an authorization policy.
+	AuthorizationRequest struct {
+		// Policy is the name of the policy that applies to the action.
+		Policy string
+		// Resource is the name of the resource exposing the action.
+		Resource string
+		// Action is the name of the action.
+		Action string
+		// Principal identifies the caller, it is set by the security middleware with
+		// WithPrincipal and is nil if the request is not authenticated.
+		Principal interface{}
+		// Params is the action context built by the generated code, it exposes the decoded
+		// parameters and payload of the request as typed fields.
+		Params interface{}
+		// RawParams contains the raw path and query string parameters.
+		RawParams url.Values
+		// Metadata is the action metadata defined in the design.
+		Metadata map[string][]string
+	}
+
+	// Authorizer is the interface implemented by the policy engines that decide whether
+	// requests made to the actions with an authorization policy are allowed.
+	Authorizer interface {
+		// Authorize returns true if the request is allowed. An error aborts the request,
+		// errors that are not service errors produce 500 responses.
+		Authorize(ctx context.Context, req *AuthorizationRequest) (bool, error)
+	}
+
+	// AuthorizerFunc is an adapter that makes it possible to use a function as an Authorizer.
+	AuthorizerFunc func(ctx context.Context, req *AuthorizationRequest) (bool, error)
+)
+
+// Authorize calls f(ctx, req).
+func (f AuthorizerFunc) Authorize(ctx context.Context, req *AuthorizationRequest) (bool, error) {
+	return f(ctx, req)
+}
+
+// SetAuthorizer sets the authorizer that decides whether the requests made to the actions with
+// an authorization policy are allowed.
+func (service *Service) SetAuthorizer(a Authorizer) {
+	service.securityMu.Lock()
+	defer service.securityMu.Unlock()
+	service.authorizer = a
+}
+
+// Authorize asks the service authorizer whether the request with the given context is allowed by
+// the given policy. params is the action context and metadata the action metadata. Authorize
+// returns an error built with ErrForbidden if the request is denied and with ErrInternal if the
+// service has no authorizer. The code generated by goagen calls Authorize for each action that
+// has a policy once the action context has been built.
+func (service *Service) Authorize(ctx context.Context, policy string, params interface{}, metadata map[string][]string) error {
+	service.securityMu.RLock()
+	a := service.authorizer
+	service.securityMu.RUnlock()
+	if a == nil {
+		return ErrInternal("no authorizer enforces policy %#v", policy)
+	}
+	req := &AuthorizationRequest{
+		Policy:    policy,
+		Resource:  ContextController(ctx),
+		Action:    ContextAction(ctx),
+		Principal: ContextPrincipal(ctx),
+		Params:    params,
+		Metadata:  metadata,
+	}
+	if r := Request(ctx); r != nil {
+		req.RawParams = r.Params
+	}
+	allowed, err := a.Authorize(ctx, req)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrForbidden("policy %#v denies the request", policy)
+	}
+	return nil
+}
+
+// WithPrincipal returns a copy of ctx holding the given principal. Security middleware call it
+// once they have authenticated the request so that authorizers may identify the caller. The name
+// of the principal is attached to the request log fields under the "principal" key, see LogWith.
+func WithPrincipal(ctx context.Context, principal interface{}) context.Context {
+	if name := principalName(principal); name != "" {
+		LogWith(ctx, "principal", name)
+	}
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// principalName returns the name used to log the given principal: the principal itself if it
+// is a string, the subject claim of JWT and OIDC claims, the result of String for principals
+// that implement fmt.Stringer and the empty string otherwise.
+func principalName(principal interface{}) string {
+	switch p := principal.(type) {
+	case string:
+		return p
+	case map[string]interface{}:
+		if sub, ok := p["sub"].(string); ok {
+			return sub
+		}
+	case fmt.Stringer:
+		return p.String()
+	}
+	return ""
+}
+
+// ContextPrincipal returns the principal of the request with the given context, nil if the
+// request is not authenticated.
+func ContextPrincipal(ctx context.Context) interface{} {
+	return ctx.Value(principalKey)
+}
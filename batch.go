@@ -0,0 +1,198 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+type (
+	// BatchHandler serves the batch endpoint of a service. The endpoint accepts POST requests
+	// whose body is a JSON array of sub-requests, each sub-request is dispatched to the
+	// controllers mounted on the service as if it had been sent on its own so that it goes
+	// through the same routing, middleware and validation. The response body is the JSON array
+	// of the sub-request responses in the same order. Sub-requests inherit the headers of the
+	// batch request (e.g. "Authorization"), the headers they define take precedence.
+	// The MountBatch function generated by goagen for APIs whose design defines a batch
+	// endpoint mounts a BatchHandler on the service.
+	BatchHandler struct {
+		// Service is the service the sub-requests are dispatched to.
+		Service *Service
+		// Concurrent causes the sub-requests to be dispatched concurrently, they are
+		// dispatched sequentially in order otherwise.
+		Concurrent bool
+		// MaxRequests is the maximum number of sub-requests in a batch, zero means no limit.
+		MaxRequests int
+	}
+
+	// BatchRequest describes a sub-request of a batch.
+	BatchRequest struct {
+		// ID identifies the sub-request in the batch response if not empty.
+		ID string `json:"id,omitempty"`
+		// Version is the name of the API version of the action, empty for the default.
+		Version string `json:"version,omitempty"`
+		// Method is the HTTP method of the sub-request.
+		Method string `json:"method"`
+		// Path is the path of the sub-request including the querystring if any.
+		Path string `json:"path"`
+		// Headers contains the sub-request headers.
+		Headers map[string]string `json:"headers,omitempty"`
+		// Body is the JSON sub-request body if any.
+		Body json.RawMessage `json:"body,omitempty"`
+	}
+
+	// BatchResponse describes the response of a sub-request.
+	BatchResponse struct {
+		// ID is the ID of the sub-request if any.
+		ID string `json:"id,omitempty"`
+		// Status is the response status code.
+		Status int `json:"status"`
+		// Headers contains the first value of each response header.
+		Headers map[string]string `json:"headers,omitempty"`
+		// Body is the response body. JSON responses are embedded as is, other responses are
+		// encoded as a JSON string.
+		Body json.RawMessage `json:"body,omitempty"`
+	}
+)
+
+// NewBatchHandler returns a handler that dispatches the sub-requests of batches to the given
+// service sequentially.
+func NewBatchHandler(service *Service) *BatchHandler {
+	return &BatchHandler{Service: service}
+}
+
+// Mount mounts the batch endpoint on the service using the given path.
+func (h *BatchHandler) Mount(path string) {
+	h.Service.Mux.Handle("POST", path, func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+		h.serve(rw, req)
+	})
+}
+
+// Dispatch dispatches the given sub-requests and returns their responses in the same order. req
+// is the batch request, its headers are copied to the sub-requests.
+func (h *BatchHandler) Dispatch(req *http.Request, reqs []*BatchRequest) []*BatchResponse {
+	resps := make([]*BatchResponse, len(reqs))
+	if !h.Concurrent {
+		for i, r := range reqs {
+			resps[i] = h.dispatch(req, r)
+		}
+		return resps
+	}
+	var wg sync.WaitGroup
+	for i, r := range reqs {
+		wg.Add(1)
+		go func(i int, r *BatchRequest) {
+			defer wg.Done()
+			resps[i] = h.dispatch(req, r)
+		}(i, r)
+	}
+	wg.Wait()
+	return resps
+}
+
+// serve decodes the sub-requests, dispatches them and writes the batch response.
+func (h *BatchHandler) serve(rw http.ResponseWriter, req *http.Request) {
+	var reqs []*BatchRequest
+	if err := json.NewDecoder(req.Body).Decode(&reqs); err != nil {
+		batchWriteError(rw, http.StatusBadRequest, fmt.Sprintf("invalid batch: %s", err))
+		return
+	}
+	if h.MaxRequests > 0 && len(reqs) > h.MaxRequests {
+		batchWriteError(rw, http.StatusBadRequest,
+			fmt.Sprintf("too many requests in batch, got %d, maximum is %d", len(reqs), h.MaxRequests))
+		return
+	}
+	for i, r := range reqs {
+		if r == nil || r.Method == "" || !strings.HasPrefix(r.Path, "/") {
+			batchWriteError(rw, http.StatusBadRequest,
+				fmt.Sprintf("invalid request at index %d, method and absolute path are required", i))
+			return
+		}
+	}
+	b, err := json.Marshal(h.Dispatch(req, reqs))
+	if err != nil {
+		batchWriteError(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(b)
+}
+
+// dispatch serves a single sub-request and returns its response.
+func (h *BatchHandler) dispatch(req *http.Request, r *BatchRequest) *BatchResponse {
+	resp := &BatchResponse{ID: r.ID}
+	inner, err := batchRequest(req, r)
+	if err != nil {
+		resp.Status = http.StatusBadRequest
+		resp.Body, _ = json.Marshal(map[string]interface{}{"title": http.StatusText(resp.Status), "msg": err.Error()})
+		return resp
+	}
+	rec := bridgeServe(h.Service, r.Version, inner)
+	resp.Status = rec.status
+	resp.Headers = make(map[string]string, len(rec.header))
+	for k, v := range rec.header {
+		if len(v) > 0 {
+			resp.Headers[k] = v[0]
+		}
+	}
+	body := bytes.TrimSpace(rec.body.Bytes())
+	switch {
+	case len(body) == 0:
+	case strings.Contains(rec.header.Get("Content-Type"), "json") && batchIsJSON(body):
+		resp.Body = json.RawMessage(body)
+	default:
+		resp.Body, _ = json.Marshal(rec.body.String())
+	}
+	return resp
+}
+
+// batchRequest builds the HTTP request corresponding to the sub-request. The headers of the batch
+// request are copied to the new request except for the ones describing the batch request body.
+func batchRequest(req *http.Request, r *BatchRequest) (*http.Request, error) {
+	var body io.Reader
+	if len(r.Body) > 0 {
+		body = bytes.NewReader(r.Body)
+	}
+	inner, err := http.NewRequest(strings.ToUpper(r.Method), r.Path, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.Header {
+		if k != "Content-Type" && k != "Content-Length" {
+			inner.Header[k] = v
+		}
+	}
+	if body != nil {
+		inner.Header.Set("Content-Type", "application/json")
+	} else {
+		inner.Body = ioutil.NopCloser(bytes.NewReader(nil))
+	}
+	for k, v := range r.Headers {
+		inner.Header.Set(k, v)
+	}
+	inner.Host = req.Host
+	inner.RemoteAddr = req.RemoteAddr
+	inner.TLS = req.TLS
+	return inner, nil
+}
+
+// batchIsJSON returns true if b is a valid JSON document.
+func batchIsJSON(b []byte) bool {
+	var v interface{}
+	return json.Unmarshal(b, &v) == nil
+}
+
+// batchWriteError writes an error response using the same body layout as goa errors.
+func batchWriteError(rw http.ResponseWriter, status int, msg string) {
+	b, _ := json.Marshal(map[string]interface{}{"title": http.StatusText(status), "msg": msg})
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	rw.Write(b)
+}
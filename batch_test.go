@@ -0,0 +1,106 @@
+package goa_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BatchHandler", func() {
+	var service *goa.Service
+	var handler *goa.BatchHandler
+	var body string
+	var rw *httptest.ResponseRecorder
+
+	var gotAuth string
+	var gotBody []byte
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		handler = goa.NewBatchHandler(service)
+		gotAuth, gotBody = "", nil
+		service.Mux.Handle("POST", "/bottles", func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			gotAuth = req.Header.Get("Authorization")
+			gotBody, _ = ioutil.ReadAll(req.Body)
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(201)
+			rw.Write([]byte(`{"id":42}`))
+		})
+		service.Mux.Handle("GET", "/bottles/:id", func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			rw.Header().Set("Content-Type", "text/plain")
+			rw.Write([]byte("bottle " + req.URL.Query().Get("id")))
+		})
+		body = `[
+			{"id": "create", "method": "POST", "path": "/bottles", "body": {"name": "Number 8"}},
+			{"id": "show", "method": "GET", "path": "/bottles/1?id=1"}
+		]`
+	})
+
+	JustBeforeEach(func() {
+		handler.Mount("/batch")
+		req, err := http.NewRequest("POST", "/batch", bytes.NewBufferString(body))
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Set("Content-Type", "application/json")
+		rw = httptest.NewRecorder()
+		service.Mux.ServeHTTP(rw, req)
+	})
+
+	It("dispatches the sub-requests", func() {
+		Ω(rw.Code).Should(Equal(200))
+		var resps []*goa.BatchResponse
+		Ω(json.Unmarshal(rw.Body.Bytes(), &resps)).ShouldNot(HaveOccurred())
+		Ω(resps).Should(HaveLen(2))
+		Ω(resps[0].ID).Should(Equal("create"))
+		Ω(resps[0].Status).Should(Equal(201))
+		Ω(string(resps[0].Body)).Should(Equal(`{"id":42}`))
+		Ω(resps[1].ID).Should(Equal("show"))
+		Ω(resps[1].Status).Should(Equal(200))
+		Ω(resps[1].Headers).Should(HaveKeyWithValue("Content-Type", "text/plain"))
+		Ω(string(resps[1].Body)).Should(Equal(`"bottle 1"`))
+		Ω(gotAuth).Should(Equal("Bearer token"))
+		Ω(string(gotBody)).Should(Equal(`{"name": "Number 8"}`))
+	})
+
+	Context("dispatching concurrently", func() {
+		BeforeEach(func() {
+			handler.Concurrent = true
+		})
+
+		It("returns the responses in order", func() {
+			Ω(rw.Code).Should(Equal(200))
+			var resps []*goa.BatchResponse
+			Ω(json.Unmarshal(rw.Body.Bytes(), &resps)).ShouldNot(HaveOccurred())
+			Ω(resps).Should(HaveLen(2))
+			Ω(resps[0].Status).Should(Equal(201))
+			Ω(resps[1].Status).Should(Equal(200))
+		})
+	})
+
+	Context("with too many sub-requests", func() {
+		BeforeEach(func() {
+			handler.MaxRequests = 1
+		})
+
+		It("rejects the batch", func() {
+			Ω(rw.Code).Should(Equal(400))
+		})
+	})
+
+	Context("with an invalid sub-request", func() {
+		BeforeEach(func() {
+			body = `[{"method": "GET", "path": "bottles"}]`
+		})
+
+		It("rejects the batch", func() {
+			Ω(rw.Code).Should(Equal(400))
+		})
+	})
+})
@@ -0,0 +1,33 @@
+package goa
+
+// SetMaxRequestSize sets the maximum size in bytes of the bodies of the requests made to the
+// actions that have no size set with SetActionMaxRequestSize. 0 removes the limit.
+func (service *Service) SetMaxRequestSize(n int64) {
+	service.maxSizesMu.Lock()
+	defer service.maxSizesMu.Unlock()
+	service.maxSize = n
+}
+
+// SetActionMaxRequestSize sets the maximum size in bytes of the bodies of the requests made to the
+// action with the given name of the controller with the given name. The code generated by goagen
+// calls SetActionMaxRequestSize for the actions whose design uses the MaxSize DSL.
+func (service *Service) SetActionMaxRequestSize(ctrl, action string, n int64) {
+	service.maxSizesMu.Lock()
+	defer service.maxSizesMu.Unlock()
+	if service.maxSizes == nil {
+		service.maxSizes = make(map[string]int64)
+	}
+	service.maxSizes[ctrl+"#"+action] = n
+}
+
+// MaxRequestSize returns the maximum size in bytes of the bodies of the requests made to the
+// given controller action, 0 if the size is not limited. Controller.MuxHandler rejects the
+// requests whose body is larger with a 413 response.
+func (service *Service) MaxRequestSize(ctrl, action string) int64 {
+	service.maxSizesMu.RLock()
+	defer service.maxSizesMu.RUnlock()
+	if n, ok := service.maxSizes[ctrl+"#"+action]; ok {
+		return n
+	}
+	return service.maxSize
+}
@@ -0,0 +1,81 @@
+package goa_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("MaxRequestSize", func() {
+	var service *goa.Service
+	var ctrl *goa.Controller
+	var unmarshaled bool
+	var body string
+	var rw *httptest.ResponseRecorder
+	var handled error
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		ctrl = service.NewController("files")
+		ctrl.ErrorHandler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request, err error) {
+			handled = err
+			rw.WriteHeader(goa.ErrorStatus(err))
+		}
+		unmarshaled = false
+		handled = nil
+		body = "0123456789"
+	})
+
+	JustBeforeEach(func() {
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.WriteHeader(204)
+			return nil
+		}
+		unm := func(ctx context.Context, req *http.Request) error {
+			unmarshaled = true
+			_, err := ioutil.ReadAll(req.Body)
+			return err
+		}
+		req, _ := http.NewRequest("POST", "/files", strings.NewReader(body))
+		rw = httptest.NewRecorder()
+		ctrl.MuxHandler("upload", h, unm)(rw, req, url.Values{})
+	})
+
+	It("does not limit the bodies by default", func() {
+		Ω(service.MaxRequestSize("files", "upload")).Should(BeZero())
+		Ω(rw.Code).Should(Equal(204))
+		Ω(unmarshaled).Should(BeTrue())
+	})
+
+	Context("with a service limit", func() {
+		BeforeEach(func() {
+			service.SetMaxRequestSize(5)
+		})
+
+		It("rejects larger bodies before decoding them", func() {
+			Ω(rw.Code).Should(Equal(413))
+			Ω(handled).Should(HaveOccurred())
+			Ω(handled.(*goa.HTTPError).Code).Should(Equal("request_too_large"))
+			Ω(unmarshaled).Should(BeFalse())
+		})
+
+		Context("overridden by the action", func() {
+			BeforeEach(func() {
+				service.SetActionMaxRequestSize("files", "upload", 10)
+			})
+
+			It("accepts bodies that fit", func() {
+				Ω(service.MaxRequestSize("files", "upload")).Should(Equal(int64(10)))
+				Ω(rw.Code).Should(Equal(204))
+				Ω(unmarshaled).Should(BeTrue())
+			})
+		})
+	})
+})
@@ -0,0 +1,152 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// bridgeRecorder captures the response written by an action invoked by a transport bridge such as
+// the gRPC server or the GraphQL handler.
+type bridgeRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// bridgeServe dispatches the request to the action of the given API version and returns the
+// recorded response.
+func bridgeServe(service *Service, version string, req *http.Request) *bridgeRecorder {
+	rec := &bridgeRecorder{header: make(http.Header)}
+	mux := service.Mux
+	if version != "" {
+		mux = service.Version(version).Mux
+	}
+	mux.ServeHTTP(rec, req)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec
+}
+
+// bridgeRequest builds the HTTP request sent to the action with the given route from the values
+// of the bridged request fields: the values of the fields named after the route wildcards are
+// used to build the path, the value of the field named payloadField if any is sent as the JSON
+// request body and the other values are sent in the querystring. The headers of req are copied
+// to the new request except for the ones describing the bridged request body and transport.
+func bridgeRequest(req *http.Request, verb, path string, fields map[string]interface{}, payloadField string) (*http.Request, error) {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if len(seg) < 2 || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		name := seg[1:]
+		val, ok := fields[name]
+		if !ok || val == nil {
+			return nil, fmt.Errorf("missing field %s", name)
+		}
+		segments[i] = bridgeParamValue(val)
+		delete(fields, name)
+	}
+	var body io.Reader
+	if payload, ok := fields[payloadField]; ok {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload: %s", err)
+		}
+		body = bytes.NewReader(b)
+		delete(fields, payloadField)
+	}
+	query := make(url.Values)
+	for name, val := range fields {
+		switch actual := val.(type) {
+		case nil:
+		case []interface{}:
+			for _, v := range actual {
+				query.Add(name, bridgeParamValue(v))
+			}
+		default:
+			query.Set(name, bridgeParamValue(val))
+		}
+	}
+	u := &url.URL{Path: strings.Join(segments, "/"), RawQuery: query.Encode()}
+	inner, err := http.NewRequest(verb, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.Header {
+		switch {
+		case k == "Content-Type", k == "Content-Length", k == "Te", k == "Trailer":
+		case strings.HasPrefix(k, "Grpc-"):
+		default:
+			inner.Header[k] = v
+		}
+	}
+	inner.Header.Set("Accept", "application/json")
+	if body != nil {
+		inner.Header.Set("Content-Type", "application/json")
+	}
+	inner.Host = req.Host
+	inner.RemoteAddr = req.RemoteAddr
+	inner.TLS = req.TLS
+	return inner, nil
+}
+
+// Header returns the recorded response headers.
+func (r *bridgeRecorder) Header() http.Header {
+	return r.header
+}
+
+// Write records the response body.
+func (r *bridgeRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+// WriteHeader records the response status.
+func (r *bridgeRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// failed returns true if the recorded status is not a success status.
+func (r *bridgeRecorder) failed() bool {
+	return r.status < 200 || r.status >= 300
+}
+
+// errorMessage returns the message of the error returned by the action. This is the "msg" field
+// of goa error responses, the response body for other text responses or the status text.
+func (r *bridgeRecorder) errorMessage() string {
+	body := bytes.TrimSpace(r.body.Bytes())
+	if strings.Contains(r.header.Get("Content-Type"), "json") {
+		var e struct {
+			Msg string `json:"msg"`
+		}
+		if err := json.Unmarshal(body, &e); err == nil && e.Msg != "" {
+			return e.Msg
+		}
+	} else if strings.HasPrefix(r.header.Get("Content-Type"), "text/") && len(body) > 0 {
+		return string(body)
+	}
+	return http.StatusText(r.status)
+}
+
+// bridgeParamValue returns the string representation of a bridged field value used to build the
+// request path and querystring.
+func bridgeParamValue(v interface{}) string {
+	switch actual := v.(type) {
+	case string:
+		return actual
+	case float64:
+		return strconv.FormatFloat(actual, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -3,6 +3,8 @@ package goa
 import (
 	"bytes"
 	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -36,8 +38,30 @@ type (
 		UserAgent string
 		// Dump indicates whether to dump request response.
 		Dump bool
+		// RequestHooks contains the ordered list of hooks invoked on each request prior to
+		// sending it. A hook may modify the request, for example to add headers.
+		RequestHooks []RequestHook
+		// ResponseHooks contains the ordered list of hooks invoked on each response
+		// received by the client.
+		ResponseHooks []ResponseHook
+		// MaxRetries is the maximum number of times a request that received a 429 (Too Many
+		// Requests) or 503 (Service Unavailable) response with a Retry-After header is
+		// retried. Only requests without a body are retried.
+		MaxRetries int
+		// MaxRetryAfter caps the time the client waits before retrying a request if not zero.
+		// Responses whose Retry-After header exceeds the cap are not retried.
+		MaxRetryAfter time.Duration
 	}
 
+	// RequestHook is the function invoked by the client prior to sending a request.
+	// Returning an error aborts the request, the error is returned by Do.
+	RequestHook func(*http.Request) error
+
+	// ResponseHook is the function invoked by the client once a response has been received.
+	// The request that produced the response is available via the response Request field.
+	// Returning an error causes Do to return the error instead of the response.
+	ResponseHook func(*http.Response) error
+
 	// Signer is the common interface implemented by all signers.
 	Signer interface {
 		// Sign adds required headers, cookies etc.
@@ -68,6 +92,30 @@ type (
 		token string
 	}
 
+	// HMACSigner signs requests with the HMAC-SHA256 signature of the canonical request, see
+	// SignHMACRequest.
+	HMACSigner struct {
+		// Header is the name of the header holding the signature.
+		// The default is "Authorization".
+		Header string
+		// KeyID identifies the key used to sign the requests.
+		KeyID string
+		// Secret is the secret key shared with the service.
+		Secret string
+	}
+
+	// APIKeySigner authenticates requests with an API key given in a header or query string
+	// parameter.
+	APIKeySigner struct {
+		// In is the location of the key, either "header" or "query".
+		// The default is "header".
+		In string
+		// Name is the name of the header or query string parameter holding the key.
+		Name string
+		// Key is the API key.
+		Key string
+	}
+
 	// OAuth2Signer enables the use of OAuth2 refresh tokens. It takes care of creating access
 	// tokens given a refresh token and a refresh URL as defined in RFC 6749.
 	// Note that this signer does not concern itself with generating the initial refresh token,
@@ -105,9 +153,62 @@ func NewClient() *Client {
 	}
 }
 
-// Do wraps the underlying http client Do method and adds logging.
+// UseClientCertificate makes the client present the certificate and private key stored in the
+// given PEM files to the services that require mutual TLS. caFile is the optional path to the PEM
+// file containing the certificates of the authorities used to verify the service certificate,
+// the system roots are used if empty. The client switches to a copy of its underlying HTTP client
+// that uses the resulting TLS configuration.
+func (c *Client) UseClientCertificate(certFile, keyFile, caFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	conf := &tls.Config{Certificates: []tls.Certificate{cert}}
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in CA file %s", caFile)
+		}
+	}
+	hc := *c.Client
+	hc.Transport = &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: conf}
+	c.Client = &hc
+	return nil
+}
+
+// Use adds the given hooks to the client request hooks.
+func (c *Client) Use(hooks ...RequestHook) {
+	c.RequestHooks = append(c.RequestHooks, hooks...)
+}
+
+// UseResponse adds the given hooks to the client response hooks.
+func (c *Client) UseResponse(hooks ...ResponseHook) {
+	c.ResponseHooks = append(c.ResponseHooks, hooks...)
+}
+
+// Do wraps the underlying http client Do method and adds logging. Do invokes the client request
+// hooks prior to sending the request and the response hooks once the response is received.
+// The request is signed by the client signers once the hooks have run.
+// Do retries requests that receive a response with a Retry-After header up to MaxRetries times,
+// replay protected requests are retried with new replay headers, see SetReplayHeaders.
+// Do sets the B3 tracing headers of requests whose context holds a span, see InjectSpan.
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	req.Header.Set("User-Agent", c.UserAgent)
+	if req.Header.Get(TraceIDHeader) == "" {
+		InjectSpan(req.Context(), req.Header)
+	}
+	for _, hook := range c.RequestHooks {
+		if err := hook(req); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.sign(req); err != nil {
+		return nil, err
+	}
 	var reqBody []byte
 	startedAt := time.Now()
 	id := shortID()
@@ -118,6 +219,23 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 		c.Info(nil, "started", KV{"id", id}, KV{req.Method, req.URL.String()})
 	}
 	resp, err := c.Client.Do(req)
+	for attempt := 0; err == nil && attempt < c.MaxRetries; attempt++ {
+		delay, ok := c.retryDelay(req, resp)
+		if !ok {
+			break
+		}
+		resp.Body.Close()
+		c.Info(nil, "retrying", KV{"id", id}, KV{"status", resp.StatusCode}, KV{"after", delay.String()})
+		time.Sleep(delay)
+		if req.Header.Get(NonceHeader) != "" {
+			// The service rejects replayed nonces, retry with a new nonce.
+			SetReplayHeaders(req)
+			if err := c.sign(req); err != nil {
+				return nil, err
+			}
+		}
+		resp, err = c.Client.Do(req)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -126,9 +244,41 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	} else {
 		c.Info(nil, "completed", KV{"id", id}, KV{"status", resp.StatusCode}, KV{"time", time.Since(startedAt).String()})
 	}
+	for _, hook := range c.ResponseHooks {
+		if err := hook(resp); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+	}
 	return resp, err
 }
 
+// sign signs the request with the client signers.
+func (c *Client) sign(req *http.Request) error {
+	for _, s := range c.Signers {
+		if err := s.Sign(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryDelay returns the time to wait before retrying the request that produced the given response
+// and true if the request should be retried, false otherwise.
+func (c *Client) retryDelay(req *http.Request, resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != 429 && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	if req.Body != nil {
+		return 0, false
+	}
+	delay, ok := ParseRetryAfter(resp.Header.Get("Retry-After"))
+	if !ok || (c.MaxRetryAfter > 0 && delay > c.MaxRetryAfter) {
+		return 0, false
+	}
+	return delay, true
+}
+
 // Sign adds the basic auth header to the request.
 func (s *BasicSigner) Sign(req *http.Request) error {
 	if s.Username != "" && s.Password != "" {
@@ -162,6 +312,38 @@ func (s *JWTSigner) RegisterFlags(app *cobra.Command) {
 	app.Flags().StringVar(&s.token, "jwt", "", "JSON web token")
 }
 
+// Sign adds the HMAC signature header.
+func (s *HMACSigner) Sign(req *http.Request) error {
+	header := s.Header
+	if header == "" {
+		header = "Authorization"
+	}
+	return SignHMACRequest(req, header, s.KeyID, []byte(s.Secret))
+}
+
+// RegisterFlags adds the "--key-id" and "--secret" flags to the client tool.
+func (s *HMACSigner) RegisterFlags(app *cobra.Command) {
+	app.Flags().StringVar(&s.KeyID, "key-id", "", "HMAC signing key ID")
+	app.Flags().StringVar(&s.Secret, "secret", "", "HMAC signing secret")
+}
+
+// Sign adds the API key to the request header or query string.
+func (s *APIKeySigner) Sign(req *http.Request) error {
+	if s.In == "query" {
+		q := req.URL.Query()
+		q.Set(s.Name, s.Key)
+		req.URL.RawQuery = q.Encode()
+		return nil
+	}
+	req.Header.Set(s.Name, s.Key)
+	return nil
+}
+
+// RegisterFlags adds the "--key" flag to the client tool.
+func (s *APIKeySigner) RegisterFlags(app *cobra.Command) {
+	app.Flags().StringVar(&s.Key, "key", "", "API key")
+}
+
 // Sign refreshes the access token if needed and adds the OAuth header.
 func (s *OAuth2Signer) Sign(req *http.Request) error {
 	if s.expiresAt.Before(time.Now()) {
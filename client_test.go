@@ -0,0 +1,126 @@
+package goa_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var client *goa.Client
+	var server *httptest.Server
+	var receivedHeader string
+
+	BeforeEach(func() {
+		receivedHeader = ""
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			receivedHeader = req.Header.Get("X-Hook")
+			rw.WriteHeader(200)
+		}))
+		client = goa.NewClient()
+		client.Logger = new(TestLog)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("with request hooks", func() {
+		var hookErr error
+
+		BeforeEach(func() {
+			hookErr = nil
+			client.Use(func(req *http.Request) error {
+				req.Header.Set("X-Hook", "foo")
+				return hookErr
+			})
+		})
+
+		It("invokes the hooks prior to sending the request", func() {
+			req, err := http.NewRequest("GET", server.URL, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = client.Do(req)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(receivedHeader).Should(Equal("foo"))
+		})
+
+		Context("returning an error", func() {
+			BeforeEach(func() {
+				hookErr = errors.New("hook failed")
+			})
+
+			It("aborts the request", func() {
+				req, err := http.NewRequest("GET", server.URL, nil)
+				Ω(err).ShouldNot(HaveOccurred())
+				resp, err := client.Do(req)
+				Ω(err).Should(Equal(hookErr))
+				Ω(resp).Should(BeNil())
+				Ω(receivedHeader).Should(BeEmpty())
+			})
+		})
+	})
+
+	Context("with response hooks", func() {
+		var status int
+
+		BeforeEach(func() {
+			status = 0
+			client.UseResponse(func(resp *http.Response) error {
+				status = resp.StatusCode
+				return nil
+			})
+		})
+
+		It("invokes the hooks with the response", func() {
+			req, err := http.NewRequest("GET", server.URL, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = client.Do(req)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(status).Should(Equal(200))
+		})
+	})
+	Context("with retries", func() {
+		var calls int
+		var throttled *httptest.Server
+
+		BeforeEach(func() {
+			calls = 0
+			throttled = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				calls++
+				if calls == 1 {
+					rw.Header().Set("Retry-After", "0")
+					rw.WriteHeader(429)
+					return
+				}
+				rw.WriteHeader(200)
+			}))
+		})
+
+		AfterEach(func() {
+			throttled.Close()
+		})
+
+		It("does not retry by default", func() {
+			req, err := http.NewRequest("GET", throttled.URL, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			resp, err := client.Do(req)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(resp.StatusCode).Should(Equal(429))
+			Ω(calls).Should(Equal(1))
+		})
+
+		It("honors the Retry-After header", func() {
+			client.MaxRetries = 2
+			req, err := http.NewRequest("GET", throttled.URL, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			resp, err := client.Do(req)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(resp.StatusCode).Should(Equal(200))
+			Ω(calls).Should(Equal(2))
+		})
+	})
+})
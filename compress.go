@@ -0,0 +1,327 @@
+package goa
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultCompressMinSize is the minimum size of the response bodies compressed by the Compress
+// middleware when the options do not specify one.
+const DefaultCompressMinSize = 1024
+
+// DefaultCompressTypes lists the MIME types of the response bodies compressed by the Compress
+// middleware when the options do not specify any.
+var DefaultCompressTypes = []string{
+	"text/*",
+	"application/json",
+	"application/*+json",
+	"application/xml",
+	"application/*+xml",
+	"application/javascript",
+	"application/x-www-form-urlencoded",
+	"image/svg+xml",
+}
+
+type (
+	// CompressOptions configures the Compress middleware.
+	CompressOptions struct {
+		// Level is the compression level, see compress/flate. Zero means the default level.
+		Level int
+		// MinSize is the minimum size in bytes of the response bodies that get compressed,
+		// smaller bodies are written as is. Zero means DefaultCompressMinSize.
+		MinSize int
+		// Types lists the MIME types of the response bodies that get compressed. Entries may
+		// use wildcards for the subtype ("text/*") or the subtype prefix of structured syntax
+		// suffixes ("application/*+json"). Nil means DefaultCompressTypes.
+		Types []string
+	}
+
+	// compressWriter is the response writer used by the Compress middleware. It buffers the
+	// beginning of the response body until it can decide whether to compress it.
+	compressWriter struct {
+		http.ResponseWriter
+		encoding string
+		opts     *CompressOptions
+		status   int
+		buf      bytes.Buffer
+		decided  bool
+		enc      io.WriteCloser
+	}
+)
+
+// Compress returns a middleware that compresses the response bodies with gzip or deflate
+// depending on the request Accept-Encoding header. Only the response bodies whose content type is
+// listed in the options and whose size is at least the options minimum size are compressed, the
+// middleware sets the Content-Encoding and Vary response headers accordingly. Responses that
+// already define a Content-Encoding header and WebSocket upgrades are left untouched.
+// opts may be nil in which case the default options apply.
+func Compress(opts *CompressOptions) Middleware {
+	o := CompressOptions{Level: gzip.DefaultCompression, MinSize: DefaultCompressMinSize, Types: DefaultCompressTypes}
+	if opts != nil {
+		if opts.Level != 0 {
+			o.Level = opts.Level
+		}
+		if opts.MinSize > 0 {
+			o.MinSize = opts.MinSize
+		}
+		if opts.Types != nil {
+			o.Types = opts.Types
+		}
+	}
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			encoding := acceptedEncoding(req.Header.Get("Accept-Encoding"))
+			if encoding == "" || req.Header.Get("Upgrade") != "" {
+				return h(ctx, rw, req)
+			}
+			cw := &compressWriter{encoding: encoding, opts: &o}
+			resp := Response(ctx)
+			if resp != nil {
+				cw.ResponseWriter = resp.SwitchWriter(cw)
+			} else {
+				cw.ResponseWriter = rw
+				rw = cw
+			}
+			err := h(ctx, rw, req)
+			if cerr := cw.Close(); cerr != nil {
+				Error(ctx, "failed to compress response", KV{"error", cerr.Error()})
+			}
+			if resp != nil {
+				resp.SwitchWriter(cw.ResponseWriter)
+			}
+			return err
+		}
+	}
+}
+
+// acceptedEncoding returns the content coding used to compress the response given the value of
+// the request Accept-Encoding header: "gzip", "deflate" or the empty string if the client does not
+// accept either. gzip is preferred when both are accepted with the same quality.
+func acceptedEncoding(accept string) string {
+	qs := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(accept, ",") {
+		coding, q := parseCoding(part)
+		switch coding {
+		case "gzip", "x-gzip":
+			qs["gzip"] = q
+		case "deflate":
+			qs["deflate"] = q
+		case "*":
+			wildcard = q
+		}
+	}
+	var best string
+	var bestQ float64
+	for _, coding := range []string{"gzip", "deflate"} {
+		q, ok := qs[coding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// parseCoding parses an element of the Accept-Encoding header and returns the coding and its
+// quality.
+func parseCoding(part string) (string, float64) {
+	elems := strings.Split(part, ";")
+	coding := strings.ToLower(strings.TrimSpace(elems[0]))
+	q := 1.0
+	for _, param := range elems[1:] {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "q=") {
+			if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+				q = v
+			}
+		}
+	}
+	return coding, q
+}
+
+// WriteHeader records the response status, the header is written once the response body is
+// known to be compressed or not.
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers b until MinSize bytes have been written, it then writes the header and the
+// buffered bytes - compressed if applicable - and writes the subsequent bytes directly.
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		return w.write(b)
+	}
+	w.buf.Write(b)
+	if w.buf.Len() >= w.opts.MinSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush writes the buffered bytes and flushes the underlying writer if it supports it. The
+// response is compressed if it qualifies regardless of its current size.
+func (w *compressWriter) Flush() {
+	if w.status == 0 {
+		return
+	}
+	if !w.decided {
+		w.decide(true)
+	}
+	if f, ok := w.enc.(interface {
+		Flush() error
+	}); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the underlying writer does.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not implement http.Hijacker")
+	}
+	w.decided = true
+	return h.Hijack()
+}
+
+// Push implements http.Pusher if the underlying writer does.
+func (w *compressWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Close writes the buffered bytes if any and terminates the compressed stream.
+func (w *compressWriter) Close() error {
+	if w.status == 0 {
+		return nil
+	}
+	if !w.decided {
+		if err := w.decide(w.buf.Len() >= w.opts.MinSize); err != nil {
+			return err
+		}
+	}
+	if w.enc != nil {
+		return w.enc.Close()
+	}
+	return nil
+}
+
+// decide writes the response header and the buffered bytes. The body is compressed if large is
+// true and the response qualifies given its status, headers and content type.
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	header := w.Header()
+	if w.compressible(header) {
+		header.Add("Vary", "Accept-Encoding")
+		if large {
+			var err error
+			switch w.encoding {
+			case "gzip":
+				w.enc, err = gzip.NewWriterLevel(w.ResponseWriter, w.opts.Level)
+			default:
+				w.enc, err = zlib.NewWriterLevel(w.ResponseWriter, w.opts.Level)
+			}
+			if err != nil {
+				return err
+			}
+			header.Set("Content-Encoding", w.encoding)
+			header.Del("Content-Length")
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// write writes b to the compressor if any, to the underlying writer otherwise.
+func (w *compressWriter) write(b []byte) (int, error) {
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// compressible returns true if the response with the given header may be compressed.
+func (w *compressWriter) compressible(header http.Header) bool {
+	if w.status < 200 || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	ct := header.Get("Content-Type")
+	if ct == "" {
+		ct = http.DetectContentType(w.buf.Bytes())
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	for _, t := range w.opts.Types {
+		if matchMediaType(t, mt) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMediaType returns true if the media type mt matches pattern. pattern may use a wildcard
+// for the subtype ("text/*") or for the subtype prefix of a structured syntax suffix
+// ("application/*+json").
+func matchMediaType(pattern, mt string) bool {
+	pattern = strings.ToLower(pattern)
+	if pattern == mt {
+		return true
+	}
+	pt, ps := splitMediaType(pattern)
+	t, s := splitMediaType(mt)
+	if pt != t {
+		return false
+	}
+	switch {
+	case ps == "*":
+		return true
+	case strings.HasPrefix(ps, "*+"):
+		return strings.HasSuffix(s, ps[1:])
+	}
+	return false
+}
+
+// splitMediaType returns the type and subtype of a media type.
+func splitMediaType(mt string) (string, string) {
+	i := strings.Index(mt, "/")
+	if i < 0 {
+		return mt, ""
+	}
+	return mt[:i], mt[i+1:]
+}
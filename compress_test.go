@@ -0,0 +1,122 @@
+package goa_test
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Compress", func() {
+	var opts *goa.CompressOptions
+	var acceptEncoding string
+	var contentType string
+	var body string
+
+	var rw *httptest.ResponseRecorder
+
+	BeforeEach(func() {
+		opts = &goa.CompressOptions{MinSize: 10}
+		acceptEncoding = "gzip"
+		contentType = "application/json"
+		body = strings.Repeat(`{"name":"goa"}`, 10)
+	})
+
+	JustBeforeEach(func() {
+		req, _ := http.NewRequest("GET", "/", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rw = httptest.NewRecorder()
+		ctx := goa.NewContext(nil, goa.New("test"), rw, req, nil)
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.Header().Set("Content-Type", contentType)
+			rw.Header().Set("Content-Length", "42")
+			rw.WriteHeader(200)
+			_, err := rw.Write([]byte(body))
+			return err
+		}
+		Ω(goa.Compress(opts)(h)(ctx, goa.Response(ctx), req)).ShouldNot(HaveOccurred())
+	})
+
+	It("compresses the response with gzip", func() {
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("Content-Encoding")).Should(Equal("gzip"))
+		Ω(rw.Header().Get("Content-Length")).Should(BeEmpty())
+		Ω(rw.Header().Get("Vary")).Should(Equal("Accept-Encoding"))
+		r, err := gzip.NewReader(rw.Body)
+		Ω(err).ShouldNot(HaveOccurred())
+		b, err := ioutil.ReadAll(r)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(b)).Should(Equal(body))
+	})
+
+	Context("with a client that prefers deflate", func() {
+		BeforeEach(func() {
+			acceptEncoding = "gzip;q=0.5, deflate"
+		})
+
+		It("compresses the response with deflate", func() {
+			Ω(rw.Header().Get("Content-Encoding")).Should(Equal("deflate"))
+			r, err := zlib.NewReader(rw.Body)
+			Ω(err).ShouldNot(HaveOccurred())
+			b, err := ioutil.ReadAll(r)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(Equal(body))
+		})
+	})
+
+	Context("with a client that does not accept compressed responses", func() {
+		BeforeEach(func() {
+			acceptEncoding = "gzip;q=0, identity"
+		})
+
+		It("does not compress the response", func() {
+			Ω(rw.Header().Get("Content-Encoding")).Should(BeEmpty())
+			Ω(rw.Body.String()).Should(Equal(body))
+		})
+	})
+
+	Context("with a small response body", func() {
+		BeforeEach(func() {
+			body = `{}`
+		})
+
+		It("does not compress the response", func() {
+			Ω(rw.Header().Get("Content-Encoding")).Should(BeEmpty())
+			Ω(rw.Header().Get("Content-Length")).Should(Equal("42"))
+			Ω(rw.Header().Get("Vary")).Should(Equal("Accept-Encoding"))
+			Ω(rw.Body.String()).Should(Equal(body))
+		})
+	})
+
+	Context("with a content type that is not compressible", func() {
+		BeforeEach(func() {
+			contentType = "image/png"
+		})
+
+		It("does not compress the response", func() {
+			Ω(rw.Header().Get("Content-Encoding")).Should(BeEmpty())
+			Ω(rw.Header().Get("Vary")).Should(BeEmpty())
+			Ω(rw.Body.String()).Should(Equal(body))
+		})
+	})
+
+	Context("with a custom list of types", func() {
+		BeforeEach(func() {
+			contentType = "application/vnd.goa.bottle+json; charset=utf-8"
+			opts.Types = []string{"application/*+json"}
+		})
+
+		It("compresses the matching responses", func() {
+			Ω(rw.Header().Get("Content-Encoding")).Should(Equal("gzip"))
+		})
+	})
+})
@@ -2,9 +2,11 @@ package goa
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 
 	"golang.org/x/net/context"
 )
@@ -16,6 +18,20 @@ const (
 	paramsKey
 	serviceKey
 	logContextKey
+	ctrlKey
+	actionKey
+	retryAfterKey
+	baseParamsKey
+	webhookEnvelopeKey
+	requestIDKey
+	requiredScopesKey
+	spanKey
+	principalKey
+	logFieldsKey
+	rolesKey
+	csrfTokenKey
+	tenantKey
+	clientAddrKey
 )
 
 var (
@@ -60,6 +76,14 @@ type (
 		Length int
 	}
 
+	// logFields holds the log fields attached to a request with LogWith. It is shared by all
+	// the contexts derived from the request context so that the fields attached by a handler
+	// are also logged by the middleware that called it.
+	logFields struct {
+		sync.Mutex
+		data []KV
+	}
+
 	// key is the type used to store internal values in the context.
 	// Context provides typed accessor methods to these values.
 	key int
@@ -108,16 +132,70 @@ func RequestService(ctx context.Context) *Service {
 	return nil
 }
 
-// LogContext returns the data prepended to all log entries.
+// ContextController returns the name of the controller handling the request with the given
+// context, empty string if the request is not being handled by a controller.
+func ContextController(ctx context.Context) string {
+	if c := ctx.Value(ctrlKey); c != nil {
+		return c.(string)
+	}
+	return ""
+}
+
+// ContextAction returns the name of the action handling the request with the given context,
+// empty string if the request is not being handled by a controller action.
+func ContextAction(ctx context.Context) string {
+	if a := ctx.Value(actionKey); a != nil {
+		return a.(string)
+	}
+	return ""
+}
+
+// LogContext returns the data prepended to all log entries: the data given to NewLogContext
+// followed by the fields attached with LogWith.
 func LogContext(ctx context.Context) []KV {
 	if ctx == nil {
 		return nil
 	}
-	data := ctx.Value(logContextKey)
-	if data == nil {
-		return nil
+	var data []KV
+	if d := ctx.Value(logContextKey); d != nil {
+		data = d.([]KV)
+	}
+	if f, ok := ctx.Value(logFieldsKey).(*logFields); ok {
+		f.Lock()
+		defer f.Unlock()
+		if len(f.data) > 0 {
+			data = append(data[:len(data):len(data)], f.data...)
+		}
 	}
-	return data.([]KV)
+	return data
+}
+
+// WithLogFields returns a copy of ctx where LogWith may attach log fields. The fields attached
+// with any context derived from the returned context are visible to all of them. The request
+// contexts built by the controllers already support LogWith.
+func WithLogFields(ctx context.Context) context.Context {
+	return context.WithValue(ctx, logFieldsKey, &logFields{})
+}
+
+// LogWith attaches the given key/value pair to the request with the given context. Unlike the
+// data given to NewLogContext the pair is included in all the entries logged for the request
+// once attached, including the entries logged by the middleware that run before the handler that
+// attached it such as the access log. Attaching a key again replaces its value. LogWith does
+// nothing if ctx was not built with WithLogFields.
+func LogWith(ctx context.Context, key string, value interface{}) {
+	f, ok := ctx.Value(logFieldsKey).(*logFields)
+	if !ok {
+		return
+	}
+	f.Lock()
+	defer f.Unlock()
+	for i, kv := range f.data {
+		if kv.Key == key {
+			f.data[i].Value = value
+			return
+		}
+	}
+	f.data = append(f.data, KV{key, value})
 }
 
 // NewLogContext creates a duplicate context where the data prepended to all log entries is
@@ -131,6 +209,38 @@ func ResetLogContext(ctx context.Context) context.Context {
 	return context.WithValue(ctx, logContextKey, nil)
 }
 
+// WithBaseParams stores the values of the API base path parameters (e.g. "tenant" or "stage") in
+// the context. The generated href factories that accept a context use these values to build the
+// resource hrefs and Location headers:
+//
+//	ctx.Context = goa.WithBaseParams(ctx.Context, url.Values{"tenant": {"acme"}})
+//	ctx.SetCreatedLocation(bottle.ID)
+//
+// The values given here override the values of the request parameters with the same names.
+func WithBaseParams(ctx context.Context, params url.Values) context.Context {
+	base := make(url.Values)
+	for k, v := range ContextBaseParams(ctx) {
+		base[k] = v
+	}
+	for k, v := range params {
+		base[k] = v
+	}
+	return context.WithValue(ctx, baseParamsKey, base)
+}
+
+// ContextBaseParams returns the values of the API base path parameters for the request with the
+// given context. It returns the values stored with WithBaseParams if any, the request parameters
+// otherwise.
+func ContextBaseParams(ctx context.Context) url.Values {
+	if p := ctx.Value(baseParamsKey); p != nil {
+		return p.(url.Values)
+	}
+	if req := Request(ctx); req != nil {
+		return req.Params
+	}
+	return nil
+}
+
 // CancelAll sends a cancellation signal to all handlers through the context.
 // see https://godoc.org/golang.org/x/net/context for details on how to handle the signal.
 func CancelAll() {
@@ -150,11 +260,52 @@ func (r *ResponseData) Written() bool {
 	return r.Status != 0
 }
 
-// Send serializes the given body matching the request Accept header against the service
-// encoders. It uses the default service encoder if no match is found.
+// Push initiates a HTTP/2 server push of the resource with the given target so that the client
+// receives it without requesting it, see http.Pusher. It returns http.ErrNotSupported if the
+// connection does not support server push, for example when the request was made with HTTP/1.1.
+// Handlers may ignore the error, the client then requests the resource itself if it needs it.
+func (r *ResponseData) Push(target string, opts *http.PushOptions) error {
+	if p, ok := r.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Send serializes the given body matching the request Accept header against the response
+// Content-Type header and the service encoders, see ServiceVersion.Negotiate. It sets the
+// Content-Type header to the negotiated content type. If the request does not accept any content
+// type the service can produce then Send returns an error of class ErrNotAcceptable without
+// writing the response unless code is an error status (400 or above), in which case the response
+// is written with the default service encoder.
 func (r *ResponseData) Send(ctx context.Context, code int, body interface{}) error {
+	service := RequestService(ctx)
+	contentType, err := service.Negotiate(Request(ctx).Header.Get("Accept"), r.Header().Get("Content-Type"))
+	if err != nil && code < 400 {
+		return err
+	}
+	if contentType != "" {
+		r.Header().Set("Content-Type", contentType)
+	}
 	r.WriteHeader(code)
-	return RequestService(ctx).EncodeResponse(ctx, body)
+	return service.EncodeResponse(ctx, body)
+}
+
+// SendRaw sends a HTTP response with the given status code, Content-Type header and body. The body
+// is written as is without looking up an encoder, it is closed once written if it implements
+// io.Closer.
+func (r *ResponseData) SendRaw(code int, contentType string, body io.Reader) error {
+	if contentType != "" {
+		r.Header().Set("Content-Type", contentType)
+	}
+	r.WriteHeader(code)
+	if body == nil {
+		return nil
+	}
+	if c, ok := body.(io.Closer); ok {
+		defer c.Close()
+	}
+	_, err := io.Copy(r, body)
+	return err
 }
 
 // BadRequest sends a HTTP response with status code 400 and the given error as body.
@@ -1,6 +1,8 @@
 package goa_test
 
 import (
+	"bytes"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 
@@ -40,4 +42,102 @@ var _ = Describe("ResponseData", func() {
 			Ω(trw.Status).Should(Equal(42))
 		})
 	})
+
+	Context("SendRaw", func() {
+		var trw *TestResponseWriter
+
+		BeforeEach(func() {
+			trw = &TestResponseWriter{ParentHeader: make(http.Header)}
+			data.SwitchWriter(trw)
+		})
+
+		It("writes the body as is with the given content type", func() {
+			body := ioutil.NopCloser(bytes.NewBufferString("\x89PNG"))
+			err := data.SendRaw(200, "image/png", body)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(trw.Status).Should(Equal(200))
+			Ω(trw.ParentHeader.Get("Content-Type")).Should(Equal("image/png"))
+			Ω(string(trw.Body)).Should(Equal("\x89PNG"))
+			Ω(data.Length).Should(Equal(4))
+		})
+
+		It("writes the header only if there is no body", func() {
+			err := data.SendRaw(204, "", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(trw.Status).Should(Equal(204))
+			Ω(trw.Body).Should(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("Push", func() {
+	var service *goa.Service
+	var req *http.Request
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		req, _ = http.NewRequest("GET", "/", nil)
+	})
+
+	It("pushes the resource if the connection supports it", func() {
+		rw := &pushResponseWriter{TestResponseWriter: TestResponseWriter{ParentHeader: make(http.Header)}}
+		ctx := goa.NewContext(nil, service, rw, req, nil)
+		Ω(goa.Response(ctx).Push("/app.js", nil)).ShouldNot(HaveOccurred())
+		Ω(rw.pushed).Should(Equal([]string{"/app.js"}))
+	})
+
+	It("returns http.ErrNotSupported otherwise", func() {
+		ctx := goa.NewContext(nil, service, &TestResponseWriter{}, req, nil)
+		Ω(goa.Response(ctx).Push("/app.js", nil)).Should(Equal(http.ErrNotSupported))
+	})
+
+	It("pushes through the Compress middleware", func() {
+		req.Header.Set("Accept-Encoding", "gzip")
+		rw := &pushResponseWriter{TestResponseWriter: TestResponseWriter{ParentHeader: make(http.Header)}}
+		ctx := goa.NewContext(nil, service, rw, req, nil)
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			return goa.Response(ctx).Push("/app.js", nil)
+		}
+		Ω(goa.Compress(nil)(h)(ctx, goa.Response(ctx), req)).ShouldNot(HaveOccurred())
+		Ω(rw.pushed).Should(Equal([]string{"/app.js"}))
+	})
+})
+
+// pushResponseWriter is a response writer that records the server pushes.
+type pushResponseWriter struct {
+	TestResponseWriter
+	pushed []string
+}
+
+func (rw *pushResponseWriter) Push(target string, _ *http.PushOptions) error {
+	rw.pushed = append(rw.pushed, target)
+	return nil
+}
+
+var _ = Describe("ContextBaseParams", func() {
+	var ctx context.Context
+
+	BeforeEach(func() {
+		req, err := http.NewRequest("GET", "/acme/bottles/1", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		params := url.Values{"tenant": []string{"acme"}, "id": []string{"1"}}
+		ctx = goa.NewContext(context.Background(), goa.New("test"), &TestResponseWriter{}, req, params)
+	})
+
+	It("defaults to the request parameters", func() {
+		Ω(goa.ContextBaseParams(ctx).Get("tenant")).Should(Equal("acme"))
+	})
+
+	It("returns the values set with WithBaseParams", func() {
+		ctx = goa.WithBaseParams(ctx, url.Values{"stage": []string{"prod"}})
+		ctx = goa.WithBaseParams(ctx, url.Values{"tenant": []string{"other"}})
+		p := goa.ContextBaseParams(ctx)
+		Ω(p.Get("tenant")).Should(Equal("other"))
+		Ω(p.Get("stage")).Should(Equal("prod"))
+		Ω(goa.Request(ctx).Params.Get("tenant")).Should(Equal("acme"))
+	})
+
+	It("returns nil without a request", func() {
+		Ω(goa.ContextBaseParams(context.Background())).Should(BeNil())
+	})
 })
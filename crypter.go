@@ -0,0 +1,139 @@
+package goa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// Crypter encrypts and decrypts the values of the attributes marked with the Encrypted DSL.
+	// The code generated by goagen encrypts the values received in the request payloads before
+	// calling the handlers and decrypts the values sent in the responses. name is the name of
+	// the attribute so that implementations may use a key per attribute or bind the ciphertext
+	// to the attribute.
+	Crypter interface {
+		// Encrypt returns the ciphertext of the given attribute value.
+		Encrypt(ctx context.Context, name, plaintext string) (string, error)
+		// Decrypt returns the plaintext of the given attribute ciphertext.
+		Decrypt(ctx context.Context, name, ciphertext string) (string, error)
+	}
+
+	// AESCrypter is a Crypter that encrypts the values with AES-GCM using the attribute name as
+	// additional data. The ciphertexts are prefixed with the ID of the key used to encrypt them
+	// so that keys may be rotated.
+	AESCrypter struct {
+		ids   []string
+		aeads map[string]cipher.AEAD
+	}
+)
+
+// ErrNoCrypter is the error returned by EncryptField and DecryptField when the service handling
+// the request has no Crypter, see SetCrypter.
+var ErrNoCrypter = errors.New("no crypter, see goa.Service.SetCrypter")
+
+// SetCrypter sets the crypter used to encrypt and decrypt the values of the encrypted attributes.
+func (service *Service) SetCrypter(c Crypter) {
+	service.securityMu.Lock()
+	defer service.securityMu.Unlock()
+	service.crypter = c
+}
+
+// EncryptField encrypts the value of the attribute with the given name using the Crypter of the
+// service handling the request with the given context.
+func EncryptField(ctx context.Context, name, value string) (string, error) {
+	c := serviceCrypter(ctx)
+	if c == nil {
+		return "", ErrNoCrypter
+	}
+	return c.Encrypt(ctx, name, value)
+}
+
+// DecryptField decrypts the value of the attribute with the given name using the Crypter of the
+// service handling the request with the given context.
+func DecryptField(ctx context.Context, name, value string) (string, error) {
+	c := serviceCrypter(ctx)
+	if c == nil {
+		return "", ErrNoCrypter
+	}
+	return c.Decrypt(ctx, name, value)
+}
+
+// NewAESCrypter returns a crypter that encrypts with the first given key and decrypts with any of
+// them so that the previous keys may be kept around during rotations. The keys must be 16, 24 or
+// 32 bytes long to select AES-128, AES-192 or AES-256.
+func NewAESCrypter(keys ...[]byte) (*AESCrypter, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("missing crypter key")
+	}
+	c := &AESCrypter{aeads: make(map[string]cipher.AEAD)}
+	for _, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(key)
+		id := hex.EncodeToString(sum[:4])
+		c.ids = append(c.ids, id)
+		c.aeads[id] = aead
+	}
+	return c, nil
+}
+
+// Encrypt implements Crypter.
+func (c *AESCrypter) Encrypt(_ context.Context, name, plaintext string) (string, error) {
+	id := c.ids[0]
+	aead := c.aeads[id]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(name))
+	return id + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt implements Crypter.
+func (c *AESCrypter) Decrypt(_ context.Context, name, ciphertext string) (string, error) {
+	i := strings.Index(ciphertext, ".")
+	if i < 0 {
+		return "", fmt.Errorf("invalid ciphertext for %s", name)
+	}
+	aead, ok := c.aeads[ciphertext[:i]]
+	if !ok {
+		return "", fmt.Errorf("unknown key for %s", name)
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(ciphertext[i+1:])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid ciphertext for %s", name)
+	}
+	n := aead.NonceSize()
+	plain, err := aead.Open(nil, sealed[:n], sealed[n:], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("invalid ciphertext for %s", name)
+	}
+	return string(plain), nil
+}
+
+// serviceCrypter returns the crypter of the service handling the request with the given context,
+// nil if there is none.
+func serviceCrypter(ctx context.Context) Crypter {
+	service := RequestService(ctx)
+	if service == nil {
+		return nil
+	}
+	service.securityMu.RLock()
+	defer service.securityMu.RUnlock()
+	return service.crypter
+}
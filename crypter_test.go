@@ -0,0 +1,93 @@
+package goa_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("AESCrypter", func() {
+	var key []byte
+	var c *goa.AESCrypter
+
+	BeforeEach(func() {
+		key = bytes.Repeat([]byte("k"), 32)
+		var err error
+		c, err = goa.NewAESCrypter(key)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("encrypts and decrypts values", func() {
+		enc, err := c.Encrypt(context.Background(), "ssn", "123-45-6789")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(enc).ShouldNot(ContainSubstring("123-45-6789"))
+		dec, err := c.Decrypt(context.Background(), "ssn", enc)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(dec).Should(Equal("123-45-6789"))
+	})
+
+	It("binds the ciphertexts to the attribute", func() {
+		enc, err := c.Encrypt(context.Background(), "ssn", "123-45-6789")
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = c.Decrypt(context.Background(), "email", enc)
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("decrypts with the previous keys", func() {
+		enc, err := c.Encrypt(context.Background(), "ssn", "123-45-6789")
+		Ω(err).ShouldNot(HaveOccurred())
+		rotated, err := goa.NewAESCrypter(bytes.Repeat([]byte("n"), 32), key)
+		Ω(err).ShouldNot(HaveOccurred())
+		dec, err := rotated.Decrypt(context.Background(), "ssn", enc)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(dec).Should(Equal("123-45-6789"))
+		renc, err := rotated.Encrypt(context.Background(), "ssn", "123-45-6789")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(strings.SplitN(renc, ".", 2)[0]).ShouldNot(Equal(strings.SplitN(enc, ".", 2)[0]))
+	})
+
+	It("rejects invalid keys", func() {
+		_, err := goa.NewAESCrypter([]byte("short"))
+		Ω(err).Should(HaveOccurred())
+		_, err = goa.NewAESCrypter()
+		Ω(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("EncryptField", func() {
+	var service *goa.Service
+	var ctx context.Context
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		req, _ := http.NewRequest("POST", "/accounts", nil)
+		ctx = goa.NewContext(nil, service, &TestResponseWriter{}, req, url.Values{})
+	})
+
+	It("fails when the service has no crypter", func() {
+		_, err := goa.EncryptField(ctx, "ssn", "123-45-6789")
+		Ω(err).Should(Equal(goa.ErrNoCrypter))
+	})
+
+	Context("with a crypter", func() {
+		BeforeEach(func() {
+			c, err := goa.NewAESCrypter(bytes.Repeat([]byte("k"), 16))
+			Ω(err).ShouldNot(HaveOccurred())
+			service.SetCrypter(c)
+		})
+
+		It("uses the service crypter", func() {
+			enc, err := goa.EncryptField(ctx, "ssn", "123-45-6789")
+			Ω(err).ShouldNot(HaveOccurred())
+			dec, err := goa.DecryptField(ctx, "ssn", enc)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(dec).Should(Equal("123-45-6789"))
+		})
+	})
+})
@@ -0,0 +1,106 @@
+package goa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// CSRFHeader is the name of the header carrying the CSRF token of the requests made to the
+	// CSRF protected actions.
+	CSRFHeader = "X-CSRF-Token"
+
+	// CSRFCookie is the name of the cookie holding the CSRF token issued to the browser.
+	CSRFCookie = "csrf_token"
+
+	// CSRFField is the name of the form field carrying the CSRF token of the form submissions
+	// made to the CSRF protected actions.
+	CSRFField = "csrf_token"
+
+	// maxCSRFFormSize is the maximum size of the form bodies read to look for the CSRF token.
+	maxCSRFFormSize = 1 << 20
+)
+
+// CSRF returns a handler that protects h against cross-site request forgery using the double
+// submit cookie pattern. The handler issues a random token in the CSRFCookie cookie to the
+// browsers that do not have one yet and rejects the requests made with methods other than GET,
+// HEAD, OPTIONS and TRACE that do not carry the same token in the CSRFHeader header or in the
+// CSRFField field of an URL encoded form with a 403 response. Handlers retrieve the token to
+// embed in the pages they render with CSRFToken. The code generated by goagen wraps the handlers of
+// the CSRF protected actions with CSRF.
+func CSRF(h Handler) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		var token string
+		if c, err := req.Cookie(CSRFCookie); err == nil && c.Value != "" {
+			token = c.Value
+		}
+		switch req.Method {
+		case "GET", "HEAD", "OPTIONS", "TRACE":
+		default:
+			submitted := req.Header.Get(CSRFHeader)
+			if submitted == "" {
+				submitted = csrfFormToken(req)
+			}
+			if token == "" || subtle.ConstantTimeCompare([]byte(submitted), []byte(token)) != 1 {
+				return ErrForbidden("missing or invalid CSRF token")
+			}
+		}
+		if token == "" {
+			b := make([]byte, 32)
+			if _, err := rand.Read(b); err != nil {
+				return ErrInternal(err)
+			}
+			token = base64.RawURLEncoding.EncodeToString(b)
+			cookie := &http.Cookie{Name: CSRFCookie, Value: token, Path: "/"}
+			if req.TLS != nil {
+				cookie.Secure = true
+			}
+			rw.Header().Add("Set-Cookie", cookie.String()+"; SameSite=Lax")
+		}
+		return h(context.WithValue(ctx, csrfTokenKey, token), rw, req)
+	}
+}
+
+// CSRFToken returns the CSRF token issued to the browser that made the request with the given
+// context, empty string if the request was not handled by CSRF.
+func CSRFToken(ctx context.Context) string {
+	if t := ctx.Value(csrfTokenKey); t != nil {
+		return t.(string)
+	}
+	return ""
+}
+
+// csrfFormToken returns the value of the CSRFField field of the URL encoded form request body,
+// empty string if there is none or if the body is larger than maxCSRFFormSize. At most
+// maxCSRFFormSize+1 bytes are read and the body remains readable.
+func csrfFormToken(req *http.Request) string {
+	if req.Body == nil {
+		return ""
+	}
+	ct, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || ct != "application/x-www-form-urlencoded" {
+		return ""
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxCSRFFormSize+1))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+	if err != nil || len(body) > maxCSRFFormSize {
+		return ""
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return ""
+	}
+	return form.Get(CSRFField)
+}
@@ -0,0 +1,7 @@
+package design
+
+// IsAbuseSensitive returns true if the requests made to the action are inspected by the abuse
+// detectors, that is if the action or its resource is tagged with AbuseSensitive.
+func (a *ActionDefinition) IsAbuseSensitive() bool {
+	return a.AbuseSensitive || a.Parent != nil && a.Parent.AbuseSensitive
+}
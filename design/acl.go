@@ -0,0 +1,75 @@
+package design
+
+import (
+	"net"
+	"strings"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// NetworkACLDefinition lists the client networks allowed and denied access to the actions of the
+// API, a resource or an action. Denied networks take precedence over allowed networks, clients
+// are allowed if there are no allowed networks and they are not denied.
+type NetworkACLDefinition struct {
+	// Allow lists the allowed networks, each entry is a CIDR (e.g. "10.0.0.0/8") or an IP.
+	Allow []string
+	// Deny lists the denied networks, each entry is a CIDR or an IP.
+	Deny []string
+	// Parent is the API, resource or action the ACL applies to.
+	Parent dslengine.Definition
+}
+
+// Context returns the generic definition name used in error messages.
+func (n *NetworkACLDefinition) Context() string {
+	if n.Parent != nil {
+		return "network ACL of " + n.Parent.Context()
+	}
+	return "network ACL"
+}
+
+// Validate checks that the ACL lists at least one network and that all the entries are valid
+// CIDRs or IPs.
+func (n *NetworkACLDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if len(n.Allow) == 0 && len(n.Deny) == 0 {
+		verr.Add(n, "network ACL must list networks with Allow or Deny")
+	}
+	for _, entries := range [][]string{n.Allow, n.Deny} {
+		for _, e := range entries {
+			if !validNetwork(e) {
+				verr.Add(n, "invalid network %#v, must be a CIDR or an IP", e)
+			}
+		}
+	}
+	return verr.AsError()
+}
+
+// Scope returns the name of the ACL: "api" for API ACLs, the resource name for resource ACLs and
+// "<resource>#<action>" for action ACLs.
+func (n *NetworkACLDefinition) Scope() string {
+	return limitScope(n.Parent)
+}
+
+// EffectiveNetworkACL returns the network ACL that applies to the action: the action ACL if any,
+// the resource ACL otherwise and finally the API ACL. It returns nil if the action has no ACL.
+func (a *ActionDefinition) EffectiveNetworkACL() *NetworkACLDefinition {
+	if a.NetworkACL != nil {
+		return a.NetworkACL
+	}
+	if a.Parent != nil && a.Parent.NetworkACL != nil {
+		return a.Parent.NetworkACL
+	}
+	if Design != nil {
+		return Design.NetworkACL
+	}
+	return nil
+}
+
+// validNetwork returns true if e is a CIDR or an IP.
+func validNetwork(e string) bool {
+	if strings.Contains(e, "/") {
+		_, _, err := net.ParseCIDR(e)
+		return err == nil
+	}
+	return net.ParseIP(e) != nil
+}
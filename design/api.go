@@ -12,6 +12,28 @@ import (
 	"github.com/julienschmidt/httprouter"
 )
 
+// List of supported Retry-After strategies, see RetryAfterDefinition.
+const (
+	// RetryAfterFixed always uses the same delay.
+	RetryAfterFixed = "fixed"
+	// RetryAfterExponential doubles the delay with each consecutive rejection.
+	RetryAfterExponential = "exponential"
+	// RetryAfterContext uses the delay stored in the request context by the service code.
+	RetryAfterContext = "context"
+)
+
+// List of supported streaming formats, see StreamingDefinition.
+const (
+	// StreamingSSE streams the response values as server-sent events.
+	StreamingSSE = "sse"
+	// StreamingJSON streams the response values as newline delimited JSON documents.
+	StreamingJSON = "json"
+)
+
+// WildcardMediaType is the media type used by passthrough encodings and responses: the controller
+// sets the actual Content-Type and writes the raw response body, no encoder is involved.
+const WildcardMediaType = "*/*"
+
 var (
 	// Design is the API definition created via DSL.
 	Design *APIDefinition
@@ -45,6 +67,7 @@ var (
 		"application/cbor":      {"github.com/goadesign/encoding/cbor", "EncoderFactory", "DecoderFactory"},
 		"application/msgpack":   {"github.com/goadesign/encoding/msgpack", "EncoderFactory", "DecoderFactory"},
 		"application/x-msgpack": {"github.com/goadesign/encoding/msgpack", "EncoderFactory", "DecoderFactory"},
+		JSONAPIMediaType:        {"jsonapi", "JSONAPIEncoderFactory", "JSONAPIDecoderFactory"},
 	}
 
 	// JSONContentTypes is a slice of default Content-Type headers that will use stdlib
@@ -82,6 +105,46 @@ type (
 		Types map[string]*UserTypeDefinition
 		// MediaTypes indexes the API media types by canonical identifier.
 		MediaTypes map[string]*MediaTypeDefinition
+		// Events indexes the domain events by name.
+		Events map[string]*EventDefinition
+		// SecuritySchemes indexes the security schemes by name.
+		SecuritySchemes map[string]*SecuritySchemeDefinition
+		// Security describes the security requirements that apply to all the API actions
+		// if any.
+		Security *SecurityDefinition
+		// Policy is the name of the authorization policy that applies to all the API
+		// actions if any.
+		Policy string
+		// Audit describes the audit of all the API actions if any.
+		Audit *AuditDefinition
+		// Replay describes the replay protection of all the API actions if any.
+		Replay *ReplayDefinition
+		// Quota describes the budgets shared by all the API actions if any.
+		Quota *QuotaDefinition
+		// RateLimit describes the rate limit shared by all the API actions if any.
+		RateLimit *RateLimitDefinition
+		// Tenant describes how the tenant of the requests is identified if the API is
+		// multi-tenant.
+		Tenant *TenantDefinition
+		// NetworkACL lists the client networks allowed to call the API actions if any.
+		NetworkACL *NetworkACLDefinition
+		// Session describes the browser session of all the API actions if any.
+		Session *SessionDefinition
+		// CSRF describes the CSRF protection of all the API actions if any.
+		CSRF *CSRFDefinition
+		// Batch describes the batch endpoint of the API if any.
+		Batch *BatchDefinition
+		// Metrics describes the metrics endpoint of the API if any.
+		Metrics *MetricsDefinition
+		// Operations describes the operations endpoints of the API if any, it is defined
+		// when at least one action is async.
+		Operations *OperationsDefinition
+		// ProblemResponses is true if the default error responses render RFC 7807 problem
+		// details documents using ErrorMedia.
+		ProblemResponses bool
+		// JSONAPI is true if the media types are rendered as JSON:API documents and the
+		// request bodies are parsed as JSON:API documents.
+		JSONAPI bool
 		// rand is the random generator used to generate examples.
 		rand *RandomGenerator
 	}
@@ -130,6 +193,8 @@ type (
 		DSLFunc func()
 		// Metadata is a list of key/value pairs
 		Metadata dslengine.MetadataDefinition
+		// Location of the API or version DSL, used in validation error messages
+		dslengine.DSLLocation
 	}
 
 	// ContactDefinition contains the API contact information.
@@ -188,10 +253,49 @@ type (
 		Params *AttributeDefinition
 		// Request headers that apply to all actions.
 		Headers *AttributeDefinition
+		// Subscriptions describes the events clients may subscribe to if any.
+		Subscriptions *SubscriptionsDefinition
+		// Security describes the security requirements that apply to all the resource
+		// actions if any, it overrides the API requirements.
+		Security *SecurityDefinition
+		// Policy is the name of the authorization policy that applies to all the resource
+		// actions if any, it overrides the API policy.
+		Policy string
+		// Audit describes the audit of all the resource actions if any, it overrides the API
+		// audit.
+		Audit *AuditDefinition
+		// Replay describes the replay protection of all the resource actions if any, it
+		// overrides the API protection.
+		Replay *ReplayDefinition
+		// Quota describes the budgets shared by all the resource actions if any, it
+		// overrides the API quota.
+		Quota *QuotaDefinition
+		// RateLimit describes the rate limit shared by all the resource actions if any, it
+		// overrides the API rate limit.
+		RateLimit *RateLimitDefinition
+		// NoTenant is true if the resource actions are not multi-tenant.
+		NoTenant bool
+		// AbuseSensitive is true if the requests made to the resource actions are inspected
+		// by the abuse detectors.
+		AbuseSensitive bool
+		// SparseFields is true if the resource actions accept the fields query parameter
+		// that limits the attributes rendered in the responses.
+		SparseFields bool
+		// NetworkACL lists the client networks allowed to call the resource actions if
+		// any, it overrides the API ACL.
+		NetworkACL *NetworkACLDefinition
+		// Session describes the browser session of all the resource actions if any, it
+		// overrides the API session.
+		Session *SessionDefinition
+		// CSRF describes the CSRF protection of all the resource actions if any, it overrides
+		// the API protection.
+		CSRF *CSRFDefinition
 		// DSLFunc contains the DSL used to create this definition if any.
 		DSLFunc func()
 		// metadata is a list of key/value pairs
 		Metadata dslengine.MetadataDefinition
+		// Location of the resource DSL, used in validation error messages
+		dslengine.DSLLocation
 	}
 
 	// EncodingDefinition defines an encoder supported by the API.
@@ -211,14 +315,27 @@ type (
 		Name string
 		// HTTP status
 		Status int
+		// StatusRange is the class of the response status codes if the response covers a
+		// range of status codes, e.g. 2 for "2xx", 0 otherwise. Status is then the default
+		// status code of the response.
+		StatusRange int
 		// Response description
 		Description string
 		// Response body type if any
 		Type DataType
 		// Response body media type if any
 		MediaType string
+		// AlternateMediaTypes lists the identifiers of the media types the response body may
+		// be rendered with instead of MediaType, e.g. an error media type.
+		AlternateMediaTypes []string
 		// Response header definitions
 		Headers *AttributeDefinition
+		// RetryAfter defines how the value of the Retry-After header is computed if any
+		RetryAfter *RetryAfterDefinition
+		// CacheControl defines the values of the Cache-Control and Vary headers if any
+		CacheControl *CacheControlDefinition
+		// Streaming defines how the response values are streamed if the response is a stream
+		Streaming *StreamingDefinition
 		// Parent action or resource
 		Parent dslengine.Definition
 		// Metadata is a list of key/value pairs
@@ -227,16 +344,41 @@ type (
 		Standard bool
 		// Global is true if the response definition comes from the global API properties
 		Global bool
+		// Location of the response DSL, used in validation error messages
+		dslengine.DSLLocation
+	}
+
+	// RetryAfterDefinition defines the strategy used to compute the value of the Retry-After
+	// header of a response.
+	RetryAfterDefinition struct {
+		// Strategy is one of RetryAfterFixed, RetryAfterExponential or RetryAfterContext.
+		Strategy string
+		// Delay is the delay in seconds for the fixed strategy and the initial delay for
+		// the exponential strategy.
+		Delay int
+		// Max is the maximum delay in seconds for the exponential strategy, 0 means no limit.
+		Max int
+	}
+
+	// StreamingDefinition defines a streaming response: the response body consists of a
+	// sequence of values of the response type written as they are produced.
+	StreamingDefinition struct {
+		// Format is one of StreamingSSE or StreamingJSON.
+		Format string
+		// Heartbeat is the interval in seconds at which keep-alive messages are written
+		// while no value is sent, 0 disables heartbeats.
+		Heartbeat int
 	}
 
 	// ResponseTemplateDefinition defines a response template.
 	// A response template is a function that takes an arbitrary number
-	// of strings and returns a response definition.
+	// of parameters and returns a response definition. The parameters may be
+	// strings, integers, booleans, slices of strings or maps of strings.
 	ResponseTemplateDefinition struct {
 		// Response template name
 		Name string
 		// Response template function
-		Template func(params ...string) *ResponseDefinition
+		Template func(params ...interface{}) *ResponseDefinition
 	}
 
 	// ActionDefinition defines a resource action.
@@ -266,10 +408,69 @@ type (
 		QueryParams *AttributeDefinition
 		// Payload blueprint (request body) if any
 		Payload *UserTypeDefinition
+		// Result is the internal type produced by the action if any. Responses with media
+		// types are rendered by projecting the result onto the media type views.
+		Result *UserTypeDefinition
 		// Request headers that need to be made available to action
 		Headers *AttributeDefinition
+		// WebSocket describes the WebSocket endpoint if the action routes upgrade requests
+		// to WebSocket connections, nil otherwise.
+		WebSocket *WebSocketDefinition
+		// MQTTSubscription describes the MQTT topic whose messages invoke the action if any.
+		MQTTSubscription *MQTTTopicDefinition
+		// MQTTPublication describes the MQTT topic the responses of the action invoked via
+		// MQTT are published on if any.
+		MQTTPublication *MQTTTopicDefinition
+		// Proxy describes the upstream service the action requests are forwarded to if any.
+		Proxy *ProxyDefinition
+		// LongPoll describes the long-polling semantics of the action if any.
+		LongPoll *LongPollDefinition
+		// Callbacks lists the outbound webhooks whose URLs are registered by the action
+		// indexed by event name.
+		Callbacks map[string]*CallbackDefinition
+		// Webhook describes the inbound webhook received by the action if any.
+		Webhook *WebhookDefinition
+		// Async describes the long-running operations started by the action if any.
+		Async *AsyncDefinition
+		// Security describes the security requirements of the action if any, it overrides
+		// the resource and API requirements.
+		Security *SecurityDefinition
+		// Policy is the name of the authorization policy of the action if any, it overrides
+		// the resource and API policies.
+		Policy string
+		// Audit describes the audit of the action if any, it overrides the resource and API
+		// audits.
+		Audit *AuditDefinition
+		// Replay describes the replay protection of the action if any, it overrides the
+		// resource and API protections.
+		Replay *ReplayDefinition
+		// Quota describes the budgets of the action if any, it overrides the resource and
+		// API quotas.
+		Quota *QuotaDefinition
+		// RateLimit describes the rate limit of the action if any, it overrides the resource
+		// and API rate limits.
+		RateLimit *RateLimitDefinition
+		// ETag describes the ETags of the successful responses of the action if any, it
+		// overrides the ETags of the response media types.
+		ETag *ETagDefinition
+		// NoTenant is true if the action is not multi-tenant.
+		NoTenant bool
+		// AbuseSensitive is true if the requests made to the action are inspected by the
+		// abuse detectors.
+		AbuseSensitive bool
+		// SparseFields is true if the action accepts the fields query parameter that limits
+		// the attributes rendered in the responses.
+		SparseFields bool
+		// NetworkACL lists the client networks allowed to call the action if any, it
+		// overrides the resource and API ACLs.
+		NetworkACL *NetworkACLDefinition
+		// CSRF describes the CSRF protection of the action if any, it overrides the resource
+		// and API protections.
+		CSRF *CSRFDefinition
 		// Metadata is a list of key/value pairs
 		Metadata dslengine.MetadataDefinition
+		// Location of the action DSL, used in validation error messages
+		dslengine.DSLLocation
 	}
 
 	// LinkDefinition defines a media type link, it specifies a URL to a related resource.
@@ -283,6 +484,8 @@ type (
 
 		// Parent media Type
 		Parent *MediaTypeDefinition
+		// Location of the link DSL, used in validation error messages
+		dslengine.DSLLocation
 	}
 
 	// ViewDefinition defines which members and links to render when building a response.
@@ -296,6 +499,8 @@ type (
 		Name string
 		// Parent media Type
 		Parent *MediaTypeDefinition
+		// Location of the view DSL, used in validation error messages
+		dslengine.DSLLocation
 	}
 
 	// RouteDefinition represents an action route.
@@ -306,6 +511,8 @@ type (
 		Path string
 		// Parent is the action this route applies to.
 		Parent *ActionDefinition
+		// Location of the route DSL, used in validation error messages
+		dslengine.DSLLocation
 	}
 
 	// ResourceIterator is the type of functions given to IterateResources.
@@ -461,7 +668,7 @@ func (a *APIDefinition) Versions() (versions []string) {
 }
 
 // IterateSets goes over all the definition sets of the API: The API definition itself, each
-// version definition, user types, media types and finally resources.
+// version definition, user types, media types, events and finally resources.
 func (a *APIDefinition) IterateSets(iterator dslengine.SetIterator) {
 	// First run the top level API DSL to initialize responses and
 	// response templates needed by resources.
@@ -500,6 +707,26 @@ func (a *APIDefinition) IterateSets(iterator dslengine.SetIterator) {
 	})
 	iterator(mediaTypes)
 
+	// Then the event DSLs
+	events := make([]dslengine.Definition, len(a.Events))
+	i = 0
+	a.IterateEvents(func(e *EventDefinition) error {
+		events[i] = e
+		i++
+		return nil
+	})
+	iterator(events)
+
+	// Then the security scheme DSLs
+	schemes := make([]dslengine.Definition, len(a.SecuritySchemes))
+	i = 0
+	a.IterateSecuritySchemes(func(s *SecuritySchemeDefinition) error {
+		schemes[i] = s
+		i++
+		return nil
+	})
+	iterator(schemes)
+
 	// And now that we have everything the resources.
 	resources := make([]dslengine.Definition, len(a.Resources))
 	i = 0
@@ -536,7 +763,8 @@ func (v *APIVersionDefinition) Context() string {
 	return Design.Context()
 }
 
-// Finalize sets the Consumes and Produces fields to the defaults if empty.
+// Finalize sets the Consumes and Produces fields to the defaults if empty. It makes the JSON:API
+// media type the default of JSON:API APIs.
 func (v *APIVersionDefinition) Finalize() {
 	if len(v.Consumes) == 0 {
 		v.Consumes = DefaultDecoders
@@ -544,6 +772,10 @@ func (v *APIVersionDefinition) Finalize() {
 	if len(v.Produces) == 0 {
 		v.Produces = DefaultEncoders
 	}
+	if Design != nil && Design.JSONAPI {
+		v.Consumes = withJSONAPI(v.Consumes)
+		v.Produces = withJSONAPI(v.Produces)
+	}
 }
 
 // IsDefault returns true if the version definition applies to all versions (i.e. is the API
@@ -552,6 +784,43 @@ func (v *APIVersionDefinition) IsDefault() bool {
 	return v.Version == ""
 }
 
+// URLSchemes returns the version schemes suitable for use in URLs, see URLSchemes.
+func (v *APIVersionDefinition) URLSchemes() []string {
+	return URLSchemes(v.Schemes)
+}
+
+// ServesTLS returns true if the API is served over TLS by at least one of its schemes.
+func (a *APIDefinition) ServesTLS() bool {
+	for _, s := range a.Schemes {
+		switch s {
+		case "https", "wss", "h3":
+			return true
+		}
+	}
+	return false
+}
+
+// URLSchemes returns the URL schemes of the given design schemes: the "h2c" and "h3" schemes
+// which describe the protocols served by the API are replaced with "http" and "https"
+// respectively. The result does not contain duplicates.
+func URLSchemes(schemes []string) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, s := range schemes {
+		switch s {
+		case "h2c":
+			s = "http"
+		case "h3":
+			s = "https"
+		}
+		if !seen[s] {
+			seen[s] = true
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
 // IterateResources calls the given iterator passing in each resource sorted in alphabetical order.
 // Iteration stops if an iterator returns an error and in this case IterateResources returns that
 // error.
@@ -806,7 +1075,35 @@ func (r *ResourceDefinition) Finalize() {
 				return nil
 			})
 		}
-		// 3. Compute QueryParams from Params and set all path params as non zero attributes
+		// 3. Create the wait and cursor params of long-poll actions
+		if a.LongPoll != nil {
+			a.LongPoll.addParams()
+		}
+		// 4. Add the Accepted response of async actions unless already defined
+		if a.Async != nil {
+			var found bool
+			for _, resp := range a.Responses {
+				if resp.Status == 202 {
+					accepted := a.Async.acceptedResponse()
+					if resp.MediaType == "" {
+						resp.MediaType = accepted.MediaType
+					}
+					if resp.Headers == nil {
+						resp.Headers = accepted.Headers
+					} else if o := resp.Headers.Type.ToObject(); o != nil && o["Location"] == nil {
+						o["Location"] = accepted.Headers.Type.ToObject()["Location"]
+					}
+					found = true
+				}
+			}
+			if !found {
+				if a.Responses == nil {
+					a.Responses = make(map[string]*ResponseDefinition)
+				}
+				a.Responses["Accepted"] = a.Async.acceptedResponse()
+			}
+		}
+		// 5. Compute QueryParams from Params and set all path params as non zero attributes
 		if params := a.Params; params != nil {
 			queryParams := DupAtt(params)
 			a.Params.NonZeroAttributes = make(map[string]bool)
@@ -834,6 +1131,18 @@ func (enc *EncodingDefinition) Context() string {
 	return fmt.Sprintf("encoding for %s", strings.Join(enc.MIMETypes, ", "))
 }
 
+// IsPassthrough returns true if the encoding definition includes the wildcard media type. The
+// other MIME types listed in a passthrough definition describe the set of possible content types
+// and do not require an encoder.
+func (enc *EncodingDefinition) IsPassthrough() bool {
+	for _, m := range enc.MIMETypes {
+		if m == WildcardMediaType {
+			return true
+		}
+	}
+	return false
+}
+
 // HasKnownEncoder returns true if the encoder for the given MIME type is known by goa.
 // MIME types with unknown encoders must be associated with a package path explicitly in the DSL.
 func HasKnownEncoder(mimeType string) bool {
@@ -843,7 +1152,7 @@ func HasKnownEncoder(mimeType string) bool {
 // IsGoaEncoder returns true if the encoder for the given MIME type is implemented in the goa
 // package.
 func IsGoaEncoder(pkgPath string) bool {
-	return pkgPath == "json" || pkgPath == "xml" || pkgPath == "gob"
+	return pkgPath == "json" || pkgPath == "xml" || pkgPath == "gob" || pkgPath == "jsonapi"
 }
 
 // SupportingPackages returns the package paths to the packages that implements the encoders and
@@ -927,17 +1236,53 @@ func (r *ResponseDefinition) Context() string {
 	return prefix + suffix
 }
 
+// StatusRangeName returns the name of the response status range, e.g. "2xx", empty string if the
+// response does not cover a range of status codes.
+func (r *ResponseDefinition) StatusRangeName() string {
+	if r.StatusRange == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dxx", r.StatusRange)
+}
+
+// MediaTypes returns the identifiers of all the media types the response body may be rendered
+// with, MediaType first.
+func (r *ResponseDefinition) MediaTypes() []string {
+	if r.MediaType == "" {
+		return r.AlternateMediaTypes
+	}
+	return append([]string{r.MediaType}, r.AlternateMediaTypes...)
+}
+
 // Dup returns a copy of the response definition.
 func (r *ResponseDefinition) Dup() *ResponseDefinition {
 	res := ResponseDefinition{
 		Name:        r.Name,
 		Status:      r.Status,
+		StatusRange: r.StatusRange,
 		Description: r.Description,
 		MediaType:   r.MediaType,
 	}
+	if r.AlternateMediaTypes != nil {
+		res.AlternateMediaTypes = append([]string(nil), r.AlternateMediaTypes...)
+	}
 	if r.Headers != nil {
 		res.Headers = DupAtt(r.Headers)
 	}
+	if r.RetryAfter != nil {
+		ra := *r.RetryAfter
+		res.RetryAfter = &ra
+	}
+	if r.CacheControl != nil {
+		cc := *r.CacheControl
+		cc.Vary = append([]string(nil), r.CacheControl.Vary...)
+		cc.Parent = &res
+		res.CacheControl = &cc
+	}
+	if r.Streaming != nil {
+		st := *r.Streaming
+		res.Streaming = &st
+	}
 	return &res
 }
 
@@ -952,17 +1297,34 @@ func (r *ResponseDefinition) Merge(other *ResponseDefinition) {
 	if r.Status == 0 {
 		r.Status = other.Status
 	}
+	if r.StatusRange == 0 {
+		r.StatusRange = other.StatusRange
+	}
 	if r.Description == "" {
 		r.Description = other.Description
 	}
 	if r.MediaType == "" {
 		r.MediaType = other.MediaType
 	}
-	if other.Headers != nil {
+	if r.AlternateMediaTypes == nil {
+		r.AlternateMediaTypes = other.AlternateMediaTypes
+	}
+	if r.RetryAfter == nil {
+		r.RetryAfter = other.RetryAfter
+	}
+	if r.CacheControl == nil {
+		r.CacheControl = other.CacheControl
+	}
+	if r.Streaming == nil {
+		r.Streaming = other.Streaming
+	}
+	if other.Headers != nil && other.Headers.Type != nil {
 		otherHeaders := other.Headers.Type.ToObject()
 		if len(otherHeaders) > 0 {
 			if r.Headers == nil {
 				r.Headers = &AttributeDefinition{Type: Object{}}
+			} else if r.Headers.Type == nil {
+				r.Headers.Type = Object{}
 			}
 			headers := r.Headers.Type.ToObject()
 			for n, h := range otherHeaders {
@@ -80,3 +80,30 @@ var _ = Describe("SupportingPackages", func() {
 		})
 	})
 })
+
+var _ = Describe("EncodingDefinition Validate", func() {
+	var enc *design.EncodingDefinition
+
+	Context("with a passthrough definition", func() {
+		BeforeEach(func() {
+			enc = &design.EncodingDefinition{
+				MIMETypes: []string{design.WildcardMediaType, "image/png"},
+			}
+		})
+
+		It("does not require encoders", func() {
+			Ω(enc.IsPassthrough()).Should(BeTrue())
+			Ω(enc.Validate().AsError()).ShouldNot(HaveOccurred())
+		})
+
+		Context("and a package path", func() {
+			BeforeEach(func() {
+				enc.PackagePath = "github.com/goadesign/goa"
+			})
+
+			It("is invalid", func() {
+				Ω(enc.Validate().AsError()).Should(HaveOccurred())
+			})
+		})
+	})
+})
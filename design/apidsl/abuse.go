@@ -0,0 +1,24 @@
+package apidsl
+
+// AbuseSensitive tags the resource or action as sensitive to abuse, e.g. login, sign up or
+// password reset actions. The generated code wraps the handlers of abuse-sensitive actions with
+// goa.DetectAbuse which computes the fingerprint of the recent requests made by the principal
+// (request rate spike, error ratio and parameter anomalies) and feeds it to the detectors set on
+// the service with SetAbuseDetectors. The detectors may let the request through, throttle it
+// with a 429 response or block it with a 403 response:
+//
+//	Resource("session", func() {
+//		Action("login", func() {
+//			Routing(POST("/login"))
+//			AbuseSensitive()
+//		})
+//	})
+//
+// AbuseSensitive may appear in Resource or Action.
+func AbuseSensitive() {
+	if r, ok := resourceDefinition(false); ok {
+		r.AbuseSensitive = true
+	} else if a, ok := actionDefinition(true); ok {
+		a.AbuseSensitive = true
+	}
+}
@@ -0,0 +1,60 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AbuseSensitive", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("with abuse-sensitive resources and actions", func() {
+		BeforeEach(func() {
+			Resource("session", func() {
+				Action("login", func() {
+					Routing(POST("/login"))
+					AbuseSensitive()
+				})
+				Action("logout", func() {
+					Routing(POST("/logout"))
+				})
+			})
+			Resource("password", func() {
+				AbuseSensitive()
+				Action("reset", func() {
+					Routing(POST("/password/reset"))
+				})
+			})
+		})
+
+		It("tags the actions", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			session := Design.Resources["session"]
+			Ω(session.Actions["login"].IsAbuseSensitive()).Should(BeTrue())
+			Ω(session.Actions["logout"].IsAbuseSensitive()).Should(BeFalse())
+			Ω(Design.Resources["password"].Actions["reset"].IsAbuseSensitive()).Should(BeTrue())
+		})
+	})
+
+	Context("in an API", func() {
+		BeforeEach(func() {
+			API("abuse", func() {
+				AbuseSensitive()
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
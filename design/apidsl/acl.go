@@ -0,0 +1,71 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// NetworkACL defines the client networks allowed and denied access to the actions of the API,
+// resource or action where it appears. Action ACLs override resource ACLs which override the API
+// ACL. The DSL lists the networks as CIDRs or IPs:
+//
+//	Resource("admin", func() {
+//		NetworkACL(func() {
+//			Allow("10.0.0.0/8", "192.168.1.10")
+//			Deny("10.13.0.0/16")
+//		})
+//		...
+//	})
+//
+// Denied networks take precedence over allowed networks and clients are allowed if the ACL does
+// not list allowed networks and they are not denied. The generated code rejects the requests made
+// by other clients with a 403 response. The client address is the one resolved by the middleware
+// returned by goa.TrustProxies for services running behind proxies, the request remote address
+// otherwise.
+// NetworkACL may appear in API, Resource or Action.
+func NetworkACL(dsl func()) {
+	var parent dslengine.Definition
+	a, isAPI := apiDefinition(false)
+	r, isResource := resourceDefinition(false)
+	var act *design.ActionDefinition
+	switch {
+	case isAPI:
+		parent = a
+	case isResource:
+		parent = r
+	default:
+		var ok bool
+		if act, ok = actionDefinition(true); !ok {
+			return
+		}
+		parent = act
+	}
+	acl := &design.NetworkACLDefinition{Parent: parent}
+	if !dslengine.Execute(dsl, acl) {
+		return
+	}
+	switch {
+	case isAPI:
+		a.NetworkACL = acl
+	case isResource:
+		r.NetworkACL = acl
+	default:
+		act.NetworkACL = acl
+	}
+}
+
+// Allow adds networks to the allowed networks, each network is a CIDR or an IP.
+// Allow may only appear in NetworkACL.
+func Allow(networks ...string) {
+	if n, ok := networkACLDefinition(true); ok {
+		n.Allow = append(n.Allow, networks...)
+	}
+}
+
+// Deny adds networks to the denied networks, each network is a CIDR or an IP.
+// Deny may only appear in NetworkACL.
+func Deny(networks ...string) {
+	if n, ok := networkACLDefinition(true); ok {
+		n.Deny = append(n.Deny, networks...)
+	}
+}
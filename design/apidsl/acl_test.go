@@ -0,0 +1,94 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NetworkACL", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("with valid ACLs", func() {
+		BeforeEach(func() {
+			API("acl", func() {
+				NetworkACL(func() {
+					Deny("198.51.100.0/24")
+				})
+			})
+			Resource("admin", func() {
+				NetworkACL(func() {
+					Allow("10.0.0.0/8", "192.168.1.10")
+					Deny("10.13.0.0/16")
+				})
+				Action("show", func() {
+					Routing(GET("/admin"))
+				})
+				Action("health", func() {
+					Routing(GET("/admin/health"))
+					NetworkACL(func() {
+						Allow("::1")
+					})
+				})
+			})
+			Resource("public", func() {
+				Action("show", func() {
+					Routing(GET("/"))
+				})
+			})
+		})
+
+		It("sets the effective ACLs", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			admin := Design.Resources["admin"]
+			acl := admin.Actions["show"].EffectiveNetworkACL()
+			Ω(acl).Should(Equal(admin.NetworkACL))
+			Ω(acl.Allow).Should(Equal([]string{"10.0.0.0/8", "192.168.1.10"}))
+			Ω(acl.Deny).Should(Equal([]string{"10.13.0.0/16"}))
+			Ω(acl.Scope()).Should(Equal("admin"))
+			health := admin.Actions["health"].EffectiveNetworkACL()
+			Ω(health.Allow).Should(Equal([]string{"::1"}))
+			Ω(health.Scope()).Should(Equal("admin#health"))
+			public := Design.Resources["public"].Actions["show"].EffectiveNetworkACL()
+			Ω(public).Should(Equal(Design.NetworkACL))
+			Ω(public.Scope()).Should(Equal("api"))
+		})
+	})
+
+	Context("with an invalid network", func() {
+		BeforeEach(func() {
+			API("acl", func() {
+				NetworkACL(func() {
+					Allow("10.0.0.0/33")
+				})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`invalid network "10.0.0.0/33"`))
+		})
+	})
+
+	Context("with an empty ACL", func() {
+		BeforeEach(func() {
+			API("acl", func() {
+				NetworkACL(func() {})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("network ACL must list networks"))
+		})
+	})
+})
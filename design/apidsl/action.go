@@ -2,6 +2,7 @@ package apidsl
 
 import (
 	"fmt"
+	"strconv"
 
 	"bitbucket.org/pkg/inflect"
 	"github.com/goadesign/goa/design"
@@ -54,6 +55,7 @@ func Action(name string, dsl func()) {
 				Parent: r,
 				Name:   name,
 			}
+			action.RecordLocation()
 		}
 		if !dslengine.Execute(dsl, action) {
 			return
@@ -76,44 +78,51 @@ func Routing(routes ...*design.RouteDefinition) {
 	}
 }
 
+// newRoute creates a route recording the location of the DSL that defines it.
+func newRoute(verb, path string) *design.RouteDefinition {
+	route := &design.RouteDefinition{Verb: verb, Path: path}
+	route.RecordLocation()
+	return route
+}
+
 // GET creates a route using the GET HTTP method.
 func GET(path string) *design.RouteDefinition {
-	return &design.RouteDefinition{Verb: "GET", Path: path}
+	return newRoute("GET", path)
 }
 
 // HEAD creates a route using the HEAD HTTP method.
 func HEAD(path string) *design.RouteDefinition {
-	return &design.RouteDefinition{Verb: "HEAD", Path: path}
+	return newRoute("HEAD", path)
 }
 
 // POST creates a route using the POST HTTP method.
 func POST(path string) *design.RouteDefinition {
-	return &design.RouteDefinition{Verb: "POST", Path: path}
+	return newRoute("POST", path)
 }
 
 // PUT creates a route using the PUT HTTP method.
 func PUT(path string) *design.RouteDefinition {
-	return &design.RouteDefinition{Verb: "PUT", Path: path}
+	return newRoute("PUT", path)
 }
 
 // DELETE creates a route using the DELETE HTTP method.
 func DELETE(path string) *design.RouteDefinition {
-	return &design.RouteDefinition{Verb: "DELETE", Path: path}
+	return newRoute("DELETE", path)
 }
 
 // TRACE creates a route using the TRACE HTTP method.
 func TRACE(path string) *design.RouteDefinition {
-	return &design.RouteDefinition{Verb: "TRACE", Path: path}
+	return newRoute("TRACE", path)
 }
 
 // CONNECT creates a route using the GET HTTP method.
 func CONNECT(path string) *design.RouteDefinition {
-	return &design.RouteDefinition{Verb: "CONNECT", Path: path}
+	return newRoute("CONNECT", path)
 }
 
 // PATCH creates a route using the PATCH HTTP method.
 func PATCH(path string) *design.RouteDefinition {
-	return &design.RouteDefinition{Verb: "PATCH", Path: path}
+	return newRoute("PATCH", path)
 }
 
 // Headers implements the DSL for describing HTTP headers. The DSL syntax is identical to the one
@@ -204,51 +213,134 @@ func Params(dsl func()) {
 //		Required("Name")	// definition into the BottlePayload type.
 //	})
 //
+// Payload may also appear in Event where it describes the event payload, see Event.
 func Payload(p interface{}, dsls ...func()) {
 	if len(dsls) > 1 {
 		dslengine.ReportError("too many arguments given to Payload")
 		return
 	}
-	if a, ok := actionDefinition(true); ok {
-		var att *design.AttributeDefinition
-		var dsl func()
-		switch actual := p.(type) {
-		case func():
-			dsl = actual
-			att = newAttribute(a.Parent.MediaType)
-			att.Type = design.Object{}
-		case *design.AttributeDefinition:
-			att = design.DupAtt(actual)
-		case design.DataStructure:
-			att = design.DupAtt(actual.Definition())
-		case string:
-			ut, ok := design.Design.Types[actual]
-			if !ok {
-				dslengine.ReportError("unknown payload type %s", actual)
-			}
-			att = design.DupAtt(ut.AttributeDefinition)
-		case *design.Array:
-			att = &design.AttributeDefinition{Type: actual}
-		case *design.Hash:
-			att = &design.AttributeDefinition{Type: actual}
-		case design.Primitive:
-			att = &design.AttributeDefinition{Type: actual}
-		}
-		if len(dsls) == 1 {
-			if dsl != nil {
-				dslengine.ReportError("invalid arguments in Payload call, must be (type), (dsl) or (type, dsl)")
-			}
-			dsl = dsls[0]
-		}
-		if dsl != nil {
-			dslengine.Execute(dsl, att)
-		}
+	if a, ok := actionDefinition(false); ok {
+		att := payloadAttribute(a.Parent.MediaType, p, dsls)
 		rn := inflect.Camelize(a.Parent.Name)
 		an := inflect.Camelize(a.Name)
 		a.Payload = &design.UserTypeDefinition{
 			AttributeDefinition: att,
 			TypeName:            fmt.Sprintf("%s%sPayload", an, rn),
 		}
+	} else if e, ok := eventDefinition(true); ok {
+		att := payloadAttribute("", p, dsls)
+		e.Payload = &design.UserTypeDefinition{
+			AttributeDefinition: att,
+			TypeName:            fmt.Sprintf("%sPayload", inflect.Camelize(e.Name)),
+		}
+	}
+}
+
+// payloadAttribute builds the attribute describing a payload from the arguments given to Payload.
+// baseMT is the identifier of the media type used as reference by inline payload definitions.
+func payloadAttribute(baseMT string, p interface{}, dsls []func()) *design.AttributeDefinition {
+	var att *design.AttributeDefinition
+	var dsl func()
+	switch actual := p.(type) {
+	case func():
+		dsl = actual
+		att = newAttribute(baseMT)
+		att.Type = design.Object{}
+	case *design.AttributeDefinition:
+		att = design.DupAtt(actual)
+	case design.DataStructure:
+		att = design.DupAtt(actual.Definition())
+	case string:
+		ut, ok := design.Design.Types[actual]
+		if !ok {
+			dslengine.ReportError("unknown payload type %s", actual)
+		}
+		att = design.DupAtt(ut.AttributeDefinition)
+	case *design.Array:
+		att = &design.AttributeDefinition{Type: actual}
+	case *design.Hash:
+		att = &design.AttributeDefinition{Type: actual}
+	case design.Primitive:
+		att = &design.AttributeDefinition{Type: actual}
+	}
+	if len(dsls) == 1 {
+		if dsl != nil {
+			dslengine.ReportError("invalid arguments in Payload call, must be (type), (dsl) or (type, dsl)")
+		}
+		dsl = dsls[0]
+	}
+	if dsl != nil {
+		dslengine.Execute(dsl, att)
+	}
+	return att
+}
+
+// MaxSize sets the maximum size in bytes of the request bodies. The generated code limits the
+// bodies of the requests made to the actions with http.MaxBytesReader and rejects the requests
+// whose body is larger with a 413 response before decoding them. Sizes set on payloads override
+// sizes set on actions which override sizes set on resources which override the size set on the
+// API. The service sets the limit of the actions without size, see goa.Service.SetMaxRequestSize.
+//
+//	Action("upload", func() {
+//		Routing(POST("/"))
+//		Payload(UploadPayload, func() {
+//			MaxSize(10 << 20)	// 10 MiB
+//		})
+//	})
+//
+// MaxSize may appear in API, Resource, Action or Payload.
+func MaxSize(n int64) {
+	if n <= 0 {
+		dslengine.ReportError("invalid MaxSize %d, must be strictly positive", n)
+		return
+	}
+	set := func(md *dslengine.MetadataDefinition) {
+		if *md == nil {
+			*md = make(dslengine.MetadataDefinition)
+		}
+		(*md)[design.MaxSizeKey] = []string{strconv.FormatInt(n, 10)}
+	}
+	if a, ok := apiDefinition(false); ok {
+		set(&a.Metadata)
+	} else if r, ok := resourceDefinition(false); ok {
+		set(&r.Metadata)
+	} else if a, ok := actionDefinition(false); ok {
+		set(&a.Metadata)
+	} else if att, ok := attributeDefinition(true); ok {
+		set(&att.Metadata)
+	}
+}
+
+// Result defines the internal type produced by the action. The result type is distinct from the
+// media types used to render the responses: goagen generates the code that projects the result
+// onto each view of the response media types so that controllers may deal with domain shaped
+// values exclusively. The argument is either a user type or the name of one:
+//
+//	Action("show", func() {
+//		Routing(GET("/:id"))
+//		Result(BottleResult)		// BottleResult is a type defined with Type
+//		Response(OK, BottleMedia)
+//	})
+//
+// The generated context then exposes one method per response view that accepts the result, e.g.
+// OKResult and OKTinyResult.
+func Result(r interface{}) {
+	a, ok := actionDefinition(true)
+	if !ok {
+		return
+	}
+	switch actual := r.(type) {
+	case *design.UserTypeDefinition:
+		a.Result = actual
+	case string:
+		ut, ok := design.Design.Types[actual]
+		if !ok {
+			dslengine.ReportError("unknown result type %s", actual)
+			return
+		}
+		a.Result = ut
+	default:
+		dslengine.ReportError("invalid Result argument, must be a user type or the name of one")
 	}
 }
 
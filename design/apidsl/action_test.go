@@ -174,6 +174,122 @@ var _ = Describe("Action", func() {
 			})
 		})
 	})
+
+	Context("using a typed response template", func() {
+		const tmplName = "Error"
+		const respMediaType = "application/vnd.goa.error"
+
+		var tmplDSL interface{}
+
+		BeforeEach(func() {
+			name = "foo"
+			tmplDSL = func(status int, mt string, headers map[string]string) {
+				Status(status)
+				Media(mt)
+				Headers(func() {
+					for n, desc := range headers {
+						Header(n, String, desc)
+					}
+				})
+			}
+		})
+
+		JustBeforeEach(func() {
+			API("test", func() {
+				ResponseTemplate(tmplName, tmplDSL)
+				ResponseTemplate("JSONError", func(status int) {
+					UseResponseTemplate(tmplName, status, "application/json", map[string]string{})
+					Description("JSON error")
+				})
+			})
+			Resource("res", func() {
+				Action(name, dsl)
+			})
+			dslengine.Run()
+			if r, ok := Design.Resources["res"]; ok {
+				action = r.Actions[name]
+			}
+		})
+
+		Context("called correctly", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Routing(GET("/:id"))
+					Response(tmplName, 422, respMediaType, map[string]string{"X-Error-Id": "Error ID"})
+				}
+			})
+
+			It("defines the response definition using the template", func() {
+				Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+				resp := action.Responses[tmplName]
+				Ω(resp).ShouldNot(BeNil())
+				Ω(resp.Status).Should(Equal(422))
+				Ω(resp.MediaType).Should(Equal(respMediaType))
+				Ω(resp.Headers.Type.ToObject()).Should(HaveKey("X-Error-Id"))
+			})
+		})
+
+		Context("called with an argument of the wrong type", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Routing(GET("/:id"))
+					Response(tmplName, "422", respMediaType, map[string]string{})
+				}
+			})
+
+			It("fails", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+				Ω(dslengine.Errors.Error()).Should(ContainSubstring("argument at position 0 of response template Error must be a int"))
+			})
+		})
+
+		Context("called with missing arguments", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Routing(GET("/:id"))
+					Response(tmplName, 422)
+				}
+			})
+
+			It("fails", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+				Ω(dslengine.Errors.Error()).Should(ContainSubstring("response template Error expects 3 arguments but got 1"))
+			})
+		})
+
+		Context("with an unsupported parameter type", func() {
+			BeforeEach(func() {
+				tmplDSL = func(status float64) {}
+				dsl = func() {
+					Routing(GET("/:id"))
+				}
+			})
+
+			It("fails when the template is defined", func() {
+				Ω(dslengine.Errors).Should(HaveOccurred())
+				Ω(dslengine.Errors.Error()).Should(ContainSubstring("unsupported type float64"))
+			})
+		})
+
+		Context("composed from another template", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Routing(GET("/:id"))
+					Response("JSONError", 400)
+				}
+			})
+
+			It("applies both templates", func() {
+				Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+				resp := action.Responses["JSONError"]
+				Ω(resp).ShouldNot(BeNil())
+				Ω(resp.Name).Should(Equal("JSONError"))
+				Ω(resp.Status).Should(Equal(400))
+				Ω(resp.MediaType).Should(Equal("application/json"))
+				Ω(resp.Description).Should(Equal("JSON error"))
+			})
+		})
+	})
 })
 
 var _ = Describe("Payload", func() {
@@ -263,3 +379,219 @@ var _ = Describe("Payload", func() {
 	})
 
 })
+
+var _ = Describe("Result", func() {
+	var result interface{}
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		Type("BottleResult", func() {
+			Attribute("name")
+		})
+	})
+
+	JustBeforeEach(func() {
+		Resource("foo", func() {
+			Action("bar", func() {
+				Routing(GET(""))
+				Result(result)
+			})
+		})
+		dslengine.Run()
+	})
+
+	Context("with a type name", func() {
+		BeforeEach(func() {
+			result = "BottleResult"
+		})
+
+		It("sets the action result", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.Resources["foo"].Actions["bar"].Result).Should(Equal(Design.Types["BottleResult"]))
+		})
+	})
+
+	Context("with an unknown type name", func() {
+		BeforeEach(func() {
+			result = "Unknown"
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a primitive type", func() {
+		BeforeEach(func() {
+			result = String
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("MaxSize", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("in API, Action and Payload", func() {
+		BeforeEach(func() {
+			API("cellar", func() {
+				MaxSize(1 << 20)
+			})
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/:id"))
+				})
+				Action("create", func() {
+					Routing(POST(""))
+					MaxSize(4096)
+					Payload(func() {
+						Member("name")
+					})
+				})
+				Action("upload", func() {
+					Routing(POST("/:id/label"))
+					MaxSize(4096)
+					Payload(func() {
+						Member("label")
+						MaxSize(10 << 20)
+					})
+				})
+			})
+		})
+
+		It("sets the effective sizes", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			actions := Design.Resources["bottle"].Actions
+			Ω(actions["show"].EffectiveMaxSize()).Should(Equal(int64(1 << 20)))
+			Ω(actions["create"].EffectiveMaxSize()).Should(Equal(int64(4096)))
+			Ω(actions["upload"].EffectiveMaxSize()).Should(Equal(int64(10 << 20)))
+		})
+	})
+
+	Context("with a size that is not positive", func() {
+		BeforeEach(func() {
+			Resource("bottle", func() {
+				Action("create", func() {
+					Routing(POST(""))
+					MaxSize(0)
+				})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("invalid MaxSize 0"))
+		})
+	})
+
+	Context("with an invalid metadata value", func() {
+		BeforeEach(func() {
+			Resource("bottle", func() {
+				Action("create", func() {
+					Routing(POST(""))
+					Metadata(MaxSizeKey, "big")
+				})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`invalid goa:maxSize metadata value "big"`))
+		})
+	})
+})
+
+var _ = Describe("Params", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("with array and hash query params", func() {
+		BeforeEach(func() {
+			Resource("bottle", func() {
+				Action("list", func() {
+					Routing(GET(""))
+					Params(func() {
+						Param("tag", ArrayOf(String))
+						Param("filter", HashOf(String, Integer))
+					})
+				})
+			})
+		})
+
+		It("accepts them", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("with an array of arrays", func() {
+		BeforeEach(func() {
+			Resource("bottle", func() {
+				Action("list", func() {
+					Routing(GET(""))
+					Params(func() {
+						Param("tag", ArrayOf(ArrayOf(String)))
+					})
+				})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("elements of array parameter tag must be of a primitive type"))
+		})
+	})
+
+	Context("with a hash with invalid keys or values", func() {
+		BeforeEach(func() {
+			Resource("bottle", func() {
+				Action("list", func() {
+					Routing(GET(""))
+					Params(func() {
+						Param("filter", HashOf(Integer, Boolean))
+					})
+				})
+			})
+		})
+
+		It("produces errors", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("keys of hash parameter filter must be strings"))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("values of hash parameter filter must be strings or integers"))
+		})
+	})
+
+	Context("with a hash path param", func() {
+		BeforeEach(func() {
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/:filter"))
+					Params(func() {
+						Param("filter", HashOf(String, String))
+					})
+				})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("path parameter filter cannot be a hash"))
+		})
+	})
+})
@@ -73,6 +73,7 @@ func API(name string, dsl func()) *design.APIDefinition {
 	}
 	design.Design.Name = name
 	design.Design.DSLFunc = dsl
+	design.Design.RecordLocation()
 	return design.Design
 }
 
@@ -81,8 +82,9 @@ func API(name string, dsl func()) *design.APIDefinition {
 // the API function.
 func Version(ver string, dsl func()) *design.APIVersionDefinition {
 	verdef := &design.APIVersionDefinition{Version: ver, DSLFunc: dsl}
-	if _, ok := design.Design.APIVersions[ver]; ok {
-		dslengine.ReportError("API Version %s defined twice", ver)
+	verdef.RecordLocation()
+	if first, ok := design.Design.APIVersions[ver]; ok {
+		dslengine.ReportDuplicate(fmt.Sprintf("API version %s", ver), first)
 		return verdef
 	}
 	if design.Design.APIVersions == nil {
@@ -96,7 +98,7 @@ func Version(ver string, dsl func()) *design.APIVersionDefinition {
 }
 
 // Description sets the definition description.
-// Description can be called inside API, Resource, Action or MediaType.
+// Description can be called inside API, Resource, Action, MediaType, Event or a security scheme.
 func Description(d string) {
 	if a, ok := apiDefinition(false); ok {
 		a.Description = d
@@ -112,6 +114,12 @@ func Description(d string) {
 		a.Description = d
 	} else if r, ok := responseDefinition(false); ok {
 		r.Description = d
+	} else if e, ok := eventDefinition(false); ok {
+		e.Description = d
+	} else if s, ok := securitySchemeDefinition(false); ok {
+		s.Description = d
+	} else if c, ok := callbackDefinition(false); ok {
+		c.Description = d
 	} else if do, ok := docsDefinition(true); ok {
 		do.Description = d
 	}
@@ -142,6 +150,10 @@ func BasePath(val string) {
 // BaseParams defines the API base path parameters. These parameters may correspond to wildcards in
 // the BasePath or URL query string values.
 // The DSL for describing each Param is the Attribute DSL.
+//
+// The href factories generated for resources whose canonical path includes API base path wildcards
+// (e.g. "/:tenant") come with a variant that reads the wildcard values from the request context, see
+// goa.WithBaseParams.
 func BaseParams(dsl func()) {
 	params := new(design.AttributeDefinition)
 	if !dslengine.Execute(dsl, params) {
@@ -185,12 +197,17 @@ func Host(host string) {
 	}
 }
 
-// Scheme sets the API URL schemes.
+// Scheme sets the API URL schemes. In addition to the "http", "https", "ws" and "wss" URL schemes
+// the "h2c" and "h3" schemes indicate that the API is also served using HTTP/2 without TLS and
+// HTTP/3 over QUIC respectively. The main function generated by goagen starts the corresponding
+// listeners, generated clients and specs use "http" and "https" in their place.
 func Scheme(vals ...string) {
 	ok := true
 	for _, v := range vals {
-		if v != "http" && v != "https" && v != "ws" && v != "wss" {
-			dslengine.ReportError(`invalid scheme "%s", must be one of "http", "https", "ws" or "wss"`, v)
+		switch v {
+		case "http", "https", "ws", "wss", "h2c", "h3":
+		default:
+			dslengine.ReportError(`invalid scheme "%s", must be one of "http", "https", "ws", "wss", "h2c" or "h3"`, v)
 			ok = false
 		}
 	}
@@ -297,6 +314,17 @@ func Consumes(args ...interface{}) {
 // Produces may also specify the path of the encoding package.
 // The package must expose a EncoderFactory method that returns an object which implements
 // goa.EncoderFactory.
+//
+// Produces also accepts the wildcard media type "*/*" for APIs that proxy or store arbitrary
+// content. The other MIME types given together with the wildcard list the possible content types
+// and do not require an encoder. Responses that use the wildcard media type generate helpers that
+// accept the Content-Type and a reader for the raw body:
+//
+//	Produces("*/*", "image/png", "image/jpeg")
+//
+//	Response(OK, func() {
+//		Media("*/*")
+//	})
 func Produces(args ...interface{}) {
 	var v *design.APIVersionDefinition
 	if a, ok := apiDefinition(false); ok {
@@ -355,10 +383,10 @@ func Package(path string) {
 // ResponseTemplate defines a response template that action definitions can use to describe their
 // responses. The template may specify the HTTP response status, header specification and body media
 // type. The template consists of a name and an anonymous function. The function is called when an
-// action uses the template to define a response. Response template functions accept string
-// parameters they can use to define the response fields. Here is an example of a response template
-// definition that uses a function with one argument corresponding to the name of the response body
-// media type:
+// action uses the template to define a response. Response template functions accept parameters
+// they can use to define the response fields, the parameters may be of type string, int, bool,
+// []string or map[string]string. Here is an example of a response template definition that uses a
+// function with one argument corresponding to the name of the response body media type:
 //
 //	ResponseTemplate(OK, func(mt string) {
 //		Status(200)				// OK response uses status code 200
@@ -375,6 +403,25 @@ func Package(path string) {
 //
 //	Response(OK, "vnd.goa.example")
 //
+// Typed parameters make it possible to define templates such as:
+//
+//	ResponseTemplate("Error", func(status int, mt string, headers map[string]string) {
+//		Status(status)
+//		Media(mt)
+//		Headers(func() {
+//			for name, desc := range headers {
+//				Header(name, String, desc)
+//			}
+//		})
+//	})
+//
+// which actions then use with:
+//
+//	Response("Error", 422, "application/vnd.goa.error", map[string]string{"X-Error-Id": "Error ID"})
+//
+// The types of the arguments given to the template are checked against the template parameters.
+// Templates may also be built on top of other templates or responses, see UseResponseTemplate.
+//
 // goa comes with a set of predefined response templates (one per standard HTTP status code). The
 // OK template is the only one that accepts an argument. It is used as shown in the example above to
 // set the response media type. Other predefined templates do not use arguments. ResponseTemplate
@@ -413,54 +460,84 @@ func setupResponseTemplate(v *design.APIVersionDefinition, name string, p interf
 		if dslengine.Execute(f, r) {
 			v.Responses[name] = r
 		}
-	} else if tmpl, ok := p.(func(...string)); ok {
-		t := func(params ...string) *design.ResponseDefinition {
-			r := &design.ResponseDefinition{Name: name}
-			dslengine.Execute(func() { tmpl(params...) }, r)
-			return r
-		}
-		v.ResponseTemplates[name] = &design.ResponseTemplateDefinition{
-			Name:     name,
-			Template: t,
+		return
+	}
+	typ := reflect.TypeOf(p)
+	if typ == nil || typ.Kind() != reflect.Func {
+		dslengine.ReportError("dsl of response template %s must be a function but got %#v", name, p)
+		return
+	}
+	num := typ.NumIn()
+	variadic := typ.IsVariadic()
+	paramType := func(i int) reflect.Type {
+		if variadic && i >= num-1 {
+			return typ.In(num - 1).Elem()
 		}
-	} else {
-		typ := reflect.TypeOf(p)
-		if kind := typ.Kind(); kind != reflect.Func {
-			dslengine.ReportError("dsl must be a function but got %s", kind)
+		return typ.In(i)
+	}
+	for i := 0; i < num; i++ {
+		if t := paramType(i); !isTemplateParamType(t) {
+			dslengine.ReportError("parameter at position %d of response template %s has unsupported type %s, must be one of string, int, bool, []string or map[string]string", i, name, t)
 			return
 		}
+	}
+	required := num
+	if variadic {
+		required--
+	}
 
-		num := typ.NumIn()
-		val := reflect.ValueOf(p)
-		t := func(params ...string) *design.ResponseDefinition {
-			if len(params) < num {
-				args := "1 argument"
-				if num > 0 {
-					args = fmt.Sprintf("%d arguments", num)
-				}
-				dslengine.ReportError("expected at least %s when invoking response template %s", args, name)
+	val := reflect.ValueOf(p)
+	var running bool
+	t := func(params ...interface{}) *design.ResponseDefinition {
+		if running {
+			dslengine.ReportError("response template %s cannot use itself", name)
+			return nil
+		}
+		if len(params) < required || !variadic && len(params) > num {
+			dslengine.ReportError("response template %s expects %s but got %d", name, plural(required, "argument"), len(params))
+			return nil
+		}
+		in := make([]reflect.Value, len(params))
+		for i, param := range params {
+			pt := paramType(i)
+			pv := reflect.ValueOf(param)
+			if !pv.IsValid() || !pv.Type().AssignableTo(pt) {
+				dslengine.ReportError("argument at position %d of response template %s must be a %s but got %#v", i, name, pt, param)
 				return nil
 			}
-			r := &design.ResponseDefinition{Name: name}
-
-			in := make([]reflect.Value, num)
-			for i := 0; i < num; i++ {
-				// type checking
-				if t := typ.In(i); t.Kind() != reflect.String {
-					dslengine.ReportError("ResponseTemplate parameters must be strings but type of parameter at position %d is %s", i, t)
-					return nil
-				}
-				// append input arguments
-				in[i] = reflect.ValueOf(params[i])
-			}
-			dslengine.Execute(func() { val.Call(in) }, r)
-			return r
-		}
-		v.ResponseTemplates[name] = &design.ResponseTemplateDefinition{
-			Name:     name,
-			Template: t,
+			in[i] = pv
 		}
+		r := &design.ResponseDefinition{Name: name}
+		running = true
+		defer func() { running = false }()
+		dslengine.Execute(func() { val.Call(in) }, r)
+		return r
+	}
+	v.ResponseTemplates[name] = &design.ResponseTemplateDefinition{
+		Name:     name,
+		Template: t,
+	}
+}
+
+// isTemplateParamType returns true if t can be used as the type of a response template parameter.
+func isTemplateParamType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Int, reflect.Bool:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String
 	}
+	return false
+}
+
+// plural returns the number followed by the given noun, pluralized if needed.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
 }
 
 // Title sets the API title used by generated documentation, JSON Hyper-schema, code comments etc.
@@ -632,6 +709,186 @@ func actionDefinition(failIfNotAction bool) (*design.ActionDefinition, bool) {
 	return a, ok
 }
 
+// webSocketDefinition returns true and current context if it is a WebSocketDefinition,
+// nil and false otherwise.
+func webSocketDefinition(failIfNotWebSocket bool) (*design.WebSocketDefinition, bool) {
+	w, ok := dslengine.CurrentDefinition().(*design.WebSocketDefinition)
+	if !ok && failIfNotWebSocket {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return w, ok
+}
+
+// proxyDefinition returns true and current context if it is a ProxyDefinition,
+// nil and false otherwise.
+func proxyDefinition(failIfNotProxy bool) (*design.ProxyDefinition, bool) {
+	p, ok := dslengine.CurrentDefinition().(*design.ProxyDefinition)
+	if !ok && failIfNotProxy {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return p, ok
+}
+
+// longPollDefinition returns true and current context if it is a LongPollDefinition,
+// nil and false otherwise.
+func longPollDefinition(failIfNotLongPoll bool) (*design.LongPollDefinition, bool) {
+	l, ok := dslengine.CurrentDefinition().(*design.LongPollDefinition)
+	if !ok && failIfNotLongPoll {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return l, ok
+}
+
+// callbackDefinition returns true and current context if it is a CallbackDefinition,
+// nil and false otherwise.
+func callbackDefinition(failIfNotCallback bool) (*design.CallbackDefinition, bool) {
+	c, ok := dslengine.CurrentDefinition().(*design.CallbackDefinition)
+	if !ok && failIfNotCallback {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return c, ok
+}
+
+// webhookDefinition returns true and current context if it is a WebhookDefinition,
+// nil and false otherwise.
+func webhookDefinition(failIfNotWebhook bool) (*design.WebhookDefinition, bool) {
+	w, ok := dslengine.CurrentDefinition().(*design.WebhookDefinition)
+	if !ok && failIfNotWebhook {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return w, ok
+}
+
+// asyncDefinition returns true and current context if it is an AsyncDefinition,
+// nil and false otherwise.
+func asyncDefinition(failIfNotAsync bool) (*design.AsyncDefinition, bool) {
+	a, ok := dslengine.CurrentDefinition().(*design.AsyncDefinition)
+	if !ok && failIfNotAsync {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return a, ok
+}
+
+// auditDefinition returns true and current context if it is an AuditDefinition,
+// nil and false otherwise.
+func auditDefinition(failIfNotAudit bool) (*design.AuditDefinition, bool) {
+	a, ok := dslengine.CurrentDefinition().(*design.AuditDefinition)
+	if !ok && failIfNotAudit {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return a, ok
+}
+
+// replayDefinition returns true and current context if it is a ReplayDefinition,
+// nil and false otherwise.
+func replayDefinition(failIfNotReplay bool) (*design.ReplayDefinition, bool) {
+	r, ok := dslengine.CurrentDefinition().(*design.ReplayDefinition)
+	if !ok && failIfNotReplay {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return r, ok
+}
+
+// quotaDefinition returns true and current context if it is a QuotaDefinition,
+// nil and false otherwise.
+func quotaDefinition(failIfNotQuota bool) (*design.QuotaDefinition, bool) {
+	q, ok := dslengine.CurrentDefinition().(*design.QuotaDefinition)
+	if !ok && failIfNotQuota {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return q, ok
+}
+
+// networkACLDefinition returns true and current context if it is a NetworkACLDefinition,
+// nil and false otherwise.
+func networkACLDefinition(failIfNotNetworkACL bool) (*design.NetworkACLDefinition, bool) {
+	n, ok := dslengine.CurrentDefinition().(*design.NetworkACLDefinition)
+	if !ok && failIfNotNetworkACL {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return n, ok
+}
+
+// rateLimitDefinition returns true and current context if it is a RateLimitDefinition,
+// nil and false otherwise.
+func rateLimitDefinition(failIfNotRateLimit bool) (*design.RateLimitDefinition, bool) {
+	rl, ok := dslengine.CurrentDefinition().(*design.RateLimitDefinition)
+	if !ok && failIfNotRateLimit {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return rl, ok
+}
+
+// sessionDefinition returns true and current context if it is a SessionDefinition,
+// nil and false otherwise.
+func sessionDefinition(failIfNotSession bool) (*design.SessionDefinition, bool) {
+	s, ok := dslengine.CurrentDefinition().(*design.SessionDefinition)
+	if !ok && failIfNotSession {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return s, ok
+}
+
+// cacheControlDefinition returns true and current context if it is a CacheControlDefinition,
+// nil and false otherwise.
+func cacheControlDefinition(failIfNotCacheControl bool) (*design.CacheControlDefinition, bool) {
+	cc, ok := dslengine.CurrentDefinition().(*design.CacheControlDefinition)
+	if !ok && failIfNotCacheControl {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return cc, ok
+}
+
+// batchDefinition returns true and current context if it is a BatchDefinition,
+// nil and false otherwise.
+func batchDefinition(failIfNotBatch bool) (*design.BatchDefinition, bool) {
+	b, ok := dslengine.CurrentDefinition().(*design.BatchDefinition)
+	if !ok && failIfNotBatch {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return b, ok
+}
+
+// eventDefinition returns true and current context if it is an EventDefinition,
+// nil and false otherwise.
+func eventDefinition(failIfNotEvent bool) (*design.EventDefinition, bool) {
+	e, ok := dslengine.CurrentDefinition().(*design.EventDefinition)
+	if !ok && failIfNotEvent {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return e, ok
+}
+
+// securitySchemeDefinition returns true and current context if it is a
+// SecuritySchemeDefinition, nil and false otherwise.
+func securitySchemeDefinition(failIfNotScheme bool) (*design.SecuritySchemeDefinition, bool) {
+	s, ok := dslengine.CurrentDefinition().(*design.SecuritySchemeDefinition)
+	if !ok && failIfNotScheme {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return s, ok
+}
+
+// securityDefinition returns true and current context if it is a SecurityDefinition,
+// nil and false otherwise.
+func securityDefinition(failIfNotSecurity bool) (*design.SecurityDefinition, bool) {
+	s, ok := dslengine.CurrentDefinition().(*design.SecurityDefinition)
+	if !ok && failIfNotSecurity {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return s, ok
+}
+
+// mqttTopicDefinition returns true and current context if it is a MQTTTopicDefinition,
+// nil and false otherwise.
+func mqttTopicDefinition(failIfNotMQTTTopic bool) (*design.MQTTTopicDefinition, bool) {
+	t, ok := dslengine.CurrentDefinition().(*design.MQTTTopicDefinition)
+	if !ok && failIfNotMQTTTopic {
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+	return t, ok
+}
+
 // responseDefinition returns true and current context if it is a ResponseDefinition,
 // nil and false otherwise.
 func responseDefinition(failIfNotResponse bool) (*design.ResponseDefinition, bool) {
@@ -0,0 +1,77 @@
+package apidsl
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Async makes the action start a long-running operation: the action responds immediately with
+// 202 Accepted, the Location header of the response is the href of the operation and the body
+// describes the operation using the built-in OperationMedia media type. Clients poll the operation
+// href to get its status and result and may cancel it with a DELETE request. The optional DSL sets
+// the maximum duration of the operations and whether they can be canceled:
+//
+//	Action("export", func() {
+//		Routing(POST("/exports"))
+//		Async(func() {
+//			OperationTimeout(10 * time.Minute)
+//			NotCancelable()
+//		})
+//	})
+//
+// The operations endpoints are exposed under "/operations" by default, see Operations. The
+// generated context of async actions exposes a Start method that runs a job with a
+// goa/async.Executor and writes the Accepted response.
+func Async(dsl ...func()) {
+	a, ok := actionDefinition(true)
+	if !ok {
+		return
+	}
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to Async")
+		return
+	}
+	async := &design.AsyncDefinition{Cancelable: true, Parent: a}
+	if len(dsl) == 1 {
+		if !dslengine.Execute(dsl[0], async) {
+			return
+		}
+	}
+	a.Async = async
+	design.Design.UseOperationMedia()
+	if design.Design.Operations == nil {
+		design.Design.Operations = &design.OperationsDefinition{
+			Path:   design.DefaultOperationsPath,
+			Parent: design.Design,
+		}
+	}
+}
+
+// OperationTimeout sets the maximum duration of the operations started by the async action, the
+// operations that run longer are canceled and fail.
+// OperationTimeout may only appear in Async.
+func OperationTimeout(d time.Duration) {
+	if a, ok := asyncDefinition(true); ok {
+		a.Timeout = d
+	}
+}
+
+// NotCancelable prevents clients from canceling the operations started by the async action.
+// NotCancelable may only appear in Async.
+func NotCancelable() {
+	if a, ok := asyncDefinition(true); ok {
+		a.Cancelable = false
+	}
+}
+
+// Operations sets the path of the operations endpoints relative to the API base path, the default
+// is "/operations". GET requests made to "<path>/:id" return the status of the operation with the
+// given ID, DELETE requests cancel it.
+// Operations may only appear in API.
+func Operations(path string) {
+	if a, ok := apiDefinition(true); ok {
+		a.Operations = &design.OperationsDefinition{Path: path, Parent: a}
+	}
+}
@@ -0,0 +1,98 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Async", func() {
+	var dsl func()
+	var apiDSL func()
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		dsl = func() {
+			OperationTimeout(time.Minute)
+		}
+		apiDSL = nil
+	})
+
+	JustBeforeEach(func() {
+		API("test", func() {
+			BasePath("/api")
+			if apiDSL != nil {
+				apiDSL()
+			}
+		})
+		Resource("report", func() {
+			Action("create", func() {
+				Routing(POST("/reports"))
+				Async(dsl)
+			})
+		})
+		dslengine.Run()
+		if r, ok := Design.Resources["report"]; ok {
+			action = r.Actions["create"]
+		}
+	})
+
+	It("adds the Accepted response and the operations endpoints", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(action.IsAsync()).Should(BeTrue())
+		Ω(action.Async.Cancelable).Should(BeTrue())
+		Ω(action.Async.Timeout).Should(Equal(time.Minute))
+		Ω(action.Responses).Should(HaveKey("Accepted"))
+		resp := action.Responses["Accepted"]
+		Ω(resp.Status).Should(Equal(202))
+		Ω(resp.MediaType).Should(Equal(OperationMediaIdentifier))
+		Ω(resp.Headers.Type.ToObject()).Should(HaveKey("Location"))
+		Ω(Design.MediaTypeWithIdentifier(OperationMediaIdentifier)).Should(Equal(OperationMedia))
+		Ω(Design.Operations).ShouldNot(BeNil())
+		Ω(Design.Operations.FullPath()).Should(Equal("/api/operations"))
+	})
+
+	Context("with NotCancelable", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				NotCancelable()
+			}
+		})
+
+		It("makes the operations not cancelable", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action.Async.Cancelable).Should(BeFalse())
+		})
+	})
+
+	Context("with a custom operations path", func() {
+		BeforeEach(func() {
+			apiDSL = func() {
+				Operations("/jobs")
+			}
+		})
+
+		It("uses the path", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.Operations.FullPath()).Should(Equal("/api/jobs"))
+		})
+	})
+
+	Context("with a negative timeout", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				OperationTimeout(-time.Second)
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
@@ -193,8 +193,13 @@ func parseAttributeArgs(baseAttr *design.AttributeDefinition, args ...interface{
 	return dataType, description, dsl
 }
 
-// Header is an alias of Attribute.
+// Header is an alias of Attribute. When used in JWTSecurity, OIDCSecurity, APIKeySecurity or
+// HMACSecurity Header sets the name of the header holding the credentials.
 func Header(name string, args ...interface{}) {
+	if s, ok := securitySchemeDefinition(false); ok {
+		setCredentialsLocation(s, "header", name, args)
+		return
+	}
 	Attribute(name, args...)
 }
 
@@ -220,6 +225,75 @@ func Default(def interface{}) {
 	}
 }
 
+// VisibleTo restricts the visibility of the attribute in the responses to the principals granted
+// at least one of the given roles:
+//
+//	var Bottle = MediaType("application/vnd.bottle+json", func() {
+//		Attributes(func() {
+//			Attribute("name", String)
+//			Attribute("price", Number, func() {
+//				VisibleTo("admin", "sommelier")
+//			})
+//		})
+//	})
+//
+// The generated response helpers omit the restricted fields that are not visible to the roles
+// returned by goa.ContextRoles. Restricted attributes cannot be required.
+// VisibleTo may only appear in the attributes of types and media types.
+func VisibleTo(roles ...string) {
+	if len(roles) == 0 {
+		dslengine.ReportError("VisibleTo requires at least one role")
+		return
+	}
+	if a, ok := attributeDefinition(true); ok {
+		if a.Metadata == nil {
+			a.Metadata = make(dslengine.MetadataDefinition)
+		}
+		a.Metadata[design.VisibleToKey] = append(a.Metadata[design.VisibleToKey], roles...)
+	}
+}
+
+// PII tags the attribute as holding personally identifiable information of the given categories,
+// e.g. "contact", "identity", "financial", "health" or "location":
+//
+//	var User = Type("User", func() {
+//		Attribute("email", String, func() {
+//			PII("contact")
+//		})
+//	})
+//
+// The values of the tagged parameters and top level payload attributes are redacted from the
+// request logs. The gdpr generator reports which actions accept and expose which PII and produces
+// the data subject export and erasure endpoints of the resources that hold PII.
+// PII may appear in the attributes of types, media types, parameters, headers and payloads.
+func PII(categories ...string) {
+	if len(categories) == 0 {
+		dslengine.ReportError("PII requires at least one category")
+		return
+	}
+	if a, ok := attributeDefinition(true); ok {
+		if a.Metadata == nil {
+			a.Metadata = make(dslengine.MetadataDefinition)
+		}
+		a.Metadata[design.PIIKey] = append(a.Metadata[design.PIIKey], categories...)
+	}
+}
+
+// Sensitive marks the attribute as holding secret values such as passwords or tokens. The values
+// of the sensitive parameters, headers and top level payload attributes are redacted from the
+// request logs and audit events and the validation errors do not echo the values of sensitive
+// attributes. The swagger generator sets the "x-sensitive" extension on the corresponding
+// properties.
+// Sensitive may appear in the attributes of types, media types, parameters, headers and payloads.
+func Sensitive() {
+	if a, ok := attributeDefinition(true); ok {
+		if a.Metadata == nil {
+			a.Metadata = make(dslengine.MetadataDefinition)
+		}
+		a.Metadata[design.SensitiveKey] = nil
+	}
+}
+
 // Example sets the example of an attribute to be used for the documentation.
 func Example(exp interface{}) {
 	if a, ok := attributeDefinition(true); ok {
@@ -240,6 +314,9 @@ func NoExample() {
 
 // Enum adds a "enum" validation to the attribute.
 // See http://json-schema.org/latest/json-schema-validation.html#anchor76.
+// The code generated for the attributes of user types and media types includes a constant for each
+// enum value (e.g. BottleRatingGood for the value "good" of the "rating" attribute of the Bottle
+// type) and a function that validates values against them (e.g. IsValidBottleRating).
 func Enum(val ...interface{}) {
 	if a, ok := attributeDefinition(true); ok {
 		ok := true
@@ -287,8 +364,12 @@ var SupportedValidationFormats = []string{
 	"ipv4",
 	"ipv6",
 	"mac",
+	"regex",
 	"regexp",
+	"rfc1123",
 	"uri",
+	"uri-template",
+	"uuid",
 }
 
 // Format adds a "format" validation to the attribute.
@@ -309,7 +390,13 @@ var SupportedValidationFormats = []string{
 //
 // "cidr": RFC4632 or RFC4291 CIDR notation IP address
 //
-// "regexp": RE2 regular expression
+// "regexp" and "regex": RE2 regular expression
+//
+// "uuid": RFC4122 UUID
+//
+// "uri-template": RFC6570 URI template
+//
+// "rfc1123": RFC1123 date time
 func Format(f string) {
 	if a, ok := attributeDefinition(true); ok {
 		if a.Type != nil && a.Type.Kind() != design.StringKind {
@@ -466,6 +553,39 @@ func Required(names ...string) {
 	}
 }
 
+// Validate adds a custom validation to the attribute. The argument is the fully qualified name of
+// a function that the generated Validate methods and context constructors call after the
+// built-in validations succeed or fail, it makes it possible to define cross-field and business
+// rules next to the design rather than in the controllers:
+//
+//	var Bottle = Type("Bottle", func() {
+//		Attribute("vintage", Integer)
+//		Attribute("release", DateTime)
+//		Validate("github.com/acme/cellar/rules.ValidBottle")
+//	})
+//
+// The name of the package defining the function must be the last element of its import path.
+// The function accepts the value of the attribute using the generated Go type (a pointer to the
+// generated struct for objects) and returns a non-nil error if the value is invalid, e.g.:
+//
+//	func ValidBottle(b *app.Bottle) error
+//
+// Validate may appear multiple times in the same attribute, the functions are called in order.
+func Validate(fn string) {
+	var at *design.AttributeDefinition
+	if a, ok := attributeDefinition(false); ok {
+		at = a
+	} else if mt, ok := mediaTypeDefinition(true); ok {
+		at = mt.AttributeDefinition
+	} else {
+		return
+	}
+	if at.Validation == nil {
+		at.Validation = &dslengine.ValidationDefinition{}
+	}
+	at.Validation.AddValidators([]string{fn})
+}
+
 // incompatibleAttributeType reports an error for validations defined on
 // incompatible attributes (e.g. max value on string).
 func incompatibleAttributeType(validation, actual, expected string) {
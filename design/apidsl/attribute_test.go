@@ -61,6 +61,7 @@ var _ = Describe("Attribute", func() {
 	var dsl func()
 
 	var parent *AttributeDefinition
+	var runErr error
 
 	BeforeEach(func() {
 		InitDesign()
@@ -88,7 +89,7 @@ var _ = Describe("Attribute", func() {
 				Attribute(name, dataType, description, dsl)
 			}
 		})
-		dslengine.Run()
+		runErr = dslengine.Run()
 		if t, ok := Design.Types["type"]; ok {
 			parent = t.AttributeDefinition
 		}
@@ -201,6 +202,38 @@ var _ = Describe("Attribute", func() {
 		})
 	})
 
+	Context("with a name and a DSL defining custom validations", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Validate("github.com/acme/rules.ValidFoo")
+				Validate("github.com/acme/rules.ValidBar")
+			}
+		})
+
+		It("records the validation functions in order", func() {
+			o := parent.Type.(Object)
+			Ω(o).Should(HaveKey(name))
+			Ω(o[name].Validation).ShouldNot(BeNil())
+			Ω(o[name].Validation.Validators).Should(Equal([]string{
+				"github.com/acme/rules.ValidFoo",
+				"github.com/acme/rules.ValidBar",
+			}))
+		})
+	})
+
+	Context("with a name and a DSL defining a custom validation with an unqualified name", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() { Validate("validFoo") }
+		})
+
+		It("produces an invalid attribute", func() {
+			Ω(runErr).Should(HaveOccurred())
+			Ω(runErr.Error()).Should(ContainSubstring("invalid validation function name"))
+		})
+	})
+
 	Context("with a name, type integer, a description and a DSL defining an enum validation", func() {
 		BeforeEach(func() {
 			name = "foo"
@@ -311,3 +344,168 @@ var _ = Describe("Attribute", func() {
 		})
 	})
 })
+
+var _ = Describe("VisibleTo", func() {
+	var dsl func()
+	var ut *UserTypeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		dsl = func() {
+			Attribute("name", String)
+			Attribute("salary", Integer, func() {
+				VisibleTo("admin", "hr")
+			})
+		}
+	})
+
+	JustBeforeEach(func() {
+		ut = Type("Employee", dsl)
+		dslengine.Run()
+	})
+
+	It("records the roles", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(ut.ToObject()["salary"].VisibleTo()).Should(Equal([]string{"admin", "hr"}))
+		Ω(ut.ToObject()["name"].VisibleTo()).Should(BeEmpty())
+		Ω(ut.HasRestrictedFields()).Should(BeTrue())
+		Ω(ut.VisibilityRoles()).Should(Equal([]string{"admin", "hr"}))
+	})
+
+	Context("on a required attribute", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Attribute("salary", Integer, func() {
+					VisibleTo("admin")
+				})
+				Required("salary")
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("restricted with VisibleTo"))
+		})
+	})
+
+	Context("with no role", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Attribute("salary", Integer, func() {
+					VisibleTo()
+				})
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("PII", func() {
+	var dsl func()
+	var ut *UserTypeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		address := Type("Address", func() {
+			Attribute("street", String, func() {
+				PII("location", "contact")
+			})
+		})
+		dsl = func() {
+			Attribute("name", String)
+			Attribute("email", String, func() {
+				PII("contact")
+			})
+			Attribute("addresses", ArrayOf(address))
+		}
+	})
+
+	JustBeforeEach(func() {
+		ut = Type("User", dsl)
+		dslengine.Run()
+	})
+
+	It("records the categories", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(ut.ToObject()["email"].PIICategories()).Should(Equal([]string{"contact"}))
+		Ω(ut.ToObject()["name"].PIICategories()).Should(BeEmpty())
+		fields := ut.PIIFields()
+		Ω(fields).Should(HaveLen(2))
+		Ω(*fields[0]).Should(Equal(PIIField{Path: "addresses[].street", Categories: []string{"location", "contact"}}))
+		Ω(*fields[1]).Should(Equal(PIIField{Path: "email", Categories: []string{"contact"}}))
+	})
+
+	Context("with no category", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Attribute("email", String, func() {
+					PII()
+				})
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Encrypted and Sensitive", func() {
+	var dsl func()
+	var ut *UserTypeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		card := Type("Card", func() {
+			Attribute("number", String, func() {
+				Encrypted()
+			})
+		})
+		dsl = func() {
+			Attribute("name", String)
+			Attribute("password", String, func() {
+				Sensitive()
+			})
+			Attribute("card", card)
+		}
+	})
+
+	JustBeforeEach(func() {
+		ut = Type("Account", dsl)
+		dslengine.Run()
+	})
+
+	It("marks the attributes", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		obj := ut.ToObject()
+		Ω(obj["name"].IsSensitive()).Should(BeFalse())
+		Ω(obj["password"].IsSensitive()).Should(BeTrue())
+		Ω(obj["password"].IsEncrypted()).Should(BeFalse())
+		number := obj["card"].Type.ToObject()["number"]
+		Ω(number.IsEncrypted()).Should(BeTrue())
+		Ω(number.IsSensitive()).Should(BeTrue())
+		Ω(ut.HasEncryptedFields()).Should(BeTrue())
+		Ω(ut.HasSensitiveFields()).Should(BeTrue())
+	})
+
+	Context("with an encrypted attribute that is not a string", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Attribute("pin", Integer, func() {
+					Encrypted()
+				})
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`field "pin" is encrypted and thus must be a string`))
+		})
+	})
+})
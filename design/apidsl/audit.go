@@ -0,0 +1,72 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Audit marks the actions of the API, resource or action where it appears for audit. The
+// generated code records an audit event for each request made to an audited action with the
+// request principal, the resource and action names, the request parameters and payload and the
+// outcome of the request. Action audits override resource audits which override the API audit.
+// The optional DSL lists the parameters and payload attributes whose values are masked in the
+// audit events:
+//
+//	Action("update", func() {
+//		Audit(func() {
+//			Redact("password", "card_number")
+//		})
+//		...
+//	})
+//
+// The events are delivered to the auditor set on the service with SetAuditor, see goa.Auditor.
+// Audit may appear in API, Resource or Action.
+func Audit(dsl ...func()) {
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to Audit")
+		return
+	}
+	var parent dslengine.Definition
+	a, isAPI := apiDefinition(false)
+	r, isResource := resourceDefinition(false)
+	var act *design.ActionDefinition
+	switch {
+	case isAPI:
+		parent = a
+	case isResource:
+		parent = r
+	default:
+		var ok bool
+		if act, ok = actionDefinition(true); !ok {
+			return
+		}
+		parent = act
+	}
+	audit := &design.AuditDefinition{Parent: parent}
+	if len(dsl) == 1 {
+		if !dslengine.Execute(dsl[0], audit) {
+			return
+		}
+	}
+	switch {
+	case isAPI:
+		a.Audit = audit
+	case isResource:
+		r.Audit = audit
+	default:
+		act.Audit = audit
+	}
+}
+
+// Redact lists the names of the parameters and top level payload attributes whose values are
+// masked in the audit events.
+// Redact may only appear in Audit.
+func Redact(names ...string) {
+	if len(names) == 0 {
+		dslengine.ReportError("Redact requires at least one name")
+		return
+	}
+	if a, ok := auditDefinition(true); ok {
+		a.Redact = append(a.Redact, names...)
+	}
+}
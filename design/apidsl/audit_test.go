@@ -0,0 +1,73 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("in Resource and Action", func() {
+		BeforeEach(func() {
+			Resource("account", func() {
+				Audit()
+				Action("show", func() {
+					Routing(GET("/:id"))
+				})
+				Action("update", func() {
+					Routing(PUT("/:id"))
+					Payload(func() {
+						Attribute("password", String)
+					})
+					Audit(func() {
+						Redact("password")
+					})
+				})
+			})
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/:id"))
+				})
+			})
+		})
+
+		It("sets the effective audits", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			account := Design.Resources["account"]
+			Ω(account.Actions["show"].EffectiveAudit()).Should(Equal(account.Audit))
+			audit := account.Actions["update"].EffectiveAudit()
+			Ω(audit).ShouldNot(BeNil())
+			Ω(audit.Redact).Should(Equal([]string{"password"}))
+			Ω(Design.Resources["bottle"].Actions["show"].EffectiveAudit()).Should(BeNil())
+		})
+	})
+
+	Context("with an unknown redacted name", func() {
+		BeforeEach(func() {
+			Resource("account", func() {
+				Action("show", func() {
+					Routing(GET("/:id"))
+					Audit(func() {
+						Redact("password")
+					})
+				})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`redacted name "password"`))
+		})
+	})
+})
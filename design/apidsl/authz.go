@@ -0,0 +1,34 @@
+package apidsl
+
+import "github.com/goadesign/goa/dslengine"
+
+// Authorize sets the name of the authorization policy enforced for the actions of the API,
+// resource or action where it appears. Action policies override resource policies which override
+// the API policy:
+//
+//	Resource("bottle", func() {
+//		Authorize("bottle:read")
+//		Action("delete", func() {
+//			Authorize("bottle:admin")
+//			...
+//		})
+//	})
+//
+// The generated code asks the authorizer set on the service with SetAuthorizer to decide whether
+// each request made to an action with a policy is allowed. The authorizer receives the policy
+// name, the request principal, the action context holding the decoded parameters and payload and
+// the action metadata. Requests that are denied are answered with a 403 response.
+// Authorize may appear in API, Resource or Action.
+func Authorize(policy string) {
+	if policy == "" {
+		dslengine.ReportError("policy name cannot be empty")
+		return
+	}
+	if a, ok := apiDefinition(false); ok {
+		a.Policy = policy
+	} else if r, ok := resourceDefinition(false); ok {
+		r.Policy = policy
+	} else if a, ok := actionDefinition(true); ok {
+		a.Policy = policy
+	}
+}
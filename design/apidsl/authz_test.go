@@ -0,0 +1,65 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Authorize", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("in API, Resource and Action", func() {
+		BeforeEach(func() {
+			API("cellar", func() {
+				Authorize("api")
+			})
+			Resource("bottle", func() {
+				Authorize("bottle:read")
+				Action("show", func() {
+					Routing(GET("/:id"))
+				})
+				Action("delete", func() {
+					Routing(DELETE("/:id"))
+					Authorize("bottle:admin")
+				})
+			})
+			Resource("account", func() {
+				Action("show", func() {
+					Routing(GET("/:id"))
+				})
+			})
+		})
+
+		It("sets the effective policies", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.Policy).Should(Equal("api"))
+			bottle := Design.Resources["bottle"]
+			Ω(bottle.Policy).Should(Equal("bottle:read"))
+			Ω(bottle.Actions["show"].EffectivePolicy()).Should(Equal("bottle:read"))
+			Ω(bottle.Actions["delete"].EffectivePolicy()).Should(Equal("bottle:admin"))
+			Ω(Design.Resources["account"].Actions["show"].EffectivePolicy()).Should(Equal("api"))
+		})
+	})
+
+	Context("with an empty policy name", func() {
+		BeforeEach(func() {
+			Resource("bottle", func() {
+				Authorize("")
+			})
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
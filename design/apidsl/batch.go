@@ -0,0 +1,58 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Batch defines an endpoint that accepts POST requests whose body is an array of sub-requests.
+// Each sub-request references an existing action by method and path and is dispatched through
+// the same routing, middleware and validation code as if it had been sent on its own. The
+// response lists the status, headers and body of each sub-request in order. The optional DSL
+// configures how sub-requests are dispatched:
+//
+//	API("cellar", func() {
+//		Batch("/batch", func() {
+//			Concurrent()		// Dispatch the sub-requests concurrently
+//			MaxRequests(20)		// Reject batches containing more than 20 sub-requests
+//		})
+//	})
+//
+// The path is relative to the API base path. The generated app package exposes a MountBatch
+// function that mounts the endpoint on the service.
+// Batch may only appear in API.
+func Batch(path string, dsl ...func()) {
+	a, ok := apiDefinition(true)
+	if !ok {
+		return
+	}
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to Batch")
+		return
+	}
+	b := &design.BatchDefinition{Path: path, Parent: a}
+	if len(dsl) == 1 {
+		if !dslengine.Execute(dsl[0], b) {
+			return
+		}
+	}
+	a.Batch = b
+}
+
+// Concurrent causes the sub-requests of a batch to be dispatched concurrently. Sub-requests are
+// dispatched sequentially in order by default.
+// Concurrent may only appear in Batch.
+func Concurrent() {
+	if b, ok := batchDefinition(true); ok {
+		b.Concurrent = true
+	}
+}
+
+// MaxRequests sets the maximum number of sub-requests in a batch. Larger batches are rejected
+// with a 400 response.
+// MaxRequests may only appear in Batch.
+func MaxRequests(n int) {
+	if b, ok := batchDefinition(true); ok {
+		b.MaxRequests = n
+	}
+}
@@ -0,0 +1,79 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Batch", func() {
+	var path string
+	var dsl func()
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		path = "/batch"
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		API("cellar", func() {
+			BasePath("/api")
+			if dsl == nil {
+				Batch(path)
+			} else {
+				Batch(path, dsl)
+			}
+		})
+		dslengine.Run()
+	})
+
+	It("produces a valid batch definition", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(Design.Validate()).ShouldNot(HaveOccurred())
+		Ω(Design.Batch).ShouldNot(BeNil())
+		Ω(Design.Batch.FullPath()).Should(Equal("/api/batch"))
+		Ω(Design.Batch.Concurrent).Should(BeFalse())
+		Ω(Design.Batch.MaxRequests).Should(Equal(0))
+	})
+
+	Context("with a DSL", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Concurrent()
+				MaxRequests(20)
+			}
+		})
+
+		It("sets the dispatch options", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.Batch.Concurrent).Should(BeTrue())
+			Ω(Design.Batch.MaxRequests).Should(Equal(20))
+		})
+	})
+
+	Context("with a relative path", func() {
+		BeforeEach(func() {
+			path = "batch"
+		})
+
+		It("produces an invalid definition", func() {
+			Ω(Design.Validate()).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a negative maximum number of requests", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				MaxRequests(-1)
+			}
+		})
+
+		It("produces an invalid definition", func() {
+			Ω(Design.Validate()).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,108 @@
+package apidsl
+
+import (
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// CacheControl defines the caching policy of the response. The generated response helpers set the
+// Cache-Control and Vary headers accordingly and the headers are documented with the response:
+//
+//	Response(OK, func() {
+//		Media(BottleMedia)
+//		CacheControl(func() {
+//			Public()
+//			MaxAge(time.Hour)
+//			SharedMaxAge(24 * time.Hour)
+//			Vary("Accept", "Accept-Language")
+//		})
+//	})
+//
+// CacheControl may only appear in Response.
+func CacheControl(dsl func()) {
+	r, ok := responseDefinition(true)
+	if !ok {
+		return
+	}
+	cc := &design.CacheControlDefinition{Parent: r}
+	if !dslengine.Execute(dsl, cc) {
+		return
+	}
+	r.CacheControl = cc
+	if r.Headers == nil {
+		r.Headers = &design.AttributeDefinition{Type: design.Object{}}
+	}
+	obj := r.Headers.Type.ToObject()
+	if obj == nil {
+		return
+	}
+	if v := cc.Value(); v != "" {
+		if _, ok := obj["Cache-Control"]; !ok {
+			obj["Cache-Control"] = &design.AttributeDefinition{
+				Type:        design.String,
+				Description: "Caching policy of the response: " + v,
+			}
+		}
+	}
+	if len(cc.Vary) > 0 {
+		if _, ok := obj["Vary"]; !ok {
+			obj["Vary"] = &design.AttributeDefinition{
+				Type:        design.String,
+				Description: "Request headers the response varies with: " + strings.Join(cc.Vary, ", "),
+			}
+		}
+	}
+}
+
+// SharedMaxAge sets the s-maxage directive, the duration for which shared caches such as proxies
+// and CDNs may use the response.
+// SharedMaxAge may only appear in CacheControl.
+func SharedMaxAge(d time.Duration) {
+	if cc, ok := cacheControlDefinition(true); ok {
+		cc.SharedMaxAge = d
+	}
+}
+
+// Public sets the public directive: shared caches may store the response even if the request is
+// authenticated.
+// Public may only appear in CacheControl.
+func Public() {
+	if cc, ok := cacheControlDefinition(true); ok {
+		cc.Public = true
+	}
+}
+
+// Private sets the private directive: only the client cache may store the response.
+// Private may only appear in CacheControl.
+func Private() {
+	if cc, ok := cacheControlDefinition(true); ok {
+		cc.Private = true
+	}
+}
+
+// NoCache sets the no-cache directive: caches must revalidate the response before using it.
+// NoCache may only appear in CacheControl.
+func NoCache() {
+	if cc, ok := cacheControlDefinition(true); ok {
+		cc.NoCache = true
+	}
+}
+
+// NoStore sets the no-store directive: caches must not store the response.
+// NoStore may only appear in CacheControl.
+func NoStore() {
+	if cc, ok := cacheControlDefinition(true); ok {
+		cc.NoStore = true
+	}
+}
+
+// Vary lists the request headers the response varies with.
+// Vary may only appear in CacheControl.
+func Vary(headers ...string) {
+	if cc, ok := cacheControlDefinition(true); ok {
+		cc.Vary = append(cc.Vary, headers...)
+	}
+}
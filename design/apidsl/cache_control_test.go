@@ -0,0 +1,75 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CacheControl", func() {
+	var dsl func()
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		Resource("bottles", func() {
+			Action("show", func() {
+				Routing(GET("/:id"))
+				Response(OK, func() {
+					CacheControl(dsl)
+				})
+			})
+		})
+		dslengine.Run()
+	})
+
+	Context("with a public policy", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Public()
+				MaxAge(time.Hour)
+				SharedMaxAge(24 * time.Hour)
+				Vary("Accept", "Accept-Language")
+			}
+		})
+
+		It("sets the policy and documents the headers", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			resp := Design.Resources["bottles"].Actions["show"].Responses["OK"]
+			cc := resp.CacheControl
+			Ω(cc).ShouldNot(BeNil())
+			Ω(cc.Value()).Should(Equal("public, max-age=3600, s-maxage=86400"))
+			Ω(cc.Vary).Should(Equal([]string{"Accept", "Accept-Language"}))
+			headers := resp.Headers.Type.ToObject()
+			Ω(headers).Should(HaveKey("Cache-Control"))
+			Ω(headers["Cache-Control"].Description).Should(ContainSubstring("public, max-age=3600, s-maxage=86400"))
+			Ω(headers).Should(HaveKey("Vary"))
+		})
+	})
+
+	Context("with inconsistent directives", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Public()
+				Private()
+				NoStore()
+				MaxAge(1500 * time.Millisecond)
+			}
+		})
+
+		It("produces errors", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("response cannot be both public and private"))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("max-age and s-maxage must be whole numbers of seconds"))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("max-age and s-maxage do not apply to responses that must not be stored"))
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package apidsl
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Callback defines an outbound webhook: a request the service sends to a URL registered by the
+// client each time the named event occurs. Callbacks are defined on the actions that register the
+// callback URLs. The callback DSL defines the media type of the request body, the signing scheme
+// and the delivery retry policy:
+//
+//	Action("subscribe", func() {
+//		Routing(POST("/subscriptions"))
+//		Payload(SubscriptionPayload)	// Contains the callback URL
+//		Callback("OrderShipped", func() {
+//			Description("Sent each time an order ships")
+//			Media(OrderShippedMedia)
+//			Signing("hmac-sha256", "X-Signature")	// Default is "hmac-sha256" with "X-Webhook-Signature"
+//			RetryPolicy(10, time.Second, time.Minute)	// Default is 5 attempts starting with 1s
+//		})
+//		Response(Created)
+//	})
+//
+// goagen generates a typed dispatcher for each callback, see goa.WebhookDispatcher. The callbacks
+// are documented in the swagger specification using the "x-callbacks" vendor extension of the
+// action operations.
+func Callback(event string, dsl func()) {
+	a, ok := actionDefinition(true)
+	if !ok {
+		return
+	}
+	if _, ok := a.Callbacks[event]; ok {
+		dslengine.ReportError("callback %#v is defined twice", event)
+		return
+	}
+	c := &design.CallbackDefinition{
+		Name:            event,
+		Method:          "POST",
+		SigningScheme:   design.SigningHMACSHA256,
+		SignatureHeader: design.DefaultSignatureHeader,
+		MaxAttempts:     design.DefaultCallbackAttempts,
+		Backoff:         design.DefaultCallbackBackoff,
+		Parent:          a,
+	}
+	c.RecordLocation()
+	if !dslengine.Execute(dsl, c) {
+		return
+	}
+	if a.Callbacks == nil {
+		a.Callbacks = make(map[string]*design.CallbackDefinition)
+	}
+	a.Callbacks[event] = c
+}
+
+// CallbackMethod sets the HTTP method of the callback requests, one of "POST" (default), "PUT"
+// or "PATCH".
+func CallbackMethod(method string) {
+	if c, ok := callbackDefinition(true); ok {
+		c.Method = method
+	}
+}
+
+// Signing sets the scheme used to sign the callback or webhook requests and optionally the name
+// of the header holding the signature. The scheme is one of "hmac-sha256" (default), "hmac-sha512",
+// "hmac-sha1" or "none" (callbacks only). The signature is the hex encoded HMAC of the request
+// body computed with the secret given to the dispatcher prefixed with the algorithm name, e.g.
+// "sha256=8d2b...".
+func Signing(scheme string, header ...string) {
+	if len(header) > 1 {
+		dslengine.ReportError("too many arguments given to Signing")
+		return
+	}
+	if c, ok := callbackDefinition(false); ok {
+		c.SigningScheme = scheme
+		if len(header) == 1 {
+			c.SignatureHeader = header[0]
+		}
+	} else if w, ok := webhookDefinition(true); ok {
+		w.SigningScheme = scheme
+		if len(header) == 1 {
+			w.SignatureHeader = header[0]
+		}
+	}
+}
+
+// RetryPolicy sets the maximum number of delivery attempts of the callback requests including the
+// first one, and optionally the delay before the first retry and the maximum delay between
+// retries. The delay doubles with each retry. Deliveries are retried on network errors and on
+// 408, 429 and 5xx responses.
+func RetryPolicy(attempts int, backoff ...time.Duration) {
+	c, ok := callbackDefinition(true)
+	if !ok {
+		return
+	}
+	if len(backoff) > 2 {
+		dslengine.ReportError("too many arguments given to RetryPolicy")
+		return
+	}
+	c.MaxAttempts = attempts
+	if len(backoff) > 0 {
+		c.Backoff = backoff[0]
+	}
+	if len(backoff) > 1 {
+		c.MaxBackoff = backoff[1]
+	}
+}
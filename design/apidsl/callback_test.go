@@ -0,0 +1,105 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Callback", func() {
+	var dsl func()
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		shipped := MediaType("application/vnd.shipped+json", func() {
+			Attributes(func() {
+				Attribute("id", Integer)
+			})
+			View("default", func() {
+				Attribute("id")
+			})
+		})
+		Resource("order", func() {
+			Action("subscribe", func() {
+				Routing(POST("/subscriptions"))
+				Callback("OrderShipped", func() {
+					Media(shipped)
+					if dsl != nil {
+						dsl()
+					}
+				})
+			})
+		})
+		dslengine.Run()
+		if r, ok := Design.Resources["order"]; ok {
+			action = r.Actions["subscribe"]
+		}
+	})
+
+	It("produces a callback with the default settings", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(action.Callbacks).Should(HaveKey("OrderShipped"))
+		c := action.Callbacks["OrderShipped"]
+		Ω(c.Method).Should(Equal("POST"))
+		Ω(c.MediaType).Should(Equal("application/vnd.shipped+json"))
+		Ω(c.SigningScheme).Should(Equal(SigningHMACSHA256))
+		Ω(c.SignatureHeader).Should(Equal(DefaultSignatureHeader))
+		Ω(c.MaxAttempts).Should(Equal(DefaultCallbackAttempts))
+		Ω(c.Backoff).Should(Equal(DefaultCallbackBackoff))
+	})
+
+	Context("with custom settings", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				CallbackMethod("PUT")
+				Signing("hmac-sha512", "X-Signature")
+				RetryPolicy(10, 2*time.Second, time.Minute)
+			}
+		})
+
+		It("sets them", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			c := action.Callbacks["OrderShipped"]
+			Ω(c.Method).Should(Equal("PUT"))
+			Ω(c.SigningScheme).Should(Equal(SigningHMACSHA512))
+			Ω(c.SignatureHeader).Should(Equal("X-Signature"))
+			Ω(c.MaxAttempts).Should(Equal(10))
+			Ω(c.Backoff).Should(Equal(2 * time.Second))
+			Ω(c.MaxBackoff).Should(Equal(time.Minute))
+		})
+	})
+
+	Context("with an unknown signing scheme", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Signing("md5")
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with an invalid retry policy", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				RetryPolicy(0)
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
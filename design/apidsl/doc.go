@@ -25,6 +25,14 @@ can be used to describe the request payloads as a whole or any attribute appeari
 (payloads, media types, headers, params etc.) and as with media type definitions they can include
 validation rules that goa leverages to validate attributes of that type.
 
+A design may be split across multiple Go packages, for example one package per group of
+resources. Each package defines its resources, media types and types with the same top level
+functions and all the definitions end up in the same API definition: the API function must be
+called once in one of the packages. goagen accepts a comma separated list of design packages with
+the --design flag, alternatively a single design package may import the others. The definitions
+of all the packages are validated together once all the packages have been loaded so that they may
+refer to each other by name. Definitions defined twice are reported with the location of both.
+
 Package apidsl also provides a generic DSL engine that other DSLs can plug into. Adding a DSL
 implementation consists of registering the root DSL object in the design package Roots variable.
 The runner iterates through all root DSL definitions and executes the definition sets they expose.
@@ -0,0 +1,37 @@
+package apidsl
+
+import "github.com/goadesign/goa/design"
+
+// ETag marks the successful responses of the action or the responses rendering the media type as
+// cacheable and sets the strategy used to compute their ETag: HashETag computes strong ETags from
+// the hash of the encoded response body, WeakETag weak ETags from the same hash and AttributeETag
+// strong ETags from the value of the media type attribute given as second argument:
+//
+//	MediaType("application/vnd.document+json", func() {
+//		ETag(AttributeETag, "version")
+//		Attributes(func() {
+//			Attribute("version", Integer)
+//			// ...
+//		})
+//	})
+//
+// The generated response methods set the ETag header and short-circuit the response with a 304
+// (If-None-Match) or 412 (If-Match) response when the request preconditions fail. The generated
+// contexts also expose a Preconditions method that checks the preconditions against an ETag before
+// the action does any work. Action ETags override the ETags of the response media types.
+// ETag may appear in Action or MediaType.
+func ETag(strategy string, attribute ...string) {
+	e := &design.ETagDefinition{Strategy: strategy}
+	if len(attribute) > 0 {
+		e.Attribute = attribute[0]
+	}
+	if a, ok := actionDefinition(false); ok {
+		e.Parent = a
+		a.ETag = e
+		return
+	}
+	if mt, ok := mediaTypeDefinition(true); ok {
+		e.Parent = mt
+		mt.ETag = e
+	}
+}
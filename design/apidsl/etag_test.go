@@ -0,0 +1,104 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ETag", func() {
+	var doc *MediaTypeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		doc = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("in MediaType and Action", func() {
+		BeforeEach(func() {
+			doc = MediaType("application/vnd.document+json", func() {
+				ETag(AttributeETag, "version")
+				Attributes(func() {
+					Attribute("version", Integer)
+					Attribute("body", String)
+				})
+				View("default", func() {
+					Attribute("version")
+					Attribute("body")
+				})
+			})
+			Resource("documents", func() {
+				Action("show", func() {
+					Routing(GET("/:id"))
+					Response(OK, func() {
+						Media(doc)
+					})
+				})
+				Action("render", func() {
+					Routing(GET("/:id/render"))
+					ETag(WeakETag)
+					Response(OK, func() {
+						Media(doc)
+					})
+				})
+				Action("delete", func() {
+					Routing(DELETE("/:id"))
+					Response(NoContent)
+				})
+			})
+		})
+
+		It("sets the effective ETags", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			actions := Design.Resources["documents"].Actions
+			e := actions["show"].EffectiveETag(doc)
+			Ω(e).Should(Equal(doc.ETag))
+			Ω(e.Strategy).Should(Equal(AttributeETag))
+			Ω(e.Attribute).Should(Equal("version"))
+			Ω(e.IsWeak()).Should(BeFalse())
+			e = actions["render"].EffectiveETag(doc)
+			Ω(e.Strategy).Should(Equal(WeakETag))
+			Ω(e.IsWeak()).Should(BeTrue())
+			Ω(actions["show"].IsConditional()).Should(BeTrue())
+			Ω(actions["delete"].IsConditional()).Should(BeFalse())
+		})
+	})
+
+	Context("with invalid ETags", func() {
+		BeforeEach(func() {
+			MediaType("application/vnd.document+json", func() {
+				ETag(AttributeETag, "revision")
+				Attributes(func() {
+					Attribute("version", Integer)
+				})
+				View("default", func() {
+					Attribute("version")
+				})
+			})
+			Resource("documents", func() {
+				Action("show", func() {
+					Routing(GET("/:id"))
+					ETag("md5")
+				})
+				Action("render", func() {
+					Routing(GET("/:id/render"))
+					ETag(HashETag, "version")
+				})
+			})
+		})
+
+		It("produces errors", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`ETag attribute "revision" is not an attribute of the media type`))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`invalid ETag strategy "md5"`))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("ETag attribute can only be used with the AttributeETag strategy"))
+		})
+	})
+})
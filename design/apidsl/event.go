@@ -0,0 +1,62 @@
+package apidsl
+
+import (
+	"fmt"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Event defines a domain event published on a message broker such as Kafka or NATS. Events
+// describe the contracts of the asynchronous messages exchanged by services alongside the HTTP
+// API. Each event is published on a topic and carries a payload, the optional key names the
+// payload attribute whose value is used as message key by brokers that support keys:
+//
+//	var _ = Event("OrderPlaced", func() {
+//		Description("OrderPlaced is published each time an order is placed")
+//		Topic("orders.placed")
+//		Payload(func() {
+//			Member("orderID", String)
+//			Member("total", Number)
+//			Required("orderID", "total")
+//		})
+//		Key("orderID")
+//	})
+//
+// "goagen events" generates the publisher and subscriber interfaces of the events together with
+// the JSON schema of their payloads.
+// Event may only appear at the top level.
+func Event(name string, dsl func()) *design.EventDefinition {
+	if design.Design.Events == nil {
+		design.Design.Events = make(map[string]*design.EventDefinition)
+	}
+	var event *design.EventDefinition
+	if dslengine.TopLevelDefinition(true) {
+		if first, ok := design.Design.Events[name]; ok {
+			dslengine.ReportDuplicate(fmt.Sprintf("event %#v", name), first)
+			return nil
+		}
+		event = &design.EventDefinition{Name: name, DSLFunc: dsl}
+		event.RecordLocation()
+		design.Design.Events[name] = event
+	}
+	return event
+}
+
+// Topic sets the name of the topic (Kafka) or subject (NATS) the event is published on.
+// Topic may only appear in Event.
+func Topic(name string) {
+	if e, ok := eventDefinition(true); ok {
+		e.Topic = name
+	}
+}
+
+// Key sets the name of the payload attribute whose value is used as the event message key. The
+// attribute must be a required primitive. Brokers that support keys use them to partition events,
+// Kafka guarantees that events with the same key are delivered in order.
+// Key may only appear in Event.
+func Key(att string) {
+	if e, ok := eventDefinition(true); ok {
+		e.Key = att
+	}
+}
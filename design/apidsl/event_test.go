@@ -0,0 +1,101 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Event", func() {
+	var name string
+	var dsl func()
+	var event *EventDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		name = "OrderPlaced"
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		Event(name, dsl)
+		dslengine.Run()
+		event = Design.Events[name]
+	})
+
+	Context("with a topic, a payload and a key", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Description("order placed")
+				Topic("orders.placed")
+				Payload(func() {
+					Member("orderID", String)
+					Member("total", Number)
+					Required("orderID")
+				})
+				Key("orderID")
+			}
+		})
+
+		It("produces a valid event definition", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(event).ShouldNot(BeNil())
+			Ω(event.Description).Should(Equal("order placed"))
+			Ω(event.Topic).Should(Equal("orders.placed"))
+			Ω(event.Key).Should(Equal("orderID"))
+			Ω(event.Payload).ShouldNot(BeNil())
+			Ω(event.Payload.TypeName).Should(Equal("OrderPlacedPayload"))
+			Ω(event.Payload.Type.ToObject()).Should(HaveKey("total"))
+		})
+	})
+
+	Context("with no topic", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Payload(String)
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("missing topic"))
+		})
+	})
+
+	Context("with a key that is not required", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Topic("orders.placed")
+				Payload(func() {
+					Member("orderID", String)
+				})
+				Key("orderID")
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`key "orderID" must be a required attribute`))
+		})
+	})
+
+	Context("with Topic used outside of an event", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Topic("orders.placed")
+				Payload(String)
+			}
+			Resource("order", func() {
+				Topic("orders")
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("invalid use of Topic"))
+		})
+	})
+})
@@ -21,15 +21,20 @@ func InitDesign() {
 			DefaultResponseTemplates: make(map[string]*design.ResponseTemplateDefinition),
 		},
 	}
-	t := func(params ...string) *design.ResponseDefinition {
+	t := func(params ...interface{}) *design.ResponseDefinition {
 		if len(params) < 1 {
 			dslengine.ReportError("expected media type as argument when invoking response template OK")
 			return nil
 		}
+		mt, ok := params[0].(string)
+		if !ok {
+			dslengine.ReportError("media type argument of response template OK must be a string but got %#v", params[0])
+			return nil
+		}
 		return &design.ResponseDefinition{
 			Name:      OK,
 			Status:    200,
-			MediaType: params[0],
+			MediaType: mt,
 		}
 	}
 	api.DefaultResponseTemplates[OK] = &design.ResponseTemplateDefinition{
@@ -77,6 +82,7 @@ func InitDesign() {
 		{416, RequestedRangeNotSatisfiable},
 		{417, ExpectationFailed},
 		{418, Teapot},
+		{429, TooManyRequests},
 		{500, InternalServerError},
 		{501, NotImplemented},
 		{502, BadGateway},
@@ -93,6 +99,6 @@ func InitDesign() {
 
 	// Initialize package variables
 	design.Design = api
-	dslengine.Roots = []dslengine.Root{api}
+	dslengine.Reset(api)
 	design.GeneratedMediaTypes = nil
 }
@@ -0,0 +1,31 @@
+package apidsl
+
+// JSONAPI makes the API comply with the JSON:API specification (http://jsonapi.org): the
+// successful responses that render media types send JSON:API documents with the
+// "application/vnd.api+json" content type and the request bodies sent with that content type are
+// parsed as JSON:API documents:
+//
+//	var _ = API("cellar", func() {
+//		JSONAPI()
+//	})
+//
+// The "id" attribute of a media type becomes the resource identifier, its links (see Link) become
+// the resource relationships and the other attributes the resource attributes. The resource type is
+// the snake case name of the media type, it can be overridden with the "jsonapi:type" metadata:
+//
+//	var BottleMedia = MediaType("application/vnd.goa.example.bottle", func() {
+//		Metadata("jsonapi:type", "bottles")
+//		// ...
+//	})
+//
+// JSONAPI makes "application/vnd.api+json" the default content type of the API, the content types
+// listed with Consumes and Produces (or the default content types if there are none) remain
+// supported.
+// JSONAPI may only appear in API.
+func JSONAPI() {
+	a, ok := apiDefinition(true)
+	if !ok {
+		return
+	}
+	a.JSONAPI = true
+}
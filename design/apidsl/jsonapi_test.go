@@ -0,0 +1,96 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSONAPI", func() {
+	var account, bottle, bottles *MediaTypeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		account = MediaType("application/vnd.account", func() {
+			TypeName("CellarAccount")
+			Attributes(func() {
+				Attribute("id", Integer)
+				Attribute("href", String)
+			})
+			View("default", func() {
+				Attribute("id")
+				Attribute("href")
+			})
+			View("link", func() {
+				Attribute("id")
+				Attribute("href")
+			})
+		})
+		bottle = MediaType("application/vnd.bottle", func() {
+			Metadata("jsonapi:type", "bottles")
+			Attributes(func() {
+				Attribute("id", Integer)
+				Attribute("name", String)
+				Attribute("account", account)
+			})
+			Links(func() {
+				Link("account")
+			})
+			View("default", func() {
+				Attribute("id")
+				Attribute("name")
+				Attribute("links")
+			})
+		})
+		bottles = CollectionOf(bottle)
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("with a JSON:API API", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				JSONAPI()
+			})
+		})
+
+		It("makes the JSON:API media type the default", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.JSONAPI).Should(BeTrue())
+			Ω(Design.Consumes[0].MIMETypes).Should(Equal([]string{JSONAPIMediaType}))
+			Ω(Design.Produces[0].MIMETypes).Should(Equal([]string{JSONAPIMediaType}))
+			Ω(Design.Consumes[1:]).Should(Equal(DefaultDecoders))
+			Ω(Design.Produces[1:]).Should(Equal(DefaultEncoders))
+		})
+
+		It("computes the resource types and relationships", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(account.JSONAPIType()).Should(Equal("cellar_account"))
+			Ω(bottle.JSONAPIType()).Should(Equal("bottles"))
+			Ω(bottle.JSONAPIRelationships()).Should(Equal(map[string]string{"account": "cellar_account"}))
+			Ω(bottles.JSONAPIType()).Should(Equal("bottles"))
+		})
+	})
+
+	Context("with a JSON:API API that lists its content types", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				JSONAPI()
+				Consumes("application/json")
+				Produces(JSONAPIMediaType, "application/json")
+			})
+		})
+
+		It("does not add the JSON:API media type twice", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.Consumes).Should(HaveLen(2))
+			Ω(Design.Consumes[0].MIMETypes).Should(Equal([]string{JSONAPIMediaType}))
+			Ω(Design.Produces).Should(HaveLen(1))
+		})
+	})
+})
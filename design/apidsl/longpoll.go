@@ -0,0 +1,80 @@
+package apidsl
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// LongPoll makes the action a long-poll endpoint: requests carry the cursor of the last event
+// received by the client and the number of seconds the client accepts to wait, the action
+// responds as soon as events following the cursor are available or once the wait expires. The
+// integer "wait" and string "cursor" params are added to the action params unless the action
+// already defines params with these names. The optional DSL overrides the default maximum wait
+// of one minute and the param names:
+//
+//	Action("feed", func() {
+//		Routing(GET("/feed"))
+//		LongPoll(func() {
+//			MaxWait(30 * time.Second)
+//			DefaultWait(20 * time.Second)	// Used when requests do not specify a wait
+//			CursorParam("since")
+//		})
+//		Response(OK, EventsMedia)
+//	})
+//
+// The generated context of long-poll actions exposes a WaitContext method that returns a context
+// canceled when the wait expires and a Poll method that blocks until the given event source has
+// events following the request cursor, see goa.EventSource.
+func LongPoll(dsl ...func()) {
+	a, ok := actionDefinition(true)
+	if !ok {
+		return
+	}
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to LongPoll")
+		return
+	}
+	l := &design.LongPollDefinition{
+		WaitParam:   design.DefaultLongPollWaitParam,
+		CursorParam: design.DefaultLongPollCursorParam,
+		MaxWait:     design.DefaultLongPollMaxWait,
+		Parent:      a,
+	}
+	if len(dsl) == 1 {
+		if !dslengine.Execute(dsl[0], l) {
+			return
+		}
+	}
+	a.LongPoll = l
+}
+
+// MaxWait sets the maximum duration long-poll requests may wait for events. It is rounded down
+// to the second as the wait param is expressed in seconds.
+func MaxWait(d time.Duration) {
+	if l, ok := longPollDefinition(true); ok {
+		l.MaxWait = d
+	}
+}
+
+// DefaultWait sets the duration long-poll requests that do not specify a wait wait for events.
+func DefaultWait(d time.Duration) {
+	if l, ok := longPollDefinition(true); ok {
+		l.DefaultWait = d
+	}
+}
+
+// WaitParam sets the name of the long-poll wait param.
+func WaitParam(name string) {
+	if l, ok := longPollDefinition(true); ok {
+		l.WaitParam = name
+	}
+}
+
+// CursorParam sets the name of the long-poll cursor param.
+func CursorParam(name string) {
+	if l, ok := longPollDefinition(true); ok {
+		l.CursorParam = name
+	}
+}
@@ -0,0 +1,105 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LongPoll", func() {
+	var dsl func()
+	var params func()
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		dsl = nil
+		params = nil
+	})
+
+	JustBeforeEach(func() {
+		Resource("feed", func() {
+			Action("events", func() {
+				Routing(GET("/events"))
+				if dsl == nil {
+					LongPoll()
+				} else {
+					LongPoll(dsl)
+				}
+				if params != nil {
+					Params(params)
+				}
+			})
+		})
+		dslengine.Run()
+		if r, ok := Design.Resources["feed"]; ok {
+			action = r.Actions["events"]
+		}
+	})
+
+	It("produces a long-poll action with wait and cursor params", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(action.IsLongPoll()).Should(BeTrue())
+		Ω(action.LongPoll.MaxWait).Should(Equal(DefaultLongPollMaxWait))
+		o := action.QueryParams.Type.ToObject()
+		Ω(o).Should(HaveKey("wait"))
+		Ω(o["wait"].Type).Should(Equal(Integer))
+		Ω(o["wait"].DefaultValue).Should(Equal(60))
+		Ω(*o["wait"].Validation.Maximum).Should(Equal(60.0))
+		Ω(o).Should(HaveKey("cursor"))
+		Ω(o["cursor"].Type).Should(Equal(String))
+	})
+
+	Context("with custom settings", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				MaxWait(30 * time.Second)
+				DefaultWait(20 * time.Second)
+				WaitParam("timeout")
+				CursorParam("since")
+			}
+			params = func() {
+				Param("limit", Integer)
+			}
+		})
+
+		It("uses them", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			o := action.Params.Type.ToObject()
+			Ω(o).Should(HaveKey("limit"))
+			Ω(o).Should(HaveKey("since"))
+			Ω(o["timeout"].DefaultValue).Should(Equal(20))
+			Ω(*o["timeout"].Validation.Maximum).Should(Equal(30.0))
+		})
+	})
+
+	Context("with a default wait greater than the maximum wait", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				MaxWait(10 * time.Second)
+				DefaultWait(20 * time.Second)
+			}
+		})
+
+		It("fails validation", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a cursor param that is not a string", func() {
+		BeforeEach(func() {
+			params = func() {
+				Param("cursor", Integer)
+			}
+		})
+
+		It("fails validation", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
@@ -73,8 +73,8 @@ func MediaType(identifier string, apidsl func()) *design.MediaTypeDefinition {
 		}
 		canonicalID := design.CanonicalIdentifier(identifier)
 		// Validate that media type identifier doesn't clash
-		if _, ok := design.Design.MediaTypes[canonicalID]; ok {
-			dslengine.ReportError("media type %#v is defined twice", identifier)
+		if first, ok := design.Design.MediaTypes[canonicalID]; ok {
+			dslengine.ReportDuplicate(fmt.Sprintf("media type %#v", identifier), first)
 			return nil
 		}
 		parts := strings.Split(identifier, "+")
@@ -116,6 +116,7 @@ func MediaType(identifier string, apidsl func()) *design.MediaTypeDefinition {
 		}
 		// Now save the type in the API media types map
 		mt := design.NewMediaTypeDefinition(typeName, identifier, apidsl)
+		mt.RecordLocation()
 		design.Design.MediaTypes[canonicalID] = mt
 		return mt
 	}
@@ -129,19 +130,61 @@ func MediaType(identifier string, apidsl func()) *design.MediaTypeDefinition {
 //		Media("application/json")
 //	})
 //
-// Media can be used inside Response or ResponseTemplate.
-func Media(val interface{}) {
-	if r, ok := responseDefinition(true); ok {
-		if m, ok := val.(*design.MediaTypeDefinition); ok {
-			if m != nil {
-				r.MediaType = m.Identifier
-			}
-		} else if identifier, ok := val.(string); ok {
+// Additional media types define alternate renderings of the response body, for example an error
+// body sent with the same status range as the regular body. Alternate media types must be defined
+// in the design:
+//
+//	Response(OK, func() {
+//		Media(BottleMedia, ErrorMedia)
+//	})
+//
+// The generated context exposes one set of response helpers per media type, the helpers of the
+// alternate media types are suffixed with the media type name (e.g. OKError).
+//
+// Media can be used inside Response, ResponseTemplate or Callback. Callbacks do not support
+// alternate media types.
+func Media(val interface{}, alternates ...interface{}) {
+	if c, ok := callbackDefinition(false); ok {
+		if len(alternates) > 0 {
+			dslengine.ReportError("callbacks do not support alternate media types")
+			return
+		}
+		if identifier, ok := mediaTypeIdentifier(val); ok {
+			c.MediaType = identifier
+		}
+	} else if r, ok := responseDefinition(true); ok {
+		if identifier, ok := mediaTypeIdentifier(val); ok && identifier != "" {
 			r.MediaType = identifier
-		} else {
-			dslengine.ReportError("media type must be a string or a pointer to MediaTypeDefinition, got %#v", val)
 		}
+		for _, alt := range alternates {
+			if identifier, ok := mediaTypeIdentifier(alt); ok && identifier != "" {
+				r.AlternateMediaTypes = append(r.AlternateMediaTypes, identifier)
+			}
+		}
+	}
+}
+
+// mediaTypeIdentifier returns the media type identifier given as a string or a media type
+// definition. It reports an error and returns false if val is neither.
+func mediaTypeIdentifier(val interface{}) (string, bool) {
+	if m, ok := val.(*design.MediaTypeDefinition); ok {
+		if m == nil {
+			return "", true
+		}
+		if m == design.ErrorMedia {
+			design.Design.UseErrorMedia()
+		}
+		return m.Identifier, true
+	}
+	if identifier, ok := val.(string); ok && identifier == design.ErrorMediaIdentifier {
+		design.Design.UseErrorMedia()
+		return identifier, true
+	}
+	if identifier, ok := val.(string); ok {
+		return identifier, true
 	}
+	dslengine.ReportError("media type must be a string or a pointer to MediaTypeDefinition, got %#v", val)
+	return "", false
 }
 
 // Reference sets a type or media type reference. The value itself can be a type or a media type.
@@ -263,11 +306,13 @@ func View(name string, apidsl ...func()) {
 					}
 				}
 			}
-			mt.Views[name] = &design.ViewDefinition{
+			view := &design.ViewDefinition{
 				AttributeDefinition: at,
 				Name:                name,
 				Parent:              mt,
 			}
+			view.RecordLocation()
+			mt.Views[name] = view
 		}
 	} else if a, ok := attributeDefinition(true); ok {
 		a.View = name
@@ -305,6 +350,7 @@ func Link(name string, view ...string) {
 			}
 		}
 		link := &design.LinkDefinition{Name: name, Parent: mt}
+		link.RecordLocation()
 		if len(view) > 1 {
 			dslengine.ReportError("invalid syntax in Link definition for %#v, allowed syntax is Link(name) or Link(name, view)", name)
 		}
@@ -381,6 +427,7 @@ func CollectionOf(v interface{}, apidsl ...func()) *design.MediaTypeDefinition {
 	})
 	// Do not execute the apidsl right away, will be done last to make sure the element apidsl has run
 	// first.
+	mt.RecordLocation()
 	design.GeneratedMediaTypes[typeName] = mt
 	return mt
 }
@@ -12,7 +12,24 @@ package apidsl
 //               Overrides tags that goagen would otherwise set.
 //               If the metadata value is a slice then the
 //               strings are joined with the space character as
-//               separator.
+//               separator. Values starting with a comma only list
+//               tag options and are prefixed with the attribute
+//               name. "struct:tag:xxx" is an alias.
+//
+// "struct:field:name": overrides the name of the struct field generated for
+//               the attribute, e.g. "ID" instead of "Id".
+//
+// "struct:field:type": overrides the Go type of the struct field generated for
+//               the attribute. The optional second value is the import path
+//               of the package that defines the type. The type should have
+//               the same underlying type as the attribute type so that the
+//               generated validation code compiles.
+//
+// "struct:field:pointer": "false" causes the struct field generated for an
+//               optional primitive attribute to be a value rather than a
+//               pointer, "true" forces a pointer. Set on a type or media
+//               type it applies to all its optional primitive attributes.
+//               See also the goagen --value-defaults flag.
 //
 // "swagger:tag=xxx": sets the Swagger object field tag xxx. The value
 //               must be one to three strings. The first string is
@@ -21,10 +38,50 @@ package apidsl
 //               Subsequent calls to Metadata on the same attribute
 //               with key "swagger:tag" builds up the Swagger tag list.
 //
+// "gateway:ratelimit": sets the rate limit enforced by API gateways on the
+//               API, resource or action requests. The value must be
+//               of the form "<count>/<period>" where period is one of
+//               second, minute, hour, day, month or year.
+//
+// "gateway:quota": sets the quota enforced by API gateways using the same
+//               format as "gateway:ratelimit".
+//
+// "gateway:security": lists the security schemes enforced by API gateways,
+//               one or more of "basic", "apikey", "jwt" or "oauth2".
+//
+// "proto:field": sets the number of the protobuf field generated for the
+//               attribute by the proto generator.
+//
+// "grpc:service": sets the name of the gRPC service generated for the resource.
+//
+// "grpc:method": sets the name of the rpc generated for the action.
+//
+// "grpc:skip": excludes the resource or action from the gRPC transport.
+//
+// "grpc:field": sets the path of the gRPC request message field holding the
+//               value of the parameter, e.g. "bottle.id". Used by the
+//               grpcbridge generator.
+//
+// "grpc:body": sets the path of the gRPC request message field holding the
+//               action payload, "*" means all the fields that do not hold
+//               parameters. Used by the grpcbridge generator.
+//
+// "router:strict-slash", "router:case-insensitive" and "router:redirect": "true"
+//               or "false" override the corresponding goa.RouteOptions
+//               for the routes of the API or resource, see goa.ConfigureRoute.
+//
 // Usage:
 //        Metadata("struct:tag=json", "myName,omitempty")
 //        Metadata("struct:tag=xml", "myName,attr")
+//        Metadata("struct:tag:validate", "required")
+//        Metadata("struct:field:name", "ID")
+//        Metadata("struct:field:type", "types.AccountID", "github.com/acme/types")
+//        Metadata("struct:field:pointer", "false")
 //        Metadata("swagger:tag=backend")
+//        Metadata("gateway:ratelimit", "100/minute")
+//        Metadata("grpc:method", "GetBottle")
+//        Metadata("grpc:field", "bottle.id")
+//        Metadata("router:strict-slash", "true")
 func Metadata(name string, value ...string) {
 	if at, ok := attributeDefinition(false); ok {
 		if at.Metadata == nil {
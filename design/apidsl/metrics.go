@@ -0,0 +1,19 @@
+package apidsl
+
+import "github.com/goadesign/goa/design"
+
+// Metrics defines an endpoint that serves the request metrics collected by the goa Instrument
+// middleware in the Prometheus text format:
+//
+//	API("cellar", func() {
+//		Metrics("/metrics")
+//	})
+//
+// The path is relative to the API base path. The generated app package exposes a MountMetrics
+// function that mounts the endpoint on the service.
+// Metrics may only appear in API.
+func Metrics(path string) {
+	if a, ok := apiDefinition(true); ok {
+		a.Metrics = &design.MetricsDefinition{Path: path, Parent: a}
+	}
+}
@@ -0,0 +1,44 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	var path string
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		path = "/metrics"
+	})
+
+	JustBeforeEach(func() {
+		API("cellar", func() {
+			BasePath("/api")
+			Metrics(path)
+		})
+		dslengine.Run()
+	})
+
+	It("produces a valid metrics definition", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(Design.Validate()).ShouldNot(HaveOccurred())
+		Ω(Design.Metrics).ShouldNot(BeNil())
+		Ω(Design.Metrics.FullPath()).Should(Equal("/api/metrics"))
+	})
+
+	Context("with a relative path", func() {
+		BeforeEach(func() {
+			path = "metrics"
+		})
+
+		It("produces an invalid definition", func() {
+			Ω(Design.Validate()).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,101 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Subscribe binds the action to a MQTT topic: messages published on the topic invoke the action.
+// The message body is decoded as the action payload using the API decoders. Topic levels of the
+// form ":name" match a single level and a last level of the form "*name" matches any number of
+// levels, the matched values are used as the values of the action parameters with the same names.
+// The optional DSL sets the QoS used to subscribe:
+//
+//	Action("command", func() {
+//		Routing(POST("/devices/:deviceID/commands"))
+//		Params(func() {
+//			Param("deviceID", String)
+//		})
+//		Payload(Command)
+//		Subscribe("devices/:deviceID/commands", func() {
+//			QoS(1)
+//		})
+//	})
+//
+// Subscribe may only appear in Action.
+func Subscribe(topic string, dsl ...func()) {
+	if a, ok := actionDefinition(true); ok {
+		if t := mqttTopic(a, topic, true, dsl); t != nil {
+			a.MQTTSubscription = t
+		}
+	}
+}
+
+// Publish binds the action or media type to a MQTT topic messages are published on.
+// When used in a media type the generated code exposes a publisher that encodes instances of the
+// media type with the API encoders and publishes them on the topic, the publisher takes one
+// argument per topic wildcard. When used in an action that subscribes to a topic the action
+// responses are published on the topic, the topic wildcards are then matched against the action
+// parameters. The optional DSL sets the QoS and whether the messages are retained:
+//
+//	MediaType("application/vnd.telemetry+json", func() {
+//		Publish("devices/:deviceID/telemetry", func() {
+//			QoS(1)
+//			Retain()
+//		})
+//		Attributes(func() {
+//			// ...
+//		})
+//	})
+//
+// Publish may appear in Action or MediaType.
+func Publish(topic string, dsl ...func()) {
+	if a, ok := actionDefinition(false); ok {
+		if t := mqttTopic(a, topic, false, dsl); t != nil {
+			a.MQTTPublication = t
+		}
+		return
+	}
+	if mt, ok := mediaTypeDefinition(true); ok {
+		if t := mqttTopic(mt, topic, false, dsl); t != nil {
+			mt.MQTTPublication = t
+		}
+	}
+}
+
+// QoS sets the MQTT quality of service level used to publish or subscribe: 0 (at most once, the
+// default), 1 (at least once) or 2 (exactly once).
+// QoS may appear in Subscribe or Publish.
+func QoS(level int) {
+	if t, ok := mqttTopicDefinition(true); ok {
+		t.QoS = level
+	}
+}
+
+// Retain causes the messages published on the topic to be retained by the broker so that new
+// subscribers receive the last message right away.
+// Retain may appear in Publish.
+func Retain() {
+	if t, ok := mqttTopicDefinition(true); ok {
+		t.Retain = true
+	}
+}
+
+// mqttTopic builds the definition of a MQTT topic bound to the given parent and runs its DSL.
+func mqttTopic(parent dslengine.Definition, topic string, subscribe bool, dsl []func()) *design.MQTTTopicDefinition {
+	if len(dsl) > 1 {
+		if subscribe {
+			dslengine.ReportError("too many arguments given to Subscribe")
+		} else {
+			dslengine.ReportError("too many arguments given to Publish")
+		}
+		return nil
+	}
+	t := &design.MQTTTopicDefinition{Topic: topic, Subscribe: subscribe, Parent: parent}
+	if len(dsl) == 1 {
+		if !dslengine.Execute(dsl[0], t) {
+			return nil
+		}
+	}
+	return t
+}
@@ -0,0 +1,136 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MQTT", func() {
+	var topic string
+	var dsl func()
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		topic = "devices/:deviceID/commands"
+		dsl = func() {}
+	})
+
+	JustBeforeEach(func() {
+		Resource("device", func() {
+			Action("command", func() {
+				Routing(POST("/devices/:deviceID/commands"))
+				Params(func() {
+					Param("deviceID", String)
+				})
+				Subscribe(topic, dsl)
+				Publish("devices/:deviceID/replies")
+			})
+		})
+		dslengine.Run()
+		if r, ok := Design.Resources["device"]; ok {
+			action = r.Actions["command"]
+		}
+	})
+
+	Context("with a valid subscription", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				QoS(1)
+			}
+		})
+
+		It("binds the action to the topics", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action.IsMQTT()).Should(BeTrue())
+			Ω(action.MQTTSubscription.Topic).Should(Equal(topic))
+			Ω(action.MQTTSubscription.QoS).Should(Equal(1))
+			Ω(action.MQTTSubscription.Filter()).Should(Equal("devices/+/commands"))
+			Ω(action.MQTTSubscription.Wildcards()).Should(Equal([]string{"deviceID"}))
+			Ω(action.MQTTPublication.Topic).Should(Equal("devices/:deviceID/replies"))
+			Ω(action.MQTTPublication.Subscribe).Should(BeFalse())
+		})
+	})
+
+	Context("with a wildcard that is not a parameter", func() {
+		BeforeEach(func() {
+			topic = "fleets/:fleetID/commands"
+		})
+
+		It("fails", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("topic wildcard fleetID does not match any action parameter"))
+		})
+	})
+
+	Context("with a raw MQTT wildcard", func() {
+		BeforeEach(func() {
+			topic = "devices/+/commands"
+		})
+
+		It("fails", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with an invalid QoS", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				QoS(3)
+			}
+		})
+
+		It("fails", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("invalid QoS 3"))
+		})
+	})
+
+	Context("with a retained subscription", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Retain()
+			}
+		})
+
+		It("fails", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("Retain only applies to publications"))
+		})
+	})
+})
+
+var _ = Describe("Publish in MediaType", func() {
+	var mt *MediaTypeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		mt = MediaType("application/vnd.telemetry+json", func() {
+			Publish("devices/:deviceID/telemetry/*sensor", func() {
+				QoS(2)
+				Retain()
+			})
+			Attributes(func() {
+				Attribute("temperature", Number)
+			})
+			View("default", func() {
+				Attribute("temperature")
+			})
+		})
+		dslengine.Run()
+	})
+
+	It("binds the media type to the topic", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(mt.MQTTPublication).ShouldNot(BeNil())
+		Ω(mt.MQTTPublication.QoS).Should(Equal(2))
+		Ω(mt.MQTTPublication.Retain).Should(BeTrue())
+		Ω(mt.MQTTPublication.Filter()).Should(Equal("devices/+/telemetry/#"))
+		Ω(mt.MQTTPublication.Wildcards()).Should(Equal([]string{"deviceID", "sensor"}))
+	})
+})
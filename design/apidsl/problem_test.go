@@ -0,0 +1,65 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProblemResponses", func() {
+	var problems bool
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		problems = true
+	})
+
+	JustBeforeEach(func() {
+		API("test", func() {
+			if problems {
+				ProblemResponses()
+			}
+		})
+		Resource("bottle", func() {
+			Action("show", func() {
+				Routing(GET("/:id"))
+				Response(NoContent)
+				Response(NotFound)
+				if !problems {
+					Response(BadRequest, func() {
+						Media(ErrorMedia)
+					})
+				}
+			})
+		})
+		dslengine.Run()
+		if r, ok := Design.Resources["bottle"]; ok {
+			action = r.Actions["show"]
+		}
+	})
+
+	It("makes the error responses use ErrorMedia", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(Design.ProblemResponses).Should(BeTrue())
+		Ω(Design.MediaTypeWithIdentifier(ErrorMediaIdentifier)).Should(Equal(ErrorMedia))
+		Ω(action.Responses[NotFound].MediaType).Should(Equal(ErrorMediaIdentifier))
+		Ω(action.Responses[NoContent].MediaType).Should(BeEmpty())
+	})
+
+	Context("with ErrorMedia used explicitly", func() {
+		BeforeEach(func() {
+			problems = false
+		})
+
+		It("adds ErrorMedia to the design", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.MediaTypeWithIdentifier(ErrorMediaIdentifier)).Should(Equal(ErrorMedia))
+			Ω(action.Responses[BadRequest].MediaType).Should(Equal(ErrorMediaIdentifier))
+			Ω(action.Responses[NotFound].MediaType).Should(BeEmpty())
+		})
+	})
+})
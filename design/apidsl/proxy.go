@@ -0,0 +1,79 @@
+package apidsl
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Proxy turns the action into a pass-through: the requests made to the action routes are
+// forwarded to the upstream service with the given URL and the upstream responses are streamed
+// back to the clients. The request path is appended to the path of the upstream URL. The action
+// params, headers and payload are still validated, the controller middleware (e.g. security) is
+// still applied and the action is documented in the swagger specification as any other action.
+// The optional DSL defines the upstream timeout and the rules used to rewrite the requests:
+//
+//	Action("search", func() {
+//		Routing(GET("/search/*path"))
+//		Proxy("http://search.internal:8080/v2", func() {
+//			UpstreamTimeout(10 * time.Second)
+//			StripPrefix("/search")		// Forward "/search/foo" to "/v2/foo"
+//			SetHeader("X-Gateway", "cellar")
+//			RemoveHeader("Cookie")
+//		})
+//	})
+//
+// Proxy actions have no controller method. Hop-by-hop headers are not forwarded and the
+// X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto headers are set on upstream requests.
+func Proxy(upstream string, dsl ...func()) {
+	a, ok := actionDefinition(true)
+	if !ok {
+		return
+	}
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to Proxy")
+		return
+	}
+	p := &design.ProxyDefinition{Upstream: upstream, Parent: a}
+	if len(dsl) == 1 {
+		if !dslengine.Execute(dsl[0], p) {
+			return
+		}
+	}
+	a.Proxy = p
+}
+
+// UpstreamTimeout sets the maximum duration of the upstream requests including reading the
+// response body. Requests that time out are answered with a 502 Bad Gateway response.
+func UpstreamTimeout(d time.Duration) {
+	if p, ok := proxyDefinition(true); ok {
+		p.Timeout = d
+	}
+}
+
+// StripPrefix sets the prefix removed from the request path before it is appended to the
+// upstream URL path.
+func StripPrefix(prefix string) {
+	if p, ok := proxyDefinition(true); ok {
+		p.StripPrefix = prefix
+	}
+}
+
+// SetHeader sets a header on the upstream requests, overriding the value sent by the client if
+// any.
+func SetHeader(name, value string) {
+	if p, ok := proxyDefinition(true); ok {
+		if p.SetHeaders == nil {
+			p.SetHeaders = make(map[string]string)
+		}
+		p.SetHeaders[name] = value
+	}
+}
+
+// RemoveHeader removes a header sent by the client from the upstream requests.
+func RemoveHeader(name string) {
+	if p, ok := proxyDefinition(true); ok {
+		p.RemoveHeaders = append(p.RemoveHeaders, name)
+	}
+}
@@ -0,0 +1,77 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Proxy", func() {
+	var upstream string
+	var dsl func()
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		upstream = "http://search.internal:8080/v2"
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		Resource("search", func() {
+			Action("search", func() {
+				Routing(GET("/search/*path"))
+				if dsl == nil {
+					Proxy(upstream)
+				} else {
+					Proxy(upstream, dsl)
+				}
+			})
+		})
+		dslengine.Run()
+		if r, ok := Design.Resources["search"]; ok {
+			action = r.Actions["search"]
+		}
+	})
+
+	It("produces a proxy action", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(action.IsProxy()).Should(BeTrue())
+		Ω(action.Proxy.Upstream).Should(Equal(upstream))
+		Ω(action.Proxy.Validate()).Should(BeNil())
+	})
+
+	Context("with rewrite rules", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				UpstreamTimeout(10 * time.Second)
+				StripPrefix("/search")
+				SetHeader("X-Gateway", "cellar")
+				RemoveHeader("Cookie")
+			}
+		})
+
+		It("records the rules", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action.Proxy.Timeout).Should(Equal(10 * time.Second))
+			Ω(action.Proxy.StripPrefix).Should(Equal("/search"))
+			Ω(action.Proxy.SetHeaders).Should(Equal(map[string]string{"X-Gateway": "cellar"}))
+			Ω(action.Proxy.RemoveHeaders).Should(Equal([]string{"Cookie"}))
+		})
+	})
+
+	Context("with a relative upstream URL", func() {
+		BeforeEach(func() {
+			upstream = "/search"
+		})
+
+		It("produces an invalid proxy definition", func() {
+			Ω(action.Proxy.Validate()).ShouldNot(BeNil())
+		})
+	})
+})
@@ -0,0 +1,74 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Quota defines the daily and monthly budgets of the requests each principal may make to the
+// actions of the API, resource or action where it appears. The budgets of API quotas are shared by
+// all the API actions, the budgets of resource quotas by all the resource actions. Action quotas
+// override resource quotas which override the API quota. The DSL lists the budgets:
+//
+//	Resource("reports", func() {
+//		Quota(func() {
+//			Requests(10000, Daily)		// 10,000 requests per day
+//			Bytes(1<<30, Monthly)		// 1 GiB of request and response bodies per month
+//		})
+//		...
+//	})
+//
+// The generated code rejects the requests made once a budget is exhausted with a 429 response and
+// sets the X-Quota-Limit, X-Quota-Remaining and X-Quota-Reset headers on the responses. The usage
+// is recorded in the store set on the service with SetQuotaStore, see goa.QuotaStore. The
+// principal is the one set by the security middleware, the client IP for unauthenticated requests.
+// goagen also generates the endpoint that returns the quota usage of the request principal, see
+// design.QuotaStatusPath.
+// Quota may appear in API, Resource or Action.
+func Quota(dsl func()) {
+	var parent dslengine.Definition
+	a, isAPI := apiDefinition(false)
+	r, isResource := resourceDefinition(false)
+	var act *design.ActionDefinition
+	switch {
+	case isAPI:
+		parent = a
+	case isResource:
+		parent = r
+	default:
+		var ok bool
+		if act, ok = actionDefinition(true); !ok {
+			return
+		}
+		parent = act
+	}
+	quota := &design.QuotaDefinition{Parent: parent}
+	if !dslengine.Execute(dsl, quota) {
+		return
+	}
+	switch {
+	case isAPI:
+		a.Quota = quota
+	case isResource:
+		r.Quota = quota
+	default:
+		act.Quota = quota
+	}
+}
+
+// Requests sets the number of requests allowed per period, period is either Daily or Monthly.
+// Requests may only appear in Quota.
+func Requests(n int64, period string) {
+	if q, ok := quotaDefinition(true); ok {
+		q.Limits = append(q.Limits, &design.QuotaLimitDefinition{Unit: design.QuotaRequests, Limit: n, Period: period})
+	}
+}
+
+// Bytes sets the number of bytes of the request and response bodies allowed per period, period
+// is either Daily or Monthly.
+// Bytes may only appear in Quota.
+func Bytes(n int64, period string) {
+	if q, ok := quotaDefinition(true); ok {
+		q.Limits = append(q.Limits, &design.QuotaLimitDefinition{Unit: design.QuotaBytes, Limit: n, Period: period})
+	}
+}
@@ -0,0 +1,101 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Quota", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("in API, Resource and Action", func() {
+		BeforeEach(func() {
+			API("reporting", func() {
+				Quota(func() {
+					Requests(100000, Monthly)
+				})
+			})
+			Resource("reports", func() {
+				Quota(func() {
+					Requests(10000, Daily)
+					Bytes(1<<30, Monthly)
+				})
+				Action("list", func() {
+					Routing(GET(""))
+				})
+				Action("export", func() {
+					Routing(POST("/export"))
+					Quota(func() {
+						Requests(10, Daily)
+					})
+				})
+			})
+			Resource("health", func() {
+				Action("check", func() {
+					Routing(GET(""))
+				})
+			})
+		})
+
+		It("sets the effective quotas", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.HasQuotas()).Should(BeTrue())
+			reports := Design.Resources["reports"]
+			quota := reports.Actions["list"].EffectiveQuota()
+			Ω(quota).Should(Equal(reports.Quota))
+			Ω(quota.Scope()).Should(Equal("reports"))
+			Ω(quota.Limits).Should(Equal([]*QuotaLimitDefinition{
+				{Unit: QuotaRequests, Limit: 10000, Period: Daily},
+				{Unit: QuotaBytes, Limit: 1 << 30, Period: Monthly},
+			}))
+			quota = reports.Actions["export"].EffectiveQuota()
+			Ω(quota.Scope()).Should(Equal("reports#export"))
+			Ω(quota.Limits).Should(HaveLen(1))
+			quota = Design.Resources["health"].Actions["check"].EffectiveQuota()
+			Ω(quota.Scope()).Should(Equal("api"))
+			Ω(Design.QuotaStatusFullPath()).Should(Equal("/quota"))
+		})
+	})
+
+	Context("with invalid budgets", func() {
+		BeforeEach(func() {
+			Resource("reports", func() {
+				Quota(func() {
+					Requests(0, Daily)
+					Bytes(1024, "weekly")
+					Bytes(2048, "weekly")
+				})
+			})
+		})
+
+		It("produces errors", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("invalid requests budget 0"))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`invalid bytes budget period "weekly"`))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("weekly bytes budget is defined more than once"))
+		})
+	})
+
+	Context("without budget", func() {
+		BeforeEach(func() {
+			Resource("reports", func() {
+				Quota(func() {})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("at least one budget"))
+		})
+	})
+})
@@ -0,0 +1,74 @@
+package apidsl
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// RateLimit defines the number of requests each client may make per period to the actions of the
+// API, resource or action where it appears. The rate limit of the API is shared by all the API
+// actions, the rate limit of a resource by all the resource actions. Action rate limits override
+// resource rate limits which override the API rate limit. The optional DSL sets the algorithm used
+// to enforce the limit, TokenBucket by default:
+//
+//	Resource("search", func() {
+//		RateLimit(100, time.Minute, func() {	// 100 requests per minute
+//			Algorithm(SlidingWindow)
+//		})
+//		...
+//	})
+//
+// The generated code rejects the requests in excess with a 429 response and sets the
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers on the responses. The
+// clients are tracked in the store set on the service with SetRateLimitStore, see
+// goa.RateLimitStore. The client is the principal set by the security middleware, the client IP
+// for unauthenticated requests.
+// RateLimit may appear in API, Resource or Action.
+func RateLimit(limit int, period time.Duration, dsl ...func()) {
+	var parent dslengine.Definition
+	a, isAPI := apiDefinition(false)
+	r, isResource := resourceDefinition(false)
+	var act *design.ActionDefinition
+	switch {
+	case isAPI:
+		parent = a
+	case isResource:
+		parent = r
+	default:
+		var ok bool
+		if act, ok = actionDefinition(true); !ok {
+			return
+		}
+		parent = act
+	}
+	rl := &design.RateLimitDefinition{
+		Limit:     limit,
+		Period:    period,
+		Algorithm: design.TokenBucket,
+		Parent:    parent,
+	}
+	if len(dsl) > 0 {
+		if !dslengine.Execute(dsl[0], rl) {
+			return
+		}
+	}
+	switch {
+	case isAPI:
+		a.RateLimit = rl
+	case isResource:
+		r.RateLimit = rl
+	default:
+		act.RateLimit = rl
+	}
+}
+
+// Algorithm sets the algorithm used to enforce the rate limit, either TokenBucket or
+// SlidingWindow.
+// Algorithm may only appear in RateLimit.
+func Algorithm(name string) {
+	if rl, ok := rateLimitDefinition(true); ok {
+		rl.Algorithm = name
+	}
+}
@@ -0,0 +1,80 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimit", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("in API, Resource and Action", func() {
+		BeforeEach(func() {
+			API("search", func() {
+				RateLimit(1000, time.Hour)
+			})
+			Resource("queries", func() {
+				RateLimit(100, time.Minute, func() {
+					Algorithm(SlidingWindow)
+				})
+				Action("list", func() {
+					Routing(GET(""))
+				})
+				Action("run", func() {
+					Routing(POST(""))
+					RateLimit(10, time.Second)
+				})
+			})
+			Resource("health", func() {
+				Action("check", func() {
+					Routing(GET(""))
+				})
+			})
+		})
+
+		It("sets the effective rate limits", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			queries := Design.Resources["queries"]
+			rl := queries.Actions["list"].EffectiveRateLimit()
+			Ω(rl).Should(Equal(queries.RateLimit))
+			Ω(rl.Scope()).Should(Equal("queries"))
+			Ω(rl.Limit).Should(Equal(100))
+			Ω(rl.Period).Should(Equal(time.Minute))
+			Ω(rl.Algorithm).Should(Equal(SlidingWindow))
+			rl = queries.Actions["run"].EffectiveRateLimit()
+			Ω(rl.Scope()).Should(Equal("queries#run"))
+			Ω(rl.Algorithm).Should(Equal(TokenBucket))
+			rl = Design.Resources["health"].Actions["check"].EffectiveRateLimit()
+			Ω(rl.Scope()).Should(Equal("api"))
+		})
+	})
+
+	Context("with an invalid rate limit", func() {
+		BeforeEach(func() {
+			Resource("queries", func() {
+				RateLimit(0, time.Millisecond, func() {
+					Algorithm("leaky-bucket")
+				})
+			})
+		})
+
+		It("produces errors", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("invalid rate limit 0"))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("invalid rate limit period 1ms"))
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`invalid rate limit algorithm "leaky-bucket"`))
+		})
+	})
+})
@@ -0,0 +1,137 @@
+package apidsl
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Webhook defines the action as the endpoint of an inbound webhook: the requests made by a third
+// party service to notify the API of events. The provider is one of "github", "stripe" or "hmac"
+// (requests sent by goa callback dispatchers, see Callback) and determines how the requests are
+// signed and where the event name and delivery ID are read from. The webhook DSL lists the
+// handled events together with the types of their payloads:
+//
+//	Action("github", func() {
+//		Routing(POST("/hooks/github"))
+//		Webhook("github", func() {
+//			WebhookEvent("push", PushEvent)
+//			WebhookEvent("issues", IssuesEvent)
+//		})
+//		Response(NoContent)
+//	})
+//
+// goagen generates a receiver that verifies the request signatures prior to invoking the action,
+// methods that decode the event payloads into the event types and a dispatcher that calls the
+// handler of each event at most once using a goa.WebhookDeduper. Webhook actions cannot define a
+// payload and only accept POST requests.
+func Webhook(provider string, dsl ...func()) {
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to Webhook")
+		return
+	}
+	a, ok := actionDefinition(true)
+	if !ok {
+		return
+	}
+	w := &design.WebhookDefinition{
+		Provider:      provider,
+		SigningScheme: design.SigningHMACSHA256,
+		Parent:        a,
+	}
+	switch provider {
+	case design.WebhookProviderGitHub:
+		w.SignatureHeader = "X-Hub-Signature-256"
+		w.EventHeader = "X-GitHub-Event"
+		w.DeliveryHeader = "X-GitHub-Delivery"
+	case design.WebhookProviderStripe:
+		w.SignatureHeader = "Stripe-Signature"
+		w.Tolerance = design.DefaultWebhookTolerance
+	default:
+		w.SignatureHeader = design.DefaultSignatureHeader
+		w.EventHeader = "X-Webhook-Event"
+		w.DeliveryHeader = "X-Webhook-Delivery"
+	}
+	if len(dsl) == 1 && !dslengine.Execute(dsl[0], w) {
+		return
+	}
+	a.Webhook = w
+}
+
+// WebhookEvent defines an event handled by the webhook and the type of its payload. The type is
+// a user type, a media type or the name of a user type.
+// WebhookEvent may only appear in Webhook.
+func WebhookEvent(name string, t interface{}) {
+	w, ok := webhookDefinition(true)
+	if !ok {
+		return
+	}
+	if _, ok := w.Events[name]; ok {
+		dslengine.ReportError("event %#v is defined twice", name)
+		return
+	}
+	var ut *design.UserTypeDefinition
+	switch actual := t.(type) {
+	case *design.UserTypeDefinition:
+		ut = actual
+	case *design.MediaTypeDefinition:
+		ut = actual.UserTypeDefinition
+	case string:
+		if ut, ok = design.Design.Types[actual]; !ok {
+			dslengine.ReportError("unknown type %s", actual)
+			return
+		}
+	default:
+		dslengine.ReportError("invalid type of event %#v, must be a user type, a media type or the name of a user type", name)
+		return
+	}
+	if w.Events == nil {
+		w.Events = make(map[string]*design.UserTypeDefinition)
+	}
+	w.Events[name] = ut
+}
+
+// EventHeader sets the name of the header holding the event name of the webhook requests. It
+// defaults to "X-GitHub-Event" for GitHub webhooks and "X-Webhook-Event" for HMAC webhooks,
+// Stripe webhooks read the event name from the request body.
+// EventHeader may only appear in Webhook.
+func EventHeader(name string) {
+	if w, ok := webhookDefinition(true); ok {
+		w.EventHeader = name
+	}
+}
+
+// DeliveryHeader sets the name of the header holding the delivery ID of the webhook requests. It
+// defaults to "X-GitHub-Delivery" for GitHub webhooks and "X-Webhook-Delivery" for HMAC webhooks,
+// Stripe webhooks use the event ID read from the request body.
+// DeliveryHeader may only appear in Webhook.
+func DeliveryHeader(name string) {
+	if w, ok := webhookDefinition(true); ok {
+		w.DeliveryHeader = name
+	}
+}
+
+// Tolerance sets the maximum age of the timestamp of signed Stripe webhook requests, five minutes
+// by default. Zero disables the check. In HMACSecurity Tolerance sets the maximum difference
+// between the date of the signed requests and the server clock, five minutes by default. In
+// ReplayProtection Tolerance sets the maximum difference between the request timestamp and the
+// server clock, five minutes by default.
+// Tolerance may only appear in Webhook, HMACSecurity or ReplayProtection.
+func Tolerance(d time.Duration) {
+	if r, ok := replayDefinition(false); ok {
+		r.Tolerance = d
+		return
+	}
+	if s, ok := securitySchemeDefinition(false); ok {
+		if s.Kind != design.HMACSecurityKind {
+			dslengine.ReportError("Tolerance may only be used in HMACSecurity")
+			return
+		}
+		s.Tolerance = d
+		return
+	}
+	if w, ok := webhookDefinition(true); ok {
+		w.Tolerance = d
+	}
+}
@@ -0,0 +1,119 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webhook", func() {
+	var provider string
+	var dsl func()
+	var route func(string) *RouteDefinition
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		provider = "github"
+		route = POST
+		dsl = func() {
+			WebhookEvent("push", "PushEvent")
+		}
+	})
+
+	JustBeforeEach(func() {
+		Type("PushEvent", func() {
+			Attribute("ref", String)
+		})
+		Resource("hooks", func() {
+			Action("github", func() {
+				Routing(route("/github"))
+				Webhook(provider, dsl)
+			})
+		})
+		dslengine.Run()
+		if r, ok := Design.Resources["hooks"]; ok {
+			action = r.Actions["github"]
+		}
+	})
+
+	It("produces a webhook with the provider defaults", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		w := action.Webhook
+		Ω(w).ShouldNot(BeNil())
+		Ω(w.Provider).Should(Equal(WebhookProviderGitHub))
+		Ω(w.SigningScheme).Should(Equal(SigningHMACSHA256))
+		Ω(w.SignatureHeader).Should(Equal("X-Hub-Signature-256"))
+		Ω(w.EventHeader).Should(Equal("X-GitHub-Event"))
+		Ω(w.DeliveryHeader).Should(Equal("X-GitHub-Delivery"))
+		Ω(w.Events).Should(HaveKey("push"))
+		Ω(w.Events["push"].TypeName).Should(Equal("PushEvent"))
+	})
+
+	Context("with the Stripe provider", func() {
+		BeforeEach(func() {
+			provider = "stripe"
+		})
+
+		It("reads the event from the body", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action.Webhook.SignatureHeader).Should(Equal("Stripe-Signature"))
+			Ω(action.Webhook.EventHeader).Should(BeEmpty())
+			Ω(action.Webhook.Tolerance).Should(Equal(DefaultWebhookTolerance))
+		})
+	})
+
+	Context("with custom headers", func() {
+		BeforeEach(func() {
+			provider = "hmac"
+			dsl = func() {
+				Signing("hmac-sha512", "X-Signature")
+				EventHeader("X-Event")
+				DeliveryHeader("X-Delivery")
+				WebhookEvent("push", "PushEvent")
+			}
+		})
+
+		It("sets them", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			w := action.Webhook
+			Ω(w.SigningScheme).Should(Equal(SigningHMACSHA512))
+			Ω(w.SignatureHeader).Should(Equal("X-Signature"))
+			Ω(w.EventHeader).Should(Equal("X-Event"))
+			Ω(w.DeliveryHeader).Should(Equal("X-Delivery"))
+		})
+	})
+
+	Context("with an unknown provider", func() {
+		BeforeEach(func() {
+			provider = "bitbucket"
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with no event", func() {
+		BeforeEach(func() {
+			dsl = func() {}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a GET route", func() {
+		BeforeEach(func() {
+			route = GET
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,63 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// ReplayProtection requires the requests made to the actions of the API, resource or action where
+// it appears to carry the X-Request-Timestamp and X-Request-Nonce headers. The timestamp is the
+// time the request was made at in seconds since the Unix epoch, the nonce is a value unique to the
+// request. The generated code rejects the requests whose timestamp is too far from the server clock
+// and the requests whose nonce was already used, the generated clients set both headers. The
+// requests made to actions secured by a HMAC security scheme sign the headers so that they cannot
+// be tampered with. Action protections override resource protections which override the API
+// protection. The optional DSL sets the maximum difference between the request timestamp and the
+// server clock, five minutes by default:
+//
+//	Action("transfer", func() {
+//		ReplayProtection(func() {
+//			Tolerance(time.Minute)
+//		})
+//		...
+//	})
+//
+// The nonces are recorded in the replay cache set on the service with SetReplayCache, see
+// goa.ReplayCache.
+// ReplayProtection may appear in API, Resource or Action.
+func ReplayProtection(dsl ...func()) {
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to ReplayProtection")
+		return
+	}
+	var parent dslengine.Definition
+	a, isAPI := apiDefinition(false)
+	r, isResource := resourceDefinition(false)
+	var act *design.ActionDefinition
+	switch {
+	case isAPI:
+		parent = a
+	case isResource:
+		parent = r
+	default:
+		var ok bool
+		if act, ok = actionDefinition(true); !ok {
+			return
+		}
+		parent = act
+	}
+	replay := &design.ReplayDefinition{Parent: parent}
+	if len(dsl) == 1 {
+		if !dslengine.Execute(dsl[0], replay) {
+			return
+		}
+	}
+	switch {
+	case isAPI:
+		a.Replay = replay
+	case isResource:
+		r.Replay = replay
+	default:
+		act.Replay = replay
+	}
+}
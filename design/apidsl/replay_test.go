@@ -0,0 +1,73 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReplayProtection", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("in Resource and Action", func() {
+		BeforeEach(func() {
+			Resource("account", func() {
+				ReplayProtection()
+				Action("show", func() {
+					Routing(GET("/:id"))
+				})
+				Action("transfer", func() {
+					Routing(POST("/:id/transfer"))
+					ReplayProtection(func() {
+						Tolerance(time.Minute)
+					})
+				})
+			})
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/:id"))
+				})
+			})
+		})
+
+		It("sets the effective replay protections", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			account := Design.Resources["account"]
+			Ω(account.Actions["show"].EffectiveReplay()).Should(Equal(account.Replay))
+			Ω(account.Replay.Tolerance).Should(BeZero())
+			replay := account.Actions["transfer"].EffectiveReplay()
+			Ω(replay).ShouldNot(BeNil())
+			Ω(replay.Tolerance).Should(Equal(time.Minute))
+			Ω(Design.Resources["bottle"].Actions["show"].EffectiveReplay()).Should(BeNil())
+		})
+	})
+
+	Context("with a negative tolerance", func() {
+		BeforeEach(func() {
+			Resource("account", func() {
+				Action("transfer", func() {
+					Routing(POST("/:id/transfer"))
+					ReplayProtection(func() {
+						Tolerance(-time.Minute)
+					})
+				})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("cannot be negative"))
+		})
+	})
+})
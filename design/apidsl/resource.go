@@ -1,6 +1,8 @@
 package apidsl
 
 import (
+	"fmt"
+
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
 )
@@ -42,11 +44,12 @@ func Resource(name string, dsl func()) *design.ResourceDefinition {
 	}
 	var resource *design.ResourceDefinition
 	if dslengine.TopLevelDefinition(true) {
-		if _, ok := design.Design.Resources[name]; ok {
-			dslengine.ReportError("resource %#v is defined twice", name)
+		if first, ok := design.Design.Resources[name]; ok {
+			dslengine.ReportDuplicate(fmt.Sprintf("resource %#v", name), first)
 			return nil
 		}
 		resource = design.NewResourceDefinition(name, dsl)
+		resource.RecordLocation()
 		design.Design.Resources[name] = resource
 	}
 	return resource
@@ -261,3 +261,17 @@ var _ = Describe("Resource", func() {
 		})
 	})
 })
+
+var _ = Describe("Resource defined twice", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		Resource("foo", nil)
+		Resource("foo", nil)
+	})
+
+	It("reports the location of the first definition", func() {
+		Ω(dslengine.Errors).Should(HaveLen(1))
+		Ω(dslengine.Errors.Error()).Should(MatchRegexp(`resource "foo" is defined twice, first definition at .*resource_test\.go:\d+`))
+	})
+})
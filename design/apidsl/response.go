@@ -1,6 +1,8 @@
 package apidsl
 
 import (
+	"strings"
+
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
 )
@@ -70,6 +72,7 @@ func Response(name string, paramsAndDSL ...interface{}) {
 				resp.MediaType = a.Parent.MediaType
 			}
 			resp.Parent = a
+			resp.RecordLocation()
 			a.Responses[name] = resp
 		}
 	} else if r, ok := resourceDefinition(true); ok {
@@ -85,11 +88,37 @@ func Response(name string, paramsAndDSL ...interface{}) {
 				resp.MediaType = r.MediaType
 			}
 			resp.Parent = r
+			resp.RecordLocation()
 			r.Responses[name] = resp
 		}
 	}
 }
 
+// ProblemResponses makes the default error responses (status 4xx and 5xx) render RFC 7807 problem
+// details documents using the built-in ErrorMedia media type instead of plain text. The generated
+// main sets the service error handler to goa.ProblemErrorHandler so that the errors returned by
+// the controllers are rendered the same way:
+//
+//	var _ = API("cellar", func() {
+//		ProblemResponses()
+//	})
+//
+// Individual responses may also use ErrorMedia explicitly with Media(ErrorMedia).
+// ProblemResponses may only appear in API.
+func ProblemResponses() {
+	a, ok := apiDefinition(true)
+	if !ok {
+		return
+	}
+	a.ProblemResponses = true
+	a.UseErrorMedia()
+	for _, resp := range a.DefaultResponses {
+		if resp.Status >= 400 && resp.MediaType == "" {
+			resp.MediaType = design.ErrorMediaIdentifier
+		}
+	}
+}
+
 // Status sets the Response status.
 func Status(status int) {
 	if r, ok := responseDefinition(true); ok {
@@ -97,8 +126,155 @@ func Status(status int) {
 	}
 }
 
+// StatusRange makes the response cover a range of status codes given as "1xx", "2xx", "3xx",
+// "4xx" or "5xx". The generated response helpers accept the status code as first argument and
+// return an error if it does not belong to the range. The response status, if not in the range,
+// defaults to the first status code of the range (e.g. 200 for "2xx"), it is the status code
+// documented in the swagger specification:
+//
+//	Response("Success", func() {
+//		StatusRange("2xx")
+//		Media(BottleMedia)
+//	})
+func StatusRange(statusRange string) {
+	r, ok := responseDefinition(true)
+	if !ok {
+		return
+	}
+	if len(statusRange) != 3 || statusRange[0] < '1' || statusRange[0] > '5' ||
+		strings.ToLower(statusRange[1:]) != "xx" {
+		dslengine.ReportError("invalid status range %#v, must be one of \"1xx\", \"2xx\", \"3xx\", \"4xx\" or \"5xx\"", statusRange)
+		return
+	}
+	r.StatusRange = int(statusRange[0] - '0')
+	if r.Status/100 != r.StatusRange {
+		r.Status = r.StatusRange * 100
+	}
+}
+
+// RetryAfter defines the strategy used to compute the value of the Retry-After header sent with
+// the response. It is typically used with the TooManyRequests and ServiceUnavailable responses. The
+// strategy is one of:
+//
+//   - "fixed": the header value is always the given number of seconds.
+//   - "exponential": the header value starts with the given number of seconds and doubles with each
+//     consecutive rejection of the same principal by the action, optionally capped with a maximum
+//     number of seconds.
+//   - "context": the header value is the delay stored in the request context by the service, see
+//     goa.WithRetryAfter.
+//
+// RetryAfter also adds the Retry-After header to the response headers. Examples:
+//
+//	Response(TooManyRequests, func() {
+//		RetryAfter("fixed", 30)		// Retry after 30 seconds
+//	})
+//
+//	Response(ServiceUnavailable, func() {
+//		RetryAfter("exponential", 1, 60)	// Retry after 1, 2, 4... seconds up to a minute
+//	})
+func RetryAfter(strategy string, seconds ...int) {
+	r, ok := responseDefinition(true)
+	if !ok {
+		return
+	}
+	if len(seconds) > 2 {
+		dslengine.ReportError("too many arguments given to RetryAfter")
+		return
+	}
+	ra := &design.RetryAfterDefinition{Strategy: strategy}
+	if len(seconds) > 0 {
+		ra.Delay = seconds[0]
+	}
+	if len(seconds) > 1 {
+		ra.Max = seconds[1]
+	}
+	r.RetryAfter = ra
+	if r.Headers == nil {
+		r.Headers = &design.AttributeDefinition{Type: design.Object{}}
+	}
+	if obj := r.Headers.Type.ToObject(); obj != nil {
+		if _, ok := obj["Retry-After"]; !ok {
+			obj["Retry-After"] = &design.AttributeDefinition{
+				Type:        design.String,
+				Description: "Number of seconds to wait before retrying the request",
+			}
+		}
+	}
+}
+
+// Streaming turns the response into a stream of values of the response type written as they are
+// produced. The format is one of "sse" (server-sent events) or "json" (newline delimited JSON
+// documents), default is "sse". The optional heartbeat is the interval in seconds at which
+// keep-alive messages are written while no value is sent. Examples:
+//
+//	Response(OK, func() {
+//		Media(BottleMedia)
+//		Streaming()		// Server-sent events, no heartbeat
+//	})
+//
+//	Response(OK, func() {
+//		Media(BottleMedia)
+//		Streaming("json", 15)	// Newline delimited JSON with a heartbeat every 15 seconds
+//	})
+//
+// The generated context exposes response helpers that stream the values received on a channel.
+func Streaming(args ...interface{}) {
+	r, ok := responseDefinition(true)
+	if !ok {
+		return
+	}
+	if len(args) > 2 {
+		dslengine.ReportError("too many arguments given to Streaming")
+		return
+	}
+	st := &design.StreamingDefinition{Format: design.StreamingSSE}
+	if len(args) > 0 {
+		format, ok := args[0].(string)
+		if !ok {
+			dslengine.InvalidArgError("string", args[0])
+			return
+		}
+		st.Format = format
+	}
+	if len(args) > 1 {
+		heartbeat, ok := args[1].(int)
+		if !ok {
+			dslengine.InvalidArgError("int", args[1])
+			return
+		}
+		st.Heartbeat = heartbeat
+	}
+	r.Streaming = st
+}
+
+// UseResponseTemplate applies the response or response template with the given name to the
+// response being defined. It makes it possible to compose response templates from other templates.
+// The fields that are already set on the response take precedence over the fields defined by the
+// template so that the response DSL may override them by calling UseResponseTemplate first:
+//
+//	ResponseTemplate("JSONError", func(status int) {
+//		UseResponseTemplate("Error", status, "application/json", map[string]string{})
+//		Description("JSON error")
+//	})
+//
+// UseResponseTemplate may be used in ResponseTemplate and Response DSLs.
+func UseResponseTemplate(name string, params ...interface{}) {
+	r, ok := responseDefinition(true)
+	if !ok {
+		return
+	}
+	base := lookupResponse(name, params)
+	if base == nil {
+		return
+	}
+	if r.Type == nil {
+		r.Type = base.Type
+	}
+	r.Merge(base)
+}
+
 func executeResponseDSL(name string, paramsAndDSL ...interface{}) *design.ResponseDefinition {
-	var params []string
+	var params []interface{}
 	var dsl func()
 	var ok bool
 	var dt design.DataType
@@ -113,35 +289,11 @@ func executeResponseDSL(name string, paramsAndDSL ...interface{}) *design.Respon
 				paramsAndDSL = paramsAndDSL[1:]
 			}
 		}
-		params = make([]string, len(paramsAndDSL))
-		for i, p := range paramsAndDSL {
-			params[i], ok = p.(string)
-			if !ok {
-				dslengine.ReportError("invalid response template parameter %#v, must be a string", p)
-				return nil
-			}
-		}
+		params = paramsAndDSL
 	}
-	var resp *design.ResponseDefinition
-	if len(params) > 0 {
-		if tmpl, ok := design.Design.ResponseTemplates[name]; ok {
-			resp = tmpl.Template(params...)
-		} else if tmpl, ok := design.Design.DefaultResponseTemplates[name]; ok {
-			resp = tmpl.Template(params...)
-		} else {
-			dslengine.ReportError("no response template named %#v", name)
-			return nil
-		}
-	} else {
-		if ar, ok := design.Design.Responses[name]; ok {
-			resp = ar.Dup()
-			resp.Global = true
-		} else if ar, ok := design.Design.DefaultResponses[name]; ok {
-			resp = ar.Dup()
-			resp.Standard = true
-		} else {
-			resp = &design.ResponseDefinition{Name: name}
-		}
+	resp := lookupResponse(name, params)
+	if resp == nil {
+		return nil
 	}
 	if dsl != nil {
 		if !dslengine.Execute(dsl, resp) {
@@ -151,11 +303,41 @@ func executeResponseDSL(name string, paramsAndDSL ...interface{}) *design.Respon
 		resp.Global = false
 	}
 	if dt != nil {
+		if dt == design.ErrorMedia {
+			design.Design.UseErrorMedia()
+		}
 		resp.Type = dt
 	}
 	return resp
 }
 
+// lookupResponse returns a new response definition built from the response template with the
+// given name if params is not empty, from the API or default response with the given name
+// otherwise.
+func lookupResponse(name string, params []interface{}) *design.ResponseDefinition {
+	if len(params) > 0 {
+		if tmpl, ok := design.Design.ResponseTemplates[name]; ok {
+			return tmpl.Template(params...)
+		}
+		if tmpl, ok := design.Design.DefaultResponseTemplates[name]; ok {
+			return tmpl.Template(params...)
+		}
+		dslengine.ReportError("no response template named %#v", name)
+		return nil
+	}
+	if ar, ok := design.Design.Responses[name]; ok {
+		resp := ar.Dup()
+		resp.Global = true
+		return resp
+	}
+	if ar, ok := design.Design.DefaultResponses[name]; ok {
+		resp := ar.Dup()
+		resp.Standard = true
+		return resp
+	}
+	return &design.ResponseDefinition{Name: name}
+}
+
 // List of all built-in response names.
 const (
 	Continue           = "Continue"
@@ -196,6 +378,7 @@ const (
 	RequestedRangeNotSatisfiable = "RequestedRangeNotSatisfiable"
 	ExpectationFailed            = "ExpectationFailed"
 	Teapot                       = "Teapot"
+	TooManyRequests              = "TooManyRequests"
 	UnprocessableEntity          = "UnprocessableEntity"
 
 	InternalServerError     = "InternalServerError"
@@ -77,6 +77,155 @@ var _ = Describe("Response", func() {
 		})
 	})
 
+	Context("with a Retry-After strategy", func() {
+		BeforeEach(func() {
+			name = TooManyRequests
+			dsl = func() {
+				RetryAfter("exponential", 1, 60)
+			}
+		})
+
+		It("sets the Retry-After definition and header", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).ShouldNot(HaveOccurred())
+			Ω(res.Status).Should(Equal(429))
+			Ω(res.RetryAfter).Should(Equal(&RetryAfterDefinition{Strategy: RetryAfterExponential, Delay: 1, Max: 60}))
+			Ω(res.Headers).ShouldNot(BeNil())
+			Ω(res.Headers.Type.ToObject()).Should(HaveKey("Retry-After"))
+		})
+	})
+
+	Context("with an invalid Retry-After strategy", func() {
+		BeforeEach(func() {
+			name = ServiceUnavailable
+			dsl = func() {
+				RetryAfter("fixed")
+			}
+		})
+
+		It("produces an invalid response definition", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a streaming response", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Status(200)
+				Media("application/json")
+				Streaming("json", 15)
+			}
+		})
+
+		It("sets the streaming definition", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).ShouldNot(HaveOccurred())
+			Ω(res.Streaming).Should(Equal(&StreamingDefinition{Format: StreamingJSON, Heartbeat: 15}))
+		})
+	})
+
+	Context("with an unknown streaming format", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				Status(200)
+				Media("application/json")
+				Streaming("xml")
+			}
+		})
+
+		It("produces an invalid response definition", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a status range", func() {
+		BeforeEach(func() {
+			name = "Success"
+			dsl = func() {
+				StatusRange("2xx")
+				Media("application/json")
+			}
+		})
+
+		It("sets the range and defaults the status", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).ShouldNot(HaveOccurred())
+			Ω(res.StatusRange).Should(Equal(2))
+			Ω(res.StatusRangeName()).Should(Equal("2xx"))
+			Ω(res.Status).Should(Equal(200))
+		})
+	})
+
+	Context("with a status outside of the status range", func() {
+		BeforeEach(func() {
+			name = "Failure"
+			dsl = func() {
+				StatusRange("4xx")
+				Status(503)
+			}
+		})
+
+		It("produces an invalid response definition", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).Should(HaveOccurred())
+		})
+	})
+
+	Context("with an invalid status range", func() {
+		BeforeEach(func() {
+			name = "foo"
+			dsl = func() {
+				StatusRange("20x")
+			}
+		})
+
+		It("reports an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with alternate media types", func() {
+		BeforeEach(func() {
+			name = OK
+			MediaType("application/vnd.goa.error", func() {
+				Attributes(func() {
+					Attribute("msg")
+				})
+				View("default", func() {
+					Attribute("msg")
+				})
+			})
+			dsl = func() {
+				Media("application/json", "application/vnd.goa.error")
+			}
+		})
+
+		It("sets the alternate media types", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).ShouldNot(HaveOccurred())
+			Ω(res.MediaType).Should(Equal("application/json"))
+			Ω(res.MediaTypes()).Should(Equal([]string{"application/json", "application/vnd.goa.error"}))
+		})
+	})
+
+	Context("with an undefined alternate media type", func() {
+		BeforeEach(func() {
+			name = OK
+			dsl = func() {
+				Media("application/json", "application/vnd.goa.unknown")
+			}
+		})
+
+		It("produces an invalid response definition", func() {
+			Ω(res).ShouldNot(BeNil())
+			Ω(res.Validate()).Should(HaveOccurred())
+		})
+	})
+
 	Context("with a type override", func() {
 		const status = 201
 
@@ -0,0 +1,423 @@
+package apidsl
+
+import (
+	"fmt"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// JWTSecurity defines a security scheme that authenticates requests with a JSON Web Token. The
+// token is read from the "Authorization" header by default, Header or Query change the location.
+// The optional DSL may also list the scopes that the tokens may grant and the URL of the
+// endpoint issuing the tokens:
+//
+//	var JWT = JWTSecurity("jwt", func() {
+//		Description("Use the token issued by the signin endpoint")
+//		Header("Authorization")
+//		TokenURL("https://cellar.goa.design/signin")
+//		Scope("api:read", "Read access to the API")
+//		Scope("api:write", "Write access to the API")
+//	})
+//
+// The scheme is applied to the API, resources or actions with Security.
+// JWTSecurity may only appear at the top level.
+func JWTSecurity(name string, dsl ...func()) *design.SecuritySchemeDefinition {
+	s := newSecurityScheme(design.JWTSecurityKind, name, dsl)
+	if s != nil {
+		s.In = "header"
+		s.Name = "Authorization"
+	}
+	return s
+}
+
+// OIDCSecurity defines a security scheme that authenticates requests with an OpenID Connect ID
+// token issued by an external provider. The DSL must set the provider issuer URL and may set the
+// audience the tokens must be issued for. The token is read from the "Authorization" header by
+// default. Claims describes the token claims mapped into the principal type generated for the
+// scheme:
+//
+//	var Google = OIDCSecurity("google", func() {
+//		Issuer("https://accounts.google.com")
+//		Audience("cellar.apps.googleusercontent.com")
+//		Claims(func() {
+//			Attribute("email", String)
+//			Attribute("email_verified", Boolean)
+//		})
+//	})
+//
+// OIDCSecurity may only appear at the top level.
+func OIDCSecurity(name string, dsl ...func()) *design.SecuritySchemeDefinition {
+	s := newSecurityScheme(design.OIDCSecurityKind, name, dsl)
+	if s != nil {
+		s.In = "header"
+		s.Name = "Authorization"
+	}
+	return s
+}
+
+// APIKeySecurity defines a security scheme that authenticates requests with an API key read
+// from a header or a query string parameter. The DSL must use Header or Query to define the
+// location of the key:
+//
+//	var APIKey = APIKeySecurity("api_key", func() {
+//		Query("key")
+//	})
+//
+// KeyManagement makes the service issue and revoke the keys itself, the scheme may then define
+// the scopes the keys grant with Scope.
+//
+// APIKeySecurity may only appear at the top level.
+func APIKeySecurity(name string, dsl ...func()) *design.SecuritySchemeDefinition {
+	return newSecurityScheme(design.APIKeySecurityKind, name, dsl)
+}
+
+// BasicAuthSecurity defines a security scheme that authenticates requests with HTTP basic
+// authentication:
+//
+//	var Basic = BasicAuthSecurity("basic", func() {
+//		Description("Use your account email and password")
+//	})
+//
+// BasicAuthSecurity may only appear at the top level.
+func BasicAuthSecurity(name string, dsl ...func()) *design.SecuritySchemeDefinition {
+	return newSecurityScheme(design.BasicAuthSecurityKind, name, dsl)
+}
+
+// OAuth2Security defines a security scheme that authenticates requests with an OAuth2 bearer
+// access token. The DSL must define the flow used to obtain the tokens with AccessCodeFlow,
+// ImplicitFlow, PasswordFlow or ApplicationFlow and may list the scopes:
+//
+//	var OAuth2 = OAuth2Security("oauth2", func() {
+//		AccessCodeFlow("https://cellar.goa.design/authorize", "https://cellar.goa.design/token")
+//		Scope("api:read", "Read access to the API")
+//	})
+//
+// OAuth2Security may only appear at the top level.
+func OAuth2Security(name string, dsl ...func()) *design.SecuritySchemeDefinition {
+	return newSecurityScheme(design.OAuth2SecurityKind, name, dsl)
+}
+
+// HMACSecurity defines a security scheme that authenticates requests with a HMAC-SHA256 signature
+// computed with a secret shared with the client over the canonical request: the request method,
+// path, query string, Date header and body digest. The signature is read from the "Authorization"
+// header by default, Header changes the header and Tolerance the maximum difference between the
+// request date and the server clock:
+//
+//	var Signed = HMACSecurity("signed", func() {
+//		Description("Sign the requests with your access key")
+//		Header("X-Signature")
+//		Tolerance(2 * time.Minute)
+//	})
+//
+// The generated clients sign the requests made to the actions secured by the scheme.
+// HMACSecurity may only appear at the top level.
+func HMACSecurity(name string, dsl ...func()) *design.SecuritySchemeDefinition {
+	s := newSecurityScheme(design.HMACSecurityKind, name, dsl)
+	if s != nil {
+		s.In = "header"
+		s.Name = "Authorization"
+	}
+	return s
+}
+
+// MutualTLSSecurity defines a security scheme that authenticates requests with the certificate
+// presented by the client during the TLS handshake. ClientCA sets the file containing the
+// certificates of the authorities that issue the client certificates:
+//
+//	var Partners = MutualTLSSecurity("partners", func() {
+//		Description("Use the certificate issued to your organization")
+//		ClientCA("partners-ca.pem")
+//	})
+//
+// The generated main function configures the TLS listeners to request and verify client
+// certificates, requests made to the actions secured by the scheme are rejected if the client
+// did not present a valid certificate. The API must be served over TLS.
+// MutualTLSSecurity may only appear at the top level.
+func MutualTLSSecurity(name string, dsl ...func()) *design.SecuritySchemeDefinition {
+	return newSecurityScheme(design.MutualTLSSecurityKind, name, dsl)
+}
+
+// Security sets the security scheme used to authenticate the requests made to the API,
+// resource or action. The scheme is given either as the value returned by one of the
+// security scheme DSLs or as the scheme name. The optional DSL lists the scopes the requests
+// must be granted:
+//
+//	Action("update", func() {
+//		Security(JWT, func() {
+//			Scope("api:write")
+//		})
+//	})
+//
+// Resource security overrides the API security and action security overrides both. Security may
+// appear in API, Resource or Action.
+func Security(scheme interface{}, dsl ...func()) {
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to Security")
+		return
+	}
+	var def *design.SecuritySchemeDefinition
+	switch s := scheme.(type) {
+	case *design.SecuritySchemeDefinition:
+		def = s
+	case string:
+		def = design.Design.SecuritySchemes[s]
+		if def == nil {
+			dslengine.ReportError("unknown security scheme %#v", s)
+			return
+		}
+	default:
+		dslengine.InvalidArgError("security scheme or security scheme name", scheme)
+		return
+	}
+	if def == nil {
+		return
+	}
+	sec := &design.SecurityDefinition{Scheme: def}
+	if a, ok := apiDefinition(false); ok {
+		sec.Parent = a
+		a.Security = sec
+	} else if r, ok := resourceDefinition(false); ok {
+		sec.Parent = r
+		r.Security = sec
+	} else if a, ok := actionDefinition(true); ok {
+		sec.Parent = a
+		a.Security = sec
+	} else {
+		return
+	}
+	if len(dsl) == 1 {
+		dslengine.Execute(dsl[0], sec)
+	}
+}
+
+// NoSecurity disables the security of the resource or action, the requests are not
+// authenticated even if the API or parent resource defines a security scheme.
+// NoSecurity may appear in Resource or Action.
+func NoSecurity() {
+	if r, ok := resourceDefinition(false); ok {
+		r.Security = &design.SecurityDefinition{Parent: r}
+	} else if a, ok := actionDefinition(true); ok {
+		a.Security = &design.SecurityDefinition{Parent: a}
+	}
+}
+
+// Scope defines a scope in JWTSecurity, OIDCSecurity, OAuth2Security or in an APIKeySecurity that
+// uses KeyManagement, the optional argument is the scope description. In Security Scope adds a scope to the list of scopes the requests must be
+// granted.
+func Scope(name string, desc ...string) {
+	if s, ok := securitySchemeDefinition(false); ok {
+		if len(desc) > 1 {
+			dslengine.ReportError("too many arguments given to Scope")
+			return
+		}
+		if s.Scopes == nil {
+			s.Scopes = make(map[string]string)
+		}
+		var d string
+		if len(desc) == 1 {
+			d = desc[0]
+		}
+		s.Scopes[name] = d
+	} else if sec, ok := securityDefinition(true); ok {
+		if len(desc) > 0 {
+			dslengine.ReportError("scope descriptions may only be given in security schemes")
+			return
+		}
+		sec.Scopes = append(sec.Scopes, name)
+	}
+}
+
+// Query sets the name of the query string parameter holding the credentials.
+// Query may only appear in JWTSecurity, OIDCSecurity or APIKeySecurity.
+func Query(name string) {
+	if s, ok := securitySchemeDefinition(true); ok {
+		setCredentialsLocation(s, "query", name, nil)
+	}
+}
+
+// TokenURL sets the URL of the endpoint issuing the tokens.
+// TokenURL may only appear in JWTSecurity.
+func TokenURL(url string) {
+	if s, ok := securitySchemeDefinition(true); ok {
+		if s.Kind != design.JWTSecurityKind {
+			dslengine.ReportError("TokenURL may only be used in JWTSecurity, use the flow DSLs in OAuth2Security")
+			return
+		}
+		s.TokenURL = url
+	}
+}
+
+// Issuer sets the URL of the OpenID Connect provider issuing the tokens. The provider discovery
+// document is retrieved from the "/.well-known/openid-configuration" path of the URL.
+// Issuer may only appear in OIDCSecurity.
+func Issuer(url string) {
+	if s, ok := oidcSchemeDefinition("Issuer"); ok {
+		s.Issuer = url
+	}
+}
+
+// Audience sets the audience the tokens must be issued for.
+// Audience may only appear in OIDCSecurity.
+func Audience(aud string) {
+	if s, ok := oidcSchemeDefinition("Audience"); ok {
+		s.Audience = aud
+	}
+}
+
+// Claims describes the token claims mapped into the principal type generated for the scheme. The
+// DSL defines one attribute per claim using the claim name.
+// Claims may only appear in JWTSecurity or OIDCSecurity.
+func Claims(dsl func()) {
+	s, ok := securitySchemeDefinition(true)
+	if !ok {
+		return
+	}
+	if !s.HasClaims() {
+		dslengine.ReportError("Claims may only be used in JWTSecurity or OIDCSecurity")
+		return
+	}
+	claims := newAttribute("")
+	if dslengine.Execute(dsl, claims) {
+		s.Claims = claims
+	}
+}
+
+// AccessCodeFlow sets the OAuth2 flow of the scheme to the authorization code flow.
+// AccessCodeFlow may only appear in OAuth2Security.
+func AccessCodeFlow(authorizationURL, tokenURL string) {
+	setOAuth2Flow(design.OAuth2AccessCodeFlow, authorizationURL, tokenURL)
+}
+
+// ImplicitFlow sets the OAuth2 flow of the scheme to the implicit flow.
+// ImplicitFlow may only appear in OAuth2Security.
+func ImplicitFlow(authorizationURL string) {
+	setOAuth2Flow(design.OAuth2ImplicitFlow, authorizationURL, "")
+}
+
+// PasswordFlow sets the OAuth2 flow of the scheme to the resource owner password credentials
+// flow.
+// PasswordFlow may only appear in OAuth2Security.
+func PasswordFlow(tokenURL string) {
+	setOAuth2Flow(design.OAuth2PasswordFlow, "", tokenURL)
+}
+
+// ApplicationFlow sets the OAuth2 flow of the scheme to the client credentials flow.
+// ApplicationFlow may only appear in OAuth2Security.
+func ApplicationFlow(tokenURL string) {
+	setOAuth2Flow(design.OAuth2ApplicationFlow, "", tokenURL)
+}
+
+// ClientCA sets the path to the PEM file containing the certificates of the authorities that
+// issue the client certificates.
+// ClientCA may only appear in MutualTLSSecurity.
+func ClientCA(file string) {
+	if s, ok := securitySchemeDefinition(true); ok {
+		if s.Kind != design.MutualTLSSecurityKind {
+			dslengine.ReportError("ClientCA may only be used in MutualTLSSecurity")
+			return
+		}
+		s.ClientCA = file
+	}
+}
+
+// KeyManagement makes the service manage the keys of the scheme: goagen generates the code that
+// validates the keys issued by an apikey.Manager and mounts the key administration endpoints
+// under the given path. The keys grant the scopes defined with Scope:
+//
+//	var APIKey = APIKeySecurity("api_key", func() {
+//		Header("X-API-Key")
+//		KeyManagement("/keys")
+//		Scope("api:read", "Read access")
+//	})
+//
+// KeyManagement may only appear in APIKeySecurity.
+func KeyManagement(path string) {
+	if s, ok := securitySchemeDefinition(true); ok {
+		if s.Kind != design.APIKeySecurityKind {
+			dslengine.ReportError("KeyManagement may only be used in APIKeySecurity")
+			return
+		}
+		s.KeysPath = path
+	}
+}
+
+// newSecurityScheme records a new top level security scheme definition.
+func newSecurityScheme(kind, name string, dsl []func()) *design.SecuritySchemeDefinition {
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to security scheme %#v", name)
+		return nil
+	}
+	if !dslengine.TopLevelDefinition(true) {
+		return nil
+	}
+	if design.Design.SecuritySchemes == nil {
+		design.Design.SecuritySchemes = make(map[string]*design.SecuritySchemeDefinition)
+	}
+	if first, ok := design.Design.SecuritySchemes[name]; ok {
+		dslengine.ReportDuplicate(fmt.Sprintf("security scheme %#v", name), first)
+		return nil
+	}
+	s := &design.SecuritySchemeDefinition{Kind: kind, SchemeName: name}
+	if len(dsl) == 1 {
+		s.DSLFunc = dsl[0]
+	}
+	s.RecordLocation()
+	design.Design.SecuritySchemes[name] = s
+	return s
+}
+
+// setCredentialsLocation sets the location of the credentials of JWT, OIDC, API key and HMAC
+// schemes.
+func setCredentialsLocation(s *design.SecuritySchemeDefinition, in, name string, args []interface{}) {
+	if len(args) > 0 {
+		dslengine.ReportError("too many arguments given to Header in security scheme")
+		return
+	}
+	switch s.Kind {
+	case design.JWTSecurityKind, design.OIDCSecurityKind, design.APIKeySecurityKind:
+	case design.HMACSecurityKind:
+		if in != "header" {
+			dslengine.ReportError("HMACSecurity signatures may only be given in a header")
+			return
+		}
+	default:
+		dslengine.ReportError("credentials location may only be set in JWTSecurity, OIDCSecurity, APIKeySecurity or HMACSecurity")
+		return
+	}
+	s.In = in
+	s.Name = name
+}
+
+// oidcSchemeDefinition returns the current OIDC security scheme definition, it reports an error
+// mentioning the DSL function with the given name if there is none.
+func oidcSchemeDefinition(fn string) (*design.SecuritySchemeDefinition, bool) {
+	s, ok := securitySchemeDefinition(true)
+	if !ok {
+		return nil, false
+	}
+	if s.Kind != design.OIDCSecurityKind {
+		dslengine.ReportError("%s may only be used in OIDCSecurity", fn)
+		return nil, false
+	}
+	return s, true
+}
+
+// setOAuth2Flow sets the flow of the current OAuth2 security scheme.
+func setOAuth2Flow(flow, authorizationURL, tokenURL string) {
+	s, ok := securitySchemeDefinition(true)
+	if !ok {
+		return
+	}
+	if s.Kind != design.OAuth2SecurityKind {
+		dslengine.ReportError("OAuth2 flows may only be used in OAuth2Security")
+		return
+	}
+	if s.Flow != "" {
+		dslengine.ReportError("OAuth2 flow already set to %#v", s.Flow)
+		return
+	}
+	s.Flow = flow
+	s.AuthorizationURL = authorizationURL
+	s.TokenURL = tokenURL
+}
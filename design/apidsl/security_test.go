@@ -0,0 +1,339 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Security", func() {
+	var schemeDSL func()
+	var resourceDSL func()
+	var scheme *SecuritySchemeDefinition
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		schemeDSL = func() {
+			Scope("api:read", "Read access")
+			Scope("api:write", "Write access")
+		}
+		resourceDSL = nil
+	})
+
+	JustBeforeEach(func() {
+		scheme = JWTSecurity("jwt", schemeDSL)
+		API("cellar", func() {
+			Security(scheme, func() {
+				Scope("api:read")
+			})
+		})
+		Resource("bottle", func() {
+			if resourceDSL != nil {
+				resourceDSL()
+			}
+			Action("show", func() {
+				Routing(GET("/bottles/:id"))
+			})
+			Action("update", func() {
+				Routing(PUT("/bottles/:id"))
+				Security("jwt", func() {
+					Scope("api:write")
+				})
+			})
+		})
+		dslengine.Run()
+		if r, ok := Design.Resources["bottle"]; ok {
+			action = r.Actions["show"]
+		}
+	})
+
+	It("records the scheme and the requirements", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(Design.SecuritySchemes).Should(HaveKeyWithValue("jwt", scheme))
+		Ω(scheme.Kind).Should(Equal(JWTSecurityKind))
+		Ω(scheme.In).Should(Equal("header"))
+		Ω(scheme.Name).Should(Equal("Authorization"))
+		Ω(scheme.Scopes).Should(HaveLen(2))
+		Ω(action.EffectiveSecurity().Scopes).Should(Equal([]string{"api:read"}))
+		update := Design.Resources["bottle"].Actions["update"]
+		Ω(update.EffectiveSecurity().Scheme).Should(Equal(scheme))
+		Ω(update.EffectiveSecurity().Scopes).Should(Equal([]string{"api:write"}))
+	})
+
+	Context("with a query string token", func() {
+		BeforeEach(func() {
+			schemeDSL = func() {
+				Query("token")
+				Scope("api:read")
+				Scope("api:write")
+			}
+		})
+
+		It("records the location", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(scheme.In).Should(Equal("query"))
+			Ω(scheme.Name).Should(Equal("token"))
+		})
+	})
+
+	Context("with an unknown scope", func() {
+		BeforeEach(func() {
+			schemeDSL = func() {
+				Scope("api:read")
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`scope "api:write" is not defined`))
+		})
+	})
+
+	Context("with NoSecurity", func() {
+		BeforeEach(func() {
+			resourceDSL = func() {
+				NoSecurity()
+			}
+		})
+
+		It("disables security", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action.EffectiveSecurity()).Should(BeNil())
+		})
+	})
+})
+
+var _ = Describe("OAuth2Security", func() {
+	var dsl func()
+	var scheme *SecuritySchemeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		scheme = OAuth2Security("oauth2", dsl)
+		dslengine.Run()
+	})
+
+	Context("with a flow", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				AccessCodeFlow("https://cellar.goa.design/authorize", "https://cellar.goa.design/token")
+			}
+		})
+
+		It("records the flow", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(scheme.Flow).Should(Equal(OAuth2AccessCodeFlow))
+			Ω(scheme.AuthorizationURL).Should(Equal("https://cellar.goa.design/authorize"))
+			Ω(scheme.TokenURL).Should(Equal("https://cellar.goa.design/token"))
+		})
+	})
+
+	Context("with no flow", func() {
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("missing OAuth2 flow"))
+		})
+	})
+})
+
+var _ = Describe("OIDCSecurity", func() {
+	var dsl func()
+	var scheme *SecuritySchemeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		dsl = func() {
+			Issuer("https://accounts.google.com")
+			Audience("cellar")
+			Claims(func() {
+				Attribute("email", String)
+				Attribute("groups", ArrayOf(String))
+			})
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme = OIDCSecurity("google", dsl)
+		dslengine.Run()
+	})
+
+	It("records the issuer, the audience and the claims", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(scheme.Kind).Should(Equal(OIDCSecurityKind))
+		Ω(scheme.Issuer).Should(Equal("https://accounts.google.com"))
+		Ω(scheme.Audience).Should(Equal("cellar"))
+		Ω(scheme.Name).Should(Equal("Authorization"))
+		Ω(scheme.Claims.Type.ToObject()).Should(HaveKey("email"))
+		Ω(scheme.Claims.Type.ToObject()).Should(HaveKey("groups"))
+	})
+
+	Context("with no issuer", func() {
+		BeforeEach(func() {
+			dsl = nil
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("absolute issuer URL"))
+		})
+	})
+})
+
+var _ = Describe("HMACSecurity", func() {
+	var dsl func()
+	var scheme *SecuritySchemeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		dsl = func() {
+			Header("X-Signature")
+			Tolerance(2 * time.Minute)
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme = HMACSecurity("signed", dsl)
+		dslengine.Run()
+	})
+
+	It("records the header and the tolerance", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(scheme.Kind).Should(Equal(HMACSecurityKind))
+		Ω(scheme.In).Should(Equal("header"))
+		Ω(scheme.Name).Should(Equal("X-Signature"))
+		Ω(scheme.Tolerance).Should(Equal(2 * time.Minute))
+	})
+
+	Context("with a query string signature", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Query("sig")
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("may only be given in a header"))
+		})
+	})
+})
+
+var _ = Describe("KeyManagement", func() {
+	var dsl func()
+	var scheme *SecuritySchemeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		dsl = func() {
+			Header("X-API-Key")
+			KeyManagement("/keys")
+			Scope("api:read", "Read access")
+		}
+	})
+
+	JustBeforeEach(func() {
+		API("cellar", func() {})
+		scheme = APIKeySecurity("api_key", dsl)
+		dslengine.Run()
+	})
+
+	It("records the key administration path and the scopes", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(scheme.KeysPath).Should(Equal("/keys"))
+		Ω(scheme.ManagesKeys()).Should(BeTrue())
+		Ω(scheme.Scopes).Should(HaveKey("api:read"))
+	})
+
+	Context("with scopes but no key management", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Header("X-API-Key")
+				Scope("api:read")
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("scopes are only supported"))
+		})
+	})
+
+	Context("with a relative path", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Header("X-API-Key")
+				KeyManagement("keys")
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("must start with /"))
+		})
+	})
+})
+
+var _ = Describe("MutualTLSSecurity", func() {
+	var dsl func()
+	var scheme *SecuritySchemeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		dsl = func() {
+			ClientCA("ca.pem")
+		}
+	})
+
+	JustBeforeEach(func() {
+		API("partners", func() {
+			Scheme("https")
+		})
+		scheme = MutualTLSSecurity("partners", dsl)
+		dslengine.Run()
+	})
+
+	It("records the client certificate authorities", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(scheme.Kind).Should(Equal(MutualTLSSecurityKind))
+		Ω(scheme.ClientCA).Should(Equal("ca.pem"))
+	})
+
+	Context("with no client certificate authorities", func() {
+		BeforeEach(func() {
+			dsl = nil
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("use ClientCA"))
+		})
+	})
+
+	Context("with a credentials location", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				ClientCA("ca.pem")
+				Header("X-Cert")
+			}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
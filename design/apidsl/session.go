@@ -0,0 +1,115 @@
+package apidsl
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Session defines the browser session of the actions of the API or resource where it appears. The
+// session state is kept in a cookie signed with the keys set on the service with SetSessionConfig,
+// the DSL describes the state fields with Attribute and may set the cookie name, lifetime and
+// whether its content is encrypted:
+//
+//	Resource("account", func() {
+//		Session(func() {
+//			Cookie("account_session")
+//			MaxAge(24 * time.Hour)
+//			Encrypted()
+//			Attribute("user_id", Integer)
+//			Attribute("flash", String)
+//		})
+//		...
+//	})
+//
+// The generated contexts of the actions using a session expose the typed Session, SaveSession and
+// ClearSession methods. Resource sessions override the API session.
+// Session may appear in API or Resource.
+func Session(dsl func()) {
+	var parent dslengine.Definition
+	a, isAPI := apiDefinition(false)
+	var r *design.ResourceDefinition
+	if isAPI {
+		parent = a
+	} else {
+		var ok bool
+		if r, ok = resourceDefinition(true); !ok {
+			return
+		}
+		parent = r
+	}
+	session := &design.SessionDefinition{Parent: parent}
+	if !dslengine.Execute(dsl, session) {
+		return
+	}
+	if isAPI {
+		a.Session = session
+	} else {
+		r.Session = session
+	}
+}
+
+// Cookie sets the name of the session cookie, "session" by default.
+// Cookie may only appear in Session.
+func Cookie(name string) {
+	if s, ok := sessionDefinition(true); ok {
+		s.CookieName = name
+	}
+}
+
+// MaxAge sets the lifetime of the session cookie. By default the cookie expires when the browser
+// is closed. In CacheControl MaxAge sets the max-age directive, the duration for which the response
+// is fresh.
+// MaxAge may appear in Session or CacheControl.
+func MaxAge(d time.Duration) {
+	if s, ok := sessionDefinition(false); ok {
+		s.MaxAge = d
+	} else if cc, ok := cacheControlDefinition(true); ok {
+		cc.MaxAge = d
+	}
+}
+
+// Encrypted causes the content of the session cookie to be encrypted in addition to being signed
+// so that it cannot be read by the browser when used in Session.
+//
+// When used in an attribute Encrypted causes the generated code to encrypt the values of the
+// attribute received in the request payloads and to decrypt the values sent in the responses with
+// the Crypter set on the service, see goa.Service.SetCrypter. The handlers thus only see the
+// ciphertext which they can store as is. Encrypted attributes must be strings and are sensitive,
+// see Sensitive.
+// Encrypted may appear in Session or in an attribute.
+func Encrypted() {
+	if s, ok := sessionDefinition(false); ok {
+		s.Encrypt = true
+		return
+	}
+	if a, ok := attributeDefinition(true); ok {
+		if a.Metadata == nil {
+			a.Metadata = make(dslengine.MetadataDefinition)
+		}
+		a.Metadata[design.EncryptedKey] = nil
+	}
+}
+
+// CSRF protects the actions of the API, resource or action where it appears against cross-site
+// request forgery. The generated code issues a random token in the "csrf_token" cookie and rejects
+// the requests made with unsafe methods (POST, PUT, PATCH, DELETE...) that do not carry the same
+// token in the X-CSRF-Token header or in the "csrf_token" form field with a 403 response. The
+// handlers retrieve the token to embed in pages with goa.CSRFToken. Action protections override
+// resource protections which override the API protection.
+// CSRF may appear in API, Resource or Action.
+func CSRF() {
+	a, isAPI := apiDefinition(false)
+	r, isResource := resourceDefinition(false)
+	switch {
+	case isAPI:
+		a.CSRF = &design.CSRFDefinition{Parent: a}
+	case isResource:
+		r.CSRF = &design.CSRFDefinition{Parent: r}
+	default:
+		if act, ok := actionDefinition(true); ok {
+			act.CSRF = &design.CSRFDefinition{Parent: act}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package apidsl_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("in API and Resource", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				Session(func() {
+					Attribute("user_id", Integer)
+				})
+				CSRF()
+			})
+			Resource("account", func() {
+				Session(func() {
+					Cookie("account_session")
+					MaxAge(time.Hour)
+					Encrypted()
+					Attribute("flash", String)
+				})
+				Action("show", func() {
+					Routing(GET("/:id"))
+				})
+			})
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/:id"))
+				})
+			})
+		})
+
+		It("sets the effective sessions", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			session := Design.Resources["account"].Actions["show"].EffectiveSession()
+			Ω(session).ShouldNot(BeNil())
+			Ω(session.Name()).Should(Equal("account_session"))
+			Ω(session.MaxAge).Should(Equal(time.Hour))
+			Ω(session.Encrypt).Should(BeTrue())
+			Ω(session.Attributes.Type.ToObject()).Should(HaveKey("flash"))
+			session = Design.Resources["bottle"].Actions["show"].EffectiveSession()
+			Ω(session).Should(Equal(Design.Session))
+			Ω(session.Name()).Should(Equal(DefaultSessionCookie))
+			Ω(session.Attributes.Type.ToObject()).Should(HaveKey("user_id"))
+		})
+
+		It("sets the effective CSRF protections", func() {
+			Ω(Design.Resources["bottle"].Actions["show"].EffectiveCSRF()).Should(Equal(Design.CSRF))
+		})
+	})
+
+	Context("with a negative max age", func() {
+		BeforeEach(func() {
+			Resource("account", func() {
+				Session(func() {
+					MaxAge(-time.Hour)
+				})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("cannot be negative"))
+		})
+	})
+
+	Context("in Action", func() {
+		BeforeEach(func() {
+			Resource("account", func() {
+				Action("show", func() {
+					Routing(GET("/:id"))
+					Session(func() {})
+				})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
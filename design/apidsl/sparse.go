@@ -0,0 +1,26 @@
+package apidsl
+
+// SparseFields makes the resource or action accept the fields query parameter which lists the
+// attributes rendered in the responses, e.g. "?fields=id,name". This lets clients request smaller
+// payloads without defining a view per combination of attributes:
+//
+//	Resource("bottle", func() {
+//		SparseFields()
+//		Action("show", func() {
+//			Routing(GET("/:id"))
+//			Response(OK, func() { Media(BottleMedia) })
+//		})
+//	})
+//
+// The legal attribute names are the names of the attributes of the views of the response media
+// types. The generated context rejects requests that list other names with a 400 response and
+// exposes the list in its Fields field, the media type response helpers only render the listed
+// attributes.
+// SparseFields may appear in Resource or Action.
+func SparseFields() {
+	if r, ok := resourceDefinition(false); ok {
+		r.SparseFields = true
+	} else if a, ok := actionDefinition(true); ok {
+		a.SparseFields = true
+	}
+}
@@ -0,0 +1,113 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SparseFields", func() {
+	var bottle *MediaTypeDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		bottle = MediaType("application/vnd.bottle", func() {
+			Attributes(func() {
+				Attribute("id", Integer)
+				Attribute("name", String)
+				Attribute("vintage", Integer)
+				Attribute("href", String)
+			})
+			View("default", func() {
+				Attribute("id")
+				Attribute("name")
+				Attribute("vintage")
+			})
+			View("tiny", func() {
+				Attribute("id")
+				Attribute("name")
+			})
+			View("link", func() {
+				Attribute("href")
+			})
+		})
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("with sparse resources and actions", func() {
+		BeforeEach(func() {
+			Resource("bottle", func() {
+				SparseFields()
+				Action("show", func() {
+					Routing(GET("/bottles/:id"))
+					Response(OK, func() { Media(bottle) })
+				})
+			})
+			Resource("cellar", func() {
+				Action("list", func() {
+					Routing(GET("/bottles"))
+					SparseFields()
+					Response(OK, func() { Media(CollectionOf(bottle)) })
+				})
+				Action("count", func() {
+					Routing(GET("/count"))
+					Response(OK)
+				})
+			})
+		})
+
+		It("lists the legal field names", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			show := Design.Resources["bottle"].Actions["show"]
+			Ω(show.HasSparseFields()).Should(BeTrue())
+			Ω(show.SparseFieldNames()).Should(Equal([]string{"id", "name", "vintage"}))
+			list := Design.Resources["cellar"].Actions["list"]
+			Ω(list.HasSparseFields()).Should(BeTrue())
+			Ω(list.SparseFieldNames()).Should(Equal([]string{"id", "name", "vintage"}))
+			Ω(Design.Resources["cellar"].Actions["count"].HasSparseFields()).Should(BeFalse())
+		})
+	})
+
+	Context("with an action without media type", func() {
+		BeforeEach(func() {
+			Resource("cellar", func() {
+				Action("count", func() {
+					Routing(GET("/count"))
+					SparseFields()
+					Response(OK)
+				})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("sparse fieldsets require a response with a media type"))
+		})
+	})
+
+	Context("with a fields parameter", func() {
+		BeforeEach(func() {
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/bottles/:id"))
+					Params(func() {
+						Param("fields", String)
+					})
+					SparseFields()
+					Response(OK, func() { Media(bottle) })
+				})
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`sparse fieldsets conflict with parameter "fields"`))
+		})
+	})
+})
@@ -0,0 +1,51 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Subscribable lets clients subscribe to the given events of the resource. The events are
+// defined with Event and given by name or by definition:
+//
+//	var OrderShipped = Event("OrderShipped", func() {
+//		Topic("orders.shipped")
+//		Payload(OrderShippedPayload)
+//	})
+//
+//	Resource("order", func() {
+//		BasePath("/orders")
+//		Subscribable(OrderShipped, "OrderCanceled")
+//	})
+//
+// goagen generates a function that mounts the subscription endpoints under
+// "<resource base path>/subscriptions": POST creates a subscription, GET lists the subscriptions
+// or - if the request asks for a WebSocket upgrade - streams the events, GET and DELETE on
+// "/subscriptions/:id" show and delete a subscription. Subscriptions either register a callback
+// URL the events are posted to or are bound to the WebSocket connection. The events are published
+// to the subscribers with the Publish method of subscription.Manager.
+// Subscribable may only appear in Resource.
+func Subscribable(events ...interface{}) {
+	r, ok := resourceDefinition(true)
+	if !ok {
+		return
+	}
+	if r.Subscriptions == nil {
+		r.Subscriptions = &design.SubscriptionsDefinition{
+			Path:   design.DefaultSubscriptionsPath,
+			Parent: r,
+		}
+	}
+	for _, e := range events {
+		switch actual := e.(type) {
+		case string:
+			r.Subscriptions.Events = append(r.Subscriptions.Events, actual)
+		case *design.EventDefinition:
+			if actual != nil {
+				r.Subscriptions.Events = append(r.Subscriptions.Events, actual.Name)
+			}
+		default:
+			dslengine.ReportError("invalid event %#v, must be the name or the definition of an event", e)
+		}
+	}
+}
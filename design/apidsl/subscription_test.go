@@ -0,0 +1,77 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Subscribable", func() {
+	var basePath string
+	var events []interface{}
+	var res *ResourceDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		basePath = "/orders"
+		shipped := Event("OrderShipped", func() {
+			Topic("orders.shipped")
+			Payload(func() {
+				Member("orderID", String)
+			})
+		})
+		events = []interface{}{shipped, "OrderShipped2"}
+	})
+
+	JustBeforeEach(func() {
+		API("test", func() {
+			BasePath("/api")
+		})
+		Event("OrderShipped2", func() {
+			Topic("orders.shipped2")
+			Payload(func() {
+				Member("orderID", String)
+			})
+		})
+		Resource("order", func() {
+			BasePath(basePath)
+			Subscribable(events...)
+			Action("show", func() {
+				Routing(GET("/:id"))
+				Response(NoContent)
+			})
+		})
+		dslengine.Run()
+		res = Design.Resources["order"]
+	})
+
+	It("makes the resource subscribable", func() {
+		Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+		Ω(res.IsSubscribable()).Should(BeTrue())
+		Ω(res.Subscriptions.Events).Should(Equal([]string{"OrderShipped", "OrderShipped2"}))
+		Ω(res.Subscriptions.FullPath()).Should(Equal("/api/orders/subscriptions"))
+	})
+
+	Context("with an unknown event", func() {
+		BeforeEach(func() {
+			events = []interface{}{"OrderLost"}
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a base path that has parameters", func() {
+		BeforeEach(func() {
+			basePath = "/accounts/:accountID/orders"
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+})
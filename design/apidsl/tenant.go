@@ -0,0 +1,56 @@
+package apidsl
+
+import "github.com/goadesign/goa/design"
+
+// Tenant makes the API multi-tenant and defines how the tenant each request is made on behalf of
+// is identified: SubdomainTenant uses the first label of the request host, HeaderTenant the value
+// of a request header and ParamTenant the value of an API base path parameter. The optional second
+// argument is the name of the header ("X-Tenant-ID" by default), of the base path parameter
+// ("tenant" by default) or the parent domain of the tenant subdomains:
+//
+//	API("saas", func() {
+//		BasePath("/:tenant")
+//		Tenant(ParamTenant)
+//	})
+//
+//	API("saas", func() {
+//		Tenant(SubdomainTenant, "api.example.com")	// acme.api.example.com
+//	})
+//
+// The generated code resolves the tenant before invoking the action and rejects the requests that
+// do not identify one with a 400 response. The tenant is then available through the Tenant field
+// of the action contexts and goa.ContextTenant. The hook set with the service SetTenantHook method
+// may reject unknown tenants and load the tenant configuration in the request context. The href
+// factories of ParamTenant APIs use the tenant of the request context to build the resource hrefs
+// and Location headers. The generated clients expose a UseTenant method that sets the tenant of
+// the requests made with HeaderTenant and SubdomainTenant APIs, see NoTenant for the actions that
+// are not multi-tenant.
+// Tenant may only appear in API.
+func Tenant(source string, name ...string) {
+	a, ok := apiDefinition(true)
+	if !ok {
+		return
+	}
+	t := &design.TenantDefinition{Source: source, Parent: a}
+	switch {
+	case len(name) > 0:
+		t.Name = name[0]
+	case source == design.HeaderTenant:
+		t.Name = "X-Tenant-ID"
+	case source == design.ParamTenant:
+		t.Name = "tenant"
+	}
+	a.Tenant = t
+}
+
+// NoTenant makes the resource or action not multi-tenant, the generated code does not resolve the
+// tenant of the requests made to it. This is typically used for actions such as tenant sign up or
+// health checks.
+// NoTenant may appear in Resource or Action.
+func NoTenant() {
+	if r, ok := resourceDefinition(false); ok {
+		r.NoTenant = true
+	} else if a, ok := actionDefinition(true); ok {
+		a.NoTenant = true
+	}
+}
@@ -0,0 +1,96 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tenant", func() {
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+	})
+
+	JustBeforeEach(func() {
+		dslengine.Run()
+	})
+
+	Context("with a base path parameter", func() {
+		BeforeEach(func() {
+			API("saas", func() {
+				BasePath("/:tenant")
+				Tenant(ParamTenant)
+			})
+			Resource("projects", func() {
+				Action("list", func() {
+					Routing(GET("/projects"))
+				})
+				Action("health", func() {
+					Routing(GET("/health"))
+					NoTenant()
+				})
+			})
+			Resource("signup", func() {
+				NoTenant()
+				Action("create", func() {
+					Routing(POST("/signup"))
+				})
+			})
+		})
+
+		It("sets the effective tenants", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			t := Design.Tenant
+			Ω(t).ShouldNot(BeNil())
+			Ω(t.Source).Should(Equal(ParamTenant))
+			Ω(t.Name).Should(Equal("tenant"))
+			actions := Design.Resources["projects"].Actions
+			Ω(actions["list"].EffectiveTenant()).Should(Equal(t))
+			Ω(actions["health"].EffectiveTenant()).Should(BeNil())
+			Ω(Design.Resources["signup"].Actions["create"].EffectiveTenant()).Should(BeNil())
+		})
+	})
+
+	Context("with a header", func() {
+		BeforeEach(func() {
+			API("saas", func() {
+				Tenant(HeaderTenant)
+			})
+		})
+
+		It("uses the default header name", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(Design.Tenant.Name).Should(Equal("X-Tenant-ID"))
+		})
+	})
+
+	Context("with invalid tenants", func() {
+		BeforeEach(func() {
+			API("saas", func() {
+				BasePath("/v1")
+				Tenant(ParamTenant, "org")
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`tenant parameter "org" is not a parameter of the API base path`))
+		})
+	})
+
+	Context("with an invalid source", func() {
+		BeforeEach(func() {
+			API("saas", func() {
+				Tenant("cookie")
+			})
+		})
+
+		It("produces an error", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring(`invalid tenant source "cookie"`))
+		})
+	})
+})
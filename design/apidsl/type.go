@@ -1,6 +1,8 @@
 package apidsl
 
 import (
+	"fmt"
+
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
 )
@@ -31,8 +33,8 @@ import (
 func Type(name string, dsl func()) *design.UserTypeDefinition {
 	if design.Design.Types == nil {
 		design.Design.Types = make(map[string]*design.UserTypeDefinition)
-	} else if _, ok := design.Design.Types[name]; ok {
-		dslengine.ReportError("type %#v defined twice", name)
+	} else if first, ok := design.Design.Types[name]; ok {
+		dslengine.ReportDuplicate(fmt.Sprintf("type %#v", name), first)
 		return nil
 	}
 	var t *design.UserTypeDefinition
@@ -44,6 +46,7 @@ func Type(name string, dsl func()) *design.UserTypeDefinition {
 		if dsl == nil {
 			t.Type = design.String
 		}
+		t.RecordLocation()
 		design.Design.Types[name] = t
 	}
 	return t
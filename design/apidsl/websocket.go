@@ -0,0 +1,85 @@
+package apidsl
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// WebSocket turns the action into a WebSocket endpoint: requests made to the action routes are
+// upgraded to WebSocket connections. The action routes must use the GET method and the action
+// cannot define a payload. The optional DSL lists the supported subprotocols and the types of the
+// messages exchanged over the connection:
+//
+//	Action("chat", func() {
+//		Routing(GET("/rooms/:roomID/chat"))
+//		WebSocket(func() {
+//			Subprotocol("chat.v1")
+//			Receives(ChatMessage)	// Type of the messages sent by clients
+//			Sends(ChatEvent)	// Type of the messages sent to clients
+//		})
+//	})
+//
+// The generated action context exposes an Upgrade method that gives access to a connection
+// whose Send and Receive methods use the given types.
+func WebSocket(dsl ...func()) {
+	a, ok := actionDefinition(true)
+	if !ok {
+		return
+	}
+	if len(dsl) > 1 {
+		dslengine.ReportError("too many arguments given to WebSocket")
+		return
+	}
+	ws := &design.WebSocketDefinition{Parent: a}
+	if len(dsl) == 1 {
+		if !dslengine.Execute(dsl[0], ws) {
+			return
+		}
+	}
+	a.WebSocket = ws
+}
+
+// Subprotocol adds a subprotocol to the list of WebSocket subprotocols supported by the endpoint.
+// Connections requesting subprotocols none of which is listed are rejected.
+func Subprotocol(name string) {
+	if ws, ok := webSocketDefinition(true); ok {
+		ws.Subprotocols = append(ws.Subprotocols, name)
+	}
+}
+
+// Receives sets the type of the messages sent by clients over the WebSocket connection. The
+// argument is a user type, a media type or the name of a user type or media type identifier.
+func Receives(t interface{}) {
+	if ws, ok := webSocketDefinition(true); ok {
+		ws.Receives = messageType(t)
+	}
+}
+
+// Sends sets the type of the messages sent to clients over the WebSocket connection. The argument
+// is a user type, a media type or the name of a user type or media type identifier.
+func Sends(t interface{}) {
+	if ws, ok := webSocketDefinition(true); ok {
+		ws.Sends = messageType(t)
+	}
+}
+
+// messageType returns the data type of WebSocket messages described by t.
+func messageType(t interface{}) design.DataType {
+	switch actual := t.(type) {
+	case *design.UserTypeDefinition:
+		return actual
+	case *design.MediaTypeDefinition:
+		return actual
+	case string:
+		if ut, ok := design.Design.Types[actual]; ok {
+			return ut
+		}
+		if mt := design.Design.MediaTypeWithIdentifier(actual); mt != nil {
+			return mt
+		}
+		dslengine.ReportError("unknown message type %s", actual)
+	default:
+		dslengine.ReportError("invalid message type, must be a user type, a media type or the name of one")
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package apidsl_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebSocket", func() {
+	var route *RouteDefinition
+	var dsl func()
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		route = GET("/chat")
+		dsl = nil
+	})
+
+	JustBeforeEach(func() {
+		Type("Message", func() {
+			Attribute("text", String)
+		})
+		Resource("res", func() {
+			Action("chat", func() {
+				Routing(route)
+				WebSocket(dsl)
+			})
+		})
+		dslengine.Run()
+		if r, ok := Design.Resources["res"]; ok {
+			action = r.Actions["chat"]
+		}
+	})
+
+	Context("with a primitive message type", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Subprotocol("chat.v1")
+				Subprotocol("chat.v2")
+				Receives("Message")
+				Sends(String)
+			}
+		})
+
+		It("fails on invalid message types", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+		})
+	})
+
+	Context("with a valid definition", func() {
+		BeforeEach(func() {
+			dsl = func() {
+				Subprotocol("chat.v1")
+				Subprotocol("chat.v2")
+				Receives("Message")
+				Sends("Message")
+			}
+		})
+
+		It("sets the action WebSocket definition", func() {
+			Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+			Ω(action).ShouldNot(BeNil())
+			Ω(action.IsWebSocket()).Should(BeTrue())
+			Ω(action.Routes[0].IsWebSocket()).Should(BeTrue())
+			ws := action.WebSocket
+			Ω(ws.Subprotocols).Should(Equal([]string{"chat.v1", "chat.v2"}))
+			Ω(ws.Receives).Should(Equal(Design.Types["Message"]))
+			Ω(ws.Sends).Should(Equal(Design.Types["Message"]))
+		})
+	})
+
+	Context("with a POST route", func() {
+		BeforeEach(func() {
+			route = POST("/chat")
+			dsl = func() {}
+		})
+
+		It("produces an invalid action", func() {
+			Ω(dslengine.Errors).Should(HaveOccurred())
+			Ω(dslengine.Errors.Error()).Should(ContainSubstring("GET method"))
+		})
+	})
+})
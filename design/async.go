@@ -0,0 +1,220 @@
+package design
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa/dslengine"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// OperationMediaIdentifier is the identifier of OperationMedia.
+	OperationMediaIdentifier = "application/vnd.goa.operation+json"
+
+	// DefaultOperationsPath is the default path of the operations endpoints relative to the
+	// API base path.
+	DefaultOperationsPath = "/operations"
+)
+
+// List of operation statuses.
+const (
+	// OperationPending is the status of operations that have not started yet.
+	OperationPending = "pending"
+	// OperationRunning is the status of running operations.
+	OperationRunning = "running"
+	// OperationSucceeded is the status of operations that completed successfully.
+	OperationSucceeded = "succeeded"
+	// OperationFailed is the status of operations that completed with an error.
+	OperationFailed = "failed"
+	// OperationCanceled is the status of canceled operations.
+	OperationCanceled = "canceled"
+)
+
+type (
+	// AsyncDefinition describes an asynchronous action: the action starts a long-running
+	// operation and responds immediately with 202 Accepted, the Location header of the response
+	// is the href of the operation that clients poll to get its status and result.
+	AsyncDefinition struct {
+		// Cancelable is true if the operations can be canceled by clients.
+		Cancelable bool
+		// Timeout is the maximum duration of the operations, zero means no limit.
+		Timeout time.Duration
+		// Parent is the async action.
+		Parent *ActionDefinition
+	}
+
+	// OperationsDefinition describes the operations endpoints of an API: GET requests made to
+	// the operation hrefs return the operation status, DELETE requests cancel them.
+	OperationsDefinition struct {
+		// Path is the path of the operations endpoints relative to the API base path.
+		Path string
+		// Parent is the API exposing the endpoints.
+		Parent *APIDefinition
+	}
+)
+
+// OperationMedia is the built-in media type that describes the status of the operations started
+// by async actions, see goa/async.Operation. OperationMedia is added to the design media types the
+// first time an async action is defined.
+var OperationMedia = newOperationMedia()
+
+// newOperationMedia builds the OperationMedia media type definition.
+func newOperationMedia() *MediaTypeDefinition {
+	att := &AttributeDefinition{
+		Type: Object{
+			"id": &AttributeDefinition{
+				Type:        String,
+				Description: "Operation ID",
+			},
+			"href": &AttributeDefinition{
+				Type:        String,
+				Description: "API href of the operation",
+			},
+			"resource": &AttributeDefinition{
+				Type:        String,
+				Description: "Name of the resource of the action that started the operation",
+			},
+			"action": &AttributeDefinition{
+				Type:        String,
+				Description: "Name of the action that started the operation",
+			},
+			"status": &AttributeDefinition{
+				Type:        String,
+				Description: "Operation status",
+				Validation: &dslengine.ValidationDefinition{
+					Values: []interface{}{OperationPending, OperationRunning, OperationSucceeded, OperationFailed, OperationCanceled},
+				},
+			},
+			"cancelable": &AttributeDefinition{
+				Type:        Boolean,
+				Description: "Whether the operation can be canceled",
+			},
+			"result": &AttributeDefinition{
+				Type:        Any,
+				Description: "Result of the operation once it succeeded",
+			},
+			"error": &AttributeDefinition{
+				Type:        String,
+				Description: "Error message of the operation once it failed",
+			},
+			"created_at": &AttributeDefinition{
+				Type:        DateTime,
+				Description: "Creation timestamp",
+			},
+			"updated_at": &AttributeDefinition{
+				Type:        DateTime,
+				Description: "Last update timestamp",
+			},
+		},
+		Description: "Status of a long-running operation",
+		Validation:  &dslengine.ValidationDefinition{Required: []string{"id", "href", "status"}},
+	}
+	mt := &MediaTypeDefinition{
+		UserTypeDefinition: &UserTypeDefinition{
+			AttributeDefinition: att,
+			TypeName:            "OperationMedia",
+		},
+		Identifier: OperationMediaIdentifier,
+	}
+	mt.Views = map[string]*ViewDefinition{
+		"default": {
+			AttributeDefinition: &AttributeDefinition{Type: att.Type},
+			Name:                "default",
+			Parent:              mt,
+		},
+	}
+	return mt
+}
+
+// Context returns the generic definition name used in error messages.
+func (a *AsyncDefinition) Context() string {
+	if a.Parent != nil {
+		return "async " + a.Parent.Context()
+	}
+	return "async action"
+}
+
+// Validate checks that the timeout is not negative, that the action is not also a WebSocket,
+// long-poll, proxy or webhook endpoint and that its Accepted response if defined uses
+// OperationMedia.
+func (a *AsyncDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if a.Timeout < 0 {
+		verr.Add(a, "invalid timeout %s, cannot be negative", a.Timeout)
+	}
+	if p := a.Parent; p != nil {
+		if p.WebSocket != nil || p.LongPoll != nil || p.Proxy != nil || p.Webhook != nil {
+			verr.Add(a, "async actions cannot be WebSocket, long-poll, proxy or webhook endpoints")
+		}
+		for _, r := range p.Responses {
+			if r.Status == 202 && r.MediaType != "" && CanonicalIdentifier(r.MediaType) != CanonicalIdentifier(OperationMediaIdentifier) {
+				verr.Add(a, "Accepted response of async actions must use media type %#v, got %#v", OperationMediaIdentifier, r.MediaType)
+			}
+		}
+	}
+	return verr.AsError()
+}
+
+// acceptedResponse returns the response of the async action, it uses OperationMedia and sets the
+// Location header to the operation href.
+func (a *AsyncDefinition) acceptedResponse() *ResponseDefinition {
+	return &ResponseDefinition{
+		Name:        "Accepted",
+		Description: "The operation started, its status can be retrieved from the Location header URL",
+		Status:      202,
+		MediaType:   OperationMediaIdentifier,
+		Headers: &AttributeDefinition{
+			Type: Object{
+				"Location": &AttributeDefinition{
+					Type:        String,
+					Description: "Href of the operation",
+				},
+			},
+		},
+		Parent: a.Parent,
+	}
+}
+
+// IsAsync returns true if the action starts long-running operations.
+func (a *ActionDefinition) IsAsync() bool {
+	return a.Async != nil
+}
+
+// Context returns the generic definition name used in error messages.
+func (o *OperationsDefinition) Context() string {
+	if o.Parent != nil {
+		return "operations endpoints of " + o.Parent.Context()
+	}
+	return "operations endpoints"
+}
+
+// FullPath returns the path of the operations endpoints including the API base path.
+func (o *OperationsDefinition) FullPath() string {
+	var basePath string
+	if o.Parent != nil {
+		basePath = o.Parent.BasePath
+	}
+	return httprouter.CleanPath(path.Join(basePath, o.Path))
+}
+
+// Validate checks that the operations path is absolute.
+func (o *OperationsDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if !strings.HasPrefix(o.Path, "/") {
+		verr.Add(o, "invalid path %#v, operations path must start with /", o.Path)
+	}
+	return verr.AsError()
+}
+
+// UseOperationMedia adds OperationMedia to the design media types if not already present.
+func (a *APIDefinition) UseOperationMedia() {
+	if a.MediaTypes == nil {
+		a.MediaTypes = make(map[string]*MediaTypeDefinition)
+	}
+	id := CanonicalIdentifier(OperationMediaIdentifier)
+	if _, ok := a.MediaTypes[id]; !ok {
+		a.MediaTypes[id] = OperationMedia
+	}
+}
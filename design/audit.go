@@ -0,0 +1,58 @@
+package design
+
+import "github.com/goadesign/goa/dslengine"
+
+// AuditDefinition describes the audit of the requests made to actions. The generated code records
+// an audit event for each request made to an audited action with the request principal, the
+// action and resource names, the request parameters and payload and the outcome of the request.
+type AuditDefinition struct {
+	// Redact lists the names of the parameters and top level payload attributes whose values
+	// are masked in the audit events.
+	Redact []string
+	// Parent is the API, resource or action being audited.
+	Parent dslengine.Definition
+}
+
+// Context returns the generic definition name used in error messages.
+func (a *AuditDefinition) Context() string {
+	if a.Parent != nil {
+		return "audit of " + a.Parent.Context()
+	}
+	return "audit"
+}
+
+// EffectiveAudit returns the audit definition that applies to the action: the action audit if
+// any, the resource audit otherwise and finally the API audit. It returns nil if the action is not
+// audited.
+func (a *ActionDefinition) EffectiveAudit() *AuditDefinition {
+	if a.Audit != nil {
+		return a.Audit
+	}
+	if a.Parent != nil && a.Parent.Audit != nil {
+		return a.Parent.Audit
+	}
+	if Design != nil {
+		return Design.Audit
+	}
+	return nil
+}
+
+// validateAudit checks that the redacted names of the action audit are names of action
+// parameters or payload attributes.
+func (a *ActionDefinition) validateAudit() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	for _, n := range a.Audit.Redact {
+		if a.Params != nil && a.Params.Type.IsObject() {
+			if _, ok := a.Params.Type.ToObject()[n]; ok {
+				continue
+			}
+		}
+		if a.Payload != nil && a.Payload.IsObject() {
+			if _, ok := a.Payload.ToObject()[n]; ok {
+				continue
+			}
+		}
+		verr.Add(a.Audit, "redacted name %#v is neither a parameter nor a payload attribute of the action", n)
+	}
+	return verr.AsError()
+}
@@ -0,0 +1,17 @@
+package design
+
+// EffectivePolicy returns the name of the authorization policy that applies to the action: the
+// action policy if any, the resource policy otherwise and finally the API policy. It returns the
+// empty string if no policy applies.
+func (a *ActionDefinition) EffectivePolicy() string {
+	if a.Policy != "" {
+		return a.Policy
+	}
+	if a.Parent != nil && a.Parent.Policy != "" {
+		return a.Parent.Policy
+	}
+	if Design != nil {
+		return Design.Policy
+	}
+	return ""
+}
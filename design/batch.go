@@ -0,0 +1,54 @@
+package design
+
+import (
+	"path"
+	"strings"
+
+	"github.com/goadesign/goa/dslengine"
+	"github.com/julienschmidt/httprouter"
+)
+
+// BatchDefinition describes the batch endpoint of an API. Clients send an array of sub-requests
+// to the endpoint, each sub-request references an action of the API by method and path and is
+// dispatched through the same routing and validation code as the requests made directly to the
+// action.
+type BatchDefinition struct {
+	// Path is the path of the batch endpoint relative to the API base path.
+	Path string
+	// Concurrent is true if the sub-requests of a batch are dispatched concurrently.
+	Concurrent bool
+	// MaxRequests is the maximum number of sub-requests in a batch, zero means no limit.
+	MaxRequests int
+	// Parent is the API exposing the endpoint.
+	Parent *APIDefinition
+}
+
+// Context returns the generic definition name used in error messages.
+func (b *BatchDefinition) Context() string {
+	if b.Parent != nil {
+		return "batch endpoint of " + b.Parent.Context()
+	}
+	return "batch endpoint"
+}
+
+// FullPath returns the batch endpoint path including the API base path.
+func (b *BatchDefinition) FullPath() string {
+	var basePath string
+	if b.Parent != nil {
+		basePath = b.Parent.BasePath
+	}
+	return httprouter.CleanPath(path.Join(basePath, b.Path))
+}
+
+// Validate checks that the batch endpoint path is absolute and that the maximum number of
+// sub-requests is not negative.
+func (b *BatchDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if !strings.HasPrefix(b.Path, "/") {
+		verr.Add(b, "invalid path %#v, batch endpoint path must start with /", b.Path)
+	}
+	if b.MaxRequests < 0 {
+		verr.Add(b, "invalid maximum number of requests %d, must be positive", b.MaxRequests)
+	}
+	return verr.AsError()
+}
@@ -0,0 +1,58 @@
+package design
+
+import (
+	"strconv"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// MaxSizeKey is the metadata key holding the maximum size in bytes of the request bodies, see
+// the MaxSize DSL. It may be set on the API, resources, actions and payloads.
+const MaxSizeKey = "goa:maxSize"
+
+// EffectiveMaxSize returns the maximum size in bytes of the bodies of the requests made to the
+// action: the size set on the action payload if any, the size set on the action otherwise, then
+// the size set on the resource and finally the size set on the API. It returns 0 if no size is
+// set.
+func (a *ActionDefinition) EffectiveMaxSize() int64 {
+	mds := []dslengine.MetadataDefinition{a.Metadata}
+	if a.Payload != nil {
+		mds = append([]dslengine.MetadataDefinition{a.Payload.Metadata}, mds...)
+	}
+	if a.Parent != nil {
+		mds = append(mds, a.Parent.Metadata)
+	}
+	if Design != nil {
+		mds = append(mds, Design.Metadata)
+	}
+	for _, md := range mds {
+		if n, ok := maxSize(md); ok {
+			return n
+		}
+	}
+	return 0
+}
+
+// validateMaxSize checks that the value of the "goa:maxSize" metadata, if any, is a strictly
+// positive integer.
+func validateMaxSize(def dslengine.Definition, md dslengine.MetadataDefinition) *dslengine.ValidationErrors {
+	if _, ok := md[MaxSizeKey]; !ok {
+		return nil
+	}
+	verr := new(dslengine.ValidationErrors)
+	if _, ok := maxSize(md); !ok {
+		verr.Add(def, "invalid %s metadata value %#v: must be a strictly positive integer",
+			MaxSizeKey, metadataValue(md, MaxSizeKey))
+	}
+	return verr.AsError()
+}
+
+// maxSize returns the size held by the "goa:maxSize" metadata, false if there is none or if it is
+// invalid.
+func maxSize(md dslengine.MetadataDefinition) (int64, bool) {
+	n, err := strconv.ParseInt(metadataValue(md, MaxSizeKey), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,88 @@
+package design
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// CacheControlDefinition describes the caching policy of a response, that is the value of its
+// Cache-Control and Vary headers.
+type CacheControlDefinition struct {
+	// MaxAge is the max-age directive, the duration for which the response is fresh.
+	MaxAge time.Duration
+	// SharedMaxAge is the s-maxage directive, the max-age of shared caches such as proxies
+	// and CDNs.
+	SharedMaxAge time.Duration
+	// Public is true if shared caches may store the response.
+	Public bool
+	// Private is true if only the client cache may store the response.
+	Private bool
+	// NoCache is true if caches must revalidate the response before using it.
+	NoCache bool
+	// NoStore is true if caches must not store the response.
+	NoStore bool
+	// Vary lists the request headers the response varies with.
+	Vary []string
+	// Parent is the response the policy applies to.
+	Parent *ResponseDefinition
+}
+
+// Context returns the generic definition name used in error messages.
+func (c *CacheControlDefinition) Context() string {
+	if c.Parent != nil {
+		return "cache control of " + c.Parent.Context()
+	}
+	return "cache control"
+}
+
+// Validate checks that the directives are consistent.
+func (c *CacheControlDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if c.Public && c.Private {
+		verr.Add(c, "response cannot be both public and private")
+	}
+	if c.MaxAge < 0 || c.SharedMaxAge < 0 {
+		verr.Add(c, "max-age and s-maxage cannot be negative")
+	}
+	if c.MaxAge%time.Second != 0 || c.SharedMaxAge%time.Second != 0 {
+		verr.Add(c, "max-age and s-maxage must be whole numbers of seconds")
+	}
+	if c.Private && c.SharedMaxAge > 0 {
+		verr.Add(c, "s-maxage does not apply to private responses")
+	}
+	if c.NoStore && (c.MaxAge > 0 || c.SharedMaxAge > 0) {
+		verr.Add(c, "max-age and s-maxage do not apply to responses that must not be stored")
+	}
+	if c.Value() == "" && len(c.Vary) == 0 {
+		verr.Add(c, "cache control does not define any directive")
+	}
+	return verr.AsError()
+}
+
+// Value returns the value of the Cache-Control header, e.g. "public, max-age=3600". It returns
+// the empty string if the policy only lists the Vary headers.
+func (c *CacheControlDefinition) Value() string {
+	var directives []string
+	if c.Public {
+		directives = append(directives, "public")
+	}
+	if c.Private {
+		directives = append(directives, "private")
+	}
+	if c.NoCache {
+		directives = append(directives, "no-cache")
+	}
+	if c.NoStore {
+		directives = append(directives, "no-store")
+	}
+	if c.MaxAge > 0 {
+		directives = append(directives, "max-age="+strconv.Itoa(int(c.MaxAge/time.Second)))
+	}
+	if c.SharedMaxAge > 0 {
+		directives = append(directives, "s-maxage="+strconv.Itoa(int(c.SharedMaxAge/time.Second)))
+	}
+	return strings.Join(directives, ", ")
+}
@@ -0,0 +1,133 @@
+package design
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// List of supported callback signing schemes.
+const (
+	// SigningNone disables the signing of callback requests.
+	SigningNone = "none"
+	// SigningHMACSHA1 signs callback requests with HMAC SHA-1.
+	SigningHMACSHA1 = "hmac-sha1"
+	// SigningHMACSHA256 signs callback requests with HMAC SHA-256.
+	SigningHMACSHA256 = "hmac-sha256"
+	// SigningHMACSHA512 signs callback requests with HMAC SHA-512.
+	SigningHMACSHA512 = "hmac-sha512"
+)
+
+const (
+	// DefaultSignatureHeader is the default name of the header holding the signature of
+	// callback requests.
+	DefaultSignatureHeader = "X-Webhook-Signature"
+
+	// DefaultCallbackAttempts is the default maximum number of delivery attempts of callbacks.
+	DefaultCallbackAttempts = 5
+
+	// DefaultCallbackBackoff is the default delay before the first callback delivery retry.
+	DefaultCallbackBackoff = time.Second
+)
+
+type (
+	// CallbackDefinition describes an outbound webhook: a request sent by the service to a URL
+	// registered by a client each time an event occurs. Callbacks are defined on the actions
+	// that register the callback URLs and documented with them.
+	CallbackDefinition struct {
+		// Name is the name of the event that triggers the callback, e.g. "OrderShipped".
+		Name string
+		// Description is the optional callback description.
+		Description string
+		// Method is the HTTP method of the callback requests, "POST" by default.
+		Method string
+		// MediaType is the identifier of the media type of the callback request bodies.
+		MediaType string
+		// SigningScheme is one of SigningNone, SigningHMACSHA1, SigningHMACSHA256 or
+		// SigningHMACSHA512.
+		SigningScheme string
+		// SignatureHeader is the name of the header holding the request signature.
+		SignatureHeader string
+		// MaxAttempts is the maximum number of delivery attempts including the first one.
+		MaxAttempts int
+		// Backoff is the delay before the first retry, it doubles with each retry.
+		Backoff time.Duration
+		// MaxBackoff caps the delay between retries if not zero.
+		MaxBackoff time.Duration
+		// Parent is the action that registers the callback URLs.
+		Parent *ActionDefinition
+		// Location of the callback DSL, used in validation error messages
+		dslengine.DSLLocation
+	}
+
+	// CallbackIterator is the type of functions given to IterateCallbacks.
+	CallbackIterator func(c *CallbackDefinition) error
+)
+
+// Context returns the generic definition name used in error messages.
+func (c *CallbackDefinition) Context() string {
+	var suffix string
+	if c.Parent != nil {
+		suffix = fmt.Sprintf(" of %s", c.Parent.Context())
+	}
+	return fmt.Sprintf("callback %#v%s", c.Name, suffix)
+}
+
+// Validate checks that the callback payload media type is defined in the design, that the signing
+// scheme is supported and that the retry policy is consistent.
+func (c *CallbackDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if c.Name == "" {
+		verr.Add(c, "callback name cannot be empty")
+	}
+	switch c.Method {
+	case "POST", "PUT", "PATCH":
+	default:
+		verr.Add(c, "invalid method %#v, must be one of POST, PUT or PATCH", c.Method)
+	}
+	if c.MediaType == "" {
+		verr.Add(c, "missing payload media type")
+	} else if Design != nil && Design.MediaTypeWithIdentifier(c.MediaType) == nil {
+		verr.Add(c, "media type %#v is not defined in the design", c.MediaType)
+	}
+	switch c.SigningScheme {
+	case SigningNone, SigningHMACSHA1, SigningHMACSHA256, SigningHMACSHA512:
+	default:
+		verr.Add(c, "unknown signing scheme %#v, must be one of %#v, %#v, %#v or %#v", c.SigningScheme,
+			SigningNone, SigningHMACSHA1, SigningHMACSHA256, SigningHMACSHA512)
+	}
+	if c.SigningScheme != SigningNone && c.SignatureHeader == "" {
+		verr.Add(c, "signature header cannot be empty")
+	}
+	if c.MaxAttempts < 1 {
+		verr.Add(c, "invalid maximum number of attempts %d, must be at least 1", c.MaxAttempts)
+	}
+	if c.Backoff < 0 {
+		verr.Add(c, "invalid backoff %s, cannot be negative", c.Backoff)
+	}
+	if c.MaxBackoff != 0 && c.MaxBackoff < c.Backoff {
+		verr.Add(c, "maximum backoff %s is lower than backoff %s", c.MaxBackoff, c.Backoff)
+	}
+	return verr.AsError()
+}
+
+// IterateCallbacks calls the given iterator passing in each callback of the action sorted in
+// alphabetical order. Iteration stops if an iterator returns an error and in this case
+// IterateCallbacks returns that error.
+func (a *ActionDefinition) IterateCallbacks(it CallbackIterator) error {
+	names := make([]string, len(a.Callbacks))
+	i := 0
+	for n := range a.Callbacks {
+		names[i] = n
+		i++
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if err := it(a.Callbacks[n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
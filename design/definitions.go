@@ -6,6 +6,20 @@ import (
 	"github.com/goadesign/goa/dslengine"
 )
 
+// FieldPointerKey is the name of the metadata used to control whether the struct field generated
+// for an optional primitive attribute is a pointer ("true") or a value ("false"). The metadata may
+// be set on the attribute or on the parent object attribute (e.g. a type) to apply to all its
+// optional primitive attributes. Value fields cannot distinguish unset attributes from attributes
+// set to the zero value, the validations apply to the zero value in that case.
+const FieldPointerKey = "struct:field:pointer"
+
+// ValueDefaults causes the struct fields generated for optional primitive attributes that have a
+// default value to be values rather than pointers when the attribute or its parent do not define
+// the "struct:field:pointer" metadata. The generated code initializes the value fields of the
+// action params and payloads with the default values. It is set with the goagen --value-defaults
+// flag.
+var ValueDefaults bool
+
 type (
 	// AttributeDefinition defines a JSON object member with optional description, default
 	// value and validations.
@@ -98,7 +112,9 @@ func (a *AttributeDefinition) IsNonZero(attName string) bool {
 }
 
 // IsPrimitivePointer returns true if the field generated for the given attribute should be a
-// pointer to a primitive type. The target attribute must be an object.
+// pointer to a primitive type. The target attribute must be an object. Required and non-zero
+// attributes are never pointers, the "struct:field:pointer" metadata and ValueDefaults control
+// the fields of the other primitive attributes, see FieldPointerKey.
 func (a *AttributeDefinition) IsPrimitivePointer(attName string) bool {
 	if !a.Type.IsObject() {
 		panic("checking pointer field on non-object") // bug
@@ -107,10 +123,29 @@ func (a *AttributeDefinition) IsPrimitivePointer(attName string) bool {
 	if att == nil {
 		return false
 	}
-	if att.Type.IsPrimitive() {
-		return !a.IsRequired(attName) && !a.IsNonZero(attName)
+	if !att.Type.IsPrimitive() || a.IsRequired(attName) || a.IsNonZero(attName) {
+		return false
 	}
-	return false
+	for _, md := range []dslengine.MetadataDefinition{att.Metadata, a.Metadata} {
+		if vals, ok := md[FieldPointerKey]; ok && len(vals) > 0 {
+			return vals[0] != "false"
+		}
+	}
+	return !ValueDefaults || att.DefaultValue == nil
+}
+
+// IsValueWithDefault returns true if the field generated for the given attribute is a value that
+// must be initialized with the attribute default value when the attribute is not set. The target
+// attribute must be an object.
+func (a *AttributeDefinition) IsValueWithDefault(attName string) bool {
+	att := a.Type.ToObject()[attName]
+	if att == nil || att.DefaultValue == nil || !att.Type.IsPrimitive() {
+		return false
+	}
+	if a.IsRequired(attName) || a.IsNonZero(attName) {
+		return false
+	}
+	return !a.IsPrimitivePointer(attName)
 }
 
 // GenerateExample returns a random instance of the attribute that validates.
@@ -45,3 +45,90 @@ var _ = Describe("IsRequired", func() {
 		})
 	})
 })
+
+var _ = Describe("IsPrimitivePointer", func() {
+	var field, parent dslengine.MetadataDefinition
+	var defaultValue interface{}
+	var valueDefaults bool
+
+	var attribute *design.AttributeDefinition
+	var res bool
+
+	BeforeEach(func() {
+		field, parent, defaultValue, valueDefaults = nil, nil, nil, false
+	})
+
+	JustBeforeEach(func() {
+		design.ValueDefaults = valueDefaults
+		integer := &design.AttributeDefinition{
+			Type:         design.Integer,
+			DefaultValue: defaultValue,
+			Metadata:     field,
+		}
+		attribute = &design.AttributeDefinition{
+			Type:     design.Object{"optional": integer},
+			Metadata: parent,
+		}
+		res = attribute.IsPrimitivePointer("optional")
+	})
+
+	AfterEach(func() {
+		design.ValueDefaults = false
+	})
+
+	It("returns true for optional fields", func() {
+		Ω(res).Should(BeTrue())
+	})
+
+	Context("with a default value", func() {
+		BeforeEach(func() {
+			defaultValue = 42
+		})
+
+		It("returns true", func() {
+			Ω(res).Should(BeTrue())
+		})
+
+		Context("and ValueDefaults set", func() {
+			BeforeEach(func() {
+				valueDefaults = true
+			})
+
+			It("returns false", func() {
+				Ω(res).Should(BeFalse())
+				Ω(attribute.IsValueWithDefault("optional")).Should(BeTrue())
+			})
+		})
+	})
+
+	Context("with the field metadata set to false", func() {
+		BeforeEach(func() {
+			field = dslengine.MetadataDefinition{design.FieldPointerKey: {"false"}}
+		})
+
+		It("returns false", func() {
+			Ω(res).Should(BeFalse())
+			Ω(attribute.IsValueWithDefault("optional")).Should(BeFalse())
+		})
+	})
+
+	Context("with the parent metadata set to false", func() {
+		BeforeEach(func() {
+			parent = dslengine.MetadataDefinition{design.FieldPointerKey: {"false"}}
+		})
+
+		It("returns false", func() {
+			Ω(res).Should(BeFalse())
+		})
+
+		Context("and the field metadata set to true", func() {
+			BeforeEach(func() {
+				field = dslengine.MetadataDefinition{design.FieldPointerKey: {"true"}}
+			})
+
+			It("returns true", func() {
+				Ω(res).Should(BeTrue())
+			})
+		})
+	})
+})
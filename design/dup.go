@@ -91,10 +91,12 @@ func (d *dupper) DupType(t DataType) DataType {
 			return m
 		}
 		m := &MediaTypeDefinition{
-			Identifier: actual.Identifier,
-			Links:      actual.Links,
-			Views:      actual.Views,
-			Resource:   actual.Resource,
+			Identifier:      actual.Identifier,
+			Links:           actual.Links,
+			Views:           actual.Views,
+			Resource:        actual.Resource,
+			MQTTPublication: actual.MQTTPublication,
+			ETag:            actual.ETag,
 		}
 		d.dmts[actual.Identifier] = m
 		m.UserTypeDefinition = d.DupUserType(actual.UserTypeDefinition)
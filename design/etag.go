@@ -0,0 +1,90 @@
+package design
+
+import "github.com/goadesign/goa/dslengine"
+
+const (
+	// HashETag is the strategy of the strong ETags computed from the hash of the encoded
+	// response bodies.
+	HashETag = "hash"
+	// WeakETag is the strategy of the weak ETags computed from the hash of the encoded response
+	// bodies.
+	WeakETag = "weak"
+	// AttributeETag is the strategy of the strong ETags computed from the value of a media type
+	// attribute, e.g. a version number or a last modification time.
+	AttributeETag = "attribute"
+)
+
+// ETagDefinition describes how the ETags of the successful responses of an action or of the
+// responses rendering a media type are generated.
+type ETagDefinition struct {
+	// Strategy is HashETag, WeakETag or AttributeETag.
+	Strategy string
+	// Attribute is the name of the media type attribute the ETags are computed from when
+	// Strategy is AttributeETag.
+	Attribute string
+	// Parent is the action or media type the ETag applies to.
+	Parent dslengine.Definition
+}
+
+// Context returns the generic definition name used in error messages.
+func (e *ETagDefinition) Context() string {
+	if e.Parent != nil {
+		return "ETag of " + e.Parent.Context()
+	}
+	return "ETag"
+}
+
+// Validate checks that the strategy is valid and that the attribute of AttributeETag strategies
+// exists when the ETag applies to a media type.
+func (e *ETagDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	switch e.Strategy {
+	case HashETag, WeakETag:
+		if e.Attribute != "" {
+			verr.Add(e, "ETag attribute can only be used with the AttributeETag strategy")
+		}
+	case AttributeETag:
+		if e.Attribute == "" {
+			verr.Add(e, "AttributeETag strategy requires the name of an attribute")
+		} else if mt, ok := e.Parent.(*MediaTypeDefinition); ok {
+			if o := mt.Type.ToObject(); o == nil || o[e.Attribute] == nil {
+				verr.Add(e, "ETag attribute %#v is not an attribute of the media type", e.Attribute)
+			}
+		}
+	default:
+		verr.Add(e, "invalid ETag strategy %#v, must be HashETag, WeakETag or AttributeETag", e.Strategy)
+	}
+	return verr.AsError()
+}
+
+// IsWeak returns true if the ETags are weak validators.
+func (e *ETagDefinition) IsWeak() bool {
+	return e.Strategy == WeakETag
+}
+
+// EffectiveETag returns the ETag of the successful responses of the action that render the given
+// media type: the action ETag if any, the media type ETag otherwise. mt may be nil. It returns nil
+// if the responses have no ETag.
+func (a *ActionDefinition) EffectiveETag(mt *MediaTypeDefinition) *ETagDefinition {
+	if a.ETag != nil {
+		return a.ETag
+	}
+	if mt != nil {
+		return mt.ETag
+	}
+	return nil
+}
+
+// IsConditional returns true if the action or the media type of one of its responses defines an
+// ETag.
+func (a *ActionDefinition) IsConditional() bool {
+	if a.ETag != nil {
+		return true
+	}
+	for _, r := range a.Responses {
+		if mt := Design.MediaTypeWithIdentifier(r.MediaType); mt != nil && mt.ETag != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package design
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+type (
+	// EventDefinition describes a domain event published on a message broker topic. Events are
+	// defined with the Event DSL, the code generated by "goagen events" publishes and subscribes
+	// to events using the encoders and decoders registered on the service.
+	EventDefinition struct {
+		dslengine.DSLLocation
+		// Name is the event name.
+		Name string
+		// Description is the optional event description.
+		Description string
+		// Topic is the name of the topic (Kafka) or subject (NATS) the event is published on.
+		Topic string
+		// Key is the name of the payload attribute whose value is used as the message key if
+		// any. Brokers that support keys (Kafka) use it to partition the events.
+		Key string
+		// Payload is the type of the event payload.
+		Payload *UserTypeDefinition
+		// DSLFunc contains the DSL used to initialize the event.
+		DSLFunc func()
+	}
+
+	// EventIterator is the type of functions given to IterateEvents.
+	EventIterator func(e *EventDefinition) error
+)
+
+// Context returns the generic definition name used in error messages.
+func (e *EventDefinition) Context() string {
+	if e.Name != "" {
+		return fmt.Sprintf("event %#v", e.Name)
+	}
+	return "unnamed event"
+}
+
+// DSL returns the initialization DSL.
+func (e *EventDefinition) DSL() func() {
+	return e.DSLFunc
+}
+
+// Validate checks that the event definition is consistent: it has a topic and a payload and the
+// key if any is a required primitive attribute of the payload.
+func (e *EventDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if e.Topic == "" {
+		verr.Add(e, "missing topic")
+	}
+	if e.Payload == nil {
+		verr.Add(e, "missing payload")
+		if e.Key != "" {
+			verr.Add(e, "key %#v requires a payload", e.Key)
+		}
+		return verr.AsError()
+	}
+	verr.Merge(e.Payload.Validate("payload", e))
+	if e.Key != "" {
+		var att *AttributeDefinition
+		if e.Payload.Type.IsObject() {
+			att = e.Payload.Type.ToObject()[e.Key]
+		}
+		switch {
+		case att == nil:
+			verr.Add(e, "key %#v is not a payload attribute", e.Key)
+		case !att.Type.IsPrimitive():
+			verr.Add(e, "key %#v must be a primitive attribute", e.Key)
+		case !e.Payload.IsRequired(e.Key):
+			verr.Add(e, "key %#v must be a required attribute", e.Key)
+		}
+	}
+	return verr.AsError()
+}
+
+// IterateEvents calls the given iterator passing in each event sorted in alphabetical order.
+// Iteration stops if an iterator returns an error and in this case IterateEvents returns that
+// error.
+func (a *APIDefinition) IterateEvents(it EventIterator) error {
+	names := make([]string, len(a.Events))
+	i := 0
+	for n := range a.Events {
+		names[i] = n
+		i++
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if err := it(a.Events[n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
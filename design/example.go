@@ -5,8 +5,6 @@ import (
 	"math"
 	"regexp"
 	"time"
-
-	regen "github.com/zach-klippenstein/goregen"
 )
 
 // exampleGenerator generates a random example based on the given validations on the definition.
@@ -131,19 +129,29 @@ func (eg *exampleGenerator) generateFormatExample() interface{} {
 	if res, ok := map[string]interface{}{
 		"email":     eg.r.faker.Email(),
 		"hostname":  eg.r.faker.DomainName() + "." + eg.r.faker.DomainSuffix(),
-		"date-time": time.Unix(int64(eg.r.Int())%1454957045, 0).Format(time.RFC3339), // to obtain a "fixed" rand
+		"date-time": time.Unix(int64(eg.r.Int())%1454957045, 0).UTC().Format(time.RFC3339), // to obtain a "fixed" rand
 		"ipv4":      eg.r.faker.IPv4Address().String(),
 		"ipv6":      eg.r.faker.IPv6Address().String(),
 		"uri":       eg.r.faker.URL(),
 		"mac": func() string {
-			res, err := regen.Generate(`([0-9A-F]{2}-){5}[0-9A-F]{2}`)
+			res, err := eg.r.Regexp(`([0-9A-F]{2}-){5}[0-9A-F]{2}`)
 			if err != nil {
 				return "12-34-56-78-9A-BC"
 			}
 			return res
 		}(),
-		"cidr":   "192.168.100.14/24",
-		"regexp": eg.r.faker.Characters(3) + ".*",
+		"cidr":         "192.168.100.14/24",
+		"regexp":       eg.r.faker.Characters(3) + ".*",
+		"regex":        eg.r.faker.Characters(3) + ".*",
+		"uri-template": eg.r.faker.URL() + "/{id}{?fields}",
+		"rfc1123":      time.Unix(int64(eg.r.Int())%1454957045, 0).UTC().Format(time.RFC1123),
+		"uuid": func() string {
+			res, err := eg.r.Regexp(`[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}`)
+			if err != nil {
+				return "6ba7b810-9dad-41d1-80b4-00c04fd430c8"
+			}
+			return res
+		}(),
 	}[format]; ok {
 		return res
 	}
@@ -176,7 +184,7 @@ func (eg *exampleGenerator) generateValidatedPatternExample() interface{} {
 		return false
 	}
 	pattern := eg.a.Validation.Pattern
-	example, err := regen.Generate(pattern)
+	example, err := eg.r.Regexp(pattern)
 	if err != nil {
 		return eg.r.faker.Name()
 	}
@@ -0,0 +1,86 @@
+package design
+
+import (
+	"bytes"
+	"unicode"
+)
+
+// JSONAPIMediaType is the media type of JSON:API documents.
+const JSONAPIMediaType = "application/vnd.api+json"
+
+// JSONAPITypeMetadata is the metadata key that overrides the JSON:API resource type of a media
+// type, e.g. Metadata("jsonapi:type", "bottles").
+const JSONAPITypeMetadata = "jsonapi:type"
+
+// JSONAPIType returns the JSON:API resource type of the media type: the value of the
+// "jsonapi:type" metadata if any, the snake case type name otherwise. The type of a collection is
+// the type of its elements.
+func (m *MediaTypeDefinition) JSONAPIType() string {
+	if elem := m.jsonAPIElem(); elem != m {
+		return elem.JSONAPIType()
+	}
+	if t, ok := m.Metadata[JSONAPITypeMetadata]; ok && len(t) > 0 {
+		return t[0]
+	}
+	return snakeCase(m.TypeName)
+}
+
+// JSONAPIRelationships returns the JSON:API resource types of the related resources of the media
+// type indexed by link name. The relationships of a collection are the relationships of its
+// elements.
+func (m *MediaTypeDefinition) JSONAPIRelationships() map[string]string {
+	m = m.jsonAPIElem()
+	if len(m.Links) == 0 {
+		return nil
+	}
+	rels := make(map[string]string, len(m.Links))
+	for n, l := range m.Links {
+		if l.Parent == nil || l.Attribute() == nil {
+			continue
+		}
+		if lmt := l.MediaType(); lmt != nil {
+			rels[n] = lmt.JSONAPIType()
+		}
+	}
+	return rels
+}
+
+// jsonAPIElem returns the element media type of collections, m otherwise.
+func (m *MediaTypeDefinition) jsonAPIElem() *MediaTypeDefinition {
+	if !m.IsArray() {
+		return m
+	}
+	if elem, ok := m.ToArray().ElemType.Type.(*MediaTypeDefinition); ok {
+		return elem
+	}
+	return m
+}
+
+// snakeCase converts a Go type name to snake case, e.g. "BottlePayload" to "bottle_payload".
+func snakeCase(name string) string {
+	var b bytes.Buffer
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// withJSONAPI returns the given encodings preceded by the JSON:API media type encoding if they do
+// not already list it.
+func withJSONAPI(encs []*EncodingDefinition) []*EncodingDefinition {
+	for _, enc := range encs {
+		for _, m := range enc.MIMETypes {
+			if m == JSONAPIMediaType {
+				return encs
+			}
+		}
+	}
+	return append([]*EncodingDefinition{{MIMETypes: []string{JSONAPIMediaType}}}, encs...)
+}
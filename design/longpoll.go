@@ -0,0 +1,124 @@
+package design
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+const (
+	// DefaultLongPollWaitParam is the default name of the param that holds the maximum number
+	// of seconds long-poll requests wait for events.
+	DefaultLongPollWaitParam = "wait"
+
+	// DefaultLongPollCursorParam is the default name of the param that holds the cursor of the
+	// last event received by the client.
+	DefaultLongPollCursorParam = "cursor"
+
+	// DefaultLongPollMaxWait is the default maximum duration long-poll requests may wait for.
+	DefaultLongPollMaxWait = 60 * time.Second
+)
+
+// LongPollDefinition describes the long-polling semantics of an action. Requests made to a
+// long-poll action carry the cursor of the last event seen by the client and the number of
+// seconds the client is willing to wait; the action responds as soon as events following the
+// cursor are available or once the wait expires. The wait and cursor params are added to the
+// action params when the design is finalized unless the action already defines them.
+type LongPollDefinition struct {
+	// WaitParam is the name of the wait param, "wait" by default.
+	WaitParam string
+	// CursorParam is the name of the cursor param, "cursor" by default.
+	CursorParam string
+	// MaxWait is the maximum wait duration accepted by the action, one minute by default.
+	MaxWait time.Duration
+	// DefaultWait is the wait duration used when requests do not specify one, MaxWait if zero.
+	DefaultWait time.Duration
+	// Parent is the long-poll action.
+	Parent *ActionDefinition
+}
+
+// Context returns the generic definition name used in error messages.
+func (l *LongPollDefinition) Context() string {
+	if l.Parent != nil {
+		return fmt.Sprintf("long poll of %s", l.Parent.Context())
+	}
+	return "long poll"
+}
+
+// Validate checks that the wait durations are consistent, that the param names are distinct and
+// that the action is not also a WebSocket endpoint or a proxy.
+func (l *LongPollDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if l.MaxWait < time.Second {
+		verr.Add(l, "invalid maximum wait %s, must be at least one second", l.MaxWait)
+	}
+	if l.DefaultWait < 0 || l.DefaultWait > l.MaxWait {
+		verr.Add(l, "invalid default wait %s, must be between 0 and the maximum wait %s", l.DefaultWait, l.MaxWait)
+	}
+	if l.WaitParam == "" || l.CursorParam == "" {
+		verr.Add(l, "wait and cursor param names cannot be empty")
+	} else if l.WaitParam == l.CursorParam {
+		verr.Add(l, "wait and cursor params must have different names, both are %#v", l.WaitParam)
+	}
+	if l.Parent != nil {
+		if l.Parent.WebSocket != nil {
+			verr.Add(l, "WebSocket actions cannot long poll")
+		}
+		if l.Parent.Proxy != nil {
+			verr.Add(l, "proxy actions cannot long poll")
+		}
+		if l.Parent.Params != nil {
+			o := l.Parent.Params.Type.ToObject()
+			if att, ok := o[l.WaitParam]; ok && att.Type.Kind() != IntegerKind {
+				verr.Add(l, "wait param %#v must be an integer", l.WaitParam)
+			}
+			if att, ok := o[l.CursorParam]; ok && att.Type.Kind() != StringKind {
+				verr.Add(l, "cursor param %#v must be a string", l.CursorParam)
+			}
+		}
+	}
+	return verr.AsError()
+}
+
+// Wait returns the wait duration used when requests do not specify one.
+func (l *LongPollDefinition) Wait() time.Duration {
+	if l.DefaultWait > 0 {
+		return l.DefaultWait
+	}
+	return l.MaxWait
+}
+
+// addParams adds the wait and cursor params to the parent action params if not already defined.
+// The wait param is an integer number of seconds between 0 and the maximum wait, it defaults to
+// the default wait.
+func (l *LongPollDefinition) addParams() {
+	a := l.Parent
+	if a.Params == nil {
+		a.Params = &AttributeDefinition{Type: Object{}}
+	}
+	o := a.Params.Type.ToObject()
+	if o == nil {
+		return
+	}
+	if _, ok := o[l.WaitParam]; !ok {
+		min, max := 0.0, float64(l.MaxWait/time.Second)
+		o[l.WaitParam] = &AttributeDefinition{
+			Type:         Integer,
+			Description:  "Maximum number of seconds to wait for events",
+			DefaultValue: int(l.Wait() / time.Second),
+			Validation:   &dslengine.ValidationDefinition{Minimum: &min, Maximum: &max},
+		}
+	}
+	if _, ok := o[l.CursorParam]; !ok {
+		o[l.CursorParam] = &AttributeDefinition{
+			Type:        String,
+			Description: "Cursor of the last event received, omit to receive the events from the start",
+		}
+	}
+}
+
+// IsLongPoll returns true if the action waits for events before responding.
+func (a *ActionDefinition) IsLongPoll() bool {
+	return a.LongPoll != nil
+}
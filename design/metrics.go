@@ -0,0 +1,44 @@
+package design
+
+import (
+	"path"
+	"strings"
+
+	"github.com/goadesign/goa/dslengine"
+	"github.com/julienschmidt/httprouter"
+)
+
+// MetricsDefinition describes the endpoint that exposes the API request metrics in the Prometheus
+// text format.
+type MetricsDefinition struct {
+	// Path is the path of the metrics endpoint relative to the API base path.
+	Path string
+	// Parent is the API exposing the endpoint.
+	Parent *APIDefinition
+}
+
+// Context returns the generic definition name used in error messages.
+func (m *MetricsDefinition) Context() string {
+	if m.Parent != nil {
+		return "metrics endpoint of " + m.Parent.Context()
+	}
+	return "metrics endpoint"
+}
+
+// FullPath returns the metrics endpoint path including the API base path.
+func (m *MetricsDefinition) FullPath() string {
+	var basePath string
+	if m.Parent != nil {
+		basePath = m.Parent.BasePath
+	}
+	return httprouter.CleanPath(path.Join(basePath, m.Path))
+}
+
+// Validate checks that the metrics endpoint path is absolute.
+func (m *MetricsDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if !strings.HasPrefix(m.Path, "/") {
+		verr.Add(m, "invalid path %#v, metrics endpoint path must start with /", m.Path)
+	}
+	return verr.AsError()
+}
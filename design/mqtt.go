@@ -0,0 +1,122 @@
+package design
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// MQTTTopicDefinition binds an action or a media type to a MQTT topic. The topic levels may be
+// wildcards of the form ":name" that match a single level or a last level of the form "*name"
+// that matches any number of levels. The wildcard values are the values of the action parameters
+// with the same names for actions and the arguments of the generated publisher for media types.
+type MQTTTopicDefinition struct {
+	// Topic is the topic name including wildcards.
+	Topic string
+	// QoS is the MQTT quality of service level used to publish or subscribe: 0, 1 or 2.
+	QoS int
+	// Retain is true if the messages published on the topic are retained by the broker.
+	Retain bool
+	// Subscribe is true if the definition describes a subscription, false for a publication.
+	Subscribe bool
+	// Parent is the action or media type bound to the topic.
+	Parent dslengine.Definition
+}
+
+// Context returns the generic definition name used in error messages.
+func (t *MQTTTopicDefinition) Context() string {
+	kind := "MQTT publication"
+	if t.Subscribe {
+		kind = "MQTT subscription"
+	}
+	if t.Parent != nil {
+		return fmt.Sprintf("%s of %s", kind, t.Parent.Context())
+	}
+	return kind
+}
+
+// Wildcards returns the names of the topic wildcards in order.
+func (t *MQTTTopicDefinition) Wildcards() []string {
+	var names []string
+	for _, level := range strings.Split(t.Topic, "/") {
+		if len(level) > 1 && (level[0] == ':' || level[0] == '*') {
+			names = append(names, level[1:])
+		}
+	}
+	return names
+}
+
+// Filter returns the MQTT topic filter used to subscribe to the topic: the single level
+// wildcards are replaced with "+" and the multi-level wildcard with "#".
+func (t *MQTTTopicDefinition) Filter() string {
+	levels := strings.Split(t.Topic, "/")
+	for i, level := range levels {
+		if len(level) > 1 {
+			switch level[0] {
+			case ':':
+				levels[i] = "+"
+			case '*':
+				levels[i] = "#"
+			}
+		}
+	}
+	return strings.Join(levels, "/")
+}
+
+// Validate checks that the topic definition is consistent: the topic is not empty and does not
+// use the MQTT wildcard characters directly, the multi-level wildcard if any is last, the QoS is
+// valid and subscriptions are not retained. The wildcards of topics bound to actions must match
+// action parameters and the wildcards of the topic actions publish their responses on must be
+// wildcards of the subscription topic.
+func (t *MQTTTopicDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if t.Topic == "" {
+		verr.Add(t, "topic cannot be empty")
+	}
+	levels := strings.Split(t.Topic, "/")
+	for i, level := range levels {
+		if strings.ContainsAny(level, "+#") {
+			verr.Add(t, `topic %#v cannot use the MQTT wildcards "+" and "#", use ":name" and "*name" levels instead`, t.Topic)
+			break
+		}
+		if strings.HasPrefix(level, "*") && i != len(levels)-1 {
+			verr.Add(t, "multi-level wildcard %s of topic %#v must be the last level", level, t.Topic)
+		}
+	}
+	if t.QoS < 0 || t.QoS > 2 {
+		verr.Add(t, "invalid QoS %d, must be 0, 1 or 2", t.QoS)
+	}
+	if t.Subscribe && t.Retain {
+		verr.Add(t, "Retain only applies to publications")
+	}
+	if a, ok := t.Parent.(*ActionDefinition); ok {
+		params := a.AllParams()
+		for _, w := range t.Wildcards() {
+			if params == nil || !params.Type.IsObject() || params.Type.ToObject()[w] == nil {
+				verr.Add(t, "topic wildcard %s does not match any action parameter", w)
+			}
+		}
+		if !t.Subscribe {
+			if a.MQTTSubscription == nil {
+				verr.Add(t, "action publishing its responses on a MQTT topic must subscribe to a MQTT topic")
+			} else {
+				subscribed := make(map[string]bool)
+				for _, w := range a.MQTTSubscription.Wildcards() {
+					subscribed[w] = true
+				}
+				for _, w := range t.Wildcards() {
+					if !subscribed[w] {
+						verr.Add(t, "topic wildcard %s is not a wildcard of the subscription topic", w)
+					}
+				}
+			}
+		}
+	}
+	return verr.AsError()
+}
+
+// IsMQTT returns true if the action is invoked by messages published on a MQTT topic.
+func (a *ActionDefinition) IsMQTT() bool {
+	return a.MQTTSubscription != nil
+}
@@ -0,0 +1,109 @@
+package design
+
+import "sort"
+
+// PIIKey is the attribute metadata key listing the categories of personally identifiable
+// information held by the attribute, see the PII DSL.
+const PIIKey = "goa:pii"
+
+// PIIField describes a field that holds personally identifiable information.
+type PIIField struct {
+	// Path is the path of the field relative to the attribute that contains it, e.g.
+	// "address.street". Array elements are denoted with "[]", e.g. "contacts[].email".
+	Path string
+	// Categories lists the PII categories of the field.
+	Categories []string
+}
+
+// PIICategories returns the categories of personally identifiable information held by the
+// attribute, nil if the attribute holds none.
+func (a *AttributeDefinition) PIICategories() []string {
+	return a.Metadata[PIIKey]
+}
+
+// PIIFields returns the fields of the attribute tagged with PII sorted by path. It looks into the
+// objects, arrays, user types and media types the attribute contains.
+func (a *AttributeDefinition) PIIFields() []*PIIField {
+	var fields []*PIIField
+	collectPIIFields(a.Type, "", &fields, make(map[interface{}]bool))
+	sort.Sort(piiFieldsByPath(fields))
+	return fields
+}
+
+// collectPIIFields implements PIIFields, seen records the user types already visited to support
+// recursive types.
+func collectPIIFields(dt DataType, prefix string, fields *[]*PIIField, seen map[interface{}]bool) {
+	switch t := dt.(type) {
+	case *MediaTypeDefinition:
+		if !seen[t] {
+			seen[t] = true
+			collectPIIFields(t.Type, prefix, fields, seen)
+			delete(seen, t)
+		}
+	case *UserTypeDefinition:
+		if !seen[t] {
+			seen[t] = true
+			collectPIIFields(t.Type, prefix, fields, seen)
+			delete(seen, t)
+		}
+	case *Array:
+		collectPIIFields(t.ElemType.Type, prefix+"[]", fields, seen)
+	case Object:
+		for n, att := range t {
+			path := n
+			if prefix != "" {
+				path = prefix + "." + n
+			}
+			if cats := att.PIICategories(); len(cats) > 0 {
+				*fields = append(*fields, &PIIField{Path: path, Categories: cats})
+			}
+			collectPIIFields(att.Type, path, fields, seen)
+		}
+	}
+}
+
+// piiFieldsByPath sorts PII fields by path.
+type piiFieldsByPath []*PIIField
+
+func (p piiFieldsByPath) Len() int           { return len(p) }
+func (p piiFieldsByPath) Less(i, j int) bool { return p[i].Path < p[j].Path }
+func (p piiFieldsByPath) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// PIINames returns the sorted names of the parameters, headers and top level payload attributes
+// of the action that hold personally identifiable information directly or in the fields they
+// contain. The generated code redacts their values from the request logs.
+func (a *ActionDefinition) PIINames() []string {
+	return a.topLevelNames(func(att *AttributeDefinition) bool {
+		return len(att.PIICategories()) > 0 || len(att.PIIFields()) > 0
+	})
+}
+
+// topLevelNames returns the sorted names of the parameters, headers and top level payload
+// attributes of the action for which match returns true.
+func (a *ActionDefinition) topLevelNames(match func(*AttributeDefinition) bool) []string {
+	set := make(map[string]bool)
+	collect := func(att *AttributeDefinition) {
+		if att == nil || !att.Type.IsObject() {
+			return
+		}
+		for n, f := range att.Type.ToObject() {
+			if match(f) {
+				set[n] = true
+			}
+		}
+	}
+	collect(a.AllParams())
+	if a.Parent != nil {
+		collect(a.Parent.Headers)
+	}
+	collect(a.Headers)
+	if a.Payload != nil {
+		collect(a.Payload.AttributeDefinition)
+	}
+	names := make([]string, 0, len(set))
+	for n := range set {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
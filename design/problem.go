@@ -0,0 +1,69 @@
+package design
+
+import "github.com/goadesign/goa/dslengine"
+
+// ErrorMediaIdentifier is the identifier of ErrorMedia.
+const ErrorMediaIdentifier = "application/problem+json"
+
+// ErrorMedia is the built-in media type of RFC 7807 problem details documents. Responses that use
+// it render errors as JSON objects with the "type", "title", "status", "detail" and "instance"
+// members, see goa.Problem. ErrorMedia is added to the design media types the first time it is
+// used.
+var ErrorMedia = newErrorMedia()
+
+// newErrorMedia builds the ErrorMedia media type definition.
+func newErrorMedia() *MediaTypeDefinition {
+	att := &AttributeDefinition{
+		Type: Object{
+			"type": &AttributeDefinition{
+				Type:         String,
+				Description:  "URI reference that identifies the problem type",
+				DefaultValue: "about:blank",
+			},
+			"title": &AttributeDefinition{
+				Type:        String,
+				Description: "Short, human-readable summary of the problem type",
+			},
+			"status": &AttributeDefinition{
+				Type:        Integer,
+				Description: "HTTP status code generated by the origin server for this occurrence of the problem",
+			},
+			"detail": &AttributeDefinition{
+				Type:        String,
+				Description: "Human-readable explanation specific to this occurrence of the problem",
+			},
+			"instance": &AttributeDefinition{
+				Type:        String,
+				Description: "URI reference that identifies the specific occurrence of the problem",
+			},
+		},
+		Description: "Error response media type, see RFC 7807",
+		Validation:  &dslengine.ValidationDefinition{Required: []string{"title", "status"}},
+	}
+	mt := &MediaTypeDefinition{
+		UserTypeDefinition: &UserTypeDefinition{
+			AttributeDefinition: att,
+			TypeName:            "ErrorMedia",
+		},
+		Identifier: ErrorMediaIdentifier,
+	}
+	mt.Views = map[string]*ViewDefinition{
+		"default": {
+			AttributeDefinition: &AttributeDefinition{Type: att.Type},
+			Name:                "default",
+			Parent:              mt,
+		},
+	}
+	return mt
+}
+
+// UseErrorMedia adds ErrorMedia to the design media types if not already present.
+func (a *APIDefinition) UseErrorMedia() {
+	if a.MediaTypes == nil {
+		a.MediaTypes = make(map[string]*MediaTypeDefinition)
+	}
+	id := CanonicalIdentifier(ErrorMediaIdentifier)
+	if _, ok := a.MediaTypes[id]; !ok {
+		a.MediaTypes[id] = ErrorMedia
+	}
+}
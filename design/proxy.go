@@ -0,0 +1,61 @@
+package design
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// ProxyDefinition describes the upstream service that the requests made to an action are
+// forwarded to. Proxy actions have no controller method: the generated handler validates the
+// request params, headers and payload, runs the controller middleware and streams the request to
+// the upstream service and the response back to the client.
+type ProxyDefinition struct {
+	// Upstream is the URL of the upstream service, e.g. "http://search.internal:8080/v2".
+	Upstream string
+	// Timeout is the maximum duration of upstream requests, zero means no timeout.
+	Timeout time.Duration
+	// StripPrefix is removed from the path of the requests before it is appended to the
+	// upstream URL path.
+	StripPrefix string
+	// SetHeaders lists the headers set on upstream requests indexed by name.
+	SetHeaders map[string]string
+	// RemoveHeaders lists the names of the headers removed from upstream requests.
+	RemoveHeaders []string
+	// Parent is the proxied action.
+	Parent *ActionDefinition
+}
+
+// Context returns the generic definition name used in error messages.
+func (p *ProxyDefinition) Context() string {
+	if p.Parent != nil {
+		return fmt.Sprintf("proxy of %s", p.Parent.Context())
+	}
+	return "proxy"
+}
+
+// Validate checks that the upstream URL is an absolute HTTP or HTTPS URL, that the timeout is
+// not negative and that the action is not also a WebSocket endpoint.
+func (p *ProxyDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	u, err := url.Parse(p.Upstream)
+	if err != nil {
+		verr.Add(p, "invalid upstream URL %#v: %s", p.Upstream, err)
+	} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.Add(p, "invalid upstream URL %#v, must be an absolute http or https URL", p.Upstream)
+	}
+	if p.Timeout < 0 {
+		verr.Add(p, "invalid timeout %s, must be positive", p.Timeout)
+	}
+	if p.Parent != nil && p.Parent.WebSocket != nil {
+		verr.Add(p, "WebSocket actions cannot be proxied")
+	}
+	return verr.AsError()
+}
+
+// IsProxy returns true if the requests made to the action are forwarded to an upstream service.
+func (a *ActionDefinition) IsProxy() bool {
+	return a.Proxy != nil
+}
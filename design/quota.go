@@ -0,0 +1,123 @@
+package design
+
+import (
+	"path"
+
+	"github.com/goadesign/goa/dslengine"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// Daily is the period of the quotas whose usage is reset every day at midnight UTC.
+	Daily = "daily"
+	// Monthly is the period of the quotas whose usage is reset on the first day of every
+	// month at midnight UTC.
+	Monthly = "monthly"
+
+	// QuotaRequests is the unit of the quotas that limit the number of requests.
+	QuotaRequests = "requests"
+	// QuotaBytes is the unit of the quotas that limit the number of bytes of the request and
+	// response bodies.
+	QuotaBytes = "bytes"
+
+	// QuotaStatusPath is the path relative to the API base path of the endpoint generated for
+	// the APIs that define quotas. The endpoint returns the quota usage of the request
+	// principal.
+	QuotaStatusPath = "/quota"
+)
+
+type (
+	// QuotaDefinition describes the budgets of the requests made by each principal to the
+	// actions of the API, a resource or an action. Each budget is shared by all the actions
+	// the quota applies to.
+	QuotaDefinition struct {
+		// Limits lists the budgets of the quota.
+		Limits []*QuotaLimitDefinition
+		// Parent is the API, resource or action the quota applies to.
+		Parent dslengine.Definition
+	}
+
+	// QuotaLimitDefinition describes a single budget of a quota.
+	QuotaLimitDefinition struct {
+		// Unit is QuotaRequests or QuotaBytes.
+		Unit string
+		// Limit is the number of requests or bytes allowed per period.
+		Limit int64
+		// Period is Daily or Monthly.
+		Period string
+	}
+)
+
+// Context returns the generic definition name used in error messages.
+func (q *QuotaDefinition) Context() string {
+	if q.Parent != nil {
+		return "quota of " + q.Parent.Context()
+	}
+	return "quota"
+}
+
+// Validate checks that the quota defines at least one budget and that the budgets are valid.
+func (q *QuotaDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if len(q.Limits) == 0 {
+		verr.Add(q, "quota must define at least one budget with Requests or Bytes")
+	}
+	seen := make(map[string]bool)
+	for _, l := range q.Limits {
+		if l.Limit <= 0 {
+			verr.Add(q, "invalid %s budget %d, must be strictly positive", l.Unit, l.Limit)
+		}
+		if l.Period != Daily && l.Period != Monthly {
+			verr.Add(q, "invalid %s budget period %#v, must be Daily or Monthly", l.Unit, l.Period)
+		}
+		if seen[l.Unit+" "+l.Period] {
+			verr.Add(q, "%s %s budget is defined more than once", l.Period, l.Unit)
+		}
+		seen[l.Unit+" "+l.Period] = true
+	}
+	return verr.AsError()
+}
+
+// Scope returns the name of the budgets of the quota: "api" for API quotas, the resource name
+// for resource quotas and "<resource>#<action>" for action quotas.
+func (q *QuotaDefinition) Scope() string {
+	return limitScope(q.Parent)
+}
+
+// EffectiveQuota returns the quota that applies to the action: the action quota if any, the
+// resource quota otherwise and finally the API quota. It returns nil if the action has no quota.
+func (a *ActionDefinition) EffectiveQuota() *QuotaDefinition {
+	if a.Quota != nil {
+		return a.Quota
+	}
+	if a.Parent != nil && a.Parent.Quota != nil {
+		return a.Parent.Quota
+	}
+	if Design != nil {
+		return Design.Quota
+	}
+	return nil
+}
+
+// HasQuotas returns true if the API, one of its resources or one of its actions defines a quota.
+func (a *APIDefinition) HasQuotas() bool {
+	if a.Quota != nil {
+		return true
+	}
+	for _, r := range a.Resources {
+		if r.Quota != nil {
+			return true
+		}
+		for _, act := range r.Actions {
+			if act.Quota != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// QuotaStatusFullPath returns the path of the quota status endpoint including the API base path.
+func (a *APIDefinition) QuotaStatusFullPath() string {
+	return httprouter.CleanPath(path.Join(a.BasePath, QuotaStatusPath))
+}
@@ -7,8 +7,13 @@ import (
 	"time"
 
 	"github.com/manveru/faker"
+	regen "github.com/zach-klippenstein/goregen"
 )
 
+// maxDateTime is the upper bound of the random dates produced by RandomGenerator. Using a fixed
+// bound rather than the current time guarantees that the same seed always produces the same dates.
+var maxDateTime = time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+
 // RandomGenerator generates consistent random values of different types given a seed.
 // The random values are consistent in that given the same seed the same random values get
 // generated.
@@ -48,14 +53,19 @@ func (r *RandomGenerator) String() string {
 
 }
 
-// DateTime produces a random date.
+// DateTime produces a random UTC date.
 func (r *RandomGenerator) DateTime() time.Time {
-	// get the time now as seconds since epoch
-	// seed the int random generator with it
-	// and convert that back to a time
-	unix := r.rand.Int63n(time.Now().Unix())
-	return time.Unix(unix, 0)
+	unix := r.rand.Int63n(maxDateTime)
+	return time.Unix(unix, 0).UTC()
+}
 
+// Regexp produces a random string that matches the given regular expression.
+func (r *RandomGenerator) Regexp(pattern string) (string, error) {
+	g, err := regen.NewGenerator(pattern, &regen.GeneratorArgs{RngSource: r.rand})
+	if err != nil {
+		return "", err
+	}
+	return g.Generate(), nil
 }
 
 // Bool produces a random boolean.
@@ -0,0 +1,18 @@
+package design_test
+
+import (
+	"time"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RandomGenerator", func() {
+	It("produces the same dates for the same seed", func() {
+		d1 := NewRandomGenerator("seed").DateTime()
+		d2 := NewRandomGenerator("seed").DateTime()
+		Ω(d1).Should(Equal(d2))
+		Ω(d1.Location()).Should(Equal(time.UTC))
+	})
+})
@@ -0,0 +1,89 @@
+package design
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+const (
+	// TokenBucket is the rate limiting algorithm that allows bursts of up to the limit and
+	// refills the bucket of each client continuously.
+	TokenBucket = "token-bucket"
+	// SlidingWindow is the rate limiting algorithm that counts the requests made by each client
+	// in the period preceding each request.
+	SlidingWindow = "sliding-window"
+)
+
+// RateLimitDefinition describes the rate at which each client may make requests to the actions of
+// the API, a resource or an action. The limit is shared by all the actions it applies to.
+type RateLimitDefinition struct {
+	// Limit is the number of requests allowed per period.
+	Limit int
+	// Period is the duration of the period.
+	Period time.Duration
+	// Algorithm is TokenBucket or SlidingWindow.
+	Algorithm string
+	// Parent is the API, resource or action the rate limit applies to.
+	Parent dslengine.Definition
+}
+
+// Context returns the generic definition name used in error messages.
+func (r *RateLimitDefinition) Context() string {
+	if r.Parent != nil {
+		return "rate limit of " + r.Parent.Context()
+	}
+	return "rate limit"
+}
+
+// Validate checks that the limit, period and algorithm are valid.
+func (r *RateLimitDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if r.Limit <= 0 {
+		verr.Add(r, "invalid rate limit %d, must be strictly positive", r.Limit)
+	}
+	if r.Period < time.Second {
+		verr.Add(r, "invalid rate limit period %s, must be at least one second", r.Period)
+	}
+	if r.Algorithm != TokenBucket && r.Algorithm != SlidingWindow {
+		verr.Add(r, "invalid rate limit algorithm %#v, must be TokenBucket or SlidingWindow", r.Algorithm)
+	}
+	return verr.AsError()
+}
+
+// Scope returns the name of the rate limit: "api" for API rate limits, the resource name for
+// resource rate limits and "<resource>#<action>" for action rate limits.
+func (r *RateLimitDefinition) Scope() string {
+	return limitScope(r.Parent)
+}
+
+// EffectiveRateLimit returns the rate limit that applies to the action: the action rate limit if
+// any, the resource rate limit otherwise and finally the API rate limit. It returns nil if the
+// action has no rate limit.
+func (a *ActionDefinition) EffectiveRateLimit() *RateLimitDefinition {
+	if a.RateLimit != nil {
+		return a.RateLimit
+	}
+	if a.Parent != nil && a.Parent.RateLimit != nil {
+		return a.Parent.RateLimit
+	}
+	if Design != nil {
+		return Design.RateLimit
+	}
+	return nil
+}
+
+// limitScope returns the name of the quotas and rate limits defined in the given API, resource or
+// action.
+func limitScope(parent dslengine.Definition) string {
+	switch p := parent.(type) {
+	case *ResourceDefinition:
+		return p.Name
+	case *ActionDefinition:
+		if p.Parent != nil {
+			return p.Parent.Name + "#" + p.Name
+		}
+		return p.Name
+	}
+	return "api"
+}
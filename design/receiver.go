@@ -0,0 +1,138 @@
+package design
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// List of supported inbound webhook providers.
+const (
+	// WebhookProviderGitHub denotes GitHub style webhooks signed with HMAC SHA-256.
+	WebhookProviderGitHub = "github"
+	// WebhookProviderStripe denotes Stripe style webhooks signed with a timestamped HMAC SHA-256.
+	WebhookProviderStripe = "stripe"
+	// WebhookProviderHMAC denotes webhooks sent by goa callback dispatchers.
+	WebhookProviderHMAC = "hmac"
+)
+
+// DefaultWebhookTolerance is the default maximum age of the timestamp of Stripe requests.
+const DefaultWebhookTolerance = 5 * time.Minute
+
+type (
+	// WebhookDefinition describes an inbound webhook: the requests made by a third party
+	// service to notify the API of events. The requests are signed with a secret shared with
+	// the provider, the generated code verifies the signatures, decodes the event envelopes and
+	// dispatches the events to typed handlers.
+	WebhookDefinition struct {
+		// Provider is one of WebhookProviderGitHub, WebhookProviderStripe or
+		// WebhookProviderHMAC.
+		Provider string
+		// SigningScheme is the scheme used to sign the requests, Stripe requests are always
+		// signed with SigningHMACSHA256.
+		SigningScheme string
+		// SignatureHeader is the name of the header holding the request signature.
+		SignatureHeader string
+		// EventHeader is the name of the header holding the event name, not used by the
+		// Stripe provider.
+		EventHeader string
+		// DeliveryHeader is the name of the header holding the delivery ID, not used by the
+		// Stripe provider.
+		DeliveryHeader string
+		// Tolerance is the maximum age of the timestamp of Stripe requests, zero disables the
+		// check.
+		Tolerance time.Duration
+		// Events lists the types of the payloads of the handled events indexed by event name.
+		Events map[string]*UserTypeDefinition
+		// Parent is the action receiving the webhook requests.
+		Parent *ActionDefinition
+	}
+
+	// WebhookEventIterator is the type of functions given to IterateEvents.
+	WebhookEventIterator func(name string, t *UserTypeDefinition) error
+)
+
+// Context returns the generic definition name used in error messages.
+func (w *WebhookDefinition) Context() string {
+	if w.Parent != nil {
+		return fmt.Sprintf("webhook of %s", w.Parent.Context())
+	}
+	return "webhook"
+}
+
+// Validate checks that the provider and signing scheme are supported, that at least one event is
+// handled and that the action receiving the requests accepts POST requests and does not define a
+// payload - the request body is the event envelope.
+func (w *WebhookDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	switch w.Provider {
+	case WebhookProviderGitHub, WebhookProviderHMAC:
+		switch w.SigningScheme {
+		case SigningHMACSHA1, SigningHMACSHA256, SigningHMACSHA512:
+		default:
+			verr.Add(w, "unsupported signing scheme %#v, must be one of %#v, %#v or %#v", w.SigningScheme,
+				SigningHMACSHA1, SigningHMACSHA256, SigningHMACSHA512)
+		}
+	case WebhookProviderStripe:
+		if w.SigningScheme != SigningHMACSHA256 {
+			verr.Add(w, "Stripe webhooks are signed with %#v", SigningHMACSHA256)
+		}
+	default:
+		verr.Add(w, "unknown provider %#v, must be one of %#v, %#v or %#v", w.Provider,
+			WebhookProviderGitHub, WebhookProviderStripe, WebhookProviderHMAC)
+	}
+	if w.SignatureHeader == "" {
+		verr.Add(w, "signature header cannot be empty")
+	}
+	if w.Provider != WebhookProviderStripe && (w.EventHeader == "" || w.DeliveryHeader == "") {
+		verr.Add(w, "event and delivery headers cannot be empty")
+	}
+	if w.Tolerance < 0 {
+		verr.Add(w, "invalid tolerance %s, cannot be negative", w.Tolerance)
+	}
+	if len(w.Events) == 0 {
+		verr.Add(w, "webhook must handle at least one event")
+	}
+	for name, t := range w.Events {
+		if t == nil {
+			verr.Add(w, "missing type of event %#v", name)
+		} else if !t.IsObject() {
+			verr.Add(w, "type of event %#v must be an object", name)
+		}
+	}
+	if a := w.Parent; a != nil {
+		if a.Payload != nil {
+			verr.Add(w, "webhook actions cannot define a payload, use WebhookEvent to define the event types")
+		}
+		if a.Proxy != nil || a.WebSocket != nil || a.LongPoll != nil {
+			verr.Add(w, "webhook actions cannot be proxies, WebSocket endpoints or long poll")
+		}
+		for _, r := range a.Routes {
+			if r.Verb != "POST" {
+				verr.Add(w, "webhook actions only accept POST requests, route %s %s is invalid", r.Verb, r.Path)
+			}
+		}
+	}
+	return verr.AsError()
+}
+
+// IterateEvents calls the given iterator passing in the name and payload type of each event handled
+// by the webhook sorted in alphabetical order. Iteration stops if an iterator returns an error and
+// in this case IterateEvents returns that error.
+func (w *WebhookDefinition) IterateEvents(it WebhookEventIterator) error {
+	names := make([]string, len(w.Events))
+	i := 0
+	for n := range w.Events {
+		names[i] = n
+		i++
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if err := it(n, w.Events[n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
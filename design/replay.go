@@ -0,0 +1,66 @@
+package design
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+const (
+	// ReplayTimestampHeader is the name of the header holding the time the replay protected
+	// requests were made at in seconds since the Unix epoch.
+	ReplayTimestampHeader = "X-Request-Timestamp"
+
+	// ReplayNonceHeader is the name of the header holding the unique nonce of the replay
+	// protected requests.
+	ReplayNonceHeader = "X-Request-Nonce"
+
+	// DefaultReplayTolerance is the default maximum difference between the timestamp of the
+	// replay protected requests and the server clock.
+	DefaultReplayTolerance = 5 * time.Minute
+)
+
+// ReplayDefinition describes the replay protection of actions. The requests made to protected
+// actions must carry a timestamp and a nonce headers, requests whose timestamp is too far from the
+// server clock or whose nonce was already used are rejected. Actions secured with HMAC security
+// schemes sign both headers.
+type ReplayDefinition struct {
+	// Tolerance is the maximum difference between the request timestamp and the server clock,
+	// zero means DefaultReplayTolerance. Nonces are remembered for twice that duration.
+	Tolerance time.Duration
+	// Parent is the API, resource or action being protected.
+	Parent dslengine.Definition
+}
+
+// Context returns the generic definition name used in error messages.
+func (r *ReplayDefinition) Context() string {
+	if r.Parent != nil {
+		return "replay protection of " + r.Parent.Context()
+	}
+	return "replay protection"
+}
+
+// Validate checks that the tolerance is not negative.
+func (r *ReplayDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if r.Tolerance < 0 {
+		verr.Add(r, "invalid tolerance %s, cannot be negative", r.Tolerance)
+	}
+	return verr.AsError()
+}
+
+// EffectiveReplay returns the replay protection that applies to the action: the action
+// protection if any, the resource protection otherwise and finally the API protection. It returns
+// nil if the action is not protected.
+func (a *ActionDefinition) EffectiveReplay() *ReplayDefinition {
+	if a.Replay != nil {
+		return a.Replay
+	}
+	if a.Parent != nil && a.Parent.Replay != nil {
+		return a.Parent.Replay
+	}
+	if Design != nil {
+		return Design.Replay
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package design
+
+import "github.com/goadesign/goa/dslengine"
+
+const (
+	// EncryptedKey is the attribute metadata key set on the attributes whose values are
+	// encrypted with the service Crypter, see the Encrypted DSL.
+	EncryptedKey = "goa:encrypted"
+
+	// SensitiveKey is the attribute metadata key set on the attributes whose values must not
+	// appear in the logs, audit events and error messages, see the Sensitive DSL.
+	SensitiveKey = "goa:sensitive"
+)
+
+// IsEncrypted returns true if the values of the attribute are encrypted with the service Crypter.
+func (a *AttributeDefinition) IsEncrypted() bool {
+	_, ok := a.Metadata[EncryptedKey]
+	return ok
+}
+
+// IsSensitive returns true if the values of the attribute must be kept out of the logs, audit
+// events and error messages. Encrypted attributes are always sensitive.
+func (a *AttributeDefinition) IsSensitive() bool {
+	if _, ok := a.Metadata[SensitiveKey]; ok {
+		return true
+	}
+	return a.IsEncrypted()
+}
+
+// HasEncryptedFields returns true if the attribute is an object, a user type, a media type or an
+// array of these that has encrypted fields, directly or in the objects, user types and media types
+// it contains. The generated types of such attributes have EncryptFields and DecryptFields methods.
+func (a *AttributeDefinition) HasEncryptedFields() bool {
+	return hasMatchingFields(a.Type, (*AttributeDefinition).IsEncrypted, make(map[interface{}]bool))
+}
+
+// HasSensitiveFields returns true if the attribute is an object, a user type, a media type or an
+// array of these that has sensitive fields, directly or in the objects, user types and media
+// types it contains.
+func (a *AttributeDefinition) HasSensitiveFields() bool {
+	return hasMatchingFields(a.Type, (*AttributeDefinition).IsSensitive, make(map[interface{}]bool))
+}
+
+// SensitiveNames returns the sorted names of the parameters, headers and top level payload
+// attributes of the action that are sensitive or contain sensitive fields. The generated code
+// redacts their values from the request logs and audit events.
+func (a *ActionDefinition) SensitiveNames() []string {
+	return a.topLevelNames(func(att *AttributeDefinition) bool {
+		return att.IsSensitive() || att.HasSensitiveFields()
+	})
+}
+
+// validateSecrets checks that the encrypted fields of the object attribute a are strings as the
+// crypters encrypt and decrypt string values.
+func (a *AttributeDefinition) validateSecrets(ctx string, parent dslengine.Definition) *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	for n, att := range a.Type.ToObject() {
+		if att.IsEncrypted() && att.Type.Kind() != StringKind {
+			verr.Add(parent, `%sfield "%s" is encrypted and thus must be a string`, ctx, n)
+		}
+	}
+	return verr.AsError()
+}
+
+// hasMatchingFields returns true if the given type contains fields for which match returns true,
+// seen records the user types already visited to support recursive types.
+func hasMatchingFields(dt DataType, match func(*AttributeDefinition) bool, seen map[interface{}]bool) bool {
+	switch t := dt.(type) {
+	case *MediaTypeDefinition:
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+		return hasMatchingFields(t.Type, match, seen)
+	case *UserTypeDefinition:
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+		return hasMatchingFields(t.Type, match, seen)
+	case *Array:
+		return hasMatchingFields(t.ElemType.Type, match, seen)
+	case Object:
+		for _, att := range t {
+			if match(att) || hasMatchingFields(att.Type, match, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
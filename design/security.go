@@ -0,0 +1,308 @@
+package design
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Kinds of security schemes.
+const (
+	// JWTSecurityKind is the kind of the schemes that authenticate requests with a JSON Web
+	// Token.
+	JWTSecurityKind = "jwt"
+
+	// APIKeySecurityKind is the kind of the schemes that authenticate requests with an API key
+	// given in a header or query string parameter.
+	APIKeySecurityKind = "apiKey"
+
+	// BasicAuthSecurityKind is the kind of the schemes that authenticate requests with HTTP
+	// basic authentication.
+	BasicAuthSecurityKind = "basic"
+
+	// OAuth2SecurityKind is the kind of the schemes that authenticate requests with an OAuth2
+	// bearer access token.
+	OAuth2SecurityKind = "oauth2"
+
+	// OIDCSecurityKind is the kind of the schemes that authenticate requests with an OpenID
+	// Connect ID token issued by an external provider.
+	OIDCSecurityKind = "oidc"
+
+	// HMACSecurityKind is the kind of the schemes that authenticate requests with a HMAC
+	// signature computed over the canonical request with a secret shared with the client.
+	HMACSecurityKind = "hmac"
+
+	// MutualTLSSecurityKind is the kind of the schemes that authenticate requests with the
+	// client certificate presented during the TLS handshake.
+	MutualTLSSecurityKind = "mtls"
+)
+
+// OAuth2 flows.
+const (
+	// OAuth2AccessCodeFlow is the OAuth2 authorization code flow.
+	OAuth2AccessCodeFlow = "accessCode"
+
+	// OAuth2ImplicitFlow is the OAuth2 implicit flow.
+	OAuth2ImplicitFlow = "implicit"
+
+	// OAuth2PasswordFlow is the OAuth2 resource owner password credentials flow.
+	OAuth2PasswordFlow = "password"
+
+	// OAuth2ApplicationFlow is the OAuth2 client credentials flow.
+	OAuth2ApplicationFlow = "application"
+)
+
+type (
+	// SecuritySchemeDefinition describes a security scheme used to authenticate requests.
+	// Security schemes are defined with the JWTSecurity, OIDCSecurity, APIKeySecurity,
+	// BasicAuthSecurity, OAuth2Security, HMACSecurity and MutualTLSSecurity DSLs and applied to
+	// the API, resources or actions with Security.
+	SecuritySchemeDefinition struct {
+		dslengine.DSLLocation
+		// Kind is one of JWTSecurityKind, APIKeySecurityKind, BasicAuthSecurityKind,
+		// OAuth2SecurityKind, OIDCSecurityKind, HMACSecurityKind or MutualTLSSecurityKind.
+		Kind string
+		// SchemeName is the name of the scheme, e.g. "jwt".
+		SchemeName string
+		// Description is the optional scheme description.
+		Description string
+		// In is the location of the credentials for the JWT, OIDC, API key and HMAC schemes,
+		// either "header" or "query". HMAC schemes only support "header".
+		In string
+		// Name is the name of the header or query string parameter holding the credentials
+		// for the JWT, OIDC, API key and HMAC schemes.
+		Name string
+		// Scopes lists the scopes defined by JWT, OIDC and OAuth2 schemes indexed by name,
+		// the values are the scope descriptions.
+		Scopes map[string]string
+		// Flow is the OAuth2 flow, one of OAuth2AccessCodeFlow, OAuth2ImplicitFlow,
+		// OAuth2PasswordFlow or OAuth2ApplicationFlow.
+		Flow string
+		// TokenURL is the URL of the endpoint issuing the tokens for the JWT and OAuth2
+		// schemes.
+		TokenURL string
+		// AuthorizationURL is the URL of the OAuth2 authorization endpoint.
+		AuthorizationURL string
+		// Issuer is the URL of the OpenID Connect provider issuing the tokens of OIDC
+		// schemes.
+		Issuer string
+		// Audience is the audience the tokens of OIDC schemes must be issued for, typically
+		// the client ID of the API with the provider.
+		Audience string
+		// Tolerance is the maximum difference between the date of the requests signed with
+		// HMAC schemes and the server clock, zero means the runtime default.
+		Tolerance time.Duration
+		// ClientCA is the path to the PEM file containing the certificates of the authorities
+		// that issue the client certificates of mutual TLS schemes.
+		ClientCA string
+		// KeysPath is the path of the key administration endpoints of API key schemes whose
+		// keys are managed by the service, see the KeyManagement DSL.
+		KeysPath string
+		// Claims describes the token claims mapped into the principal type generated for JWT
+		// and OIDC schemes, nil if the scheme does not define claims.
+		Claims *AttributeDefinition
+		// DSLFunc contains the DSL used to initialize the scheme.
+		DSLFunc func()
+	}
+
+	// SecurityDefinition describes the security requirements of the API, a resource or an
+	// action: the scheme used to authenticate the requests and the scopes they must be granted.
+	// A definition with a nil scheme disables security, see NoSecurity.
+	SecurityDefinition struct {
+		// Scheme is the security scheme, nil if security is disabled.
+		Scheme *SecuritySchemeDefinition
+		// Scopes lists the scopes required by the requests.
+		Scopes []string
+		// Parent is the API, resource or action definition the requirements apply to.
+		Parent dslengine.Definition
+	}
+
+	// SecuritySchemeIterator is the type of functions given to IterateSecuritySchemes.
+	SecuritySchemeIterator func(s *SecuritySchemeDefinition) error
+)
+
+// Context returns the generic definition name used in error messages.
+func (s *SecuritySchemeDefinition) Context() string {
+	if s.SchemeName != "" {
+		return fmt.Sprintf("security scheme %#v", s.SchemeName)
+	}
+	return "unnamed security scheme"
+}
+
+// DSL returns the initialization DSL.
+func (s *SecuritySchemeDefinition) DSL() func() {
+	return s.DSLFunc
+}
+
+// HasScopes returns true if the scheme supports scopes: JWT, OIDC and OAuth2 schemes and the API
+// key schemes whose keys are managed by the service.
+func (s *SecuritySchemeDefinition) HasScopes() bool {
+	return s.Kind == JWTSecurityKind || s.Kind == OIDCSecurityKind || s.Kind == OAuth2SecurityKind ||
+		s.ManagesKeys()
+}
+
+// ManagesKeys returns true if the scheme is an API key scheme whose keys are issued, rotated and
+// revoked by the service.
+func (s *SecuritySchemeDefinition) ManagesKeys() bool {
+	return s.Kind == APIKeySecurityKind && s.KeysPath != ""
+}
+
+// HasClaims returns true if the scheme kind authenticates requests with tokens whose claims may
+// be mapped into a principal.
+func (s *SecuritySchemeDefinition) HasClaims() bool {
+	return s.Kind == JWTSecurityKind || s.Kind == OIDCSecurityKind
+}
+
+// Validate checks that the scheme definition is consistent: the location of the credentials is
+// set for JWT, OIDC, API key and HMAC schemes, OIDC schemes define an issuer, scopes and claims
+// are only defined for the kinds that support them and OAuth2 schemes define a flow together with
+// the URLs it requires, mutual TLS schemes define the client certificate authorities and the key
+// administration path of API key schemes is an absolute path.
+func (s *SecuritySchemeDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if s.SchemeName == "" {
+		verr.Add(s, "security scheme name cannot be empty")
+	}
+	switch s.Kind {
+	case JWTSecurityKind, OIDCSecurityKind, APIKeySecurityKind, HMACSecurityKind:
+		if s.Kind == HMACSecurityKind && s.In != "header" {
+			verr.Add(s, "HMAC security scheme signatures must be given in a header, use Header")
+		} else if s.In != "header" && s.In != "query" {
+			verr.Add(s, "missing credentials location, use Header or Query")
+		}
+		if s.Name == "" {
+			verr.Add(s, "missing credentials header or query string parameter name")
+		}
+		if s.Kind == OIDCSecurityKind {
+			if u, err := url.Parse(s.Issuer); err != nil || !u.IsAbs() {
+				verr.Add(s, "OIDC security scheme requires an absolute issuer URL, use Issuer")
+			}
+		}
+	case BasicAuthSecurityKind:
+	case MutualTLSSecurityKind:
+		if s.ClientCA == "" {
+			verr.Add(s, "mutual TLS security scheme requires the client certificate authorities, use ClientCA")
+		}
+	case OAuth2SecurityKind:
+		switch s.Flow {
+		case OAuth2AccessCodeFlow:
+			if s.AuthorizationURL == "" || s.TokenURL == "" {
+				verr.Add(s, "access code flow requires both an authorization and a token URL")
+			}
+		case OAuth2ImplicitFlow:
+			if s.AuthorizationURL == "" {
+				verr.Add(s, "implicit flow requires an authorization URL")
+			}
+		case OAuth2PasswordFlow, OAuth2ApplicationFlow:
+			if s.TokenURL == "" {
+				verr.Add(s, "%s flow requires a token URL", s.Flow)
+			}
+		case "":
+			verr.Add(s, "missing OAuth2 flow, use AccessCodeFlow, ImplicitFlow, PasswordFlow or ApplicationFlow")
+		}
+	default:
+		verr.Add(s, "unknown security scheme kind %#v", s.Kind)
+	}
+	if s.Tolerance < 0 {
+		verr.Add(s, "tolerance cannot be negative")
+	} else if s.Tolerance > 0 && s.Kind != HMACSecurityKind {
+		verr.Add(s, "tolerance is only supported by HMAC security schemes")
+	}
+	if s.ClientCA != "" && s.Kind != MutualTLSSecurityKind {
+		verr.Add(s, "client certificate authorities are only supported by mutual TLS security schemes")
+	}
+	if s.KeysPath != "" {
+		if s.Kind != APIKeySecurityKind {
+			verr.Add(s, "key management is only supported by API key security schemes")
+		} else if !strings.HasPrefix(s.KeysPath, "/") {
+			verr.Add(s, "invalid key administration path %#v, must start with /", s.KeysPath)
+		}
+	}
+	if len(s.Scopes) > 0 && !s.HasScopes() {
+		verr.Add(s, "scopes are only supported by JWT, OIDC, OAuth2 and managed API key security schemes")
+	}
+	if s.Claims != nil {
+		if !s.HasClaims() {
+			verr.Add(s, "claims are only supported by JWT and OIDC security schemes")
+		} else {
+			verr.Merge(s.Claims.Validate("claims", s))
+		}
+	}
+	for _, u := range []string{s.TokenURL, s.AuthorizationURL} {
+		if u == "" {
+			continue
+		}
+		if _, err := url.Parse(u); err != nil {
+			verr.Add(s, "invalid URL %#v: %s", u, err)
+		}
+	}
+	return verr.AsError()
+}
+
+// Context returns the generic definition name used in error messages.
+func (s *SecurityDefinition) Context() string {
+	if s.Parent != nil {
+		return "security of " + s.Parent.Context()
+	}
+	return "security"
+}
+
+// Validate checks that the security scheme is defined and that it defines the required scopes.
+func (s *SecurityDefinition) Validate() *dslengine.ValidationErrors {
+	if s.Scheme == nil {
+		return nil
+	}
+	verr := new(dslengine.ValidationErrors)
+	if Design.SecuritySchemes[s.Scheme.SchemeName] != s.Scheme {
+		verr.Add(s, "security scheme %#v is not defined", s.Scheme.SchemeName)
+	}
+	if len(s.Scopes) > 0 && !s.Scheme.HasScopes() {
+		verr.Add(s, "%s does not support scopes", s.Scheme.Context())
+	}
+	for _, scope := range s.Scopes {
+		if _, ok := s.Scheme.Scopes[scope]; !ok && s.Scheme.HasScopes() {
+			verr.Add(s, "scope %#v is not defined by %s", scope, s.Scheme.Context())
+		}
+	}
+	return verr.AsError()
+}
+
+// EffectiveSecurity returns the security requirements that apply to the action: the action
+// requirements if defined, the parent resource requirements otherwise or the API requirements if
+// the resource does not define any either. It returns nil if the action is not secured.
+func (a *ActionDefinition) EffectiveSecurity() *SecurityDefinition {
+	sec := a.Security
+	if sec == nil && a.Parent != nil {
+		sec = a.Parent.Security
+	}
+	if sec == nil && Design != nil {
+		sec = Design.Security
+	}
+	if sec == nil || sec.Scheme == nil {
+		return nil
+	}
+	return sec
+}
+
+// IterateSecuritySchemes calls the given iterator passing in each security scheme sorted in
+// alphabetical order. Iteration stops if an iterator returns an error and in this case
+// IterateSecuritySchemes returns that error.
+func (a *APIDefinition) IterateSecuritySchemes(it SecuritySchemeIterator) error {
+	names := make([]string, len(a.SecuritySchemes))
+	i := 0
+	for n := range a.SecuritySchemes {
+		names[i] = n
+		i++
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if err := it(a.SecuritySchemes[n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
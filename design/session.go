@@ -0,0 +1,112 @@
+package design
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// DefaultSessionCookie is the name of the session cookie when none is given.
+const DefaultSessionCookie = "session"
+
+type (
+	// SessionDefinition describes the browser session of the actions of an API or resource.
+	// The session state is kept in a signed (and optionally encrypted) cookie, its attributes
+	// describe the fields of the state.
+	SessionDefinition struct {
+		// CookieName is the name of the session cookie, DefaultSessionCookie if empty.
+		CookieName string
+		// MaxAge is the lifetime of the session cookie, zero means the cookie expires when
+		// the browser is closed.
+		MaxAge time.Duration
+		// Encrypt is true if the cookie content is encrypted in addition to being signed.
+		Encrypt bool
+		// Attributes describes the session state fields.
+		Attributes *AttributeDefinition
+		// Parent is the API or resource defining the session.
+		Parent dslengine.Definition
+	}
+
+	// CSRFDefinition describes the cross-site request forgery protection of actions. The
+	// requests made to protected actions with unsafe methods must carry the token issued in
+	// the CSRF cookie in a header or form field.
+	CSRFDefinition struct {
+		// Parent is the API, resource or action being protected.
+		Parent dslengine.Definition
+	}
+)
+
+// Context returns the generic definition name used in error messages.
+func (s *SessionDefinition) Context() string {
+	if s.Parent != nil {
+		return "session of " + s.Parent.Context()
+	}
+	return "session"
+}
+
+// Attribute returns the attribute describing the session state fields so that the Attribute DSL
+// may be used to define them.
+func (s *SessionDefinition) Attribute() *AttributeDefinition {
+	if s.Attributes == nil {
+		s.Attributes = &AttributeDefinition{Type: Object{}}
+	}
+	return s.Attributes
+}
+
+// Name returns the name of the session cookie.
+func (s *SessionDefinition) Name() string {
+	if s.CookieName == "" {
+		return DefaultSessionCookie
+	}
+	return s.CookieName
+}
+
+// Validate checks that the session state is an object and that the max age is not negative.
+func (s *SessionDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if s.MaxAge < 0 {
+		verr.Add(s, "invalid max age %s, cannot be negative", s.MaxAge)
+	}
+	if s.Attributes != nil {
+		if _, ok := s.Attributes.Type.(Object); !ok {
+			verr.Add(s, "session state must be an object")
+		}
+	}
+	return verr.AsError()
+}
+
+// Context returns the generic definition name used in error messages.
+func (c *CSRFDefinition) Context() string {
+	if c.Parent != nil {
+		return "CSRF protection of " + c.Parent.Context()
+	}
+	return "CSRF protection"
+}
+
+// EffectiveSession returns the session used by the action: the resource session if any, the API
+// session otherwise. It returns nil if the action does not use a session.
+func (a *ActionDefinition) EffectiveSession() *SessionDefinition {
+	if a.Parent != nil && a.Parent.Session != nil {
+		return a.Parent.Session
+	}
+	if Design != nil {
+		return Design.Session
+	}
+	return nil
+}
+
+// EffectiveCSRF returns the CSRF protection that applies to the action: the action protection if
+// any, the resource protection otherwise and finally the API protection. It returns nil if the
+// action is not protected.
+func (a *ActionDefinition) EffectiveCSRF() *CSRFDefinition {
+	if a.CSRF != nil {
+		return a.CSRF
+	}
+	if a.Parent != nil && a.Parent.CSRF != nil {
+		return a.Parent.CSRF
+	}
+	if Design != nil {
+		return Design.CSRF
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package design
+
+import (
+	"sort"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// SparseFieldsParam is the name of the query parameter that lists the attributes rendered in the
+// responses of the actions that support sparse fieldsets.
+const SparseFieldsParam = "fields"
+
+// HasSparseFields returns true if the action accepts the fields query parameter, that is if the
+// action or its resource uses the SparseFields DSL.
+func (a *ActionDefinition) HasSparseFields() bool {
+	return a.SparseFields || a.Parent != nil && a.Parent.SparseFields
+}
+
+// SparseFieldNames returns the sorted names of the attributes that may be listed in the fields
+// query parameter of the action: the attributes of the views (other than "link") of the media
+// types of the action responses. The attributes of the views of the element media type are used
+// for collections.
+func (a *ActionDefinition) SparseFieldNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, r := range a.Responses {
+		if r.Type != nil || Design == nil {
+			continue
+		}
+		mt := Design.MediaTypeWithIdentifier(r.MediaType)
+		if mt == nil {
+			continue
+		}
+		if mt.IsArray() {
+			elem, ok := mt.ToArray().ElemType.Type.(*MediaTypeDefinition)
+			if !ok {
+				continue
+			}
+			mt = elem
+		}
+		for vn, v := range mt.Views {
+			if vn == "link" || v.AttributeDefinition == nil {
+				continue
+			}
+			for n := range v.Type.ToObject() {
+				if !seen[n] {
+					seen[n] = true
+					names = append(names, n)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateSparseFields checks that the action renders media types and does not already define a
+// fields parameter.
+func (a *ActionDefinition) validateSparseFields() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if len(a.SparseFieldNames()) == 0 {
+		verr.Add(a, "sparse fieldsets require a response with a media type")
+	}
+	if a.Params != nil {
+		if _, ok := a.Params.Type.ToObject()[SparseFieldsParam]; ok {
+			verr.Add(a, "sparse fieldsets conflict with parameter %#v", SparseFieldsParam)
+		}
+	}
+	return verr
+}
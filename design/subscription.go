@@ -0,0 +1,70 @@
+package design
+
+import (
+	"path"
+
+	"github.com/goadesign/goa/dslengine"
+	"github.com/julienschmidt/httprouter"
+)
+
+// DefaultSubscriptionsPath is the path of the subscription endpoints relative to the resource
+// base path.
+const DefaultSubscriptionsPath = "/subscriptions"
+
+// SubscriptionsDefinition describes the events of a resource that clients may subscribe to.
+// Clients create subscriptions that either register a callback URL the events are delivered to or
+// open a WebSocket connection the events are sent on.
+type SubscriptionsDefinition struct {
+	// Events lists the names of the subscribable events, see EventDefinition.
+	Events []string
+	// Path is the path of the subscription endpoints relative to the resource base path.
+	Path string
+	// Parent is the subscribable resource.
+	Parent *ResourceDefinition
+}
+
+// Context returns the generic definition name used in error messages.
+func (s *SubscriptionsDefinition) Context() string {
+	if s.Parent != nil {
+		return "subscriptions of " + s.Parent.Context()
+	}
+	return "subscriptions"
+}
+
+// FullPath returns the path of the subscription endpoints including the API and resource base
+// paths.
+func (s *SubscriptionsDefinition) FullPath() string {
+	var basePath string
+	if s.Parent != nil && Design != nil {
+		basePath = s.Parent.FullPath(Design.APIVersionDefinition)
+	}
+	return httprouter.CleanPath(path.Join(basePath, s.Path))
+}
+
+// Validate checks that the subscribable events are defined in the design and that the subscription
+// endpoints path does not contain wildcards.
+func (s *SubscriptionsDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if len(s.Events) == 0 {
+		verr.Add(s, "no subscribable event")
+	}
+	seen := make(map[string]bool, len(s.Events))
+	for _, e := range s.Events {
+		if seen[e] {
+			verr.Add(s, "event %#v is listed twice", e)
+		}
+		seen[e] = true
+		if Design == nil || Design.Events[e] == nil {
+			verr.Add(s, "event %#v is not defined in the design", e)
+		}
+	}
+	if wcs := ExtractWildcards(s.FullPath()); len(wcs) > 0 {
+		verr.Add(s, "path %#v of subscription endpoints cannot have parameters %v", s.FullPath(), wcs)
+	}
+	return verr.AsError()
+}
+
+// IsSubscribable returns true if clients may subscribe to the resource events.
+func (r *ResourceDefinition) IsSubscribable() bool {
+	return r.Subscriptions != nil
+}
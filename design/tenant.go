@@ -0,0 +1,69 @@
+package design
+
+import "github.com/goadesign/goa/dslengine"
+
+const (
+	// SubdomainTenant identifies the tenant with the first label of the request host, e.g.
+	// "acme" in "acme.api.example.com".
+	SubdomainTenant = "subdomain"
+	// HeaderTenant identifies the tenant with the value of a request header.
+	HeaderTenant = "header"
+	// ParamTenant identifies the tenant with the value of an API base path parameter.
+	ParamTenant = "param"
+)
+
+// TenantDefinition describes how the tenant each request is made on behalf of is identified.
+type TenantDefinition struct {
+	// Source is SubdomainTenant, HeaderTenant or ParamTenant.
+	Source string
+	// Name is the name of the header for HeaderTenant, the name of the API base path
+	// parameter for ParamTenant and the parent domain of the tenant subdomains for
+	// SubdomainTenant. The parent domain is optional, the first label of the request host is
+	// used when it is empty.
+	Name string
+	// Parent is the API the tenant applies to.
+	Parent *APIDefinition
+}
+
+// Context returns the generic definition name used in error messages.
+func (t *TenantDefinition) Context() string {
+	if t.Parent != nil {
+		return "tenant of " + t.Parent.Context()
+	}
+	return "tenant"
+}
+
+// Validate checks that the source is valid and that the parameter of ParamTenant tenants is an
+// API base path parameter.
+func (t *TenantDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	switch t.Source {
+	case SubdomainTenant:
+	case HeaderTenant:
+		if t.Name == "" {
+			verr.Add(t, "HeaderTenant tenant requires the name of a header")
+		}
+	case ParamTenant:
+		found := false
+		if t.Parent != nil {
+			for _, w := range ExtractWildcards(t.Parent.BasePath) {
+				found = found || w == t.Name
+			}
+		}
+		if !found {
+			verr.Add(t, "tenant parameter %#v is not a parameter of the API base path", t.Name)
+		}
+	default:
+		verr.Add(t, "invalid tenant source %#v, must be SubdomainTenant, HeaderTenant or ParamTenant", t.Source)
+	}
+	return verr.AsError()
+}
+
+// EffectiveTenant returns the tenant definition that applies to the action, nil if the API does
+// not define tenants or if the action or its resource opted out with NoTenant.
+func (a *ActionDefinition) EffectiveTenant() *TenantDefinition {
+	if a.NoTenant || a.Parent != nil && a.Parent.NoTenant || Design == nil {
+		return nil
+	}
+	return Design.Tenant
+}
@@ -0,0 +1,42 @@
+package design
+
+import (
+	"time"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// TimeoutKey is the action metadata key used to override the budget of the goa Timeout middleware
+// for the action. The value is a duration as accepted by time.ParseDuration, e.g. "30s". "0"
+// disables the timeout for the action.
+const TimeoutKey = "goa:timeout"
+
+// Timeout returns the budget set with the "goa:timeout" metadata if any.
+func (a *ActionDefinition) Timeout() (time.Duration, bool) {
+	v := metadataValue(a.Metadata, TimeoutKey)
+	if v == "" {
+		return 0, false
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// validateTimeout checks that the value of the "goa:timeout" metadata, if any, is a valid
+// duration.
+func (a *ActionDefinition) validateTimeout() *dslengine.ValidationErrors {
+	if _, ok := a.Metadata[TimeoutKey]; !ok {
+		return nil
+	}
+	verr := new(dslengine.ValidationErrors)
+	v := metadataValue(a.Metadata, TimeoutKey)
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		verr.Add(a, "invalid %s metadata value %#v: %s", TimeoutKey, v, err)
+	} else if d < 0 {
+		verr.Add(a, "invalid %s metadata value %#v: timeout cannot be negative", TimeoutKey, v)
+	}
+	return verr.AsError()
+}
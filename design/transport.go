@@ -0,0 +1,85 @@
+package design
+
+import "github.com/goadesign/goa/dslengine"
+
+// Metadata keys used to control how resources and actions are exposed via the gRPC transport.
+const (
+	// GRPCServiceKey is the resource metadata key used to override the name of the gRPC
+	// service generated for the resource.
+	GRPCServiceKey = "grpc:service"
+
+	// GRPCMethodKey is the action metadata key used to override the name of the rpc generated
+	// for the action.
+	GRPCMethodKey = "grpc:method"
+
+	// GRPCSkipKey is the resource or action metadata key used to exclude the resource or
+	// action from the gRPC transport. The key takes no value.
+	GRPCSkipKey = "grpc:skip"
+
+	// GRPCFieldKey is the parameter attribute metadata key used to set the path of the gRPC
+	// request message field holding the parameter value, e.g. "bottle.id".
+	GRPCFieldKey = "grpc:field"
+
+	// GRPCBodyKey is the action metadata key used to set the path of the gRPC request message
+	// field holding the action payload. The value "*" means that the payload is made of all the
+	// message fields that do not hold parameters.
+	GRPCBodyKey = "grpc:body"
+)
+
+// GRPCServiceName returns the name of the gRPC service exposing the resource actions, that is
+// the value of the "grpc:service" metadata if set, the empty string otherwise.
+func (r *ResourceDefinition) GRPCServiceName() string {
+	return metadataValue(r.Metadata, GRPCServiceKey)
+}
+
+// GRPCExposed returns true if the resource actions are exposed via gRPC.
+func (r *ResourceDefinition) GRPCExposed() bool {
+	_, ok := r.Metadata[GRPCSkipKey]
+	return !ok
+}
+
+// GRPCMethodName returns the name of the rpc exposing the action, that is the value of the
+// "grpc:method" metadata if set, the empty string otherwise.
+func (a *ActionDefinition) GRPCMethodName() string {
+	return metadataValue(a.Metadata, GRPCMethodKey)
+}
+
+// GRPCExposed returns true if the action is exposed via gRPC. Actions of resources that are not
+// exposed are not exposed either.
+func (a *ActionDefinition) GRPCExposed() bool {
+	if _, ok := a.Metadata[GRPCSkipKey]; ok {
+		return false
+	}
+	return a.Parent == nil || a.Parent.GRPCExposed()
+}
+
+// GRPCFields returns the paths of the gRPC request message fields holding the values of the
+// action parameters indexed by parameter name as set with the "grpc:field" metadata. Parameters
+// without metadata are omitted.
+func (a *ActionDefinition) GRPCFields() map[string]string {
+	fields := make(map[string]string)
+	params := a.AllParams()
+	if params == nil || !params.Type.IsObject() {
+		return fields
+	}
+	for n, att := range params.Type.ToObject() {
+		if path := metadataValue(att.Metadata, GRPCFieldKey); path != "" {
+			fields[n] = path
+		}
+	}
+	return fields
+}
+
+// GRPCBody returns the path of the gRPC request message field holding the action payload, that
+// is the value of the "grpc:body" metadata if set, the empty string otherwise.
+func (a *ActionDefinition) GRPCBody() string {
+	return metadataValue(a.Metadata, GRPCBodyKey)
+}
+
+// metadataValue returns the first value of the metadata with the given key if any.
+func metadataValue(md dslengine.MetadataDefinition, key string) string {
+	if vals, ok := md[key]; ok && len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
@@ -88,6 +88,8 @@ type (
 		*AttributeDefinition
 		// Name of type
 		TypeName string
+		// Location of the type DSL, used in validation error messages
+		dslengine.DSLLocation
 	}
 
 	// MediaTypeDefinition describes the rendering of a resource using property and link
@@ -107,6 +109,11 @@ type (
 		Views map[string]*ViewDefinition
 		// Resource this media type is the canonical representation for if any
 		Resource *ResourceDefinition
+		// MQTTPublication describes the MQTT topic instances of the media type are published
+		// on if any.
+		MQTTPublication *MQTTTopicDefinition
+		// ETag describes the ETags of the responses rendering the media type if any.
+		ETag *ETagDefinition
 	}
 )
 
@@ -676,6 +683,7 @@ func (m *MediaTypeDefinition) projectSingle(view string) (p *MediaTypeDefinition
 				Validation:  val,
 			},
 		},
+		ETag: m.ETag,
 	}
 	GeneratedMediaTypes[typeName] = p
 	projectedObj := p.Type.ToObject()
@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -71,6 +72,7 @@ func (a *APIDefinition) Validate() error {
 	a.validateContact(verr)
 	a.validateLicense(verr)
 	a.validateDocs(verr)
+	verr.Merge(validateMaxSize(a, a.Metadata))
 
 	a.IterateVersions(func(ver *APIVersionDefinition) error {
 		var allRoutes []*routeInfo
@@ -146,6 +148,47 @@ func (a *APIDefinition) Validate() error {
 		}
 		return nil
 	})
+	a.IterateEvents(func(e *EventDefinition) error {
+		verr.Merge(e.Validate())
+		return nil
+	})
+	a.IterateSecuritySchemes(func(s *SecuritySchemeDefinition) error {
+		verr.Merge(s.Validate())
+		if s.Kind == MutualTLSSecurityKind && !a.ServesTLS() {
+			verr.Add(s, "mutual TLS security scheme requires the API to be served over TLS, use the https, wss or h3 scheme")
+		}
+		return nil
+	})
+	if a.Security != nil {
+		verr.Merge(a.Security.Validate())
+	}
+	if a.Replay != nil {
+		verr.Merge(a.Replay.Validate())
+	}
+	if a.Quota != nil {
+		verr.Merge(a.Quota.Validate())
+	}
+	if a.RateLimit != nil {
+		verr.Merge(a.RateLimit.Validate())
+	}
+	if a.Tenant != nil {
+		verr.Merge(a.Tenant.Validate())
+	}
+	if a.NetworkACL != nil {
+		verr.Merge(a.NetworkACL.Validate())
+	}
+	if a.Session != nil {
+		verr.Merge(a.Session.Validate())
+	}
+	if a.Batch != nil {
+		verr.Merge(a.Batch.Validate())
+	}
+	if a.Metrics != nil {
+		verr.Merge(a.Metrics.Validate())
+	}
+	if a.Operations != nil {
+		verr.Merge(a.Operations.Validate())
+	}
 
 	err := verr.AsError()
 	if err == nil {
@@ -199,6 +242,28 @@ func (r *ResourceDefinition) Validate(version *APIVersionDefinition) *dslengine.
 	if r.Params != nil {
 		verr.Merge(r.Params.Validate("resource parameters", r))
 	}
+	verr.Merge(validateMaxSize(r, r.Metadata))
+	if r.Subscriptions != nil {
+		verr.Merge(r.Subscriptions.Validate())
+	}
+	if r.Security != nil {
+		verr.Merge(r.Security.Validate())
+	}
+	if r.Replay != nil {
+		verr.Merge(r.Replay.Validate())
+	}
+	if r.Quota != nil {
+		verr.Merge(r.Quota.Validate())
+	}
+	if r.RateLimit != nil {
+		verr.Merge(r.RateLimit.Validate())
+	}
+	if r.NetworkACL != nil {
+		verr.Merge(r.NetworkACL.Validate())
+	}
+	if r.Session != nil {
+		verr.Merge(r.Session.Validate())
+	}
 	if !r.SupportsNoVersion() {
 		if err := dslengine.CanUse(r, Design); err != nil {
 			verr.Add(r, "Invalid API version in list")
@@ -278,6 +343,12 @@ func (enc *EncodingDefinition) Validate() *dslengine.ValidationErrors {
 			verr.Add(enc, "invalid MIME type %#v: %s", m, err)
 		}
 	}
+	if enc.IsPassthrough() {
+		if enc.PackagePath != "" {
+			verr.Add(enc, "passthrough encoding %#v cannot specify a Go package", WildcardMediaType)
+		}
+		return verr
+	}
 	if len(enc.PackagePath) > 0 {
 		found := false
 		rel := filepath.FromSlash(enc.PackagePath)
@@ -327,12 +398,89 @@ func (a *ActionDefinition) Validate(version *APIVersionDefinition) *dslengine.Va
 	if a.Payload != nil {
 		verr.Merge(a.Payload.Validate("action payload", a))
 	}
+	if a.Result != nil {
+		verr.Merge(a.validateResult())
+	}
+	if a.HasSparseFields() {
+		verr.Merge(a.validateSparseFields())
+	}
+	if a.Audit != nil {
+		verr.Merge(a.validateAudit())
+	}
+	if a.Replay != nil {
+		verr.Merge(a.Replay.Validate())
+	}
+	if a.Quota != nil {
+		verr.Merge(a.Quota.Validate())
+	}
+	if a.RateLimit != nil {
+		verr.Merge(a.RateLimit.Validate())
+	}
+	if a.NetworkACL != nil {
+		verr.Merge(a.NetworkACL.Validate())
+	}
+	if a.ETag != nil {
+		verr.Merge(a.ETag.Validate())
+	}
+	if a.WebSocket != nil {
+		verr.Merge(a.WebSocket.Validate())
+	}
+	if a.MQTTSubscription != nil {
+		verr.Merge(a.MQTTSubscription.Validate())
+	}
+	if a.MQTTPublication != nil {
+		verr.Merge(a.MQTTPublication.Validate())
+	}
+	if a.Proxy != nil {
+		verr.Merge(a.Proxy.Validate())
+	}
+	if a.LongPoll != nil {
+		verr.Merge(a.LongPoll.Validate())
+	}
+	for _, c := range a.Callbacks {
+		verr.Merge(c.Validate())
+	}
+	if a.Webhook != nil {
+		verr.Merge(a.Webhook.Validate())
+	}
+	if a.Async != nil {
+		verr.Merge(a.Async.Validate())
+	}
+	verr.Merge(a.validateTimeout())
+	verr.Merge(validateMaxSize(a, a.Metadata))
+	if a.Payload != nil {
+		verr.Merge(validateMaxSize(a, a.Payload.Metadata))
+	}
+	if a.Security != nil {
+		verr.Merge(a.Security.Validate())
+	}
 	if a.Parent == nil {
 		verr.Add(a, "missing parent resource")
 	}
 	return verr.AsError()
 }
 
+// validateResult checks that the action result type can be projected onto the media types of the
+// action responses.
+func (a *ActionDefinition) validateResult() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if !a.Result.IsObject() && !a.Result.IsArray() {
+		verr.Add(a, "result type %s must be an object or an array", a.Result.TypeName)
+		return verr
+	}
+	for _, r := range a.Responses {
+		mt := Design.MediaTypeWithIdentifier(r.MediaType)
+		if mt == nil || r.Type != nil {
+			continue
+		}
+		if mt.IsObject() != a.Result.IsObject() || mt.IsArray() != a.Result.IsArray() {
+			verr.Add(a, "result type %s cannot be projected onto media type %s of response %s",
+				a.Result.TypeName, mt.Identifier, r.Name)
+		}
+	}
+	return verr
+}
+
 // ValidateParams checks the action parameters (make sure they have names, members and types).
 func (a *ActionDefinition) ValidateParams(version *APIVersionDefinition) *dslengine.ValidationErrors {
 	verr := new(dslengine.ValidationErrors)
@@ -370,6 +518,24 @@ func (a *ActionDefinition) ValidateParams(version *APIVersionDefinition) *dsleng
 		if p.Type.Kind() == ObjectKind {
 			verr.Add(a, `parameter %s cannot be an object, only action payloads may be of type object`, n)
 		}
+		switch actual := p.Type.(type) {
+		case *Array:
+			if !actual.ElemType.Type.IsPrimitive() {
+				verr.Add(a, "elements of array parameter %s must be of a primitive type", n)
+			}
+		case *Hash:
+			if actual.KeyType.Type.Kind() != StringKind {
+				verr.Add(a, "keys of hash parameter %s must be strings", n)
+			}
+			if k := actual.ElemType.Type.Kind(); k != StringKind && k != IntegerKind {
+				verr.Add(a, "values of hash parameter %s must be strings or integers", n)
+			}
+			for _, wc := range wcs {
+				if wc == n {
+					verr.Add(a, "path parameter %s cannot be a hash", n)
+				}
+			}
+		}
 		ctx := fmt.Sprintf("parameter %s", n)
 		verr.Merge(p.Validate(ctx, a))
 	}
@@ -382,7 +548,11 @@ func (a *ActionDefinition) ValidateParams(version *APIVersionDefinition) *dsleng
 // validated keeps track of validated attributes to handle cyclical definitions.
 var validated = make(map[*AttributeDefinition]bool)
 
-// Validate tests whether the attribute definition is consistent: required fields exist.
+// validatorRegex matches the fully qualified names of exported functions.
+var validatorRegex = regexp.MustCompile(`^[^\s]+\.[A-Z][A-Za-z0-9_]*$`)
+
+// Validate tests whether the attribute definition is consistent: required fields exist and the
+// custom validation functions names are fully qualified.
 // Since attributes are unaware of their context, additional context information can be provided
 // to be used in error messages.
 // The parent definition context is automatically added to error messages.
@@ -399,6 +569,13 @@ func (a *AttributeDefinition) Validate(ctx string, parent dslengine.Definition)
 	if ctx != "" {
 		ctx += " - "
 	}
+	if a.Validation != nil {
+		for _, fn := range a.Validation.Validators {
+			if !validatorRegex.MatchString(fn) {
+				verr.Add(parent, `%sinvalid validation function name %#v, must be of the form "import/path.Func"`, ctx, fn)
+			}
+		}
+	}
 	o := a.Type.ToObject()
 	if o != nil {
 		for _, n := range a.AllRequired() {
@@ -411,8 +588,12 @@ func (a *AttributeDefinition) Validate(ctx string, parent dslengine.Definition)
 			}
 			if !found {
 				verr.Add(parent, `%srequired field "%s" does not exist`, ctx, n)
+			} else if o[n].DefaultValue != nil {
+				dslengine.ReportDefinitionWarning(parent, `%sdefault value of required field "%s" is never used`, ctx, n)
 			}
 		}
+		verr.Merge(a.validateVisibility(ctx, parent))
+		verr.Merge(a.validateSecrets(ctx, parent))
 		for n, att := range o {
 			ctx = fmt.Sprintf("field %s", n)
 			verr.Merge(att.Validate(ctx, a))
@@ -427,8 +608,8 @@ func (a *AttributeDefinition) Validate(ctx string, parent dslengine.Definition)
 	return verr.AsError()
 }
 
-// Validate checks that the response definition is consistent: its status is set and the media
-// type definition if any is valid.
+// Validate checks that the response definition is consistent: its status is set and within the
+// status range if any, and its alternate media types are defined in the design.
 func (r *ResponseDefinition) Validate() *dslengine.ValidationErrors {
 	verr := new(dslengine.ValidationErrors)
 	if r.Headers != nil {
@@ -437,9 +618,82 @@ func (r *ResponseDefinition) Validate() *dslengine.ValidationErrors {
 	if r.Status == 0 {
 		verr.Add(r, "response status not defined")
 	}
+	if r.StatusRange != 0 {
+		if r.StatusRange < 1 || r.StatusRange > 5 {
+			verr.Add(r, "invalid status range %s, must be one of 1xx, 2xx, 3xx, 4xx or 5xx", r.StatusRangeName())
+		} else if r.Status != 0 && r.Status/100 != r.StatusRange {
+			verr.Add(r, "status %d is not in the response status range %s", r.Status, r.StatusRangeName())
+		}
+		if r.Streaming != nil {
+			verr.Add(r, "streaming responses cannot define a status range")
+		}
+	}
+	for _, m := range r.AlternateMediaTypes {
+		if r.MediaType == WildcardMediaType || m == WildcardMediaType {
+			verr.Add(r, "response with media type %#v sends a raw body and cannot define alternate media types", WildcardMediaType)
+			break
+		}
+		if CanonicalIdentifier(m) == CanonicalIdentifier(r.MediaType) {
+			verr.Add(r, "alternate media type %#v is the response media type", m)
+		} else if Design != nil && Design.MediaTypeWithIdentifier(m) == nil {
+			verr.Add(r, "alternate media type %#v is not defined in the design", m)
+		}
+	}
+	if r.RetryAfter != nil {
+		verr.Merge(r.RetryAfter.Validate(r))
+	}
+	if r.CacheControl != nil {
+		verr.Merge(r.CacheControl.Validate())
+	}
+	if r.Streaming != nil {
+		verr.Merge(r.Streaming.Validate(r))
+	}
+	if r.MediaType == WildcardMediaType && r.Type != nil {
+		verr.Add(r, "response with media type %#v sends a raw body and cannot define a type", WildcardMediaType)
+	}
 	return verr.AsError()
 }
 
+// Validate checks that the Retry-After definition uses a known strategy with consistent delays.
+func (r *RetryAfterDefinition) Validate(parent dslengine.Definition) *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	switch r.Strategy {
+	case RetryAfterFixed, RetryAfterExponential:
+		if r.Delay <= 0 {
+			verr.Add(parent, "Retry-After %s strategy requires a positive delay", r.Strategy)
+		}
+		if r.Max != 0 && r.Max < r.Delay {
+			verr.Add(parent, "Retry-After maximum delay %d is lower than delay %d", r.Max, r.Delay)
+		}
+	case RetryAfterContext:
+	default:
+		verr.Add(parent, "unknown Retry-After strategy %#v, must be one of %#v, %#v or %#v",
+			r.Strategy, RetryAfterFixed, RetryAfterExponential, RetryAfterContext)
+	}
+	return verr
+}
+
+// Validate checks that the streaming definition uses a known format, that its heartbeat is not
+// negative and that the parent response defines the type of the streamed values.
+func (s *StreamingDefinition) Validate(parent *ResponseDefinition) *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	switch s.Format {
+	case StreamingSSE, StreamingJSON:
+	default:
+		verr.Add(parent, "unknown streaming format %#v, must be one of %#v or %#v",
+			s.Format, StreamingSSE, StreamingJSON)
+	}
+	if s.Heartbeat < 0 {
+		verr.Add(parent, "streaming heartbeat cannot be negative")
+	}
+	if parent.MediaType == WildcardMediaType {
+		verr.Add(parent, "response with media type %#v sends a raw body and cannot be streamed", WildcardMediaType)
+	} else if parent.Type == nil && parent.MediaType == "" {
+		verr.Add(parent, "streaming response must define the type of the streamed values")
+	}
+	return verr
+}
+
 // Validate checks that the route definition is consistent: it has a parent.
 func (r *RouteDefinition) Validate() *dslengine.ValidationErrors {
 	verr := new(dslengine.ValidationErrors)
@@ -534,6 +788,12 @@ func (m *MediaTypeDefinition) Validate() *dslengine.ValidationErrors {
 	for _, l := range m.Links {
 		verr.Merge(l.Validate())
 	}
+	if m.MQTTPublication != nil {
+		verr.Merge(m.MQTTPublication.Validate())
+	}
+	if m.ETag != nil {
+		verr.Merge(m.ETag.Validate())
+	}
 	return verr.AsError()
 }
 
@@ -48,6 +48,23 @@ var _ = Describe("Validation", func() {
 			})
 		})
 
+		Context("with a required attribute that has a default value", func() {
+			BeforeEach(func() {
+				dsl = func() {
+					Attribute(attName, String, func() {
+						Default("red")
+					})
+					Required(attName)
+				}
+			})
+
+			It("produces a warning", func() {
+				Ω(dslengine.Errors).ShouldNot(HaveOccurred())
+				Ω(dslengine.Warnings).Should(HaveLen(1))
+				Ω(dslengine.Warnings.Error()).Should(ContainSubstring(`default value of required field "attName" is never used`))
+			})
+		})
+
 		Context("with an incompatible enum validation type", func() {
 			BeforeEach(func() {
 				dsl = func() {
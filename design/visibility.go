@@ -0,0 +1,106 @@
+package design
+
+import (
+	"sort"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// VisibleToKey is the attribute metadata key listing the roles allowed to see the attribute in
+// the responses, see the VisibleTo DSL. Attributes without the metadata are visible to all.
+const VisibleToKey = "goa:visibleTo"
+
+// VisibleTo returns the roles allowed to see the attribute in the responses, nil if the attribute
+// is visible to all.
+func (a *AttributeDefinition) VisibleTo() []string {
+	return a.Metadata[VisibleToKey]
+}
+
+// HasRestrictedFields returns true if the attribute is an object, a user type, a media type or an
+// array of these that has fields restricted with VisibleTo, directly or in the objects and arrays
+// of objects it contains. The generated code renders the values of such attributes according to
+// the roles of the request principal. The values of hash attributes are not restricted.
+func (a *AttributeDefinition) HasRestrictedFields() bool {
+	return hasRestrictedFields(a.Type, make(map[interface{}]bool))
+}
+
+// VisibilityRoles returns the sorted list of the roles listed by the VisibleTo annotations of the
+// fields of the attribute and of the user types and media types they refer to.
+func (a *AttributeDefinition) VisibilityRoles() []string {
+	set := make(map[string]bool)
+	collectVisibilityRoles(a.Type, set, make(map[interface{}]bool))
+	roles := make([]string, 0, len(set))
+	for r := range set {
+		roles = append(roles, r)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// validateVisibility checks that the restricted fields of the object attribute a are neither
+// required nor non-zero as the generated code must be able to omit them.
+func (a *AttributeDefinition) validateVisibility(ctx string, parent dslengine.Definition) *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	for n, att := range a.Type.ToObject() {
+		if len(att.VisibleTo()) == 0 {
+			continue
+		}
+		if a.IsRequired(n) || a.IsNonZero(n) {
+			verr.Add(parent, `%sfield "%s" is restricted with VisibleTo and thus cannot be required`, ctx, n)
+		}
+	}
+	return verr.AsError()
+}
+
+// hasRestrictedFields implements HasRestrictedFields, seen records the user types already
+// visited to support recursive types.
+func hasRestrictedFields(dt DataType, seen map[interface{}]bool) bool {
+	switch t := dt.(type) {
+	case *MediaTypeDefinition:
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+		return hasRestrictedFields(t.Type, seen)
+	case *UserTypeDefinition:
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+		return hasRestrictedFields(t.Type, seen)
+	case *Array:
+		return hasRestrictedFields(t.ElemType.Type, seen)
+	case Object:
+		for _, att := range t {
+			if len(att.VisibleTo()) > 0 || hasRestrictedFields(att.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collectVisibilityRoles implements VisibilityRoles.
+func collectVisibilityRoles(dt DataType, roles map[string]bool, seen map[interface{}]bool) {
+	switch t := dt.(type) {
+	case *MediaTypeDefinition:
+		if !seen[t] {
+			seen[t] = true
+			collectVisibilityRoles(t.Type, roles, seen)
+		}
+	case *UserTypeDefinition:
+		if !seen[t] {
+			seen[t] = true
+			collectVisibilityRoles(t.Type, roles, seen)
+		}
+	case *Array:
+		collectVisibilityRoles(t.ElemType.Type, roles, seen)
+	case Object:
+		for _, att := range t {
+			for _, r := range att.VisibleTo() {
+				roles[r] = true
+			}
+			collectVisibilityRoles(att.Type, roles, seen)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package design
+
+import (
+	"fmt"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// WebSocketDefinition describes the WebSocket endpoint of an action. Requests made to the action
+// routes are upgraded to WebSocket connections that exchange messages of the given types.
+type WebSocketDefinition struct {
+	// Subprotocols lists the WebSocket subprotocols supported by the endpoint if any.
+	Subprotocols []string
+	// Receives is the type of the messages sent by clients if any.
+	Receives DataType
+	// Sends is the type of the messages sent to clients if any.
+	Sends DataType
+	// Parent is the action exposing the endpoint.
+	Parent *ActionDefinition
+}
+
+// Context returns the generic definition name used in error messages.
+func (w *WebSocketDefinition) Context() string {
+	if w.Parent != nil {
+		return fmt.Sprintf("WebSocket of %s", w.Parent.Context())
+	}
+	return "WebSocket"
+}
+
+// Validate checks that the WebSocket endpoint definition is consistent: the action routes all
+// use the GET method and the action does not define a payload.
+func (w *WebSocketDefinition) Validate() *dslengine.ValidationErrors {
+	verr := new(dslengine.ValidationErrors)
+	if w.Parent == nil {
+		verr.Add(w, "missing parent action")
+		return verr
+	}
+	for _, r := range w.Parent.Routes {
+		if r.Verb != "GET" {
+			verr.Add(w, "WebSocket routes must use the GET method, route %s %s does not", r.Verb, r.Path)
+		}
+	}
+	if w.Parent.Payload != nil {
+		verr.Add(w, "WebSocket actions cannot define a payload, use Receives to define the type of the messages sent by clients")
+	}
+	return verr.AsError()
+}
+
+// IsWebSocket returns true if the action routes upgrade requests to WebSocket connections.
+func (a *ActionDefinition) IsWebSocket() bool {
+	return a.WebSocket != nil
+}
+
+// IsWebSocket returns true if the route upgrades requests to WebSocket connections.
+func (r *RouteDefinition) IsWebSocket() bool {
+	return r.Parent != nil && r.Parent.IsWebSocket()
+}
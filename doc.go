@@ -26,7 +26,15 @@ that correspond to the request parameters and body data structure descriptions a
 
 The response state exposes  the response status and body length as well as the underlying ResponseWriter.
 Action contexts provide action specific helper methods that write the responses as described in the
-design optionally taking an instance of the media type for responses that contain a body.
+design optionally taking an instance of the media type for responses that contain a body. The
+helpers of the actions and media types that define an ETag set the ETag header and handle conditional
+requests, replying with 304 Not Modified or 412 Precondition Failed when the If-None-Match or If-Match
+preconditions fail, see ResponseData.SendETag and CheckPreconditions. The helpers of the responses that
+define a caching policy with the CacheControl DSL set the Cache-Control and Vary headers. The contexts
+of the actions that use the SparseFields DSL parse the fields query parameter (e.g. "?fields=id,name")
+into their Fields field and the helpers only render the listed attributes, see SparseFields. The
+helpers of the APIs that use the JSONAPI DSL render JSON:API documents, see RenderJSONAPI and
+JSONAPIDecoderFactory.
 
 Here is an example showing an "update" action corresponding to following design (extract):
 
@@ -96,7 +104,65 @@ creates the action specific context and calls the controller action with it.
 
 Middleware can be added to a goa service or a specific controller using the Service type Use method.
 goa comes with a few stock middleware that handle common needs such as logging, panic recovery or
-using the RequestID header to trace requests across multiple services.
+using the RequestID header to trace requests across multiple services. The RequestID middleware
+reads or generates the X-Request-Id header, echoes it in the response and adds it to the log
+context so that all the log entries written for the request include it. The Compress middleware
+compresses the response bodies with gzip or deflate for the clients that accept them. The Timeout
+middleware cancels the requests that exceed a time budget, the "goa:timeout" action metadata
+overrides the budget for specific actions. The TokenBucket middleware rate limits requests per
+client IP or custom key and keeps its buckets in memory or in Redis, the SlidingWindow middleware
+does the same with sliding windows and the RateLimit middleware shares a single bucket between all
+the requests. The handlers of the actions that use the RateLimit DSL are wrapped with
+EnforceRateLimit which applies the limit to each principal and computes the Retry-After header with
+the strategy of the action 429 response if any. The handlers of the actions that use
+the Quota DSL are wrapped with EnforceQuota which tracks the daily and monthly request and byte
+budgets of each principal in the service QuotaStore, MountQuotaStatus serves the usage. The handlers of
+the actions of multi-tenant APIs (Tenant DSL) are wrapped with ResolveTenant which reads the tenant
+from the request subdomain, header or base path parameter, stores it in the context (see
+ContextTenant) and invokes the hook set with SetTenantHook. The TrustProxies middleware resolves
+the client address of requests forwarded by trusted proxies from the Forwarded or X-Forwarded-For
+headers (see ContextClientAddr), the handlers of the actions that use the NetworkACL DSL are wrapped
+with EnforceNetworkACL which rejects the clients outside the allowed networks. SetMaintenance
+disables the whole service or individual actions whose requests then get 503 responses with a
+Retry-After header, the state is controlled by the endpoint mounted with MountMaintenance or the
+file given to ServerConfig.MaintenanceFile and is reported by the endpoint mounted with
+MountHealth. The handlers of the actions tagged with the AbuseSensitive DSL are wrapped with
+DetectAbuse which computes the fingerprint of the recent requests of each principal (rate spike,
+error ratio and parameter anomalies) and feeds it to the detectors set with SetAbuseDetectors, see
+ThresholdDetector. The Instrument middleware
+records Prometheus request count, latency and in-flight metrics labeled by resource, action and
+status, MountMetrics serves them. The Tracer middleware creates a span per request that joins
+the Zipkin B3 trace of the caller, the goa client propagates it to the requests made while
+handling the request.
+
+Security
+
+The design language defines security schemes (JWT, OpenID Connect, API key, basic auth and
+OAuth2) and applies them to the API, resources or actions. The generated code wraps the handlers
+of the secured actions with Secure which delegates to the middleware registered for the scheme
+with SetSecurityMiddleware. The security package implements middleware for each kind of scheme.
+API key schemes that use the KeyManagement DSL have their keys issued, rotated and revoked by the
+service with the manager provided by the apikey package.
+The handlers of the actions marked with the Audit DSL are wrapped with Audit which records an
+audit event for each request with the Auditor set on the service, the audit package provides
+file, syslog, HTTP and Kafka sinks.
+The handlers of the actions that use the ReplayProtection DSL are wrapped with ReplayProtect which
+rejects the requests whose timestamp is stale or whose nonce was already recorded in the service
+ReplayCache, the generated clients set the timestamp and nonce headers.
+The contexts of the actions that use a Session expose typed accessors backed by a SessionCookie
+signed and optionally encrypted with the keys set with SetSessionConfig. The handlers of the
+actions that use the CSRF DSL are wrapped with CSRF which checks the token issued in a cookie.
+The values of the parameters, headers and payload attributes tagged with the PII DSL are redacted
+from the logs of the service, see SetLogRedaction. The "gdpr" goagen command reports which actions
+accept or expose PII and generates the data subject export and erasure endpoints.
+The "sectest" goagen command generates a tool that probes a running service for missing
+authentication and authorization, oversized payloads, content-type confusion and injections.
+The generated code encrypts the attributes marked with the Encrypted DSL received in the request
+payloads and decrypts them in the responses with the Crypter set on the service, see SetCrypter
+and AESCrypter. The values of the encrypted and Sensitive attributes are kept out of the logs,
+audit events and validation errors.
+The controllers reject the requests whose body is larger than the size set with the MaxSize DSL or
+with SetMaxRequestSize with a 413 Request Entity Too Large response before decoding them.
 
 Validation
 
@@ -105,13 +171,20 @@ data structure definitions. One specific type of validation consists of defining
 data structure string field must follow. Example of formats include email, data time, hostnames etc.
 The ValidateFormat function provides the implementation for the format validation invoked from the
 code generated by goagen.
+The generated code reads array query parameters from comma separated values (?tag=a,b) or repeated
+keys (?tag=a&tag=b) whose values are not split, and hash query parameters from bracketed keys
+(?filter[status]=open), see ParamValues and ParamMap. Each element is coerced to the parameter element type and validated.
+The boolean, integer, number and date time request headers are parsed into typed context fields,
+the values that cannot be parsed produce errors created with InvalidHeaderTypeError.
 
 Encoding
 
 The goa design language makes it possible to specify the encodings supported by the API both as
 input (Consumes) and output (Produces). goagen uses that information to registed the corresponding
 packages with the service encoders and decoders via the SetEncoder and SetDecoder methods. The
-service exposes the Decode, DecodeRequest, Encode and EncodeResponse that implement a simple content
-type negotiation algorithm for picking the right encoder for the "Accept" request header.
+service exposes the Decode, DecodeRequest, Encode and EncodeResponse that implement a content type
+negotiation algorithm for picking the right encoder for the "Accept" request header. The algorithm
+honors the q-values and wildcards of the header (see Negotiate), responses whose media type is not
+acceptable fail with a 406 Not Acceptable error.
 */
 package goa
@@ -51,6 +51,23 @@ type (
 		Finalize()
 	}
 
+	// Locatable is the interface implemented by definitions that know the location of the DSL
+	// that created them. Validation errors reported for such definitions start with the
+	// location so that the offending DSL is easy to find.
+	Locatable interface {
+		Definition
+		// Location returns the file name and line number of the DSL that created the
+		// definition, the empty string and 0 if unknown.
+		Location() (file string, line int)
+	}
+
+	// DSLLocation records the location of the DSL that created a definition. Definitions
+	// embed it and call RecordLocation when created to implement Locatable.
+	DSLLocation struct {
+		file string
+		line int
+	}
+
 	// Versioned is implemented by potentially versioned definitions such as API resources.
 	Versioned interface {
 		Definition
@@ -104,9 +121,23 @@ type (
 		// Required list the required fields of object attributes as described at
 		// http://json-schema.org/latest/json-schema-validation.html#anchor61.
 		Required []string
+		// Validators lists the fully qualified names of the user functions called by the
+		// generated code after the other validations, e.g. "github.com/acme/rules.ValidBottle".
+		Validators []string
 	}
 )
 
+// Location returns the location recorded by RecordLocation.
+func (l *DSLLocation) Location() (string, int) {
+	return l.file, l.line
+}
+
+// RecordLocation records the location of the user code being executed, that is the location of
+// the first caller that is not part of the goa DSL packages.
+func (l *DSLLocation) RecordLocation() {
+	l.file, l.line = computeErrorLocation()
+}
+
 // Context returns the generic definition name used in error messages.
 func (t *TraitDefinition) Context() string {
 	if t.Name != "" {
@@ -149,6 +180,7 @@ func (v *ValidationDefinition) Merge(other *ValidationDefinition) {
 		v.MaxLength = other.MaxLength
 	}
 	v.AddRequired(other.Required)
+	v.AddValidators(other.Validators)
 }
 
 // AddRequired merges the required fields from other into v
@@ -167,16 +199,33 @@ func (v *ValidationDefinition) AddRequired(required []string) {
 	}
 }
 
+// AddValidators merges the validation functions from other into v.
+func (v *ValidationDefinition) AddValidators(validators []string) {
+	for _, f := range validators {
+		found := false
+		for _, ff := range v.Validators {
+			if f == ff {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.Validators = append(v.Validators, f)
+		}
+	}
+}
+
 // Dup makes a shallow dup of the validation.
 func (v *ValidationDefinition) Dup() *ValidationDefinition {
 	return &ValidationDefinition{
-		Values:    v.Values,
-		Format:    v.Format,
-		Pattern:   v.Pattern,
-		Minimum:   v.Minimum,
-		Maximum:   v.Maximum,
-		MinLength: v.MinLength,
-		MaxLength: v.MaxLength,
-		Required:  v.Required,
+		Values:     v.Values,
+		Format:     v.Format,
+		Pattern:    v.Pattern,
+		Minimum:    v.Minimum,
+		Maximum:    v.Maximum,
+		MinLength:  v.MinLength,
+		MaxLength:  v.MaxLength,
+		Required:   v.Required,
+		Validators: v.Validators,
 	}
 }
@@ -14,6 +14,11 @@ var (
 	// Errors contains the DSL execution errors if any.
 	Errors MultiError
 
+	// Warnings contains the DSL warnings if any. Warnings describe issues that do not prevent
+	// the design from being used such as suspicious default values, tools print them but do not
+	// fail unless running in strict mode.
+	Warnings MultiError
+
 	// Global DSL evaluation stack
 	ctxStack contextStack
 
@@ -25,6 +30,9 @@ var (
 	// For example the API DSL only has one root definition (the API definition) but many top level
 	// definitions (API, Version, Type, MediaType etc.) all defining a definition set.
 	Roots []Root
+
+	// customRoots lists the roots registered with RegisterRoot.
+	customRoots []Root
 )
 
 type (
@@ -53,6 +61,7 @@ func Run() error {
 		return nil
 	}
 	Errors = nil
+	Warnings = nil
 
 	executed := 0
 	recursed := 0
@@ -84,6 +93,66 @@ func Run() error {
 	return nil
 }
 
+// Register adds the given root to Roots unless it is already registered. This makes it possible
+// for the packages of a design split across multiple Go packages to each register the roots they
+// contribute to: Run executes and validates the definitions of all the packages together.
+func Register(root Root) {
+	for _, r := range Roots {
+		if sameRoot(r, root) {
+			return
+		}
+	}
+	Roots = append(Roots, root)
+}
+
+// RegisterRoot registers a root built by a DSL implemented outside of goa, for example a package
+// providing keywords such as Database or Queue that describe resources consumed by custom
+// generators. The root takes part in the same lifecycle as the goa roots: Run executes the DSL of
+// its definitions that implement Source, then validates and finalizes the definitions implementing
+// Validate and Finalize. Generators receive the root together with the goa roots.
+// Contrary to roots appended to Roots registered roots are kept by Reset so that the package
+// registering them may do so in its init function regardless of package initialization order.
+func RegisterRoot(root Root) {
+	for _, r := range customRoots {
+		if sameRoot(r, root) {
+			return
+		}
+	}
+	customRoots = append(customRoots, root)
+	Register(root)
+}
+
+// Reset sets Roots to the given roots followed by the roots registered with RegisterRoot.
+func Reset(roots ...Root) {
+	Roots = append(roots, customRoots...)
+}
+
+// RegisterDSLPackage registers the import path of a package implementing DSL keywords. The errors
+// reported by the keywords of registered packages are located at the user code invoking the
+// keywords rather than in the package itself.
+func RegisterDSLPackage(path string) {
+	for _, p := range dslPackages {
+		if p == path {
+			return
+		}
+	}
+	dslPackages = append(dslPackages, path)
+}
+
+// sameRoot returns true if a and b are the same root. Roots may be maps (e.g. the generated media
+// types root) which cannot be compared with ==.
+func sameRoot(a, b Root) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		return false
+	}
+	switch va.Kind() {
+	case reflect.Map, reflect.Ptr, reflect.Slice, reflect.Func, reflect.Chan:
+		return va.Pointer() == vb.Pointer()
+	}
+	return va.Type().Comparable() && a == b
+}
+
 // Execute runs the given DSL to initialize the given definition. It returns true on success.
 // It returns false and appends to Errors on failure.
 // Note that `Run` takes care of calling `Execute` on all definitions that implement Source.
@@ -116,6 +185,41 @@ func (s contextStack) Current() Definition {
 
 // ReportError records a DSL error for reporting post DSL execution.
 func ReportError(fm string, vals ...interface{}) {
+	Errors = append(Errors, newError(fm, vals...))
+}
+
+// ReportDuplicate records an error for a definition whose name is already used by the first
+// definition. The error gives the location of the first definition when known as it may come from
+// a different design package.
+func ReportDuplicate(what string, first Definition) {
+	if l, ok := first.(Locatable); ok {
+		if file, line := l.Location(); file != "" {
+			ReportError("%s is defined twice, first definition at %s:%d", what, file, line)
+			return
+		}
+	}
+	ReportError("%s is defined twice", what)
+}
+
+// ReportWarning records a DSL warning for reporting post DSL execution. Warnings do not cause
+// Run to fail.
+func ReportWarning(fm string, vals ...interface{}) {
+	Warnings = append(Warnings, newError(fm, vals...))
+}
+
+// ReportDefinitionWarning records a warning about the given definition. It is intended for use
+// by validations which run after the DSL has executed, the warning is reported at the location
+// of the definition DSL if the definition implements Locatable.
+func ReportDefinitionWarning(def Definition, fm string, vals ...interface{}) {
+	w := &Error{GoError: fmt.Errorf("%s: %s", def.Context(), fmt.Sprintf(fm, vals...))}
+	if l, ok := def.(Locatable); ok {
+		w.File, w.Line = l.Location()
+	}
+	Warnings = append(Warnings, w)
+}
+
+// newError builds a DSL error located in the user code that invoked the DSL.
+func newError(fm string, vals ...interface{}) *Error {
 	var suffix string
 	if cur := ctxStack.Current(); cur != nil {
 		if ctx := cur.Context(); ctx != "" {
@@ -126,11 +230,11 @@ func ReportError(fm string, vals ...interface{}) {
 	}
 	err := fmt.Errorf(fm+suffix, vals...)
 	file, line := computeErrorLocation()
-	Errors = append(Errors, &Error{
+	return &Error{
 		GoError: err,
 		File:    file,
 		Line:    line,
-	})
+	}
 }
 
 // Error returns the error message.
@@ -148,7 +252,7 @@ func (de *Error) Error() string {
 		if de.File == "" {
 			return err.Error()
 		}
-		return fmt.Sprintf("[%s:%d] %s", de.File, de.Line, err.Error())
+		return fmt.Sprintf("%s:%d: %s", de.File, de.Line, err.Error())
 	}
 	return ""
 }
@@ -168,31 +272,20 @@ func InvalidArgError(expected string, actual interface{}) {
 }
 
 // computeErrorLocation implements a heuristic to find the location in the user
-// code where the error occurred. It walks back the callstack until the file
-// doesn't match "/goa/design/*.go".
+// code where the error occurred. It walks back the callstack until it finds a
+// frame that is neither in the goa DSL packages (design, design/apidsl and
+// dslengine) nor in a goa DSL source file.
 // When successful it returns the file name and line number, empty string and
 // 0 otherwise.
 func computeErrorLocation() (file string, line int) {
-	depth := 2
-	_, file, line, _ = runtime.Caller(depth)
-	ok := strings.HasSuffix(file, "_test.go") // Be nice with tests
-	if !ok {
-		nok, _ := regexp.MatchString(`/goa/design/.+\.go$`, file)
-		if !nok {
-			nok, _ = regexp.MatchString(`/goa/dslengine/.+\.go$`, file)
-		}
-		ok = !nok
-	}
-	for !ok {
-		depth++
-		_, file, line, _ = runtime.Caller(depth)
-		ok = strings.HasSuffix(file, "_test.go")
+	for depth := 2; ; depth++ {
+		pc, f, l, ok := runtime.Caller(depth)
 		if !ok {
-			nok, _ := regexp.MatchString(`/goa/design/.+\.go$`, file)
-			if !nok {
-				nok, _ = regexp.MatchString(`/goa/dslengine/.+\.go$`, file)
-			}
-			ok = !nok
+			return "", 0
+		}
+		if strings.HasSuffix(f, "_test.go") || !isDSLFrame(pc, f) { // Be nice with tests
+			file, line = f, l
+			break
 		}
 	}
 	wd, err := os.Getwd()
@@ -211,6 +304,29 @@ func computeErrorLocation() (file string, line int) {
 	return
 }
 
+// dslPackages lists the import paths of the packages implementing the goa DSL and the packages
+// registered with RegisterDSLPackage.
+var dslPackages = []string{
+	"github.com/goadesign/goa/design",
+	"github.com/goadesign/goa/design/apidsl",
+	"github.com/goadesign/goa/dslengine",
+}
+
+// isDSLFrame returns true if the stack frame with the given program counter and file belongs
+// to the goa DSL implementation.
+func isDSLFrame(pc uintptr, file string) bool {
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		name := fn.Name()
+		for _, p := range dslPackages {
+			if strings.HasPrefix(name, p+".") {
+				return true
+			}
+		}
+	}
+	ok, _ := regexp.MatchString(`/goa/(design|dslengine)/.+\.go$`, file)
+	return ok
+}
+
 // runSet executes the DSL for all definitions in the given set. The definition DSLs may append to
 // the set as they execute.
 func runSet(set DefinitionSet) error {
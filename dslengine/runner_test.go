@@ -1,6 +1,8 @@
 package dslengine_test
 
 import (
+	"fmt"
+
 	. "github.com/goadesign/goa/design"
 	. "github.com/goadesign/goa/design/apidsl"
 	"github.com/goadesign/goa/dslengine"
@@ -68,7 +70,7 @@ var _ = Describe("DSL errors", func() {
 		const errMsg = "err"
 
 		// See NOTE below.
-		const lineNumber = 76
+		const lineNumber = 78
 
 		BeforeEach(func() {
 			// NOTE: moving the line below requires updating the
@@ -102,7 +104,7 @@ var _ = Describe("DSL errors", func() {
 
 	Context("with invalid DSL", func() {
 		// See NOTE below.
-		const lineNumber = 112
+		const lineNumber = 114
 
 		BeforeEach(func() {
 			InitDesign()
@@ -125,7 +127,7 @@ var _ = Describe("DSL errors", func() {
 
 	Context("with DSL calling a function with an invalid argument type", func() {
 		// See NOTE below.
-		const lineNumber = 135
+		const lineNumber = 137
 
 		BeforeEach(func() {
 			InitDesign()
@@ -145,4 +147,169 @@ var _ = Describe("DSL errors", func() {
 			Ω(dslengine.Errors[0].Line).Should(Equal(lineNumber))
 		})
 	})
+
+	Context("with a definition that does not validate", func() {
+		// See NOTE below.
+		const lineNumber = 160
+
+		BeforeEach(func() {
+			InitDesign()
+			Resource("res", func() {
+				// NOTE: moving the line below requires updating the
+				// constant above to match its number.
+				Action("act", func() {})
+			})
+			dslengine.Run()
+		})
+
+		It("prefixes the validation error with the DSL location", func() {
+			Ω(ErrorMsg).Should(ContainSubstring("No route defined for action"))
+			Ω(ErrorMsg).Should(MatchRegexp(fmt.Sprintf(`runner_test\.go:%d: resource "res" action "act"`, lineNumber)))
+		})
+	})
+})
+
+var _ = Describe("DSL warnings", func() {
+	BeforeEach(func() {
+		dslengine.Errors = nil
+		dslengine.Warnings = nil
+	})
+
+	Context("with a warning", func() {
+		const warnMsg = "suspicious"
+
+		BeforeEach(func() {
+			dslengine.ReportWarning(warnMsg)
+		})
+
+		It("records the warning but no error", func() {
+			Ω(dslengine.Errors).Should(BeNil())
+			Ω(dslengine.Warnings).Should(HaveLen(1))
+			Ω(dslengine.Warnings[0].File).Should(HaveSuffix("runner_test.go"))
+			Ω(dslengine.Warnings.Error()).Should(ContainSubstring(warnMsg))
+		})
+	})
+
+	Context("with a definition warning", func() {
+		BeforeEach(func() {
+			InitDesign()
+			Type("bar", func() {
+				Attribute("baz", String, func() {
+					Default("foo")
+				})
+				Required("baz")
+			})
+			Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+		})
+
+		It("reports the warning without failing the run", func() {
+			Ω(dslengine.Warnings).Should(HaveLen(1))
+			Ω(dslengine.Warnings.Error()).Should(ContainSubstring(`default value of required field "baz" is never used`))
+		})
+	})
+})
+
+var _ = Describe("Register", func() {
+	var roots []dslengine.Root
+
+	BeforeEach(func() {
+		roots = dslengine.Roots
+		InitDesign()
+	})
+
+	AfterEach(func() {
+		dslengine.Roots = roots
+	})
+
+	It("registers roots only once", func() {
+		generated := make(MediaTypeRoot)
+		dslengine.Register(Design)
+		dslengine.Register(generated)
+		dslengine.Register(generated)
+		Ω(dslengine.Roots).Should(HaveLen(2))
+		Ω(dslengine.Roots[0]).Should(Equal(Design))
+	})
+})
+
+// queueRoot is a custom DSL root holding queue definitions.
+type queueRoot struct {
+	queues dslengine.DefinitionSet
+}
+
+func (r *queueRoot) IterateSets(it dslengine.SetIterator) {
+	it(r.queues)
+}
+
+// queueDefinition is a custom definition built by the Queue keyword.
+type queueDefinition struct {
+	name      string
+	durable   bool
+	dsl       func()
+	finalized bool
+}
+
+func (q *queueDefinition) Context() string { return fmt.Sprintf("queue %#v", q.name) }
+func (q *queueDefinition) DSL() func()     { return q.dsl }
+func (q *queueDefinition) Finalize()       { q.finalized = true }
+func (q *queueDefinition) Validate() error {
+	if q.name == "" {
+		return fmt.Errorf("queue name cannot be empty")
+	}
+	return nil
+}
+
+var _ = Describe("RegisterRoot", func() {
+	root := new(queueRoot)
+	var roots []dslengine.Root
+
+	// Queue and Durable are custom DSL keywords.
+	Queue := func(name string, dsl func()) *queueDefinition {
+		if !dslengine.TopLevelDefinition(true) {
+			return nil
+		}
+		q := &queueDefinition{name: name, dsl: dsl}
+		root.queues = append(root.queues, q)
+		return q
+	}
+	Durable := func() {
+		if q, ok := dslengine.CurrentDefinition().(*queueDefinition); ok {
+			q.durable = true
+			return
+		}
+		dslengine.IncompatibleDSL(dslengine.Caller())
+	}
+
+	BeforeEach(func() {
+		roots = dslengine.Roots
+		dslengine.RegisterRoot(root)
+		dslengine.RegisterRoot(root)
+		InitDesign()
+	})
+
+	AfterEach(func() {
+		root.queues = nil
+		dslengine.Roots = roots
+	})
+
+	It("keeps the registered roots when the design is initialized", func() {
+		Ω(dslengine.Roots).Should(HaveLen(2))
+		Ω(dslengine.Roots[0]).Should(Equal(Design))
+		Ω(dslengine.Roots[1]).Should(Equal(root))
+	})
+
+	It("runs the custom DSL through all the phases", func() {
+		q := Queue("orders", func() {
+			Durable()
+		})
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+		Ω(q.durable).Should(BeTrue())
+		Ω(q.finalized).Should(BeTrue())
+	})
+
+	It("validates the custom definitions", func() {
+		Queue("", nil)
+		err := dslengine.Run()
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring("queue name cannot be empty"))
+	})
 })
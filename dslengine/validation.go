@@ -15,7 +15,13 @@ type ValidationErrors struct {
 func (verr *ValidationErrors) Error() string {
 	msg := make([]string, len(verr.Errors))
 	for i, err := range verr.Errors {
-		msg[i] = fmt.Sprintf("%s: %s", verr.Definitions[i].Context(), err)
+		def := verr.Definitions[i]
+		msg[i] = fmt.Sprintf("%s: %s", def.Context(), err)
+		if l, ok := def.(Locatable); ok {
+			if file, line := l.Location(); file != "" {
+				msg[i] = fmt.Sprintf("%s:%d: %s", file, line, msg[i])
+			}
+		}
 	}
 	return strings.Join(msg, "\n")
 }
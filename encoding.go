@@ -8,6 +8,9 @@ import (
 	"io"
 	"mime"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -178,23 +181,185 @@ func (p *decoderPool) Put(d Decoder) {
 }
 
 // EncodeResponse uses registered Encoders to marshal the response body based on the request
-// `Accept` header and writes it to the http.ResponseWriter
+// `Accept` header and writes it to the http.ResponseWriter. The encoder is selected by the
+// content type returned by Negotiate given the request Accept header and the response Content-Type
+// header. The default encoder is used if the request does not accept any of the registered content
+// types.
 func (ver *ServiceVersion) EncodeResponse(ctx context.Context, v interface{}) error {
 	now := time.Now()
-	accept := Request(ctx).Header.Get("Accept")
-	if accept == "" {
-		accept = "*/*"
+	contentType := Response(ctx).Header().Get("Content-Type")
+	if ct, err := ver.Negotiate(Request(ctx).Header.Get("Accept"), contentType); err == nil {
+		contentType = ct
 	}
-	var contentType string
+	defer MeasureSince([]string{"goa", "encode", contentType}, now)
+	p := ver.encoderPool(contentType)
+	if p == nil {
+		return fmt.Errorf("No encoder registered for %s and no default encoder", contentType)
+	}
+
+	// the encoderPool will handle whether or not a pool is actually in use
+	encoder := p.Get(Response(ctx))
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	p.Put(encoder)
+
+	return nil
+}
+
+// Negotiate returns the content type of a response given the request Accept header and the
+// response content type if already set. The Accept header is parsed according to RFC 7231: media
+// ranges may use wildcards (e.g. "application/*") and q-values, the q-value of the most specific
+// range matching a content type applies. contentType is returned if acceptable, otherwise
+// Negotiate returns the registered encoder content type with the highest q-value, ties are broken
+// using the order in which the encoders were registered. Content types with a structured syntax
+// suffix (e.g. "application/vnd.goa.example+json") match the content types whose subtype is the
+// suffix (e.g. "application/json"). Negotiate returns an error of class ErrNotAcceptable if no
+// content type is acceptable. It returns the empty string if the service only has a default
+// encoder registered for "*/*".
+func (ver *ServiceVersion) Negotiate(accept, contentType string) (string, error) {
+	ranges := ParseAccept(accept)
+	if contentType != "" {
+		mediaType := contentType
+		if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+			mediaType = mt
+		}
+		if acceptQuality(ranges, mediaType) > 0 {
+			return contentType, nil
+		}
+	}
+	if len(ver.encodableContentTypes) == 0 {
+		return "", nil
+	}
+	var best string
+	var bestQ float64
 	for _, t := range ver.encodableContentTypes {
-		if accept == "*/*" || accept == t {
-			contentType = accept
-			break
+		if q := acceptQuality(ranges, t); q > bestQ {
+			best, bestQ = t, q
+		}
+	}
+	if best == "" {
+		return "", ErrNotAcceptable("none of the media types accepted by the request (%s) can be produced", accept)
+	}
+	return best, nil
+}
+
+// AcceptRange is a media range listed in an Accept header.
+type AcceptRange struct {
+	// Type is the media type, e.g. "application" or "*".
+	Type string
+	// Subtype is the media subtype, e.g. "json" or "*".
+	Subtype string
+	// Q is the q-value of the range between 0 and 1.
+	Q float64
+}
+
+// ParseAccept parses the given Accept header value and returns the media ranges sorted by
+// decreasing q-value. Invalid ranges are ignored. An empty header accepts any media type.
+func ParseAccept(accept string) []*AcceptRange {
+	if strings.TrimSpace(accept) == "" {
+		return []*AcceptRange{{Type: "*", Subtype: "*", Q: 1}}
+	}
+	var ranges []*AcceptRange
+	for _, r := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(r))
+		if err != nil {
+			continue
+		}
+		elems := strings.SplitN(mediaType, "/", 2)
+		if len(elems) != 2 || (elems[0] == "*" && elems[1] != "*") {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, &AcceptRange{Type: elems[0], Subtype: elems[1], Q: q})
+	}
+	sort.Stable(byQuality(ranges))
+	return ranges
+}
+
+// byQuality sorts media ranges by decreasing q-value.
+type byQuality []*AcceptRange
+
+func (b byQuality) Len() int           { return len(b) }
+func (b byQuality) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byQuality) Less(i, j int) bool { return b[i].Q > b[j].Q }
+
+// specificity returns how specifically the range matches the given media type: 3 for an exact
+// match, 2 for a match through a structured syntax suffix, 1 for a subtype wildcard, 0 for the
+// "*/*" range and -1 if the range does not match.
+func (r *AcceptRange) specificity(mediaType string) int {
+	elems := strings.SplitN(mediaType, "/", 2)
+	if len(elems) != 2 {
+		return -1
+	}
+	typ, sub := elems[0], elems[1]
+	switch {
+	case r.Type == "*":
+		return 0
+	case r.Type != typ:
+		return -1
+	case r.Subtype == "*":
+		return 1
+	case r.Subtype == sub:
+		return 3
+	case strings.HasSuffix(sub, "+"+r.Subtype), strings.HasSuffix(r.Subtype, "+"+sub):
+		return 2
+	}
+	return -1
+}
+
+// acceptQuality returns the q-value of the most specific range matching the media type, 0 if
+// none does.
+func acceptQuality(ranges []*AcceptRange, mediaType string) float64 {
+	var q float64
+	spec := -1
+	for _, r := range ranges {
+		if s := r.specificity(mediaType); s > spec {
+			spec, q = s, r.Q
 		}
 	}
+	return q
+}
+
+// encoderPool returns the pool of the encoder registered for the given content type. It falls
+// back to the encoder registered for the structured syntax suffix of the content type if any
+// (e.g. "application/json" for "application/vnd.goa.example+json") and to the default encoder.
+func (ver *ServiceVersion) encoderPool(contentType string) *encoderPool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	if p, ok := ver.encoderPools[contentType]; ok {
+		return p
+	}
+	if i := strings.LastIndex(contentType, "+"); i > -1 {
+		if j := strings.Index(contentType, "/"); j > -1 {
+			if p, ok := ver.encoderPools[contentType[:j+1]+contentType[i+1:]]; ok {
+				return p
+			}
+		}
+	}
+	return ver.encoderPools["*/*"]
+}
+
+// Encode uses registered Encoders to marshal v into body based on the contentType
+func (ver *ServiceVersion) Encode(v interface{}, body io.Writer, contentType string) error {
+	now := time.Now()
 	defer MeasureSince([]string{"goa", "encode", contentType}, now)
+	if contentType == "" {
+		// Default to JSON
+		contentType = "application/json"
+	} else {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			contentType = mediaType
+		}
+	}
 	p := ver.encoderPools[contentType]
-	if p == nil && contentType != "*/*" {
+	if p == nil {
 		p = ver.encoderPools["*/*"]
 	}
 	if p == nil {
@@ -202,7 +367,7 @@ func (ver *ServiceVersion) EncodeResponse(ctx context.Context, v interface{}) er
 	}
 
 	// the encoderPool will handle whether or not a pool is actually in use
-	encoder := p.Get(Response(ctx))
+	encoder := p.Get(body)
 	if err := encoder.Encode(v); err != nil {
 		return err
 	}
@@ -227,12 +392,26 @@ func (ver *ServiceVersion) SetEncoder(f EncoderFactory, makeDefault bool, conten
 		ver.encoderPools["*/*"] = p
 	}
 
-	// Rebuild a unique index of registered content encoders to be used in EncodeResponse
-	ver.encodableContentTypes = make([]string, 0, len(ver.encoderPools))
-	for contentType := range ver.encoderPools {
-		ver.encodableContentTypes = append(ver.encodableContentTypes, contentType)
+	// Record the registered content types in order for response negotiation
+	for _, contentType := range contentTypes {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			mediaType = contentType
+		}
+		if strings.Contains(mediaType, "*") {
+			continue
+		}
+		found := false
+		for _, t := range ver.encodableContentTypes {
+			if t == mediaType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ver.encodableContentTypes = append(ver.encodableContentTypes, mediaType)
+		}
 	}
-
 }
 
 // newEncodePool checks to see if the EncoderFactory returns reusable encoders
@@ -0,0 +1,117 @@
+package goa_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("ParseAccept", func() {
+	It("sorts the media ranges by q-value", func() {
+		ranges := goa.ParseAccept("text/html;q=0.5, application/*;q=0.8, application/json, invalid;q=2")
+		Ω(ranges).Should(HaveLen(3))
+		Ω(*ranges[0]).Should(Equal(goa.AcceptRange{Type: "application", Subtype: "json", Q: 1}))
+		Ω(*ranges[1]).Should(Equal(goa.AcceptRange{Type: "application", Subtype: "*", Q: 0.8}))
+		Ω(*ranges[2]).Should(Equal(goa.AcceptRange{Type: "text", Subtype: "html", Q: 0.5}))
+	})
+
+	It("accepts any media type if the header is empty", func() {
+		Ω(goa.ParseAccept("")).Should(Equal([]*goa.AcceptRange{{Type: "*", Subtype: "*", Q: 1}}))
+	})
+})
+
+var _ = Describe("Negotiate", func() {
+	var service *goa.Service
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.SetEncoder(goa.JSONEncoderFactory(), true, "application/json")
+		service.SetEncoder(goa.XMLEncoderFactory(), false, "application/xml", "text/xml")
+	})
+
+	cases := []struct{ desc, accept, contentType, expected string }{
+		{"no Accept header", "", "", "application/json"},
+		{"a wildcard", "*/*", "", "application/json"},
+		{"an exact match", "application/xml", "", "application/xml"},
+		{"q-values", "application/json;q=0.5, application/xml", "", "application/xml"},
+		{"a subtype wildcard", "text/*", "", "text/xml"},
+		{"overlapping ranges", "application/*;q=0.9, application/json;q=0.1", "", "application/xml"},
+		{"an acceptable content type", "application/*", "application/vnd.goa.example+json", "application/vnd.goa.example+json"},
+		{"a structured syntax suffix", "application/json", "application/vnd.goa.example+json", "application/vnd.goa.example+json"},
+		{"an unacceptable content type", "application/xml", "application/vnd.goa.example+json", "application/xml"},
+	}
+	for _, c := range cases {
+		c := c
+		It(fmt.Sprintf("picks the content type given %s", c.desc), func() {
+			ct, err := service.Negotiate(c.accept, c.contentType)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(ct).Should(Equal(c.expected))
+		})
+	}
+
+	It("returns a not acceptable error if nothing matches", func() {
+		_, err := service.Negotiate("text/html, application/json;q=0", "")
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(406))
+	})
+
+	Describe("Send", func() {
+		var accept string
+		var rw *TestResponseWriter
+		var data *goa.ResponseData
+		var code int
+		var err error
+
+		BeforeEach(func() {
+			accept = "application/xml"
+			code = 200
+		})
+
+		JustBeforeEach(func() {
+			req, e := http.NewRequest("GET", "/", nil)
+			Ω(e).ShouldNot(HaveOccurred())
+			req.Header.Set("Accept", accept)
+			rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+			ctx := goa.NewContext(context.Background(), service, rw, req, url.Values{})
+			data = goa.Response(ctx)
+			data.Header().Set("Content-Type", "application/vnd.goa.example+json")
+			err = data.Send(ctx, code, "hello")
+		})
+
+		It("encodes the body with the negotiated encoder", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rw.Status).Should(Equal(200))
+			Ω(rw.ParentHeader.Get("Content-Type")).Should(Equal("application/xml"))
+			Ω(string(rw.Body)).Should(Equal("<string>hello</string>"))
+		})
+
+		Context("with an unacceptable request", func() {
+			BeforeEach(func() {
+				accept = "text/html"
+			})
+
+			It("does not write the response", func() {
+				Ω(err).Should(HaveOccurred())
+				Ω(goa.ErrorStatus(err)).Should(Equal(406))
+				Ω(rw.Status).Should(Equal(0))
+			})
+
+			Context("and an error status", func() {
+				BeforeEach(func() {
+					code = 406
+				})
+
+				It("writes the response with the default encoder", func() {
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(rw.Status).Should(Equal(406))
+					Ω(string(rw.Body)).Should(Equal("\"hello\"\n"))
+				})
+			})
+		})
+	})
+})
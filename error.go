@@ -17,6 +17,12 @@
 // back to the client. The response status code is inferred from the type wrapping the error object:
 // a BadRequestError produces a 400 status code while any other error produce a 500. This behavior
 // can be overridden by setting a custom ErrorHandler in the application.
+//
+// Handlers may also return errors built with the error classes defined in this file such as
+// ErrBadRequest, ErrNotFound or ErrInternal. Each class produces HTTPError values that carry a
+// code, the response status code, a detail message and optional metadata. The error handlers use
+// the status of any error that implements ServiceError - including BadRequestError - to build
+// the response.
 package goa
 
 import (
@@ -44,6 +50,72 @@ type (
 	BadRequestError struct {
 		Actual error
 	}
+
+	// ServiceError is the interface implemented by the errors that determine the status code of
+	// the response written by the error handlers.
+	ServiceError interface {
+		error
+		// ResponseStatus returns the status code of the response.
+		ResponseStatus() int
+		// ErrorCode returns the code of the error class, e.g. "not_found".
+		ErrorCode() string
+	}
+
+	// ErrorClass is an error generating function. It accepts a detail message formatted a la
+	// fmt.Sprintf or an error whose message is used as detail and returns a HTTPError with the
+	// class code and status.
+	ErrorClass func(detail interface{}, v ...interface{}) *HTTPError
+
+	// HTTPError is the error produced by error classes. It serializes into the response body.
+	HTTPError struct {
+		// Code identifies the error class, e.g. "not_found".
+		Code string `json:"code" xml:"code"`
+		// Status is the response status code.
+		Status int `json:"status" xml:"status"`
+		// Detail describes the specific error occurrence.
+		Detail string `json:"detail" xml:"detail"`
+		// MetaValues contains additional key/value pairs useful to clients.
+		MetaValues map[string]interface{} `json:"meta,omitempty" xml:"-"`
+	}
+)
+
+var (
+	// ErrBadRequest is the class of errors returned when the request is invalid.
+	ErrBadRequest = NewErrorClass("bad_request", 400)
+
+	// ErrUnauthorized is the class of errors returned when the request lacks valid credentials.
+	ErrUnauthorized = NewErrorClass("unauthorized", 401)
+
+	// ErrForbidden is the class of errors returned when the credentials do not grant access to
+	// the resource.
+	ErrForbidden = NewErrorClass("forbidden", 403)
+
+	// ErrNotFound is the class of errors returned when the resource does not exist.
+	ErrNotFound = NewErrorClass("not_found", 404)
+
+	// ErrMethodNotAllowed is the class of errors returned when the request method is not
+	// supported by the resource.
+	ErrMethodNotAllowed = NewErrorClass("method_not_allowed", 405)
+
+	// ErrNotAcceptable is the class of errors returned when the service cannot produce a
+	// response in any of the media types accepted by the request.
+	ErrNotAcceptable = NewErrorClass("not_acceptable", 406)
+
+	// ErrConflict is the class of errors returned when the request conflicts with the state of
+	// the resource.
+	ErrConflict = NewErrorClass("conflict", 409)
+
+	// ErrRequestTooLarge is the class of errors returned when the request body exceeds the
+	// maximum size set for the action, see SetMaxRequestSize.
+	ErrRequestTooLarge = NewErrorClass("request_too_large", 413)
+
+	// ErrInternal is the class of errors returned when an unexpected condition prevents the
+	// request from completing.
+	ErrInternal = NewErrorClass("internal", 500)
+
+	// ErrServiceUnavailable is the class of errors returned when the service or the action is
+	// temporarily disabled, see SetMaintenance.
+	ErrServiceUnavailable = NewErrorClass("service_unavailable", 503)
 )
 
 const (
@@ -98,6 +170,16 @@ const (
 	// ErrInvalidVersion is the error rendered by the default mux when a
 	// request specifies an invalid version.
 	ErrInvalidVersion
+
+	// ErrCustomValidation is the error produced by the generated code when
+	// a validation function referenced in the design definition returns an
+	// error.
+	ErrCustomValidation
+
+	// ErrInvalidHeaderType is the error produced by the generated code when
+	// a request header value does not match the type of the header in the
+	// design.
+	ErrInvalidHeaderType
 )
 
 // Title returns a human friendly error title
@@ -125,6 +207,10 @@ func (k ErrorID) Title() string {
 		return "invalid value length"
 	case ErrInvalidVersion:
 		return "invalid version"
+	case ErrCustomValidation:
+		return "value does not pass custom validation"
+	case ErrInvalidHeaderType:
+		return "invalid HTTP header value"
 	}
 	return "unknown error"
 }
@@ -142,6 +228,66 @@ func (t *TypedError) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// NewErrorClass creates a new error class. It is the responsibility of the client to guarantee
+// uniqueness of code.
+func NewErrorClass(code string, status int) ErrorClass {
+	return func(detail interface{}, v ...interface{}) *HTTPError {
+		var msg string
+		switch actual := detail.(type) {
+		case string:
+			msg = actual
+			if len(v) > 0 {
+				msg = fmt.Sprintf(actual, v...)
+			}
+		case error:
+			msg = actual.Error()
+		default:
+			msg = fmt.Sprintf("%v", actual)
+		}
+		return &HTTPError{Code: code, Status: status, Detail: msg}
+	}
+}
+
+// Error implements error.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Detail)
+}
+
+// ResponseStatus implements ServiceError.
+func (e *HTTPError) ResponseStatus() int {
+	return e.Status
+}
+
+// ErrorCode implements ServiceError.
+func (e *HTTPError) ErrorCode() string {
+	return e.Code
+}
+
+// Meta adds the given key/value pairs to the error metadata and returns the error. The keys must
+// be strings, pairs with other keys are ignored.
+func (e *HTTPError) Meta(keyvals ...interface{}) *HTTPError {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		k, ok := keyvals[i].(string)
+		if !ok {
+			continue
+		}
+		if e.MetaValues == nil {
+			e.MetaValues = make(map[string]interface{})
+		}
+		e.MetaValues[k] = keyvals[i+1]
+	}
+	return e
+}
+
+// ErrorStatus returns the status code of the response that describes err: the status of err if
+// it implements ServiceError, 500 otherwise.
+func ErrorStatus(err error) int {
+	if serr, ok := err.(ServiceError); ok {
+		return serr.ResponseStatus()
+	}
+	return 500
+}
+
 // Error builds an error message from the typed error details.
 func (t *TypedError) Error() string {
 	IncrCounter([]string{"goa", "error", strconv.Itoa(int(t.ID))}, 1.0)
@@ -193,6 +339,16 @@ func (b *BadRequestError) Error() string {
 	return b.Actual.Error()
 }
 
+// ResponseStatus implements ServiceError.
+func (b *BadRequestError) ResponseStatus() int {
+	return 400
+}
+
+// ErrorCode implements ServiceError.
+func (b *BadRequestError) ErrorCode() string {
+	return "bad_request"
+}
+
 // InvalidParamTypeError appends a typed error of id ErrInvalidParamType to
 // err and returns it.
 func InvalidParamTypeError(name string, val interface{}, expected string, err error) error {
@@ -245,6 +401,17 @@ func MissingHeaderError(name string, err error) error {
 	return ReportError(err, &terr)
 }
 
+// InvalidHeaderTypeError appends a typed error of id ErrInvalidHeaderType to
+// err and returns it.
+func InvalidHeaderTypeError(name string, val interface{}, expected string, err error) error {
+	terr := TypedError{
+		ID: ErrInvalidHeaderType,
+		Mesg: fmt.Sprintf("invalid value %#v for HTTP header %#v, must be a %s",
+			val, name, expected),
+	}
+	return ReportError(err, &terr)
+}
+
 // InvalidEnumValueError appends a typed error of id ErrInvalidEnumValue to
 // err and returns it.
 func InvalidEnumValueError(ctx string, val interface{}, allowed []interface{}, err error) error {
@@ -312,6 +479,16 @@ func InvalidLengthError(ctx string, target interface{}, ln, value int, min bool,
 	return ReportError(err, &terr)
 }
 
+// CustomValidationError appends a typed error of id ErrCustomValidation to
+// err and returns it.
+func CustomValidationError(ctx string, validationError, err error) error {
+	terr := TypedError{
+		ID:   ErrCustomValidation,
+		Mesg: fmt.Sprintf("%s is invalid, %s", ctx, validationError.Error()),
+	}
+	return ReportError(err, &terr)
+}
+
 // ReportError coerces the first argument into a MultiError then appends the second argument and
 // returns the resulting MultiError.
 func ReportError(err error, err2 error) error {
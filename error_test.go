@@ -328,6 +328,31 @@ var _ = Describe("MissingAttributeError", func() {
 	})
 })
 
+var _ = Describe("InvalidHeaderTypeError", func() {
+	var valErr error
+	name := "X-Count"
+	val := "ten"
+	expected := "integer"
+
+	JustBeforeEach(func() {
+		valErr = goa.InvalidHeaderTypeError(name, val, expected, nil)
+	})
+
+	It("creates a multi error", func() {
+		Ω(valErr).ShouldNot(BeNil())
+		Ω(valErr).Should(BeAssignableToTypeOf(goa.MultiError{}))
+		mErr := valErr.(goa.MultiError)
+		Ω(mErr).Should(HaveLen(1))
+		Ω(mErr[0]).Should(BeAssignableToTypeOf(&goa.TypedError{}))
+		tErr := mErr[0].(*goa.TypedError)
+		Ω(tErr.ID).Should(Equal(goa.ErrorID(goa.ErrInvalidHeaderType)))
+		Ω(tErr.ID.Title()).Should(Equal("invalid HTTP header value"))
+		Ω(tErr.Mesg).Should(ContainSubstring(name))
+		Ω(tErr.Mesg).Should(ContainSubstring(val))
+		Ω(tErr.Mesg).Should(ContainSubstring(expected))
+	})
+})
+
 var _ = Describe("MissingHeaderError", func() {
 	var valErr, err error
 	name := "param"
@@ -806,3 +831,41 @@ var _ = Describe("ReportError", func() {
 		})
 	})
 })
+
+var _ = Describe("ErrorClass", func() {
+	var class goa.ErrorClass
+	var herr *goa.HTTPError
+
+	BeforeEach(func() {
+		class = goa.NewErrorClass("teapot", 418)
+	})
+
+	It("creates errors with the class code and status", func() {
+		herr = class("brewing %s", "tea")
+		Ω(herr.Code).Should(Equal("teapot"))
+		Ω(herr.Status).Should(Equal(418))
+		Ω(herr.Detail).Should(Equal("brewing tea"))
+		Ω(herr.Error()).Should(Equal("418 teapot: brewing tea"))
+		Ω(goa.ErrorStatus(herr)).Should(Equal(418))
+	})
+
+	It("uses the message of errors as detail", func() {
+		herr = class(errors.New("boom"))
+		Ω(herr.Detail).Should(Equal("boom"))
+	})
+
+	It("records metadata", func() {
+		herr = class("brewing").Meta("kind", "tea", 42, "ignored", "temp", 90)
+		Ω(herr.MetaValues).Should(Equal(map[string]interface{}{"kind": "tea", "temp": 90}))
+		js, err := json.Marshal(herr)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(js)).Should(MatchJSON(`{"code":"teapot","status":418,"detail":"brewing","meta":{"kind":"tea","temp":90}}`))
+	})
+
+	It("classifies bad request errors", func() {
+		var serr goa.ServiceError = goa.NewBadRequestError(errors.New("invalid"))
+		Ω(serr.ResponseStatus()).Should(Equal(400))
+		Ω(serr.ErrorCode()).Should(Equal("bad_request"))
+		Ω(goa.ErrorStatus(errors.New("boom"))).Should(Equal(500))
+	})
+})
@@ -0,0 +1,147 @@
+package goa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// ComputeETag returns the quoted ETag of the given response body. The ETag is derived from the
+// SHA-256 hash of the body and is prefixed with "W/" if weak is true.
+func ComputeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// AttributeETag returns the quoted strong ETag computed from the given attribute value, typically
+// a version number or a last modification time. Pointers are dereferenced, AttributeETag returns
+// the empty string if v is nil.
+func AttributeETag(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+	return ComputeETag([]byte(fmt.Sprint(rv.Interface())), false)
+}
+
+// CheckPreconditions sets the ETag response header to the given ETag and evaluates the request
+// If-Match and If-None-Match headers against it. If a precondition fails it writes a 412 response,
+// or a 304 response for GET and HEAD requests whose If-None-Match header matches, and returns true
+// in which case the caller must not write the response. An empty ETag means that the resource does
+// not exist: If-Match preconditions fail and If-None-Match preconditions succeed.
+func CheckPreconditions(ctx context.Context, etag string) bool {
+	rw := Response(ctx)
+	if etag != "" {
+		rw.Header().Set("ETag", etag)
+	}
+	code := preconditionStatus(Request(ctx).Request, etag)
+	if code == 0 {
+		return false
+	}
+	rw.WriteHeader(code)
+	return true
+}
+
+// SendETag is like Send but also handles conditional requests: it sets the ETag response header
+// and writes a 304 or 412 response instead of the body if the request preconditions fail, see
+// CheckPreconditions. If etag is empty the ETag is computed from the encoded body and is weak if
+// weak is true, the body is then encoded in memory before the response is written. Responses with
+// a status code outside of the 2xx range are sent as is.
+func (r *ResponseData) SendETag(ctx context.Context, code int, body interface{}, etag string, weak bool) error {
+	if code < 200 || code > 299 {
+		return r.Send(ctx, code, body)
+	}
+	service := RequestService(ctx)
+	contentType, err := service.Negotiate(Request(ctx).Header.Get("Accept"), r.Header().Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		r.Header().Set("Content-Type", contentType)
+	}
+	var buf *bytes.Buffer
+	if etag == "" {
+		p := service.encoderPool(contentType)
+		if p == nil {
+			return fmt.Errorf("No encoder registered for %s and no default encoder", contentType)
+		}
+		buf = new(bytes.Buffer)
+		encoder := p.Get(buf)
+		if err := encoder.Encode(body); err != nil {
+			return err
+		}
+		p.Put(encoder)
+		etag = ComputeETag(buf.Bytes(), weak)
+	}
+	if CheckPreconditions(ctx, etag) {
+		return nil
+	}
+	r.WriteHeader(code)
+	if buf != nil {
+		_, err := r.Write(buf.Bytes())
+		return err
+	}
+	return service.EncodeResponse(ctx, body)
+}
+
+// preconditionStatus returns the status code of the response to a request whose preconditions
+// fail given the current ETag of the resource, 0 if the preconditions succeed. If-Match headers
+// use the strong comparison function and If-None-Match headers the weak comparison function as
+// specified by RFC 7232.
+func preconditionStatus(req *http.Request, etag string) int {
+	if im := req.Header.Get("If-Match"); im != "" {
+		if !matchETag(im, etag, false) {
+			return http.StatusPreconditionFailed
+		}
+	}
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		if matchETag(inm, etag, true) {
+			if req.Method == "GET" || req.Method == "HEAD" {
+				return http.StatusNotModified
+			}
+			return http.StatusPreconditionFailed
+		}
+	}
+	return 0
+}
+
+// matchETag returns true if the given ETag matches one of the ETags listed in the If-Match or
+// If-None-Match header value. "*" matches any existing resource.
+func matchETag(header, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	strong := !strings.HasPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+			continue
+		}
+		if strong && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
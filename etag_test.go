@@ -0,0 +1,102 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("ETag", func() {
+	It("computes strong and weak ETags", func() {
+		etag := goa.ComputeETag([]byte("body"), false)
+		Ω(etag).Should(HavePrefix(`"`))
+		Ω(goa.ComputeETag([]byte("body"), true)).Should(Equal("W/" + etag))
+		Ω(goa.ComputeETag([]byte("other"), false)).ShouldNot(Equal(etag))
+	})
+
+	It("computes ETags from attribute values", func() {
+		version := 42
+		Ω(goa.AttributeETag(&version)).Should(Equal(goa.AttributeETag(42)))
+		Ω(goa.AttributeETag((*int)(nil))).Should(BeEmpty())
+	})
+})
+
+var _ = Describe("SendETag", func() {
+	var method, ifMatch, ifNoneMatch, etag string
+	var rw *httptest.ResponseRecorder
+
+	BeforeEach(func() {
+		method, ifMatch, ifNoneMatch, etag = "GET", "", "", ""
+	})
+
+	JustBeforeEach(func() {
+		service := goa.New("test")
+		service.SetEncoder(goa.JSONEncoderFactory(), true, "application/json")
+		req, _ := http.NewRequest(method, "/", nil)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rw = httptest.NewRecorder()
+		ctx := goa.NewContext(context.Background(), service, rw, req, nil)
+		Ω(goa.Response(ctx).SendETag(ctx, 200, map[string]int{"version": 1}, etag, false)).ShouldNot(HaveOccurred())
+	})
+
+	It("sets the ETag computed from the body", func() {
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("ETag")).Should(Equal(goa.ComputeETag(rw.Body.Bytes(), false)))
+		Ω(rw.Body.String()).Should(ContainSubstring(`"version":1`))
+	})
+
+	Context("with a matching If-None-Match header", func() {
+		BeforeEach(func() {
+			etag = `"v1"`
+			ifNoneMatch = `"v0", W/"v1"`
+		})
+
+		It("responds with 304", func() {
+			Ω(rw.Code).Should(Equal(304))
+			Ω(rw.Header().Get("ETag")).Should(Equal(`"v1"`))
+			Ω(rw.Body.Len()).Should(Equal(0))
+		})
+
+		Context("on an unsafe request", func() {
+			BeforeEach(func() {
+				method = "PUT"
+			})
+
+			It("responds with 412", func() {
+				Ω(rw.Code).Should(Equal(412))
+			})
+		})
+	})
+
+	Context("with a mismatching If-Match header", func() {
+		BeforeEach(func() {
+			etag = `"v2"`
+			ifMatch = `"v1"`
+		})
+
+		It("responds with 412", func() {
+			Ω(rw.Code).Should(Equal(412))
+		})
+	})
+
+	Context("with a wildcard If-Match header", func() {
+		BeforeEach(func() {
+			etag = `"v2"`
+			ifMatch = "*"
+		})
+
+		It("sends the body", func() {
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Body.Len()).ShouldNot(Equal(0))
+		})
+	})
+})
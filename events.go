@@ -0,0 +1,143 @@
+package goa
+
+import (
+	"bytes"
+	"fmt"
+)
+
+type (
+	// EventBroker is the interface implemented by the message brokers events are published on.
+	// goa provides adapters for Kafka and NATS, see KafkaBroker and NATSBroker.
+	EventBroker interface {
+		// Publish publishes a message with the given key and body on the given topic. key
+		// may be nil.
+		Publish(topic string, key, body []byte) error
+		// Subscribe subscribes to the given topic, handler is called for each message
+		// published on the topic.
+		Subscribe(topic string, handler EventHandler) error
+	}
+
+	// EventHandler handles the messages received on a topic.
+	EventHandler func(topic string, key, body []byte) error
+
+	// EventBus publishes and subscribes to the events defined in the design. It encodes and
+	// decodes the event payloads with the encoders and decoders registered on the service.
+	// The code generated by "goagen events" implements the typed event publishers and
+	// subscribers on top of it.
+	EventBus struct {
+		// Service is the service whose encoders and decoders are used to serialize events.
+		Service *Service
+		// Broker is the message broker events are published on.
+		Broker EventBroker
+		// ContentType is the content type of the event payloads, "application/json" if
+		// empty.
+		ContentType string
+	}
+
+	// KafkaProducer is the interface implemented by Kafka producers. Applications typically
+	// implement it with a thin adapter around the Kafka client library of their choice.
+	KafkaProducer interface {
+		// SendMessage sends a message with the given key and value to the given topic.
+		SendMessage(topic string, key, value []byte) error
+	}
+
+	// KafkaConsumer is the interface implemented by Kafka consumers.
+	KafkaConsumer interface {
+		// Consume calls handler for each message sent to the given topic. The message
+		// offset should only be committed once handler returns successfully.
+		Consume(topic string, handler func(key, value []byte) error) error
+	}
+
+	// KafkaBroker is the EventBroker adapter for Kafka. Events are sent to the Kafka topic
+	// with the same name using the event key as message key.
+	KafkaBroker struct {
+		// Producer is used to publish events.
+		Producer KafkaProducer
+		// Consumer is used to subscribe to events, it may be nil for services that only
+		// publish events.
+		Consumer KafkaConsumer
+	}
+
+	// NATSConn is the interface implemented by NATS connections. Applications typically
+	// implement it with a thin adapter around the NATS client.
+	NATSConn interface {
+		// Publish publishes data on the given subject.
+		Publish(subject string, data []byte) error
+		// Subscribe calls handler for each message published on the given subject.
+		Subscribe(subject string, handler func(subject string, data []byte)) error
+	}
+
+	// NATSBroker is the EventBroker adapter for NATS. Events are published on the NATS subject
+	// with the same name as the topic, NATS has no notion of keys so event keys are not sent.
+	NATSBroker struct {
+		// Conn is the NATS connection.
+		Conn NATSConn
+	}
+)
+
+// NewEventBus returns an event bus that publishes events on the given broker using the encoders
+// and decoders of the given service.
+func NewEventBus(service *Service, broker EventBroker) *EventBus {
+	return &EventBus{Service: service, Broker: broker}
+}
+
+// Publish encodes the given payload with the service encoders and publishes it on the given
+// topic. key is the message key, it may be empty.
+func (b *EventBus) Publish(topic, key string, payload interface{}) error {
+	var buf bytes.Buffer
+	if err := b.Service.Encode(payload, &buf, b.ContentType); err != nil {
+		return fmt.Errorf("failed to encode event published on %s: %s", topic, err)
+	}
+	var k []byte
+	if key != "" {
+		k = []byte(key)
+	}
+	return b.Broker.Publish(topic, k, buf.Bytes())
+}
+
+// Subscribe subscribes to the given topic. newPayload returns the value the message bodies are
+// decoded into before being given to handler. The errors returned by handler are logged and
+// returned to the broker.
+func (b *EventBus) Subscribe(topic string, newPayload func() interface{}, handler func(interface{}) error) error {
+	return b.Broker.Subscribe(topic, func(topic string, key, body []byte) error {
+		payload := newPayload()
+		err := b.Service.Decode(payload, bytes.NewReader(body), b.ContentType)
+		if err != nil {
+			err = fmt.Errorf("failed to decode event received on %s: %s", topic, err)
+		} else {
+			err = handler(payload)
+		}
+		if err != nil {
+			b.Service.LogError("event", KV{"topic", topic}, KV{"error", err.Error()})
+		}
+		return err
+	})
+}
+
+// Publish sends the message to the Kafka topic.
+func (k *KafkaBroker) Publish(topic string, key, body []byte) error {
+	return k.Producer.SendMessage(topic, key, body)
+}
+
+// Subscribe consumes the messages sent to the Kafka topic.
+func (k *KafkaBroker) Subscribe(topic string, handler EventHandler) error {
+	if k.Consumer == nil {
+		return fmt.Errorf("cannot subscribe to %s: no Kafka consumer", topic)
+	}
+	return k.Consumer.Consume(topic, func(key, value []byte) error {
+		return handler(topic, key, value)
+	})
+}
+
+// Publish publishes the message body on the NATS subject, the key is ignored.
+func (n *NATSBroker) Publish(topic string, key, body []byte) error {
+	return n.Conn.Publish(topic, body)
+}
+
+// Subscribe subscribes to the NATS subject. NATS does not support acknowledging core messages so
+// the errors returned by handler are only logged by the event bus.
+func (n *NATSBroker) Subscribe(topic string, handler EventHandler) error {
+	return n.Conn.Subscribe(topic, func(subject string, data []byte) {
+		handler(subject, nil, data)
+	})
+}
@@ -0,0 +1,107 @@
+package goa_test
+
+import (
+	"fmt"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type kafkaMessage struct {
+	topic string
+	key   string
+	value string
+}
+
+type fakeKafka struct {
+	sent     []kafkaMessage
+	handlers map[string]func(key, value []byte) error
+}
+
+func (k *fakeKafka) SendMessage(topic string, key, value []byte) error {
+	k.sent = append(k.sent, kafkaMessage{topic, string(key), string(value)})
+	if h, ok := k.handlers[topic]; ok {
+		return h(key, value)
+	}
+	return nil
+}
+
+func (k *fakeKafka) Consume(topic string, handler func(key, value []byte) error) error {
+	k.handlers[topic] = handler
+	return nil
+}
+
+type fakeNATS struct {
+	published map[string]string
+}
+
+func (n *fakeNATS) Publish(subject string, data []byte) error {
+	n.published[subject] = string(data)
+	return nil
+}
+
+func (n *fakeNATS) Subscribe(subject string, handler func(subject string, data []byte)) error {
+	return nil
+}
+
+type orderPlaced struct {
+	OrderID string  `json:"orderID"`
+	Total   float64 `json:"total"`
+}
+
+var _ = Describe("EventBus", func() {
+	var service *goa.Service
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.SetEncoder(goa.JSONEncoderFactory(), true, "application/json")
+		service.SetDecoder(goa.JSONDecoderFactory(), true, "application/json")
+	})
+
+	Context("with Kafka", func() {
+		var kafka *fakeKafka
+		var bus *goa.EventBus
+
+		BeforeEach(func() {
+			kafka = &fakeKafka{handlers: make(map[string]func(key, value []byte) error)}
+			bus = goa.NewEventBus(service, &goa.KafkaBroker{Producer: kafka, Consumer: kafka})
+		})
+
+		It("encodes and publishes events with their keys", func() {
+			err := bus.Publish("orders.placed", "o1", &orderPlaced{OrderID: "o1", Total: 42})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(kafka.sent).Should(Equal([]kafkaMessage{{"orders.placed", "o1", "{\"orderID\":\"o1\",\"total\":42}\n"}}))
+		})
+
+		It("decodes the events given to subscribers", func() {
+			var received *orderPlaced
+			newPayload := func() interface{} { return new(orderPlaced) }
+			handler := func(p interface{}) error {
+				received = p.(*orderPlaced)
+				return nil
+			}
+			Ω(bus.Subscribe("orders.placed", newPayload, handler)).ShouldNot(HaveOccurred())
+			Ω(bus.Publish("orders.placed", "o1", &orderPlaced{OrderID: "o1", Total: 42})).ShouldNot(HaveOccurred())
+			Ω(received).Should(Equal(&orderPlaced{OrderID: "o1", Total: 42}))
+		})
+
+		It("returns the subscriber errors to the broker", func() {
+			newPayload := func() interface{} { return new(orderPlaced) }
+			handler := func(interface{}) error { return fmt.Errorf("boom") }
+			Ω(bus.Subscribe("orders.placed", newPayload, handler)).ShouldNot(HaveOccurred())
+			err := bus.Publish("orders.placed", "o1", &orderPlaced{OrderID: "o1"})
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(Equal("boom"))
+		})
+	})
+
+	Context("with NATS", func() {
+		It("publishes events on the subject named after the topic", func() {
+			nats := &fakeNATS{published: make(map[string]string)}
+			bus := goa.NewEventBus(service, &goa.NATSBroker{Conn: nats})
+			Ω(bus.Publish("orders.placed", "o1", &orderPlaced{OrderID: "o1"})).ShouldNot(HaveOccurred())
+			Ω(nats.published).Should(HaveKeyWithValue("orders.placed", "{\"orderID\":\"o1\",\"total\":0}\n"))
+		})
+	})
+})
@@ -0,0 +1,100 @@
+// Package codegentest provides the design and the helpers shared by the tests of the generators
+// that bridge other transports to the controllers.
+package codegentest
+
+import (
+	"go/parser"
+	"go/token"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+// CellarAPI returns the design of an API named "cellar" with a "bottle" resource. The resource
+// has a "show" action that takes an "id" parameter and returns a bottle, a "list" action that
+// returns a bottle collection and a "create" action that takes a CreateBottlePayload payload.
+func CellarAPI() *design.APIDefinition {
+	bottle := &design.MediaTypeDefinition{
+		UserTypeDefinition: &design.UserTypeDefinition{
+			AttributeDefinition: &design.AttributeDefinition{
+				Type: design.Object{
+					"id":     &design.AttributeDefinition{Type: design.Integer},
+					"name":   &design.AttributeDefinition{Type: design.String},
+					"rating": &design.AttributeDefinition{Type: design.Number},
+					"tags":   &design.AttributeDefinition{Type: &design.Array{ElemType: &design.AttributeDefinition{Type: design.String}}},
+				},
+				Validation: &dslengine.ValidationDefinition{Required: []string{"id"}},
+			},
+			TypeName: "Bottle",
+		},
+		Identifier: "application/vnd.bottle+json",
+	}
+	bottles := &design.MediaTypeDefinition{
+		UserTypeDefinition: &design.UserTypeDefinition{
+			AttributeDefinition: &design.AttributeDefinition{
+				Type: &design.Array{ElemType: &design.AttributeDefinition{Type: bottle}},
+			},
+			TypeName: "BottleCollection",
+		},
+		Identifier: "application/vnd.bottle+json; type=collection",
+	}
+	payload := &design.UserTypeDefinition{
+		AttributeDefinition: &design.AttributeDefinition{
+			Type: design.Object{
+				"name":     &design.AttributeDefinition{Type: design.String},
+				"metadata": &design.AttributeDefinition{Type: &design.Hash{KeyType: &design.AttributeDefinition{Type: design.String}, ElemType: &design.AttributeDefinition{Type: design.String}}},
+			},
+			Validation: &dslengine.ValidationDefinition{Required: []string{"name"}},
+		},
+		TypeName: "CreateBottlePayload",
+	}
+	res := &design.ResourceDefinition{Name: "bottle", BasePath: "/bottles", MediaType: bottle.Identifier}
+	show := &design.ActionDefinition{
+		Name:        "show",
+		Description: "Retrieve bottle with given id",
+		Parent:      res,
+		Params: &design.AttributeDefinition{
+			Type: design.Object{
+				"id": &design.AttributeDefinition{Type: design.Integer},
+			},
+			Validation: &dslengine.ValidationDefinition{Required: []string{"id"}},
+		},
+		Responses: map[string]*design.ResponseDefinition{
+			"OK": {Name: "OK", Status: 200, MediaType: bottle.Identifier},
+		},
+	}
+	show.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "/:id", Parent: show}}
+	list := &design.ActionDefinition{
+		Name:   "list",
+		Parent: res,
+		Responses: map[string]*design.ResponseDefinition{
+			"OK": {Name: "OK", Status: 200, MediaType: bottles.Identifier},
+		},
+	}
+	list.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "", Parent: list}}
+	create := &design.ActionDefinition{
+		Name:    "create",
+		Parent:  res,
+		Payload: payload,
+		Responses: map[string]*design.ResponseDefinition{
+			"Created": {Name: "Created", Status: 201},
+		},
+	}
+	create.Routes = []*design.RouteDefinition{{Verb: "POST", Path: "", Parent: create}}
+	res.Actions = map[string]*design.ActionDefinition{"show": show, "list": list, "create": create}
+	return &design.APIDefinition{
+		APIVersionDefinition: &design.APIVersionDefinition{Name: "cellar", Host: "cellar.example.com"},
+		Resources:            map[string]*design.ResourceDefinition{"bottle": res},
+		MediaTypes: map[string]*design.MediaTypeDefinition{
+			bottle.Identifier:  bottle,
+			bottles.Identifier: bottles,
+		},
+	}
+}
+
+// ParseDir parses the Go source files of the given directory and returns the first syntax error
+// if any.
+func ParseDir(dir string) error {
+	_, err := parser.ParseDir(token.NewFileSet(), dir, nil, parser.AllErrors)
+	return err
+}
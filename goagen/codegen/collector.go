@@ -0,0 +1,114 @@
+package codegen
+
+import (
+	"sort"
+
+	"github.com/goadesign/goa/design"
+)
+
+type (
+	// TypeCollector records the user types and media types used by the attributes exposed by
+	// the transport generators so that each type definition is generated once.
+	TypeCollector struct {
+		types map[string]*CollectedType
+	}
+
+	// CollectedType contains the data needed to generate the definition of a collected type.
+	CollectedType struct {
+		// Name is the Go type name.
+		Name string
+		// Description is the type description if any.
+		Description string
+		// Def is the Go type definition.
+		Def string
+	}
+
+	// byStatus makes it possible to sort responses by status code.
+	byStatus []*design.ResponseDefinition
+)
+
+// NewTypeCollector returns an empty type collector.
+func NewTypeCollector() *TypeCollector {
+	return &TypeCollector{types: make(map[string]*CollectedType)}
+}
+
+// Collect records the user types and media types used by the given attribute.
+func (c *TypeCollector) Collect(att *design.AttributeDefinition) {
+	switch actual := att.Type.(type) {
+	case *design.UserTypeDefinition:
+		c.CollectUserType(actual)
+	case *design.MediaTypeDefinition:
+		c.CollectUserType(actual.UserTypeDefinition)
+	case design.Object:
+		for _, a := range actual {
+			c.Collect(a)
+		}
+	case *design.Array:
+		c.Collect(actual.ElemType)
+	case *design.Hash:
+		c.Collect(actual.KeyType)
+		c.Collect(actual.ElemType)
+	}
+}
+
+// CollectUserType records the given user type and the types it uses.
+func (c *TypeCollector) CollectUserType(ut *design.UserTypeDefinition) {
+	name := Goify(ut.TypeName, true)
+	if _, ok := c.types[name]; ok {
+		return
+	}
+	t := &CollectedType{Name: name, Description: ut.Description}
+	c.types[name] = t
+	t.Def = GoTypeDef(ut.AttributeDefinition, false, "", 0, true)
+	c.Collect(ut.AttributeDefinition)
+}
+
+// Types returns the collected types sorted by name.
+func (c *TypeCollector) Types() []*CollectedType {
+	names := make([]string, 0, len(c.types))
+	for n := range c.types {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	types := make([]*CollectedType, len(names))
+	for i, n := range names {
+		types[i] = c.types[n]
+	}
+	return types
+}
+
+// SuccessResponses returns the success responses of the given action ordered by status code and
+// name.
+func SuccessResponses(a *design.ActionDefinition) []*design.ResponseDefinition {
+	var responses []*design.ResponseDefinition
+	for _, r := range a.Responses {
+		if r.Status >= 200 && r.Status < 300 {
+			responses = append(responses, r)
+		}
+	}
+	sort.Sort(byStatus(responses))
+	return responses
+}
+
+// ResultMediaType returns the media type of the first success response (ordered by status code)
+// of the given action that has one, nil if none.
+func ResultMediaType(a *design.ActionDefinition) *design.MediaTypeDefinition {
+	for _, r := range SuccessResponses(a) {
+		if r.MediaType == "" {
+			continue
+		}
+		if mt := design.Design.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+			return mt
+		}
+	}
+	return nil
+}
+
+func (b byStatus) Len() int      { return len(b) }
+func (b byStatus) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byStatus) Less(i, j int) bool {
+	if b[i].Status == b[j].Status {
+		return b[i].Name < b[j].Name
+	}
+	return b[i].Status < b[j].Status
+}
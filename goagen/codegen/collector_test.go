@@ -0,0 +1,61 @@
+package codegen_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TypeCollector", func() {
+	var bottle *MediaTypeDefinition
+	var action *ActionDefinition
+
+	BeforeEach(func() {
+		InitDesign()
+		dslengine.Errors = nil
+		winery := Type("winery", func() {
+			Attribute("name", String)
+		})
+		bottle = MediaType("application/vnd.bottle+json", func() {
+			Attributes(func() {
+				Attribute("id", Integer)
+				Attribute("winery", winery)
+				Attribute("tags", HashOf(String, winery))
+			})
+			View("default", func() {
+				Attribute("id")
+			})
+		})
+		Resource("bottle", func() {
+			Action("show", func() {
+				Routing(GET("/:id"))
+				Response(Accepted)
+				Response(OK, func() {
+					Media(bottle)
+				})
+			})
+		})
+		Ω(dslengine.Run()).ShouldNot(HaveOccurred())
+		action = Design.Resources["bottle"].Actions["show"]
+	})
+
+	It("collects the types used by the attributes once, sorted by name", func() {
+		c := codegen.NewTypeCollector()
+		c.Collect(&AttributeDefinition{Type: bottle})
+		c.Collect(&AttributeDefinition{Type: Object{"bottle": {Type: bottle}}})
+		types := c.Types()
+		Ω(types).Should(HaveLen(2))
+		Ω(types[0].Name).Should(Equal("Bottle"))
+		Ω(types[1].Name).Should(Equal("Winery"))
+		Ω(types[1].Def).Should(ContainSubstring("Name *string"))
+	})
+
+	It("returns the media type of the first success response", func() {
+		Ω(codegen.SuccessResponses(action)).Should(HaveLen(2))
+		Ω(codegen.SuccessResponses(action)[0].Status).Should(Equal(200))
+		Ω(codegen.ResultMediaType(action).Identifier).Should(Equal("application/vnd.bottle+json"))
+	})
+})
@@ -3,7 +3,9 @@ package codegen
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/goadesign/goa/design"
 	"github.com/spf13/pflag"
 )
 
@@ -12,7 +14,8 @@ var (
 	// written to.
 	OutputDir string
 
-	// DesignPackagePath is the path to the user Go design package.
+	// DesignPackagePath is the path to the user Go design package. Designs split across
+	// multiple packages list the package paths separated with commas, see DesignPackagePaths.
 	DesignPackagePath string
 
 	// Debug toggles debug mode.
@@ -25,6 +28,21 @@ var (
 	// NoFormat causes "goimports" to be skipped when true.
 	NoFormat bool
 
+	// NoCommandLine causes the goagen command line to be omitted from the generated file headers
+	// so that the output does not depend on the flags or paths used to invoke the tool.
+	NoCommandLine bool
+
+	// DryRun causes the generators to report the files they would create, overwrite or delete
+	// instead of writing to the output directory.
+	DryRun bool
+
+	// ShowDiff causes the dry-run report to include a unified diff of the files that would be
+	// overwritten.
+	ShowDiff bool
+
+	// Strict causes the DSL warnings to be treated as errors.
+	Strict bool
+
 	// CommandName is the name of the command being run.
 	CommandName string
 
@@ -69,10 +87,30 @@ func RegisterFlags(r FlagRegistry) {
 		os.Exit(1)
 	}
 	r.Flags().StringVarP(&OutputDir, "out", "o", cwd, "output directory")
-	r.Flags().StringVarP(&DesignPackagePath, "design", "d", "", "design package path")
+	r.Flags().StringVarP(&DesignPackagePath, "design", "d", "", "design package path, separate the paths of designs split across multiple packages with commas")
 	r.Flags().BoolVar(&Debug, "debug", false, "enable debug mode, does not cleanup temporary files.")
 	r.Flags().BoolVar(&NoFormat, "noformat", false, "disable goimports, useful to goa developers for debugging.")
 	r.Flags().MarkHidden("noformat")
+	r.Flags().BoolVar(&NoCommandLine, "no-cmdline", false, "omit the goagen command line from the generated file headers")
+	r.Flags().BoolVar(&DryRun, "dry-run", false, "print the files that would be created, overwritten or deleted without writing them")
+	r.Flags().BoolVar(&ShowDiff, "show-diff", false, "with --dry-run, also print a unified diff of the files that would be overwritten")
+	r.Flags().BoolVar(&Strict, "strict", false, "fail on design warnings instead of only printing them")
+	r.Flags().BoolVar(&design.ValueDefaults, "value-defaults", false, "generate value rather than pointer struct fields for optional primitive attributes with a default value")
+	registerNamingFlags(r)
+	registerTemplatesFlags(r)
+	registerFilterFlags(r)
+}
+
+// DesignPackagePaths returns the import paths of the design packages listed in
+// DesignPackagePath.
+func DesignPackagePaths() []string {
+	var paths []string
+	for _, p := range strings.Split(DesignPackagePath, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
 }
 
 // BaseCommand provides the basic logic for all commands. It implements
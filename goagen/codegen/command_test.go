@@ -0,0 +1,42 @@
+package codegen_test
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DesignPackagePaths", func() {
+	var path string
+	var paths []string
+
+	JustBeforeEach(func() {
+		prev := codegen.DesignPackagePath
+		codegen.DesignPackagePath = path
+		paths = codegen.DesignPackagePaths()
+		codegen.DesignPackagePath = prev
+	})
+
+	Context("with a single package", func() {
+		BeforeEach(func() {
+			path = "github.com/goadesign/cellar/design"
+		})
+
+		It("returns the package path", func() {
+			Ω(paths).Should(Equal([]string{"github.com/goadesign/cellar/design"}))
+		})
+	})
+
+	Context("with a design split across multiple packages", func() {
+		BeforeEach(func() {
+			path = "github.com/goadesign/cellar/design, github.com/goadesign/cellar/design/accounts,"
+		})
+
+		It("returns all the package paths", func() {
+			Ω(paths).Should(Equal([]string{
+				"github.com/goadesign/cellar/design",
+				"github.com/goadesign/cellar/design/accounts",
+			}))
+		})
+	})
+})
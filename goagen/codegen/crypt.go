@@ -0,0 +1,94 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/goadesign/goa/design"
+)
+
+// GoTypeCrypt produces the Go code of the EncryptFields and DecryptFields methods of the type
+// generated for ut. The methods replace the values of the fields marked with the Encrypted DSL
+// with their ciphertext, respectively their plaintext, computed by the Crypter of the service
+// handling the request, the fields of the nested objects, user types and media types included.
+// recv is the name of the methods receiver. The function returns the empty string if ut has no
+// encrypted field.
+func GoTypeCrypt(ut *design.UserTypeDefinition, recv string) string {
+	if !ut.HasEncryptedFields() || !(ut.IsObject() || ut.IsArray()) {
+		return ""
+	}
+	var buf bytes.Buffer
+	ref := GoTypeRef(ut, nil, 0)
+	for i, op := range []string{"Encrypt", "Decrypt"} {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		switch op {
+		case "Encrypt":
+			fmt.Fprintf(&buf, "// EncryptFields replaces the values of the encrypted fields of %s with their ciphertext.\n", recv)
+		default:
+			fmt.Fprintf(&buf, "// DecryptFields replaces the values of the encrypted fields of %s with their plaintext.\n", recv)
+		}
+		fmt.Fprintf(&buf, "func (%s %s) %sFields(ctx context.Context) error {\n", recv, ref, op)
+		switch {
+		case ut.IsObject():
+			fmt.Fprintf(&buf, "\tif %s == nil {\n\t\treturn nil\n\t}\n", recv)
+			buf.WriteString(cryptObject(ut.AttributeDefinition, recv, op, 1))
+		default:
+			fmt.Fprintf(&buf, "\tfor _, e := range %s {\n", recv)
+			buf.WriteString(cryptValue(ut.ToArray().ElemType, "e", op, 2))
+			buf.WriteString("\t}\n")
+		}
+		buf.WriteString("\treturn nil\n}\n")
+	}
+	return buf.String()
+}
+
+// cryptObject produces the code that encrypts or decrypts the encrypted fields of the object held
+// by target.
+func cryptObject(att *design.AttributeDefinition, target, op string, depth int) string {
+	var buf bytes.Buffer
+	obj := att.Type.ToObject()
+	tabs := Tabs(depth)
+	for _, n := range sortedKeys(obj) {
+		field := obj[n]
+		ftarget := target + "." + GoFieldName(field, n)
+		if field.IsEncrypted() {
+			val, assign := ftarget, ftarget+" = v"
+			if att.IsPrimitivePointer(n) {
+				fmt.Fprintf(&buf, "%sif %s != nil {\n", tabs, ftarget)
+				val, assign = "*"+ftarget, ftarget+" = &v"
+			} else {
+				fmt.Fprintf(&buf, "%sif %s != \"\" {\n", tabs, ftarget)
+			}
+			fmt.Fprintf(&buf, "%s\tv, err := goa.%sField(ctx, %q, %s)\n", tabs, op, n, val)
+			fmt.Fprintf(&buf, "%s\tif err != nil {\n%s\t\treturn err\n%s\t}\n", tabs, tabs, tabs)
+			fmt.Fprintf(&buf, "%s\t%s\n%s}\n", tabs, assign, tabs)
+			continue
+		}
+		buf.WriteString(cryptValue(field, ftarget, op, depth))
+	}
+	return buf.String()
+}
+
+// cryptValue produces the code that encrypts or decrypts the encrypted fields of the value held
+// by target. It returns the empty string if the value has no encrypted field.
+func cryptValue(att *design.AttributeDefinition, target, op string, depth int) string {
+	if !att.HasEncryptedFields() {
+		return ""
+	}
+	tabs := Tabs(depth)
+	switch actual := att.Type.(type) {
+	case *design.UserTypeDefinition, *design.MediaTypeDefinition:
+		return fmt.Sprintf("%sif err := %s.%sFields(ctx); err != nil {\n%s\treturn err\n%s}\n",
+			tabs, target, op, tabs, tabs)
+	case design.Object:
+		return fmt.Sprintf("%sif %s != nil {\n%s%s}\n",
+			tabs, target, cryptObject(att, target, op, depth+1), tabs)
+	case *design.Array:
+		e := fmt.Sprintf("e%d", depth)
+		return fmt.Sprintf("%sfor _, %s := range %s {\n%s%s}\n",
+			tabs, e, target, cryptValue(actual.ElemType, e, op, depth+1), tabs)
+	}
+	return ""
+}
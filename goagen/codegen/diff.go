@@ -0,0 +1,135 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change in a diff hunk.
+const diffContext = 3
+
+// diffLine is a single line of a diff: kind is ' ' for unchanged lines, '-' for removed lines and
+// '+' for added lines. from and to are the indices of the line in the original and new content.
+type diffLine struct {
+	kind     byte
+	text     string
+	from, to int
+}
+
+// UnifiedDiff returns the differences between the from and to contents in the unified diff format.
+// fromName and toName are used in the diff header. UnifiedDiff returns an empty string if the
+// contents are identical.
+func UnifiedDiff(fromName, toName, from, to string) string {
+	lines := diffLines(splitLines(from), splitLines(to))
+	var buf bytes.Buffer
+	for k := 0; k < len(lines); {
+		for k < len(lines) && lines[k].kind == ' ' {
+			k++
+		}
+		if k == len(lines) {
+			break
+		}
+		if buf.Len() == 0 {
+			fmt.Fprintf(&buf, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		start := k - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := k
+		for {
+			for end < len(lines) && lines[end].kind != ' ' {
+				end++
+			}
+			next := end
+			for next < len(lines) && lines[next].kind == ' ' {
+				next++
+			}
+			if next < len(lines) && next-end <= 2*diffContext {
+				end = next
+				continue
+			}
+			end += diffContext
+			if end > len(lines) {
+				end = len(lines)
+			}
+			break
+		}
+		writeHunk(&buf, lines[start:end])
+		k = end
+	}
+	return buf.String()
+}
+
+// writeHunk writes the given diff lines as a single unified diff hunk.
+func writeHunk(buf *bytes.Buffer, lines []diffLine) {
+	var fromCount, toCount int
+	for _, l := range lines {
+		if l.kind != '+' {
+			fromCount++
+		}
+		if l.kind != '-' {
+			toCount++
+		}
+	}
+	fromStart, toStart := lines[0].from, lines[0].to
+	if fromCount > 0 {
+		fromStart++
+	}
+	if toCount > 0 {
+		toStart++
+	}
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", fromStart, fromCount, toStart, toCount)
+	for _, l := range lines {
+		buf.WriteByte(l.kind)
+		buf.WriteString(l.text)
+		buf.WriteByte('\n')
+	}
+}
+
+// diffLines computes the shortest edit script turning a into b using the longest common
+// subsequence of lines.
+func diffLines(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var lines []diffLine
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i], i, j})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i], i, j})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j], i, j})
+			j++
+		}
+	}
+	return lines
+}
+
+// splitLines splits content into lines ignoring the trailing newline.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
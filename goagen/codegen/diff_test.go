@@ -0,0 +1,49 @@
+package codegen_test
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UnifiedDiff", func() {
+	var from, to string
+	var diff string
+
+	JustBeforeEach(func() {
+		diff = codegen.UnifiedDiff("a.go", "a.go.new", from, to)
+	})
+
+	Context("with identical contents", func() {
+		BeforeEach(func() {
+			from = "package main\n\nfunc main() {}\n"
+			to = from
+		})
+
+		It("returns an empty diff", func() {
+			Ω(diff).Should(BeEmpty())
+		})
+	})
+
+	Context("with a changed line", func() {
+		BeforeEach(func() {
+			from = "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+			to = "1\n2\n3\n4\n5\nsix\n7\n8\n9\n10\n"
+		})
+
+		It("returns a hunk with context", func() {
+			Ω(diff).Should(Equal("--- a.go\n+++ a.go.new\n@@ -3,7 +3,7 @@\n 3\n 4\n 5\n-6\n+six\n 7\n 8\n 9\n"))
+		})
+	})
+
+	Context("with changes far apart", func() {
+		BeforeEach(func() {
+			from = "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+			to = "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\neleven\n"
+		})
+
+		It("returns separate hunks", func() {
+			Ω(diff).Should(Equal("--- a.go\n+++ a.go.new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -8,3 +8,4 @@\n 8\n 9\n 10\n+eleven\n"))
+		})
+	})
+})
@@ -0,0 +1,100 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+)
+
+var (
+	// SelectedResources lists the names of the resources the generators should produce code
+	// for. All resources are selected if empty.
+	SelectedResources []string
+
+	// SelectedVersion is the API version the generators should produce code for. All versions
+	// are selected if empty.
+	SelectedVersion string
+)
+
+// Filtered returns true if the command line restricts generation to a subset of the design.
+func Filtered() bool {
+	return len(SelectedResources) > 0 || SelectedVersion != ""
+}
+
+// ResourceSelected returns true if code should be generated for the given resource, that is if
+// the resource is listed with --resource (or no resource is listed) and it is exposed by the
+// version given with --version (or no version is given).
+func ResourceSelected(r *design.ResourceDefinition) bool {
+	if SelectedVersion != "" && !r.SupportsVersion(SelectedVersion) {
+		return false
+	}
+	if len(SelectedResources) == 0 {
+		return true
+	}
+	for _, n := range SelectedResources {
+		if n == r.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// VersionSelected returns true if code should be generated for the given API version, that is
+// if it is the version given with --version (or no version is given) and it exposes at least one
+// of the resources listed with --resource (or no resource is listed).
+func VersionSelected(v *design.APIVersionDefinition) bool {
+	if SelectedVersion != "" && v.Version != SelectedVersion {
+		return false
+	}
+	if len(SelectedResources) == 0 {
+		return true
+	}
+	selected := false
+	v.IterateResources(func(r *design.ResourceDefinition) error {
+		if ResourceSelected(r) {
+			selected = true
+		}
+		return nil
+	})
+	return selected
+}
+
+// CheckFilters returns an error if the resources or version given on the command line are not
+// defined by the API design.
+func CheckFilters(api *design.APIDefinition) error {
+	if SelectedVersion != "" {
+		if _, ok := api.APIVersions[SelectedVersion]; !ok {
+			return fmt.Errorf("unknown API version %#v", SelectedVersion)
+		}
+	}
+	var unknown []string
+	for _, n := range SelectedResources {
+		if _, ok := api.Resources[n]; !ok {
+			unknown = append(unknown, n)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown resource(s) %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// FilterArgs returns the command line flags that reproduce the current resource and version
+// filters. The meta generator uses them to forward the filters to the generator tool.
+func FilterArgs() []string {
+	var args []string
+	if len(SelectedResources) > 0 {
+		args = append(args, fmt.Sprintf("--resource=%s", strings.Join(SelectedResources, ",")))
+	}
+	if SelectedVersion != "" {
+		args = append(args, fmt.Sprintf("--version=%s", SelectedVersion))
+	}
+	return args
+}
+
+// registerFilterFlags registers the flags that restrict generation to parts of the design.
+func registerFilterFlags(r FlagRegistry) {
+	r.Flags().StringSliceVar(&SelectedResources, "resource", nil, "comma separated list of the names of the resources to generate code for, default is all resources")
+	r.Flags().StringVar(&SelectedVersion, "version", "", "API version to generate code for, default is all versions")
+}
@@ -0,0 +1,93 @@
+package codegen_test
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("generation filters", func() {
+	var api *design.APIDefinition
+	var bottle, account *design.ResourceDefinition
+	var design0 *design.APIDefinition
+
+	BeforeEach(func() {
+		design0 = design.Design
+		bottle = &design.ResourceDefinition{Name: "bottle", APIVersions: []string{"v1"}}
+		account = &design.ResourceDefinition{Name: "account"}
+		api = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "test"},
+			APIVersions: map[string]*design.APIVersionDefinition{
+				"v1": {Name: "test", Version: "v1"},
+			},
+			Resources: map[string]*design.ResourceDefinition{
+				"bottle":  bottle,
+				"account": account,
+			},
+		}
+		design.Design = api
+	})
+
+	AfterEach(func() {
+		design.Design = design0
+		codegen.SelectedResources = nil
+		codegen.SelectedVersion = ""
+	})
+
+	Context("with no filter", func() {
+		It("selects everything", func() {
+			Ω(codegen.Filtered()).Should(BeFalse())
+			Ω(codegen.ResourceSelected(bottle)).Should(BeTrue())
+			Ω(codegen.ResourceSelected(account)).Should(BeTrue())
+			Ω(codegen.VersionSelected(api.APIVersionDefinition)).Should(BeTrue())
+			Ω(codegen.VersionSelected(api.APIVersions["v1"])).Should(BeTrue())
+			Ω(codegen.CheckFilters(api)).ShouldNot(HaveOccurred())
+			Ω(codegen.FilterArgs()).Should(BeEmpty())
+		})
+	})
+
+	Context("with a resource filter", func() {
+		BeforeEach(func() {
+			codegen.SelectedResources = []string{"bottle"}
+		})
+
+		It("selects the resource and the versions that expose it", func() {
+			Ω(codegen.Filtered()).Should(BeTrue())
+			Ω(codegen.ResourceSelected(bottle)).Should(BeTrue())
+			Ω(codegen.ResourceSelected(account)).Should(BeFalse())
+			Ω(codegen.VersionSelected(api.APIVersionDefinition)).Should(BeFalse())
+			Ω(codegen.VersionSelected(api.APIVersions["v1"])).Should(BeTrue())
+			Ω(codegen.FilterArgs()).Should(Equal([]string{"--resource=bottle"}))
+		})
+	})
+
+	Context("with a version filter", func() {
+		BeforeEach(func() {
+			codegen.SelectedVersion = "v1"
+		})
+
+		It("selects the version and the resources it exposes", func() {
+			Ω(codegen.ResourceSelected(bottle)).Should(BeTrue())
+			Ω(codegen.ResourceSelected(account)).Should(BeFalse())
+			Ω(codegen.VersionSelected(api.APIVersionDefinition)).Should(BeFalse())
+			Ω(codegen.VersionSelected(api.APIVersions["v1"])).Should(BeTrue())
+			Ω(codegen.FilterArgs()).Should(Equal([]string{"--version=v1"}))
+		})
+	})
+
+	Context("with unknown names", func() {
+		BeforeEach(func() {
+			codegen.SelectedResources = []string{"bottle", "cellar"}
+		})
+
+		It("fails", func() {
+			err := codegen.CheckFilters(api)
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("cellar"))
+			codegen.SelectedResources = nil
+			codegen.SelectedVersion = "v2"
+			Ω(codegen.CheckFilters(api)).Should(HaveOccurred())
+		})
+	})
+})
@@ -120,7 +120,7 @@ func VersionPackage(version string) string {
 		}
 	}
 	if digitPrefixRegex.MatchString(version) {
-		version = "v" + version
+		version = VersionPrefix + version
 	}
 	return Goify(version, false)
 }
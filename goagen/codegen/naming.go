@@ -0,0 +1,88 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Default values of the naming options.
+const (
+	DefaultContextSuffix    = "Context"
+	DefaultControllerSuffix = "Controller"
+	DefaultVersionPrefix    = "v"
+)
+
+var (
+	// ContextSuffix is appended to the action and resource names to build the names of the
+	// generated action context data structures, e.g. "ShowBottleContext".
+	ContextSuffix = DefaultContextSuffix
+
+	// ControllerSuffix is appended to the resource names to build the names of the generated
+	// controller interfaces and mount functions, e.g. "BottleController".
+	ControllerSuffix = DefaultControllerSuffix
+
+	// VersionPrefix is prepended to API versions that start with a digit to build the names
+	// of the version packages, e.g. "v1".
+	VersionPrefix = DefaultVersionPrefix
+
+	// Initialisms lists additional initialisms that Goify keeps in upper case, e.g. "SKU".
+	Initialisms []string
+)
+
+// ContextName returns the name of the context data structure generated for the given action
+// of the given resource.
+func ContextName(action, resource string) string {
+	return ActionTypeName(action, resource, ContextSuffix)
+}
+
+// ActionTypeName returns the name of a type generated for the given action of the given resource,
+// e.g. "ShowBottleReceiver" for the suffix "Receiver". The context suffix does not apply.
+func ActionTypeName(action, resource, suffix string) string {
+	return Goify(action, true) + Goify(resource, true) + suffix
+}
+
+// ControllerName returns the name of the controller interface generated for the given resource.
+func ControllerName(resource string) string {
+	return Goify(resource, true) + ControllerSuffix
+}
+
+// NamingArgs returns the command line flags that reproduce the current naming options. The meta
+// generator uses them to forward the options to the generator tool.
+func NamingArgs() []string {
+	var args []string
+	if ContextSuffix != DefaultContextSuffix {
+		args = append(args, fmt.Sprintf("--context-suffix=%s", ContextSuffix))
+	}
+	if ControllerSuffix != DefaultControllerSuffix {
+		args = append(args, fmt.Sprintf("--controller-suffix=%s", ControllerSuffix))
+	}
+	if VersionPrefix != DefaultVersionPrefix {
+		args = append(args, fmt.Sprintf("--version-prefix=%s", VersionPrefix))
+	}
+	if len(Initialisms) > 0 {
+		args = append(args, fmt.Sprintf("--initialisms=%s", strings.Join(Initialisms, ",")))
+	}
+	return args
+}
+
+// registerNamingFlags registers the flags that control the generated identifiers.
+func registerNamingFlags(r FlagRegistry) {
+	r.Flags().StringVar(&ContextSuffix, "context-suffix", DefaultContextSuffix, "suffix of generated action context type names")
+	r.Flags().StringVar(&ControllerSuffix, "controller-suffix", DefaultControllerSuffix, "suffix of generated controller interface names")
+	r.Flags().StringVar(&VersionPrefix, "version-prefix", DefaultVersionPrefix, "prefix of generated version package names for versions starting with a digit")
+	r.Flags().StringSliceVar(&Initialisms, "initialisms", nil, "comma separated list of additional initialisms kept upper case in generated identifiers, e.g. SKU,ISBN")
+}
+
+// isInitialism returns true if word is a common initialism or one of the initialisms given on
+// the command line.
+func isInitialism(word string) bool {
+	if commonInitialisms[word] {
+		return true
+	}
+	for _, i := range Initialisms {
+		if strings.ToUpper(i) == word {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package codegen_test
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("naming options", func() {
+	AfterEach(func() {
+		codegen.ContextSuffix = codegen.DefaultContextSuffix
+		codegen.ControllerSuffix = codegen.DefaultControllerSuffix
+		codegen.Initialisms = nil
+	})
+
+	Context("with the default options", func() {
+		It("produces the default names", func() {
+			Ω(codegen.ContextName("show", "bottle")).Should(Equal("ShowBottleContext"))
+			Ω(codegen.ControllerName("bottle")).Should(Equal("BottleController"))
+			Ω(codegen.NamingArgs()).Should(BeEmpty())
+		})
+	})
+
+	Context("with custom suffixes", func() {
+		BeforeEach(func() {
+			codegen.ContextSuffix = "Ctx"
+			codegen.ControllerSuffix = "API"
+		})
+
+		It("uses the suffixes", func() {
+			Ω(codegen.ContextName("show", "bottle")).Should(Equal("ShowBottleCtx"))
+			Ω(codegen.ControllerName("bottle")).Should(Equal("BottleAPI"))
+			Ω(codegen.ActionTypeName("show", "bottle", "Receiver")).Should(Equal("ShowBottleReceiver"))
+			Ω(codegen.NamingArgs()).Should(ConsistOf("--context-suffix=Ctx", "--controller-suffix=API"))
+		})
+	})
+
+	Context("with additional initialisms", func() {
+		BeforeEach(func() {
+			codegen.Initialisms = []string{"sku"}
+		})
+
+		It("keeps them upper case", func() {
+			Ω(codegen.Goify("bottle_sku", true)).Should(Equal("BottleSKU"))
+			Ω(codegen.NamingArgs()).Should(Equal([]string{"--initialisms=sku"}))
+		})
+	})
+})
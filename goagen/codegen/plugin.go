@@ -0,0 +1,141 @@
+package codegen
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/goadesign/goa/design"
+	"github.com/spf13/cobra"
+)
+
+type (
+	// Generator is the interface implemented by third party generators (plugins). Plugins
+	// register themselves with RegisterGenerator, typically from the init function of their
+	// package, and are run by "goagen gen --pkg=<plugin package path>".
+	Generator interface {
+		// Generate produces the artifacts for the given API definition and returns the paths
+		// to the generated files. The global flags such as OutputDir are initialized prior
+		// to the call.
+		Generate(api *design.APIDefinition) ([]string, error)
+	}
+
+	// GeneratorFlags is implemented by generators that accept command line flags. The flag
+	// values are given to goagen after "--", e.g. "goagen gen --pkg=<path> -- --myflag=val".
+	GeneratorFlags interface {
+		// RegisterFlags initializes the given registry with the generator flags.
+		RegisterFlags(r FlagRegistry)
+	}
+
+	// GeneratorCleaner is implemented by generators that need to remove the files they
+	// generated when a subsequent generator fails.
+	GeneratorCleaner interface {
+		// Cleanup removes the generated files.
+		Cleanup()
+	}
+
+	// GeneratorFunc is an adapter that makes it possible to use a function as a Generator.
+	GeneratorFunc func(api *design.APIDefinition) ([]string, error)
+)
+
+var (
+	generatorsMu sync.Mutex
+	generators   = make(map[string]Generator)
+)
+
+// Generate calls f(api).
+func (f GeneratorFunc) Generate(api *design.APIDefinition) ([]string, error) {
+	return f(api)
+}
+
+// RegisterGenerator registers the generator with the given name. RegisterGenerator panics if a
+// generator with the same name was already registered.
+func RegisterGenerator(name string, g Generator) {
+	generatorsMu.Lock()
+	defer generatorsMu.Unlock()
+	if g == nil {
+		panic("goagen: nil generator " + name)
+	}
+	if _, ok := generators[name]; ok {
+		panic("goagen: generator " + name + " registered twice")
+	}
+	generators[name] = g
+}
+
+// Generators returns the names of the registered generators sorted alphabetically.
+func Generators() []string {
+	generatorsMu.Lock()
+	defer generatorsMu.Unlock()
+	names := make([]string, 0, len(generators))
+	for n := range generators {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupGenerator returns the generator registered with the given name, nil if there is none.
+func LookupGenerator(name string) Generator {
+	generatorsMu.Lock()
+	defer generatorsMu.Unlock()
+	return generators[name]
+}
+
+// RunGenerators is the entry point called by the meta generator to run the registered generators.
+// It parses the command line flags and runs the generators in alphabetical order. It stops at the
+// first error in which case the generators that implement GeneratorCleaner get to cleanup.
+func RunGenerators(roots []interface{}) (files []string, err error) {
+	var api *design.APIDefinition
+	for _, r := range roots {
+		if a, ok := r.(*design.APIDefinition); ok {
+			api = a
+			break
+		}
+	}
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+	names := Generators()
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no generator registered, make sure the plugin package calls codegen.RegisterGenerator")
+	}
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "Plugins generator",
+		Long:  "third party generators runner",
+		Run: func(*cobra.Command, []string) {
+			files, err = runGenerators(api, names)
+		},
+	}
+	RegisterFlags(root)
+	for _, n := range names {
+		if f, ok := LookupGenerator(n).(GeneratorFlags); ok {
+			f.RegisterFlags(root)
+		}
+	}
+	if execErr := root.Execute(); execErr != nil {
+		return nil, execErr
+	}
+	return
+}
+
+// runGenerators runs the generators with the given names in order.
+func runGenerators(api *design.APIDefinition, names []string) ([]string, error) {
+	var files []string
+	var done []Generator
+	for _, n := range names {
+		g := LookupGenerator(n)
+		gen, err := g.Generate(api)
+		if err != nil {
+			for _, d := range append(done, g) {
+				if c, ok := d.(GeneratorCleaner); ok {
+					c.Cleanup()
+				}
+			}
+			return nil, fmt.Errorf("%s: %s", n, err)
+		}
+		done = append(done, g)
+		files = append(files, gen...)
+	}
+	return files, nil
+}
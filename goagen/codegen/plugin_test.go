@@ -0,0 +1,81 @@
+package codegen_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cleaner is a test generator that records cleanups.
+type cleaner struct {
+	cleaned bool
+}
+
+func (c *cleaner) Generate(api *design.APIDefinition) ([]string, error) {
+	return []string{"cleaner"}, nil
+}
+
+func (c *cleaner) Cleanup() {
+	c.cleaned = true
+}
+
+var _ = Describe("generator plugins", func() {
+	// The registry is global so the generators are registered once for all the tests.
+	var fail bool
+	var api *design.APIDefinition
+	var first *cleaner
+
+	BeforeEach(func() {
+		if first == nil {
+			first = new(cleaner)
+			codegen.RegisterGenerator("plugin-test-a", first)
+			codegen.RegisterGenerator("plugin-test-b", codegen.GeneratorFunc(func(a *design.APIDefinition) ([]string, error) {
+				if fail {
+					return nil, fmt.Errorf("kaboom")
+				}
+				api = a
+				return []string{"b"}, nil
+			}))
+		}
+		fail = false
+		first.cleaned = false
+		os.Args = []string{"codegen", "--out=" + os.TempDir(), "--design=foo"}
+	})
+
+	It("lists the registered generators", func() {
+		Ω(codegen.Generators()).Should(ContainElement("plugin-test-a"))
+		Ω(codegen.Generators()).Should(ContainElement("plugin-test-b"))
+		Ω(codegen.LookupGenerator("plugin-test-a")).Should(Equal(first))
+		Ω(codegen.LookupGenerator("unknown")).Should(BeNil())
+	})
+
+	It("panics when registering a generator twice", func() {
+		Ω(func() { codegen.RegisterGenerator("plugin-test-a", first) }).Should(Panic())
+	})
+
+	It("runs the generators in order", func() {
+		def := &design.APIDefinition{}
+		files, err := codegen.RunGenerators([]interface{}{def})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(files).Should(Equal([]string{"cleaner", "b"}))
+		Ω(api).Should(Equal(def))
+		Ω(codegen.OutputDir).Should(Equal(os.TempDir()))
+	})
+
+	It("cleans up when a generator fails", func() {
+		fail = true
+		files, err := codegen.RunGenerators([]interface{}{&design.APIDefinition{}})
+		Ω(err).Should(MatchError("plugin-test-b: kaboom"))
+		Ω(files).Should(BeEmpty())
+		Ω(first.cleaned).Should(BeTrue())
+	})
+
+	It("requires an API definition", func() {
+		_, err := codegen.RunGenerators([]interface{}{})
+		Ω(err).Should(HaveOccurred())
+	})
+})
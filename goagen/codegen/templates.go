@@ -0,0 +1,72 @@
+package codegen
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// TemplatesDir is the path to a directory containing user provided templates that override the
+// built-in templates. A file named after a template with the ".tmpl" extension overrides the
+// template with that name, e.g. "context.tmpl" overrides the template that renders the action
+// contexts. See TemplateNames for the list of overridable templates.
+var TemplatesDir string
+
+// TemplateNames lists the names of the built-in templates that may be overridden via TemplatesDir.
+var TemplateNames = []string{
+	// gen_app
+	"context", "new", "payload", "builder", "typeResponse", "mediaTypeResponse", "response",
+	"result", "controller", "mount", "unmarshal", "resource", "mediatype", "usertype", "types",
+	// gen_main
+	"scaffoldMain", "scaffoldController",
+	// gen_client
+	"clientMain", "registerCmds",
+	// gen_js
+	"module", "jsFuncs", "exampleHTML", "examples",
+	// gen_test and gen_mock
+	"test", "mock",
+}
+
+// TemplatesArgs returns the command line arguments that propagate the template overrides
+// directory to the generators spawned by the meta generator.
+func TemplatesArgs() []string {
+	if TemplatesDir == "" {
+		return nil
+	}
+	dir, err := filepath.Abs(TemplatesDir)
+	if err != nil {
+		dir = TemplatesDir
+	}
+	return []string{fmt.Sprintf("--templates=%s", dir)}
+}
+
+// registerTemplatesFlags registers the flag that sets TemplatesDir.
+func registerTemplatesFlags(r FlagRegistry) {
+	r.Flags().StringVar(&TemplatesDir, "templates", "", "directory containing templates that override the built-in templates, one <name>.tmpl file per template")
+}
+
+// parseTemplate parses the template with the given name. It uses the user provided override if
+// there is one and the given source otherwise.
+func parseTemplate(name, source string, funcMap template.FuncMap) (*template.Template, error) {
+	if TemplatesDir != "" {
+		path := filepath.Join(TemplatesDir, name+".tmpl")
+		override, err := ioutil.ReadFile(path)
+		if err == nil {
+			tmpl, err := template.New(name).Funcs(DefaultFuncMap).Funcs(funcMap).Parse(string(override))
+			if err != nil {
+				return nil, fmt.Errorf("invalid template override %s: %s", path, err)
+			}
+			return tmpl, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	tmpl, err := template.New(name).Funcs(DefaultFuncMap).Funcs(funcMap).Parse(source)
+	if err != nil {
+		panic(err) // bug
+	}
+	return tmpl, nil
+}
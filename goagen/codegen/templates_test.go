@@ -0,0 +1,83 @@
+package codegen_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("template overrides", func() {
+	var workspace *codegen.Workspace
+	var file *codegen.SourceFile
+	var dir string
+	var override string
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err := workspace.NewPackage("tmpl")
+		Ω(err).ShouldNot(HaveOccurred())
+		file = pkg.CreateSourceFile("tmpl.go")
+		dir, err = ioutil.TempDir("", "templates")
+		Ω(err).ShouldNot(HaveOccurred())
+		override = ""
+	})
+
+	JustBeforeEach(func() {
+		if override != "" {
+			err := ioutil.WriteFile(filepath.Join(dir, "greeting.tmpl"), []byte(override), 0644)
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+		codegen.TemplatesDir = dir
+	})
+
+	AfterEach(func() {
+		codegen.TemplatesDir = ""
+		os.RemoveAll(dir)
+		workspace.Delete()
+	})
+
+	render := func() (string, error) {
+		if err := file.ExecuteTemplate("greeting", "hello {{.}}", nil, "world"); err != nil {
+			return "", err
+		}
+		b, err := ioutil.ReadFile(file.Abs())
+		return string(b), err
+	}
+
+	Context("with no override", func() {
+		It("uses the built-in template", func() {
+			Ω(render()).Should(Equal("hello world"))
+		})
+	})
+
+	Context("with an override", func() {
+		BeforeEach(func() {
+			override = "{{goify . true}} says hi"
+		})
+
+		It("uses the override", func() {
+			Ω(render()).Should(Equal("World says hi"))
+		})
+
+		It("propagates the directory to the spawned generators", func() {
+			Ω(codegen.TemplatesArgs()).Should(Equal([]string{"--templates=" + dir}))
+		})
+	})
+
+	Context("with an invalid override", func() {
+		BeforeEach(func() {
+			override = "{{.Foo"
+		})
+
+		It("returns an error", func() {
+			_, err := render()
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -13,9 +13,20 @@ import (
 	"github.com/goadesign/goa/dslengine"
 )
 
-// TransformMapKey is the name of the metadata used to specify the key for mapping fields when
-// generating the code that transforms one data structure into another.
-const TransformMapKey = "transform:key"
+const (
+	// TransformMapKey is the name of the metadata used to specify the key for mapping fields
+	// when generating the code that transforms one data structure into another.
+	TransformMapKey = "transform:key"
+
+	// FieldNameKey is the name of the metadata used to override the name of the struct field
+	// generated for an attribute.
+	FieldNameKey = "struct:field:name"
+
+	// FieldTypeKey is the name of the metadata used to override the Go type of the struct field
+	// generated for an attribute. The optional second value is the import path of the package
+	// that defines the type.
+	FieldTypeKey = "struct:field:type"
+)
 
 var (
 	// TempCount holds the value appended to variable names to make them unique.
@@ -35,6 +46,7 @@ func init() {
 		"tabs":               Tabs,
 		"add":                func(a, b int) int { return a + b },
 		"goify":              Goify,
+		"gofieldname":        GoFieldName,
 		"gotyperef":          GoTypeRef,
 		"gotypename":         GoTypeName,
 		"transformAttribute": transformAttribute,
@@ -100,17 +112,16 @@ func GoTypeDef(ds design.DataStructure, versioned bool, defPkg string, tabs int,
 			WriteTabs(&buffer, tabs+1)
 			field := actual[name]
 			typedef := GoTypeDef(field, versioned, defPkg, tabs+1, jsonTags)
+			if t := GoFieldType(field); t != "" {
+				typedef = t
+			}
 			if field.Type.IsObject() || def.IsPrimitivePointer(name) {
 				typedef = "*" + typedef
 			}
-			fname := Goify(name, true)
+			fname := GoFieldName(field, name)
 			var tags string
 			if jsonTags {
-				var omit string
-				if !def.IsRequired(name) {
-					omit = ",omitempty"
-				}
-				tags = fmt.Sprintf(" `json:\"%s%s\" xml:\"%s%s\"`", name, omit, name, omit)
+				tags = " " + StructTags(field, name, def.IsRequired(name))
 			}
 			desc := actual[name].Description
 			if desc != "" {
@@ -130,6 +141,124 @@ func GoTypeDef(ds design.DataStructure, versioned bool, defPkg string, tabs int,
 	}
 }
 
+// GoFieldName returns the name of the struct field generated for the attribute with the given
+// name, that is the value of the "struct:field:name" metadata if set, the goified attribute name
+// otherwise.
+func GoFieldName(att *design.AttributeDefinition, name string) string {
+	if vals, ok := att.Metadata[FieldNameKey]; ok && len(vals) > 0 {
+		return vals[0]
+	}
+	return Goify(name, true)
+}
+
+// GoFieldType returns the Go type of the struct field generated for the attribute as set with the
+// "struct:field:type" metadata, the empty string if the metadata is not set. The type should have
+// the same underlying type as the type goagen would otherwise generate so that the generated
+// validation and transformation code compiles.
+func GoFieldType(att *design.AttributeDefinition) string {
+	if vals, ok := att.Metadata[FieldTypeKey]; ok && len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// FieldTypeImports returns the imports of the packages that define the struct field types set
+// with the "struct:field:type" metadata and the custom validation functions set with the Validate
+// DSL on the given attributes or on any of their child attributes.
+func FieldTypeImports(atts ...*design.AttributeDefinition) []*ImportSpec {
+	var imports []*ImportSpec
+	seen := make(map[string]bool)
+	var collect func(*design.AttributeDefinition)
+	collect = func(att *design.AttributeDefinition) {
+		if vals, ok := att.Metadata[FieldTypeKey]; ok && len(vals) > 1 && !seen[vals[1]] {
+			seen[vals[1]] = true
+			imports = append(imports, SimpleImport(vals[1]))
+		}
+		if att.Validation != nil {
+			for _, fn := range att.Validation.Validators {
+				if p, _ := validatorPackage(fn); p != "" && !seen[p] {
+					seen[p] = true
+					imports = append(imports, SimpleImport(p))
+				}
+			}
+		}
+		switch actual := att.Type.(type) {
+		case design.Object:
+			for _, n := range sortedKeys(actual) {
+				collect(actual[n])
+			}
+		case *design.Array:
+			collect(actual.ElemType)
+		case *design.Hash:
+			collect(actual.KeyType)
+			collect(actual.ElemType)
+		case *design.UserTypeDefinition:
+			if !seen[actual.TypeName] {
+				seen[actual.TypeName] = true
+				collect(actual.AttributeDefinition)
+			}
+		case *design.MediaTypeDefinition:
+			if !seen[actual.TypeName] {
+				seen[actual.TypeName] = true
+				collect(actual.AttributeDefinition)
+			}
+		}
+	}
+	for _, att := range atts {
+		collect(att)
+	}
+	return imports
+}
+
+// sortedKeys returns the names of the object attributes sorted alphabetically.
+func sortedKeys(o design.Object) []string {
+	keys := make([]string, 0, len(o))
+	for n := range o {
+		keys = append(keys, n)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// StructTags returns the tags of the struct field generated for the attribute with the given name
+// including the enclosing backquotes. goagen produces json and xml tags that use the attribute
+// name and the omitempty option for attributes that are not required. Metadata with keys of the
+// form "struct:tag=xxx" or "struct:tag:xxx" override the tag xxx if goagen produces it or add it
+// otherwise. Values that start with a comma only list options and are prefixed with the attribute
+// name, e.g. Metadata("struct:tag:json", ",string").
+func StructTags(att *design.AttributeDefinition, name string, required bool) string {
+	var omit string
+	if !required {
+		omit = ",omitempty"
+	}
+	tags := map[string]string{"json": name + omit, "xml": name + omit}
+	var extra []string
+	for key, vals := range att.Metadata {
+		var tag string
+		if strings.HasPrefix(key, "struct:tag=") || strings.HasPrefix(key, "struct:tag:") {
+			tag = key[len("struct:tag="):]
+		}
+		if tag == "" {
+			continue
+		}
+		val := strings.Join(vals, " ")
+		if strings.HasPrefix(val, ",") {
+			val = name + val
+		}
+		if _, ok := tags[tag]; !ok {
+			extra = append(extra, tag)
+		}
+		tags[tag] = val
+	}
+	sort.Strings(extra)
+	names := append([]string{"json", "xml"}, extra...)
+	elems := make([]string, len(names))
+	for i, n := range names {
+		elems[i] = fmt.Sprintf("%s:%q", n, tags[n])
+	}
+	return "`" + strings.Join(elems, " ") + "`"
+}
+
 // GoTypeRef returns the Go code that refers to the Go type which matches the given data type
 // (the part that comes after `var foo`)
 // required only applies when referring to a user type that is an object defined inline. In this
@@ -163,6 +292,33 @@ func GoPackageTypeRef(t design.DataType, required []string, versioned bool, defP
 	}
 }
 
+// AppTypeRef returns the Go type reference to t from a package other than the generated
+// application package, e.g. the test helpers or mock controllers packages. User types and media
+// types are qualified with appPkg, the name of the application package, unless they are versioned
+// in which case they are qualified with the version package.
+func AppTypeRef(t design.DataType, version *design.APIVersionDefinition, appPkg string) string {
+	var ut *design.UserTypeDefinition
+	switch actual := t.(type) {
+	case *design.UserTypeDefinition:
+		ut = actual
+	case *design.MediaTypeDefinition:
+		ut = actual.UserTypeDefinition
+	case *design.Array:
+		return "[]" + AppTypeRef(actual.ElemType.Type, version, appPkg)
+	default:
+		return GoTypeRef(t, nil, 0)
+	}
+	pkg := appPkg
+	if !version.IsDefault() && len(ut.APIVersions) > 0 {
+		pkg = VersionPackage(version.Version)
+	}
+	var prefix string
+	if ut.IsObject() {
+		prefix = "*"
+	}
+	return prefix + pkg + "." + Goify(ut.TypeName, true)
+}
+
 // GoTypeName returns the Go type name for a data type.
 // tabs is used to properly tabulate the object struct fields and only applies to this case.
 // This function assumes the type is in the same package as the code accessing it.
@@ -329,7 +485,7 @@ func Goify(str string, firstUpper bool) string {
 		// [w,i] is a word.
 		word := string(runes[w:i])
 		// is it one of our initialisms?
-		if u := strings.ToUpper(word); commonInitialisms[u] {
+		if u := strings.ToUpper(word); isInitialism(u) {
 			if firstUpper {
 				u = strings.ToUpper(u)
 			}
@@ -670,7 +826,7 @@ const transformTmpl = `func {{.Name}}(source {{gotyperef .Source nil 0}}) (targe
 const transformObjectTmpl = `{{tabs .Depth}}{{.TargetCtx}} = new({{if .TargetPkg}}{{.TargetPkg}}.{{end}}{{if .TargetType}}{{.TargetType}}{{else}}{{gotyperef .Target.Type .Target.AllRequired 1}}{{end}})
 {{range $source, $target := .AttributeMap}}{{/*
 */}}{{$sourceAtt := index $.Source $source}}{{$targetAtt := index $.Target $target}}{{/*
-*/}}{{$source := goify $source true}}{{$target := goify $target true}}{{/*
+*/}}{{$source := gofieldname $sourceAtt $source}}{{$target := gofieldname $targetAtt $target}}{{/*
 */}}{{     if $sourceAtt.Type.IsArray}}{{ transformArray  $sourceAtt.Type.ToArray  $targetAtt.Type.ToArray  $.TargetPkg (printf "%s.%s" $.SourceCtx $source) (printf "%s.%s" $.TargetCtx $target) $.Depth}}{{/*
 */}}{{else if $sourceAtt.Type.IsHash}}{{  transformHash   $sourceAtt.Type.ToHash   $targetAtt.Type.ToHash   $.TargetPkg (printf "%s.%s" $.SourceCtx $source) (printf "%s.%s" $.TargetCtx $target) $.Depth}}{{/*
 */}}{{else if $sourceAtt.Type.IsObject}}{{transformObject $sourceAtt.Type.ToObject $targetAtt.Type.ToObject $.TargetPkg (typeName $targetAtt) (printf "%s.%s" $.SourceCtx $source) (printf "%s.%s" $.TargetCtx $target) $.Depth}}{{/*
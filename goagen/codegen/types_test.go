@@ -265,6 +265,86 @@ var _ = Describe("code generation", func() {
 				})
 			})
 
+			Context("with struct tag metadata", func() {
+				BeforeEach(func() {
+					object = Object{
+						"foo": &AttributeDefinition{
+							Type: Integer,
+							Metadata: dslengine.MetadataDefinition{
+								"struct:tag=json":     {"myFoo,omitempty"},
+								"struct:tag:xml":      {",attr"},
+								"struct:tag:validate": {"min=1"},
+								"struct:tag:gorm":     {"column:foo_id;", "not null"},
+							},
+						},
+					}
+					required = &dslengine.ValidationDefinition{
+						Required: []string{"foo"},
+					}
+				})
+
+				It("overrides and adds the tags", func() {
+					expected := "struct {\n" +
+						"	Foo int `json:\"myFoo,omitempty\" xml:\"foo,attr\" gorm:\"column:foo_id; not null\" validate:\"min=1\"`\n" +
+						"}"
+					Ω(st).Should(Equal(expected))
+				})
+			})
+
+			Context("with struct field metadata", func() {
+				BeforeEach(func() {
+					object = Object{
+						"id": &AttributeDefinition{
+							Type:     String,
+							Metadata: dslengine.MetadataDefinition{"struct:field:name": {"ID"}},
+						},
+						"owner": &AttributeDefinition{
+							Type: String,
+							Metadata: dslengine.MetadataDefinition{
+								"struct:field:type": {"types.AccountID", "github.com/acme/types"},
+							},
+						},
+					}
+					required = &dslengine.ValidationDefinition{
+						Required: []string{"id"},
+					}
+				})
+
+				It("overrides the field names and types", func() {
+					expected := "struct {\n" +
+						"	ID string `json:\"id\" xml:\"id\"`\n" +
+						"	Owner *types.AccountID `json:\"owner,omitempty\" xml:\"owner,omitempty\"`\n" +
+						"}"
+					Ω(st).Should(Equal(expected))
+				})
+
+				It("lists the imports of the field types", func() {
+					imports := codegen.FieldTypeImports(&AttributeDefinition{Type: object})
+					Ω(imports).Should(HaveLen(1))
+					Ω(imports[0].Path).Should(Equal("github.com/acme/types"))
+				})
+			})
+
+			Context("with struct field pointer metadata", func() {
+				BeforeEach(func() {
+					object = Object{
+						"count": &AttributeDefinition{
+							Type:     Integer,
+							Metadata: dslengine.MetadataDefinition{"struct:field:pointer": {"false"}},
+						},
+						"name": &AttributeDefinition{Type: String},
+					}
+					required = nil
+				})
+
+				It("produces value fields", func() {
+					expected := "struct {\n" +
+						"	Count int `json:\"count,omitempty\" xml:\"count,omitempty\"`\n" +
+						"	Name *string `json:\"name,omitempty\" xml:\"name,omitempty\"`\n" +
+						"}"
+					Ω(st).Should(Equal(expected))
+				})
+			})
 		})
 
 		Context("given an array", func() {
@@ -306,6 +386,31 @@ var _ = Describe("code generation", func() {
 	})
 })
 
+var _ = Describe("AppTypeRef", func() {
+	var bottle *UserTypeDefinition
+	var version *APIVersionDefinition
+
+	BeforeEach(func() {
+		bottle = &UserTypeDefinition{
+			AttributeDefinition: &AttributeDefinition{Type: Object{"name": &AttributeDefinition{Type: String}}},
+			TypeName:            "bottle",
+		}
+		version = &APIVersionDefinition{}
+	})
+
+	It("qualifies user types with the application package", func() {
+		Ω(codegen.AppTypeRef(bottle, version, "app")).Should(Equal("*app.Bottle"))
+		Ω(codegen.AppTypeRef(&Array{ElemType: &AttributeDefinition{Type: bottle}}, version, "app")).Should(Equal("[]*app.Bottle"))
+		Ω(codegen.AppTypeRef(DateTime, version, "app")).Should(Equal("time.Time"))
+	})
+
+	It("qualifies versioned user types with the version package", func() {
+		version.Version = "v1"
+		bottle.APIVersions = []string{"v1"}
+		Ω(codegen.AppTypeRef(bottle, version, "app")).Should(Equal("*v1.Bottle"))
+	})
+})
+
 var _ = Describe("GoTypeTransform", func() {
 	var source, target *UserTypeDefinition
 	var targetPkg, funcName string
@@ -2,6 +2,7 @@ package codegen
 
 import (
 	"fmt"
+	"path"
 	"strings"
 	"text/template"
 
@@ -17,6 +18,11 @@ var (
 	minMaxValT   *template.Template
 	lengthValT   *template.Template
 	requiredValT *template.Template
+	customValT   *template.Template
+
+	// patternVars maps the patterns used by the validation code generated since the last call
+	// to PatternVars to the names of the variables holding their compiled regular expressions.
+	patternVars = make(map[string]string)
 )
 
 //  init instantiates the templates.
@@ -28,8 +34,11 @@ func init() {
 		"oneof":            oneof,
 		"constant":         constant,
 		"goify":            Goify,
+		"gofieldname":      GoFieldName,
 		"add":              func(a, b int) int { return a + b },
 		"recursiveChecker": RecursiveChecker,
+		"patternVar":       PatternVar,
+		"validatorFunc":    ValidatorFunc,
 	}
 	if arrayValT, err = template.New("array").Funcs(fm).Parse(arrayValTmpl); err != nil {
 		panic(err)
@@ -52,6 +61,9 @@ func init() {
 	if requiredValT, err = template.New("required").Funcs(fm).Parse(requiredValTmpl); err != nil {
 		panic(err)
 	}
+	if customValT, err = template.New("custom").Funcs(fm).Parse(customValTmpl); err != nil {
+		panic(err)
+	}
 }
 
 // RecursiveChecker produces Go code that runs the validation checks recursively over the given
@@ -73,18 +85,21 @@ func RecursiveChecker(att *design.AttributeDefinition, nonzero, required bool, t
 			if catt.Type.IsObject() {
 				actualDepth = depth + 1
 			}
+			// Optional primitive attributes generated as value fields are validated as
+			// non-zero attributes.
+			nonzero := att.IsNonZero(n) || (catt.Type.IsPrimitive() && !att.IsPrimitivePointer(n))
 			validation := RecursiveChecker(
 				catt,
-				att.IsNonZero(n),
+				nonzero,
 				att.IsRequired(n),
-				fmt.Sprintf("%s.%s", target, Goify(n, true)),
+				fmt.Sprintf("%s.%s", target, GoFieldName(catt, n)),
 				fmt.Sprintf("%s.%s", context, n),
 				actualDepth,
 			)
 			if validation != "" {
 				if catt.Type.IsObject() {
 					validation = fmt.Sprintf("%sif %s.%s != nil {\n%s\n%s}",
-						Tabs(depth), target, Goify(n, true), validation, Tabs(depth))
+						Tabs(depth), target, GoFieldName(catt, n), validation, Tabs(depth))
 				}
 				checks = append(checks, validation)
 			}
@@ -110,7 +125,8 @@ func RecursiveChecker(att *design.AttributeDefinition, nonzero, required bool, t
 // context is used to keep track of recursion to produce helpful error messages in case of type
 // validation error.
 // The generated code assumes that there is a pre-existing "err" variable of type
-// error. It initializes that variable in case a validation fails.
+// error. It initializes that variable in case a validation fails. The errors do not include the
+// values of sensitive attributes.
 // Note: we do not want to recurse here, recursion is done by the marshaler/unmarshaler code.
 func ValidationChecker(att *design.AttributeDefinition, nonzero, required bool, target, context string, depth int) string {
 	t := target
@@ -125,6 +141,7 @@ func ValidationChecker(att *design.AttributeDefinition, nonzero, required bool,
 		"context":   context,
 		"target":    target,
 		"targetVal": t,
+		"sensitive": att.IsSensitive(),
 		"array":     att.Type.IsArray(),
 		"depth":     depth,
 	}
@@ -192,9 +209,57 @@ func validationsCode(validation *dslengine.ValidationDefinition, data map[string
 			res = append(res, val)
 		}
 	}
+	for _, fn := range validation.Validators {
+		data["validator"] = fn
+		if val := RunTemplate(customValT, data); val != "" {
+			res = append(res, val)
+		}
+	}
 	return
 }
 
+// ValidatorFunc returns the Go expression used to call the custom validation function with the
+// given fully qualified name, e.g. "rules.ValidBottle" for "github.com/acme/rules.ValidBottle".
+func ValidatorFunc(fn string) string {
+	_, name := validatorPackage(fn)
+	return name
+}
+
+// validatorPackage splits the fully qualified name of a custom validation function into the
+// import path of its package and the Go expression used to call it.
+func validatorPackage(fn string) (string, string) {
+	dot := strings.LastIndex(fn, ".")
+	if dot < 0 {
+		return "", fn
+	}
+	pkgPath := fn[:dot]
+	return pkgPath, path.Base(pkgPath) + fn[dot:]
+}
+
+// PatternVar returns the name of the package variable holding the compiled regular expression of
+// the given pattern. The generated validation code uses these variables so that patterns get
+// compiled once when the package is initialized rather than on each validation, generators
+// declare them using the result of PatternVars.
+func PatternVar(pattern string) string {
+	if name, ok := patternVars[pattern]; ok {
+		return name
+	}
+	name := fmt.Sprintf("patternRegexp%d", len(patternVars)+1)
+	patternVars[pattern] = name
+	return name
+}
+
+// PatternVars returns the patterns used by the validation code generated since the last call
+// indexed by the names of the variables returned by PatternVar and resets the variable names.
+func PatternVars() map[string]string {
+	vars := make(map[string]string, len(patternVars))
+	for p, name := range patternVars {
+		vars[name] = p
+	}
+	patternVars = make(map[string]string)
+	return vars
+}
+
 // oneof produces code that compares target with each element of vals and ORs
 // the result, e.g. "target == 1 || target == 2".
 func oneof(target string, vals []interface{}) string {
@@ -226,6 +291,14 @@ func constant(formatName string) string {
 		return "goa.FormatCIDR"
 	case "regexp":
 		return "goa.FormatRegexp"
+	case "regex":
+		return "goa.FormatRegex"
+	case "uuid":
+		return "goa.FormatUUID"
+	case "uri-template":
+		return "goa.FormatURITemplate"
+	case "rfc1123":
+		return "goa.FormatRFC1123"
 	}
 	panic("unknown format") // bug
 }
@@ -239,28 +312,28 @@ const (
 	enumValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs $depth}}if !({{oneof .targetVal .values}}) {
-{{tabs $depth}}	err = goa.InvalidEnumValueError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, {{slice .values}}, err)
+{{tabs $depth}}	err = goa.InvalidEnumValueError(` + "`" + `{{.context}}` + "`" + `, {{if .sensitive}}goa.Redacted{{else}}{{.targetVal}}{{end}}, {{slice .values}}, err)
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
 	patternValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
-{{end}}{{tabs $depth}}if ok := goa.ValidatePattern(` + "`{{.pattern}}`" + `, {{.targetVal}}); !ok {
-{{tabs $depth}}	err = goa.InvalidPatternError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, ` + "`{{.pattern}}`" + `, err)
+{{end}}{{tabs $depth}}if ok := {{patternVar .pattern}}.MatchString({{.targetVal}}); !ok {
+{{tabs $depth}}	err = goa.InvalidPatternError(` + "`" + `{{.context}}` + "`" + `, {{if .sensitive}}goa.Redacted{{else}}{{.targetVal}}{{end}}, ` + "`{{.pattern}}`" + `, err)
 {{tabs $depth}}}{{if .isPointer}}
 {{tabs .depth}}}{{end}}`
 
 	formatValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs $depth}}if err2 := goa.ValidateFormat({{constant .format}}, {{.targetVal}}); err2 != nil {
-{{tabs $depth}}		err = goa.InvalidFormatError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, {{constant .format}}, err2, err)
+{{tabs $depth}}		err = goa.InvalidFormatError(` + "`" + `{{.context}}` + "`" + `, {{if .sensitive}}goa.Redacted{{else}}{{.targetVal}}{{end}}, {{constant .format}}, err2, err)
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
 	minMaxValTmpl = `{{$depth := or (and .isPointer (add .depth 1)) .depth}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs .depth}}	if {{.targetVal}} {{if .isMin}}<{{else}}>{{end}} {{if .isMin}}{{.min}}{{else}}{{.max}}{{end}} {
-{{tabs $depth}}	err = goa.InvalidRangeError(` + "`" + `{{.context}}` + "`" + `, {{.targetVal}}, {{if .isMin}}{{.min}}, true{{else}}{{.max}}, false{{end}}, err)
+{{tabs $depth}}	err = goa.InvalidRangeError(` + "`" + `{{.context}}` + "`" + `, {{if .sensitive}}goa.Redacted{{else}}{{.targetVal}}{{end}}, {{if .isMin}}{{.min}}, true{{else}}{{.max}}, false{{end}}, err)
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
@@ -268,14 +341,22 @@ const (
 */}}{{$target := or (and (or .array .nonzero) .target) .targetVal}}{{/*
 */}}{{if .isPointer}}{{tabs .depth}}if {{.target}} != nil {
 {{end}}{{tabs .depth}}if len({{$target}}) {{if .isMinLength}}<{{else}}>{{end}} {{if .isMinLength}}{{.minLength}}{{else}}{{.maxLength}}{{end}} {
-{{tabs $depth}}	err = goa.InvalidLengthError(` + "`" + `{{.context}}` + "`" + `, {{$target}}, len({{$target}}), {{if .isMinLength}}{{.minLength}}, true{{else}}{{.maxLength}}, false{{end}}, err)
+{{tabs $depth}}	err = goa.InvalidLengthError(` + "`" + `{{.context}}` + "`" + `, {{if .sensitive}}goa.Redacted{{else}}{{$target}}{{end}}, len({{$target}}), {{if .isMinLength}}{{.minLength}}, true{{else}}{{.maxLength}}, false{{end}}, err)
 {{if .isPointer}}{{tabs $depth}}}
 {{end}}{{tabs .depth}}}`
 
-	requiredValTmpl = `{{range $r := .required}}{{$catt := index $.attribute.Type.ToObject $r}}{{if eq $catt.Type.Kind 4}}{{tabs $.depth}}if {{$.target}}.{{goify $r true}} == "" {
+	requiredValTmpl = `{{range $r := .required}}{{$catt := index $.attribute.Type.ToObject $r}}{{if eq $catt.Type.Kind 4}}{{tabs $.depth}}if {{$.target}}.{{gofieldname $catt $r}} == "" {
 {{tabs $.depth}}	err = goa.MissingAttributeError(` + "`" + `{{$.context}}` + "`" + `, "{{$r}}", err)
-{{tabs $.depth}}}{{else if (not $catt.Type.IsPrimitive)}}{{tabs $.depth}}if {{$.target}}.{{goify $r true}} == nil {
+{{tabs $.depth}}}{{else if (not $catt.Type.IsPrimitive)}}{{tabs $.depth}}if {{$.target}}.{{gofieldname $catt $r}} == nil {
 {{tabs $.depth}}	err = goa.MissingAttributeError(` + "`" + `{{$.context}}` + "`" + `, "{{$r}}", err)
 {{tabs $.depth}}}{{end}}
 {{end}}`
+
+	customValTmpl = `{{$isPointer := and .isPointer .attribute.Type.IsPrimitive}}{{/*
+*/}}{{$depth := or (and $isPointer (add .depth 1)) .depth}}{{/*
+*/}}{{if $isPointer}}{{tabs .depth}}if {{.target}} != nil {
+{{end}}{{tabs $depth}}if err2 := {{validatorFunc .validator}}({{.targetVal}}); err2 != nil {
+{{tabs $depth}}	err = goa.CustomValidationError(` + "`" + `{{.context}}` + "`" + `, err2, err)
+{{if $isPointer}}{{tabs $depth}}}
+{{end}}{{tabs .depth}}}`
 )
@@ -11,6 +11,7 @@ import (
 var _ = Describe("validation code generation", func() {
 	BeforeEach(func() {
 		codegen.TempCount = 0
+		codegen.PatternVars()
 	})
 
 	Describe("ValidationChecker", func() {
@@ -53,6 +54,28 @@ var _ = Describe("validation code generation", func() {
 				It("produces the validation go code", func() {
 					Ω(code).Should(Equal(patternValCode))
 				})
+
+				It("records the pattern variable", func() {
+					Ω(codegen.PatternVars()).Should(Equal(map[string]string{"patternRegexp1": ".*"}))
+				})
+			})
+
+			Context("of pattern on a sensitive attribute", func() {
+				BeforeEach(func() {
+					attType = design.String
+					validation = &dslengine.ValidationDefinition{
+						Pattern: ".*",
+					}
+					att.Metadata = dslengine.MetadataDefinition{design.SensitiveKey: nil}
+				})
+
+				AfterEach(func() {
+					att.Metadata = nil
+				})
+
+				It("does not echo the value", func() {
+					Ω(code).Should(Equal(sensitivePatternValCode))
+				})
 			})
 
 			Context("of min value 0", func() {
@@ -69,6 +92,32 @@ var _ = Describe("validation code generation", func() {
 				})
 			})
 
+			Context("of custom validation", func() {
+				BeforeEach(func() {
+					attType = design.String
+					validation = &dslengine.ValidationDefinition{
+						Validators: []string{"github.com/acme/rules.ValidName"},
+					}
+				})
+
+				It("produces the validation go code", func() {
+					Ω(code).Should(Equal(customValCode))
+				})
+			})
+
+			Context("of custom validation on an object", func() {
+				BeforeEach(func() {
+					attType = design.Object{"foo": &design.AttributeDefinition{Type: design.String}}
+					validation = &dslengine.ValidationDefinition{
+						Validators: []string{"github.com/acme/rules.ValidFoo"},
+					}
+				})
+
+				It("calls the function with the object", func() {
+					Ω(code).Should(Equal(customObjectValCode))
+				})
+			})
+
 			Context("of embedded object", func() {
 				BeforeEach(func() {
 					enumVal := &dslengine.ValidationDefinition{
@@ -116,17 +165,33 @@ const (
 	}`
 
 	patternValCode = `	if val != nil {
-		if ok := goa.ValidatePattern(` + "`.*`" + `, *val); !ok {
+		if ok := patternRegexp1.MatchString(*val); !ok {
 			err = goa.InvalidPatternError(` + "`context`" + `, *val, ` + "`.*`" + `, err)
 		}
 	}`
 
+	sensitivePatternValCode = `	if val != nil {
+		if ok := patternRegexp1.MatchString(*val); !ok {
+			err = goa.InvalidPatternError(` + "`context`" + `, goa.Redacted, ` + "`.*`" + `, err)
+		}
+	}`
+
 	minValCode = `	if val != nil {
 		if *val < 0 {
 			err = goa.InvalidRangeError(` + "`" + `context` + "`" + `, *val, 0, true, err)
 		}
 	}`
 
+	customValCode = `	if val != nil {
+		if err2 := rules.ValidName(*val); err2 != nil {
+			err = goa.CustomValidationError(` + "`context`" + `, err2, err)
+		}
+	}`
+
+	customObjectValCode = `	if err2 := rules.ValidFoo(val); err2 != nil {
+		err = goa.CustomValidationError(` + "`context`" + `, err2, err)
+	}`
+
 	embeddedValCode = `	if val.Foo != nil {
 		if val.Foo.Bar != nil {
 			if !(*val.Foo.Bar == 1 || *val.Foo.Bar == 2 || *val.Foo.Bar == 3) {
@@ -0,0 +1,112 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+)
+
+// GoTypeRestrict produces the Go code of the Restrict method of the type generated for ut. The
+// method returns a copy of its receiver where the fields restricted with the VisibleTo DSL that
+// are not visible to the given roles are omitted, the fields of the nested objects, user types and
+// media types included. recv is the name of the method receiver. The function returns the empty
+// string if ut has no restricted field.
+func GoTypeRestrict(ut *design.UserTypeDefinition, recv string, versioned bool, defPkg string) string {
+	if !ut.HasRestrictedFields() || !(ut.IsObject() || ut.IsArray()) {
+		return ""
+	}
+	var buf bytes.Buffer
+	ref := GoTypeRef(ut, nil, 0)
+	fmt.Fprintf(&buf, "// Restrict returns a copy of %s where the fields that are not visible to the given roles are\n", recv)
+	buf.WriteString("// omitted.\n")
+	fmt.Fprintf(&buf, "func (%s %s) Restrict(roles []string) %s {\n", recv, ref, ref)
+	fmt.Fprintf(&buf, "\tif %s == nil {\n\t\treturn nil\n\t}\n", recv)
+	switch {
+	case ut.IsObject():
+		fmt.Fprintf(&buf, "\tres := *%s\n", recv)
+		buf.WriteString(restrictObject(ut.AttributeDefinition, "res", versioned, defPkg, 1))
+		buf.WriteString("\treturn &res\n")
+	default:
+		fmt.Fprintf(&buf, "\tres := make(%s, len(%s))\n", ref, recv)
+		fmt.Fprintf(&buf, "\tfor i, e := range %s {\n\t\tres[i] = e\n", recv)
+		buf.WriteString(restrictValue(ut.ToArray().ElemType, "res[i]", versioned, defPkg, 2))
+		buf.WriteString("\t}\n\treturn res\n")
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// restrictObject produces the code that omits the fields of the object held by target that are
+// not visible to the roles.
+func restrictObject(att *design.AttributeDefinition, target string, versioned bool, defPkg string, depth int) string {
+	var buf bytes.Buffer
+	obj := att.Type.ToObject()
+	for _, n := range sortedKeys(obj) {
+		field := obj[n]
+		ftarget := target + "." + GoFieldName(field, n)
+		if roles := field.VisibleTo(); len(roles) > 0 {
+			allowed := make([]string, len(roles))
+			for i, r := range roles {
+				allowed[i] = fmt.Sprintf("%q", r)
+			}
+			fmt.Fprintf(&buf, "%sif !goa.VisibleTo(roles, %s) {\n", Tabs(depth), strings.Join(allowed, ", "))
+			fmt.Fprintf(&buf, "%s%s = %s\n", Tabs(depth+1), ftarget, restrictZero(att, n, versioned, defPkg))
+			fmt.Fprintf(&buf, "%s}\n", Tabs(depth))
+		}
+		buf.WriteString(restrictValue(field, ftarget, versioned, defPkg, depth))
+	}
+	return buf.String()
+}
+
+// restrictValue produces the code that replaces the value held by target with a copy where the
+// fields not visible to the roles are omitted. It returns the empty string if the value has no
+// restricted field.
+func restrictValue(att *design.AttributeDefinition, target string, versioned bool, defPkg string, depth int) string {
+	if !att.HasRestrictedFields() {
+		return ""
+	}
+	tabs := Tabs(depth)
+	switch actual := att.Type.(type) {
+	case *design.UserTypeDefinition, *design.MediaTypeDefinition:
+		return fmt.Sprintf("%s%s = %s.Restrict(roles)\n", tabs, target, target)
+	case design.Object:
+		v := fmt.Sprintf("v%d", depth)
+		return fmt.Sprintf("%sif %s != nil {\n%s\t%s := *%s\n%s%s\t%s = &%s\n%s}\n",
+			tabs, target, tabs, v, target,
+			restrictObject(att, v, versioned, defPkg, depth+1),
+			tabs, target, v, tabs)
+	case *design.Array:
+		s := fmt.Sprintf("s%d", depth)
+		i := fmt.Sprintf("i%d", depth)
+		return fmt.Sprintf("%sif %s != nil {\n%s\t%s := append(%s[:0:0], %s...)\n%s\tfor %s := range %s {\n%s%s\t}\n%s\t%s = %s\n%s}\n",
+			tabs, target, tabs, s, target, target, tabs, i, s,
+			restrictValue(actual.ElemType, fmt.Sprintf("%s[%s]", s, i), versioned, defPkg, depth+2),
+			tabs, tabs, target, s, tabs)
+	}
+	return ""
+}
+
+// restrictZero returns the Go code of the zero value of the field n of the object att.
+func restrictZero(att *design.AttributeDefinition, n string, versioned bool, defPkg string) string {
+	field := att.Type.ToObject()[n]
+	if field.Type.IsObject() || field.Type.IsArray() || field.Type.IsHash() || att.IsPrimitivePointer(n) {
+		return "nil"
+	}
+	switch field.Type.Kind() {
+	case design.BooleanKind:
+		return "false"
+	case design.IntegerKind, design.NumberKind:
+		return "0"
+	case design.StringKind:
+		return `""`
+	case design.AnyKind:
+		return "nil"
+	}
+	t := GoFieldType(field)
+	if t == "" {
+		t = GoPackageTypeRef(field.Type, field.AllRequired(), versioned, defPkg, 0)
+	}
+	return fmt.Sprintf("*new(%s)", t)
+}
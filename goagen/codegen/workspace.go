@@ -53,7 +53,10 @@ var (
 	DefaultFuncMap = template.FuncMap{
 		"add":               func(a, b int) int { return a + b },
 		"commandLine":       CommandLine,
+		"contextName":       ContextName,
+		"controllerName":    ControllerName,
 		"comment":           Comment,
+		"gofieldname":       GoFieldName,
 		"goify":             Goify,
 		"gonative":          GoNativeType,
 		"gopkgtypename":     GoPackageTypeName,
@@ -63,6 +66,8 @@ var (
 		"gotyperef":         GoTypeRef,
 		"join":              strings.Join,
 		"recursiveValidate": RecursiveChecker,
+		"crypt":             GoTypeCrypt,
+		"restrict":          GoTypeRestrict,
 		"tabs":              Tabs,
 		"tempvar":           Tempvar,
 		"title":             strings.Title,
@@ -216,9 +221,14 @@ func SourceFileFor(path string) (*SourceFile, error) {
 
 // WriteHeader writes the generic generated code header.
 func (f *SourceFile) WriteHeader(title, pack string, imports []*ImportSpec) error {
+	var cmdline string
+	if !NoCommandLine {
+		cmdline = CommandLine()
+	}
 	ctx := map[string]interface{}{
 		"Title":       title,
 		"ToolVersion": Version,
+		"CommandLine": cmdline,
 		"Pkg":         pack,
 		"Imports":     imports,
 	}
@@ -279,11 +289,12 @@ func (f *SourceFile) Abs() string {
 	return filepath.Join(f.Package.Abs(), f.Name)
 }
 
-// ExecuteTemplate executes the template and writes the output to the file.
+// ExecuteTemplate executes the template and writes the output to the file. The template source
+// is read from TemplatesDir instead if it contains an override for the template with the given name.
 func (f *SourceFile) ExecuteTemplate(name, source string, funcMap template.FuncMap, data interface{}) error {
-	tmpl, err := template.New(name).Funcs(DefaultFuncMap).Funcs(funcMap).Parse(source)
+	tmpl, err := parseTemplate(name, source, funcMap)
 	if err != nil {
-		panic(err) // bug
+		return err
 	}
 	return tmpl.Execute(f, data)
 }
@@ -359,8 +370,8 @@ const (
 	headerT = `{{if .Title}}//************************************************************************//
 // {{.Title}}
 //
-// Generated with goagen v{{.ToolVersion}}, command line:
-{{comment commandLine}}
+// Generated with goagen v{{.ToolVersion}}{{if .CommandLine}}, command line:
+{{comment .CommandLine}}{{end}}
 //
 // The content of this file is auto-generated, DO NOT MODIFY
 //************************************************************************//
@@ -0,0 +1,52 @@
+package codegen_test
+
+import (
+	"io/ioutil"
+
+	"github.com/goadesign/goa/goagen/codegen"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WriteHeader", func() {
+	var workspace *codegen.Workspace
+	var file *codegen.SourceFile
+	var header string
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err := workspace.NewPackage("header")
+		Ω(err).ShouldNot(HaveOccurred())
+		file = pkg.CreateSourceFile("header.go")
+	})
+
+	JustBeforeEach(func() {
+		err := file.WriteHeader("Title", "header", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		b, err := ioutil.ReadFile(file.Abs())
+		Ω(err).ShouldNot(HaveOccurred())
+		header = string(b)
+	})
+
+	AfterEach(func() {
+		codegen.NoCommandLine = false
+		workspace.Delete()
+	})
+
+	It("includes the command line", func() {
+		Ω(header).Should(ContainSubstring("command line:\n// $ "))
+	})
+
+	Context("with NoCommandLine", func() {
+		BeforeEach(func() {
+			codegen.NoCommandLine = true
+		})
+
+		It("omits the command line", func() {
+			Ω(header).ShouldNot(ContainSubstring("command line"))
+			Ω(header).Should(ContainSubstring("// Generated with goagen v" + codegen.Version + "\n//\n"))
+		})
+	})
+})
@@ -6,8 +6,11 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
 	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/utils"
 	"github.com/spf13/cobra"
@@ -28,8 +31,12 @@ func Generate(roots []interface{}) (files []string, err error) {
 		Long:  "application code generator",
 		PreRunE: func(*cobra.Command, []string) error {
 			outdir := AppOutputDir()
-			os.RemoveAll(outdir)
-			g.genfiles = []string{outdir}
+			if !codegen.Filtered() {
+				// Keep the code generated for the resources and versions that are not
+				// selected.
+				os.RemoveAll(outdir)
+				g.genfiles = []string{outdir}
+			}
 			err = os.MkdirAll(outdir, 0777)
 			return err
 		},
@@ -76,8 +83,14 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		}
 	}()
 
+	if err = codegen.CheckFilters(api); err != nil {
+		return nil, err
+	}
 	outdir := AppOutputDir()
 	err = api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		if !codegen.VersionSelected(v) {
+			return nil
+		}
 		verdir := outdir
 		if v.Version != "" {
 			verdir = filepath.Join(verdir, codegen.VersionPackage(v.Version))
@@ -85,6 +98,7 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		if err := os.MkdirAll(verdir, 0755); err != nil {
 			return err
 		}
+		codegen.PatternVars() // discard the patterns used by other versions
 		if err := g.generateContexts(verdir, api, v); err != nil {
 			return err
 		}
@@ -100,6 +114,23 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		if err := g.generateUserTypes(verdir, v); err != nil {
 			return err
 		}
+		if err := g.generateSessions(verdir, v); err != nil {
+			return err
+		}
+		if err := g.generatePatterns(verdir, v); err != nil {
+			return err
+		}
+		if err := g.generateCallbacks(verdir, v); err != nil {
+			return err
+		}
+		if err := g.generateWebhooks(verdir, v); err != nil {
+			return err
+		}
+		if v.IsDefault() {
+			if err := g.generateSecurity(verdir, api); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 	if err != nil {
@@ -109,12 +140,19 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 	return g.genfiles, nil
 }
 
-// Cleanup removes the entire "app" directory if it was created by this generator.
+// Cleanup removes the entire "app" directory if it was created by this generator. Only the
+// generated files are removed when generating a subset of the design.
 func (g *Generator) Cleanup() {
 	if len(g.genfiles) == 0 {
 		return
 	}
-	os.RemoveAll(AppOutputDir())
+	if codegen.Filtered() {
+		for _, f := range g.genfiles {
+			os.Remove(f)
+		}
+	} else {
+		os.RemoveAll(AppOutputDir())
+	}
 	g.genfiles = nil
 }
 
@@ -154,6 +192,7 @@ func (g *Generator) generateContexts(verdir string, api *design.APIDefinition, v
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.SimpleImport("io"),
 		codegen.SimpleImport("strconv"),
 		codegen.SimpleImport("strings"),
 		codegen.SimpleImport("time"),
@@ -166,13 +205,20 @@ func (g *Generator) generateContexts(verdir string, api *design.APIDefinition, v
 		}
 		imports = append(imports, codegen.SimpleImport(appPkg))
 	}
+	if hasWebSocket(version) {
+		imports = append(imports, codegen.SimpleImport("github.com/goadesign/goa/wshub"))
+	}
+	if hasAsync(version) {
+		imports = append(imports, codegen.SimpleImport("github.com/goadesign/goa/async"))
+	}
+	imports = append(imports, fieldTypeImports(version)...)
 	ctxWr.WriteHeader(title, packageName(version), imports)
 	err = version.IterateResources(func(r *design.ResourceDefinition) error {
 		if !r.SupportsVersion(version.Version) {
 			return nil
 		}
 		return r.IterateActions(func(a *design.ActionDefinition) error {
-			ctxName := codegen.Goify(a.Name, true) + codegen.Goify(a.Parent.Name, true) + "Context"
+			ctxName := codegen.ContextName(a.Name, a.Parent.Name)
 			headers := r.Headers.Merge(a.Headers)
 			if headers != nil && len(headers.Type.ToObject()) == 0 {
 				headers = nil // So that {{if .Headers}} returns false in templates
@@ -186,13 +232,28 @@ func (g *Generator) generateContexts(verdir string, api *design.APIDefinition, v
 				ResourceName: r.Name,
 				ActionName:   a.Name,
 				Payload:      a.Payload,
+				Result:       a.Result,
 				Params:       params,
 				Headers:      headers,
 				Routes:       a.Routes,
 				Responses:    MergeResponses(r.Responses, a.Responses),
+				Href:         resourceData(r, version),
 				API:          api,
 				Version:      version,
 				DefaultPkg:   TargetPackage,
+				WebSocket:    a.WebSocket,
+				LongPoll:     a.LongPoll,
+				Webhook:      a.Webhook,
+				Async:        a.Async,
+				ETag:         a.ETag,
+				Conditional:  a.IsConditional(),
+				Tenant:       a.EffectiveTenant(),
+			}
+			if session := a.EffectiveSession(); session != nil {
+				ctxData.Session = sessionData(session)
+			}
+			if a.HasSparseFields() {
+				ctxData.Fields = a.SparseFieldNames()
 			}
 			return ctxWr.Execute(&ctxData)
 		})
@@ -211,6 +272,16 @@ func BuildEncoderMap(info []*design.EncodingDefinition, encoder bool) (map[strin
 	if len(info) == 0 {
 		return nil, nil
 	}
+	var encodings []*design.EncodingDefinition
+	for _, enc := range info {
+		if !enc.IsPassthrough() {
+			encodings = append(encodings, enc)
+		}
+	}
+	if len(encodings) == 0 {
+		return nil, nil
+	}
+	info = encodings
 	packages := make(map[string]map[string]bool)
 	for _, enc := range info {
 		supporting := enc.SupportingPackages()
@@ -253,8 +324,8 @@ func BuildEncoderMap(info []*design.EncodingDefinition, encoder bool) (map[strin
 			mimeTypes[i] = m
 			i++
 		}
-		first := mimeTypes[0]
 		sort.Strings(mimeTypes)
+		first := mimeTypes[0]
 		var factory string
 		if encoder {
 			if !design.IsGoaEncoder(p) {
@@ -281,6 +352,40 @@ func BuildEncoderMap(info []*design.EncodingDefinition, encoder bool) (map[strin
 	return data, nil
 }
 
+// hasWebSocket returns true if one of the actions exposed by the version is a WebSocket endpoint.
+func hasWebSocket(version *design.APIVersionDefinition) bool {
+	found := false
+	version.IterateResources(func(r *design.ResourceDefinition) error {
+		if !r.SupportsVersion(version.Version) {
+			return nil
+		}
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			if a.IsWebSocket() {
+				found = true
+			}
+			return nil
+		})
+	})
+	return found
+}
+
+// hasAsync returns true if one of the actions exposed by the version is async.
+func hasAsync(version *design.APIVersionDefinition) bool {
+	found := false
+	version.IterateResources(func(r *design.ResourceDefinition) error {
+		if !r.SupportsVersion(version.Version) {
+			return nil
+		}
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			if a.IsAsync() {
+				found = true
+			}
+			return nil
+		})
+	})
+	return found
+}
+
 // generateControllers iterates through the version resources and generates the low level
 // controllers.
 func (g *Generator) generateControllers(verdir string, version *design.APIVersionDefinition) error {
@@ -291,7 +396,10 @@ func (g *Generator) generateControllers(verdir string, version *design.APIVersio
 	}
 	title := fmt.Sprintf("%s: Application Controllers", version.Context())
 	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("bytes"),
+		codegen.SimpleImport("io/ioutil"),
 		codegen.SimpleImport("net/http"),
+		codegen.SimpleImport("time"),
 		codegen.SimpleImport("golang.org/x/net/context"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 	}
@@ -317,11 +425,31 @@ func (g *Generator) generateControllers(verdir string, version *design.APIVersio
 	for _, data := range decoderMap {
 		encoderImports[data.PackagePath] = true
 	}
+	packagePaths := make([]string, 0, len(encoderImports))
 	for packagePath := range encoderImports {
 		if !design.IsGoaEncoder(packagePath) {
-			imports = append(imports, codegen.SimpleImport(packagePath))
+			packagePaths = append(packagePaths, packagePath)
 		}
 	}
+	sort.Strings(packagePaths)
+	for _, packagePath := range packagePaths {
+		imports = append(imports, codegen.SimpleImport(packagePath))
+	}
+	if version.IsDefault() && design.Design.Operations != nil {
+		imports = append(imports, codegen.SimpleImport("github.com/goadesign/goa/async"))
+	}
+	var subscriptions []*design.SubscriptionsDefinition
+	if version.IsDefault() {
+		design.Design.IterateResources(func(r *design.ResourceDefinition) error {
+			if r.IsSubscribable() {
+				subscriptions = append(subscriptions, r.Subscriptions)
+			}
+			return nil
+		})
+	}
+	if len(subscriptions) > 0 {
+		imports = append(imports, codegen.SimpleImport("github.com/goadesign/goa/subscription"))
+	}
 	ctlWr.WriteHeader(title, packageName(version), imports)
 	var controllersData []*ControllerTemplateData
 	version.IterateResources(func(r *design.ResourceDefinition) error {
@@ -330,14 +458,73 @@ func (g *Generator) generateControllers(verdir string, version *design.APIVersio
 		}
 		data := &ControllerTemplateData{Resource: codegen.Goify(r.Name, true)}
 		err := r.IterateActions(func(a *design.ActionDefinition) error {
-			context := fmt.Sprintf("%s%sContext", codegen.Goify(a.Name, true), codegen.Goify(r.Name, true))
+			context := codegen.ContextName(a.Name, r.Name)
 			unmarshal := fmt.Sprintf("unmarshal%s%sPayload", codegen.Goify(a.Name, true), codegen.Goify(r.Name, true))
-			action := map[string]interface{}{
-				"Name":      codegen.Goify(a.Name, true),
-				"Routes":    a.Routes,
-				"Context":   context,
-				"Unmarshal": unmarshal,
-				"Payload":   a.Payload,
+			action := &ActionTemplateData{
+				Name:      codegen.Goify(a.Name, true),
+				Routes:    a.Routes,
+				Context:   context,
+				Unmarshal: unmarshal,
+				Payload:   a.Payload,
+				Proxy:     a.Proxy,
+			}
+			if a.Proxy != nil {
+				action.ProxyVar = codegen.Goify(a.Name, false) + "Proxy"
+				action.ProxyTimeout = durationCode(a.Proxy.Timeout)
+			}
+			if a.Webhook != nil {
+				action.Receiver = codegen.ActionTypeName(a.Name, r.Name, "Receiver")
+			}
+			action.Security = a.EffectiveSecurity()
+			if policy := a.EffectivePolicy(); policy != "" {
+				action.Policy = policy
+				action.PolicyMetadata = metadataCode(a.Metadata)
+			}
+			sensitive := a.SensitiveNames()
+			if audit := a.EffectiveAudit(); audit != nil {
+				if len(sensitive) > 0 {
+					dup := *audit
+					dup.Redact = mergeNames(audit.Redact, sensitive)
+					audit = &dup
+				}
+				action.Audit = audit
+			}
+			if replay := a.EffectiveReplay(); replay != nil {
+				tolerance := replay.Tolerance
+				if tolerance == 0 {
+					tolerance = design.DefaultReplayTolerance
+				}
+				action.Replay = durationCode(tolerance)
+			}
+			action.Quota = a.EffectiveQuota()
+			if rl := a.EffectiveRateLimit(); rl != nil {
+				action.RateLimit = rl
+				action.RateLimitPeriod = durationCode(rl.Period)
+				action.RateLimitRetryAfter = rateLimitRetryAfter(a)
+			}
+			action.Tenant = a.EffectiveTenant()
+			action.NetworkACL = a.EffectiveNetworkACL()
+			action.AbuseSensitive = a.IsAbuseSensitive()
+			action.CSRF = a.EffectiveCSRF() != nil
+			if md := routeMetadata(version, r); len(md) > 0 {
+				action.RouteMetadata = metadataCode(md)
+			}
+			// Use the controller name given by the generated main to NewController.
+			ctrlName := r.Name
+			if !version.IsDefault() {
+				ctrlName += " " + version.Version
+			}
+			if d, ok := a.Timeout(); ok {
+				action.Timeout = durationCode(d)
+				action.TimeoutController = ctrlName
+			}
+			if n := a.EffectiveMaxSize(); n > 0 {
+				action.MaxSize = n
+				action.MaxSizeController = ctrlName
+			}
+			if names := mergeNames(a.PIINames(), sensitive); len(names) > 0 {
+				action.Redact = names
+				action.RedactController = ctrlName
 			}
 			data.Actions = append(data.Actions, action)
 			return nil
@@ -357,6 +544,31 @@ func (g *Generator) generateControllers(verdir string, version *design.APIVersio
 	if err = ctlWr.Execute(controllersData); err != nil {
 		return err
 	}
+	if version.IsDefault() && design.Design.Batch != nil {
+		if err = ctlWr.WriteBatch(design.Design.Batch); err != nil {
+			return err
+		}
+	}
+	if version.IsDefault() && design.Design.Metrics != nil {
+		if err = ctlWr.WriteMetrics(design.Design.Metrics); err != nil {
+			return err
+		}
+	}
+	if version.IsDefault() && design.Design.Operations != nil {
+		if err = ctlWr.WriteOperations(design.Design.Operations); err != nil {
+			return err
+		}
+	}
+	if version.IsDefault() && design.Design.HasQuotas() {
+		if err = ctlWr.WriteQuotaStatus(design.Design); err != nil {
+			return err
+		}
+	}
+	if len(subscriptions) > 0 {
+		if err = ctlWr.WriteSubscriptions(subscriptions); err != nil {
+			return err
+		}
+	}
 	return ctlWr.FormatCode()
 }
 
@@ -371,42 +583,99 @@ func (g *Generator) generateHrefs(verdir string, version *design.APIVersionDefin
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("fmt"),
 	}
+	var resources []*ResourceData
+	version.IterateResources(func(r *design.ResourceDefinition) error {
+		if r.SupportsVersion(version.Version) {
+			resources = append(resources, resourceData(r, version))
+		}
+		return nil
+	})
+	for _, data := range resources {
+		if len(data.BaseParams) > 0 {
+			imports = append(imports,
+				codegen.SimpleImport("golang.org/x/net/context"),
+				codegen.SimpleImport("github.com/goadesign/goa"),
+			)
+			break
+		}
+	}
 	resWr.WriteHeader(title, packageName(version), imports)
-	err = version.IterateResources(func(r *design.ResourceDefinition) error {
-		if !r.SupportsVersion(version.Version) {
-			return nil
+	g.genfiles = append(g.genfiles, hrefFile)
+	for _, data := range resources {
+		if err := resWr.Execute(data); err != nil {
+			return err
 		}
-		m := design.Design.MediaTypeWithIdentifier(r.MediaType)
-		var identifier string
-		if m != nil {
-			identifier = m.Identifier
-		} else {
-			identifier = "plain/text"
+	}
+	return resWr.FormatCode()
+}
+
+// resourceData builds the data used to render the href factories of the given resource. The
+// canonical path parameters that correspond to wildcards of the API base path are listed in
+// BaseParams so that they can be read from the request context.
+func resourceData(r *design.ResourceDefinition, version *design.APIVersionDefinition) *ResourceData {
+	m := design.Design.MediaTypeWithIdentifier(r.MediaType)
+	var identifier string
+	if m != nil {
+		identifier = m.Identifier
+	} else {
+		identifier = "plain/text"
+	}
+	canoTemplate := r.URITemplate(version)
+	canoTemplate = design.WildcardRegex.ReplaceAllLiteralString(canoTemplate, "/%v")
+	var canoParams []string
+	if ca := r.CanonicalAction(); ca != nil {
+		if len(ca.Routes) > 0 {
+			canoParams = ca.Routes[0].Params(version)
 		}
-		canoTemplate := r.URITemplate(version)
-		canoTemplate = design.WildcardRegex.ReplaceAllLiteralString(canoTemplate, "/%v")
-		var canoParams []string
-		if ca := r.CanonicalAction(); ca != nil {
-			if len(ca.Routes) > 0 {
-				canoParams = ca.Routes[0].Params(version)
+	}
+	var baseParams, hrefParams []string
+	if len(canoParams) > 0 {
+		base := make(map[string]bool)
+		for _, p := range design.ExtractWildcards(version.BasePath) {
+			base[p] = true
+		}
+		for _, p := range canoParams {
+			if base[p] {
+				baseParams = append(baseParams, p)
+			} else {
+				hrefParams = append(hrefParams, p)
 			}
 		}
+	}
 
-		data := ResourceData{
-			Name:              codegen.Goify(r.Name, true),
-			Identifier:        identifier,
-			Description:       r.Description,
-			Type:              m,
-			CanonicalTemplate: canoTemplate,
-			CanonicalParams:   canoParams,
-		}
-		return resWr.Execute(&data)
-	})
-	g.genfiles = append(g.genfiles, hrefFile)
-	if err != nil {
-		return err
+	return &ResourceData{
+		Name:              codegen.Goify(r.Name, true),
+		Identifier:        identifier,
+		Description:       r.Description,
+		Type:              m,
+		CanonicalTemplate: canoTemplate,
+		CanonicalParams:   canoParams,
+		BaseParams:        baseParams,
+		HrefParams:        hrefParams,
 	}
-	return resWr.FormatCode()
+}
+
+// fieldTypeImports returns the imports of the packages that define the struct field types set
+// with the "struct:field:type" metadata on the version media types, user types and payloads.
+func fieldTypeImports(version *design.APIVersionDefinition) []*codegen.ImportSpec {
+	var atts []*design.AttributeDefinition
+	version.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		atts = append(atts, mt.AttributeDefinition)
+		return nil
+	})
+	version.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		atts = append(atts, ut.AttributeDefinition)
+		return nil
+	})
+	version.IterateResources(func(r *design.ResourceDefinition) error {
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			if a.Payload != nil {
+				atts = append(atts, a.Payload.AttributeDefinition)
+			}
+			return nil
+		})
+	})
+	return codegen.FieldTypeImports(atts...)
 }
 
 // generateMediaTypes iterates through the media types and generate the data structures and
@@ -422,6 +691,7 @@ func (g *Generator) generateMediaTypes(verdir string, version *design.APIVersion
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("time"),
+		codegen.SimpleImport("golang.org/x/net/context"),
 	}
 	if !version.IsDefault() {
 		appPkg, err := AppPackagePath()
@@ -430,6 +700,7 @@ func (g *Generator) generateMediaTypes(verdir string, version *design.APIVersion
 		}
 		imports = append(imports, codegen.SimpleImport(appPkg))
 	}
+	imports = append(imports, fieldTypeImports(version)...)
 	mtWr.WriteHeader(title, packageName(version), imports)
 	err = version.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
 		data := &MediaTypeTemplateData{
@@ -462,6 +733,7 @@ func (g *Generator) generateUserTypes(verdir string, version *design.APIVersionD
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("fmt"),
 		codegen.SimpleImport("time"),
+		codegen.SimpleImport("golang.org/x/net/context"),
 	}
 	if !version.IsDefault() {
 		appPkg, err := AppPackagePath()
@@ -470,6 +742,7 @@ func (g *Generator) generateUserTypes(verdir string, version *design.APIVersionD
 		}
 		imports = append(imports, codegen.SimpleImport(appPkg))
 	}
+	imports = append(imports, fieldTypeImports(version)...)
 	utWr.WriteHeader(title, packageName(version), imports)
 	err = version.IterateUserTypes(func(t *design.UserTypeDefinition) error {
 		data := &UserTypeTemplateData{
@@ -485,3 +758,396 @@ func (g *Generator) generateUserTypes(verdir string, version *design.APIVersionD
 	}
 	return utWr.FormatCode()
 }
+
+// generateSessions generates the state types and the cookie descriptions of the sessions used by
+// the version actions.
+func (g *Generator) generateSessions(verdir string, version *design.APIVersionDefinition) error {
+	sessionsFile := filepath.Join(verdir, "sessions.go")
+	sessions := sessionsData(version)
+	if len(sessions) == 0 {
+		os.Remove(sessionsFile)
+		return nil
+	}
+	utWr, err := NewUserTypesWriter(sessionsFile)
+	if err != nil {
+		panic(err) // bug
+	}
+	title := fmt.Sprintf("%s: Application Sessions", version.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("time"),
+	}
+	if !version.IsDefault() {
+		appPkg, err := AppPackagePath()
+		if err != nil {
+			return err
+		}
+		imports = append(imports, codegen.SimpleImport(appPkg))
+	}
+	imports = append(imports, fieldTypeImports(version)...)
+	utWr.WriteHeader(title, packageName(version), imports)
+	g.genfiles = append(g.genfiles, sessionsFile)
+	fn := template.FuncMap{"durationCode": durationCode}
+	for _, s := range sessions {
+		if err := utWr.ExecuteTemplate("cookie", sessionCookieT, fn, s); err != nil {
+			return err
+		}
+		att := s.Session.Attributes
+		if att == nil {
+			att = &design.AttributeDefinition{Type: design.Object{}}
+		}
+		ut := &design.UserTypeDefinition{
+			AttributeDefinition: &design.AttributeDefinition{
+				Type:        att.Type,
+				Description: fmt.Sprintf("%s is the state of the %s.", s.TypeName, s.Session.Context()),
+				Validation:  att.Validation,
+			},
+			TypeName: s.TypeName,
+		}
+		data := &UserTypeTemplateData{
+			UserType:   ut,
+			Versioned:  version.Version != "",
+			DefaultPkg: TargetPackage,
+		}
+		if err := utWr.Execute(data); err != nil {
+			return err
+		}
+	}
+	return utWr.FormatCode()
+}
+
+// sessionsData returns the data needed to render the sessions used by the version actions in the
+// order of the resources using them.
+func sessionsData(version *design.APIVersionDefinition) []*SessionTemplateData {
+	seen := make(map[*design.SessionDefinition]bool)
+	var sessions []*SessionTemplateData
+	version.IterateResources(func(r *design.ResourceDefinition) error {
+		if !r.SupportsVersion(version.Version) {
+			return nil
+		}
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			if s := a.EffectiveSession(); s != nil && !seen[s] {
+				seen[s] = true
+				sessions = append(sessions, sessionData(s))
+			}
+			return nil
+		})
+	})
+	return sessions
+}
+
+// sessionData returns the data needed to render the given session. The state type of the API
+// session is "Session", the state type of resource sessions is the resource name followed by
+// "Session".
+func sessionData(s *design.SessionDefinition) *SessionTemplateData {
+	name := "Session"
+	if r, ok := s.Parent.(*design.ResourceDefinition); ok {
+		name = codegen.Goify(r.Name, true) + name
+	}
+	return &SessionTemplateData{
+		TypeName:  name,
+		CookieVar: codegen.Goify(name, false) + "Cookie",
+		Session:   s,
+	}
+}
+
+// generatePatterns declares the variables holding the compiled regular expressions of the patterns
+// used by the validation code generated for the version.
+func (g *Generator) generatePatterns(verdir string, version *design.APIVersionDefinition) error {
+	patternsFile := filepath.Join(verdir, "patterns.go")
+	patterns := codegen.PatternVars()
+	if len(patterns) == 0 {
+		os.Remove(patternsFile)
+		return nil
+	}
+	file, err := codegen.SourceFileFor(patternsFile)
+	if err != nil {
+		return err
+	}
+	title := fmt.Sprintf("%s: Application Validation Patterns", version.Context())
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("regexp")}
+	file.WriteHeader(title, packageName(version), imports)
+	g.genfiles = append(g.genfiles, patternsFile)
+	if err := file.ExecuteTemplate("patterns", patternsT, nil, patterns); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// generateCallbacks generates the typed webhook dispatchers of the callbacks defined by the
+// version actions.
+func (g *Generator) generateCallbacks(verdir string, version *design.APIVersionDefinition) error {
+	callbacksFile := filepath.Join(verdir, "callbacks.go")
+	callbacks, err := callbacksData(version)
+	if err != nil {
+		return err
+	}
+	if len(callbacks) == 0 {
+		os.Remove(callbacksFile)
+		return nil
+	}
+	file, err := codegen.SourceFileFor(callbacksFile)
+	if err != nil {
+		return err
+	}
+	title := fmt.Sprintf("%s: Application Callbacks", version.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("golang.org/x/net/context"),
+		codegen.SimpleImport("time"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	if !version.IsDefault() {
+		appPkg, err := AppPackagePath()
+		if err != nil {
+			return err
+		}
+		imports = append(imports, codegen.SimpleImport(appPkg))
+	}
+	file.WriteHeader(title, packageName(version), imports)
+	g.genfiles = append(g.genfiles, callbacksFile)
+	fn := template.FuncMap{"durationCode": durationCode}
+	if err := file.ExecuteTemplate("callbacks", callbacksT, fn, callbacks); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// callbacksData returns the data needed to render the webhook dispatchers of the callbacks defined
+// by the version actions sorted by resource, action and event name.
+func callbacksData(version *design.APIVersionDefinition) ([]*CallbackTemplateData, error) {
+	var callbacks []*CallbackTemplateData
+	names := make(map[string]*design.CallbackDefinition)
+	err := version.IterateResources(func(r *design.ResourceDefinition) error {
+		if !r.SupportsVersion(version.Version) {
+			return nil
+		}
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			return a.IterateCallbacks(func(c *design.CallbackDefinition) error {
+				name := codegen.Goify(c.Name, true) + "Webhook"
+				if other, ok := names[name]; ok {
+					return fmt.Errorf("%s and %s generate the same dispatcher %s", c.Context(), other.Context(), name)
+				}
+				names[name] = c
+				mt := design.Design.MediaTypeWithIdentifier(c.MediaType)
+				if mt == nil {
+					return fmt.Errorf("unknown media type %#v of %s", c.MediaType, c.Context())
+				}
+				projected, _, err := mt.Project("default")
+				if err != nil {
+					return err
+				}
+				callbacks = append(callbacks, &CallbackTemplateData{
+					Name:       name,
+					Callback:   c,
+					Resource:   r.Name,
+					Action:     a.Name,
+					PayloadRef: codegen.GoPackageTypeRef(projected, projected.AllRequired(), !version.IsDefault(), TargetPackage, 0),
+				})
+				return nil
+			})
+		})
+	})
+	return callbacks, err
+}
+
+// generateWebhooks generates the receivers and event dispatchers of the inbound webhooks received
+// by the version actions.
+func (g *Generator) generateWebhooks(verdir string, version *design.APIVersionDefinition) error {
+	webhooksFile := filepath.Join(verdir, "webhooks.go")
+	webhooks := webhooksData(version)
+	if len(webhooks) == 0 {
+		os.Remove(webhooksFile)
+		return nil
+	}
+	file, err := codegen.SourceFileFor(webhooksFile)
+	if err != nil {
+		return err
+	}
+	title := fmt.Sprintf("%s: Application Webhooks", version.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("errors"),
+		codegen.SimpleImport("time"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	if !version.IsDefault() {
+		appPkg, err := AppPackagePath()
+		if err != nil {
+			return err
+		}
+		imports = append(imports, codegen.SimpleImport(appPkg))
+	}
+	file.WriteHeader(title, packageName(version), imports)
+	g.genfiles = append(g.genfiles, webhooksFile)
+	fn := template.FuncMap{"durationCode": durationCode}
+	if err := file.ExecuteTemplate("webhooks", webhooksT, fn, webhooks); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// generateSecurity generates the descriptions of the API security schemes and the functions that
+// mount the middleware enforcing them.
+func (g *Generator) generateSecurity(verdir string, api *design.APIDefinition) error {
+	securityFile := filepath.Join(verdir, "security.go")
+	var schemes []*design.SecuritySchemeDefinition
+	api.IterateSecuritySchemes(func(s *design.SecuritySchemeDefinition) error {
+		schemes = append(schemes, s)
+		return nil
+	})
+	if len(schemes) == 0 {
+		os.Remove(securityFile)
+		return nil
+	}
+	file, err := codegen.SourceFileFor(securityFile)
+	if err != nil {
+		return err
+	}
+	title := fmt.Sprintf("%s: Application Security", api.Context())
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa")}
+	for _, s := range schemes {
+		if s.Tolerance > 0 {
+			imports = append(imports, codegen.SimpleImport("time"))
+			break
+		}
+	}
+	var claims, managed bool
+	for _, s := range schemes {
+		claims = claims || s.Claims != nil
+		managed = managed || s.ManagesKeys()
+	}
+	if claims {
+		imports = append(imports,
+			codegen.SimpleImport("encoding/json"),
+			codegen.SimpleImport("golang.org/x/net/context"),
+		)
+	}
+	if managed {
+		imports = append(imports, codegen.SimpleImport("github.com/goadesign/goa/apikey"))
+	}
+	if claims || managed {
+		imports = append(imports, codegen.SimpleImport("github.com/goadesign/goa/security"))
+	}
+	file.WriteHeader(title, TargetPackage, imports)
+	g.genfiles = append(g.genfiles, securityFile)
+	fn := template.FuncMap{"securityType": securityType, "durationCode": durationCode}
+	if err := file.ExecuteTemplate("security", securityT, fn, schemes); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// securityType returns the name of the goa type describing security schemes of the given kind.
+func securityType(kind string) string {
+	switch kind {
+	case design.JWTSecurityKind:
+		return "JWTSecurity"
+	case design.OIDCSecurityKind:
+		return "OIDCSecurity"
+	case design.APIKeySecurityKind:
+		return "APIKeySecurity"
+	case design.OAuth2SecurityKind:
+		return "OAuth2Security"
+	case design.HMACSecurityKind:
+		return "HMACSecurity"
+	case design.MutualTLSSecurityKind:
+		return "MutualTLSSecurity"
+	}
+	return "BasicAuthSecurity"
+}
+
+// webhooksData returns the data needed to render the receivers and dispatchers of the inbound
+// webhooks received by the version actions.
+func webhooksData(version *design.APIVersionDefinition) []*WebhookTemplateData {
+	var webhooks []*WebhookTemplateData
+	version.IterateResources(func(r *design.ResourceDefinition) error {
+		if !r.SupportsVersion(version.Version) {
+			return nil
+		}
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			if a.Webhook == nil {
+				return nil
+			}
+			ctxName := codegen.ContextName(a.Name, r.Name)
+			data := &WebhookTemplateData{
+				Name:     codegen.ActionTypeName(a.Name, r.Name, ""),
+				Context:  ctxName,
+				Resource: r.Name,
+				Action:   a.Name,
+				Webhook:  a.Webhook,
+			}
+			a.Webhook.IterateEvents(func(name string, t *design.UserTypeDefinition) error {
+				data.Events = append(data.Events, &WebhookEventTemplateData{
+					Name:    name,
+					Handler: webhookEventName(name),
+					TypeRef: codegen.GoPackageTypeRef(t, t.AllRequired(), !version.IsDefault(), TargetPackage, 0),
+				})
+				return nil
+			})
+			webhooks = append(webhooks, data)
+			return nil
+		})
+	})
+	return webhooks
+}
+
+// webhookEventName returns the Go identifier used to name the handler and decoder of the webhook
+// event with the given name, e.g. "CustomerCreated" for "customer.created".
+func webhookEventName(name string) string {
+	return codegen.Goify(strings.Replace(name, ".", "_", -1), true)
+}
+
+// mergeNames returns the sorted union of the given lists of names.
+func mergeNames(a, b []string) []string {
+	set := make(map[string]bool, len(a)+len(b))
+	for _, n := range a {
+		set[n] = true
+	}
+	for _, n := range b {
+		set[n] = true
+	}
+	names := make([]string, 0, len(set))
+	for n := range set {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// durationCode returns the Go expression for the given duration, e.g. "10 * time.Second".
+func durationCode(d time.Duration) string {
+	switch {
+	case d%time.Second == 0:
+		return fmt.Sprintf("%d * time.Second", d/time.Second)
+	case d%time.Millisecond == 0:
+		return fmt.Sprintf("%d * time.Millisecond", d/time.Millisecond)
+	}
+	return fmt.Sprintf("time.Duration(%d)", d)
+}
+
+// metadataCode returns the Go expression for the given metadata, "nil" if there is none.
+func metadataCode(md dslengine.MetadataDefinition) string {
+	if len(md) == 0 {
+		return "nil"
+	}
+	return fmt.Sprintf("%#v", map[string][]string(md))
+}
+
+// routeMetadata returns the metadata that override the route options of the resource actions:
+// the "router:" keys of the version metadata overridden by the keys of the resource metadata.
+func routeMetadata(version *design.APIVersionDefinition, r *design.ResourceDefinition) dslengine.MetadataDefinition {
+	md := make(dslengine.MetadataDefinition)
+	for _, source := range []dslengine.MetadataDefinition{version.Metadata, r.Metadata} {
+		for k, v := range source {
+			if strings.HasPrefix(k, "router:") {
+				md[k] = v
+			}
+		}
+	}
+	return md
+}
+
+// durationSeconds returns the number of whole seconds in d.
+func durationSeconds(d time.Duration) int {
+	return int(d / time.Second)
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
@@ -67,6 +68,165 @@ var _ = Describe("Generate", func() {
 		})
 	})
 
+	Context("with an OIDC security scheme", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "test api"},
+				SecuritySchemes: map[string]*design.SecuritySchemeDefinition{
+					"google": {
+						Kind:       design.OIDCSecurityKind,
+						SchemeName: "google",
+						In:         "header",
+						Name:       "Authorization",
+						Issuer:     "https://accounts.google.com",
+						Audience:   "cellar",
+						Claims: &design.AttributeDefinition{
+							Type: design.Object{
+								"email": &design.AttributeDefinition{Type: design.String},
+							},
+						},
+					},
+				},
+			}
+		})
+
+		It("generates the scheme description and the principal", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "security.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			security := string(content)
+			Ω(security).Should(ContainSubstring("func NewGoogleSecurity() *goa.OIDCSecurity {"))
+			Ω(security).Should(ContainSubstring(`Issuer:   "https://accounts.google.com",`))
+			Ω(security).Should(ContainSubstring(`Audience: "cellar",`))
+			Ω(security).Should(ContainSubstring("type GooglePrincipal struct {\n\tEmail *string `json:\"email,omitempty\" xml:\"email,omitempty\"`\n}"))
+			Ω(security).Should(ContainSubstring("func ContextGooglePrincipal(ctx context.Context) (*GooglePrincipal, error) {"))
+		})
+	})
+
+	Context("with a HMAC security scheme", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "test api"},
+				SecuritySchemes: map[string]*design.SecuritySchemeDefinition{
+					"signed": {
+						Kind:       design.HMACSecurityKind,
+						SchemeName: "signed",
+						In:         "header",
+						Name:       "X-Signature",
+						Tolerance:  2 * time.Minute,
+					},
+				},
+			}
+		})
+
+		It("generates the scheme description", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "security.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			security := string(content)
+			Ω(security).Should(ContainSubstring("func NewSignedSecurity() *goa.HMACSecurity {"))
+			Ω(security).Should(ContainSubstring(`Name:      "X-Signature",`))
+			Ω(security).Should(ContainSubstring(`Tolerance: 120 * time.Second,`))
+			Ω(security).ShouldNot(ContainSubstring(`In:`))
+		})
+	})
+
+	Context("with an API key security scheme that manages its keys", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "test api"},
+				SecuritySchemes: map[string]*design.SecuritySchemeDefinition{
+					"key": {
+						Kind:       design.APIKeySecurityKind,
+						SchemeName: "key",
+						In:         "header",
+						Name:       "X-API-Key",
+						KeysPath:   "/keys",
+						Scopes:     map[string]string{"api:read": "Read access"},
+					},
+				},
+			}
+		})
+
+		It("generates the function that mounts the key manager", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "security.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			security := string(content)
+			Ω(security).Should(ContainSubstring(`"api:read": "Read access",`))
+			Ω(security).Should(ContainSubstring("func MountKeyKeys(service *goa.Service, store apikey.Store) *apikey.Manager {"))
+			Ω(security).Should(ContainSubstring(`manager := apikey.NewManager("key", "/keys", store)`))
+			Ω(security).Should(ContainSubstring("UseKeyMiddleware(service, security.APIKey(NewKeySecurity(), manager.Validate))"))
+		})
+	})
+
+	Context("with a mutual TLS security scheme", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "test api", Schemes: []string{"https"}},
+				SecuritySchemes: map[string]*design.SecuritySchemeDefinition{
+					"partners": {
+						Kind:       design.MutualTLSSecurityKind,
+						SchemeName: "partners",
+						ClientCA:   "ca.pem",
+					},
+				},
+			}
+		})
+
+		It("generates the scheme description", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "security.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			security := string(content)
+			Ω(security).Should(ContainSubstring("func NewPartnersSecurity() *goa.MutualTLSSecurity {"))
+			Ω(security).Should(ContainSubstring(`ClientCA: "ca.pem",`))
+		})
+	})
+
+	Context("with a metrics endpoint", func() {
+		BeforeEach(func() {
+			api := &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "test api", BasePath: "/api"},
+			}
+			api.Metrics = &design.MetricsDefinition{Path: "/metrics", Parent: api}
+			design.Design = api
+		})
+
+		It("generates the function that mounts it", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "controllers.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("func MountMetrics(service *goa.Service, m *goa.PrometheusMetrics) {"))
+			Ω(string(content)).Should(ContainSubstring(`goa.MountMetrics(service, "/api/metrics", m)`))
+		})
+	})
+
+	Context("with a quota", func() {
+		BeforeEach(func() {
+			api := &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "test api", BasePath: "/api"},
+				Security: &design.SecurityDefinition{
+					Scheme: &design.SecuritySchemeDefinition{Kind: design.JWTSecurityKind, SchemeName: "jwt"},
+				},
+			}
+			api.Quota = &design.QuotaDefinition{
+				Limits: []*design.QuotaLimitDefinition{{Unit: design.QuotaRequests, Limit: 1000, Period: design.Daily}},
+				Parent: api,
+			}
+			design.Design = api
+		})
+
+		It("generates the function that mounts the quota status endpoint", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "controllers.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("func MountQuotaStatus(service *goa.Service) {"))
+			Ω(string(content)).Should(ContainSubstring(`h = goa.Secure("jwt", h)
+	service.Mux.Handle("GET", "/api/quota", ctrl.MuxHandler("show", h, nil))`))
+		})
+	})
+
 	Context("with a simple API", func() {
 		var contextsCode, controllersCode, hrefsCode, mediaTypesCode, version string
 		var payload *design.UserTypeDefinition
@@ -246,6 +406,71 @@ var _ = Describe("Generate", func() {
 			})
 		})
 
+		Context("with an authorization policy", func() {
+			BeforeEach(func() {
+				get := design.Design.Resources["Widget"].Actions["get"]
+				get.Policy = "widget:read"
+				get.Metadata = dslengine.MetadataDefinition{"owner": {"id"}}
+			})
+
+			It("generates the enforcement point", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "controllers.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(ContainSubstring(`if err := service.Authorize(ctx, "widget:read", rctx, map[string][]string{"owner": []string{"id"}}); err != nil {`))
+			})
+		})
+
+		Context("with a webhook and a custom context suffix", func() {
+			BeforeEach(func() {
+				codegen.ContextSuffix = "Ctx"
+				get := design.Design.Resources["Widget"].Actions["get"]
+				get.Webhook = &design.WebhookDefinition{
+					Provider:      design.WebhookProviderHMAC,
+					SigningScheme: design.SigningHMACSHA256,
+					Parent:        get,
+				}
+			})
+
+			AfterEach(func() {
+				codegen.ContextSuffix = codegen.DefaultContextSuffix
+			})
+
+			It("derives the receiver name from the action and resource names", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "controllers.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(ContainSubstring("h = GetWidgetReceiver.Middleware()(h)"))
+				content, err = ioutil.ReadFile(filepath.Join(outDir, "app", "webhooks.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(ContainSubstring("var GetWidgetReceiver = &goa.WebhookReceiver{"))
+			})
+		})
+
+		Context("with a resource session", func() {
+			BeforeEach(func() {
+				res := design.Design.Resources["Widget"]
+				res.Session = &design.SessionDefinition{
+					CookieName: "widget_session",
+					MaxAge:     time.Hour,
+					Attributes: &design.AttributeDefinition{Type: design.Object{"user_id": {Type: design.Integer}}},
+					Parent:     res,
+				}
+			})
+
+			It("generates the session type and accessors", func() {
+				Ω(genErr).Should(BeNil())
+				content, err := ioutil.ReadFile(filepath.Join(outDir, "app", "sessions.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(ContainSubstring("type WidgetSession struct {"))
+				Ω(string(content)).Should(ContainSubstring(`Name:   "widget_session",`))
+				Ω(string(content)).Should(ContainSubstring("MaxAge: 3600 * time.Second,"))
+				content, err = ioutil.ReadFile(filepath.Join(outDir, "app", "contexts.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(ContainSubstring("func (ctx *GetWidgetContext) Session() (*WidgetSession, error) {"))
+			})
+		})
+
 	})
 })
 
@@ -288,6 +513,26 @@ var _ = Describe("BuildEncoderMap", func() {
 		})
 	})
 
+	Context("with a passthrough definition", func() {
+		BeforeEach(func() {
+			passthrough := &design.EncodingDefinition{
+				MIMETypes: []string{design.WildcardMediaType, "image/png"},
+			}
+			json := &design.EncodingDefinition{
+				MIMETypes: []string{"application/json"},
+			}
+			info = append(info, passthrough, json)
+			encoder = true
+		})
+
+		It("ignores the passthrough definition", func() {
+			Ω(resErr).ShouldNot(HaveOccurred())
+			Ω(data).Should(HaveLen(1))
+			Ω(data).Should(HaveKey("json"))
+			Ω(data["json"].Default).Should(BeTrue())
+		})
+	})
+
 	Context("with a single definition using a single known MIME type for decoding", func() {
 		BeforeEach(func() {
 			simple := &design.EncodingDefinition{
@@ -452,7 +697,7 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 		return ctrl.Get(rctx)
 	}
 	mux.Handle("GET", "/:id", ctrl.MuxHandler("Get", h, nil))
-	goa.Info(goa.RootContext, "mount", goa.KV{"ctrl", "Widget"},{{if .version}} goa.KV{"version", "{{.version}}"},{{end}} goa.KV{"action", "Get"}, goa.KV{"route", "GET /:id"})
+	service.LogInfo("mount", goa.KV{"ctrl", "Widget"},{{if .version}} goa.KV{"version", "{{.version}}"},{{end}} goa.KV{"action", "Get"}, goa.KV{"route", "GET /:id"})
 }
 `
 
@@ -510,7 +755,7 @@ func MountWidgetController(service *goa.Service, ctrl WidgetController) {
 		return ctrl.Get(rctx)
 	}
 	mux.Handle("GET", "/:id", ctrl.MuxHandler("Get", h, unmarshalGetWidgetPayload))
-	goa.Info(goa.RootContext, "mount", goa.KV{"ctrl", "Widget"}, goa.KV{"action", "Get"}, goa.KV{"route", "GET /:id"})
+	service.LogInfo("mount", goa.KV{"ctrl", "Widget"}, goa.KV{"action", "Get"}, goa.KV{"route", "GET /:id"})
 }
 
 // unmarshalGetWidgetPayload unmarshals the request body into the context request data Payload field.
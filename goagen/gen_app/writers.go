@@ -3,6 +3,7 @@ package genapp
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -23,6 +24,9 @@ type (
 		CtxNewTmpl  *template.Template
 		CtxRespTmpl *template.Template
 		PayloadTmpl *template.Template
+
+		// projections records the result projection functions already written.
+		projections map[string]bool
 	}
 
 	// ControllersWriter generate code for a goa application handlers.
@@ -64,12 +68,60 @@ type (
 		ActionName   string // e.g. "list"
 		Params       *design.AttributeDefinition
 		Payload      *design.UserTypeDefinition
+		Result       *design.UserTypeDefinition
 		Headers      *design.AttributeDefinition
 		Routes       []*design.RouteDefinition
 		Responses    map[string]*design.ResponseDefinition
 		API          *design.APIDefinition
 		Version      *design.APIVersionDefinition
 		DefaultPkg   string
+		Href         *ResourceData               // Href factory data of the action resource, may be nil
+		WebSocket    *design.WebSocketDefinition // WebSocket endpoint of the action, may be nil
+		LongPoll     *design.LongPollDefinition  // Long-poll semantics of the action, may be nil
+		Webhook      *design.WebhookDefinition   // Inbound webhook received by the action, may be nil
+		Async        *design.AsyncDefinition     // Async semantics of the action, may be nil
+		Session      *SessionTemplateData        // Session used by the action, may be nil
+		ETag         *design.ETagDefinition      // ETag of the successful responses of the action, may be nil
+		Conditional  bool                        // Whether the action or its response media types define an ETag
+		Tenant       *design.TenantDefinition    // Tenant of the actions of multi-tenant APIs, may be nil
+		Fields       []string                    // Attributes that may be listed in the fields query parameter, nil if the action does not support sparse fieldsets
+	}
+
+	// SessionTemplateData contains the information required to generate the state type and
+	// the cookie of a session.
+	SessionTemplateData struct {
+		TypeName  string                    // Name of the state type, e.g. "AccountSession"
+		CookieVar string                    // Name of the cookie variable, e.g. "accountSessionCookie"
+		Session   *design.SessionDefinition // Session definition
+	}
+
+	// CallbackTemplateData contains the information required to generate the webhook
+	// dispatcher of a callback.
+	CallbackTemplateData struct {
+		Name       string                     // Dispatcher type name, e.g. "OrderShippedWebhook"
+		Callback   *design.CallbackDefinition // Callback definition
+		Resource   string                     // Name of the resource of the action defining the callback
+		Action     string                     // Name of the action defining the callback
+		PayloadRef string                     // Go type reference of the payload, e.g. "*OrderShipped"
+	}
+
+	// WebhookTemplateData contains the information required to generate the receiver and the
+	// event dispatcher of an inbound webhook.
+	WebhookTemplateData struct {
+		Name     string                      // Prefix of the generated names, e.g. "GithubHooks"
+		Context  string                      // Name of the action context, e.g. "GithubHooksContext"
+		Resource string                      // Name of the resource of the action receiving the webhook
+		Action   string                      // Name of the action receiving the webhook
+		Webhook  *design.WebhookDefinition   // Webhook definition
+		Events   []*WebhookEventTemplateData // Handled events sorted by name
+	}
+
+	// WebhookEventTemplateData contains the information required to generate the code that
+	// handles a webhook event.
+	WebhookEventTemplateData struct {
+		Name    string // Event name, e.g. "issue_comment"
+		Handler string // Go name of the event handler and decoder, e.g. "IssueComment"
+		TypeRef string // Go type reference of the event payload, e.g. "*IssueCommentEvent"
 	}
 
 	// MediaTypeTemplateData contains all the information used by the template to redner the
@@ -91,12 +143,47 @@ type (
 	// ControllerTemplateData contains the information required to generate an action handler.
 	ControllerTemplateData struct {
 		Resource   string                          // Lower case plural resource name, e.g. "bottles"
-		Actions    []map[string]interface{}        // Array of actions, each action has keys "Name", "Routes", "Context" and "Unmarshal"
+		Actions    []*ActionTemplateData           // Resource actions
 		Version    *design.APIVersionDefinition    // Controller API version
 		EncoderMap map[string]*EncoderTemplateData // Encoder data indexed by package path
 		DecoderMap map[string]*EncoderTemplateData // Decoder data indexed by package path
 	}
 
+	// ActionTemplateData contains the information required to generate the code that mounts an
+	// action handler. The fields that describe optional features are zero if the action does not
+	// use them.
+	ActionTemplateData struct {
+		Name                string                       // Action name, e.g. "Show"
+		Routes              []*design.RouteDefinition    // Action routes
+		Context             string                       // Name of the action context data structure
+		Unmarshal           string                       // Name of the payload unmarshal function
+		Payload             *design.UserTypeDefinition   // Action payload, may be nil
+		Proxy               *design.ProxyDefinition      // Upstream the requests are forwarded to, may be nil
+		ProxyVar            string                       // Name of the reverse proxy variable
+		ProxyTimeout        string                       // Code of the reverse proxy timeout duration
+		Receiver            string                       // Name of the webhook receiver variable
+		Security            *design.SecurityDefinition   // Security of the action, may be nil
+		Policy              string                       // Authorization policy of the action
+		PolicyMetadata      string                       // Code of the metadata given to the authorizer
+		Audit               *design.AuditDefinition      // Audit of the action, may be nil
+		Replay              string                       // Code of the replay protection tolerance duration
+		Quota               *design.QuotaDefinition      // Quota of the action, may be nil
+		RateLimit           *design.RateLimitDefinition  // Rate limit of the action, may be nil
+		RateLimitPeriod     string                       // Code of the rate limit period duration
+		RateLimitRetryAfter string                       // Code of the rate limit Retry-After policy
+		Tenant              *design.TenantDefinition     // Tenant of the actions of multi-tenant APIs, may be nil
+		NetworkACL          *design.NetworkACLDefinition // Network ACL of the action, may be nil
+		AbuseSensitive      bool                         // Whether the action requests are checked for abuse
+		CSRF                bool                         // Whether the action is CSRF protected
+		RouteMetadata       string                       // Code of the route options metadata of the resource
+		Timeout             string                       // Code of the action timeout duration
+		TimeoutController   string                       // Name of the controller the timeout applies to
+		MaxSize             int64                        // Maximum request body size, 0 if not limited
+		MaxSizeController   string                       // Name of the controller the maximum size applies to
+		Redact              []string                     // Names of the PII and sensitive values redacted from the logs
+		RedactController    string                       // Name of the controller the redaction applies to
+	}
+
 	// ResourceData contains the information required to generate the resource GoGenerator
 	ResourceData struct {
 		Name              string                      // Name of resource
@@ -105,6 +192,45 @@ type (
 		Type              *design.MediaTypeDefinition // Type of resource media type
 		CanonicalTemplate string                      // CanonicalFormat represents the resource canonical path in the form of a fmt.Sprintf format.
 		CanonicalParams   []string                    // CanonicalParams is the list of parameter names that appear in the resource canonical path in order.
+		BaseParams        []string                    // BaseParams is the list of canonical path parameters that are API base path parameters.
+		HrefParams        []string                    // HrefParams is the list of canonical path parameters that are not API base path parameters.
+	}
+
+	// BuilderField contains the data needed to render the setter of a payload builder.
+	BuilderField struct {
+		// Name is the attribute name as defined in the design, e.g. "vintage".
+		Name string
+		// Setter is the name of the builder setter method, e.g. "Vintage".
+		Setter string
+		// Field is the name of the payload struct field, e.g. "Vintage".
+		Field string
+		// Type is the Go type of the setter argument.
+		Type string
+		// Pointer is true if the payload struct field is a pointer to a primitive type.
+		Pointer bool
+		// Default is the Go literal for the attribute default value if any.
+		Default string
+	}
+
+	// Enum contains the data needed to render the constants listing the values of an attribute
+	// enum validation together with the helper function that validates values against them.
+	Enum struct {
+		// Name is the prefix of the constant names, e.g. "BottleRating".
+		Name string
+		// Attribute is the name of the attribute as defined in the design, e.g. "rating".
+		Attribute string
+		// Type is the Go type of the constants.
+		Type string
+		// Values lists the constants in the order of the enum validation values.
+		Values []*EnumValue
+	}
+
+	// EnumValue contains the data needed to render a single enum constant.
+	EnumValue struct {
+		// Name is the name of the constant, e.g. "BottleRatingGood".
+		Name string
+		// Literal is the Go literal of the enum value, e.g. "\"good\"".
+		Literal string
 	}
 
 	// EncoderTemplateData contains the data needed to render the registration code for a single
@@ -128,6 +254,11 @@ func (c *ContextTemplateData) Versioned() bool {
 	return !c.Version.IsDefault()
 }
 
+// ConnName returns the name of the typed WebSocket connection type, e.g. "ChatRoomConn".
+func (c *ContextTemplateData) ConnName() string {
+	return codegen.ActionTypeName(c.ActionName, c.ResourceName, "Conn")
+}
+
 // IsPathParam returns true if the given parameter name corresponds to a path parameter for all
 // the context action routes. Such parameter is required but does not need to be validated as
 // httprouter takes care of that.
@@ -157,6 +288,13 @@ func (c *ContextTemplateData) MustValidate(name string) bool {
 	return c.Params.IsRequired(name) && !c.IsPathParam(name)
 }
 
+// HasTenantField returns true if the context has a Tenant field holding the tenant of the request,
+// that is if the action belongs to a multi-tenant API and none of its parameters already uses the
+// field name.
+func (c *ContextTemplateData) HasTenantField() bool {
+	return c.Tenant != nil && !hasField(c.Params, "Tenant")
+}
+
 // IterateResponses iterates through the responses sorted by status code.
 func (c *ContextTemplateData) IterateResponses(it func(*design.ResponseDefinition) error) error {
 	m := make(map[int]*design.ResponseDefinition, len(c.Responses))
@@ -182,20 +320,25 @@ func NewContextsWriter(filename string) (*ContextsWriter, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &ContextsWriter{SourceFile: file}, nil
+	return &ContextsWriter{SourceFile: file, projections: make(map[string]bool)}, nil
 }
 
 // Execute writes the code for the context types to the writer.
 func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 	fn := template.FuncMap{
 		"hasAPIVersion": hasAPIVersion,
+		"typedHeader":   typedHeader,
 	}
 	if err := w.ExecuteTemplate("context", ctxT, fn, data); err != nil {
 		return err
 	}
 	fn = template.FuncMap{
-		"newCoerceData":  newCoerceData,
-		"arrayAttribute": arrayAttribute,
+		"newCoerceData":       newCoerceData,
+		"newHeaderCoerceData": newHeaderCoerceData,
+		"arrayAttribute":      arrayAttribute,
+		"hashAttribute":       hashAttribute,
+		"defaultLiteral":      defaultLiteral,
+		"typedHeader":         typedHeader,
 	}
 	if err := w.ExecuteTemplate("new", ctxNewT, fn, data); err != nil {
 		return err
@@ -204,45 +347,540 @@ func (w *ContextsWriter) Execute(data *ContextTemplateData) error {
 		if err := w.ExecuteTemplate("payload", payloadT, nil, data); err != nil {
 			return err
 		}
+		if data.Payload.IsObject() {
+			fn = template.FuncMap{"builderFields": builderFields}
+			if err := w.ExecuteTemplate("builder", payloadBuilderT, fn, data); err != nil {
+				return err
+			}
+		}
+	}
+	if data.WebSocket != nil {
+		if err := w.ExecuteTemplate("websocket", ctxWebSocketT, nil, data); err != nil {
+			return err
+		}
+	}
+	if data.LongPoll != nil {
+		fn = template.FuncMap{
+			"durationCode": durationCode,
+			"seconds":      durationSeconds,
+		}
+		if err := w.ExecuteTemplate("longpoll", ctxLongPollT, fn, data); err != nil {
+			return err
+		}
+	}
+	if data.Webhook != nil {
+		fn = template.FuncMap{"webhookEventName": webhookEventName}
+		if err := w.ExecuteTemplate("webhook", ctxWebhookT, fn, data); err != nil {
+			return err
+		}
+	}
+	if data.Async != nil {
+		fn = template.FuncMap{"durationCode": durationCode}
+		if err := w.ExecuteTemplate("async", ctxAsyncT, fn, data); err != nil {
+			return err
+		}
+	}
+	if data.Session != nil {
+		if err := w.ExecuteTemplate("session", ctxSessionT, nil, data); err != nil {
+			return err
+		}
+	}
+	if data.Conditional {
+		if err := w.ExecuteTemplate("preconditions", ctxPreconditionsT, nil, data); err != nil {
+			return err
+		}
 	}
 	fn = template.FuncMap{
 		"project": func(mt *design.MediaTypeDefinition, v string) *design.MediaTypeDefinition {
 			p, _, _ := mt.Project(v)
 			return p
 		},
+		"cacheControl": cacheControl,
+		"retryAfter":   retryAfter,
+		"sendMTResp":   sendMTResp,
+		"statusDoc":    statusDoc,
+		"statusArg":    statusArg,
+		"statusCode":   statusCode,
+		"statusCheck":  statusCheck,
 	}
-	data.IterateResponses(func(resp *design.ResponseDefinition) error {
+	return data.IterateResponses(func(resp *design.ResponseDefinition) error {
 		respData := map[string]interface{}{
-			"Context":  data,
-			"Response": resp,
+			"Context":     data,
+			"Response":    resp,
+			"ContentType": resp.MediaType,
 		}
-		if resp.Type != nil {
+		if resp.MediaType == design.WildcardMediaType {
+			if err := w.ExecuteTemplate("rawResponse", ctxRawRespT, fn, respData); err != nil {
+				return err
+			}
+		} else if resp.Type != nil {
 			respData["Type"] = resp.Type
-			if err := w.ExecuteTemplate("response", ctxTRespT, fn, respData); err != nil {
+			if ut, ok := resp.Type.(*design.UserTypeDefinition); ok {
+				respData["Encrypted"] = ut.HasEncryptedFields()
+			}
+			if err := w.ExecuteTemplate("typeResponse", ctxTRespT, fn, respData); err != nil {
 				return err
 			}
 		} else if mt := design.Design.MediaTypeWithIdentifier(resp.MediaType); mt != nil {
 			respData["MediaType"] = mt
-			fn["respName"] = func(resp *design.ResponseDefinition, view string) string {
-				if view == "default" {
-					return codegen.Goify(resp.Name, true)
-				}
-				base := fmt.Sprintf("%s%s", resp.Name, strings.Title(view))
-				return codegen.Goify(base, true)
+			if jsonAPIResp(resp, mt) {
+				respData["ContentType"] = design.JSONAPIMediaType
 			}
-			if err := w.ExecuteTemplate("response", ctxMTRespT, fn, respData); err != nil {
+			fn["respName"] = respName
+			if err := w.ExecuteTemplate("mediaTypeResponse", ctxMTRespT, fn, respData); err != nil {
 				return err
 			}
+			if data.Result != nil {
+				if err := w.writeResultResponses(data, resp, mt); err != nil {
+					return err
+				}
+			}
 		} else {
 			if err := w.ExecuteTemplate("response", ctxNoMTRespT, fn, respData); err != nil {
 				return err
 			}
 		}
+		if resp.Streaming != nil && resp.MediaType != design.WildcardMediaType {
+			if err := w.writeStreamResponses(data, resp); err != nil {
+				return err
+			}
+		}
+		if err := w.writeAlternateResponses(data, resp, fn); err != nil {
+			return err
+		}
+		if data.Href != nil && data.Href.CanonicalTemplate != "" && hasLocation(resp) {
+			if err := w.ExecuteTemplate("location", ctxLocationT, nil, respData); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
+}
+
+// writeAlternateResponses writes the response helpers of the alternate media types of the given
+// response. The helper names are suffixed with the name of the media type Go type, e.g. "OKError".
+func (w *ContextsWriter) writeAlternateResponses(data *ContextTemplateData, resp *design.ResponseDefinition, fn template.FuncMap) error {
+	for _, id := range resp.AlternateMediaTypes {
+		mt := design.Design.MediaTypeWithIdentifier(id)
+		if mt == nil {
+			continue
+		}
+		respData := map[string]interface{}{
+			"Context":     data,
+			"Response":    resp,
+			"MediaType":   mt,
+			"ContentType": id,
+		}
+		fn["respName"] = func(resp *design.ResponseDefinition, view string) string {
+			return alternateRespName(resp, mt, view)
+		}
+		if err := w.ExecuteTemplate("alternateResponse", ctxMTRespT, fn, respData); err != nil {
+			return err
+		}
+	}
+	fn["respName"] = respName
+	return nil
+}
+
+// writeResultResponses writes the response helpers that accept the action result for each view of
+// the response media type together with the functions that project the result onto the views.
+func (w *ContextsWriter) writeResultResponses(data *ContextTemplateData, resp *design.ResponseDefinition, mt *design.MediaTypeDefinition) error {
+	if codegen.PackagePrefix(data.Result, data.Versioned(), data.DefaultPkg) != "" {
+		return fmt.Errorf("result type %s of action %s must be defined in version %s",
+			data.Result.TypeName, data.ActionName, data.Version.Version)
+	}
+	views := make([]string, 0, len(mt.Views))
+	for n := range mt.Views {
+		if n != "link" {
+			views = append(views, n)
+		}
+	}
+	sort.Strings(views)
+	for _, view := range views {
+		projected, _, err := mt.Project(view)
+		if err != nil {
+			return err
+		}
+		projection := codegen.GoTypeTransformName(data.Result, projected.UserTypeDefinition, "")
+		if !w.projections[projection] {
+			prefix := codegen.PackagePrefix(projected.UserTypeDefinition, data.Versioned(), data.DefaultPkg)
+			code, err := codegen.GoTypeTransform(data.Result, projected.UserTypeDefinition, strings.TrimSuffix(prefix, "."), projection)
+			if err != nil {
+				return fmt.Errorf("cannot project result type %s onto view %s of media type %s: %s",
+					data.Result.TypeName, view, mt.Identifier, err)
+			}
+			if _, err := w.Write([]byte("\n" + code)); err != nil {
+				return err
+			}
+			w.projections[projection] = true
+		}
+		respData := map[string]interface{}{
+			"Context":    data,
+			"Response":   resp,
+			"Name":       respName(resp, view),
+			"View":       view,
+			"Projection": projection,
+		}
+		if err := w.ExecuteTemplate("result", ctxResultRespT, nil, respData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeStreamResponses writes the response helpers that stream values of the response type. There
+// is one helper per view of the response media type unless the response overrides the type.
+func (w *ContextsWriter) writeStreamResponses(data *ContextTemplateData, resp *design.ResponseDefinition) error {
+	names := make(map[string]string) // Go type references indexed by helper name
+	if resp.Type != nil {
+		names[respName(resp, "default")] = codegen.GoPackageTypeRef(resp.Type, nil, data.Versioned(), data.DefaultPkg, 0)
+	} else if mt := design.Design.MediaTypeWithIdentifier(resp.MediaType); mt != nil {
+		for view := range mt.Views {
+			if view == "link" {
+				continue
+			}
+			projected, _, err := mt.Project(view)
+			if err != nil {
+				return err
+			}
+			names[respName(resp, view)] = codegen.GoPackageTypeRef(projected, projected.AllRequired(), data.Versioned(), data.DefaultPkg, 0)
+		}
+	} else {
+		return nil
+	}
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+	for _, n := range sorted {
+		respData := map[string]interface{}{
+			"Context":   data,
+			"Response":  resp,
+			"Name":      n,
+			"TypeRef":   names[n],
+			"Streaming": resp.Streaming,
+		}
+		if err := w.ExecuteTemplate("stream", ctxStreamRespT, nil, respData); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// cacheControl returns the code that sets the Cache-Control and Vary headers of the given response,
+// empty string if the response does not define a caching policy.
+func cacheControl(resp *design.ResponseDefinition) string {
+	cc := resp.CacheControl
+	if cc == nil {
+		return ""
+	}
+	var code string
+	if v := cc.Value(); v != "" {
+		code = fmt.Sprintf("\tctx.ResponseData.Header().Set(\"Cache-Control\", %q)\n", v)
+	}
+	for _, h := range cc.Vary {
+		code += fmt.Sprintf("\tctx.ResponseData.Header().Add(\"Vary\", %q)\n", h)
+	}
+	return code
+}
+
+// retryAfter returns the code that sets the Retry-After header of the given response, empty string
+// if the response does not define a Retry-After strategy.
+func retryAfter(resp *design.ResponseDefinition) string {
+	if resp.RetryAfter == nil {
+		return ""
+	}
+	return fmt.Sprintf("\tgoa.SetRetryAfter(ctx.ResponseData.Header(), (%s).Next(ctx))\n", retryAfterPolicy(resp.RetryAfter))
+}
+
+// rateLimitRetryAfter returns the code that initializes the Retry-After policy of the rate limit
+// of the given action, empty string if the action does not define a 429 response with a
+// Retry-After strategy.
+func rateLimitRetryAfter(a *design.ActionDefinition) string {
+	names := make([]string, 0, len(a.Responses))
+	for n := range a.Responses {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if r := a.Responses[n]; r.Status == 429 && r.RetryAfter != nil {
+			return retryAfterPolicy(r.RetryAfter)
+		}
+	}
+	return ""
+}
+
+// retryAfterPolicy returns the code that initializes a goa.RetryAfterPolicy from the given
+// definition.
+func retryAfterPolicy(ra *design.RetryAfterDefinition) string {
+	policy := fmt.Sprintf("Strategy: %q", ra.Strategy)
+	if ra.Delay > 0 {
+		policy += fmt.Sprintf(", Delay: %d * time.Second", ra.Delay)
+	}
+	if ra.Max > 0 {
+		policy += fmt.Sprintf(", Max: %d * time.Second", ra.Max)
+	}
+	return "&goa.RetryAfterPolicy{" + policy + "}"
+}
+
+// statusDoc returns the description of the status code of the given response used in the doc
+// comments of the response helpers, e.g. "status code 200" or "a 2xx status code".
+func statusDoc(resp *design.ResponseDefinition) string {
+	if resp.StatusRange != 0 {
+		return fmt.Sprintf("a %s status code", resp.StatusRangeName())
+	}
+	return fmt.Sprintf("status code %d", resp.Status)
+}
+
+// statusArg returns the status code argument declaration of the helpers of responses that cover a
+// range of status codes, empty string for the other responses.
+func statusArg(resp *design.ResponseDefinition) string {
+	if resp.StatusRange == 0 {
+		return ""
+	}
+	return "status int, "
+}
+
+// statusCode returns the status code expression used by the response helpers.
+func statusCode(resp *design.ResponseDefinition) string {
+	if resp.StatusRange == 0 {
+		return strconv.Itoa(resp.Status)
+	}
+	return "status"
+}
+
+// sendMTResp returns the code that sends the response r rendering the given projection of the
+// media type mt. The successful responses whose ETag is defined in the action or media type are
+// sent with SendETag so that conditional requests are handled. The successful responses of actions
+// that support sparse fieldsets only render the attributes listed in the context Fields field.
+// The successful responses of JSON:API APIs render JSON:API documents.
+func sendMTResp(data *ContextTemplateData, resp *design.ResponseDefinition, mt, projected *design.MediaTypeDefinition) string {
+	code := statusCode(resp)
+	success := isSuccess(resp)
+	body := "r"
+	if data.Fields != nil && success {
+		body = "goa.SparseFields(r, ctx.Fields)"
+	}
+	if jsonAPIResp(resp, mt) {
+		body = fmt.Sprintf("goa.RenderJSONAPI(%s, %q, %s)", body, mt.JSONAPIType(), jsonAPIRelationships(mt))
+	}
+	etag := data.ETag
+	if etag == nil {
+		etag = mt.ETag
+	}
+	if etag == nil || !success {
+		return fmt.Sprintf("ctx.ResponseData.Send(ctx.Context, %s, %s)", code, body)
+	}
+	val := `""`
+	if etag.Strategy == design.AttributeETag {
+		if att, ok := projected.Type.ToObject()[etag.Attribute]; ok {
+			val = fmt.Sprintf("goa.AttributeETag(r.%s)", codegen.GoFieldName(att, etag.Attribute))
+		}
+	}
+	return fmt.Sprintf("ctx.ResponseData.SendETag(ctx.Context, %s, %s, %s, %t)", code, body, val, etag.IsWeak())
+}
+
+// isSuccess returns true if the response has a 2xx status code.
+func isSuccess(resp *design.ResponseDefinition) bool {
+	return resp.StatusRange == 2 || resp.StatusRange == 0 && resp.Status >= 200 && resp.Status < 300
+}
+
+// jsonAPIResp returns true if the response renders a JSON:API document, that is if the API uses
+// the JSONAPI DSL and the response is a successful response that renders the media type mt (and
+// not one of its alternate media types).
+func jsonAPIResp(resp *design.ResponseDefinition, mt *design.MediaTypeDefinition) bool {
+	if !design.Design.JSONAPI || !isSuccess(resp) {
+		return false
+	}
+	return design.CanonicalIdentifier(resp.MediaType) == design.CanonicalIdentifier(mt.Identifier)
+}
+
+// jsonAPIRelationships returns the code that initializes the types of the related resources of
+// the media type indexed by link name given to RenderJSONAPI.
+func jsonAPIRelationships(mt *design.MediaTypeDefinition) string {
+	rels := mt.JSONAPIRelationships()
+	if len(rels) == 0 {
+		return "nil"
+	}
+	names := make([]string, 0, len(rels))
+	for n := range rels {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	entries := make([]string, len(names))
+	for i, n := range names {
+		entries[i] = fmt.Sprintf("%q: %q", n, rels[n])
+	}
+	return fmt.Sprintf("map[string]string{%s}", strings.Join(entries, ", "))
+}
+
+// statusCheck returns the code that checks that the status code given to the helpers of responses
+// that cover a range of status codes belongs to the range, empty string for the other responses.
+func statusCheck(resp *design.ResponseDefinition) string {
+	if resp.StatusRange == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\tif status/100 != %d {\n\t\treturn fmt.Errorf(\"invalid status %%d for response %s, must be %s\", status)\n\t}\n",
+		resp.StatusRange, resp.Name, resp.StatusRangeName())
+}
+
+// isBaseParam returns true if the given canonical path parameter is an API base path parameter.
+func isBaseParam(data *ResourceData, param string) bool {
+	for _, p := range data.BaseParams {
+		if p == param {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLocation returns true if the given response defines a Location header.
+func hasLocation(resp *design.ResponseDefinition) bool {
+	if resp.Headers == nil {
+		return false
+	}
+	_, ok := resp.Headers.Type.ToObject()["Location"]
+	return ok
+}
+
+// respName returns the name of the context method that sends the response rendered with the given
+// view.
+func respName(resp *design.ResponseDefinition, view string) string {
+	if view == "default" {
+		return codegen.Goify(resp.Name, true)
+	}
+	base := fmt.Sprintf("%s%s", resp.Name, strings.Title(view))
+	return codegen.Goify(base, true)
+}
+
+// alternateRespName returns the name of the context method that sends the response rendered with
+// the given view of the given alternate media type.
+func alternateRespName(resp *design.ResponseDefinition, mt *design.MediaTypeDefinition, view string) string {
+	base := resp.Name + "_" + mt.TypeName
+	if view != "default" {
+		base += strings.Title(view)
+	}
+	return codegen.Goify(base, true)
+}
+
+// builderFields returns the setters of the given context object payload builder sorted by
+// attribute name.
+func builderFields(data *ContextTemplateData) []*BuilderField {
+	def := data.Payload.AttributeDefinition
+	obj := def.Type.ToObject()
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	fields := make([]*BuilderField, len(names))
+	for i, n := range names {
+		att := obj[n]
+		typ := codegen.GoTypeDef(att, data.Versioned(), data.DefaultPkg, 0, true)
+		if t := codegen.GoFieldType(att); t != "" {
+			typ = t
+		}
+		if att.Type.IsObject() {
+			typ = "*" + typ
+		}
+		field := codegen.GoFieldName(att, n)
+		setter := field
+		if setter == "Build" {
+			setter = "SetBuild"
+		}
+		fields[i] = &BuilderField{
+			Name:    n,
+			Setter:  setter,
+			Field:   field,
+			Type:    typ,
+			Pointer: def.IsPrimitivePointer(n),
+			Default: defaultLiteral(att),
+		}
+	}
+	return fields
+}
+
+// enums returns the data needed to render the constants of the enum validations of the given
+// type attributes. typeName is the Go name of the type, it prefixes the names of the constants.
+func enums(typeName string, def *design.AttributeDefinition) []*Enum {
+	if def == nil || !def.Type.IsObject() {
+		return nil
+	}
+	obj := def.Type.ToObject()
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var res []*Enum
+	for _, n := range names {
+		att := obj[n]
+		if att.Validation == nil || len(att.Validation.Values) == 0 {
+			continue
+		}
+		switch att.Type.Kind() {
+		case design.BooleanKind, design.IntegerKind, design.NumberKind, design.StringKind:
+		default:
+			continue
+		}
+		typ := codegen.GoNativeType(att.Type)
+		if t := codegen.GoFieldType(att); t != "" {
+			typ = t
+		}
+		enum := &Enum{
+			Name:      typeName + codegen.GoFieldName(att, n),
+			Attribute: n,
+			Type:      typ,
+		}
+		seen := make(map[string]bool)
+		for i, v := range att.Validation.Values {
+			name := enum.Name + codegen.Goify(fmt.Sprintf("%v", v), true)
+			if seen[name] {
+				name = fmt.Sprintf("%s%d", name, i)
+			}
+			seen[name] = true
+			enum.Values = append(enum.Values, &EnumValue{Name: name, Literal: fmt.Sprintf("%#v", v)})
+		}
+		res = append(res, enum)
+	}
+	return res
+}
+
+// defaultLiteral returns the Go literal for the default value of the given attribute. It returns
+// an empty string if the attribute has no default value or if the default value type has no
+// literal representation.
+func defaultLiteral(att *design.AttributeDefinition) string {
+	if att.DefaultValue == nil {
+		return ""
+	}
+	switch att.Type.Kind() {
+	case design.BooleanKind:
+		if b, ok := att.DefaultValue.(bool); ok {
+			return fmt.Sprintf("%t", b)
+		}
+	case design.IntegerKind:
+		switch v := att.DefaultValue.(type) {
+		case int:
+			return fmt.Sprintf("%d", v)
+		case float64:
+			return fmt.Sprintf("%d", int(v))
+		}
+	case design.NumberKind:
+		switch v := att.DefaultValue.(type) {
+		case int:
+			return fmt.Sprintf("float64(%d)", v)
+		case float64:
+			return fmt.Sprintf("float64(%v)", v)
+		}
+	case design.StringKind:
+		if s, ok := att.DefaultValue.(string); ok {
+			return fmt.Sprintf("%q", s)
+		}
+	}
+	return ""
+}
+
 // NewControllersWriter returns a handlers code writer.
 // Handlers provide the glue between the underlying request data and the user controller.
 func NewControllersWriter(filename string) (*ControllersWriter, error) {
@@ -262,13 +900,46 @@ func (w *ControllersWriter) Execute(data []*ControllerTemplateData) error {
 		if err := w.ExecuteTemplate("mount", mountT, nil, d); err != nil {
 			return err
 		}
-		if err := w.ExecuteTemplate("unmarshal", unmarshalT, nil, d); err != nil {
+		fn := template.FuncMap{"defaultLiteral": defaultLiteral}
+		if err := w.ExecuteTemplate("unmarshal", unmarshalT, fn, d); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// WriteBatch writes the function that mounts the API batch endpoint.
+func (w *ControllersWriter) WriteBatch(batch *design.BatchDefinition) error {
+	return w.ExecuteTemplate("batch", batchT, nil, batch)
+}
+
+// WriteMetrics writes the function that mounts the API metrics endpoint.
+func (w *ControllersWriter) WriteMetrics(metrics *design.MetricsDefinition) error {
+	return w.ExecuteTemplate("metrics", metricsT, nil, metrics)
+}
+
+// WriteOperations writes the function that mounts the API operations endpoints.
+func (w *ControllersWriter) WriteOperations(ops *design.OperationsDefinition) error {
+	return w.ExecuteTemplate("operations", operationsT, nil, ops)
+}
+
+// WriteQuotaStatus writes the function that mounts the endpoint returning the quota usage of the
+// request principal.
+func (w *ControllersWriter) WriteQuotaStatus(api *design.APIDefinition) error {
+	data := map[string]interface{}{
+		"Path":     api.QuotaStatusFullPath(),
+		"Security": api.Security,
+	}
+	return w.ExecuteTemplate("quotaStatus", quotaStatusT, nil, data)
+}
+
+// WriteSubscriptions writes the functions that mount the subscription endpoints of the
+// subscribable resources.
+func (w *ControllersWriter) WriteSubscriptions(subs []*design.SubscriptionsDefinition) error {
+	fn := template.FuncMap{"goify": codegen.Goify}
+	return w.ExecuteTemplate("subscriptions", subscriptionsT, fn, subs)
+}
+
 // NewResourcesWriter returns a contexts code writer.
 // Resources provide the glue between the underlying request data and the user controller.
 func NewResourcesWriter(filename string) (*ResourcesWriter, error) {
@@ -281,7 +952,8 @@ func NewResourcesWriter(filename string) (*ResourcesWriter, error) {
 
 // Execute writes the code for the context types to the writer.
 func (w *ResourcesWriter) Execute(data *ResourceData) error {
-	return w.ExecuteTemplate("resource", resourceT, nil, data)
+	fn := template.FuncMap{"isBaseParam": isBaseParam}
+	return w.ExecuteTemplate("resource", resourceT, fn, data)
 }
 
 // NewMediaTypesWriter returns a contexts code writer.
@@ -315,6 +987,10 @@ func (w *MediaTypesWriter) Execute(data *MediaTypeTemplateData) error {
 	if err != nil {
 		return err
 	}
+	name := codegen.Goify(mt.TypeName, true)
+	if err := w.ExecuteTemplate("enums", enumsT, nil, enums(name, mt.AttributeDefinition)); err != nil {
+		return err
+	}
 	if mLinks != nil {
 		lData := &UserTypeTemplateData{
 			UserType:   mLinks,
@@ -340,11 +1016,19 @@ func NewUserTypesWriter(filename string) (*UserTypesWriter, error) {
 
 // Execute writes the code for the context types to the writer.
 func (w *UserTypesWriter) Execute(data *UserTypeTemplateData) error {
-	return w.ExecuteTemplate("types", userTypeT, nil, data)
+	if err := w.ExecuteTemplate("types", userTypeT, nil, data); err != nil {
+		return err
+	}
+	ut := data.UserType
+	return w.ExecuteTemplate("enums", enumsT, nil, enums(codegen.Goify(ut.TypeName, true), ut.AttributeDefinition))
 }
 
 // newCoerceData is a helper function that creates a map that can be given to the "Coerce" template.
 func newCoerceData(name string, att *design.AttributeDefinition, pointer bool, pkg string, depth int) map[string]interface{} {
+	echo := "raw" + codegen.Goify(name, true)
+	if att.IsSensitive() {
+		echo = "goa.Redacted"
+	}
 	return map[string]interface{}{
 		"Name":      name,
 		"VarName":   codegen.Goify(name, false),
@@ -352,17 +1036,47 @@ func newCoerceData(name string, att *design.AttributeDefinition, pointer bool, p
 		"Attribute": att,
 		"Pkg":       pkg,
 		"Depth":     depth,
+		"Echo":      echo,
+		"TypeError": "InvalidParamTypeError",
 	}
 }
 
+// newHeaderCoerceData is a helper function that creates a map that can be given to the "Coerce"
+// template to coerce header values.
+func newHeaderCoerceData(name string, att *design.AttributeDefinition, pointer bool, pkg string, depth int) map[string]interface{} {
+	data := newCoerceData(name, att, pointer, pkg, depth)
+	data["TypeError"] = "InvalidHeaderTypeError"
+	return data
+}
+
+// typedHeader returns true if the context has a typed field for the header with the given
+// attribute, i.e. if the header is a boolean, an integer, a number or a date time.
+func typedHeader(att *design.AttributeDefinition) bool {
+	switch att.Type.Kind() {
+	case design.BooleanKind, design.IntegerKind, design.NumberKind, design.DateTimeKind:
+		return true
+	}
+	return false
+}
+
 // arrayAttribute returns the array element attribute definition.
 func arrayAttribute(a *design.AttributeDefinition) *design.AttributeDefinition {
 	return a.Type.(*design.Array).ElemType
 }
 
-// hasAPIVersion returns true if the given attribute has a child attribute whose goified name is
+// hashAttribute returns the hash element attribute definition.
+func hashAttribute(a *design.AttributeDefinition) *design.AttributeDefinition {
+	return a.Type.(*design.Hash).ElemType
+}
+
+// hasAPIVersion returns true if the given attribute has a child attribute whose field name is
 // "APIVersion". This is used to not generate the built in APIVersion when such a field exists.
 func hasAPIVersion(params *design.AttributeDefinition) bool {
+	return hasField(params, "APIVersion")
+}
+
+// hasField returns true if the given attribute has a child attribute whose field name is field.
+func hasField(params *design.AttributeDefinition, field string) bool {
 	if params == nil {
 		return false
 	}
@@ -370,8 +1084,8 @@ func hasAPIVersion(params *design.AttributeDefinition) bool {
 	if o == nil {
 		return false
 	}
-	for n := range o {
-		if codegen.Goify(n, true) == "APIVersion" {
+	for n, att := range o {
+		if codegen.GoFieldName(att, n) == field {
 			return true
 		}
 	}
@@ -387,11 +1101,133 @@ type {{.Name}} struct {
 	*goa.ResponseData
 	*goa.RequestData
 {{if .Params}}{{range $name, $att := .Params.Type.ToObject}}{{/*
-*/}}	{{goify $name true}} {{if and $att.Type.IsPrimitive ($.Params.IsPrimitivePointer $name)}}*{{end}}{{gotyperef .Type nil 0}}
-{{end}}{{end}}{{if .Payload}}	Payload {{gotyperef .Payload nil 0}}
+*/}}	{{gofieldname $att $name}} {{if and $att.Type.IsPrimitive ($.Params.IsPrimitivePointer $name)}}*{{end}}{{gotyperef .Type nil 0}}
+{{end}}{{end}}{{if .Headers}}{{range $name, $att := .Headers.Type.ToObject}}{{if typedHeader $att}}{{/*
+*/}}	{{gofieldname $att $name}} {{if $.Headers.IsPrimitivePointer $name}}*{{end}}{{gotyperef .Type nil 0}}
+{{end}}{{end}}{{end}}{{if .Payload}}	Payload {{gotyperef .Payload nil 0}}
 {{end}}{{if and (not .Version.IsDefault) (not (hasAPIVersion .Params))}}	APIVersion string
+{{end}}{{if .HasTenantField}}	Tenant     string
+{{end}}{{if .Fields}}	// Fields lists the attributes rendered in the responses, all the attributes of the
+	// response views if empty.
+	Fields []string
 {{end}}}
 `
+	// ctxWebSocketT generates the typed connection and the Upgrade method of WebSocket actions.
+	// template input: *ContextTemplateData
+	ctxWebSocketT = `{{$conn := .ConnName}}
+// {{$conn}} is a {{.ResourceName}} {{.ActionName}} WebSocket connection.
+type {{$conn}} struct {
+	*wshub.Conn
+}
+{{if .WebSocket.Sends}}
+// Send sends a message to the client.
+func (c *{{$conn}}) Send(msg {{gopkgtyperef .WebSocket.Sends nil .Versioned .DefaultPkg 0}}) error {
+	return c.Conn.Send(msg)
+}
+{{end}}{{if .WebSocket.Receives}}
+// Receive waits for the next message sent by the client.
+func (c *{{$conn}}) Receive() ({{gopkgtyperef .WebSocket.Receives nil .Versioned .DefaultPkg 0}}, error) {
+	var msg {{gopkgtyperef .WebSocket.Receives nil .Versioned .DefaultPkg 0}}
+	err := c.Conn.Receive(&msg)
+	return msg, err
+}
+{{end}}
+// Upgrade upgrades the request to a WebSocket connection served by hub and calls handler with
+// the connection. Upgrade returns once the connection is closed.
+func (ctx *{{.Name}}) Upgrade(hub *wshub.Hub, handler func(*{{$conn}}) error) error {
+	subprotocols := {{if .WebSocket.Subprotocols}}[]string{{"{"}}{{range $i, $p := .WebSocket.Subprotocols}}{{if $i}}, {{end}}{{printf "%q" $p}}{{end}}{{"}"}}{{else}}[]string(nil){{end}}
+	return hub.Serve(ctx.Context, ctx.ResponseData, ctx.RequestData.Request, subprotocols, func(c *wshub.Conn) error {
+		return handler(&{{$conn}}{Conn: c})
+	})
+}
+`
+
+	// ctxLongPollT generates the WaitContext and Poll methods of long-poll actions.
+	// template input: *ContextTemplateData
+	ctxLongPollT = `{{$wait := .LongPoll.WaitParam}}{{$watt := index .Params.Type.ToObject $wait}}{{/*
+*/}}{{$cursor := .LongPoll.CursorParam}}{{$catt := index .Params.Type.ToObject $cursor}}
+// WaitContext returns a context that is canceled once the wait requested by the client expires.
+func (ctx *{{.Name}}) WaitContext() (context.Context, context.CancelFunc) {
+{{if .Params.IsPrimitivePointer $wait}}	wait := {{seconds .LongPoll.Wait}}
+	if ctx.{{gofieldname $watt $wait}} != nil {
+		wait = *ctx.{{gofieldname $watt $wait}}
+	}
+{{else}}	wait := ctx.{{gofieldname $watt $wait}}
+{{end}}	return goa.LongPollContext(ctx.Context, wait, {{durationCode .LongPoll.MaxWait}})
+}
+
+// Poll blocks until source has events following the request cursor or the wait requested by the
+// client expires. It returns the events and the cursor of the last one, no event and the request
+// cursor if the wait expired.
+func (ctx *{{.Name}}) Poll(source goa.EventSource) ([]interface{}, string, error) {
+	wctx, cancel := ctx.WaitContext()
+	defer cancel()
+{{if .Params.IsPrimitivePointer $cursor}}	var cursor string
+	if ctx.{{gofieldname $catt $cursor}} != nil {
+		cursor = *ctx.{{gofieldname $catt $cursor}}
+	}
+{{else}}	cursor := ctx.{{gofieldname $catt $cursor}}
+{{end}}	return goa.LongPoll(wctx, source, cursor)
+}
+`
+
+	// ctxAsyncT generates the Start method of async actions.
+	// template input: *ContextTemplateData
+	ctxAsyncT = `
+// Start records a new operation, runs job in the background and sends the 202 Accepted response
+// whose Location header is the operation href.
+func (ctx *{{.Name}}) Start(exec *async.Executor, job async.Job) error {
+	opts := async.Options{
+		Cancelable: {{.Async.Cancelable}},
+{{if .Async.Timeout}}		Timeout:    {{durationCode .Async.Timeout}},
+{{end}}	}
+	op, err := exec.Start(ctx.Context, {{printf "%q" .ResourceName}}, {{printf "%q" .ActionName}}, opts, job)
+	if err != nil {
+		return err
+	}
+	ctx.ResponseData.Header().Set("Location", op.Href)
+	ctx.ResponseData.Header().Set("Content-Type", async.MediaType)
+	return ctx.ResponseData.Send(ctx.Context, 202, op)
+}
+`
+
+	// ctxSessionT generates the session accessors of the actions using a session.
+	// template input: *ContextTemplateData
+	ctxSessionT = `
+// Session returns the state of the session of the browser that made the request, nil if the
+// request does not carry a valid session cookie.
+func (ctx *{{.Name}}) Session() (*{{.Session.TypeName}}, error) {
+	var s {{.Session.TypeName}}
+	ok, err := {{.Session.CookieVar}}.Load(ctx, &s)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SaveSession writes the session cookie holding the given state to the response. It must be
+// called before the response is sent.
+func (ctx *{{.Name}}) SaveSession(s *{{.Session.TypeName}}) error {
+	return {{.Session.CookieVar}}.Save(ctx, s)
+}
+
+// ClearSession deletes the session cookie of the browser that made the request.
+func (ctx *{{.Name}}) ClearSession() error {
+	return {{.Session.CookieVar}}.Clear(ctx)
+}
+`
+
+	// sessionCookieT generates the variable describing the cookie of a session.
+	// template input: *SessionTemplateData
+	sessionCookieT = `// {{.CookieVar}} describes the cookie holding the {{.TypeName}} state.
+var {{.CookieVar}} = &goa.SessionCookie{
+	Name:    {{printf "%q" .Session.Name}},
+{{if .Session.MaxAge}}	MaxAge:  {{durationCode .Session.MaxAge}},
+{{end}}{{if .Session.Encrypt}}	Encrypt: true,
+{{end}}}
+
+`
+
 	// coerceT generates the code that coerces the generic deserialized
 	// data to the actual type.
 	// template input: map[string]interface{} as returned by newCoerceData
@@ -403,7 +1239,7 @@ type {{.Name}} struct {
 {{if .Pointer}}{{tabs .Depth}}	{{$varName}} := &{{.VarName}}
 {{end}}{{tabs .Depth}}	{{.Pkg}} = {{$varName}}
 {{tabs .Depth}}} else {
-{{tabs .Depth}}	err = goa.InvalidParamTypeError("{{.Name}}", raw{{goify .Name true}}, "boolean", err)
+{{tabs .Depth}}	err = goa.{{.TypeError}}("{{.Name}}", {{.Echo}}, "boolean", err)
 {{tabs .Depth}}}
 {{end}}{{if eq .Attribute.Type.Kind 2}}{{/*
 
@@ -415,7 +1251,7 @@ type {{.Name}} struct {
 {{tabs .Depth}}	{{.Pkg}} = {{$tmp}}
 {{else}}{{tabs .Depth}}	{{.Pkg}} = int({{.VarName}})
 {{end}}{{tabs .Depth}}} else {
-{{tabs .Depth}}	err = goa.InvalidParamTypeError("{{.Name}}", raw{{goify .Name true}}, "integer", err)
+{{tabs .Depth}}	err = goa.{{.TypeError}}("{{.Name}}", {{.Echo}}, "integer", err)
 {{tabs .Depth}}}
 {{end}}{{if eq .Attribute.Type.Kind 3}}{{/*
 
@@ -425,7 +1261,7 @@ type {{.Name}} struct {
 {{if .Pointer}}{{tabs .Depth}}	{{$varName}} := &{{.VarName}}
 {{end}}{{tabs .Depth}}	{{.Pkg}} = {{$varName}}
 {{tabs .Depth}}} else {
-{{tabs .Depth}}	err = goa.InvalidParamTypeError("{{.Name}}", raw{{goify .Name true}}, "number", err)
+{{tabs .Depth}}	err = goa.{{.TypeError}}("{{.Name}}", {{.Echo}}, "number", err)
 {{tabs .Depth}}}
 {{end}}{{if eq .Attribute.Type.Kind 4}}{{/*
 
@@ -435,11 +1271,11 @@ type {{.Name}} struct {
 
 */}}{{/* DateTimeType */}}{{/*
 */}}{{$varName := or (and (not .Pointer) .VarName) tempvar}}{{/*
-*/}}{{tabs .Depth}}if {{.VarName}}, err2 := time.Parse("RFC3339", raw{{goify .Name true}}); err2 == nil {
+*/}}{{tabs .Depth}}if {{.VarName}}, err2 := time.Parse(time.RFC3339, raw{{goify .Name true}}); err2 == nil {
 {{if .Pointer}}{{tabs .Depth}}	{{$varName}} := &{{.VarName}}
 {{end}}{{tabs .Depth}}	{{.Pkg}} = {{$varName}}
 {{tabs .Depth}}} else {
-{{tabs .Depth}}	err = goa.InvalidParamTypeError("{{.Name}}", raw{{goify .Name true}}, "datetime", err)
+{{tabs .Depth}}	err = goa.{{.TypeError}}("{{.Name}}", {{.Echo}}, "datetime", err)
 {{tabs .Depth}}}
 {{end}}{{if eq .Attribute.Type.Kind 6}}{{/*
 
@@ -448,13 +1284,19 @@ type {{.Name}} struct {
 {{end}}{{if eq .Attribute.Type.Kind 7}}{{/*
 
 */}}{{/* ArrayType */}}{{/*
-*/}}{{tabs .Depth}}elems{{goify .Name true}} := strings.Split(raw{{goify .Name true}}, ",")
-{{if eq (arrayAttribute .Attribute).Type.Kind 4}}{{tabs .Depth}}{{.Pkg}} = elems{{goify .Name true}}
-{{else}}{{tabs .Depth}}elems{{goify .Name true}}2 := make({{gotyperef .Attribute.Type nil .Depth}}, len(elems{{goify .Name true}}))
-{{tabs .Depth}}for i, rawElem := range elems{{goify .Name true}} {
-{{template "Coerce" (newCoerceData "elem" (arrayAttribute .Attribute) false (printf "elems%s2[i]" (goify .Name true)) (add .Depth 1))}}{{tabs .Depth}}}
-{{tabs .Depth}}{{.Pkg}} = elems{{goify .Name true}}2
-{{end}}{{end}}`
+*/}}{{if eq (arrayAttribute .Attribute).Type.Kind 4}}{{tabs .Depth}}{{.Pkg}} = raw{{goify .Name true}}
+{{else}}{{tabs .Depth}}elems{{goify .Name true}} := make({{gotyperef .Attribute.Type nil .Depth}}, len(raw{{goify .Name true}}))
+{{tabs .Depth}}for i, rawElem := range raw{{goify .Name true}} {
+{{template "Coerce" (newCoerceData "elem" (arrayAttribute .Attribute) false (printf "elems%s[i]" (goify .Name true)) (add .Depth 1))}}{{tabs .Depth}}}
+{{tabs .Depth}}{{.Pkg}} = elems{{goify .Name true}}
+{{end}}{{end}}{{if eq .Attribute.Type.Kind 9}}{{/*
+
+*/}}{{/* HashType */}}{{/*
+*/}}{{tabs .Depth}}elems{{goify .Name true}} := make({{gotyperef .Attribute.Type nil .Depth}}, len(raw{{goify .Name true}}))
+{{tabs .Depth}}for key, rawElem := range raw{{goify .Name true}} {
+{{template "Coerce" (newCoerceData "elem" (hashAttribute .Attribute) false (printf "elems%s[key]" (goify .Name true)) (add .Depth 1))}}{{tabs .Depth}}}
+{{tabs .Depth}}{{.Pkg}} = elems{{goify .Name true}}
+{{end}}`
 
 	// ctxNewT generates the code for the context factory method.
 	// template input: *ContextTemplateData
@@ -470,52 +1312,129 @@ func New{{.Name}}(ctx context.Context) (*{{.Name}}, error) {
 		err = goa.MissingHeaderError("{{$name}}", err)
 	} else {
 {{else}}	if raw{{goify $name true}} != "" {
-{{end}}{{$validation := validationChecker $att ($headers.IsNonZero $name) ($headers.IsRequired $name) (printf "raw%s" (goify $name true)) $name 2}}{{/*
+{{end}}{{if typedHeader $att}}{{template "Coerce" (newHeaderCoerceData $name $att ($headers.IsPrimitivePointer $name) (printf "rctx.%s" (gofieldname $att $name)) 2)}}{{/*
+*/}}{{$validation := validationChecker $att (not ($headers.IsPrimitivePointer $name)) ($headers.IsRequired $name) (printf "rctx.%s" (gofieldname $att $name)) $name 2}}{{/*
+*/}}{{if $validation}}{{$validation}}
+{{end}}	}{{$default := defaultLiteral $att}}{{if and $default ($headers.IsValueWithDefault $name)}} else {
+		rctx.{{gofieldname $att $name}} = {{$default}}
+	}{{end}}
+{{else}}{{$validation := validationChecker $att ($headers.IsNonZero $name) ($headers.IsRequired $name) (printf "raw%s" (goify $name true)) $name 2}}{{/*
 */}}{{if $validation}}{{$validation}}
 {{end}}	}
-{{end}}{{end}}{{if.Params}}{{range $name, $att := .Params.Type.ToObject}}	raw{{goify $name true}} := req.Params.Get("{{$name}}")
-{{$mustValidate := $.MustValidate $name}}{{if $mustValidate}}	if raw{{goify $name true}} == "" {
+{{end}}{{end}}{{end}}{{if.Params}}{{range $name, $att := .Params.Type.ToObject}}{{$multi := or (eq $att.Type.Kind 7) (eq $att.Type.Kind 9)}}{{/*
+*/}}	raw{{goify $name true}} := {{if eq $att.Type.Kind 7}}goa.ParamValues(req.Params, "{{$name}}"){{else if eq $att.Type.Kind 9}}goa.ParamMap(req.Params, "{{$name}}"){{else}}req.Params.Get("{{$name}}"){{end}}
+{{$mustValidate := $.MustValidate $name}}{{if $mustValidate}}	if {{if $multi}}len(raw{{goify $name true}}) == 0{{else}}raw{{goify $name true}} == ""{{end}} {
 		err = goa.MissingParamError("{{$name}}", err)
 	} else {
-{{else}}	if raw{{goify $name true}} != "" {
-{{end}}{{template "Coerce" (newCoerceData $name $att ($.Params.IsPrimitivePointer $name) (printf "rctx.%s" (goify $name true)) 2)}}{{/*
-*/}}{{$validation := validationChecker $att ($.Params.IsNonZero $name) ($.Params.IsRequired $name) (printf "rctx.%s" (goify $name true)) $name 2}}{{/*
+{{else}}	if {{if $multi}}len(raw{{goify $name true}}) > 0{{else}}raw{{goify $name true}} != ""{{end}} {
+{{end}}{{template "Coerce" (newCoerceData $name $att ($.Params.IsPrimitivePointer $name) (printf "rctx.%s" (gofieldname $att $name)) 2)}}{{/*
+*/}}{{$validation := validationChecker $att (not ($.Params.IsPrimitivePointer $name)) ($.Params.IsRequired $name) (printf "rctx.%s" (gofieldname $att $name)) $name 2}}{{/*
 */}}{{if $validation}}{{$validation}}
-{{end}}	}
-{{end}}{{end}}{{/* if .Params */}}	return &rctx, err
+{{end}}	}{{$default := defaultLiteral $att}}{{if and $default ($.Params.IsValueWithDefault $name)}} else {
+		rctx.{{gofieldname $att $name}} = {{$default}}
+	}{{end}}
+{{end}}{{end}}{{/* if .Params */}}{{if .HasTenantField}}	rctx.Tenant = goa.ContextTenant(ctx)
+{{end}}{{if .Fields}}	if rawFields := req.Params.Get("fields"); rawFields != "" {
+		rctx.Fields, err = goa.ParseFields(rawFields, []string{ {{- range $i, $f := .Fields}}{{if $i}}, {{end}}{{printf "%q" $f}}{{end -}} }, err)
+	}
+{{end}}	return &rctx, err
 }
 `
 	// ctxMTRespT generates the response helpers for responses with media types.
 	// template input: map[string]interface{}
 	ctxMTRespT = `{{$ctx := .Context}}{{$resp := .Response}}{{$mt := .MediaType}}{{/*
 */}}{{range $name, $view := $mt.Views}}{{if not (eq $name "link")}}{{$projected := project $mt $name}}
-// {{respName $resp $name}} sends a HTTP response with status code {{$resp.Status}}.
-func (ctx *{{$ctx.Name}}) {{respName $resp $name}}(r {{gopkgtyperef $projected $projected.AllRequired $ctx.Versioned $ctx.DefaultPkg 0}}) error {
-	ctx.ResponseData.Header().Set("Content-Type", "{{$resp.MediaType}}")
-	return ctx.ResponseData.Send(ctx.Context, {{$resp.Status}}, r)
+// {{respName $resp $name}} sends a HTTP response with {{statusDoc $resp}}.{{if $projected.HasEncryptedFields}}
+// The encrypted fields of r are decrypted in place.{{end}}
+func (ctx *{{$ctx.Name}}) {{respName $resp $name}}({{statusArg $resp}}r {{gopkgtyperef $projected $projected.AllRequired $ctx.Versioned $ctx.DefaultPkg 0}}) error {
+{{statusCheck $resp}}	ctx.ResponseData.Header().Set("Content-Type", "{{$.ContentType}}")
+{{retryAfter $resp}}{{cacheControl $resp}}{{if $projected.HasRestrictedFields}}	r = r.Restrict(goa.ContextRoles(ctx.Context))
+{{end}}{{if $projected.HasEncryptedFields}}	if err := r.DecryptFields(ctx.Context); err != nil {
+		return err
+	}
+{{end}}	return {{sendMTResp $ctx $resp $mt $projected}}
 }
 {{end}}{{end}}
+`
+
+	// ctxPreconditionsT generates the method that evaluates the request preconditions of the
+	// actions whose responses have an ETag.
+	// template input: *ContextTemplateData
+	ctxPreconditionsT = `
+// Preconditions sets the ETag response header and evaluates the request If-Match and If-None-Match
+// headers against the current ETag of the resource. It writes a 304 or 412 response and returns
+// true if a precondition fails, the action must then return without sending a response.
+func (ctx *{{.Name}}) Preconditions(etag string) bool {
+	return goa.CheckPreconditions(ctx.Context, etag)
+}
+`
+
+	// ctxResultRespT generates the response helpers that project the action result.
+	// template input: map[string]interface{}
+	ctxResultRespT = `
+// {{.Name}}Result projects r onto the {{.View}} view of the response media type and sends a HTTP
+// response with {{if .Response.StatusRange}}a {{.Response.StatusRangeName}} status code{{else}}status code {{.Response.Status}}{{end}}.
+func (ctx *{{.Context.Name}}) {{.Name}}Result({{if .Response.StatusRange}}status int, {{end}}r {{gotyperef .Context.Result nil 0}}) error {
+	return ctx.{{.Name}}({{if .Response.StatusRange}}status, {{end}}{{.Projection}}(r))
+}
+`
+
+	// ctxStreamRespT generates the response helpers of streaming responses.
+	// template input: map[string]interface{}
+	ctxStreamRespT = `{{$sse := eq .Streaming.Format "sse"}}
+// {{.Name}}Stream streams the values received on values with status code {{.Response.Status}} using
+// {{if $sse}}server-sent events{{else}}newline delimited JSON{{end}}.
+// It returns once values is closed, the request context is done or the client goes away.
+// opts may be nil, the values not set in opts default to the values defined in the design.
+func (ctx *{{.Context.Name}}) {{.Name}}Stream(values <-chan {{.TypeRef}}, opts *goa.StreamOptions) error {
+	opts = opts.WithDefaults({{if $sse}}goa.StreamSSE{{else}}goa.StreamJSON{{end}}, {{if .Streaming.Heartbeat}}{{.Streaming.Heartbeat}}*time.Second{{else}}0{{end}})
+	return ctx.ResponseData.Stream(ctx.Context, {{.Response.Status}}, opts, values)
+}
 `
 
 	// ctxTRespT generates the response helpers for responses with overridden types.
 	// template input: map[string]interface{}
-	ctxTRespT = `// {{goify .Response.Name true}} sends a HTTP response with status code {{.Response.Status}}.
-func (ctx *{{.Context.Name}}) {{goify .Response.Name true}}(r {{gopkgtyperef .Type nil .Context.Versioned .Context.DefaultPkg 0}}) error {
-	ctx.ResponseData.Header().Set("Content-Type", "{{.Response.MediaType}}")
-	return ctx.ResponseData.Send(ctx.Context, {{.Response.Status}}, r)
+	ctxTRespT = `// {{goify .Response.Name true}} sends a HTTP response with {{statusDoc .Response}}.{{if .Encrypted}}
+// The encrypted fields of r are decrypted in place.{{end}}
+func (ctx *{{.Context.Name}}) {{goify .Response.Name true}}({{statusArg .Response}}r {{gopkgtyperef .Type nil .Context.Versioned .Context.DefaultPkg 0}}) error {
+{{statusCheck .Response}}	ctx.ResponseData.Header().Set("Content-Type", "{{.Response.MediaType}}")
+{{retryAfter .Response}}{{cacheControl .Response}}{{if .Encrypted}}	if err := r.DecryptFields(ctx.Context); err != nil {
+		return err
+	}
+{{end}}	return ctx.ResponseData.Send(ctx.Context, {{statusCode .Response}}, r)
 }
 `
 
 	// ctxNoMTRespT generates the response helpers for responses with no known media type.
 	// template input: *ContextTemplateData
 	ctxNoMTRespT = `
-// {{goify .Response.Name true}} sends a HTTP response with status code {{.Response.Status}}.
-func (ctx *{{.Context.Name}}) {{goify .Response.Name true}}({{if .Response.MediaType}}resp []byte{{end}}) error {
-{{if .Response.MediaType}}	ctx.ResponseData.Header().Set("Content-Type", "{{.Response.MediaType}}")
-{{end}}	ctx.ResponseData.WriteHeader({{.Response.Status}}){{if .Response.MediaType}}
+// {{goify .Response.Name true}} sends a HTTP response with {{statusDoc .Response}}.
+func (ctx *{{.Context.Name}}) {{goify .Response.Name true}}({{if .Response.MediaType}}{{statusArg .Response}}resp []byte{{else if .Response.StatusRange}}status int{{end}}) error {
+{{statusCheck .Response}}{{if .Response.MediaType}}	ctx.ResponseData.Header().Set("Content-Type", "{{.Response.MediaType}}")
+{{end}}{{retryAfter .Response}}{{cacheControl .Response}}	ctx.ResponseData.WriteHeader({{statusCode .Response}}){{if .Response.MediaType}}
 	ctx.ResponseData.Write(resp){{end}}
 	return nil
 }
+`
+
+	// ctxLocationT generates the helpers that set the Location header of responses that define it.
+	// template input: map[string]interface{}
+	ctxLocationT = `{{$href := .Context.Href}}
+// Set{{goify .Response.Name true}}Location sets the Location header of the {{.Response.Name}} response to the
+// {{$href.Name}} href built from the given parameters{{if $href.BaseParams}} and the request base parameters{{end}}.
+func (ctx *{{.Context.Name}}) Set{{goify .Response.Name true}}Location({{if $href.HrefParams}}{{join $href.HrefParams ", "}} interface{}{{end}}) {
+	ctx.ResponseData.Header().Set("Location", {{$href.Name}}Href{{if $href.BaseParams}}FromContext(ctx{{range $href.HrefParams}}, {{.}}{{end}}){{else}}({{join $href.HrefParams ", "}}){{end}})
+}
+`
+
+	// ctxRawRespT generates the response helpers for responses with the wildcard media type.
+	// template input: map[string]interface{}
+	ctxRawRespT = `
+// {{goify .Response.Name true}} sends a HTTP response with {{statusDoc .Response}}.
+// The response body is copied from body as is and the Content-Type header is set to contentType.
+func (ctx *{{.Context.Name}}) {{goify .Response.Name true}}({{statusArg .Response}}contentType string, body io.Reader) error {
+{{statusCheck .Response}}{{retryAfter .Response}}{{cacheControl .Response}}	return ctx.ResponseData.SendRaw({{statusCode .Response}}, contentType, body)
+}
 `
 
 	// payloadT generates the payload type definition GoGenerator
@@ -527,22 +1446,59 @@ type {{gotypename .Payload nil 1}} {{gotypedef .Payload .Versioned .DefaultPkg 0
 func (payload {{gotyperef .Payload .Payload.AllRequired 0}}) Validate() (err error) {
 {{$validation}}
        return
-}{{end}}
+}{{end}}{{$crypt := crypt .Payload "payload"}}{{if $crypt}}
+
+{{$crypt}}{{end}}
+`
+	// payloadBuilderT generates the fluent builder of an object payload.
+	// template input: *ContextTemplateData
+	payloadBuilderT = `{{$name := gotypename .Payload nil 1}}{{$builder := printf "%sBuilder" $name}}
+// {{$builder}} builds {{$name}} values, see New{{$builder}}.
+type {{$builder}} struct {
+	payload *{{$name}}
+}
+
+// New{{$builder}} creates a {{$name}} builder initialized with the default values defined
+// in the design.
+func New{{$builder}}() *{{$builder}} {
+	payload := &{{$name}}{}
+{{range builderFields .}}{{if .Default}}{{if .Pointer}}{{$v := goify (printf "default_%s" .Name) false}}	{{$v}} := {{.Default}}
+	payload.{{.Field}} = &{{$v}}
+{{else}}	payload.{{.Field}} = {{.Default}}
+{{end}}{{end}}{{end}}	return &{{$builder}}{payload: payload}
+}
+{{range builderFields .}}
+// {{.Setter}} sets the value of the "{{.Name}}" payload field.
+func (b *{{$builder}}) {{.Setter}}(val {{.Type}}) *{{$builder}} {
+	b.payload.{{.Field}} = {{if .Pointer}}&{{end}}val
+	return b
+}
+{{end}}
+// Build returns the {{$name}} built so far{{if recursiveValidate .Payload.AttributeDefinition false false "payload" "raw" 1}} or
+// an error if it does not validate{{end}}.
+func (b *{{$builder}}) Build() (*{{$name}}, error) {
+	payload := *b.payload
+{{if recursiveValidate .Payload.AttributeDefinition false false "payload" "raw" 1}}	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
+{{end}}	return &payload, nil
+}
 `
+
 	// ctrlT generates the controller interface for a given resource.
 	// template input: *ControllerTemplateData
-	ctrlT = `// {{.Resource}}Controller is the controller interface for the {{.Resource}} actions.
-type {{.Resource}}Controller interface {
+	ctrlT = `// {{controllerName .Resource}} is the controller interface for the {{.Resource}} actions.
+type {{controllerName .Resource}} interface {
 	goa.Muxer
-{{range .Actions}}	{{.Name}}(*{{.Context}}) error
-{{end}}}
+{{range .Actions}}{{if not .Proxy}}	{{.Name}}(*{{.Context}}) error
+{{end}}{{end}}}
 `
 
 	// mountT generates the code for a resource "Mount" function.
 	// template input: *ControllerTemplateData
 	mountT = `
-// Mount{{.Resource}}Controller "mounts" a {{.Resource}} resource controller on the given service.
-func Mount{{.Resource}}Controller(service *goa.Service, ctrl {{.Resource}}Controller) {
+// Mount{{controllerName .Resource}} "mounts" a {{.Resource}} resource controller on the given service.
+func Mount{{controllerName .Resource}}(service *goa.Service, ctrl {{controllerName .Resource}}) {
 	// Setup encoders and decoders. This is idempotent and is done by each MountXXX function.
 {{range .EncoderMap}}{{$tmp := tempvar}}{{/*
 */}}	service.{{if not $.Version.IsDefault}}Version("{{$.Version.Version}}").{{end}}SetEncoder({{.PackageName}}.{{.Factory}}(), {{.Default}}, "{{join .MIMETypes "\", \""}}")
@@ -552,7 +1508,26 @@ func Mount{{.Resource}}Controller(service *goa.Service, ctrl {{.Resource}}Contro
 	// Setup endpoint handler
 	var h goa.Handler
 	mux := service.{{if not .Version.IsDefault}}Version("{{.Version.Version}}").Mux{{else}}Mux{{end}}
-{{$res := .Resource}}{{$ver := .Version}}{{range .Actions}}{{$action := .}}	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+{{$res := .Resource}}{{$ver := .Version}}{{range .Actions}}{{$action := .}}{{with .Proxy}}{{$p := $action.ProxyVar}}{{/*
+*/}}	{{$p}} := goa.NewReverseProxy({{printf "%q" .Upstream}})
+{{if .Timeout}}	{{$p}}.Timeout = {{$action.ProxyTimeout}}
+{{end}}{{if .StripPrefix}}	{{$p}}.StripPrefix = {{printf "%q" .StripPrefix}}
+{{end}}{{if .SetHeaders}}	{{$p}}.SetHeaders = map[string]string{ {{range $k, $v := .SetHeaders}}{{printf "%q" $k}}: {{printf "%q" $v}}, {{end}}}
+{{end}}{{if .RemoveHeaders}}	{{$p}}.RemoveHeaders = []string{ {{range $i, $h := .RemoveHeaders}}{{if $i}}, {{end}}{{printf "%q" $h}}{{end}} }
+{{end}}	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+{{if $action.Policy}}		rctx, err := New{{$action.Context}}(ctx)
+		if err != nil {
+			return goa.NewBadRequestError(err)
+		}
+		if err := service.Authorize(ctx, {{printf "%q" $action.Policy}}, rctx, {{$action.PolicyMetadata}}); err != nil {
+			return err
+		}
+{{else}}		if _, err := New{{$action.Context}}(ctx); err != nil {
+			return goa.NewBadRequestError(err)
+		}
+{{end}}		return {{$p}}.Handle(ctx, rw, req)
+	}
+{{else}}	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
 		rctx, err := New{{.Context}}(ctx)
 		if err != nil {
 			return goa.NewBadRequestError(err)
@@ -561,24 +1536,130 @@ func Mount{{.Resource}}Controller(service *goa.Service, ctrl {{.Resource}}Contro
 {{if .Payload}}if rawPayload := goa.Request(ctx).Payload; rawPayload != nil {
 			rctx.Payload = rawPayload.({{gotyperef .Payload nil 1}})
 		}
-		{{end}}		return ctrl.{{.Name}}(rctx)
+		{{end}}{{if .Policy}}		if err := service.Authorize(ctx, {{printf "%q" .Policy}}, rctx, {{.PolicyMetadata}}); err != nil {
+			return err
+		}
+{{end}}		return ctrl.{{.Name}}(rctx)
 	}
-{{range .Routes}}	mux.Handle("{{.Verb}}", "{{.FullPath $ver}}", ctrl.MuxHandler("{{$action.Name}}", h, {{if $action.Payload}}{{$action.Unmarshal}}{{else}}nil{{end}}))
-	goa.Info(goa.RootContext, "mount", goa.KV{"ctrl", "{{$res}}"},{{if not $ver.IsDefault}} goa.KV{"version", "{{$ver.Version}}"},{{end}} goa.KV{"action", "{{$action.Name}}"}, goa.KV{"route", "{{.Verb}} {{.FullPath $ver}}"})
+{{end}}{{if .Receiver}}	h = {{.Receiver}}.Middleware()(h)
+{{end}}{{with .Replay}}	h = goa.ReplayProtect(h, {{.}})
+{{end}}{{with .Quota}}	service.SetQuota({{printf "%q" .Scope}}{{range .Limits}}, goa.QuotaLimit{Unit: {{printf "%q" .Unit}}, Limit: {{.Limit}}, Period: {{printf "%q" .Period}}}{{end}})
+	h = goa.EnforceQuota(h, {{printf "%q" .Scope}})
+{{end}}{{with .RateLimit}}	service.SetRateLimit({{printf "%q" .Scope}}, goa.RateLimitPolicy{Limit: {{.Limit}}, Period: {{$action.RateLimitPeriod}}, Algorithm: {{printf "%q" .Algorithm}}{{with $action.RateLimitRetryAfter}}, RetryAfter: {{.}}{{end}}})
+	h = goa.EnforceRateLimit(h, {{printf "%q" .Scope}})
+{{end}}{{if .AbuseSensitive}}	h = goa.DetectAbuse(h)
+{{end}}{{with .Security}}	h = goa.Secure({{printf "%q" .Scheme.SchemeName}}, h{{range .Scopes}}, {{printf "%q" .}}{{end}})
+{{end}}{{if .CSRF}}	h = goa.CSRF(h)
+{{end}}{{with .Audit}}	h = goa.Audit(h{{range .Redact}}, {{printf "%q" .}}{{end}})
+{{end}}{{with .Tenant}}	h = goa.ResolveTenant(h, {{printf "%q" .Source}}, {{printf "%q" .Name}})
+{{end}}{{with .NetworkACL}}	service.SetNetworkACL({{printf "%q" .Scope}}, goa.MustNetworkACL({{if .Allow}}[]string{ {{- range $i, $n := .Allow}}{{if $i}}, {{end}}{{printf "%q" $n}}{{end -}} }{{else}}nil{{end}}, {{if .Deny}}[]string{ {{- range $i, $n := .Deny}}{{if $i}}, {{end}}{{printf "%q" $n}}{{end -}} }{{else}}nil{{end}}))
+	h = goa.EnforceNetworkACL(h, {{printf "%q" .Scope}})
+{{end}}{{if .Timeout}}	service.SetActionTimeout({{printf "%q" .TimeoutController}}, "{{.Name}}", {{.Timeout}})
+{{end}}{{if .MaxSize}}	service.SetActionMaxRequestSize({{printf "%q" .MaxSizeController}}, "{{.Name}}", {{.MaxSize}})
+{{end}}{{if .Redact}}	service.SetLogRedaction({{printf "%q" .RedactController}}, "{{.Name}}"{{range .Redact}}, {{printf "%q" .}}{{end}})
+{{end}}{{range .Routes}}	mux.Handle("{{.Verb}}", "{{.FullPath $ver}}", ctrl.MuxHandler("{{$action.Name}}", h, {{if $action.Payload}}{{$action.Unmarshal}}{{else}}nil{{end}}))
+{{if $action.RouteMetadata}}	goa.ConfigureRoute(mux, "{{.FullPath $ver}}", {{$action.RouteMetadata}})
+{{end}}	service.LogInfo("mount", goa.KV{"ctrl", "{{$res}}"},{{if not $ver.IsDefault}} goa.KV{"version", "{{$ver.Version}}"},{{end}} goa.KV{"action", "{{$action.Name}}"}, goa.KV{"route", "{{.Verb}} {{.FullPath $ver}}"})
 {{end}}{{end}}}
 `
 
+	// batchT generates the code that mounts the batch endpoint.
+	// template input: *design.BatchDefinition
+	batchT = `
+// MountBatch mounts the batch endpoint on the given service. The endpoint accepts POST requests
+// made to {{.FullPath}} whose body lists sub-requests that are dispatched to the controllers
+// mounted on the service{{if .Concurrent}} concurrently{{end}}.
+func MountBatch(service *goa.Service) *goa.BatchHandler {
+	h := goa.NewBatchHandler(service)
+{{if .Concurrent}}	h.Concurrent = true
+{{end}}{{if .MaxRequests}}	h.MaxRequests = {{.MaxRequests}}
+{{end}}	h.Mount("{{.FullPath}}")
+	service.LogInfo("mount", goa.KV{"ctrl", "Batch"}, goa.KV{"route", "POST {{.FullPath}}"})
+	return h
+}
+`
+
+	// metricsT generates the code that mounts the metrics endpoint.
+	// template input: *design.MetricsDefinition
+	metricsT = `
+// MountMetrics mounts the endpoint that serves the request metrics collected in m in the
+// Prometheus text format on the given service. The endpoint accepts GET requests made to
+// {{.FullPath}}, the controllers record the metrics when they use the goa.Instrument middleware.
+func MountMetrics(service *goa.Service, m *goa.PrometheusMetrics) {
+	goa.MountMetrics(service, "{{.FullPath}}", m)
+}
+`
+
+	// operationsT generates the code that mounts the operations endpoints.
+	// template input: *design.OperationsDefinition
+	operationsT = `
+// MountOperations mounts the operations endpoints on the given service. GET requests made to
+// {{.FullPath}}/:id return the status of the operations started by async actions, DELETE requests
+// cancel them. The returned executor records the operations in store and must be given to the
+// Start methods of the async action contexts.
+func MountOperations(service *goa.Service, store async.Store) *async.Executor {
+	exec := async.NewExecutor(store, "{{.FullPath}}")
+	exec.Mount(service)
+	service.LogInfo("mount", goa.KV{"ctrl", "Operations"}, goa.KV{"route", "GET {{.FullPath}}/:id"})
+	service.LogInfo("mount", goa.KV{"ctrl", "Operations"}, goa.KV{"route", "DELETE {{.FullPath}}/:id"})
+	return exec
+}
+`
+
+	// quotaStatusT generates the code that mounts the quota status endpoint.
+	// template input: map[string]interface{}
+	quotaStatusT = `{{$path := .Path}}
+// MountQuotaStatus mounts the endpoint that returns the quota usage of the request principal on
+// the given service. The endpoint accepts GET requests made to {{$path}}.
+func MountQuotaStatus(service *goa.Service) {
+	ctrl := service.NewController("QuotaStatus")
+	var h goa.Handler = goa.QuotaStatusHandler
+{{with .Security}}{{if .Scheme}}	h = goa.Secure({{printf "%q" .Scheme.SchemeName}}, h)
+{{end}}{{end}}	service.Mux.Handle("GET", {{printf "%q" $path}}, ctrl.MuxHandler("show", h, nil))
+	service.LogInfo("mount", goa.KV{"ctrl", "QuotaStatus"}, goa.KV{"action", "show"}, goa.KV{"route", "GET {{$path}}"})
+}
+`
+
+	// subscriptionsT generates the code that mounts the subscription endpoints.
+	// template input: []*design.SubscriptionsDefinition
+	subscriptionsT = `{{range .}}{{$name := goify .Parent.Name true}}{{$path := .FullPath}}
+// Mount{{$name}}Subscriptions mounts the subscription endpoints of the {{.Parent.Name}} resource on
+// the given service under {{$path}}. The returned manager records the subscriptions in store and
+// notifies the subscribers of the events given to its Publish method.
+func Mount{{$name}}Subscriptions(service *goa.Service, store subscription.Store) *subscription.Manager {
+	m := subscription.NewManager(service, {{printf "%q" .Parent.Name}}, {{printf "%q" $path}}, store{{range .Events}}, {{printf "%q" .}}{{end}})
+	m.Mount(service)
+	service.LogInfo("mount", goa.KV{"ctrl", "{{$name}}Subscriptions"}, goa.KV{"route", "POST {{$path}}"})
+	service.LogInfo("mount", goa.KV{"ctrl", "{{$name}}Subscriptions"}, goa.KV{"route", "GET {{$path}}"})
+	service.LogInfo("mount", goa.KV{"ctrl", "{{$name}}Subscriptions"}, goa.KV{"route", "GET {{$path}}/:id"})
+	service.LogInfo("mount", goa.KV{"ctrl", "{{$name}}Subscriptions"}, goa.KV{"route", "DELETE {{$path}}/:id"})
+	return m
+}
+{{end}}`
+
 	// unmarshalT generates the code for an action payload unmarshal function.
 	// template input: *ControllerTemplateData
 	unmarshalT = `{{range .Actions}}{{if .Payload}}
 // {{.Unmarshal}} unmarshals the request body into the context request data Payload field.
 func {{.Unmarshal}}(ctx context.Context, req *http.Request) error {
-	var payload {{gotypename .Payload nil 1}}
-	if err := goa.RequestService(ctx).DecodeRequest(req, &payload); err != nil {
+{{if .Proxy}}	// Buffer the body so that it can be forwarded to the upstream service once validated.
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	defer func() { req.Body = ioutil.NopCloser(bytes.NewReader(body)) }()
+{{end}}	var payload {{gotypename .Payload nil 1}}
+{{$payload := .Payload}}{{range $name, $att := .Payload.ToObject}}{{$default := defaultLiteral $att}}{{/*
+*/}}{{if and $default ($payload.IsValueWithDefault $name)}}	payload.{{gofieldname $att $name}} = {{$default}}
+{{end}}{{end}}	if err := goa.RequestService(ctx).DecodeRequest(req, &payload); err != nil {
 		return err
 	}{{$validation := recursiveValidate .Payload.AttributeDefinition false false "payload" "raw" 1}}{{if $validation}}
 	if err := payload.Validate(); err != nil {
 		return err
+	}{{end}}{{if .Payload.HasEncryptedFields}}
+	if err := payload.EncryptFields(ctx); err != nil {
+		return err
 	}{{end}}
 	goa.Request(ctx).Payload = {{if .Payload.IsObject}}&{{end}}payload
 	return nil
@@ -592,7 +1673,14 @@ func {{.Unmarshal}}(ctx context.Context, req *http.Request) error {
 func {{.Name}}Href({{if .CanonicalParams}}{{join .CanonicalParams ", "}} interface{}{{end}}) string {
 	return fmt.Sprintf("{{.CanonicalTemplate}}", {{join .CanonicalParams ", "}})
 }
-{{end}}`
+{{if .BaseParams}}
+// {{.Name}}HrefFromContext returns the resource href using the values of the API base parameters
+// {{join .BaseParams ", "}} of the request with the given context, see goa.WithBaseParams.
+func {{.Name}}HrefFromContext(ctx context.Context{{if .HrefParams}}, {{join .HrefParams ", "}} interface{}{{end}}) string {
+	base := goa.ContextBaseParams(ctx)
+	return {{.Name}}Href({{range $i, $p := .CanonicalParams}}{{if $i}}, {{end}}{{if isBaseParam $ $p}}base.Get("{{$p}}"){{else}}{{$p}}{{end}}{{end}})
+}
+{{end}}{{end}}`
 
 	// mediaTypeT generates the code for a media type.
 	// template input: MediaTypeTemplateData
@@ -605,9 +1693,219 @@ func (mt {{gotyperef .MediaType .MediaType.AllRequired 0}}) Validate() (err erro
 {{$validation}}
 	return
 }
-{{end}}
+{{end}}{{$restrict := restrict .MediaType.UserTypeDefinition "mt" .Versioned .DefaultPkg}}{{if $restrict}}
+{{$restrict}}{{end}}{{$crypt := crypt .MediaType.UserTypeDefinition "mt"}}{{if $crypt}}
+{{$crypt}}{{end}}
+`
+
+	// patternsT generates the variables holding the compiled regular expressions used by the
+	// pattern validations.
+	// template input: map[string]string
+	patternsT = `// Compiled regular expressions of the patterns used by the validation code.
+var (
+{{range $name, $pattern := .}}	{{$name}} = regexp.MustCompile({{printf "%q" $pattern}})
+{{end}})
 `
 
+	// callbacksT generates the typed webhook dispatchers of the callbacks.
+	// template input: []*CallbackTemplateData
+	callbacksT = `{{range .}}{{$c := .Callback}}
+// {{.Name}} delivers the {{$c.Name}} callbacks whose URLs are registered with the {{.Resource}}
+// {{.Action}} action.{{if $c.Description}}
+// {{$c.Description}}{{end}}
+type {{.Name}} struct {
+	*goa.WebhookDispatcher
+}
+
+// New{{.Name}} creates a {{$c.Name}} webhook dispatcher that signs the requests with secret and
+// records the deliveries in store. store may be nil.
+func New{{.Name}}(service *goa.Service, secret []byte, store goa.DeliveryStore) *{{.Name}} {
+	d := goa.NewWebhookDispatcher(service, {{printf "%q" $c.Name}})
+	d.Method = {{printf "%q" $c.Method}}
+	d.ContentType = {{printf "%q" $c.MediaType}}
+	d.SigningScheme = {{printf "%q" $c.SigningScheme}}
+	d.SignatureHeader = {{printf "%q" $c.SignatureHeader}}
+	d.Secret = secret
+	d.MaxAttempts = {{$c.MaxAttempts}}
+	d.Backoff = {{durationCode $c.Backoff}}
+{{if $c.MaxBackoff}}	d.MaxBackoff = {{durationCode $c.MaxBackoff}}
+{{end}}	d.Store = store
+	return &{{.Name}}{WebhookDispatcher: d}
+}
+
+// Deliver sends payload to the callback URL, retrying failed attempts with an exponential
+// backoff. It returns an error if all the attempts fail.
+func (w *{{.Name}}) Deliver(ctx context.Context, url string, payload {{.PayloadRef}}) error {
+	return w.WebhookDispatcher.Deliver(ctx, url, payload)
+}
+{{end}}`
+
+	// ctxWebhookT generates the methods that decode the events received by webhook actions.
+	// template input: *ContextTemplateData
+	ctxWebhookT = `{{$ctx := .}}
+// Envelope returns the envelope of the webhook event received by the request, nil if the request
+// was not verified by the action webhook receiver.
+func (ctx *{{.Name}}) Envelope() *goa.WebhookEnvelope {
+	return goa.ContextWebhookEnvelope(ctx.Context)
+}
+{{range $name, $t := .Webhook.Events}}{{$ref := gopkgtyperef $t $t.AllRequired $ctx.Versioned $ctx.DefaultPkg 0}}
+// Decode{{webhookEventName $name}} decodes the payload of the {{printf "%q" $name}} event received by the request.
+func (ctx *{{$ctx.Name}}) Decode{{webhookEventName $name}}() ({{$ref}}, error) {
+	env := ctx.Envelope()
+	if env == nil || env.Event != {{printf "%q" $name}} {
+		return nil, fmt.Errorf("request is not a %s event", {{printf "%q" $name}})
+	}
+	var event {{gopkgtypename $t $t.AllRequired $ctx.Versioned $ctx.DefaultPkg 0}}
+	if err := env.Decode(&event); err != nil {
+		return nil, err
+	}{{if recursiveValidate $t.AttributeDefinition false false "event" "raw" 1}}
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}{{end}}
+	return &event, nil
+}
+{{end}}`
+
+	// securityT generates the descriptions of the security schemes, the functions that mount
+	// the middleware enforcing them and the principal types of the schemes that define claims.
+	// template input: []*design.SecuritySchemeDefinition
+	securityT = `{{range .}}{{$name := goify .SchemeName true}}{{$type := securityType .Kind}}
+// New{{$name}}Security returns the description of the {{printf "%q" .SchemeName}} security scheme.{{if .Description}}
+{{comment .Description}}{{end}}
+func New{{$name}}Security() *goa.{{$type}} {
+	return &goa.{{$type}}{ {{if .In}}{{if ne .Kind "hmac"}}
+		In:   {{printf "%q" .In}},{{end}}
+		Name: {{printf "%q" .Name}},{{end}}{{if .Tolerance}}
+		Tolerance: {{durationCode .Tolerance}},{{end}}{{if .Flow}}
+		Flow: {{printf "%q" .Flow}},{{end}}{{if .TokenURL}}
+		TokenURL: {{printf "%q" .TokenURL}},{{end}}{{if .AuthorizationURL}}
+		AuthorizationURL: {{printf "%q" .AuthorizationURL}},{{end}}{{if .Issuer}}
+		Issuer: {{printf "%q" .Issuer}},{{end}}{{if .Audience}}
+		Audience: {{printf "%q" .Audience}},{{end}}{{if .ClientCA}}
+		ClientCA: {{printf "%q" .ClientCA}},{{end}}{{if .Scopes}}
+		Scopes: map[string]string{ {{range $scope, $desc := .Scopes}}
+			{{printf "%q" $scope}}: {{printf "%q" $desc}},{{end}}
+		},{{end}}
+	}
+}
+
+// Use{{$name}}Middleware mounts the middleware that enforces the {{printf "%q" .SchemeName}}
+// security scheme on the service. The actions secured by the scheme fail with a 500 response
+// until it is mounted.
+func Use{{$name}}Middleware(service *goa.Service, middleware goa.Middleware) {
+	service.SetSecurityMiddleware({{printf "%q" .SchemeName}}, middleware)
+}
+{{if .ManagesKeys}}
+// Mount{{$name}}Keys manages the keys of the {{printf "%q" .SchemeName}} security scheme with a manager
+// that records them in store: it mounts the middleware that validates the keys issued by the
+// manager and the key administration endpoints under {{printf "%q" .KeysPath}}.
+func Mount{{$name}}Keys(service *goa.Service, store apikey.Store) *apikey.Manager {
+	manager := apikey.NewManager({{printf "%q" .SchemeName}}, {{printf "%q" .KeysPath}}, store)
+	Use{{$name}}Middleware(service, security.APIKey(New{{$name}}Security(), manager.Validate))
+	manager.Mount(service)
+	return manager
+}
+{{end}}{{if .Claims}}
+// {{$name}}Principal is built from the claims of the tokens of the {{printf "%q" .SchemeName}}
+// security scheme.
+type {{$name}}Principal {{gotypedef .Claims false "" 0 true}}
+
+// Context{{$name}}Principal returns the principal built from the claims of the token that
+// authenticated the request with the given context, nil if the request was not authenticated
+// with a token of the scheme.
+func Context{{$name}}Principal(ctx context.Context) (*{{$name}}Principal, error) {
+	claims := security.ContextClaims(ctx)
+	if claims == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	var p {{$name}}Principal
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+{{end}}{{end}}`
+
+	// webhooksT generates the receivers and event dispatchers of the inbound webhooks.
+	// template input: []*WebhookTemplateData
+	webhooksT = `{{range .}}{{$w := .Webhook}}{{$ctx := .Context}}
+// {{.Name}}Receiver verifies the signature of the {{$w.Provider}} webhook requests made to the
+// {{.Resource}} {{.Action}} action. Set its Secret field prior to serving requests.
+var {{.Name}}Receiver = &goa.WebhookReceiver{
+	Provider:        {{printf "%q" $w.Provider}},
+	SigningScheme:   {{printf "%q" $w.SigningScheme}},
+	SignatureHeader: {{printf "%q" $w.SignatureHeader}},
+{{if $w.EventHeader}}	EventHeader:     {{printf "%q" $w.EventHeader}},
+{{end}}{{if $w.DeliveryHeader}}	DeliveryHeader:  {{printf "%q" $w.DeliveryHeader}},
+{{end}}{{if $w.Tolerance}}	Tolerance:       {{durationCode $w.Tolerance}},
+{{end}}}
+
+// {{.Name}}Events lists the handlers of the events received by the {{.Resource}} {{.Action}}
+// action. Events without a handler are acknowledged and ignored.
+type {{.Name}}Events struct {
+{{range .Events}}	// {{.Handler}} handles the {{printf "%q" .Name}} events.
+	{{.Handler}} func(ctx *{{$ctx}}, event {{.TypeRef}}) error
+{{end}}	// Deduper records the processed deliveries so that events delivered more than once are
+	// handled once. Events are handled each time they are delivered if nil.
+	Deduper goa.WebhookDeduper
+}
+
+// Dispatch decodes the event received by the request and calls its handler. The delivery is
+// released from the deduper if handling fails so that it is processed when the provider retries
+// it.
+func (e *{{.Name}}Events) Dispatch(ctx *{{$ctx}}) error {
+	env := ctx.Envelope()
+	if env == nil {
+		return errors.New("missing webhook envelope, the request was not verified")
+	}
+	if e.Deduper != nil {
+		claimed, err := e.Deduper.Claim(ctx.Context, env.ID)
+		if err != nil || !claimed {
+			return err
+		}
+	}
+	var err error
+	switch env.Event {
+{{range .Events}}	case {{printf "%q" .Name}}:
+		if e.{{.Handler}} != nil {
+			var event {{.TypeRef}}
+			if event, err = ctx.Decode{{.Handler}}(); err != nil {
+				err = goa.NewBadRequestError(err)
+			} else {
+				err = e.{{.Handler}}(ctx, event)
+			}
+		}
+{{end}}	}
+	if err != nil && e.Deduper != nil {
+		e.Deduper.Release(ctx.Context, env.ID)
+	}
+	return err
+}
+{{end}}`
+
+	// enumsT generates the constants listing the values of enum validations and the functions
+	// that validate values against them.
+	// template input: []*Enum
+	enumsT = `{{range .}}
+// {{.Name}} values as defined by the enum validation of the "{{.Attribute}}" attribute.
+const (
+{{$enum := .}}{{range .Values}}	{{.Name}} {{$enum.Type}} = {{.Literal}}
+{{end}})
+
+// IsValid{{.Name}} returns true if v is one of the {{.Name}} values.
+func IsValid{{.Name}}(v {{.Type}}) bool {
+	switch v {
+	case {{range $i, $v := .Values}}{{if $i}}, {{end}}{{$v.Name}}{{end}}:
+		return true
+	}
+	return false
+}
+{{end}}`
+
 	// userTypeT generates the code for a user type.
 	// template input: UserTypeTemplateData
 	userTypeT = `// {{if .UserType.Description}}{{.UserType.Description}}{{else}}{{gotypename .UserType .UserType.AllRequired 0}} type{{end}}
@@ -617,6 +1915,10 @@ type {{gotypename .UserType .UserType.AllRequired 0}} {{gotypedef .UserType .Ver
 func (ut {{gotyperef .UserType .UserType.AllRequired 0}}) Validate() (err error) {
 {{$validation}}
 	return
-}{{end}}
+}{{end}}{{$restrict := restrict .UserType "ut" .Versioned .DefaultPkg}}{{if $restrict}}
+
+{{$restrict}}{{end}}{{$crypt := crypt .UserType "ut"}}{{if $crypt}}
+
+{{$crypt}}{{end}}
 `
 )
@@ -3,6 +3,7 @@ package genapp_test
 import (
 	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
@@ -47,9 +48,17 @@ var _ = Describe("ContextsWriter", func() {
 
 		Context("with data", func() {
 			var params, headers *design.AttributeDefinition
-			var payload *design.UserTypeDefinition
+			var payload, result *design.UserTypeDefinition
 			var responses map[string]*design.ResponseDefinition
 			var mediaTypes map[string]*design.MediaTypeDefinition
+			var href *genapp.ResourceData
+			var webSocket *design.WebSocketDefinition
+			var longPoll *design.LongPollDefinition
+			var session *genapp.SessionTemplateData
+			var etag *design.ETagDefinition
+			var conditional bool
+			var tenant *design.TenantDefinition
+			var fields []string
 
 			var data *genapp.ContextTemplateData
 
@@ -57,8 +66,17 @@ var _ = Describe("ContextsWriter", func() {
 				params = nil
 				headers = nil
 				payload = nil
+				result = nil
 				responses = nil
 				mediaTypes = nil
+				href = nil
+				webSocket = nil
+				longPoll = nil
+				session = nil
+				etag = nil
+				conditional = false
+				tenant = nil
+				fields = nil
 				data = nil
 			})
 
@@ -75,11 +93,20 @@ var _ = Describe("ContextsWriter", func() {
 					ActionName:   "list",
 					Params:       params,
 					Payload:      payload,
+					Result:       result,
 					Headers:      headers,
 					Responses:    responses,
 					API:          design.Design,
 					Version:      version,
 					DefaultPkg:   "",
+					Href:         href,
+					WebSocket:    webSocket,
+					LongPoll:     longPoll,
+					Session:      session,
+					ETag:         etag,
+					Conditional:  conditional,
+					Tenant:       tenant,
+					Fields:       fields,
 				}
 			})
 
@@ -96,6 +123,30 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with typed headers", func() {
+				BeforeEach(func() {
+					min := 1.0
+					headers = &design.AttributeDefinition{
+						Type: design.Object{
+							"X-Count": {Type: design.Integer, Validation: &dslengine.ValidationDefinition{Minimum: &min}},
+							"X-Since": {Type: design.DateTime},
+							"X-Trace": {Type: design.String},
+						},
+						Validation: &dslengine.ValidationDefinition{Required: []string{"X-Count"}},
+					}
+				})
+
+				It("writes the contexts code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(typedHeadersContext))
+					Ω(written).Should(ContainSubstring(typedHeadersContextFactory))
+				})
+			})
+
 			Context("with an integer param", func() {
 				BeforeEach(func() {
 					intParam := &design.AttributeDefinition{Type: design.Integer}
@@ -240,6 +291,30 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
+			Context("with a hash param", func() {
+				BeforeEach(func() {
+					hashParam := &design.AttributeDefinition{
+						Type: &design.Hash{
+							KeyType:  &design.AttributeDefinition{Type: design.String},
+							ElemType: &design.AttributeDefinition{Type: design.Integer},
+						},
+					}
+					params = &design.AttributeDefinition{
+						Type: design.Object{"param": hashParam},
+					}
+				})
+
+				It("writes the contexts code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("Param map[string]int"))
+					Ω(written).Should(ContainSubstring(hashContextFactory))
+				})
+			})
+
 			Context("with an param using a reserved keyword as name", func() {
 				BeforeEach(func() {
 					intParam := &design.AttributeDefinition{Type: design.Integer}
@@ -290,263 +365,1214 @@ var _ = Describe("ContextsWriter", func() {
 				})
 			})
 
-			Context("with a simple payload", func() {
+			Context("with a Retry-After response", func() {
+				var design0 *design.APIDefinition
+
 				BeforeEach(func() {
-					payload = &design.UserTypeDefinition{
-						AttributeDefinition: &design.AttributeDefinition{Type: design.String},
-						TypeName:            "ListBottlePayload",
+					design0 = design.Design
+					design.Design = &design.APIDefinition{
+						APIVersionDefinition: &design.APIVersionDefinition{Name: "test"},
+					}
+					responses = map[string]*design.ResponseDefinition{
+						"TooManyRequests": {
+							Name:       "TooManyRequests",
+							Status:     429,
+							RetryAfter: &design.RetryAfterDefinition{Strategy: "fixed", Delay: 30},
+						},
 					}
 				})
 
-				It("writes the contexts code", func() {
+				AfterEach(func() {
+					design.Design = design0
+				})
+
+				It("sets the Retry-After header", func() {
 					err := writer.Execute(data)
 					Ω(err).ShouldNot(HaveOccurred())
 					b, err := ioutil.ReadFile(filename)
 					Ω(err).ShouldNot(HaveOccurred())
 					written := string(b)
-					Ω(written).ShouldNot(BeEmpty())
-					Ω(written).Should(ContainSubstring(payloadContext))
-					Ω(written).Should(ContainSubstring(payloadContextFactory))
+					Ω(written).Should(ContainSubstring(retryAfterResponse))
 				})
 			})
 
-			Context("with a object payload", func() {
+			Context("with a cached response", func() {
+				var design0 *design.APIDefinition
+
 				BeforeEach(func() {
-					intParam := &design.AttributeDefinition{Type: design.Integer}
-					strParam := &design.AttributeDefinition{Type: design.String}
-					dataType := design.Object{
-						"int": intParam,
-						"str": strParam,
-					}
-					required := &dslengine.ValidationDefinition{
-						Required: []string{"int"},
+					design0 = design.Design
+					design.Design = &design.APIDefinition{
+						APIVersionDefinition: &design.APIVersionDefinition{Name: "test"},
 					}
-					payload = &design.UserTypeDefinition{
-						AttributeDefinition: &design.AttributeDefinition{
-							Type:       dataType,
-							Validation: required,
+					responses = map[string]*design.ResponseDefinition{
+						"OK": {
+							Name:   "OK",
+							Status: 200,
+							CacheControl: &design.CacheControlDefinition{
+								Public: true,
+								MaxAge: time.Hour,
+								Vary:   []string{"Accept", "Accept-Language"},
+							},
 						},
-						TypeName: "ListBottlePayload",
 					}
 				})
 
-				It("writes the contexts code", func() {
+				AfterEach(func() {
+					design.Design = design0
+				})
+
+				It("sets the Cache-Control and Vary headers", func() {
 					err := writer.Execute(data)
 					Ω(err).ShouldNot(HaveOccurred())
 					b, err := ioutil.ReadFile(filename)
 					Ω(err).ShouldNot(HaveOccurred())
 					written := string(b)
-					Ω(written).ShouldNot(BeEmpty())
-					Ω(written).Should(ContainSubstring(payloadObjContext))
+					Ω(written).Should(ContainSubstring(cachedResponse))
 				})
+			})
 
-				var _ = Describe("IterateResponses", func() {
-					var resps []*design.ResponseDefinition
-					var testIt = func(r *design.ResponseDefinition) error {
-						resps = append(resps, r)
-						return nil
+			Context("with a response that defines a Location header", func() {
+				var design0 *design.APIDefinition
+
+				BeforeEach(func() {
+					design0 = design.Design
+					design.Design = &design.APIDefinition{
+						APIVersionDefinition: &design.APIVersionDefinition{Name: "test"},
+					}
+					responses = map[string]*design.ResponseDefinition{
+						"Created": {
+							Name:   "Created",
+							Status: 201,
+							Headers: &design.AttributeDefinition{
+								Type: design.Object{"Location": {Type: design.String}},
+							},
+						},
+					}
+					href = &genapp.ResourceData{
+						Name:              "Bottle",
+						CanonicalTemplate: "/%v/bottles/%v",
+						CanonicalParams:   []string{"tenant", "id"},
+						BaseParams:        []string{"tenant"},
+						HrefParams:        []string{"id"},
 					}
-					Context("with responses", func() {
-						BeforeEach(func() {
-							responses = map[string]*design.ResponseDefinition{
-								"OK":      {Status: 200},
-								"Created": {Status: 201},
-							}
-						})
-						It("iterates responses in order", func() {
-							data.IterateResponses(testIt)
-							Ω(resps).Should(Equal([]*design.ResponseDefinition{
-								responses["OK"],
-								responses["Created"],
-							}))
-						})
-					})
 				})
-			})
-		})
-	})
-})
 
-var _ = Describe("ControllersWriter", func() {
-	var writer *genapp.ControllersWriter
-	var workspace *codegen.Workspace
-	var filename string
+				AfterEach(func() {
+					design.Design = design0
+				})
 
-	BeforeEach(func() {
-		var err error
-		workspace, err = codegen.NewWorkspace("test")
-		Ω(err).ShouldNot(HaveOccurred())
-		pkg, err := workspace.NewPackage("controllers")
-		Ω(err).ShouldNot(HaveOccurred())
-		src := pkg.CreateSourceFile("test.go")
-		filename = src.Abs()
-	})
+				It("writes the Location helper", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(locationResponse))
+				})
+			})
 
-	JustBeforeEach(func() {
-		var err error
-		writer, err = genapp.NewControllersWriter(filename)
-		Ω(err).ShouldNot(HaveOccurred())
-	})
+			Context("with a streaming response", func() {
+				BeforeEach(func() {
+					bottle := &design.UserTypeDefinition{
+						AttributeDefinition: &design.AttributeDefinition{
+							Type: design.Object{"id": {Type: design.Integer}},
+						},
+						TypeName: "Bottle",
+					}
+					responses = map[string]*design.ResponseDefinition{
+						"OK": {
+							Name:      "OK",
+							Status:    200,
+							Type:      bottle,
+							MediaType: "application/json",
+							Streaming: &design.StreamingDefinition{Format: design.StreamingSSE, Heartbeat: 15},
+						},
+					}
+				})
 
-	AfterEach(func() {
-		workspace.Delete()
-	})
+				It("writes the stream helper", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(streamResponse))
+				})
+			})
 
-	Context("correctly configured", func() {
-		var f *os.File
-		BeforeEach(func() {
-			f, _ = os.Create(filename)
-		})
+			Context("with a wildcard media type response", func() {
+				var design0 *design.APIDefinition
 
-		Context("with data", func() {
-			var actions, verbs, paths, contexts, unmarshals []string
-			var payloads []*design.UserTypeDefinition
-			var encoderMap, decoderMap map[string]*genapp.EncoderTemplateData
+				BeforeEach(func() {
+					design0 = design.Design
+					design.Design = &design.APIDefinition{
+						APIVersionDefinition: &design.APIVersionDefinition{Name: "test"},
+					}
+					responses = map[string]*design.ResponseDefinition{
+						"OK": {
+							Name:      "OK",
+							Status:    200,
+							MediaType: design.WildcardMediaType,
+						},
+					}
+				})
 
-			var data []*genapp.ControllerTemplateData
+				AfterEach(func() {
+					design.Design = design0
+				})
 
-			BeforeEach(func() {
-				actions = nil
-				verbs = nil
-				paths = nil
-				contexts = nil
-				unmarshals = nil
-				payloads = nil
-				encoderMap = nil
-				decoderMap = nil
+				It("writes a response helper that sends the raw body", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(rawResponse))
+				})
 			})
 
-			JustBeforeEach(func() {
-				codegen.TempCount = 0
-				d := &genapp.ControllerTemplateData{
-					Resource: "Bottles",
-					Version:  &design.APIVersionDefinition{},
-				}
-				as := make([]map[string]interface{}, len(actions))
-				for i, a := range actions {
-					var unmarshal string
-					var payload *design.UserTypeDefinition
-					if i < len(unmarshals) {
-						unmarshal = unmarshals[i]
+			Context("with a status range and an alternate media type", func() {
+				var design0 *design.APIDefinition
+
+				BeforeEach(func() {
+					design0 = design.Design
+					attr := &design.AttributeDefinition{
+						Type: design.Object{"msg": &design.AttributeDefinition{Type: design.String}},
 					}
-					if i < len(payloads) {
-						payload = payloads[i]
+					mt := &design.MediaTypeDefinition{
+						UserTypeDefinition: &design.UserTypeDefinition{
+							AttributeDefinition: attr,
+							TypeName:            "Error",
+						},
+						Identifier: "application/vnd.goa.error",
 					}
-					as[i] = map[string]interface{}{
-						"Name": a,
-						"Routes": []*design.RouteDefinition{
-							{
-								Verb: verbs[i],
-								Path: paths[i],
-							}},
-						"Context":   contexts[i],
-						"Unmarshal": unmarshal,
-						"Payload":   payload,
+					mt.Views = map[string]*design.ViewDefinition{
+						"default": {Name: "default", AttributeDefinition: attr, Parent: mt},
 					}
-				}
-				if len(as) > 0 {
-					d.Actions = as
-					d.EncoderMap = encoderMap
-					d.DecoderMap = decoderMap
-					data = []*genapp.ControllerTemplateData{d}
-				} else {
-					data = nil
-				}
-			})
+					design.Design = &design.APIDefinition{
+						APIVersionDefinition: &design.APIVersionDefinition{Name: "test"},
+						MediaTypes:           map[string]*design.MediaTypeDefinition{mt.Identifier: mt},
+					}
+					responses = map[string]*design.ResponseDefinition{
+						"Success": {
+							Name:                "Success",
+							Status:              200,
+							StatusRange:         2,
+							MediaType:           "application/json",
+							AlternateMediaTypes: []string{mt.Identifier},
+						},
+					}
+				})
 
-			Context("with missing data", func() {
-				It("returns an empty string", func() {
+				AfterEach(func() {
+					design.Design = design0
+				})
+
+				It("writes response helpers that take the status for each media type", func() {
 					err := writer.Execute(data)
 					Ω(err).ShouldNot(HaveOccurred())
 					b, err := ioutil.ReadFile(filename)
 					Ω(err).ShouldNot(HaveOccurred())
 					written := string(b)
-					Ω(written).Should(BeEmpty())
+					Ω(written).Should(ContainSubstring(rangeResponse))
+					Ω(written).Should(ContainSubstring(alternateResponse))
 				})
 			})
 
-			Context("with a simple controller", func() {
+			Context("with a WebSocket endpoint", func() {
 				BeforeEach(func() {
-					actions = []string{"List"}
-					verbs = []string{"GET"}
-					paths = []string{"/accounts/:accountID/bottles"}
-					contexts = []string{"ListBottleContext"}
+					msg := &design.UserTypeDefinition{
+						AttributeDefinition: &design.AttributeDefinition{
+							Type: design.Object{"text": {Type: design.String}},
+						},
+						TypeName: "ChatMessage",
+					}
+					webSocket = &design.WebSocketDefinition{
+						Subprotocols: []string{"chat.v1", "chat.v2"},
+						Receives:     msg,
+						Sends:        msg,
+					}
 				})
 
-				It("writes the controller code", func() {
+				It("writes the typed connection and the Upgrade method", func() {
 					err := writer.Execute(data)
 					Ω(err).ShouldNot(HaveOccurred())
 					b, err := ioutil.ReadFile(filename)
 					Ω(err).ShouldNot(HaveOccurred())
 					written := string(b)
-					Ω(written).ShouldNot(BeEmpty())
-					Ω(written).Should(ContainSubstring(simpleController))
-					Ω(written).Should(ContainSubstring(simpleMount))
+					Ω(written).Should(ContainSubstring(webSocketConn))
+					Ω(written).Should(ContainSubstring(webSocketUpgrade))
+				})
+
+				It("names the connection after the action and resource", func() {
+					codegen.ContextSuffix = "Ctx"
+					defer func() { codegen.ContextSuffix = codegen.DefaultContextSuffix }()
+					Ω(data.ConnName()).Should(Equal("ListBottlesConn"))
 				})
 			})
 
-			Context("with actions that take a payload", func() {
+			Context("with a long-poll action", func() {
 				BeforeEach(func() {
-					actions = []string{"List"}
-					verbs = []string{"GET"}
-					paths = []string{"/accounts/:accountID/bottles"}
-					contexts = []string{"ListBottleContext"}
-					unmarshals = []string{"unmarshalListBottlePayload"}
-					payloads = []*design.UserTypeDefinition{
-						{
-							TypeName: "ListBottlePayload",
-							AttributeDefinition: &design.AttributeDefinition{
-								Type: design.Object{
-									"id": &design.AttributeDefinition{
-										Type: design.String,
-									},
-								},
-							},
+					params = &design.AttributeDefinition{
+						Type: design.Object{
+							"wait":   {Type: design.Integer, DefaultValue: 20},
+							"cursor": {Type: design.String},
 						},
 					}
+					longPoll = &design.LongPollDefinition{
+						WaitParam:   "wait",
+						CursorParam: "cursor",
+						MaxWait:     30 * time.Second,
+						DefaultWait: 20 * time.Second,
+					}
+				})
+
+				It("writes the WaitContext and Poll methods", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(longPollWaitContext))
+					Ω(written).Should(ContainSubstring(longPollPoll))
+				})
+			})
+
+			Context("with a session", func() {
+				BeforeEach(func() {
+					session = &genapp.SessionTemplateData{
+						TypeName:  "AccountSession",
+						CookieVar: "accountSessionCookie",
+						Session:   &design.SessionDefinition{},
+					}
+				})
+
+				It("writes the typed session accessors", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(sessionAccessors))
+				})
+			})
+
+			Context("with a result type", func() {
+				var design0 *design.APIDefinition
+
+				BeforeEach(func() {
+					design0 = design.Design
+					attr := &design.AttributeDefinition{
+						Type: design.Object{"name": &design.AttributeDefinition{Type: design.String}},
+					}
+					mt := &design.MediaTypeDefinition{
+						UserTypeDefinition: &design.UserTypeDefinition{
+							AttributeDefinition: attr,
+							TypeName:            "Bottle",
+						},
+						Identifier: "application/vnd.bottle+json",
+					}
+					mt.Views = map[string]*design.ViewDefinition{
+						"default": {Name: "default", AttributeDefinition: attr, Parent: mt},
+					}
+					design.Design = &design.APIDefinition{
+						APIVersionDefinition: &design.APIVersionDefinition{Name: "test"},
+						MediaTypes:           map[string]*design.MediaTypeDefinition{mt.Identifier: mt},
+					}
+					design.GeneratedMediaTypes = nil
+					result = &design.UserTypeDefinition{
+						AttributeDefinition: &design.AttributeDefinition{
+							Type: design.Object{
+								"name":  &design.AttributeDefinition{Type: design.String},
+								"stock": &design.AttributeDefinition{Type: design.Integer},
+							},
+						},
+						TypeName: "BottleResult",
+					}
+					responses = map[string]*design.ResponseDefinition{
+						"OK": {Name: "OK", Status: 200, MediaType: mt.Identifier},
+					}
+				})
+
+				AfterEach(func() {
+					design.Design = design0
+				})
+
+				It("writes the result response helpers", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("func BottleResultToBottle(source *BottleResult) (target *Bottle) {"))
+					Ω(written).Should(ContainSubstring(resultResponse))
+				})
+			})
+
+			Context("with a multi-tenant action", func() {
+				BeforeEach(func() {
+					tenant = &design.TenantDefinition{Source: design.SubdomainTenant}
+				})
+
+				It("sets the tenant of the context", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring("	Tenant     string\n}"))
+					Ω(written).Should(ContainSubstring("	rctx.Tenant = goa.ContextTenant(ctx)\n	return &rctx, err"))
+				})
+
+				Context("with a tenant param", func() {
+					BeforeEach(func() {
+						tenant = &design.TenantDefinition{Source: design.ParamTenant, Name: "tenant"}
+						params = &design.AttributeDefinition{
+							Type: design.Object{"tenant": {Type: design.String}},
+						}
+					})
+
+					It("does not override the param field", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).ShouldNot(ContainSubstring("goa.ContextTenant"))
+					})
+				})
+			})
+
+			Context("with a media type that defines an ETag", func() {
+				var design0 *design.APIDefinition
+
+				BeforeEach(func() {
+					design0 = design.Design
+					attr := &design.AttributeDefinition{
+						Type: design.Object{
+							"version": &design.AttributeDefinition{Type: design.Integer},
+							"body":    &design.AttributeDefinition{Type: design.String},
+						},
+					}
+					mt := &design.MediaTypeDefinition{
+						UserTypeDefinition: &design.UserTypeDefinition{
+							AttributeDefinition: attr,
+							TypeName:            "Document",
+						},
+						Identifier: "application/vnd.document+json",
+					}
+					mt.ETag = &design.ETagDefinition{Strategy: design.AttributeETag, Attribute: "version", Parent: mt}
+					mt.Views = map[string]*design.ViewDefinition{
+						"default": {Name: "default", AttributeDefinition: attr, Parent: mt},
+					}
+					design.Design = &design.APIDefinition{
+						APIVersionDefinition: &design.APIVersionDefinition{Name: "test"},
+						MediaTypes:           map[string]*design.MediaTypeDefinition{mt.Identifier: mt},
+					}
+					design.GeneratedMediaTypes = nil
+					responses = map[string]*design.ResponseDefinition{
+						"OK":       {Name: "OK", Status: 200, MediaType: mt.Identifier},
+						"Conflict": {Name: "Conflict", Status: 409, MediaType: mt.Identifier},
+					}
+					conditional = true
+				})
+
+				AfterEach(func() {
+					design.Design = design0
+				})
+
+				It("sends the successful responses with their ETag", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(etagResponse))
+					Ω(written).Should(ContainSubstring("return ctx.ResponseData.Send(ctx.Context, 409, r)"))
+					Ω(written).Should(ContainSubstring(preconditions))
+				})
+
+				Context("overridden by the action", func() {
+					BeforeEach(func() {
+						etag = &design.ETagDefinition{Strategy: design.WeakETag}
+					})
+
+					It("computes the ETag from the response body", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).Should(ContainSubstring(`return ctx.ResponseData.SendETag(ctx.Context, 200, r, "", true)`))
+					})
+				})
+
+				Context("with sparse fieldsets", func() {
+					BeforeEach(func() {
+						fields = []string{"body", "version"}
+					})
+
+					It("renders the listed attributes of the successful responses", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).Should(ContainSubstring(sparseContext))
+						Ω(written).Should(ContainSubstring(sparseContextFactory))
+						Ω(written).Should(ContainSubstring("return ctx.ResponseData.SendETag(ctx.Context, 200, goa.SparseFields(r, ctx.Fields), goa.AttributeETag(r.Version), false)"))
+						Ω(written).Should(ContainSubstring("return ctx.ResponseData.Send(ctx.Context, 409, r)"))
+					})
+				})
+
+				Context("with a JSON:API API", func() {
+					BeforeEach(func() {
+						design.Design.JSONAPI = true
+					})
+
+					It("renders the successful responses as JSON:API documents", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).Should(ContainSubstring(jsonAPIResponse))
+						Ω(written).Should(ContainSubstring("return ctx.ResponseData.Send(ctx.Context, 409, r)"))
+					})
+				})
+			})
+
+			Context("with a simple payload", func() {
+				BeforeEach(func() {
+					payload = &design.UserTypeDefinition{
+						AttributeDefinition: &design.AttributeDefinition{Type: design.String},
+						TypeName:            "ListBottlePayload",
+					}
+				})
+
+				It("writes the contexts code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(payloadContext))
+					Ω(written).Should(ContainSubstring(payloadContextFactory))
+				})
+			})
+
+			Context("with a object payload with default values", func() {
+				BeforeEach(func() {
+					intParam := &design.AttributeDefinition{Type: design.Integer}
+					strParam := &design.AttributeDefinition{Type: design.String, DefaultValue: "foo"}
+					dataType := design.Object{
+						"int": intParam,
+						"str": strParam,
+					}
+					required := &dslengine.ValidationDefinition{
+						Required: []string{"int"},
+					}
+					payload = &design.UserTypeDefinition{
+						AttributeDefinition: &design.AttributeDefinition{
+							Type:       dataType,
+							Validation: required,
+						},
+						TypeName: "ListBottlePayload",
+					}
+				})
+
+				It("writes the payload builder code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(payloadObjBuilder))
+				})
+			})
+
+			Context("with a object payload", func() {
+				BeforeEach(func() {
+					intParam := &design.AttributeDefinition{Type: design.Integer}
+					strParam := &design.AttributeDefinition{Type: design.String}
+					dataType := design.Object{
+						"int": intParam,
+						"str": strParam,
+					}
+					required := &dslengine.ValidationDefinition{
+						Required: []string{"int"},
+					}
+					payload = &design.UserTypeDefinition{
+						AttributeDefinition: &design.AttributeDefinition{
+							Type:       dataType,
+							Validation: required,
+						},
+						TypeName: "ListBottlePayload",
+					}
+				})
+
+				It("writes the contexts code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(payloadObjContext))
+				})
+
+				var _ = Describe("IterateResponses", func() {
+					var resps []*design.ResponseDefinition
+					var testIt = func(r *design.ResponseDefinition) error {
+						resps = append(resps, r)
+						return nil
+					}
+					Context("with responses", func() {
+						BeforeEach(func() {
+							responses = map[string]*design.ResponseDefinition{
+								"OK":      {Status: 200},
+								"Created": {Status: 201},
+							}
+						})
+						It("iterates responses in order", func() {
+							data.IterateResponses(testIt)
+							Ω(resps).Should(Equal([]*design.ResponseDefinition{
+								responses["OK"],
+								responses["Created"],
+							}))
+						})
+					})
+				})
+			})
+		})
+	})
+})
+
+var _ = Describe("ControllersWriter", func() {
+	var writer *genapp.ControllersWriter
+	var workspace *codegen.Workspace
+	var filename string
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err := workspace.NewPackage("controllers")
+		Ω(err).ShouldNot(HaveOccurred())
+		src := pkg.CreateSourceFile("test.go")
+		filename = src.Abs()
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		writer, err = genapp.NewControllersWriter(filename)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	Context("correctly configured", func() {
+		var f *os.File
+		BeforeEach(func() {
+			f, _ = os.Create(filename)
+		})
+
+		Context("with data", func() {
+			var actions, verbs, paths, contexts, unmarshals []string
+			var payloads []*design.UserTypeDefinition
+			var proxies []*design.ProxyDefinition
+			var timeouts []string
+			var maxSizes []int64
+			var security *design.SecurityDefinition
+			var audit *design.AuditDefinition
+			var replay string
+			var quota *design.QuotaDefinition
+			var rateLimit *design.RateLimitDefinition
+			var rateLimitRetryAfter string
+			var tenant *design.TenantDefinition
+			var networkACL *design.NetworkACLDefinition
+			var abuseSensitive bool
+			var csrf bool
+			var routeMetadata string
+			var redact []string
+			var encoderMap, decoderMap map[string]*genapp.EncoderTemplateData
+
+			var data []*genapp.ControllerTemplateData
+
+			BeforeEach(func() {
+				actions = nil
+				verbs = nil
+				paths = nil
+				contexts = nil
+				unmarshals = nil
+				payloads = nil
+				proxies = nil
+				timeouts = nil
+				maxSizes = nil
+				security = nil
+				audit = nil
+				replay = ""
+				quota = nil
+				rateLimit = nil
+				rateLimitRetryAfter = ""
+				tenant = nil
+				networkACL = nil
+				abuseSensitive = false
+				csrf = false
+				routeMetadata = ""
+				redact = nil
+				encoderMap = nil
+				decoderMap = nil
+			})
+
+			JustBeforeEach(func() {
+				codegen.TempCount = 0
+				d := &genapp.ControllerTemplateData{
+					Resource: "Bottles",
+					Version:  &design.APIVersionDefinition{},
+				}
+				as := make([]*genapp.ActionTemplateData, len(actions))
+				for i, a := range actions {
+					var unmarshal string
+					var payload *design.UserTypeDefinition
+					if i < len(unmarshals) {
+						unmarshal = unmarshals[i]
+					}
+					if i < len(payloads) {
+						payload = payloads[i]
+					}
+					as[i] = &genapp.ActionTemplateData{
+						Name: a,
+						Routes: []*design.RouteDefinition{
+							{
+								Verb: verbs[i],
+								Path: paths[i],
+							}},
+						Context:        contexts[i],
+						Unmarshal:      unmarshal,
+						Payload:        payload,
+						Security:       security,
+						Audit:          audit,
+						Replay:         replay,
+						Quota:          quota,
+						Tenant:         tenant,
+						NetworkACL:     networkACL,
+						AbuseSensitive: abuseSensitive,
+						CSRF:           csrf,
+						RouteMetadata:  routeMetadata,
+					}
+					if i < len(proxies) {
+						as[i].Proxy = proxies[i]
+						as[i].ProxyVar = "searchProxy"
+						as[i].ProxyTimeout = "10 * time.Second"
+					}
+					if rateLimit != nil {
+						as[i].RateLimit = rateLimit
+						as[i].RateLimitPeriod = "60 * time.Second"
+						as[i].RateLimitRetryAfter = rateLimitRetryAfter
+					}
+					if redact != nil {
+						as[i].Redact = redact
+						as[i].RedactController = "users"
+					}
+					if i < len(timeouts) {
+						as[i].Timeout = timeouts[i]
+						as[i].TimeoutController = "bottle"
+					}
+					if i < len(maxSizes) {
+						as[i].MaxSize = maxSizes[i]
+						as[i].MaxSizeController = "bottle"
+					}
+				}
+				if len(as) > 0 {
+					d.Actions = as
+					d.EncoderMap = encoderMap
+					d.DecoderMap = decoderMap
+					data = []*genapp.ControllerTemplateData{d}
+				} else {
+					data = nil
+				}
+			})
+
+			Context("with missing data", func() {
+				It("returns an empty string", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(BeEmpty())
+				})
+			})
+
+			Context("with a simple controller", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+				})
+
+				It("writes the controller code", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).ShouldNot(BeEmpty())
+					Ω(written).Should(ContainSubstring(simpleController))
+					Ω(written).Should(ContainSubstring(simpleMount))
+				})
+			})
+
+			Context("with actions that take a payload", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					unmarshals = []string{"unmarshalListBottlePayload"}
+					payloads = []*design.UserTypeDefinition{
+						{
+							TypeName: "ListBottlePayload",
+							AttributeDefinition: &design.AttributeDefinition{
+								Type: design.Object{
+									"id": &design.AttributeDefinition{
+										Type: design.String,
+									},
+								},
+							},
+						},
+					}
+				})
+
+				It("writes the payload unmarshal function", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(payloadNoValidationsObjUnmarshal))
+				})
+			})
+			Context("with actions that take a payload with a required validation", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					required := &dslengine.ValidationDefinition{
+						Required: []string{"id"},
+					}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					unmarshals = []string{"unmarshalListBottlePayload"}
+					payloads = []*design.UserTypeDefinition{
+						{
+							TypeName: "ListBottlePayload",
+							AttributeDefinition: &design.AttributeDefinition{
+								Type: design.Object{
+									"id": &design.AttributeDefinition{
+										Type: design.String,
+									},
+								},
+								Validation: required,
+							},
+						},
+					}
+				})
+
+				It("writes the payload unmarshal function", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(payloadObjUnmarshal))
+				})
+			})
+
+			Context("with actions that take a payload with an encrypted attribute", func() {
+				BeforeEach(func() {
+					actions = []string{"Create"}
+					verbs = []string{"POST"}
+					paths = []string{"/accounts"}
+					contexts = []string{"CreateAccountContext"}
+					unmarshals = []string{"unmarshalCreateAccountPayload"}
+					payloads = []*design.UserTypeDefinition{
+						{
+							TypeName: "CreateAccountPayload",
+							AttributeDefinition: &design.AttributeDefinition{
+								Type: design.Object{
+									"ssn": &design.AttributeDefinition{
+										Type:     design.String,
+										Metadata: dslengine.MetadataDefinition{design.EncryptedKey: nil},
+									},
+								},
+							},
+						},
+					}
+				})
+
+				It("encrypts the payload before handing it to the controller", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`	if err := payload.EncryptFields(ctx); err != nil {
+		return err
+	}
+	goa.Request(ctx).Payload = &payload`))
+				})
+			})
+
+			Context("with a proxy action", func() {
+				BeforeEach(func() {
+					actions = []string{"List", "Search"}
+					verbs = []string{"GET", "GET"}
+					paths = []string{"/accounts/:accountID/bottles", "/search/*path"}
+					contexts = []string{"ListBottleContext", "SearchBottleContext"}
+					proxies = []*design.ProxyDefinition{nil, {
+						Upstream:      "http://search.internal",
+						Timeout:       10 * time.Second,
+						RemoveHeaders: []string{"Cookie"},
+					}}
+				})
+
+				It("forwards the requests to the upstream service", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(proxyMount))
+					Ω(written).Should(ContainSubstring("List(*ListBottleContext) error"))
+					Ω(written).ShouldNot(ContainSubstring("Search(*SearchBottleContext) error"))
+				})
+			})
+
+			Context("with a secured action", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					security = &design.SecurityDefinition{
+						Scheme: &design.SecuritySchemeDefinition{Kind: design.JWTSecurityKind, SchemeName: "jwt"},
+						Scopes: []string{"api:read"},
+					}
+				})
+
+				It("enforces the security scheme", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`h = goa.Secure("jwt", h, "api:read")
+	mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))`))
+				})
+			})
+
+			Context("with an audited secured action", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					security = &design.SecurityDefinition{
+						Scheme: &design.SecuritySchemeDefinition{Kind: design.JWTSecurityKind, SchemeName: "jwt"},
+					}
+					audit = &design.AuditDefinition{Redact: []string{"accountID"}}
+				})
+
+				It("audits the requests rejected by the security middleware", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`h = goa.Secure("jwt", h)
+	h = goa.Audit(h, "accountID")
+	mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))`))
+				})
+			})
+
+			Context("with a replay protected secured action", func() {
+				BeforeEach(func() {
+					actions = []string{"Transfer"}
+					verbs = []string{"POST"}
+					paths = []string{"/accounts/:accountID/transfer"}
+					contexts = []string{"TransferAccountContext"}
+					security = &design.SecurityDefinition{
+						Scheme: &design.SecuritySchemeDefinition{Kind: design.HMACSecurityKind, SchemeName: "hmac"},
+					}
+					replay = "60 * time.Second"
+				})
+
+				It("checks the replay headers of the authenticated requests", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`h = goa.ReplayProtect(h, 60 * time.Second)
+	h = goa.Secure("hmac", h)
+	mux.Handle("POST", "/accounts/:accountID/transfer", ctrl.MuxHandler("Transfer", h, nil))`))
+				})
+			})
+
+			Context("with a secured action with a quota", func() {
+				BeforeEach(func() {
+					actions = []string{"Export"}
+					verbs = []string{"POST"}
+					paths = []string{"/accounts/:accountID/export"}
+					contexts = []string{"ExportAccountContext"}
+					security = &design.SecurityDefinition{
+						Scheme: &design.SecuritySchemeDefinition{Kind: design.JWTSecurityKind, SchemeName: "jwt"},
+					}
+					quota = &design.QuotaDefinition{
+						Limits: []*design.QuotaLimitDefinition{
+							{Unit: design.QuotaRequests, Limit: 100, Period: design.Daily},
+							{Unit: design.QuotaBytes, Limit: 1024, Period: design.Monthly},
+						},
+						Parent: &design.ResourceDefinition{Name: "account"},
+					}
+				})
+
+				It("enforces the quota of the authenticated requests", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`service.SetQuota("account", goa.QuotaLimit{Unit: "requests", Limit: 100, Period: "daily"}, goa.QuotaLimit{Unit: "bytes", Limit: 1024, Period: "monthly"})
+	h = goa.EnforceQuota(h, "account")
+	h = goa.Secure("jwt", h)
+	mux.Handle("POST", "/accounts/:accountID/export", ctrl.MuxHandler("Export", h, nil))`))
+				})
+			})
+
+			Context("with a secured action with a rate limit", func() {
+				BeforeEach(func() {
+					actions = []string{"Search"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/search"}
+					contexts = []string{"SearchAccountContext"}
+					security = &design.SecurityDefinition{
+						Scheme: &design.SecuritySchemeDefinition{Kind: design.JWTSecurityKind, SchemeName: "jwt"},
+					}
+					rateLimit = &design.RateLimitDefinition{
+						Limit:     100,
+						Period:    time.Minute,
+						Algorithm: design.SlidingWindow,
+						Parent:    &design.ResourceDefinition{Name: "account"},
+					}
+				})
+
+				It("enforces the rate limit of the authenticated requests", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`service.SetRateLimit("account", goa.RateLimitPolicy{Limit: 100, Period: 60 * time.Second, Algorithm: "sliding-window"})
+	h = goa.EnforceRateLimit(h, "account")
+	h = goa.Secure("jwt", h)
+	mux.Handle("GET", "/accounts/:accountID/search", ctrl.MuxHandler("Search", h, nil))`))
+				})
+
+				Context("with a Retry-After strategy", func() {
+					BeforeEach(func() {
+						rateLimitRetryAfter = `&goa.RetryAfterPolicy{Strategy: "exponential", Delay: 1 * time.Second}`
+					})
+
+					It("sets the Retry-After policy of the rate limit", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(string(b)).Should(ContainSubstring(`service.SetRateLimit("account", goa.RateLimitPolicy{Limit: 100, Period: 60 * time.Second, Algorithm: "sliding-window", RetryAfter: &goa.RetryAfterPolicy{Strategy: "exponential", Delay: 1 * time.Second}})`))
+					})
+				})
+			})
+
+			Context("with a secured action of a multi-tenant API", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/projects"}
+					contexts = []string{"ListProjectContext"}
+					security = &design.SecurityDefinition{
+						Scheme: &design.SecuritySchemeDefinition{Kind: design.JWTSecurityKind, SchemeName: "jwt"},
+					}
+					tenant = &design.TenantDefinition{Source: design.HeaderTenant, Name: "X-Tenant-ID"}
+				})
+
+				It("resolves the tenant before authenticating the requests", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`	h = goa.Secure("jwt", h)
+	h = goa.ResolveTenant(h, "header", "X-Tenant-ID")
+	mux.Handle("GET", "/projects", ctrl.MuxHandler("List", h, nil))`))
+				})
+			})
+
+			Context("with a network ACL", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/projects"}
+					contexts = []string{"ListProjectContext"}
+					tenant = &design.TenantDefinition{Source: design.HeaderTenant, Name: "X-Tenant-ID"}
+					networkACL = &design.NetworkACLDefinition{
+						Allow:  []string{"10.0.0.0/8", "192.168.1.10"},
+						Parent: &design.ResourceDefinition{Name: "project"},
+					}
+				})
+
+				It("enforces the ACL before resolving the tenant", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`	h = goa.ResolveTenant(h, "header", "X-Tenant-ID")
+	service.SetNetworkACL("project", goa.MustNetworkACL([]string{"10.0.0.0/8", "192.168.1.10"}, nil))
+	h = goa.EnforceNetworkACL(h, "project")
+	mux.Handle("GET", "/projects", ctrl.MuxHandler("List", h, nil))`))
+				})
+			})
+
+			Context("with a secured abuse-sensitive action", func() {
+				BeforeEach(func() {
+					actions = []string{"Login"}
+					verbs = []string{"POST"}
+					paths = []string{"/login"}
+					contexts = []string{"LoginSessionContext"}
+					security = &design.SecurityDefinition{
+						Scheme: &design.SecuritySchemeDefinition{Kind: design.BasicAuthSecurityKind, SchemeName: "basic"},
+					}
+					abuseSensitive = true
+				})
+
+				It("inspects the authenticated requests", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`	h = goa.DetectAbuse(h)
+	h = goa.Secure("basic", h)
+	mux.Handle("POST", "/login", ctrl.MuxHandler("Login", h, nil))`))
+				})
+			})
+
+			Context("with a CSRF protected secured action", func() {
+				BeforeEach(func() {
+					actions = []string{"Transfer"}
+					verbs = []string{"POST"}
+					paths = []string{"/accounts/:accountID/transfer"}
+					contexts = []string{"TransferAccountContext"}
+					security = &design.SecurityDefinition{
+						Scheme: &design.SecuritySchemeDefinition{Kind: design.JWTSecurityKind, SchemeName: "jwt"},
+					}
+					csrf = true
+				})
+
+				It("checks the CSRF token before authenticating the requests", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`h = goa.Secure("jwt", h)
+	h = goa.CSRF(h)
+	mux.Handle("POST", "/accounts/:accountID/transfer", ctrl.MuxHandler("Transfer", h, nil))`))
+				})
+			})
+
+			Context("with route options metadata", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					routeMetadata = `map[string][]string{"router:strict-slash": []string{"true"}}`
+				})
+
+				It("configures the routes", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
+	goa.ConfigureRoute(mux, "/accounts/:accountID/bottles", map[string][]string{"router:strict-slash": []string{"true"}})`))
+				})
+			})
+
+			Context("with an action that accepts PII", func() {
+				BeforeEach(func() {
+					actions = []string{"Create"}
+					verbs = []string{"POST"}
+					paths = []string{"/users"}
+					contexts = []string{"CreateUserContext"}
+					redact = []string{"email", "phone"}
+				})
+
+				It("redacts the PII from the logs", func() {
+					err := writer.Execute(data)
+					Ω(err).ShouldNot(HaveOccurred())
+					b, err := ioutil.ReadFile(filename)
+					Ω(err).ShouldNot(HaveOccurred())
+					written := string(b)
+					Ω(written).Should(ContainSubstring(`service.SetLogRedaction("users", "Create", "email", "phone")`))
 				})
+			})
 
-				It("writes the payload unmarshal function", func() {
+			Context("with an action timeout", func() {
+				BeforeEach(func() {
+					actions = []string{"List"}
+					verbs = []string{"GET"}
+					paths = []string{"/accounts/:accountID/bottles"}
+					contexts = []string{"ListBottleContext"}
+					timeouts = []string{"30 * time.Second"}
+				})
+
+				It("overrides the timeout of the action", func() {
 					err := writer.Execute(data)
 					Ω(err).ShouldNot(HaveOccurred())
 					b, err := ioutil.ReadFile(filename)
 					Ω(err).ShouldNot(HaveOccurred())
 					written := string(b)
-					Ω(written).Should(ContainSubstring(payloadNoValidationsObjUnmarshal))
+					Ω(written).Should(ContainSubstring(`service.SetActionTimeout("bottle", "List", 30 * time.Second)
+	mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))`))
 				})
 			})
-			Context("with actions that take a payload with a required validation", func() {
+
+			Context("with an action maximum body size", func() {
 				BeforeEach(func() {
 					actions = []string{"List"}
-					required := &dslengine.ValidationDefinition{
-						Required: []string{"id"},
-					}
 					verbs = []string{"GET"}
 					paths = []string{"/accounts/:accountID/bottles"}
 					contexts = []string{"ListBottleContext"}
-					unmarshals = []string{"unmarshalListBottlePayload"}
-					payloads = []*design.UserTypeDefinition{
-						{
-							TypeName: "ListBottlePayload",
-							AttributeDefinition: &design.AttributeDefinition{
-								Type: design.Object{
-									"id": &design.AttributeDefinition{
-										Type: design.String,
-									},
-								},
-								Validation: required,
-							},
-						},
-					}
+					maxSizes = []int64{1024}
 				})
 
-				It("writes the payload unmarshal function", func() {
+				It("limits the size of the request bodies", func() {
 					err := writer.Execute(data)
 					Ω(err).ShouldNot(HaveOccurred())
 					b, err := ioutil.ReadFile(filename)
 					Ω(err).ShouldNot(HaveOccurred())
 					written := string(b)
-					Ω(written).Should(ContainSubstring(payloadObjUnmarshal))
+					Ω(written).Should(ContainSubstring(`service.SetActionMaxRequestSize("bottle", "List", 1024)
+	mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))`))
 				})
 			})
 
@@ -634,7 +1660,7 @@ var _ = Describe("HrefWriter", func() {
 	Context("correctly configured", func() {
 		Context("with data", func() {
 			var canoTemplate string
-			var canoParams []string
+			var canoParams, baseParams, hrefParams []string
 			var mediaType *design.MediaTypeDefinition
 
 			var data *genapp.ResourceData
@@ -643,6 +1669,8 @@ var _ = Describe("HrefWriter", func() {
 				mediaType = nil
 				canoTemplate = ""
 				canoParams = nil
+				baseParams = nil
+				hrefParams = nil
 				data = nil
 			})
 
@@ -654,6 +1682,8 @@ var _ = Describe("HrefWriter", func() {
 					Type:              mediaType,
 					CanonicalTemplate: canoTemplate,
 					CanonicalParams:   canoParams,
+					BaseParams:        baseParams,
+					HrefParams:        hrefParams,
 				}
 			})
 
@@ -717,12 +1747,47 @@ var _ = Describe("HrefWriter", func() {
 						Ω(written).Should(ContainSubstring(simpleResourceHref))
 					})
 				})
+
+				Context("and a canonical action using base params", func() {
+					BeforeEach(func() {
+						canoTemplate = "/%v/%v/bottles/%v"
+						canoParams = []string{"tenant", "stage", "id"}
+						baseParams = []string{"tenant", "stage"}
+						hrefParams = []string{"id"}
+					})
+
+					It("writes the href methods", func() {
+						err := writer.Execute(data)
+						Ω(err).ShouldNot(HaveOccurred())
+						b, err := ioutil.ReadFile(filename)
+						Ω(err).ShouldNot(HaveOccurred())
+						written := string(b)
+						Ω(written).Should(ContainSubstring(baseParamsResourceHref))
+						Ω(written).Should(ContainSubstring(baseParamsResourceHrefFromContext))
+					})
+				})
 			})
 		})
 	})
 })
 
 const (
+	sessionAccessors = `func (ctx *ListBottleContext) Session() (*AccountSession, error) {
+	var s AccountSession
+	ok, err := accountSessionCookie.Load(ctx, &s)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SaveSession writes the session cookie holding the given state to the response. It must be
+// called before the response is sent.
+func (ctx *ListBottleContext) SaveSession(s *AccountSession) error {
+	return accountSessionCookie.Save(ctx, s)
+}
+`
+
 	emptyContext = `
 type ListBottleContext struct {
 	context.Context
@@ -857,10 +1922,9 @@ func NewListBottleContext(ctx context.Context) (*ListBottleContext, error) {
 	var err error
 	req := goa.Request(ctx)
 	rctx := ListBottleContext{Context: ctx, ResponseData: goa.Response(ctx), RequestData: req}
-	rawParam := req.Params.Get("param")
-	if rawParam != "" {
-		elemsParam := strings.Split(rawParam, ",")
-		rctx.Param = elemsParam
+	rawParam := goa.ParamValues(req.Params, "param")
+	if len(rawParam) > 0 {
+		rctx.Param = rawParam
 	}
 	return &rctx, err
 }
@@ -880,18 +1944,76 @@ func NewListBottleContext(ctx context.Context) (*ListBottleContext, error) {
 	var err error
 	req := goa.Request(ctx)
 	rctx := ListBottleContext{Context: ctx, ResponseData: goa.Response(ctx), RequestData: req}
-	rawParam := req.Params.Get("param")
-	if rawParam != "" {
-		elemsParam := strings.Split(rawParam, ",")
-		elemsParam2 := make([]int, len(elemsParam))
-		for i, rawElem := range elemsParam {
+	rawParam := goa.ParamValues(req.Params, "param")
+	if len(rawParam) > 0 {
+		elemsParam := make([]int, len(rawParam))
+		for i, rawElem := range rawParam {
+			if elem, err2 := strconv.Atoi(rawElem); err2 == nil {
+				elemsParam[i] = int(elem)
+			} else {
+				err = goa.InvalidParamTypeError("elem", rawElem, "integer", err)
+			}
+		}
+		rctx.Param = elemsParam
+	}
+	return &rctx, err
+}
+`
+
+	typedHeadersContext = `
+type ListBottleContext struct {
+	context.Context
+	*goa.ResponseData
+	*goa.RequestData
+	XCount int
+	XSince *time.Time
+}
+`
+
+	typedHeadersContextFactory = `
+	rawXCount := req.Header.Get("X-Count")
+	if rawXCount == "" {
+		err = goa.MissingHeaderError("X-Count", err)
+	} else {
+		if xCount, err2 := strconv.Atoi(rawXCount); err2 == nil {
+			rctx.XCount = int(xCount)
+		} else {
+			err = goa.InvalidHeaderTypeError("X-Count", rawXCount, "integer", err)
+		}
+			if rctx.XCount < 1 {
+			err = goa.InvalidRangeError(` + "`" + `X-Count` + "`" + `, rctx.XCount, 1, true, err)
+		}
+	}
+	rawXSince := req.Header.Get("X-Since")
+	if rawXSince != "" {
+		if xSince, err2 := time.Parse(time.RFC3339, rawXSince); err2 == nil {
+			tmp2 := &xSince
+			rctx.XSince = tmp2
+		} else {
+			err = goa.InvalidHeaderTypeError("X-Since", rawXSince, "datetime", err)
+		}
+	}
+	rawXTrace := req.Header.Get("X-Trace")
+	if rawXTrace != "" {
+	}
+`
+
+	hashContextFactory = `
+func NewListBottleContext(ctx context.Context) (*ListBottleContext, error) {
+	var err error
+	req := goa.Request(ctx)
+	rctx := ListBottleContext{Context: ctx, ResponseData: goa.Response(ctx), RequestData: req}
+	rawParam := goa.ParamMap(req.Params, "param")
+	if len(rawParam) > 0 {
+		elemsParam := make(map[string]int, len(rawParam))
+		for key, rawElem := range rawParam {
 			if elem, err2 := strconv.Atoi(rawElem); err2 == nil {
-				elemsParam2[i] = int(elem)
+				elemsParam[key] = int(elem)
 			} else {
 				err = goa.InvalidParamTypeError("elem", rawElem, "integer", err)
 			}
 		}
-		rctx.Param = elemsParam2
+		rctx.Param = elemsParam
 	}
 	return &rctx, err
 }
@@ -977,6 +2099,219 @@ type ListBottleContext struct {
 	*goa.RequestData
 	Payload *ListBottlePayload
 }
+`
+
+	retryAfterResponse = `
+// TooManyRequests sends a HTTP response with status code 429.
+func (ctx *ListBottleContext) TooManyRequests() error {
+	goa.SetRetryAfter(ctx.ResponseData.Header(), (&goa.RetryAfterPolicy{Strategy: "fixed", Delay: 30 * time.Second}).Next(ctx))
+	ctx.ResponseData.WriteHeader(429)
+	return nil
+}
+`
+
+	cachedResponse = `
+// OK sends a HTTP response with status code 200.
+func (ctx *ListBottleContext) OK() error {
+	ctx.ResponseData.Header().Set("Cache-Control", "public, max-age=3600")
+	ctx.ResponseData.Header().Add("Vary", "Accept")
+	ctx.ResponseData.Header().Add("Vary", "Accept-Language")
+	ctx.ResponseData.WriteHeader(200)
+	return nil
+}
+`
+
+	locationResponse = `
+// SetCreatedLocation sets the Location header of the Created response to the
+// Bottle href built from the given parameters and the request base parameters.
+func (ctx *ListBottleContext) SetCreatedLocation(id interface{}) {
+	ctx.ResponseData.Header().Set("Location", BottleHrefFromContext(ctx, id))
+}
+`
+
+	webSocketConn = `
+// ListBottlesConn is a bottles list WebSocket connection.
+type ListBottlesConn struct {
+	*wshub.Conn
+}
+
+// Send sends a message to the client.
+func (c *ListBottlesConn) Send(msg *ChatMessage) error {
+	return c.Conn.Send(msg)
+}
+
+// Receive waits for the next message sent by the client.
+func (c *ListBottlesConn) Receive() (*ChatMessage, error) {
+	var msg *ChatMessage
+	err := c.Conn.Receive(&msg)
+	return msg, err
+}
+`
+
+	webSocketUpgrade = `
+// Upgrade upgrades the request to a WebSocket connection served by hub and calls handler with
+// the connection. Upgrade returns once the connection is closed.
+func (ctx *ListBottleContext) Upgrade(hub *wshub.Hub, handler func(*ListBottlesConn) error) error {
+	subprotocols := []string{"chat.v1", "chat.v2"}
+	return hub.Serve(ctx.Context, ctx.ResponseData, ctx.RequestData.Request, subprotocols, func(c *wshub.Conn) error {
+		return handler(&ListBottlesConn{Conn: c})
+	})
+}
+`
+
+	longPollWaitContext = `
+// WaitContext returns a context that is canceled once the wait requested by the client expires.
+func (ctx *ListBottleContext) WaitContext() (context.Context, context.CancelFunc) {
+	wait := 20
+	if ctx.Wait != nil {
+		wait = *ctx.Wait
+	}
+	return goa.LongPollContext(ctx.Context, wait, 30 * time.Second)
+}
+`
+
+	longPollPoll = `
+func (ctx *ListBottleContext) Poll(source goa.EventSource) ([]interface{}, string, error) {
+	wctx, cancel := ctx.WaitContext()
+	defer cancel()
+	var cursor string
+	if ctx.Cursor != nil {
+		cursor = *ctx.Cursor
+	}
+	return goa.LongPoll(wctx, source, cursor)
+}
+`
+
+	streamResponse = `
+// OKStream streams the values received on values with status code 200 using
+// server-sent events.
+// It returns once values is closed, the request context is done or the client goes away.
+// opts may be nil, the values not set in opts default to the values defined in the design.
+func (ctx *ListBottleContext) OKStream(values <-chan *Bottle, opts *goa.StreamOptions) error {
+	opts = opts.WithDefaults(goa.StreamSSE, 15*time.Second)
+	return ctx.ResponseData.Stream(ctx.Context, 200, opts, values)
+}
+`
+
+	rawResponse = `
+// OK sends a HTTP response with status code 200.
+// The response body is copied from body as is and the Content-Type header is set to contentType.
+func (ctx *ListBottleContext) OK(contentType string, body io.Reader) error {
+	return ctx.ResponseData.SendRaw(200, contentType, body)
+}
+`
+
+	rangeResponse = `
+// Success sends a HTTP response with a 2xx status code.
+func (ctx *ListBottleContext) Success(status int, resp []byte) error {
+	if status/100 != 2 {
+		return fmt.Errorf("invalid status %d for response Success, must be 2xx", status)
+	}
+	ctx.ResponseData.Header().Set("Content-Type", "application/json")
+	ctx.ResponseData.WriteHeader(status)
+	ctx.ResponseData.Write(resp)
+	return nil
+}
+`
+
+	alternateResponse = `
+// SuccessError sends a HTTP response with a 2xx status code.
+func (ctx *ListBottleContext) SuccessError(status int, r *Error) error {
+	if status/100 != 2 {
+		return fmt.Errorf("invalid status %d for response Success, must be 2xx", status)
+	}
+	ctx.ResponseData.Header().Set("Content-Type", "application/vnd.goa.error")
+	return ctx.ResponseData.Send(ctx.Context, status, r)
+}
+`
+
+	resultResponse = `
+// OKResult projects r onto the default view of the response media type and sends a HTTP
+// response with status code 200.
+func (ctx *ListBottleContext) OKResult(r *BottleResult) error {
+	return ctx.OK(BottleResultToBottle(r))
+}
+`
+
+	sparseContext = `
+type ListBottleContext struct {
+	context.Context
+	*goa.ResponseData
+	*goa.RequestData
+	// Fields lists the attributes rendered in the responses, all the attributes of the
+	// response views if empty.
+	Fields []string
+}
+`
+
+	sparseContextFactory = `
+	if rawFields := req.Params.Get("fields"); rawFields != "" {
+		rctx.Fields, err = goa.ParseFields(rawFields, []string{"body", "version"}, err)
+	}
+	return &rctx, err
+`
+
+	etagResponse = `
+// OK sends a HTTP response with status code 200.
+func (ctx *ListBottleContext) OK(r *Document) error {
+	ctx.ResponseData.Header().Set("Content-Type", "application/vnd.document+json")
+	return ctx.ResponseData.SendETag(ctx.Context, 200, r, goa.AttributeETag(r.Version), false)
+}
+`
+
+	jsonAPIResponse = `
+// OK sends a HTTP response with status code 200.
+func (ctx *ListBottleContext) OK(r *Document) error {
+	ctx.ResponseData.Header().Set("Content-Type", "application/vnd.api+json")
+	return ctx.ResponseData.SendETag(ctx.Context, 200, goa.RenderJSONAPI(r, "document", nil), goa.AttributeETag(r.Version), false)
+}
+`
+
+	preconditions = `
+// Preconditions sets the ETag response header and evaluates the request If-Match and If-None-Match
+// headers against the current ETag of the resource. It writes a 304 or 412 response and returns
+// true if a precondition fails, the action must then return without sending a response.
+func (ctx *ListBottleContext) Preconditions(etag string) bool {
+	return goa.CheckPreconditions(ctx.Context, etag)
+}
+`
+
+	payloadObjBuilder = `
+// ListBottlePayloadBuilder builds ListBottlePayload values, see NewListBottlePayloadBuilder.
+type ListBottlePayloadBuilder struct {
+	payload *ListBottlePayload
+}
+
+// NewListBottlePayloadBuilder creates a ListBottlePayload builder initialized with the default values defined
+// in the design.
+func NewListBottlePayloadBuilder() *ListBottlePayloadBuilder {
+	payload := &ListBottlePayload{}
+	defaultStr := "foo"
+	payload.Str = &defaultStr
+	return &ListBottlePayloadBuilder{payload: payload}
+}
+
+// Int sets the value of the "int" payload field.
+func (b *ListBottlePayloadBuilder) Int(val int) *ListBottlePayloadBuilder {
+	b.payload.Int = val
+	return b
+}
+
+// Str sets the value of the "str" payload field.
+func (b *ListBottlePayloadBuilder) Str(val string) *ListBottlePayloadBuilder {
+	b.payload.Str = &val
+	return b
+}
+
+// Build returns the ListBottlePayload built so far or
+// an error if it does not validate.
+func (b *ListBottlePayloadBuilder) Build() (*ListBottlePayload, error) {
+	payload := *b.payload
+	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
 `
 
 	payloadObjUnmarshal = `
@@ -1028,10 +2363,21 @@ func MountBottlesController(service *goa.Service, ctrl BottlesController) {
 		return ctrl.List(rctx)
 	}
 	mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
-	goa.Info(goa.RootContext, "mount", goa.KV{"ctrl", "Bottles"}, goa.KV{"action", "List"}, goa.KV{"route", "GET /accounts/:accountID/bottles"})
+	service.LogInfo("mount", goa.KV{"ctrl", "Bottles"}, goa.KV{"action", "List"}, goa.KV{"route", "GET /accounts/:accountID/bottles"})
 }
 `
 
+	proxyMount = `	searchProxy := goa.NewReverseProxy("http://search.internal")
+	searchProxy.Timeout = 10 * time.Second
+	searchProxy.RemoveHeaders = []string{ "Cookie" }
+	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		if _, err := NewSearchBottleContext(ctx); err != nil {
+			return goa.NewBadRequestError(err)
+		}
+		return searchProxy.Handle(ctx, rw, req)
+	}
+`
+
 	simpleMount = `func MountBottlesController(service *goa.Service, ctrl BottlesController) {
 	// Setup encoders and decoders. This is idempotent and is done by each MountXXX function.
 
@@ -1046,7 +2392,7 @@ func MountBottlesController(service *goa.Service, ctrl BottlesController) {
 		return ctrl.List(rctx)
 	}
 	mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
-	goa.Info(goa.RootContext, "mount", goa.KV{"ctrl", "Bottles"}, goa.KV{"action", "List"}, goa.KV{"route", "GET /accounts/:accountID/bottles"})
+	service.LogInfo("mount", goa.KV{"ctrl", "Bottles"}, goa.KV{"action", "List"}, goa.KV{"route", "GET /accounts/:accountID/bottles"})
 }
 `
 
@@ -1072,7 +2418,7 @@ type BottlesController interface {
 		return ctrl.List(rctx)
 	}
 	mux.Handle("GET", "/accounts/:accountID/bottles", ctrl.MuxHandler("List", h, nil))
-	goa.Info(goa.RootContext, "mount", goa.KV{"ctrl", "Bottles"}, goa.KV{"action", "List"}, goa.KV{"route", "GET /accounts/:accountID/bottles"})
+	service.LogInfo("mount", goa.KV{"ctrl", "Bottles"}, goa.KV{"action", "List"}, goa.KV{"route", "GET /accounts/:accountID/bottles"})
 	h = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
 		rctx, err := NewShowBottleContext(ctx)
 		if err != nil {
@@ -1081,12 +2427,213 @@ type BottlesController interface {
 		return ctrl.Show(rctx)
 	}
 	mux.Handle("GET", "/accounts/:accountID/bottles/:id", ctrl.MuxHandler("Show", h, nil))
-	goa.Info(goa.RootContext, "mount", goa.KV{"ctrl", "Bottles"}, goa.KV{"action", "Show"}, goa.KV{"route", "GET /accounts/:accountID/bottles/:id"})
+	service.LogInfo("mount", goa.KV{"ctrl", "Bottles"}, goa.KV{"action", "Show"}, goa.KV{"route", "GET /accounts/:accountID/bottles/:id"})
 }
 `
 
 	simpleResourceHref = `func BottleHref(id interface{}) string {
 	return fmt.Sprintf("/bottles/%v", id)
 }
+`
+
+	baseParamsResourceHref = `func BottleHref(tenant, stage, id interface{}) string {
+	return fmt.Sprintf("/%v/%v/bottles/%v", tenant, stage, id)
+}
+`
+
+	baseParamsResourceHrefFromContext = `// BottleHrefFromContext returns the resource href using the values of the API base parameters
+// tenant, stage of the request with the given context, see goa.WithBaseParams.
+func BottleHrefFromContext(ctx context.Context, id interface{}) string {
+	base := goa.ContextBaseParams(ctx)
+	return BottleHref(base.Get("tenant"), base.Get("stage"), id)
+}
 `
 )
+
+var _ = Describe("UserTypesWriter", func() {
+	var writer *genapp.UserTypesWriter
+	var workspace *codegen.Workspace
+	var filename string
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		pkg, err := workspace.NewPackage("app")
+		Ω(err).ShouldNot(HaveOccurred())
+		src := pkg.CreateSourceFile("test.go")
+		filename = src.Abs()
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		writer, err = genapp.NewUserTypesWriter(filename)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	Context("with an enum validation", func() {
+		var data *genapp.UserTypeTemplateData
+
+		BeforeEach(func() {
+			rating := &design.AttributeDefinition{
+				Type:       design.String,
+				Validation: &dslengine.ValidationDefinition{Values: []interface{}{"good", "bad"}},
+			}
+			userType := &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{"rating": rating},
+				},
+				TypeName: "Bottle",
+			}
+			data = &genapp.UserTypeTemplateData{UserType: userType}
+		})
+
+		It("generates the enum constants and validation helper", func() {
+			err := writer.Execute(data)
+			Ω(err).ShouldNot(HaveOccurred())
+			b, err := ioutil.ReadFile(filename)
+			Ω(err).ShouldNot(HaveOccurred())
+			written := string(b)
+			Ω(written).Should(MatchRegexp(`BottleRatingGood\s+string = "good"`))
+			Ω(written).Should(MatchRegexp(`BottleRatingBad\s+string = "bad"`))
+			Ω(written).Should(ContainSubstring("func IsValidBottleRating(v string) bool {"))
+			Ω(written).Should(ContainSubstring("case BottleRatingGood, BottleRatingBad:"))
+		})
+	})
+
+	Context("with fields restricted with VisibleTo", func() {
+		var data *genapp.UserTypeTemplateData
+
+		BeforeEach(func() {
+			restricted := func(t design.DataType, roles ...string) *design.AttributeDefinition {
+				return &design.AttributeDefinition{
+					Type:     t,
+					Metadata: dslengine.MetadataDefinition{design.VisibleToKey: roles},
+				}
+			}
+			price := &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{"cost": restricted(design.Number, "buyer")},
+				},
+				TypeName: "Price",
+			}
+			userType := &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"name":   &design.AttributeDefinition{Type: design.String},
+						"vendor": restricted(design.String, "admin", "buyer"),
+						"prices": &design.AttributeDefinition{Type: &design.Array{ElemType: &design.AttributeDefinition{Type: price}}},
+						"details": &design.AttributeDefinition{Type: design.Object{
+							"margin": restricted(design.Number, "admin"),
+						}},
+					},
+				},
+				TypeName: "Bottle",
+			}
+			data = &genapp.UserTypeTemplateData{UserType: userType}
+		})
+
+		It("generates the Restrict method", func() {
+			err := writer.Execute(data)
+			Ω(err).ShouldNot(HaveOccurred())
+			b, err := ioutil.ReadFile(filename)
+			Ω(err).ShouldNot(HaveOccurred())
+			written := string(b)
+			Ω(written).Should(ContainSubstring(restrictCode))
+		})
+	})
+
+	Context("with encrypted fields", func() {
+		var data *genapp.UserTypeTemplateData
+
+		BeforeEach(func() {
+			encrypted := &design.AttributeDefinition{
+				Type:     design.String,
+				Metadata: dslengine.MetadataDefinition{design.EncryptedKey: nil},
+			}
+			card := &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{"number": encrypted},
+				},
+				TypeName: "Card",
+			}
+			userType := &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"name":  &design.AttributeDefinition{Type: design.String},
+						"ssn":   encrypted,
+						"cards": &design.AttributeDefinition{Type: &design.Array{ElemType: &design.AttributeDefinition{Type: card}}},
+					},
+					Validation: &dslengine.ValidationDefinition{Required: []string{"ssn"}},
+				},
+				TypeName: "Account",
+			}
+			data = &genapp.UserTypeTemplateData{UserType: userType}
+		})
+
+		It("generates the EncryptFields and DecryptFields methods", func() {
+			err := writer.Execute(data)
+			Ω(err).ShouldNot(HaveOccurred())
+			b, err := ioutil.ReadFile(filename)
+			Ω(err).ShouldNot(HaveOccurred())
+			written := string(b)
+			Ω(written).Should(ContainSubstring(cryptCode))
+		})
+	})
+})
+
+const cryptCode = `// EncryptFields replaces the values of the encrypted fields of ut with their ciphertext.
+func (ut *Account) EncryptFields(ctx context.Context) error {
+	if ut == nil {
+		return nil
+	}
+	for _, e1 := range ut.Cards {
+		if err := e1.EncryptFields(ctx); err != nil {
+			return err
+		}
+	}
+	if ut.Ssn != "" {
+		v, err := goa.EncryptField(ctx, "ssn", ut.Ssn)
+		if err != nil {
+			return err
+		}
+		ut.Ssn = v
+	}
+	return nil
+}
+
+// DecryptFields replaces the values of the encrypted fields of ut with their plaintext.
+func (ut *Account) DecryptFields(ctx context.Context) error {
+`
+
+const restrictCode = `// Restrict returns a copy of ut where the fields that are not visible to the given roles are
+// omitted.
+func (ut *Bottle) Restrict(roles []string) *Bottle {
+	if ut == nil {
+		return nil
+	}
+	res := *ut
+	if res.Details != nil {
+		v1 := *res.Details
+		if !goa.VisibleTo(roles, "admin") {
+			v1.Margin = nil
+		}
+		res.Details = &v1
+	}
+	if res.Prices != nil {
+		s1 := append(res.Prices[:0:0], res.Prices...)
+		for i1 := range s1 {
+			s1[i1] = s1[i1].Restrict(roles)
+		}
+		res.Prices = s1
+	}
+	if !goa.VisibleTo(roles, "admin", "buyer") {
+		res.Vendor = nil
+	}
+	return &res
+}
+`
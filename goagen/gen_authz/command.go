@@ -0,0 +1,40 @@
+package genauthz
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+// FailUnprotected causes the command to fail if any action has no authorization policy.
+var FailUnprotected bool
+
+// Command is the goa authorization policy coverage report generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("authz", "Generate authorization policy coverage report")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().BoolVar(&FailUnprotected, "fail-unprotected", false, "fail if any action has no authorization policy")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{}
+	if FailUnprotected {
+		flags["fail-unprotected"] = "true"
+	}
+	gen := meta.NewGenerator(
+		"genauthz.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_authz")},
+		flags,
+	)
+	return gen.Generate()
+}
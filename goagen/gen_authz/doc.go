@@ -0,0 +1,13 @@
+/*
+Package genauthz implements the "authz" command which produces a report of the authorization
+policies enforced by the API actions (see the Authorize DSL). The report lists each action with its
+routes, effective security scheme and effective policy and ends with the list of unprotected
+actions, that is the actions no policy applies to.
+
+The report is written to authz_coverage.txt in the output directory. The --fail-unprotected flag
+makes the command fail if any action is unprotected so that it may be used in continuous
+integration:
+
+	goagen authz -d github.com/acme/cellar/design --fail-unprotected
+*/
+package genauthz
@@ -0,0 +1,13 @@
+package genauthz_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenAuthz(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenAuthz Suite")
+}
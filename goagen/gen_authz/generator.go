@@ -0,0 +1,151 @@
+package genauthz
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// ReportFile is the name of the file the coverage report is written to.
+const ReportFile = "authz_coverage.txt"
+
+// Generator is the authorization policy coverage report generator.
+type Generator struct {
+	genfiles []string
+}
+
+type (
+	// Report contains the data used to render the coverage report.
+	Report struct {
+		// API is the API name.
+		API string
+		// Actions lists the API actions sorted by resource and action names.
+		Actions []*ActionCoverage
+		// Unprotected lists the actions that have no authorization policy.
+		Unprotected []*ActionCoverage
+	}
+
+	// ActionCoverage describes the authorization of a single action.
+	ActionCoverage struct {
+		// Resource is the name of the action resource.
+		Resource string
+		// Action is the action name.
+		Action string
+		// Routes lists the action routes formatted as "METHOD /path".
+		Routes []string
+		// Security is the name of the effective security scheme, empty if none.
+		Security string
+		// Policy is the name of the effective policy, empty if none.
+		Policy string
+	}
+)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "Authorization policy coverage report generator",
+		Long:  "Authorization policy coverage report generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// Generate writes the coverage report to the output directory. It returns an error listing the
+// unprotected actions if FailUnprotected is true and there is any.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	r := NewReport(api)
+	if err = os.MkdirAll(codegen.OutputDir, 0755); err != nil {
+		return
+	}
+	filename := filepath.Join(codegen.OutputDir, ReportFile)
+	f, err := os.Create(filename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	g.genfiles = append(g.genfiles, filename)
+	tmpl := template.Must(template.New("authz").Funcs(template.FuncMap{"join": strings.Join}).Parse(reportT))
+	if err = tmpl.Execute(f, r); err != nil {
+		return
+	}
+	if FailUnprotected && len(r.Unprotected) > 0 {
+		names := make([]string, len(r.Unprotected))
+		for i, a := range r.Unprotected {
+			names[i] = a.Resource + "#" + a.Action
+		}
+		return g.genfiles, fmt.Errorf("%d unprotected action(s): %s", len(names), strings.Join(names, ", "))
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.Remove(f)
+	}
+	g.genfiles = nil
+}
+
+// NewReport computes the authorization policy coverage of the API actions.
+func NewReport(api *design.APIDefinition) *Report {
+	r := &Report{API: api.Name}
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			ac := &ActionCoverage{
+				Resource: res.Name,
+				Action:   a.Name,
+				Policy:   a.EffectivePolicy(),
+			}
+			for _, rt := range a.Routes {
+				ac.Routes = append(ac.Routes, rt.Verb+" "+rt.FullPath(api.APIVersionDefinition))
+			}
+			if sec := a.EffectiveSecurity(); sec != nil {
+				ac.Security = sec.Scheme.SchemeName
+			}
+			r.Actions = append(r.Actions, ac)
+			if ac.Policy == "" {
+				r.Unprotected = append(r.Unprotected, ac)
+			}
+			return nil
+		})
+	})
+	return r
+}
+
+const reportT = `# Code generated by goagen, DO NOT EDIT.
+# Authorization policy coverage of the {{ .API }} API
+{{ range .Actions }}
+{{ .Resource }}#{{ .Action }}
+  routes:   {{ if .Routes }}{{ join .Routes ", " }}{{ else }}-{{ end }}
+  security: {{ if .Security }}{{ .Security }}{{ else }}-{{ end }}
+  policy:   {{ if .Policy }}{{ .Policy }}{{ else }}UNPROTECTED{{ end }}
+{{ end }}
+{{ len .Actions }} action(s), {{ len .Unprotected }} unprotected{{ range .Unprotected }}
+  {{ .Resource }}#{{ .Action }}{{ end }}
+`
@@ -0,0 +1,77 @@
+package genauthz_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_authz"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var oldDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("authztest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+		oldDesign = design.Design
+
+		res := &design.ResourceDefinition{Name: "bottles", BasePath: "/bottles", Policy: "bottle:read"}
+		show := &design.ActionDefinition{Name: "show", Parent: res}
+		show.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "/:id", Parent: show}}
+		del := &design.ActionDefinition{Name: "delete", Parent: res, Policy: "bottle:admin"}
+		del.Routes = []*design.RouteDefinition{{Verb: "DELETE", Path: "/:id", Parent: del}}
+		res.Actions = map[string]*design.ActionDefinition{"show": show, "delete": del}
+		health := &design.ResourceDefinition{Name: "health", BasePath: "/health"}
+		check := &design.ActionDefinition{Name: "check", Parent: health}
+		check.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "", Parent: check}}
+		health.Actions = map[string]*design.ActionDefinition{"check": check}
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "cellar"},
+			Resources:            map[string]*design.ResourceDefinition{"bottles": res, "health": health},
+		}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = genauthz.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+		design.Design = oldDesign
+		genauthz.FailUnprotected = false
+	})
+
+	It("generates the coverage report", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(files).Should(HaveLen(1))
+		content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), genauthz.ReportFile))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(content)).Should(ContainSubstring("bottles#delete\n  routes:   DELETE /bottles/:id\n  security: -\n  policy:   bottle:admin\n"))
+		Ω(string(content)).Should(ContainSubstring("bottles#show\n  routes:   GET /bottles/:id\n  security: -\n  policy:   bottle:read\n"))
+		Ω(string(content)).Should(ContainSubstring("health#check\n  routes:   GET /health\n  security: -\n  policy:   UNPROTECTED\n"))
+		Ω(string(content)).Should(ContainSubstring("3 action(s), 1 unprotected\n  health#check\n"))
+	})
+
+	Context("with --fail-unprotected", func() {
+		BeforeEach(func() {
+			os.Args = append(os.Args, "--fail-unprotected")
+		})
+
+		It("fails on unprotected actions", func() {
+			Ω(genErr).Should(MatchError("1 unprotected action(s): health#check"))
+		})
+	})
+})
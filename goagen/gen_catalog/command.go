@@ -0,0 +1,47 @@
+package gencatalog
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// Owner is the catalog owner of the service, defaults to the API contact.
+	Owner string
+
+	// Lifecycle is the catalog lifecycle of the service, e.g. "production".
+	Lifecycle string
+
+	// SwaggerURL is the location of the API swagger specification.
+	SwaggerURL string
+)
+
+// Command is the goa service catalog descriptor generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("catalog", "Generate service catalog descriptor (catalog-info.yaml)")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&Owner, "owner", "", "Service owner, defaults to the API contact name or email")
+	r.Flags().StringVar(&Lifecycle, "lifecycle", "production", "Service lifecycle, e.g. experimental, production or deprecated")
+	r.Flags().StringVar(&SwaggerURL, "swagger-url", "", "URL to the API swagger specification, defaults to /swagger.json on the API host")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"owner": Owner, "lifecycle": Lifecycle, "swagger-url": SwaggerURL}
+	gen := meta.NewGenerator(
+		"gencatalog.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_catalog")},
+		flags,
+	)
+	return gen.Generate()
+}
@@ -0,0 +1,12 @@
+/*
+Package gencatalog provides a generator that produces a service catalog descriptor for the API so
+that organizations can register goa services in their service catalog automatically.
+
+The generated file follows the Backstage descriptor format (catalog-info.yaml). It contains a
+Component entity describing the service and an API entity describing its HTTP interface. The
+entities include the API name, title, description and version, the owner (taken from the API
+Contact unless overridden on the command line), the documentation URL, the location of the
+swagger specification, the security schemes (see the "gateway:security" metadata key) and the
+list of routes.
+*/
+package gencatalog
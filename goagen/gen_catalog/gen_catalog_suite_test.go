@@ -0,0 +1,13 @@
+package gencatalog_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenCatalog(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenCatalog Suite")
+}
@@ -0,0 +1,208 @@
+package gencatalog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_gateway"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the service catalog descriptor generator.
+type Generator struct {
+	genfiles []string
+}
+
+// Catalog contains the data used to render the service catalog descriptor.
+type Catalog struct {
+	// Name is the catalog entity name derived from the API name.
+	Name string
+	// Title is the API title.
+	Title string
+	// Description is the API description.
+	Description string
+	// Versions lists the API versions if any.
+	Versions []string
+	// Owner is the service owner.
+	Owner string
+	// Lifecycle is the service lifecycle.
+	Lifecycle string
+	// DocsURL is the URL to the API external documentation if any.
+	DocsURL string
+	// SwaggerURL is the location of the API swagger specification.
+	SwaggerURL string
+	// Security lists the security schemes used by the API routes.
+	Security []string
+	// Routes lists the API routes formatted as "METHOD /path".
+	Routes []string
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "Service catalog descriptor generator",
+		Long:  "Service catalog descriptor generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// Generate produces the service catalog descriptor.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	c, err := NewCatalog(api)
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(codegen.OutputDir, 0755); err != nil {
+		return
+	}
+	catalogFile := filepath.Join(codegen.OutputDir, "catalog-info.yaml")
+	f, err := os.Create(catalogFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	g.genfiles = append(g.genfiles, catalogFile)
+	tmpl := template.Must(template.New("catalog").Funcs(template.FuncMap{"quote": strconv.Quote, "join": strings.Join}).Parse(catalogT))
+	if err = tmpl.Execute(f, c); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.Remove(f)
+	}
+	g.genfiles = nil
+}
+
+// NewCatalog builds the service catalog data from the API definition and the command line flags.
+func NewCatalog(api *design.APIDefinition) (*Catalog, error) {
+	m, err := gengateway.NewManifest(api)
+	if err != nil {
+		return nil, err
+	}
+	c := &Catalog{
+		Name:        EntityName(api.Name),
+		Title:       api.Title,
+		Description: api.Description,
+		Versions:    api.Versions(),
+		Owner:       Owner,
+		Lifecycle:   Lifecycle,
+		SwaggerURL:  SwaggerURL,
+	}
+	if c.Name == "" {
+		return nil, fmt.Errorf("invalid API name %#v, cannot be used as catalog entity name", api.Name)
+	}
+	if c.Owner == "" && api.Contact != nil {
+		c.Owner = api.Contact.Name
+		if c.Owner == "" {
+			c.Owner = api.Contact.Email
+		}
+	}
+	if c.Owner == "" {
+		c.Owner = "unknown"
+	}
+	if c.Lifecycle == "" {
+		c.Lifecycle = "production"
+	}
+	if api.Docs != nil {
+		c.DocsURL = api.Docs.URL
+	}
+	if c.SwaggerURL == "" {
+		scheme := "http"
+		if schemes := api.URLSchemes(); len(schemes) > 0 {
+			scheme = schemes[0]
+		}
+		host := api.Host
+		if host == "" {
+			host = "localhost"
+		}
+		c.SwaggerURL = fmt.Sprintf("%s://%s/swagger.json", scheme, host)
+	}
+	seen := make(map[string]bool)
+	for _, r := range m.Routes {
+		c.Routes = append(c.Routes, r.Method+" "+r.Path)
+		for _, s := range r.Security {
+			if !seen[s] {
+				seen[s] = true
+				c.Security = append(c.Security, s)
+			}
+		}
+	}
+	return c, nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// EntityName returns a catalog entity name for the given API name: lowercase alphanumeric
+// characters separated with dashes.
+func EntityName(name string) string {
+	return strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+const catalogT = `# Code generated by goagen, DO NOT EDIT.
+apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: {{ quote .Name }}
+{{ if .Title }}  title: {{ quote .Title }}
+{{ end }}{{ if .Description }}  description: {{ quote .Description }}
+{{ end }}  annotations:
+{{ if .Versions }}    goa.design/versions: {{ quote (join .Versions ",") }}
+{{ end }}    goa.design/swagger: {{ quote .SwaggerURL }}
+{{ if .Security }}    goa.design/security: {{ quote (join .Security ",") }}
+{{ end }}{{ if .DocsURL }}  links:
+    - url: {{ quote .DocsURL }}
+      title: "Documentation"
+{{ end }}spec:
+  type: service
+  lifecycle: {{ quote .Lifecycle }}
+  owner: {{ quote .Owner }}
+  providesApis:
+    - {{ quote .Name }}
+---
+apiVersion: backstage.io/v1alpha1
+kind: API
+metadata:
+  name: {{ quote .Name }}
+{{ if .Description }}  description: {{ quote .Description }}
+{{ end }}  annotations:
+    goa.design/swagger: {{ quote .SwaggerURL }}
+{{ if .Routes }}    goa.design/routes: {{ quote (join .Routes ", ") }}
+{{ end }}spec:
+  type: openapi
+  lifecycle: {{ quote .Lifecycle }}
+  owner: {{ quote .Owner }}
+  definition:
+    $text: {{ quote .SwaggerURL }}
+`
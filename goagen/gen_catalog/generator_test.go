@@ -0,0 +1,100 @@
+package gencatalog_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_catalog"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var oldDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("catalogtest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+		oldDesign = design.Design
+
+		res := &design.ResourceDefinition{Name: "bottles", BasePath: "/bottles"}
+		action := &design.ActionDefinition{Name: "show", Parent: res}
+		action.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "/:id", Parent: action}}
+		res.Actions = map[string]*design.ActionDefinition{"show": action}
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{
+				Name:        "Wine Cellar",
+				Title:       "The wine cellar API",
+				Description: "Manages bottles",
+				Host:        "cellar.example.com",
+				Schemes:     []string{"https"},
+				Contact:     &design.ContactDefinition{Name: "cellar-team"},
+				Docs:        &design.DocsDefinition{URL: "https://docs.example.com/cellar"},
+				Metadata:    dslengine.MetadataDefinition{"gateway:security": {"jwt"}},
+			},
+			Resources: map[string]*design.ResourceDefinition{"bottles": res},
+		}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = gencatalog.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+		design.Design = oldDesign
+	})
+
+	It("generates the catalog descriptor", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(files).Should(HaveLen(1))
+		content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "catalog-info.yaml"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(content)).Should(ContainSubstring("kind: Component"))
+		Ω(string(content)).Should(ContainSubstring("kind: API"))
+		Ω(string(content)).Should(ContainSubstring(`name: "wine-cellar"`))
+		Ω(string(content)).Should(ContainSubstring(`owner: "cellar-team"`))
+		Ω(string(content)).Should(ContainSubstring(`lifecycle: "production"`))
+		Ω(string(content)).Should(ContainSubstring(`url: "https://docs.example.com/cellar"`))
+		Ω(string(content)).Should(ContainSubstring(`goa.design/swagger: "https://cellar.example.com/swagger.json"`))
+		Ω(string(content)).Should(ContainSubstring(`goa.design/security: "jwt"`))
+		Ω(string(content)).Should(ContainSubstring(`goa.design/routes: "GET /bottles/:id"`))
+	})
+
+	Context("with an owner flag", func() {
+		BeforeEach(func() {
+			os.Args = append(os.Args, "--owner=group:sommeliers", "--swagger-url=https://example.com/swagger.yaml")
+		})
+
+		AfterEach(func() {
+			gencatalog.Owner = ""
+			gencatalog.SwaggerURL = ""
+		})
+
+		It("overrides the API contact", func() {
+			Ω(genErr).ShouldNot(HaveOccurred())
+			content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "catalog-info.yaml"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`owner: "group:sommeliers"`))
+			Ω(string(content)).Should(ContainSubstring(`$text: "https://example.com/swagger.yaml"`))
+		})
+	})
+})
+
+var _ = Describe("EntityName", func() {
+	It("produces lowercase dash separated names", func() {
+		Ω(gencatalog.EntityName("My API (v2)")).Should(Equal("my-api-v2"))
+	})
+})
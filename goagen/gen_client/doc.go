@@ -1,6 +1,17 @@
 /*
 Package genclient provides a generator for the client tool and package of a goa application.
 The generator creates a main.go file and a subpackage containing data structures specific to the
-service.
+service. The client package NewInProcess function creates a client that calls the service handlers
+directly without going through the network, this is useful to embed a service in another one or
+to write fast integration tests:
+
+	service := goa.New("cellar")
+	app.MountBottleController(service, NewBottleController(service))
+	c := client.NewInProcess(service)
+	resp, err := c.ShowBottle(ctx, "/bottles/1")
+
+The client methods accept the context of the request being handled, the requests they make carry
+the B3 tracing headers of the span created by the goa Tracer middleware if any so that they belong
+to the same trace.
 */
 package genclient
@@ -39,11 +39,16 @@ func Generate(roots []interface{}) (files []string, err error) {
 
 func makeToolDir(g *Generator, apiName string) (toolDir string, err error) {
 	codegen.OutputDir = filepath.Join(codegen.OutputDir, "client")
+	toolDir = filepath.Join(codegen.OutputDir, fmt.Sprintf("%s-cli", apiName))
+	if codegen.Filtered() {
+		// Keep the files generated for the resources that are not selected.
+		err = os.MkdirAll(toolDir, 0755)
+		return
+	}
 	if err = os.RemoveAll(codegen.OutputDir); err != nil {
 		return
 	}
 	g.genfiles = append(g.genfiles, codegen.OutputDir)
-	toolDir = filepath.Join(codegen.OutputDir, fmt.Sprintf("%s-cli", apiName))
 	if err = os.MkdirAll(toolDir, 0755); err != nil {
 		return
 	}
@@ -60,6 +65,7 @@ func (g *Generator) generateMain(mainFile string, clientPkg string, funcs templa
 		codegen.SimpleImport("os"),
 		codegen.SimpleImport("time"),
 		codegen.SimpleImport(clientPkg),
+		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("github.com/spf13/cobra"),
 	}
 	for _, pkg := range SignerPackages {
@@ -75,11 +81,13 @@ func (g *Generator) generateMain(mainFile string, clientPkg string, funcs templa
 	g.genfiles = append(g.genfiles, mainFile)
 
 	data := map[string]interface{}{
-		"API":     api,
-		"Signers": Signers,
-		"Version": Version,
+		"API":       api,
+		"Signers":   Signers,
+		"HMAC":      hmacScheme(api),
+		"MutualTLS": hasMutualTLS(api),
+		"Version":   Version,
 	}
-	if err := file.ExecuteTemplate("main", mainTmpl, nil, data); err != nil {
+	if err := file.ExecuteTemplate("clientMain", mainTmpl, nil, data); err != nil {
 		return err
 	}
 
@@ -101,6 +109,30 @@ func (g *Generator) generateMain(mainFile string, clientPkg string, funcs templa
 	return file.FormatCode()
 }
 
+// hmacScheme returns the first HMAC security scheme of the API, nil if there is none. The client
+// tool signs the requests with the key given on the command line for this scheme.
+func hmacScheme(api *design.APIDefinition) *design.SecuritySchemeDefinition {
+	var scheme *design.SecuritySchemeDefinition
+	api.IterateSecuritySchemes(func(s *design.SecuritySchemeDefinition) error {
+		if scheme == nil && s.Kind == design.HMACSecurityKind {
+			scheme = s
+		}
+		return nil
+	})
+	return scheme
+}
+
+// hasMutualTLS returns true if the API defines a mutual TLS security scheme in which case the
+// client tool accepts the client certificate on the command line.
+func hasMutualTLS(api *design.APIDefinition) bool {
+	found := false
+	api.IterateSecuritySchemes(func(s *design.SecuritySchemeDefinition) error {
+		found = found || s.Kind == design.MutualTLSSecurityKind
+		return nil
+	})
+	return found
+}
+
 func (g *Generator) generateCommands(commandsFile string, clientPkg string, funcs template.FuncMap, api *design.APIDefinition) error {
 	file, err := codegen.SourceFileFor(commandsFile)
 	if err != nil {
@@ -115,6 +147,7 @@ func (g *Generator) generateCommands(commandsFile string, clientPkg string, func
 		codegen.SimpleImport(clientPkg),
 		codegen.SimpleImport("github.com/goadesign/goa"),
 		codegen.SimpleImport("github.com/spf13/cobra"),
+		codegen.SimpleImport("golang.org/x/net/context"),
 	}
 	if err := file.WriteHeader("", "main", imports); err != nil {
 		return err
@@ -157,6 +190,7 @@ func (g *Generator) generateClient(clientFile string, clientPkg string, funcs te
 	imports := []*codegen.ImportSpec{
 		codegen.SimpleImport("net/http"),
 		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("github.com/goadesign/goa/apikey"),
 		codegen.SimpleImport("github.com/spf13/cobra"),
 	}
 	if err := file.WriteHeader("", "client", imports); err != nil {
@@ -182,15 +216,28 @@ func (g *Generator) generateClientResources(clientPkg string, funcs template.Fun
 		codegen.SimpleImport("net/url"),
 		codegen.SimpleImport("strconv"),
 		codegen.SimpleImport("strings"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("golang.org/x/net/context"),
 	}
 
 	return api.IterateResources(func(res *design.ResourceDefinition) error {
+		if !codegen.ResourceSelected(res) {
+			return nil
+		}
 		filename := filepath.Join(codegen.OutputDir, snakeCase(res.Name)+".go")
 		file, err := codegen.SourceFileFor(filename)
 		if err != nil {
 			return err
 		}
-		if err := file.WriteHeader("", "client", imports); err != nil {
+		var payloads []*design.AttributeDefinition
+		res.IterateActions(func(action *design.ActionDefinition) error {
+			if action.Payload != nil {
+				payloads = append(payloads, action.Payload.AttributeDefinition)
+			}
+			return nil
+		})
+		resImports := append(imports[:len(imports):len(imports)], codegen.FieldTypeImports(payloads...)...)
+		if err := file.WriteHeader("", "client", resImports); err != nil {
 			return err
 		}
 		g.genfiles = append(g.genfiles, filename)
@@ -215,6 +262,10 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		}
 	}()
 
+	if err = codegen.CheckFilters(api); err != nil {
+		return
+	}
+
 	// Make tool directory
 	toolDir, err := makeToolDir(g, api.Name)
 	if err != nil {
@@ -320,13 +371,20 @@ func join(att *design.AttributeDefinition) string {
 		return ""
 	}
 	obj := att.Type.ToObject()
-	elems := make([]string, len(obj))
-	i := 0
-	for n, a := range obj {
-		elems[i] = fmt.Sprintf("%s %s", n, codegen.GoNativeType(a.Type))
-		i++
+	// Sort the same way as joinNames so that the arguments match.
+	byField := make(map[string]string, len(obj))
+	fields := make([]string, 0, len(obj))
+	for n := range obj {
+		f := codegen.Goify(n, true)
+		byField[f] = n
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	elems := make([]string, len(fields))
+	for i, f := range fields {
+		n := byField[f]
+		elems[i] = fmt.Sprintf("%s %s", codegen.Goify(n, false), codegen.GoNativeType(obj[n].Type))
 	}
-	sort.Strings(elems)
 	return strings.Join(elems, ", ")
 }
 
@@ -357,7 +415,7 @@ func toString(name, target string, att *design.AttributeDefinition) string {
 		case design.StringKind:
 			return fmt.Sprintf("%s := %s", target, name)
 		case design.DateTimeKind:
-			return fmt.Sprintf("%s, err  := time.Parse(time.RFC3339, '%s')", target, name)
+			return fmt.Sprintf("%s := %s.Format(time.RFC3339)", target, name)
 		case design.AnyKind:
 			return fmt.Sprintf("%s := fmt.Sprintf(\"%%v\", %s)", target, name)
 		default:
@@ -392,6 +450,11 @@ func flagType(att *design.AttributeDefinition) string {
 		return "String"
 	case design.ArrayKind:
 		return flagType(att.Type.(*design.Array).ElemType) + "Slice"
+	case design.HashKind:
+		if att.Type.(*design.Hash).ElemType.Type.Kind() == design.IntegerKind {
+			return "StringToInt"
+		}
+		return "StringToString"
 	case design.UserTypeKind:
 		return flagType(att.Type.(*design.UserTypeDefinition).AttributeDefinition)
 	case design.MediaTypeKind:
@@ -424,14 +487,31 @@ func main() {
 		Short: "CLI client for the {{.API.Name}} service{{if .API.Docs}} ({{.API.Docs.URL}}){{end}}",
 	}
 	c := client.New()
-{{if .Signers}}	c.Signers = RegisterSigners(app)
+{{if or .Signers .HMAC}}	c.Signers = RegisterSigners(app)
 {{end}}	c.UserAgent = "{{.API.Name}}-cli/{{.Version}}"
-	app.PersistentFlags().StringVarP(&c.Scheme, "scheme", "s", "{{if gt (len .API.Schemes) 0}}{{index .API.Schemes 0}}{{end}}", "Set the requests scheme")
+	app.PersistentFlags().StringVarP(&c.Scheme, "scheme", "s", "{{if gt (len .API.URLSchemes) 0}}{{index .API.URLSchemes 0}}{{end}}", "Set the requests scheme")
 	app.PersistentFlags().StringVarP(&c.Host, "host", "H", "{{.API.Host}}", "API hostname")
 	app.PersistentFlags().DurationVarP(&c.Timeout, "timeout", "t", time.Duration(20) * time.Second, "Set the request timeout, defaults to 20s")
 	app.PersistentFlags().BoolVar(&c.Dump, "dump", false, "Dump HTTP request and response.")
+	app.PersistentFlags().IntVar(&c.MaxRetries, "retries", 3, "Maximum number of retries of requests rejected with a Retry-After header")
+	app.PersistentFlags().DurationVar(&c.MaxRetryAfter, "max-retry-after", time.Minute, "Maximum time to wait before retrying a request")
 	app.PersistentFlags().BoolVar(&PrettyPrint, "pp", false, "Pretty print response body")
-	RegisterCommands(app, c)
+{{with .API.Tenant}}{{if ne .Source "param"}}	var tenant string
+	app.PersistentFlags().StringVar(&tenant, "tenant", "", "Tenant the requests are made on behalf of")
+	c.Use(func(req *http.Request) error {
+		return goa.SetRequestTenant(req, {{printf "%q" .Source}}, {{printf "%q" .Name}}, tenant)
+	})
+{{end}}{{end}}{{if .MutualTLS}}	var certFile, keyFile, caFile string
+	app.PersistentFlags().StringVar(&certFile, "cert", "", "Client certificate file")
+	app.PersistentFlags().StringVar(&keyFile, "key", "", "Client certificate private key file")
+	app.PersistentFlags().StringVar(&caFile, "ca", "", "File of the certificate authorities used to verify the service certificate")
+	app.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if certFile == "" {
+			return nil
+		}
+		return c.UseClientCertificate(certFile, keyFile, caFile)
+	}
+{{end}}	RegisterCommands(app, c)
 	if err := app.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "request failed: %s", err)
 		os.Exit(-1)
@@ -492,11 +572,14 @@ func HandleResponse(c *client.Client, resp *http.Response) {
 	os.Exit(exitStatus)
 }
 
-{{if .Signers}}// RegisterSigners adds the supported signers to the command line.
+{{if or .Signers .HMAC}}// RegisterSigners adds the supported signers to the command line.
 func RegisterSigners(app *cobra.Command) (signers []goa.Signer) {
 {{range $signers := .Signers}}{{$tmp := tempvar}}	{{$tmp}} := &{{$signers}}{}
 	{{$tmp}}.RegisterFlags(app)
 	signers = append(signers, {{$tmp}})
+{{end}}{{with .HMAC}}{{$tmp := tempvar}}	{{$tmp}} := &goa.HMACSigner{Header: {{printf "%q" .Name}}}
+	{{$tmp}}.RegisterFlags(app)
+	signers = append(signers, {{$tmp}})
 {{end}}	return
 }
 {{end}}
@@ -515,7 +598,7 @@ const commandTypesTmpl = `{{$cmdName := goify (printf "%s%s%s" .Name (title .Par
 {{end}}{{$params := .QueryParams}}{{if $params}}{{range $name, $att := $params.Type.ToObject}}{{if $att.Description}}		// {{$att.Description}}
 {{end}}		{{goify $name true}} {{nativeType $att.Type}}
 {{end}}{{end}}{{$headers := .Headers}}{{if $headers}}{{range $name, $att := $headers.Type.ToObject}}{{if $att.Description}}		// {{$att.Description}}
-{{end}}		{{goify $name true}} string
+{{end}}		{{goify $name true}} {{nativeType $att.Type}}
 {{end}}{{end}}	}
 
 `
@@ -538,7 +621,7 @@ func (cmd *{{$cmdName}}) Run(c *client.Client, args []string) error {
 {{else}}			return fmt.Errorf("failed to deserialize payload: %s", err)
 {{end}}		}
 	}
-{{end}}	resp, err := c.{{goify (printf "%s%s" .Action.Name (title .Resource.Name)) true}}(path{{if .Action.Payload}}, {{if or .Action.Payload.Type.IsObject .Action.Payload.IsPrimitive}}&{{end}}payload{{else}}{{end}}{{/*
+{{end}}	resp, err := c.{{goify (printf "%s%s" .Action.Name (title .Resource.Name)) true}}(context.Background(), path{{if .Action.Payload}}, {{if or .Action.Payload.Type.IsObject .Action.Payload.IsPrimitive}}&{{end}}payload{{else}}{{end}}{{/*
 	*/}}{{$params := joinNames .Action.QueryParams}}{{if $params}}, {{$params}}{{end}}{{/*
 	*/}}{{$headers := joinNames .Action.Headers}}{{if $headers}}, {{$headers}}{{end}})
 	if err != nil {
@@ -555,9 +638,9 @@ func (cmd *{{$cmdName}}) RegisterFlags(cc *cobra.Command) {
 */}}{{if not $param.DefaultValue}}	var {{$tmp}} {{gotypedef $param false "" 1 true}}
 {{end}}	cc.Flags().{{flagType $param}}Var(&cmd.{{goify $name true}}, "{{$name}}", {{if $param.DefaultValue}}{{printf "%#v" $param.DefaultValue}}{{else}}{{$tmp}}{{end}}, "{{$param.Description}}")
 {{end}}{{end}}{{/*
-*/}}{{$headers := .Action.Headers}}{{if $headers}}{{range $name, $header := $headers.Type.ToObject}}{{/*
-*/}}
-	cc.Flags().StringVar(&cmd.{{goify $name true}}, "{{$name}}", {{if $header.DefaultValue}}"{{$header.DefaultValue}}"{{else}}""{{end}}, "{{$header.Description}}")
+*/}}{{$headers := .Action.Headers}}{{if $headers}}{{range $name, $header := $headers.Type.ToObject}}{{$tmp := tempvar}}{{/*
+*/}}{{if not $header.DefaultValue}}	var {{$tmp}} {{gotypedef $header false "" 1 true}}
+{{end}}	cc.Flags().{{flagType $header}}Var(&cmd.{{goify $name true}}, "{{$name}}", {{if $header.DefaultValue}}{{printf "%#v" $header.DefaultValue}}{{else}}{{$tmp}}{{end}}, "{{$header.Description}}")
 {{end}}{{end}}}
 `
 
@@ -565,7 +648,7 @@ const clientsTmpl = `{{$payload := goify (printf "%s%sPayload" .Name (title .Par
 type {{$payload}} {{gotypedef .Payload false "" 1 true}}
 
 {{end}}{{$funcName := goify (printf "%s%s" .Name (title .Parent.Name)) true}}{{$desc := .Description}}{{if $desc}}// {{$desc}}{{else}}// {{$funcName}} makes a request to the {{.Name}} action endpoint of the {{.Parent.Name}} resource{{end}}
-func (c *Client) {{$funcName}}(path string{{if .Payload}}, payload {{if .Payload.Type.IsObject}}*{{end}}{{$payload}}{{end}}{{/*
+func (c *Client) {{$funcName}}(ctx context.Context, path string{{if .Payload}}, payload {{if .Payload.Type.IsObject}}*{{end}}{{$payload}}{{end}}{{/*
 	*/}}{{$params := join .QueryParams}}{{if $params}}, {{$params}}{{end}}{{/*
 	*/}}{{$headers := join .Headers}}{{if $headers}}, {{$headers}}{{end}}) (*http.Response, error) {
 	var body io.Reader
@@ -577,6 +660,10 @@ func (c *Client) {{$funcName}}(path string{{if .Payload}}, payload {{if .Payload
 {{end}}	u := url.URL{Host: c.Host, Scheme: c.Scheme, Path: path}
 {{$params := .QueryParams}}{{if $params}}{{if gt (len $params.Type.ToObject) 0}}	values := u.Query()
 {{range $name, $att := $params.Type.ToObject}}{{if (eq $att.Type.Kind 4)}}	values.Set("{{$name}}", {{goify $name false}})
+{{else if (eq $att.Type.Kind 9)}}	for k, v := range {{goify $name false}} {
+		{{$tmp := tempvar}}{{toString "v" $tmp $att.Type.ElemType}}
+		values.Set("{{$name}}["+k+"]", {{$tmp}})
+	}
 {{else}}{{$tmp := tempvar}}{{toString (goify $name false) $tmp $att}}
 	values.Set("{{$name}}", {{$tmp}})
 {{end}}{{end}}	u.RawQuery = values.Encode()
@@ -585,11 +672,12 @@ func (c *Client) {{$funcName}}(path string{{if .Payload}}, payload {{if .Payload
 		return nil, err
 	}
 {{$headers := .Headers}}	header := req.Header
-{{if $headers}}{{range $name, $att := $params.Type.ToObject}}{{if (eq $att.Type.Kind 4)}}	header.Set("{{$name}}", {{goify $name false}})
+{{if $headers}}{{range $name, $att := $headers.Type.ToObject}}{{if (eq $att.Type.Kind 4)}}	header.Set("{{$name}}", {{goify $name false}})
 {{else}}{{$tmp := tempvar}}{{toString (goify $name false) $tmp $att}}
 	header.Set("{{$name}}", {{$tmp}})
 {{end}}{{end}}{{end}}	header.Set("Content-Type", "application/json")
-	return c.Client.Do(req)
+{{if .EffectiveReplay}}	goa.SetReplayHeaders(req)
+{{end}}	return c.Client.Do(req.WithContext(ctx))
 }
 `
 
@@ -614,7 +702,40 @@ const clientTmpl = `type (
 func New() *Client {
 	return &Client{Client: goa.NewClient()}
 }
-`
+
+// NewInProcess instantiates a client that sends its requests directly to the given service
+// without going through the network. The requests are still routed, validated and encoded by
+// the service so that the client behaves as if the service was remote.
+func NewInProcess(service *goa.Service) *Client {
+	return &Client{Client: goa.NewLoopbackClient(service)}
+}
+{{with .Tenant}}{{if ne .Source "param"}}
+// UseTenant makes the client send its requests on behalf of the given tenant.
+func (c *Client) UseTenant(tenant string) {
+	c.Use(func(req *http.Request) error {
+		return goa.SetRequestTenant(req, {{printf "%q" .Source}}, {{printf "%q" .Name}}, tenant)
+	})
+}
+{{end}}{{end}}{{range .SecuritySchemes}}{{if eq .Kind "hmac"}}
+// Use{{goify .SchemeName true}}Signer signs the requests with the given key as required by the
+// {{printf "%q" .SchemeName}} HMAC security scheme.
+func (c *Client) Use{{goify .SchemeName true}}Signer(keyID, secret string) {
+	c.Signers = append(c.Signers, &goa.HMACSigner{Header: {{printf "%q" .Name}}, KeyID: keyID, Secret: secret})
+}
+{{else if eq .Kind "apiKey"}}
+// Use{{goify .SchemeName true}}Key authenticates the requests with the given key as required by the
+// {{printf "%q" .SchemeName}} API key security scheme.
+func (c *Client) Use{{goify .SchemeName true}}Key(key string) {
+	c.Signers = append(c.Signers, &goa.APIKeySigner{In: {{printf "%q" .In}}, Name: {{printf "%q" .Name}}, Key: key})
+}
+{{if .ManagesKeys}}
+// {{goify .SchemeName true}}Keys returns the client of the administration endpoints of the keys of the
+// {{printf "%q" .SchemeName}} security scheme. The requests must be authenticated with a key granting
+// the apikey.AdminScope scope.
+func (c *Client) {{goify .SchemeName true}}Keys() *apikey.AdminClient {
+	return &apikey.AdminClient{Client: c.Client, Path: {{printf "%q" .KeysPath}}}
+}
+{{end}}{{end}}{{end}}`
 
 // Takes map[string][]*design.ActionDefinition as input
 const registerCmdsT = `// RegisterCommands all the resource action subcommands to the application command line.
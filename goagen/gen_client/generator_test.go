@@ -62,6 +62,138 @@ var _ = Describe("Generate", func() {
 			_, err = gexec.Build(filepath.Join(testgenPackagePath, "client", "testapi-cli"))
 			Ω(err).ShouldNot(HaveOccurred())
 		})
+
+		It("generates the in-process client constructor", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "client.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("func NewInProcess(service *goa.Service) *Client {\n\treturn &Client{Client: goa.NewLoopbackClient(service)}\n}"))
+		})
+	})
+
+	Context("with a HMAC security scheme", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "testapi"},
+				SecuritySchemes: map[string]*design.SecuritySchemeDefinition{
+					"signed": {
+						Kind:       design.HMACSecurityKind,
+						SchemeName: "signed",
+						In:         "header",
+						Name:       "X-Signature",
+					},
+				},
+			}
+		})
+
+		It("generates the signer helpers", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "client.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("func (c *Client) UseSignedSigner(keyID, secret string) {\n\tc.Signers = append(c.Signers, &goa.HMACSigner{Header: \"X-Signature\", KeyID: keyID, Secret: secret})\n}"))
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "client", "testapi-cli", "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("c.Signers = RegisterSigners(app)"))
+			Ω(string(content)).Should(ContainSubstring(`&goa.HMACSigner{Header: "X-Signature"}`))
+		})
+	})
+
+	Context("with an API key security scheme that manages its keys", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "testapi"},
+				SecuritySchemes: map[string]*design.SecuritySchemeDefinition{
+					"key": {
+						Kind:       design.APIKeySecurityKind,
+						SchemeName: "key",
+						In:         "query",
+						Name:       "key",
+						KeysPath:   "/keys",
+					},
+				},
+			}
+		})
+
+		It("generates the key helpers", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "client.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("func (c *Client) UseKeyKey(key string) {\n\tc.Signers = append(c.Signers, &goa.APIKeySigner{In: \"query\", Name: \"key\", Key: key})\n}"))
+			Ω(string(content)).Should(ContainSubstring("func (c *Client) KeyKeys() *apikey.AdminClient {\n\treturn &apikey.AdminClient{Client: c.Client, Path: \"/keys\"}\n}"))
+		})
+	})
+
+	Context("with a replay protected action", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "testapi"},
+				Resources: map[string]*design.ResourceDefinition{
+					"account": {
+						Name: "account",
+						Actions: map[string]*design.ActionDefinition{
+							"transfer": {
+								Name:   "transfer",
+								Routes: []*design.RouteDefinition{{Verb: "POST", Path: "/transfer"}},
+								Replay: &design.ReplayDefinition{},
+							},
+						},
+					},
+				},
+			}
+			res := design.Design.Resources["account"]
+			act := res.Actions["transfer"]
+			act.Parent = res
+			act.Routes[0].Parent = act
+		})
+
+		It("sets the replay headers of the requests", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "account.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("\tgoa.SetReplayHeaders(req)\n\treturn c.Client.Do(req.WithContext(ctx))"))
+		})
+	})
+
+	Context("with a mutual TLS security scheme", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "testapi", Schemes: []string{"https"}},
+				SecuritySchemes: map[string]*design.SecuritySchemeDefinition{
+					"partners": {Kind: design.MutualTLSSecurityKind, SchemeName: "partners", ClientCA: "ca.pem"},
+				},
+			}
+		})
+
+		It("accepts the client certificate on the command line", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "testapi-cli", "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`app.PersistentFlags().StringVar(&certFile, "cert", "", "Client certificate file")`))
+			Ω(string(content)).Should(ContainSubstring("return c.UseClientCertificate(certFile, keyFile, caFile)"))
+		})
+	})
+
+	Context("with a multi-tenant API", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "testapi"},
+			}
+			design.Design.Tenant = &design.TenantDefinition{
+				Source: design.HeaderTenant,
+				Name:   "X-Tenant-ID",
+				Parent: design.Design,
+			}
+		})
+
+		It("sets the tenant of the requests", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "client.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("func (c *Client) UseTenant(tenant string) {\n\tc.Use(func(req *http.Request) error {\n\t\treturn goa.SetRequestTenant(req, \"header\", \"X-Tenant-ID\", tenant)\n\t})\n}"))
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "client", "testapi-cli", "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`app.PersistentFlags().StringVar(&tenant, "tenant", "", "Tenant the requests are made on behalf of")`))
+		})
 	})
 
 	Context("with an action with an integer parameter with no default value", func() {
@@ -114,4 +246,137 @@ var _ = Describe("Generate", func() {
 
 		})
 	})
+
+	Context("with an action with array and hash parameters", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "testapi"},
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"list": {
+								Name: "list",
+								QueryParams: &design.AttributeDefinition{
+									Type: design.Object{
+										"tag": &design.AttributeDefinition{Type: &design.Array{
+											ElemType: &design.AttributeDefinition{Type: design.String},
+										}},
+										"filter": &design.AttributeDefinition{Type: &design.Hash{
+											KeyType:  &design.AttributeDefinition{Type: design.String},
+											ElemType: &design.AttributeDefinition{Type: design.Integer},
+										}},
+									},
+								},
+								Routes: []*design.RouteDefinition{{Verb: "GET", Path: ""}},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			listAct := fooRes.Actions["list"]
+			listAct.Parent = fooRes
+			listAct.Routes[0].Parent = listAct
+		})
+
+		It("encodes the hash entries with bracketed keys", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("for k, v := range filter {"))
+			Ω(string(content)).Should(ContainSubstring(`values.Set("filter["+k+"]", tmp`))
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "client", "testapi-cli", "commands.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`cc.Flags().StringToIntVar(&cmd.Filter, "filter"`))
+			Ω(string(content)).Should(ContainSubstring(`cc.Flags().StringSliceVar(&cmd.Tag, "tag"`))
+		})
+	})
+
+	Context("with an action with typed headers", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "testapi"},
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"list": {
+								Name: "list",
+								Headers: &design.AttributeDefinition{
+									Type: design.Object{
+										"X-Count": &design.AttributeDefinition{Type: design.Integer},
+										"X-Since": &design.AttributeDefinition{Type: design.DateTime},
+									},
+								},
+								Routes: []*design.RouteDefinition{{Verb: "GET", Path: ""}},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			listAct := fooRes.Actions["list"]
+			listAct.Parent = fooRes
+			listAct.Routes[0].Parent = listAct
+		})
+
+		It("serializes the header values", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("path string, xCount int, xSince time.Time)"))
+			Ω(string(content)).Should(ContainSubstring("strconv.Itoa(xCount)"))
+			Ω(string(content)).Should(ContainSubstring("xSince.Format(time.RFC3339)"))
+			content, err = ioutil.ReadFile(filepath.Join(outDir, "client", "testapi-cli", "commands.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`cc.Flags().IntVar(&cmd.XCount, "X-Count"`))
+			Ω(string(content)).Should(ContainSubstring("path, cmd.XCount, cmd.XSince)"))
+		})
+	})
+
+	Context("with an action with query parameters and headers", func() {
+		BeforeEach(func() {
+			codegen.TempCount = 0
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "testapi"},
+				Resources: map[string]*design.ResourceDefinition{
+					"foo": {
+						Name: "foo",
+						Actions: map[string]*design.ActionDefinition{
+							"list": {
+								Name: "list",
+								QueryParams: &design.AttributeDefinition{
+									Type: design.Object{
+										"tag": &design.AttributeDefinition{Type: design.String},
+									},
+								},
+								Headers: &design.AttributeDefinition{
+									Type: design.Object{
+										"X-Trace": &design.AttributeDefinition{Type: design.String},
+									},
+								},
+								Routes: []*design.RouteDefinition{{Verb: "GET", Path: ""}},
+							},
+						},
+					},
+				},
+			}
+			fooRes := design.Design.Resources["foo"]
+			listAct := fooRes.Actions["list"]
+			listAct.Parent = fooRes
+			listAct.Routes[0].Parent = listAct
+		})
+
+		It("sets the request headers from the header arguments", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "client", "foo.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`values.Set("tag", tag)`))
+			Ω(string(content)).Should(ContainSubstring(`header.Set("X-Trace", xTrace)`))
+			Ω(string(content)).ShouldNot(ContainSubstring(`header.Set("tag"`))
+		})
+	})
 })
@@ -0,0 +1,115 @@
+package gendiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// BaseDesign is the import path of the design package the design given with --design is
+	// compared to.
+	BaseDesign string
+
+	// BaseRevision is the git revision of the base design package.
+	BaseRevision string
+
+	// Revision is the git revision of the design package given with --design.
+	Revision string
+
+	// Output is the writer the diff report is written to.
+	Output io.Writer = os.Stdout
+)
+
+// Command is the goa design diff command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("diff", "Compare two designs and report backwards incompatible changes")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&BaseDesign, "base", "", "Import path of the design package to compare to, default is the package given with --design")
+	r.Flags().StringVar(&BaseRevision, "base-rev", "", "git revision of the base design package, default is the working tree")
+	r.Flags().StringVar(&Revision, "rev", "", "git revision of the design package, default is the working tree")
+}
+
+// Run computes the snapshots of the base and new designs, compares them and writes the report to
+// Output. Run returns an error if the new design introduces backwards incompatible changes.
+func (c *Command) Run() ([]string, error) {
+	if BaseDesign == "" && BaseRevision == "" {
+		return nil, fmt.Errorf("missing base design, use --base and/or --base-rev")
+	}
+	base := BaseDesign
+	if base == "" {
+		base = codegen.DesignPackagePath
+	}
+	from, err := loadSnapshot(base, BaseRevision)
+	if err != nil {
+		return nil, err
+	}
+	to, err := loadSnapshot(codegen.DesignPackagePath, Revision)
+	if err != nil {
+		return nil, err
+	}
+	changes := Compare(from, to)
+	Report(Output, changes)
+	if n := CountBreaking(changes); n > 0 {
+		return nil, fmt.Errorf("%d backwards incompatible change(s)", n)
+	}
+	return nil, nil
+}
+
+// loadSnapshot runs the snapshot generator against the design package with the given import path,
+// as of the given git revision if not empty, and loads the resulting snapshot.
+func loadSnapshot(pkg, rev string) (*Snapshot, error) {
+	dir, err := ioutil.TempDir("", "goagen-snapshot")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if rev != "" {
+		ws, err := exportRevision(pkg, rev)
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(ws)
+		gopath := os.Getenv("GOPATH")
+		defer os.Setenv("GOPATH", gopath)
+		os.Setenv("GOPATH", ws+string(os.PathListSeparator)+gopath)
+	}
+	outDir, designPath, dryRun := codegen.OutputDir, codegen.DesignPackagePath, codegen.DryRun
+	defer func() {
+		codegen.OutputDir, codegen.DesignPackagePath, codegen.DryRun = outDir, designPath, dryRun
+	}()
+	codegen.OutputDir, codegen.DesignPackagePath, codegen.DryRun = dir, pkg, false
+	gen := meta.NewGenerator(
+		"gendiff.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_diff")},
+		nil,
+	)
+	if _, err := gen.Generate(); err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, SnapshotFile))
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,326 @@
+package gendiff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa/dslengine"
+)
+
+// Change describes a difference between two designs.
+type Change struct {
+	// Path identifies the changed element, e.g. "bottle.show payload.name".
+	Path string
+	// Desc describes the change.
+	Desc string
+	// Breaking is true if the change is backwards incompatible, that is if clients of the
+	// original API may fail against the new API.
+	Breaking bool
+}
+
+// comparer accumulates the changes found while comparing two snapshots.
+type comparer struct {
+	changes []*Change
+}
+
+// Compare returns the changes needed to go from the "from" snapshot to the "to" snapshot sorted by
+// path. Request attributes (parameters and payloads) and response attributes are compared
+// differently: adding a required request attribute or tightening a request validation breaks
+// existing clients while the same changes made to a response are backwards compatible, and vice
+// versa.
+func Compare(from, to *Snapshot) []*Change {
+	c := new(comparer)
+	for _, n := range keys(from.Resources, to.Resources) {
+		f, t := from.Resources[n], to.Resources[n]
+		switch {
+		case t == nil:
+			c.add(n, true, "resource removed")
+		case f == nil:
+			c.add(n, false, "resource added")
+		default:
+			c.compareResource(n, f, t)
+		}
+	}
+	sort.Stable(byPath(c.changes))
+	return c.changes
+}
+
+// CountBreaking returns the number of backwards incompatible changes.
+func CountBreaking(changes []*Change) int {
+	count := 0
+	for _, c := range changes {
+		if c.Breaking {
+			count++
+		}
+	}
+	return count
+}
+
+// Report writes a human readable report of the given changes to w.
+func Report(w io.Writer, changes []*Change) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "no changes")
+		return
+	}
+	for _, c := range changes {
+		flag := "        "
+		if c.Breaking {
+			flag = "BREAKING"
+		}
+		fmt.Fprintf(w, "%s %s: %s\n", flag, c.Path, c.Desc)
+	}
+	fmt.Fprintf(w, "\n%d change(s), %d backwards incompatible\n", len(changes), CountBreaking(changes))
+}
+
+// compareResource compares the actions of two versions of a resource.
+func (c *comparer) compareResource(path string, from, to *ResourceSnapshot) {
+	for _, n := range keys(from.Actions, to.Actions) {
+		f, t := from.Actions[n], to.Actions[n]
+		p := path + "." + n
+		switch {
+		case t == nil:
+			c.add(p, true, "action removed")
+		case f == nil:
+			c.add(p, false, "action added")
+		default:
+			c.compareAction(p, f, t)
+		}
+	}
+}
+
+// compareAction compares the routes, parameters, payloads and responses of two versions of an
+// action.
+func (c *comparer) compareAction(path string, from, to *ActionSnapshot) {
+	for _, r := range from.Routes {
+		if !contains(to.Routes, r) {
+			c.add(path, true, "route %s removed", r)
+		}
+	}
+	for _, r := range to.Routes {
+		if !contains(from.Routes, r) {
+			c.add(path, false, "route %s added", r)
+		}
+	}
+	c.compareAttribute(path+" params", from.Params, to.Params, true)
+	switch {
+	case from.Payload == nil && to.Payload != nil:
+		c.add(path, true, "payload added")
+	case from.Payload != nil && to.Payload == nil:
+		c.add(path, true, "payload removed")
+	case from.Payload != nil:
+		c.compareAttribute(path+" payload", from.Payload, to.Payload, true)
+	}
+	for _, n := range keys(from.Responses, to.Responses) {
+		f, t := from.Responses[n], to.Responses[n]
+		p := path + " response " + n
+		switch {
+		case t == nil:
+			c.add(p, f.Status >= 200 && f.Status < 300, "response removed")
+		case f == nil:
+			c.add(p, false, "response added")
+		case f.Status != t.Status:
+			c.add(p, true, "status changed from %d to %d", f.Status, t.Status)
+		case f.MediaType != t.MediaType:
+			c.add(p, true, "media type changed from %q to %q", f.MediaType, t.MediaType)
+		case f.Body != nil && t.Body != nil:
+			c.compareAttribute(p, f.Body, t.Body, false)
+		}
+	}
+}
+
+// compareAttribute compares two versions of an attribute. request is true if the attribute
+// describes request data, false if it describes response data.
+func (c *comparer) compareAttribute(path string, from, to *AttributeSnapshot, request bool) {
+	if from == nil {
+		from = &AttributeSnapshot{Type: "object"}
+	}
+	if to == nil {
+		to = &AttributeSnapshot{Type: "object"}
+	}
+	if from.Type != to.Type {
+		c.add(path, true, "type changed from %s to %s", from.Type, to.Type)
+		return
+	}
+	c.compareValidation(path, from.Validation, to.Validation, request)
+	for _, n := range keys(from.Fields, to.Fields) {
+		f, t := from.Fields[n], to.Fields[n]
+		p := path + "." + n
+		fromReq, toReq := isRequired(from, n), isRequired(to, n)
+		switch {
+		case t == nil:
+			c.add(p, true, "attribute removed")
+		case f == nil:
+			if toReq {
+				c.add(p, request, "required attribute added")
+			} else {
+				c.add(p, false, "attribute added")
+			}
+		default:
+			if !fromReq && toReq {
+				c.add(p, request, "attribute is now required")
+			} else if fromReq && !toReq {
+				c.add(p, !request, "attribute is no longer required")
+			}
+			c.compareAttribute(p, f, t, request)
+		}
+	}
+	if from.Key != nil && to.Key != nil {
+		c.compareAttribute(path+"[key]", from.Key, to.Key, request)
+	}
+	if from.Elem != nil && to.Elem != nil {
+		c.compareAttribute(path+"[]", from.Elem, to.Elem, request)
+	}
+}
+
+// compareValidation compares two versions of the validations of an attribute. Tightening a
+// validation is backwards incompatible for requests, loosening it is backwards incompatible for
+// responses. The required validation is handled by compareAttribute.
+func (c *comparer) compareValidation(path string, from, to *dslengine.ValidationDefinition, request bool) {
+	if from == nil {
+		from = &dslengine.ValidationDefinition{}
+	}
+	if to == nil {
+		to = &dslengine.ValidationDefinition{}
+	}
+	tightened := func(format string, args ...interface{}) { c.add(path, request, format, args...) }
+	loosened := func(format string, args ...interface{}) { c.add(path, !request, format, args...) }
+
+	fromValues, toValues := enumValues(from.Values), enumValues(to.Values)
+	switch {
+	case len(from.Values) == 0 && len(to.Values) > 0:
+		tightened("enum %s added", strings.Join(toValues, ", "))
+	case len(from.Values) > 0 && len(to.Values) == 0:
+		loosened("enum removed")
+	default:
+		for _, v := range fromValues {
+			if !contains(toValues, v) {
+				tightened("enum value %s removed", v)
+			}
+		}
+		for _, v := range toValues {
+			if !contains(fromValues, v) {
+				loosened("enum value %s added", v)
+			}
+		}
+	}
+	c.compareString(path, "format", from.Format, to.Format, request)
+	c.compareString(path, "pattern", from.Pattern, to.Pattern, request)
+	c.compareBound(path, "minimum", from.Minimum, to.Minimum, true, request)
+	c.compareBound(path, "maximum", from.Maximum, to.Maximum, false, request)
+	c.compareBound(path, "min length", intBound(from.MinLength), intBound(to.MinLength), true, request)
+	c.compareBound(path, "max length", intBound(from.MaxLength), intBound(to.MaxLength), false, request)
+}
+
+// compareString compares two versions of a string validation (format or pattern). Any change
+// other than removing the validation is treated as tightening it.
+func (c *comparer) compareString(path, name, from, to string, request bool) {
+	switch {
+	case from == to:
+	case from == "":
+		c.add(path, request, "%s %q added", name, to)
+	case to == "":
+		c.add(path, !request, "%s %q removed", name, from)
+	default:
+		c.add(path, request, "%s changed from %q to %q", name, from, to)
+	}
+}
+
+// compareBound compares two versions of a bound validation. lower is true for lower bounds
+// (minimum and min length) and false for upper bounds.
+func (c *comparer) compareBound(path, name string, from, to *float64, lower, request bool) {
+	switch {
+	case from == nil && to == nil:
+	case from == nil:
+		c.add(path, request, "%s %v added", name, *to)
+	case to == nil:
+		c.add(path, !request, "%s %v removed", name, *from)
+	case *from != *to:
+		tighter := *to > *from
+		if !lower {
+			tighter = !tighter
+		}
+		c.add(path, tighter == request, "%s changed from %v to %v", name, *from, *to)
+	}
+}
+
+// add records a change.
+func (c *comparer) add(path string, breaking bool, format string, args ...interface{}) {
+	c.changes = append(c.changes, &Change{Path: path, Desc: fmt.Sprintf(format, args...), Breaking: breaking})
+}
+
+// isRequired returns true if the object attribute field with the given name is required.
+func isRequired(att *AttributeSnapshot, name string) bool {
+	if att.Validation == nil {
+		return false
+	}
+	return contains(att.Validation.Required, name)
+}
+
+// enumValues returns the string representations of the given enum values.
+func enumValues(vals []interface{}) []string {
+	res := make([]string, len(vals))
+	for i, v := range vals {
+		res[i] = fmt.Sprintf("%v", v)
+	}
+	return res
+}
+
+// intBound converts an integer bound to a float bound.
+func intBound(i *int) *float64 {
+	if i == nil {
+		return nil
+	}
+	f := float64(*i)
+	return &f
+}
+
+// contains returns true if vals contains val.
+func contains(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+// keys returns the sorted union of the keys of the given maps which must all be maps indexed by
+// strings.
+func keys(maps ...interface{}) []string {
+	names := make(map[string]bool)
+	for _, m := range maps {
+		switch actual := m.(type) {
+		case map[string]*ResourceSnapshot:
+			for n := range actual {
+				names[n] = true
+			}
+		case map[string]*ActionSnapshot:
+			for n := range actual {
+				names[n] = true
+			}
+		case map[string]*ResponseSnapshot:
+			for n := range actual {
+				names[n] = true
+			}
+		case map[string]*AttributeSnapshot:
+			for n := range actual {
+				names[n] = true
+			}
+		}
+	}
+	res := make([]string, 0, len(names))
+	for n := range names {
+		res = append(res, n)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// byPath makes it possible to sort changes by path.
+type byPath []*Change
+
+func (b byPath) Len() int           { return len(b) }
+func (b byPath) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byPath) Less(i, j int) bool { return b[i].Path < b[j].Path }
@@ -0,0 +1,125 @@
+package gendiff_test
+
+import (
+	"bytes"
+
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_diff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compare", func() {
+	var from, to *gendiff.Snapshot
+	var changes []*gendiff.Change
+
+	snapshot := func() *gendiff.Snapshot {
+		min := 1.0
+		return &gendiff.Snapshot{Resources: map[string]*gendiff.ResourceSnapshot{
+			"bottle": {Actions: map[string]*gendiff.ActionSnapshot{
+				"show": {
+					Routes: []string{"GET /bottles/:id"},
+					Params: &gendiff.AttributeSnapshot{
+						Type:       "object",
+						Fields:     map[string]*gendiff.AttributeSnapshot{"id": {Type: "integer"}},
+						Validation: &dslengine.ValidationDefinition{Required: []string{"id"}},
+					},
+					Responses: map[string]*gendiff.ResponseSnapshot{
+						"OK": {Status: 200, MediaType: "application/vnd.bottle+json", Body: &gendiff.AttributeSnapshot{
+							Type: "object",
+							Fields: map[string]*gendiff.AttributeSnapshot{
+								"name":   {Type: "string"},
+								"rating": {Type: "integer", Validation: &dslengine.ValidationDefinition{Minimum: &min}},
+							},
+							Validation: &dslengine.ValidationDefinition{Required: []string{"name"}},
+						}},
+					},
+				},
+				"create": {
+					Routes: []string{"POST /bottles"},
+					Payload: &gendiff.AttributeSnapshot{
+						Type: "object",
+						Fields: map[string]*gendiff.AttributeSnapshot{
+							"name":  {Type: "string"},
+							"color": {Type: "string", Validation: &dslengine.ValidationDefinition{Values: []interface{}{"red", "white"}}},
+						},
+					},
+				},
+			}},
+		}}
+	}
+
+	BeforeEach(func() {
+		from = snapshot()
+		to = snapshot()
+	})
+
+	JustBeforeEach(func() {
+		changes = gendiff.Compare(from, to)
+	})
+
+	It("finds no changes in identical designs", func() {
+		Ω(changes).Should(BeEmpty())
+	})
+
+	Context("with removed and added elements", func() {
+		BeforeEach(func() {
+			delete(to.Resources["bottle"].Actions, "create")
+			to.Resources["account"] = &gendiff.ResourceSnapshot{}
+			to.Resources["bottle"].Actions["show"].Routes = []string{"GET /bottles/:id", "GET /v2/bottles/:id"}
+		})
+
+		It("flags removals as breaking", func() {
+			Ω(changes).Should(HaveLen(3))
+			Ω(*changes[0]).Should(Equal(gendiff.Change{Path: "account", Desc: "resource added"}))
+			Ω(*changes[1]).Should(Equal(gendiff.Change{Path: "bottle.create", Desc: "action removed", Breaking: true}))
+			Ω(*changes[2]).Should(Equal(gendiff.Change{Path: "bottle.show", Desc: "route GET /v2/bottles/:id added"}))
+		})
+	})
+
+	Context("with request changes", func() {
+		BeforeEach(func() {
+			payload := to.Resources["bottle"].Actions["create"].Payload
+			payload.Fields["vintage"] = &gendiff.AttributeSnapshot{Type: "integer"}
+			payload.Validation = &dslengine.ValidationDefinition{Required: []string{"vintage"}}
+			payload.Fields["color"].Validation.Values = []interface{}{"red", "white", "rose"}
+			to.Resources["bottle"].Actions["show"].Params.Fields["id"].Type = "string"
+		})
+
+		It("flags tightened requests as breaking", func() {
+			Ω(changes).Should(HaveLen(3))
+			Ω(*changes[0]).Should(Equal(gendiff.Change{Path: "bottle.create payload.color", Desc: "enum value rose added"}))
+			Ω(*changes[1]).Should(Equal(gendiff.Change{Path: "bottle.create payload.vintage", Desc: "required attribute added", Breaking: true}))
+			Ω(*changes[2]).Should(Equal(gendiff.Change{Path: "bottle.show params.id", Desc: "type changed from integer to string", Breaking: true}))
+		})
+	})
+
+	Context("with response changes", func() {
+		BeforeEach(func() {
+			body := to.Resources["bottle"].Actions["show"].Responses["OK"].Body
+			body.Fields["vintage"] = &gendiff.AttributeSnapshot{Type: "integer"}
+			body.Validation.Required = []string{"vintage"}
+			min := 0.0
+			body.Fields["rating"].Validation.Minimum = &min
+		})
+
+		It("flags loosened responses as breaking", func() {
+			Ω(changes).Should(HaveLen(3))
+			Ω(*changes[0]).Should(Equal(gendiff.Change{Path: "bottle.show response OK.name", Desc: "attribute is no longer required", Breaking: true}))
+			Ω(*changes[1]).Should(Equal(gendiff.Change{Path: "bottle.show response OK.rating", Desc: "minimum changed from 1 to 0", Breaking: true}))
+			Ω(*changes[2]).Should(Equal(gendiff.Change{Path: "bottle.show response OK.vintage", Desc: "required attribute added"}))
+		})
+	})
+
+	Describe("Report", func() {
+		BeforeEach(func() {
+			delete(to.Resources, "bottle")
+		})
+
+		It("lists the changes", func() {
+			var buf bytes.Buffer
+			gendiff.Report(&buf, changes)
+			Ω(buf.String()).Should(Equal("BREAKING bottle: resource removed\n\n1 change(s), 1 backwards incompatible\n"))
+		})
+	})
+})
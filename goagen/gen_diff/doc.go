@@ -0,0 +1,22 @@
+/*
+Package gendiff implements the "diff" command which compares two designs and reports the resources,
+actions, routes, attributes and validations that were added, removed or changed, flagging the
+changes that are backwards incompatible. The command exits with a non zero status when such
+changes are found so that it can be used to gate the review of API changes:
+
+	goagen diff -d github.com/acme/cellar/design --base-rev origin/master
+
+The base design is either another design package given with --base or the same design package
+as of a git revision given with --base-rev (or both). The --rev flag makes it possible to compare
+two revisions instead of a revision and the working tree.
+
+Each design is loaded by a generator tool compiled with the design package, as done by the other
+commands. The tool writes a JSON snapshot of the design that the command then compares. Git
+revisions are exported to a temporary GOPATH workspace prepended to GOPATH when compiling the tool.
+
+Request attributes (parameters and payloads) and response attributes are compared differently:
+removing an attribute, adding a required request attribute or tightening a request validation
+breaks existing clients, while adding a required response attribute or tightening a response
+validation does not.
+*/
+package gendiff
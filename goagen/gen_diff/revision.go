@@ -0,0 +1,94 @@
+package gendiff
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+// exportRevision extracts the content of the git repository containing the package with the given
+// import path, as of the given revision, into a new GOPATH workspace. It returns the path to the
+// workspace which should be prepended to GOPATH to load the package at that revision.
+func exportRevision(pkg, rev string) (string, error) {
+	src, err := codegen.PackageSourcePath(pkg)
+	if err != nil {
+		return "", err
+	}
+	top, err := git(src, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	root := strings.TrimSpace(string(top))
+	rootPkg, err := codegen.PackagePath(root)
+	if err != nil {
+		return "", err
+	}
+	archive, err := git(root, "archive", "--format=tar", rev)
+	if err != nil {
+		return "", err
+	}
+	dir, err := ioutil.TempDir("", "goagen-diff")
+	if err != nil {
+		return "", err
+	}
+	if err := untar(archive, filepath.Join(dir, "src", filepath.FromSlash(rootPkg))); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// git runs the git command with the given arguments in dir and returns its output.
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %s\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return out, nil
+}
+
+// untar extracts the directories and regular files of the given tar archive into dir.
+func untar(archive []byte, dir string) error {
+	r := tar.NewReader(bytes.NewReader(archive))
+	for {
+		h, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(h.Name))
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(h.Mode)&0777)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, r)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
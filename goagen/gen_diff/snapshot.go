@@ -0,0 +1,158 @@
+package gendiff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+type (
+	// Snapshot is the normalized description of a design compared by the diff command. Snapshots
+	// are computed by the generator tool compiled with the design package and serialized to JSON
+	// so that designs that cannot be loaded in the same process can be compared.
+	Snapshot struct {
+		// Resources lists the resource snapshots indexed by resource name.
+		Resources map[string]*ResourceSnapshot `json:"resources"`
+	}
+
+	// ResourceSnapshot describes a resource.
+	ResourceSnapshot struct {
+		// Actions lists the action snapshots indexed by action name.
+		Actions map[string]*ActionSnapshot `json:"actions"`
+	}
+
+	// ActionSnapshot describes an action.
+	ActionSnapshot struct {
+		// Routes lists the action routes across all API versions sorted alphabetically, e.g.
+		// "GET /api/bottles/:id".
+		Routes []string `json:"routes"`
+		// Params describes the action path and querystring parameters.
+		Params *AttributeSnapshot `json:"params,omitempty"`
+		// Payload describes the action payload if any.
+		Payload *AttributeSnapshot `json:"payload,omitempty"`
+		// Responses lists the action responses indexed by name.
+		Responses map[string]*ResponseSnapshot `json:"responses,omitempty"`
+	}
+
+	// ResponseSnapshot describes an action response.
+	ResponseSnapshot struct {
+		// Status is the response HTTP status code.
+		Status int `json:"status"`
+		// MediaType is the identifier of the response media type if any.
+		MediaType string `json:"media_type,omitempty"`
+		// Body describes the response media type if it is defined by the design.
+		Body *AttributeSnapshot `json:"body,omitempty"`
+	}
+
+	// AttributeSnapshot describes an attribute and its type. User types are expanded so that
+	// changes made to a type show up in all the actions that use it.
+	AttributeSnapshot struct {
+		// Type is the name of the attribute underlying type, e.g. "string" or "object".
+		Type string `json:"type"`
+		// TypeName is the name of the user type or media type if any.
+		TypeName string `json:"type_name,omitempty"`
+		// Fields lists the object fields indexed by name.
+		Fields map[string]*AttributeSnapshot `json:"fields,omitempty"`
+		// Key describes the hash keys.
+		Key *AttributeSnapshot `json:"key,omitempty"`
+		// Elem describes the array elements or hash values.
+		Elem *AttributeSnapshot `json:"elem,omitempty"`
+		// Validation lists the attribute validations if any.
+		Validation *dslengine.ValidationDefinition `json:"validation,omitempty"`
+	}
+)
+
+// NewSnapshot computes the snapshot of the given API.
+func NewSnapshot(api *design.APIDefinition) *Snapshot {
+	s := &Snapshot{Resources: make(map[string]*ResourceSnapshot)}
+	api.IterateResources(func(r *design.ResourceDefinition) error {
+		rs := &ResourceSnapshot{Actions: make(map[string]*ActionSnapshot)}
+		r.IterateActions(func(a *design.ActionDefinition) error {
+			rs.Actions[a.Name] = actionSnapshot(api, a)
+			return nil
+		})
+		s.Resources[r.Name] = rs
+		return nil
+	})
+	return s
+}
+
+// actionSnapshot computes the snapshot of an action.
+func actionSnapshot(api *design.APIDefinition, a *design.ActionDefinition) *ActionSnapshot {
+	as := &ActionSnapshot{Params: attributeSnapshot(a.AllParams(), nil)}
+	seen := make(map[string]bool)
+	api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		if !a.Parent.SupportsVersion(v.Version) {
+			return nil
+		}
+		for _, r := range a.Routes {
+			route := fmt.Sprintf("%s %s", r.Verb, r.FullPath(v))
+			if !seen[route] {
+				seen[route] = true
+				as.Routes = append(as.Routes, route)
+			}
+		}
+		return nil
+	})
+	sort.Strings(as.Routes)
+	if a.Payload != nil {
+		as.Payload = attributeSnapshot(&design.AttributeDefinition{Type: a.Payload}, nil)
+	}
+	if len(a.Responses) > 0 {
+		as.Responses = make(map[string]*ResponseSnapshot, len(a.Responses))
+		for n, r := range a.Responses {
+			rs := &ResponseSnapshot{Status: r.Status, MediaType: r.MediaType}
+			if mt := api.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+				rs.Body = attributeSnapshot(&design.AttributeDefinition{Type: mt}, nil)
+			}
+			as.Responses[n] = rs
+		}
+	}
+	return as
+}
+
+// attributeSnapshot computes the snapshot of an attribute. parents lists the names of the user
+// types being expanded and is used to stop the expansion of recursive types.
+func attributeSnapshot(att *design.AttributeDefinition, parents []string) *AttributeSnapshot {
+	as := &AttributeSnapshot{Type: att.Type.Name(), Validation: att.Validation}
+	dt := att.Type
+	var ut *design.UserTypeDefinition
+	switch actual := dt.(type) {
+	case *design.UserTypeDefinition:
+		ut = actual
+	case *design.MediaTypeDefinition:
+		ut = actual.UserTypeDefinition
+	}
+	if ut != nil {
+		as.TypeName = ut.TypeName
+		for _, p := range parents {
+			if p == ut.TypeName {
+				return as
+			}
+		}
+		parents = append(parents, ut.TypeName)
+		dt = ut.Type
+		if as.Validation == nil {
+			as.Validation = ut.Validation
+		} else if ut.Validation != nil {
+			val := *as.Validation
+			val.Required = append(append([]string{}, val.Required...), ut.Validation.Required...)
+			as.Validation = &val
+		}
+	}
+	switch actual := dt.(type) {
+	case design.Object:
+		as.Fields = make(map[string]*AttributeSnapshot, len(actual))
+		for n, fatt := range actual {
+			as.Fields[n] = attributeSnapshot(fatt, parents)
+		}
+	case *design.Array:
+		as.Elem = attributeSnapshot(actual.ElemType, parents)
+	case *design.Hash:
+		as.Key = attributeSnapshot(actual.KeyType, parents)
+		as.Elem = attributeSnapshot(actual.ElemType, parents)
+	}
+	return as
+}
@@ -0,0 +1,74 @@
+package gendiff_test
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_diff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewSnapshot", func() {
+	var snapshot *gendiff.Snapshot
+	var prevDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		bottle := &design.MediaTypeDefinition{
+			UserTypeDefinition: &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"name": &design.AttributeDefinition{Type: design.String},
+					},
+					Validation: &dslengine.ValidationDefinition{Required: []string{"name"}},
+				},
+				TypeName: "Bottle",
+			},
+			Identifier: "application/vnd.bottle+json",
+		}
+		bottle.Type.ToObject()["parent"] = &design.AttributeDefinition{Type: bottle}
+		res := &design.ResourceDefinition{Name: "bottle", BasePath: "/bottles"}
+		show := &design.ActionDefinition{
+			Name:   "show",
+			Parent: res,
+			Params: &design.AttributeDefinition{Type: design.Object{
+				"id": &design.AttributeDefinition{Type: design.Integer},
+			}},
+			Responses: map[string]*design.ResponseDefinition{
+				"OK": {Name: "OK", Status: 200, MediaType: bottle.Identifier},
+			},
+		}
+		show.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "/:id", Parent: show}}
+		res.Actions = map[string]*design.ActionDefinition{"show": show}
+		prevDesign = design.Design
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "cellar", BasePath: "/api"},
+			Resources:            map[string]*design.ResourceDefinition{"bottle": res},
+			MediaTypes:           map[string]*design.MediaTypeDefinition{bottle.Identifier: bottle},
+		}
+		snapshot = gendiff.NewSnapshot(design.Design)
+	})
+
+	AfterEach(func() {
+		design.Design = prevDesign
+	})
+
+	It("describes the resources and actions", func() {
+		Ω(snapshot.Resources).Should(HaveKey("bottle"))
+		show := snapshot.Resources["bottle"].Actions["show"]
+		Ω(show).ShouldNot(BeNil())
+		Ω(show.Routes).Should(Equal([]string{"GET /api/bottles/:id"}))
+		Ω(show.Params.Fields).Should(HaveKey("id"))
+		Ω(show.Params.Fields["id"].Type).Should(Equal("integer"))
+	})
+
+	It("expands the response media types", func() {
+		body := snapshot.Resources["bottle"].Actions["show"].Responses["OK"].Body
+		Ω(body.Type).Should(Equal("object"))
+		Ω(body.TypeName).Should(Equal("Bottle"))
+		Ω(body.Validation.Required).Should(Equal([]string{"name"}))
+		Ω(body.Fields["name"].Type).Should(Equal("string"))
+		parent := body.Fields["parent"]
+		Ω(parent.TypeName).Should(Equal("Bottle"))
+		Ω(parent.Fields).Should(BeEmpty())
+	})
+})
@@ -0,0 +1,37 @@
+package genevents
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+// TargetPackage is the name of the generated Go package.
+var TargetPackage string
+
+// Command is the goa events generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("events", "Generate the event publisher and subscriber interfaces and payload schemas")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&TargetPackage, "pkg", "events", "Name of the generated Go package")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"pkg": TargetPackage}
+	gen := meta.NewGenerator(
+		"genevents.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_events")},
+		flags,
+	)
+	return gen.Generate()
+}
@@ -0,0 +1,19 @@
+/*
+Package genevents provides a generator for the domain events defined with the Event DSL. The
+generated package defines the event payload types together with:
+
+  - one constant per event holding the name of the topic the event is published on,
+  - the Publisher interface with one method per event and its implementation NewPublisher,
+  - the Subscriber interface that services consuming the events implement,
+  - the Subscribe function that dispatches the events received on a goa.EventBus to a Subscriber,
+  - schema.json, the JSON schema of the event payloads.
+
+Events are serialized with the encoders and decoders registered on the service so that they share
+the representations of the HTTP API. The event bus relies on a goa.EventBroker to publish and
+subscribe to the topics, goa provides adapters for Kafka and NATS:
+
+	bus := goa.NewEventBus(service, &goa.KafkaBroker{Producer: producer, Consumer: consumer})
+	publisher := events.NewPublisher(bus)
+	err := publisher.PublishOrderPlaced(&events.OrderPlacedPayload{OrderID: "o1"})
+*/
+package genevents
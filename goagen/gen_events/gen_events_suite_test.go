@@ -0,0 +1,13 @@
+package genevents_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenEvents(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenEvents Suite")
+}
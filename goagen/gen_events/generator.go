@@ -0,0 +1,250 @@
+package genevents
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_schema"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the events generator.
+type Generator struct {
+	genfiles []string
+	types    *codegen.TypeCollector
+}
+
+// Event contains the data needed to generate the publisher and subscriber of a single event.
+type Event struct {
+	// Name is the Go name of the event, e.g. "OrderPlaced".
+	Name string
+	// Topic is the topic the event is published on.
+	Topic string
+	// Payload is the Go type name of the event payload.
+	Payload string
+	// Key is the Go expression that computes the message key from the payload held in the
+	// variable "e", "" if the event has no key.
+	Key string
+	// Event is the event definition.
+	Event *design.EventDefinition
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "Events generator",
+		Long:  "Event publisher and subscriber interfaces and payload schemas generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// EventsDir returns the path to the directory where the events files are generated.
+func EventsDir() string {
+	return filepath.Join(codegen.OutputDir, TargetPackage)
+}
+
+// Generate produces the payload types, the publisher and subscriber Go code and the payload
+// schemas.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	os.RemoveAll(EventsDir())
+	if err = os.MkdirAll(EventsDir(), 0755); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, EventsDir())
+	g.types = codegen.NewTypeCollector()
+	var events []*Event
+	err = api.IterateEvents(func(e *design.EventDefinition) error {
+		events = append(events, g.event(e))
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	if err = g.generateTypes(api); err != nil {
+		return
+	}
+	if err = g.generateEvents(api, events); err != nil {
+		return
+	}
+	if err = g.generateSchema(api); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes the entire events directory if it was created by this generator.
+func (g *Generator) Cleanup() {
+	if len(g.genfiles) == 0 {
+		return
+	}
+	os.RemoveAll(EventsDir())
+	g.genfiles = nil
+}
+
+// event builds the data needed to generate the given event and records the types it uses.
+func (g *Generator) event(e *design.EventDefinition) *Event {
+	g.types.CollectUserType(e.Payload)
+	ev := &Event{
+		Name:    codegen.Goify(e.Name, true),
+		Topic:   e.Topic,
+		Payload: codegen.GoTypeName(e.Payload, nil, 0),
+		Event:   e,
+	}
+	if e.Key != "" {
+		field := "e." + codegen.Goify(e.Key, true)
+		if e.Payload.Type.ToObject()[e.Key].Type.Kind() == design.StringKind {
+			ev.Key = field
+		} else {
+			ev.Key = fmt.Sprintf("fmt.Sprintf(\"%%v\", %s)", field)
+		}
+	}
+	return ev
+}
+
+// generateTypes generates the event payload types.
+func (g *Generator) generateTypes(api *design.APIDefinition) error {
+	filename := filepath.Join(EventsDir(), "types.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("time")}
+	title := fmt.Sprintf("%s: Event Payload Types", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	types := g.types.Types()
+	if err := file.ExecuteTemplate("types", typesTmpl, nil, types); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// generateEvents generates the event topics and the publisher and subscriber interfaces and
+// implementations.
+func (g *Generator) generateEvents(api *design.APIDefinition, events []*Event) error {
+	filename := filepath.Join(EventsDir(), "events.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	title := fmt.Sprintf("%s: Events", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	if err := file.ExecuteTemplate("events", eventsTmpl, nil, events); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// generateSchema generates the JSON schema describing the payloads of all the events. The schema
+// has one property per event, the types used by the payloads are listed in its definitions.
+func (g *Generator) generateSchema(api *design.APIDefinition) error {
+	genschema.Definitions = make(map[string]*genschema.JSONSchema)
+	s := genschema.NewJSONSchema()
+	s.Title = fmt.Sprintf("%s events", api.Name)
+	s.Type = genschema.JSONObject
+	api.IterateEvents(func(e *design.EventDefinition) error {
+		genschema.GenerateTypeDefinition(api, e.Payload)
+		es := genschema.Definitions[e.Payload.TypeName].Dup()
+		es.Title = fmt.Sprintf("%s event published on %s", e.Name, e.Topic)
+		es.Description = e.Description
+		s.Properties[e.Name] = es
+		return nil
+	})
+	s.Definitions = genschema.Definitions
+	js, err := s.JSON()
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(EventsDir(), "schema.json")
+	if err := ioutil.WriteFile(filename, js, 0644); err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	return nil
+}
+
+const typesTmpl = `{{range .}}{{if .Description}}{{comment .Description}}{{else}}// {{.Name}} is an event payload type.{{end}}
+type {{.Name}} {{.Def}}
+
+{{end}}`
+
+const eventsTmpl = `// Event topics
+const (
+{{range .}}	// {{.Name}}Topic is the topic {{.Name}} events are published on.
+	{{.Name}}Topic = "{{.Topic}}"
+{{end}})
+
+// Publisher is the interface implemented by the event publishers.
+type Publisher interface {
+{{range .}}	// Publish{{.Name}} publishes {{.Name}} events.{{if .Event.Description}}
+	{{comment .Event.Description}}{{end}}
+	Publish{{.Name}}(e *{{.Payload}}) error
+{{end}}}
+
+// Subscriber is the interface implemented by the event handlers.
+type Subscriber interface {
+{{range .}}	// Handle{{.Name}} handles {{.Name}} events.
+	Handle{{.Name}}(e *{{.Payload}}) error
+{{end}}}
+
+// NewPublisher returns a publisher that encodes the events with the service encoders and
+// publishes them on the event bus broker.
+func NewPublisher(bus *goa.EventBus) Publisher {
+	return &publisher{bus: bus}
+}
+
+// publisher implements Publisher.
+type publisher struct {
+	bus *goa.EventBus
+}
+{{range .}}
+// Publish{{.Name}} publishes e on {{.Name}}Topic.
+func (p *publisher) Publish{{.Name}}(e *{{.Payload}}) error {
+	return p.bus.Publish({{.Name}}Topic, {{if .Key}}{{.Key}}{{else}}""{{end}}, e)
+}
+{{end}}
+// Subscribe subscribes s to all the events: the events published on the event bus broker are
+// decoded with the service decoders and given to the corresponding Subscriber method.
+func Subscribe(bus *goa.EventBus, s Subscriber) error {
+{{range .}}	if err := bus.Subscribe({{.Name}}Topic, func() interface{} { return new({{.Payload}}) }, func(e interface{}) error {
+		return s.Handle{{.Name}}(e.(*{{.Payload}}))
+	}); err != nil {
+		return err
+	}
+{{end}}	return nil
+}
+`
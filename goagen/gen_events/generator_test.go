@@ -0,0 +1,100 @@
+package genevents_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_events"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var prevDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("eventstest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+
+		placed := &design.EventDefinition{
+			Name:        "OrderPlaced",
+			Description: "OrderPlaced is published each time an order is placed.",
+			Topic:       "orders.placed",
+			Key:         "orderID",
+			Payload: &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"orderID": &design.AttributeDefinition{Type: design.String},
+						"total":   &design.AttributeDefinition{Type: design.Number},
+					},
+					Validation: &dslengine.ValidationDefinition{Required: []string{"orderID"}},
+				},
+				TypeName: "OrderPlacedPayload",
+			},
+		}
+		shipped := &design.EventDefinition{
+			Name:  "OrderShipped",
+			Topic: "orders.shipped",
+			Key:   "orderNumber",
+			Payload: &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"orderNumber": &design.AttributeDefinition{Type: design.Integer},
+					},
+					Validation: &dslengine.ValidationDefinition{Required: []string{"orderNumber"}},
+				},
+				TypeName: "OrderShippedPayload",
+			},
+		}
+		prevDesign = design.Design
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "orders"},
+			Events:               map[string]*design.EventDefinition{placed.Name: placed, shipped.Name: shipped},
+		}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = genevents.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		design.Design = prevDesign
+		workspace.Delete()
+	})
+
+	It("generates the payload types, the publishers, the subscribers and the schema", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(4))
+
+		types, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "events", "types.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(types)).Should(ContainSubstring("type OrderPlacedPayload struct {"))
+		Ω(string(types)).Should(ContainSubstring("type OrderShippedPayload struct {"))
+
+		events, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "events", "events.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(events)).Should(ContainSubstring(`OrderPlacedTopic = "orders.placed"`))
+		Ω(string(events)).Should(ContainSubstring("PublishOrderPlaced(e *OrderPlacedPayload) error"))
+		Ω(string(events)).Should(ContainSubstring("HandleOrderShipped(e *OrderShippedPayload) error"))
+		Ω(string(events)).Should(ContainSubstring("return p.bus.Publish(OrderPlacedTopic, e.OrderID, e)"))
+		Ω(string(events)).Should(ContainSubstring(`return p.bus.Publish(OrderShippedTopic, fmt.Sprintf("%v", e.OrderNumber), e)`))
+		Ω(string(events)).Should(ContainSubstring("func Subscribe(bus *goa.EventBus, s Subscriber) error {"))
+
+		schema, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "events", "schema.json"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(schema)).Should(ContainSubstring(`"title":"OrderPlaced event published on orders.placed"`))
+		Ω(string(schema)).Should(ContainSubstring(`"required":["orderNumber"]`))
+	})
+})
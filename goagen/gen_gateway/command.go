@@ -0,0 +1,43 @@
+package gengateway
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// Gateway is the name of the gateway the manifest is generated for.
+	Gateway string
+
+	// Upstream is the URL of the service the gateway routes requests to.
+	Upstream string
+)
+
+// Command is the goa gateway manifest generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("gateway", "Generate API gateway rate limit, quota and security manifest")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&Gateway, "gateway", "kong", "Target gateway, one of kong, tyk or envoy")
+	r.Flags().StringVar(&Upstream, "upstream", "http://localhost:8080", "URL of the upstream service the gateway proxies requests to")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"gateway": Gateway, "upstream": Upstream}
+	gen := meta.NewGenerator(
+		"gengateway.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_gateway")},
+		flags,
+	)
+	return gen.Generate()
+}
@@ -0,0 +1,16 @@
+/*
+Package gengateway provides a generator that exports the edge policies declared in the API design
+as a manifest consumable by API gateways.
+
+The policies are declared using the "gateway:ratelimit", "gateway:quota" and "gateway:security"
+metadata keys on the API, resource or action definitions (see apidsl.Metadata). Action policies
+override resource policies which in turn override API policies.
+
+The generator supports the following gateways:
+
+	kong:  Kong declarative configuration (rate-limiting and authentication plugins)
+	tyk:   Tyk API definition and companion policy (rate limits, quotas and authentication)
+	envoy: Envoy route configuration (local rate limits, quotas and security schemes are
+	       exported as route filter metadata under the "goa" namespace)
+*/
+package gengateway
@@ -0,0 +1,205 @@
+package gengateway
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Renderers maps the supported gateway names to the functions that render the manifest in the
+// corresponding gateway configuration format.
+var Renderers = map[string]func(m *Manifest, upstream string) (interface{}, error){
+	"kong":  Kong,
+	"tyk":   Tyk,
+	"envoy": Envoy,
+}
+
+// kongAuthPlugins maps the security schemes to the corresponding Kong plugin names.
+var kongAuthPlugins = map[string]string{
+	"basic":  "basic-auth",
+	"apikey": "key-auth",
+	"jwt":    "jwt",
+	"oauth2": "oauth2",
+}
+
+// Kong renders the manifest as a Kong declarative configuration. Rate limits and quotas are both
+// mapped to the "rate-limiting" plugin, the smallest count wins if they share the same period.
+func Kong(m *Manifest, upstream string) (interface{}, error) {
+	routes := make([]map[string]interface{}, len(m.Routes))
+	for i, r := range m.Routes {
+		route := map[string]interface{}{
+			"name":       r.Name,
+			"methods":    []string{r.Method},
+			"paths":      []string{"~" + r.PathRegex()},
+			"strip_path": false,
+		}
+		if m.Host != "" {
+			route["hosts"] = []string{m.Host}
+		}
+		var plugins []map[string]interface{}
+		if r.RateLimit != nil || r.Quota != nil {
+			config := make(map[string]interface{})
+			for _, l := range []*Limit{r.RateLimit, r.Quota} {
+				if l == nil {
+					continue
+				}
+				if c, ok := config[l.Period]; !ok || c.(int) > l.Count {
+					config[l.Period] = l.Count
+				}
+			}
+			plugins = append(plugins, map[string]interface{}{"name": "rate-limiting", "config": config})
+		}
+		for _, s := range r.Security {
+			plugins = append(plugins, map[string]interface{}{"name": kongAuthPlugins[s]})
+		}
+		if plugins != nil {
+			route["plugins"] = plugins
+		}
+		routes[i] = route
+	}
+	return map[string]interface{}{
+		"_format_version": "1.1",
+		"services": []map[string]interface{}{
+			{"name": m.Name, "url": upstream, "routes": routes},
+		},
+	}, nil
+}
+
+// Tyk renders the manifest as a Tyk API definition together with a policy holding the quota.
+// Tyk enforces quotas per key so all the routes must share the same quota if any.
+func Tyk(m *Manifest, upstream string) (interface{}, error) {
+	var rateLimits []map[string]interface{}
+	var quota *Limit
+	schemes := make(map[string]bool)
+	for _, r := range m.Routes {
+		if r.RateLimit != nil {
+			rateLimits = append(rateLimits, map[string]interface{}{
+				"path":   r.PathRegex(),
+				"method": r.Method,
+				"rate":   r.RateLimit.Count,
+				"per":    r.RateLimit.Seconds(),
+			})
+		}
+		if r.Quota != nil {
+			if quota != nil && *quota != *r.Quota {
+				return nil, fmt.Errorf("tyk: route %s quota %d/%s differs from quota %d/%s used by other routes",
+					r.Name, r.Quota.Count, r.Quota.Period, quota.Count, quota.Period)
+			}
+			quota = r.Quota
+		}
+		for _, s := range r.Security {
+			schemes[s] = true
+		}
+	}
+	listenPath := "/"
+	if u, err := url.Parse(upstream); err == nil && u.Path != "" {
+		listenPath = u.Path
+	}
+	api := map[string]interface{}{
+		"name":              m.Name,
+		"api_id":            m.Name,
+		"use_keyless":       len(schemes) == 0,
+		"use_basic_auth":    schemes["basic"],
+		"use_standard_auth": schemes["apikey"],
+		"enable_jwt":        schemes["jwt"],
+		"use_oauth2":        schemes["oauth2"],
+		"active":            true,
+		"proxy": map[string]interface{}{
+			"listen_path":       listenPath,
+			"target_url":        strings.TrimSuffix(upstream, "/"),
+			"strip_listen_path": false,
+		},
+		"version_data": map[string]interface{}{
+			"not_versioned": true,
+			"versions": map[string]interface{}{
+				"Default": map[string]interface{}{
+					"name": "Default",
+					"extended_paths": map[string]interface{}{
+						"rate_limit": rateLimits,
+					},
+				},
+			},
+		},
+	}
+	if m.Host != "" {
+		api["domain"] = m.Host
+	}
+	policy := map[string]interface{}{
+		"name":               m.Name,
+		"active":             true,
+		"quota_max":          -1,
+		"quota_renewal_rate": -1,
+		"access_rights": map[string]interface{}{
+			m.Name: map[string]interface{}{"api_name": m.Name, "api_id": m.Name, "versions": []string{"Default"}},
+		},
+	}
+	if quota != nil {
+		policy["quota_max"] = quota.Count
+		policy["quota_renewal_rate"] = quota.Seconds()
+	}
+	return map[string]interface{}{"api_definition": api, "policies": []interface{}{policy}}, nil
+}
+
+// Envoy renders the manifest as an Envoy route configuration. Rate limits are mapped to the
+// local rate limit filter. Envoy has no built-in notion of quotas or of the security schemes so
+// these are exported as route filter metadata for use by external filters.
+func Envoy(m *Manifest, upstream string) (interface{}, error) {
+	domain := "*"
+	if m.Host != "" {
+		domain = m.Host
+	}
+	routes := make([]map[string]interface{}, len(m.Routes))
+	for i, r := range m.Routes {
+		route := map[string]interface{}{
+			"name": r.Name,
+			"match": map[string]interface{}{
+				"safe_regex": map[string]interface{}{"regex": r.PathRegex()},
+				"headers": []map[string]interface{}{
+					{"name": ":method", "string_match": map[string]interface{}{"exact": r.Method}},
+				},
+			},
+			"route": map[string]interface{}{"cluster": m.Name},
+		}
+		if r.RateLimit != nil {
+			route["typed_per_filter_config"] = map[string]interface{}{
+				"envoy.filters.http.local_ratelimit": map[string]interface{}{
+					"@type":       "type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit",
+					"stat_prefix": r.Name,
+					"token_bucket": map[string]interface{}{
+						"max_tokens":      r.RateLimit.Count,
+						"tokens_per_fill": r.RateLimit.Count,
+						"fill_interval":   fmt.Sprintf("%ds", r.RateLimit.Seconds()),
+					},
+					"filter_enabled":  envoyFullPercent,
+					"filter_enforced": envoyFullPercent,
+				},
+			}
+		}
+		if r.Quota != nil || len(r.Security) > 0 {
+			md := make(map[string]interface{})
+			if r.Quota != nil {
+				md["quota"] = map[string]interface{}{"count": r.Quota.Count, "period": r.Quota.Period}
+			}
+			if len(r.Security) > 0 {
+				md["security"] = r.Security
+			}
+			route["metadata"] = map[string]interface{}{
+				"filter_metadata": map[string]interface{}{"goa": md},
+			}
+		}
+		routes[i] = route
+	}
+	return map[string]interface{}{
+		"name": m.Name,
+		"virtual_hosts": []map[string]interface{}{
+			{"name": m.Name, "domains": []string{domain}, "routes": routes},
+		},
+	}, nil
+}
+
+// envoyFullPercent is the Envoy runtime fractional percent used to enable and enforce filters on
+// all requests.
+var envoyFullPercent = map[string]interface{}{
+	"runtime_key":   "local_rate_limit_enabled",
+	"default_value": map[string]interface{}{"numerator": 100, "denominator": "HUNDRED"},
+}
@@ -0,0 +1,90 @@
+package gengateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the gateway manifest generator.
+type Generator struct {
+	genfiles []string
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "API gateway manifest generator",
+		Long:  "API gateway manifest generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// GatewayDir returns the path to the directory where the manifest is generated.
+func GatewayDir() string {
+	return filepath.Join(codegen.OutputDir, "gateway")
+}
+
+// Generate produces the gateway manifest.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+	render, ok := Renderers[Gateway]
+	if !ok {
+		return nil, fmt.Errorf("unsupported gateway %#v, must be one of kong, tyk or envoy", Gateway)
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	m, err := NewManifest(api)
+	if err != nil {
+		return
+	}
+	conf, err := render(m, Upstream)
+	if err != nil {
+		return
+	}
+	b, err := json.MarshalIndent(conf, "", "  ")
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(GatewayDir(), 0755); err != nil {
+		return
+	}
+	manifestFile := filepath.Join(GatewayDir(), Gateway+".json")
+	if err = ioutil.WriteFile(manifestFile, b, 0644); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, manifestFile)
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.Remove(f)
+	}
+	g.genfiles = nil
+}
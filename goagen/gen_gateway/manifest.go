@@ -0,0 +1,218 @@
+package gengateway
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+)
+
+const (
+	// RateLimitKey is the metadata key used to declare rate limits, e.g.:
+	//
+	//	Metadata("gateway:ratelimit", "100/minute")
+	RateLimitKey = "gateway:ratelimit"
+
+	// QuotaKey is the metadata key used to declare quotas, e.g.:
+	//
+	//	Metadata("gateway:quota", "10000/month")
+	QuotaKey = "gateway:quota"
+
+	// SecurityKey is the metadata key used to declare the security schemes enforced by the
+	// gateway, e.g.:
+	//
+	//	Metadata("gateway:security", "apikey", "jwt")
+	SecurityKey = "gateway:security"
+)
+
+// Periods lists the supported rate limit and quota periods with their duration in seconds.
+var Periods = map[string]int{
+	"second": 1,
+	"minute": 60,
+	"hour":   3600,
+	"day":    86400,
+	"month":  2592000,
+	"year":   31536000,
+}
+
+// SecuritySchemes lists the security scheme names that may be used with SecurityKey.
+var SecuritySchemes = []string{"basic", "apikey", "jwt", "oauth2"}
+
+type (
+	// Manifest is the gateway agnostic description of the API edge policies.
+	Manifest struct {
+		// Name is the API name.
+		Name string
+		// Host is the API hostname if any.
+		Host string
+		// Routes lists the API routes sorted by name.
+		Routes []*Route
+	}
+
+	// Route describes the policies that apply to a single action route.
+	Route struct {
+		// Name is a unique name for the route built from the version, resource and
+		// action names.
+		Name string
+		// Version is the API version the route belongs to if any.
+		Version string
+		// Method is the route HTTP method.
+		Method string
+		// Path is the route full path, e.g. "/bottles/:id".
+		Path string
+		// RateLimit is the route rate limit if any.
+		RateLimit *Limit
+		// Quota is the route quota if any.
+		Quota *Limit
+		// Security lists the security schemes enforced on the route.
+		Security []string
+	}
+
+	// Limit describes a rate limit or a quota.
+	Limit struct {
+		// Count is the maximum number of requests allowed during Period.
+		Count int
+		// Period is the name of the limit period, e.g. "minute".
+		Period string
+	}
+)
+
+// NewManifest builds the manifest from the API definition.
+func NewManifest(api *design.APIDefinition) (*Manifest, error) {
+	m := &Manifest{Name: api.Name, Host: api.Host}
+	err := api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		return v.IterateResources(func(r *design.ResourceDefinition) error {
+			if !r.SupportsVersion(v.Version) {
+				return nil
+			}
+			return r.IterateActions(func(a *design.ActionDefinition) error {
+				mdatas := []dslengine.MetadataDefinition{api.Metadata, v.Metadata, r.Metadata, a.Metadata}
+				rl, err := limitFromMetadata(RateLimitKey, mdatas)
+				if err != nil {
+					return fmt.Errorf("%s: %s", a.Context(), err)
+				}
+				quota, err := limitFromMetadata(QuotaKey, mdatas)
+				if err != nil {
+					return fmt.Errorf("%s: %s", a.Context(), err)
+				}
+				sec, err := securityFromMetadata(mdatas)
+				if err != nil {
+					return fmt.Errorf("%s: %s", a.Context(), err)
+				}
+				for i, route := range a.Routes {
+					name := r.Name + "-" + a.Name
+					if v.Version != "" {
+						name = v.Version + "-" + name
+					}
+					if i > 0 {
+						name += "-" + strconv.Itoa(i)
+					}
+					m.Routes = append(m.Routes, &Route{
+						Name:      name,
+						Version:   v.Version,
+						Method:    route.Verb,
+						Path:      route.FullPath(v),
+						RateLimit: rl,
+						Quota:     quota,
+						Security:  sec,
+					})
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(byName(m.Routes))
+	return m, nil
+}
+
+// PathRegex returns a regular expression matching the route path. Path parameters match a single
+// path segment while catch-all wildcards match the rest of the path.
+func (r *Route) PathRegex() string {
+	matches := design.WildcardRegex.FindAllStringIndex(r.Path, -1)
+	var b bytes.Buffer
+	b.WriteString("^")
+	last := 0
+	for _, match := range matches {
+		b.WriteString(regexp.QuoteMeta(r.Path[last:match[0]]))
+		if r.Path[match[0]+1] == '*' {
+			b.WriteString("/.*")
+		} else {
+			b.WriteString("/[^/]+")
+		}
+		last = match[1]
+	}
+	b.WriteString(regexp.QuoteMeta(r.Path[last:]))
+	b.WriteString("$")
+	return b.String()
+}
+
+// Seconds returns the duration of the limit period in seconds.
+func (l *Limit) Seconds() int {
+	return Periods[l.Period]
+}
+
+// limitFromMetadata returns the limit defined by the last definition in mdatas that uses the
+// given key, nil if there is none.
+func limitFromMetadata(key string, mdatas []dslengine.MetadataDefinition) (*Limit, error) {
+	var value string
+	for _, mdata := range mdatas {
+		if vals, ok := mdata[key]; ok && len(vals) > 0 {
+			value = vals[len(vals)-1]
+		}
+	}
+	if value == "" {
+		return nil, nil
+	}
+	elems := strings.SplitN(value, "/", 2)
+	if len(elems) != 2 {
+		return nil, fmt.Errorf(`invalid %s value %#v, format must be "<count>/<period>"`, key, value)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(elems[0]))
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("invalid %s count %#v, must be a positive integer", key, elems[0])
+	}
+	period := strings.TrimSpace(elems[1])
+	if _, ok := Periods[period]; !ok {
+		return nil, fmt.Errorf("invalid %s period %#v, must be one of second, minute, hour, day, month or year", key, period)
+	}
+	return &Limit{Count: count, Period: period}, nil
+}
+
+// securityFromMetadata returns the security schemes defined by the last definition in mdatas
+// that uses the security key.
+func securityFromMetadata(mdatas []dslengine.MetadataDefinition) ([]string, error) {
+	var schemes []string
+	for _, mdata := range mdatas {
+		if vals, ok := mdata[SecurityKey]; ok {
+			schemes = vals
+		}
+	}
+	for _, s := range schemes {
+		known := false
+		for _, k := range SecuritySchemes {
+			if s == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown security scheme %#v, must be one of %s", s, strings.Join(SecuritySchemes, ", "))
+		}
+	}
+	return schemes, nil
+}
+
+// byName makes it possible to sort routes by name.
+type byName []*Route
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
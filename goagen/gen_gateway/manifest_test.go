@@ -0,0 +1,108 @@
+package gengateway_test
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_gateway"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewManifest", func() {
+	var api *design.APIDefinition
+	var action *design.ActionDefinition
+	var manifest *gengateway.Manifest
+	var err error
+
+	BeforeEach(func() {
+		res := &design.ResourceDefinition{Name: "bottles", BasePath: "/bottles"}
+		action = &design.ActionDefinition{Name: "show", Parent: res}
+		action.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "/:id", Parent: action}}
+		res.Actions = map[string]*design.ActionDefinition{"show": action}
+		api = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{
+				Name:     "cellar",
+				BasePath: "/cellar",
+				Metadata: dslengine.MetadataDefinition{"gateway:ratelimit": {"10/second"}},
+			},
+			Resources: map[string]*design.ResourceDefinition{"bottles": res},
+		}
+		design.Design = api
+	})
+
+	JustBeforeEach(func() {
+		manifest, err = gengateway.NewManifest(api)
+	})
+
+	It("inherits the API policies", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(manifest.Routes).Should(HaveLen(1))
+		r := manifest.Routes[0]
+		Ω(r.Name).Should(Equal("bottles-show"))
+		Ω(r.Method).Should(Equal("GET"))
+		Ω(r.Path).Should(Equal("/cellar/bottles/:id"))
+		Ω(r.PathRegex()).Should(Equal("^/cellar/bottles/[^/]+$"))
+		Ω(r.RateLimit).Should(Equal(&gengateway.Limit{Count: 10, Period: "second"}))
+		Ω(r.Quota).Should(BeNil())
+	})
+
+	Context("with action policies", func() {
+		BeforeEach(func() {
+			action.Metadata = dslengine.MetadataDefinition{
+				"gateway:ratelimit": {"100/minute"},
+				"gateway:quota":     {"1000/day"},
+				"gateway:security":  {"apikey"},
+			}
+		})
+
+		It("overrides the API policies", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			r := manifest.Routes[0]
+			Ω(r.RateLimit).Should(Equal(&gengateway.Limit{Count: 100, Period: "minute"}))
+			Ω(r.Quota).Should(Equal(&gengateway.Limit{Count: 1000, Period: "day"}))
+			Ω(r.Security).Should(Equal([]string{"apikey"}))
+		})
+	})
+
+	Context("with an invalid rate limit", func() {
+		BeforeEach(func() {
+			action.Metadata = dslengine.MetadataDefinition{"gateway:ratelimit": {"100/fortnight"}}
+		})
+
+		It("returns an error", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("fortnight"))
+		})
+	})
+
+	Context("with an unknown security scheme", func() {
+		BeforeEach(func() {
+			action.Metadata = dslengine.MetadataDefinition{"gateway:security": {"magic"}}
+		})
+
+		It("returns an error", func() {
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("rendered for Kong", func() {
+		var conf map[string]interface{}
+
+		JustBeforeEach(func() {
+			c, err := gengateway.Kong(manifest, "http://localhost:8080")
+			Ω(err).ShouldNot(HaveOccurred())
+			conf = c.(map[string]interface{})
+		})
+
+		It("produces rate limiting plugins", func() {
+			services := conf["services"].([]map[string]interface{})
+			Ω(services).Should(HaveLen(1))
+			routes := services[0]["routes"].([]map[string]interface{})
+			Ω(routes).Should(HaveLen(1))
+			plugins := routes[0]["plugins"].([]map[string]interface{})
+			Ω(plugins).Should(HaveLen(1))
+			Ω(plugins[0]["name"]).Should(Equal("rate-limiting"))
+			Ω(plugins[0]["config"]).Should(Equal(map[string]interface{}{"second": 10}))
+		})
+	})
+})
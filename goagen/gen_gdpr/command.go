@@ -0,0 +1,43 @@
+package gengdpr
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// TargetPackage is the name of the generated Go package.
+	TargetPackage string
+
+	// MountPath is the path prefix of the generated data subject endpoints.
+	MountPath string
+)
+
+// Command is the goa PII report and data subject endpoints generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("gdpr", "Generate the PII flow report and the data subject export and erasure endpoints")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&TargetPackage, "pkg", "gdpr", "Name of the generated Go package")
+	r.Flags().StringVar(&MountPath, "path", "/gdpr/subjects", "Path prefix of the data subject endpoints")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"pkg": TargetPackage, "path": MountPath}
+	gen := meta.NewGenerator(
+		"gengdpr.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_gdpr")},
+		flags,
+	)
+	return gen.Generate()
+}
@@ -0,0 +1,19 @@
+/*
+Package gengdpr implements the "gdpr" command which relies on the attributes tagged with the PII
+DSL to produce:
+
+  - pii_report.txt, the PII flow report listing for each action the PII fields it accepts in its
+    parameters, headers and payload and the PII fields it exposes in its responses,
+  - a Go package that defines one DataSubject interface per resource holding PII and the Mount
+    function that mounts the data subject export and erasure endpoints of these resources.
+
+The endpoints are mounted under the path given by the --path flag, "/gdpr/subjects" by default:
+GET requests made to "<path>/:subject/<resource>" export the data the resource holds about the
+subject, DELETE requests erase it. The service implements the interfaces:
+
+	gdpr.Mount(service, &gdpr.Handlers{Users: &usersDataSubject{db: db}})
+
+The values of the PII parameters and payload attributes are redacted from the request logs by the
+code generated by the "app" command, see goa.Service.SetLogRedaction.
+*/
+package gengdpr
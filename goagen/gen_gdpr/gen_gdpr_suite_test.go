@@ -0,0 +1,13 @@
+package gengdpr_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenGDPR(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenGDPR Suite")
+}
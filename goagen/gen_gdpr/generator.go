@@ -0,0 +1,314 @@
+package gengdpr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// ReportFile is the name of the file the PII flow report is written to.
+const ReportFile = "pii_report.txt"
+
+// Generator is the PII flow report and data subject endpoints generator.
+type Generator struct {
+	genfiles []string
+}
+
+type (
+	// Report contains the data used to render the PII flow report and the data subject
+	// endpoints.
+	Report struct {
+		// API is the API name.
+		API string
+		// Actions lists the API actions sorted by resource and action names.
+		Actions []*ActionFlow
+		// Resources lists the resources whose actions accept or expose PII.
+		Resources []*Resource
+	}
+
+	// ActionFlow describes the PII accepted and exposed by a single action.
+	ActionFlow struct {
+		// Resource is the name of the action resource.
+		Resource string
+		// Action is the action name.
+		Action string
+		// Routes lists the action routes formatted as "METHOD /path".
+		Routes []string
+		// Accepts lists the PII fields of the action parameters, headers and payload
+		// formatted as "path (category, category)".
+		Accepts []string
+		// Exposes lists the PII fields of the action responses formatted as
+		// "status: path (category, category)".
+		Exposes []string
+	}
+
+	// Resource describes a resource holding PII.
+	Resource struct {
+		// Name is the resource name.
+		Name string
+		// GoName is the name of the resource used in Go identifiers.
+		GoName string
+		// Fields lists the PII fields the resource actions accept or expose.
+		Fields []string
+	}
+)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "PII flow report and data subject endpoints generator",
+		Long:  "PII flow report and data subject endpoints generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// PackageDir returns the path to the directory where the data subject endpoints are generated.
+func PackageDir() string {
+	return filepath.Join(codegen.OutputDir, TargetPackage)
+}
+
+// Generate writes the PII flow report to the output directory and the data subject endpoints to
+// the target package directory.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	r := NewReport(api)
+	if err = g.generateReport(r); err != nil {
+		return
+	}
+	if err = g.generateEndpoints(api, r); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.RemoveAll(f)
+	}
+	g.genfiles = nil
+}
+
+// NewReport computes the PII accepted and exposed by the API actions.
+func NewReport(api *design.APIDefinition) *Report {
+	r := &Report{API: api.Name}
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		fields := make(map[string]bool)
+		res.IterateActions(func(a *design.ActionDefinition) error {
+			af := &ActionFlow{Resource: res.Name, Action: a.Name}
+			for _, rt := range a.Routes {
+				af.Routes = append(af.Routes, rt.Verb+" "+rt.FullPath(api.APIVersionDefinition))
+			}
+			accepted := func(kind string, att *design.AttributeDefinition) {
+				if att == nil {
+					return
+				}
+				for _, f := range att.PIIFields() {
+					af.Accepts = append(af.Accepts, formatField(kind+" "+f.Path, f.Categories))
+					fields[f.Path] = true
+				}
+			}
+			accepted("param", a.AllParams())
+			if a.Parent != nil {
+				accepted("header", a.Parent.Headers)
+			}
+			accepted("header", a.Headers)
+			if a.Payload != nil {
+				accepted("payload", a.Payload.AttributeDefinition)
+			}
+			for _, resp := range sortedResponses(a) {
+				mt := api.MediaTypeWithIdentifier(resp.MediaType)
+				if mt == nil {
+					continue
+				}
+				for _, f := range mt.PIIFields() {
+					af.Exposes = append(af.Exposes, formatField(fmt.Sprintf("%d %s", resp.Status, f.Path), f.Categories))
+					fields[f.Path] = true
+				}
+			}
+			r.Actions = append(r.Actions, af)
+			return nil
+		})
+		if len(fields) > 0 {
+			rs := &Resource{Name: res.Name, GoName: codegen.Goify(res.Name, true)}
+			for f := range fields {
+				rs.Fields = append(rs.Fields, f)
+			}
+			sort.Strings(rs.Fields)
+			r.Resources = append(r.Resources, rs)
+		}
+		return nil
+	})
+	return r
+}
+
+// generateReport writes the PII flow report.
+func (g *Generator) generateReport(r *Report) error {
+	if err := os.MkdirAll(codegen.OutputDir, 0755); err != nil {
+		return err
+	}
+	filename := filepath.Join(codegen.OutputDir, ReportFile)
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	g.genfiles = append(g.genfiles, filename)
+	tmpl := template.Must(template.New("pii").Funcs(template.FuncMap{"join": strings.Join}).Parse(reportT))
+	return tmpl.Execute(f, r)
+}
+
+// generateEndpoints generates the data subject interfaces and the function that mounts the
+// export and erasure endpoints. It does nothing if no resource holds PII.
+func (g *Generator) generateEndpoints(api *design.APIDefinition, r *Report) error {
+	if len(r.Resources) == 0 {
+		return nil
+	}
+	os.RemoveAll(PackageDir())
+	if err := os.MkdirAll(PackageDir(), 0755); err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, PackageDir())
+	filename := filepath.Join(PackageDir(), "gdpr.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("net/http"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("golang.org/x/net/context"),
+	}
+	title := fmt.Sprintf("%s: Data Subject Endpoints", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	data := map[string]interface{}{
+		"Resources": r.Resources,
+		"Path":      strings.TrimSuffix(MountPath, "/"),
+	}
+	if err := file.ExecuteTemplate("gdpr", endpointsT, nil, data); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// sortedResponses returns the responses of the action sorted by name.
+func sortedResponses(a *design.ActionDefinition) []*design.ResponseDefinition {
+	names := make([]string, 0, len(a.Responses))
+	for n := range a.Responses {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	resps := make([]*design.ResponseDefinition, len(names))
+	for i, n := range names {
+		resps[i] = a.Responses[n]
+	}
+	return resps
+}
+
+// formatField formats a PII field for the report.
+func formatField(path string, categories []string) string {
+	return fmt.Sprintf("%s (%s)", path, strings.Join(categories, ", "))
+}
+
+const reportT = `# Code generated by goagen, DO NOT EDIT.
+# PII flow of the {{ .API }} API
+{{ range .Actions }}
+{{ .Resource }}#{{ .Action }}
+  routes:  {{ if .Routes }}{{ join .Routes ", " }}{{ else }}-{{ end }}
+  accepts: {{ if .Accepts }}{{ range .Accepts }}
+    {{ . }}{{ end }}{{ else }}-{{ end }}
+  exposes: {{ if .Exposes }}{{ range .Exposes }}
+    {{ . }}{{ end }}{{ else }}-{{ end }}
+{{ end }}
+{{ len .Actions }} action(s), {{ len .Resources }} resource(s) holding PII{{ range .Resources }}
+  {{ .Name }}{{ end }}
+`
+
+const endpointsT = `type (
+{{ range .Resources }}	// {{ .GoName }}DataSubject exports and erases the personal data the {{ .Name }} resource holds
+	// about a data subject.
+	{{ .GoName }}DataSubject interface {
+		// Export returns the data held about the subject, it is encoded with the service encoders.
+		Export(ctx context.Context, subject string) (interface{}, error)
+		// Erase erases the data held about the subject.
+		Erase(ctx context.Context, subject string) error
+	}
+
+{{ end }}	// Handlers lists the data subject implementations of the resources holding PII. Mount
+	// skips the nil handlers.
+	Handlers struct {
+{{ range .Resources }}		{{ .GoName }} {{ .GoName }}DataSubject
+{{ end }}	}
+)
+
+// PIIFields lists the PII fields accepted or exposed by the actions of each resource.
+var PIIFields = map[string][]string{
+{{ range .Resources }}	{{ printf "%q" .Name }}: { {{ range $i, $f := .Fields }}{{ if $i }}, {{ end }}{{ printf "%q" $f }}{{ end }} },
+{{ end }}}
+
+// Mount mounts the data subject endpoints on the service: GET requests made to
+// "{{ .Path }}/:subject/<resource>" export the data the resource holds about the subject, DELETE
+// requests erase it.
+func Mount(service *goa.Service, h *Handlers) {
+{{ range .Resources }}	if h.{{ .GoName }} != nil {
+		mount(service, {{ printf "%q" .Name }}, h.{{ .GoName }})
+	}
+{{ end }}}
+
+// mount mounts the export and erasure endpoints of a resource.
+func mount(service *goa.Service, name string, ds interface {
+	Export(context.Context, string) (interface{}, error)
+	Erase(context.Context, string) error
+}) {
+	ctrl := service.NewController("GDPR")
+	path := "{{ .Path }}/:subject/" + name
+	h := ctrl.MuxHandler("export "+name, func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		data, err := ds.Export(ctx, goa.Request(ctx).Params.Get("subject"))
+		if err != nil {
+			return err
+		}
+		return goa.Response(ctx).Send(ctx, http.StatusOK, data)
+	}, nil)
+	service.Mux.Handle("GET", path, h)
+	service.LogInfo("mount", goa.KV{"ctrl", "GDPR"}, goa.KV{"action", "export " + name}, goa.KV{"route", "GET " + path})
+	h = ctrl.MuxHandler("erase "+name, func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		if err := ds.Erase(ctx, goa.Request(ctx).Params.Get("subject")); err != nil {
+			return err
+		}
+		rw.WriteHeader(http.StatusNoContent)
+		return nil
+	}, nil)
+	service.Mux.Handle("DELETE", path, h)
+	service.LogInfo("mount", goa.KV{"ctrl", "GDPR"}, goa.KV{"action", "erase " + name}, goa.KV{"route", "DELETE " + path})
+}
+`
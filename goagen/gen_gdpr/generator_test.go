@@ -0,0 +1,100 @@
+package gengdpr_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_gdpr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var oldDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("gdprtest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+		oldDesign = design.Design
+
+		email := &design.AttributeDefinition{
+			Type:     design.String,
+			Metadata: dslengine.MetadataDefinition{design.PIIKey: {"contact"}},
+		}
+		mt := &design.MediaTypeDefinition{
+			UserTypeDefinition: &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{"id": {Type: design.Integer}, "email": email},
+				},
+				TypeName: "User",
+			},
+			Identifier: "application/vnd.user+json",
+		}
+		res := &design.ResourceDefinition{Name: "users", BasePath: "/users"}
+		show := &design.ActionDefinition{Name: "show", Parent: res}
+		show.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "/:id", Parent: show}}
+		show.Responses = map[string]*design.ResponseDefinition{
+			"OK": {Name: "OK", Status: 200, MediaType: "application/vnd.user+json"},
+		}
+		update := &design.ActionDefinition{Name: "update", Parent: res}
+		update.Routes = []*design.RouteDefinition{{Verb: "PUT", Path: "/:id", Parent: update}}
+		update.Payload = &design.UserTypeDefinition{
+			AttributeDefinition: &design.AttributeDefinition{Type: design.Object{"email": email}},
+			TypeName:            "UpdateUserPayload",
+		}
+		res.Actions = map[string]*design.ActionDefinition{"show": show, "update": update}
+		health := &design.ResourceDefinition{Name: "health", BasePath: "/health"}
+		check := &design.ActionDefinition{Name: "check", Parent: health}
+		check.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "", Parent: check}}
+		health.Actions = map[string]*design.ActionDefinition{"check": check}
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "directory"},
+			Resources:            map[string]*design.ResourceDefinition{"users": res, "health": health},
+			MediaTypes:           map[string]*design.MediaTypeDefinition{"application/vnd.user+json": mt},
+		}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = gengdpr.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+		design.Design = oldDesign
+	})
+
+	It("generates the PII flow report", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), gengdpr.ReportFile))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(content)).Should(ContainSubstring("health#check\n  routes:  GET /health\n  accepts: -\n  exposes: -\n"))
+		Ω(string(content)).Should(ContainSubstring("users#show\n  routes:  GET /users/:id\n  accepts: -\n  exposes: \n    200 email (contact)\n"))
+		Ω(string(content)).Should(ContainSubstring("users#update\n  routes:  PUT /users/:id\n  accepts: \n    payload email (contact)\n  exposes: -\n"))
+		Ω(string(content)).Should(ContainSubstring("3 action(s), 1 resource(s) holding PII\n  users\n"))
+	})
+
+	It("generates the data subject endpoints", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(files).Should(HaveLen(3))
+		content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "gdpr", "gdpr.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(content)).Should(ContainSubstring("UsersDataSubject interface {"))
+		Ω(string(content)).Should(ContainSubstring("Users UsersDataSubject"))
+		Ω(string(content)).Should(ContainSubstring(`"users": {"email"},`))
+		Ω(string(content)).Should(ContainSubstring(`mount(service, "users", h.Users)`))
+		Ω(string(content)).Should(ContainSubstring(`path := "/gdpr/subjects/:subject/" + name`))
+		Ω(string(content)).ShouldNot(ContainSubstring("Health"))
+	})
+})
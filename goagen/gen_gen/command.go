@@ -9,6 +9,10 @@ import (
 )
 
 var (
+	// GenPkg contains the path to the Go package of third party generators that register
+	// themselves with codegen.RegisterGenerator.
+	GenPkg string
+
 	// GenPkgPath contains the path to the third party generator Go package.
 	GenPkgPath string
 
@@ -30,12 +34,27 @@ func NewCommand() *Command {
 
 // RegisterFlags registers the command line flags with the given registry.
 func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&GenPkg, "pkg", "", "Go package path to plugin package. The package must register its generators with codegen.RegisterGenerator.")
 	r.Flags().StringVar(&GenPkgPath, "pkg-path", "", "Go package path to generator package. The package must implement the Generate global function.")
 	r.Flags().StringVar(&GenPkgName, "pkg-name", "", "Go package name of generator package. Defaults to name of inner most directory in package path.")
 }
 
 // Run simply calls the meta generator.
 func (c *Command) Run() ([]string, error) {
+	if GenPkg != "" {
+		gen := meta.NewGenerator(
+			"codegen.RunGenerators",
+			[]*codegen.ImportSpec{
+				codegen.SimpleImport("github.com/goadesign/goa/goagen/codegen"),
+				codegen.NewImport("_", GenPkg),
+			},
+			nil,
+		)
+		return gen.Generate()
+	}
+	if GenPkgPath == "" {
+		return nil, fmt.Errorf("missing generator package, use --pkg to specify the Go package path of the plugin")
+	}
 	if GenPkgName == "" {
 		GenPkgName = filepath.ToSlash(filepath.Base(GenPkgPath))
 	}
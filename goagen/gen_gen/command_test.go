@@ -28,7 +28,9 @@ var _ = Describe("RegisterFlags", func() {
 		})
 
 		It("registers the flags", func() {
-			f := root.Flags().Lookup("pkg-path")
+			f := root.Flags().Lookup("pkg")
+			Ω(f).ShouldNot(BeNil())
+			f = root.Flags().Lookup("pkg-path")
 			Ω(f).ShouldNot(BeNil())
 			f = root.Flags().Lookup("pkg-name")
 			Ω(f).ShouldNot(BeNil())
@@ -2,76 +2,86 @@
 //
 // How to Write a goagen Plugin
 //
-// The only requirement is that the package exposes a global Generate function with the following
-// signature:
+// A plugin is a Go package that registers one or more implementations of the codegen.Generator
+// interface with codegen.RegisterGenerator from its init function:
 //
-// 	func Generate(api *design.APIDefinition) ([]string, error)
+//	type Generator interface {
+//		Generate(api *design.APIDefinition) ([]string, error)
+//	}
 //
 // where api is the API definition computed from the design DSL. On success Generate should return
 // the path to the generated files. On error the error message gets displayed to the user (and
-// goagen exist with status 1).
+// goagen exits with status 1). The global flags such as the output directory (codegen.OutputDir)
+// are initialized prior to calling Generate. Generators that accept their own flags implement
+// codegen.GeneratorFlags, the values are given to goagen after "--". Generators that implement
+// codegen.GeneratorCleaner get a chance to remove the files they generated if a generator that
+// runs after them fails. Registered generators run in alphabetical order of their names.
 //
 // The Generate method should take advantage of the APIDefinition IterateXXX methods to iterate
 // through the API resources, media types and types to guarantee that the order doesn't change
 // between two invokation of the function (thereby generating different output even if the design
-// hasn't changed).
+// hasn't changed). All the codegen helpers (Goify, GoTypeRef, SourceFileFor etc.) are available to
+// plugins.
 //
 // They may also take advantage of Metadata. The goa design language allows defining Metadata on a
 // number of definitions: API, Resource, Action, Response and Attribute (which means Type and
 // MediaType as well since these definitions are attributes). A metadata field consists of a
 // key/value pair where both are simple strings. The generator can use these key/value pairs to
 // produce different results, see example below. Metadata has no effect on the buit-in generators.
-// The Output directory is available through the codegen.OutputDir global variable.
 //
 // Package genresnames is an example of a goagen plugin. It creates a file "names.txt" containing
 // the names of the API resources sorted in alphabetical order. If a resource has a
 // metadata pair with the key "genresnames/name" then the plugin uses the metadata value instead.
 //
 // Invoke the plugin with:
-//	goagen gen -d <Go package path to design package> --pkg-path=<Go package path to genresnames>
+//	goagen gen -d <Go package path to design package> --pkg=<Go package path to genresnames>
 //
 // Source code:
-// 	package genresnames
+//	package genresnames
 //
-// 	import (
-// 		"io/ioutil"
-// 		"os"
-// 		"path/filepath"
-// 		"strings"
+//	import (
+//		"io/ioutil"
+//		"path/filepath"
+//		"strings"
 //
-// 		"gopkg.in/alecthomas/kingpin.v2"
+//		"github.com/goadesign/goa/design"
+//		"github.com/goadesign/goa/goagen/codegen"
+//	)
 //
-// 		"github.com/goadesign/goa/design"
-// 		"github.com/goadesign/goa/goagen/codegen"
-// 	)
+//	func init() {
+//		codegen.RegisterGenerator("genresnames", codegen.GeneratorFunc(Generate))
+//	}
 //
 //	// Generate is the function called by goagen to generate the names file.
 //	func Generate(api *design.APIDefinition) ([]string, error) {
-//		// Make sure to parse the common flags so that codegen.OutputDir gets properly
-//		// initialized.
-//		app := kingpin.New("Resource names", "Resource name generator")
-//		codegen.RegisterFlags(app)
-//		if _, err := app.Parse(os.Args[1:]); err != nil {
-//			panic(err)
-//		}
-//
-//		// Now iterate through the resources to gather their names
-//		names := make([]string, len(api.Resources))
-//		i := 0
+//		// Iterate through the resources to gather their names
+//		var names []string
 //		api.IterateResources(func(res *design.ResourceDefinition) error {
 //			if n, ok := res.Metadata["genresnames/name"]; ok {
-//				names[i] = n
+//				names = append(names, n[0])
 //			} else {
-//				names[i] = res.Name
+//				names = append(names, res.Name)
 //			}
-//			i++
 //			return nil
 //		})
 //		content := strings.Join(names, "\n")
 //
 //		// Write the output file and return its name
 //		outputFile := filepath.Join(codegen.OutputDir, "names.txt")
-//		ioutil.WriteFile(outputFile, []byte(content), 0644)
+//		if err := ioutil.WriteFile(outputFile, []byte(content), 0644); err != nil {
+//			return nil, err
+//		}
 //		return []string{outputFile}, nil
 //	}
+//
+// Legacy Plugins
+//
+// goagen still supports plugins that expose a global Generate function with the following
+// signature:
+//
+//	func Generate(roots []interface{}) ([]string, error)
+//
+// Such plugins are invoked with --pkg-path (and --pkg-name if the package name differs from the
+// inner most directory of the package path). These plugins must parse the global flags themselves
+// using codegen.RegisterFlags.
 package gengen
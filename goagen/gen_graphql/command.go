@@ -0,0 +1,37 @@
+package gengraphql
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+// TargetPackage is the name of the generated Go package.
+var TargetPackage string
+
+// Command is the goa GraphQL generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("graphql", "Generate the GraphQL schema and resolvers bridging to the controllers")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&TargetPackage, "pkg", "graphql", "Name of the generated Go package")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"pkg": TargetPackage}
+	gen := meta.NewGenerator(
+		"gengraphql.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_graphql")},
+		flags,
+	)
+	return gen.Generate()
+}
@@ -0,0 +1,18 @@
+/*
+Package gengraphql provides a generator for a GraphQL endpoint of the API. The generator produces
+the GraphQL schema of the API together with the Go code that creates a goa.GraphQLHandler whose
+resolvers delegate to the controllers mounted on the service:
+
+	service := goa.New("cellar")
+	app.MountBottleController(service, NewBottleController(service))
+	graphql.NewHandler(service, nil, nil).Mount("/graphql")
+	service.ListenAndServe(":8080")
+
+Media types become object types and payloads become input types. Actions whose route uses the GET
+method become fields of the Query type, the other actions become fields of the Mutation type. The
+field arguments are the action parameters and the "payload" argument for actions that have a
+payload. Resolvers for individual fields may be overridden by implementing the generated
+QueryResolver and MutationResolver interfaces, embedding ControllerResolver makes it possible to
+override only some of the fields.
+*/
+package gengraphql
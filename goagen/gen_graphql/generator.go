@@ -0,0 +1,145 @@
+package gengraphql
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the GraphQL generator.
+type Generator struct {
+	genfiles []string
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "GraphQL generator",
+		Long:  "GraphQL schema and resolver bridge generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// GraphQLDir returns the path to the directory where the GraphQL files are generated.
+func GraphQLDir() string {
+	return filepath.Join(codegen.OutputDir, TargetPackage)
+}
+
+// Generate produces the GraphQL schema and the handler Go code.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	schema, err := NewSchema(api)
+	if err != nil {
+		return
+	}
+	os.RemoveAll(GraphQLDir())
+	if err = os.MkdirAll(GraphQLDir(), 0755); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, GraphQLDir())
+	schemaFile := filepath.Join(GraphQLDir(), "schema.graphql")
+	if err = ioutil.WriteFile(schemaFile, schema.Render(), 0644); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, schemaFile)
+	if err = g.generateHandler(api, schema); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes the entire GraphQL directory if it was created by this generator.
+func (g *Generator) Cleanup() {
+	if len(g.genfiles) == 0 {
+		return
+	}
+	os.RemoveAll(GraphQLDir())
+	g.genfiles = nil
+}
+
+// generateHandler generates the resolver interfaces and the code that creates the GraphQL handler.
+func (g *Generator) generateHandler(api *design.APIDefinition, schema *Schema) error {
+	filename := filepath.Join(GraphQLDir(), "handler.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("golang.org/x/net/context"),
+	}
+	title := fmt.Sprintf("%s: GraphQL Handler", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	if err := file.ExecuteTemplate("handler", handlerTmpl, nil, schema); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+const handlerTmpl = `// QueryResolver resolves the fields of the Query type.
+type QueryResolver interface {
+{{range .Queries}}	// {{.MethodName}} resolves the {{.Name}} field.
+	{{.MethodName}}(ctx context.Context, args map[string]interface{}) (interface{}, error)
+{{end}}}
+
+// MutationResolver resolves the fields of the Mutation type.
+type MutationResolver interface {
+{{range .Mutations}}	// {{.MethodName}} resolves the {{.Name}} field.
+	{{.MethodName}}(ctx context.Context, args map[string]interface{}) (interface{}, error)
+{{end}}}
+
+// ControllerResolver implements QueryResolver and MutationResolver by dispatching each field to
+// the action it was generated from.
+type ControllerResolver struct {
+	Service *goa.Service
+}
+{{range .Queries}}{{template "bridge" .}}{{end}}{{range .Mutations}}{{template "bridge" .}}{{end}}
+// NewHandler returns a GraphQL handler serving the schema described in schema.graphql. The query
+// and mutation resolvers default to ControllerResolver if nil so that the controllers mounted on
+// the service handle both the REST and the GraphQL requests.
+func NewHandler(service *goa.Service, query QueryResolver, mutation MutationResolver) *goa.GraphQLHandler {
+	if query == nil {
+		query = &ControllerResolver{Service: service}
+	}
+	if mutation == nil {
+		mutation = &ControllerResolver{Service: service}
+	}
+	h := goa.NewGraphQLHandler(service)
+{{range .Queries}}	h.Query("{{.Name}}", query.{{.MethodName}})
+{{end}}{{range .Mutations}}	h.Mutation("{{.Name}}", mutation.{{.MethodName}})
+{{end}}	return h
+}
+{{define "bridge"}}
+// {{.MethodName}} dispatches the {{.Name}} field to the {{.Action.Name}} action of the {{.Action.Parent.Name}} resource.
+func (r *ControllerResolver) {{.MethodName}}(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return goa.GraphQLBridge(r.Service, goa.GraphQLRoute{ {{if .VersionName}}Version: "{{.VersionName}}", {{end}}Verb: "{{.Verb}}", Path: "{{.Path}}"})(ctx, args)
+}
+{{end}}`
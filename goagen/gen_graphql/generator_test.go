@@ -0,0 +1,80 @@
+package gengraphql_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/codegen/codegentest"
+	"github.com/goadesign/goa/goagen/gen_graphql"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var res *design.ResourceDefinition
+	var prevDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("graphqltest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+
+		prevDesign = design.Design
+		design.Design = codegentest.CellarAPI()
+		res = design.Design.Resources["bottle"]
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = gengraphql.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		design.Design = prevDesign
+		workspace.Delete()
+	})
+
+	It("generates the schema and the handler", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(3))
+		Ω(codegentest.ParseDir(filepath.Join(testPkg.Abs(), "graphql"))).Should(Succeed())
+
+		schema, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "graphql", "schema.graphql"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(schema)).Should(ContainSubstring("schema {\n\tquery: Query\n\tmutation: Mutation\n}"))
+		Ω(string(schema)).Should(ContainSubstring("scalar JSON"))
+		Ω(string(schema)).Should(ContainSubstring("type Query {\n\tlistBottle: [Bottle]\n\t# Retrieve bottle with given id\n\tshowBottle(id: Int!): Bottle\n}"))
+		Ω(string(schema)).Should(ContainSubstring("type Mutation {\n\tcreateBottle(payload: CreateBottleInput!): JSON\n}"))
+		Ω(string(schema)).Should(ContainSubstring("type Bottle {\n\tid: Int!\n\tname: String\n\trating: Float\n\ttags: [String]\n}"))
+		Ω(string(schema)).Should(ContainSubstring("input CreateBottleInput {\n\tmetadata: JSON\n\tname: String!\n}"))
+
+		handler, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "graphql", "handler.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(handler)).Should(ContainSubstring("ShowBottle(ctx context.Context, args map[string]interface{}) (interface{}, error)"))
+		Ω(string(handler)).Should(ContainSubstring(`goa.GraphQLBridge(r.Service, goa.GraphQLRoute{Verb: "GET", Path: "/bottles/:id"})(ctx, args)`))
+		Ω(string(handler)).Should(ContainSubstring(`goa.GraphQLBridge(r.Service, goa.GraphQLRoute{Verb: "POST", Path: "/bottles"})(ctx, args)`))
+		Ω(string(handler)).Should(ContainSubstring("func NewHandler(service *goa.Service, query QueryResolver, mutation MutationResolver) *goa.GraphQLHandler {"))
+		Ω(string(handler)).Should(ContainSubstring(`h.Query("showBottle", query.ShowBottle)`))
+		Ω(string(handler)).Should(ContainSubstring(`h.Mutation("createBottle", mutation.CreateBottle)`))
+	})
+
+	Context("with an attribute name that is not a valid GraphQL name", func() {
+		BeforeEach(func() {
+			res.Actions["show"].Params.Type.ToObject()["bottle-id"] = &design.AttributeDefinition{Type: design.String}
+		})
+
+		It("returns an error", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(genErr.Error()).Should(ContainSubstring(`"bottle-id" is not a valid GraphQL name`))
+		})
+	})
+})
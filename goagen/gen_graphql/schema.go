@@ -0,0 +1,344 @@
+package gengraphql
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+// jsonScalar is the name of the custom scalar used for values that have no GraphQL equivalent.
+const jsonScalar = "JSON"
+
+// nameRegex matches valid GraphQL names.
+var nameRegex = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+type (
+	// Schema describes a GraphQL schema.
+	Schema struct {
+		// Types lists the object and input types sorted by name.
+		Types []*Type
+		// Queries lists the fields of the Query type.
+		Queries []*Field
+		// Mutations lists the fields of the Mutation type.
+		Mutations []*Field
+		// UsesJSON is true if the schema uses the JSON scalar.
+		UsesJSON bool
+	}
+
+	// Type describes a GraphQL object or input type.
+	Type struct {
+		// Name is the type name.
+		Name string
+		// Input is true for input types.
+		Input bool
+		// Description is the type description if any.
+		Description string
+		// Fields lists the type fields sorted by name.
+		Fields []*Field
+	}
+
+	// Field describes a field of a GraphQL type.
+	Field struct {
+		// Name is the field name.
+		Name string
+		// Type is the field type reference, e.g. "[Bottle]" or "Int!".
+		Type string
+		// Description is the field description if any.
+		Description string
+		// Args lists the field arguments sorted by name.
+		Args []*Field
+		// Action is the action resolving the field for Query and Mutation fields.
+		Action *design.ActionDefinition
+		// Version is the API version defining the action for Query and Mutation fields.
+		Version *design.APIVersionDefinition
+	}
+
+	// builder keeps track of the types defined while building a schema.
+	builder struct {
+		schema *Schema
+		types  map[string]*Type
+	}
+)
+
+// NewSchema builds the GraphQL schema of the given API. Media types become object types and
+// payloads become input types. Actions whose first route uses the GET method become Query fields,
+// the other actions become Mutation fields.
+func NewSchema(api *design.APIDefinition) (*Schema, error) {
+	b := &builder{schema: new(Schema), types: make(map[string]*Type)}
+	err := api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		return v.IterateResources(func(r *design.ResourceDefinition) error {
+			return r.IterateActions(func(a *design.ActionDefinition) error {
+				if len(a.Routes) == 0 {
+					return nil
+				}
+				f, err := b.actionField(a, v)
+				if err != nil {
+					return fmt.Errorf("%s: %s", a.Context(), err)
+				}
+				if a.Routes[0].Verb == "GET" {
+					b.schema.Queries = append(b.schema.Queries, f)
+				} else {
+					b.schema.Mutations = append(b.schema.Mutations, f)
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range b.types {
+		sort.Sort(byName(t.Fields))
+		b.schema.Types = append(b.schema.Types, t)
+	}
+	sort.Sort(byTypeName(b.schema.Types))
+	sort.Sort(byName(b.schema.Queries))
+	sort.Sort(byName(b.schema.Mutations))
+	return b.schema, nil
+}
+
+// Render produces the GraphQL schema definition.
+func (s *Schema) Render() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("schema {\n\tquery: Query\n")
+	if len(s.Mutations) > 0 {
+		buf.WriteString("\tmutation: Mutation\n")
+	}
+	buf.WriteString("}\n")
+	if s.UsesJSON {
+		fmt.Fprintf(&buf, "\n# %s is an arbitrary JSON value.\nscalar %s\n", jsonScalar, jsonScalar)
+	}
+	renderType(&buf, &Type{Name: "Query", Fields: s.Queries})
+	if len(s.Mutations) > 0 {
+		renderType(&buf, &Type{Name: "Mutation", Fields: s.Mutations})
+	}
+	for _, t := range s.Types {
+		renderType(&buf, t)
+	}
+	return buf.Bytes()
+}
+
+// MethodName returns the name of the resolver method of a Query or Mutation field.
+func (f *Field) MethodName() string {
+	return codegen.Goify(f.Name, true)
+}
+
+// Path returns the full path of the route of the action resolving the field.
+func (f *Field) Path() string {
+	return f.Action.Routes[0].FullPath(f.Version)
+}
+
+// Verb returns the HTTP method of the route of the action resolving the field.
+func (f *Field) Verb() string {
+	return f.Action.Routes[0].Verb
+}
+
+// VersionName returns the name of the API version defining the action resolving the field, the
+// empty string for the default version.
+func (f *Field) VersionName() string {
+	if f.Version.IsDefault() {
+		return ""
+	}
+	return f.Version.Version
+}
+
+// renderType writes the definition of t to buf.
+func renderType(buf *bytes.Buffer, t *Type) {
+	buf.WriteString("\n")
+	writeComment(buf, t.Description, 0)
+	kind := "type"
+	if t.Input {
+		kind = "input"
+	}
+	fmt.Fprintf(buf, "%s %s {\n", kind, t.Name)
+	for _, f := range t.Fields {
+		writeComment(buf, f.Description, 1)
+		fmt.Fprintf(buf, "\t%s", f.Name)
+		if len(f.Args) > 0 {
+			args := make([]string, len(f.Args))
+			for i, a := range f.Args {
+				args[i] = a.Name + ": " + a.Type
+			}
+			fmt.Fprintf(buf, "(%s)", strings.Join(args, ", "))
+		}
+		fmt.Fprintf(buf, ": %s\n", f.Type)
+	}
+	buf.WriteString("}\n")
+}
+
+// actionField builds the Query or Mutation field resolved by the given action. The field
+// arguments are the action parameters and the "payload" argument if the action has a payload.
+func (b *builder) actionField(a *design.ActionDefinition, v *design.APIVersionDefinition) (*Field, error) {
+	name := codegen.Goify(a.Name, false) + codegen.Goify(a.Parent.Name, true)
+	if !v.IsDefault() {
+		name += codegen.Goify(codegen.VersionPackage(v.Version), true)
+	}
+	f := &Field{Name: name, Description: a.Description, Action: a, Version: v}
+	if params := a.AllParams(); params != nil {
+		if obj := params.Type.ToObject(); obj != nil {
+			for n, att := range obj {
+				typ, err := b.typeRef(att, params.IsRequired(n), true, codegen.Goify(name, true)+codegen.Goify(n, true))
+				if err != nil {
+					return nil, err
+				}
+				if err := checkName(n); err != nil {
+					return nil, err
+				}
+				f.Args = append(f.Args, &Field{Name: n, Type: typ, Description: att.Description})
+			}
+		}
+	}
+	if a.Payload != nil {
+		typ, err := b.typeRef(&design.AttributeDefinition{Type: a.Payload}, true, true, "")
+		if err != nil {
+			return nil, err
+		}
+		f.Args = append(f.Args, &Field{Name: "payload", Type: typ})
+	}
+	sort.Sort(byName(f.Args))
+	typ, err := b.responseType(a)
+	if err != nil {
+		return nil, err
+	}
+	f.Type = typ
+	return f, nil
+}
+
+// responseType returns the type of the field resolved by the given action. This is the media type
+// of the first success response (ordered by status code) if any, the JSON scalar otherwise.
+func (b *builder) responseType(a *design.ActionDefinition) (string, error) {
+	if mt := codegen.ResultMediaType(a); mt != nil {
+		return b.typeRef(&design.AttributeDefinition{Type: mt}, false, false, "")
+	}
+	b.schema.UsesJSON = true
+	return jsonScalar, nil
+}
+
+// typeRef returns the GraphQL type reference of a field with the given attribute, defining the
+// object or input types it uses. name is used to name the type defined for inline objects.
+func (b *builder) typeRef(att *design.AttributeDefinition, required, input bool, name string) (string, error) {
+	var typ string
+	switch actual := att.Type.(type) {
+	case design.Primitive:
+		switch actual.Kind() {
+		case design.BooleanKind:
+			typ = "Boolean"
+		case design.IntegerKind:
+			typ = "Int"
+		case design.NumberKind:
+			typ = "Float"
+		case design.StringKind, design.DateTimeKind:
+			typ = "String"
+		default:
+			b.schema.UsesJSON = true
+			typ = jsonScalar
+		}
+	case *design.MediaTypeDefinition:
+		if actual.Type.IsArray() {
+			return b.typeRef(actual.AttributeDefinition, required, input, name)
+		}
+		t, err := b.objectType(codegen.Goify(actual.TypeName, true), actual.AttributeDefinition, input)
+		if err != nil {
+			return "", err
+		}
+		typ = t
+	case *design.UserTypeDefinition:
+		if actual.Type.IsArray() {
+			return b.typeRef(actual.AttributeDefinition, required, input, name)
+		}
+		t, err := b.objectType(codegen.Goify(actual.TypeName, true), actual.AttributeDefinition, input)
+		if err != nil {
+			return "", err
+		}
+		typ = t
+	case design.Object:
+		t, err := b.objectType(name, att, input)
+		if err != nil {
+			return "", err
+		}
+		typ = t
+	case *design.Array:
+		elem, err := b.typeRef(actual.ElemType, false, input, name+"Item")
+		if err != nil {
+			return "", err
+		}
+		typ = "[" + elem + "]"
+	case *design.Hash:
+		b.schema.UsesJSON = true
+		typ = jsonScalar
+	default:
+		return "", fmt.Errorf("unsupported type %s", att.Type.Name())
+	}
+	if required {
+		typ += "!"
+	}
+	return typ, nil
+}
+
+// objectType defines the object or input type with the given name built from the given object
+// attribute and returns its name. Input type names end with "Input", the "Payload" suffix of
+// payload type names is removed first.
+func (b *builder) objectType(name string, att *design.AttributeDefinition, input bool) (string, error) {
+	if !att.Type.IsObject() {
+		b.schema.UsesJSON = true
+		return jsonScalar, nil
+	}
+	if input {
+		name = strings.TrimSuffix(name, "Payload") + "Input"
+	}
+	if _, ok := b.types[name]; ok {
+		return name, nil
+	}
+	t := &Type{Name: name, Input: input, Description: att.Description}
+	b.types[name] = t
+	obj := att.Type.ToObject()
+	for n, fatt := range obj {
+		if err := checkName(n); err != nil {
+			return "", err
+		}
+		typ, err := b.typeRef(fatt, att.IsRequired(n), input, strings.TrimSuffix(name, "Input")+codegen.Goify(n, true))
+		if err != nil {
+			return "", fmt.Errorf("field %s: %s", n, err)
+		}
+		t.Fields = append(t.Fields, &Field{Name: n, Type: typ, Description: fatt.Description})
+	}
+	return name, nil
+}
+
+// checkName returns an error if n is not a valid GraphQL name.
+func checkName(n string) error {
+	if !nameRegex.MatchString(n) {
+		return fmt.Errorf("%#v is not a valid GraphQL name", n)
+	}
+	return nil
+}
+
+// writeComment writes desc as a GraphQL comment at the given depth.
+func writeComment(buf *bytes.Buffer, desc string, depth int) {
+	if desc == "" {
+		return
+	}
+	tabs := codegen.Tabs(depth)
+	for _, l := range strings.Split(strings.TrimSpace(desc), "\n") {
+		fmt.Fprintf(buf, "%s# %s\n", tabs, strings.TrimSpace(l))
+	}
+}
+
+// byName makes it possible to sort fields by name.
+type byName []*Field
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
+
+// byTypeName makes it possible to sort types by name.
+type byTypeName []*Type
+
+func (b byTypeName) Len() int           { return len(b) }
+func (b byTypeName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byTypeName) Less(i, j int) bool { return b[i].Name < b[j].Name }
@@ -0,0 +1,48 @@
+package gengrpc
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// ProtoPackage is the name of the generated protobuf package.
+	ProtoPackage string
+
+	// GoPackage is the value of the go_package option written to the generated protobuf files
+	// if any.
+	GoPackage string
+
+	// TargetPackage is the name of the generated Go package.
+	TargetPackage string
+)
+
+// Command is the goa gRPC transport generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("grpc", "Generate the gRPC transport bridging to the controllers")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&ProtoPackage, "package", "api", "Name of generated protobuf package")
+	r.Flags().StringVar(&GoPackage, "go-package", "", "Value of the go_package option in the generated protobuf files")
+	r.Flags().StringVar(&TargetPackage, "pkg", "grpc", "Name of the generated Go package")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"package": ProtoPackage, "go-package": GoPackage, "pkg": TargetPackage}
+	gen := meta.NewGenerator(
+		"gengrpc.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_grpc")},
+		flags,
+	)
+	return gen.Generate()
+}
@@ -0,0 +1,18 @@
+/*
+Package gengrpc provides a generator for a gRPC transport of the API. The generator produces the
+Protocol Buffers definition of the API, one file per version, together with the Go code that
+creates a goa.GRPCServer bridging each rpc to the action it was generated from. The same
+controllers serve both the REST and the gRPC requests:
+
+	service := goa.New("cellar")
+	app.MountBottleController(service, NewBottleController(service))
+	server := grpc.NewServer(service)
+	http.ListenAndServeTLS(":8443", "cert.pem", "key.pem", server.Handler(service.Mux))
+
+The protobuf messages and services are built as done by the "proto" command, see the genproto
+package. The generated fields use the json_name option so that the JSON mapping of the messages
+matches the attribute names used by the actions. The "grpc:service", "grpc:method" and "grpc:skip"
+metadata keys control the names of the generated services and rpcs and which resources and actions
+are exposed.
+*/
+package gengrpc
@@ -0,0 +1,13 @@
+package gengrpc_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenGRPC(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenGRPC Suite")
+}
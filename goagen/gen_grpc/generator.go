@@ -0,0 +1,165 @@
+package gengrpc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_proto"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the gRPC transport generator.
+type Generator struct {
+	genfiles []string
+}
+
+// Method contains the data needed to register the route of a single rpc.
+type Method struct {
+	// Name is the full name of the rpc, e.g. "/api.Bottle/Show".
+	Name string
+	// Version is the name of the API version defining the action, empty for the default version.
+	Version string
+	// Verb is the HTTP method of the action route.
+	Verb string
+	// Path is the full path of the action route.
+	Path string
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "gRPC transport generator",
+		Long:  "gRPC service definitions and server bridge generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// GRPCDir returns the path to the directory where the gRPC transport files are generated.
+func GRPCDir() string {
+	return filepath.Join(codegen.OutputDir, TargetPackage)
+}
+
+// Generate produces one protobuf file per API version and the server bridge Go code.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	os.RemoveAll(GRPCDir())
+	if err = os.MkdirAll(GRPCDir(), 0755); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, GRPCDir())
+	var methods []*Method
+	err = api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		pkg := ProtoPackage
+		name := "api.proto"
+		if v.Version != "" {
+			vpkg := codegen.VersionPackage(v.Version)
+			pkg += "." + vpkg
+			name = vpkg + ".proto"
+		}
+		f, err := genproto.NewFile(pkg, GoPackage, v)
+		if err != nil {
+			return err
+		}
+		f.JSONNames = true
+		protoFile := filepath.Join(GRPCDir(), name)
+		if err := ioutil.WriteFile(protoFile, f.Render(), 0644); err != nil {
+			return err
+		}
+		g.genfiles = append(g.genfiles, protoFile)
+		methods = append(methods, fileMethods(f, v)...)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	if err = g.generateServer(api, methods); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes the entire gRPC directory if it was created by this generator.
+func (g *Generator) Cleanup() {
+	if len(g.genfiles) == 0 {
+		return
+	}
+	os.RemoveAll(GRPCDir())
+	g.genfiles = nil
+}
+
+// generateServer generates the code that creates the gRPC server and registers the rpc routes.
+func (g *Generator) generateServer(api *design.APIDefinition, methods []*Method) error {
+	filename := filepath.Join(GRPCDir(), "server.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa")}
+	title := fmt.Sprintf("%s: gRPC Server", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	if err := file.ExecuteTemplate("server", serverTmpl, nil, methods); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// fileMethods returns the methods of the services defined in the given protobuf file. Each rpc
+// is mapped to the first route of the corresponding action.
+func fileMethods(f *genproto.File, version *design.APIVersionDefinition) []*Method {
+	var methods []*Method
+	for _, svc := range f.Services {
+		for _, rpc := range svc.RPCs {
+			if len(rpc.Action.Routes) == 0 {
+				continue
+			}
+			route := rpc.Action.Routes[0]
+			m := &Method{
+				Name: fmt.Sprintf("/%s.%s/%s", f.Package, svc.Name, rpc.Name),
+				Verb: route.Verb,
+				Path: route.FullPath(version),
+			}
+			if !version.IsDefault() {
+				m.Version = version.Version
+			}
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+const serverTmpl = `// NewServer returns a gRPC server exposing the services described in the generated protobuf
+// files. Each rpc is bridged to the action it was generated from so that the controllers mounted
+// on the service handle both the REST and the gRPC requests.
+func NewServer(service *goa.Service) *goa.GRPCServer {
+	s := goa.NewGRPCServer(service)
+{{range .}}	s.Handle("{{.Name}}", goa.GRPCRoute{ {{if .Version}}Version: "{{.Version}}", {{end}}Verb: "{{.Verb}}", Path: "{{.Path}}"})
+{{end}}	return s
+}
+`
@@ -0,0 +1,118 @@
+package gengrpc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_grpc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var res *design.ResourceDefinition
+	var prevDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("grpctest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+
+		bottle := &design.MediaTypeDefinition{
+			UserTypeDefinition: &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"id":        &design.AttributeDefinition{Type: design.Integer},
+						"vintageID": &design.AttributeDefinition{Type: design.Integer},
+					},
+				},
+				TypeName: "Bottle",
+			},
+			Identifier: "application/vnd.bottle+json",
+		}
+		res = &design.ResourceDefinition{Name: "bottle", BasePath: "/bottles", MediaType: bottle.Identifier}
+		show := &design.ActionDefinition{
+			Name:   "show",
+			Parent: res,
+			Params: &design.AttributeDefinition{Type: design.Object{
+				"id": &design.AttributeDefinition{Type: design.Integer},
+			}},
+			Responses: map[string]*design.ResponseDefinition{
+				"OK": {Name: "OK", Status: 200, MediaType: bottle.Identifier},
+			},
+		}
+		show.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "/:id", Parent: show}}
+		del := &design.ActionDefinition{
+			Name:   "delete",
+			Parent: res,
+			Params: &design.AttributeDefinition{Type: design.Object{
+				"id": &design.AttributeDefinition{Type: design.Integer},
+			}},
+			Metadata: dslengine.MetadataDefinition{design.GRPCSkipKey: nil},
+		}
+		del.Routes = []*design.RouteDefinition{{Verb: "DELETE", Path: "/:id", Parent: del}}
+		res.Actions = map[string]*design.ActionDefinition{"show": show, "delete": del}
+		prevDesign = design.Design
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "cellar"},
+			Resources:            map[string]*design.ResourceDefinition{"bottle": res},
+			MediaTypes:           map[string]*design.MediaTypeDefinition{bottle.Identifier: bottle},
+		}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = gengrpc.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		design.Design = prevDesign
+		workspace.Delete()
+	})
+
+	It("generates the protobuf file and the server bridge", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(3))
+
+		proto, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "grpc", "api.proto"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(proto)).Should(ContainSubstring("rpc Show(ShowBottleRequest) returns (Bottle);"))
+		Ω(string(proto)).Should(ContainSubstring(`int64 vintage_id = 2 [json_name = "vintageID"];`))
+		Ω(string(proto)).Should(ContainSubstring("int64 id = 1;"))
+		Ω(string(proto)).ShouldNot(ContainSubstring("Delete"))
+
+		server, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "grpc", "server.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(server)).Should(ContainSubstring("func NewServer(service *goa.Service) *goa.GRPCServer {"))
+		Ω(string(server)).Should(ContainSubstring(`s.Handle("/api.Bottle/Show", goa.GRPCRoute{Verb: "GET", Path: "/bottles/:id"})`))
+		Ω(string(server)).ShouldNot(ContainSubstring("DELETE"))
+	})
+
+	Context("with service and method names metadata", func() {
+		BeforeEach(func() {
+			res.Metadata = dslengine.MetadataDefinition{design.GRPCServiceKey: {"Cellar"}}
+			res.Actions["show"].Metadata = dslengine.MetadataDefinition{design.GRPCMethodKey: {"GetBottle"}}
+		})
+
+		It("uses the given names", func() {
+			Ω(genErr).Should(BeNil())
+			proto, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "grpc", "api.proto"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(proto)).Should(ContainSubstring("service Cellar {"))
+			Ω(string(proto)).Should(ContainSubstring("rpc GetBottle(ShowBottleRequest) returns (Bottle);"))
+			server, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "grpc", "server.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(server)).Should(ContainSubstring(`s.Handle("/api.Cellar/GetBottle"`))
+		})
+	})
+})
@@ -0,0 +1,43 @@
+package gengrpcbridge
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// ProtoPackage is the name of the protobuf package of the bridged gRPC services.
+	ProtoPackage string
+
+	// TargetPackage is the name of the generated Go package.
+	TargetPackage string
+)
+
+// Command is the goa REST and gRPC bridge generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("grpcbridge", "Generate the layer bridging the REST and gRPC transports")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&ProtoPackage, "package", "api", "Name of the protobuf package of the gRPC services")
+	r.Flags().StringVar(&TargetPackage, "pkg", "grpcbridge", "Name of the generated Go package")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"package": ProtoPackage, "pkg": TargetPackage}
+	gen := meta.NewGenerator(
+		"gengrpcbridge.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_grpcbridge")},
+		flags,
+	)
+	return gen.Generate()
+}
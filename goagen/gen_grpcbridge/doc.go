@@ -0,0 +1,22 @@
+/*
+Package gengrpcbridge provides a generator for the layer bridging the REST and gRPC transports of
+the API. The generated code lists the route of the action exposed by each rpc together with the
+rules used to map the action parameters and payload to the rpc request message fields, and uses
+them in both directions:
+
+	// gRPC front for the controllers mounted on the service
+	server := grpcbridge.NewServer(service)
+	http.ListenAndServeTLS(":8443", "cert.pem", "key.pem", server.Handler(service.Mux))
+
+	// REST front for a gRPC implementation of the API
+	grpcbridge.MountProxy(service, "https://backend:8443")
+	service.ListenAndServe(":8080")
+
+By default the parameters map to the top level message fields with the same names and the payload
+to the "payload" field as done by the "grpc" command. The "grpc:field" parameter metadata sets the
+path of the message field holding a parameter value, e.g. "bottle.id", and the "grpc:body" action
+metadata sets the path of the field holding the payload, "*" meaning all the fields that do not
+hold parameters. The "grpc:service", "grpc:method" and "grpc:skip" metadata keys control the names
+of the rpcs and which resources and actions are bridged.
+*/
+package gengrpcbridge
@@ -0,0 +1,13 @@
+package gengrpcbridge_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenGRPCBridge(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenGRPCBridge Suite")
+}
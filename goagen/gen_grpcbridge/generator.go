@@ -0,0 +1,238 @@
+package gengrpcbridge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_proto"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the REST and gRPC bridge generator.
+type Generator struct {
+	genfiles []string
+}
+
+// Route contains the data needed to generate the route of a single rpc.
+type Route struct {
+	// Method is the full name of the rpc, e.g. "/api.Bottle/Show".
+	Method string
+	// Version is the name of the API version defining the action, empty for the default version.
+	Version string
+	// Verb is the HTTP method of the action route.
+	Verb string
+	// Path is the full path of the action route.
+	Path string
+	// Fields maps the parameter names to the paths of the message fields holding their values.
+	Fields map[string]string
+	// Body is the path of the message field holding the payload, empty for the default.
+	Body string
+	// Params maps the parameter names to their types.
+	Params map[string]string
+	// Status is the status of the first success response of the action.
+	Status int
+	// Items is true if the action response is an array.
+	Items bool
+	// Action is the action exposed by the rpc.
+	Action *design.ActionDefinition
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "REST and gRPC bridge generator",
+		Long:  "Generator of the layer translating between REST requests and gRPC calls",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// BridgeDir returns the path to the directory where the bridge files are generated.
+func BridgeDir() string {
+	return filepath.Join(codegen.OutputDir, TargetPackage)
+}
+
+// Generate produces the rpc routes and the code that creates the gRPC server and the REST proxy.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	os.RemoveAll(BridgeDir())
+	if err = os.MkdirAll(BridgeDir(), 0755); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, BridgeDir())
+	var routes []*Route
+	err = api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		pkg := ProtoPackage
+		if v.Version != "" {
+			pkg += "." + codegen.VersionPackage(v.Version)
+		}
+		f, err := genproto.NewFile(pkg, "", v)
+		if err != nil {
+			return err
+		}
+		routes = append(routes, fileRoutes(f, v)...)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	if err = g.generateBridge(api, routes); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes the entire bridge directory if it was created by this generator.
+func (g *Generator) Cleanup() {
+	if len(g.genfiles) == 0 {
+		return
+	}
+	os.RemoveAll(BridgeDir())
+	g.genfiles = nil
+}
+
+// generateBridge generates the rpc routes and the code that creates the gRPC server and the
+// REST proxy.
+func (g *Generator) generateBridge(api *design.APIDefinition, routes []*Route) error {
+	filename := filepath.Join(BridgeDir(), "bridge.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa")}
+	title := fmt.Sprintf("%s: REST and gRPC Bridge", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	if err := file.ExecuteTemplate("bridge", bridgeTmpl, nil, routes); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// fileRoutes returns the routes of the rpcs of the services defined in the given protobuf file.
+// Each rpc is mapped to the first route of the corresponding action.
+func fileRoutes(f *genproto.File, version *design.APIVersionDefinition) []*Route {
+	var routes []*Route
+	for _, svc := range f.Services {
+		for _, rpc := range svc.RPCs {
+			a := rpc.Action
+			if len(a.Routes) == 0 {
+				continue
+			}
+			route := a.Routes[0]
+			r := &Route{
+				Method: fmt.Sprintf("/%s.%s/%s", f.Package, svc.Name, rpc.Name),
+				Verb:   route.Verb,
+				Path:   route.FullPath(version),
+				Fields: a.GRPCFields(),
+				Body:   a.GRPCBody(),
+				Params: paramTypes(a),
+				Action: a,
+			}
+			if !version.IsDefault() {
+				r.Version = version.Version
+			}
+			r.Status, r.Items = successResponse(a)
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+// paramTypes returns the types of the action parameters indexed by name as expected by
+// goa.GRPCRoute.
+func paramTypes(a *design.ActionDefinition) map[string]string {
+	types := make(map[string]string)
+	params := a.AllParams()
+	if params == nil || !params.Type.IsObject() {
+		return types
+	}
+	for n, att := range params.Type.ToObject() {
+		switch {
+		case att.Type.IsPrimitive():
+			types[n] = att.Type.Name()
+		case att.Type.IsArray():
+			types[n] = "[]" + att.Type.ToArray().ElemType.Type.Name()
+		}
+	}
+	return types
+}
+
+// successResponse returns the status of the first success response of the action (ordered by
+// status code) and whether its media type is an array. The status defaults to 200.
+func successResponse(a *design.ActionDefinition) (int, bool) {
+	responses := codegen.SuccessResponses(a)
+	if len(responses) == 0 {
+		return 200, false
+	}
+	r := responses[0]
+	if r.MediaType != "" {
+		if mt := design.Design.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+			return r.Status, mt.Type.IsArray()
+		}
+	}
+	return r.Status, false
+}
+
+const bridgeTmpl = `{{define "map"}}map[string]string{ {{range $k, $v := .}}"{{$k}}": "{{$v}}", {{end}}}{{end}}{{/*
+*/}}// Routes maps the full names of the rpcs to the routes of the actions they expose. The routes
+// also describe how the action parameters and payload map to the rpc request message fields.
+var Routes = map[string]goa.GRPCRoute{
+{{range .}}	// {{.Action.Parent.Name}} {{.Action.Name}}
+	"{{.Method}}": {
+{{if .Version}}		Version: "{{.Version}}",
+{{end}}		Verb: "{{.Verb}}",
+		Path: "{{.Path}}",
+{{if .Fields}}		Fields: {{template "map" .Fields}},
+{{end}}{{if .Body}}		Body: "{{.Body}}",
+{{end}}{{if .Params}}		Params: {{template "map" .Params}},
+{{end}}		Status: {{.Status}},
+{{if .Items}}		Items: true,
+{{end}}	},
+{{end}}}
+
+// NewServer returns a gRPC server that bridges the rpcs to the controllers mounted on the service
+// so that the same controllers handle both the REST and the gRPC requests.
+func NewServer(service *goa.Service) *goa.GRPCServer {
+	s := goa.NewGRPCServer(service)
+	for method, route := range Routes {
+		s.Handle(method, route)
+	}
+	return s
+}
+
+// MountProxy mounts the routes of the actions on the service and translates the requests they
+// receive into calls to the rpcs of the gRPC service located at url. This makes it possible to
+// expose a gRPC implementation of the API as a REST API.
+func MountProxy(service *goa.Service, url string) *goa.GRPCProxy {
+	p := goa.NewGRPCProxy(service, url)
+	for method, route := range Routes {
+		p.Handle(method, route)
+	}
+	return p
+}
+`
@@ -0,0 +1,123 @@
+package gengrpcbridge_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_grpcbridge"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var prevDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("grpcbridgetest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+
+		bottle := &design.MediaTypeDefinition{
+			UserTypeDefinition: &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"id":   &design.AttributeDefinition{Type: design.Integer},
+						"name": &design.AttributeDefinition{Type: design.String},
+					},
+				},
+				TypeName: "Bottle",
+			},
+			Identifier: "application/vnd.bottle+json",
+		}
+		bottles := &design.MediaTypeDefinition{
+			UserTypeDefinition: &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{Type: &design.Array{ElemType: bottle.AttributeDefinition}},
+				TypeName:            "BottleCollection",
+			},
+			Identifier: "application/vnd.bottle+json; type=collection",
+		}
+		res := &design.ResourceDefinition{Name: "bottle", BasePath: "/bottles", MediaType: bottle.Identifier}
+		update := &design.ActionDefinition{
+			Name:   "update",
+			Parent: res,
+			Params: &design.AttributeDefinition{Type: design.Object{
+				"id": &design.AttributeDefinition{
+					Type:     design.Integer,
+					Metadata: dslengine.MetadataDefinition{design.GRPCFieldKey: {"bottle.id"}},
+				},
+			}},
+			Payload: &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{Type: design.Object{
+					"name": &design.AttributeDefinition{Type: design.String},
+				}},
+				TypeName: "UpdateBottlePayload",
+			},
+			Responses: map[string]*design.ResponseDefinition{
+				"NoContent": {Name: "NoContent", Status: 204},
+			},
+			Metadata: dslengine.MetadataDefinition{design.GRPCBodyKey: {"bottle"}},
+		}
+		update.Routes = []*design.RouteDefinition{{Verb: "PATCH", Path: "/:id", Parent: update}}
+		list := &design.ActionDefinition{
+			Name:   "list",
+			Parent: res,
+			Params: &design.AttributeDefinition{Type: design.Object{
+				"tags": &design.AttributeDefinition{Type: &design.Array{ElemType: &design.AttributeDefinition{Type: design.String}}},
+			}},
+			Responses: map[string]*design.ResponseDefinition{
+				"OK": {Name: "OK", Status: 200, MediaType: bottles.Identifier},
+			},
+		}
+		list.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "", Parent: list}}
+		res.Actions = map[string]*design.ActionDefinition{"update": update, "list": list}
+		prevDesign = design.Design
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "cellar"},
+			Resources:            map[string]*design.ResourceDefinition{"bottle": res},
+			MediaTypes: map[string]*design.MediaTypeDefinition{
+				bottle.Identifier:  bottle,
+				bottles.Identifier: bottles,
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = gengrpcbridge.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		design.Design = prevDesign
+		workspace.Delete()
+	})
+
+	It("generates the routes with the mapping rules, the server and the proxy", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(2))
+
+		bridge, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "grpcbridge", "bridge.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		b := string(bridge)
+		Ω(b).Should(ContainSubstring(`"/api.Bottle/Update": {`))
+		Ω(b).Should(ContainSubstring(`Path:   "/bottles/:id",`))
+		Ω(b).Should(ContainSubstring(`Fields: map[string]string{"id": "bottle.id"},`))
+		Ω(b).Should(ContainSubstring(`Body:   "bottle",`))
+		Ω(b).Should(ContainSubstring(`Params: map[string]string{"id": "integer"},`))
+		Ω(b).Should(ContainSubstring(`Status: 204,`))
+		Ω(b).Should(ContainSubstring(`"/api.Bottle/List": {`))
+		Ω(b).Should(ContainSubstring(`Params: map[string]string{"tags": "[]string"},`))
+		Ω(b).Should(ContainSubstring(`Items:  true,`))
+		Ω(b).Should(ContainSubstring("func NewServer(service *goa.Service) *goa.GRPCServer {"))
+		Ω(b).Should(ContainSubstring("func MountProxy(service *goa.Service, url string) *goa.GRPCProxy {"))
+	})
+})
@@ -56,8 +56,8 @@ func (g *Generator) generateJS(jsFile string, api *design.APIDefinition) (_ *des
 	}
 	g.genfiles = append(g.genfiles, jsFile)
 
-	if Scheme == "" && len(api.Schemes) > 0 {
-		Scheme = api.Schemes[0]
+	if schemes := api.URLSchemes(); Scheme == "" && len(schemes) > 0 {
+		Scheme = schemes[0]
 	}
 	data := map[string]interface{}{
 		"API":     api,
@@ -354,7 +354,7 @@ const exampleCtrlT = `// MountController mounts the JavaScript example controlle
 func MountController(service *goa.Service) {
 	// Serve static files under js
 	service.ServeFiles("/js/*filepath", "{{.ServeDir}}")
-	goa.Info(goa.RootContext, "mount", goa.KV{"ctrl", "JS"}, goa.KV{"action", "ServeFiles"}, goa.KV{"route", "GET /js/*"})
+	service.LogInfo("mount", goa.KV{"ctrl", "JS"}, goa.KV{"action", "ServeFiles"}, goa.KV{"route", "GET /js/*"})
 }
 `
 
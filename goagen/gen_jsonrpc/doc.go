@@ -0,0 +1,19 @@
+/*
+Package genjsonrpc provides a generator for a JSON-RPC 2.0 transport of the API. Each action is
+exposed as a method named after its resource and action ("bottle.show"), methods of actions
+defined in a non default API version are prefixed with the version package name ("v1.bottle.show").
+The generator produces the method dispatch table together with the Go code that creates a
+goa.JSONRPCServer bridging each method to the action it was generated from:
+
+	service := goa.New("cellar")
+	app.MountBottleController(service, NewBottleController(service))
+	jsonrpc.NewServer(service).Mount("/rpc")
+	service.ListenAndServe(":8080")
+
+Method parameters are given by name: the action parameters and the "payload" parameter for actions
+that have a payload. The generated package also defines one params struct per method and the types
+of the method results built from the action media types. The server uses the params structs to
+check the types of the request parameters, clients may use the params and result types to build
+requests and decode responses.
+*/
+package genjsonrpc
@@ -0,0 +1,13 @@
+package genjsonrpc_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenJSONRPC(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenJSONRPC Suite")
+}
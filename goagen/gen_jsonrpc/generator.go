@@ -0,0 +1,233 @@
+package genjsonrpc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the JSON-RPC transport generator.
+type Generator struct {
+	genfiles []string
+	types    *codegen.TypeCollector
+}
+
+// Method contains the data needed to generate a single JSON-RPC method.
+type Method struct {
+	// Name is the method name, e.g. "bottle.show".
+	Name string
+	// Version is the name of the API version defining the action, empty for the default version.
+	Version string
+	// Verb is the HTTP method of the action route.
+	Verb string
+	// Path is the full path of the action route.
+	Path string
+	// Params is the name of the method params struct, empty if the method has no parameter.
+	Params string
+	// ParamsDef is the definition of the method params struct.
+	ParamsDef string
+	// Result is the name of the method result type, empty if the action response has no media
+	// type.
+	Result string
+	// Action is the action exposed by the method.
+	Action *design.ActionDefinition
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "JSON-RPC transport generator",
+		Long:  "JSON-RPC 2.0 methods and server bridge generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// JSONRPCDir returns the path to the directory where the JSON-RPC transport files are generated.
+func JSONRPCDir() string {
+	return filepath.Join(codegen.OutputDir, TargetPackage)
+}
+
+// Generate produces the method types and the server bridge Go code.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	os.RemoveAll(JSONRPCDir())
+	if err = os.MkdirAll(JSONRPCDir(), 0755); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, JSONRPCDir())
+	g.types = codegen.NewTypeCollector()
+	var methods []*Method
+	err = api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		return v.IterateResources(func(r *design.ResourceDefinition) error {
+			return r.IterateActions(func(a *design.ActionDefinition) error {
+				if len(a.Routes) == 0 {
+					return nil
+				}
+				methods = append(methods, g.method(a, v))
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return
+	}
+	sort.Sort(byName(methods))
+	if err = g.generateTypes(api, methods); err != nil {
+		return
+	}
+	if err = g.generateServer(api, methods); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes the entire JSON-RPC directory if it was created by this generator.
+func (g *Generator) Cleanup() {
+	if len(g.genfiles) == 0 {
+		return
+	}
+	os.RemoveAll(JSONRPCDir())
+	g.genfiles = nil
+}
+
+// method builds the JSON-RPC method exposing the given action and records the types it uses.
+func (g *Generator) method(a *design.ActionDefinition, v *design.APIVersionDefinition) *Method {
+	route := a.Routes[0]
+	name := a.Parent.Name + "." + a.Name
+	typeName := codegen.Goify(a.Name, true) + codegen.Goify(a.Parent.Name, true)
+	m := &Method{Name: name, Verb: route.Verb, Path: route.FullPath(v), Action: a}
+	if !v.IsDefault() {
+		vpkg := codegen.VersionPackage(v.Version)
+		m.Name = vpkg + "." + name
+		m.Version = v.Version
+		typeName = codegen.Goify(vpkg, true) + typeName
+	}
+	obj := make(design.Object)
+	var required []string
+	if params := a.AllParams(); params != nil && params.Type.IsObject() {
+		for n, att := range params.Type.ToObject() {
+			obj[n] = att
+		}
+		required = params.AllRequired()
+	}
+	if a.Payload != nil {
+		obj["payload"] = &design.AttributeDefinition{Type: a.Payload}
+		required = append(required, "payload")
+	}
+	if len(obj) > 0 {
+		att := &design.AttributeDefinition{
+			Type:       obj,
+			Validation: &dslengine.ValidationDefinition{Required: required},
+		}
+		g.types.Collect(att)
+		m.Params = typeName + "Params"
+		m.ParamsDef = codegen.GoTypeDef(att, false, "", 0, true)
+	}
+	if mt := codegen.ResultMediaType(a); mt != nil {
+		att := &design.AttributeDefinition{Type: mt}
+		g.types.Collect(att)
+		m.Result = codegen.GoTypeName(mt, nil, 0)
+	}
+	return m
+}
+
+// generateTypes generates the params structs and the types they and the method results use.
+func (g *Generator) generateTypes(api *design.APIDefinition, methods []*Method) error {
+	filename := filepath.Join(JSONRPCDir(), "types.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("time")}
+	title := fmt.Sprintf("%s: JSON-RPC Types", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	types := g.types.Types()
+	data := map[string]interface{}{"Methods": methods, "Types": types}
+	if err := file.ExecuteTemplate("types", typesTmpl, nil, data); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// generateServer generates the method dispatch table and the code that creates the JSON-RPC
+// server.
+func (g *Generator) generateServer(api *design.APIDefinition, methods []*Method) error {
+	filename := filepath.Join(JSONRPCDir(), "server.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa")}
+	title := fmt.Sprintf("%s: JSON-RPC Server", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	if err := file.ExecuteTemplate("server", serverTmpl, nil, methods); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// byName makes it possible to sort methods by name.
+type byName []*Method
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
+
+const typesTmpl = `{{range .Methods}}{{if .Params}}// {{.Params}} is the type of the params of the {{.Name}} method.
+type {{.Params}} {{.ParamsDef}}
+
+{{end}}{{end}}{{range .Types}}{{if .Description}}{{comment .Description}}{{else}}// {{.Name}} is a type used by the methods params or results.{{end}}
+type {{.Name}} {{.Def}}
+
+{{end}}`
+
+const serverTmpl = `// Methods is the JSON-RPC method dispatch table, it maps each method to the route of the action it
+// was generated from.
+var Methods = map[string]*goa.JSONRPCMethod{
+{{range .}}	// {{.Name}}{{if .Params}} takes {{.Params}}{{end}}{{if .Result}}{{if .Params}} and{{end}} returns {{.Result}}{{end}}
+	"{{.Name}}": { {{if .Version}}Version: "{{.Version}}", {{end}}Verb: "{{.Verb}}", Path: "{{.Path}}"{{if .Params}}, NewParams: func() interface{} { return new({{.Params}}) }{{end}}},
+{{end}}}
+
+// NewServer returns a JSON-RPC server exposing the methods listed in Methods. Each method is
+// bridged to the action it was generated from so that the controllers mounted on the service
+// handle both the REST and the JSON-RPC requests.
+func NewServer(service *goa.Service) *goa.JSONRPCServer {
+	s := goa.NewJSONRPCServer(service)
+	for name, m := range Methods {
+		s.Handle(name, m)
+	}
+	return s
+}
+`
@@ -0,0 +1,65 @@
+package genjsonrpc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/codegen/codegentest"
+	"github.com/goadesign/goa/goagen/gen_jsonrpc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var prevDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("jsonrpctest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+
+		prevDesign = design.Design
+		design.Design = codegentest.CellarAPI()
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = genjsonrpc.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		design.Design = prevDesign
+		workspace.Delete()
+	})
+
+	It("generates the method types and the server", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(3))
+		Ω(codegentest.ParseDir(filepath.Join(testPkg.Abs(), "jsonrpc"))).Should(Succeed())
+
+		types, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "jsonrpc", "types.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(types)).Should(ContainSubstring("type ShowBottleParams struct {\n\tID int `json:\"id\" xml:\"id\"`\n}"))
+		Ω(string(types)).Should(ContainSubstring("type CreateBottleParams struct {\n\tPayload *CreateBottlePayload `json:\"payload\" xml:\"payload\"`\n}"))
+		Ω(string(types)).Should(ContainSubstring("type CreateBottlePayload struct {"))
+		Ω(string(types)).Should(ContainSubstring("type Bottle struct {"))
+		Ω(string(types)).Should(ContainSubstring("type BottleCollection []*Bottle"))
+		Ω(string(types)).ShouldNot(ContainSubstring("ListBottleParams"))
+
+		server, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "jsonrpc", "server.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(server)).Should(ContainSubstring(`"bottle.show": {Verb: "GET", Path: "/bottles/:id", NewParams: func() interface{} { return new(ShowBottleParams) }},`))
+		Ω(string(server)).Should(ContainSubstring(`"bottle.list": {Verb: "GET", Path: "/bottles"},`))
+		Ω(string(server)).Should(ContainSubstring(`"bottle.create": {Verb: "POST", Path: "/bottles", NewParams: func() interface{} { return new(CreateBottleParams) }},`))
+		Ω(string(server)).Should(ContainSubstring("func NewServer(service *goa.Service) *goa.JSONRPCServer {"))
+	})
+})
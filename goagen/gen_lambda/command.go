@@ -0,0 +1,48 @@
+package genlambda
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// Mode is the deployment mode, either "monolithic" (one Lambda function serving all the
+	// actions) or "action" (one Lambda function per action).
+	Mode string
+
+	// Handler is the name of the executable run by the Lambda functions.
+	Handler string
+
+	// CodeURI is the path to the directory containing the executable.
+	CodeURI string
+)
+
+// Command is the goa AWS Lambda generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("lambda", "Generate the AWS Lambda adapter and SAM template")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&Mode, "mode", "monolithic", `Deployment mode: "monolithic" or "action" (one function per action)`)
+	r.Flags().StringVar(&Handler, "handler", "main", "Name of the executable run by the Lambda functions")
+	r.Flags().StringVar(&CodeURI, "code-uri", ".", "Path to the directory containing the executable")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"mode": Mode, "handler": Handler, "code-uri": CodeURI}
+	gen := meta.NewGenerator(
+		"genlambda.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_lambda")},
+		flags,
+	)
+	return gen.Generate()
+}
@@ -0,0 +1,20 @@
+/*
+Package genlambda provides a generator that makes AWS Lambda a deployment target of the service
+produced by the "main" command. The generator writes two files next to the generated main:
+
+	lambda.go     - built with the "lambda" build tag only, it sets goa.ServeFunc so that the
+	                service.ListenAndServe call made by main starts the AWS Lambda runtime loop
+	                with a goa.LambdaHandler instead of a HTTP server.
+	template.yaml - the AWS SAM template describing the Lambda functions with one API event per
+	                action route.
+
+The service and its controllers are initialized once per Lambda container by main, the
+invocations only translate the Amazon API Gateway proxy events into requests dispatched to the
+generated contexts. In "monolithic" mode (the default) the template defines one function serving
+all the actions, in "action" mode it defines one function per action so that each action can be
+configured and scaled independently. All the functions run the same executable:
+
+	GOOS=linux go build -tags lambda -o main
+	sam deploy --guided
+*/
+package genlambda
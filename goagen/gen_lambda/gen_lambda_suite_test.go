@@ -0,0 +1,13 @@
+package genlambda_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenLambda(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenLambda Suite")
+}
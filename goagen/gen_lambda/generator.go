@@ -0,0 +1,249 @@
+package genlambda
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the AWS Lambda generator.
+type Generator struct {
+	genfiles []string
+}
+
+// Function contains the data needed to generate the definition of a Lambda function.
+type Function struct {
+	// Name is the logical ID of the function in the SAM template, e.g. "ShowBottleFunction".
+	Name string
+	// Description is the function description.
+	Description string
+	// Events lists the API events that trigger the function, one per route.
+	Events []*Event
+}
+
+// Event contains the data needed to generate an API event of a Lambda function.
+type Event struct {
+	// Name is the logical ID of the event, e.g. "ShowBottle0".
+	Name string
+	// Path is the API Gateway path of the route, e.g. "/bottles/{id}".
+	Path string
+	// Method is the lower case HTTP method of the route.
+	Method string
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "AWS Lambda generator",
+		Long:  "AWS Lambda adapter and SAM template generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// Generate produces the Lambda adapter and the SAM template.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+	if Mode != "monolithic" && Mode != "action" {
+		return nil, fmt.Errorf(`invalid mode %#v, must be "monolithic" or "action"`, Mode)
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	if err = os.MkdirAll(codegen.OutputDir, 0755); err != nil {
+		return
+	}
+	if err = g.generateAdapter(); err != nil {
+		return
+	}
+	functions, err := buildFunctions(api)
+	if err != nil {
+		return
+	}
+	if err = g.generateTemplate(api, functions); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes the files written by the generator.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.Remove(f)
+	}
+	g.genfiles = nil
+}
+
+// generateAdapter generates the code that starts the Lambda runtime loop.
+func (g *Generator) generateAdapter() error {
+	filename := filepath.Join(codegen.OutputDir, "lambda.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	if _, err := file.Write([]byte("// +build lambda\n\n")); err != nil {
+		return err
+	}
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("github.com/aws/aws-lambda-go/lambda"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	if err := file.WriteHeader("AWS Lambda Adapter", "main", imports); err != nil {
+		return err
+	}
+	if err := file.ExecuteTemplate("adapter", adapterTmpl, nil, nil); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// generateTemplate generates the SAM template.
+func (g *Generator) generateTemplate(api *design.APIDefinition, functions []*Function) error {
+	tmpl, err := template.New("sam").Parse(samTmpl)
+	if err != nil {
+		panic(err) // bug
+	}
+	desc := api.Title
+	if desc == "" {
+		desc = api.Name
+	}
+	data := map[string]interface{}{
+		"Description": desc,
+		"Handler":     Handler,
+		"CodeURI":     CodeURI,
+		"Functions":   functions,
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+	filename := filepath.Join(codegen.OutputDir, "template.yaml")
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	return nil
+}
+
+// buildFunctions returns the Lambda functions serving the API actions given the mode.
+func buildFunctions(api *design.APIDefinition) ([]*Function, error) {
+	var functions []*Function
+	var all *Function
+	if Mode == "monolithic" {
+		all = &Function{
+			Name:        codegen.Goify(api.Name, true) + "Function",
+			Description: fmt.Sprintf("Serves all the %s actions", api.Name),
+		}
+		functions = append(functions, all)
+	}
+	err := api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		return v.IterateResources(func(r *design.ResourceDefinition) error {
+			return r.IterateActions(func(a *design.ActionDefinition) error {
+				name := codegen.Goify(a.Name, true) + codegen.Goify(r.Name, true)
+				if !v.IsDefault() {
+					name = codegen.Goify(codegen.VersionPackage(v.Version), true) + name
+				}
+				fn := all
+				if fn == nil {
+					fn = &Function{
+						Name:        name + "Function",
+						Description: fmt.Sprintf("Serves the %s %s action", r.Name, a.Name),
+					}
+					functions = append(functions, fn)
+				}
+				for i, route := range a.Routes {
+					fn.Events = append(fn.Events, &Event{
+						Name:   fmt.Sprintf("%s%d", name, i),
+						Path:   apiGatewayPath(route.FullPath(v)),
+						Method: strings.ToLower(route.Verb),
+					})
+				}
+				return nil
+			})
+		})
+	})
+	return functions, err
+}
+
+// apiGatewayPath converts the given route path into an API Gateway resource path: wildcards of
+// the form ":name" become "{name}" and catch-all wildcards of the form "*name" become
+// "{name+}".
+func apiGatewayPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if len(seg) < 2 {
+			continue
+		}
+		switch seg[0] {
+		case ':':
+			segments[i] = "{" + seg[1:] + "}"
+		case '*':
+			segments[i] = "{" + seg[1:] + "+}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+const adapterTmpl = `// init replaces the HTTP server started by main with the AWS Lambda runtime loop. The service
+// and the controllers are initialized once per Lambda container by main, the invocations only
+// translate the API Gateway events into requests handled by the controllers.
+func init() {
+	goa.ServeFunc = func(service *goa.Service) error {
+		lambda.StartHandler(goa.NewLambdaHandler(service))
+		return nil
+	}
+}
+`
+
+const samTmpl = `AWSTemplateFormatVersion: "2010-09-09"
+Transform: AWS::Serverless-2016-10-31
+Description: {{printf "%q" .Description}}
+
+Globals:
+  Function:
+    Runtime: go1.x
+    Handler: {{printf "%q" .Handler}}
+    CodeUri: {{printf "%q" .CodeURI}}
+    Timeout: 30
+
+Resources:
+{{range .Functions}}  {{.Name}}:
+    Type: AWS::Serverless::Function
+    Properties:
+      Description: {{printf "%q" .Description}}{{if .Events}}
+      Events:
+{{range .Events}}        {{.Name}}:
+          Type: Api
+          Properties:
+            Path: {{printf "%q" .Path}}
+            Method: {{.Method}}
+{{end}}{{end}}
+{{end}}`
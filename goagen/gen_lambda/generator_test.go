@@ -0,0 +1,102 @@
+package genlambda_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_lambda"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var mode string
+	var prevDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("lambdatest")
+		Ω(err).ShouldNot(HaveOccurred())
+		mode = "monolithic"
+
+		res := &design.ResourceDefinition{Name: "bottle", BasePath: "/bottles"}
+		show := &design.ActionDefinition{Name: "show", Parent: res}
+		show.Routes = []*design.RouteDefinition{
+			{Verb: "GET", Path: "/:id", Parent: show},
+			{Verb: "GET", Path: "/:id/files/*path", Parent: show},
+		}
+		list := &design.ActionDefinition{Name: "list", Parent: res}
+		list.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "", Parent: list}}
+		res.Actions = map[string]*design.ActionDefinition{"show": show, "list": list}
+		prevDesign = design.Design
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "cellar", Title: "The cellar API"},
+			Resources:            map[string]*design.ResourceDefinition{"bottle": res},
+		}
+	})
+
+	JustBeforeEach(func() {
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo", "--mode=" + mode}
+		files, genErr = genlambda.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		design.Design = prevDesign
+		workspace.Delete()
+	})
+
+	It("generates the adapter and a template with one function", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(2))
+
+		adapter, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "lambda.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(adapter)).Should(HavePrefix("// +build lambda\n\n"))
+		Ω(string(adapter)).Should(ContainSubstring("package main"))
+		Ω(string(adapter)).Should(ContainSubstring("lambda.StartHandler(goa.NewLambdaHandler(service))"))
+
+		tmpl, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "template.yaml"))
+		Ω(err).ShouldNot(HaveOccurred())
+		t := string(tmpl)
+		Ω(t).Should(ContainSubstring(`Description: "The cellar API"`))
+		Ω(t).Should(ContainSubstring("  CellarFunction:\n    Type: AWS::Serverless::Function"))
+		Ω(t).Should(ContainSubstring("        ShowBottle0:\n          Type: Api\n          Properties:\n            Path: \"/bottles/{id}\"\n            Method: get\n"))
+		Ω(t).Should(ContainSubstring(`Path: "/bottles/{id}/files/{path+}"`))
+		Ω(t).Should(ContainSubstring("        ListBottle0:"))
+		Ω(t).ShouldNot(ContainSubstring("ShowBottleFunction"))
+	})
+
+	Context("in action mode", func() {
+		BeforeEach(func() {
+			mode = "action"
+		})
+
+		It("generates one function per action", func() {
+			Ω(genErr).Should(BeNil())
+			tmpl, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "template.yaml"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(tmpl)).Should(ContainSubstring("  ShowBottleFunction:\n"))
+			Ω(string(tmpl)).Should(ContainSubstring("  ListBottleFunction:\n"))
+			Ω(string(tmpl)).ShouldNot(ContainSubstring("CellarFunction"))
+		})
+	})
+
+	Context("with an invalid mode", func() {
+		BeforeEach(func() {
+			mode = "foo"
+		})
+
+		It("fails", func() {
+			Ω(genErr).Should(HaveOccurred())
+		})
+	})
+})
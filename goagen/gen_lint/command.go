@@ -0,0 +1,104 @@
+package genlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// DisabledRules lists the names of the rules whose findings are not reported.
+	DisabledRules []string
+
+	// Strict causes warnings to fail the command as errors do.
+	Strict bool
+
+	// Output is the writer the findings are written to.
+	Output io.Writer = os.Stdout
+)
+
+// Command is the goa design linter command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("lint", "Run quality checks over the design")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringSliceVar(&DisabledRules, "disable", nil, "comma separated list of the names of the rules to disable")
+	r.Flags().BoolVar(&Strict, "strict", false, "fail on warnings as well as on errors")
+}
+
+// Run lints the design and writes the findings to Output. Run returns an error if any error is
+// found, or any finding at all if Strict is true.
+func (c *Command) Run() ([]string, error) {
+	dir, err := ioutil.TempDir("", "goagen-lint")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	outDir, dryRun := codegen.OutputDir, codegen.DryRun
+	defer func() { codegen.OutputDir, codegen.DryRun = outDir, dryRun }()
+	codegen.OutputDir, codegen.DryRun = dir, false
+	gen := meta.NewGenerator(
+		"genlint.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_lint")},
+		nil,
+	)
+	if _, err := gen.Generate(); err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, ReportFile))
+	if err != nil {
+		return nil, err
+	}
+	var findings []*Finding
+	if err := json.Unmarshal(b, &findings); err != nil {
+		return nil, err
+	}
+	findings = Filter(findings, DisabledRules)
+	failures := 0
+	for _, f := range findings {
+		fmt.Fprintln(Output, f)
+		if Strict || f.Severity == SeverityError {
+			failures++
+		}
+	}
+	if failures > 0 {
+		return nil, fmt.Errorf("%d lint failure(s)", failures)
+	}
+	return nil, nil
+}
+
+// Filter returns the findings produced by rules that are not listed in disabled.
+func Filter(findings []*Finding, disabled []string) []*Finding {
+	if len(disabled) == 0 {
+		return findings
+	}
+	var res []*Finding
+	for _, f := range findings {
+		skip := false
+		for _, d := range disabled {
+			if f.Rule == d {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			res = append(res, f)
+		}
+	}
+	return res
+}
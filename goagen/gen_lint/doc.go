@@ -0,0 +1,21 @@
+/*
+Package genlint implements the "lint" command which runs heuristic quality checks over the
+finalized design. The design validation only makes sure that the design is structurally valid, the
+linter flags designs that are valid but likely not what was intended or hard to consume:
+
+	no-response      actions that define no response
+	no-view          media types that define no view
+	unused-response  API responses and response templates that no action uses
+	naming           names that do not follow the style (snake_case, camelCase...) used by most
+	                 names of the same kind
+	description      API, resources, actions, types and media types with no description
+	route-conflict   routes that cannot be mounted together, for example because a wildcard and
+	                 a static segment share the same position
+
+Route conflicts are reported as errors and cause the command to exit with a non zero status, the
+other findings are warnings. The --strict flag makes warnings fail the command as well and
+--disable turns off the given rules:
+
+	goagen lint -d github.com/acme/cellar/design --strict --disable description
+*/
+package genlint
@@ -0,0 +1,69 @@
+package genlint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/spf13/cobra"
+)
+
+// ReportFile is the name of the file the findings are written to.
+const ReportFile = "lint.json"
+
+// Generator is the design linter generator.
+type Generator struct {
+	genfiles []string
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "Design linter",
+		Long:  "Design linter running heuristic quality checks",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// Generate lints the API design and writes the findings to the output directory.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+	findings := Lint(api)
+	if findings == nil {
+		findings = []*Finding{}
+	}
+	b, err := json.MarshalIndent(findings, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(codegen.OutputDir, 0755); err != nil {
+		return nil, err
+	}
+	filename := filepath.Join(codegen.OutputDir, ReportFile)
+	if err = ioutil.WriteFile(filename, b, 0644); err != nil {
+		return nil, err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	return g.genfiles, nil
+}
+
+// Cleanup removes the report file if it was written by this generator.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.Remove(f)
+	}
+	g.genfiles = nil
+}
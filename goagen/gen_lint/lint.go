@@ -0,0 +1,375 @@
+package genlint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/goadesign/goa/design"
+)
+
+// Lint rule names. Rules can be disabled with the --disable flag.
+const (
+	// RuleNoResponse flags actions that define no response.
+	RuleNoResponse = "no-response"
+	// RuleNoView flags media types that define no view.
+	RuleNoView = "no-view"
+	// RuleUnusedResponse flags API responses and response templates that no action uses.
+	RuleUnusedResponse = "unused-response"
+	// RuleNaming flags names that do not follow the naming style used by most names of the same
+	// kind (snake_case, camelCase etc.).
+	RuleNaming = "naming"
+	// RuleDescription flags API, resources, actions, types and media types with no description.
+	RuleDescription = "description"
+	// RuleRouteConflict flags routes that cannot be mounted together on the same mux.
+	RuleRouteConflict = "route-conflict"
+)
+
+// Finding severities.
+const (
+	// SeverityError is the severity of findings that denote a design that does not work as
+	// intended.
+	SeverityError = "error"
+	// SeverityWarning is the severity of findings that denote a quality issue.
+	SeverityWarning = "warning"
+)
+
+// Finding describes an issue found in the design.
+type Finding struct {
+	// Rule is the name of the rule that produced the finding.
+	Rule string `json:"rule"`
+	// Severity is either SeverityError or SeverityWarning.
+	Severity string `json:"severity"`
+	// Context describes the definition the finding applies to.
+	Context string `json:"context"`
+	// Message describes the issue.
+	Message string `json:"message"`
+}
+
+// linter accumulates the findings produced by the rules.
+type linter struct {
+	api      *design.APIDefinition
+	findings []*Finding
+}
+
+// Lint runs all the rules against the given finalized API definition and returns the findings.
+func Lint(api *design.APIDefinition) []*Finding {
+	l := &linter{api: api}
+	l.checkResponses()
+	l.checkViews()
+	l.checkUnusedResponses()
+	l.checkNaming()
+	l.checkDescriptions()
+	l.checkRoutes()
+	return l.findings
+}
+
+// String returns a human readable representation of the finding.
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s [%s]", f.Severity, f.Context, f.Message, f.Rule)
+}
+
+// checkResponses flags actions that define no response.
+func (l *linter) checkResponses() {
+	l.iterateActions(func(a *design.ActionDefinition) {
+		if len(a.Responses) == 0 {
+			l.warn(RuleNoResponse, a.Context(), "action defines no response")
+		}
+	})
+}
+
+// checkViews flags media types that define no view.
+func (l *linter) checkViews() {
+	l.api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		if len(mt.Views) == 0 {
+			l.warn(RuleNoView, mediaTypeContext(mt), "media type defines no view")
+		}
+		return nil
+	})
+}
+
+// checkUnusedResponses flags the API responses and response templates that no resource or
+// action uses. Responses built from an API response or template share its name.
+func (l *linter) checkUnusedResponses() {
+	used := make(map[string]bool)
+	l.api.IterateResources(func(r *design.ResourceDefinition) error {
+		for n := range r.Responses {
+			used[n] = true
+		}
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			for n := range a.Responses {
+				used[n] = true
+			}
+			return nil
+		})
+	})
+	l.api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		for _, n := range sortedKeys(v.Responses) {
+			if !used[n] {
+				l.warn(RuleUnusedResponse, v.Context(), fmt.Sprintf("response %#v is not used by any action", n))
+			}
+		}
+		for _, n := range sortedKeys(v.ResponseTemplates) {
+			if !used[n] {
+				l.warn(RuleUnusedResponse, v.ResponseTemplates[n].Context(), "response template is not used by any action")
+			}
+		}
+		return nil
+	})
+}
+
+// checkNaming flags the resource, action and attribute names that do not follow the naming
+// style used by most names of the same kind.
+func (l *linter) checkNaming() {
+	resources := new(nameSet)
+	actions := new(nameSet)
+	attributes := new(nameSet)
+	l.api.IterateResources(func(r *design.ResourceDefinition) error {
+		resources.add(r.Name, r.Context())
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			actions.add(a.Name, a.Context())
+			if a.Params != nil {
+				attributes.addAttribute(a.Params, "parameter", a.Context())
+			}
+			return nil
+		})
+	})
+	l.api.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		attributes.addAttribute(ut.AttributeDefinition, "attribute", ut.Context())
+		return nil
+	})
+	l.api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		attributes.addAttribute(mt.AttributeDefinition, "attribute", mediaTypeContext(mt))
+		return nil
+	})
+	for _, set := range []*nameSet{resources, actions, attributes} {
+		main := set.mainStyle()
+		for _, n := range set.names {
+			if n.style != "" && n.style != main {
+				l.warn(RuleNaming, n.context, fmt.Sprintf("%s uses %s while most names use %s", n.desc, n.style, main))
+			}
+		}
+	}
+}
+
+// checkDescriptions flags the API, resources, actions, types and media types with no
+// description.
+func (l *linter) checkDescriptions() {
+	if l.api.Description == "" {
+		l.warn(RuleDescription, l.api.Context(), "missing description")
+	}
+	l.api.IterateResources(func(r *design.ResourceDefinition) error {
+		if r.Description == "" {
+			l.warn(RuleDescription, r.Context(), "missing description")
+		}
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			if a.Description == "" {
+				l.warn(RuleDescription, a.Context(), "missing description")
+			}
+			return nil
+		})
+	})
+	l.api.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		if ut.Description == "" {
+			l.warn(RuleDescription, ut.Context(), "missing description")
+		}
+		return nil
+	})
+	l.api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		if mt.Description == "" {
+			l.warn(RuleDescription, mediaTypeContext(mt), "missing description")
+		}
+		return nil
+	})
+}
+
+// checkRoutes flags the routes of each API version that cannot be mounted together: routes with
+// the same method and path and routes where a wildcard and a static segment share the same
+// position after an identical prefix. The design validation already makes sure that wildcards at
+// the same position have the same name.
+func (l *linter) checkRoutes() {
+	type route struct {
+		verb     string
+		path     string
+		segments []string
+		action   *design.ActionDefinition
+	}
+	l.api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		var routes []*route
+		v.IterateResources(func(r *design.ResourceDefinition) error {
+			if !r.SupportsVersion(v.Version) {
+				return nil
+			}
+			return r.IterateActions(func(a *design.ActionDefinition) error {
+				for _, rt := range a.Routes {
+					p := rt.FullPath(v)
+					routes = append(routes, &route{rt.Verb, p, strings.Split(p, "/"), a})
+				}
+				return nil
+			})
+		})
+		for i, r := range routes {
+			for _, other := range routes[i+1:] {
+				if r.verb != other.verb {
+					continue
+				}
+				if msg := conflict(r.segments, other.segments); msg != "" {
+					l.findings = append(l.findings, &Finding{
+						Rule:     RuleRouteConflict,
+						Severity: SeverityError,
+						Context:  r.action.Context(),
+						Message: fmt.Sprintf("route %s %s conflicts with route %s %s of %s: %s",
+							r.verb, r.path, other.verb, other.path, other.action.Context(), msg),
+					})
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// iterateActions calls it with each action of the API.
+func (l *linter) iterateActions(it func(*design.ActionDefinition)) {
+	l.api.IterateResources(func(r *design.ResourceDefinition) error {
+		return r.IterateActions(func(a *design.ActionDefinition) error {
+			it(a)
+			return nil
+		})
+	})
+}
+
+// warn records a warning.
+func (l *linter) warn(rule, context, msg string) {
+	l.findings = append(l.findings, &Finding{Rule: rule, Severity: SeverityWarning, Context: context, Message: msg})
+}
+
+// conflict returns a description of the conflict between the routes with the given path
+// segments, the empty string if there is none.
+func conflict(a, b []string) string {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		wa, wb := isWildcard(a[i]), isWildcard(b[i])
+		switch {
+		case wa && wb:
+			continue
+		case wa != wb:
+			return "wildcard and static segments at the same position"
+		case a[i] != b[i]:
+			return ""
+		}
+	}
+	if len(a) == len(b) {
+		return "identical paths"
+	}
+	return ""
+}
+
+// isWildcard returns true if the path segment is a wildcard.
+func isWildcard(seg string) bool {
+	return strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*")
+}
+
+// mediaTypeContext returns the context used in findings about the given media type.
+func mediaTypeContext(mt *design.MediaTypeDefinition) string {
+	return fmt.Sprintf("media type %#v", mt.Identifier)
+}
+
+// nameSet records names of a given kind together with their naming style.
+type nameSet struct {
+	names []*name
+}
+
+// name is a name recorded in a nameSet.
+type name struct {
+	desc    string
+	context string
+	style   string
+}
+
+// add records the name of a definition.
+func (s *nameSet) add(n, context string) {
+	s.names = append(s.names, &name{desc: fmt.Sprintf("name %#v", n), context: context, style: nameStyle(n)})
+}
+
+// addAttribute records the names of the fields of the given object attribute, kind describes the
+// fields in findings ("attribute" or "parameter").
+func (s *nameSet) addAttribute(att *design.AttributeDefinition, kind, context string) {
+	obj := att.Type.ToObject()
+	if obj == nil {
+		return
+	}
+	for _, n := range sortedKeys(obj) {
+		s.names = append(s.names, &name{desc: fmt.Sprintf("%s %#v", kind, n), context: context, style: nameStyle(n)})
+	}
+}
+
+// mainStyle returns the style used by most of the names in the set. Ties are broken using the
+// alphabetical order of the style names.
+func (s *nameSet) mainStyle() string {
+	counts := make(map[string]int)
+	for _, n := range s.names {
+		if n.style != "" {
+			counts[n.style]++
+		}
+	}
+	var main string
+	for _, st := range sortedKeys(counts) {
+		if counts[st] > counts[main] {
+			main = st
+		}
+	}
+	return main
+}
+
+// nameStyle returns the naming style of n or the empty string if n could follow any style, for
+// example because it is a single lowercase word.
+func nameStyle(n string) string {
+	hasUpper := false
+	for _, r := range n {
+		if unicode.IsUpper(r) {
+			hasUpper = true
+			break
+		}
+	}
+	switch {
+	case strings.Contains(n, "_"):
+		if hasUpper {
+			return "mixed case"
+		}
+		return "snake_case"
+	case strings.Contains(n, "-"):
+		return "kebab-case"
+	case !hasUpper:
+		return ""
+	case unicode.IsUpper([]rune(n)[0]):
+		return "PascalCase"
+	default:
+		return "camelCase"
+	}
+}
+
+// sortedKeys returns the keys of the given map sorted alphabetically. m must be a map indexed by
+// strings.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch actual := m.(type) {
+	case map[string]*design.ResponseDefinition:
+		for k := range actual {
+			keys = append(keys, k)
+		}
+	case map[string]*design.ResponseTemplateDefinition:
+		for k := range actual {
+			keys = append(keys, k)
+		}
+	case design.Object:
+		for k := range actual {
+			keys = append(keys, k)
+		}
+	case map[string]int:
+		for k := range actual {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,143 @@
+package genlint_test
+
+import (
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_lint"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lint", func() {
+	var findings []*genlint.Finding
+
+	BeforeEach(func() {
+		InitDesign()
+	})
+
+	JustBeforeEach(func() {
+		err := dslengine.Run()
+		Ω(err).ShouldNot(HaveOccurred())
+		findings = genlint.Filter(genlint.Lint(Design), []string{genlint.RuleDescription})
+	})
+
+	rules := func() []string {
+		var res []string
+		for _, f := range findings {
+			res = append(res, f.Rule)
+		}
+		return res
+	}
+
+	Context("with a clean design", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				Description("test API")
+			})
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/bottles/:bottleID"))
+					Params(func() {
+						Param("bottleID", Integer)
+					})
+					Response(NoContent)
+				})
+			})
+		})
+
+		It("reports nothing", func() {
+			Ω(findings).Should(BeEmpty())
+		})
+	})
+
+	Context("with an action without response", func() {
+		BeforeEach(func() {
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/bottles/:id"))
+				})
+			})
+		})
+
+		It("reports a warning", func() {
+			Ω(findings).Should(HaveLen(1))
+			Ω(findings[0].Rule).Should(Equal(genlint.RuleNoResponse))
+			Ω(findings[0].Severity).Should(Equal(genlint.SeverityWarning))
+			Ω(findings[0].Context).Should(Equal(`resource "bottle" action "show"`))
+		})
+	})
+
+	Context("with an unused response template", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				ResponseTemplate("Found", func(location string) {
+					Status(302)
+				})
+			})
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/bottles/:id"))
+					Response(OK)
+				})
+			})
+		})
+
+		It("reports the template", func() {
+			Ω(rules()).Should(Equal([]string{genlint.RuleUnusedResponse}))
+			Ω(findings[0].Context).Should(Equal(`response template "Found"`))
+		})
+	})
+
+	Context("with inconsistent attribute names", func() {
+		BeforeEach(func() {
+			Type("Bottle", func() {
+				Attribute("vintage_year", Integer)
+				Attribute("bottle_name", String)
+				Attribute("createdAt", DateTime)
+			})
+		})
+
+		It("reports the odd name", func() {
+			Ω(rules()).Should(Equal([]string{genlint.RuleNaming}))
+			Ω(findings[0].Message).Should(Equal(`attribute "createdAt" uses camelCase while most names use snake_case`))
+		})
+	})
+
+	Context("with conflicting routes", func() {
+		BeforeEach(func() {
+			Resource("bottle", func() {
+				Action("show", func() {
+					Routing(GET("/bottles/:id"))
+					Response(NoContent)
+				})
+				Action("new", func() {
+					Routing(GET("/bottles/new"))
+					Response(NoContent)
+				})
+			})
+		})
+
+		It("reports an error", func() {
+			Ω(findings).Should(HaveLen(1))
+			Ω(findings[0].Rule).Should(Equal(genlint.RuleRouteConflict))
+			Ω(findings[0].Severity).Should(Equal(genlint.SeverityError))
+			Ω(findings[0].Message).Should(ContainSubstring("wildcard and static segments at the same position"))
+		})
+	})
+
+	Context("with missing descriptions", func() {
+		BeforeEach(func() {
+			API("test", nil)
+		})
+
+		JustBeforeEach(func() {
+			findings = genlint.Lint(Design)
+		})
+
+		It("reports the API", func() {
+			Ω(rules()).Should(Equal([]string{genlint.RuleDescription}))
+			Ω(findings[0].String()).Should(Equal(`warning: API "test": missing description [description]`))
+		})
+	})
+})
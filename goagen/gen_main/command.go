@@ -14,6 +14,13 @@ var (
 
 	// Force is true if pre-existing files should be overwritten during generation.
 	Force bool
+
+	// Diff is true if the scaffold of pre-existing files should be written to "<file>.new"
+	// together with a "<file>.diff" file describing the changes instead of being skipped.
+	Diff bool
+
+	// Compress is true if the generated main should mount the goa.Compress middleware.
+	Compress bool
 )
 
 // Command is the goa application code generator command line data structure.
@@ -31,6 +38,8 @@ func NewCommand() *Command {
 // RegisterFlags registers the command line flags with the given registry.
 func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
 	r.Flags().BoolVar(&Force, "force", false, "overwrite existing files")
+	r.Flags().BoolVar(&Diff, "diff", false, "write <file>.new and <file>.diff next to existing files whose scaffold changed")
+	r.Flags().BoolVar(&Compress, "compress", false, "mount the gzip/deflate response compression middleware in the generated main")
 	r.Flags().StringVar(&AppName, "name", "API", "application name")
 	if r.Flags().Lookup("pkg") == nil {
 		// Special case because the bootstrap command calls RegisterFlags on genapp which
@@ -42,6 +51,15 @@ func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
 // Run simply calls the meta generator.
 func (c *Command) Run() ([]string, error) {
 	flags := map[string]string{"name": AppName}
+	if Force {
+		flags["force"] = "true"
+	}
+	if Diff {
+		flags["diff"] = "true"
+	}
+	if Compress {
+		flags["compress"] = "true"
+	}
 	gen := meta.NewGenerator(
 		"genmain.Generate",
 		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_main")},
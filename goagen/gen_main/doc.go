@@ -5,5 +5,10 @@ bootstrap new applications.
 The generator creates a main.go file and one file per resource listed in the API metadata.
 If a file already exists it skips its creation unless the flag --force is provided on the command
 line in which case it overrides the content of existing files.
+With the flag --diff the generator instead writes the new scaffold of existing files next to them
+as "<file>.new" together with a "<file>.diff" file listing the changes, existing files are never
+modified. This makes it possible to merge changes made to the design into hand-edited files.
+With the flag --compress the generated main mounts the goa.Compress middleware which compresses
+the responses of the clients that accept gzip or deflate encoded bodies.
 */
 package genmain
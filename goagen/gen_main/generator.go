@@ -3,6 +3,7 @@ package genmain
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -61,33 +62,26 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		}
 	}()
 
-	mainFile := filepath.Join(codegen.OutputDir, "main.go")
-	if Force {
-		os.Remove(mainFile)
-	}
-	g.genfiles = append(g.genfiles, mainFile)
-	_, err = os.Stat(mainFile)
 	funcs := template.FuncMap{
 		"tempvar":              tempvar,
 		"generateSwagger":      generateSwagger,
 		"okResp":               okResp,
 		"newControllerVersion": newControllerVersion,
 		"targetPkg":            func() string { return TargetPackage },
+		"compress":             func() bool { return Compress },
 	}
-	if err != nil {
-		file, err := codegen.SourceFileFor(mainFile)
-		if err != nil {
-			return nil, err
-		}
-		var outPkg string
-		outPkg, err = codegen.PackagePath(codegen.OutputDir)
+	mainFile := filepath.Join(codegen.OutputDir, "main.go")
+	err = g.scaffold(mainFile, func(file *codegen.SourceFile) error {
+		outPkg, err := codegen.PackagePath(codegen.OutputDir)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		outPkg = strings.TrimPrefix(filepath.ToSlash(outPkg), "src/")
 		appPkg := path.Join(outPkg, "app")
 		swaggerPkg := path.Join(outPkg, "swagger")
 		imports := []*codegen.ImportSpec{
+			codegen.SimpleImport("flag"),
+			codegen.SimpleImport("time"),
 			codegen.SimpleImport("github.com/goadesign/goa"),
 			codegen.SimpleImport("github.com/goadesign/middleware"),
 			codegen.SimpleImport(appPkg),
@@ -97,17 +91,33 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 			jsonSchemaPkg := path.Join(outPkg, "schema")
 			imports = append(imports, codegen.SimpleImport(jsonSchemaPkg))
 		}
-		file.WriteHeader("", "main", imports)
-		data := map[string]interface{}{
-			"Name": AppName,
-			"API":  api,
+		if api.Operations != nil {
+			imports = append(imports, codegen.SimpleImport("github.com/goadesign/goa/async"))
+		}
+		if len(mutualTLSSchemes(api)) > 0 {
+			imports = append(imports, codegen.SimpleImport("github.com/goadesign/goa/security"))
+		}
+		if usesSessions(api) {
+			imports = append(imports, codegen.SimpleImport("os"))
 		}
-		if err = file.ExecuteTemplate("main", mainT, funcs, data); err != nil {
-			return nil, err
+		for _, r := range api.Resources {
+			if r.IsSubscribable() {
+				imports = append(imports, codegen.SimpleImport("github.com/goadesign/goa/subscription"))
+				break
+			}
 		}
-		if err = file.FormatCode(); err != nil {
-			return nil, err
+		file.WriteHeader("", "main", imports)
+		data := map[string]interface{}{
+			"Name":      AppName,
+			"API":       api,
+			"Server":    serverConfig(api),
+			"MutualTLS": mutualTLSSchemes(api),
+			"Sessions":  usesSessions(api),
 		}
+		return file.ExecuteTemplate("scaffoldMain", mainT, funcs, data)
+	})
+	if err != nil {
+		return
 	}
 	imp, err := codegen.PackagePath(codegen.OutputDir)
 	if err != nil {
@@ -127,27 +137,10 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 	})
 	err = api.IterateResources(func(r *design.ResourceDefinition) error {
 		filename := filepath.Join(codegen.OutputDir, snakeCase(r.Name)+".go")
-		if Force {
-			if err := os.Remove(filename); err != nil {
-				return err
-			}
-		}
-		g.genfiles = append(g.genfiles, filename)
-		if _, err := os.Stat(filename); err != nil {
-			file, err := codegen.SourceFileFor(filename)
-			if err != nil {
-				return err
-			}
+		return g.scaffold(filename, func(file *codegen.SourceFile) error {
 			file.WriteHeader("", "main", imports)
-			err = file.ExecuteTemplate("controller", ctrlT, funcs, r)
-			if err != nil {
-				return err
-			}
-			if err := file.FormatCode(); err != nil {
-				return err
-			}
-		}
-		return nil
+			return file.ExecuteTemplate("scaffoldController", ctrlT, funcs, r)
+		})
 	})
 	if err != nil {
 		return
@@ -156,6 +149,60 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 	return g.genfiles, nil
 }
 
+// scaffold renders the file with the given name unless it already exists and Force is false so
+// that user changes are never overwritten. If Diff is true the scaffold of an existing file is
+// rendered to "<filename>.new" instead and the differences with the existing file are written to
+// "<filename>.diff". Both files are removed if the scaffold did not change. Only the files actually
+// written are recorded so that Cleanup never deletes user files.
+func (g *Generator) scaffold(filename string, render func(*codegen.SourceFile) error) error {
+	target := filename
+	if _, err := os.Stat(filename); err == nil {
+		switch {
+		case Force:
+			if err := os.Remove(filename); err != nil {
+				return err
+			}
+		case Diff:
+			target = filename + ".new"
+			os.Remove(target)
+		default:
+			return nil
+		}
+	}
+	file, err := codegen.SourceFileFor(target)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, target)
+	if err := render(file); err != nil {
+		return err
+	}
+	if err := file.FormatCode(); err != nil {
+		return err
+	}
+	if target == filename {
+		return nil
+	}
+	current, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	scaffold, err := ioutil.ReadFile(target)
+	if err != nil {
+		return err
+	}
+	diffFile := filename + ".diff"
+	diff := codegen.UnifiedDiff(filename, target, string(current), string(scaffold))
+	if diff == "" {
+		os.Remove(target)
+		os.Remove(diffFile)
+		g.genfiles = g.genfiles[:len(g.genfiles)-1]
+		return nil
+	}
+	g.genfiles = append(g.genfiles, diffFile)
+	return ioutil.WriteFile(diffFile, []byte(diff), 0644)
+}
+
 // Cleanup removes all the files generated by this generator during the last invokation of Generate.
 func (g *Generator) Cleanup() {
 	for _, f := range g.genfiles {
@@ -213,13 +260,73 @@ func okResp(a *design.ActionDefinition, v string) map[string]interface{} {
 	if strings.HasPrefix(typeref, "*") {
 		typeref = "&" + typeref[1:]
 	}
+	var status string
+	if ok.StatusRange != 0 {
+		status = fmt.Sprintf("%d, ", ok.Status)
+	}
 	return map[string]interface{}{
 		"Name":    ok.Name,
 		"GoType":  codegen.GoNativeType(mt),
 		"TypeRef": typeref,
+		"Status":  status,
 	}
 }
 
+// serverConfig returns the data needed to render the configuration of the listeners serving the
+// API schemes. It returns nil if the API is only served using HTTP/1.1 without TLS in which case
+// the generated main function uses the default listener.
+func serverConfig(api *design.APIDefinition) map[string]interface{} {
+	var tls, custom bool
+	for _, s := range api.Schemes {
+		switch s {
+		case "https", "wss", "h3":
+			tls, custom = true, true
+		case "h2c":
+			custom = true
+		}
+	}
+	if !custom {
+		return nil
+	}
+	var clientCA string
+	if schemes := mutualTLSSchemes(api); tls && len(schemes) > 0 {
+		clientCA = schemes[0].ClientCA
+	}
+	return map[string]interface{}{
+		"Schemes":  api.Schemes,
+		"TLS":      tls,
+		"ClientCA": clientCA,
+	}
+}
+
+// usesSessions returns true if the API or one of its resources defines a session. The generated
+// main function configures the keys of the session cookies.
+func usesSessions(api *design.APIDefinition) bool {
+	if api.Session != nil {
+		return true
+	}
+	for _, r := range api.Resources {
+		if r.Session != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// mutualTLSSchemes returns the mutual TLS security schemes of the API. The generated main
+// function mounts their middleware and configures the TLS listeners to verify the client
+// certificates issued by the authorities of the first scheme.
+func mutualTLSSchemes(api *design.APIDefinition) []*design.SecuritySchemeDefinition {
+	var schemes []*design.SecuritySchemeDefinition
+	api.IterateSecuritySchemes(func(s *design.SecuritySchemeDefinition) error {
+		if s.Kind == design.MutualTLSSecurityKind {
+			schemes = append(schemes, s)
+		}
+		return nil
+	})
+	return schemes
+}
+
 // snakeCase produces the snake_case version of the given CamelCase string.
 func snakeCase(name string) string {
 	var b bytes.Buffer
@@ -247,49 +354,91 @@ func snakeCase(name string) string {
 
 const mainT = `
 func main() {
-	// Create service
+{{with .Server}}{{if .TLS}}	// Parse the paths to the TLS certificate and key files
+	cert := flag.String("cert", "cert.pem", "path to the TLS certificate file")
+	key := flag.String("key", "key.pem", "path to the TLS private key file")
+	flag.Parse()
+
+{{end}}{{end}}	// Create service
 	service := goa.New("{{.Name}}")
 
 	// Setup middleware
 	service.Use(middleware.RequestID())
 	service.Use(middleware.LogRequest(true))
 	service.Use(middleware.Recover())
-{{$api := .API}}
+{{if compress}}	service.Use(goa.Compress(nil))
+{{end}}{{range .MutualTLS}}{{$name := goify .SchemeName true}}	// Authenticate the requests secured by the "{{.SchemeName}}" scheme with the client certificates
+	{{targetPkg}}.Use{{$name}}Middleware(service, security.MutualTLS({{targetPkg}}.New{{$name}}Security()))
+{{end}}{{$api := .API}}{{if $api.ProblemResponses}}
+	// Render errors as RFC 7807 problem details documents
+	service.ErrorHandler = goa.ProblemErrorHandler
+{{end}}{{if .Sessions}}
+	// Sign the session cookies with the key given by the SESSION_KEY environment variable
+	service.SetSessionConfig(&goa.SessionConfig{
+		Keys:   [][]byte{[]byte(os.Getenv("SESSION_KEY"))},
+		Secure: {{if .Server}}{{.Server.TLS}}{{else}}false{{end}},
+	})
+{{end}}
 {{range $name, $res := $api.Resources}}{{if $res.SupportsNoVersion}}{{$name := goify $res.Name true}}	// Mount "{{$res.Name}}" controller
-	{{$tmp := tempvar}}{{$tmp}} := New{{$name}}Controller(service)
-	{{targetPkg}}.Mount{{$name}}Controller(service, {{$tmp}})
+	{{$tmp := tempvar}}{{$tmp}} := New{{controllerName $name}}(service)
+	{{targetPkg}}.Mount{{controllerName $name}}(service, {{$tmp}})
 {{end}}{{end}}{{range $ver, $prop := $api.APIVersions}}
 	// Version {{$ver}}
 {{range $name, $res := $api.Resources}}{{if $res.SupportsVersion $ver}}{{$name := goify (printf "%s%s" $res.Name (or (and $ver (goify (versionPkg $ver) true)) "")) true}}	// Mount "{{$res.Name}}" controller
-	{{$tmp := tempvar}}{{$tmp}} := New{{$name}}Controller(service)
-	{{versionPkg $ver}}.Mount{{goify $res.Name true}}Controller(service, {{$tmp}})
+	{{$tmp := tempvar}}{{$tmp}} := New{{controllerName $name}}(service)
+	{{versionPkg $ver}}.Mount{{controllerName $res.Name}}(service, {{$tmp}})
 {{end}}{{end}}
-{{end}}{{if generateSwagger}}// Mount Swagger spec provider controller
+{{end}}{{if $api.Batch}}	// Mount batch endpoint
+	{{targetPkg}}.MountBatch(service)
+{{end}}{{if $api.Operations}}	// Mount operations endpoints, the returned executor runs the jobs of async actions
+	{{targetPkg}}.MountOperations(service, async.NewMemoryStore())
+{{end}}{{if $api.HasQuotas}}	// Mount quota status endpoint
+	{{targetPkg}}.MountQuotaStatus(service)
+{{end}}{{range $name, $res := $api.Resources}}{{if $res.IsSubscribable}}	// Mount "{{$res.Name}}" subscription endpoints, the returned manager publishes the events
+	{{targetPkg}}.Mount{{goify $res.Name true}}Subscriptions(service, subscription.NewMemoryStore())
+{{end}}{{end}}{{if generateSwagger}}// Mount Swagger spec provider controller
 	swagger.MountController(service)
 {{end}}
-	// Start service, listen on port 8080
-	service.ListenAndServe(":8080")
-}
+{{with .Server}}	// Start service, serve the API schemes and shutdown gracefully on SIGINT or SIGTERM
+	service.Serve(&goa.ServerConfig{
+		Schemes:  []string{ {{range $i, $s := .Schemes}}{{if $i}}, {{end}}"{{$s}}"{{end}} },
+		Addr:     ":8080",
+{{if .TLS}}		TLSAddr:  ":8443",
+		CertFile: *cert,
+		KeyFile:  *key,
+{{if .ClientCA}}		ClientCAFile: "{{.ClientCA}}",
+{{end}}{{end}}
+		// Give in-flight requests up to 30 seconds to complete on shutdown
+		ShutdownTimeout: 30 * time.Second,
+	})
+{{else}}	// Start service, listen on port 8080 and shutdown gracefully on SIGINT or SIGTERM
+	service.Serve(&goa.ServerConfig{
+		Addr: ":8080",
+
+		// Give in-flight requests up to 30 seconds to complete on shutdown
+		ShutdownTimeout: 30 * time.Second,
+	})
+{{end}}}
 `
 const ctrlT = `{{define "OneVersion"}}` + ctrlVerT + `{{end}}` + `{{$ctrl := .}}{{/*
 */}}{{if .APIVersions}}{{range $ver := .APIVersions}}{{template "OneVersion" (newControllerVersion $ctrl $ver)}}
 {{end}}{{else}}{{template "OneVersion" (newControllerVersion $ctrl "")}}
 {{end}}`
 
-const ctrlVerT = `// {{$ctrlName := printf "%s%s" (goify (printf "%s%s" .Controller.Name (or (and .Version (goify (versionPkg .Version) true)) ""))  true) "Controller"}}{{$ctrlName}} implements the{{if .Version}} {{.Version}} {{end}}{{.Controller.Name}} resource.
+const ctrlVerT = `// {{$ctrlName := controllerName (printf "%s%s" .Controller.Name (or (and .Version (goify (versionPkg .Version) true)) ""))}}{{$ctrlName}} implements the{{if .Version}} {{.Version}} {{end}}{{.Controller.Name}} resource.
 type {{$ctrlName}} struct {
 	*goa.Controller
 }
 
 // New{{$ctrlName}} creates a {{.Controller.Name}} controller.
-func New{{$ctrlName}}(service *goa.Service) {{if .Version}}{{versionPkg .Version}}{{else}}{{targetPkg}}{{end}}.{{goify .Controller.Name true}}Controller {
+func New{{$ctrlName}}(service *goa.Service) {{if .Version}}{{versionPkg .Version}}{{else}}{{targetPkg}}{{end}}.{{controllerName .Controller.Name}} {
 	return &{{$ctrlName}}{Controller: service.NewController("{{.Controller.Name}}{{if .Version}} {{.Version}}{{end}}")}
 }
-{{$ctrl := .Controller}}{{$version := .Version}}{{range .Controller.Actions}}
+{{$ctrl := .Controller}}{{$version := .Version}}{{range .Controller.Actions}}{{if not .IsProxy}}
 // {{goify .Name true}} runs the {{.Name}} action.
-func (c *{{$ctrlName}}) {{goify .Name true}}(ctx *{{if $version}}{{versionPkg $version}}{{else}}{{targetPkg}}{{end}}.{{goify .Name true}}{{goify $ctrl.Name true}}Context) error {
+func (c *{{$ctrlName}}) {{goify .Name true}}(ctx *{{if $version}}{{versionPkg $version}}{{else}}{{targetPkg}}{{end}}.{{contextName .Name $ctrl.Name}}) error {
 {{$ok := okResp . $version}}{{if $ok}}	res := {{$ok.TypeRef}}{}
-{{end}}	return {{if $ok}}ctx.{{$ok.Name}}(res){{else}}nil{{end}}
+{{end}}	return {{if $ok}}ctx.{{$ok.Name}}({{$ok.Status}}res){{else}}nil{{end}}
 }
-{{end}}
+{{end}}{{end}}
 `
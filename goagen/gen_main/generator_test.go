@@ -63,4 +63,151 @@ var _ = Describe("Generate", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 		})
 	})
+
+	Context("with the default scheme", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{
+					Name: "test api",
+				},
+			}
+		})
+
+		It("shuts down gracefully", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("service.Serve(&goa.ServerConfig{"))
+			Ω(string(content)).Should(ContainSubstring(`Addr: ":8080",`))
+			Ω(string(content)).Should(ContainSubstring("ShutdownTimeout: 30 * time.Second,"))
+			Ω(string(content)).ShouldNot(ContainSubstring("ListenAndServe"))
+			Ω(string(content)).ShouldNot(ContainSubstring("flag.Parse()"))
+		})
+	})
+
+	Context("with h2c and h3 schemes", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{
+					Name:    "test api",
+					Schemes: []string{"h2c", "h3"},
+				},
+			}
+		})
+
+		It("serves the schemes", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("service.Serve(&goa.ServerConfig{"))
+			Ω(string(content)).Should(ContainSubstring(`Schemes:  []string{"h2c", "h3"},`))
+			Ω(string(content)).Should(ContainSubstring(`cert := flag.String("cert", "cert.pem", "path to the TLS certificate file")`))
+			Ω(string(content)).Should(ContainSubstring(`key := flag.String("key", "key.pem", "path to the TLS private key file")`))
+			Ω(string(content)).Should(ContainSubstring(`CertFile: *cert,`))
+			Ω(string(content)).ShouldNot(ContainSubstring("ListenAndServe"))
+		})
+	})
+
+	Context("with a mutual TLS security scheme", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{
+					Name:    "test api",
+					Schemes: []string{"https"},
+				},
+				SecuritySchemes: map[string]*design.SecuritySchemeDefinition{
+					"partners": {Kind: design.MutualTLSSecurityKind, SchemeName: "partners", ClientCA: "ca.pem"},
+				},
+			}
+		})
+
+		It("verifies the client certificates", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`ClientCAFile: "ca.pem",`))
+			Ω(string(content)).Should(ContainSubstring("app.UsePartnersMiddleware(service, security.MutualTLS(app.NewPartnersSecurity()))"))
+			Ω(string(content)).Should(ContainSubstring(`"github.com/goadesign/goa/security"`))
+		})
+	})
+
+	Context("with a session", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "test api"},
+				Session:              &design.SessionDefinition{},
+			}
+		})
+
+		It("configures the session keys", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(`Keys:   [][]byte{[]byte(os.Getenv("SESSION_KEY"))},`))
+			Ω(string(content)).Should(ContainSubstring(`Secure: false,`))
+		})
+	})
+
+	Context("with the compress flag", func() {
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "test api"},
+			}
+			os.Args = append(os.Args, "--compress")
+		})
+
+		AfterEach(func() {
+			genmain.Compress = false
+		})
+
+		It("mounts the compression middleware", func() {
+			Ω(genErr).Should(BeNil())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring("service.Use(goa.Compress(nil))"))
+		})
+	})
+
+	Context("with an existing main file", func() {
+		const userCode = "package main\n\n// user code\nfunc main() {}\n"
+
+		BeforeEach(func() {
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "test api"},
+			}
+			err := ioutil.WriteFile(filepath.Join(outDir, "main.go"), []byte(userCode), 0644)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			genmain.Diff = false
+		})
+
+		It("does not overwrite it", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(BeEmpty())
+			content, err := ioutil.ReadFile(filepath.Join(outDir, "main.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(Equal(userCode))
+		})
+
+		Context("with the diff flag", func() {
+			BeforeEach(func() {
+				os.Args = append(os.Args, "--diff")
+			})
+
+			It("writes the new scaffold and the diff", func() {
+				Ω(genErr).Should(BeNil())
+				mainFile := filepath.Join(outDir, "main.go")
+				Ω(files).Should(Equal([]string{mainFile + ".new", mainFile + ".diff"}))
+				content, err := ioutil.ReadFile(mainFile)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(Equal(userCode))
+				diff, err := ioutil.ReadFile(mainFile + ".diff")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(diff)).Should(ContainSubstring("-// user code"))
+				Ω(string(diff)).Should(ContainSubstring("+\tservice := goa.New("))
+			})
+		})
+	})
 })
@@ -0,0 +1,51 @@
+package genmock
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// AppPkg is the name of the generated application Go package.
+	AppPkg string
+
+	// TargetPackage is the name of the generated mock controllers Go package.
+	TargetPackage string
+
+	// Chaos is true if the mock controllers should support the injection of latency, errors
+	// and malformed responses configured with chaos profiles.
+	Chaos bool
+)
+
+// Command is the goa mock controllers generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("mock", "Generate mock controllers returning example data")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&AppPkg, "app-pkg", "app", "Name of the generated application Go package")
+	r.Flags().StringVar(&TargetPackage, "pkg", "mock", "Name of the generated mock controllers Go package")
+	r.Flags().BoolVar(&Chaos, "chaos", false, "Generate support for latency, error and malformed response injection profiles")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"app-pkg": AppPkg, "pkg": TargetPackage}
+	if Chaos {
+		flags["chaos"] = "true"
+	}
+	gen := meta.NewGenerator(
+		"genmock.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_mock")},
+		flags,
+	)
+	return gen.Generate()
+}
@@ -0,0 +1,24 @@
+/*
+Package genmock provides a generator for mock implementations of the controllers of a goa
+application. The generated controllers respond to each request with the first successful response
+defined in the design, using the examples produced by design.APIDefinition.GenerateExample as
+response bodies. Mounting the mock controllers makes it possible for clients to integrate against
+a realistic fake of the API before the real implementation exists:
+
+	service := goa.New("cellar")
+	app.MountBottleController(service, mock.NewBottleController(service))
+	service.ListenAndServe(":8080")
+
+The examples are deterministic: the random generator used to produce them is seeded after the API
+name so that generating the mocks twice produces the same data.
+
+The --chaos flag generates mock controllers that can also simulate an unreliable API. A chaos
+profile configures the latency distribution of the responses and, for each action, the rate of
+error responses (picked among the error responses the design defines for the action) and of
+malformed response bodies:
+
+	if err := mock.LoadChaosProfile("chaos.json"); err != nil {
+		log.Fatal(err)
+	}
+*/
+package genmock
@@ -0,0 +1,13 @@
+package genmock_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenMock(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenMock Suite")
+}
@@ -0,0 +1,579 @@
+package genmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the mock controllers code generator.
+type Generator struct {
+	genfiles []string
+}
+
+type (
+	// MockController contains the data needed to render the mock implementation of a single
+	// resource controller.
+	MockController struct {
+		// Name is the name of the mock controller struct, e.g. "BottleController".
+		Name string
+		// ResourceName is the name of the resource, e.g. "bottle".
+		ResourceName string
+		// Interface is the qualified name of the controller interface, e.g. "app.BottleController".
+		Interface string
+		// Actions lists the mock controller actions sorted by name.
+		Actions []*MockAction
+	}
+
+	// MockAction contains the data needed to render a single mock controller action.
+	MockAction struct {
+		// Name is the Go name of the controller action method, e.g. "Show".
+		Name string
+		// ActionName is the name of the action as defined in the design, e.g. "show".
+		ActionName string
+		// ContextType is the qualified name of the action context, e.g. "app.ShowBottleContext".
+		ContextType string
+		// MockResponse describes the successful response sent by the action.
+		*MockResponse
+		// Errors lists the error responses that may be injected when using chaos profiles,
+		// sorted by name.
+		Errors []*MockResponse
+	}
+
+	// MockResponse contains the data needed to render the code that sends a response.
+	MockResponse struct {
+		// Response is the Go name of the context method used to send the response, e.g. "OK".
+		Response string
+		// Status is the response HTTP status code.
+		Status int
+		// ContentType is the response Content-Type if known.
+		ContentType string
+		// ResponseType is the Go type reference of the response body if any.
+		ResponseType string
+		// Example is the JSON representation of the example response body if any.
+		Example string
+		// Raw is true if the response method accepts the raw response body bytes.
+		Raw bool
+		// Passthrough is true if the response method accepts a content type and a body reader.
+		Passthrough bool
+		// Ranged is true if the response covers a range of status codes in which case the
+		// response method accepts the status code as first argument.
+		Ranged bool
+	}
+)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "Mock controllers generator",
+		Long:  "mock controllers generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// MockDir returns the directory containing the generated mock controllers for the given version.
+func MockDir(version *design.APIVersionDefinition) string {
+	dir := filepath.Join(codegen.OutputDir, TargetPackage)
+	if !version.IsDefault() {
+		dir = filepath.Join(dir, codegen.VersionPackage(version.Version))
+	}
+	return dir
+}
+
+// Generate produces the mock controllers.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	appPath, err := codegen.PackagePath(filepath.Join(codegen.OutputDir, AppPkg))
+	if err != nil {
+		return nil, err
+	}
+	os.RemoveAll(filepath.Join(codegen.OutputDir, TargetPackage))
+	err = api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		dir := MockDir(v)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		g.genfiles = append(g.genfiles, dir)
+		if Chaos {
+			if err := g.generateChaos(dir, v); err != nil {
+				return err
+			}
+		}
+		return v.IterateResources(func(r *design.ResourceDefinition) error {
+			if !r.SupportsVersion(v.Version) {
+				return nil
+			}
+			return g.generateMock(dir, appPath, api, v, r)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.RemoveAll(f)
+	}
+	g.genfiles = nil
+}
+
+// generateMock generates the mock controller of the given resource.
+func (g *Generator) generateMock(dir, appPath string, api *design.APIDefinition, version *design.APIVersionDefinition, res *design.ResourceDefinition) error {
+	appPkg := AppPkg
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("encoding/json"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport(appPath),
+	}
+	pkg := TargetPackage
+	if !version.IsDefault() {
+		appPkg = codegen.VersionPackage(version.Version)
+		pkg = appPkg
+		imports = append(imports, codegen.SimpleImport(path.Join(appPath, appPkg)))
+	}
+	ctrl := &MockController{
+		Name:         codegen.ControllerName(res.Name),
+		ResourceName: res.Name,
+		Interface:    appPkg + "." + codegen.ControllerName(res.Name),
+	}
+	err := res.IterateActions(func(a *design.ActionDefinition) error {
+		action, err := mockAction(api, version, appPkg, a)
+		if err != nil {
+			return err
+		}
+		ctrl.Actions = append(ctrl.Actions, action)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(dir, strings.ToLower(codegen.Goify(res.Name, false))+".go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	title := fmt.Sprintf("%s: %s Mock Controller", version.Context(), res.Name)
+	if err := file.WriteHeader(title, pkg, imports); err != nil {
+		return err
+	}
+	data := map[string]interface{}{"Controller": ctrl, "Chaos": Chaos}
+	if err := file.ExecuteTemplate("mock", mockTmpl, template.FuncMap{"malformed": malformed}, data); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// generateChaos generates the chaos profile support code used by the mock controllers of the
+// given version.
+func (g *Generator) generateChaos(dir string, version *design.APIVersionDefinition) error {
+	pkg := TargetPackage
+	if !version.IsDefault() {
+		pkg = codegen.VersionPackage(version.Version)
+	}
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("encoding/json"),
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("io/ioutil"),
+		codegen.SimpleImport("math/rand"),
+		codegen.SimpleImport("sync"),
+		codegen.SimpleImport("time"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("golang.org/x/net/context"),
+	}
+	filename := filepath.Join(dir, "chaos.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	title := fmt.Sprintf("%s: Mock Controllers Chaos Profiles", version.Context())
+	if err := file.WriteHeader(title, pkg, imports); err != nil {
+		return err
+	}
+	if err := file.ExecuteTemplate("chaos", chaosTmpl, nil, nil); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// mockAction computes the data needed to render the mock implementation of the given action.
+// The mock sends the first successful response, using an example value as body.
+func mockAction(api *design.APIDefinition, version *design.APIVersionDefinition, appPkg string, a *design.ActionDefinition) (*MockAction, error) {
+	action := &MockAction{
+		Name:         codegen.Goify(a.Name, true),
+		ActionName:   a.Name,
+		ContextType:  appPkg + "." + codegen.ContextName(a.Name, a.Parent.Name),
+		MockResponse: &MockResponse{Status: 200},
+	}
+	if resps := codegen.SuccessResponses(a); len(resps) > 0 {
+		success, err := mockResponse(api, version, appPkg, a, resps[0])
+		if err != nil {
+			return nil, err
+		}
+		action.MockResponse = success
+	}
+	for _, resp := range errorResponses(a) {
+		mr, err := mockResponse(api, version, appPkg, a, resp)
+		if err != nil {
+			return nil, err
+		}
+		action.Errors = append(action.Errors, mr)
+	}
+	return action, nil
+}
+
+// mockResponse computes the data needed to render the code that sends the given response of the
+// given action, using an example value as body.
+func mockResponse(api *design.APIDefinition, version *design.APIVersionDefinition, appPkg string, a *design.ActionDefinition, resp *design.ResponseDefinition) (*MockResponse, error) {
+	mr := &MockResponse{
+		Response:    codegen.Goify(resp.Name, true),
+		Status:      resp.Status,
+		ContentType: resp.MediaType,
+		Ranged:      resp.StatusRange != 0,
+	}
+	var body design.DataType
+	if resp.MediaType == design.WildcardMediaType {
+		mr.Passthrough = true
+		mr.ContentType = ""
+		return mr, nil
+	} else if resp.Type != nil {
+		body = resp.Type
+	} else if mt := api.MediaTypeWithIdentifier(resp.MediaType); mt != nil {
+		projected, _, err := mt.Project("default")
+		if err != nil {
+			return nil, err
+		}
+		body = projected
+	} else if resp.MediaType != "" {
+		mr.Raw = true
+		return mr, nil
+	}
+	if body == nil {
+		return mr, nil
+	}
+	example, err := json.Marshal(api.GenerateExample(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate example for %s action of %s: %s", a.Name, a.Parent.Name, err)
+	}
+	if mr.ContentType == "" {
+		mr.ContentType = "application/json"
+	}
+	mr.ResponseType = codegen.AppTypeRef(body, version, AppPkg)
+	mr.Example = string(example)
+	return mr, nil
+}
+
+// errorResponses returns the 4xx and 5xx responses of the given action sorted by name.
+func errorResponses(a *design.ActionDefinition) []*design.ResponseDefinition {
+	var names []string
+	for n, resp := range a.Responses {
+		if resp.Status >= 400 {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	resps := make([]*design.ResponseDefinition, len(names))
+	for i, n := range names {
+		resps[i] = a.Responses[n]
+	}
+	return resps
+}
+
+// malformed returns a truncated version of the given example body that clients cannot decode.
+func malformed(example string) string {
+	if len(example) < 2 {
+		return "{"
+	}
+	return example[:len(example)/2]
+}
+
+// mockTmpl generates the mock implementation of a controller.
+// template input: map[string]interface{}
+const mockTmpl = `{{define "respond"}}{{if .Example}}	var res {{.ResponseType}}
+	if err := json.Unmarshal([]byte({{printf "%q" .Example}}), &res); err != nil {
+		return err
+	}
+	return ctx.{{.Response}}({{if .Ranged}}{{.Status}}, {{end}}res)
+{{else if .Passthrough}}	return ctx.{{.Response}}({{if .Ranged}}{{.Status}}, {{end}}"application/octet-stream", nil)
+{{else if .Raw}}	return ctx.{{.Response}}({{if .Ranged}}{{.Status}}, {{end}}nil)
+{{else if .Response}}	return ctx.{{.Response}}({{if .Ranged}}{{.Status}}{{end}})
+{{else}}	return nil
+{{end}}{{end}}{{$chaos := .Chaos}}{{with .Controller}}// {{.Name}} is a mock implementation of the {{.ResourceName}} resource controller that
+// responds with example data.
+type {{.Name}} struct {
+	*goa.Controller
+}
+
+// New{{.Name}} creates a mock {{.ResourceName}} controller.
+func New{{.Name}}(service *goa.Service) {{.Interface}} {
+	return &{{.Name}}{Controller: service.NewController("{{.ResourceName}}")}
+}
+{{$ctrl := .}}{{range .Actions}}
+// {{.Name}} runs the mock implementation of the {{.Name}} action.
+func (c *{{$ctrl.Name}}) {{.Name}}(ctx *{{.ContextType}}) error {
+{{if $chaos}}	fault, {{if .Errors}}i{{else}}_{{end}} := injectChaos(ctx, "{{$ctrl.ResourceName}}", "{{.ActionName}}", {{len .Errors}})
+	switch fault {
+{{if .Errors}}	case errorFault:
+		switch i {
+{{range $i, $e := .Errors}}		case {{$i}}:
+{{template "respond" $e}}{{end}}		}
+{{end}}	case malformedFault:
+		return sendMalformed(ctx.ResponseData, {{.Status}}, "{{.ContentType}}", {{printf "%q" (malformed .Example)}})
+	}
+{{end}}{{template "respond" .MockResponse}}}
+{{end}}{{end}}`
+
+// chaosTmpl generates the chaos profile support code shared by the mock controllers.
+const chaosTmpl = `
+type (
+	// ChaosProfile configures the latency, errors and malformed responses injected by the mock
+	// controllers. Chaos profiles are typically loaded from JSON files, e.g.:
+	//
+	//	{
+	//		"latency": {"distribution": "normal", "mean": "200ms", "stddev": "50ms"},
+	//		"actions": {
+	//			"*": {"error_rate": 0.01},
+	//			"bottle.show": {"error_rate": 0.2, "malformed_rate": 0.05}
+	//		}
+	//	}
+	ChaosProfile struct {
+		// Latency is the distribution of the delay added before each response.
+		Latency *LatencyProfile ` + "`" + `json:"latency,omitempty"` + "`" + `
+		// Actions configures the failures injected by each action indexed by
+		// "resource.action". The "*" entry applies to the actions that are not listed.
+		Actions map[string]*ActionChaos ` + "`" + `json:"actions,omitempty"` + "`" + `
+		// Seed seeds the random generator so that failures can be reproduced, the current
+		// time is used if zero.
+		Seed int64 ` + "`" + `json:"seed,omitempty"` + "`" + `
+	}
+
+	// ActionChaos configures the failures injected by a single action.
+	ActionChaos struct {
+		// Latency overrides the profile latency distribution if not nil.
+		Latency *LatencyProfile ` + "`" + `json:"latency,omitempty"` + "`" + `
+		// ErrorRate is the probability that the action sends one of the error responses
+		// defined in the design instead of the successful response.
+		ErrorRate float64 ` + "`" + `json:"error_rate,omitempty"` + "`" + `
+		// MalformedRate is the probability that the action sends a truncated body that
+		// cannot be decoded.
+		MalformedRate float64 ` + "`" + `json:"malformed_rate,omitempty"` + "`" + `
+	}
+
+	// LatencyProfile describes the distribution of the delay added before a response.
+	LatencyProfile struct {
+		// Distribution is one of "fixed", "uniform", "normal" or "exponential".
+		Distribution string ` + "`" + `json:"distribution"` + "`" + `
+		// Mean is the delay of the fixed distribution and the mean of the normal and
+		// exponential distributions.
+		Mean Duration ` + "`" + `json:"mean,omitempty"` + "`" + `
+		// StdDev is the standard deviation of the normal distribution.
+		StdDev Duration ` + "`" + `json:"stddev,omitempty"` + "`" + `
+		// Min is the minimum delay.
+		Min Duration ` + "`" + `json:"min,omitempty"` + "`" + `
+		// Max is the maximum delay, there is no maximum if zero except for the uniform
+		// distribution.
+		Max Duration ` + "`" + `json:"max,omitempty"` + "`" + `
+	}
+
+	// Duration is a time.Duration represented as a string such as "150ms" in JSON.
+	Duration time.Duration
+
+	// fault identifies the failure injected in a response.
+	fault int
+)
+
+const (
+	noFault fault = iota
+	errorFault
+	malformedFault
+)
+
+var (
+	chaosMu   sync.Mutex
+	chaos     *ChaosProfile
+	chaosRand *rand.Rand
+)
+
+// UseChaosProfile configures the mock controllers to inject the failures described by the given
+// profile. A nil profile disables failure injection.
+func UseChaosProfile(p *ChaosProfile) error {
+	if p != nil {
+		if err := p.Latency.validate(); err != nil {
+			return err
+		}
+		for name, a := range p.Actions {
+			if err := a.Latency.validate(); err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+			if a.ErrorRate < 0 || a.MalformedRate < 0 || a.ErrorRate+a.MalformedRate > 1 {
+				return fmt.Errorf("%s: error and malformed rates must be positive and add up to at most 1", name)
+			}
+		}
+	}
+	seed := time.Now().UnixNano()
+	if p != nil && p.Seed != 0 {
+		seed = p.Seed
+	}
+	chaosMu.Lock()
+	defer chaosMu.Unlock()
+	chaos = p
+	chaosRand = rand.New(rand.NewSource(seed))
+	return nil
+}
+
+// LoadChaosProfile reads the JSON chaos profile stored in the given file and uses it, see
+// UseChaosProfile.
+func LoadChaosProfile(filename string) error {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var p ChaosProfile
+	if err := json.Unmarshal(b, &p); err != nil {
+		return fmt.Errorf("invalid chaos profile %s: %s", filename, err)
+	}
+	return UseChaosProfile(&p)
+}
+
+// UnmarshalJSON parses durations such as "150ms".
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON renders durations as strings such as "150ms".
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// validate checks that the distribution is known and that the bounds are consistent.
+func (l *LatencyProfile) validate() error {
+	if l == nil {
+		return nil
+	}
+	switch l.Distribution {
+	case "fixed", "uniform", "normal", "exponential":
+	default:
+		return fmt.Errorf("unknown latency distribution %#v", l.Distribution)
+	}
+	if l.Max > 0 && l.Max < l.Min {
+		return fmt.Errorf("maximum latency %s is lower than minimum %s", time.Duration(l.Max), time.Duration(l.Min))
+	}
+	return nil
+}
+
+// delay draws a delay from the distribution.
+func (l *LatencyProfile) delay(r *rand.Rand) time.Duration {
+	if l == nil {
+		return 0
+	}
+	var d float64
+	switch l.Distribution {
+	case "uniform":
+		d = float64(l.Min) + r.Float64()*float64(l.Max-l.Min)
+	case "normal":
+		d = float64(l.Mean) + r.NormFloat64()*float64(l.StdDev)
+	case "exponential":
+		d = r.ExpFloat64() * float64(l.Mean)
+	default:
+		d = float64(l.Mean)
+	}
+	if d < float64(l.Min) {
+		d = float64(l.Min)
+	}
+	if l.Max > 0 && d > float64(l.Max) {
+		d = float64(l.Max)
+	}
+	return time.Duration(d)
+}
+
+// injectChaos waits for the delay drawn from the latency distribution of the given action and
+// returns the failure to inject if any. The second return value is the index of the error
+// response to send given the number of error responses defined by the action.
+func injectChaos(ctx context.Context, resource, action string, errors int) (fault, int) {
+	chaosMu.Lock()
+	p := chaos
+	if p == nil {
+		chaosMu.Unlock()
+		return noFault, 0
+	}
+	a, ok := p.Actions[resource+"."+action]
+	if !ok {
+		a = p.Actions["*"]
+	}
+	latency := p.Latency
+	if a != nil && a.Latency != nil {
+		latency = a.Latency
+	}
+	delay := latency.delay(chaosRand)
+	f, i := noFault, 0
+	if a != nil {
+		r := chaosRand.Float64()
+		if r < a.ErrorRate {
+			if errors > 0 {
+				f, i = errorFault, chaosRand.Intn(errors)
+			}
+		} else if r < a.ErrorRate+a.MalformedRate {
+			f = malformedFault
+		}
+	}
+	chaosMu.Unlock()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+	return f, i
+}
+
+// sendMalformed sends a response with the given status code whose body cannot be decoded.
+func sendMalformed(rw *goa.ResponseData, status int, contentType, body string) error {
+	if contentType != "" {
+		rw.Header().Set("Content-Type", contentType)
+	}
+	rw.WriteHeader(status)
+	_, err := rw.Write([]byte(body))
+	return err
+}
+`
@@ -0,0 +1,118 @@
+package genmock_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("mocktest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = genmock.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	Context("with an API with two actions", func() {
+		BeforeEach(func() {
+			attr := &design.AttributeDefinition{
+				Type: design.Object{"name": &design.AttributeDefinition{Type: design.String}},
+			}
+			bottle := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					AttributeDefinition: attr,
+					TypeName:            "Bottle",
+				},
+				Identifier: "application/vnd.bottle+json",
+				Views: map[string]*design.ViewDefinition{
+					"default": {Name: "default", AttributeDefinition: attr},
+				},
+			}
+			res := &design.ResourceDefinition{Name: "bottle", BasePath: "/bottles"}
+			show := &design.ActionDefinition{
+				Name:   "show",
+				Parent: res,
+				Responses: map[string]*design.ResponseDefinition{
+					"OK":       {Name: "OK", Status: 200, MediaType: bottle.Identifier},
+					"NotFound": {Name: "NotFound", Status: 404},
+				},
+			}
+			bottle.Views["default"].Parent = bottle
+			del := &design.ActionDefinition{
+				Name:   "delete",
+				Parent: res,
+				Responses: map[string]*design.ResponseDefinition{
+					"NoContent": {Name: "NoContent", Status: 204},
+				},
+			}
+			res.Actions = map[string]*design.ActionDefinition{"show": show, "delete": del}
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "cellar"},
+				Resources:            map[string]*design.ResourceDefinition{"bottle": res},
+				MediaTypes:           map[string]*design.MediaTypeDefinition{bottle.Identifier: bottle},
+			}
+		})
+
+		It("generates the mock controller", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(HaveLen(2))
+			content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "mock", "bottle.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(
+				"func NewBottleController(service *goa.Service) app.BottleController {"))
+			Ω(string(content)).Should(ContainSubstring(
+				"func (c *BottleController) Show(ctx *app.ShowBottleContext) error {"))
+			Ω(string(content)).Should(ContainSubstring("var res *app.Bottle"))
+			Ω(string(content)).Should(ContainSubstring(`json.Unmarshal([]byte("{\"name\":`))
+			Ω(string(content)).Should(ContainSubstring("return ctx.OK(res)"))
+			Ω(string(content)).Should(ContainSubstring("return ctx.NoContent()"))
+			Ω(string(content)).ShouldNot(ContainSubstring("injectChaos"))
+		})
+
+		Context("with chaos profiles", func() {
+			BeforeEach(func() {
+				os.Args = append(os.Args, "--chaos")
+			})
+
+			AfterEach(func() {
+				genmock.Chaos = false
+			})
+
+			It("generates the failure injection code", func() {
+				Ω(genErr).Should(BeNil())
+				Ω(files).Should(HaveLen(3))
+				chaos, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "mock", "chaos.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(chaos)).Should(ContainSubstring("func LoadChaosProfile(filename string) error {"))
+				content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "mock", "bottle.go"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(ContainSubstring(`fault, i := injectChaos(ctx, "bottle", "show", 1)`))
+				Ω(string(content)).Should(ContainSubstring("return ctx.NotFound()"))
+				Ω(string(content)).Should(ContainSubstring(`return sendMalformed(ctx.ResponseData, 200, "application/vnd.bottle+json", "{\"name`))
+				Ω(string(content)).Should(ContainSubstring(`fault, _ := injectChaos(ctx, "bottle", "delete", 0)`))
+			})
+		})
+	})
+})
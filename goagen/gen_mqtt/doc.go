@@ -0,0 +1,20 @@
+/*
+Package genmqtt provides a generator for a MQTT transport of the API. Actions that subscribe to a
+topic with the Subscribe DSL are invoked by the messages published on the topic and media types
+that define a topic with the Publish DSL get a typed publisher. The generator produces the routes of
+the subscribing actions together with the Go code that creates a goa.MQTTRouter dispatching the
+messages to the controllers mounted on the service:
+
+	service := goa.New("fleet")
+	app.MountDeviceController(service, NewDeviceController(service))
+	router := mqtt.NewRouter(service, client)
+	if err := router.Subscribe(); err != nil {
+		log.Fatal(err)
+	}
+
+where client is an adapter implementing goa.MQTTClient around the MQTT client library of choice.
+The generated package also defines the message types built from the action payloads and the
+published media types. Messages are encoded and decoded with the encoders and decoders registered
+on the service so that devices and HTTP clients share the same representations.
+*/
+package genmqtt
@@ -0,0 +1,13 @@
+package genmqtt_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenMQTT(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenMQTT Suite")
+}
@@ -0,0 +1,282 @@
+package genmqtt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the MQTT transport generator.
+type Generator struct {
+	genfiles []string
+	types    *codegen.TypeCollector
+}
+
+// Route contains the data needed to generate the route of an action that subscribes to a topic.
+type Route struct {
+	// Name is the Go name used to build the names of the generated route helpers, e.g.
+	// "CommandDevice".
+	Name string
+	// Topic is the subscription topic.
+	Topic *design.MQTTTopicDefinition
+	// Reply is the topic the action responses are published on if any.
+	Reply *design.MQTTTopicDefinition
+	// Version is the name of the API version defining the action, empty for the default version.
+	Version string
+	// Verb is the HTTP method of the action route.
+	Verb string
+	// Path is the full path of the action route.
+	Path string
+	// Message is the Go type name of the action payload, empty if the action has no payload.
+	Message string
+	// Wildcards lists the topic wildcards.
+	Wildcards []*Wildcard
+	// Action is the action invoked by the messages published on the topic.
+	Action *design.ActionDefinition
+}
+
+// Publication contains the data needed to generate the publisher of a media type.
+type Publication struct {
+	// Name is the Go type name of the media type.
+	Name string
+	// Topic is the topic instances of the media type are published on.
+	Topic *design.MQTTTopicDefinition
+	// Wildcards lists the topic wildcards.
+	Wildcards []*Wildcard
+}
+
+// Wildcard describes a topic wildcard.
+type Wildcard struct {
+	// Name is the wildcard name as it appears in the topic.
+	Name string
+	// VarName is the name of the corresponding publisher argument.
+	VarName string
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "MQTT transport generator",
+		Long:  "MQTT topic routes, typed messages and publishers generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// MQTTDir returns the path to the directory where the MQTT transport files are generated.
+func MQTTDir() string {
+	return filepath.Join(codegen.OutputDir, TargetPackage)
+}
+
+// Generate produces the message types, the publishers and the router Go code.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	os.RemoveAll(MQTTDir())
+	if err = os.MkdirAll(MQTTDir(), 0755); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, MQTTDir())
+	g.types = codegen.NewTypeCollector()
+	var routes []*Route
+	err = api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		return v.IterateResources(func(r *design.ResourceDefinition) error {
+			return r.IterateActions(func(a *design.ActionDefinition) error {
+				if !a.IsMQTT() || len(a.Routes) == 0 {
+					return nil
+				}
+				routes = append(routes, g.route(a, v))
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return
+	}
+	var pubs []*Publication
+	err = api.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		if mt.MQTTPublication == nil {
+			return nil
+		}
+		g.types.CollectUserType(mt.UserTypeDefinition)
+		pubs = append(pubs, &Publication{
+			Name:      codegen.GoTypeName(mt, nil, 0),
+			Topic:     mt.MQTTPublication,
+			Wildcards: wildcards(mt.MQTTPublication),
+		})
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	sort.Sort(byName(routes))
+	if err = g.generateTypes(api); err != nil {
+		return
+	}
+	if err = g.generateRouter(api, routes, pubs); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes the entire MQTT directory if it was created by this generator.
+func (g *Generator) Cleanup() {
+	if len(g.genfiles) == 0 {
+		return
+	}
+	os.RemoveAll(MQTTDir())
+	g.genfiles = nil
+}
+
+// route builds the MQTT route of the given action and records the types it uses.
+func (g *Generator) route(a *design.ActionDefinition, v *design.APIVersionDefinition) *Route {
+	route := a.Routes[0]
+	r := &Route{
+		Name:      codegen.Goify(a.Name, true) + codegen.Goify(a.Parent.Name, true),
+		Topic:     a.MQTTSubscription,
+		Reply:     a.MQTTPublication,
+		Verb:      route.Verb,
+		Path:      route.FullPath(v),
+		Wildcards: wildcards(a.MQTTSubscription),
+		Action:    a,
+	}
+	if !v.IsDefault() {
+		r.Version = v.Version
+		r.Name = codegen.Goify(codegen.VersionPackage(v.Version), true) + r.Name
+	}
+	if a.Payload != nil {
+		g.types.CollectUserType(a.Payload)
+		r.Message = codegen.GoTypeName(a.Payload, nil, 0)
+	}
+	return r
+}
+
+// generateTypes generates the message types.
+func (g *Generator) generateTypes(api *design.APIDefinition) error {
+	filename := filepath.Join(MQTTDir(), "types.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("time")}
+	title := fmt.Sprintf("%s: MQTT Message Types", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	types := g.types.Types()
+	if err := file.ExecuteTemplate("types", typesTmpl, nil, types); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// generateRouter generates the routes, the publishers and the code that creates the MQTT router.
+func (g *Generator) generateRouter(api *design.APIDefinition, routes []*Route, pubs []*Publication) error {
+	filename := filepath.Join(MQTTDir(), "router.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa")}
+	title := fmt.Sprintf("%s: MQTT Router", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	data := map[string]interface{}{"Routes": routes, "Publications": pubs}
+	if err := file.ExecuteTemplate("router", routerTmpl, nil, data); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// wildcards returns the wildcards of the given topic.
+func wildcards(t *design.MQTTTopicDefinition) []*Wildcard {
+	names := t.Wildcards()
+	ws := make([]*Wildcard, len(names))
+	for i, n := range names {
+		ws[i] = &Wildcard{Name: n, VarName: codegen.Goify(n, false)}
+	}
+	return ws
+}
+
+// byName makes it possible to sort routes by name.
+type byName []*Route
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
+
+const typesTmpl = `{{range .}}{{if .Description}}{{comment .Description}}{{else}}// {{.Name}} is a MQTT message type.{{end}}
+type {{.Name}} {{.Def}}
+
+{{end}}`
+
+const routerTmpl = `{{define "args"}}{{range .}}{{.VarName}} string, {{end}}{{end}}{{/*
+*/}}{{define "values"}}{{if .}}map[string]string{ {{range $i, $w := .}}{{if $i}}, {{end}}"{{$w.Name}}": {{$w.VarName}}{{end}} }{{else}}nil{{end}}{{end}}{{/*
+*/}}{{define "topic"}}&goa.MQTTTopic{Topic: "{{.Topic}}"{{if .QoS}}, QoS: {{.QoS}}{{end}}{{if .Retain}}, Retain: true{{end}}}{{end}}{{/*
+*/}}// Routes lists the MQTT routes, each route maps the topic an action subscribes to to the action
+// route.
+var Routes = []*goa.MQTTRoute{
+{{range .Routes}}	// {{.Action.Parent.Name}} {{.Action.Name}}
+	{Topic: "{{.Topic.Topic}}"{{if .Topic.QoS}}, QoS: {{.Topic.QoS}}{{end}}{{if .Version}}, Version: "{{.Version}}"{{end}}, Verb: "{{.Verb}}", Path: "{{.Path}}"{{if .Reply}}, Reply: {{template "topic" .Reply}}{{end}}},
+{{end}}}
+
+// NewRouter returns a MQTT router with the routes listed in Routes. The router dispatches the
+// messages published on the route topics to the controllers mounted on the service so that the
+// same controllers handle both the HTTP requests and the MQTT messages.
+func NewRouter(service *goa.Service, client goa.MQTTClient) *goa.MQTTRouter {
+	r := goa.NewMQTTRouter(service, client)
+	for _, route := range Routes {
+		r.Handle(route)
+	}
+	return r
+}
+{{range .Routes}}{{if .Message}}
+// Publish{{.Name}} encodes msg with the service encoders and publishes it on the
+// {{.Topic.Topic}} topic subscribed to by {{.Action.Parent.Name}} {{.Action.Name}}.
+func Publish{{.Name}}(r *goa.MQTTRouter, {{template "args" .Wildcards}}msg *{{.Message}}) error {
+	return r.Publish({{template "topic" .Topic}}, {{template "values" .Wildcards}}, msg)
+}
+{{end}}{{end}}{{range .Publications}}
+// {{.Name}}Topic is the MQTT topic {{.Name}} messages are published on.
+var {{.Name}}Topic = {{template "topic" .Topic}}
+
+// Publish{{.Name}} encodes msg with the service encoders and publishes it on {{.Name}}Topic.
+func Publish{{.Name}}(r *goa.MQTTRouter, {{template "args" .Wildcards}}msg *{{.Name}}) error {
+	return r.Publish({{.Name}}Topic, {{template "values" .Wildcards}}, msg)
+}
+
+// Decode{{.Name}} decodes a {{.Name}} message with the service decoders.
+func Decode{{.Name}}(r *goa.MQTTRouter, payload []byte) (*{{.Name}}, error) {
+	var msg {{.Name}}
+	if err := r.Decode(payload, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+{{end}}`
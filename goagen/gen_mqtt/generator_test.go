@@ -0,0 +1,111 @@
+package genmqtt_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_mqtt"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var prevDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("mqtttest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+
+		telemetry := &design.MediaTypeDefinition{
+			UserTypeDefinition: &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"temperature": &design.AttributeDefinition{Type: design.Number},
+					},
+				},
+				TypeName: "Telemetry",
+			},
+			Identifier: "application/vnd.telemetry+json",
+		}
+		telemetry.MQTTPublication = &design.MQTTTopicDefinition{
+			Topic:  "devices/:deviceID/telemetry/*sensor",
+			QoS:    1,
+			Retain: true,
+			Parent: telemetry,
+		}
+		payload := &design.UserTypeDefinition{
+			AttributeDefinition: &design.AttributeDefinition{
+				Type: design.Object{
+					"name": &design.AttributeDefinition{Type: design.String},
+				},
+				Validation: &dslengine.ValidationDefinition{Required: []string{"name"}},
+			},
+			TypeName: "Command",
+		}
+		res := &design.ResourceDefinition{Name: "device", BasePath: "/devices"}
+		command := &design.ActionDefinition{
+			Name:   "command",
+			Parent: res,
+			Params: &design.AttributeDefinition{
+				Type: design.Object{
+					"deviceID": &design.AttributeDefinition{Type: design.String},
+				},
+			},
+			Payload: payload,
+		}
+		command.Routes = []*design.RouteDefinition{{Verb: "POST", Path: "/:deviceID/commands", Parent: command}}
+		command.MQTTSubscription = &design.MQTTTopicDefinition{Topic: "devices/:deviceID/commands", QoS: 2, Subscribe: true, Parent: command}
+		command.MQTTPublication = &design.MQTTTopicDefinition{Topic: "devices/:deviceID/replies", Parent: command}
+		list := &design.ActionDefinition{Name: "list", Parent: res}
+		list.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "", Parent: list}}
+		res.Actions = map[string]*design.ActionDefinition{"command": command, "list": list}
+		prevDesign = design.Design
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "fleet"},
+			Resources:            map[string]*design.ResourceDefinition{"device": res},
+			MediaTypes:           map[string]*design.MediaTypeDefinition{telemetry.Identifier: telemetry},
+		}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = genmqtt.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		design.Design = prevDesign
+		workspace.Delete()
+	})
+
+	It("generates the message types, the routes and the publishers", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(3))
+
+		types, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "mqtt", "types.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(types)).Should(ContainSubstring("type Command struct {"))
+		Ω(string(types)).Should(ContainSubstring("type Telemetry struct {"))
+
+		router, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "mqtt", "router.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(router)).Should(ContainSubstring(`{Topic: "devices/:deviceID/commands", QoS: 2, Verb: "POST", Path: "/devices/:deviceID/commands", Reply: &goa.MQTTTopic{Topic: "devices/:deviceID/replies"}},`))
+		Ω(string(router)).ShouldNot(ContainSubstring(`Path: "/devices"`))
+		Ω(string(router)).Should(ContainSubstring("func NewRouter(service *goa.Service, client goa.MQTTClient) *goa.MQTTRouter {"))
+		Ω(string(router)).Should(ContainSubstring("func PublishCommandDevice(r *goa.MQTTRouter, deviceID string, msg *Command) error {"))
+		Ω(string(router)).Should(ContainSubstring(`var TelemetryTopic = &goa.MQTTTopic{Topic: "devices/:deviceID/telemetry/*sensor", QoS: 1, Retain: true}`))
+		Ω(string(router)).Should(ContainSubstring("func PublishTelemetry(r *goa.MQTTRouter, deviceID string, sensor string, msg *Telemetry) error {"))
+		Ω(string(router)).Should(ContainSubstring(`return r.Publish(TelemetryTopic, map[string]string{"deviceID": deviceID, "sensor": sensor}, msg)`))
+		Ω(string(router)).Should(ContainSubstring("func DecodeTelemetry(r *goa.MQTTRouter, payload []byte) (*Telemetry, error) {"))
+	})
+})
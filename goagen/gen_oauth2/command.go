@@ -0,0 +1,53 @@
+package genoauth2
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// TargetPackage is the name of the generated Go package.
+	TargetPackage string
+
+	// Force is true if the pre-existing storage scaffold should be overwritten.
+	Force bool
+
+	// OIDC is true if the OpenID Connect discovery endpoint should be generated.
+	OIDC bool
+)
+
+// Command is the goa OAuth2 authorization server generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("oauth2", "Generate the authorization server of the OAuth2 schemes hosted by the API")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&TargetPackage, "pkg", "oauth2", "Name of the generated Go package")
+	r.Flags().BoolVar(&Force, "force", false, "overwrite the existing storage scaffold")
+	r.Flags().BoolVar(&OIDC, "oidc", false, "generate the OpenID Connect discovery endpoint")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"pkg": TargetPackage}
+	if Force {
+		flags["force"] = "true"
+	}
+	if OIDC {
+		flags["oidc"] = "true"
+	}
+	gen := meta.NewGenerator(
+		"genoauth2.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_oauth2")},
+		flags,
+	)
+	return gen.Generate()
+}
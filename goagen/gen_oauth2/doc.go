@@ -0,0 +1,36 @@
+/*
+Package genoauth2 provides a generator for the authorization server of the OAuth2 security schemes
+hosted by the API. A scheme is hosted by the API when its token or authorization URL is a path
+rather than an absolute URL:
+
+	var Cellar = OAuth2Security("cellar", func() {
+		AccessCodeFlow("/oauth2/authorize", "/oauth2/token")
+		Scope("api:read", "Read access")
+	})
+
+The generator produces three files in the target package:
+
+	provider.go      New<Scheme>Provider and Mount<Scheme>Provider for each hosted scheme
+	store.go         in-memory client and token storage, generated once and never overwritten
+	provider_test.go conformance tests exercising the endpoints of each hosted scheme
+
+The providers are security.OAuth2Provider values: the token endpoint accepts the grants of the
+scheme flow (authorization_code and refresh_token for the access code flow, password and
+refresh_token for the password flow, client_credentials for the application flow) and the
+authorize endpoint issues codes or tokens for the access code and implicit flows. The main
+function mounts the endpoints and validates the tokens issued with the OAuth2 middleware:
+
+	store := oauth2.NewMemoryStore()
+	provider := oauth2.NewCellarProvider(store, store)
+	provider.Authorize = consent // renders the login and consent page
+	oauth2.MountCellarProvider(service, provider)
+	app.UseCellarMiddleware(service, security.OAuth2(app.NewCellarSecurity(), provider.Validate))
+
+The --oidc flag also generates discovery.go which defines Mount<Scheme>Discovery for each hosted
+scheme. The function serves the OpenID Connect discovery document and the key set that relying
+parties use to verify the ID tokens issued by the service.
+
+The storage scaffold is meant for development: replace the MemoryStore with an implementation of
+the security.OAuth2ClientStore and security.OAuth2TokenStore interfaces backed by a database.
+*/
+package genoauth2
@@ -0,0 +1,13 @@
+package genoauth2_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenOAuth2(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenOAuth2 Suite")
+}
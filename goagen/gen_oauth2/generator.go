@@ -0,0 +1,504 @@
+package genoauth2
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the OAuth2 authorization server generator.
+type Generator struct {
+	genfiles []string
+}
+
+// Provider contains the data needed to generate the authorization server of a single OAuth2
+// security scheme.
+type Provider struct {
+	// Name is the Go name of the scheme, e.g. "Cellar".
+	Name string
+	// Scheme is the name of the scheme in the design.
+	Scheme string
+	// Flow is the scheme OAuth2 flow, e.g. "accessCode".
+	Flow string
+	// AuthorizePath is the path of the authorize endpoint, empty if the flow does not use it.
+	AuthorizePath string
+	// TokenPath is the path of the token endpoint, empty if the flow does not use it.
+	TokenPath string
+	// Scopes lists the names of the scheme scopes in alphabetical order.
+	Scopes []string
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "OAuth2 authorization server generator",
+		Long:  "OAuth2 authorization server generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// OAuth2Dir returns the path to the directory where the authorization server files are generated.
+func OAuth2Dir() string {
+	return filepath.Join(codegen.OutputDir, TargetPackage)
+}
+
+// Generate produces the providers, the storage scaffold and the conformance tests of the OAuth2
+// schemes hosted by the API.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	providers := Providers(api)
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("the API does not host any OAuth2 security scheme, the token or authorization URL of a hosted scheme must be a path")
+	}
+	if err = os.MkdirAll(OAuth2Dir(), 0755); err != nil {
+		return
+	}
+	title := fmt.Sprintf("%s: OAuth2 Authorization Server", api.Context())
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("github.com/goadesign/goa/security"),
+	}
+	err = g.render("provider.go", title, imports, "provider", providerT, providers)
+	if err != nil {
+		return
+	}
+	imports = []*codegen.ImportSpec{
+		codegen.SimpleImport("encoding/json"),
+		codegen.SimpleImport("errors"),
+		codegen.SimpleImport("net/http"),
+		codegen.SimpleImport("net/http/httptest"),
+		codegen.SimpleImport("net/url"),
+		codegen.SimpleImport("strings"),
+		codegen.SimpleImport("testing"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport("github.com/goadesign/goa/security"),
+		codegen.SimpleImport("golang.org/x/net/context"),
+	}
+	title = fmt.Sprintf("%s: OAuth2 Authorization Server Conformance Tests", api.Context())
+	err = g.render("provider_test.go", title, imports, "tests", testsT, providers)
+	if err != nil {
+		return
+	}
+	if OIDC {
+		imports = []*codegen.ImportSpec{
+			codegen.SimpleImport("github.com/goadesign/goa"),
+			codegen.SimpleImport("github.com/goadesign/goa/security"),
+		}
+		title = fmt.Sprintf("%s: OpenID Connect Discovery", api.Context())
+		err = g.render("discovery.go", title, imports, "discovery", discoveryT, providers)
+		if err != nil {
+			return
+		}
+	}
+	store := filepath.Join(OAuth2Dir(), "store.go")
+	if _, err := os.Stat(store); err == nil && !Force {
+		return g.genfiles, nil
+	}
+	imports = []*codegen.ImportSpec{
+		codegen.SimpleImport("sync"),
+		codegen.SimpleImport("github.com/goadesign/goa/security"),
+		codegen.SimpleImport("golang.org/x/net/context"),
+	}
+	if err = g.render("store.go", "", imports, "store", storeT, nil); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.Remove(f)
+	}
+	g.genfiles = nil
+}
+
+// Providers returns the data needed to generate the authorization server of each OAuth2 scheme
+// hosted by the API sorted by scheme name. A scheme is hosted by the API if its token or
+// authorization URL is a path.
+func Providers(api *design.APIDefinition) []*Provider {
+	var providers []*Provider
+	api.IterateSecuritySchemes(func(s *design.SecuritySchemeDefinition) error {
+		if s.Kind != design.OAuth2SecurityKind {
+			return nil
+		}
+		p := &Provider{
+			Name:   codegen.Goify(s.SchemeName, true),
+			Scheme: s.SchemeName,
+			Flow:   s.Flow,
+		}
+		for scope := range s.Scopes {
+			p.Scopes = append(p.Scopes, scope)
+		}
+		sort.Strings(p.Scopes)
+		switch s.Flow {
+		case design.OAuth2AccessCodeFlow:
+			p.AuthorizePath = hostedPath(s.AuthorizationURL)
+			p.TokenPath = hostedPath(s.TokenURL)
+		case design.OAuth2ImplicitFlow:
+			p.AuthorizePath = hostedPath(s.AuthorizationURL)
+		default:
+			p.TokenPath = hostedPath(s.TokenURL)
+		}
+		if p.AuthorizePath != "" || p.TokenPath != "" {
+			providers = append(providers, p)
+		}
+		return nil
+	})
+	return providers
+}
+
+// hostedPath returns the path of the given URL if it does not specify a host, the empty string
+// otherwise.
+func hostedPath(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host != "" || parsed.Path == "" {
+		return ""
+	}
+	return parsed.Path
+}
+
+// render generates the file with the given name in the target package.
+func (g *Generator) render(name, title string, imports []*codegen.ImportSpec, tmplName, tmpl string, data interface{}) error {
+	filename := filepath.Join(OAuth2Dir(), name)
+	os.Remove(filename)
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	if err := file.ExecuteTemplate(tmplName, tmpl, nil, data); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+const providerT = `{{range .}}// New{{.Name}}Provider returns the authorization server implementing the {{.Flow}} flow of the
+// {{printf "%q" .Scheme}} security scheme.{{if eq .Flow "password"}} Set the provider Authenticate field prior to mounting
+// it so that it checks the resource owner credentials.{{else if .AuthorizePath}} Set the provider Authorize field prior to mounting
+// it so that it obtains the resource owner consent.{{end}}
+func New{{.Name}}Provider(clients security.OAuth2ClientStore, tokens security.OAuth2TokenStore) *security.OAuth2Provider {
+	return &security.OAuth2Provider{
+		Flow:    {{printf "%q" .Flow}},
+		Clients: clients,
+		Tokens:  tokens,
+	}
+}
+
+// Mount{{.Name}}Provider mounts the endpoints of the {{printf "%q" .Scheme}} authorization server
+// on the service: {{if .AuthorizePath}}GET {{.AuthorizePath}}{{if .TokenPath}} and {{end}}{{end}}{{if .TokenPath}}POST {{.TokenPath}}{{end}}.
+func Mount{{.Name}}Provider(service *goa.Service, provider *security.OAuth2Provider) {
+	provider.Mount(service, {{printf "%q" .AuthorizePath}}, {{printf "%q" .TokenPath}})
+}
+{{end}}`
+
+const discoveryT = `{{range .}}// Mount{{.Name}}Discovery mounts the OpenID Connect discovery document of the {{printf "%q" .Scheme}}
+// authorization server and the key set used to verify the ID tokens it issues. issuer is the
+// absolute URL of the service without trailing slash, keys are the public keys indexed by key ID.
+func Mount{{.Name}}Discovery(service *goa.Service, issuer string, keys map[string]interface{}) error {
+	doc := &security.OIDCDiscovery{
+		Issuer:                           issuer,{{if .AuthorizePath}}
+		AuthorizationEndpoint:            issuer + {{printf "%q" .AuthorizePath}},{{end}}{{if .TokenPath}}
+		TokenEndpoint:                    issuer + {{printf "%q" .TokenPath}},{{end}}
+		JWKSURI:                          issuer + "/.well-known/jwks.json",{{if eq .Flow "accessCode"}}
+		ResponseTypesSupported:           []string{"code"},{{else if eq .Flow "implicit"}}
+		ResponseTypesSupported:           []string{"token"},{{end}}
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{"RS256", "ES256"},
+		ScopesSupported:                  []string{"openid"{{range .Scopes}}, {{printf "%q" .}}{{end}}},
+	}
+	return security.MountOIDCDiscovery(service, doc, keys)
+}
+{{end}}`
+
+const storeT = `// MemoryStore is an in-memory implementation of the security.OAuth2ClientStore and
+// security.OAuth2TokenStore interfaces. It is meant for development and tests, replace it with a
+// persistent store before deploying the service.
+type MemoryStore struct {
+	mu      sync.Mutex
+	clients map[string]*security.OAuth2Client
+	tokens  map[string]*security.OAuth2Token
+	refresh map[string]*security.OAuth2Token
+	codes   map[string]*security.OAuth2Code
+}
+
+// NewMemoryStore returns an empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		clients: make(map[string]*security.OAuth2Client),
+		tokens:  make(map[string]*security.OAuth2Token),
+		refresh: make(map[string]*security.OAuth2Token),
+		codes:   make(map[string]*security.OAuth2Code),
+	}
+}
+
+// AddClient registers the given client.
+func (s *MemoryStore) AddClient(c *security.OAuth2Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[c.ID] = c
+}
+
+// Client returns the client with the given ID, nil if there is none.
+func (s *MemoryStore) Client(ctx context.Context, id string) (*security.OAuth2Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clients[id], nil
+}
+
+// SaveToken persists the given token.
+func (s *MemoryStore) SaveToken(ctx context.Context, t *security.OAuth2Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[t.AccessToken] = t
+	if t.RefreshToken != "" {
+		s.refresh[t.RefreshToken] = t
+	}
+	return nil
+}
+
+// Token returns the token with the given access token value, nil if there is none.
+func (s *MemoryStore) Token(ctx context.Context, access string) (*security.OAuth2Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[access], nil
+}
+
+// RefreshToken returns the token with the given refresh token value, nil if there is none.
+func (s *MemoryStore) RefreshToken(ctx context.Context, refresh string) (*security.OAuth2Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refresh[refresh], nil
+}
+
+// RevokeToken deletes the given token.
+func (s *MemoryStore) RevokeToken(ctx context.Context, t *security.OAuth2Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, t.AccessToken)
+	delete(s.refresh, t.RefreshToken)
+	return nil
+}
+
+// SaveCode persists the given authorization code.
+func (s *MemoryStore) SaveCode(ctx context.Context, c *security.OAuth2Code) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[c.Code] = c
+	return nil
+}
+
+// ConsumeCode deletes and returns the authorization code with the given value, nil if there is
+// none.
+func (s *MemoryStore) ConsumeCode(ctx context.Context, code string) (*security.OAuth2Code, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.codes[code]
+	delete(s.codes, code)
+	return c, nil
+}
+`
+
+const testsT = `// testClient is the client registered with the authorization servers under test.
+var testClient = &security.OAuth2Client{
+	ID:           "client",
+	Secret:       "secret",
+	RedirectURIs: []string{"https://client.example.com/callback"},
+}
+
+// newTestService returns a service serving the given provider backed by a memory store.
+func newTestService(provider func(security.OAuth2ClientStore, security.OAuth2TokenStore) *security.OAuth2Provider,
+	mount func(*goa.Service, *security.OAuth2Provider)) (*goa.Service, *security.OAuth2Provider) {
+
+	store := NewMemoryStore()
+	store.AddClient(testClient)
+	p := provider(store, store)
+	p.Authenticate = func(ctx context.Context, username, password string) (string, error) {
+		if username != "user" || password != "password" {
+			return "", errors.New("invalid credentials")
+		}
+		return "user", nil
+	}
+	p.Authorize = func(ctx context.Context, rw http.ResponseWriter, req *http.Request, c *security.OAuth2Client, scopes []string) (string, error) {
+		return "user", nil
+	}
+	service := goa.New("test")
+	mount(service, p)
+	return service, p
+}
+
+// requestToken makes a token request authenticated with the test client ID and the given secret.
+func requestToken(t *testing.T, service *goa.Service, path, secret string, form url.Values) (int, map[string]interface{}) {
+	req, _ := http.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(testClient.ID, secret)
+	rw := httptest.NewRecorder()
+	service.Mux.ServeHTTP(rw, req)
+	if cc := rw.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("token response: invalid Cache-Control header %q", cc)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rw.Body.Bytes(), &body); err != nil {
+		t.Fatalf("token response: invalid body %q: %s", rw.Body.String(), err)
+	}
+	return rw.Code, body
+}
+
+// requestAuthorization makes an authorization request for the test client and returns the
+// redirection URL.
+func requestAuthorization(t *testing.T, service *goa.Service, path, responseType string) *url.URL {
+	params := url.Values{
+		"response_type": {responseType},
+		"client_id":     {testClient.ID},
+		"redirect_uri":  {testClient.RedirectURIs[0]},
+		"state":         {"xyz"},
+	}
+	req, _ := http.NewRequest("GET", path+"?"+params.Encode(), nil)
+	rw := httptest.NewRecorder()
+	service.Mux.ServeHTTP(rw, req)
+	if rw.Code != 302 {
+		t.Fatalf("authorize response: got status %d, expected 302", rw.Code)
+	}
+	u, err := url.Parse(rw.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("authorize response: invalid location: %s", err)
+	}
+	return u
+}
+
+// checkError checks that the token response is an error response with the given status and code.
+func checkError(t *testing.T, status int, body map[string]interface{}, expStatus int, expCode string) {
+	if status != expStatus || body["error"] != expCode {
+		t.Errorf("got status %d and error %v, expected status %d and error %q", status, body["error"], expStatus, expCode)
+	}
+}
+
+// checkToken checks that the token response is a successful response and returns the issued
+// access and refresh tokens.
+func checkToken(t *testing.T, p *security.OAuth2Provider, status int, body map[string]interface{}) (string, string) {
+	if status != 200 {
+		t.Fatalf("got status %d, expected 200, body: %v", status, body)
+	}
+	if body["token_type"] != "bearer" {
+		t.Errorf("got token type %v, expected \"bearer\"", body["token_type"])
+	}
+	access, _ := body["access_token"].(string)
+	if _, _, err := p.Validate(context.Background(), access); err != nil {
+		t.Errorf("issued access token %q is not valid: %s", access, err)
+	}
+	refresh, _ := body["refresh_token"].(string)
+	return access, refresh
+}
+{{range .}}{{if .TokenPath}}
+func Test{{.Name}}InvalidClient(t *testing.T) {
+	service, _ := newTestService(New{{.Name}}Provider, Mount{{.Name}}Provider)
+	status, body := requestToken(t, service, {{printf "%q" .TokenPath}}, "invalid", url.Values{"grant_type": {"client_credentials"}})
+	checkError(t, status, body, 401, "invalid_client")
+}
+
+func Test{{.Name}}UnsupportedGrantType(t *testing.T) {
+	service, _ := newTestService(New{{.Name}}Provider, Mount{{.Name}}Provider)
+	status, body := requestToken(t, service, {{printf "%q" .TokenPath}}, testClient.Secret, url.Values{"grant_type": {"unknown"}})
+	checkError(t, status, body, 400, "unsupported_grant_type")
+}
+{{end}}{{if and .AuthorizePath .TokenPath}}
+func Test{{.Name}}AuthorizationCodeGrant(t *testing.T) {
+	service, p := newTestService(New{{.Name}}Provider, Mount{{.Name}}Provider)
+	redirect := requestAuthorization(t, service, {{printf "%q" .AuthorizePath}}, "code")
+	code := redirect.Query().Get("code")
+	if code == "" || redirect.Query().Get("state") != "xyz" {
+		t.Fatalf("invalid redirection %s", redirect)
+	}
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {code}, "redirect_uri": {testClient.RedirectURIs[0]}}
+	status, body := requestToken(t, service, {{printf "%q" .TokenPath}}, testClient.Secret, form)
+	_, refresh := checkToken(t, p, status, body)
+	if refresh == "" {
+		t.Error("no refresh token issued")
+	}
+	status, body = requestToken(t, service, {{printf "%q" .TokenPath}}, testClient.Secret, form)
+	checkError(t, status, body, 400, "invalid_grant")
+	form = url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}}
+	status, body = requestToken(t, service, {{printf "%q" .TokenPath}}, testClient.Secret, form)
+	checkToken(t, p, status, body)
+	status, body = requestToken(t, service, {{printf "%q" .TokenPath}}, testClient.Secret, form)
+	checkError(t, status, body, 400, "invalid_grant")
+}
+{{end}}{{if .AuthorizePath}}
+func Test{{.Name}}AccessDenied(t *testing.T) {
+	service, p := newTestService(New{{.Name}}Provider, Mount{{.Name}}Provider)
+	p.Authorize = func(ctx context.Context, rw http.ResponseWriter, req *http.Request, c *security.OAuth2Client, scopes []string) (string, error) {
+		return "", security.ErrAccessDenied
+	}
+	redirect := requestAuthorization(t, service, {{printf "%q" .AuthorizePath}}, {{if eq .Flow "implicit"}}"token"{{else}}"code"{{end}})
+	params := redirect.Query(){{if eq .Flow "implicit"}}
+	params, _ = url.ParseQuery(redirect.Fragment){{end}}
+	if params.Get("error") != "access_denied" {
+		t.Errorf("invalid redirection %s", redirect)
+	}
+}
+{{end}}{{if eq .Flow "implicit"}}
+func Test{{.Name}}ImplicitGrant(t *testing.T) {
+	service, p := newTestService(New{{.Name}}Provider, Mount{{.Name}}Provider)
+	redirect := requestAuthorization(t, service, {{printf "%q" .AuthorizePath}}, "token")
+	params, _ := url.ParseQuery(redirect.Fragment)
+	if params.Get("token_type") != "bearer" || params.Get("state") != "xyz" {
+		t.Fatalf("invalid redirection %s", redirect)
+	}
+	if _, _, err := p.Validate(context.Background(), params.Get("access_token")); err != nil {
+		t.Errorf("issued access token is not valid: %s", err)
+	}
+}
+{{end}}{{if eq .Flow "password"}}
+func Test{{.Name}}PasswordGrant(t *testing.T) {
+	service, p := newTestService(New{{.Name}}Provider, Mount{{.Name}}Provider)
+	form := url.Values{"grant_type": {"password"}, "username": {"user"}, "password": {"password"}}
+	status, body := requestToken(t, service, {{printf "%q" .TokenPath}}, testClient.Secret, form)
+	_, refresh := checkToken(t, p, status, body)
+	if refresh == "" {
+		t.Error("no refresh token issued")
+	}
+	form = url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}}
+	status, body = requestToken(t, service, {{printf "%q" .TokenPath}}, testClient.Secret, form)
+	checkToken(t, p, status, body)
+	form = url.Values{"grant_type": {"password"}, "username": {"user"}, "password": {"invalid"}}
+	status, body = requestToken(t, service, {{printf "%q" .TokenPath}}, testClient.Secret, form)
+	checkError(t, status, body, 400, "invalid_grant")
+}
+{{end}}{{if eq .Flow "application"}}
+func Test{{.Name}}ClientCredentialsGrant(t *testing.T) {
+	service, p := newTestService(New{{.Name}}Provider, Mount{{.Name}}Provider)
+	status, body := requestToken(t, service, {{printf "%q" .TokenPath}}, testClient.Secret, url.Values{"grant_type": {"client_credentials"}})
+	if _, refresh := checkToken(t, p, status, body); refresh != "" {
+		t.Error("refresh token issued for client credentials grant")
+	}
+}
+{{end}}{{end}}`
@@ -0,0 +1,130 @@
+package genoauth2_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_oauth2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var schemes map[string]*design.SecuritySchemeDefinition
+	var prevDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("oauth2test")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+		schemes = map[string]*design.SecuritySchemeDefinition{
+			"cellar": {
+				Kind:             design.OAuth2SecurityKind,
+				SchemeName:       "cellar",
+				Flow:             design.OAuth2AccessCodeFlow,
+				AuthorizationURL: "/oauth2/authorize",
+				TokenURL:         "/oauth2/token",
+			},
+			"github": {
+				Kind:             design.OAuth2SecurityKind,
+				SchemeName:       "github",
+				Flow:             design.OAuth2AccessCodeFlow,
+				AuthorizationURL: "https://github.com/login/oauth/authorize",
+				TokenURL:         "https://github.com/login/oauth/access_token",
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		prevDesign = design.Design
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "cellar"},
+			SecuritySchemes:      schemes,
+		}
+		files, genErr = genoauth2.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		design.Design = prevDesign
+		workspace.Delete()
+	})
+
+	It("generates the providers of the hosted schemes, the store and the tests", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(3))
+
+		provider, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "oauth2", "provider.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(provider)).Should(ContainSubstring("func NewCellarProvider(clients security.OAuth2ClientStore, tokens security.OAuth2TokenStore) *security.OAuth2Provider {"))
+		Ω(string(provider)).Should(ContainSubstring(`Flow:    "accessCode",`))
+		Ω(string(provider)).Should(ContainSubstring(`provider.Mount(service, "/oauth2/authorize", "/oauth2/token")`))
+		Ω(string(provider)).ShouldNot(ContainSubstring("Github"))
+
+		store, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "oauth2", "store.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(store)).Should(ContainSubstring("func (s *MemoryStore) ConsumeCode(ctx context.Context, code string) (*security.OAuth2Code, error) {"))
+
+		tests, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "oauth2", "provider_test.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(tests)).Should(ContainSubstring("func TestCellarAuthorizationCodeGrant(t *testing.T) {"))
+		Ω(string(tests)).Should(ContainSubstring("func TestCellarAccessDenied(t *testing.T) {"))
+		Ω(string(tests)).Should(ContainSubstring("func TestCellarUnsupportedGrantType(t *testing.T) {"))
+		Ω(string(tests)).ShouldNot(ContainSubstring("TestCellarPasswordGrant"))
+	})
+
+	Context("with an existing store", func() {
+		var storeFile string
+
+		BeforeEach(func() {
+			storeFile = filepath.Join(testPkg.Abs(), "oauth2", "store.go")
+			Ω(os.MkdirAll(filepath.Dir(storeFile), 0755)).Should(Succeed())
+			Ω(ioutil.WriteFile(storeFile, []byte("package oauth2\n"), 0644)).Should(Succeed())
+		})
+
+		It("does not overwrite it", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(HaveLen(2))
+			store, err := ioutil.ReadFile(storeFile)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(store)).Should(Equal("package oauth2\n"))
+		})
+	})
+
+	Context("with the OIDC flag", func() {
+		BeforeEach(func() {
+			os.Args = append(os.Args, "--oidc")
+			schemes["cellar"].Scopes = map[string]string{"api:read": "Read access"}
+		})
+
+		It("generates the discovery endpoint", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(HaveLen(4))
+			discovery, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "oauth2", "discovery.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(discovery)).Should(ContainSubstring("func MountCellarDiscovery(service *goa.Service, issuer string, keys map[string]interface{}) error {"))
+			Ω(string(discovery)).Should(ContainSubstring(`TokenEndpoint:                    issuer + "/oauth2/token",`))
+			Ω(string(discovery)).Should(ContainSubstring(`ScopesSupported:                  []string{"openid", "api:read"},`))
+		})
+	})
+
+	Context("with no hosted scheme", func() {
+		BeforeEach(func() {
+			delete(schemes, "cellar")
+		})
+
+		It("returns an error", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(files).Should(BeEmpty())
+		})
+	})
+})
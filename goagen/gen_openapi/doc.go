@@ -0,0 +1,9 @@
+/*
+Package genopenapi provides a generator for the OpenAPI 3 specification of the API.
+The specification is served by the generated controller in response to GET /openapi.json requests.
+It describes the same operations as the Swagger specification generated by genswagger using the
+OpenAPI 3 constructs: servers, component schemas, request bodies, oneOf for the responses that may
+render alternate media types, callbacks for the outbound webhooks and links derived from the media
+type links. See https://spec.openapis.org/oas/v3.0.3 for more information.
+*/
+package genopenapi
@@ -0,0 +1,99 @@
+package genopenapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the OpenAPI code generator.
+type Generator struct{}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "OpenAPI generator",
+		Long:  "OpenAPI generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// Generate produces the OpenAPI specification and the controller that serves it.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	var genfiles []string
+
+	cleanup := func() {
+		for _, f := range genfiles {
+			os.Remove(f)
+		}
+	}
+
+	go utils.Catch(nil, cleanup)
+
+	defer func() {
+		if err != nil {
+			cleanup()
+		}
+	}()
+
+	if err = codegen.CheckFilters(api); err != nil {
+		return
+	}
+	doc, err := New(api)
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return
+	}
+	openapiDir := filepath.Join(codegen.OutputDir, "openapi")
+	os.RemoveAll(openapiDir)
+	if err = os.MkdirAll(openapiDir, 0755); err != nil {
+		return
+	}
+	genfiles = append(genfiles, openapiDir)
+	openapiFile := filepath.Join(openapiDir, "openapi.json")
+	err = ioutil.WriteFile(openapiFile, b, 0644)
+	if err != nil {
+		return
+	}
+	genfiles = append(genfiles, openapiFile)
+	controllerFile := filepath.Join(openapiDir, "openapi.go")
+	genfiles = append(genfiles, controllerFile)
+	file, err := codegen.SourceFileFor(controllerFile)
+	if err != nil {
+		return
+	}
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("github.com/goadesign/goa"),
+	}
+	file.WriteHeader(fmt.Sprintf("%s OpenAPI Spec", api.Name), "openapi", imports)
+	file.Write([]byte(openapi))
+	if err = file.FormatCode(); err != nil {
+		return
+	}
+
+	return genfiles, nil
+}
+
+const openapi = `
+// MountController mounts the OpenAPI spec controller under "/openapi.json".
+func MountController(service *goa.Service) {
+	service.ServeFiles("/openapi.json", "openapi/openapi.json")
+}
+`
@@ -0,0 +1,794 @@
+package genopenapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_schema"
+	"github.com/goadesign/goa/goagen/gen_swagger"
+)
+
+// Version is the version of the OpenAPI specification the generated documents comply with.
+const Version = "3.0.3"
+
+type (
+	// OpenAPI represents an OpenAPI 3 document.
+	// See https://spec.openapis.org/oas/v3.0.3
+	OpenAPI struct {
+		OpenAPI      string                   `json:"openapi"`
+		Info         *genswagger.Info         `json:"info"`
+		Servers      []*Server                `json:"servers,omitempty"`
+		Paths        map[string]*PathItem     `json:"paths"`
+		Components   *Components              `json:"components,omitempty"`
+		Security     []map[string][]string    `json:"security,omitempty"`
+		Tags         []*genswagger.Tag        `json:"tags,omitempty"`
+		ExternalDocs *genswagger.ExternalDocs `json:"externalDocs,omitempty"`
+	}
+
+	// Server describes a server hosting the API.
+	Server struct {
+		// URL of the server, it may contain variables in curly braces.
+		URL string `json:"url"`
+		// Description of the server.
+		Description string `json:"description,omitempty"`
+		// Variables describes the variables of the URL indexed by name.
+		Variables map[string]*ServerVariable `json:"variables,omitempty"`
+	}
+
+	// ServerVariable describes a variable of a server URL.
+	ServerVariable struct {
+		// Default is the value used when the client does not provide one.
+		Default string `json:"default"`
+		// Description of the variable.
+		Description string `json:"description,omitempty"`
+	}
+
+	// Components holds the reusable objects referenced by the document.
+	Components struct {
+		// Schemas contains the schemas of the API types and media types indexed by name.
+		Schemas map[string]*genschema.JSONSchema `json:"schemas,omitempty"`
+		// Responses contains the API global responses indexed by name.
+		Responses map[string]*Response `json:"responses,omitempty"`
+		// Parameters contains the API base path parameters indexed by name.
+		Parameters map[string]*Parameter `json:"parameters,omitempty"`
+		// SecuritySchemes contains the API security schemes indexed by name.
+		SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+	}
+
+	// PathItem describes the operations available on a single path.
+	PathItem struct {
+		// Get defines a GET operation on this path.
+		Get *Operation `json:"get,omitempty"`
+		// Put defines a PUT operation on this path.
+		Put *Operation `json:"put,omitempty"`
+		// Post defines a POST operation on this path.
+		Post *Operation `json:"post,omitempty"`
+		// Delete defines a DELETE operation on this path.
+		Delete *Operation `json:"delete,omitempty"`
+		// Options defines a OPTIONS operation on this path.
+		Options *Operation `json:"options,omitempty"`
+		// Head defines a HEAD operation on this path.
+		Head *Operation `json:"head,omitempty"`
+		// Patch defines a PATCH operation on this path.
+		Patch *Operation `json:"patch,omitempty"`
+		// Parameters is the list of parameters that are applicable for all the operations
+		// described under this path.
+		Parameters []*Parameter `json:"parameters,omitempty"`
+	}
+
+	// Operation describes a single API operation on a path.
+	Operation struct {
+		// Tags is a list of tags for API documentation control.
+		Tags []string `json:"tags,omitempty"`
+		// Summary is a short summary of what the operation does.
+		Summary string `json:"summary,omitempty"`
+		// Description is a verbose explanation of the operation behavior.
+		Description string `json:"description,omitempty"`
+		// ExternalDocs points to additional external documentation for this operation.
+		ExternalDocs *genswagger.ExternalDocs `json:"externalDocs,omitempty"`
+		// OperationID is a unique string used to identify the operation.
+		OperationID string `json:"operationId,omitempty"`
+		// Parameters is a list of parameters that are applicable for this operation.
+		Parameters []*Parameter `json:"parameters,omitempty"`
+		// RequestBody describes the request body of the operation if any.
+		RequestBody *RequestBody `json:"requestBody,omitempty"`
+		// Responses is the list of possible responses indexed by status code.
+		Responses map[string]*Response `json:"responses"`
+		// Callbacks describes the outbound webhooks whose URLs are registered by the
+		// operation indexed by event name, then by URL expression.
+		Callbacks map[string]map[string]*PathItem `json:"callbacks,omitempty"`
+		// Deprecated declares this operation to be deprecated.
+		Deprecated bool `json:"deprecated,omitempty"`
+		// Security is a declaration of which security schemes are applied for this operation.
+		Security []map[string][]string `json:"security,omitempty"`
+		// WebSocket describes the WebSocket endpoint of the operation if any. This field is
+		// rendered as the "x-websocket" vendor extension.
+		WebSocket *genswagger.WebSocket `json:"x-websocket,omitempty"`
+		// RateLimit describes the rate limit that applies to the operation if any. This field
+		// is rendered as the "x-ratelimit" vendor extension.
+		RateLimit *genswagger.RateLimit `json:"x-ratelimit,omitempty"`
+	}
+
+	// Parameter describes a single operation parameter.
+	Parameter struct {
+		// Name of the parameter. Parameter names are case sensitive.
+		Name string `json:"name"`
+		// In is the location of the parameter, one of "query", "header", "path" or "cookie".
+		In string `json:"in"`
+		// Description is a brief description of the parameter.
+		Description string `json:"description,omitempty"`
+		// Required determines whether this parameter is mandatory.
+		Required bool `json:"required,omitempty"`
+		// Style describes how array values are serialized, e.g. "form" or "pipeDelimited".
+		Style string `json:"style,omitempty"`
+		// Explode is false if array values are serialized as a single parameter.
+		Explode *bool `json:"explode,omitempty"`
+		// Schema defines the type of the parameter.
+		Schema *genschema.JSONSchema `json:"schema,omitempty"`
+		// Sensitive is true if the parameter value is kept out of the logs. This field is
+		// rendered as the "x-sensitive" vendor extension.
+		Sensitive bool `json:"x-sensitive,omitempty"`
+	}
+
+	// RequestBody describes the body of the requests made to an operation.
+	RequestBody struct {
+		// Description of the request body.
+		Description string `json:"description,omitempty"`
+		// Content describes the body indexed by content type.
+		Content map[string]*MediaType `json:"content"`
+		// Required determines whether the body is mandatory.
+		Required bool `json:"required,omitempty"`
+	}
+
+	// MediaType describes a request or response body rendered with a given content type.
+	MediaType struct {
+		// Schema defines the type of the body.
+		Schema *genschema.JSONSchema `json:"schema,omitempty"`
+	}
+
+	// Response describes an operation response.
+	Response struct {
+		// Ref references a global API response.
+		// This field is exclusive with the other fields of Response.
+		Ref string `json:"$ref,omitempty"`
+		// Description of the response.
+		Description string `json:"description,omitempty"`
+		// Headers is a list of headers that are sent with the response.
+		Headers map[string]*Header `json:"headers,omitempty"`
+		// Content describes the response body indexed by content type.
+		Content map[string]*MediaType `json:"content,omitempty"`
+		// Links describes the operations that retrieve the resources linked by the response
+		// indexed by link name.
+		Links map[string]*Link `json:"links,omitempty"`
+	}
+
+	// Header describes a response header.
+	Header struct {
+		// Description is a brief description of the header.
+		Description string `json:"description,omitempty"`
+		// Schema defines the type of the header.
+		Schema *genschema.JSONSchema `json:"schema,omitempty"`
+	}
+
+	// Link describes how the values of a response can be used to make a request to another
+	// operation.
+	Link struct {
+		// OperationID is the ID of the linked operation.
+		OperationID string `json:"operationId"`
+		// Parameters maps the parameters of the linked operation to runtime expressions
+		// evaluated against the response.
+		Parameters map[string]string `json:"parameters,omitempty"`
+		// Description of the link.
+		Description string `json:"description,omitempty"`
+	}
+
+	// SecurityScheme describes a security scheme used by the operations.
+	SecurityScheme struct {
+		// Type of the security scheme: "apiKey", "http", "oauth2" or "openIdConnect".
+		Type string `json:"type"`
+		// Description of the security scheme.
+		Description string `json:"description,omitempty"`
+		// Name of the header or query parameter holding the key of "apiKey" schemes.
+		Name string `json:"name,omitempty"`
+		// In is the location of the key of "apiKey" schemes, "query" or "header".
+		In string `json:"in,omitempty"`
+		// Scheme is the HTTP authorization scheme of "http" schemes, e.g. "basic".
+		Scheme string `json:"scheme,omitempty"`
+		// BearerFormat is the format of the bearer tokens, e.g. "JWT".
+		BearerFormat string `json:"bearerFormat,omitempty"`
+		// Flows describes the flows of "oauth2" schemes.
+		Flows map[string]*OAuthFlow `json:"flows,omitempty"`
+		// OpenIDConnectURL is the discovery URL of "openIdConnect" schemes.
+		OpenIDConnectURL string `json:"openIdConnectUrl,omitempty"`
+		// HMAC describes how the requests are signed for HMAC security schemes. This field
+		// is rendered as the "x-hmac" vendor extension.
+		HMAC *genswagger.HMACSigning `json:"x-hmac,omitempty"`
+	}
+
+	// OAuthFlow describes an OAuth2 flow.
+	OAuthFlow struct {
+		// AuthorizationURL is the authorization endpoint URL.
+		AuthorizationURL string `json:"authorizationUrl,omitempty"`
+		// TokenURL is the token endpoint URL.
+		TokenURL string `json:"tokenUrl,omitempty"`
+		// Scopes lists the available scopes indexed by name, the values are the scope
+		// descriptions.
+		Scopes map[string]string `json:"scopes"`
+	}
+)
+
+// oauthFlows maps the design OAuth2 flows to the OpenAPI flow names.
+var oauthFlows = map[string]string{
+	design.OAuth2AccessCodeFlow:  "authorizationCode",
+	design.OAuth2ImplicitFlow:    "implicit",
+	design.OAuth2PasswordFlow:    "password",
+	design.OAuth2ApplicationFlow: "clientCredentials",
+}
+
+// New creates an OpenAPI 3 document from an API definition. The paths, schemas and responses are
+// converted from the Swagger specification of the API (see genswagger.New): the body parameters
+// become request bodies, the definitions become component schemas and the alternate schemas of the
+// responses are described with oneOf. The servers are computed from the API schemes, host and base
+// path. The callbacks of the actions and the links of the response media types are described with
+// OpenAPI callbacks and links.
+func New(api *design.APIDefinition) (*OpenAPI, error) {
+	if api == nil {
+		return nil, nil
+	}
+	s, err := genswagger.New(api)
+	if err != nil {
+		return nil, err
+	}
+	doc := &OpenAPI{
+		OpenAPI:      Version,
+		Info:         s.Info,
+		Servers:      serversFromDefinition(api),
+		Paths:        make(map[string]*PathItem, len(s.Paths)),
+		Security:     s.Security,
+		Tags:         s.Tags,
+		ExternalDocs: s.ExternalDocs,
+	}
+	for key, p := range s.Paths {
+		doc.Paths[key] = pathItemFromSwagger(s, p)
+	}
+	comps := &Components{
+		Schemas:         make(map[string]*genschema.JSONSchema, len(s.Definitions)),
+		Responses:       make(map[string]*Response, len(s.Responses)),
+		Parameters:      make(map[string]*Parameter, len(s.Parameters)),
+		SecuritySchemes: make(map[string]*SecurityScheme),
+	}
+	for n, d := range s.Definitions {
+		comps.Schemas[n] = convertSchema(d)
+	}
+	for n, r := range s.Responses {
+		comps.Responses[n] = responseFromSwagger(n, r, s.Produces)
+	}
+	for n, p := range s.Parameters {
+		comps.Parameters[n] = parameterFromSwagger(p)
+	}
+	api.IterateSecuritySchemes(func(scheme *design.SecuritySchemeDefinition) error {
+		if def, ok := s.SecurityDefinitions[scheme.SchemeName]; ok {
+			comps.SecuritySchemes[scheme.SchemeName] = securitySchemeFromDefinition(scheme, def)
+		}
+		return nil
+	})
+	if len(comps.Schemas)+len(comps.Responses)+len(comps.Parameters)+len(comps.SecuritySchemes) > 0 {
+		doc.Components = comps
+	}
+
+	operations := make(map[string]*Operation)
+	for _, p := range doc.Paths {
+		for _, op := range p.operations() {
+			operations[op.OperationID] = op
+		}
+	}
+	err = api.IterateResources(func(res *design.ResourceDefinition) error {
+		if !codegen.ResourceSelected(res) {
+			return nil
+		}
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			for _, route := range a.Routes {
+				op, ok := operations[genswagger.OperationID(route)]
+				if !ok {
+					continue
+				}
+				buildLinks(api, a, op)
+				buildCallbacks(api, a, op)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// serversFromDefinition returns the servers of the API, one per URL scheme. The URLs are relative
+// to the document location if the API does not define a host. The base path parameters are
+// described as server variables.
+func serversFromDefinition(api *design.APIDefinition) []*Server {
+	var vars map[string]*ServerVariable
+	base := design.WildcardRegex.ReplaceAllStringFunc(api.BasePath, func(w string) string {
+		name := w[2:]
+		if vars == nil {
+			vars = make(map[string]*ServerVariable)
+		}
+		v := &ServerVariable{}
+		if api.BaseParams != nil {
+			if at, ok := api.BaseParams.Type.ToObject()[name]; ok {
+				v.Description = at.Description
+				if at.DefaultValue != nil {
+					v.Default = fmt.Sprintf("%v", at.DefaultValue)
+				}
+			}
+		}
+		vars[name] = v
+		return "/{" + name + "}"
+	})
+	if api.Host == "" {
+		if base == "" {
+			base = "/"
+		}
+		return []*Server{{URL: base, Variables: vars}}
+	}
+	schemes := api.URLSchemes()
+	if len(schemes) == 0 {
+		schemes = []string{"http"}
+	}
+	servers := make([]*Server, len(schemes))
+	for i, scheme := range schemes {
+		servers[i] = &Server{URL: fmt.Sprintf("%s://%s%s", scheme, api.Host, base), Variables: vars}
+	}
+	return servers
+}
+
+// pathItemFromSwagger converts the given Swagger path.
+func pathItemFromSwagger(s *genswagger.Swagger, p *genswagger.Path) *PathItem {
+	item := &PathItem{}
+	for _, v := range []struct {
+		op  *genswagger.Operation
+		dst **Operation
+	}{
+		{p.Get, &item.Get}, {p.Put, &item.Put}, {p.Post, &item.Post}, {p.Delete, &item.Delete},
+		{p.Options, &item.Options}, {p.Head, &item.Head}, {p.Patch, &item.Patch},
+	} {
+		if v.op != nil {
+			*v.dst = operationFromSwagger(s, v.op)
+		}
+	}
+	for _, param := range p.Parameters {
+		item.Parameters = append(item.Parameters, parameterFromSwagger(param))
+	}
+	return item
+}
+
+// operationFromSwagger converts the given Swagger operation. The body parameter becomes the
+// request body rendered with the content types consumed by the operation.
+func operationFromSwagger(s *genswagger.Swagger, op *genswagger.Operation) *Operation {
+	o := &Operation{
+		Tags:         op.Tags,
+		Summary:      op.Summary,
+		Description:  op.Description,
+		ExternalDocs: op.ExternalDocs,
+		OperationID:  op.OperationID,
+		Responses:    make(map[string]*Response, len(op.Responses)),
+		Deprecated:   op.Deprecated,
+		Security:     op.Security,
+		RateLimit:    op.RateLimit,
+	}
+	consumes := op.Consumes
+	if len(consumes) == 0 {
+		consumes = s.Consumes
+	}
+	produces := op.Produces
+	if len(produces) == 0 {
+		produces = s.Produces
+	}
+	for _, p := range op.Parameters {
+		if p.In == "body" {
+			o.RequestBody = &RequestBody{
+				Description: p.Description,
+				Content:     content(consumes, convertSchema(p.Schema)),
+				Required:    p.Required,
+			}
+			continue
+		}
+		o.Parameters = append(o.Parameters, parameterFromSwagger(p))
+	}
+	for status, r := range op.Responses {
+		if r.StatusRange != "" {
+			status = r.StatusRange
+		}
+		o.Responses[status] = responseFromSwagger(status, r, produces)
+	}
+	if ws := op.WebSocket; ws != nil {
+		o.WebSocket = &genswagger.WebSocket{
+			Subprotocols: ws.Subprotocols,
+			Receives:     convertSchema(ws.Receives),
+			Sends:        convertSchema(ws.Sends),
+		}
+	}
+	return o
+}
+
+// parameterFromSwagger converts the given Swagger non-body parameter. The collection formats of
+// array parameters are converted to the equivalent styles.
+func parameterFromSwagger(p *genswagger.Parameter) *Parameter {
+	items := p.Items
+	if p.Type == "array" && items != nil && items.Type == "array" {
+		// genswagger describes the array parameter itself in Items.
+		items = items.Items
+	}
+	param := &Parameter{
+		Name:        p.Name,
+		In:          p.In,
+		Description: p.Description,
+		Required:    p.Required,
+		Sensitive:   p.Sensitive,
+		Schema: itemsSchema(&genswagger.Items{
+			Type:      p.Type,
+			Format:    p.Format,
+			Items:     items,
+			Default:   p.Default,
+			Maximum:   p.Maximum,
+			Minimum:   p.Minimum,
+			MaxLength: p.MaxLength,
+			MinLength: p.MinLength,
+			Pattern:   p.Pattern,
+			Enum:      p.Enum,
+		}),
+	}
+	explode := false
+	switch p.CollectionFormat {
+	case "csv":
+		if p.In == "query" {
+			param.Style, param.Explode = "form", &explode
+		}
+	case "ssv":
+		param.Style, param.Explode = "spaceDelimited", &explode
+	case "pipes":
+		param.Style, param.Explode = "pipeDelimited", &explode
+	}
+	return param
+}
+
+// responseFromSwagger converts the given Swagger response. The response body is rendered with
+// the given content types, its alternate schemas are described with oneOf. References to global
+// responses are converted to references to the component responses.
+func responseFromSwagger(name string, r *genswagger.Response, produces []string) *Response {
+	if r.Ref != "" {
+		return &Response{Ref: "#/components/responses/" + strings.TrimPrefix(r.Ref, "#/responses/")}
+	}
+	resp := &Response{Description: r.Description}
+	if resp.Description == "" {
+		resp.Description = name
+		if code, err := strconv.Atoi(name); err == nil && http.StatusText(code) != "" {
+			resp.Description = http.StatusText(code)
+		}
+	}
+	if len(r.Headers) > 0 {
+		resp.Headers = make(map[string]*Header, len(r.Headers))
+		for n, h := range r.Headers {
+			resp.Headers[n] = &Header{
+				Description: h.Description,
+				Schema: itemsSchema(&genswagger.Items{
+					Type:      h.Type,
+					Format:    h.Format,
+					Items:     h.Items,
+					Default:   h.Default,
+					Maximum:   h.Maximum,
+					Minimum:   h.Minimum,
+					MaxLength: h.MaxLength,
+					MinLength: h.MinLength,
+					Pattern:   h.Pattern,
+					Enum:      h.Enum,
+				}),
+			}
+		}
+	}
+	if r.Schema != nil {
+		schema := convertSchema(r.Schema)
+		if len(r.AlternateSchemas) > 0 {
+			schema = &genschema.JSONSchema{OneOf: []*genschema.JSONSchema{schema}}
+			for _, alt := range r.AlternateSchemas {
+				schema.OneOf = append(schema.OneOf, convertSchema(alt))
+			}
+		}
+		resp.Content = content(produces, schema)
+	}
+	return resp
+}
+
+// securitySchemeFromDefinition returns the security scheme describing the given design scheme.
+// def is the Swagger definition of the scheme. JWT schemes that read the tokens from the
+// Authorization header are described as HTTP bearer schemes and OIDC schemes as OpenID Connect
+// schemes using the discovery URL of their issuer.
+func securitySchemeFromDefinition(scheme *design.SecuritySchemeDefinition, def *genswagger.SecurityDefinition) *SecurityScheme {
+	sc := &SecurityScheme{
+		Type:        def.Type,
+		Description: def.Description,
+		Name:        def.Name,
+		In:          def.In,
+		HMAC:        def.HMAC,
+	}
+	switch scheme.Kind {
+	case design.BasicAuthSecurityKind:
+		sc.Type, sc.Scheme = "http", "basic"
+	case design.JWTSecurityKind:
+		if scheme.In == "header" && strings.EqualFold(scheme.Name, "Authorization") {
+			sc.Type, sc.Scheme, sc.BearerFormat = "http", "bearer", "JWT"
+			sc.Name, sc.In = "", ""
+		}
+	case design.OIDCSecurityKind:
+		if scheme.Issuer != "" {
+			sc.Type = "openIdConnect"
+			sc.OpenIDConnectURL = strings.TrimSuffix(scheme.Issuer, "/") + "/.well-known/openid-configuration"
+			sc.Name, sc.In = "", ""
+		}
+	case design.OAuth2SecurityKind:
+		scopes := make(map[string]string, len(scheme.Scopes))
+		for n, d := range scheme.Scopes {
+			scopes[n] = d
+		}
+		flow := &OAuthFlow{Scopes: scopes}
+		switch scheme.Flow {
+		case design.OAuth2AccessCodeFlow:
+			flow.AuthorizationURL, flow.TokenURL = scheme.AuthorizationURL, scheme.TokenURL
+		case design.OAuth2ImplicitFlow:
+			flow.AuthorizationURL = scheme.AuthorizationURL
+		default:
+			flow.TokenURL = scheme.TokenURL
+		}
+		sc.Flows = map[string]*OAuthFlow{oauthFlows[scheme.Flow]: flow}
+	}
+	return sc
+}
+
+// buildLinks adds the links of the media types rendered by the successful responses of the action
+// to the given operation. Each link of a media type that is rendered by the canonical action of a
+// resource is described with a link to the operation of that action. The link sets the path
+// parameter of the operation to the "id" attribute of the linked resource if the canonical action
+// path has a single parameter and the link view renders the "id" attribute.
+func buildLinks(api *design.APIDefinition, a *design.ActionDefinition, op *Operation) {
+	for _, r := range a.Responses {
+		if r.Status < 200 || r.Status >= 300 || r.StatusRange != 0 {
+			continue
+		}
+		resp, ok := op.Responses[strconv.Itoa(r.Status)]
+		if !ok || resp.Ref != "" {
+			continue
+		}
+		mt := api.MediaTypeWithIdentifier(r.MediaType)
+		if mt == nil || mt.IsArray() {
+			continue
+		}
+		names := make([]string, 0, len(mt.Links))
+		for n := range mt.Links {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			l := mt.Links[n]
+			if l.Attribute() == nil {
+				continue
+			}
+			lmt := l.MediaType()
+			if lmt == nil {
+				continue
+			}
+			route := canonicalRoute(api, lmt)
+			if route == nil {
+				continue
+			}
+			link := &Link{
+				OperationID: genswagger.OperationID(route),
+				Description: fmt.Sprintf("Retrieves the %s linked by the response", n),
+			}
+			view := l.View
+			if view == "" {
+				view = "link"
+			}
+			if params := route.Params(api.APIVersionDefinition); len(params) == 1 {
+				if v, ok := lmt.Views[view]; ok && v.AttributeDefinition != nil {
+					if _, ok := v.Type.ToObject()["id"]; ok {
+						link.Parameters = map[string]string{params[0]: fmt.Sprintf("$response.body#/links/%s/id", n)}
+					}
+				}
+			}
+			if resp.Links == nil {
+				resp.Links = make(map[string]*Link)
+			}
+			resp.Links[n] = link
+		}
+	}
+}
+
+// buildCallbacks adds the callbacks of the action to the given operation. The callback URL is
+// read from the first request body attribute with the "uri" format, or if there is none the first
+// attribute whose name contains "url", or else the "url" attribute.
+func buildCallbacks(api *design.APIDefinition, a *design.ActionDefinition, op *Operation) {
+	expr := fmt.Sprintf("{$request.body#/%s}", callbackURLAttribute(a))
+	a.IterateCallbacks(func(c *design.CallbackDefinition) error {
+		cop := &Operation{
+			Description: c.Description,
+			Responses: map[string]*Response{
+				"2XX": {Description: "The callback request was received"},
+			},
+		}
+		if mt, ok := api.MediaTypes[design.CanonicalIdentifier(c.MediaType)]; ok {
+			cop.RequestBody = &RequestBody{
+				Content:  content([]string{c.MediaType}, convertSchema(genschema.TypeSchema(api, mt))),
+				Required: true,
+			}
+		}
+		if c.SigningScheme != "" && c.SigningScheme != design.SigningNone {
+			cop.Parameters = []*Parameter{{
+				Name:        c.SignatureHeader,
+				In:          "header",
+				Description: fmt.Sprintf("Signature of the request body computed with %s", c.SigningScheme),
+				Required:    true,
+				Schema:      &genschema.JSONSchema{Type: genschema.JSONString},
+			}}
+		}
+		item := &PathItem{}
+		item.set(c.Method, cop)
+		if op.Callbacks == nil {
+			op.Callbacks = make(map[string]map[string]*PathItem)
+		}
+		op.Callbacks[c.Name] = map[string]*PathItem{expr: item}
+		return nil
+	})
+}
+
+// callbackURLAttribute returns the name of the request body attribute holding the callback URLs.
+func callbackURLAttribute(a *design.ActionDefinition) string {
+	if a.Payload == nil {
+		return "url"
+	}
+	obj := a.Payload.ToObject()
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if v := obj[n].Validation; v != nil && v.Format == "uri" {
+			return n
+		}
+	}
+	for _, n := range names {
+		if strings.Contains(strings.ToLower(n), "url") {
+			return n
+		}
+	}
+	return "url"
+}
+
+// canonicalRoute returns the first route of the canonical action of the first resource whose
+// default media type is mt, nil if there is none.
+func canonicalRoute(api *design.APIDefinition, mt *design.MediaTypeDefinition) *design.RouteDefinition {
+	var route *design.RouteDefinition
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		if route != nil || !codegen.ResourceSelected(res) {
+			return nil
+		}
+		if design.CanonicalIdentifier(res.MediaType) != design.CanonicalIdentifier(mt.Identifier) {
+			return nil
+		}
+		if ca := res.CanonicalAction(); ca != nil && len(ca.Routes) > 0 {
+			route = ca.Routes[0]
+		}
+		return nil
+	})
+	return route
+}
+
+// operations returns the operations of the path.
+func (p *PathItem) operations() []*Operation {
+	var ops []*Operation
+	for _, op := range []*Operation{p.Get, p.Put, p.Post, p.Delete, p.Options, p.Head, p.Patch} {
+		if op != nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// set sets the operation of the path for the given HTTP method, POST if empty.
+func (p *PathItem) set(method string, op *Operation) {
+	switch strings.ToUpper(method) {
+	case "GET":
+		p.Get = op
+	case "PUT":
+		p.Put = op
+	case "DELETE":
+		p.Delete = op
+	case "OPTIONS":
+		p.Options = op
+	case "HEAD":
+		p.Head = op
+	case "PATCH":
+		p.Patch = op
+	default:
+		p.Post = op
+	}
+}
+
+// content returns the content of a body rendered with the given content types.
+func content(types []string, schema *genschema.JSONSchema) map[string]*MediaType {
+	if len(types) == 0 {
+		types = []string{"application/json"}
+	}
+	c := make(map[string]*MediaType, len(types))
+	for _, t := range types {
+		c[t] = &MediaType{Schema: schema}
+	}
+	return c
+}
+
+// itemsSchema returns the schema of a Swagger non-body parameter, header or array item.
+func itemsSchema(it *genswagger.Items) *genschema.JSONSchema {
+	if it == nil {
+		return nil
+	}
+	return &genschema.JSONSchema{
+		Type:         genschema.JSONType(it.Type),
+		Format:       it.Format,
+		Items:        itemsSchema(it.Items),
+		DefaultValue: it.Default,
+		Maximum:      it.Maximum,
+		Minimum:      it.Minimum,
+		MaxLength:    it.MaxLength,
+		MinLength:    it.MinLength,
+		Pattern:      it.Pattern,
+		Enum:         it.Enum,
+	}
+}
+
+// convertSchema returns a copy of the given JSON schema that complies with OpenAPI 3: the
+// references to the Swagger definitions are replaced with references to the component schemas and
+// the JSON hyper-schema members are removed. The copy does not share memory with s so that the
+// schemas generated by genschema are left untouched.
+func convertSchema(s *genschema.JSONSchema) *genschema.JSONSchema {
+	if s == nil {
+		return nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return s
+	}
+	var c genschema.JSONSchema
+	if err := json.Unmarshal(b, &c); err != nil {
+		return s
+	}
+	rewriteRefs(&c)
+	return &c
+}
+
+// rewriteRefs rewrites the references of s and its sub-schemas in place.
+func rewriteRefs(s *genschema.JSONSchema) {
+	if s == nil {
+		return
+	}
+	s.Schema, s.ID, s.Media, s.Links, s.PathStart, s.Definitions = "", "", nil, nil, "", nil
+	if strings.HasPrefix(s.Ref, "#/definitions/") {
+		s.Ref = "#/components/schemas/" + strings.TrimPrefix(s.Ref, "#/definitions/")
+	}
+	rewriteRefs(s.Items)
+	for _, p := range s.Properties {
+		rewriteRefs(p)
+	}
+	for _, a := range s.AnyOf {
+		rewriteRefs(a)
+	}
+	for _, o := range s.OneOf {
+		rewriteRefs(o)
+	}
+}
@@ -0,0 +1,240 @@
+package genopenapi_test
+
+import (
+	"encoding/json"
+
+	. "github.com/goadesign/goa/design"
+	. "github.com/goadesign/goa/design/apidsl"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_openapi"
+	"github.com/goadesign/goa/goagen/gen_schema"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("New", func() {
+	var doc *genopenapi.OpenAPI
+	var newErr error
+
+	BeforeEach(func() {
+		doc = nil
+		newErr = nil
+		InitDesign()
+		dslengine.Errors = nil
+		genschema.Definitions = make(map[string]*genschema.JSONSchema)
+	})
+
+	JustBeforeEach(func() {
+		err := dslengine.Run()
+		Ω(err).ShouldNot(HaveOccurred())
+		doc, newErr = genopenapi.New(Design)
+	})
+
+	Context("with a basic API", func() {
+		BeforeEach(func() {
+			API("test", func() {
+				Title("title")
+				Host("goa.design")
+				Scheme("https", "h2c")
+				BasePath("/accounts/:accountID")
+				BaseParams(func() {
+					Param("accountID", String, "Account ID", func() {
+						Default("1")
+					})
+				})
+			})
+		})
+
+		It("sets the servers", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(doc.OpenAPI).Should(Equal("3.0.3"))
+			Ω(doc.Info.Title).Should(Equal("title"))
+			Ω(doc.Servers).Should(HaveLen(2))
+			Ω(doc.Servers[0].URL).Should(Equal("https://goa.design/accounts/{accountID}"))
+			Ω(doc.Servers[1].URL).Should(Equal("http://goa.design/accounts/{accountID}"))
+			Ω(doc.Servers[0].Variables).Should(HaveKey("accountID"))
+			Ω(doc.Servers[0].Variables["accountID"].Default).Should(Equal("1"))
+			Ω(doc.Servers[0].Variables["accountID"].Description).Should(Equal("Account ID"))
+		})
+	})
+
+	Context("with resources", func() {
+		var jwt *SecuritySchemeDefinition
+
+		BeforeEach(func() {
+			API("test", func() {
+				BasePath("/api")
+			})
+			jwt = JWTSecurity("jwt", func() {
+				TokenURL("https://goa.design/signin")
+			})
+			account := MediaType("application/vnd.account+json", func() {
+				Attributes(func() {
+					Attribute("id", Integer)
+					Attribute("href", String)
+				})
+				View("default", func() {
+					Attribute("id")
+					Attribute("href")
+				})
+				View("link", func() {
+					Attribute("id")
+					Attribute("href")
+				})
+			})
+			bottle := MediaType("application/vnd.bottle+json", func() {
+				Attributes(func() {
+					Attribute("id", Integer)
+					Attribute("name", String)
+					Attribute("account", account)
+				})
+				Links(func() {
+					Link("account")
+				})
+				View("default", func() {
+					Attribute("id")
+					Attribute("name")
+					Attribute("links")
+				})
+			})
+			problem := MediaType("application/vnd.problem+json", func() {
+				Attributes(func() {
+					Attribute("detail", String)
+				})
+				View("default", func() {
+					Attribute("detail")
+				})
+			})
+			shipped := MediaType("application/vnd.shipped+json", func() {
+				Attributes(func() {
+					Attribute("bottle_id", Integer)
+				})
+				View("default", func() {
+					Attribute("bottle_id")
+				})
+			})
+			Resource("account", func() {
+				DefaultMedia(account)
+				BasePath("/accounts")
+				Action("show", func() {
+					Routing(GET("/:accountID"))
+					Response(OK)
+				})
+			})
+			Resource("bottle", func() {
+				DefaultMedia(bottle)
+				BasePath("/bottles")
+				Action("show", func() {
+					Routing(GET("/:bottleID"))
+					Params(func() {
+						Param("tags", ArrayOf(String))
+					})
+					Security(jwt)
+					Response(OK, func() {
+						Media(bottle, problem)
+					})
+				})
+				Action("subscribe", func() {
+					Routing(POST("/subscriptions"))
+					Payload(func() {
+						Attribute("name", String)
+						Attribute("hook", String, func() {
+							Format("uri")
+						})
+					})
+					Callback("BottleShipped", func() {
+						Media(shipped)
+					})
+					Response(Created)
+				})
+			})
+		})
+
+		It("describes the operations", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(doc.Servers).Should(HaveLen(1))
+			Ω(doc.Servers[0].URL).Should(Equal("/api"))
+			Ω(doc.Paths).Should(HaveKey("/bottles/{bottleID}"))
+			show := doc.Paths["/bottles/{bottleID}"].Get
+			Ω(show).ShouldNot(BeNil())
+			Ω(show.OperationID).Should(Equal("bottle#show"))
+			Ω(show.Parameters).Should(HaveLen(2))
+			for _, p := range show.Parameters {
+				Ω(p.Schema).ShouldNot(BeNil())
+				if p.Name == "tags" {
+					Ω(p.In).Should(Equal("query"))
+					Ω(string(p.Schema.Type)).Should(Equal("array"))
+					Ω(string(p.Schema.Items.Type)).Should(Equal("string"))
+					Ω(p.Style).Should(Equal("form"))
+					Ω(*p.Explode).Should(BeFalse())
+				}
+			}
+			Ω(show.Security).Should(Equal([]map[string][]string{{"jwt": {}}}))
+		})
+
+		It("describes the request bodies", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			subscribe := doc.Paths["/bottles/subscriptions"].Post
+			Ω(subscribe).ShouldNot(BeNil())
+			Ω(subscribe.RequestBody).ShouldNot(BeNil())
+			Ω(subscribe.RequestBody.Required).Should(BeTrue())
+			Ω(subscribe.RequestBody.Content).Should(HaveKey("application/json"))
+			schema := subscribe.RequestBody.Content["application/json"].Schema
+			Ω(schema.Ref).Should(Equal("#/components/schemas/SubscribeBottlePayload"))
+			Ω(doc.Components.Schemas).Should(HaveKey("SubscribeBottlePayload"))
+			Ω(doc.Components.Schemas["SubscribeBottlePayload"].Properties).Should(HaveKey("hook"))
+		})
+
+		It("describes the alternate response bodies with oneOf", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			ok := doc.Paths["/bottles/{bottleID}"].Get.Responses["200"]
+			Ω(ok).ShouldNot(BeNil())
+			Ω(ok.Content).Should(HaveKey("application/json"))
+			schema := ok.Content["application/json"].Schema
+			Ω(schema.OneOf).Should(HaveLen(2))
+			Ω(schema.OneOf[0].Ref).Should(Equal("#/components/schemas/Bottle"))
+			Ω(schema.OneOf[1].Ref).Should(Equal("#/components/schemas/Problem"))
+			Ω(doc.Components.Schemas).Should(HaveKey("Bottle"))
+			Ω(doc.Components.Schemas).Should(HaveKey("Problem"))
+		})
+
+		It("describes the links of the responses", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			ok := doc.Paths["/bottles/{bottleID}"].Get.Responses["200"]
+			Ω(ok.Links).Should(HaveKey("account"))
+			link := ok.Links["account"]
+			Ω(link.OperationID).Should(Equal("account#show"))
+			Ω(link.Parameters).Should(Equal(map[string]string{"accountID": "$response.body#/links/account/id"}))
+		})
+
+		It("describes the callbacks", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			subscribe := doc.Paths["/bottles/subscriptions"].Post
+			Ω(subscribe.Callbacks).Should(HaveKey("BottleShipped"))
+			cb := subscribe.Callbacks["BottleShipped"]
+			Ω(cb).Should(HaveKey("{$request.body#/hook}"))
+			post := cb["{$request.body#/hook}"].Post
+			Ω(post).ShouldNot(BeNil())
+			Ω(post.RequestBody.Content).Should(HaveKey("application/vnd.shipped+json"))
+			Ω(post.Parameters).Should(HaveLen(1))
+			Ω(post.Parameters[0].Name).Should(Equal(DefaultSignatureHeader))
+			Ω(post.Parameters[0].In).Should(Equal("header"))
+		})
+
+		It("describes the security schemes", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			Ω(doc.Components.SecuritySchemes).Should(HaveKey("jwt"))
+			scheme := doc.Components.SecuritySchemes["jwt"]
+			Ω(scheme.Type).Should(Equal("http"))
+			Ω(scheme.Scheme).Should(Equal("bearer"))
+			Ω(scheme.BearerFormat).Should(Equal("JWT"))
+		})
+
+		It("does not reference Swagger definitions", func() {
+			Ω(newErr).ShouldNot(HaveOccurred())
+			b, err := json.Marshal(doc)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).ShouldNot(ContainSubstring("#/definitions/"))
+		})
+	})
+})
@@ -0,0 +1,43 @@
+package genproto
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// ProtoPackage is the name of the generated protobuf package.
+	ProtoPackage string
+
+	// GoPackage is the value of the go_package option written to the generated file if any.
+	GoPackage string
+)
+
+// Command is the goa protobuf generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("proto", "Generate Protocol Buffers messages and gRPC services")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&ProtoPackage, "package", "api", "Name of generated protobuf package")
+	r.Flags().StringVar(&GoPackage, "go-package", "", "Value of the go_package option in the generated file")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"package": ProtoPackage, "go-package": GoPackage}
+	gen := meta.NewGenerator(
+		"genproto.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_proto")},
+		flags,
+	)
+	return gen.Generate()
+}
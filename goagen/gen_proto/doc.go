@@ -0,0 +1,20 @@
+/*
+Package genproto provides a generator for the Protocol Buffers definition of the API.
+The generator translates user types and media types into protobuf messages and each resource into
+a gRPC service with one rpc per action. The rpc request message is built from the action
+parameters and payload while the response message is the media type of the first success
+response of the action.
+
+Field numbers are assigned in alphabetical order of the attribute names by default. The
+"proto:field" metadata key makes it possible to pin the number of a given field so that the
+numbering stays stable as the design evolves:
+
+	Attribute("name", String, func() {
+		Metadata("proto:field", "2")
+	})
+
+The "grpc:service" and "grpc:method" metadata keys override the names of the service generated
+for a resource and of the rpc generated for an action respectively. Resources and actions that
+define the "grpc:skip" metadata key are left out of the generated services.
+*/
+package genproto
@@ -0,0 +1,93 @@
+package genproto
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the protobuf generator.
+type Generator struct {
+	genfiles []string
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "Protocol Buffers generator",
+		Long:  "Protocol Buffers and gRPC service definitions generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// ProtoDir returns the path to the directory where the protobuf files are generated.
+func ProtoDir() string {
+	return filepath.Join(codegen.OutputDir, "proto")
+}
+
+// Generate produces one protobuf file per API version.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	os.RemoveAll(ProtoDir())
+	if err = os.MkdirAll(ProtoDir(), 0755); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, ProtoDir())
+	err = api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		pkg := ProtoPackage
+		name := "api.proto"
+		if v.Version != "" {
+			vpkg := codegen.VersionPackage(v.Version)
+			pkg += "." + vpkg
+			name = vpkg + ".proto"
+		}
+		f, err := NewFile(pkg, GoPackage, v)
+		if err != nil {
+			return err
+		}
+		protoFile := filepath.Join(ProtoDir(), name)
+		if err := ioutil.WriteFile(protoFile, f.Render(), 0644); err != nil {
+			return err
+		}
+		g.genfiles = append(g.genfiles, protoFile)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes the entire "proto" directory if it was created by this generator.
+func (g *Generator) Cleanup() {
+	if len(g.genfiles) == 0 {
+		return
+	}
+	os.RemoveAll(ProtoDir())
+	g.genfiles = nil
+}
@@ -0,0 +1,456 @@
+package genproto
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+// FieldKey is the metadata key used to set the protobuf field number of an attribute.
+const FieldKey = "proto:field"
+
+const (
+	timestampType = "google.protobuf.Timestamp"
+	valueType     = "google.protobuf.Value"
+	emptyType     = "google.protobuf.Empty"
+)
+
+// wellKnownImports lists the files that must be imported when using well known types.
+var wellKnownImports = map[string]string{
+	timestampType: "google/protobuf/timestamp.proto",
+	valueType:     "google/protobuf/struct.proto",
+	emptyType:     "google/protobuf/empty.proto",
+}
+
+type (
+	// File describes a protobuf file.
+	File struct {
+		// Package is the protobuf package name.
+		Package string
+		// GoPackage is the value of the go_package option if any.
+		GoPackage string
+		// Imports lists the imported files sorted alphabetically.
+		Imports []string
+		// Messages lists the top level messages.
+		Messages []*Message
+		// Services lists the gRPC services, one per resource.
+		Services []*Service
+		// JSONNames causes the json_name option to be rendered for fields whose name differs
+		// from the name of the corresponding attribute.
+		JSONNames bool
+	}
+
+	// Message describes a protobuf message.
+	Message struct {
+		// Name is the message name.
+		Name string
+		// Description is the message description if any.
+		Description string
+		// Fields lists the message fields sorted by number.
+		Fields []*Field
+		// Nested lists the messages defined inside this message.
+		Nested []*Message
+	}
+
+	// Field describes a protobuf message field.
+	Field struct {
+		// Name is the field name.
+		Name string
+		// Type is the field protobuf type.
+		Type string
+		// Number is the field number.
+		Number int
+		// Repeated is true if the field is a list.
+		Repeated bool
+		// Description is the field description if any.
+		Description string
+		// JSONName is the name of the attribute the field was built from.
+		JSONName string
+	}
+
+	// Service describes a gRPC service.
+	Service struct {
+		// Name is the service name.
+		Name string
+		// Description is the service description if any.
+		Description string
+		// RPCs lists the service methods.
+		RPCs []*RPC
+	}
+
+	// RPC describes a gRPC service method.
+	RPC struct {
+		// Name is the method name.
+		Name string
+		// Description is the method description if any.
+		Description string
+		// Request is the name of the request message.
+		Request string
+		// Response is the name of the response message.
+		Response string
+		// Action is the action exposed by the rpc.
+		Action *design.ActionDefinition
+	}
+
+	// builder keeps track of the well known types used while building a file.
+	builder struct {
+		imports map[string]bool
+	}
+)
+
+// NewFile builds the protobuf file describing the given API version.
+func NewFile(pkg, goPkg string, version *design.APIVersionDefinition) (*File, error) {
+	f := &File{Package: pkg, GoPackage: goPkg}
+	b := &builder{imports: make(map[string]bool)}
+	err := version.IterateUserTypes(func(ut *design.UserTypeDefinition) error {
+		m, err := b.userTypeMessage(ut)
+		if err != nil {
+			return err
+		}
+		f.Messages = append(f.Messages, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = version.IterateMediaTypes(func(mt *design.MediaTypeDefinition) error {
+		if !mt.Type.IsObject() && !mt.Type.IsArray() {
+			return nil
+		}
+		m, err := b.userTypeMessage(mt.UserTypeDefinition)
+		if err != nil {
+			return err
+		}
+		f.Messages = append(f.Messages, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = version.IterateResources(func(r *design.ResourceDefinition) error {
+		if !r.GRPCExposed() {
+			return nil
+		}
+		name := r.GRPCServiceName()
+		if name == "" {
+			name = codegen.Goify(r.Name, true)
+		}
+		svc := &Service{Name: name, Description: r.Description}
+		err := r.IterateActions(func(a *design.ActionDefinition) error {
+			if !a.GRPCExposed() {
+				return nil
+			}
+			req, err := b.requestMessage(a)
+			if err != nil {
+				return err
+			}
+			f.Messages = append(f.Messages, req)
+			name := a.GRPCMethodName()
+			if name == "" {
+				name = codegen.Goify(a.Name, true)
+			}
+			svc.RPCs = append(svc.RPCs, &RPC{
+				Name:        name,
+				Description: a.Description,
+				Request:     req.Name,
+				Response:    b.responseType(a),
+				Action:      a,
+			})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(svc.RPCs) == 0 {
+			return nil
+		}
+		f.Services = append(f.Services, svc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range b.imports {
+		f.Imports = append(f.Imports, i)
+	}
+	sort.Strings(f.Imports)
+	return f, nil
+}
+
+// Render produces the protobuf file content.
+func (f *File) Render() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&buf, "package %s;\n", f.Package)
+	if f.GoPackage != "" {
+		fmt.Fprintf(&buf, "\noption go_package = %q;\n", f.GoPackage)
+	}
+	if len(f.Imports) > 0 {
+		buf.WriteString("\n")
+		for _, i := range f.Imports {
+			fmt.Fprintf(&buf, "import %q;\n", i)
+		}
+	}
+	for _, m := range f.Messages {
+		buf.WriteString("\n")
+		m.render(&buf, 0, f.JSONNames)
+	}
+	for _, s := range f.Services {
+		buf.WriteString("\n")
+		writeComment(&buf, s.Description, 0)
+		fmt.Fprintf(&buf, "service %s {\n", s.Name)
+		for _, r := range s.RPCs {
+			writeComment(&buf, r.Description, 1)
+			fmt.Fprintf(&buf, "\trpc %s(%s) returns (%s);\n", r.Name, r.Request, r.Response)
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes()
+}
+
+// render writes the message definition to buf.
+func (m *Message) render(buf *bytes.Buffer, depth int, jsonNames bool) {
+	tabs := codegen.Tabs(depth)
+	writeComment(buf, m.Description, depth)
+	fmt.Fprintf(buf, "%smessage %s {\n", tabs, m.Name)
+	for _, n := range m.Nested {
+		n.render(buf, depth+1, jsonNames)
+	}
+	for _, f := range m.Fields {
+		writeComment(buf, f.Description, depth+1)
+		repeated := ""
+		if f.Repeated {
+			repeated = "repeated "
+		}
+		opts := ""
+		if jsonNames && f.JSONName != "" && f.JSONName != f.Name {
+			opts = fmt.Sprintf(" [json_name = %q]", f.JSONName)
+		}
+		fmt.Fprintf(buf, "%s\t%s%s %s = %d%s;\n", tabs, repeated, f.Type, f.Name, f.Number, opts)
+	}
+	fmt.Fprintf(buf, "%s}\n", tabs)
+}
+
+// userTypeMessage builds the message corresponding to the given user type or media type.
+// Array types produce a message with a single repeated "items" field.
+func (b *builder) userTypeMessage(ut *design.UserTypeDefinition) (*Message, error) {
+	name := codegen.Goify(ut.TypeName, true)
+	if ut.Type.IsArray() {
+		m := &Message{Name: name, Description: ut.Description}
+		typ, nested, err := b.fieldType(ut.Type.ToArray().ElemType, "Item")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", ut.Context(), err)
+		}
+		if nested != nil {
+			m.Nested = append(m.Nested, nested)
+		}
+		m.Fields = []*Field{{Name: "items", Type: typ, Number: 1, Repeated: true}}
+		return m, nil
+	}
+	m, err := b.objectMessage(name, ut.AttributeDefinition)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", ut.Context(), err)
+	}
+	return m, nil
+}
+
+// requestMessage builds the rpc request message of the given action from its parameters and
+// payload. The payload is added as the "payload" field.
+func (b *builder) requestMessage(a *design.ActionDefinition) (*Message, error) {
+	name := codegen.Goify(a.Name, true) + codegen.Goify(a.Parent.Name, true) + "Request"
+	params := a.AllParams()
+	att := &design.AttributeDefinition{Type: design.Object{}}
+	if params != nil && params.Type.IsObject() {
+		att = design.DupAtt(params)
+	}
+	if a.Payload != nil {
+		att.Type.ToObject()["payload"] = &design.AttributeDefinition{Type: a.Payload}
+	}
+	m, err := b.objectMessage(name, att)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", a.Context(), err)
+	}
+	return m, nil
+}
+
+// responseType returns the name of the message used to describe the action response. This is
+// the media type of the first success response (ordered by status code) if any.
+func (b *builder) responseType(a *design.ActionDefinition) string {
+	for _, r := range codegen.SuccessResponses(a) {
+		if r.MediaType == "" {
+			continue
+		}
+		if mt := design.Design.MediaTypeWithIdentifier(r.MediaType); mt != nil {
+			if mt.Type.IsObject() || mt.Type.IsArray() {
+				return codegen.Goify(mt.TypeName, true)
+			}
+		}
+	}
+	b.imports[wellKnownImports[emptyType]] = true
+	return emptyType
+}
+
+// objectMessage builds a message from an object attribute. Fields are numbered using the
+// "proto:field" metadata if present, the remaining fields are numbered in alphabetical order
+// starting with the first number not already taken.
+func (b *builder) objectMessage(name string, att *design.AttributeDefinition) (*Message, error) {
+	m := &Message{Name: name, Description: att.Description}
+	obj := att.Type.ToObject()
+	if obj == nil {
+		return nil, fmt.Errorf("type of %s is not an object", name)
+	}
+	taken := make(map[int]string)
+	var names []string
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var auto []*Field
+	for _, n := range names {
+		fatt := obj[n]
+		typ, nested, err := b.fieldType(fatt, codegen.Goify(n, true))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %s", n, err)
+		}
+		if nested != nil {
+			m.Nested = append(m.Nested, nested)
+		}
+		field := &Field{
+			Name:        fieldName(n),
+			Type:        typ,
+			Repeated:    fatt.Type.IsArray(),
+			Description: fatt.Description,
+			JSONName:    n,
+		}
+		if vals, ok := fatt.Metadata[FieldKey]; ok && len(vals) > 0 {
+			num, err := strconv.Atoi(vals[0])
+			if err != nil || num < 1 || num > 536870911 || (num >= 19000 && num <= 19999) {
+				return nil, fmt.Errorf("invalid %s value %#v for field %s", FieldKey, vals[0], n)
+			}
+			if other, ok := taken[num]; ok {
+				return nil, fmt.Errorf("fields %s and %s use the same number %d", other, n, num)
+			}
+			taken[num] = n
+			field.Number = num
+		} else {
+			auto = append(auto, field)
+		}
+		m.Fields = append(m.Fields, field)
+	}
+	next := 1
+	for _, f := range auto {
+		for {
+			if _, ok := taken[next]; !ok {
+				break
+			}
+			next++
+		}
+		f.Number = next
+		taken[next] = f.Name
+	}
+	sort.Sort(byNumber(m.Fields))
+	return m, nil
+}
+
+// fieldType returns the protobuf type of a field with the given attribute. It also returns the
+// nested message definition if the attribute is an inline object, name is used to name that
+// message.
+func (b *builder) fieldType(att *design.AttributeDefinition, name string) (string, *Message, error) {
+	switch actual := att.Type.(type) {
+	case design.Primitive:
+		return b.primitiveType(actual), nil, nil
+	case *design.UserTypeDefinition:
+		return codegen.Goify(actual.TypeName, true), nil, nil
+	case *design.MediaTypeDefinition:
+		return codegen.Goify(actual.TypeName, true), nil, nil
+	case design.Object:
+		nested, err := b.objectMessage(name, att)
+		if err != nil {
+			return "", nil, err
+		}
+		return name, nested, nil
+	case *design.Array:
+		if actual.ElemType.Type.IsArray() {
+			return "", nil, fmt.Errorf("arrays of arrays are not supported, use a user type for the element")
+		}
+		return b.fieldType(actual.ElemType, name+"Item")
+	case *design.Hash:
+		key, ok := actual.KeyType.Type.(design.Primitive)
+		if !ok || (key.Kind() != design.StringKind && key.Kind() != design.IntegerKind && key.Kind() != design.BooleanKind) {
+			return "", nil, fmt.Errorf("hash keys must be strings, integers or booleans")
+		}
+		if actual.ElemType.Type.IsArray() || actual.ElemType.Type.IsHash() {
+			return "", nil, fmt.Errorf("hash values cannot be arrays or hashes, use a user type for the value")
+		}
+		elem, nested, err := b.fieldType(actual.ElemType, name+"Value")
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("map<%s, %s>", b.primitiveType(key), elem), nested, nil
+	}
+	return "", nil, fmt.Errorf("unsupported type %s", att.Type.Name())
+}
+
+// primitiveType returns the protobuf scalar or well known type corresponding to p.
+func (b *builder) primitiveType(p design.Primitive) string {
+	var t string
+	switch p.Kind() {
+	case design.BooleanKind:
+		t = "bool"
+	case design.IntegerKind:
+		t = "int64"
+	case design.NumberKind:
+		t = "double"
+	case design.StringKind:
+		t = "string"
+	case design.DateTimeKind:
+		t = timestampType
+	default:
+		t = valueType
+	}
+	if i, ok := wellKnownImports[t]; ok {
+		b.imports[i] = true
+	}
+	return t
+}
+
+// fieldName produces a protobuf field name (snake case) from an attribute name.
+func fieldName(n string) string {
+	var buf bytes.Buffer
+	runes := []rune(codegen.Goify(n, true))
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				buf.WriteByte('_')
+			}
+		}
+		buf.WriteRune(unicode.ToLower(r))
+	}
+	return buf.String()
+}
+
+// writeComment writes desc as a protobuf comment at the given depth.
+func writeComment(buf *bytes.Buffer, desc string, depth int) {
+	if desc == "" {
+		return
+	}
+	tabs := codegen.Tabs(depth)
+	for _, l := range strings.Split(strings.TrimSpace(desc), "\n") {
+		fmt.Fprintf(buf, "%s// %s\n", tabs, strings.TrimSpace(l))
+	}
+}
+
+// byNumber makes it possible to sort fields by number.
+type byNumber []*Field
+
+func (b byNumber) Len() int           { return len(b) }
+func (b byNumber) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byNumber) Less(i, j int) bool { return b[i].Number < b[j].Number }
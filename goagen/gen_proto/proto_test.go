@@ -0,0 +1,101 @@
+package genproto_test
+
+import (
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/gen_proto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewFile", func() {
+	var bottle *design.MediaTypeDefinition
+	var file *genproto.File
+	var err error
+
+	BeforeEach(func() {
+		bottle = &design.MediaTypeDefinition{
+			UserTypeDefinition: &design.UserTypeDefinition{
+				AttributeDefinition: &design.AttributeDefinition{
+					Type: design.Object{
+						"id":         &design.AttributeDefinition{Type: design.Integer},
+						"name":       &design.AttributeDefinition{Type: design.String},
+						"created_at": &design.AttributeDefinition{Type: design.DateTime},
+					},
+				},
+				TypeName: "Bottle",
+			},
+			Identifier: "application/vnd.bottle+json",
+		}
+		res := &design.ResourceDefinition{Name: "bottle", MediaType: bottle.Identifier}
+		show := &design.ActionDefinition{
+			Name:   "show",
+			Parent: res,
+			Params: &design.AttributeDefinition{Type: design.Object{
+				"id": &design.AttributeDefinition{Type: design.Integer},
+			}},
+			Responses: map[string]*design.ResponseDefinition{
+				"OK": {Name: "OK", Status: 200, MediaType: bottle.Identifier},
+			},
+		}
+		res.Actions = map[string]*design.ActionDefinition{"show": show}
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "cellar"},
+			Resources:            map[string]*design.ResourceDefinition{"bottle": res},
+			MediaTypes:           map[string]*design.MediaTypeDefinition{bottle.Identifier: bottle},
+		}
+	})
+
+	JustBeforeEach(func() {
+		file, err = genproto.NewFile("cellar", "", design.Design.APIVersionDefinition)
+	})
+
+	It("produces messages and services", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(file.Messages).Should(HaveLen(2))
+		m := file.Messages[0]
+		Ω(m.Name).Should(Equal("Bottle"))
+		Ω(m.Fields).Should(HaveLen(3))
+		Ω(m.Fields[0].Name).Should(Equal("created_at"))
+		Ω(m.Fields[0].Type).Should(Equal("google.protobuf.Timestamp"))
+		Ω(m.Fields[1].Name).Should(Equal("id"))
+		Ω(m.Fields[1].Number).Should(Equal(2))
+		Ω(file.Imports).Should(Equal([]string{"google/protobuf/timestamp.proto"}))
+		Ω(file.Services).Should(HaveLen(1))
+		Ω(file.Services[0].RPCs).Should(HaveLen(1))
+		rpc := file.Services[0].RPCs[0]
+		Ω(rpc.Name).Should(Equal("Show"))
+		Ω(rpc.Request).Should(Equal("ShowBottleRequest"))
+		Ω(rpc.Response).Should(Equal("Bottle"))
+		Ω(string(file.Render())).Should(ContainSubstring("rpc Show(ShowBottleRequest) returns (Bottle);"))
+	})
+
+	Context("with field number metadata", func() {
+		BeforeEach(func() {
+			bottle.Type.ToObject()["name"].Metadata = dslengine.MetadataDefinition{"proto:field": {"1"}}
+		})
+
+		It("uses the given numbers and numbers the other fields after", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+			fields := file.Messages[0].Fields
+			Ω(fields[0].Name).Should(Equal("name"))
+			Ω(fields[0].Number).Should(Equal(1))
+			Ω(fields[1].Name).Should(Equal("created_at"))
+			Ω(fields[1].Number).Should(Equal(2))
+			Ω(fields[2].Name).Should(Equal("id"))
+			Ω(fields[2].Number).Should(Equal(3))
+		})
+	})
+
+	Context("with duplicate field numbers", func() {
+		BeforeEach(func() {
+			obj := bottle.Type.ToObject()
+			obj["name"].Metadata = dslengine.MetadataDefinition{"proto:field": {"1"}}
+			obj["id"].Metadata = dslengine.MetadataDefinition{"proto:field": {"1"}}
+		})
+
+		It("returns an error", func() {
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -48,6 +48,9 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		}
 	}()
 
+	if err = codegen.CheckFilters(api); err != nil {
+		return
+	}
 	os.RemoveAll(JSONSchemaDir())
 	os.MkdirAll(JSONSchemaDir(), 0755)
 	g.genfiles = append(g.genfiles, JSONSchemaDir())
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 
 	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
 )
 
 type (
@@ -44,6 +46,14 @@ type (
 
 		// Union
 		AnyOf []*JSONSchema `json:"anyOf,omitempty"`
+		OneOf []*JSONSchema `json:"oneOf,omitempty"`
+
+		// Roles allowed to see the property, see the VisibleTo DSL
+		VisibleTo []string `json:"x-visible-to,omitempty"`
+		// Encrypted is true if the property value is encrypted, see the Encrypted DSL
+		Encrypted bool `json:"x-encrypted,omitempty"`
+		// Sensitive is true if the property value is kept out of the logs, see the Sensitive DSL
+		Sensitive bool `json:"x-sensitive,omitempty"`
 	}
 
 	// JSONType is the JSON type enum.
@@ -121,7 +131,9 @@ func (s *JSONSchema) JSON() ([]byte, error) {
 // APISchema produces the API JSON hyper schema.
 func APISchema(api *design.APIDefinition) *JSONSchema {
 	api.IterateResources(func(r *design.ResourceDefinition) error {
-		GenerateResourceDefinition(api, r)
+		if codegen.ResourceSelected(r) {
+			GenerateResourceDefinition(api, r)
+		}
 		return nil
 	})
 	links := []*JSONLink{
@@ -179,7 +191,13 @@ func GenerateResourceDefinition(api *design.APIDefinition, r *design.ResourceDef
 		}
 		var targetSchema *JSONSchema
 		var identifier string
-		for _, resp := range a.Responses {
+		respNames := make([]string, 0, len(a.Responses))
+		for n := range a.Responses {
+			respNames = append(respNames, n)
+		}
+		sort.Strings(respNames)
+		for _, n := range respNames {
+			resp := a.Responses[n]
 			if mt, ok := api.MediaTypes[resp.MediaType]; ok {
 				if identifier == "" {
 					identifier = mt.Identifier
@@ -362,15 +380,24 @@ func (s *JSONSchema) Dup() *JSONSchema {
 		MaxLength:            s.MaxLength,
 		Required:             s.Required,
 		AdditionalProperties: s.AdditionalProperties,
+		VisibleTo:            s.VisibleTo,
+		Encrypted:            s.Encrypted,
+		Sensitive:            s.Sensitive,
 	}
-	for n, p := range s.Properties {
-		js.Properties[n] = p.Dup()
+	if s.Properties != nil {
+		js.Properties = make(map[string]*JSONSchema, len(s.Properties))
+		for n, p := range s.Properties {
+			js.Properties[n] = p.Dup()
+		}
 	}
 	if s.Items != nil {
 		js.Items = s.Items.Dup()
 	}
-	for n, d := range s.Definitions {
-		js.Definitions[n] = d.Dup()
+	if s.Definitions != nil {
+		js.Definitions = make(map[string]*JSONSchema, len(s.Definitions))
+		for n, d := range s.Definitions {
+			js.Definitions[n] = d.Dup()
+		}
 	}
 	return &js
 }
@@ -381,6 +408,9 @@ func buildAttributeSchema(api *design.APIDefinition, s *JSONSchema, at *design.A
 	s.DefaultValue = at.DefaultValue
 	s.Description = at.Description
 	s.Example = at.Example
+	s.VisibleTo = at.VisibleTo()
+	s.Encrypted = at.IsEncrypted()
+	s.Sensitive = at.IsSensitive()
 	val := at.Validation
 	if val == nil {
 		return s
@@ -440,6 +470,7 @@ func buildMediaTypeSchema(api *design.APIDefinition, mt *design.MediaTypeDefinit
 		lnames[i] = n
 		i++
 	}
+	sort.Strings(lnames)
 	for _, ln := range lnames {
 		l := mt.Links[ln]
 		att := l.Attribute() // cannot be nil if DSL validated
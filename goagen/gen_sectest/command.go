@@ -0,0 +1,37 @@
+package gensectest
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+// TargetDir is the name of the directory the security test tool is generated in.
+var TargetDir string
+
+// Command is the goa security test suite generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("sectest", "Generate a tool that runs security probes against a running service")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&TargetDir, "dir", "sectest", "Name of the directory of the generated tool")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"dir": TargetDir}
+	gen := meta.NewGenerator(
+		"gensectest.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_sectest")},
+		flags,
+	)
+	return gen.Generate()
+}
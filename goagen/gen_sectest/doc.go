@@ -0,0 +1,22 @@
+/*
+Package gensectest implements the "sectest" command which generates a command line tool that runs
+security probes derived from the design against a running service:
+
+  - unauthenticated requests made to the secured actions must be rejected with 401 or 403,
+  - requests made to the actions that require scopes with credentials lacking them must be
+    rejected with 403,
+  - payloads larger than the size set with the MaxSize DSL must be rejected with 413,
+  - payloads whose content does not match their Content-Type header must be rejected with a 4xx
+    response,
+  - injection payloads (SQL, script, path traversal, command and template) sent in the string
+    path and query string parameters must not cause 5xx responses nor be reflected unescaped.
+
+The tool is generated in the directory given by the --dir flag, "sectest" by default:
+
+	go run ./sectest -host localhost:8080 -token "Bearer $TOKEN" -low-token "Bearer $LOW" -out results.json
+
+The -token credentials are used to reach the secured actions, the probes that require credentials
+are skipped when they are not given. The tool writes the results in JSON and exits with status 1
+if any probe fails so that it may run in CI.
+*/
+package gensectest
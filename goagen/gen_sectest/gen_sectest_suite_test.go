@@ -0,0 +1,13 @@
+package gensectest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenSecTest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenSecTest Suite")
+}
@@ -0,0 +1,598 @@
+package gensectest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// CategoryUnauthenticated is the category of the probes that make requests without
+	// credentials to the secured actions.
+	CategoryUnauthenticated = "unauthenticated"
+	// CategoryScopeEscalation is the category of the probes that make requests with credentials
+	// lacking the required scopes.
+	CategoryScopeEscalation = "scope-escalation"
+	// CategoryOversizedPayload is the category of the probes that send payloads larger than
+	// the action maximum body size.
+	CategoryOversizedPayload = "oversized-payload"
+	// CategoryContentType is the category of the probes that send payloads whose content does
+	// not match their Content-Type header.
+	CategoryContentType = "content-type-confusion"
+	// CategoryInjection is the category of the probes that send injection payloads in the
+	// string parameters.
+	CategoryInjection = "injection"
+)
+
+const (
+	// AuthNone indicates that the probe request carries no credentials.
+	AuthNone = "none"
+	// AuthToken indicates that the probe request carries the credentials given with -token.
+	AuthToken = "token"
+	// AuthLowToken indicates that the probe request carries the credentials given with
+	// -low-token.
+	AuthLowToken = "low-token"
+)
+
+// Generator is the security test suite generator.
+type Generator struct {
+	genfiles []string
+}
+
+type (
+	// Suite lists the probes run by the generated tool.
+	Suite struct {
+		// API is the API name.
+		API string
+		// Probes lists the probes sorted by resource and action names.
+		Probes []*Probe
+	}
+
+	// Probe describes a single request made by the generated tool and the responses it
+	// expects.
+	Probe struct {
+		// Category is the probe category, one of the Category constants.
+		Category string
+		// Resource is the name of the probed action resource.
+		Resource string
+		// Action is the name of the probed action.
+		Action string
+		// Method is the request method.
+		Method string
+		// Path is the route path including the wildcards.
+		Path string
+		// Params lists the values of the path wildcards indexed by name.
+		Params map[string]string
+		// Query lists the values of the required query string parameters indexed by name.
+		Query map[string]string
+		// Auth is the credentials used to make the request, one of the Auth constants.
+		Auth string
+		// CredIn is the location of the credentials, "header" or "query".
+		CredIn string
+		// CredName is the name of the header or query string parameter holding the
+		// credentials.
+		CredName string
+		// ContentType is the value of the request Content-Type header if any.
+		ContentType string
+		// Body is the request body if any.
+		Body string
+		// MaxSize is the maximum size of the action request bodies set in the design for
+		// the oversized payload probes, zero if the design sets none.
+		MaxSize int64
+		// Inject is the name of the parameter the injection probes send their payloads in.
+		Inject string
+		// InjectIn is the location of the Inject parameter, "path" or "query".
+		InjectIn string
+		// Expect lists the accepted response statuses separated with commas, "4xx" matches
+		// any client error and "!5xx" any status but server errors.
+		Expect string
+	}
+)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "Security test suite generator",
+		Long:  "Security test suite generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// ToolDir returns the path to the directory where the security test tool is generated.
+func ToolDir() string {
+	return filepath.Join(codegen.OutputDir, TargetDir)
+}
+
+// Generate writes the security test tool to the target directory.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	os.RemoveAll(ToolDir())
+	if err = os.MkdirAll(ToolDir(), 0755); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, ToolDir())
+	s := NewSuite(api)
+	if err = g.generateProbes(api, s); err != nil {
+		return
+	}
+	if err = g.generateMain(api); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.RemoveAll(f)
+	}
+	g.genfiles = nil
+}
+
+// NewSuite computes the probes of the API actions.
+func NewSuite(api *design.APIDefinition) *Suite {
+	s := &Suite{API: api.Name}
+	contentType := "application/json"
+	if len(api.Consumes) > 0 && len(api.Consumes[0].MIMETypes) > 0 {
+		contentType = api.Consumes[0].MIMETypes[0]
+	}
+	api.IterateResources(func(res *design.ResourceDefinition) error {
+		return res.IterateActions(func(a *design.ActionDefinition) error {
+			if len(a.Routes) == 0 || a.IsWebSocket() {
+				return nil
+			}
+			route := a.Routes[0]
+			params := a.AllParams()
+			obj := params.Type.ToObject()
+			base := Probe{
+				Resource: res.Name,
+				Action:   a.Name,
+				Method:   route.Verb,
+				Path:     route.FullPath(api.APIVersionDefinition),
+				Params:   make(map[string]string),
+				Query:    make(map[string]string),
+				Auth:     AuthNone,
+			}
+			wildcards := make(map[string]bool)
+			for _, p := range design.ExtractWildcards(base.Path) {
+				base.Params[p] = sampleValue(obj[p])
+				wildcards[p] = true
+			}
+			for _, n := range sortedNames(obj) {
+				if !wildcards[n] && params.IsRequired(n) {
+					base.Query[n] = sampleValue(obj[n])
+				}
+			}
+			add := func(p Probe) { s.Probes = append(s.Probes, &p) }
+
+			sec := a.EffectiveSecurity()
+			if sec != nil && sec.Scheme.Kind != design.MutualTLSSecurityKind {
+				base.CredIn, base.CredName = credentials(sec.Scheme)
+				p := base
+				p.Category = CategoryUnauthenticated
+				p.Expect = "401,403"
+				add(p)
+				if len(sec.Scopes) > 0 {
+					p = base
+					p.Category = CategoryScopeEscalation
+					p.Auth = AuthLowToken
+					p.Expect = "403"
+					add(p)
+				}
+				base.Auth = AuthToken
+			}
+
+			if a.Payload != nil {
+				p := base
+				p.Category = CategoryOversizedPayload
+				p.ContentType = contentType
+				p.MaxSize = a.EffectiveMaxSize()
+				p.Expect = "413"
+				add(p)
+				p = base
+				p.Category = CategoryContentType
+				p.ContentType = "application/json"
+				p.Body = `<?xml version="1.0"?><probe/>`
+				p.Expect = "4xx"
+				add(p)
+			}
+
+			for _, n := range sortedNames(obj) {
+				att := obj[n]
+				if att.Type.Kind() != design.StringKind || (att.Validation != nil && len(att.Validation.Values) > 0) {
+					continue
+				}
+				in := "query"
+				if wildcards[n] {
+					in = "path"
+				}
+				p := base
+				p.Category = CategoryInjection
+				p.Inject = n
+				p.InjectIn = in
+				p.Expect = "!5xx"
+				add(p)
+			}
+			return nil
+		})
+	})
+	return s
+}
+
+// generateProbes generates the file listing the probes.
+func (g *Generator) generateProbes(api *design.APIDefinition, s *Suite) error {
+	filename := filepath.Join(ToolDir(), "probes.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	title := fmt.Sprintf("%s: Security Probes", api.Context())
+	if err := file.WriteHeader(title, "main", nil); err != nil {
+		return err
+	}
+	if err := file.ExecuteTemplate("probes", probesT, nil, s); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// generateMain generates the tool main function which runs the probes and reports the results.
+func (g *Generator) generateMain(api *design.APIDefinition) error {
+	filename := filepath.Join(ToolDir(), "main.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("encoding/json"),
+		codegen.SimpleImport("flag"),
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("io"),
+		codegen.SimpleImport("io/ioutil"),
+		codegen.SimpleImport("net/http"),
+		codegen.SimpleImport("net/url"),
+		codegen.SimpleImport("os"),
+		codegen.SimpleImport("strconv"),
+		codegen.SimpleImport("strings"),
+		codegen.SimpleImport("time"),
+	}
+	title := fmt.Sprintf("%s: Security Test Tool", api.Context())
+	if err := file.WriteHeader(title, "main", imports); err != nil {
+		return err
+	}
+	data := map[string]interface{}{"API": api.Name}
+	if err := file.ExecuteTemplate("main", mainT, nil, data); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// credentials returns the location and the name of the header or query string parameter holding
+// the credentials of the given scheme.
+func credentials(scheme *design.SecuritySchemeDefinition) (string, string) {
+	switch scheme.Kind {
+	case design.APIKeySecurityKind, design.JWTSecurityKind, design.OIDCSecurityKind, design.HMACSecurityKind:
+		if scheme.Name != "" {
+			in := scheme.In
+			if in == "" {
+				in = "header"
+			}
+			return in, scheme.Name
+		}
+	}
+	return "header", "Authorization"
+}
+
+// sampleValue returns a value of the given parameter that passes the validations of common
+// designs: the example or the first enum value if any, a value of the parameter type otherwise.
+func sampleValue(att *design.AttributeDefinition) string {
+	if att == nil {
+		return "probe"
+	}
+	if att.Example != nil {
+		return fmt.Sprintf("%v", att.Example)
+	}
+	if att.Validation != nil && len(att.Validation.Values) > 0 {
+		return fmt.Sprintf("%v", att.Validation.Values[0])
+	}
+	switch att.Type.Kind() {
+	case design.IntegerKind, design.NumberKind:
+		return "1"
+	case design.BooleanKind:
+		return "true"
+	case design.DateTimeKind:
+		return "2017-01-01T00:00:00Z"
+	}
+	if att.Validation != nil && att.Validation.Format == "uuid" {
+		return "00000000-0000-0000-0000-000000000001"
+	}
+	return "probe"
+}
+
+// sortedNames returns the names of the object attributes sorted alphabetically.
+func sortedNames(obj design.Object) []string {
+	names := make([]string, 0, len(obj))
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+const probesT = `// probes lists the security probes derived from the {{ .API }} API design.
+var probes = []*probe{
+{{ range .Probes }}	{
+		Category: {{ printf "%q" .Category }},
+		Resource: {{ printf "%q" .Resource }},
+		Action:   {{ printf "%q" .Action }},
+		Method:   {{ printf "%q" .Method }},
+		Path:     {{ printf "%q" .Path }},
+{{ if .Params }}		Params:   map[string]string{ {{ range $k, $v := .Params }}{{ printf "%q" $k }}: {{ printf "%q" $v }}, {{ end }}},
+{{ end }}{{ if .Query }}		Query:    map[string]string{ {{ range $k, $v := .Query }}{{ printf "%q" $k }}: {{ printf "%q" $v }}, {{ end }}},
+{{ end }}		Auth:     {{ printf "%q" .Auth }},
+{{ if .CredName }}		CredIn:   {{ printf "%q" .CredIn }},
+		CredName: {{ printf "%q" .CredName }},
+{{ end }}{{ if .ContentType }}		ContentType: {{ printf "%q" .ContentType }},
+{{ end }}{{ if .Body }}		Body:     {{ printf "%q" .Body }},
+{{ end }}{{ if .MaxSize }}		MaxSize:  {{ .MaxSize }},
+{{ end }}{{ if .Inject }}		Inject:   {{ printf "%q" .Inject }},
+		InjectIn: {{ printf "%q" .InjectIn }},
+{{ end }}		Expect:   {{ printf "%q" .Expect }},
+	},
+{{ end }}}
+`
+
+const mainT = `type (
+	// probe describes a request made to the service and the response statuses it expects.
+	probe struct {
+		Category    string
+		Resource    string
+		Action      string
+		Method      string
+		Path        string
+		Params      map[string]string
+		Query       map[string]string
+		Auth        string
+		CredIn      string
+		CredName    string
+		ContentType string
+		Body        string
+		MaxSize     int64
+		Inject      string
+		InjectIn    string
+		Expect      string
+	}
+
+	// result is the outcome of a probe request.
+	result struct {
+		Category string ` + "`" + `json:"category"` + "`" + `
+		Resource string ` + "`" + `json:"resource"` + "`" + `
+		Action   string ` + "`" + `json:"action"` + "`" + `
+		Method   string ` + "`" + `json:"method"` + "`" + `
+		URL      string ` + "`" + `json:"url"` + "`" + `
+		Payload  string ` + "`" + `json:"payload,omitempty"` + "`" + `
+		Expected string ` + "`" + `json:"expected"` + "`" + `
+		Status   int    ` + "`" + `json:"status,omitempty"` + "`" + `
+		Outcome  string ` + "`" + `json:"outcome"` + "`" + `
+		Detail   string ` + "`" + `json:"detail,omitempty"` + "`" + `
+	}
+
+	// report is the document written by the tool.
+	report struct {
+		API     string    ` + "`" + `json:"api"` + "`" + `
+		Passed  int       ` + "`" + `json:"passed"` + "`" + `
+		Failed  int       ` + "`" + `json:"failed"` + "`" + `
+		Skipped int       ` + "`" + `json:"skipped"` + "`" + `
+		Results []*result ` + "`" + `json:"results"` + "`" + `
+	}
+)
+
+// injections lists the payloads sent by the injection probes.
+var injections = []string{
+	"' OR '1'='1",
+	"'; DROP TABLE users; --",
+	"<script>alert(1)</script>",
+	"../../../../etc/passwd",
+	"; cat /etc/passwd",
+	"${7*7}",
+}
+
+func main() {
+	var (
+		scheme   = flag.String("scheme", "http", "Scheme used to make the requests")
+		host     = flag.String("host", "localhost:8080", "Host of the service")
+		token    = flag.String("token", "", "Credentials granting access to the secured actions")
+		lowToken = flag.String("low-token", "", "Credentials lacking the scopes required by the secured actions")
+		maxSize  = flag.Int64("max-size", 10<<20, "Maximum body size enforced by the service on the actions whose design sets none")
+		timeout  = flag.Duration("timeout", 20*time.Second, "Timeout of the requests")
+		out      = flag.String("out", "", "Path of the JSON results file, stdout if empty")
+	)
+	flag.Parse()
+
+	client := &http.Client{Timeout: *timeout}
+	creds := map[string]string{"none": "", "token": *token, "low-token": *lowToken}
+	rep := &report{API: {{ printf "%q" .API }}}
+	for _, p := range probes {
+		payloads := []string{""}
+		if p.Inject != "" {
+			payloads = injections
+		}
+		for _, payload := range payloads {
+			r := run(client, *scheme, *host, p, creds[p.Auth], payload, *maxSize)
+			switch r.Outcome {
+			case "pass":
+				rep.Passed++
+			case "skip":
+				rep.Skipped++
+			default:
+				rep.Failed++
+			}
+			rep.Results = append(rep.Results, r)
+		}
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rep); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	fmt.Fprintf(os.Stderr, "%d passed, %d failed, %d skipped\n", rep.Passed, rep.Failed, rep.Skipped)
+	if rep.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// run makes the probe request sending payload in the injected parameter if any and checks the
+// response.
+func run(client *http.Client, scheme, host string, p *probe, cred, payload string, maxSize int64) *result {
+	r := &result{
+		Category: p.Category,
+		Resource: p.Resource,
+		Action:   p.Action,
+		Method:   p.Method,
+		Payload:  payload,
+		Expected: p.Expect,
+	}
+	query := url.Values{}
+	for n, v := range p.Query {
+		query.Set(n, v)
+	}
+	params := make(map[string]string, len(p.Params))
+	for n, v := range p.Params {
+		params[n] = v
+	}
+	if p.Inject != "" {
+		if p.InjectIn == "path" {
+			params[p.Inject] = payload
+		} else {
+			query.Set(p.Inject, payload)
+		}
+	}
+	r.URL = scheme + "://" + host + expand(p.Path, params)
+	if len(query) > 0 {
+		r.URL += "?" + query.Encode()
+	}
+	if p.Auth != "none" && cred == "" {
+		r.Outcome = "skip"
+		r.Detail = "no -" + p.Auth + " given"
+		return r
+	}
+
+	var body io.Reader
+	if p.Category == "oversized-payload" {
+		size := p.MaxSize
+		if size == 0 {
+			size = maxSize
+		}
+		body = strings.NewReader(` + "`" + `{"probe":"` + "`" + ` + strings.Repeat("a", int(size)) + ` + "`" + `"}` + "`" + `)
+	} else if p.Body != "" {
+		body = strings.NewReader(p.Body)
+	}
+	req, err := http.NewRequest(p.Method, r.URL, body)
+	if err != nil {
+		r.Outcome = "fail"
+		r.Detail = err.Error()
+		return r
+	}
+	if p.ContentType != "" {
+		req.Header.Set("Content-Type", p.ContentType)
+	}
+	if p.Auth != "none" {
+		if p.CredIn == "query" {
+			q := req.URL.Query()
+			q.Set(p.CredName, cred)
+			req.URL.RawQuery = q.Encode()
+		} else {
+			req.Header.Set(p.CredName, cred)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		r.Outcome = "fail"
+		r.Detail = err.Error()
+		return r
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	r.Status = resp.StatusCode
+	r.Outcome = "pass"
+	if !expected(p.Expect, resp.StatusCode) {
+		r.Outcome = "fail"
+		r.Detail = "unexpected response status " + resp.Status
+	} else if strings.Contains(payload, "<") && strings.Contains(string(b), payload) {
+		r.Outcome = "fail"
+		r.Detail = "payload reflected unescaped in the response"
+	}
+	return r
+}
+
+// expand replaces the wildcards of the given route path with the escaped parameter values.
+func expand(path string, params map[string]string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if len(s) > 1 && (s[0] == ':' || s[0] == '*') {
+			segments[i] = strings.Replace(url.QueryEscape(params[s[1:]]), "+", "%20", -1)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// expected returns true if the status is one of the statuses listed in expect, see probe.Expect.
+func expected(expect string, status int) bool {
+	for _, e := range strings.Split(expect, ",") {
+		switch e {
+		case "4xx":
+			if status >= 400 && status < 500 {
+				return true
+			}
+		case "!5xx":
+			if status < 500 {
+				return true
+			}
+		default:
+			if n, err := strconv.Atoi(e); err == nil && n == status {
+				return true
+			}
+		}
+	}
+	return false
+}
+`
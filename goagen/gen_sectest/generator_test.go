@@ -0,0 +1,113 @@
+package gensectest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_sectest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var oldDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("sectesttest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+		oldDesign = design.Design
+
+		jwt := &design.SecuritySchemeDefinition{Kind: design.JWTSecurityKind, SchemeName: "jwt"}
+		res := &design.ResourceDefinition{
+			Name:     "bottles",
+			BasePath: "/bottles",
+			Security: &design.SecurityDefinition{Scheme: jwt},
+		}
+		show := &design.ActionDefinition{Name: "show", Parent: res}
+		show.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "/:id", Parent: show}}
+		show.Params = &design.AttributeDefinition{
+			Type: design.Object{
+				"id":   {Type: design.Integer},
+				"view": {Type: design.String, Validation: &dslengine.ValidationDefinition{Values: []interface{}{"default", "tiny"}}},
+			},
+		}
+		create := &design.ActionDefinition{Name: "create", Parent: res}
+		create.Routes = []*design.RouteDefinition{{Verb: "POST", Path: "", Parent: create}}
+		create.Security = &design.SecurityDefinition{Scheme: jwt, Scopes: []string{"bottles:write"}}
+		create.Params = &design.AttributeDefinition{
+			Type:       design.Object{"account": {Type: design.String}},
+			Validation: &dslengine.ValidationDefinition{Required: []string{"account"}},
+		}
+		create.Payload = &design.UserTypeDefinition{
+			AttributeDefinition: &design.AttributeDefinition{
+				Type:     design.Object{"name": {Type: design.String}},
+				Metadata: dslengine.MetadataDefinition{design.MaxSizeKey: {"1024"}},
+			},
+			TypeName: "CreateBottlePayload",
+		}
+		res.Actions = map[string]*design.ActionDefinition{"show": show, "create": create}
+		design.Design = &design.APIDefinition{
+			APIVersionDefinition: &design.APIVersionDefinition{Name: "cellar"},
+			Resources:            map[string]*design.ResourceDefinition{"bottles": res},
+		}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = gensectest.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+		design.Design = oldDesign
+	})
+
+	It("derives the probes from the design", func() {
+		s := gensectest.NewSuite(design.Design)
+		var categories []string
+		for _, p := range s.Probes {
+			categories = append(categories, p.Action+" "+p.Category)
+		}
+		Ω(categories).Should(Equal([]string{
+			"create unauthenticated",
+			"create scope-escalation",
+			"create oversized-payload",
+			"create content-type-confusion",
+			"create injection",
+			"show unauthenticated",
+		}))
+		Ω(s.Probes[0].Query).Should(Equal(map[string]string{"account": "probe"}))
+		Ω(s.Probes[0].CredName).Should(Equal("Authorization"))
+		Ω(s.Probes[2].MaxSize).Should(Equal(int64(1024)))
+		Ω(s.Probes[2].Auth).Should(Equal(gensectest.AuthToken))
+		Ω(s.Probes[4].Inject).Should(Equal("account"))
+		Ω(s.Probes[4].InjectIn).Should(Equal("query"))
+		Ω(s.Probes[5].Params).Should(Equal(map[string]string{"id": "1"}))
+	})
+
+	It("generates the security test tool", func() {
+		Ω(genErr).ShouldNot(HaveOccurred())
+		Ω(files).Should(HaveLen(3))
+		content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "sectest", "probes.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(content)).Should(ContainSubstring("package main"))
+		Ω(string(content)).Should(ContainSubstring(`Category: "scope-escalation",`))
+		Ω(string(content)).Should(MatchRegexp(`Params: +map\[string\]string{"id": "1"},`))
+		Ω(string(content)).Should(MatchRegexp(`MaxSize: +1024,`))
+		content, err = ioutil.ReadFile(filepath.Join(testPkg.Abs(), "sectest", "main.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(content)).Should(ContainSubstring(`rep := &report{API: "cellar"}`))
+		Ω(string(content)).Should(ContainSubstring("func expected(expect string, status int) bool {"))
+	})
+})
@@ -0,0 +1,47 @@
+package gensoap
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// TargetPackage is the name of the generated Go package.
+	TargetPackage string
+
+	// Namespace is the target namespace of the generated WSDL.
+	Namespace string
+
+	// Path is the path of the SOAP endpoint written to the WSDL service address.
+	Path string
+)
+
+// Command is the goa SOAP facade generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("soap", "Generate the WSDL and the SOAP endpoint bridging to the controllers")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&TargetPackage, "pkg", "soap", "Name of the generated Go package")
+	r.Flags().StringVar(&Namespace, "namespace", "", `Target namespace of the WSDL, defaults to "urn:<API name>"`)
+	r.Flags().StringVar(&Path, "path", "/soap", "Path of the SOAP endpoint")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"pkg": TargetPackage, "namespace": Namespace, "path": Path}
+	gen := meta.NewGenerator(
+		"gensoap.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_soap")},
+		flags,
+	)
+	return gen.Generate()
+}
@@ -0,0 +1,23 @@
+/*
+Package gensoap provides a generator for a SOAP 1.1 facade of the API so that legacy enterprise
+clients can consume it without a separate service. The generator renders the design as a WSDL
+document using the document/literal wrapped style: each action is exposed as an operation named
+after its action and resource ("ShowBottle"), operations of actions defined in a non default API
+version are prefixed with the version ("V1ShowBottle"). The request element of an operation
+contains one child element per action parameter and a "payload" element for actions that have a
+payload, the response element contains the action response media type.
+
+The generator also produces the Go code that creates a goa.SOAPServer bridging each operation to
+the action it was generated from and serving the WSDL:
+
+	service := goa.New("cellar")
+	app.MountBottleController(service, NewBottleController(service))
+	soap.NewServer(service).Mount("/soap")
+	service.ListenAndServe(":8080")
+
+The WSDL is served in response to GET requests made to the endpoint path with the "wsdl"
+querystring parameter ("/soap?wsdl") and is also written to the "service.wsdl" file. Media type
+attributes are rendered in the responses in alphabetical order, arrays as repeated elements and
+hashes and attributes of type Any as elements of type xsd:anyType.
+*/
+package gensoap
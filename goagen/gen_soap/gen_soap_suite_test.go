@@ -0,0 +1,13 @@
+package gensoap_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenSOAP(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenSOAP Suite")
+}
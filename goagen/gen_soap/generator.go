@@ -0,0 +1,247 @@
+package gensoap
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the SOAP facade generator.
+type Generator struct {
+	genfiles []string
+	types    *codegen.TypeCollector
+}
+
+// Handler contains the data needed to generate the dispatch entry of a single SOAP operation.
+type Handler struct {
+	// Name is the operation name, e.g. "ShowBottle".
+	Name string
+	// Version is the name of the API version defining the action, empty for the default version.
+	Version string
+	// Verb is the HTTP method of the action route.
+	Verb string
+	// Path is the full path of the action route.
+	Path string
+	// Params is the name of the struct the request element is decoded into, empty if the
+	// action has no parameter and no payload.
+	Params string
+	// ParamsDef is the definition of the params struct.
+	ParamsDef string
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "SOAP facade generator",
+		Long:  "WSDL and SOAP endpoint generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// SOAPDir returns the path to the directory where the SOAP facade files are generated.
+func SOAPDir() string {
+	return filepath.Join(codegen.OutputDir, TargetPackage)
+}
+
+// Generate produces the WSDL, the params types and the SOAP server Go code.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	os.RemoveAll(SOAPDir())
+	if err = os.MkdirAll(SOAPDir(), 0755); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, SOAPDir())
+	g.types = codegen.NewTypeCollector()
+
+	ns := Namespace
+	if ns == "" {
+		ns = "urn:" + codegen.Goify(api.Name, false)
+	}
+	wsdl, err := NewWSDL(api, ns, location(api))
+	if err != nil {
+		return
+	}
+	var handlers []*Handler
+	err = api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		return v.IterateResources(func(r *design.ResourceDefinition) error {
+			return r.IterateActions(func(a *design.ActionDefinition) error {
+				if len(a.Routes) == 0 {
+					return nil
+				}
+				handlers = append(handlers, g.handler(a, v))
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return
+	}
+	sort.Sort(byHandlerName(handlers))
+
+	doc := wsdl.Render()
+	wsdlFile := filepath.Join(SOAPDir(), "service.wsdl")
+	if err = ioutil.WriteFile(wsdlFile, doc, 0644); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, wsdlFile)
+	if err = g.generateTypes(api, handlers); err != nil {
+		return
+	}
+	if err = g.generateServer(api, ns, doc, handlers); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes the entire SOAP directory if it was created by this generator.
+func (g *Generator) Cleanup() {
+	if len(g.genfiles) == 0 {
+		return
+	}
+	os.RemoveAll(SOAPDir())
+	g.genfiles = nil
+}
+
+// handler builds the dispatch entry of the operation exposing the given action and records the
+// types its params struct uses.
+func (g *Generator) handler(a *design.ActionDefinition, v *design.APIVersionDefinition) *Handler {
+	route := a.Routes[0]
+	h := &Handler{Name: OperationName(a, v), Verb: route.Verb, Path: route.FullPath(v)}
+	if !v.IsDefault() {
+		h.Version = v.Version
+	}
+	att := requestObject(a)
+	if len(att.Type.ToObject()) > 0 {
+		g.types.Collect(att)
+		h.Params = h.Name + "Params"
+		h.ParamsDef = codegen.GoTypeDef(att, false, "", 0, true)
+	}
+	return h
+}
+
+// generateTypes generates the params structs and the types they use.
+func (g *Generator) generateTypes(api *design.APIDefinition, handlers []*Handler) error {
+	filename := filepath.Join(SOAPDir(), "types.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("time")}
+	title := fmt.Sprintf("%s: SOAP Types", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	types := g.types.Types()
+	data := map[string]interface{}{"Handlers": handlers, "Types": types}
+	if err := file.ExecuteTemplate("types", typesTmpl, nil, data); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// generateServer generates the operation dispatch table, the WSDL and the code that creates the
+// SOAP server.
+func (g *Generator) generateServer(api *design.APIDefinition, ns string, wsdl []byte, handlers []*Handler) error {
+	filename := filepath.Join(SOAPDir(), "server.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa")}
+	title := fmt.Sprintf("%s: SOAP Server", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	data := map[string]interface{}{
+		"Namespace": ns,
+		"WSDL":      strings.TrimSpace(string(wsdl)),
+		"Handlers":  handlers,
+	}
+	if err := file.ExecuteTemplate("server", serverTmpl, nil, data); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// location returns the URL of the SOAP endpoint built from the first API scheme, the API host and
+// the endpoint path.
+func location(api *design.APIDefinition) string {
+	scheme := "http"
+	if len(api.Schemes) > 0 {
+		scheme = api.Schemes[0]
+	}
+	host := api.Host
+	if host == "" {
+		host = "localhost"
+	}
+	return scheme + "://" + host + Path
+}
+
+// byHandlerName makes it possible to sort handlers by name.
+type byHandlerName []*Handler
+
+func (b byHandlerName) Len() int           { return len(b) }
+func (b byHandlerName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byHandlerName) Less(i, j int) bool { return b[i].Name < b[j].Name }
+
+const typesTmpl = `{{range .Handlers}}{{if .Params}}// {{.Params}} is the type the {{.Name}} request element is decoded into.
+type {{.Params}} {{.ParamsDef}}
+
+{{end}}{{end}}{{range .Types}}{{if .Description}}{{comment .Description}}{{else}}// {{.Name}} is a type used by the operation params.{{end}}
+type {{.Name}} {{.Def}}
+
+{{end}}`
+
+const serverTmpl = `// Namespace is the target namespace of the WSDL.
+const Namespace = "{{.Namespace}}"
+
+// WSDL is the WSDL document describing the SOAP operations.
+const WSDL = ` + "`{{.WSDL}}`" + `
+
+// Operations is the SOAP operation dispatch table, it maps each operation to the route of the
+// action it was generated from.
+var Operations = map[string]*goa.SOAPOperation{
+{{range .Handlers}}	"{{.Name}}": { {{if .Version}}Version: "{{.Version}}", {{end}}Verb: "{{.Verb}}", Path: "{{.Path}}"{{if .Params}}, NewParams: func() interface{} { return new({{.Params}}) }{{end}}},
+{{end}}}
+
+// NewServer returns a SOAP server exposing the operations listed in Operations and serving WSDL.
+// Each operation is bridged to the action it was generated from so that the controllers mounted
+// on the service handle both the REST and the SOAP requests.
+func NewServer(service *goa.Service) *goa.SOAPServer {
+	s := goa.NewSOAPServer(service, Namespace)
+	s.WSDL = WSDL
+	for name, op := range Operations {
+		s.Handle(name, op)
+	}
+	return s
+}
+`
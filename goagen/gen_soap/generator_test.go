@@ -0,0 +1,82 @@
+package gensoap_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/codegen/codegentest"
+	"github.com/goadesign/goa/goagen/gen_soap"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var prevDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("soaptest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+
+		prevDesign = design.Design
+		design.Design = codegentest.CellarAPI()
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = gensoap.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		design.Design = prevDesign
+		workspace.Delete()
+	})
+
+	It("generates the WSDL, the params types and the server", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(4))
+		Ω(codegentest.ParseDir(filepath.Join(testPkg.Abs(), "soap"))).Should(Succeed())
+
+		wsdl, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "soap", "service.wsdl"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(wsdl)).Should(ContainSubstring(`<definitions name="cellar" targetNamespace="urn:cellar"`))
+		Ω(string(wsdl)).Should(ContainSubstring("<xsd:complexType name=\"Bottle\">\n\t\t\t\t<xsd:sequence>\n" +
+			"\t\t\t\t\t<xsd:element name=\"id\" type=\"xsd:long\"/>\n" +
+			"\t\t\t\t\t<xsd:element name=\"name\" type=\"xsd:string\" minOccurs=\"0\"/>\n" +
+			"\t\t\t\t\t<xsd:element name=\"rating\" type=\"xsd:double\" minOccurs=\"0\"/>\n" +
+			"\t\t\t\t\t<xsd:element name=\"tags\" type=\"xsd:string\" minOccurs=\"0\" maxOccurs=\"unbounded\"/>\n"))
+		Ω(string(wsdl)).Should(ContainSubstring(`<xsd:element name="metadata" type="xsd:anyType" minOccurs="0"/>`))
+		Ω(string(wsdl)).Should(ContainSubstring(`<xsd:element name="ShowBottleResponse" type="tns:Bottle"/>`))
+		Ω(string(wsdl)).Should(ContainSubstring(`<xsd:element name="item" type="tns:Bottle" minOccurs="0" maxOccurs="unbounded"/>`))
+		Ω(string(wsdl)).Should(ContainSubstring(`<xsd:element name="CreateBottleResponse" type="xsd:anyType"/>`))
+		Ω(string(wsdl)).Should(ContainSubstring(`<xsd:element name="payload" type="tns:CreateBottlePayload"/>`))
+		Ω(string(wsdl)).Should(ContainSubstring(`<documentation>Retrieve bottle with given id</documentation>`))
+		Ω(string(wsdl)).Should(ContainSubstring(`<soap:operation soapAction="urn:cellar/ShowBottle"/>`))
+		Ω(string(wsdl)).Should(ContainSubstring(`<soap:address location="http://cellar.example.com/soap"/>`))
+
+		types, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "soap", "types.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(types)).Should(ContainSubstring("type ShowBottleParams struct {\n\tID int `json:\"id\" xml:\"id\"`\n}"))
+		Ω(string(types)).Should(ContainSubstring("type CreateBottleParams struct {\n\tPayload *CreateBottlePayload `json:\"payload\" xml:\"payload\"`\n}"))
+		Ω(string(types)).Should(ContainSubstring("type CreateBottlePayload struct {"))
+		Ω(string(types)).ShouldNot(ContainSubstring("type Bottle struct {"))
+		Ω(string(types)).ShouldNot(ContainSubstring("ListBottleParams"))
+
+		server, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "soap", "server.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(server)).Should(ContainSubstring(`const Namespace = "urn:cellar"`))
+		Ω(string(server)).Should(ContainSubstring("const WSDL = `<?xml version=\"1.0\" encoding=\"UTF-8\"?>"))
+		Ω(string(server)).Should(ContainSubstring(`"ShowBottle":   {Verb: "GET", Path: "/bottles/:id", NewParams: func() interface{} { return new(ShowBottleParams) }},`))
+		Ω(string(server)).Should(ContainSubstring(`"ListBottle":   {Verb: "GET", Path: "/bottles"},`))
+		Ω(string(server)).Should(ContainSubstring("func NewServer(service *goa.Service) *goa.SOAPServer {"))
+	})
+})
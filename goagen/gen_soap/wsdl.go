@@ -0,0 +1,367 @@
+package gensoap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+type (
+	// WSDL describes a WSDL 1.1 document that uses the SOAP 1.1 binding.
+	WSDL struct {
+		// Name is the name of the API.
+		Name string
+		// Documentation is the API description if any.
+		Documentation string
+		// Namespace is the target namespace.
+		Namespace string
+		// Location is the URL of the SOAP endpoint.
+		Location string
+		// Types lists the named complex types sorted by name.
+		Types []*ComplexType
+		// Operations lists the operations sorted by name.
+		Operations []*Operation
+	}
+
+	// Operation describes a SOAP operation.
+	Operation struct {
+		// Name is the operation name, also the name of the request element.
+		Name string
+		// Documentation is the action description if any.
+		Documentation string
+		// Request describes the request element content.
+		Request *ComplexType
+		// Response describes the response element.
+		Response *Element
+	}
+
+	// ComplexType describes a XML schema complex type whose content is a sequence of elements.
+	ComplexType struct {
+		// Name is the type name, empty for anonymous types.
+		Name string
+		// Documentation is the type description if any.
+		Documentation string
+		// Elements lists the sequence elements sorted by name.
+		Elements []*Element
+	}
+
+	// Element describes a XML schema element.
+	Element struct {
+		// Name is the element name.
+		Name string
+		// Type is the qualified name of the element type, empty if the type is anonymous.
+		Type string
+		// Inline is the anonymous type of the element if any.
+		Inline *ComplexType
+		// Optional is true if the element may be omitted.
+		Optional bool
+		// Unbounded is true if the element may be repeated.
+		Unbounded bool
+	}
+
+	// builder builds the WSDL types from the design attributes.
+	builder struct {
+		types map[string]*ComplexType
+	}
+)
+
+// NewWSDL builds the WSDL describing the operations of all the actions of the API.
+func NewWSDL(api *design.APIDefinition, namespace, location string) (*WSDL, error) {
+	w := &WSDL{
+		Name:          api.Name,
+		Documentation: api.Description,
+		Namespace:     namespace,
+		Location:      location,
+	}
+	b := &builder{types: make(map[string]*ComplexType)}
+	err := api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		return v.IterateResources(func(r *design.ResourceDefinition) error {
+			return r.IterateActions(func(a *design.ActionDefinition) error {
+				if len(a.Routes) == 0 {
+					return nil
+				}
+				w.Operations = append(w.Operations, b.operation(a, v))
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(byName(w.Operations))
+	var names []string
+	for n := range b.types {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		w.Types = append(w.Types, b.types[n])
+	}
+	return w, nil
+}
+
+// OperationName returns the name of the operation exposing the given action.
+func OperationName(a *design.ActionDefinition, v *design.APIVersionDefinition) string {
+	name := codegen.Goify(a.Name, true) + codegen.Goify(a.Parent.Name, true)
+	if !v.IsDefault() {
+		name = codegen.Goify(codegen.VersionPackage(v.Version), true) + name
+	}
+	return name
+}
+
+// Render produces the WSDL document.
+func (w *WSDL) Render() []byte {
+	var buf bytes.Buffer
+	service := codegen.Goify(w.Name, true)
+	buf.WriteString(xml.Header)
+	fmt.Fprintf(&buf, "<definitions name=%s targetNamespace=%s xmlns=\"http://schemas.xmlsoap.org/wsdl/\" xmlns:soap=\"http://schemas.xmlsoap.org/wsdl/soap/\" xmlns:tns=%s xmlns:xsd=\"http://www.w3.org/2001/XMLSchema\">\n",
+		attr(w.Name), attr(w.Namespace), attr(w.Namespace))
+	writeDocumentation(&buf, "", w.Documentation, 1)
+	buf.WriteString("\t<types>\n")
+	fmt.Fprintf(&buf, "\t\t<xsd:schema targetNamespace=%s elementFormDefault=\"unqualified\">\n", attr(w.Namespace))
+	for _, t := range w.Types {
+		t.render(&buf, 3)
+	}
+	for _, o := range w.Operations {
+		req := &Element{Name: o.Name, Inline: o.Request}
+		req.render(&buf, 3)
+		o.Response.render(&buf, 3)
+	}
+	buf.WriteString("\t\t</xsd:schema>\n")
+	buf.WriteString("\t</types>\n")
+	for _, o := range w.Operations {
+		fmt.Fprintf(&buf, "\t<message name=\"%sRequest\">\n\t\t<part name=\"parameters\" element=\"tns:%s\"/>\n\t</message>\n", o.Name, o.Name)
+		fmt.Fprintf(&buf, "\t<message name=\"%sResponse\">\n\t\t<part name=\"parameters\" element=\"tns:%s\"/>\n\t</message>\n", o.Name, o.Response.Name)
+	}
+	fmt.Fprintf(&buf, "\t<portType name=\"%sPortType\">\n", service)
+	for _, o := range w.Operations {
+		fmt.Fprintf(&buf, "\t\t<operation name=\"%s\">\n", o.Name)
+		writeDocumentation(&buf, "", o.Documentation, 3)
+		fmt.Fprintf(&buf, "\t\t\t<input message=\"tns:%sRequest\"/>\n", o.Name)
+		fmt.Fprintf(&buf, "\t\t\t<output message=\"tns:%sResponse\"/>\n", o.Name)
+		buf.WriteString("\t\t</operation>\n")
+	}
+	buf.WriteString("\t</portType>\n")
+	fmt.Fprintf(&buf, "\t<binding name=\"%sBinding\" type=\"tns:%sPortType\">\n", service, service)
+	buf.WriteString("\t\t<soap:binding style=\"document\" transport=\"http://schemas.xmlsoap.org/soap/http\"/>\n")
+	for _, o := range w.Operations {
+		fmt.Fprintf(&buf, "\t\t<operation name=\"%s\">\n", o.Name)
+		fmt.Fprintf(&buf, "\t\t\t<soap:operation soapAction=%s/>\n", attr(w.Namespace+"/"+o.Name))
+		buf.WriteString("\t\t\t<input>\n\t\t\t\t<soap:body use=\"literal\"/>\n\t\t\t</input>\n")
+		buf.WriteString("\t\t\t<output>\n\t\t\t\t<soap:body use=\"literal\"/>\n\t\t\t</output>\n")
+		buf.WriteString("\t\t</operation>\n")
+	}
+	buf.WriteString("\t</binding>\n")
+	fmt.Fprintf(&buf, "\t<service name=\"%sService\">\n", service)
+	fmt.Fprintf(&buf, "\t\t<port name=\"%sPort\" binding=\"tns:%sBinding\">\n", service, service)
+	fmt.Fprintf(&buf, "\t\t\t<soap:address location=%s/>\n", attr(w.Location))
+	buf.WriteString("\t\t</port>\n\t</service>\n</definitions>\n")
+	return buf.Bytes()
+}
+
+// render writes the complex type definition.
+func (t *ComplexType) render(buf *bytes.Buffer, depth int) {
+	tabs := strings.Repeat("\t", depth)
+	if t.Name != "" {
+		fmt.Fprintf(buf, "%s<xsd:complexType name=\"%s\">\n", tabs, t.Name)
+	} else {
+		fmt.Fprintf(buf, "%s<xsd:complexType>\n", tabs)
+	}
+	writeDocumentation(buf, "xsd:", t.Documentation, depth+1)
+	if len(t.Elements) == 0 {
+		fmt.Fprintf(buf, "%s\t<xsd:sequence/>\n", tabs)
+	} else {
+		fmt.Fprintf(buf, "%s\t<xsd:sequence>\n", tabs)
+		for _, e := range t.Elements {
+			e.render(buf, depth+2)
+		}
+		fmt.Fprintf(buf, "%s\t</xsd:sequence>\n", tabs)
+	}
+	fmt.Fprintf(buf, "%s</xsd:complexType>\n", tabs)
+}
+
+// render writes the element definition.
+func (e *Element) render(buf *bytes.Buffer, depth int) {
+	tabs := strings.Repeat("\t", depth)
+	fmt.Fprintf(buf, "%s<xsd:element name=%s", tabs, attr(e.Name))
+	if e.Type != "" {
+		fmt.Fprintf(buf, " type=\"%s\"", e.Type)
+	}
+	if e.Optional {
+		buf.WriteString(" minOccurs=\"0\"")
+	}
+	if e.Unbounded {
+		buf.WriteString(" maxOccurs=\"unbounded\"")
+	}
+	if e.Inline == nil {
+		buf.WriteString("/>\n")
+		return
+	}
+	buf.WriteString(">\n")
+	e.Inline.render(buf, depth+1)
+	fmt.Fprintf(buf, "%s</xsd:element>\n", tabs)
+}
+
+// operation builds the operation exposing the given action.
+func (b *builder) operation(a *design.ActionDefinition, v *design.APIVersionDefinition) *Operation {
+	name := OperationName(a, v)
+	return &Operation{
+		Name:          name,
+		Documentation: a.Description,
+		Request:       b.complexType("", "", requestObject(a)),
+		Response:      b.response(name+"Response", a),
+	}
+}
+
+// response builds the response element of the given action. Collection media types are rendered
+// as a sequence of "item" elements, the content of responses that do not define a media type is
+// not described.
+func (b *builder) response(name string, a *design.ActionDefinition) *Element {
+	mt := codegen.ResultMediaType(a)
+	if mt == nil {
+		return &Element{Name: name, Type: "xsd:anyType"}
+	}
+	if mt.IsArray() {
+		item := b.element("item", mt.AttributeDefinition, false)
+		return &Element{Name: name, Inline: &ComplexType{Elements: []*Element{item}}}
+	}
+	return b.element(name, &design.AttributeDefinition{Type: mt}, true)
+}
+
+// complexType builds the complex type with the given name describing the given object.
+func (b *builder) complexType(name, doc string, att *design.AttributeDefinition) *ComplexType {
+	t := &ComplexType{Name: name, Documentation: doc}
+	obj := att.Type.ToObject()
+	var names []string
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		t.Elements = append(t.Elements, b.element(n, obj[n], att.IsRequired(n)))
+	}
+	return t
+}
+
+// element builds the element with the given name describing the given attribute. Arrays are
+// described with repeated elements, the items of nested arrays with repeated "item" elements.
+func (b *builder) element(name string, att *design.AttributeDefinition, required bool) *Element {
+	var e *Element
+	switch actual := att.Type.(type) {
+	case design.Primitive:
+		e = &Element{Name: name, Type: primitiveType(actual)}
+	case *design.Array:
+		if actual.ElemType.Type.IsArray() {
+			item := b.element("item", actual.ElemType, false)
+			e = &Element{Name: name, Inline: &ComplexType{Elements: []*Element{item}}}
+		} else {
+			e = b.element(name, actual.ElemType, true)
+		}
+		e.Unbounded = true
+	case design.Object:
+		e = &Element{Name: name, Inline: b.complexType("", "", att)}
+	case *design.Hash:
+		e = &Element{Name: name, Type: "xsd:anyType"}
+	case *design.UserTypeDefinition:
+		e = b.userTypeElement(name, actual)
+	case *design.MediaTypeDefinition:
+		e = b.userTypeElement(name, actual.UserTypeDefinition)
+	}
+	e.Optional = !required
+	return e
+}
+
+// userTypeElement builds the element with the given name describing the given user type. Object
+// user types are described with named complex types, the other user types with their underlying
+// type.
+func (b *builder) userTypeElement(name string, ut *design.UserTypeDefinition) *Element {
+	if !ut.IsObject() {
+		return b.element(name, ut.AttributeDefinition, true)
+	}
+	typeName := codegen.Goify(ut.TypeName, true)
+	if _, ok := b.types[typeName]; !ok {
+		t := &ComplexType{Name: typeName}
+		b.types[typeName] = t
+		*t = *b.complexType(typeName, ut.Description, ut.AttributeDefinition)
+	}
+	return &Element{Name: name, Type: "tns:" + typeName}
+}
+
+// requestObject returns the attribute describing the content of the request element of the given
+// action: the action parameters and the "payload" element if the action has a payload.
+func requestObject(a *design.ActionDefinition) *design.AttributeDefinition {
+	obj := make(design.Object)
+	var required []string
+	if params := a.AllParams(); params != nil && params.Type.IsObject() {
+		for n, att := range params.Type.ToObject() {
+			obj[n] = att
+		}
+		required = params.AllRequired()
+	}
+	if a.Payload != nil {
+		obj["payload"] = &design.AttributeDefinition{Type: a.Payload}
+		required = append(required, "payload")
+	}
+	return &design.AttributeDefinition{
+		Type:       obj,
+		Validation: &dslengine.ValidationDefinition{Required: required},
+	}
+}
+
+// primitiveType returns the XML schema type of the given primitive type.
+func primitiveType(p design.Primitive) string {
+	switch p.Kind() {
+	case design.BooleanKind:
+		return "xsd:boolean"
+	case design.IntegerKind:
+		return "xsd:long"
+	case design.NumberKind:
+		return "xsd:double"
+	case design.StringKind:
+		return "xsd:string"
+	case design.DateTimeKind:
+		return "xsd:dateTime"
+	}
+	return "xsd:anyType"
+}
+
+// writeDocumentation writes the documentation element with the given prefix ("" for WSDL
+// elements, "xsd:" for schema elements) if doc is not empty.
+func writeDocumentation(buf *bytes.Buffer, prefix, doc string, depth int) {
+	if doc == "" {
+		return
+	}
+	tabs := strings.Repeat("\t", depth)
+	if prefix == "" {
+		fmt.Fprintf(buf, "%s<documentation>%s</documentation>\n", tabs, text(doc))
+		return
+	}
+	fmt.Fprintf(buf, "%s<%sannotation>\n%s\t<%sdocumentation>%s</%sdocumentation>\n%s</%sannotation>\n",
+		tabs, prefix, tabs, prefix, text(doc), prefix, tabs, prefix)
+}
+
+// text returns the escaped XML representation of s. Backquotes are escaped as well so that the
+// document can be embedded in a Go raw string literal.
+func text(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return strings.Replace(buf.String(), "`", "&#96;", -1)
+}
+
+// attr returns the quoted and escaped XML attribute value s.
+func attr(s string) string {
+	return `"` + text(s) + `"`
+}
+
+// byName makes it possible to sort operations by name.
+type byName []*Operation
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
@@ -50,6 +50,9 @@ func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error)
 		}
 	}()
 
+	if err = codegen.CheckFilters(api); err != nil {
+		return
+	}
 	s, err := New(api)
 	if err != nil {
 		return
@@ -2,11 +2,14 @@ package genswagger
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/gen_schema"
 )
 
@@ -95,6 +98,60 @@ type (
 		Deprecated bool `json:"deprecated,omitempty"`
 		// Secury is a declaration of which security schemes are applied for this operation.
 		Security []map[string][]string `json:"security,omitempty"`
+		// WebSocket describes the WebSocket endpoint of the operation if any. This field is
+		// rendered as the "x-websocket" vendor extension.
+		WebSocket *WebSocket `json:"x-websocket,omitempty"`
+		// Callbacks describes the outbound webhooks whose URLs are registered by the
+		// operation indexed by event name. This field is rendered as the "x-callbacks" vendor
+		// extension.
+		Callbacks map[string]*Callback `json:"x-callbacks,omitempty"`
+		// RateLimit describes the rate limit that applies to the operation if any. This field
+		// is rendered as the "x-ratelimit" vendor extension.
+		RateLimit *RateLimit `json:"x-ratelimit,omitempty"`
+	}
+
+	// RateLimit describes the number of requests each client may make to an operation per
+	// period.
+	RateLimit struct {
+		// Scope is the name of the rate limit, operations with the same scope share the
+		// limit.
+		Scope string `json:"scope"`
+		// Limit is the number of requests allowed per period.
+		Limit int `json:"limit"`
+		// Period is the duration of the period in seconds.
+		Period int64 `json:"period"`
+		// Algorithm is "token-bucket" or "sliding-window".
+		Algorithm string `json:"algorithm"`
+	}
+
+	// Callback describes the requests sent to the URLs registered by an operation each time an
+	// event occurs.
+	Callback struct {
+		// Description of the callback.
+		Description string `json:"description,omitempty"`
+		// Method is the HTTP method of the callback requests.
+		Method string `json:"method"`
+		// ContentType is the content type of the callback request bodies.
+		ContentType string `json:"contentType"`
+		// Schema is the schema of the callback request bodies.
+		Schema *genschema.JSONSchema `json:"schema,omitempty"`
+		// Signing is the scheme used to sign the callback requests, e.g. "hmac-sha256".
+		Signing string `json:"signing"`
+		// SignatureHeader is the name of the header holding the request signatures.
+		SignatureHeader string `json:"signatureHeader,omitempty"`
+		// MaxAttempts is the maximum number of delivery attempts.
+		MaxAttempts int `json:"maxAttempts"`
+	}
+
+	// WebSocket describes the connections established by operations whose requests are
+	// upgraded to WebSocket connections.
+	WebSocket struct {
+		// Subprotocols lists the WebSocket subprotocols supported by the endpoint.
+		Subprotocols []string `json:"subprotocols,omitempty"`
+		// Receives is the schema of the messages sent by clients.
+		Receives *genschema.JSONSchema `json:"receives,omitempty"`
+		// Sends is the schema of the messages sent to clients.
+		Sends *genschema.JSONSchema `json:"sends,omitempty"`
 	}
 
 	// Parameter describes a single operation parameter.
@@ -144,6 +201,9 @@ type (
 		UniqueItems      bool          `json:"uniqueItems,omitempty"`
 		Enum             []interface{} `json:"enum,omitempty"`
 		MultipleOf       float64       `json:"multipleOf,omitempty"`
+		// Sensitive is true if the parameter value is kept out of the logs. This field is
+		// rendered as the "x-sensitive" vendor extension.
+		Sensitive bool `json:"x-sensitive,omitempty"`
 	}
 
 	// Response describes an operation response.
@@ -160,6 +220,12 @@ type (
 		// Ref references a global API response.
 		// This field is exclusive with the other fields of Response.
 		Ref string `json:"$ref,omitempty"`
+		// StatusRange is the range of status codes covered by the response if any, e.g.
+		// "2XX". This field is rendered as the "x-status-range" vendor extension.
+		StatusRange string `json:"x-status-range,omitempty"`
+		// AlternateSchemas lists the schemas of the alternate response bodies if any. This
+		// field is rendered as the "x-alternate-schemas" vendor extension.
+		AlternateSchemas []*genschema.JSONSchema `json:"x-alternate-schemas,omitempty"`
 	}
 
 	// Header represents a header parameter.
@@ -207,7 +273,7 @@ type (
 		Name string `json:"name,omitempty"`
 		// In is the location of the API key when type is "apiKey".
 		// Valid values are "query" or "header".
-		In string `json:"in"`
+		In string `json:"in,omitempty"`
 		// Flow is the flow used by the OAuth2 security scheme when type is "oauth2"
 		// Valid values are "implicit", "password", "application" or "accessCode".
 		Flow string `json:"flow,omitempty"`
@@ -217,6 +283,22 @@ type (
 		TokenURL string `json:"tokenUrl,omitempty"`
 		// Scopes list the  available scopes for the OAuth2 security scheme.
 		Scopes map[string]*Scope `json:"scopes,omitempty"`
+		// HMAC describes how the requests are signed for HMAC security schemes. This field
+		// is rendered as the "x-hmac" vendor extension.
+		HMAC *HMACSigning `json:"x-hmac,omitempty"`
+	}
+
+	// HMACSigning describes the signature of the requests made with a HMAC security scheme.
+	HMACSigning struct {
+		// Algorithm is the signature algorithm, "HMAC-SHA256".
+		Algorithm string `json:"algorithm"`
+		// SignedComponents lists the components of the canonical request in order.
+		SignedComponents []string `json:"signedComponents"`
+		// Format is the format of the signature header value.
+		Format string `json:"format"`
+		// Tolerance is the maximum difference in seconds between the request Date header
+		// and the server clock, the server default applies if omitted.
+		Tolerance int `json:"tolerance,omitempty"`
 	}
 
 	// Scope corresponds to an available scope for an OAuth2 security scheme.
@@ -301,10 +383,7 @@ func New(api *design.APIDefinition) (*Swagger, error) {
 	for _, c := range api.Consumes {
 		consumes = append(consumes, c.MIMETypes...)
 	}
-	var produces []string
-	for _, p := range api.Produces {
-		produces = append(produces, p.MIMETypes...)
-	}
+	produces := producesFromDefinition(api.Produces)
 	s := &Swagger{
 		Swagger: "2.0",
 		Info: &Info{
@@ -318,13 +397,25 @@ func New(api *design.APIDefinition) (*Swagger, error) {
 		Host:         api.Host,
 		BasePath:     api.BasePath,
 		Paths:        make(map[string]*Path),
-		Schemes:      api.Schemes,
+		Schemes:      api.URLSchemes(),
 		Consumes:     consumes,
 		Produces:     produces,
 		Parameters:   paramMap,
 		Tags:         tags,
 		ExternalDocs: docsFromDefinition(api.Docs),
+		Security:     securityFromDefinition(api.Security),
 	}
+	api.IterateSecuritySchemes(func(scheme *design.SecuritySchemeDefinition) error {
+		if scheme.Kind == design.MutualTLSSecurityKind {
+			// Swagger cannot describe schemes that operate at the transport level
+			return nil
+		}
+		if s.SecurityDefinitions == nil {
+			s.SecurityDefinitions = make(map[string]*SecurityDefinition)
+		}
+		s.SecurityDefinitions[scheme.SchemeName] = securitySchemeFromDefinition(scheme)
+		return nil
+	})
 
 	err = api.IterateResponses(func(r *design.ResponseDefinition) error {
 		res, err := responseSpecFromDefinition(s, api, r)
@@ -341,6 +432,9 @@ func New(api *design.APIDefinition) (*Swagger, error) {
 		return nil, err
 	}
 	err = api.IterateResources(func(res *design.ResourceDefinition) error {
+		if !codegen.ResourceSelected(res) {
+			return nil
+		}
 		return res.IterateActions(func(a *design.ActionDefinition) error {
 			for _, route := range a.Routes {
 				if err := buildPathFromDefinition(s, api, route); err != nil {
@@ -353,6 +447,21 @@ func New(api *design.APIDefinition) (*Swagger, error) {
 	if err != nil {
 		return nil, err
 	}
+	if api.Batch != nil {
+		buildBatchPath(s, api)
+	}
+	if api.Operations != nil {
+		buildOperationsPath(s, api)
+	}
+	err = api.IterateResources(func(res *design.ResourceDefinition) error {
+		if res.IsSubscribable() && codegen.ResourceSelected(res) {
+			buildSubscriptionsPaths(s, api, res.Subscriptions)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	if len(genschema.Definitions) > 0 {
 		s.Definitions = make(map[string]*genschema.JSONSchema)
 		for n, d := range genschema.Definitions {
@@ -361,12 +470,19 @@ func New(api *design.APIDefinition) (*Swagger, error) {
 			d.Links = nil
 			s.Definitions[n] = d
 		}
+		buildVisibilityVariants(s.Definitions)
 	}
 	return s, nil
 }
 
 func tagsFromDefinition(mdata dslengine.MetadataDefinition) (tags []*Tag, err error) {
-	for key, value := range mdata {
+	keys := make([]string, 0, len(mdata))
+	for key := range mdata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := mdata[key]
 		if len(key) > 12 && strings.HasPrefix(key, "swagger:tag=") {
 			tag := &Tag{Name: key[12:]}
 			if len(value) > 0 {
@@ -427,10 +543,15 @@ func paramsFromDefinition(params *design.AttributeDefinition, path string) ([]*P
 			Required:    required,
 			In:          in,
 			Type:        at.Type.Name(),
+			Sensitive:   at.IsSensitive(),
 		}
 		var items *Items
 		if at.Type.IsArray() {
 			items = itemsFromDefinition(at)
+			if in == "query" {
+				// The generated clients send comma separated values, see goa.ParamValues.
+				param.CollectionFormat = "csv"
+			}
 		}
 		param.Items = items
 		initValidations(at, param)
@@ -457,14 +578,22 @@ func responseSpecFromDefinition(s *Swagger, api *design.APIDefinition, r *design
 			schema = genschema.TypeSchema(api, mt)
 		}
 	}
+	var alternates []*genschema.JSONSchema
+	for _, m := range r.AlternateMediaTypes {
+		if mt, ok := api.MediaTypes[design.CanonicalIdentifier(m)]; ok {
+			alternates = append(alternates, genschema.TypeSchema(api, mt))
+		}
+	}
 	headers, err := headersFromDefinition(r.Headers)
 	if err != nil {
 		return nil, err
 	}
 	return &Response{
-		Description: r.Description,
-		Schema:      schema,
-		Headers:     headers,
+		Description:      r.Description,
+		Schema:           schema,
+		Headers:          headers,
+		StatusRange:      strings.ToUpper(r.StatusRangeName()),
+		AlternateSchemas: alternates,
 	}, nil
 }
 
@@ -498,6 +627,38 @@ func responseFromDefinition(s *Swagger, api *design.APIDefinition, r *design.Res
 	return response, nil
 }
 
+// producesFromDefinition returns the MIME types listed in the given encoding definitions. The
+// wildcard media type is only listed if no other MIME type is declared.
+func producesFromDefinition(encs []*design.EncodingDefinition) []string {
+	var produces []string
+	wildcard := false
+	for _, enc := range encs {
+		for _, m := range enc.MIMETypes {
+			if m == design.WildcardMediaType {
+				wildcard = true
+				continue
+			}
+			produces = append(produces, m)
+		}
+	}
+	if wildcard && len(produces) == 0 {
+		produces = []string{design.WildcardMediaType}
+	}
+	return produces
+}
+
+// passthroughTypes returns the possible content types of the responses that use the wildcard media
+// type, that is the MIME types declared together with the wildcard.
+func passthroughTypes(encs []*design.EncodingDefinition) []string {
+	var types []string
+	for _, enc := range encs {
+		if enc.IsPassthrough() {
+			types = append(types, producesFromDefinition([]*design.EncodingDefinition{enc})...)
+		}
+	}
+	return types
+}
+
 func headersFromDefinition(headers *design.AttributeDefinition) (map[string]*Header, error) {
 	if headers == nil {
 		return nil, nil
@@ -520,6 +681,27 @@ func headersFromDefinition(headers *design.AttributeDefinition) (map[string]*Hea
 	return res, nil
 }
 
+// replayParams returns the header parameters required by the replay protected actions.
+func replayParams() []*Parameter {
+	return []*Parameter{
+		{
+			Name:        design.ReplayTimestampHeader,
+			In:          "header",
+			Description: "Time the request was made at in seconds since the Unix epoch",
+			Required:    true,
+			Type:        "integer",
+			Format:      "int64",
+		},
+		{
+			Name:        design.ReplayNonceHeader,
+			In:          "header",
+			Description: "Unique request nonce, requests reusing a nonce are rejected",
+			Required:    true,
+			Type:        "string",
+		},
+	}
+}
+
 func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *design.RouteDefinition) error {
 	action := route.Parent
 	tagNames, err := tagNamesFromDefinition([]dslengine.MetadataDefinition{action.Parent.Metadata, action.Metadata})
@@ -549,21 +731,39 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 		}
 		params = append(params, pp)
 	}
-	operationID := fmt.Sprintf("%s#%s", action.Parent.Name, action.Name)
-	index := 0
-	for i, rt := range action.Routes {
-		if rt == route {
-			index = i
-			break
-		}
+	if action.EffectiveReplay() != nil {
+		params = append(params, replayParams()...)
 	}
-	if index > 0 {
-		operationID = fmt.Sprintf("%s#%d", operationID, index)
+	if t := action.EffectiveTenant(); t != nil && t.Source == design.HeaderTenant {
+		params = append(params, &Parameter{
+			Name:        t.Name,
+			In:          "header",
+			Description: "Tenant the request is made on behalf of",
+			Required:    true,
+			Type:        "string",
+		})
+	}
+	if action.HasSparseFields() {
+		names := action.SparseFieldNames()
+		enum := make([]interface{}, len(names))
+		for i, n := range names {
+			enum[i] = n
+		}
+		params = append(params, &Parameter{
+			Name:             design.SparseFieldsParam,
+			In:               "query",
+			Description:      "Attributes rendered in the response, all the attributes of the view if missing",
+			Type:             "array",
+			Items:            &Items{Type: "string", Enum: enum},
+			CollectionFormat: "csv",
+		})
 	}
+	operationID := OperationID(route)
 	schemes := action.Schemes
 	if len(schemes) == 0 {
 		schemes = api.Schemes
 	}
+	schemes = design.URLSchemes(schemes)
 	operation := &Operation{
 		Tags:         tagNames,
 		Description:  action.Description,
@@ -574,6 +774,77 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 		Schemes:      schemes,
 		Deprecated:   false,
 	}
+	if sec := action.Security; sec != nil || action.Parent.Security != nil {
+		if sec == nil {
+			sec = action.Parent.Security
+		}
+		operation.Security = securityFromDefinition(sec)
+	}
+	for _, r := range action.Responses {
+		if r.MediaType == design.WildcardMediaType {
+			operation.Produces = passthroughTypes(api.Produces)
+			break
+		}
+	}
+	if ws := action.WebSocket; ws != nil {
+		operation.WebSocket = &WebSocket{Subprotocols: ws.Subprotocols}
+		if ws.Receives != nil {
+			operation.WebSocket.Receives = genschema.TypeSchema(api, ws.Receives)
+		}
+		if ws.Sends != nil {
+			operation.WebSocket.Sends = genschema.TypeSchema(api, ws.Sends)
+		}
+		if _, ok := responses["101"]; !ok {
+			responses["101"] = &Response{Description: "Switching Protocols"}
+		}
+	}
+	if rl := action.EffectiveRateLimit(); rl != nil {
+		operation.RateLimit = &RateLimit{
+			Scope:     rl.Scope(),
+			Limit:     rl.Limit,
+			Period:    int64(rl.Period / time.Second),
+			Algorithm: rl.Algorithm,
+		}
+		if _, ok := responses["429"]; !ok {
+			responses["429"] = &Response{
+				Description: "Too Many Requests",
+				Headers: map[string]*Header{
+					"Retry-After": {Description: "Number of seconds to wait before retrying", Type: "integer"},
+				},
+			}
+		}
+		for _, r := range responses {
+			if r.Ref != "" {
+				continue
+			}
+			if r.Headers == nil {
+				r.Headers = make(map[string]*Header)
+			}
+			r.Headers["X-RateLimit-Limit"] = &Header{Description: "Number of requests allowed per period", Type: "integer"}
+			r.Headers["X-RateLimit-Remaining"] = &Header{Description: "Number of requests left", Type: "integer"}
+			r.Headers["X-RateLimit-Reset"] = &Header{Description: "Unix time at which the limit resets", Type: "integer"}
+		}
+	}
+	action.IterateCallbacks(func(c *design.CallbackDefinition) error {
+		if operation.Callbacks == nil {
+			operation.Callbacks = make(map[string]*Callback)
+		}
+		cb := &Callback{
+			Description: c.Description,
+			Method:      c.Method,
+			ContentType: c.MediaType,
+			Signing:     c.SigningScheme,
+			MaxAttempts: c.MaxAttempts,
+		}
+		if c.SigningScheme != design.SigningNone {
+			cb.SignatureHeader = c.SignatureHeader
+		}
+		if mt, ok := api.MediaTypes[design.CanonicalIdentifier(c.MediaType)]; ok {
+			cb.Schema = genschema.TypeSchema(api, mt)
+		}
+		operation.Callbacks[c.Name] = cb
+		return nil
+	})
 	key := design.WildcardRegex.ReplaceAllStringFunc(
 		route.FullPath(design.Design.APIVersionDefinition),
 		func(w string) string {
@@ -609,6 +880,330 @@ func buildPathFromDefinition(s *Swagger, api *design.APIDefinition, route *desig
 	return nil
 }
 
+// OperationID returns the ID of the operation that describes the given route:
+// "<resource>#<action>" for the first route of the action and "<resource>#<action>#<index>" for
+// the others.
+func OperationID(route *design.RouteDefinition) string {
+	action := route.Parent
+	operationID := fmt.Sprintf("%s#%s", action.Parent.Name, action.Name)
+	index := 0
+	for i, rt := range action.Routes {
+		if rt == route {
+			index = i
+			break
+		}
+	}
+	if index > 0 {
+		operationID = fmt.Sprintf("%s#%d", operationID, index)
+	}
+	return operationID
+}
+
+// buildBatchPath adds the batch endpoint operation to the spec together with the definitions of
+// the batch request and response envelopes.
+func buildBatchPath(s *Swagger, api *design.APIDefinition) {
+	b := api.Batch
+	str := func(desc string) *genschema.JSONSchema {
+		return &genschema.JSONSchema{Type: genschema.JSONString, Description: desc}
+	}
+	headers := &genschema.JSONSchema{
+		Type:                 genschema.JSONObject,
+		Description:          "Headers indexed by name",
+		AdditionalProperties: true,
+	}
+	req := genschema.NewJSONSchema()
+	req.Type = genschema.JSONObject
+	req.Description = "Batch sub-request"
+	req.Properties["id"] = str("Identifies the sub-request in the batch response")
+	req.Properties["version"] = str("API version of the action, empty for the default version")
+	req.Properties["method"] = str("HTTP method of the sub-request")
+	req.Properties["path"] = str("Path of the sub-request including the querystring")
+	req.Properties["headers"] = headers
+	req.Properties["body"] = &genschema.JSONSchema{Description: "Sub-request body"}
+	req.Required = []string{"method", "path"}
+	genschema.Definitions["BatchRequest"] = req
+
+	resp := genschema.NewJSONSchema()
+	resp.Type = genschema.JSONObject
+	resp.Description = "Batch sub-request response"
+	resp.Properties["id"] = str("ID of the sub-request")
+	resp.Properties["status"] = &genschema.JSONSchema{Type: genschema.JSONInteger, Description: "Response status code"}
+	resp.Properties["headers"] = headers
+	resp.Properties["body"] = &genschema.JSONSchema{Description: "Response body"}
+	resp.Required = []string{"status"}
+	genschema.Definitions["BatchResponse"] = resp
+
+	desc := "Dispatches the sub-requests to the API actions and returns their responses in order."
+	if b.Concurrent {
+		desc = "Dispatches the sub-requests to the API actions concurrently and returns their responses in order."
+	}
+	if b.MaxRequests > 0 {
+		desc += fmt.Sprintf(" Batches may contain up to %d sub-requests.", b.MaxRequests)
+	}
+	operation := &Operation{
+		Summary:     "batch",
+		Description: desc,
+		OperationID: "batch",
+		Consumes:    []string{"application/json"},
+		Produces:    []string{"application/json"},
+		Parameters: []*Parameter{{
+			Name:     "batch",
+			In:       "body",
+			Required: true,
+			Schema: &genschema.JSONSchema{
+				Type:  genschema.JSONArray,
+				Items: &genschema.JSONSchema{Ref: "#/definitions/BatchRequest"},
+			},
+		}},
+		Responses: map[string]*Response{
+			"200": {
+				Description: "Responses of the sub-requests",
+				Schema: &genschema.JSONSchema{
+					Type:  genschema.JSONArray,
+					Items: &genschema.JSONSchema{Ref: "#/definitions/BatchResponse"},
+				},
+			},
+			"400": {Description: "Invalid batch"},
+		},
+	}
+	key := design.WildcardRegex.ReplaceAllStringFunc(b.FullPath(), func(w string) string {
+		return fmt.Sprintf("/{%s}", w[2:])
+	})
+	key = strings.TrimPrefix(key, api.BasePath)
+	if key == "" {
+		key = "/"
+	}
+	path, ok := s.Paths[key]
+	if !ok {
+		path = new(Path)
+		s.Paths[key] = path
+	}
+	path.Post = operation
+}
+
+// buildOperationsPath adds the operations endpoints to the spec: the GET operation returns the
+// status of an operation started by an async action and the DELETE operation cancels it.
+func buildOperationsPath(s *Swagger, api *design.APIDefinition) {
+	schema := genschema.TypeSchema(api, design.OperationMedia)
+	params := []*Parameter{{
+		Name:        "id",
+		In:          "path",
+		Description: "Operation ID",
+		Required:    true,
+		Type:        "string",
+	}}
+	get := &Operation{
+		Summary:     "show operation",
+		Description: "Returns the status of the operation, the result is set once the operation succeeded.",
+		OperationID: "operations#show",
+		Produces:    []string{design.OperationMediaIdentifier},
+		Parameters:  params,
+		Responses: map[string]*Response{
+			"200": {Description: "Operation status", Schema: schema},
+			"404": {Description: "Operation not found"},
+		},
+	}
+	cancel := &Operation{
+		Summary:     "cancel operation",
+		Description: "Cancels the operation.",
+		OperationID: "operations#cancel",
+		Produces:    []string{design.OperationMediaIdentifier},
+		Parameters:  params,
+		Responses: map[string]*Response{
+			"200": {Description: "Canceled operation", Schema: schema},
+			"404": {Description: "Operation not found"},
+			"409": {Description: "Operation cannot be canceled or already completed"},
+		},
+	}
+	key := strings.TrimPrefix(api.Operations.FullPath(), api.BasePath) + "/{id}"
+	path, ok := s.Paths[key]
+	if !ok {
+		path = new(Path)
+		s.Paths[key] = path
+	}
+	path.Get = get
+	path.Delete = cancel
+}
+
+// buildSubscriptionsPaths adds the subscription endpoints of a subscribable resource to the spec
+// together with the definitions of the subscription request and response bodies.
+func buildSubscriptionsPaths(s *Swagger, api *design.APIDefinition, subs *design.SubscriptionsDefinition) {
+	str := func(desc string) *genschema.JSONSchema {
+		return &genschema.JSONSchema{Type: genschema.JSONString, Description: desc}
+	}
+	events := func(desc string) *genschema.JSONSchema {
+		enum := make([]interface{}, len(subs.Events))
+		for i, e := range subs.Events {
+			enum[i] = e
+		}
+		return &genschema.JSONSchema{
+			Type:        genschema.JSONArray,
+			Description: desc,
+			Items:       &genschema.JSONSchema{Type: genschema.JSONString, Enum: enum},
+		}
+	}
+	name := codegen.Goify(subs.Parent.Name, true)
+
+	req := genschema.NewJSONSchema()
+	req.Type = genschema.JSONObject
+	req.Description = fmt.Sprintf("Subscription to the %s events", subs.Parent.Name)
+	req.Properties["events"] = events("Names of the events, all the events if empty")
+	req.Properties["callback_url"] = str("URL the events are posted to")
+	req.Required = []string{"callback_url"}
+	genschema.Definitions[name+"SubscriptionRequest"] = req
+
+	sub := genschema.NewJSONSchema()
+	sub.Type = genschema.JSONObject
+	sub.Description = fmt.Sprintf("Subscription to the %s events", subs.Parent.Name)
+	sub.Properties["id"] = str("Subscription ID")
+	sub.Properties["href"] = str("API href of the subscription")
+	sub.Properties["resource"] = str("Name of the subscribable resource")
+	sub.Properties["events"] = events("Names of the events")
+	sub.Properties["callback_url"] = str("URL the events are posted to")
+	sub.Properties["created_at"] = &genschema.JSONSchema{
+		Type:        genschema.JSONString,
+		Format:      "date-time",
+		Description: "Creation timestamp",
+	}
+	sub.Required = []string{"id", "href", "events", "callback_url"}
+	genschema.Definitions[name+"Subscription"] = sub
+
+	ref := &genschema.JSONSchema{Ref: "#/definitions/" + name + "Subscription"}
+	tags := []string{subs.Parent.Name}
+	id := []*Parameter{{
+		Name:        "id",
+		In:          "path",
+		Description: "Subscription ID",
+		Required:    true,
+		Type:        "string",
+	}}
+	notFound := &Response{Description: "Subscription not found"}
+	prefix := subs.Parent.Name + "#"
+	base := &Path{
+		Post: &Operation{
+			Tags:    tags,
+			Summary: "subscribe",
+			Description: fmt.Sprintf("Subscribes the callback URL to the %s events. The events are posted to the URL with their name in the X-Webhook-Event header.",
+				strings.Join(subs.Events, ", ")),
+			OperationID: prefix + "subscribe",
+			Consumes:    []string{"application/json"},
+			Produces:    []string{"application/json"},
+			Parameters: []*Parameter{{
+				Name:     "subscription",
+				In:       "body",
+				Required: true,
+				Schema:   &genschema.JSONSchema{Ref: "#/definitions/" + name + "SubscriptionRequest"},
+			}},
+			Responses: map[string]*Response{
+				"201": {
+					Description: "Subscription created",
+					Schema:      ref,
+					Headers:     map[string]*Header{"Location": {Type: "string", Description: "Href of the subscription"}},
+				},
+				"400": {Description: "Invalid subscription"},
+			},
+		},
+		Get: &Operation{
+			Tags:        tags,
+			Summary:     "list subscriptions",
+			Description: "Lists the subscriptions. Requests that ask for a WebSocket upgrade stream the events given in the events querystring parameter instead.",
+			OperationID: prefix + "list_subscriptions",
+			Produces:    []string{"application/json"},
+			Parameters: []*Parameter{{
+				Name:        "events",
+				In:          "query",
+				Description: "Comma separated list of the events streamed on the WebSocket connection, all the events if absent",
+				Type:        "string",
+			}},
+			Responses: map[string]*Response{
+				"101": {Description: "Switching to the WebSocket protocol"},
+				"200": {
+					Description: "Subscriptions",
+					Schema:      &genschema.JSONSchema{Type: genschema.JSONArray, Items: ref},
+				},
+			},
+		},
+	}
+	item := &Path{
+		Get: &Operation{
+			Tags:        tags,
+			Summary:     "show subscription",
+			OperationID: prefix + "show_subscription",
+			Produces:    []string{"application/json"},
+			Parameters:  id,
+			Responses: map[string]*Response{
+				"200": {Description: "Subscription", Schema: ref},
+				"404": notFound,
+			},
+		},
+		Delete: &Operation{
+			Tags:        tags,
+			Summary:     "unsubscribe",
+			OperationID: prefix + "unsubscribe",
+			Parameters:  id,
+			Responses: map[string]*Response{
+				"204": {Description: "Subscription deleted"},
+				"404": notFound,
+			},
+		},
+	}
+	key := strings.TrimPrefix(subs.FullPath(), api.BasePath)
+	s.Paths[key] = base
+	s.Paths[key+"/{id}"] = item
+}
+
+// securitySchemeFromDefinition returns the security definition describing the given scheme. JWT,
+// OIDC and HMAC schemes are described as API key schemes as Swagger has no dedicated scheme type,
+// the "x-hmac" extension describes the signature of HMAC schemes.
+func securitySchemeFromDefinition(scheme *design.SecuritySchemeDefinition) *SecurityDefinition {
+	def := &SecurityDefinition{
+		Type:        scheme.Kind,
+		Description: scheme.Description,
+	}
+	switch scheme.Kind {
+	case design.JWTSecurityKind, design.OIDCSecurityKind, design.APIKeySecurityKind:
+		def.Type = "apiKey"
+		def.In = scheme.In
+		def.Name = scheme.Name
+	case design.HMACSecurityKind:
+		def.Type = "apiKey"
+		def.In = scheme.In
+		def.Name = scheme.Name
+		def.HMAC = &HMACSigning{
+			Algorithm:        "HMAC-SHA256",
+			SignedComponents: []string{"method", "path", "query", "date", "body-sha256"},
+			Format:           "HMAC-SHA256 Credential=<key ID>, Signature=<base64 signature>",
+			Tolerance:        int(scheme.Tolerance / time.Second),
+		}
+	case design.OAuth2SecurityKind:
+		def.Flow = scheme.Flow
+		def.AuthorizationURL = scheme.AuthorizationURL
+		def.TokenURL = scheme.TokenURL
+		def.Scopes = make(map[string]*Scope, len(scheme.Scopes))
+		for name, desc := range scheme.Scopes {
+			def.Scopes[name] = &Scope{Description: desc}
+		}
+	}
+	return def
+}
+
+// securityFromDefinition returns the security requirements described by the given definition.
+// Definitions that disable security produce an empty requirement so that the operation does not
+// inherit the API requirements. So do mutual TLS requirements as Swagger cannot describe them.
+func securityFromDefinition(sec *design.SecurityDefinition) []map[string][]string {
+	if sec == nil {
+		return nil
+	}
+	if sec.Scheme == nil || sec.Scheme.Kind == design.MutualTLSSecurityKind {
+		return []map[string][]string{{}}
+	}
+	scopes := sec.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return []map[string][]string{{sec.Scheme.SchemeName: scopes}}
+}
+
 func docsFromDefinition(docs *design.DocsDefinition) *ExternalDocs {
 	if docs == nil {
 		return nil
@@ -723,3 +1318,96 @@ func initValidations(attr *design.AttributeDefinition, def interface{}) {
 		initMaxLengthValidation(def, *val.MaxLength)
 	}
 }
+
+// buildVisibilityVariants adds the role specific variants of the definitions that have properties
+// restricted with the VisibleTo DSL. The variant for a role is named after the definition and the
+// role (e.g. "AccountAsAdmin"), it omits the properties the role may not see and its references to
+// restricted definitions refer to the variants for the same role.
+func buildVisibilityVariants(defs map[string]*genschema.JSONSchema) {
+	restricted := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for n, d := range defs {
+			if !restricted[n] && isRestrictedSchema(d, restricted) {
+				restricted[n] = true
+				changed = true
+			}
+		}
+	}
+	roles := make(map[string]bool)
+	for n := range restricted {
+		collectSchemaRoles(defs[n], roles)
+	}
+	for n := range restricted {
+		for role := range roles {
+			v := defs[n].Dup()
+			restrictSchema(v, role, restricted)
+			defs[visibilityVariantName(n, role)] = v
+		}
+	}
+}
+
+// isRestrictedSchema returns true if s has properties restricted with VisibleTo or refers to a
+// restricted definition, directly or via its properties and items.
+func isRestrictedSchema(s *genschema.JSONSchema, restricted map[string]bool) bool {
+	if s == nil {
+		return false
+	}
+	if n := strings.TrimPrefix(s.Ref, "#/definitions/"); n != s.Ref && restricted[n] {
+		return true
+	}
+	for _, p := range s.Properties {
+		if len(p.VisibleTo) > 0 || isRestrictedSchema(p, restricted) {
+			return true
+		}
+	}
+	return isRestrictedSchema(s.Items, restricted)
+}
+
+// collectSchemaRoles records the roles listed by the properties of s and of its items.
+func collectSchemaRoles(s *genschema.JSONSchema, roles map[string]bool) {
+	if s == nil {
+		return
+	}
+	for _, p := range s.Properties {
+		for _, r := range p.VisibleTo {
+			roles[r] = true
+		}
+		collectSchemaRoles(p, roles)
+	}
+	collectSchemaRoles(s.Items, roles)
+}
+
+// restrictSchema removes the properties of s that are not visible to role and makes the references
+// to restricted definitions refer to the variants for role.
+func restrictSchema(s *genschema.JSONSchema, role string, restricted map[string]bool) {
+	if s == nil {
+		return
+	}
+	if n := strings.TrimPrefix(s.Ref, "#/definitions/"); n != s.Ref && restricted[n] {
+		s.Ref = "#/definitions/" + visibilityVariantName(n, role)
+	}
+	for n, p := range s.Properties {
+		if len(p.VisibleTo) > 0 && !visibleTo(p.VisibleTo, role) {
+			delete(s.Properties, n)
+			continue
+		}
+		restrictSchema(p, role, restricted)
+	}
+	restrictSchema(s.Items, role, restricted)
+}
+
+// visibilityVariantName returns the name of the variant of the definition n for role.
+func visibilityVariantName(n, role string) string {
+	return n + "As" + codegen.Goify(role, true)
+}
+
+// visibleTo returns true if role is one of the allowed roles.
+func visibleTo(allowed []string, role string) bool {
+	for _, a := range allowed {
+		if a == role {
+			return true
+		}
+	}
+	return false
+}
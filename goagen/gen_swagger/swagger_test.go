@@ -2,12 +2,14 @@ package genswagger_test
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/go-swagger/go-swagger/spec"
 	_ "github.com/goadesign/goa-cellar/design"
 	. "github.com/goadesign/goa/design"
 	. "github.com/goadesign/goa/design/apidsl"
 	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/gen_schema"
 	"github.com/goadesign/goa/goagen/gen_swagger"
 	. "github.com/onsi/ginkgo"
@@ -276,6 +278,292 @@ var _ = Describe("New", func() {
 			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
 		})
 
+		Context("with a passthrough encoding", func() {
+			BeforeEach(func() {
+				base := Design.DSLFunc
+				Design.DSLFunc = func() {
+					base()
+					Produces("*/*", "image/png", "image/jpeg")
+				}
+				Resource("content", func() {
+					Action("show", func() {
+						Routing(GET("/content/:id"))
+						Params(func() {
+							Param("id", String)
+						})
+						Response(OK, func() {
+							Media(WildcardMediaType)
+						})
+					})
+				})
+			})
+
+			It("lists the declared content types", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				Ω(swagger.Produces).Should(ContainElement("image/png"))
+				Ω(swagger.Produces).Should(ContainElement("image/jpeg"))
+				Ω(swagger.Produces).ShouldNot(ContainElement(WildcardMediaType))
+				op := swagger.Paths["/content/{id}"].Get
+				Ω(op).ShouldNot(BeNil())
+				Ω(op.Produces).Should(Equal([]string{"image/png", "image/jpeg"}))
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
+		Context("with a WebSocket endpoint", func() {
+			BeforeEach(func() {
+				Message := Type("ChatMessage", func() {
+					Attribute("text", String)
+				})
+				Resource("room", func() {
+					Action("chat", func() {
+						Routing(GET("/rooms/:id/chat"))
+						Params(func() {
+							Param("id", Integer)
+						})
+						WebSocket(func() {
+							Subprotocol("chat.v1")
+							Receives(Message)
+							Sends(Message)
+						})
+					})
+				})
+			})
+
+			It("documents the endpoint with an extension", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				op := swagger.Paths["/rooms/{id}/chat"].Get
+				Ω(op).ShouldNot(BeNil())
+				Ω(op.WebSocket).ShouldNot(BeNil())
+				Ω(op.WebSocket.Subprotocols).Should(Equal([]string{"chat.v1"}))
+				Ω(op.WebSocket.Receives.Ref).Should(Equal("#/definitions/ChatMessage"))
+				Ω(op.WebSocket.Sends.Ref).Should(Equal("#/definitions/ChatMessage"))
+				Ω(op.Responses).Should(HaveKey("101"))
+				Ω(swagger.Definitions).Should(HaveKey("ChatMessage"))
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
+		Context("with a rate limited action", func() {
+			BeforeEach(func() {
+				Resource("search", func() {
+					RateLimit(100, time.Minute, func() {
+						Algorithm(SlidingWindow)
+					})
+					Action("query", func() {
+						Routing(GET("/search"))
+						Response(OK)
+					})
+				})
+			})
+
+			It("documents the rate limit", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				op := swagger.Paths["/search"].Get
+				Ω(op.RateLimit).Should(Equal(&genswagger.RateLimit{Scope: "search", Limit: 100, Period: 60, Algorithm: "sliding-window"}))
+				Ω(op.Responses).Should(HaveKey("429"))
+				Ω(op.Responses["429"].Headers).Should(HaveKey("Retry-After"))
+				Ω(op.Responses["429"].Headers).Should(HaveKey("X-RateLimit-Reset"))
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
+		Context("with a replay protected action", func() {
+			BeforeEach(func() {
+				Resource("account", func() {
+					Action("transfer", func() {
+						Routing(POST("/accounts/:id/transfer"))
+						Params(func() {
+							Param("id", Integer)
+						})
+						ReplayProtection()
+					})
+				})
+			})
+
+			It("documents the replay headers", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				op := swagger.Paths["/accounts/{id}/transfer"].Post
+				Ω(op).ShouldNot(BeNil())
+				headers := make(map[string]*genswagger.Parameter)
+				for _, p := range op.Parameters {
+					if p.In == "header" {
+						headers[p.Name] = p
+					}
+				}
+				Ω(headers).Should(HaveLen(2))
+				Ω(headers["X-Request-Timestamp"].Required).Should(BeTrue())
+				Ω(headers["X-Request-Timestamp"].Type).Should(Equal("integer"))
+				Ω(headers["X-Request-Nonce"].Required).Should(BeTrue())
+				Ω(headers["X-Request-Nonce"].Type).Should(Equal("string"))
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
+		Context("with a cached response", func() {
+			BeforeEach(func() {
+				Resource("bottles", func() {
+					Action("list", func() {
+						Routing(GET("/bottles"))
+						Response(OK, func() {
+							CacheControl(func() {
+								Private()
+								MaxAge(time.Minute)
+								Vary("Authorization")
+							})
+						})
+					})
+				})
+			})
+
+			It("documents the caching headers", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				headers := swagger.Paths["/bottles"].Get.Responses["200"].Headers
+				Ω(headers).Should(HaveKey("Cache-Control"))
+				Ω(headers["Cache-Control"].Description).Should(ContainSubstring("private, max-age=60"))
+				Ω(headers).Should(HaveKey("Vary"))
+				Ω(headers["Vary"].Description).Should(ContainSubstring("Authorization"))
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
+		Context("with a multi-tenant API", func() {
+			BeforeEach(func() {
+				Design.Tenant = &TenantDefinition{Source: HeaderTenant, Name: "X-Tenant-ID", Parent: Design}
+				Resource("projects", func() {
+					Action("list", func() {
+						Routing(GET("/projects"))
+						Response(OK)
+					})
+					Action("health", func() {
+						Routing(GET("/health"))
+						NoTenant()
+						Response(OK)
+					})
+				})
+			})
+
+			It("documents the tenant header", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				params := swagger.Paths["/projects"].Get.Parameters
+				Ω(params).Should(HaveLen(1))
+				Ω(params[0].Name).Should(Equal("X-Tenant-ID"))
+				Ω(params[0].In).Should(Equal("header"))
+				Ω(params[0].Required).Should(BeTrue())
+				Ω(swagger.Paths["/health"].Get.Parameters).Should(BeEmpty())
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
+		Context("with sparse fieldsets", func() {
+			BeforeEach(func() {
+				bottle := MediaType("application/vnd.bottle", func() {
+					Attributes(func() {
+						Attribute("id", Integer)
+						Attribute("name", String)
+					})
+					View("default", func() {
+						Attribute("id")
+						Attribute("name")
+					})
+				})
+				Resource("bottles", func() {
+					Action("show", func() {
+						Routing(GET("/bottles/:id"))
+						SparseFields()
+						Response(OK, func() { Media(bottle) })
+					})
+				})
+			})
+
+			It("documents the fields parameter", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				params := swagger.Paths["/bottles/{id}"].Get.Parameters
+				Ω(params).Should(HaveLen(2))
+				Ω(params[1].Name).Should(Equal("fields"))
+				Ω(params[1].In).Should(Equal("query"))
+				Ω(params[1].Type).Should(Equal("array"))
+				Ω(params[1].CollectionFormat).Should(Equal("csv"))
+				Ω(params[1].Items.Enum).Should(Equal([]interface{}{"id", "name"}))
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
+		Context("with security schemes", func() {
+			BeforeEach(func() {
+				JWTSecurity("jwt", func() {
+					Scope("api:read", "Read access")
+				})
+				OAuth2Security("oauth2", func() {
+					AccessCodeFlow("http://authURL.com", "http://tokenURL.com")
+					Scope("api:write", "Write access")
+				})
+				OIDCSecurity("google", func() {
+					Issuer("https://accounts.google.com")
+				})
+				HMACSecurity("signed", func() {
+					Header("X-Signature")
+					Tolerance(time.Minute)
+				})
+				Resource("bottle", func() {
+					Security("jwt", func() {
+						Scope("api:read")
+					})
+					Action("show", func() {
+						Routing(GET("/bottles/:id"))
+						Params(func() {
+							Param("id", Integer)
+						})
+					})
+					Action("health", func() {
+						Routing(GET("/health"))
+						NoSecurity()
+					})
+				})
+			})
+
+			It("sets the SecurityDefinitions and the operations Security fields", func() {
+				Ω(newErr).ShouldNot(HaveOccurred())
+				Ω(swagger.SecurityDefinitions).Should(HaveLen(4))
+				Ω(swagger.SecurityDefinitions["jwt"]).Should(Equal(&genswagger.SecurityDefinition{
+					Type: "apiKey",
+					In:   "header",
+					Name: "Authorization",
+				}))
+				Ω(swagger.SecurityDefinitions["google"]).Should(Equal(&genswagger.SecurityDefinition{
+					Type: "apiKey",
+					In:   "header",
+					Name: "Authorization",
+				}))
+				signed := swagger.SecurityDefinitions["signed"]
+				Ω(signed.Type).Should(Equal("apiKey"))
+				Ω(signed.Name).Should(Equal("X-Signature"))
+				Ω(signed.HMAC).ShouldNot(BeNil())
+				Ω(signed.HMAC.Algorithm).Should(Equal("HMAC-SHA256"))
+				Ω(signed.HMAC.SignedComponents).Should(Equal([]string{"method", "path", "query", "date", "body-sha256"}))
+				Ω(signed.HMAC.Tolerance).Should(Equal(60))
+				oauth2 := swagger.SecurityDefinitions["oauth2"]
+				Ω(oauth2.Type).Should(Equal("oauth2"))
+				Ω(oauth2.Flow).Should(Equal("accessCode"))
+				Ω(oauth2.AuthorizationURL).Should(Equal("http://authURL.com"))
+				Ω(oauth2.TokenURL).Should(Equal("http://tokenURL.com"))
+				Ω(oauth2.Scopes).Should(HaveKeyWithValue("api:write", &genswagger.Scope{Description: "Write access"}))
+				show := swagger.Paths["/bottles/{id}"].Get
+				Ω(show.Security).Should(Equal([]map[string][]string{{"jwt": {"api:read"}}}))
+				health := swagger.Paths["/health"].Get
+				Ω(health.Security).Should(Equal([]map[string][]string{{}}))
+			})
+
+			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
+		})
+
 		Context("with resources", func() {
 			BeforeEach(func() {
 				Origin := MediaType("application/vnd.goa.example.origin", func() {
@@ -387,6 +675,21 @@ var _ = Describe("New", func() {
 				Ω(swagger.Paths["/bottles/{id}"].Put.Tags).Should(Equal(tags))
 			})
 
+			Context("with a resource filter that does not select the resource", func() {
+				BeforeEach(func() {
+					codegen.SelectedResources = []string{"other"}
+				})
+
+				AfterEach(func() {
+					codegen.SelectedResources = nil
+				})
+
+				It("does not describe the resource paths", func() {
+					Ω(newErr).ShouldNot(HaveOccurred())
+					Ω(swagger.Paths).Should(BeEmpty())
+				})
+			})
+
 			It("serializes into valid swagger JSON", func() { validateSwagger(swagger) })
 		})
 	})
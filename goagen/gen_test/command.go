@@ -0,0 +1,43 @@
+package gentest
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// AppPkg is the name of the generated application Go package.
+	AppPkg string
+
+	// TargetPackage is the name of the generated test Go package.
+	TargetPackage string
+)
+
+// Command is the goa test helpers generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("test", "Generate controller test helpers")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&AppPkg, "app-pkg", "app", "Name of the generated application Go package")
+	r.Flags().StringVar(&TargetPackage, "pkg", "test", "Name of the generated test helpers Go package")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"app-pkg": AppPkg, "pkg": TargetPackage}
+	gen := meta.NewGenerator(
+		"gentest.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_test")},
+		flags,
+	)
+	return gen.Generate()
+}
@@ -0,0 +1,14 @@
+/*
+Package gentest provides a generator for the test helpers of a goa application.
+The generator produces a "test" package under the application package with one helper function
+per action and response, e.g. ShowBottleOK. Each helper builds the request, creates the action
+context, runs the controller action, asserts the response status and returns the response writer
+together with the decoded response media type if any. This makes it possible to unit test
+controllers without having to write the httptest and context plumbing by hand:
+
+	rw, bottle := test.ShowBottleOK(t, ctrl, 42)
+	if bottle.Name != "Number 8" {
+		t.Errorf("unexpected bottle name %s", bottle.Name)
+	}
+*/
+package gentest
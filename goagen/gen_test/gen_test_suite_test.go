@@ -0,0 +1,13 @@
+package gentest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenTest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenTest Suite")
+}
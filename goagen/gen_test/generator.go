@@ -0,0 +1,349 @@
+package gentest
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the test helpers code generator.
+type Generator struct {
+	genfiles []string
+}
+
+type (
+	// TestMethod contains the data needed to render the helper function of a single action
+	// response.
+	TestMethod struct {
+		// Name is the helper function name, e.g. "ShowBottleOK".
+		Name string
+		// ActionName is the Go name of the controller action method, e.g. "Show".
+		ActionName string
+		// ResourceName is the name of the resource, e.g. "bottle".
+		ResourceName string
+		// ControllerName is the name of the controller interface, e.g. "BottleController".
+		ControllerName string
+		// ContextName is the name of the action context, e.g. "ShowBottleContext".
+		ContextName string
+		// Verb is the HTTP method of the first action route.
+		Verb string
+		// PathFormat is the fmt format used to build the request path.
+		PathFormat string
+		// PathParams lists the Go variable names of the path parameters in order.
+		PathParams []string
+		// Params lists the action parameters sorted by name.
+		Params []*TestParam
+		// Payload is the Go type reference of the action payload if any.
+		Payload string
+		// Status is the expected response status code.
+		Status int
+		// StatusRange is the expected response status code class if the response covers a
+		// range of status codes, e.g. 2 for "2xx", 0 otherwise.
+		StatusRange int
+		// ReturnType is the Go type reference of the response media type if any.
+		ReturnType string
+		// Restricted is true if the response media type has fields restricted with VisibleTo,
+		// a helper that runs the action with given principal roles is generated in this case.
+		Restricted bool
+	}
+
+	// TestParam describes a helper function parameter.
+	TestParam struct {
+		// Name is the parameter name as defined in the design.
+		Name string
+		// VarName is the Go variable name.
+		VarName string
+		// Type is the Go type reference.
+		Type string
+		// IsArray is true if the parameter type is an array.
+		IsArray bool
+		// IsDateTime is true if the parameter or its elements are date times, they are
+		// formatted with RFC3339.
+		IsDateTime bool
+	}
+)
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "Test helpers generator",
+		Long:  "controller test helpers generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// TestDir returns the directory containing the generated test helpers for the given version.
+func TestDir(version *design.APIVersionDefinition) string {
+	dir := filepath.Join(codegen.OutputDir, AppPkg)
+	if !version.IsDefault() {
+		dir = filepath.Join(dir, codegen.VersionPackage(version.Version))
+	}
+	return filepath.Join(dir, TargetPackage)
+}
+
+// Generate produces the test helpers.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	appPath, err := codegen.PackagePath(filepath.Join(codegen.OutputDir, AppPkg))
+	if err != nil {
+		return nil, err
+	}
+	err = api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		dir := TestDir(v)
+		os.RemoveAll(dir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		g.genfiles = append(g.genfiles, dir)
+		return v.IterateResources(func(r *design.ResourceDefinition) error {
+			if !r.SupportsVersion(v.Version) {
+				return nil
+			}
+			return g.generateResourceTest(dir, appPath, v, r)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g.genfiles, nil
+}
+
+// Cleanup removes all the files generated by this generator during the last invokation of Generate.
+func (g *Generator) Cleanup() {
+	for _, f := range g.genfiles {
+		os.RemoveAll(f)
+	}
+	g.genfiles = nil
+}
+
+// generateResourceTest generates the test helpers for all the actions of the given resource.
+func (g *Generator) generateResourceTest(dir, appPath string, version *design.APIVersionDefinition, res *design.ResourceDefinition) error {
+	appPkg := AppPkg
+	imports := []*codegen.ImportSpec{
+		codegen.SimpleImport("encoding/json"),
+		codegen.SimpleImport("fmt"),
+		codegen.SimpleImport("net/http"),
+		codegen.SimpleImport("net/http/httptest"),
+		codegen.SimpleImport("net/url"),
+		codegen.SimpleImport("strings"),
+		codegen.SimpleImport("testing"),
+		codegen.SimpleImport("time"),
+		codegen.SimpleImport("github.com/goadesign/goa"),
+		codegen.SimpleImport(appPath),
+	}
+	if !version.IsDefault() {
+		appPkg = codegen.VersionPackage(version.Version)
+		imports = append(imports, codegen.SimpleImport(path.Join(appPath, appPkg)))
+	}
+	var methods []*TestMethod
+	err := res.IterateActions(func(a *design.ActionDefinition) error {
+		if len(a.Routes) == 0 {
+			return nil
+		}
+		route := a.Routes[0]
+		pathParams := route.Params(version)
+		params := a.AllParams()
+		var tparams []*TestParam
+		if params != nil {
+			obj := params.Type.ToObject()
+			var names []string
+			for n := range obj {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			for _, n := range names {
+				tparams = append(tparams, &TestParam{
+					Name:       n,
+					VarName:    varName(n),
+					Type:       codegen.AppTypeRef(obj[n].Type, version, AppPkg),
+					IsArray:    obj[n].Type.IsArray(),
+					IsDateTime: isDateTime(obj[n].Type),
+				})
+			}
+		}
+		pathVars := make([]string, len(pathParams))
+		for i, p := range pathParams {
+			pathVars[i] = varName(p)
+		}
+		var payload string
+		if a.Payload != nil {
+			payload = codegen.AppTypeRef(a.Payload, version, AppPkg)
+		}
+		responses := make([]*design.ResponseDefinition, 0, len(a.Responses))
+		for _, resp := range a.Responses {
+			responses = append(responses, resp)
+		}
+		sort.Sort(byStatus(responses))
+		for _, resp := range responses {
+			var ret string
+			var restricted bool
+			if resp.Type != nil {
+				ret = codegen.AppTypeRef(resp.Type, version, AppPkg)
+			} else if mt := design.Design.MediaTypeWithIdentifier(resp.MediaType); mt != nil {
+				if mt.Type.IsObject() || mt.Type.IsArray() {
+					ret = codegen.AppTypeRef(mt, version, AppPkg)
+					restricted = mt.HasRestrictedFields()
+				}
+			}
+			methods = append(methods, &TestMethod{
+				Name:           codegen.Goify(a.Name, true) + codegen.Goify(res.Name, true) + codegen.Goify(resp.Name, true),
+				ActionName:     codegen.Goify(a.Name, true),
+				ResourceName:   res.Name,
+				ControllerName: appPkg + "." + codegen.ControllerName(res.Name),
+				ContextName:    appPkg + ".New" + codegen.ContextName(a.Name, res.Name),
+				Verb:           route.Verb,
+				PathFormat:     design.WildcardRegex.ReplaceAllLiteralString(route.FullPath(version), "/%v"),
+				PathParams:     pathVars,
+				Params:         tparams,
+				Payload:        payload,
+				Status:         resp.Status,
+				StatusRange:    resp.StatusRange,
+				ReturnType:     ret,
+				Restricted:     restricted,
+			})
+		}
+		return nil
+	})
+	if err != nil || len(methods) == 0 {
+		return err
+	}
+	filename := filepath.Join(dir, strings.ToLower(codegen.Goify(res.Name, false))+".go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	title := fmt.Sprintf("%s: %s TestHelpers", version.Context(), res.Name)
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	for _, m := range methods {
+		if err := file.ExecuteTemplate("test", testTmpl, template.FuncMap{}, m); err != nil {
+			return err
+		}
+	}
+	return file.FormatCode()
+}
+
+// isDateTime returns true if t is DateTime or an array of DateTime.
+func isDateTime(t design.DataType) bool {
+	if t.IsArray() {
+		t = t.ToArray().ElemType.Type
+	}
+	return t.Kind() == design.DateTimeKind
+}
+
+// varName returns a valid Go variable name for the parameter with the given name, e.g.
+// "bottle_id" => "bottleID" and "id" => "id".
+func varName(n string) string {
+	runes := []rune(codegen.Goify(n, true))
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) {
+		// Keep the first letter of the next word upper case, e.g. "HTTPServer" => "httpServer"
+		upper--
+	}
+	for i := 0; i < upper || i == 0; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	name := string(runes)
+	if token.Lookup(name).IsKeyword() {
+		name += "_"
+	}
+	return name
+}
+
+// byStatus makes it possible to sort responses by status code.
+type byStatus []*design.ResponseDefinition
+
+func (b byStatus) Len() int           { return len(b) }
+func (b byStatus) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byStatus) Less(i, j int) bool { return b[i].Status < b[j].Status }
+
+// testTmpl generates a single test helper function.
+// template input: *TestMethod
+const testTmpl = `
+// {{.Name}} runs the method {{.ActionName}} of the given controller with the given parameters{{if .Payload}} and payload{{end}}.
+// It returns the response writer so it's possible to inspect the response headers{{if .ReturnType}} and the media type struct written to the response{{end}}.
+// The test fails if the action returns an error or if the response status code is not {{if .StatusRange}}{{.StatusRange}}xx{{else}}{{.Status}}{{end}}.
+func {{.Name}}(t *testing.T, ctrl {{.ControllerName}}{{range .Params}}, {{.VarName}} {{.Type}}{{end}}{{if .Payload}}, payload {{.Payload}}{{end}}) (*httptest.ResponseRecorder{{if .ReturnType}}, {{.ReturnType}}{{end}}) {
+{{if .Restricted}}	return {{.Name}}As(t, nil, ctrl{{range .Params}}, {{.VarName}}{{end}}{{if .Payload}}, payload{{end}})
+}
+
+// {{.Name}}As runs the method {{.ActionName}} like {{.Name}} with a request principal granted the
+// given roles, the response only includes the media type fields visible to these roles.
+func {{.Name}}As(t *testing.T, roles []string, ctrl {{.ControllerName}}{{range .Params}}, {{.VarName}} {{.Type}}{{end}}{{if .Payload}}, payload {{.Payload}}{{end}}) (*httptest.ResponseRecorder{{if .ReturnType}}, {{.ReturnType}}{{end}}) {
+{{end}}	service := goa.New("test")
+	service.SetEncoder(goa.JSONEncoderFactory(), true, "*/*")
+	rw := httptest.NewRecorder()
+	u := &url.URL{
+		Path: fmt.Sprintf("{{.PathFormat}}"{{range .PathParams}}, {{.}}{{end}}),
+	}
+	req, err := http.NewRequest("{{.Verb}}", u.String(), nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %s", err)
+	}
+	prms := url.Values{}
+{{range .Params}}{{if .IsArray}}	{{.VarName}}Elems := make([]string, len({{.VarName}}))
+	for i, e := range {{.VarName}} {
+		{{.VarName}}Elems[i] = {{if .IsDateTime}}e.Format(time.RFC3339){{else}}fmt.Sprintf("%v", e){{end}}
+	}
+	prms["{{.Name}}"] = []string{strings.Join({{.VarName}}Elems, ",")}
+{{else if .IsDateTime}}	prms["{{.Name}}"] = []string{ {{.VarName}}.Format(time.RFC3339)}
+{{else}}	prms["{{.Name}}"] = []string{fmt.Sprintf("%v", {{.VarName}})}
+{{end}}{{end}}	goaCtx := goa.NewContext(goa.RootContext, service, rw, req, prms)
+{{if .Restricted}}	goaCtx = goa.WithRoles(goaCtx, roles...)
+{{end}}
+	ctx, err := {{.ContextName}}(goaCtx)
+	if err != nil {
+		t.Fatalf("invalid test data: %s", err)
+	}
+{{if .Payload}}	ctx.Payload = payload
+{{end}}	if err := ctrl.{{.ActionName}}(ctx); err != nil {
+		t.Fatalf("controller returned %s", err)
+	}
+{{if .StatusRange}}	if rw.Code/100 != {{.StatusRange}} {
+		t.Errorf("invalid response status code: got %d, expected {{.StatusRange}}xx", rw.Code)
+	}
+{{else}}	if rw.Code != {{.Status}} {
+		t.Errorf("invalid response status code: got %d, expected {{.Status}}", rw.Code)
+	}
+{{end}}{{if .ReturnType}}	var mt {{.ReturnType}}
+	if err := json.Unmarshal(rw.Body.Bytes(), &mt); err != nil {
+		t.Fatalf("failed to decode response body: %s", err)
+	}
+	return rw, mt
+{{else}}	return rw
+{{end}}}
+`
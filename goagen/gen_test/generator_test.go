@@ -0,0 +1,86 @@
+package gentest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/gen_test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("testtest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = gentest.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		workspace.Delete()
+	})
+
+	Context("with an API with one action", func() {
+		BeforeEach(func() {
+			bottle := &design.MediaTypeDefinition{
+				UserTypeDefinition: &design.UserTypeDefinition{
+					AttributeDefinition: &design.AttributeDefinition{
+						Type: design.Object{"name": &design.AttributeDefinition{Type: design.String}},
+					},
+					TypeName: "Bottle",
+				},
+				Identifier: "application/vnd.bottle+json",
+			}
+			res := &design.ResourceDefinition{Name: "bottle", BasePath: "/bottles"}
+			show := &design.ActionDefinition{
+				Name:   "show",
+				Parent: res,
+				Params: &design.AttributeDefinition{Type: design.Object{
+					"id":    &design.AttributeDefinition{Type: design.Integer},
+					"since": &design.AttributeDefinition{Type: design.DateTime},
+				}},
+				Responses: map[string]*design.ResponseDefinition{
+					"OK":       {Name: "OK", Status: 200, MediaType: bottle.Identifier},
+					"NotFound": {Name: "NotFound", Status: 404},
+				},
+			}
+			show.Routes = []*design.RouteDefinition{{Verb: "GET", Path: "/:id", Parent: show}}
+			res.Actions = map[string]*design.ActionDefinition{"show": show}
+			design.Design = &design.APIDefinition{
+				APIVersionDefinition: &design.APIVersionDefinition{Name: "cellar"},
+				Resources:            map[string]*design.ResourceDefinition{"bottle": res},
+				MediaTypes:           map[string]*design.MediaTypeDefinition{bottle.Identifier: bottle},
+			}
+		})
+
+		It("generates the test helpers", func() {
+			Ω(genErr).Should(BeNil())
+			Ω(files).Should(HaveLen(2))
+			content, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "app", "test", "bottle.go"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(ContainSubstring(
+				"func ShowBottleOK(t *testing.T, ctrl app.BottleController, id int, since time.Time) (*httptest.ResponseRecorder, *app.Bottle) {"))
+			Ω(string(content)).Should(ContainSubstring(
+				"func ShowBottleNotFound(t *testing.T, ctrl app.BottleController, id int, since time.Time) *httptest.ResponseRecorder {"))
+			Ω(string(content)).Should(ContainSubstring(`fmt.Sprintf("/bottles/%v", id)`))
+			Ω(string(content)).Should(ContainSubstring("app.NewShowBottleContext(goaCtx)"))
+			Ω(string(content)).Should(ContainSubstring(`"time"`))
+			Ω(string(content)).Should(ContainSubstring(`prms["since"] = []string{since.Format(time.RFC3339)}`))
+		})
+	})
+})
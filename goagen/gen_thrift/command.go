@@ -0,0 +1,43 @@
+package genthrift
+
+import (
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/meta"
+)
+
+var (
+	// TargetPackage is the name of the generated Go package.
+	TargetPackage string
+
+	// Namespace is the Thrift namespace declared in the generated IDL.
+	Namespace string
+)
+
+// Command is the goa Thrift generator command line data structure.
+// It implements meta.Command.
+type Command struct {
+	*codegen.BaseCommand
+}
+
+// NewCommand instantiates a new command.
+func NewCommand() *Command {
+	base := codegen.NewBaseCommand("thrift", "Generate the Thrift IDL and the Thrift server bridging to the controllers")
+	return &Command{BaseCommand: base}
+}
+
+// RegisterFlags registers the command line flags with the given registry.
+func (c *Command) RegisterFlags(r codegen.FlagRegistry) {
+	r.Flags().StringVar(&TargetPackage, "pkg", "thrift", "Name of the generated Go package")
+	r.Flags().StringVar(&Namespace, "namespace", "", "Thrift namespace declared in the IDL, defaults to the API name")
+}
+
+// Run simply calls the meta generator.
+func (c *Command) Run() ([]string, error) {
+	flags := map[string]string{"pkg": TargetPackage, "namespace": Namespace}
+	gen := meta.NewGenerator(
+		"genthrift.Generate",
+		[]*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa/goagen/gen_thrift")},
+		flags,
+	)
+	return gen.Generate()
+}
@@ -0,0 +1,37 @@
+/*
+Package genthrift provides a generator for a Thrift facade of the API so that services
+standardized on Thrift RPC internally can consume it while it keeps being exposed as REST
+externally. The generator renders the design as a Thrift IDL file ("service.thrift"): each
+resource is exposed as a service named after the resource ("BottleService") and each action as a
+function of that service named after the action ("show"). Services of resources defined in a non
+default API version are prefixed with the version ("V1BottleService").
+
+The function arguments are the action parameters and a "payload" argument for actions that have a
+payload. The function result is the media type of the first success response of the action, void
+if none. All functions throw the ActionError exception when the action fails, the exception
+carries the status code and the message of the action error response.
+
+User types and media types that are objects are rendered as structs, inline objects produce
+structs named after the enclosing struct and the field. Fields and arguments are identified
+using the "thrift:field" metadata if present, the remaining fields are identified in alphabetical
+order:
+
+	Attribute("name", String, func() {
+		Metadata("thrift:field", "2")
+	})
+
+Attributes of type DateTime are rendered as strings holding RFC3339 values and attributes of type
+Any as strings holding JSON values.
+
+The generator also produces the Go code that creates a goa.ThriftServer bridging each function to
+the action it was generated from:
+
+	service := goa.New("cellar")
+	app.MountBottleController(service, NewBottleController(service))
+	thrift.NewServer(service).Mount("/thrift")
+	service.ListenAndServe(":8080")
+
+The server speaks the binary protocol over HTTP, clients must use the multiplexed protocol with
+the service name so that function names are qualified ("BottleService:show").
+*/
+package genthrift
@@ -0,0 +1,13 @@
+package genthrift_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenThrift(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GenThrift Suite")
+}
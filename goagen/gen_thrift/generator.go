@@ -0,0 +1,167 @@
+package genthrift
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/utils"
+	"github.com/spf13/cobra"
+)
+
+// Generator is the Thrift facade generator.
+type Generator struct {
+	genfiles []string
+}
+
+// Method contains the data needed to generate the dispatch entry of a single Thrift function.
+type Method struct {
+	// Name is the function name qualified with the service name, e.g. "BottleService:show".
+	Name string
+	// Function is the function definition.
+	Function *Function
+}
+
+// Generate is the generator entry point called by the meta generator.
+func Generate(roots []interface{}) (files []string, err error) {
+	api := roots[0].(*design.APIDefinition)
+	g := new(Generator)
+	root := &cobra.Command{
+		Use:   "goagen",
+		Short: "Thrift facade generator",
+		Long:  "Thrift IDL and server generator",
+		Run:   func(*cobra.Command, []string) { files, err = g.Generate(api) },
+	}
+	codegen.RegisterFlags(root)
+	NewCommand().RegisterFlags(root)
+	root.Execute()
+	return
+}
+
+// ThriftDir returns the path to the directory where the Thrift facade files are generated.
+func ThriftDir() string {
+	return filepath.Join(codegen.OutputDir, TargetPackage)
+}
+
+// Generate produces the Thrift IDL and the Thrift server Go code.
+func (g *Generator) Generate(api *design.APIDefinition) (_ []string, err error) {
+	if api == nil {
+		return nil, fmt.Errorf("missing API definition, make sure design.Design is properly initialized")
+	}
+
+	go utils.Catch(nil, func() { g.Cleanup() })
+
+	defer func() {
+		if err != nil {
+			g.Cleanup()
+		}
+	}()
+
+	ns := Namespace
+	if ns == "" {
+		ns = fieldName(api.Name)
+	}
+	idl, err := NewIDL(api, ns)
+	if err != nil {
+		return
+	}
+
+	os.RemoveAll(ThriftDir())
+	if err = os.MkdirAll(ThriftDir(), 0755); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, ThriftDir())
+
+	idlFile := filepath.Join(ThriftDir(), "service.thrift")
+	if err = ioutil.WriteFile(idlFile, idl.Render(), 0644); err != nil {
+		return
+	}
+	g.genfiles = append(g.genfiles, idlFile)
+	if err = g.generateServer(api, idl); err != nil {
+		return
+	}
+
+	return g.genfiles, nil
+}
+
+// Cleanup removes the entire Thrift directory if it was created by this generator.
+func (g *Generator) Cleanup() {
+	if len(g.genfiles) == 0 {
+		return
+	}
+	os.RemoveAll(ThriftDir())
+	g.genfiles = nil
+}
+
+// generateServer generates the struct descriptions, the function dispatch table and the code that
+// creates the Thrift server.
+func (g *Generator) generateServer(api *design.APIDefinition, idl *IDL) error {
+	filename := filepath.Join(ThriftDir(), "server.go")
+	file, err := codegen.SourceFileFor(filename)
+	if err != nil {
+		return err
+	}
+	g.genfiles = append(g.genfiles, filename)
+	imports := []*codegen.ImportSpec{codegen.SimpleImport("github.com/goadesign/goa")}
+	title := fmt.Sprintf("%s: Thrift Server", api.Context())
+	if err := file.WriteHeader(title, TargetPackage, imports); err != nil {
+		return err
+	}
+	var methods []*Method
+	for _, s := range idl.Services {
+		for _, f := range s.Functions {
+			methods = append(methods, &Method{Name: s.Name + ":" + f.Name, Function: f})
+		}
+	}
+	sort.Sort(byMethodName(methods))
+	data := map[string]interface{}{"Structs": idl.Structs, "Methods": methods}
+	if err := file.ExecuteTemplate("server", serverTmpl, nil, data); err != nil {
+		return err
+	}
+	return file.FormatCode()
+}
+
+// byMethodName makes it possible to sort methods by name.
+type byMethodName []*Method
+
+func (b byMethodName) Len() int           { return len(b) }
+func (b byMethodName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byMethodName) Less(i, j int) bool { return b[i].Name < b[j].Name }
+
+const serverTmpl = `// Structs describes the fields of the structs used by the Thrift functions.
+var Structs = map[string][]goa.ThriftField{
+{{range .Structs}}	"{{.Name}}": { {{range .Fields}}
+		{ID: {{.ID}}, Name: "{{.Attribute}}", Type: "{{.RuntimeType}}"},{{end}}
+	},
+{{end}}}
+
+// Methods is the Thrift function dispatch table, it maps each function to the route of the action
+// it was generated from.
+var Methods = map[string]*goa.ThriftMethod{
+{{range .Methods}}{{$f := .Function}}	"{{.Name}}": {
+{{if $f.Version}}		Version: "{{$f.Version}}",
+{{end}}		Verb: "{{$f.Verb}}",
+		Path: "{{$f.Path}}",
+{{if $f.Args}}		Args: []goa.ThriftField{ {{range $f.Args}}
+			{ID: {{.ID}}, Name: "{{.Attribute}}", Type: "{{.RuntimeType}}"},{{end}}
+		},
+{{end}}{{if $f.RuntimeResult}}		Result: "{{$f.RuntimeResult}}",
+{{end}}	},
+{{end}}}
+
+// NewServer returns a Thrift server exposing the functions listed in Methods. Each function is
+// bridged to the action it was generated from so that the controllers mounted on the service
+// handle both the REST and the Thrift requests.
+func NewServer(service *goa.Service) *goa.ThriftServer {
+	s := goa.NewThriftServer(service)
+	s.Structs = Structs
+	for name, m := range Methods {
+		s.Handle(name, m)
+	}
+	return s
+}
+`
@@ -0,0 +1,99 @@
+package genthrift_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/dslengine"
+	"github.com/goadesign/goa/goagen/codegen"
+	"github.com/goadesign/goa/goagen/codegen/codegentest"
+	"github.com/goadesign/goa/goagen/gen_thrift"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generate", func() {
+	var files []string
+	var genErr error
+	var workspace *codegen.Workspace
+	var testPkg *codegen.Package
+	var payload *design.UserTypeDefinition
+	var prevDesign *design.APIDefinition
+
+	BeforeEach(func() {
+		var err error
+		workspace, err = codegen.NewWorkspace("test")
+		Ω(err).ShouldNot(HaveOccurred())
+		testPkg, err = workspace.NewPackage("thrifttest")
+		Ω(err).ShouldNot(HaveOccurred())
+		os.Args = []string{"codegen", "--out=" + testPkg.Abs(), "--design=foo"}
+
+		prevDesign = design.Design
+		design.Design = codegentest.CellarAPI()
+		payload = design.Design.Resources["bottle"].Actions["create"].Payload
+		payload.Type.ToObject()["name"].Metadata = dslengine.MetadataDefinition{"thrift:field": {"1"}}
+	})
+
+	JustBeforeEach(func() {
+		files, genErr = genthrift.Generate([]interface{}{design.Design})
+	})
+
+	AfterEach(func() {
+		design.Design = prevDesign
+		workspace.Delete()
+	})
+
+	It("generates the IDL and the server", func() {
+		Ω(genErr).Should(BeNil())
+		Ω(files).Should(HaveLen(3))
+		Ω(codegentest.ParseDir(filepath.Join(testPkg.Abs(), "thrift"))).Should(Succeed())
+
+		idl, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "thrift", "service.thrift"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(idl)).Should(HavePrefix("namespace * cellar\n"))
+		Ω(string(idl)).Should(ContainSubstring("exception ActionError {\n"))
+		Ω(string(idl)).Should(ContainSubstring("struct Bottle {\n" +
+			"\t1: required i64 id\n" +
+			"\t2: optional string name\n" +
+			"\t3: optional double rating\n" +
+			"\t4: optional list<string> tags\n" +
+			"}\n"))
+		Ω(string(idl)).Should(ContainSubstring("struct CreateBottlePayload {\n" +
+			"\t1: required string name\n" +
+			"\t2: optional map<string,string> metadata\n" +
+			"}\n"))
+		Ω(string(idl)).Should(ContainSubstring("service BottleService {\n" +
+			"\tvoid create(1: CreateBottlePayload payload) throws (1: ActionError error)\n" +
+			"\tlist<Bottle> list() throws (1: ActionError error)\n" +
+			"\t// Retrieve bottle with given id\n" +
+			"\tBottle show(1: i64 id) throws (1: ActionError error)\n" +
+			"}\n"))
+
+		server, err := ioutil.ReadFile(filepath.Join(testPkg.Abs(), "thrift", "server.go"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(server)).Should(ContainSubstring("\t\"Bottle\": {\n" +
+			"\t\t{ID: 1, Name: \"id\", Type: \"i64\"},\n"))
+		Ω(string(server)).Should(ContainSubstring("\t\"BottleService:show\": {\n" +
+			"\t\tVerb: \"GET\",\n" +
+			"\t\tPath: \"/bottles/:id\",\n" +
+			"\t\tArgs: []goa.ThriftField{\n" +
+			"\t\t\t{ID: 1, Name: \"id\", Type: \"i64\"},\n" +
+			"\t\t},\n" +
+			"\t\tResult: \"Bottle\",\n"))
+		Ω(string(server)).Should(ContainSubstring(`Result: "list<Bottle>",`))
+		Ω(string(server)).Should(ContainSubstring("func NewServer(service *goa.Service) *goa.ThriftServer {"))
+	})
+
+	Context("with a user type using the exception name", func() {
+		BeforeEach(func() {
+			payload.TypeName = "ActionError"
+		})
+
+		It("returns an error", func() {
+			Ω(genErr).Should(HaveOccurred())
+			Ω(files).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,398 @@
+package genthrift
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goadesign/goa/design"
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+// FieldKey is the metadata key used to set the Thrift field identifier of an attribute.
+const FieldKey = "thrift:field"
+
+// ErrorStruct is the name of the exception thrown by the functions, it must match
+// goa.ThriftErrorStruct.
+const ErrorStruct = "ActionError"
+
+type (
+	// IDL describes a Thrift IDL file.
+	IDL struct {
+		// Namespace is the Thrift namespace used for all target languages.
+		Namespace string
+		// Structs lists the structs sorted by name.
+		Structs []*Struct
+		// Services lists the services, one per resource and API version.
+		Services []*Service
+	}
+
+	// Struct describes a Thrift struct.
+	Struct struct {
+		// Name is the struct name.
+		Name string
+		// Description is the struct description if any.
+		Description string
+		// Fields lists the struct fields sorted by identifier.
+		Fields []*Field
+	}
+
+	// Field describes a Thrift struct field or function argument.
+	Field struct {
+		// ID is the field identifier.
+		ID int
+		// Name is the field name.
+		Name string
+		// Attribute is the name of the attribute the field was built from.
+		Attribute string
+		// Type is the field IDL type.
+		Type string
+		// RuntimeType is the field type as described to goa.ThriftServer.
+		RuntimeType string
+		// Required is true if the attribute is required.
+		Required bool
+		// Description is the field description if any.
+		Description string
+	}
+
+	// Service describes a Thrift service.
+	Service struct {
+		// Name is the service name.
+		Name string
+		// Description is the service description if any.
+		Description string
+		// Functions lists the service functions.
+		Functions []*Function
+	}
+
+	// Function describes a Thrift service function.
+	Function struct {
+		// Name is the function name.
+		Name string
+		// Description is the function description if any.
+		Description string
+		// Args lists the function arguments sorted by identifier.
+		Args []*Field
+		// Result is the IDL type of the function result, "void" if none.
+		Result string
+		// RuntimeResult is the type of the function result as described to goa.ThriftServer,
+		// empty if none.
+		RuntimeResult string
+		// Version is the name of the API version defining the action, empty for the default
+		// version.
+		Version string
+		// Verb is the HTTP method of the action route.
+		Verb string
+		// Path is the full path of the action route.
+		Path string
+	}
+
+	// builder records the structs built from the design types.
+	builder struct {
+		structs map[string]*Struct
+	}
+)
+
+// NewIDL builds the Thrift IDL describing the services exposing the actions of all the API
+// resources.
+func NewIDL(api *design.APIDefinition, namespace string) (*IDL, error) {
+	idl := &IDL{Namespace: namespace}
+	b := &builder{structs: make(map[string]*Struct)}
+	err := api.IterateVersions(func(v *design.APIVersionDefinition) error {
+		return v.IterateResources(func(r *design.ResourceDefinition) error {
+			svc := &Service{Name: ServiceName(r, v), Description: r.Description}
+			err := r.IterateActions(func(a *design.ActionDefinition) error {
+				if len(a.Routes) == 0 {
+					return nil
+				}
+				f, err := b.function(a, v)
+				if err != nil {
+					return fmt.Errorf("%s: %s", a.Context(), err)
+				}
+				svc.Functions = append(svc.Functions, f)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if len(svc.Functions) > 0 {
+				idl.Services = append(idl.Services, svc)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := b.structs[ErrorStruct]; ok {
+		return nil, fmt.Errorf("type name %s is reserved for the exception thrown by the Thrift functions", ErrorStruct)
+	}
+	var names []string
+	for n := range b.structs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		idl.Structs = append(idl.Structs, b.structs[n])
+	}
+	return idl, nil
+}
+
+// ServiceName returns the name of the Thrift service exposing the actions of the given resource.
+func ServiceName(r *design.ResourceDefinition, v *design.APIVersionDefinition) string {
+	name := codegen.Goify(r.Name, true) + "Service"
+	if !v.IsDefault() {
+		name = codegen.Goify(codegen.VersionPackage(v.Version), true) + name
+	}
+	return name
+}
+
+// Render produces the Thrift IDL content.
+func (idl *IDL) Render() []byte {
+	var buf bytes.Buffer
+	if idl.Namespace != "" {
+		fmt.Fprintf(&buf, "namespace * %s\n\n", idl.Namespace)
+	}
+	fmt.Fprintf(&buf, "// %s is thrown when the action exposed by a function fails.\n", ErrorStruct)
+	fmt.Fprintf(&buf, "exception %s {\n", ErrorStruct)
+	buf.WriteString("\t// HTTP status code of the action response\n\t1: required i32 status\n")
+	buf.WriteString("\t// Error message\n\t2: required string message\n}\n")
+	for _, s := range idl.Structs {
+		buf.WriteString("\n")
+		writeComment(&buf, s.Description, 0)
+		fmt.Fprintf(&buf, "struct %s {\n", s.Name)
+		for _, f := range s.Fields {
+			writeComment(&buf, f.Description, 1)
+			req := "optional"
+			if f.Required {
+				req = "required"
+			}
+			fmt.Fprintf(&buf, "\t%d: %s %s %s\n", f.ID, req, f.Type, f.Name)
+		}
+		buf.WriteString("}\n")
+	}
+	for _, s := range idl.Services {
+		buf.WriteString("\n")
+		writeComment(&buf, s.Description, 0)
+		fmt.Fprintf(&buf, "service %s {\n", s.Name)
+		for _, f := range s.Functions {
+			writeComment(&buf, f.Description, 1)
+			args := make([]string, len(f.Args))
+			for i, a := range f.Args {
+				args[i] = fmt.Sprintf("%d: %s %s", a.ID, a.Type, a.Name)
+			}
+			fmt.Fprintf(&buf, "\t%s %s(%s) throws (1: %s error)\n", f.Result, f.Name, strings.Join(args, ", "), ErrorStruct)
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes()
+}
+
+// function builds the function exposing the given action. The arguments are the action
+// parameters and the "payload" argument for actions that have a payload.
+func (b *builder) function(a *design.ActionDefinition, v *design.APIVersionDefinition) (*Function, error) {
+	route := a.Routes[0]
+	f := &Function{
+		Name:        codegen.Goify(a.Name, false),
+		Description: a.Description,
+		Result:      "void",
+		Verb:        route.Verb,
+		Path:        route.FullPath(v),
+	}
+	if !v.IsDefault() {
+		f.Version = v.Version
+	}
+	obj := make(design.Object)
+	params := a.AllParams()
+	if params != nil && params.Type.IsObject() {
+		for n, att := range params.Type.ToObject() {
+			obj[n] = att
+		}
+	}
+	if a.Payload != nil {
+		obj["payload"] = &design.AttributeDefinition{Type: a.Payload}
+	}
+	prefix := codegen.Goify(a.Name, true) + codegen.Goify(a.Parent.Name, true)
+	args, err := b.fields(prefix, obj, params)
+	if err != nil {
+		return nil, err
+	}
+	f.Args = args
+	if mt := codegen.ResultMediaType(a); mt != nil {
+		f.Result, f.RuntimeResult, err = b.fieldType(&design.AttributeDefinition{Type: mt}, prefix+"Result")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// fields builds the fields of a struct or the arguments of a function from the given object.
+// Fields are identified using the "thrift:field" metadata if present, the remaining fields are
+// identified in alphabetical order starting with the first identifier not already taken. parent
+// is used to look up required attributes, it may be nil.
+func (b *builder) fields(prefix string, obj design.Object, parent *design.AttributeDefinition) ([]*Field, error) {
+	taken := make(map[int]string)
+	var names []string
+	for n := range obj {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var fields, auto []*Field
+	for _, n := range names {
+		att := obj[n]
+		typ, rtyp, err := b.fieldType(att, prefix+codegen.Goify(n, true))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %s", n, err)
+		}
+		field := &Field{
+			Name:        fieldName(n),
+			Attribute:   n,
+			Type:        typ,
+			RuntimeType: rtyp,
+			Required:    parent != nil && parent.IsRequired(n),
+			Description: att.Description,
+		}
+		if vals, ok := att.Metadata[FieldKey]; ok && len(vals) > 0 {
+			id, err := strconv.Atoi(vals[0])
+			if err != nil || id < 1 || id > 32767 {
+				return nil, fmt.Errorf("invalid %s value %#v for field %s", FieldKey, vals[0], n)
+			}
+			if other, ok := taken[id]; ok {
+				return nil, fmt.Errorf("fields %s and %s use the same identifier %d", other, n, id)
+			}
+			taken[id] = n
+			field.ID = id
+		} else {
+			auto = append(auto, field)
+		}
+		fields = append(fields, field)
+	}
+	next := 1
+	for _, f := range auto {
+		for {
+			if _, ok := taken[next]; !ok {
+				break
+			}
+			next++
+		}
+		f.ID = next
+		taken[next] = f.Attribute
+	}
+	sort.Sort(byID(fields))
+	return fields, nil
+}
+
+// fieldType returns the IDL type and the runtime type of a field with the given attribute. Inline
+// objects produce a struct named after name, user types and media types produce a struct named
+// after the type if they are objects and are otherwise replaced with their underlying type.
+func (b *builder) fieldType(att *design.AttributeDefinition, name string) (string, string, error) {
+	switch actual := att.Type.(type) {
+	case design.Primitive:
+		switch actual.Kind() {
+		case design.BooleanKind:
+			return "bool", "bool", nil
+		case design.IntegerKind:
+			return "i64", "i64", nil
+		case design.NumberKind:
+			return "double", "double", nil
+		case design.StringKind, design.DateTimeKind:
+			return "string", "string", nil
+		default:
+			return "string", "json", nil
+		}
+	case *design.UserTypeDefinition:
+		return b.userType(actual)
+	case *design.MediaTypeDefinition:
+		return b.userType(actual.UserTypeDefinition)
+	case design.Object:
+		if err := b.object(name, att); err != nil {
+			return "", "", err
+		}
+		return name, name, nil
+	case *design.Array:
+		elem, relem, err := b.fieldType(actual.ElemType, name+"Item")
+		if err != nil {
+			return "", "", err
+		}
+		return fmt.Sprintf("list<%s>", elem), fmt.Sprintf("list<%s>", relem), nil
+	case *design.Hash:
+		key, ok := actual.KeyType.Type.(design.Primitive)
+		if !ok || (key.Kind() != design.StringKind && key.Kind() != design.IntegerKind && key.Kind() != design.BooleanKind) {
+			return "", "", fmt.Errorf("hash keys must be strings, integers or booleans")
+		}
+		ktyp, _, _ := b.fieldType(actual.KeyType, "")
+		elem, relem, err := b.fieldType(actual.ElemType, name+"Value")
+		if err != nil {
+			return "", "", err
+		}
+		return fmt.Sprintf("map<%s,%s>", ktyp, elem), fmt.Sprintf("map<%s,%s>", ktyp, relem), nil
+	}
+	return "", "", fmt.Errorf("unsupported type %s", att.Type.Name())
+}
+
+// userType returns the IDL type and the runtime type of a field whose type is the given user
+// type.
+func (b *builder) userType(ut *design.UserTypeDefinition) (string, string, error) {
+	name := codegen.Goify(ut.TypeName, true)
+	if !ut.Type.IsObject() {
+		return b.fieldType(ut.AttributeDefinition, name)
+	}
+	if err := b.object(name, ut.AttributeDefinition); err != nil {
+		return "", "", fmt.Errorf("%s: %s", ut.Context(), err)
+	}
+	return name, name, nil
+}
+
+// object records the struct with the given name built from the given object attribute.
+func (b *builder) object(name string, att *design.AttributeDefinition) error {
+	if _, ok := b.structs[name]; ok {
+		return nil
+	}
+	s := &Struct{Name: name, Description: att.Description}
+	b.structs[name] = s
+	fields, err := b.fields(name, att.Type.ToObject(), att)
+	if err != nil {
+		return err
+	}
+	s.Fields = fields
+	return nil
+}
+
+// fieldName produces a Thrift field name (snake case) from an attribute name.
+func fieldName(n string) string {
+	var buf bytes.Buffer
+	runes := []rune(codegen.Goify(n, true))
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				buf.WriteByte('_')
+			}
+		}
+		buf.WriteRune(unicode.ToLower(r))
+	}
+	return buf.String()
+}
+
+// writeComment writes desc as a Thrift comment at the given depth.
+func writeComment(buf *bytes.Buffer, desc string, depth int) {
+	if desc == "" {
+		return
+	}
+	tabs := codegen.Tabs(depth)
+	for _, l := range strings.Split(strings.TrimSpace(desc), "\n") {
+		fmt.Fprintf(buf, "%s// %s\n", tabs, strings.TrimSpace(l))
+	}
+}
+
+// byID makes it possible to sort fields by identifier.
+type byID []*Field
+
+func (b byID) Len() int           { return len(b) }
+func (b byID) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byID) Less(i, j int) bool { return b[i].ID < b[j].ID }
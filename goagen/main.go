@@ -8,12 +8,33 @@ import (
 
 	"github.com/goadesign/goa/goagen/codegen"
 	"github.com/goadesign/goa/goagen/gen_app"
+	"github.com/goadesign/goa/goagen/gen_authz"
+	"github.com/goadesign/goa/goagen/gen_catalog"
 	"github.com/goadesign/goa/goagen/gen_client"
+	"github.com/goadesign/goa/goagen/gen_diff"
+	"github.com/goadesign/goa/goagen/gen_events"
+	"github.com/goadesign/goa/goagen/gen_gateway"
+	"github.com/goadesign/goa/goagen/gen_gdpr"
 	"github.com/goadesign/goa/goagen/gen_gen"
+	"github.com/goadesign/goa/goagen/gen_graphql"
+	"github.com/goadesign/goa/goagen/gen_grpc"
+	"github.com/goadesign/goa/goagen/gen_grpcbridge"
 	"github.com/goadesign/goa/goagen/gen_js"
+	"github.com/goadesign/goa/goagen/gen_jsonrpc"
+	"github.com/goadesign/goa/goagen/gen_lambda"
+	"github.com/goadesign/goa/goagen/gen_lint"
 	"github.com/goadesign/goa/goagen/gen_main"
+	"github.com/goadesign/goa/goagen/gen_mock"
+	"github.com/goadesign/goa/goagen/gen_mqtt"
+	"github.com/goadesign/goa/goagen/gen_oauth2"
+	"github.com/goadesign/goa/goagen/gen_openapi"
+	"github.com/goadesign/goa/goagen/gen_proto"
 	"github.com/goadesign/goa/goagen/gen_schema"
+	"github.com/goadesign/goa/goagen/gen_sectest"
+	"github.com/goadesign/goa/goagen/gen_soap"
 	"github.com/goadesign/goa/goagen/gen_swagger"
+	"github.com/goadesign/goa/goagen/gen_test"
+	"github.com/goadesign/goa/goagen/gen_thrift"
 	"github.com/goadesign/goa/goagen/utils"
 	"github.com/spf13/cobra"
 )
@@ -25,9 +46,30 @@ var Commands = []codegen.Command{
 	genmain.NewCommand(),
 	genclient.NewCommand(),
 	genswagger.NewCommand(),
+	genopenapi.NewCommand(),
 	genjs.NewCommand(),
 	genschema.NewCommand(),
 	gengen.NewCommand(),
+	gengateway.NewCommand(),
+	genproto.NewCommand(),
+	gengrpc.NewCommand(),
+	gengrpcbridge.NewCommand(),
+	gengraphql.NewCommand(),
+	genjsonrpc.NewCommand(),
+	genmqtt.NewCommand(),
+	genevents.NewCommand(),
+	genlambda.NewCommand(),
+	gensoap.NewCommand(),
+	genoauth2.NewCommand(),
+	genthrift.NewCommand(),
+	gentest.NewCommand(),
+	genmock.NewCommand(),
+	gencatalog.NewCommand(),
+	gendiff.NewCommand(),
+	genlint.NewCommand(),
+	genauthz.NewCommand(),
+	gengdpr.NewCommand(),
+	gensectest.NewCommand(),
 }
 
 var cfgFile string
@@ -92,6 +134,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if codegen.DryRun {
+		// The dry-run report has already been printed by the generators.
+		return
+	}
+
 	rels := make([]string, len(files))
 	cwd, err := os.Getwd()
 	for i, f := range files {
@@ -0,0 +1,175 @@
+package meta
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goadesign/goa/goagen/codegen"
+)
+
+// DryRunOutput is the writer the dry-run report is written to.
+var DryRunOutput io.Writer = os.Stdout
+
+// dryRun runs the generator against a copy of the output directory and reports the differences
+// with the actual output directory, which is left untouched. The copy lives in a temporary
+// workspace that is added to GOPATH so that the generated import paths are identical. toolDir is
+// the directory containing the generator tool source, it is excluded from the copy.
+func (m *Generator) dryRun(genbin, toolDir string) error {
+	dir, err := ioutil.TempDir("", "goagen-dryrun")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if !codegen.Debug {
+			os.RemoveAll(dir)
+		}
+	}()
+	target, err := filepath.Abs(codegen.OutputDir)
+	if err != nil {
+		return err
+	}
+	pkgPath, err := codegen.PackagePath(target)
+	if err != nil {
+		return err
+	}
+	out := filepath.Join(dir, "src", filepath.FromSlash(pkgPath))
+	if err := copyTree(target, out, toolDir); err != nil {
+		return err
+	}
+	var env []string
+	for _, e := range os.Environ() {
+		if !strings.HasPrefix(e, "GOPATH=") {
+			env = append(env, e)
+		}
+	}
+	env = append(env, fmt.Sprintf("GOPATH=%s%c%s", dir, os.PathListSeparator, os.Getenv("GOPATH")))
+	files, err := m.spawn(genbin, out, env)
+	if err != nil {
+		return err
+	}
+	return report(DryRunOutput, target, out, files, toolDir)
+}
+
+// report writes the list of files that generating to dir would create, overwrite or delete given
+// the files generated in the copy of dir. It also writes the diff of overwritten files if
+// codegen.ShowDiff is true.
+func report(w io.Writer, dir, copyDir string, files []string, skip string) error {
+	actions := make(map[string]string)
+	diffs := make(map[string]string)
+	for _, f := range files {
+		err := filepath.Walk(f, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return err
+			}
+			rel, err := filepath.Rel(copyDir, path)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				return nil
+			}
+			target := filepath.Join(dir, rel)
+			generated, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			existing, err := ioutil.ReadFile(target)
+			if os.IsNotExist(err) {
+				actions[target] = "create"
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(existing, generated) {
+				actions[target] = "overwrite"
+				diffs[target] = codegen.UnifiedDiff(target, target, string(existing), string(generated))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	err := walkTree(dir, skip, func(path string, info os.FileInfo) error {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(copyDir, rel)); os.IsNotExist(err) {
+			actions[path] = "delete"
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(actions))
+	for p := range actions {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	cwd, _ := os.Getwd()
+	for _, p := range paths {
+		name := p
+		if r, err := filepath.Rel(cwd, p); err == nil {
+			name = r
+		}
+		fmt.Fprintf(w, "%-9s %s\n", actions[p], name)
+		if codegen.ShowDiff && diffs[p] != "" {
+			fmt.Fprint(w, diffs[p])
+		}
+	}
+	return nil
+}
+
+// copyTree copies the regular files under src to dst, see walkTree for the files being skipped.
+func copyTree(src, dst, skip string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	return walkTree(src, skip, func(path string, info os.FileInfo) error {
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, content, info.Mode())
+	})
+}
+
+// walkTree calls fn for each regular file under root. It does not descend into the skip
+// directory, hidden directories and vendor directories as generators never write there. It does
+// nothing if root does not exist.
+func walkTree(root, skip string, fn func(string, os.FileInfo) error) error {
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != root && (path == skip || strings.HasPrefix(name, ".") || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return fn(path, info)
+	})
+}
@@ -1,14 +1,17 @@
 package meta
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
+	"github.com/goadesign/goa/design"
 	"github.com/goadesign/goa/goagen/codegen"
 )
 
@@ -50,7 +53,8 @@ func (m *Generator) Generate() ([]string, error) {
 		return nil, fmt.Errorf("missing output directory specification")
 	}
 
-	if codegen.DesignPackagePath == "" {
+	designPaths := codegen.DesignPackagePaths()
+	if len(designPaths) == 0 {
 		return nil, fmt.Errorf("missing design package path specification")
 	}
 
@@ -59,8 +63,10 @@ func (m *Generator) Generate() ([]string, error) {
 	}
 
 	// Create output directory
-	if err := os.MkdirAll(codegen.OutputDir, 0755); err != nil {
-		return nil, err
+	if !codegen.DryRun {
+		if err := os.MkdirAll(codegen.OutputDir, 0755); err != nil {
+			return nil, err
+		}
 	}
 
 	// Create temporary workspace used for generation
@@ -84,8 +90,9 @@ func (m *Generator) Generate() ([]string, error) {
 		fmt.Printf("** Code generator source dir: %s\n", tmpDir)
 	}
 
-	// Figure out design package name from its path
-	path, err := codegen.PackageSourcePath(codegen.DesignPackagePath)
+	// Figure out design package name from its path, the first package names the generator
+	// package when the design is split across multiple packages.
+	path, err := codegen.PackageSourcePath(designPaths[0])
 	if err != nil {
 		return nil, err
 	}
@@ -110,7 +117,10 @@ func (m *Generator) Generate() ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	return m.spawn(genbin)
+	if codegen.DryRun {
+		return nil, m.dryRun(genbin, tmpDir)
+	}
+	return m.spawn(genbin, codegen.OutputDir, nil)
 }
 
 func (m *Generator) generateToolSourceCode(pkg *codegen.Package) {
@@ -120,8 +130,10 @@ func (m *Generator) generateToolSourceCode(pkg *codegen.Package) {
 		codegen.SimpleImport("os"),
 		codegen.SimpleImport("strings"),
 		codegen.SimpleImport("github.com/goadesign/goa/dslengine"),
-		codegen.NewImport("_", filepath.ToSlash(codegen.DesignPackagePath)),
 	)
+	for _, p := range codegen.DesignPackagePaths() {
+		imports = append(imports, codegen.NewImport("_", filepath.ToSlash(p)))
+	}
 	file.WriteHeader("Code Generator", "main", imports)
 	tmpl, err := template.New("generator").Parse(mainTmpl)
 	if err != nil {
@@ -131,10 +143,11 @@ func (m *Generator) generateToolSourceCode(pkg *codegen.Package) {
 	if err != nil {
 		panic(err)
 	}
-	context := map[string]string{
+	context := map[string]interface{}{
 		"Genfunc":       m.Genfunc,
 		"DesignPackage": codegen.DesignPackagePath,
 		"PkgName":       pkgName,
+		"Strict":        codegen.Strict,
 	}
 	err = tmpl.Execute(file, context)
 	if err != nil {
@@ -143,27 +156,48 @@ func (m *Generator) generateToolSourceCode(pkg *codegen.Package) {
 }
 
 // spawn runs the compiled generator using the arguments initialized by Kingpin
-// when parsing the command line.
-func (m *Generator) spawn(genbin string) ([]string, error) {
+// when parsing the command line. The generated files are written to outDir. env overrides the
+// environment of the generator process if not nil. The design warnings printed by the generator
+// are copied to the standard error.
+func (m *Generator) spawn(genbin, outDir string, env []string) ([]string, error) {
 	args := []string{
-		fmt.Sprintf("--out=%s", codegen.OutputDir),
+		fmt.Sprintf("--out=%s", outDir),
 		fmt.Sprintf("--design=%s", codegen.DesignPackagePath),
 	}
 	if codegen.NoFormat {
 		args = append(args, fmt.Sprintf("--noformat"))
 	}
-	for name, value := range m.Flags {
-		if value != "" {
+	names := make([]string, 0, len(m.Flags))
+	for name := range m.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value := m.Flags[name]; value != "" {
 			args = append(args, fmt.Sprintf("--%s=%s", name, value))
 		}
 	}
+	if codegen.NoCommandLine {
+		args = append(args, "--no-cmdline")
+	}
+	if design.ValueDefaults {
+		args = append(args, "--value-defaults")
+	}
+	args = append(args, codegen.NamingArgs()...)
+	args = append(args, codegen.FilterArgs()...)
+	args = append(args, codegen.TemplatesArgs()...)
 	args = append(args, codegen.ExtraFlags...)
 	cmd := exec.Command(genbin, args...)
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("%s\n%s", err, string(out))
+	if env != nil {
+		cmd.Env = env
 	}
-	res := strings.Split(string(out), "\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s\n%s%s", err, stderr.String(), stdout.String())
+	}
+	os.Stderr.Write(stderr.Bytes())
+	res := strings.Split(stdout.String(), "\n")
 	for (len(res) > 0) && (res[len(res)-1] == "") {
 		res = res[:len(res)-1]
 	}
@@ -182,6 +216,14 @@ func main() {
 	// Now run the secondary DSLs
 	failOnError(dslengine.Run())
 
+	// Report the design warnings{{if .Strict}}, strict mode treats them as errors
+	if dslengine.Warnings != nil {
+		failOnError(dslengine.Warnings)
+	}{{else}}
+	for _, w := range dslengine.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}{{end}}
+
 	// Now take the results and call the generator with it
 	roots := make([]interface{}, len(dslengine.Roots))
 	for i, r := range dslengine.Roots {
@@ -246,6 +246,39 @@ var _ = Describe("Run", func() {
 				Ω(compiledFiles).Should(Equal(filePaths))
 			})
 		})
+
+		Context("in dry-run mode", func() {
+			var output bytes.Buffer
+
+			BeforeEach(func() {
+				p, err := outputWorkspace.NewPackage("dryrun")
+				Ω(err).ShouldNot(HaveOccurred())
+				outputDir = p.Abs()
+				err = ioutil.WriteFile(filepath.Join(outputDir, "existing.txt"), []byte("old"), 0644)
+				Ω(err).ShouldNot(HaveOccurred())
+				designPackageSource = dryRunSource
+				output.Reset()
+				meta.DryRunOutput = &output
+				codegen.DryRun = true
+			})
+
+			AfterEach(func() {
+				meta.DryRunOutput = os.Stdout
+				codegen.DryRun = false
+			})
+
+			It("reports the changes without writing files", func() {
+				Ω(compileError).ShouldNot(HaveOccurred())
+				Ω(compiledFiles).Should(BeEmpty())
+				_, err := os.Stat(filepath.Join(outputDir, "new.txt"))
+				Ω(os.IsNotExist(err)).Should(BeTrue())
+				content, err := ioutil.ReadFile(filepath.Join(outputDir, "existing.txt"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(string(content)).Should(Equal("old"))
+				Ω(output.String()).Should(MatchRegexp(`create +\S*new\.txt`))
+				Ω(output.String()).Should(MatchRegexp(`overwrite +\S*existing\.txt`))
+			})
+		})
 	})
 })
 
@@ -260,6 +293,32 @@ func Generate(roots []interface{}) ([]string, error) {
 }
 
 func init() { panic("kaboom") }
+`
+
+	dryRunSource = `package foo
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+func Generate(roots []interface{}) ([]string, error) {
+	var out string
+	for _, arg := range os.Args {
+		if strings.HasPrefix(arg, "--out=") {
+			out = strings.TrimPrefix(arg, "--out=")
+		}
+	}
+	var files []string
+	for _, name := range []string{"new.txt", "existing.txt"} {
+		f := filepath.Join(out, name)
+		if err := ioutil.WriteFile(f, []byte("new"), 0644); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
 `
 
 	validSource = `package foo
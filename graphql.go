@@ -0,0 +1,757 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// graphqlPayloadArg is the name of the GraphQL field argument holding the action payload.
+const graphqlPayloadArg = "payload"
+
+type (
+	// GraphQLHandler serves GraphQL requests. Each top level field of the query and mutation
+	// types is resolved by a GraphQLResolver, the selection sets of the query are then applied
+	// to the generic JSON representation of the values returned by the resolvers.
+	// The code generated by "goagen graphql" registers resolvers that bridge the fields to the
+	// controllers mounted on the service, see GraphQLBridge.
+	//
+	// The handler supports queries and mutations with variables, aliases and nested selection
+	// sets. Fragments, directives and subscriptions are not supported.
+	GraphQLHandler struct {
+		// Service is the service the handler is mounted on.
+		Service   *Service
+		queries   map[string]GraphQLResolver
+		mutations map[string]GraphQLResolver
+	}
+
+	// GraphQLResolver resolves the value of a top level GraphQL field given the field
+	// arguments. The returned value is serialized using its JSON representation before the
+	// field selection set is applied.
+	GraphQLResolver func(ctx context.Context, args map[string]interface{}) (interface{}, error)
+
+	// GraphQLRoute describes the action route a GraphQL field maps to.
+	GraphQLRoute struct {
+		// Version is the name of the API version that defines the action, empty if none.
+		Version string
+		// Verb is the HTTP method of the route.
+		Verb string
+		// Path is the full path of the route including wildcards.
+		Path string
+	}
+
+	// GraphQLResponse is the response to a GraphQL request.
+	GraphQLResponse struct {
+		// Data contains the values of the requested fields, nil if the request is invalid.
+		Data interface{} `json:"data,omitempty"`
+		// Errors lists the errors that occurred while executing the request if any.
+		Errors []*GraphQLError `json:"errors,omitempty"`
+	}
+
+	// GraphQLError describes an error returned in a GraphQL response.
+	GraphQLError struct {
+		// Message describes the error.
+		Message string `json:"message"`
+		// Path is the response key of the field that failed if any.
+		Path []interface{} `json:"path,omitempty"`
+	}
+
+	// graphqlRequest is the body of GraphQL POST requests.
+	graphqlRequest struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+
+	// graphqlOperation is a parsed query or mutation.
+	graphqlOperation struct {
+		kind       string
+		name       string
+		defaults   map[string]interface{}
+		selections []*graphqlField
+	}
+
+	// graphqlField is a parsed field selection.
+	graphqlField struct {
+		alias      string
+		name       string
+		args       map[string]interface{}
+		selections []*graphqlField
+	}
+
+	// graphqlVariable is a reference to a query variable in a field argument value.
+	graphqlVariable string
+
+	// graphqlEnum is an enum value literal in a field argument value.
+	graphqlEnum string
+
+	// graphqlObject is a JSON object whose keys are serialized in selection order.
+	graphqlObject struct {
+		keys   []string
+		values map[string]interface{}
+	}
+
+	// graphqlParser parses GraphQL documents.
+	graphqlParser struct {
+		src string
+		pos int
+	}
+)
+
+// NewGraphQLHandler returns a GraphQL handler that serves requests with the given service.
+func NewGraphQLHandler(service *Service) *GraphQLHandler {
+	return &GraphQLHandler{
+		Service:   service,
+		queries:   make(map[string]GraphQLResolver),
+		mutations: make(map[string]GraphQLResolver),
+	}
+}
+
+// Query registers the resolver of the query field with the given name.
+func (h *GraphQLHandler) Query(name string, resolver GraphQLResolver) {
+	h.queries[name] = resolver
+}
+
+// Mutation registers the resolver of the mutation field with the given name.
+func (h *GraphQLHandler) Mutation(name string, resolver GraphQLResolver) {
+	h.mutations[name] = resolver
+}
+
+// Mount registers the handler with the service mux for GET and POST requests made to the given
+// path, "/graphql" if empty. This makes the GraphQL endpoint available alongside the REST routes.
+func (h *GraphQLHandler) Mount(path string) {
+	if path == "" {
+		path = "/graphql"
+	}
+	handle := func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+		h.ServeHTTP(rw, req)
+	}
+	h.Service.Mux.Handle("GET", path, handle)
+	h.Service.Mux.Handle("POST", path, handle)
+	h.Service.LogInfo("mount graphql", KV{"path", fmt.Sprintf("GET|POST %s", path)})
+}
+
+// ServeHTTP serves a GraphQL request. POST requests send the query in a JSON body with the
+// "query", "operationName" and "variables" fields or as the raw body when the content type is
+// "application/graphql". GET requests send the same fields in the querystring and may only
+// execute queries.
+func (h *GraphQLHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	var gr graphqlRequest
+	status := http.StatusOK
+	err := h.decodeRequest(req, &gr)
+	if err != nil {
+		status = http.StatusBadRequest
+	}
+	var resp *GraphQLResponse
+	if err == nil {
+		ctx := NewContext(nil, h.Service, rw, req, nil)
+		var op *graphqlOperation
+		if op, err = parseGraphQL(gr.Query, gr.OperationName); err != nil {
+			status = http.StatusBadRequest
+		} else if op.kind == "mutation" && req.Method != "POST" {
+			status = http.StatusMethodNotAllowed
+			err = errors.New("mutations must use the POST method")
+		} else {
+			resp = h.execute(ctx, op, gr.Variables)
+		}
+	}
+	if err != nil {
+		resp = &GraphQLResponse{Errors: []*GraphQLError{{Message: err.Error()}}}
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(resp)
+}
+
+// Execute runs the operation with the given name, which may be empty if the query contains a
+// single operation, using the given variable values.
+func (h *GraphQLHandler) Execute(ctx context.Context, query, operationName string, variables map[string]interface{}) *GraphQLResponse {
+	op, err := parseGraphQL(query, operationName)
+	if err != nil {
+		return &GraphQLResponse{Errors: []*GraphQLError{{Message: err.Error()}}}
+	}
+	return h.execute(ctx, op, variables)
+}
+
+// GraphQLBridge returns a resolver that dispatches requests to the action with the given route.
+// The arguments named after the route wildcards are used to build the request path, the
+// "payload" argument if any is sent as the request body and the other arguments are sent in the
+// querystring. The headers of the GraphQL request are copied to the action request so that
+// security schemes apply unchanged. Error responses are turned into resolver errors.
+func GraphQLBridge(service *Service, route GraphQLRoute) GraphQLResolver {
+	return func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+		fields := make(map[string]interface{}, len(args))
+		for k, v := range args {
+			fields[k] = v
+		}
+		orig := &http.Request{Header: make(http.Header)}
+		if r := Request(ctx); r != nil && r.Request != nil {
+			orig = r.Request
+		}
+		inner, err := bridgeRequest(orig, route.Verb, route.Path, fields, graphqlPayloadArg)
+		if err != nil {
+			return nil, err
+		}
+		rec := bridgeServe(service, route.Version, inner)
+		if rec.failed() {
+			return nil, errors.New(rec.errorMessage())
+		}
+		body := rec.body.Bytes()
+		if len(bytes.TrimSpace(body)) == 0 {
+			return nil, nil
+		}
+		var val interface{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&val); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %s", err)
+		}
+		return val, nil
+	}
+}
+
+// decodeRequest reads the query, operation name and variables of a GraphQL request.
+func (h *GraphQLHandler) decodeRequest(req *http.Request, gr *graphqlRequest) error {
+	switch req.Method {
+	case "GET":
+		q := req.URL.Query()
+		gr.Query = q.Get("query")
+		gr.OperationName = q.Get("operationName")
+		if vars := q.Get("variables"); vars != "" {
+			dec := json.NewDecoder(strings.NewReader(vars))
+			dec.UseNumber()
+			if err := dec.Decode(&gr.Variables); err != nil {
+				return fmt.Errorf("invalid variables: %s", err)
+			}
+		}
+	case "POST":
+		if req.Body == nil {
+			return errors.New("missing request body")
+		}
+		if strings.HasPrefix(req.Header.Get("Content-Type"), "application/graphql") {
+			b, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			gr.Query = string(b)
+			break
+		}
+		dec := json.NewDecoder(req.Body)
+		dec.UseNumber()
+		if err := dec.Decode(gr); err != nil {
+			return fmt.Errorf("invalid request body: %s", err)
+		}
+	default:
+		return fmt.Errorf("unsupported method %s", req.Method)
+	}
+	if gr.Query == "" {
+		return errors.New("missing query")
+	}
+	return nil
+}
+
+// execute resolves the top level fields of the operation and applies their selection sets.
+func (h *GraphQLHandler) execute(ctx context.Context, op *graphqlOperation, variables map[string]interface{}) *GraphQLResponse {
+	resolvers := h.queries
+	if op.kind == "mutation" {
+		resolvers = h.mutations
+	}
+	data := newGraphQLObject()
+	resp := &GraphQLResponse{Data: data}
+	for _, f := range op.selections {
+		key := f.key()
+		if f.name == "__typename" {
+			data.set(key, strings.Title(op.kind))
+			continue
+		}
+		resolver, ok := resolvers[f.name]
+		if !ok {
+			resp.Errors = append(resp.Errors, &GraphQLError{
+				Message: fmt.Sprintf("unknown %s field %s", op.kind, f.name),
+				Path:    []interface{}{key},
+			})
+			data.set(key, nil)
+			continue
+		}
+		args := make(map[string]interface{}, len(f.args))
+		for name, val := range f.args {
+			args[name] = graphqlResolveValue(val, variables, op.defaults)
+		}
+		val, err := resolver(ctx, args)
+		if err == nil {
+			val, err = graphqlGeneric(val)
+		}
+		if err != nil {
+			resp.Errors = append(resp.Errors, &GraphQLError{Message: err.Error(), Path: []interface{}{key}})
+			data.set(key, nil)
+			continue
+		}
+		data.set(key, graphqlProject(val, f.selections))
+	}
+	return resp
+}
+
+// key returns the response key of the field.
+func (f *graphqlField) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// graphqlResolveValue replaces the variable references contained in the argument value v.
+func graphqlResolveValue(v interface{}, variables, defaults map[string]interface{}) interface{} {
+	switch actual := v.(type) {
+	case graphqlVariable:
+		if val, ok := variables[string(actual)]; ok {
+			return val
+		}
+		return graphqlResolveValue(defaults[string(actual)], nil, nil)
+	case graphqlEnum:
+		return string(actual)
+	case []interface{}:
+		res := make([]interface{}, len(actual))
+		for i, e := range actual {
+			res[i] = graphqlResolveValue(e, variables, defaults)
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(actual))
+		for k, e := range actual {
+			res[k] = graphqlResolveValue(e, variables, defaults)
+		}
+		return res
+	}
+	return v
+}
+
+// graphqlGeneric returns the generic JSON representation of v (maps, slices and primitive
+// values).
+func graphqlGeneric(v interface{}) (interface{}, error) {
+	switch v.(type) {
+	case nil, map[string]interface{}, []interface{}, string, bool, json.Number:
+		return v, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// graphqlProject applies the selection set to the generic value v.
+func graphqlProject(v interface{}, selections []*graphqlField) interface{} {
+	if len(selections) == 0 {
+		return v
+	}
+	switch actual := v.(type) {
+	case map[string]interface{}:
+		res := newGraphQLObject()
+		for _, f := range selections {
+			res.set(f.key(), graphqlProject(actual[f.name], f.selections))
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(actual))
+		for i, e := range actual {
+			res[i] = graphqlProject(e, selections)
+		}
+		return res
+	}
+	return v
+}
+
+// newGraphQLObject returns an empty object.
+func newGraphQLObject() *graphqlObject {
+	return &graphqlObject{values: make(map[string]interface{})}
+}
+
+// set sets the value of the given key, keys are serialized in the order they are first set.
+func (o *graphqlObject) set(key string, val interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = val
+}
+
+// MarshalJSON serializes the object keys in order.
+func (o *graphqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// parseGraphQL parses the GraphQL document and returns the operation with the given name. name
+// may be empty if the document contains a single operation.
+func parseGraphQL(src, name string) (*graphqlOperation, error) {
+	p := &graphqlParser{src: src}
+	var ops []*graphqlOperation
+	for {
+		p.skip()
+		if p.pos >= len(p.src) {
+			break
+		}
+		op, err := p.operation()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	if len(ops) == 0 {
+		return nil, errors.New("query contains no operation")
+	}
+	if name == "" {
+		if len(ops) > 1 {
+			return nil, errors.New("operation name is required when the query contains multiple operations")
+		}
+		return ops[0], nil
+	}
+	for _, op := range ops {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %s", name)
+}
+
+// operation parses an operation definition.
+func (p *graphqlParser) operation() (*graphqlOperation, error) {
+	op := &graphqlOperation{kind: "query", defaults: make(map[string]interface{})}
+	if p.peek() != '{' {
+		kind := p.name()
+		switch kind {
+		case "query", "mutation":
+			op.kind = kind
+		case "fragment":
+			return nil, p.errorf("fragments are not supported")
+		case "subscription":
+			return nil, p.errorf("subscriptions are not supported")
+		default:
+			return nil, p.errorf("unexpected %#v", kind)
+		}
+		p.skip()
+		if graphqlNameStart(p.peek()) {
+			op.name = p.name()
+		}
+		if p.accept('(') {
+			for !p.accept(')') {
+				if err := p.variableDefinition(op.defaults); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if p.peek() == '@' {
+			return nil, p.errorf("directives are not supported")
+		}
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+// variableDefinition parses a variable definition and records its default value if any. The
+// variable type is not checked.
+func (p *graphqlParser) variableDefinition(defaults map[string]interface{}) error {
+	if !p.accept('$') {
+		return p.errorf("expected variable definition")
+	}
+	name := p.name()
+	if name == "" || !p.accept(':') {
+		return p.errorf("invalid variable definition")
+	}
+	if err := p.typeRef(); err != nil {
+		return err
+	}
+	if p.accept('=') {
+		val, err := p.value()
+		if err != nil {
+			return err
+		}
+		defaults[name] = val
+	}
+	return nil
+}
+
+// typeRef skips a type reference.
+func (p *graphqlParser) typeRef() error {
+	if p.accept('[') {
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+		if !p.accept(']') {
+			return p.errorf("expected ]")
+		}
+	} else if p.name() == "" {
+		return p.errorf("expected type")
+	}
+	p.accept('!')
+	return nil
+}
+
+// selectionSet parses a selection set.
+func (p *graphqlParser) selectionSet() ([]*graphqlField, error) {
+	if !p.accept('{') {
+		return nil, p.errorf("expected {")
+	}
+	var fields []*graphqlField
+	for !p.accept('}') {
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated selection set")
+		}
+		if strings.HasPrefix(p.src[p.pos:], "...") {
+			return nil, p.errorf("fragments are not supported")
+		}
+		f, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	if len(fields) == 0 {
+		return nil, p.errorf("empty selection set")
+	}
+	return fields, nil
+}
+
+// field parses a field selection.
+func (p *graphqlParser) field() (*graphqlField, error) {
+	f := &graphqlField{name: p.name()}
+	if f.name == "" {
+		return nil, p.errorf("expected field name")
+	}
+	if p.accept(':') {
+		f.alias = f.name
+		if f.name = p.name(); f.name == "" {
+			return nil, p.errorf("expected field name")
+		}
+	}
+	if p.accept('(') {
+		f.args = make(map[string]interface{})
+		for !p.accept(')') {
+			name := p.name()
+			if name == "" || !p.accept(':') {
+				return nil, p.errorf("invalid argument")
+			}
+			val, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			f.args[name] = val
+		}
+	}
+	if p.peek() == '@' {
+		return nil, p.errorf("directives are not supported")
+	}
+	if p.peek() == '{' {
+		sels, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		f.selections = sels
+	}
+	return f, nil
+}
+
+// value parses an argument value.
+func (p *graphqlParser) value() (interface{}, error) {
+	p.skip()
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("expected value")
+	}
+	c := p.src[p.pos]
+	switch {
+	case c == '$':
+		p.pos++
+		name := p.name()
+		if name == "" {
+			return nil, p.errorf("expected variable name")
+		}
+		return graphqlVariable(name), nil
+	case c == '"':
+		return p.str()
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	case c == '[':
+		p.pos++
+		var list []interface{}
+		for !p.accept(']') {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		if list == nil {
+			list = []interface{}{}
+		}
+		return list, nil
+	case c == '{':
+		p.pos++
+		obj := make(map[string]interface{})
+		for !p.accept('}') {
+			name := p.name()
+			if name == "" || !p.accept(':') {
+				return nil, p.errorf("invalid object field")
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = v
+		}
+		return obj, nil
+	case graphqlNameStart(c):
+		switch name := p.name(); name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return graphqlEnum(name), nil
+		}
+	}
+	return nil, p.errorf("unexpected character %q", c)
+}
+
+// str parses a string value.
+func (p *graphqlParser) str() (interface{}, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '\n':
+			return nil, p.errorf("unterminated string")
+		case '"':
+			p.pos++
+			s, err := strconv.Unquote(p.src[start:p.pos])
+			if err != nil {
+				return nil, p.errorf("invalid string %s", p.src[start:p.pos])
+			}
+			return s, nil
+		}
+		p.pos++
+	}
+	return nil, p.errorf("unterminated string")
+}
+
+// number parses an int or float value.
+func (p *graphqlParser) number() (interface{}, error) {
+	start := p.pos
+	float := false
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '.' || c == 'e' || c == 'E' {
+			float = true
+		} else if !(c >= '0' && c <= '9') && c != '-' && c != '+' {
+			break
+		}
+		p.pos++
+	}
+	lit := p.src[start:p.pos]
+	if float {
+		f, err := strconv.ParseFloat(lit, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %s", lit)
+		}
+		return f, nil
+	}
+	i, err := strconv.ParseInt(lit, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %s", lit)
+	}
+	return i, nil
+}
+
+// name parses a name, it returns the empty string if there is none at the current position.
+func (p *graphqlParser) name() string {
+	p.skip()
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if !graphqlNameStart(c) && !(c >= '0' && c <= '9' && p.pos > start) {
+			break
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// accept consumes the punctuator c if it is next and returns true, it returns false otherwise.
+func (p *graphqlParser) accept(c byte) bool {
+	if p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// peek returns the next significant character, 0 at the end of the document.
+func (p *graphqlParser) peek() byte {
+	p.skip()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// skip skips white spaces, commas and comments.
+func (p *graphqlParser) skip() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r', ',':
+			p.pos++
+		case '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// errorf returns a syntax error reported at the current position.
+func (p *graphqlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// graphqlNameStart returns true if c may start a GraphQL name.
+func graphqlNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,145 @@
+package goa_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GraphQLHandler", func() {
+	var service *goa.Service
+	var handler *goa.GraphQLHandler
+	var method string
+	var body string
+	var rw *httptest.ResponseRecorder
+
+	var gotPath string
+	var gotQuery url.Values
+	var gotBody []byte
+	var gotAuth string
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		handler = goa.NewGraphQLHandler(service)
+		method = "POST"
+		body = `{"query":"query Show($id: Int!) { bottle: showBottle(account_id: 1, id: $id, view: tiny) { id name } }","variables":{"id":42}}`
+		rw = httptest.NewRecorder()
+		gotPath, gotQuery, gotBody, gotAuth = "", nil, nil, ""
+		handler.Query("showBottle", goa.GraphQLBridge(service, goa.GraphQLRoute{Verb: "GET", Path: "/accounts/:account_id/bottles/:id"}))
+		handler.Query("listBottles", goa.GraphQLBridge(service, goa.GraphQLRoute{Verb: "GET", Path: "/bottles"}))
+		handler.Mutation("createBottle", goa.GraphQLBridge(service, goa.GraphQLRoute{Verb: "POST", Path: "/accounts/:account_id/bottles"}))
+		handle := func(status int, body string) goa.MuxHandler {
+			return func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+				gotPath = req.URL.Path
+				gotQuery = req.URL.Query()
+				gotAuth = req.Header.Get("Authorization")
+				if req.Body != nil {
+					gotBody, _ = ioutil.ReadAll(req.Body)
+				}
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(status)
+				rw.Write([]byte(body))
+			}
+		}
+		service.Mux.Handle("GET", "/accounts/:account_id/bottles/:id", handle(200, `{"id":42,"name":"Number 8","vintage":2012}`))
+		service.Mux.Handle("POST", "/accounts/:account_id/bottles", handle(404, `{"id":1,"title":"not found","msg":"no account with id 1"}`))
+		service.Mux.Handle("GET", "/bottles", handle(200, `[{"id":1,"name":"a"},{"id":2,"name":"b"}]`))
+		handler.Mount("")
+	})
+
+	JustBeforeEach(func() {
+		var req *http.Request
+		var err error
+		if method == "GET" {
+			req, err = http.NewRequest("GET", "http://localhost/graphql?"+body, nil)
+		} else {
+			req, err = http.NewRequest("POST", "http://localhost/graphql", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+		}
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Authorization", "Bearer token")
+		service.Mux.ServeHTTP(rw, req)
+	})
+
+	It("bridges the query to the action and applies the selection set", func() {
+		Ω(rw.Code).Should(Equal(200))
+		Ω(gotPath).Should(Equal("/accounts/1/bottles/42"))
+		Ω(gotQuery).Should(Equal(url.Values{"view": {"tiny"}}))
+		Ω(gotAuth).Should(Equal("Bearer token"))
+		Ω(rw.Body.String()).Should(MatchJSON(`{"data":{"bottle":{"id":42,"name":"Number 8"}}}`))
+	})
+
+	Context("with a list response", func() {
+		BeforeEach(func() {
+			body = `{"query":"{ listBottles { name } }"}`
+		})
+
+		It("applies the selection set to each item", func() {
+			Ω(rw.Body.String()).Should(MatchJSON(`{"data":{"listBottles":[{"name":"a"},{"name":"b"}]}}`))
+		})
+	})
+
+	Context("with a mutation", func() {
+		BeforeEach(func() {
+			body = `{"query":"mutation { createBottle(account_id: 1, payload: {name: \"Number 8\"}) { id } }"}`
+		})
+
+		It("sends the payload in the body and reports the action error", func() {
+			Ω(rw.Code).Should(Equal(200))
+			Ω(gotPath).Should(Equal("/accounts/1/bottles"))
+			Ω(string(gotBody)).Should(MatchJSON(`{"name":"Number 8"}`))
+			Ω(rw.Body.String()).Should(MatchJSON(`{"data":{"createBottle":null},"errors":[{"message":"no account with id 1","path":["createBottle"]}]}`))
+		})
+	})
+
+	Context("with a GET request", func() {
+		BeforeEach(func() {
+			method = "GET"
+			body = url.Values{"query": {"{ showBottle(account_id: 1, id: 42) { name } }"}}.Encode()
+		})
+
+		It("executes the query", func() {
+			Ω(rw.Body.String()).Should(MatchJSON(`{"data":{"showBottle":{"name":"Number 8"}}}`))
+		})
+
+		Context("containing a mutation", func() {
+			BeforeEach(func() {
+				body = url.Values{"query": {"mutation { createBottle(account_id: 1) { id } }"}}.Encode()
+			})
+
+			It("refuses to execute it", func() {
+				Ω(rw.Code).Should(Equal(405))
+				Ω(gotPath).Should(BeEmpty())
+			})
+		})
+	})
+
+	Context("with a missing path argument", func() {
+		BeforeEach(func() {
+			body = `{"query":"{ showBottle(id: 42) { name } }"}`
+		})
+
+		It("returns an error", func() {
+			Ω(gotPath).Should(BeEmpty())
+			Ω(rw.Body.String()).Should(MatchJSON(`{"data":{"showBottle":null},"errors":[{"message":"missing field account_id","path":["showBottle"]}]}`))
+		})
+	})
+
+	Context("with an invalid query", func() {
+		BeforeEach(func() {
+			body = `{"query":"{ showBottle { ...bottleFields } }"}`
+		})
+
+		It("returns a bad request", func() {
+			Ω(rw.Code).Should(Equal(400))
+			Ω(rw.Body.String()).Should(ContainSubstring("fragments are not supported"))
+			Ω(rw.Body.String()).ShouldNot(ContainSubstring(`"data"`))
+		})
+	})
+})
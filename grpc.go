@@ -0,0 +1,433 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gRPC status codes, see https://github.com/grpc/grpc/blob/master/doc/statuscodes.md.
+const (
+	grpcOK                = 0
+	grpcInvalidArgument   = 3
+	grpcDeadlineExceeded  = 4
+	grpcNotFound          = 5
+	grpcAlreadyExists     = 6
+	grpcPermissionDenied  = 7
+	grpcResourceExhausted = 8
+	grpcUnimplemented     = 12
+	grpcInternal          = 13
+	grpcUnavailable       = 14
+	grpcUnauthenticated   = 16
+)
+
+const (
+	// grpcContentTypePrefix is the prefix of all gRPC request content types.
+	grpcContentTypePrefix = "application/grpc"
+	// grpcPayloadField is the name of the request message field holding the action payload.
+	grpcPayloadField = "payload"
+	// grpcItemsField is the name of the response message field holding array responses.
+	grpcItemsField = "items"
+)
+
+type (
+	// GRPCServer serves gRPC requests by bridging them to the controllers mounted on a service.
+	// Each rpc is mapped to the route of the corresponding action: the top level fields of the
+	// request message provide the values of the path and querystring parameters while the
+	// "payload" field, if any, provides the request body. The response body becomes the response
+	// message and the response status is translated into a gRPC status code.
+	// The code generated by "goagen grpc" registers the routes of all the rpcs defined in the
+	// design.
+	//
+	// Only unary rpcs using uncompressed messages are supported. The server implements
+	// http.Handler and must be served over HTTP/2 to be usable by standard gRPC clients.
+	GRPCServer struct {
+		// Service is the service the requests are dispatched to.
+		Service *Service
+		routes  map[string]GRPCRoute
+		codecs  map[string]GRPCCodec
+	}
+
+	// GRPCRoute describes the action route a gRPC method maps to. By default the values of the
+	// action parameters are held in the top level request message fields with the same names
+	// and the payload in the "payload" field, Fields and Body make it possible to describe other
+	// message layouts.
+	GRPCRoute struct {
+		// Version is the name of the API version that defines the action, empty if none.
+		Version string
+		// Verb is the HTTP method of the route.
+		Verb string
+		// Path is the full path of the route including wildcards.
+		Path string
+		// Fields maps the names of action parameters to the paths of the request message
+		// fields holding their values, e.g. "bottle.id". Parameters that are not listed use
+		// the top level fields with the same names.
+		Fields map[string]string
+		// Body is the path of the request message field holding the action payload, "payload"
+		// if empty. The value "*" means that the payload is made of all the message fields
+		// that are not listed in Fields.
+		Body string
+		// Params maps the names of the action parameters to their types ("string", "integer",
+		// "number" or "boolean", prefixed with "[]" for arrays). GRPCProxy uses it to build
+		// the request message fields, parameters that are not listed are not sent.
+		Params map[string]string
+		// Status is the HTTP status of the responses written by GRPCProxy for successful rpcs,
+		// http.StatusOK if 0.
+		Status int
+		// Items is true if the action response is an array. GRPCProxy writes the value of the
+		// "items" response message field instead of the whole message.
+		Items bool
+	}
+
+	// GRPCCodec encodes and decodes gRPC messages. Codecs are registered by content subtype, the
+	// subtype of a request with content type "application/grpc+json" is "json". Messages are
+	// decoded into and encoded from their generic JSON representation (maps, slices and
+	// primitive values) keyed by the JSON names of the message fields.
+	GRPCCodec interface {
+		// Unmarshal decodes a request message.
+		Unmarshal(data []byte) (map[string]interface{}, error)
+		// Marshal encodes a response message.
+		Marshal(msg map[string]interface{}) ([]byte, error)
+	}
+
+	// grpcJSONCodec is the codec used for the "json" content subtype.
+	grpcJSONCodec struct{}
+
+	// grpcError describes a failed rpc.
+	grpcError struct {
+		code int
+		msg  string
+	}
+)
+
+// NewGRPCServer returns a gRPC server that dispatches requests to the given service. The server
+// supports the "json" content subtype out of the box, other encodings can be added with
+// RegisterCodec.
+func NewGRPCServer(service *Service) *GRPCServer {
+	return &GRPCServer{
+		Service: service,
+		routes:  make(map[string]GRPCRoute),
+		codecs:  map[string]GRPCCodec{"json": grpcJSONCodec{}},
+	}
+}
+
+// IsGRPCRequest returns true if the request content type is a gRPC content type.
+func IsGRPCRequest(req *http.Request) bool {
+	return strings.HasPrefix(req.Header.Get("Content-Type"), grpcContentTypePrefix)
+}
+
+// Handle maps the gRPC method with the given full name ("/package.Service/Method") to the given
+// action route.
+func (s *GRPCServer) Handle(method string, route GRPCRoute) {
+	s.routes[method] = route
+}
+
+// RegisterCodec sets the codec used to encode and decode the messages of requests with the given
+// content subtype.
+func (s *GRPCServer) RegisterCodec(subtype string, codec GRPCCodec) {
+	s.codecs[subtype] = codec
+}
+
+// Handler returns a http.Handler that serves gRPC requests with the server and all other requests
+// with next. This makes it possible to serve both transports on the same listener:
+//
+//	http.ListenAndServeTLS(addr, cert, key, grpcServer.Handler(service.Mux))
+func (s *GRPCServer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if IsGRPCRequest(req) {
+			s.ServeHTTP(rw, req)
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// ServeHTTP serves a gRPC request.
+func (s *GRPCServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	contentType := req.Header.Get("Content-Type")
+	rw.Header().Set("Content-Type", contentType)
+	msg, err := s.serve(req, contentType)
+	if err != nil {
+		// Trailers-only response
+		rw.Header().Set("Grpc-Status", strconv.Itoa(err.code))
+		rw.Header().Set("Grpc-Message", grpcEncodeMessage(err.msg))
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+	rw.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	rw.WriteHeader(http.StatusOK)
+	writeGRPCFrame(rw, msg)
+	rw.Header().Set("Grpc-Status", strconv.Itoa(grpcOK))
+	rw.Header().Set("Grpc-Message", "")
+}
+
+// serve dispatches the request to the action mapped to the rpc and returns the encoded response
+// message.
+func (s *GRPCServer) serve(req *http.Request, contentType string) ([]byte, *grpcError) {
+	if req.Method != "POST" {
+		return nil, &grpcError{grpcUnimplemented, "gRPC requests must use the POST method"}
+	}
+	subtype := strings.TrimPrefix(strings.TrimPrefix(contentType, grpcContentTypePrefix), "+")
+	if subtype == "" {
+		subtype = "proto"
+	}
+	codec, ok := s.codecs[subtype]
+	if !ok {
+		return nil, &grpcError{grpcUnimplemented, fmt.Sprintf("unsupported content subtype %#v", subtype)}
+	}
+	route, ok := s.routes[req.URL.Path]
+	if !ok {
+		return nil, &grpcError{grpcUnimplemented, fmt.Sprintf("unknown method %s", req.URL.Path)}
+	}
+	data, gerr := readGRPCFrame(req.Body)
+	if gerr != nil {
+		return nil, gerr
+	}
+	msg, err := codec.Unmarshal(data)
+	if err != nil {
+		return nil, &grpcError{grpcInternal, fmt.Sprintf("failed to decode request message: %s", err)}
+	}
+	inner, err := bridgeRequest(req, route.Verb, route.Path, route.fields(msg), grpcPayloadField)
+	if err != nil {
+		return nil, &grpcError{grpcInvalidArgument, err.Error()}
+	}
+	rec := bridgeServe(s.Service, route.Version, inner)
+	if rec.failed() {
+		return nil, &grpcError{grpcStatus(rec.status), rec.errorMessage()}
+	}
+	resp, err := grpcResponseMessage(rec.body.Bytes())
+	if err != nil {
+		return nil, &grpcError{grpcInternal, fmt.Sprintf("failed to decode response: %s", err)}
+	}
+	out, err := codec.Marshal(resp)
+	if err != nil {
+		return nil, &grpcError{grpcInternal, fmt.Sprintf("failed to encode response message: %s", err)}
+	}
+	return out, nil
+}
+
+// fields returns the values of the action parameters and payload held in the request message
+// keyed by parameter name, the payload is stored under the "payload" key.
+func (r GRPCRoute) fields(msg map[string]interface{}) map[string]interface{} {
+	if len(r.Fields) == 0 && (r.Body == "" || r.Body == grpcPayloadField) {
+		return msg
+	}
+	fields := make(map[string]interface{})
+	for name, path := range r.Fields {
+		if val, ok := grpcExtractField(msg, path); ok {
+			fields[name] = val
+		}
+	}
+	switch r.Body {
+	case "", grpcPayloadField:
+	case "*":
+		if len(msg) > 0 {
+			fields[grpcPayloadField] = msg
+		}
+		return fields
+	default:
+		if val, ok := grpcExtractField(msg, r.Body); ok {
+			fields[grpcPayloadField] = val
+		}
+	}
+	for name, val := range msg {
+		if _, ok := fields[name]; !ok {
+			fields[name] = val
+		}
+	}
+	return fields
+}
+
+// grpcExtractField removes the field with the given dotted path from msg and returns its value.
+// The messages left empty by the removal are removed as well.
+func grpcExtractField(msg map[string]interface{}, path string) (interface{}, bool) {
+	elems := strings.SplitN(path, ".", 2)
+	val, ok := msg[elems[0]]
+	if !ok {
+		return nil, false
+	}
+	if len(elems) == 1 {
+		delete(msg, elems[0])
+		return val, true
+	}
+	inner, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	val, ok = grpcExtractField(inner, elems[1])
+	if ok && len(inner) == 0 {
+		delete(msg, elems[0])
+	}
+	return val, ok
+}
+
+// grpcSetField sets the value of the field with the given dotted path in msg creating the
+// intermediary messages as needed.
+func grpcSetField(msg map[string]interface{}, path string, val interface{}) {
+	elems := strings.Split(path, ".")
+	for _, elem := range elems[:len(elems)-1] {
+		inner, ok := msg[elem].(map[string]interface{})
+		if !ok {
+			inner = make(map[string]interface{})
+			msg[elem] = inner
+		}
+		msg = inner
+	}
+	msg[elems[len(elems)-1]] = val
+}
+
+// Unmarshal decodes a JSON request message, numbers are kept as json.Number values so that
+// integer fields round trip unchanged.
+func (grpcJSONCodec) Unmarshal(data []byte) (map[string]interface{}, error) {
+	msg := make(map[string]interface{})
+	if len(bytes.TrimSpace(data)) == 0 {
+		return msg, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Marshal encodes a JSON response message.
+func (grpcJSONCodec) Marshal(msg map[string]interface{}) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+// readGRPCFrame reads a length-prefixed message from r.
+func readGRPCFrame(r io.Reader) ([]byte, *grpcError) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, &grpcError{grpcInternal, fmt.Sprintf("failed to read message header: %s", err)}
+	}
+	if header[0] != 0 {
+		return nil, &grpcError{grpcUnimplemented, "compressed messages are not supported"}
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, &grpcError{grpcInternal, fmt.Sprintf("failed to read message: %s", err)}
+	}
+	return data, nil
+}
+
+// writeGRPCFrame writes msg to w prefixed with its length.
+func writeGRPCFrame(w io.Writer, msg []byte) error {
+	var header [5]byte
+	binary.BigEndian.PutUint32(header[1:], uint32(len(msg)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+// grpcResponseMessage decodes the action response body into a response message. Array responses
+// are wrapped into a message with a single "items" field as done by the proto generator.
+func grpcResponseMessage(body []byte) (map[string]interface{}, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	var val interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&val); err != nil {
+		return nil, err
+	}
+	switch actual := val.(type) {
+	case map[string]interface{}:
+		return actual, nil
+	case []interface{}:
+		return map[string]interface{}{grpcItemsField: actual}, nil
+	}
+	return nil, fmt.Errorf("response body is not an object or an array")
+}
+
+// grpcStatus returns the gRPC status code corresponding to the given HTTP error status.
+func grpcStatus(status int) int {
+	switch status {
+	case http.StatusBadRequest:
+		return grpcInvalidArgument
+	case http.StatusUnauthorized:
+		return grpcUnauthenticated
+	case http.StatusForbidden:
+		return grpcPermissionDenied
+	case http.StatusNotFound:
+		return grpcNotFound
+	case http.StatusConflict:
+		return grpcAlreadyExists
+	case 429:
+		return grpcResourceExhausted
+	case http.StatusNotImplemented:
+		return grpcUnimplemented
+	case http.StatusServiceUnavailable:
+		return grpcUnavailable
+	case http.StatusGatewayTimeout:
+		return grpcDeadlineExceeded
+	}
+	return grpcInternal
+}
+
+// grpcHTTPStatus returns the HTTP status corresponding to the given gRPC status code, it is the
+// reverse of grpcStatus.
+func grpcHTTPStatus(code int) int {
+	switch code {
+	case grpcOK:
+		return http.StatusOK
+	case grpcInvalidArgument:
+		return http.StatusBadRequest
+	case grpcUnauthenticated:
+		return http.StatusUnauthorized
+	case grpcPermissionDenied:
+		return http.StatusForbidden
+	case grpcNotFound:
+		return http.StatusNotFound
+	case grpcAlreadyExists:
+		return http.StatusConflict
+	case grpcResourceExhausted:
+		return 429
+	case grpcUnimplemented:
+		return http.StatusNotImplemented
+	case grpcUnavailable:
+		return http.StatusServiceUnavailable
+	case grpcDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// grpcEncodeMessage percent-encodes the status message as required by the gRPC protocol.
+func grpcEncodeMessage(msg string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c < 0x20 || c > 0x7E || c == '%' {
+			fmt.Fprintf(&buf, "%%%02X", c)
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// grpcDecodeMessage decodes a percent-encoded status message.
+func grpcDecodeMessage(msg string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(msg); i++ {
+		if msg[i] == '%' && i+2 < len(msg) {
+			if c, err := strconv.ParseUint(msg[i+1:i+3], 16, 8); err == nil {
+				buf.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		buf.WriteByte(msg[i])
+	}
+	return buf.String()
+}
@@ -0,0 +1,160 @@
+package goa_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GRPCServer", func() {
+	var service *goa.Service
+	var server *goa.GRPCServer
+	var method string
+	var msg string
+	var contentType string
+	var rw *TestResponseWriter
+
+	var gotPath string
+	var gotQuery url.Values
+	var gotBody []byte
+
+	frame := func(msg string) []byte {
+		b := make([]byte, 5+len(msg))
+		binary.BigEndian.PutUint32(b[1:5], uint32(len(msg)))
+		copy(b[5:], msg)
+		return b
+	}
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		server = goa.NewGRPCServer(service)
+		method = "/api.Bottle/Show"
+		msg = `{"account_id":1,"id":42,"view":"tiny"}`
+		contentType = "application/grpc+json"
+		rw = &TestResponseWriter{ParentHeader: make(http.Header)}
+		gotPath, gotQuery, gotBody = "", nil, nil
+		server.Handle("/api.Bottle/Show", goa.GRPCRoute{Verb: "GET", Path: "/accounts/:account_id/bottles/:id"})
+		server.Handle("/api.Bottle/Create", goa.GRPCRoute{Verb: "POST", Path: "/accounts/:account_id/bottles"})
+		server.Handle("/api.Bottle/List", goa.GRPCRoute{Verb: "GET", Path: "/bottles"})
+		handle := func(status int, body string) goa.MuxHandler {
+			return func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+				gotPath = req.URL.Path
+				gotQuery = req.URL.Query()
+				if req.Body != nil {
+					gotBody, _ = ioutil.ReadAll(req.Body)
+				}
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(status)
+				rw.Write([]byte(body))
+			}
+		}
+		service.Mux.Handle("GET", "/accounts/:account_id/bottles/:id", handle(200, `{"id":42,"name":"Number 8"}`))
+		service.Mux.Handle("POST", "/accounts/:account_id/bottles", handle(404, `{"id":1,"title":"not found","msg":"no account with id 1"}`))
+		service.Mux.Handle("GET", "/bottles", handle(200, `[{"id":1},{"id":2}]`))
+	})
+
+	JustBeforeEach(func() {
+		req, err := http.NewRequest("POST", "http://localhost"+method, bytes.NewReader(frame(msg)))
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Content-Type", contentType)
+		server.ServeHTTP(rw, req)
+	})
+
+	It("bridges the request to the action", func() {
+		Ω(rw.Status).Should(Equal(200))
+		Ω(gotPath).Should(Equal("/accounts/1/bottles/42"))
+		Ω(gotQuery).Should(Equal(url.Values{"view": {"tiny"}}))
+		Ω(rw.ParentHeader.Get("Grpc-Status")).Should(Equal("0"))
+		Ω(rw.ParentHeader.Get("Content-Type")).Should(Equal("application/grpc+json"))
+		Ω(rw.Body).Should(Equal(frame(`{"id":42,"name":"Number 8"}`)))
+	})
+
+	Context("with a payload", func() {
+		BeforeEach(func() {
+			method = "/api.Bottle/Create"
+			msg = `{"account_id":1,"payload":{"name":"Number 8"}}`
+		})
+
+		It("sends the payload in the body and maps the error status", func() {
+			Ω(gotPath).Should(Equal("/accounts/1/bottles"))
+			Ω(string(gotBody)).Should(MatchJSON(`{"name":"Number 8"}`))
+			Ω(rw.ParentHeader.Get("Grpc-Status")).Should(Equal("5"))
+			Ω(rw.ParentHeader.Get("Grpc-Message")).Should(Equal("no account with id 1"))
+			Ω(rw.Body).Should(BeEmpty())
+		})
+	})
+
+	Context("with an array response", func() {
+		BeforeEach(func() {
+			method = "/api.Bottle/List"
+			msg = `{}`
+		})
+
+		It("wraps the items in a message", func() {
+			Ω(rw.ParentHeader.Get("Grpc-Status")).Should(Equal("0"))
+			var resp map[string]interface{}
+			Ω(json.Unmarshal(rw.Body[5:], &resp)).ShouldNot(HaveOccurred())
+			Ω(resp).Should(HaveKey("items"))
+			Ω(resp["items"]).Should(HaveLen(2))
+		})
+	})
+
+	Context("with a missing path parameter", func() {
+		BeforeEach(func() {
+			msg = `{"id":42}`
+		})
+
+		It("returns an invalid argument status", func() {
+			Ω(gotPath).Should(BeEmpty())
+			Ω(rw.ParentHeader.Get("Grpc-Status")).Should(Equal("3"))
+			Ω(rw.ParentHeader.Get("Grpc-Message")).Should(Equal("missing field account_id"))
+		})
+	})
+
+	Context("with field mapping rules", func() {
+		BeforeEach(func() {
+			server.Handle("/api.Bottle/Update", goa.GRPCRoute{
+				Verb:   "POST",
+				Path:   "/accounts/:account_id/bottles",
+				Fields: map[string]string{"account_id": "bottle.account.id", "view": "options.view"},
+				Body:   "*",
+			})
+			method = "/api.Bottle/Update"
+			msg = `{"bottle":{"account":{"id":1},"name":"Number 8"},"options":{"view":"tiny"},"vintage":2012}`
+		})
+
+		It("extracts the parameters and sends the other fields as payload", func() {
+			Ω(gotPath).Should(Equal("/accounts/1/bottles"))
+			Ω(gotQuery).Should(Equal(url.Values{"view": {"tiny"}}))
+			Ω(string(gotBody)).Should(MatchJSON(`{"bottle":{"name":"Number 8"},"vintage":2012}`))
+		})
+	})
+
+	Context("with an unknown method", func() {
+		BeforeEach(func() {
+			method = "/api.Bottle/Delete"
+		})
+
+		It("returns an unimplemented status", func() {
+			Ω(rw.ParentHeader.Get("Grpc-Status")).Should(Equal("12"))
+		})
+	})
+
+	Context("with an unsupported codec", func() {
+		BeforeEach(func() {
+			contentType = "application/grpc"
+		})
+
+		It("returns an unimplemented status", func() {
+			Ω(rw.ParentHeader.Get("Grpc-Status")).Should(Equal("12"))
+			Ω(gotPath).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,260 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GRPCProxy exposes a gRPC service as a REST API. It is the reverse of GRPCServer: the requests
+// made to the action routes are translated into calls to the corresponding rpcs and the response
+// messages are translated back into REST responses. This makes it possible to put a REST front in
+// front of a gRPC implementation of the API. The parameters and payload of the requests are
+// mapped to the request message fields as described by the GRPCRoute Fields and Body fields.
+// The code generated by "goagen grpcbridge" mounts the routes of all the rpcs defined in the
+// design.
+//
+// Only unary rpcs are supported. Standard gRPC servers only accept HTTP/2 requests so Client must
+// use a HTTP/2 transport.
+type GRPCProxy struct {
+	// Service is the service whose mux the routes are mounted on.
+	Service *Service
+	// URL is the base URL of the gRPC service, e.g. "https://localhost:8443".
+	URL string
+	// Client is the HTTP client used to make the rpcs.
+	Client *http.Client
+	// Codec is used to encode the request messages and decode the response messages.
+	Codec GRPCCodec
+	// ContentType is the content type of the rpc requests, it must match Codec.
+	ContentType string
+}
+
+// NewGRPCProxy returns a proxy that mounts its routes on the given service and makes rpcs to the
+// gRPC service located at the given URL. The proxy uses the "json" content subtype and the default
+// HTTP client.
+func NewGRPCProxy(service *Service, url string) *GRPCProxy {
+	return &GRPCProxy{
+		Service:     service,
+		URL:         strings.TrimSuffix(url, "/"),
+		Client:      http.DefaultClient,
+		Codec:       grpcJSONCodec{},
+		ContentType: grpcContentTypePrefix + "+json",
+	}
+}
+
+// Handle mounts the route of the action bridged to the gRPC method with the given full name
+// ("/package.Service/Method").
+func (p *GRPCProxy) Handle(method string, route GRPCRoute) {
+	mux := p.Service.Mux
+	if route.Version != "" {
+		mux = p.Service.Version(route.Version).Mux
+	}
+	mux.Handle(route.Verb, route.Path, func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+		p.serve(rw, req, method, route, params)
+	})
+}
+
+// serve makes the rpc corresponding to the request and writes the response.
+func (p *GRPCProxy) serve(rw http.ResponseWriter, req *http.Request, method string, route GRPCRoute, params url.Values) {
+	msg, err := grpcRequestMessage(req, route, params)
+	if err != nil {
+		grpcWriteError(rw, http.StatusBadRequest, err.Error())
+		return
+	}
+	resp, gerr := p.call(req, method, msg)
+	if gerr != nil {
+		grpcWriteError(rw, grpcHTTPStatus(gerr.code), gerr.msg)
+		return
+	}
+	var body interface{} = resp
+	if route.Items {
+		items, ok := resp[grpcItemsField]
+		if !ok || items == nil {
+			items = []interface{}{}
+		}
+		body = items
+	}
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status == http.StatusNoContent {
+		rw.WriteHeader(status)
+		return
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		grpcWriteError(rw, http.StatusInternalServerError, fmt.Sprintf("failed to encode response: %s", err))
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	rw.Write(b)
+}
+
+// call makes the rpc and returns the decoded response message.
+func (p *GRPCProxy) call(req *http.Request, method string, msg map[string]interface{}) (map[string]interface{}, *grpcError) {
+	data, err := p.Codec.Marshal(msg)
+	if err != nil {
+		return nil, &grpcError{grpcInternal, fmt.Sprintf("failed to encode request message: %s", err)}
+	}
+	var buf bytes.Buffer
+	writeGRPCFrame(&buf, data)
+	outer, err := http.NewRequest("POST", p.URL+method, &buf)
+	if err != nil {
+		return nil, &grpcError{grpcInternal, err.Error()}
+	}
+	for k, v := range req.Header {
+		switch k {
+		case "Accept", "Accept-Encoding", "Connection", "Content-Type", "Content-Length":
+		default:
+			outer.Header[k] = v
+		}
+	}
+	outer.Header.Set("Content-Type", p.ContentType)
+	outer.Header.Set("Te", "trailers")
+	resp, err := p.Client.Do(outer)
+	if err != nil {
+		return nil, &grpcError{grpcUnavailable, err.Error()}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &grpcError{grpcStatus(resp.StatusCode), fmt.Sprintf("unexpected HTTP status %d", resp.StatusCode)}
+	}
+	if gerr := grpcResponseStatus(resp.Header); gerr != nil {
+		// Trailers-only response
+		return nil, gerr
+	}
+	data, gerr := readGRPCFrame(resp.Body)
+	if gerr != nil {
+		return nil, gerr
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	if gerr := grpcResponseStatus(resp.Trailer); gerr != nil {
+		return nil, gerr
+	}
+	out, err := p.Codec.Unmarshal(data)
+	if err != nil {
+		return nil, &grpcError{grpcInternal, fmt.Sprintf("failed to decode response message: %s", err)}
+	}
+	return out, nil
+}
+
+// grpcRequestMessage builds the request message from the values of the request parameters and
+// body.
+func grpcRequestMessage(req *http.Request, route GRPCRoute, params url.Values) (map[string]interface{}, error) {
+	msg := make(map[string]interface{})
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body: %s", err)
+		}
+		if len(bytes.TrimSpace(b)) > 0 {
+			var payload interface{}
+			dec := json.NewDecoder(bytes.NewReader(b))
+			dec.UseNumber()
+			if err := dec.Decode(&payload); err != nil {
+				return nil, fmt.Errorf("failed to decode body: %s", err)
+			}
+			switch route.Body {
+			case "*":
+				obj, ok := payload.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("body must be an object")
+				}
+				msg = obj
+			case "":
+				msg[grpcPayloadField] = payload
+			default:
+				grpcSetField(msg, route.Body, payload)
+			}
+		}
+	}
+	for name, typ := range route.Params {
+		vals, ok := params[name]
+		if !ok {
+			continue
+		}
+		val, err := grpcParamValue(name, vals, typ)
+		if err != nil {
+			return nil, err
+		}
+		path := name
+		if p, ok := route.Fields[name]; ok {
+			path = p
+		}
+		grpcSetField(msg, path, val)
+	}
+	return msg, nil
+}
+
+// grpcParamValue converts the values of a request parameter into the value of the corresponding
+// message field given the parameter type.
+func grpcParamValue(name string, vals []string, typ string) (interface{}, error) {
+	if strings.HasPrefix(typ, "[]") {
+		var elems []string
+		for _, v := range vals {
+			elems = append(elems, strings.Split(v, ",")...)
+		}
+		list := make([]interface{}, len(elems))
+		for i, e := range elems {
+			v, err := grpcParamValue(name, []string{e}, typ[2:])
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return list, nil
+	}
+	val := vals[0]
+	switch typ {
+	case "integer":
+		if _, err := strconv.ParseInt(val, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid value %#v for parameter %s, must be an integer", val, name)
+		}
+		return json.Number(val), nil
+	case "number":
+		if _, err := strconv.ParseFloat(val, 64); err != nil {
+			return nil, fmt.Errorf("invalid value %#v for parameter %s, must be a number", val, name)
+		}
+		return json.Number(val), nil
+	case "boolean":
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %#v for parameter %s, must be a boolean", val, name)
+		}
+		return b, nil
+	}
+	return val, nil
+}
+
+// grpcResponseStatus returns the error described by the Grpc-Status and Grpc-Message fields of
+// the given response headers or trailers if any.
+func grpcResponseStatus(h http.Header) *grpcError {
+	status := h.Get("Grpc-Status")
+	if status == "" {
+		return nil
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return &grpcError{grpcInternal, fmt.Sprintf("invalid gRPC status %#v", status)}
+	}
+	if code == grpcOK {
+		return nil
+	}
+	return &grpcError{code, grpcDecodeMessage(h.Get("Grpc-Message"))}
+}
+
+// grpcWriteError writes an error response with the given status and message.
+func grpcWriteError(rw http.ResponseWriter, status int, msg string) {
+	b, _ := json.Marshal(map[string]interface{}{"title": http.StatusText(status), "msg": msg})
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	rw.Write(b)
+}
@@ -0,0 +1,135 @@
+package goa_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GRPCProxy", func() {
+	var backend *goa.Service
+	var front *goa.Service
+	var ts *httptest.Server
+	var verb, path, body string
+	var rw *httptest.ResponseRecorder
+
+	var gotPath string
+	var gotQuery url.Values
+	var gotBody []byte
+
+	routes := map[string]goa.GRPCRoute{
+		"/api.Bottle/Show": {
+			Verb:   "GET",
+			Path:   "/accounts/:account_id/bottles/:id",
+			Fields: map[string]string{"id": "bottle.id"},
+			Params: map[string]string{"account_id": "integer", "id": "integer", "tags": "[]string"},
+		},
+		"/api.Bottle/Create": {
+			Verb:   "POST",
+			Path:   "/accounts/:account_id/bottles",
+			Body:   "bottle",
+			Params: map[string]string{"account_id": "integer"},
+			Status: 201,
+		},
+		"/api.Bottle/List": {
+			Verb:  "GET",
+			Path:  "/bottles",
+			Items: true,
+		},
+	}
+
+	BeforeEach(func() {
+		gotPath, gotQuery, gotBody = "", nil, nil
+		backend = goa.New("backend")
+		handle := func(status int, body string) goa.MuxHandler {
+			return func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+				gotPath = req.URL.Path
+				gotQuery = req.URL.Query()
+				if req.Body != nil {
+					gotBody, _ = ioutil.ReadAll(req.Body)
+				}
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(status)
+				rw.Write([]byte(body))
+			}
+		}
+		backend.Mux.Handle("GET", "/accounts/:account_id/bottles/:id", handle(200, `{"id":42,"name":"Number 8"}`))
+		backend.Mux.Handle("POST", "/accounts/:account_id/bottles", handle(404, `{"id":1,"title":"not found","msg":"no account with id 1"}`))
+		backend.Mux.Handle("GET", "/bottles", handle(200, `[{"id":1},{"id":2}]`))
+		server := goa.NewGRPCServer(backend)
+		for method, route := range routes {
+			server.Handle(method, route)
+		}
+		ts = httptest.NewServer(server)
+
+		front = goa.New("front")
+		proxy := goa.NewGRPCProxy(front, ts.URL)
+		for method, route := range routes {
+			proxy.Handle(method, route)
+		}
+		verb, path, body = "GET", "/accounts/1/bottles/42?tags=a,b", ""
+		rw = httptest.NewRecorder()
+	})
+
+	JustBeforeEach(func() {
+		req, err := http.NewRequest(verb, "http://localhost"+path, strings.NewReader(body))
+		Ω(err).ShouldNot(HaveOccurred())
+		front.Mux.ServeHTTP(rw, req)
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	It("translates the request into a rpc and the response message into a response", func() {
+		Ω(gotPath).Should(Equal("/accounts/1/bottles/42"))
+		Ω(gotQuery).Should(Equal(url.Values{"tags": {"a", "b"}}))
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("Content-Type")).Should(Equal("application/json"))
+		Ω(rw.Body.String()).Should(MatchJSON(`{"id":42,"name":"Number 8"}`))
+	})
+
+	Context("with a body and a rpc error", func() {
+		BeforeEach(func() {
+			verb, path, body = "POST", "/accounts/1/bottles", `{"name":"Number 8"}`
+		})
+
+		It("sends the body in the mapped field and maps the error status", func() {
+			Ω(gotPath).Should(Equal("/accounts/1/bottles"))
+			Ω(string(gotBody)).Should(MatchJSON(`{"name":"Number 8"}`))
+			Ω(rw.Code).Should(Equal(404))
+			var e map[string]interface{}
+			Ω(json.Unmarshal(rw.Body.Bytes(), &e)).ShouldNot(HaveOccurred())
+			Ω(e["msg"]).Should(Equal("no account with id 1"))
+		})
+	})
+
+	Context("with an array response", func() {
+		BeforeEach(func() {
+			path = "/bottles"
+		})
+
+		It("unwraps the items", func() {
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Body.String()).Should(MatchJSON(`[{"id":1},{"id":2}]`))
+		})
+	})
+
+	Context("with an invalid parameter", func() {
+		BeforeEach(func() {
+			path = "/accounts/one/bottles/42"
+		})
+
+		It("does not make the rpc", func() {
+			Ω(gotPath).Should(BeEmpty())
+			Ω(rw.Code).Should(Equal(400))
+		})
+	})
+})
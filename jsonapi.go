@@ -0,0 +1,282 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// JSONAPIMediaType is the media type of JSON:API documents, see http://jsonapi.org.
+const JSONAPIMediaType = "application/vnd.api+json"
+
+type (
+	// JSONAPIDocument is a JSON:API top-level document.
+	JSONAPIDocument struct {
+		// Data is the primary data of the document: a *JSONAPIResource, a slice of
+		// *JSONAPIResource or nil.
+		Data interface{} `json:"data"`
+	}
+
+	// JSONAPIResource is a JSON:API resource object.
+	JSONAPIResource struct {
+		// Type is the resource type.
+		Type string `json:"type"`
+		// ID is the resource identifier.
+		ID string `json:"id,omitempty"`
+		// Attributes contains the resource attributes.
+		Attributes map[string]interface{} `json:"attributes,omitempty"`
+		// Relationships contains the relationships of the resource indexed by name.
+		Relationships map[string]*JSONAPIRelationship `json:"relationships,omitempty"`
+		// Links contains the "self" link of the resource if it has a href.
+		Links map[string]string `json:"links,omitempty"`
+	}
+
+	// JSONAPIRelationship is a JSON:API relationship object.
+	JSONAPIRelationship struct {
+		// Data is the resource linkage: a *JSONAPIIdentifier, a slice of
+		// *JSONAPIIdentifier or nil.
+		Data interface{} `json:"data"`
+		// Links contains the "related" link of the relationship if the related resource
+		// has a href.
+		Links map[string]string `json:"links,omitempty"`
+	}
+
+	// JSONAPIIdentifier is a JSON:API resource identifier object.
+	JSONAPIIdentifier struct {
+		// Type is the related resource type.
+		Type string `json:"type"`
+		// ID is the related resource identifier.
+		ID string `json:"id"`
+	}
+
+	// jsonAPIFactory uses encoding/json to encode and decode JSON:API documents.
+	jsonAPIFactory struct{}
+
+	// jsonAPIDecoder decodes the JSON:API documents read from r.
+	jsonAPIDecoder struct {
+		r io.Reader
+	}
+)
+
+// RenderJSONAPI returns the JSON:API document that renders body as resources of the given type.
+// body is the value of a media type, that is an object or an array of objects. The "id" member of
+// the objects becomes the resource identifier and the "href" member the "self" link. The members
+// of the "links" member (rendered from the media type links) become relationships, the types of
+// the related resources are given by relationships indexed by link name. The other members become
+// the resource attributes. RenderJSONAPI returns body as is if it cannot be rendered. The code
+// generated by goagen for the APIs that use the JSONAPI DSL uses RenderJSONAPI to render the
+// successful responses.
+func RenderJSONAPI(body interface{}, typ string, relationships map[string]string) interface{} {
+	if body == nil {
+		return &JSONAPIDocument{}
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return body
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return body
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return &JSONAPIDocument{Data: jsonAPIResource(val, typ, relationships)}
+	case []interface{}:
+		data := make([]*JSONAPIResource, 0, len(val))
+		for _, e := range val {
+			obj, ok := e.(map[string]interface{})
+			if !ok {
+				return body
+			}
+			data = append(data, jsonAPIResource(obj, typ, relationships))
+		}
+		return &JSONAPIDocument{Data: data}
+	case nil:
+		return &JSONAPIDocument{}
+	}
+	return body
+}
+
+// JSONAPIDecoderFactory returns a factory that creates decoders of JSON:API documents. The
+// decoders flatten the resources of the document primary data before decoding them in the target
+// value: the resource attributes, its identifier as the "id" member and its relationships as the
+// members of the "links" member, each relationship being rendered as an object with an "id" member
+// or an array of such objects. This is the inverse of RenderJSONAPI so that the media types and
+// payloads can be decoded from the documents.
+func JSONAPIDecoderFactory() DecoderFactory {
+	return &jsonAPIFactory{}
+}
+
+// NewDecoder returns a new JSON:API decoder.
+func (f *jsonAPIFactory) NewDecoder(r io.Reader) Decoder {
+	return &jsonAPIDecoder{r: r}
+}
+
+// JSONAPIEncoderFactory returns a factory that creates JSON encoders. The JSON:API documents are
+// built by RenderJSONAPI, the encoders only need to render them in JSON.
+func JSONAPIEncoderFactory() EncoderFactory {
+	return &jsonAPIFactory{}
+}
+
+// NewEncoder returns a new json.Encoder.
+func (f *jsonAPIFactory) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+// Decode reads a JSON:API document and decodes its flattened primary data in v.
+func (d *jsonAPIDecoder) Decode(v interface{}) error {
+	var doc struct {
+		Data *json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(d.r).Decode(&doc); err != nil {
+		return err
+	}
+	if doc.Data == nil {
+		return errors.New("JSON:API document is missing primary data")
+	}
+	var data interface{}
+	raw := []byte(*doc.Data)
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		var resources []*JSONAPIResource
+		if err := json.Unmarshal(raw, &resources); err != nil {
+			return err
+		}
+		flat := make([]map[string]interface{}, len(resources))
+		for i, r := range resources {
+			flat[i] = r.flatten()
+		}
+		data = flat
+	} else {
+		var resource *JSONAPIResource
+		if err := json.Unmarshal(raw, &resource); err != nil {
+			return err
+		}
+		if resource != nil {
+			data = resource.flatten()
+		}
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// UnmarshalJSON decodes the relationship resource linkage in JSONAPIIdentifier values.
+func (r *JSONAPIRelationship) UnmarshalJSON(b []byte) error {
+	var rel struct {
+		Data  json.RawMessage   `json:"data"`
+		Links map[string]string `json:"links"`
+	}
+	if err := json.Unmarshal(b, &rel); err != nil {
+		return err
+	}
+	r.Links = rel.Links
+	if bytes.HasPrefix(bytes.TrimSpace(rel.Data), []byte("[")) {
+		var ids []*JSONAPIIdentifier
+		if err := json.Unmarshal(rel.Data, &ids); err != nil {
+			return err
+		}
+		r.Data = ids
+		return nil
+	}
+	var id *JSONAPIIdentifier
+	if len(rel.Data) > 0 {
+		if err := json.Unmarshal(rel.Data, &id); err != nil {
+			return err
+		}
+	}
+	if id != nil {
+		r.Data = id
+	}
+	return nil
+}
+
+// flatten returns the media type representation of the resource.
+func (r *JSONAPIResource) flatten() map[string]interface{} {
+	obj := make(map[string]interface{}, len(r.Attributes)+2)
+	for k, v := range r.Attributes {
+		obj[k] = v
+	}
+	if r.ID != "" {
+		obj["id"] = r.ID
+	}
+	if len(r.Relationships) == 0 {
+		return obj
+	}
+	links := make(map[string]interface{}, len(r.Relationships))
+	for n, rel := range r.Relationships {
+		switch data := rel.Data.(type) {
+		case *JSONAPIIdentifier:
+			links[n] = map[string]interface{}{"id": data.ID}
+		case []*JSONAPIIdentifier:
+			ids := make([]map[string]interface{}, len(data))
+			for i, id := range data {
+				ids[i] = map[string]interface{}{"id": id.ID}
+			}
+			links[n] = ids
+		}
+	}
+	obj["links"] = links
+	return obj
+}
+
+// jsonAPIResource builds the resource object that renders the given media type representation.
+func jsonAPIResource(obj map[string]interface{}, typ string, relationships map[string]string) *JSONAPIResource {
+	res := &JSONAPIResource{Type: typ}
+	for k, v := range obj {
+		switch k {
+		case "id":
+			if v != nil {
+				res.ID = fmt.Sprint(v)
+			}
+		case "href":
+			if href, ok := v.(string); ok {
+				res.Links = map[string]string{"self": href}
+			}
+		case "links":
+			links, ok := v.(map[string]interface{})
+			if !ok {
+				break
+			}
+			res.Relationships = make(map[string]*JSONAPIRelationship, len(links))
+			for n, l := range links {
+				res.Relationships[n] = jsonAPIRelationship(l, relationships[n])
+			}
+		default:
+			if res.Attributes == nil {
+				res.Attributes = make(map[string]interface{})
+			}
+			res.Attributes[k] = v
+		}
+	}
+	return res
+}
+
+// jsonAPIRelationship builds the relationship object that renders the given link, an object or an
+// array of objects rendered with the link view of the related media type.
+func jsonAPIRelationship(link interface{}, typ string) *JSONAPIRelationship {
+	rel := &JSONAPIRelationship{}
+	switch l := link.(type) {
+	case map[string]interface{}:
+		if id, ok := l["id"]; ok && id != nil {
+			rel.Data = &JSONAPIIdentifier{Type: typ, ID: fmt.Sprint(id)}
+		}
+		if href, ok := l["href"].(string); ok {
+			rel.Links = map[string]string{"related": href}
+		}
+	case []interface{}:
+		ids := make([]*JSONAPIIdentifier, 0, len(l))
+		for _, e := range l {
+			if obj, ok := e.(map[string]interface{}); ok && obj["id"] != nil {
+				ids = append(ids, &JSONAPIIdentifier{Type: typ, ID: fmt.Sprint(obj["id"])})
+			}
+		}
+		rel.Data = ids
+	}
+	return rel
+}
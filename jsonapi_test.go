@@ -0,0 +1,117 @@
+package goa_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RenderJSONAPI", func() {
+	type link struct {
+		ID   int    `json:"id"`
+		Href string `json:"href"`
+	}
+	type bottle struct {
+		ID    int    `json:"id"`
+		Href  string `json:"href,omitempty"`
+		Name  string `json:"name"`
+		Links *struct {
+			Account *link   `json:"account,omitempty"`
+			Notes   []*link `json:"notes,omitempty"`
+		} `json:"links,omitempty"`
+	}
+	rels := map[string]string{"account": "accounts", "notes": "notes"}
+
+	render := func(body interface{}) string {
+		b, err := json.Marshal(goa.RenderJSONAPI(body, "bottles", rels))
+		Ω(err).ShouldNot(HaveOccurred())
+		return string(b)
+	}
+
+	It("renders objects as resources", func() {
+		b := &bottle{ID: 1, Href: "/bottles/1", Name: "Merlot"}
+		b.Links = &struct {
+			Account *link   `json:"account,omitempty"`
+			Notes   []*link `json:"notes,omitempty"`
+		}{Account: &link{ID: 2, Href: "/accounts/2"}, Notes: []*link{{ID: 3}, {ID: 4}}}
+		Ω(render(b)).Should(MatchJSON(`{"data": {
+			"type": "bottles",
+			"id": "1",
+			"attributes": {"name": "Merlot"},
+			"relationships": {
+				"account": {"data": {"type": "accounts", "id": "2"}, "links": {"related": "/accounts/2"}},
+				"notes": {"data": [{"type": "notes", "id": "3"}, {"type": "notes", "id": "4"}]}
+			},
+			"links": {"self": "/bottles/1"}
+		}}`))
+	})
+
+	It("renders collections as arrays of resources", func() {
+		bottles := []*bottle{{ID: 1, Name: "Merlot"}, {ID: 2, Name: "Syrah"}}
+		Ω(render(bottles)).Should(MatchJSON(`{"data": [
+			{"type": "bottles", "id": "1", "attributes": {"name": "Merlot"}},
+			{"type": "bottles", "id": "2", "attributes": {"name": "Syrah"}}
+		]}`))
+	})
+
+	It("renders nil as null primary data", func() {
+		Ω(render(nil)).Should(MatchJSON(`{"data": null}`))
+	})
+})
+
+var _ = Describe("JSONAPIDecoderFactory", func() {
+	type payload struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Links *struct {
+			Account *struct {
+				ID string `json:"id"`
+			} `json:"account"`
+		} `json:"links"`
+	}
+
+	decode := func(body string, v interface{}) error {
+		return goa.JSONAPIDecoderFactory().NewDecoder(bytes.NewBufferString(body)).Decode(v)
+	}
+
+	It("decodes the flattened resource", func() {
+		var p payload
+		err := decode(`{"data": {
+			"type": "bottles",
+			"id": "1",
+			"attributes": {"name": "Merlot"},
+			"relationships": {"account": {"data": {"type": "accounts", "id": "2"}}}
+		}}`, &p)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(p.ID).Should(Equal("1"))
+		Ω(p.Name).Should(Equal("Merlot"))
+		Ω(p.Links).ShouldNot(BeNil())
+		Ω(p.Links.Account.ID).Should(Equal("2"))
+	})
+
+	It("decodes collections", func() {
+		var ps []*payload
+		err := decode(`{"data": [{"type": "bottles", "attributes": {"name": "Merlot"}}, {"type": "bottles", "attributes": {"name": "Syrah"}}]}`, &ps)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ps).Should(HaveLen(2))
+		Ω(ps[1].Name).Should(Equal("Syrah"))
+	})
+
+	It("rejects documents without primary data", func() {
+		var p payload
+		err := decode(`{"errors": []}`, &p)
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("decodes the request bodies sent with the JSON:API media type", func() {
+		service := goa.New("test")
+		service.SetDecoder(goa.JSONAPIDecoderFactory(), false, goa.JSONAPIMediaType)
+		var p payload
+		err := service.Decode(&p, bytes.NewBufferString(`{"data": {"type": "bottles", "attributes": {"name": "Merlot"}}}`), goa.JSONAPIMediaType)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(p.Name).Should(Equal("Merlot"))
+	})
+})
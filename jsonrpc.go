@@ -0,0 +1,263 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// JSON-RPC 2.0 error codes, see http://www.jsonrpc.org/specification#error_object.
+const (
+	// JSONRPCParseError is the code of errors caused by request bodies that are not valid JSON.
+	JSONRPCParseError = -32700
+	// JSONRPCInvalidRequest is the code of errors caused by invalid request objects.
+	JSONRPCInvalidRequest = -32600
+	// JSONRPCMethodNotFound is the code of errors caused by unknown methods.
+	JSONRPCMethodNotFound = -32601
+	// JSONRPCInvalidParams is the code of errors caused by invalid method parameters.
+	JSONRPCInvalidParams = -32602
+	// JSONRPCInternalError is the code of internal errors.
+	JSONRPCInternalError = -32603
+	// JSONRPCServerError is the code of errors returned by the actions the methods map to.
+	JSONRPCServerError = -32000
+)
+
+// jsonrpcPayloadParam is the name of the JSON-RPC method parameter holding the action payload.
+const jsonrpcPayloadParam = "payload"
+
+type (
+	// JSONRPCServer serves JSON-RPC 2.0 requests by bridging them to the controllers mounted on
+	// a service. Each method is mapped to the route of the corresponding action: the method
+	// parameters named after the route wildcards are used to build the request path, the
+	// "payload" parameter if any provides the request body and the other parameters are sent in
+	// the querystring. The action response body becomes the method result. Batches and
+	// notifications are supported, parameters must be given by name.
+	// The code generated by "goagen jsonrpc" registers the methods of all the actions defined in
+	// the design.
+	JSONRPCServer struct {
+		// Service is the service the requests are dispatched to.
+		Service *Service
+		methods map[string]*JSONRPCMethod
+	}
+
+	// JSONRPCMethod describes the action route a JSON-RPC method maps to.
+	JSONRPCMethod struct {
+		// Version is the name of the API version that defines the action, empty if none.
+		Version string
+		// Verb is the HTTP method of the route.
+		Verb string
+		// Path is the full path of the route including wildcards.
+		Path string
+		// NewParams returns the value the method parameters are decoded into to check their
+		// types before the request is dispatched. Type checking is skipped if nil.
+		NewParams func() interface{}
+	}
+
+	// JSONRPCError is the error object of JSON-RPC responses. It implements error.
+	JSONRPCError struct {
+		// Code is the error code.
+		Code int `json:"code"`
+		// Message is the error message.
+		Message string `json:"message"`
+		// Data contains additional information about the error if any.
+		Data interface{} `json:"data,omitempty"`
+	}
+
+	// jsonrpcResponse is a JSON-RPC response object.
+	jsonrpcResponse struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Result  json.RawMessage `json:"result,omitempty"`
+		Error   *JSONRPCError   `json:"error,omitempty"`
+		ID      json.RawMessage `json:"id"`
+	}
+)
+
+// jsonrpcNull is the JSON null value.
+var jsonrpcNull = json.RawMessage("null")
+
+// NewJSONRPCServer returns a JSON-RPC server that dispatches requests to the given service.
+func NewJSONRPCServer(service *Service) *JSONRPCServer {
+	return &JSONRPCServer{Service: service, methods: make(map[string]*JSONRPCMethod)}
+}
+
+// Handle maps the JSON-RPC method with the given name to the given action route.
+func (s *JSONRPCServer) Handle(name string, method *JSONRPCMethod) {
+	s.methods[name] = method
+}
+
+// Mount registers the server with the service mux for POST requests made to the given path,
+// "/rpc" if empty. This makes the JSON-RPC endpoint available alongside the REST routes.
+func (s *JSONRPCServer) Mount(path string) {
+	if path == "" {
+		path = "/rpc"
+	}
+	s.Service.Mux.Handle("POST", path, func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+		s.ServeHTTP(rw, req)
+	})
+	s.Service.LogInfo("mount jsonrpc", KV{"path", fmt.Sprintf("POST %s", path)})
+}
+
+// ServeHTTP serves a JSON-RPC request or batch of requests.
+func (s *JSONRPCServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		rw.Header().Set("Allow", "POST")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		writeJSONRPC(rw, jsonrpcErrorResponse(jsonrpcNull, JSONRPCInternalError, err.Error()))
+		return
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			writeJSONRPC(rw, jsonrpcErrorResponse(jsonrpcNull, JSONRPCParseError, err.Error()))
+			return
+		}
+		if len(batch) == 0 {
+			writeJSONRPC(rw, jsonrpcErrorResponse(jsonrpcNull, JSONRPCInvalidRequest, "empty batch"))
+			return
+		}
+		var resps []*jsonrpcResponse
+		for _, raw := range batch {
+			if resp := s.serve(req, raw); resp != nil {
+				resps = append(resps, resp)
+			}
+		}
+		if len(resps) == 0 {
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSONRPC(rw, resps)
+		return
+	}
+	if !validJSON(body) {
+		writeJSONRPC(rw, jsonrpcErrorResponse(jsonrpcNull, JSONRPCParseError, "invalid JSON"))
+		return
+	}
+	resp := s.serve(req, body)
+	if resp == nil {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSONRPC(rw, resp)
+}
+
+// serve dispatches a single request to the action the method maps to and returns the response,
+// nil for notifications.
+func (s *JSONRPCServer) serve(req *http.Request, raw json.RawMessage) *jsonrpcResponse {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return jsonrpcErrorResponse(jsonrpcNull, JSONRPCInvalidRequest, "request must be an object")
+	}
+	id, hasID := fields["id"]
+	if hasID && !validJSONRPCID(id) {
+		return jsonrpcErrorResponse(jsonrpcNull, JSONRPCInvalidRequest, "id must be a string, a number or null")
+	}
+	if !hasID {
+		id = jsonrpcNull
+	}
+	var version, name string
+	if json.Unmarshal(fields["jsonrpc"], &version) != nil || version != "2.0" {
+		return jsonrpcErrorResponse(id, JSONRPCInvalidRequest, `jsonrpc must be "2.0"`)
+	}
+	if json.Unmarshal(fields["method"], &name) != nil || name == "" {
+		return jsonrpcErrorResponse(id, JSONRPCInvalidRequest, "method must be a non empty string")
+	}
+	result, rerr := s.call(req, name, fields["params"])
+	if !hasID {
+		// Notifications never get a response, not even errors.
+		return nil
+	}
+	if rerr != nil {
+		return &jsonrpcResponse{JSONRPC: "2.0", Error: rerr, ID: id}
+	}
+	return &jsonrpcResponse{JSONRPC: "2.0", Result: result, ID: id}
+}
+
+// call invokes the action the method with the given name maps to and returns its result.
+func (s *JSONRPCServer) call(req *http.Request, name string, rawParams json.RawMessage) (json.RawMessage, *JSONRPCError) {
+	method, ok := s.methods[name]
+	if !ok {
+		return nil, &JSONRPCError{Code: JSONRPCMethodNotFound, Message: fmt.Sprintf("unknown method %s", name)}
+	}
+	params := make(map[string]interface{})
+	if p := bytes.TrimSpace(rawParams); len(p) > 0 && !bytes.Equal(p, jsonrpcNull) {
+		if p[0] != '{' {
+			return nil, &JSONRPCError{Code: JSONRPCInvalidParams, Message: "params must be given by name"}
+		}
+		if method.NewParams != nil {
+			if err := json.Unmarshal(p, method.NewParams()); err != nil {
+				return nil, &JSONRPCError{Code: JSONRPCInvalidParams, Message: err.Error()}
+			}
+		}
+		dec := json.NewDecoder(bytes.NewReader(p))
+		dec.UseNumber()
+		if err := dec.Decode(&params); err != nil {
+			return nil, &JSONRPCError{Code: JSONRPCInvalidParams, Message: err.Error()}
+		}
+	}
+	inner, err := bridgeRequest(req, method.Verb, method.Path, params, jsonrpcPayloadParam)
+	if err != nil {
+		return nil, &JSONRPCError{Code: JSONRPCInvalidParams, Message: err.Error()}
+	}
+	rec := bridgeServe(s.Service, method.Version, inner)
+	if rec.failed() {
+		code := JSONRPCServerError
+		if rec.status == http.StatusBadRequest {
+			code = JSONRPCInvalidParams
+		}
+		return nil, &JSONRPCError{Code: code, Message: rec.errorMessage(), Data: map[string]int{"status": rec.status}}
+	}
+	body := bytes.TrimSpace(rec.body.Bytes())
+	if len(body) == 0 {
+		return jsonrpcNull, nil
+	}
+	if !strings.Contains(rec.header.Get("Content-Type"), "json") || !validJSON(body) {
+		b, _ := json.Marshal(string(body))
+		return b, nil
+	}
+	return json.RawMessage(body), nil
+}
+
+// Error returns the error message.
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+}
+
+// jsonrpcErrorResponse builds an error response.
+func jsonrpcErrorResponse(id json.RawMessage, code int, msg string) *jsonrpcResponse {
+	return &jsonrpcResponse{JSONRPC: "2.0", Error: &JSONRPCError{Code: code, Message: msg}, ID: id}
+}
+
+// validJSONRPCID returns true if id is a string, a number or null.
+func validJSONRPCID(id json.RawMessage) bool {
+	var v interface{}
+	if err := json.Unmarshal(id, &v); err != nil {
+		return false
+	}
+	switch v.(type) {
+	case nil, string, float64:
+		return true
+	}
+	return false
+}
+
+// validJSON returns true if b is a valid JSON document.
+func validJSON(b []byte) bool {
+	var v interface{}
+	return json.Unmarshal(b, &v) == nil
+}
+
+// writeJSONRPC writes the JSON representation of v.
+func writeJSONRPC(rw http.ResponseWriter, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(v)
+}
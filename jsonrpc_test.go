@@ -0,0 +1,154 @@
+package goa_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSONRPCServer", func() {
+	var service *goa.Service
+	var server *goa.JSONRPCServer
+	var body string
+	var rw *httptest.ResponseRecorder
+
+	var gotPath string
+	var gotQuery url.Values
+	var gotBody []byte
+	var calls int
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		server = goa.NewJSONRPCServer(service)
+		body = `{"jsonrpc":"2.0","method":"bottle.show","params":{"account_id":1,"id":42,"view":"tiny"},"id":1}`
+		rw = httptest.NewRecorder()
+		gotPath, gotQuery, gotBody, calls = "", nil, nil, 0
+		server.Handle("bottle.show", &goa.JSONRPCMethod{
+			Verb:      "GET",
+			Path:      "/accounts/:account_id/bottles/:id",
+			NewParams: func() interface{} { return &struct{ ID int }{} },
+		})
+		server.Handle("bottle.create", &goa.JSONRPCMethod{Verb: "POST", Path: "/accounts/:account_id/bottles"})
+		handle := func(status int, body string) goa.MuxHandler {
+			return func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+				calls++
+				gotPath = req.URL.Path
+				gotQuery = req.URL.Query()
+				if req.Body != nil {
+					gotBody, _ = ioutil.ReadAll(req.Body)
+				}
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(status)
+				rw.Write([]byte(body))
+			}
+		}
+		service.Mux.Handle("GET", "/accounts/:account_id/bottles/:id", handle(200, `{"id":42,"name":"Number 8"}`))
+		service.Mux.Handle("POST", "/accounts/:account_id/bottles", handle(404, `{"id":1,"title":"not found","msg":"no account with id 1"}`))
+		server.Mount("")
+	})
+
+	JustBeforeEach(func() {
+		req, err := http.NewRequest("POST", "http://localhost/rpc", bytes.NewBufferString(body))
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		service.Mux.ServeHTTP(rw, req)
+	})
+
+	It("bridges the request to the action", func() {
+		Ω(rw.Code).Should(Equal(200))
+		Ω(gotPath).Should(Equal("/accounts/1/bottles/42"))
+		Ω(gotQuery).Should(Equal(url.Values{"view": {"tiny"}}))
+		Ω(rw.Body.String()).Should(MatchJSON(`{"jsonrpc":"2.0","result":{"id":42,"name":"Number 8"},"id":1}`))
+	})
+
+	Context("with a payload", func() {
+		BeforeEach(func() {
+			body = `{"jsonrpc":"2.0","method":"bottle.create","params":{"account_id":1,"payload":{"name":"Number 8"}},"id":"a"}`
+		})
+
+		It("sends the payload in the body and returns the action error", func() {
+			Ω(gotPath).Should(Equal("/accounts/1/bottles"))
+			Ω(string(gotBody)).Should(MatchJSON(`{"name":"Number 8"}`))
+			Ω(rw.Body.String()).Should(MatchJSON(`{"jsonrpc":"2.0","error":{"code":-32000,"message":"no account with id 1","data":{"status":404}},"id":"a"}`))
+		})
+	})
+
+	Context("with a batch", func() {
+		BeforeEach(func() {
+			body = `[
+				{"jsonrpc":"2.0","method":"bottle.show","params":{"account_id":1,"id":42},"id":1},
+				{"jsonrpc":"2.0","method":"bottle.show","params":{"account_id":1,"id":42}},
+				{"jsonrpc":"2.0","method":"bottle.delete","id":2},
+				1
+			]`
+		})
+
+		It("returns the responses of the requests that are not notifications", func() {
+			Ω(calls).Should(Equal(2))
+			Ω(rw.Body.String()).Should(MatchJSON(`[
+				{"jsonrpc":"2.0","result":{"id":42,"name":"Number 8"},"id":1},
+				{"jsonrpc":"2.0","error":{"code":-32601,"message":"unknown method bottle.delete"},"id":2},
+				{"jsonrpc":"2.0","error":{"code":-32600,"message":"request must be an object"},"id":null}
+			]`))
+		})
+	})
+
+	Context("with a notification", func() {
+		BeforeEach(func() {
+			body = `{"jsonrpc":"2.0","method":"bottle.show","params":{"account_id":1,"id":42}}`
+		})
+
+		It("calls the action but does not respond", func() {
+			Ω(calls).Should(Equal(1))
+			Ω(rw.Code).Should(Equal(204))
+			Ω(rw.Body.Len()).Should(Equal(0))
+		})
+	})
+
+	Context("with params of the wrong type", func() {
+		BeforeEach(func() {
+			body = `{"jsonrpc":"2.0","method":"bottle.show","params":{"account_id":1,"id":"foo"},"id":1}`
+		})
+
+		It("returns an invalid params error", func() {
+			Ω(calls).Should(Equal(0))
+			Ω(rw.Body.String()).Should(ContainSubstring(`"code":-32602`))
+		})
+	})
+
+	Context("with positional params", func() {
+		BeforeEach(func() {
+			body = `{"jsonrpc":"2.0","method":"bottle.show","params":[1,42],"id":1}`
+		})
+
+		It("returns an invalid params error", func() {
+			Ω(rw.Body.String()).Should(MatchJSON(`{"jsonrpc":"2.0","error":{"code":-32602,"message":"params must be given by name"},"id":1}`))
+		})
+	})
+
+	Context("with a missing path parameter", func() {
+		BeforeEach(func() {
+			body = `{"jsonrpc":"2.0","method":"bottle.show","params":{"id":42},"id":1}`
+		})
+
+		It("returns an invalid params error", func() {
+			Ω(rw.Body.String()).Should(MatchJSON(`{"jsonrpc":"2.0","error":{"code":-32602,"message":"missing field account_id"},"id":1}`))
+		})
+	})
+
+	Context("with invalid JSON", func() {
+		BeforeEach(func() {
+			body = `{"jsonrpc":`
+		})
+
+		It("returns a parse error", func() {
+			Ω(rw.Body.String()).Should(MatchJSON(`{"jsonrpc":"2.0","error":{"code":-32700,"message":"invalid JSON"},"id":null}`))
+		})
+	})
+})
@@ -0,0 +1,190 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// LambdaHandler serves the Amazon API Gateway events received by AWS Lambda functions using
+	// the Lambda proxy integration. Each event is translated into a HTTP request dispatched to
+	// the controllers mounted on the service and the response they write is translated into
+	// the event response. The handler implements the Handler interface of the AWS Lambda Go
+	// runtime (github.com/aws/aws-lambda-go/lambda) so that a service can be run as a Lambda
+	// function with:
+	//
+	//	lambda.StartHandler(goa.NewLambdaHandler(service))
+	//
+	// The code generated by "goagen lambda" does that when the service is built with the
+	// "lambda" build tag.
+	LambdaHandler struct {
+		// Service is the service the requests are dispatched to.
+		Service *Service
+	}
+
+	// APIGatewayProxyRequest is the event sent by Amazon API Gateway to Lambda functions using
+	// the Lambda proxy integration.
+	APIGatewayProxyRequest struct {
+		// Resource is the API Gateway resource path, e.g. "/bottles/{id}".
+		Resource string `json:"resource"`
+		// Path is the request path.
+		Path string `json:"path"`
+		// HTTPMethod is the request method.
+		HTTPMethod string `json:"httpMethod"`
+		// Headers contains the last value of each request header.
+		Headers map[string]string `json:"headers"`
+		// MultiValueHeaders contains all the values of the request headers.
+		MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+		// QueryStringParameters contains the last value of each querystring parameter.
+		QueryStringParameters map[string]string `json:"queryStringParameters"`
+		// MultiValueQueryStringParameters contains all the values of the querystring
+		// parameters.
+		MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+		// PathParameters contains the values of the resource path parameters.
+		PathParameters map[string]string `json:"pathParameters"`
+		// StageVariables contains the variables of the API Gateway stage.
+		StageVariables map[string]string `json:"stageVariables"`
+		// RequestContext describes the request.
+		RequestContext APIGatewayProxyRequestContext `json:"requestContext"`
+		// Body is the request body, base64 encoded if IsBase64Encoded is true.
+		Body string `json:"body"`
+		// IsBase64Encoded is true if the body is base64 encoded.
+		IsBase64Encoded bool `json:"isBase64Encoded"`
+	}
+
+	// APIGatewayProxyRequestContext contains the information added to the event by API Gateway.
+	APIGatewayProxyRequestContext struct {
+		// RequestID is the API Gateway request ID.
+		RequestID string `json:"requestId"`
+		// Stage is the name of the API Gateway stage.
+		Stage string `json:"stage"`
+		// Identity describes the caller.
+		Identity APIGatewayRequestIdentity `json:"identity"`
+	}
+
+	// APIGatewayRequestIdentity describes the caller of a API Gateway request.
+	APIGatewayRequestIdentity struct {
+		// SourceIP is the IP address of the caller.
+		SourceIP string `json:"sourceIp"`
+		// UserAgent is the user agent of the caller.
+		UserAgent string `json:"userAgent"`
+	}
+
+	// APIGatewayProxyResponse is the response returned by Lambda functions using the Lambda
+	// proxy integration.
+	APIGatewayProxyResponse struct {
+		// StatusCode is the response status code.
+		StatusCode int `json:"statusCode"`
+		// Headers contains the first value of each response header.
+		Headers map[string]string `json:"headers"`
+		// MultiValueHeaders contains all the values of the response headers.
+		MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+		// Body is the response body, base64 encoded if IsBase64Encoded is true.
+		Body string `json:"body"`
+		// IsBase64Encoded is true if the body is base64 encoded.
+		IsBase64Encoded bool `json:"isBase64Encoded"`
+	}
+)
+
+// NewLambdaHandler returns a handler that dispatches the API Gateway events to the given service.
+func NewLambdaHandler(service *Service) *LambdaHandler {
+	return &LambdaHandler{Service: service}
+}
+
+// Invoke decodes the API Gateway event, serves it and returns the encoded response.
+func (h *LambdaHandler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	var req APIGatewayProxyRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, fmt.Errorf("failed to decode API Gateway event: %s", err)
+	}
+	resp, err := h.Handle(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(resp)
+}
+
+// Handle serves the given API Gateway event.
+func (h *LambdaHandler) Handle(ctx context.Context, req *APIGatewayProxyRequest) (*APIGatewayProxyResponse, error) {
+	inner, err := lambdaRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	rec := &bridgeRecorder{header: make(http.Header)}
+	h.Service.Mux.ServeHTTP(rec, inner)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	resp := &APIGatewayProxyResponse{
+		StatusCode:        rec.status,
+		Headers:           make(map[string]string, len(rec.header)),
+		MultiValueHeaders: make(map[string][]string, len(rec.header)),
+	}
+	for k, v := range rec.header {
+		if len(v) > 0 {
+			resp.Headers[k] = v[0]
+		}
+		resp.MultiValueHeaders[k] = v
+	}
+	if lambdaIsText(rec.header.Get("Content-Type")) {
+		resp.Body = rec.body.String()
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(rec.body.Bytes())
+		resp.IsBase64Encoded = true
+	}
+	return resp, nil
+}
+
+// lambdaRequest builds the HTTP request corresponding to the API Gateway event.
+func lambdaRequest(req *APIGatewayProxyRequest) (*http.Request, error) {
+	query := make(url.Values)
+	for k, v := range req.QueryStringParameters {
+		query.Set(k, v)
+	}
+	for k, v := range req.MultiValueQueryStringParameters {
+		query[k] = v
+	}
+	body := []byte(req.Body)
+	if req.IsBase64Encoded {
+		b, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode request body: %s", err)
+		}
+		body = b
+	}
+	u := &url.URL{Path: req.Path, RawQuery: query.Encode()}
+	inner, err := http.NewRequest(req.HTTPMethod, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.Headers {
+		inner.Header.Set(k, v)
+	}
+	for k, v := range req.MultiValueHeaders {
+		inner.Header[http.CanonicalHeaderKey(k)] = v
+	}
+	inner.Host = inner.Header.Get("Host")
+	inner.RemoteAddr = req.RequestContext.Identity.SourceIP
+	return inner, nil
+}
+
+// lambdaIsText returns true if responses with the given content type can be returned to API
+// Gateway without being base64 encoded.
+func lambdaIsText(contentType string) bool {
+	if contentType == "" || strings.HasPrefix(contentType, "text/") {
+		return true
+	}
+	for _, s := range []string{"json", "xml", "javascript", "x-www-form-urlencoded"} {
+		if strings.Contains(contentType, s) {
+			return true
+		}
+	}
+	return false
+}
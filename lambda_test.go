@@ -0,0 +1,120 @@
+package goa_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("LambdaHandler", func() {
+	var service *goa.Service
+	var handler *goa.LambdaHandler
+	var event string
+	var resp *goa.APIGatewayProxyResponse
+	var invokeErr error
+
+	var gotPath string
+	var gotQuery url.Values
+	var gotHeader http.Header
+	var gotBody []byte
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		handler = goa.NewLambdaHandler(service)
+		gotPath, gotQuery, gotHeader, gotBody = "", nil, nil, nil
+		service.Mux.Handle("POST", "/bottles/:id", func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			gotPath = req.URL.Path
+			gotQuery = req.URL.Query()
+			gotHeader = req.Header
+			gotBody, _ = ioutil.ReadAll(req.Body)
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(201)
+			rw.Write([]byte(`{"id":42}`))
+		})
+		service.Mux.Handle("GET", "/bottles/:id/label", func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			rw.Header().Set("Content-Type", "image/png")
+			rw.Write([]byte{0x89, 'P', 'N', 'G'})
+		})
+		event = `{
+			"httpMethod": "POST",
+			"path": "/bottles/42",
+			"headers": {"content-type": "application/json"},
+			"multiValueQueryStringParameters": {"tag": ["a", "b"]},
+			"body": "eyJuYW1lIjoiTnVtYmVyIDgifQ==",
+			"isBase64Encoded": true
+		}`
+	})
+
+	JustBeforeEach(func() {
+		var out []byte
+		resp = nil
+		out, invokeErr = handler.Invoke(context.Background(), []byte(event))
+		if invokeErr == nil {
+			resp = new(goa.APIGatewayProxyResponse)
+			Ω(json.Unmarshal(out, resp)).ShouldNot(HaveOccurred())
+		}
+	})
+
+	It("dispatches the event to the service", func() {
+		Ω(invokeErr).ShouldNot(HaveOccurred())
+		Ω(gotPath).Should(Equal("/bottles/42"))
+		Ω(gotQuery).Should(Equal(url.Values{"tag": {"a", "b"}}))
+		Ω(gotHeader.Get("Content-Type")).Should(Equal("application/json"))
+		Ω(string(gotBody)).Should(Equal(`{"name":"Number 8"}`))
+		Ω(resp.StatusCode).Should(Equal(201))
+		Ω(resp.Headers).Should(HaveKeyWithValue("Content-Type", "application/json"))
+		Ω(resp.Body).Should(Equal(`{"id":42}`))
+		Ω(resp.IsBase64Encoded).Should(BeFalse())
+	})
+
+	Context("with a binary response", func() {
+		BeforeEach(func() {
+			event = `{"httpMethod": "GET", "path": "/bottles/42/label"}`
+		})
+
+		It("base64 encodes the body", func() {
+			Ω(resp.StatusCode).Should(Equal(200))
+			Ω(resp.IsBase64Encoded).Should(BeTrue())
+			Ω(resp.Body).Should(Equal(base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G'})))
+		})
+	})
+
+	Context("with an invalid event", func() {
+		BeforeEach(func() {
+			event = `{"httpMethod":`
+		})
+
+		It("returns an error", func() {
+			Ω(invokeErr).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("ServeFunc", func() {
+	var served *goa.Service
+
+	BeforeEach(func() {
+		served = nil
+		goa.ServeFunc = func(service *goa.Service) error {
+			served = service
+			return nil
+		}
+	})
+
+	AfterEach(func() {
+		goa.ServeFunc = nil
+	})
+
+	It("replaces the HTTP server", func() {
+		service := goa.New("test")
+		Ω(service.ListenAndServe(":0")).ShouldNot(HaveOccurred())
+		Ω(served).Should(Equal(service))
+	})
+})
@@ -3,13 +3,15 @@ package goa
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 
 	"golang.org/x/net/context"
 )
 
 var (
-	// Log is the logger used by goa to log informational and error messages.
+	// Log is the logger used by goa to log informational and error messages when the service
+	// handling the request does not define its own logger, see Service.Logger.
 	// The default logger logs to Stderr.
 	Log Logger
 )
@@ -21,7 +23,8 @@ func init() {
 
 type (
 	// Logger is the logger interface used by goa to log informational and error messages.
-	// Adapters to different logging backends are provided in the logging package.
+	// Adapters to the log15, logrus and zap logging backends are provided in the subpackages
+	// of the logging package, DefaultLogger logs with the standard library log package.
 	Logger interface {
 		// Info logs a message with optional contextual data.
 		// The contextual data consists of key/value pairs (so the size of data is always an even number).
@@ -39,26 +42,112 @@ type (
 		Value interface{}
 	}
 
-	// DefaultLogger is the default goa logger implementation
+	// DefaultLogger is the default goa logger implementation, it logs with the standard library
+	// log package.
 	DefaultLogger struct {
 		*log.Logger
 	}
 )
 
-// Info logs the given informational message and accompanying data.
+// NewStdLogger returns a logger that logs to the given standard library logger.
+func NewStdLogger(l *log.Logger) Logger {
+	return &DefaultLogger{Logger: l}
+}
+
+// ContextLogger returns the logger of the service handling the request with the given context.
+// It returns Log if there is no such service or if the service does not define a logger.
+func ContextLogger(ctx context.Context) Logger {
+	if ctx != nil {
+		if s := RequestService(ctx); s != nil && s.Logger != nil {
+			return s.Logger
+		}
+	}
+	return Log
+}
+
+// Info logs the given informational message and accompanying data. The values redacted with
+// SetLogRedaction are masked.
 func Info(ctx context.Context, msg string, data ...KV) {
-	if Log != nil {
-		data = append(LogContext(ctx), data...)
-		Log.Info(ctx, msg, data...)
+	if l := ContextLogger(ctx); l != nil {
+		data = redactLog(ctx, append(LogContext(ctx), data...))
+		l.Info(ctx, msg, data...)
 	}
 }
 
-// Error logs the given error message and accompanying data.
+// Error logs the given error message and accompanying data. The values redacted with
+// SetLogRedaction are masked.
 func Error(ctx context.Context, msg string, data ...KV) {
-	if Log != nil {
-		data = append(LogContext(ctx), data...)
-		Log.Error(ctx, msg, data...)
+	if l := ContextLogger(ctx); l != nil {
+		data = redactLog(ctx, append(LogContext(ctx), data...))
+		l.Error(ctx, msg, data...)
+	}
+}
+
+// SetLogRedaction sets the names of the parameters and top level payload attributes of the given
+// controller action whose values are masked in the messages logged with Info and Error while
+// handling the action requests, including the messages logged by the request logging middleware.
+// The values of the data whose key is one of the names are masked as well as the values of the
+// entries of maps whose key is one of the names. The code generated by goagen calls
+// SetLogRedaction for the actions whose parameters or payload attributes are tagged with the PII
+// DSL.
+func (service *Service) SetLogRedaction(ctrl, action string, names ...string) {
+	service.redactMu.Lock()
+	defer service.redactMu.Unlock()
+	if service.redactions == nil {
+		service.redactions = make(map[string]map[string]bool)
+	}
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	service.redactions[ctrl+"#"+action] = set
+}
+
+// redactLog masks the values of data redacted for the action handling the request with the given
+// context.
+func redactLog(ctx context.Context, data []KV) []KV {
+	if ctx == nil {
+		return data
+	}
+	service := RequestService(ctx)
+	if service == nil {
+		return data
+	}
+	service.redactMu.RLock()
+	mask := service.redactions[ContextController(ctx)+"#"+ContextAction(ctx)]
+	service.redactMu.RUnlock()
+	if len(mask) == 0 {
+		return data
+	}
+	res := make([]KV, len(data))
+	for i, kv := range data {
+		res[i] = kv
+		if mask[kv.Key] {
+			res[i].Value = Redacted
+			continue
+		}
+		switch v := kv.Value.(type) {
+		case map[string]interface{}:
+			res[i].Value = redactPayload(v, mask)
+		case map[string][]string:
+			res[i].Value = redactValues(v, mask)
+		case url.Values:
+			res[i].Value = url.Values(redactValues(v, mask))
+		}
+	}
+	return res
+}
+
+// redactValues returns a copy of values whose entries listed in mask are redacted.
+func redactValues(values map[string][]string, mask map[string]bool) map[string][]string {
+	res := make(map[string][]string, len(values))
+	for k, v := range values {
+		if mask[k] {
+			v = []string{Redacted}
+		}
+		res[k] = v
 	}
+	return res
 }
 
 // Info logs informational messages such as service startup
@@ -0,0 +1,12 @@
+/*
+Package logging contains adapters that make it possible for goa services to log with third party
+logging packages. Each subpackage provides a New function that wraps a logger of the corresponding
+package into a goa.Logger:
+
+	service := goa.New("cellar")
+	service.Logger = goazap.New(zapLogger)
+
+Setting goa.Log instead makes the adapter the default logger of all the services. The standard
+library log package is supported by goa.NewStdLogger.
+*/
+package logging
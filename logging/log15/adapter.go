@@ -0,0 +1,46 @@
+/*
+Package log15 contains an adapter that makes it possible to configure goa so it uses log15
+as logger backend.
+Usage:
+
+	logger := log15.New()
+	// Initialize logger handler using log15 but using the goa adapter
+	service.Logger = goalog15.New(logger)
+*/
+package log15
+
+import (
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+// adapter is the log15 goa adapter logger.
+type adapter struct {
+	log15.Logger
+}
+
+// New wraps a log15 logger into a goa logger adapter.
+func New(logger log15.Logger) goa.Logger {
+	return &adapter{Logger: logger}
+}
+
+// Info logs informational messages using log15.
+func (a *adapter) Info(ctx context.Context, msg string, data ...goa.KV) {
+	a.Logger.Info(msg, ctxData(data)...)
+}
+
+// Error logs error messages using log15.
+func (a *adapter) Error(ctx context.Context, msg string, data ...goa.KV) {
+	a.Logger.Error(msg, ctxData(data)...)
+}
+
+// ctxData converts the goa key/value pairs into the alternated keys and values used by log15.
+func ctxData(data []goa.KV) []interface{} {
+	res := make([]interface{}, 2*len(data))
+	for i, kv := range data {
+		res[2*i] = kv.Key
+		res[2*i+1] = kv.Value
+	}
+	return res
+}
@@ -0,0 +1,45 @@
+/*
+Package logrus contains an adapter that makes it possible to configure goa so it uses logrus
+as logger backend.
+Usage:
+
+	logger := logrus.New()
+	// Initialize logger handler using logrus but using the goa adapter
+	service.Logger = goalogrus.New(logger)
+*/
+package logrus
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// adapter is the logrus goa adapter logger.
+type adapter struct {
+	*logrus.Logger
+}
+
+// New wraps a logrus logger into a goa logger adapter.
+func New(logger *logrus.Logger) goa.Logger {
+	return &adapter{Logger: logger}
+}
+
+// Info logs informational messages using logrus.
+func (a *adapter) Info(ctx context.Context, msg string, data ...goa.KV) {
+	a.Logger.WithFields(fields(data)).Info(msg)
+}
+
+// Error logs error messages using logrus.
+func (a *adapter) Error(ctx context.Context, msg string, data ...goa.KV) {
+	a.Logger.WithFields(fields(data)).Error(msg)
+}
+
+// fields converts the goa key/value pairs into logrus fields.
+func fields(data []goa.KV) logrus.Fields {
+	f := make(logrus.Fields, len(data))
+	for _, kv := range data {
+		f[kv.Key] = kv.Value
+	}
+	return f
+}
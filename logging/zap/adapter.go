@@ -0,0 +1,45 @@
+/*
+Package zap contains an adapter that makes it possible to configure goa so it uses zap
+as logger backend.
+Usage:
+
+	logger, _ := zap.NewProduction()
+	// Initialize logger handler using zap but using the goa adapter
+	service.Logger = goazap.New(logger)
+*/
+package zap
+
+import (
+	"github.com/goadesign/goa"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+)
+
+// adapter is the zap goa adapter logger.
+type adapter struct {
+	*zap.Logger
+}
+
+// New wraps a zap logger into a goa logger adapter.
+func New(logger *zap.Logger) goa.Logger {
+	return &adapter{Logger: logger}
+}
+
+// Info logs informational messages using zap.
+func (a *adapter) Info(ctx context.Context, msg string, data ...goa.KV) {
+	a.Logger.Info(msg, fields(data)...)
+}
+
+// Error logs error messages using zap.
+func (a *adapter) Error(ctx context.Context, msg string, data ...goa.KV) {
+	a.Logger.Error(msg, fields(data)...)
+}
+
+// fields converts the goa key/value pairs into zap fields.
+func fields(data []goa.KV) []zap.Field {
+	f := make([]zap.Field, len(data))
+	for i, kv := range data {
+		f[i] = zap.Any(kv.Key, kv.Value)
+	}
+	return f
+}
@@ -3,6 +3,8 @@ package goa_test
 import (
 	"bytes"
 	"log"
+	"net/http"
+	"net/url"
 
 	"github.com/goadesign/goa"
 	. "github.com/onsi/ginkgo"
@@ -75,6 +77,35 @@ var _ = Describe("Info and Error", func() {
 	})
 })
 
+var _ = Describe("SetLogRedaction", func() {
+	var testLog *TestLog
+
+	BeforeEach(func() {
+		testLog = new(TestLog)
+		goa.Log = testLog
+	})
+
+	It("masks the redacted values logged while handling the action requests", func() {
+		service := goa.New("test")
+		service.SetLogRedaction("users", "create", "email")
+		ctrl := service.NewController("users")
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			goa.Info(ctx, "params", goa.KV{"email", "a@b.c"}, goa.KV{"name", "a"})
+			goa.Info(ctx, "payload", goa.KV{"body", map[string]interface{}{"email": "a@b.c", "age": 42}})
+			return nil
+		}
+		req, _ := http.NewRequest("POST", "/users", nil)
+		rw := &TestResponseWriter{ParentHeader: http.Header{}}
+		ctrl.MuxHandler("create", h, nil)(rw, req, url.Values{})
+		Ω(testLog.infoEntries).Should(HaveLen(2))
+		data := testLog.infoEntries[0].data
+		Ω(data[len(data)-2]).Should(Equal(goa.KV{"email", "[REDACTED]"}))
+		Ω(data[len(data)-1]).Should(Equal(goa.KV{"name", "a"}))
+		data = testLog.infoEntries[1].data
+		Ω(data[len(data)-1].Value).Should(Equal(map[string]interface{}{"email": "[REDACTED]", "age": float64(42)}))
+	})
+})
+
 var _ = Describe("DefaultLogger", func() {
 	var logger *goa.DefaultLogger
 
@@ -99,3 +130,73 @@ var _ = Describe("DefaultLogger", func() {
 		})
 	})
 })
+
+var _ = Describe("Service logger", func() {
+	var service *goa.Service
+	var globalLog, serviceLog *TestLog
+
+	BeforeEach(func() {
+		globalLog = new(TestLog)
+		serviceLog = new(TestLog)
+		goa.Log = globalLog
+		service = goa.New("test")
+		service.Logger = serviceLog
+	})
+
+	It("receives the messages logged with the request context", func() {
+		ctx := goa.NewContext(nil, service, nil, nil, nil)
+		goa.Info(ctx, "info")
+		goa.Error(ctx, "error")
+		Ω(serviceLog.infoEntries).Should(HaveLen(1))
+		Ω(serviceLog.errorEntries).Should(HaveLen(1))
+		Ω(globalLog.infoEntries).Should(BeEmpty())
+	})
+
+	It("receives the messages logged by the service", func() {
+		service.LogInfo("mount", goa.KV{"ctrl", "Bottles"})
+		Ω(serviceLog.infoEntries).Should(HaveLen(1))
+		Ω(serviceLog.infoEntries[0].data).Should(Equal([]goa.KV{{"ctrl", "Bottles"}}))
+	})
+
+	It("defaults to goa.Log", func() {
+		service.Logger = nil
+		service.LogError("failed")
+		Ω(globalLog.errorEntries).Should(HaveLen(1))
+	})
+})
+
+var _ = Describe("LogWith", func() {
+	var testLog *TestLog
+	var ctx context.Context
+
+	BeforeEach(func() {
+		testLog = new(TestLog)
+		goa.Log = testLog
+		ctx = goa.NewLogContext(goa.WithLogFields(context.Background()), goa.KV{"ctrl", "Bottles"})
+	})
+
+	It("adds the fields to the entries logged with the parent contexts", func() {
+		inner := goa.NewLogContext(ctx, goa.KV{"inner", true})
+		goa.LogWith(inner, "user", "joe")
+		goa.Info(ctx, "done")
+		Ω(testLog.infoEntries).Should(HaveLen(1))
+		Ω(testLog.infoEntries[0].data).Should(Equal([]goa.KV{{"ctrl", "Bottles"}, {"user", "joe"}}))
+	})
+
+	It("replaces the value of fields attached again", func() {
+		goa.LogWith(ctx, "user", "joe")
+		goa.LogWith(ctx, "user", "jane")
+		Ω(goa.LogContext(ctx)).Should(Equal([]goa.KV{{"ctrl", "Bottles"}, {"user", "jane"}}))
+	})
+
+	It("attaches the name of the principal", func() {
+		goa.WithPrincipal(ctx, map[string]interface{}{"sub": "alice"})
+		Ω(goa.LogContext(ctx)).Should(ContainElement(goa.KV{"principal", "alice"}))
+	})
+
+	It("does nothing without log fields", func() {
+		plain := context.Background()
+		goa.LogWith(plain, "user", "joe")
+		Ω(goa.LogContext(plain)).Should(BeEmpty())
+	})
+})
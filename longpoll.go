@@ -0,0 +1,121 @@
+package goa
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// EventSource is implemented by the sources of the events served by long-poll actions. The
+	// Poll method of the contexts generated for long-poll actions blocks until the source has
+	// events following the request cursor or the request wait expires.
+	EventSource interface {
+		// Events returns the events that follow the given cursor together with the cursor of
+		// the last returned event. An empty cursor denotes the start of the feed. Events must
+		// not block, it returns no event and the given cursor if there is no new event.
+		Events(ctx context.Context, cursor string) (events []interface{}, next string, err error)
+		// Notify returns a channel that is closed once events following the given cursor may
+		// be available.
+		Notify(cursor string) <-chan struct{}
+	}
+
+	// EventFeed is an in-memory EventSource that retains the last events published to it. Its
+	// cursors are the decimal sequence numbers of the events.
+	EventFeed struct {
+		// Size is the maximum number of events retained by the feed, zero means no limit.
+		Size int
+
+		mu      sync.Mutex
+		events  []interface{}
+		first   uint64 // sequence number of events[0]
+		changed chan struct{}
+	}
+)
+
+// LongPoll blocks until source has events following cursor or ctx is done and returns the events
+// and the cursor of the last one. It returns no event and the given cursor if ctx is done before
+// any event becomes available, ctx expiring is not an error.
+func LongPoll(ctx context.Context, source EventSource, cursor string) ([]interface{}, string, error) {
+	for {
+		// Get the notification channel before looking for events so that events published
+		// in between are not missed.
+		notify := source.Notify(cursor)
+		events, next, err := source.Events(ctx, cursor)
+		if err != nil || len(events) > 0 {
+			return events, next, err
+		}
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			IncrCounter([]string{"goa", "longpoll", "timeout"}, 1.0)
+			return nil, cursor, nil
+		}
+	}
+}
+
+// LongPollContext returns a context that is canceled once wait seconds elapsed or ctx is done.
+// The wait is capped to max if max is not zero.
+func LongPollContext(ctx context.Context, wait int, max time.Duration) (context.Context, context.CancelFunc) {
+	d := time.Duration(wait) * time.Second
+	if d < 0 {
+		d = 0
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// NewEventFeed returns an event feed that retains up to size events, zero means no limit.
+func NewEventFeed(size int) *EventFeed {
+	return &EventFeed{Size: size}
+}
+
+// Publish appends an event to the feed and wakes up the pending long-poll requests. It returns
+// the cursor of the event.
+func (f *EventFeed) Publish(event interface{}) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, event)
+	if f.Size > 0 && len(f.events) > f.Size {
+		drop := len(f.events) - f.Size
+		f.events = append([]interface{}(nil), f.events[drop:]...)
+		f.first += uint64(drop)
+	}
+	if f.changed != nil {
+		close(f.changed)
+		f.changed = nil
+	}
+	return strconv.FormatUint(f.first+uint64(len(f.events)), 10)
+}
+
+// Events implements EventSource. Cursors that cannot be parsed or that refer to events that are no
+// longer retained cause all the retained events to be returned.
+func (f *EventFeed) Events(_ context.Context, cursor string) ([]interface{}, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	last := f.first + uint64(len(f.events))
+	seq, err := strconv.ParseUint(cursor, 10, 64)
+	if err != nil || seq < f.first || seq > last {
+		seq = f.first
+	}
+	if seq == last {
+		return nil, cursor, nil
+	}
+	events := make([]interface{}, last-seq)
+	copy(events, f.events[seq-f.first:])
+	return events, strconv.FormatUint(last, 10), nil
+}
+
+// Notify implements EventSource.
+func (f *EventFeed) Notify(cursor string) <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.changed == nil {
+		f.changed = make(chan struct{})
+	}
+	return f.changed
+}
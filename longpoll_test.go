@@ -0,0 +1,54 @@
+package goa_test
+
+import (
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("LongPoll", func() {
+	var feed *goa.EventFeed
+
+	BeforeEach(func() {
+		feed = goa.NewEventFeed(3)
+	})
+
+	It("returns the cursor when the wait expires", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		events, next, err := goa.LongPoll(ctx, feed, "")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(events).Should(BeEmpty())
+		Ω(next).Should(Equal(""))
+	})
+
+	It("returns as soon as events are published", func() {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			feed.Publish("a")
+		}()
+		ctx, cancel := goa.LongPollContext(context.Background(), 5, 0)
+		defer cancel()
+		events, next, err := goa.LongPoll(ctx, feed, "")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(events).Should(Equal([]interface{}{"a"}))
+		Ω(next).Should(Equal("1"))
+	})
+
+	It("returns the events following the cursor", func() {
+		for _, e := range []string{"a", "b", "c", "d"} {
+			feed.Publish(e)
+		}
+		events, next, err := feed.Events(context.Background(), "2")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(events).Should(Equal([]interface{}{"c", "d"}))
+		Ω(next).Should(Equal("4"))
+
+		By("returning the retained events for stale cursors")
+		events, _, _ = feed.Events(context.Background(), "0")
+		Ω(events).Should(Equal([]interface{}{"b", "c", "d"}))
+	})
+})
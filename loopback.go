@@ -0,0 +1,70 @@
+package goa
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// LoopbackTransport is a http.RoundTripper that serves requests in-process by dispatching them
+// to the mux of a service instead of sending them over the network. The requests go through the
+// same pipeline as requests received by the service HTTP server: routing, middleware, payload
+// decoding, validation and response encoding. This makes it possible to embed a goa service in
+// another one and to write fast integration tests.
+// The response body is fully buffered so the transport is not suitable for streaming responses.
+type LoopbackTransport struct {
+	// Service is the service the requests are dispatched to.
+	Service *Service
+}
+
+// LoopbackRemoteAddr is the remote address of the requests served by LoopbackTransport.
+const LoopbackRemoteAddr = "127.0.0.1:0"
+
+// NewLoopbackClient returns a client that sends its requests to the given service in-process
+// using a LoopbackTransport.
+func NewLoopbackClient(service *Service) *Client {
+	c := NewClient()
+	c.Client = &http.Client{Transport: &LoopbackTransport{Service: service}}
+	c.Scheme = "http"
+	c.Host = "localhost"
+	return c
+}
+
+// RoundTrip serves the request with the service mux and returns the recorded response. Panics
+// raised while serving the request are returned as errors.
+func (t *LoopbackTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	inner := new(http.Request)
+	*inner = *req
+	inner.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		inner.Header[k] = append([]string(nil), v...)
+	}
+	inner.RequestURI = req.URL.RequestURI()
+	if inner.Host == "" {
+		inner.Host = req.URL.Host
+	}
+	inner.RemoteAddr = LoopbackRemoteAddr
+	if req.Body == nil {
+		inner.Body = ioutil.NopCloser(bytes.NewReader(nil))
+	} else {
+		defer req.Body.Close()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic serving %s %s: %v", req.Method, req.URL, r)
+		}
+	}()
+	rec := bridgeServe(t.Service, "", inner)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.status, http.StatusText(rec.status)),
+		StatusCode:    rec.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.header,
+		Body:          ioutil.NopCloser(&rec.body),
+		ContentLength: int64(rec.body.Len()),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package goa_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LoopbackTransport", func() {
+	var service *goa.Service
+	var client *goa.Client
+
+	var gotReq *http.Request
+	var gotBody []byte
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		gotReq, gotBody = nil, nil
+		service.Mux.Handle("POST", "/bottles", func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			gotReq = req
+			gotBody, _ = ioutil.ReadAll(req.Body)
+			rw.Header().Set("Location", "/bottles/1")
+			rw.WriteHeader(201)
+			rw.Write([]byte(`{"id":1}`))
+		})
+		service.Mux.Handle("GET", "/panic", func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			panic("boom")
+		})
+		client = goa.NewLoopbackClient(service)
+	})
+
+	It("serves the requests in-process", func() {
+		req, err := http.NewRequest("POST", "http://localhost/bottles?view=tiny", bytes.NewBufferString(`{"name":"goa"}`))
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(gotReq.URL.RawQuery).Should(Equal("view=tiny"))
+		Ω(gotReq.RequestURI).Should(Equal("/bottles?view=tiny"))
+		Ω(gotReq.RemoteAddr).Should(Equal(goa.LoopbackRemoteAddr))
+		Ω(gotReq.Header.Get("Content-Type")).Should(Equal("application/json"))
+		Ω(string(gotBody)).Should(Equal(`{"name":"goa"}`))
+
+		Ω(resp.StatusCode).Should(Equal(201))
+		Ω(resp.Header.Get("Location")).Should(Equal("/bottles/1"))
+		body, err := ioutil.ReadAll(resp.Body)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(body)).Should(Equal(`{"id":1}`))
+	})
+
+	It("returns panics as errors", func() {
+		req, err := http.NewRequest("GET", "http://localhost/panic", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = client.Do(req)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring("boom"))
+	})
+})
@@ -0,0 +1,227 @@
+package goa
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// maintenanceController is the name of the controller of the maintenance endpoint, its actions
+// cannot be disabled so that maintenance can always be ended.
+const maintenanceController = "Maintenance"
+
+// MaintenanceState describes the parts of the service that are disabled. Requests made to disabled
+// actions get a 503 (Service Unavailable) response with the given message and Retry-After header.
+// The state is serialized in JSON by the maintenance endpoint and in the maintenance file, e.g.:
+//
+//	{"enabled": false, "actions": ["bottle#create", "account"], "retry_after": 300}
+type MaintenanceState struct {
+	// Enabled is true if the whole service is in maintenance.
+	Enabled bool `json:"enabled"`
+	// Actions lists the disabled actions as "<controller>#<action>", an entry made of the
+	// controller name only disables all the actions of the controller. Controller names are
+	// the names of the design resources.
+	Actions []string `json:"actions,omitempty"`
+	// Message is the detail of the 503 responses, defaults to "service is under maintenance".
+	Message string `json:"message,omitempty"`
+	// RetryAfter is the number of seconds clients should wait before retrying, zero if unknown.
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// SetMaintenance sets the maintenance state of the service, a nil state ends maintenance. The
+// requests made to the disabled actions are rejected before being decoded. The state is also
+// reflected in the health endpoint (see MountHealth) and in the "goa.maintenance" gauge, set to 1
+// while the service is in maintenance, and the "goa.maintenance.<controller>.<action>" gauges of
+// the disabled actions.
+func (service *Service) SetMaintenance(state *MaintenanceState) {
+	service.maintMu.Lock()
+	defer service.maintMu.Unlock()
+	if prev := service.maintenance; prev != nil {
+		for _, a := range prev.Actions {
+			SetGauge(maintenanceGauge(a), 0)
+		}
+	}
+	var enabled float32
+	if state != nil {
+		s := *state
+		s.Actions = append([]string(nil), state.Actions...)
+		state = &s
+		if state.Enabled {
+			enabled = 1
+		}
+		for _, a := range state.Actions {
+			SetGauge(maintenanceGauge(a), 1)
+		}
+	}
+	SetGauge([]string{"goa", "maintenance"}, enabled)
+	service.maintenance = state
+}
+
+// Maintenance returns a copy of the maintenance state of the service, nil if the service is not
+// in maintenance.
+func (service *Service) Maintenance() *MaintenanceState {
+	service.maintMu.RLock()
+	defer service.maintMu.RUnlock()
+	if service.maintenance == nil {
+		return nil
+	}
+	s := *service.maintenance
+	s.Actions = append([]string(nil), s.Actions...)
+	return &s
+}
+
+// LoadMaintenance sets the maintenance state of the service to the content of the JSON file with
+// the given path. It ends maintenance if the file does not exist so that operators may toggle
+// maintenance by creating and removing the file. See ServerConfig.MaintenanceFile for reloading
+// the file when the process receives SIGHUP.
+func (service *Service) LoadMaintenance(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		service.SetMaintenance(nil)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var state MaintenanceState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return err
+	}
+	service.SetMaintenance(&state)
+	return nil
+}
+
+// MountMaintenance mounts the maintenance endpoint on the service. GET requests made to path
+// return the maintenance state in JSON, PUT requests replace it with the request body. The
+// endpoint actions cannot be disabled. auth is applied to the endpoint requests first, it must
+// authenticate the operators since the endpoint can disable the whole service, e.g.:
+//
+//	admin := service.SecurityMiddleware("admin")
+//	if err := goa.MountMaintenance(service, "/admin/maintenance", admin); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// The additional middleware is applied after auth. MountMaintenance returns an error and does
+// not mount the endpoint if auth or any of the middleware is nil.
+func MountMaintenance(service *Service, path string, auth Middleware, middleware ...Middleware) error {
+	if auth == nil {
+		return fmt.Errorf("goa: maintenance endpoint %s requires an authentication middleware", path)
+	}
+	for _, m := range middleware {
+		if m == nil {
+			return fmt.Errorf("goa: nil middleware given to maintenance endpoint %s", path)
+		}
+	}
+	ctrl := service.NewController(maintenanceController)
+	ctrl.Use(auth)
+	for _, m := range middleware {
+		ctrl.Use(m)
+	}
+	service.Mux.Handle("GET", path, ctrl.MuxHandler("show", showMaintenance, nil))
+	service.Mux.Handle("PUT", path, ctrl.MuxHandler("update", updateMaintenance, nil))
+	service.LogInfo("mount", KV{"ctrl", maintenanceController}, KV{"action", "show"}, KV{"route", "GET " + path})
+	service.LogInfo("mount", KV{"ctrl", maintenanceController}, KV{"action", "update"}, KV{"route", "PUT " + path})
+	return nil
+}
+
+// MountHealth mounts the health endpoint on the service mux. GET requests made to path get a 200
+// response while the service is up and a 503 response with the maintenance message and
+// Retry-After header while the whole service is in maintenance, so that load balancers stop
+// routing traffic to it. The body lists the status ("ok" or "maintenance") and the disabled
+// actions, e.g. {"status":"ok","disabled":["bottle#create"]}.
+func MountHealth(service *Service, path string) {
+	service.Mux.Handle("GET", path, func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+		health := struct {
+			Status   string   `json:"status"`
+			Message  string   `json:"message,omitempty"`
+			Disabled []string `json:"disabled,omitempty"`
+		}{Status: "ok"}
+		status := http.StatusOK
+		if state := service.Maintenance(); state != nil {
+			health.Disabled = state.Actions
+			if state.Enabled {
+				health.Status = "maintenance"
+				health.Message = state.message()
+				status = http.StatusServiceUnavailable
+				SetRetryAfter(rw.Header(), time.Duration(state.RetryAfter)*time.Second)
+			}
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(status)
+		json.NewEncoder(rw).Encode(health)
+	})
+	service.LogInfo("mount", KV{"ctrl", "Health"}, KV{"route", "GET " + path})
+}
+
+// maintenanceError returns the error produced by requests made to the given action, nil if the
+// action is not disabled. It sets the Retry-After header of the response.
+func (service *Service) maintenanceError(rw http.ResponseWriter, ctrl, action string) error {
+	if ctrl == maintenanceController {
+		return nil
+	}
+	service.maintMu.RLock()
+	state := service.maintenance
+	service.maintMu.RUnlock()
+	if state == nil || !state.disables(ctrl, action) {
+		return nil
+	}
+	SetRetryAfter(rw.Header(), time.Duration(state.RetryAfter)*time.Second)
+	return ErrServiceUnavailable(state.message())
+}
+
+// disables returns true if the state disables the given action.
+func (s *MaintenanceState) disables(ctrl, action string) bool {
+	if s.Enabled {
+		return true
+	}
+	for _, a := range s.Actions {
+		if a == ctrl || a == ctrl+"#"+action {
+			return true
+		}
+	}
+	return false
+}
+
+// message returns the detail of the 503 responses.
+func (s *MaintenanceState) message() string {
+	if s.Message != "" {
+		return s.Message
+	}
+	return "service is under maintenance"
+}
+
+// showMaintenance is the handler of the maintenance endpoint GET requests.
+func showMaintenance(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+	state := RequestService(ctx).Maintenance()
+	if state == nil {
+		state = &MaintenanceState{}
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(rw).Encode(state)
+}
+
+// updateMaintenance is the handler of the maintenance endpoint PUT requests.
+func updateMaintenance(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+	var state MaintenanceState
+	if err := json.NewDecoder(req.Body).Decode(&state); err != nil {
+		return ErrBadRequest(err)
+	}
+	if state.RetryAfter < 0 {
+		return ErrBadRequest("retry_after cannot be negative")
+	}
+	RequestService(ctx).SetMaintenance(&state)
+	Info(ctx, "maintenance", KV{"enabled", state.Enabled}, KV{"actions", strings.Join(state.Actions, ",")})
+	return showMaintenance(ctx, rw, req)
+}
+
+// maintenanceGauge returns the key of the gauge of the given disabled action.
+func maintenanceGauge(action string) []string {
+	return append([]string{"goa", "maintenance"}, strings.SplitN(action, "#", 2)...)
+}
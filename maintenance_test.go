@@ -0,0 +1,147 @@
+package goa_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Maintenance", func() {
+	var service *goa.Service
+	var called bool
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.SetEncoder(goa.JSONEncoderFactory(), true, "application/json")
+		called = false
+		ctrl := service.NewController("bottle")
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called = true
+			rw.WriteHeader(200)
+			return nil
+		}
+		service.Mux.Handle("GET", "/bottles", ctrl.MuxHandler("list", h, nil))
+		service.Mux.Handle("POST", "/bottles", ctrl.MuxHandler("create", h, nil))
+	})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		rw := httptest.NewRecorder()
+		service.Mux.ServeHTTP(rw, req)
+		return rw
+	}
+
+	It("lets requests through when not in maintenance", func() {
+		Ω(serve("GET", "/bottles", "").Code).Should(Equal(200))
+		Ω(called).Should(BeTrue())
+	})
+
+	It("disables the whole service", func() {
+		service.SetMaintenance(&goa.MaintenanceState{Enabled: true, Message: "upgrading", RetryAfter: 120})
+		rw := serve("GET", "/bottles", "")
+		Ω(rw.Code).Should(Equal(503))
+		Ω(rw.Header().Get("Retry-After")).Should(Equal("120"))
+		Ω(rw.Body.String()).Should(ContainSubstring("upgrading"))
+		Ω(called).Should(BeFalse())
+	})
+
+	It("disables individual actions", func() {
+		service.SetMaintenance(&goa.MaintenanceState{Actions: []string{"bottle#create"}})
+		Ω(serve("POST", "/bottles", "").Code).Should(Equal(503))
+		Ω(called).Should(BeFalse())
+		Ω(serve("GET", "/bottles", "").Code).Should(Equal(200))
+		Ω(called).Should(BeTrue())
+	})
+
+	It("disables all the actions of a controller", func() {
+		service.SetMaintenance(&goa.MaintenanceState{Actions: []string{"bottle"}})
+		Ω(serve("GET", "/bottles", "").Code).Should(Equal(503))
+		service.SetMaintenance(nil)
+		Ω(serve("GET", "/bottles", "").Code).Should(Equal(200))
+	})
+
+	Context("with the maintenance and health endpoints", func() {
+		var authorized bool
+
+		BeforeEach(func() {
+			authorized = true
+			auth := func(h goa.Handler) goa.Handler {
+				return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+					if !authorized {
+						rw.WriteHeader(401)
+						return nil
+					}
+					return h(ctx, rw, req)
+				}
+			}
+			Ω(goa.MountMaintenance(service, "/admin/maintenance", auth)).ShouldNot(HaveOccurred())
+			goa.MountHealth(service, "/health")
+		})
+
+		It("controls the maintenance state", func() {
+			Ω(serve("GET", "/health", "").Code).Should(Equal(200))
+			rw := serve("PUT", "/admin/maintenance", `{"enabled":true,"retry_after":60}`)
+			Ω(rw.Code).Should(Equal(200))
+			Ω(service.Maintenance()).Should(Equal(&goa.MaintenanceState{Enabled: true, RetryAfter: 60}))
+			Ω(serve("GET", "/bottles", "").Code).Should(Equal(503))
+			rw = serve("GET", "/health", "")
+			Ω(rw.Code).Should(Equal(503))
+			Ω(rw.Header().Get("Retry-After")).Should(Equal("60"))
+			Ω(rw.Body.String()).Should(ContainSubstring(`"status":"maintenance"`))
+			rw = serve("GET", "/admin/maintenance", "")
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Body.String()).Should(ContainSubstring(`"enabled":true`))
+			Ω(serve("PUT", "/admin/maintenance", `{"enabled":false}`).Code).Should(Equal(200))
+			Ω(serve("GET", "/bottles", "").Code).Should(Equal(200))
+		})
+
+		It("rejects invalid states", func() {
+			Ω(serve("PUT", "/admin/maintenance", `{"retry_after":-1}`).Code).Should(Equal(400))
+		})
+
+		It("authenticates the operators", func() {
+			authorized = false
+			Ω(serve("PUT", "/admin/maintenance", `{"enabled":true}`).Code).Should(Equal(401))
+			Ω(service.Maintenance()).Should(BeNil())
+		})
+	})
+
+	It("does not mount the maintenance endpoint without authentication", func() {
+		Ω(goa.MountMaintenance(service, "/admin/maintenance", nil)).Should(HaveOccurred())
+		Ω(goa.MountMaintenance(service, "/admin/maintenance", service.SecurityMiddleware("admin"))).Should(HaveOccurred())
+		Ω(serve("PUT", "/admin/maintenance", `{"enabled":true}`).Code).Should(Equal(404))
+		Ω(service.Maintenance()).Should(BeNil())
+	})
+
+	Context("with a maintenance file", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "maintenance")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("loads the state from the file", func() {
+			path := filepath.Join(dir, "maintenance.json")
+			Ω(ioutil.WriteFile(path, []byte(`{"actions":["bottle#list"]}`), 0644)).ShouldNot(HaveOccurred())
+			Ω(service.LoadMaintenance(path)).ShouldNot(HaveOccurred())
+			Ω(service.Maintenance().Actions).Should(Equal([]string{"bottle#list"}))
+			Ω(os.Remove(path)).ShouldNot(HaveOccurred())
+			Ω(service.LoadMaintenance(path)).ShouldNot(HaveOccurred())
+			Ω(service.Maintenance()).Should(BeNil())
+		})
+	})
+})
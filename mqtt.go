@@ -0,0 +1,220 @@
+package goa
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+type (
+	// MQTTClient is the interface implemented by MQTT broker clients. Applications typically
+	// implement it with a thin adapter around the client library of their choice.
+	MQTTClient interface {
+		// Subscribe subscribes to the topics matching the given filter, handler is called for
+		// each message received on one of the topics.
+		Subscribe(filter string, qos byte, handler MQTTMessageHandler) error
+		// Publish publishes a message on the given topic.
+		Publish(topic string, qos byte, retained bool, payload []byte) error
+	}
+
+	// MQTTMessageHandler handles the messages received on MQTT topics.
+	MQTTMessageHandler func(topic string, payload []byte)
+
+	// MQTTRouter dispatches the messages published on MQTT topics to the controllers mounted on
+	// a service. Each route maps a topic to an action: the values matched by the topic wildcards
+	// are used as the values of the action parameters with the same names and the message body
+	// is sent as the request body. The action response body is published on the route reply
+	// topic if any.
+	// The code generated by "goagen mqtt" registers the routes of all the actions that
+	// subscribe to a topic in the design.
+	MQTTRouter struct {
+		// Service is the service the messages are dispatched to.
+		Service *Service
+		// Client is the client used to subscribe to the route topics and publish replies.
+		Client MQTTClient
+		// ContentType is the content type of the message bodies, "application/json" if empty.
+		ContentType string
+		routes      []*MQTTRoute
+	}
+
+	// MQTTRoute describes the action a MQTT topic maps to.
+	MQTTRoute struct {
+		// Topic is the topic pattern, its levels may be wildcards of the form ":name" that
+		// match a single level or a last level of the form "*name" that matches any number of
+		// levels.
+		Topic string
+		// QoS is the quality of service level used to subscribe to the topic.
+		QoS byte
+		// Version is the name of the API version that defines the action, empty if none.
+		Version string
+		// Verb is the HTTP method of the action route.
+		Verb string
+		// Path is the full path of the action route including wildcards.
+		Path string
+		// Reply is the topic the action response bodies are published on if any. Its
+		// wildcards are replaced with the values matched by the wildcards of Topic.
+		Reply *MQTTTopic
+	}
+
+	// MQTTTopic describes a topic messages are published on.
+	MQTTTopic struct {
+		// Topic is the topic pattern.
+		Topic string
+		// QoS is the quality of service level used to publish.
+		QoS byte
+		// Retain is true if the messages are retained by the broker.
+		Retain bool
+	}
+)
+
+// NewMQTTRouter returns a MQTT router that dispatches messages received with the given client to
+// the given service.
+func NewMQTTRouter(service *Service, client MQTTClient) *MQTTRouter {
+	return &MQTTRouter{Service: service, Client: client}
+}
+
+// Handle adds a route to the router.
+func (r *MQTTRouter) Handle(route *MQTTRoute) {
+	r.routes = append(r.routes, route)
+}
+
+// Subscribe subscribes to the topics of all the routes. The errors returned by the actions
+// invoked when messages are received are logged.
+func (r *MQTTRouter) Subscribe() error {
+	for _, route := range r.routes {
+		route := route
+		handler := func(topic string, payload []byte) {
+			if err := r.dispatch(route, topic, payload); err != nil {
+				r.Service.LogError("mqtt", KV{"topic", topic}, KV{"error", err.Error()})
+			}
+		}
+		if err := r.Client.Subscribe(MQTTTopicFilter(route.Topic), route.QoS, handler); err != nil {
+			return err
+		}
+		r.Service.LogInfo("subscribe mqtt", KV{"topic", route.Topic}, KV{"action", fmt.Sprintf("%s %s", route.Verb, route.Path)})
+	}
+	return nil
+}
+
+// Dispatch invokes the action of the first route whose topic matches the given topic.
+func (r *MQTTRouter) Dispatch(topic string, payload []byte) error {
+	for _, route := range r.routes {
+		if _, ok := MQTTTopicMatch(route.Topic, topic); ok {
+			return r.dispatch(route, topic, payload)
+		}
+	}
+	return fmt.Errorf("no route for topic %s", topic)
+}
+
+// Publish encodes v with the service encoders and publishes it on the given topic. The topic
+// wildcards are replaced with the given values.
+func (r *MQTTRouter) Publish(topic *MQTTTopic, values map[string]string, v interface{}) error {
+	var buf bytes.Buffer
+	if err := r.Service.Encode(v, &buf, r.ContentType); err != nil {
+		return err
+	}
+	return r.Client.Publish(MQTTTopicName(topic.Topic, values), topic.QoS, topic.Retain, buf.Bytes())
+}
+
+// Decode decodes the given message body into v with the service decoders.
+func (r *MQTTRouter) Decode(payload []byte, v interface{}) error {
+	return r.Service.Decode(v, bytes.NewReader(payload), r.ContentType)
+}
+
+// dispatch invokes the action of the given route and publishes its response on the route reply
+// topic if any.
+func (r *MQTTRouter) dispatch(route *MQTTRoute, topic string, payload []byte) error {
+	values, ok := MQTTTopicMatch(route.Topic, topic)
+	if !ok {
+		return fmt.Errorf("topic %s does not match %s", topic, route.Topic)
+	}
+	fields := make(map[string]interface{}, len(values))
+	for n, v := range values {
+		fields[n] = v
+	}
+	inner, err := bridgeRequest(&http.Request{Header: make(http.Header)}, route.Verb, route.Path, fields, "")
+	if err != nil {
+		return err
+	}
+	contentType := r.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	if len(payload) > 0 {
+		inner.Body = ioutil.NopCloser(bytes.NewReader(payload))
+		inner.ContentLength = int64(len(payload))
+		inner.Header.Set("Content-Type", contentType)
+	}
+	inner.Header.Set("Accept", contentType)
+	rec := bridgeServe(r.Service, route.Version, inner)
+	if rec.failed() {
+		return fmt.Errorf("%d: %s", rec.status, rec.errorMessage())
+	}
+	if route.Reply == nil {
+		return nil
+	}
+	reply := MQTTTopicName(route.Reply.Topic, values)
+	return r.Client.Publish(reply, route.Reply.QoS, route.Reply.Retain, rec.body.Bytes())
+}
+
+// MQTTTopicFilter returns the MQTT topic filter matching the topics described by the given
+// pattern: the ":name" levels are replaced with "+" and the "*name" level with "#".
+func MQTTTopicFilter(pattern string) string {
+	levels := strings.Split(pattern, "/")
+	for i, level := range levels {
+		if len(level) > 1 {
+			switch level[0] {
+			case ':':
+				levels[i] = "+"
+			case '*':
+				levels[i] = "#"
+			}
+		}
+	}
+	return strings.Join(levels, "/")
+}
+
+// MQTTTopicMatch returns the values matched by the wildcards of the given pattern and true if the
+// topic matches the pattern, nil and false otherwise.
+func MQTTTopicMatch(pattern, topic string) (map[string]string, bool) {
+	plevels := strings.Split(pattern, "/")
+	tlevels := strings.Split(topic, "/")
+	values := make(map[string]string)
+	for i, p := range plevels {
+		if len(p) > 1 && p[0] == '*' {
+			if i > len(tlevels) {
+				return nil, false
+			}
+			values[p[1:]] = strings.Join(tlevels[i:], "/")
+			return values, true
+		}
+		if i >= len(tlevels) {
+			return nil, false
+		}
+		if len(p) > 1 && p[0] == ':' {
+			values[p[1:]] = tlevels[i]
+			continue
+		}
+		if p != tlevels[i] {
+			return nil, false
+		}
+	}
+	if len(plevels) != len(tlevels) {
+		return nil, false
+	}
+	return values, true
+}
+
+// MQTTTopicName returns the topic described by the given pattern where the wildcards are replaced
+// with the given values.
+func MQTTTopicName(pattern string, values map[string]string) string {
+	levels := strings.Split(pattern, "/")
+	for i, level := range levels {
+		if len(level) > 1 && (level[0] == ':' || level[0] == '*') {
+			levels[i] = values[level[1:]]
+		}
+	}
+	return strings.Join(levels, "/")
+}
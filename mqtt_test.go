@@ -0,0 +1,129 @@
+package goa_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mqttPublication struct {
+	topic    string
+	qos      byte
+	retained bool
+	payload  string
+}
+
+type fakeMQTTClient struct {
+	handlers  map[string]goa.MQTTMessageHandler
+	published []mqttPublication
+}
+
+func (c *fakeMQTTClient) Subscribe(filter string, qos byte, handler goa.MQTTMessageHandler) error {
+	c.handlers[filter] = handler
+	return nil
+}
+
+func (c *fakeMQTTClient) Publish(topic string, qos byte, retained bool, payload []byte) error {
+	c.published = append(c.published, mqttPublication{topic, qos, retained, string(payload)})
+	return nil
+}
+
+var _ = Describe("MQTTRouter", func() {
+	var service *goa.Service
+	var client *fakeMQTTClient
+	var router *goa.MQTTRouter
+
+	var gotPath, gotBody, gotContentType string
+	var status int
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		client = &fakeMQTTClient{handlers: make(map[string]goa.MQTTMessageHandler)}
+		router = goa.NewMQTTRouter(service, client)
+		gotPath, gotBody, gotContentType = "", "", ""
+		status = 200
+		service.Mux.Handle("POST", "/devices/:deviceID/commands", func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			gotPath = req.URL.Path
+			gotContentType = req.Header.Get("Content-Type")
+			b, _ := ioutil.ReadAll(req.Body)
+			gotBody = string(b)
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(status)
+			if status == 200 {
+				rw.Write([]byte(`{"ack":true}`))
+			} else {
+				rw.Write([]byte(`{"id":1,"title":"bad request","msg":"unknown command"}`))
+			}
+		})
+		router.Handle(&goa.MQTTRoute{
+			Topic: "devices/:deviceID/commands",
+			QoS:   1,
+			Verb:  "POST",
+			Path:  "/devices/:deviceID/commands",
+			Reply: &goa.MQTTTopic{Topic: "devices/:deviceID/replies", QoS: 1, Retain: true},
+		})
+		Ω(router.Subscribe()).ShouldNot(HaveOccurred())
+	})
+
+	It("subscribes to the route topics", func() {
+		Ω(client.handlers).Should(HaveKey("devices/+/commands"))
+	})
+
+	It("dispatches messages to the action and publishes the reply", func() {
+		client.handlers["devices/+/commands"]("devices/d42/commands", []byte(`{"name":"reboot"}`))
+		Ω(gotPath).Should(Equal("/devices/d42/commands"))
+		Ω(gotBody).Should(Equal(`{"name":"reboot"}`))
+		Ω(gotContentType).Should(Equal("application/json"))
+		Ω(client.published).Should(Equal([]mqttPublication{{"devices/d42/replies", 1, true, `{"ack":true}`}}))
+	})
+
+	It("returns the action errors", func() {
+		status = 400
+		err := router.Dispatch("devices/d42/commands", []byte(`{}`))
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(Equal("400: unknown command"))
+		Ω(client.published).Should(BeEmpty())
+	})
+
+	It("encodes and publishes typed messages", func() {
+		service.SetEncoder(goa.JSONEncoderFactory(), true, "application/json")
+		topic := &goa.MQTTTopic{Topic: "devices/:deviceID/telemetry", QoS: 2}
+		err := router.Publish(topic, map[string]string{"deviceID": "d42"}, map[string]int{"temperature": 21})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(client.published).Should(Equal([]mqttPublication{{"devices/d42/telemetry", 2, false, "{\"temperature\":21}\n"}}))
+	})
+
+	It("decodes typed messages", func() {
+		service.SetDecoder(goa.JSONDecoderFactory(), true, "application/json")
+		var msg map[string]int
+		Ω(router.Decode([]byte(`{"temperature":21}`), &msg)).ShouldNot(HaveOccurred())
+		Ω(msg).Should(Equal(map[string]int{"temperature": 21}))
+	})
+
+	It("fails for topics that do not match any route", func() {
+		err := router.Dispatch("devices/d42/telemetry", nil)
+		Ω(err).Should(HaveOccurred())
+	})
+})
+
+var _ = Describe("MQTTTopicMatch", func() {
+	It("matches single and multi-level wildcards", func() {
+		values, ok := goa.MQTTTopicMatch("devices/:id/sensors/*sensor", "devices/d1/sensors/temp/inside")
+		Ω(ok).Should(BeTrue())
+		Ω(values).Should(Equal(map[string]string{"id": "d1", "sensor": "temp/inside"}))
+	})
+
+	It("does not match topics with a different number of levels", func() {
+		_, ok := goa.MQTTTopicMatch("devices/:id", "devices/d1/commands")
+		Ω(ok).Should(BeFalse())
+	})
+
+	It("builds topic names and filters", func() {
+		Ω(goa.MQTTTopicName("devices/:id/sensors/*sensor", map[string]string{"id": "d1", "sensor": "temp/inside"})).Should(Equal("devices/d1/sensors/temp/inside"))
+		Ω(goa.MQTTTopicFilter("devices/:id/sensors/*sensor")).Should(Equal("devices/+/sensors/#"))
+	})
+})
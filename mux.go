@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"sort"
+	"strings"
 
 	"golang.org/x/net/context"
 
@@ -20,7 +22,8 @@ type (
 	// ServeMux is the interface implemented by the service request muxes. There is one instance
 	// of ServeMux per service version and one for requests targeting no version.
 	// It implements http.Handler and makes it possible to register request handlers for
-	// specific HTTP methods and request path via the Handle method.
+	// specific HTTP methods and request path via the Handle method. The code generated by
+	// goagen mounts the controllers on the service ServeMux.
 	ServeMux interface {
 		http.Handler
 		// Handle sets the MuxHandler for a given HTTP method and path.
@@ -29,6 +32,27 @@ type (
 		Lookup(method, path string) MuxHandler
 	}
 
+	// Router is the interface implemented by the request routers that back the ServeMux
+	// implementation returned by NewMux. A router matches the method and path of the incoming
+	// requests against the registered path patterns and extracts the values of the path
+	// parameters. The patterns use the httprouter syntax: ":name" matches a single path
+	// segment and "*name" the rest of the path. NewHTTPRouter returns the default router,
+	// NewRouterMux makes it possible to use a different router, for example one that accepts
+	// patterns that conflict according to httprouter.
+	Router interface {
+		http.Handler
+		// Handle registers the handler of the requests made with the given method whose
+		// path matches the given pattern.
+		Handle(method, pattern string, handle RouterHandle)
+		// HandleNotFound sets the handler of the requests that match no registered
+		// pattern for the request method.
+		HandleNotFound(handler http.Handler)
+	}
+
+	// RouterHandle handles the requests routed by a Router. params contains the values of the
+	// path parameters indexed by name.
+	RouterHandle func(rw http.ResponseWriter, req *http.Request, params map[string]string)
+
 	// VersionMux is implemented by muxes that back versioned APIs.
 	VersionMux interface {
 		// Mux returns the mux for the version with given name.
@@ -53,7 +77,27 @@ type (
 		*mux
 		SelectVersionFunc SelectVersionFunc
 		muxes             map[string]ServeMux
-		service           *Service // Keep reference to service for encoding missing version responses
+		newRouter         func() Router // Creates the routers of the version muxes
+		service           *Service      // Keep reference to service for encoding missing version responses
+	}
+
+	// RouteOptions configures how the muxes created by NewMux and NewRouterMux match request
+	// paths that do not exactly match a registered pattern. DefaultRouteOptions reproduces
+	// the behavior of httprouter. The request paths that contain empty, "." or ".." segments
+	// are cleaned first regardless of the options, e.g. "/bottles//1" and "/accounts/../bottles/1"
+	// match "/bottles/:id".
+	RouteOptions struct {
+		// StrictSlash prevents the request paths that differ from a pattern only by a
+		// trailing slash from matching the pattern.
+		StrictSlash bool
+		// CaseInsensitive makes the static segments of the patterns match the request
+		// path segments regardless of case.
+		CaseInsensitive bool
+		// Redirect causes the requests whose path only matches a pattern once the trailing
+		// slash or case is fixed to be redirected to the fixed path with a 301 (Moved
+		// Permanently) response, 307 (Temporary Redirect) for methods other than GET and
+		// HEAD so that clients resend the body. The requests are handled directly otherwise.
+		Redirect bool
 	}
 
 	// SelectVersionFunc computes the API version targeted by a given request.
@@ -61,20 +105,85 @@ type (
 
 	// mux is the default ServeMux implementation.
 	mux struct {
-		router  *httprouter.Router
+		router  Router
 		handles map[string]MuxHandler
+		methods map[string][]string            // Methods of the registered handlers by path
+		service *Service                       // Service used to encode the method not allowed responses
+		options RouteOptions                   // Path matching options
+		routeMD map[string]map[string][]string // Route metadata overriding the options by path
 	}
+
+	// httpRouter is the Router implemented with httprouter.
+	httpRouter struct {
+		router *httprouter.Router
+	}
+)
+
+const (
+	// RouteStrictSlashMetadata is the metadata key that overrides RouteOptions.StrictSlash for
+	// the routes of a resource, the value is "true" or "false".
+	RouteStrictSlashMetadata = "router:strict-slash"
+
+	// RouteCaseInsensitiveMetadata is the metadata key that overrides
+	// RouteOptions.CaseInsensitive for the routes of a resource, the value is "true" or
+	// "false".
+	RouteCaseInsensitiveMetadata = "router:case-insensitive"
+
+	// RouteRedirectMetadata is the metadata key that overrides RouteOptions.Redirect for the
+	// routes of a resource, the value is "true" or "false".
+	RouteRedirectMetadata = "router:redirect"
 )
 
-// NewMux returns a RootMux.
+// DefaultRouteOptions are the options used by the muxes unless SetRouteOptions is called. Paths
+// that differ by a trailing slash or by case are redirected as done by httprouter.
+var DefaultRouteOptions = RouteOptions{CaseInsensitive: true, Redirect: true}
+
+// NewMux returns a RootMux whose muxes route the requests with httprouter. The muxes respond to
+// requests whose path matches handlers registered for other methods only with 405 (Method Not
+// Allowed) responses whose Allow header lists these methods.
 func NewMux(service *Service) *RootMux {
+	return NewRouterMux(service, NewHTTPRouter)
+}
+
+// NewRouterMux returns a RootMux whose muxes route the requests with the routers created by
+// newRouter. Services use a different router by replacing their mux before mounting any
+// controller:
+//
+//	service := goa.New("my api")
+//	service.Mux = goa.NewRouterMux(service, newMyRouter)
+func NewRouterMux(service *Service, newRouter func() Router) *RootMux {
 	return &RootMux{
-		mux: &mux{
-			router:  httprouter.New(),
-			handles: make(map[string]MuxHandler),
-		},
+		mux:       newMux(newRouter(), service),
+		newRouter: newRouter,
+		service:   service,
+	}
+}
+
+// NewHTTPRouter returns the default Router implemented with httprouter. httprouter panics when
+// registering a pattern that conflicts with a pattern already registered, for example
+// "/bottles/new" and "/bottles/:id".
+func NewHTTPRouter() Router {
+	r := httprouter.New()
+	r.HandleMethodNotAllowed = false
+	// Trailing slashes, case and non-canonical paths are fixed by the mux according to the
+	// RouteOptions.
+	r.RedirectTrailingSlash = false
+	r.RedirectFixedPath = false
+	return &httpRouter{router: r}
+}
+
+// newMux returns a mux that routes the requests with the given router.
+func newMux(router Router, service *Service) *mux {
+	m := &mux{
+		router:  router,
+		handles: make(map[string]MuxHandler),
+		methods: make(map[string][]string),
 		service: service,
+		options: DefaultRouteOptions,
+		routeMD: make(map[string]map[string][]string),
 	}
+	router.HandleNotFound(http.HandlerFunc(m.notFound))
+	return m
 }
 
 // PathSelectVersionFunc returns a SelectVersionFunc that uses the given path pattern to extract the
@@ -159,31 +268,67 @@ func (m *RootMux) Mux(version string) ServeMux {
 	if mux, ok := m.muxes[version]; ok {
 		return mux
 	}
-	mux := &mux{
-		router:  httprouter.New(),
-		handles: make(map[string]MuxHandler),
-	}
+	mux := newMux(m.newRouter(), m.service)
+	mux.options = m.options
 	m.muxes[version] = mux
 	return mux
 }
 
+// SetRouteOptions sets the path matching options of the root mux and of all the version muxes.
+func (m *RootMux) SetRouteOptions(opts RouteOptions) {
+	m.options = opts
+	for _, vm := range m.muxes {
+		if vm, ok := vm.(*mux); ok {
+			vm.options = opts
+		}
+	}
+}
+
 // VersionName returns the name of the version targeted by the request if any, emoty string
 // otherwise.
 func (m *RootMux) VersionName(req *http.Request) string {
 	return m.SelectVersionFunc(req)
 }
 
-// Handle sets the handler for the given verb and path.
+// SetRouteOptions sets the path matching options of the service mux if it supports them, see
+// RouteOptions.
+func (service *Service) SetRouteOptions(opts RouteOptions) {
+	if m, ok := service.Mux.(interface {
+		SetRouteOptions(RouteOptions)
+	}); ok {
+		m.SetRouteOptions(opts)
+	}
+}
+
+// ConfigureRoute overrides the path matching options of the routes registered on sm with the
+// given pattern using the RouteStrictSlashMetadata, RouteCaseInsensitiveMetadata and
+// RouteRedirectMetadata metadata keys. The code generated by goagen calls ConfigureRoute for the
+// routes of the resources that define these keys in their metadata or in the API metadata.
+// ConfigureRoute does nothing if sm was not created by NewMux or NewRouterMux.
+func ConfigureRoute(sm ServeMux, pattern string, metadata map[string][]string) {
+	switch m := sm.(type) {
+	case *RootMux:
+		m.routeMD[pattern] = metadata
+	case *mux:
+		m.routeMD[pattern] = metadata
+	}
+}
+
+// Handle sets the handler for the given verb and path. The values given to the handler include
+// both the querystring and path parameter values.
 func (m *mux) Handle(method, path string, handle MuxHandler) {
-	hthandle := func(rw http.ResponseWriter, req *http.Request, htparams httprouter.Params) {
+	rhandle := func(rw http.ResponseWriter, req *http.Request, pathParams map[string]string) {
 		params := req.URL.Query()
-		for _, p := range htparams {
-			params.Set(p.Key, p.Value)
+		for k, v := range pathParams {
+			params.Set(k, v)
 		}
 		handle(rw, req, params)
 	}
+	if _, ok := m.handles[method+path]; !ok {
+		m.methods[path] = append(m.methods[path], method)
+	}
 	m.handles[method+path] = handle
-	m.router.Handle(method, path, hthandle)
+	m.router.Handle(method, path, rhandle)
 }
 
 // Lookup returns the MuxHandler associated with the given method and path.
@@ -195,3 +340,190 @@ func (m *mux) Lookup(method, path string) MuxHandler {
 func (m *mux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	m.router.ServeHTTP(rw, req)
 }
+
+// allowedMethods returns the methods of the handlers registered for paths that match the given
+// request path sorted alphabetically.
+func (m *mux) allowedMethods(path string) []string {
+	var allowed []string
+	seen := make(map[string]bool)
+	for pattern, methods := range m.methods {
+		if !matchPattern(pattern, path) {
+			continue
+		}
+		for _, method := range methods {
+			if !seen[method] {
+				seen[method] = true
+				allowed = append(allowed, method)
+			}
+		}
+	}
+	sort.Strings(allowed)
+	return allowed
+}
+
+// notFound handles the requests that match no handler. It responds with 405 (Method Not
+// Allowed) and sets the Allow header if handlers are registered for the request path with other
+// methods, it responds with 404 (Not Found) otherwise.
+func (m *mux) notFound(rw http.ResponseWriter, req *http.Request) {
+	allowed := m.allowedMethods(req.URL.Path)
+	if len(allowed) == 0 {
+		if fixed, opts, ok := m.fixPath(req.Method, req.URL.Path); ok {
+			if opts.Redirect {
+				u := *req.URL
+				u.Path = fixed
+				code := http.StatusMovedPermanently
+				if req.Method != "GET" && req.Method != "HEAD" {
+					code = http.StatusTemporaryRedirect
+				}
+				http.Redirect(rw, req, u.String(), code)
+				return
+			}
+			req.URL.Path = fixed
+			m.router.ServeHTTP(rw, req)
+			return
+		}
+		http.NotFound(rw, req)
+		return
+	}
+	rw.Header().Set("Allow", strings.Join(allowed, ", "))
+	err := ErrMethodNotAllowed("method %s is not allowed, must be one of %s", req.Method, strings.Join(allowed, ", "))
+	if m.service == nil {
+		http.Error(rw, err.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := NewContext(RootContext, m.service, rw, req, nil)
+	m.service.ErrorHandler(ctx, rw, req, err)
+}
+
+// fixPath looks for a pattern registered for the given method that matches the given request
+// path once cleaned and its trailing slash or case fixed according to the options of the
+// pattern. It returns the fixed path and the options.
+func (m *mux) fixPath(method, path string) (string, RouteOptions, bool) {
+	cleaned := httprouter.CleanPath(path)
+	patterns := make([]string, 0, len(m.methods))
+	for pattern, methods := range m.methods {
+		for _, meth := range methods {
+			if meth == method {
+				patterns = append(patterns, pattern)
+				break
+			}
+		}
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		opts := m.routeOptions(pattern)
+		candidates := []string{cleaned}
+		if !opts.StrictSlash {
+			if strings.HasSuffix(cleaned, "/") {
+				if len(cleaned) > 1 {
+					candidates = append(candidates, cleaned[:len(cleaned)-1])
+				}
+			} else {
+				candidates = append(candidates, cleaned+"/")
+			}
+		}
+		for _, c := range candidates {
+			if fixed, ok := fixSegments(pattern, c, opts.CaseInsensitive); ok && fixed != path {
+				return fixed, opts, true
+			}
+		}
+	}
+	return "", RouteOptions{}, false
+}
+
+// routeOptions returns the options of the routes registered with the given pattern: the mux
+// options overridden by the route metadata.
+func (m *mux) routeOptions(pattern string) RouteOptions {
+	opts := m.options
+	md := m.routeMD[pattern]
+	override := func(key string, opt *bool) {
+		if vals, ok := md[key]; ok {
+			*opt = len(vals) == 0 || vals[0] != "false"
+		}
+	}
+	override(RouteStrictSlashMetadata, &opts.StrictSlash)
+	override(RouteCaseInsensitiveMetadata, &opts.CaseInsensitive)
+	override(RouteRedirectMetadata, &opts.Redirect)
+	return opts
+}
+
+// fixSegments returns the path that matches the given pattern exactly built from the given path,
+// the static segments of the pattern are compared regardless of case if caseInsensitive is true.
+func fixSegments(pattern, path string, caseInsensitive bool) (string, bool) {
+	psegs, segs := strings.Split(pattern, "/"), strings.Split(path, "/")
+	fixed := make([]string, 0, len(psegs))
+	for i, p := range psegs {
+		if strings.HasPrefix(p, "*") {
+			if i >= len(segs) {
+				return "", false
+			}
+			return strings.Join(append(fixed, segs[i:]...), "/"), true
+		}
+		if i >= len(segs) {
+			return "", false
+		}
+		switch {
+		case strings.HasPrefix(p, ":"):
+			if segs[i] == "" {
+				return "", false
+			}
+			fixed = append(fixed, segs[i])
+		case p == segs[i], caseInsensitive && strings.EqualFold(p, segs[i]):
+			fixed = append(fixed, p)
+		default:
+			return "", false
+		}
+	}
+	if len(psegs) != len(segs) {
+		return "", false
+	}
+	return strings.Join(fixed, "/"), true
+}
+
+// matchPattern returns true if the given request path matches the given path pattern. ":name"
+// matches a single non-empty path segment, "*name" matches the rest of the path.
+func matchPattern(pattern, path string) bool {
+	psegs, segs := strings.Split(pattern, "/"), strings.Split(path, "/")
+	for i, p := range psegs {
+		if strings.HasPrefix(p, "*") {
+			return i < len(segs)
+		}
+		if i >= len(segs) {
+			return false
+		}
+		if strings.HasPrefix(p, ":") {
+			if segs[i] == "" {
+				return false
+			}
+			continue
+		}
+		if p != segs[i] {
+			return false
+		}
+	}
+	return len(psegs) == len(segs)
+}
+
+// Handle registers the handler with httprouter.
+func (r *httpRouter) Handle(method, pattern string, handle RouterHandle) {
+	r.router.Handle(method, pattern, func(rw http.ResponseWriter, req *http.Request, htparams httprouter.Params) {
+		var params map[string]string
+		if len(htparams) > 0 {
+			params = make(map[string]string, len(htparams))
+			for _, p := range htparams {
+				params[p.Key] = p.Value
+			}
+		}
+		handle(rw, req, params)
+	})
+}
+
+// HandleNotFound sets the httprouter NotFound handler.
+func (r *httpRouter) HandleNotFound(handler http.Handler) {
+	r.router.NotFound = handler
+}
+
+// ServeHTTP routes the request with httprouter.
+func (r *httpRouter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	r.router.ServeHTTP(rw, req)
+}
@@ -2,6 +2,9 @@ package goa_test
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 
 	"github.com/goadesign/goa"
 	. "github.com/onsi/ginkgo"
@@ -52,3 +55,200 @@ var _ = Describe("PathSelectVersionFunc", func() {
 	})
 
 })
+
+// segmentRouter is a goa.Router that matches the request paths segment by segment and prefers
+// static segments over parameters so that it accepts patterns httprouter rejects.
+type segmentRouter struct {
+	routes   []segmentRoute
+	notFound http.Handler
+}
+
+type segmentRoute struct {
+	method   string
+	segments []string
+	handle   goa.RouterHandle
+}
+
+func (r *segmentRouter) Handle(method, pattern string, handle goa.RouterHandle) {
+	r.routes = append(r.routes, segmentRoute{method, strings.Split(pattern, "/"), handle})
+}
+
+func (r *segmentRouter) HandleNotFound(handler http.Handler) {
+	r.notFound = handler
+}
+
+func (r *segmentRouter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	var best *segmentRoute
+	var bestParams map[string]string
+	segments := strings.Split(req.URL.Path, "/")
+	for i, route := range r.routes {
+		if route.method != req.Method || len(route.segments) != len(segments) {
+			continue
+		}
+		params := make(map[string]string)
+		for j, s := range route.segments {
+			if strings.HasPrefix(s, ":") {
+				params[s[1:]] = segments[j]
+			} else if s != segments[j] {
+				params = nil
+				break
+			}
+		}
+		if params != nil && (best == nil || len(params) < len(bestParams)) {
+			best, bestParams = &r.routes[i], params
+		}
+	}
+	if best == nil {
+		r.notFound.ServeHTTP(rw, req)
+		return
+	}
+	best.handle(rw, req, bestParams)
+}
+
+var _ = Describe("Mux", func() {
+	var mux goa.ServeMux
+	var params url.Values
+	var handled string
+
+	handler := func(name string) goa.MuxHandler {
+		return func(rw http.ResponseWriter, req *http.Request, p url.Values) {
+			handled, params = name, p
+		}
+	}
+
+	serve := func(method, path string) int {
+		req, err := http.NewRequest(method, path, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, req)
+		return rw.Code
+	}
+
+	BeforeEach(func() {
+		handled, params = "", nil
+	})
+
+	Context("with the default router", func() {
+		BeforeEach(func() {
+			mux = goa.NewMux(goa.New("test"))
+			mux.Handle("GET", "/bottles/:id", handler("show"))
+		})
+
+		It("extracts the path and querystring parameters", func() {
+			Ω(serve("GET", "/bottles/42?view=tiny")).Should(Equal(200))
+			Ω(handled).Should(Equal("show"))
+			Ω(params.Get("id")).Should(Equal("42"))
+			Ω(params.Get("view")).Should(Equal("tiny"))
+			Ω(mux.Lookup("GET", "/bottles/:id")).ShouldNot(BeNil())
+		})
+
+		It("responds with 405 to requests made with other methods", func() {
+			mux.Handle("DELETE", "/bottles/:id", handler("delete"))
+			req, _ := http.NewRequest("PUT", "/bottles/42", nil)
+			rw := httptest.NewRecorder()
+			mux.ServeHTTP(rw, req)
+			Ω(rw.Code).Should(Equal(405))
+			Ω(rw.Header().Get("Allow")).Should(Equal("DELETE, GET"))
+			Ω(handled).Should(BeEmpty())
+		})
+
+		It("responds with 404 to requests made to unknown paths", func() {
+			Ω(serve("PUT", "/wines/42")).Should(Equal(404))
+		})
+	})
+
+	Context("with a custom router", func() {
+		BeforeEach(func() {
+			mux = goa.NewRouterMux(goa.New("test"), func() goa.Router { return &segmentRouter{} })
+			mux.Handle("GET", "/bottles/:id", handler("show"))
+			mux.Handle("GET", "/bottles/new", handler("new"))
+		})
+
+		It("routes the requests with the custom router", func() {
+			Ω(serve("GET", "/bottles/new")).Should(Equal(200))
+			Ω(handled).Should(Equal("new"))
+			Ω(serve("GET", "/bottles/42")).Should(Equal(200))
+			Ω(handled).Should(Equal("show"))
+			Ω(params.Get("id")).Should(Equal("42"))
+			Ω(serve("GET", "/wines/42")).Should(Equal(404))
+			Ω(serve("POST", "/bottles/new")).Should(Equal(405))
+		})
+
+		It("creates the version muxes with the custom router", func() {
+			root := mux.(*goa.RootMux)
+			root.SelectVersionFunc = goa.HeaderSelectVersionFunc("X-Version")
+			root.Mux("v1").Handle("GET", "/bottles/new", handler("v1 new"))
+			req, _ := http.NewRequest("GET", "/bottles/new", nil)
+			req.Header.Set("X-Version", "v1")
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+			Ω(handled).Should(Equal("v1 new"))
+		})
+	})
+
+	Context("with route options", func() {
+		var root *goa.RootMux
+
+		BeforeEach(func() {
+			root = goa.NewRouterMux(goa.New("test"), func() goa.Router { return &segmentRouter{} })
+			mux = root
+			mux.Handle("GET", "/bottles/:id", handler("show"))
+			mux.Handle("POST", "/bottles", handler("create"))
+		})
+
+		redirect := func(method, path string) (int, string) {
+			req, _ := http.NewRequest(method, path, nil)
+			rw := httptest.NewRecorder()
+			mux.ServeHTTP(rw, req)
+			return rw.Code, rw.Header().Get("Location")
+		}
+
+		It("redirects the requests whose trailing slash or case differ by default", func() {
+			code, location := redirect("GET", "/Bottles/42/?view=tiny")
+			Ω(code).Should(Equal(301))
+			Ω(location).Should(Equal("/bottles/42?view=tiny"))
+			code, location = redirect("POST", "/bottles/")
+			Ω(code).Should(Equal(307))
+			Ω(location).Should(Equal("/bottles"))
+			Ω(handled).Should(BeEmpty())
+		})
+
+		It("redirects the requests whose path is not canonical", func() {
+			code, location := redirect("GET", "/bottles//42")
+			Ω(code).Should(Equal(301))
+			Ω(location).Should(Equal("/bottles/42"))
+			code, location = redirect("GET", "/./accounts/../bottles/42/")
+			Ω(code).Should(Equal(301))
+			Ω(location).Should(Equal("/bottles/42"))
+			Ω(handled).Should(BeEmpty())
+		})
+
+		It("handles the requests directly if redirects are disabled", func() {
+			root.SetRouteOptions(goa.RouteOptions{CaseInsensitive: true})
+			Ω(serve("GET", "/BOTTLES/42/")).Should(Equal(200))
+			Ω(handled).Should(Equal("show"))
+			Ω(params.Get("id")).Should(Equal("42"))
+			Ω(serve("GET", "/bottles/./42")).Should(Equal(200))
+			Ω(params.Get("id")).Should(Equal("42"))
+		})
+
+		It("cleans the paths with strict slash", func() {
+			root.SetRouteOptions(goa.RouteOptions{StrictSlash: true, Redirect: true})
+			code, location := redirect("GET", "/bottles/x/../42")
+			Ω(code).Should(Equal(301))
+			Ω(location).Should(Equal("/bottles/42"))
+		})
+
+		It("does not match paths that differ by a trailing slash with strict slash", func() {
+			root.SetRouteOptions(goa.RouteOptions{StrictSlash: true, Redirect: true})
+			Ω(serve("GET", "/bottles/42/")).Should(Equal(404))
+			Ω(serve("GET", "/Bottles/42")).Should(Equal(404))
+		})
+
+		It("applies the route metadata", func() {
+			goa.ConfigureRoute(mux, "/bottles", map[string][]string{goa.RouteStrictSlashMetadata: {"true"}})
+			Ω(serve("POST", "/bottles/")).Should(Equal(404))
+			code, _ := redirect("GET", "/bottles/42/")
+			Ω(code).Should(Equal(301))
+		})
+	})
+})
@@ -0,0 +1,49 @@
+package goa
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ParamValues returns the values of the array parameter with the given name. The values are given
+// as a comma separated list (?tag=a,b) as documented by the generated Swagger specification or by
+// repeating the key (?tag=a&tag=b). Repeated values are not split so that elements containing
+// commas may be given by repeating the key. ParamValues returns nil if the parameter is not set.
+func ParamValues(params url.Values, name string) []string {
+	vals := params[name]
+	if len(vals) == 1 {
+		if vals[0] == "" {
+			return nil
+		}
+		return strings.Split(vals[0], ",")
+	}
+	var values []string
+	for _, v := range vals {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// ParamMap returns the values of the map parameter with the given name. Each entry is given with
+// a bracketed key (?filter[status]=open&filter[owner]=me). The first value wins when a key is
+// repeated. ParamMap returns nil if the parameter is not set.
+func ParamMap(params url.Values, name string) map[string]string {
+	var m map[string]string
+	prefix := name + "["
+	for k, v := range params {
+		if len(v) == 0 || !strings.HasPrefix(k, prefix) || !strings.HasSuffix(k, "]") {
+			continue
+		}
+		key := k[len(prefix) : len(k)-1]
+		if key == "" || strings.ContainsAny(key, "[]") {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]string)
+		}
+		m[key] = v[0]
+	}
+	return m
+}
@@ -0,0 +1,42 @@
+package goa_test
+
+import (
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParamValues", func() {
+	It("splits the comma separated values", func() {
+		params := url.Values{"tag": {"a,b"}, "other": {"x"}}
+		Ω(goa.ParamValues(params, "tag")).Should(Equal([]string{"a", "b"}))
+	})
+
+	It("does not split the repeated values", func() {
+		params := url.Values{"tag": {"a", "b,c"}, "other": {"x"}}
+		Ω(goa.ParamValues(params, "tag")).Should(Equal([]string{"a", "b,c"}))
+	})
+
+	It("returns nil when the parameter is missing", func() {
+		Ω(goa.ParamValues(url.Values{"tag": {""}}, "tag")).Should(BeNil())
+	})
+})
+
+var _ = Describe("ParamMap", func() {
+	It("collects the bracketed keys", func() {
+		params := url.Values{
+			"filter[status]": {"open", "closed"},
+			"filter[owner]":  {"me"},
+			"filter[a][b]":   {"nested"},
+			"filter":         {"plain"},
+			"filters[x]":     {"other"},
+		}
+		Ω(goa.ParamMap(params, "filter")).Should(Equal(map[string]string{"status": "open", "owner": "me"}))
+	})
+
+	It("returns nil when the parameter is missing", func() {
+		Ω(goa.ParamMap(url.Values{"filter": {"x"}}, "filter")).Should(BeNil())
+	})
+})
@@ -0,0 +1,91 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// ProblemMediaType is the content type of RFC 7807 problem details documents.
+const ProblemMediaType = "application/problem+json"
+
+// Problem is a RFC 7807 problem details document. It implements error so that controllers may
+// return problems directly, ProblemErrorHandler renders them with their status code.
+type Problem struct {
+	// Type is a URI reference that identifies the problem type, "about:blank" by default.
+	Type string `json:"type,omitempty"`
+	// Title is a short, human-readable summary of the problem type.
+	Title string `json:"title"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is a URI reference that identifies the specific occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+}
+
+// NewProblem returns a problem with the given status and detail whose title is the status text.
+// The detail is formatted a la fmt.Sprintf.
+func NewProblem(status int, detail string, args ...interface{}) *Problem {
+	if len(args) > 0 {
+		detail = fmt.Sprintf(detail, args...)
+	}
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Error implements error.
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// ProblemFromError returns the problem that describes the given error: err itself if it is a
+// *Problem, a problem with the error status if it implements ServiceError and a 500 problem
+// otherwise. The detail of the problem is the error message or the HTTPError detail.
+func ProblemFromError(err error) *Problem {
+	switch actual := err.(type) {
+	case *Problem:
+		return actual
+	case *HTTPError:
+		return NewProblem(actual.Status, actual.Detail)
+	}
+	return NewProblem(ErrorStatus(err), err.Error())
+}
+
+// SendProblem writes the given problem with its status code and the application/problem+json
+// content type. The problem instance defaults to the request path.
+func (r *ResponseData) SendProblem(ctx context.Context, p *Problem) error {
+	doc := *p
+	if doc.Instance == "" {
+		if req := Request(ctx); req != nil && req.Request != nil {
+			doc.Instance = req.URL.Path
+		}
+	}
+	body, err := json.Marshal(&doc)
+	if err != nil {
+		return err
+	}
+	return r.SendRaw(doc.Status, ProblemMediaType, bytes.NewReader(body))
+}
+
+// ProblemErrorHandler is an error handler that renders errors as RFC 7807 problem details
+// documents, see ProblemFromError. It logs internal errors (500 status).
+func ProblemErrorHandler(ctx context.Context, rw http.ResponseWriter, req *http.Request, e error) {
+	p := ProblemFromError(e)
+	if p.Status >= 500 {
+		Error(ctx, e.Error())
+	}
+	if err := Response(ctx).SendProblem(ctx, p); err != nil {
+		Error(ctx, "failed to write problem", KV{"error", err.Error()})
+	}
+}
@@ -0,0 +1,74 @@
+package goa_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("ProblemErrorHandler", func() {
+	var rw *httptest.ResponseRecorder
+	var req *http.Request
+	var ctx context.Context
+	var handlerErr error
+	var problem map[string]interface{}
+
+	BeforeEach(func() {
+		rw = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/bottles/1", nil)
+		ctx = goa.NewContext(nil, goa.New("test"), rw, req, nil)
+		problem = nil
+	})
+
+	JustBeforeEach(func() {
+		goa.ProblemErrorHandler(ctx, rw, req, handlerErr)
+		Ω(rw.Header().Get("Content-Type")).Should(Equal(goa.ProblemMediaType))
+		Ω(json.Unmarshal(rw.Body.Bytes(), &problem)).ShouldNot(HaveOccurred())
+	})
+
+	Context("with a bad request error", func() {
+		BeforeEach(func() {
+			handlerErr = goa.NewBadRequestError(errors.New("invalid id"))
+		})
+
+		It("renders a 400 problem", func() {
+			Ω(rw.Code).Should(Equal(400))
+			Ω(problem).Should(HaveKeyWithValue("type", "about:blank"))
+			Ω(problem).Should(HaveKeyWithValue("title", "Bad Request"))
+			Ω(problem).Should(HaveKeyWithValue("status", 400.0))
+			Ω(problem).Should(HaveKeyWithValue("detail", "invalid id"))
+			Ω(problem).Should(HaveKeyWithValue("instance", "/bottles/1"))
+		})
+	})
+
+	Context("with a problem", func() {
+		BeforeEach(func() {
+			p := goa.NewProblem(404, "bottle %d not found", 1)
+			p.Type = "https://example.com/probs/not-found"
+			handlerErr = p
+		})
+
+		It("renders the problem", func() {
+			Ω(rw.Code).Should(Equal(404))
+			Ω(problem).Should(HaveKeyWithValue("type", "https://example.com/probs/not-found"))
+			Ω(problem).Should(HaveKeyWithValue("detail", "bottle 1 not found"))
+		})
+	})
+
+	Context("with an internal error", func() {
+		BeforeEach(func() {
+			handlerErr = errors.New("boom")
+		})
+
+		It("renders a 500 problem", func() {
+			Ω(rw.Code).Should(Equal(500))
+			Ω(problem).Should(HaveKeyWithValue("title", "Internal Server Error"))
+		})
+	})
+})
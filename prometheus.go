@@ -0,0 +1,226 @@
+package goa
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// PrometheusMetrics collects the request count, latency and in-flight metrics recorded by
+	// the Instrument middleware and exposes them in the Prometheus text format. The request
+	// count and latency series are labeled by resource, action and response status, the
+	// in-flight series by resource and action.
+	PrometheusMetrics struct {
+		// Namespace is prepended to the metric names if not empty, e.g. "cellar" produces
+		// "cellar_http_requests_total".
+		Namespace string
+		// Buckets lists the upper bounds in seconds of the latency histogram buckets in
+		// increasing order.
+		Buckets []float64
+
+		mu       sync.Mutex
+		requests map[requestLabels]*histogram
+		inflight map[actionLabels]int64
+	}
+
+	// actionLabels identifies the action handling a request.
+	actionLabels struct {
+		resource, action string
+	}
+
+	// requestLabels identifies the action and response status of a request.
+	requestLabels struct {
+		actionLabels
+		status int
+	}
+
+	// histogram records the latency of the requests sharing the same labels, counts[i] is the
+	// number of observations less or equal to the i-th bucket upper bound.
+	histogram struct {
+		counts []uint64
+		count  uint64
+		sum    float64
+	}
+)
+
+// DefaultLatencyBuckets are the latency histogram buckets used by NewPrometheusMetrics, they
+// match the default buckets of the Prometheus client libraries.
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// NewPrometheusMetrics returns metrics whose names are prefixed with the given namespace and
+// whose latency histograms use DefaultLatencyBuckets.
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	return &PrometheusMetrics{Namespace: namespace, Buckets: DefaultLatencyBuckets}
+}
+
+// Instrument returns a middleware that records the requests in m. The resource and action labels
+// are the names of the controller and action handling the request so the middleware records
+// nothing useful when mounted outside of a controller, e.g. on a raw mux handler.
+func Instrument(m *PrometheusMetrics) Middleware {
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			labels := actionLabels{ContextController(ctx), ContextAction(ctx)}
+			m.track(labels, 1)
+			defer m.track(labels, -1)
+			startedAt := time.Now()
+			err := h(ctx, rw, req)
+			status := http.StatusOK
+			if resp := Response(ctx); resp != nil && resp.Status != 0 {
+				status = resp.Status
+			}
+			m.observe(requestLabels{labels, status}, time.Since(startedAt))
+			return err
+		}
+	}
+}
+
+// MountMetrics mounts the endpoint that serves the metrics collected in m on the service mux.
+// The endpoint accepts GET requests made to the given path.
+func MountMetrics(service *Service, path string, m *PrometheusMetrics) {
+	service.Mux.Handle("GET", path, func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+		m.ServeHTTP(rw, req)
+	})
+	service.LogInfo("mount", KV{"ctrl", "Metrics"}, KV{"route", "GET " + path})
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *PrometheusMetrics) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(rw)
+}
+
+// WriteTo writes the metrics in the Prometheus text format to w. The series are sorted by label
+// values so that the output is stable.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	requests := make([]requestLabels, 0, len(m.requests))
+	for l := range m.requests {
+		requests = append(requests, l)
+	}
+	inflight := make([]actionLabels, 0, len(m.inflight))
+	for l := range m.inflight {
+		inflight = append(inflight, l)
+	}
+	sort.Sort(byRequestLabels(requests))
+	sort.Sort(byActionLabels(inflight))
+
+	var b bytes.Buffer
+	total := m.name("http_requests_total")
+	fmt.Fprintf(&b, "# HELP %s Number of HTTP requests handled.\n# TYPE %s counter\n", total, total)
+	for _, l := range requests {
+		fmt.Fprintf(&b, "%s{%s} %d\n", total, l.String(), m.requests[l].count)
+	}
+	duration := m.name("http_request_duration_seconds")
+	fmt.Fprintf(&b, "# HELP %s Duration of the HTTP requests in seconds.\n# TYPE %s histogram\n", duration, duration)
+	for _, l := range requests {
+		h := m.requests[l]
+		for i, le := range m.Buckets {
+			fmt.Fprintf(&b, "%s_bucket{%s,le=\"%s\"} %d\n", duration, l.String(), formatFloat(le), h.counts[i])
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} %d\n", duration, l.String(), h.count)
+		fmt.Fprintf(&b, "%s_sum{%s} %s\n", duration, l.String(), formatFloat(h.sum))
+		fmt.Fprintf(&b, "%s_count{%s} %d\n", duration, l.String(), h.count)
+	}
+	gauge := m.name("http_requests_in_flight")
+	fmt.Fprintf(&b, "# HELP %s Number of HTTP requests being handled.\n# TYPE %s gauge\n", gauge, gauge)
+	for _, l := range inflight {
+		fmt.Fprintf(&b, "%s{%s} %d\n", gauge, l.String(), m.inflight[l])
+	}
+	m.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// track adds delta to the number of requests being handled by the action.
+func (m *PrometheusMetrics) track(l actionLabels, delta int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.inflight == nil {
+		m.inflight = make(map[actionLabels]int64)
+	}
+	m.inflight[l] += delta
+}
+
+// observe records the duration of a request.
+func (m *PrometheusMetrics) observe(l requestLabels, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.requests == nil {
+		m.requests = make(map[requestLabels]*histogram)
+	}
+	h, ok := m.requests[l]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.Buckets))}
+		m.requests[l] = h
+	}
+	secs := d.Seconds()
+	for i, le := range m.Buckets {
+		if secs <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += secs
+}
+
+// name returns the full name of the metric with the given name.
+func (m *PrometheusMetrics) name(n string) string {
+	if m.Namespace == "" {
+		return n
+	}
+	return m.Namespace + "_" + n
+}
+
+// String returns the Prometheus representation of the labels.
+func (l actionLabels) String() string {
+	return fmt.Sprintf(`resource="%s",action="%s"`, escapeLabel(l.resource), escapeLabel(l.action))
+}
+
+// String returns the Prometheus representation of the labels.
+func (l requestLabels) String() string {
+	return fmt.Sprintf(`%s,status="%d"`, l.actionLabels.String(), l.status)
+}
+
+// escapeLabel escapes the backslashes, double quotes and line feeds of a label value.
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// formatFloat returns the shortest representation of f.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+type (
+	byActionLabels  []actionLabels
+	byRequestLabels []requestLabels
+)
+
+func (s byActionLabels) Len() int      { return len(s) }
+func (s byActionLabels) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byActionLabels) Less(i, j int) bool {
+	if s[i].resource != s[j].resource {
+		return s[i].resource < s[j].resource
+	}
+	return s[i].action < s[j].action
+}
+
+func (s byRequestLabels) Len() int      { return len(s) }
+func (s byRequestLabels) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byRequestLabels) Less(i, j int) bool {
+	if s[i].actionLabels != s[j].actionLabels {
+		return byActionLabels{s[i].actionLabels, s[j].actionLabels}.Less(0, 1)
+	}
+	return s[i].status < s[j].status
+}
@@ -0,0 +1,75 @@
+package goa_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Instrument", func() {
+	var service *goa.Service
+	var metrics *goa.PrometheusMetrics
+	var inflight string
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		metrics = goa.NewPrometheusMetrics("cellar")
+		metrics.Buckets = []float64{0.5, 1}
+		ctrl := service.NewController("bottle")
+		ctrl.Use(goa.Instrument(metrics))
+		show := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			buf := new(bytes.Buffer)
+			metrics.WriteTo(buf)
+			inflight = buf.String()
+			return goa.ErrNotFound("no bottle")
+		}
+		list := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.WriteHeader(200)
+			return nil
+		}
+		req, _ := http.NewRequest("GET", "/bottles", nil)
+		ctrl.MuxHandler("list", list, nil)(httptest.NewRecorder(), req, nil)
+		ctrl.MuxHandler("list", list, nil)(httptest.NewRecorder(), req, nil)
+		req, _ = http.NewRequest("GET", "/bottles/1", nil)
+		ctrl.MuxHandler("show", show, nil)(httptest.NewRecorder(), req, nil)
+	})
+
+	It("counts the requests by resource, action and status", func() {
+		rw := httptest.NewRecorder()
+		metrics.ServeHTTP(rw, nil)
+		Ω(rw.Header().Get("Content-Type")).Should(Equal("text/plain; version=0.0.4"))
+		Ω(rw.Body.String()).Should(ContainSubstring("# TYPE cellar_http_requests_total counter\n"))
+		Ω(rw.Body.String()).Should(ContainSubstring(`cellar_http_requests_total{resource="bottle",action="list",status="200"} 2` + "\n"))
+		Ω(rw.Body.String()).Should(ContainSubstring(`cellar_http_requests_total{resource="bottle",action="show",status="404"} 1` + "\n"))
+	})
+
+	It("records the latency histograms", func() {
+		rw := httptest.NewRecorder()
+		metrics.ServeHTTP(rw, nil)
+		Ω(rw.Body.String()).Should(ContainSubstring("# TYPE cellar_http_request_duration_seconds histogram\n"))
+		Ω(rw.Body.String()).Should(ContainSubstring(`cellar_http_request_duration_seconds_bucket{resource="bottle",action="list",status="200",le="0.5"} 2` + "\n"))
+		Ω(rw.Body.String()).Should(ContainSubstring(`cellar_http_request_duration_seconds_bucket{resource="bottle",action="list",status="200",le="+Inf"} 2` + "\n"))
+		Ω(rw.Body.String()).Should(ContainSubstring(`cellar_http_request_duration_seconds_count{resource="bottle",action="list",status="200"} 2` + "\n"))
+	})
+
+	It("tracks the requests in flight", func() {
+		Ω(inflight).Should(ContainSubstring(`cellar_http_requests_in_flight{resource="bottle",action="show"} 1` + "\n"))
+		rw := httptest.NewRecorder()
+		metrics.ServeHTTP(rw, nil)
+		Ω(rw.Body.String()).Should(ContainSubstring(`cellar_http_requests_in_flight{resource="bottle",action="show"} 0` + "\n"))
+	})
+
+	It("is served by the metrics endpoint", func() {
+		goa.MountMetrics(service, "/metrics", metrics)
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		rw := httptest.NewRecorder()
+		service.Mux.ServeHTTP(rw, req)
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Body.String()).Should(ContainSubstring("cellar_http_requests_total"))
+	})
+})
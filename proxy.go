@@ -0,0 +1,136 @@
+package goa
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ReverseProxy forwards the requests made to proxy actions to an upstream service and streams
+// the responses back to the clients. The code generated for actions whose design uses the Proxy
+// DSL validates the requests and calls Handle instead of a controller method.
+// Hop-by-hop headers are not forwarded in either direction and the X-Forwarded-For,
+// X-Forwarded-Host and X-Forwarded-Proto headers are set on the upstream requests. Upstream errors
+// and timeouts result in 502 Bad Gateway responses.
+type ReverseProxy struct {
+	// Upstream is the URL of the upstream service, the path of the proxied requests is appended
+	// to its path.
+	Upstream *url.URL
+	// Timeout is the maximum duration of upstream requests including reading the response body,
+	// zero means no timeout.
+	Timeout time.Duration
+	// StripPrefix is removed from the path of the proxied requests before it is appended to
+	// the upstream URL path.
+	StripPrefix string
+	// SetHeaders lists the headers set on upstream requests indexed by name.
+	SetHeaders map[string]string
+	// RemoveHeaders lists the names of the headers removed from upstream requests.
+	RemoveHeaders []string
+	// Transport is used to make the upstream requests, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+
+	proxy *httputil.ReverseProxy
+}
+
+// NewReverseProxy returns a proxy that forwards requests to the upstream service with the given
+// URL. It panics if upstream is not a valid URL, the design validation guarantees that the URLs
+// used by the generated code are.
+func NewReverseProxy(upstream string) *ReverseProxy {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		panic(err)
+	}
+	p := &ReverseProxy{Upstream: u}
+	p.proxy = &httputil.ReverseProxy{
+		Director:      p.direct,
+		Transport:     proxyTransport{p},
+		FlushInterval: 100 * time.Millisecond,
+	}
+	return p
+}
+
+// Handle forwards the request to the upstream service and writes the upstream response. Its
+// signature matches Handler so that it can be used as the handler of generated actions.
+func (p *ReverseProxy) Handle(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+	IncrCounter([]string{"goa", "proxy", p.Upstream.Host}, 1.0)
+	p.proxy.ServeHTTP(rw, req.WithContext(ctx))
+	return nil
+}
+
+// direct rewrites the request so that it targets the upstream service.
+func (p *ReverseProxy) direct(req *http.Request) {
+	host := req.Host
+	reqPath := req.URL.Path
+	if p.StripPrefix != "" && strings.HasPrefix(reqPath, p.StripPrefix) {
+		reqPath = reqPath[len(p.StripPrefix):]
+	}
+	req.URL.Scheme = p.Upstream.Scheme
+	req.URL.Host = p.Upstream.Host
+	req.URL.Path = proxyJoinPath(p.Upstream.Path, reqPath)
+	req.URL.RawPath = ""
+	if p.Upstream.RawQuery != "" {
+		if req.URL.RawQuery == "" {
+			req.URL.RawQuery = p.Upstream.RawQuery
+		} else {
+			req.URL.RawQuery = p.Upstream.RawQuery + "&" + req.URL.RawQuery
+		}
+	}
+	req.Host = p.Upstream.Host
+	if req.Header.Get("X-Forwarded-Host") == "" {
+		req.Header.Set("X-Forwarded-Host", host)
+	}
+	if req.Header.Get("X-Forwarded-Proto") == "" {
+		proto := "http"
+		if req.TLS != nil {
+			proto = "https"
+		}
+		req.Header.Set("X-Forwarded-Proto", proto)
+	}
+	if _, ok := req.Header["User-Agent"]; !ok {
+		// Prevent the default Go user agent from being sent upstream.
+		req.Header.Set("User-Agent", "")
+	}
+	for _, h := range p.RemoveHeaders {
+		req.Header.Del(h)
+	}
+	for h, v := range p.SetHeaders {
+		req.Header.Set(h, v)
+	}
+}
+
+// proxyJoinPath appends the request path to the upstream path making sure the two are separated
+// by exactly one slash.
+func proxyJoinPath(base, reqPath string) string {
+	if reqPath == "" {
+		if base == "" {
+			return "/"
+		}
+		return base
+	}
+	if !strings.HasPrefix(reqPath, "/") {
+		reqPath = "/" + reqPath
+	}
+	return strings.TrimSuffix(base, "/") + reqPath
+}
+
+// proxyTransport makes the upstream requests using the proxy transport.
+type proxyTransport struct {
+	p *ReverseProxy
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.p.Transport != nil {
+		return t.p.Transport.RoundTrip(req)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -0,0 +1,94 @@
+package goa_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("ReverseProxy", func() {
+	var upstream *httptest.Server
+	var proxy *goa.ReverseProxy
+	var req *http.Request
+	var rw *httptest.ResponseRecorder
+
+	var gotPath, gotQuery string
+	var gotHeader http.Header
+	var gotBody []byte
+	var delay time.Duration
+
+	BeforeEach(func() {
+		gotPath, gotQuery, gotHeader, gotBody, delay = "", "", nil, nil, 0
+		upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			gotPath = r.URL.Path
+			gotQuery = r.URL.RawQuery
+			gotHeader = r.Header
+			gotBody, _ = ioutil.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Connection", "close")
+			w.WriteHeader(202)
+			w.Write([]byte("upstream"))
+		}))
+		proxy = goa.NewReverseProxy(upstream.URL + "/v2?key=k")
+		var err error
+		req, err = http.NewRequest("POST", "http://cellar.example/search/wines?q=red", strings.NewReader(`{"color":"red"}`))
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Cookie", "secret")
+		req.Header.Set("Authorization", "Bearer token")
+	})
+
+	JustBeforeEach(func() {
+		rw = httptest.NewRecorder()
+		Ω(proxy.Handle(context.Background(), rw, req)).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		upstream.Close()
+	})
+
+	It("forwards the request and streams the response", func() {
+		Ω(gotPath).Should(Equal("/v2/search/wines"))
+		Ω(gotQuery).Should(Equal("key=k&q=red"))
+		Ω(gotHeader.Get("Authorization")).Should(Equal("Bearer token"))
+		Ω(gotHeader.Get("X-Forwarded-Host")).Should(Equal("cellar.example"))
+		Ω(gotHeader.Get("X-Forwarded-Proto")).Should(Equal("http"))
+		Ω(string(gotBody)).Should(Equal(`{"color":"red"}`))
+		Ω(rw.Code).Should(Equal(202))
+		Ω(rw.Header().Get("Content-Type")).Should(Equal("text/plain"))
+		Ω(rw.Header().Get("Connection")).Should(BeEmpty())
+		Ω(rw.Body.String()).Should(Equal("upstream"))
+	})
+
+	Context("with rewrite rules", func() {
+		BeforeEach(func() {
+			proxy.StripPrefix = "/search"
+			proxy.SetHeaders = map[string]string{"X-Gateway": "cellar"}
+			proxy.RemoveHeaders = []string{"Cookie"}
+		})
+
+		It("rewrites the request", func() {
+			Ω(gotPath).Should(Equal("/v2/wines"))
+			Ω(gotHeader.Get("X-Gateway")).Should(Equal("cellar"))
+			Ω(gotHeader.Get("Cookie")).Should(BeEmpty())
+		})
+	})
+
+	Context("with a timeout", func() {
+		BeforeEach(func() {
+			delay = 100 * time.Millisecond
+			proxy.Timeout = 10 * time.Millisecond
+		})
+
+		It("responds with a bad gateway error", func() {
+			Ω(rw.Code).Should(Equal(http.StatusBadGateway))
+		})
+	})
+})
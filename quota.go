@@ -0,0 +1,353 @@
+package goa
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// QuotaDaily is the period of the budgets reset every day at midnight UTC.
+	QuotaDaily = "daily"
+	// QuotaMonthly is the period of the budgets reset on the first day of every month at
+	// midnight UTC.
+	QuotaMonthly = "monthly"
+	// QuotaRequests is the unit of the budgets that limit the number of requests.
+	QuotaRequests = "requests"
+	// QuotaBytes is the unit of the budgets that limit the number of bytes of the request and
+	// response bodies.
+	QuotaBytes = "bytes"
+)
+
+type (
+	// QuotaLimit is a budget of a quota.
+	QuotaLimit struct {
+		// Unit is QuotaRequests or QuotaBytes.
+		Unit string
+		// Limit is the number of requests or bytes allowed per period.
+		Limit int64
+		// Period is QuotaDaily or QuotaMonthly.
+		Period string
+	}
+
+	// QuotaStatus is the usage of a quota budget by a principal.
+	QuotaStatus struct {
+		// Scope is the name of the quota the budget belongs to.
+		Scope string `json:"scope" xml:"scope"`
+		// Unit is QuotaRequests or QuotaBytes.
+		Unit string `json:"unit" xml:"unit"`
+		// Period is QuotaDaily or QuotaMonthly.
+		Period string `json:"period" xml:"period"`
+		// Limit is the number of requests or bytes allowed per period.
+		Limit int64 `json:"limit" xml:"limit"`
+		// Used is the number of requests or bytes used in the current period.
+		Used int64 `json:"used" xml:"used"`
+		// Remaining is the number of requests or bytes left in the current period.
+		Remaining int64 `json:"remaining" xml:"remaining"`
+		// Reset is the time the current period ends at.
+		Reset time.Time `json:"reset" xml:"reset"`
+	}
+
+	// QuotaStore records the usage of the quota budgets. Stores shared by multiple service
+	// instances (e.g. RedisQuotaStore) enforce the quotas across all the instances.
+	QuotaStore interface {
+		// Add adds n to the counter with the given key and returns the new value. A
+		// counter that does not exist yet is zero, counters are deleted once expired.
+		Add(ctx context.Context, key string, n int64, expires time.Time) (int64, error)
+	}
+
+	// MemoryQuotaStore is a QuotaStore that keeps the counters in memory.
+	MemoryQuotaStore struct {
+		mu        sync.Mutex
+		counters  map[string]*quotaCounter
+		lastSweep time.Time
+	}
+
+	// RedisQuotaStore is a QuotaStore that keeps the counters in Redis so that the quotas are
+	// shared by all the service instances using the same Redis server.
+	RedisQuotaStore struct {
+		// Prefix is prepended to the counter keys, "quota:" if empty.
+		Prefix string
+		client RedisScripter
+	}
+
+	// quotaCounter is a counter kept in memory.
+	quotaCounter struct {
+		value   int64
+		expires time.Time
+	}
+)
+
+// SetQuota sets the budgets of the quota with the given scope. The code generated by goagen calls
+// SetQuota for each quota defined in the design.
+func (service *Service) SetQuota(scope string, limits ...QuotaLimit) {
+	service.quotasMu.Lock()
+	defer service.quotasMu.Unlock()
+	if service.quotas == nil {
+		service.quotas = make(map[string][]QuotaLimit)
+	}
+	service.quotas[scope] = limits
+}
+
+// SetQuotaStore sets the store that records the usage of the quota budgets. The service uses a
+// MemoryQuotaStore if no store is set.
+func (service *Service) SetQuotaStore(s QuotaStore) {
+	service.quotasMu.Lock()
+	defer service.quotasMu.Unlock()
+	service.quotaStore = s
+}
+
+// QuotaUsage returns the usage of the budgets of all the quotas by the given principal sorted by
+// scope.
+func (service *Service) QuotaUsage(ctx context.Context, principal string) ([]*QuotaStatus, error) {
+	service.quotasMu.RLock()
+	scopes := make([]string, 0, len(service.quotas))
+	for s := range service.quotas {
+		scopes = append(scopes, s)
+	}
+	service.quotasMu.RUnlock()
+	sort.Strings(scopes)
+	store := service.quotaStoreOrDefault()
+	now := time.Now()
+	var statuses []*QuotaStatus
+	for _, scope := range scopes {
+		for _, l := range service.quotaLimits(scope) {
+			st, err := addQuota(ctx, store, principal, scope, l, 0, now)
+			if err != nil {
+				return nil, err
+			}
+			statuses = append(statuses, st)
+		}
+	}
+	return statuses, nil
+}
+
+// EnforceQuota returns a handler that enforces the budgets of the quota with the given scope set
+// on the service with SetQuota before calling h. The budgets are tracked per principal, see
+// QuotaPrincipal. Requests made once a budget is exhausted are rejected with a 429 (Too Many
+// Requests) response whose Retry-After header is the time left until the end of the period. The
+// handler sets the X-Quota-Limit, X-Quota-Remaining and X-Quota-Reset (Unix time at which the
+// period ends) headers on all responses, the headers list one value per budget followed by the
+// budget unit and period, e.g. "10000;unit=requests;period=daily". Requests are let through if
+// the store fails. The code generated by goagen wraps the handlers of the actions that have a
+// quota with EnforceQuota.
+func EnforceQuota(h Handler, scope string) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		service := RequestService(ctx)
+		if service == nil {
+			return h(ctx, rw, req)
+		}
+		limits := service.quotaLimits(scope)
+		if len(limits) == 0 {
+			return h(ctx, rw, req)
+		}
+		store := service.quotaStoreOrDefault()
+		principal := QuotaPrincipal(ctx, req)
+		now := time.Now()
+		statuses := make([]*QuotaStatus, len(limits))
+		var exceeded *QuotaStatus
+		for i, l := range limits {
+			var n int64
+			if l.Unit == QuotaRequests {
+				n = 1
+			}
+			st, err := addQuota(ctx, store, principal, scope, l, n, now)
+			if err != nil {
+				Error(ctx, "quota store", KV{"err", err})
+				return h(ctx, rw, req)
+			}
+			statuses[i] = st
+			if exceeded == nil && (st.Used > l.Limit || l.Unit == QuotaBytes && st.Remaining == 0) {
+				exceeded = st
+			}
+		}
+		setQuotaHeaders(rw.Header(), statuses)
+		if exceeded != nil {
+			reject(rw, 429, exceeded.Reset.Sub(now))
+			return nil
+		}
+		err := h(ctx, rw, req)
+		var size int64
+		if req.ContentLength > 0 {
+			size = req.ContentLength
+		}
+		if resp := Response(ctx); resp != nil {
+			size += int64(resp.Length)
+		}
+		for _, l := range limits {
+			if l.Unit != QuotaBytes || size == 0 {
+				continue
+			}
+			if _, serr := addQuota(ctx, store, principal, scope, l, size, now); serr != nil {
+				Error(ctx, "quota store", KV{"err", serr})
+			}
+		}
+		return err
+	}
+}
+
+// QuotaStatusHandler is the handler of the endpoint generated by goagen for the APIs that define
+// quotas. It responds with the usage of the budgets of all the quotas by the request principal.
+func QuotaStatusHandler(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+	service := RequestService(ctx)
+	if service == nil {
+		return ErrInternal("no service")
+	}
+	statuses, err := service.QuotaUsage(ctx, QuotaPrincipal(ctx, req))
+	if err != nil {
+		return ErrInternal(err)
+	}
+	if statuses == nil {
+		statuses = []*QuotaStatus{}
+	}
+	return Response(ctx).Send(ctx, http.StatusOK, statuses)
+}
+
+// QuotaPrincipal returns the name of the principal whose budgets the request uses: the principal
+// set on the context by the security middleware if any, the client IP otherwise.
+func QuotaPrincipal(ctx context.Context, req *http.Request) string {
+	if p := auditPrincipal(ctx); p != "" {
+		return p
+	}
+	return "ip:" + ClientIP(ctx, req)
+}
+
+// NewMemoryQuotaStore returns an empty in-memory store.
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{counters: make(map[string]*quotaCounter), lastSweep: time.Now()}
+}
+
+// Add adds n to the counter with the given key.
+func (s *MemoryQuotaStore) Add(_ context.Context, key string, n int64, expires time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= time.Hour {
+		for k, c := range s.counters {
+			if !now.Before(c.expires) {
+				delete(s.counters, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expires) {
+		c = &quotaCounter{}
+		s.counters[key] = c
+	}
+	c.value += n
+	c.expires = expires
+	return c.value, nil
+}
+
+// NewRedisQuotaStore returns a store that keeps the counters in Redis using the given client.
+func NewRedisQuotaStore(client RedisScripter) *RedisQuotaStore {
+	return &RedisQuotaStore{client: client}
+}
+
+// redisAddQuota adds ARGV[1] to the counter KEYS[1] which expires at ARGV[2] (Unix time in
+// milliseconds) and returns the new value.
+const redisAddQuota = `
+local n = redis.call("INCRBY", KEYS[1], ARGV[1])
+redis.call("PEXPIREAT", KEYS[1], ARGV[2])
+return n
+`
+
+// Add adds n to the counter with the given key.
+func (s *RedisQuotaStore) Add(ctx context.Context, key string, n int64, expires time.Time) (int64, error) {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "quota:"
+	}
+	ms := expires.UnixNano() / int64(time.Millisecond)
+	res, err := s.client.Eval(ctx, redisAddQuota, []string{prefix + key}, n, ms)
+	if err != nil {
+		return 0, err
+	}
+	v, ok := res.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected quota script result %v", res)
+	}
+	return v, nil
+}
+
+// quotaLimits returns the budgets of the quota with the given scope.
+func (service *Service) quotaLimits(scope string) []QuotaLimit {
+	service.quotasMu.RLock()
+	defer service.quotasMu.RUnlock()
+	return service.quotas[scope]
+}
+
+// quotaStoreOrDefault returns the quota store of the service. It creates a memory store if none
+// is set.
+func (service *Service) quotaStoreOrDefault() QuotaStore {
+	service.quotasMu.RLock()
+	s := service.quotaStore
+	service.quotasMu.RUnlock()
+	if s != nil {
+		return s
+	}
+	service.quotasMu.Lock()
+	defer service.quotasMu.Unlock()
+	if service.quotaStore == nil {
+		service.quotaStore = NewMemoryQuotaStore()
+	}
+	return service.quotaStore
+}
+
+// addQuota adds n to the usage of the given budget by principal in the period including now and
+// returns the resulting status.
+func addQuota(ctx context.Context, store QuotaStore, principal, scope string, l QuotaLimit, n int64, now time.Time) (*QuotaStatus, error) {
+	window, reset := quotaWindow(l.Period, now)
+	key := strings.Join([]string{principal, scope, l.Unit, window}, "|")
+	used, err := store.Add(ctx, key, n, reset)
+	if err != nil {
+		return nil, err
+	}
+	remaining := l.Limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &QuotaStatus{
+		Scope:     scope,
+		Unit:      l.Unit,
+		Period:    l.Period,
+		Limit:     l.Limit,
+		Used:      used,
+		Remaining: remaining,
+		Reset:     reset,
+	}, nil
+}
+
+// quotaWindow returns the name and the end of the period including now.
+func quotaWindow(period string, now time.Time) (string, time.Time) {
+	now = now.UTC()
+	if period == QuotaMonthly {
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01"), start.AddDate(0, 1, 0)
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start.Format("2006-01-02"), start.AddDate(0, 0, 1)
+}
+
+// setQuotaHeaders sets the quota headers describing the given statuses.
+func setQuotaHeaders(header http.Header, statuses []*QuotaStatus) {
+	limits := make([]string, len(statuses))
+	remaining := make([]string, len(statuses))
+	resets := make([]string, len(statuses))
+	for i, st := range statuses {
+		params := ";unit=" + st.Unit + ";period=" + st.Period
+		limits[i] = strconv.FormatInt(st.Limit, 10) + params
+		remaining[i] = strconv.FormatInt(st.Remaining, 10) + params
+		resets[i] = strconv.FormatInt(st.Reset.Unix(), 10) + params
+	}
+	header.Set("X-Quota-Limit", strings.Join(limits, ", "))
+	header.Set("X-Quota-Remaining", strings.Join(remaining, ", "))
+	header.Set("X-Quota-Reset", strings.Join(resets, ", "))
+}
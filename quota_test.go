@@ -0,0 +1,125 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("EnforceQuota", func() {
+	var service *goa.Service
+	var handler goa.Handler
+	var called int
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.SetEncoder(goa.JSONEncoderFactory(), true, "application/json")
+		called = 0
+		handler = goa.EnforceQuota(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called++
+			return goa.Response(ctx).Send(ctx, 200, "0123456789")
+		}, "reports")
+	})
+
+	serve := func(principal string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/reports", strings.NewReader(body))
+		req.RemoteAddr = "10.0.0.1:4242"
+		rw := httptest.NewRecorder()
+		ctx := goa.NewContext(nil, service, rw, req, url.Values{})
+		if principal != "" {
+			ctx = goa.WithPrincipal(ctx, principal)
+		}
+		Ω(handler(ctx, goa.Response(ctx), req)).ShouldNot(HaveOccurred())
+		return rw
+	}
+
+	It("lets requests through when no budget is set", func() {
+		Ω(serve("", "").Code).Should(Equal(200))
+		Ω(called).Should(Equal(1))
+	})
+
+	Context("with a request budget", func() {
+		BeforeEach(func() {
+			service.SetQuota("reports", goa.QuotaLimit{Unit: goa.QuotaRequests, Limit: 2, Period: goa.QuotaDaily})
+		})
+
+		It("rejects the requests made once the budget is exhausted", func() {
+			rw := serve("alice", "")
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Header().Get("X-Quota-Limit")).Should(Equal("2;unit=requests;period=daily"))
+			Ω(rw.Header().Get("X-Quota-Remaining")).Should(Equal("1;unit=requests;period=daily"))
+			Ω(serve("alice", "").Code).Should(Equal(200))
+			rw = serve("alice", "")
+			Ω(rw.Code).Should(Equal(429))
+			Ω(rw.Header().Get("Retry-After")).ShouldNot(BeEmpty())
+			Ω(rw.Header().Get("X-Quota-Remaining")).Should(Equal("0;unit=requests;period=daily"))
+			Ω(called).Should(Equal(2))
+		})
+
+		It("tracks the budgets per principal", func() {
+			serve("alice", "")
+			serve("alice", "")
+			Ω(serve("bob", "").Code).Should(Equal(200))
+			Ω(serve("", "").Code).Should(Equal(200))
+		})
+
+		It("reports the usage", func() {
+			serve("alice", "")
+			statuses, err := service.QuotaUsage(context.Background(), "alice")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(statuses).Should(HaveLen(1))
+			Ω(statuses[0].Scope).Should(Equal("reports"))
+			Ω(statuses[0].Used).Should(Equal(int64(1)))
+			Ω(statuses[0].Remaining).Should(Equal(int64(1)))
+			Ω(statuses[0].Reset.After(time.Now())).Should(BeTrue())
+		})
+	})
+
+	Context("with a byte budget", func() {
+		BeforeEach(func() {
+			service.SetQuota("reports", goa.QuotaLimit{Unit: goa.QuotaBytes, Limit: 20, Period: goa.QuotaMonthly})
+		})
+
+		It("counts the request and response bodies", func() {
+			Ω(serve("alice", "abcde").Code).Should(Equal(200))
+			statuses, err := service.QuotaUsage(context.Background(), "alice")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(statuses[0].Used).Should(BeNumerically(">=", 15))
+			Ω(serve("alice", "abcde").Code).Should(Equal(200))
+			Ω(serve("alice", "").Code).Should(Equal(429))
+			Ω(called).Should(Equal(2))
+		})
+	})
+})
+
+var _ = Describe("MemoryQuotaStore", func() {
+	It("resets expired counters", func() {
+		store := goa.NewMemoryQuotaStore()
+		n, err := store.Add(context.Background(), "k", 3, time.Now().Add(time.Hour))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(n).Should(Equal(int64(3)))
+		n, _ = store.Add(context.Background(), "k", 2, time.Now().Add(-time.Second))
+		Ω(n).Should(Equal(int64(5)))
+		n, _ = store.Add(context.Background(), "k", 1, time.Now().Add(time.Hour))
+		Ω(n).Should(Equal(int64(1)))
+	})
+})
+
+var _ = Describe("RedisQuotaStore", func() {
+	It("runs the counter script", func() {
+		scripter := &fakeScripter{result: int64(7)}
+		expires := time.Unix(1500000000, 0)
+		n, err := goa.NewRedisQuotaStore(scripter).Add(context.Background(), "k", 2, expires)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(n).Should(Equal(int64(7)))
+		Ω(scripter.keys).Should(Equal([]string{"quota:k"}))
+		Ω(scripter.args).Should(Equal([]interface{}{int64(2), int64(1500000000000)}))
+	})
+})
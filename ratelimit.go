@@ -0,0 +1,541 @@
+package goa
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// RateLimitTokenBucket is the algorithm of the rate limits that allow bursts of up to the
+	// limit and refill the bucket of each client continuously, see TokenBucket.
+	RateLimitTokenBucket = "token-bucket"
+	// RateLimitSlidingWindow is the algorithm of the rate limits that count the requests made
+	// by each client in the period preceding each request, see SlidingWindow.
+	RateLimitSlidingWindow = "sliding-window"
+)
+
+type (
+	// RateLimitPolicy is a rate limit set on the service with SetRateLimit.
+	RateLimitPolicy struct {
+		// Limit is the number of requests allowed per period.
+		Limit int
+		// Period is the duration of the period.
+		Period time.Duration
+		// Algorithm is RateLimitTokenBucket or RateLimitSlidingWindow, RateLimitTokenBucket
+		// if empty.
+		Algorithm string
+		// RetryAfter computes the Retry-After header of the rejected requests given the
+		// number of consecutive rejections of the client. The header is the time left until
+		// the next request is allowed if RetryAfter is nil or computes no delay.
+		RetryAfter *RetryAfterPolicy
+	}
+
+	// RateLimitKeyFunc computes the key of the token bucket a request draws from, requests with
+	// the same key share the same bucket.
+	RateLimitKeyFunc func(ctx context.Context, req *http.Request) string
+
+	// RateLimitStore holds the token buckets used by the TokenBucket middleware. Stores shared
+	// by multiple service instances (e.g. RedisRateLimitStore) enforce the limit across all the
+	// instances.
+	RateLimitStore interface {
+		// Take takes a token from the bucket with the given key. The bucket holds at most
+		// limit tokens and is refilled at the rate of limit tokens per period, a bucket
+		// that does not exist yet is full.
+		Take(ctx context.Context, key string, limit int, period time.Duration) (*RateLimitStatus, error)
+	}
+
+	// RateLimitStatus is the state of a token bucket after a call to Take.
+	RateLimitStatus struct {
+		// Allowed is true if a token was taken from the bucket.
+		Allowed bool
+		// Remaining is the number of tokens left in the bucket.
+		Remaining int
+		// Reset is the time left until the bucket is full again.
+		Reset time.Duration
+		// RetryAfter is the time left until the next token is available if Allowed is
+		// false.
+		RetryAfter time.Duration
+		// Rejected is the number of consecutive requests rejected before this one if
+		// Allowed is false.
+		Rejected int
+	}
+
+	// SlidingWindowStore is implemented by the rate limit stores that support the sliding
+	// window algorithm. The window of a client is approximated by weighting the number of
+	// requests made in the previous fixed period with the part of the previous period the
+	// window overlaps.
+	SlidingWindowStore interface {
+		// TakeWindow counts a request in the window with the given key if the window
+		// holds less than limit requests made in the period preceding now.
+		TakeWindow(ctx context.Context, key string, limit int, period time.Duration) (*RateLimitStatus, error)
+	}
+
+	// MemoryRateLimitStore is a RateLimitStore and a SlidingWindowStore that keeps the buckets
+	// and windows in memory.
+	MemoryRateLimitStore struct {
+		mu        sync.Mutex
+		buckets   map[string]*bucket
+		windows   map[string]*window
+		lastSweep time.Time
+	}
+
+	// RedisScripter is implemented by Redis clients that can evaluate Lua scripts. Adapting
+	// the client library used by the service to this interface makes it possible to use
+	// RedisRateLimitStore without tying goa to a specific library.
+	RedisScripter interface {
+		// Eval evaluates the given script with the given keys and arguments and returns
+		// its result.
+		Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+	}
+
+	// RedisRateLimitStore is a RateLimitStore and a SlidingWindowStore that keeps the buckets
+	// and windows in Redis so that the limit is shared by all the service instances using the
+	// same Redis server.
+	RedisRateLimitStore struct {
+		// Prefix is prepended to the bucket keys, "ratelimit:" if empty.
+		Prefix string
+		client RedisScripter
+	}
+
+	// bucket is a token bucket kept in memory.
+	bucket struct {
+		tokens   float64
+		last     time.Time
+		rejected int
+	}
+
+	// window is a sliding window kept in memory: the number of requests counted in the fixed
+	// period starting at start and in the period before.
+	window struct {
+		start      time.Time
+		prev, curr int
+		rejected   int
+	}
+)
+
+// TokenBucket returns a middleware that limits the rate of requests using a token bucket per key:
+// each bucket allows bursts of up to limit requests and is refilled at the rate of limit requests
+// per period. The key of a request is computed with the given function, ClientIP if nil. The
+// buckets are kept in the given store, a new MemoryRateLimitStore if nil.
+// The middleware sets the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (Unix
+// time at which the bucket is full again) headers on all responses. Requests in excess are
+// rejected with a 429 (Too Many Requests) response whose Retry-After header is the time left
+// until the next token is available. Requests are let through if the store fails.
+func TokenBucket(limit int, period time.Duration, key RateLimitKeyFunc, store RateLimitStore) Middleware {
+	if key == nil {
+		key = ClientIP
+	}
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			status, err := store.Take(ctx, key(ctx, req), limit, period)
+			return limitRate(ctx, rw, req, h, limit, nil, status, err)
+		}
+	}
+}
+
+// SlidingWindow returns a middleware that limits the rate of requests using a sliding window per
+// key: each window allows up to limit requests in the period preceding each request. The key
+// function and the store default to ClientIP and a new MemoryRateLimitStore respectively. The
+// middleware sets the same headers as TokenBucket and rejects the requests in excess the same way.
+func SlidingWindow(limit int, period time.Duration, key RateLimitKeyFunc, store SlidingWindowStore) Middleware {
+	if key == nil {
+		key = ClientIP
+	}
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			status, err := store.TakeWindow(ctx, key(ctx, req), limit, period)
+			return limitRate(ctx, rw, req, h, limit, nil, status, err)
+		}
+	}
+}
+
+// SetRateLimit sets the rate limit with the given scope. The code generated by goagen calls
+// SetRateLimit for each rate limit defined in the design.
+func (service *Service) SetRateLimit(scope string, policy RateLimitPolicy) {
+	service.rateMu.Lock()
+	defer service.rateMu.Unlock()
+	if service.rateLimits == nil {
+		service.rateLimits = make(map[string]RateLimitPolicy)
+	}
+	service.rateLimits[scope] = policy
+}
+
+// SetRateLimitStore sets the store that tracks the clients of the rate limits. The store must
+// implement SlidingWindowStore if a rate limit uses the sliding window algorithm. The service uses
+// a MemoryRateLimitStore if no store is set.
+func (service *Service) SetRateLimitStore(s RateLimitStore) {
+	service.rateMu.Lock()
+	defer service.rateMu.Unlock()
+	service.rateStore = s
+}
+
+// EnforceRateLimit returns a handler that enforces the rate limit with the given scope set on the
+// service with SetRateLimit before calling h. The limit applies to each principal separately, see
+// QuotaPrincipal. The handler sets the X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset headers on all responses and rejects the requests in excess with a 429 (Too
+// Many Requests) response whose Retry-After header is computed by the policy RetryAfter, see
+// RateLimitPolicy. Requests are let through if the store fails. The code generated by goagen wraps the
+// handlers of the actions that have a rate limit with EnforceRateLimit.
+func EnforceRateLimit(h Handler, scope string) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		service := RequestService(ctx)
+		if service == nil {
+			return h(ctx, rw, req)
+		}
+		service.rateMu.RLock()
+		policy, ok := service.rateLimits[scope]
+		service.rateMu.RUnlock()
+		if !ok {
+			return h(ctx, rw, req)
+		}
+		store := service.rateLimitStoreOrDefault()
+		key := scope + ":" + QuotaPrincipal(ctx, req)
+		var status *RateLimitStatus
+		var err error
+		if policy.Algorithm == RateLimitSlidingWindow {
+			if sw, ok := store.(SlidingWindowStore); ok {
+				status, err = sw.TakeWindow(ctx, key, policy.Limit, policy.Period)
+			} else {
+				err = fmt.Errorf("rate limit store %T does not support sliding windows", store)
+			}
+		} else {
+			status, err = store.Take(ctx, key, policy.Limit, policy.Period)
+		}
+		return limitRate(ctx, rw, req, h, policy.Limit, policy.RetryAfter, status, err)
+	}
+}
+
+// rateLimitStoreOrDefault returns the rate limit store of the service. It creates a memory store if
+// none is set.
+func (service *Service) rateLimitStoreOrDefault() RateLimitStore {
+	service.rateMu.RLock()
+	s := service.rateStore
+	service.rateMu.RUnlock()
+	if s != nil {
+		return s
+	}
+	service.rateMu.Lock()
+	defer service.rateMu.Unlock()
+	if service.rateStore == nil {
+		service.rateStore = NewMemoryRateLimitStore()
+	}
+	return service.rateStore
+}
+
+// limitRate sets the rate limit headers describing the given status and calls h if the status
+// allows the request. It rejects the request otherwise, the Retry-After header is computed by the
+// given policy if not nil and the status otherwise. limitRate calls h if the store returned an
+// error.
+func limitRate(ctx context.Context, rw http.ResponseWriter, req *http.Request, h Handler, limit int, policy *RetryAfterPolicy, status *RateLimitStatus, err error) error {
+	if err != nil {
+		Error(ctx, "rate limit store", KV{"err", err})
+		return h(ctx, rw, req)
+	}
+	header := rw.Header()
+	header.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(status.Remaining))
+	reset := time.Now().Add(status.Reset)
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(float64(reset.UnixNano())/1e9)), 10))
+	if !status.Allowed {
+		delay := status.RetryAfter
+		if policy != nil {
+			if d := policy.Duration(ctx, status.Rejected); d > 0 {
+				delay = d
+			}
+		}
+		reject(rw, 429, delay)
+		return nil
+	}
+	return h(ctx, rw, req)
+}
+
+// ClientIP returns the IP address of the client that made the request: the address resolved by
+// the TrustProxies middleware if any, the request remote address otherwise. It is the default
+// TokenBucket key function.
+func ClientIP(ctx context.Context, req *http.Request) string {
+	if ip := ContextClientAddr(ctx); ip != nil {
+		return ip.String()
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// NewMemoryRateLimitStore returns an empty in-memory store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*bucket),
+		windows:   make(map[string]*window),
+		lastSweep: time.Now(),
+	}
+}
+
+// Take takes a token from the bucket with the given key.
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limit int, period time.Duration) (*RateLimitStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	rate := float64(limit) / float64(period)
+	if now.Sub(s.lastSweep) >= period {
+		// Full buckets are equivalent to missing buckets, drop them so that the store
+		// does not grow with the number of clients.
+		for k, b := range s.buckets {
+			if b.tokens+float64(now.Sub(b.last))*rate >= float64(limit) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit), last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit), b.tokens+float64(now.Sub(b.last))*rate)
+	b.last = now
+	return countRejection(&b.rejected, takeToken(&b.tokens, limit, rate)), nil
+}
+
+// TakeWindow counts a request in the window with the given key if the window is not full.
+func (s *MemoryRateLimitStore) TakeWindow(ctx context.Context, key string, limit int, period time.Duration) (*RateLimitStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	start := now.Truncate(period)
+	if now.Sub(s.lastSweep) >= period {
+		// Windows that ended before the previous period are empty, drop them.
+		for k, w := range s.windows {
+			if w.start.Before(start.Add(-period)) {
+				delete(s.windows, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	w, ok := s.windows[key]
+	if !ok {
+		w = &window{start: start}
+		s.windows[key] = w
+	}
+	if d := start.Sub(w.start); d == period {
+		w.prev, w.curr = w.curr, 0
+	} else if d > period {
+		w.prev, w.curr = 0, 0
+	}
+	w.start = start
+	elapsed := now.Sub(start)
+	allowed := weightedCount(w.prev, w.curr, period, elapsed)+1 <= float64(limit)
+	if allowed {
+		w.curr++
+	}
+	return countRejection(&w.rejected, windowStatus(allowed, w.prev, w.curr, limit, period, elapsed)), nil
+}
+
+// weightedCount returns the approximate number of requests made in the sliding window given the
+// number of requests counted in the previous and current fixed periods and the time elapsed since
+// the start of the current period.
+func weightedCount(prev, curr int, period, elapsed time.Duration) float64 {
+	return float64(prev)*(1-float64(elapsed)/float64(period)) + float64(curr)
+}
+
+// windowStatus returns the status of a sliding window given whether the request was allowed, the
+// number of requests counted in the previous and current fixed periods including the request and
+// the time elapsed since the start of the current period.
+func windowStatus(allowed bool, prev, curr, limit int, period, elapsed time.Duration) *RateLimitStatus {
+	count := weightedCount(prev, curr, period, elapsed)
+	left := period - elapsed
+	status := &RateLimitStatus{Allowed: allowed, Remaining: int(math.Max(0, float64(limit)-count)), Reset: left}
+	if curr > 0 {
+		status.Reset += period
+	}
+	if allowed {
+		return status
+	}
+	excess := count + 1 - float64(limit)
+	switch {
+	case prev > 0 && excess <= float64(prev)*float64(left)/float64(period):
+		// The requests of the previous period leave the window soon enough.
+		status.RetryAfter = time.Duration(math.Ceil(excess / float64(prev) * float64(period)))
+	case curr > 0:
+		status.RetryAfter = left + time.Duration(math.Ceil(float64(period)*(1-float64(limit-1)/float64(curr))))
+	default:
+		status.RetryAfter = left
+	}
+	return status
+}
+
+// countRejection sets the number of consecutive rejections of the given status and updates the
+// count of a bucket or window accordingly.
+func countRejection(rejected *int, status *RateLimitStatus) *RateLimitStatus {
+	if status.Allowed {
+		*rejected = 0
+		return status
+	}
+	status.Rejected = *rejected
+	*rejected++
+	return status
+}
+
+// takeToken takes a token from a bucket holding the given number of tokens refilled at the given
+// rate (tokens per nanosecond) and returns the resulting status.
+func takeToken(tokens *float64, limit int, rate float64) *RateLimitStatus {
+	status := &RateLimitStatus{}
+	if *tokens >= 1 {
+		*tokens--
+		status.Allowed = true
+	} else {
+		status.RetryAfter = time.Duration(math.Ceil((1 - *tokens) / rate))
+	}
+	status.Remaining = int(*tokens)
+	status.Reset = time.Duration(math.Ceil((float64(limit) - *tokens) / rate))
+	return status
+}
+
+// NewRedisRateLimitStore returns a store that keeps the buckets in Redis using the given client.
+func NewRedisRateLimitStore(client RedisScripter) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client}
+}
+
+// redisTokenBucket is the Lua script that takes a token from a bucket stored in Redis. The bucket
+// is stored in a hash holding the number of tokens, the time of the last update in milliseconds
+// and the number of consecutive rejections, it expires once full. The script returns whether a
+// token was taken, the number of remaining tokens, the reset and retry delays in milliseconds and
+// the number of consecutive rejections that preceded the request.
+const redisTokenBucket = `
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local rate = limit / period
+local b = redis.call("HMGET", KEYS[1], "tokens", "ts", "rejected")
+local tokens = tonumber(b[1]) or limit
+local ts = tonumber(b[2]) or now
+local rejected = tonumber(b[3]) or 0
+tokens = math.min(limit, tokens + math.max(0, now - ts) * rate)
+local allowed, retry, prior = 0, 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+	rejected = 0
+else
+	retry = math.ceil((1 - tokens) / rate)
+	prior = rejected
+	rejected = rejected + 1
+end
+local reset = math.ceil((limit - tokens) / rate)
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", now, "rejected", rejected)
+redis.call("PEXPIRE", KEYS[1], reset + 1)
+return {allowed, math.floor(tokens), reset, retry, prior}
+`
+
+// Take takes a token from the bucket with the given key.
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, limit int, period time.Duration) (*RateLimitStatus, error) {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "ratelimit:"
+	}
+	ms := int64(period / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	res, err := s.client.Eval(ctx, redisTokenBucket, []string{prefix + key}, limit, ms, now)
+	if err != nil {
+		return nil, err
+	}
+	vals, ok := res.([]interface{})
+	if !ok || len(vals) != 5 {
+		return nil, fmt.Errorf("unexpected rate limit script result %v", res)
+	}
+	ints := make([]int64, 5)
+	for i, v := range vals {
+		if ints[i], ok = v.(int64); !ok {
+			return nil, fmt.Errorf("unexpected rate limit script result %v", res)
+		}
+	}
+	return &RateLimitStatus{
+		Allowed:    ints[0] == 1,
+		Remaining:  int(ints[1]),
+		Reset:      time.Duration(ints[2]) * time.Millisecond,
+		RetryAfter: time.Duration(ints[3]) * time.Millisecond,
+		Rejected:   int(ints[4]),
+	}, nil
+}
+
+// redisSlidingWindow is the Lua script that counts a request in a sliding window stored in Redis.
+// KEYS[1] and KEYS[2] are the counters of the previous and current fixed periods, KEYS[3] the
+// number of consecutive rejections, ARGV[1] the limit, ARGV[2] the weight of the previous period
+// and ARGV[3] the period in milliseconds. The script returns whether the request was counted, the
+// values of the two counters and the number of consecutive rejections that preceded the request.
+const redisSlidingWindow = `
+local limit = tonumber(ARGV[1])
+local weight = tonumber(ARGV[2])
+local prev = tonumber(redis.call("GET", KEYS[1])) or 0
+local curr = tonumber(redis.call("GET", KEYS[2])) or 0
+local allowed, rejected = 0, 0
+if prev * weight + curr + 1 <= limit then
+	curr = redis.call("INCR", KEYS[2])
+	redis.call("PEXPIRE", KEYS[2], 2 * tonumber(ARGV[3]))
+	redis.call("DEL", KEYS[3])
+	allowed = 1
+else
+	rejected = redis.call("INCR", KEYS[3]) - 1
+	redis.call("PEXPIRE", KEYS[3], 2 * tonumber(ARGV[3]))
+end
+return {allowed, prev, curr, rejected}
+`
+
+// TakeWindow counts a request in the window with the given key if the window is not full.
+func (s *RedisRateLimitStore) TakeWindow(ctx context.Context, key string, limit int, period time.Duration) (*RateLimitStatus, error) {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "ratelimit:"
+	}
+	ms := int64(period / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	idx := now / ms
+	elapsed := now - idx*ms
+	keys := []string{
+		fmt.Sprintf("%s%s:%d", prefix, key, idx-1),
+		fmt.Sprintf("%s%s:%d", prefix, key, idx),
+		fmt.Sprintf("%s%s:rejected", prefix, key),
+	}
+	weight := strconv.FormatFloat(1-float64(elapsed)/float64(ms), 'f', -1, 64)
+	res, err := s.client.Eval(ctx, redisSlidingWindow, keys, limit, weight, ms)
+	if err != nil {
+		return nil, err
+	}
+	vals, ok := res.([]interface{})
+	if !ok || len(vals) != 4 {
+		return nil, fmt.Errorf("unexpected rate limit script result %v", res)
+	}
+	ints := make([]int64, 4)
+	for i, v := range vals {
+		if ints[i], ok = v.(int64); !ok {
+			return nil, fmt.Errorf("unexpected rate limit script result %v", res)
+		}
+	}
+	p := time.Duration(ms) * time.Millisecond
+	e := time.Duration(elapsed) * time.Millisecond
+	status := windowStatus(ints[0] == 1, int(ints[1]), int(ints[2]), limit, p, e)
+	if !status.Allowed {
+		status.Rejected = int(ints[3])
+	}
+	return status, nil
+}
@@ -0,0 +1,244 @@
+package goa_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+// fakeScripter records the keys and arguments of the scripts it evaluates.
+type fakeScripter struct {
+	keys   []string
+	args   []interface{}
+	result interface{}
+	err    error
+}
+
+func (f *fakeScripter) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	f.keys, f.args = keys, args
+	return f.result, f.err
+}
+
+var _ = Describe("TokenBucket", func() {
+	var store goa.RateLimitStore
+	var handler goa.Handler
+
+	BeforeEach(func() {
+		store = nil
+	})
+
+	JustBeforeEach(func() {
+		ok := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.WriteHeader(200)
+			return nil
+		}
+		handler = goa.TokenBucket(2, time.Hour, nil, store)(ok)
+	})
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		rw := httptest.NewRecorder()
+		Ω(handler(context.Background(), rw, req)).ShouldNot(HaveOccurred())
+		return rw
+	}
+
+	It("allows bursts up to the limit and rejects the requests in excess", func() {
+		statuses := make([]int, 3)
+		remaining := make([]string, 3)
+		var rw *httptest.ResponseRecorder
+		for i := range statuses {
+			rw = serve("10.0.0.1:1234")
+			statuses[i] = rw.Code
+			remaining[i] = rw.Header().Get("X-RateLimit-Remaining")
+		}
+		Ω(statuses).Should(Equal([]int{200, 200, 429}))
+		Ω(remaining).Should(Equal([]string{"1", "0", "0"}))
+		Ω(rw.Header().Get("X-RateLimit-Limit")).Should(Equal("2"))
+		Ω(rw.Header().Get("X-RateLimit-Reset")).ShouldNot(BeEmpty())
+		Ω(rw.Header().Get("Retry-After")).Should(Equal("1800"))
+	})
+
+	It("uses one bucket per client IP", func() {
+		serve("10.0.0.1:1234")
+		serve("10.0.0.1:1235")
+		Ω(serve("10.0.0.1:1236").Code).Should(Equal(429))
+		Ω(serve("10.0.0.2:1234").Code).Should(Equal(200))
+	})
+
+	Context("with a Redis store", func() {
+		var scripter *fakeScripter
+
+		BeforeEach(func() {
+			scripter = &fakeScripter{result: []interface{}{int64(0), int64(0), int64(5000), int64(1500), int64(0)}}
+			store = goa.NewRedisRateLimitStore(scripter)
+		})
+
+		It("uses the state returned by the script", func() {
+			rw := serve("10.0.0.1:1234")
+			Ω(rw.Code).Should(Equal(429))
+			Ω(rw.Header().Get("Retry-After")).Should(Equal("2"))
+			Ω(scripter.keys).Should(Equal([]string{"ratelimit:10.0.0.1"}))
+			Ω(scripter.args[:2]).Should(Equal([]interface{}{2, int64(3600000)}))
+		})
+
+		Context("that fails", func() {
+			BeforeEach(func() {
+				scripter.err = errors.New("connection refused")
+			})
+
+			It("lets the requests through", func() {
+				rw := serve("10.0.0.1:1234")
+				Ω(rw.Code).Should(Equal(200))
+				Ω(rw.Header().Get("X-RateLimit-Limit")).Should(BeEmpty())
+			})
+		})
+	})
+})
+
+var _ = Describe("SlidingWindow", func() {
+	var store goa.SlidingWindowStore
+	var handler goa.Handler
+
+	BeforeEach(func() {
+		store = nil
+	})
+
+	JustBeforeEach(func() {
+		ok := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.WriteHeader(200)
+			return nil
+		}
+		handler = goa.SlidingWindow(2, time.Hour, nil, store)(ok)
+	})
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		rw := httptest.NewRecorder()
+		Ω(handler(context.Background(), rw, req)).ShouldNot(HaveOccurred())
+		return rw
+	}
+
+	It("rejects the requests in excess of the limit in the window", func() {
+		Ω(serve("10.0.0.1:1234").Code).Should(Equal(200))
+		rw := serve("10.0.0.1:1234")
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("X-RateLimit-Remaining")).Should(Equal("0"))
+		rw = serve("10.0.0.1:1234")
+		Ω(rw.Code).Should(Equal(429))
+		Ω(rw.Header().Get("Retry-After")).ShouldNot(BeEmpty())
+		Ω(serve("10.0.0.2:1234").Code).Should(Equal(200))
+	})
+
+	Context("with a Redis store", func() {
+		var scripter *fakeScripter
+
+		BeforeEach(func() {
+			scripter = &fakeScripter{result: []interface{}{int64(1), int64(0), int64(1), int64(0)}}
+			store = goa.NewRedisRateLimitStore(scripter)
+		})
+
+		It("counts the request in the current period", func() {
+			rw := serve("10.0.0.1:1234")
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Header().Get("X-RateLimit-Remaining")).Should(Equal("1"))
+			Ω(scripter.keys).Should(HaveLen(3))
+			Ω(scripter.keys[0]).Should(HavePrefix("ratelimit:10.0.0.1:"))
+			Ω(scripter.keys[2]).Should(Equal("ratelimit:10.0.0.1:rejected"))
+			Ω(scripter.args[0]).Should(Equal(2))
+			Ω(scripter.args[2]).Should(Equal(int64(3600000)))
+		})
+	})
+})
+
+var _ = Describe("EnforceRateLimit", func() {
+	var service *goa.Service
+	var handler goa.Handler
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		handler = goa.EnforceRateLimit(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.WriteHeader(200)
+			return nil
+		}, "search")
+	})
+
+	serve := func(principal string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/search", nil)
+		req.RemoteAddr = "10.0.0.1:4242"
+		rw := httptest.NewRecorder()
+		ctx := goa.NewContext(nil, service, rw, req, url.Values{})
+		if principal != "" {
+			ctx = goa.WithPrincipal(ctx, principal)
+		}
+		Ω(handler(ctx, rw, req)).ShouldNot(HaveOccurred())
+		return rw
+	}
+
+	It("lets requests through when no rate limit is set", func() {
+		Ω(serve("").Code).Should(Equal(200))
+		Ω(serve("").Header().Get("X-RateLimit-Limit")).Should(BeEmpty())
+	})
+
+	for _, algorithm := range []string{goa.RateLimitTokenBucket, goa.RateLimitSlidingWindow} {
+		algorithm := algorithm
+
+		Context("with a "+algorithm+" rate limit", func() {
+			BeforeEach(func() {
+				service.SetRateLimit("search", goa.RateLimitPolicy{Limit: 1, Period: time.Minute, Algorithm: algorithm})
+			})
+
+			It("limits each principal separately", func() {
+				rw := serve("alice")
+				Ω(rw.Code).Should(Equal(200))
+				Ω(rw.Header().Get("X-RateLimit-Limit")).Should(Equal("1"))
+				Ω(serve("alice").Code).Should(Equal(429))
+				Ω(serve("bob").Code).Should(Equal(200))
+				Ω(serve("").Code).Should(Equal(200))
+			})
+		})
+	}
+
+	Context("with a Retry-After policy", func() {
+		BeforeEach(func() {
+			policy := &goa.RetryAfterPolicy{Strategy: goa.RetryAfterExponential, Delay: time.Second}
+			service.SetRateLimit("search", goa.RateLimitPolicy{Limit: 1, Period: time.Hour, RetryAfter: policy})
+		})
+
+		It("computes the Retry-After header from the consecutive rejections", func() {
+			Ω(serve("alice").Code).Should(Equal(200))
+			Ω(serve("alice").Header().Get("Retry-After")).Should(Equal("1"))
+			Ω(serve("alice").Header().Get("Retry-After")).Should(Equal("2"))
+			Ω(serve("alice").Header().Get("Retry-After")).Should(Equal("4"))
+		})
+	})
+
+	Context("with a store that does not support sliding windows", func() {
+		BeforeEach(func() {
+			service.SetRateLimit("search", goa.RateLimitPolicy{Limit: 1, Period: time.Minute, Algorithm: goa.RateLimitSlidingWindow})
+			service.SetRateLimitStore(tokenBucketOnly{goa.NewMemoryRateLimitStore()})
+		})
+
+		It("lets the requests through", func() {
+			serve("alice")
+			Ω(serve("alice").Code).Should(Equal(200))
+		})
+	})
+})
+
+// tokenBucketOnly is a RateLimitStore that does not implement SlidingWindowStore.
+type tokenBucketOnly struct {
+	store goa.RateLimitStore
+}
+
+func (t tokenBucketOnly) Take(ctx context.Context, key string, limit int, period time.Duration) (*goa.RateLimitStatus, error) {
+	return t.store.Take(ctx, key, limit, period)
+}
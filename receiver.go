@@ -0,0 +1,287 @@
+package goa
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// List of supported inbound webhook providers.
+const (
+	// WebhookProviderGitHub verifies GitHub style requests: the X-Hub-Signature-256 header
+	// holds the hex encoded HMAC SHA-256 of the body prefixed with "sha256=", the X-GitHub-Event
+	// and X-GitHub-Delivery headers hold the event name and delivery ID.
+	WebhookProviderGitHub = "github"
+	// WebhookProviderStripe verifies Stripe style requests: the Stripe-Signature header holds
+	// the request timestamp and the hex encoded HMAC SHA-256 of the timestamp and body, e.g.
+	// "t=1492774577,v1=5257a869...". The body is a JSON object whose "id", "type" and
+	// "data.object" fields hold the delivery ID, the event name and the event payload.
+	WebhookProviderStripe = "stripe"
+	// WebhookProviderHMAC verifies the requests sent by goa webhook dispatchers, see
+	// WebhookDispatcher.
+	WebhookProviderHMAC = "hmac"
+)
+
+// DefaultWebhookTolerance is the default maximum age of the timestamp of signed Stripe requests.
+const DefaultWebhookTolerance = 5 * time.Minute
+
+type (
+	// WebhookReceiver verifies the signature of the requests made to an inbound webhook endpoint
+	// and decodes their event envelope. The code generated by goagen for actions that use the
+	// Webhook DSL creates a receiver for each such action and mounts its middleware, the
+	// receiver secret must be set prior to serving requests.
+	WebhookReceiver struct {
+		// Provider is one of WebhookProviderGitHub, WebhookProviderStripe or
+		// WebhookProviderHMAC.
+		Provider string
+		// SigningScheme is the scheme used to sign the requests of the HMAC provider, one of
+		// SigningHMACSHA1, SigningHMACSHA256 or SigningHMACSHA512.
+		SigningScheme string
+		// SignatureHeader is the name of the header holding the request signature.
+		SignatureHeader string
+		// EventHeader is the name of the header holding the event name, not used by the
+		// Stripe provider.
+		EventHeader string
+		// DeliveryHeader is the name of the header holding the delivery ID, not used by the
+		// Stripe provider.
+		DeliveryHeader string
+		// Secret is the key used to sign the requests.
+		Secret []byte
+		// Tolerance is the maximum age of the timestamp of Stripe requests, zero disables
+		// the check.
+		Tolerance time.Duration
+	}
+
+	// WebhookEnvelope contains the event received by an inbound webhook endpoint.
+	WebhookEnvelope struct {
+		// ID is the delivery ID, it is the same for all the deliveries of an event.
+		ID string
+		// Event is the event name.
+		Event string
+		// Received is the time the request was received.
+		Received time.Time
+		// Payload is the raw event payload.
+		Payload json.RawMessage
+	}
+
+	// WebhookDeduper is implemented by the stores that keep track of the webhook deliveries
+	// already processed so that events delivered more than once are handled once.
+	WebhookDeduper interface {
+		// Claim records the delivery with the given ID and returns true if it was not
+		// already recorded.
+		Claim(ctx context.Context, id string) (bool, error)
+		// Release forgets the delivery with the given ID so that it can be processed again,
+		// it is called when handling the delivery fails.
+		Release(ctx context.Context, id string) error
+	}
+
+	// MemoryDeduper is an in-memory WebhookDeduper that remembers deliveries for a limited time.
+	MemoryDeduper struct {
+		// TTL is the duration deliveries are remembered for, zero means forever.
+		TTL time.Duration
+
+		mu     sync.Mutex
+		claims map[string]time.Time
+	}
+)
+
+// NewWebhookReceiver returns a receiver for the given provider initialized with the provider
+// default headers.
+func NewWebhookReceiver(provider string, secret []byte) *WebhookReceiver {
+	r := &WebhookReceiver{Provider: provider, Secret: secret}
+	switch provider {
+	case WebhookProviderGitHub:
+		r.SigningScheme = SigningHMACSHA256
+		r.SignatureHeader = "X-Hub-Signature-256"
+		r.EventHeader = "X-GitHub-Event"
+		r.DeliveryHeader = "X-GitHub-Delivery"
+	case WebhookProviderStripe:
+		r.SigningScheme = SigningHMACSHA256
+		r.SignatureHeader = "Stripe-Signature"
+		r.Tolerance = DefaultWebhookTolerance
+	default:
+		r.SigningScheme = SigningHMACSHA256
+		r.SignatureHeader = "X-Webhook-Signature"
+		r.EventHeader = "X-Webhook-Event"
+		r.DeliveryHeader = "X-Webhook-Delivery"
+	}
+	return r
+}
+
+// Middleware returns a middleware that verifies the request signature and decodes the request
+// event envelope. Requests whose signature is missing or invalid are rejected with a 401 response,
+// requests whose envelope cannot be decoded with a 400 response. The envelope of valid requests is
+// available to the handlers via ContextWebhookEnvelope.
+func (r *WebhookReceiver) Middleware() Middleware {
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			body, err := ioutil.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return NewBadRequestError(err)
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if err := r.Verify(req.Header, body, time.Now()); err != nil {
+				IncrCounter([]string{"goa", "webhook", r.Provider, "rejected"}, 1.0)
+				return Response(ctx).Send(ctx, http.StatusUnauthorized, err.Error())
+			}
+			env, err := r.Envelope(req.Header, body)
+			if err != nil {
+				return NewBadRequestError(err)
+			}
+			IncrCounter([]string{"goa", "webhook", r.Provider, "received"}, 1.0)
+			return h(context.WithValue(ctx, webhookEnvelopeKey, env), rw, req)
+		}
+	}
+}
+
+// Verify checks the signature of the request with the given headers and body. now is used to
+// check the age of the timestamp of Stripe requests.
+func (r *WebhookReceiver) Verify(header http.Header, body []byte, now time.Time) error {
+	if len(r.Secret) == 0 {
+		return errors.New("webhook secret is not set")
+	}
+	signature := header.Get(r.SignatureHeader)
+	if signature == "" {
+		return fmt.Errorf("missing signature header %s", r.SignatureHeader)
+	}
+	if r.Provider == WebhookProviderStripe {
+		return VerifyStripeSignature(r.Secret, body, signature, r.Tolerance, now)
+	}
+	if !VerifyWebhookSignature(r.SigningScheme, r.Secret, body, signature) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// Envelope decodes the event envelope of the request with the given headers and body.
+func (r *WebhookReceiver) Envelope(header http.Header, body []byte) (*WebhookEnvelope, error) {
+	env := &WebhookEnvelope{Received: time.Now()}
+	if r.Provider != WebhookProviderStripe {
+		env.ID = header.Get(r.DeliveryHeader)
+		env.Event = header.Get(r.EventHeader)
+		env.Payload = json.RawMessage(body)
+	} else {
+		var event struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+			Data struct {
+				Object json.RawMessage `json:"object"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			return nil, fmt.Errorf("invalid event: %s", err)
+		}
+		env.ID, env.Event, env.Payload = event.ID, event.Type, event.Data.Object
+	}
+	if env.Event == "" {
+		return nil, errors.New("missing event name")
+	}
+	return env, nil
+}
+
+// VerifyStripeSignature checks a Stripe-Signature header value against the request body. The
+// signature is valid if one of its v1 values is the HMAC SHA-256 of the timestamp and body and,
+// if tolerance is not zero, the timestamp is not older than tolerance.
+func VerifyStripeSignature(secret, body []byte, header string, tolerance time.Duration, now time.Time) error {
+	var ts string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+	secs, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("invalid signature timestamp")
+	}
+	if tolerance > 0 && now.Sub(time.Unix(secs, 0)) > tolerance {
+		return errors.New("signature timestamp is too old")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	for _, s := range signatures {
+		if hmac.Equal([]byte(expected), []byte(s)) {
+			return nil
+		}
+	}
+	return errors.New("invalid signature")
+}
+
+// ContextWebhookEnvelope returns the event envelope of the webhook request with the given context,
+// nil if the request was not verified by a WebhookReceiver middleware.
+func ContextWebhookEnvelope(ctx context.Context) *WebhookEnvelope {
+	if env := ctx.Value(webhookEnvelopeKey); env != nil {
+		return env.(*WebhookEnvelope)
+	}
+	return nil
+}
+
+// Decode decodes the envelope JSON payload into v.
+func (e *WebhookEnvelope) Decode(v interface{}) error {
+	if len(e.Payload) == 0 {
+		return fmt.Errorf("%s event has no payload", e.Event)
+	}
+	return json.Unmarshal(e.Payload, v)
+}
+
+// NewMemoryDeduper returns a deduper that remembers deliveries for the given duration, zero means
+// forever.
+func NewMemoryDeduper(ttl time.Duration) *MemoryDeduper {
+	return &MemoryDeduper{TTL: ttl}
+}
+
+// Claim implements WebhookDeduper. Deliveries with an empty ID are always claimed.
+func (d *MemoryDeduper) Claim(_ context.Context, id string) (bool, error) {
+	if id == "" {
+		return true, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	if d.claims == nil {
+		d.claims = make(map[string]time.Time)
+	}
+	if d.TTL > 0 {
+		for k, t := range d.claims {
+			if now.Sub(t) > d.TTL {
+				delete(d.claims, k)
+			}
+		}
+	}
+	if _, ok := d.claims[id]; ok {
+		return false, nil
+	}
+	d.claims[id] = now
+	return true, nil
+}
+
+// Release implements WebhookDeduper.
+func (d *MemoryDeduper) Release(_ context.Context, id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.claims, id)
+	return nil
+}
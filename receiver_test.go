@@ -0,0 +1,94 @@
+package goa_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("WebhookReceiver", func() {
+	var secret = []byte("secret")
+	var body = []byte(`{"ref":"refs/heads/master"}`)
+	var receiver *goa.WebhookReceiver
+	var header http.Header
+
+	Context("with the GitHub provider", func() {
+		BeforeEach(func() {
+			receiver = goa.NewWebhookReceiver(goa.WebhookProviderGitHub, secret)
+			sig, _ := goa.SignWebhook(goa.SigningHMACSHA256, secret, body)
+			header = http.Header{}
+			header.Set("X-Hub-Signature-256", sig)
+			header.Set("X-GitHub-Event", "push")
+			header.Set("X-GitHub-Delivery", "42")
+		})
+
+		It("accepts valid signatures", func() {
+			Ω(receiver.Verify(header, body, time.Now())).ShouldNot(HaveOccurred())
+			env, err := receiver.Envelope(header, body)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(env.ID).Should(Equal("42"))
+			Ω(env.Event).Should(Equal("push"))
+			var payload map[string]string
+			Ω(env.Decode(&payload)).ShouldNot(HaveOccurred())
+			Ω(payload).Should(HaveKeyWithValue("ref", "refs/heads/master"))
+		})
+
+		It("rejects invalid signatures", func() {
+			Ω(receiver.Verify(header, []byte("tampered"), time.Now())).Should(HaveOccurred())
+			header.Del("X-Hub-Signature-256")
+			Ω(receiver.Verify(header, body, time.Now())).Should(HaveOccurred())
+		})
+	})
+
+	Context("with the Stripe provider", func() {
+		var now time.Time
+		var event = []byte(`{"id":"evt_1","type":"customer.created","data":{"object":{"id":"cus_1"}}}`)
+
+		BeforeEach(func() {
+			now = time.Unix(1492774577, 0)
+			receiver = goa.NewWebhookReceiver(goa.WebhookProviderStripe, secret)
+			mac := hmac.New(sha256.New, secret)
+			fmt.Fprintf(mac, "%d.%s", now.Unix(), event)
+			header = http.Header{}
+			header.Set("Stripe-Signature", fmt.Sprintf("t=%d,v1=%s", now.Unix(), hex.EncodeToString(mac.Sum(nil))))
+		})
+
+		It("accepts valid signatures", func() {
+			Ω(receiver.Verify(header, event, now.Add(time.Minute))).ShouldNot(HaveOccurred())
+			env, err := receiver.Envelope(header, event)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(env.ID).Should(Equal("evt_1"))
+			Ω(env.Event).Should(Equal("customer.created"))
+			Ω(string(env.Payload)).Should(Equal(`{"id":"cus_1"}`))
+		})
+
+		It("rejects old timestamps", func() {
+			Ω(receiver.Verify(header, event, now.Add(time.Hour))).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("MemoryDeduper", func() {
+	It("claims deliveries once", func() {
+		d := goa.NewMemoryDeduper(0)
+		ctx := context.Background()
+		ok, err := d.Claim(ctx, "42")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(ok).Should(BeTrue())
+		ok, _ = d.Claim(ctx, "42")
+		Ω(ok).Should(BeFalse())
+
+		By("claiming released deliveries again")
+		Ω(d.Release(ctx, "42")).ShouldNot(HaveOccurred())
+		ok, _ = d.Claim(ctx, "42")
+		Ω(ok).Should(BeTrue())
+	})
+})
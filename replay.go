@@ -0,0 +1,195 @@
+package goa
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// TimestampHeader is the name of the header holding the time the replay protected requests
+	// were made at in seconds since the Unix epoch.
+	TimestampHeader = "X-Request-Timestamp"
+
+	// NonceHeader is the name of the header holding the unique nonce of the replay protected
+	// requests.
+	NonceHeader = "X-Request-Nonce"
+
+	// DefaultReplayTolerance is the default maximum difference between the timestamp of the
+	// replay protected requests and the server clock.
+	DefaultReplayTolerance = 5 * time.Minute
+
+	// maxNonceLength is the maximum length of the nonces accepted by ReplayProtect.
+	maxNonceLength = 128
+)
+
+type (
+	// ReplayCache records the nonces of the replay protected requests so that requests whose
+	// nonce was already used are rejected. Caches shared by multiple service instances (e.g.
+	// RedisReplayCache) detect replays across all the instances.
+	ReplayCache interface {
+		// Claim records the given nonce for the duration ttl and returns true if it was not
+		// already recorded.
+		Claim(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+	}
+
+	// MemoryReplayCache is a ReplayCache that keeps the nonces in memory.
+	MemoryReplayCache struct {
+		mu        sync.Mutex
+		nonces    map[string]time.Time
+		lastSweep time.Time
+	}
+
+	// RedisReplayCache is a ReplayCache that keeps the nonces in Redis so that replays are
+	// detected by all the service instances using the same Redis server.
+	RedisReplayCache struct {
+		// Prefix is prepended to the nonces, "nonce:" if empty.
+		Prefix string
+		client RedisScripter
+	}
+)
+
+// SetReplayCache sets the cache that records the nonces of the requests made to the replay
+// protected actions. The service uses a MemoryReplayCache if no cache is set.
+func (service *Service) SetReplayCache(c ReplayCache) {
+	service.securityMu.Lock()
+	defer service.securityMu.Unlock()
+	service.replayCache = c
+}
+
+// ReplayProtect returns a handler that rejects the requests that were already handled before
+// calling h. Requests must carry the TimestampHeader and NonceHeader headers, requests whose
+// headers are missing, whose timestamp differs from the server clock by more than tolerance
+// (DefaultReplayTolerance if zero) or whose nonce was already used are rejected with a 401
+// response. Nonces are recorded in the service replay cache for twice the tolerance. The code
+// generated by goagen wraps the handlers of the actions that require replay protection with
+// ReplayProtect.
+func ReplayProtect(h Handler, tolerance time.Duration) Handler {
+	if tolerance == 0 {
+		tolerance = DefaultReplayTolerance
+	}
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		ts, err := strconv.ParseInt(req.Header.Get(TimestampHeader), 10, 64)
+		if err != nil {
+			return ErrUnauthorized("missing or invalid %s header", TimestampHeader)
+		}
+		nonce := req.Header.Get(NonceHeader)
+		if nonce == "" || len(nonce) > maxNonceLength {
+			return ErrUnauthorized("missing or invalid %s header", NonceHeader)
+		}
+		if skew := time.Since(time.Unix(ts, 0)); skew > tolerance || skew < -tolerance {
+			return ErrUnauthorized("request timestamp is too far from the server clock")
+		}
+		ok, err := serviceReplayCache(ctx).Claim(ctx, nonce, 2*tolerance)
+		if err != nil {
+			Error(ctx, "replay cache", KV{"err", err})
+			return ErrInternal("failed to record request nonce")
+		}
+		if !ok {
+			return ErrUnauthorized("request nonce was already used")
+		}
+		return h(ctx, rw, req)
+	}
+}
+
+// SetReplayHeaders sets the TimestampHeader header of the request to the current time and the
+// NonceHeader header to a new random nonce. The generated clients call SetReplayHeaders on the
+// requests made to the replay protected actions.
+func SetReplayHeaders(req *http.Request) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err) // bug
+	}
+	req.Header.Set(TimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set(NonceHeader, hex.EncodeToString(nonce))
+}
+
+// serviceReplayCache returns the replay cache of the service handling the request with the given
+// context. It creates a memory cache if none is set.
+func serviceReplayCache(ctx context.Context) ReplayCache {
+	service := RequestService(ctx)
+	if service == nil {
+		return defaultReplayCache
+	}
+	service.securityMu.RLock()
+	c := service.replayCache
+	service.securityMu.RUnlock()
+	if c != nil {
+		return c
+	}
+	service.securityMu.Lock()
+	defer service.securityMu.Unlock()
+	if service.replayCache == nil {
+		service.replayCache = NewMemoryReplayCache()
+	}
+	return service.replayCache
+}
+
+// defaultReplayCache is the cache used by the handlers that are not served by a service.
+var defaultReplayCache = NewMemoryReplayCache()
+
+// NewMemoryReplayCache returns an empty in-memory cache.
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{nonces: make(map[string]time.Time), lastSweep: time.Now()}
+}
+
+// Claim implements ReplayCache.
+func (c *MemoryReplayCache) Claim(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if now.Sub(c.lastSweep) >= ttl {
+		for n, exp := range c.nonces {
+			if !now.Before(exp) {
+				delete(c.nonces, n)
+			}
+		}
+		c.lastSweep = now
+	}
+	if exp, ok := c.nonces[nonce]; ok && now.Before(exp) {
+		return false, nil
+	}
+	c.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// NewRedisReplayCache returns a cache that keeps the nonces in Redis using the given client.
+func NewRedisReplayCache(client RedisScripter) *RedisReplayCache {
+	return &RedisReplayCache{client: client}
+}
+
+// redisClaimNonce records the nonce KEYS[1] for ARGV[1] milliseconds. It returns 1 if the nonce
+// was not already recorded, 0 otherwise.
+const redisClaimNonce = `
+if redis.call("SET", KEYS[1], "1", "NX", "PX", ARGV[1]) then
+	return 1
+end
+return 0
+`
+
+// Claim implements ReplayCache.
+func (c *RedisReplayCache) Claim(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	prefix := c.Prefix
+	if prefix == "" {
+		prefix = "nonce:"
+	}
+	ms := int64(ttl / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	res, err := c.client.Eval(ctx, redisClaimNonce, []string{prefix + nonce}, ms)
+	if err != nil {
+		return false, err
+	}
+	claimed, ok := res.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected replay cache script result %v", res)
+	}
+	return claimed == 1, nil
+}
@@ -0,0 +1,101 @@
+package goa_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("ReplayProtect", func() {
+	var service *goa.Service
+	var handler goa.Handler
+	var req *http.Request
+	var called int
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		called = 0
+		handler = goa.ReplayProtect(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			called++
+			return nil
+		}, time.Minute)
+		var err error
+		req, err = http.NewRequest("POST", "/transfer", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		goa.SetReplayHeaders(req)
+	})
+
+	serve := func() error {
+		ctx := goa.NewContext(nil, service, &TestResponseWriter{}, req, url.Values{})
+		return handler(ctx, goa.Response(ctx), req)
+	}
+
+	It("accepts fresh requests once", func() {
+		Ω(serve()).ShouldNot(HaveOccurred())
+		Ω(called).Should(Equal(1))
+		err := serve()
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(401))
+		Ω(called).Should(Equal(1))
+	})
+
+	It("rejects requests with a new nonce but an old timestamp", func() {
+		req.Header.Set(goa.TimestampHeader, strconv.FormatInt(time.Now().Add(-2*time.Minute).Unix(), 10))
+		err := serve()
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(401))
+		Ω(called).Should(BeZero())
+	})
+
+	It("rejects requests without a nonce", func() {
+		req.Header.Del(goa.NonceHeader)
+		Ω(serve()).Should(HaveOccurred())
+		Ω(called).Should(BeZero())
+	})
+
+	Context("with a Redis cache", func() {
+		var scripter *fakeScripter
+
+		BeforeEach(func() {
+			scripter = &fakeScripter{result: int64(0)}
+			service.SetReplayCache(goa.NewRedisReplayCache(scripter))
+		})
+
+		It("rejects the nonces the script reports as used", func() {
+			Ω(serve()).Should(HaveOccurred())
+			Ω(called).Should(BeZero())
+			Ω(scripter.keys).Should(Equal([]string{"nonce:" + req.Header.Get(goa.NonceHeader)}))
+			Ω(scripter.args).Should(Equal([]interface{}{int64(120000)}))
+		})
+
+		Context("that fails", func() {
+			BeforeEach(func() {
+				scripter.err = errors.New("connection refused")
+			})
+
+			It("rejects the requests", func() {
+				err := serve()
+				Ω(err).Should(HaveOccurred())
+				Ω(goa.ErrorStatus(err)).Should(Equal(500))
+				Ω(called).Should(BeZero())
+			})
+		})
+	})
+})
+
+var _ = Describe("HMACCanonicalRequest", func() {
+	It("signs the replay headers", func() {
+		req, _ := http.NewRequest("POST", "/transfer", nil)
+		plain := goa.HMACCanonicalRequest(req, nil)
+		req.Header.Set(goa.TimestampHeader, "1492774577")
+		req.Header.Set(goa.NonceHeader, "abc")
+		Ω(goa.HMACCanonicalRequest(req, nil)).Should(Equal(plain + "\n1492774577\nabc"))
+	})
+})
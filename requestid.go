@@ -0,0 +1,77 @@
+package goa
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// RequestIDHeader is the name of the header used by the RequestID middleware when the options do
+// not specify one.
+const RequestIDHeader = "X-Request-Id"
+
+// DefaultRequestIDMaxLength is the maximum length of the request IDs accepted by the RequestID
+// middleware when the options do not specify one.
+const DefaultRequestIDMaxLength = 128
+
+// RequestIDOptions configures the RequestID middleware.
+type RequestIDOptions struct {
+	// Header is the name of the header the request ID is read from and written to. Empty
+	// means RequestIDHeader.
+	Header string
+	// MaxLength is the maximum length of the request IDs read from the request, a new ID is
+	// generated for requests whose ID is longer. Zero means DefaultRequestIDMaxLength.
+	MaxLength int
+	// Generate returns a new request ID, nil means a random 128-bit hex encoded value.
+	Generate func() string
+}
+
+// RequestID returns a middleware that identifies each request. The ID is read from the request
+// header if present, generated otherwise. The middleware stores the ID in the request context where
+// ContextRequestID retrieves it, adds it to the log context so that all the log entries written
+// with the request context include it under the "req_id" key and echoes it in the response header.
+// opts may be nil in which case the default options apply.
+func RequestID(opts *RequestIDOptions) Middleware {
+	o := RequestIDOptions{Header: RequestIDHeader, MaxLength: DefaultRequestIDMaxLength, Generate: newRequestID}
+	if opts != nil {
+		if opts.Header != "" {
+			o.Header = opts.Header
+		}
+		if opts.MaxLength > 0 {
+			o.MaxLength = opts.MaxLength
+		}
+		if opts.Generate != nil {
+			o.Generate = opts.Generate
+		}
+	}
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			id := req.Header.Get(o.Header)
+			if id == "" || len(id) > o.MaxLength {
+				id = o.Generate()
+			}
+			ctx = context.WithValue(ctx, requestIDKey, id)
+			ctx = NewLogContext(ctx, KV{"req_id", id})
+			rw.Header().Set(o.Header, id)
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// ContextRequestID returns the ID of the request with the given context, empty if the request
+// was not handled by a RequestID middleware.
+func ContextRequestID(ctx context.Context) string {
+	if id := ctx.Value(requestIDKey); id != nil {
+		return id.(string)
+	}
+	return ""
+}
+
+// newRequestID returns a random 128-bit hex encoded request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,81 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("RequestID", func() {
+	var opts *goa.RequestIDOptions
+	var reqID string
+
+	var rw *httptest.ResponseRecorder
+	var ctxID string
+	var logCtx []goa.KV
+
+	BeforeEach(func() {
+		opts = nil
+		reqID = ""
+		ctxID, logCtx = "", nil
+	})
+
+	JustBeforeEach(func() {
+		req, _ := http.NewRequest("GET", "/", nil)
+		if reqID != "" {
+			req.Header.Set("X-Request-Id", reqID)
+		}
+		rw = httptest.NewRecorder()
+		ctx := goa.NewContext(nil, goa.New("test"), rw, req, nil)
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			ctxID = goa.ContextRequestID(ctx)
+			logCtx = goa.LogContext(ctx)
+			return nil
+		}
+		Ω(goa.RequestID(opts)(h)(ctx, goa.Response(ctx), req)).ShouldNot(HaveOccurred())
+	})
+
+	It("generates an ID", func() {
+		Ω(ctxID).Should(HaveLen(32))
+		Ω(rw.Header().Get("X-Request-Id")).Should(Equal(ctxID))
+		Ω(logCtx).Should(ContainElement(goa.KV{Key: "req_id", Value: ctxID}))
+	})
+
+	Context("with a request ID header", func() {
+		BeforeEach(func() {
+			reqID = "foo"
+		})
+
+		It("uses the request ID", func() {
+			Ω(ctxID).Should(Equal("foo"))
+			Ω(rw.Header().Get("X-Request-Id")).Should(Equal("foo"))
+			Ω(logCtx).Should(ContainElement(goa.KV{Key: "req_id", Value: "foo"}))
+		})
+
+		Context("that is too long", func() {
+			BeforeEach(func() {
+				reqID = strings.Repeat("a", 200)
+			})
+
+			It("generates an ID", func() {
+				Ω(ctxID).Should(HaveLen(32))
+			})
+		})
+	})
+
+	Context("with a custom generator", func() {
+		BeforeEach(func() {
+			opts = &goa.RequestIDOptions{Generate: func() string { return "bar" }}
+		})
+
+		It("uses the generator", func() {
+			Ω(ctxID).Should(Equal("bar"))
+			Ω(rw.Header().Get("X-Request-Id")).Should(Equal("bar"))
+		})
+	})
+})
@@ -0,0 +1,166 @@
+package goa
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// List of supported Retry-After strategies.
+const (
+	// RetryAfterFixed always uses the policy delay.
+	RetryAfterFixed = "fixed"
+	// RetryAfterExponential doubles the policy delay with each consecutive rejection.
+	RetryAfterExponential = "exponential"
+	// RetryAfterContext uses the delay stored in the request context with WithRetryAfter.
+	RetryAfterContext = "context"
+)
+
+type (
+	// RetryAfterPolicy computes the value of the Retry-After header sent with 429 (Too Many
+	// Requests) and 503 (Service Unavailable) responses.
+	RetryAfterPolicy struct {
+		// Strategy is one of RetryAfterFixed, RetryAfterExponential or RetryAfterContext.
+		Strategy string
+		// Delay is the delay for the fixed strategy and the initial delay for the
+		// exponential strategy.
+		Delay time.Duration
+		// Max caps the delay computed by the exponential strategy if not zero.
+		Max time.Duration
+	}
+
+	// retryTracker keeps the consecutive rejections of the principals by the responses that
+	// use the exponential strategy.
+	retryTracker struct {
+		mu         sync.Mutex
+		rejections map[string]*rejection
+		lastSweep  time.Time
+	}
+
+	// rejection is the last rejection of a principal by an action.
+	rejection struct {
+		attempt int
+		at      time.Time
+		delay   time.Duration
+	}
+)
+
+// Duration returns the delay clients should wait before retrying given the number of consecutive
+// rejections that preceded the current one. It returns zero if no delay should be advertised.
+func (p *RetryAfterPolicy) Duration(ctx context.Context, attempt int) time.Duration {
+	switch p.Strategy {
+	case RetryAfterFixed:
+		return p.Delay
+	case RetryAfterExponential:
+		d := p.Delay
+		for i := 0; i < attempt; i++ {
+			d *= 2
+			if p.Max > 0 && d >= p.Max {
+				return p.Max
+			}
+		}
+		return d
+	case RetryAfterContext:
+		return ContextRetryAfter(ctx)
+	}
+	return 0
+}
+
+// Next returns the delay advertised by the response written for the request with the given
+// context. The exponential strategy uses the number of consecutive rejections of the request
+// principal (see QuotaPrincipal) by the same action, a rejection is consecutive if it happens less
+// than twice the previously advertised delay after the previous one. The code generated by goagen
+// calls Next to set the Retry-After header of the responses that define a Retry-After strategy.
+func (p *RetryAfterPolicy) Next(ctx context.Context) time.Duration {
+	if p.Strategy != RetryAfterExponential {
+		return p.Duration(ctx, 0)
+	}
+	service := RequestService(ctx)
+	req := Request(ctx)
+	if service == nil || service.retries == nil || req == nil {
+		return p.Duration(ctx, 0)
+	}
+	key := ContextController(ctx) + "#" + ContextAction(ctx) + ":" + QuotaPrincipal(ctx, req.Request)
+	return service.retries.next(ctx, key, p)
+}
+
+// WithRetryAfter stores the given delay in the context so that it's used by the responses that
+// define the "context" Retry-After strategy:
+//
+//	ctx.Context = goa.WithRetryAfter(ctx.Context, 10*time.Second)
+//	return ctx.ServiceUnavailable()
+func WithRetryAfter(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, retryAfterKey, d)
+}
+
+// ContextRetryAfter returns the delay stored in the context with WithRetryAfter, zero if none.
+func ContextRetryAfter(ctx context.Context) time.Duration {
+	if d := ctx.Value(retryAfterKey); d != nil {
+		return d.(time.Duration)
+	}
+	return 0
+}
+
+// SetRetryAfter sets the Retry-After header to the given delay rounded up to the second. It does
+// nothing if the delay is not positive.
+func SetRetryAfter(h http.Header, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	secs := int64((d + time.Second - 1) / time.Second)
+	h.Set("Retry-After", strconv.FormatInt(secs, 10))
+}
+
+// ParseRetryAfter parses the value of a Retry-After header which is either a number of seconds or
+// a HTTP date. It returns false if the value is invalid.
+func ParseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	d := t.Sub(time.Now())
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// next records a rejection of the principal with the given key and returns the delay computed by
+// the policy given the number of consecutive rejections that preceded it.
+func (t *retryTracker) next(ctx context.Context, key string, p *RetryAfterPolicy) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if now.Sub(t.lastSweep) >= time.Minute {
+		for k, r := range t.rejections {
+			if !r.consecutive(now) {
+				delete(t.rejections, k)
+			}
+		}
+		t.lastSweep = now
+	}
+	attempt := 0
+	if r, ok := t.rejections[key]; ok && r.consecutive(now) {
+		attempt = r.attempt + 1
+	}
+	d := p.Duration(ctx, attempt)
+	t.rejections[key] = &rejection{attempt: attempt, at: now, delay: d}
+	return d
+}
+
+// consecutive returns true if a rejection happening at the given time follows r.
+func (r *rejection) consecutive(now time.Time) bool {
+	return now.Sub(r.at) < 2*r.delay
+}
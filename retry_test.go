@@ -0,0 +1,105 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("RetryAfterPolicy", func() {
+	var policy *goa.RetryAfterPolicy
+
+	Context("with the fixed strategy", func() {
+		BeforeEach(func() {
+			policy = &goa.RetryAfterPolicy{Strategy: goa.RetryAfterFixed, Delay: 30 * time.Second}
+		})
+
+		It("always returns the delay", func() {
+			Ω(policy.Duration(context.Background(), 0)).Should(Equal(30 * time.Second))
+			Ω(policy.Duration(context.Background(), 5)).Should(Equal(30 * time.Second))
+		})
+	})
+
+	Context("with the exponential strategy", func() {
+		BeforeEach(func() {
+			policy = &goa.RetryAfterPolicy{Strategy: goa.RetryAfterExponential, Delay: time.Second, Max: 5 * time.Second}
+		})
+
+		It("doubles the delay up to the maximum", func() {
+			Ω(policy.Duration(context.Background(), 0)).Should(Equal(time.Second))
+			Ω(policy.Duration(context.Background(), 2)).Should(Equal(4 * time.Second))
+			Ω(policy.Duration(context.Background(), 3)).Should(Equal(5 * time.Second))
+		})
+
+		It("counts the consecutive rejections of each principal", func() {
+			service := goa.New("test")
+			ctrl := service.NewController("bottle")
+			service.Mux.Handle("GET", "/bottles", ctrl.MuxHandler("list", func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				goa.SetRetryAfter(rw.Header(), policy.Next(ctx))
+				rw.WriteHeader(429)
+				return nil
+			}, nil))
+			serve := func(addr string) string {
+				req, _ := http.NewRequest("GET", "/bottles", nil)
+				req.RemoteAddr = addr
+				rw := httptest.NewRecorder()
+				service.Mux.ServeHTTP(rw, req)
+				return rw.Header().Get("Retry-After")
+			}
+			Ω(serve("10.0.0.1:1234")).Should(Equal("1"))
+			Ω(serve("10.0.0.1:1234")).Should(Equal("2"))
+			Ω(serve("10.0.0.1:1234")).Should(Equal("4"))
+			Ω(serve("10.0.0.2:1234")).Should(Equal("1"))
+		})
+	})
+
+	Context("with the context strategy", func() {
+		BeforeEach(func() {
+			policy = &goa.RetryAfterPolicy{Strategy: goa.RetryAfterContext}
+		})
+
+		It("uses the delay stored in the context", func() {
+			ctx := goa.WithRetryAfter(context.Background(), 10*time.Second)
+			Ω(policy.Duration(ctx, 0)).Should(Equal(10 * time.Second))
+			Ω(policy.Duration(context.Background(), 0)).Should(BeZero())
+		})
+	})
+})
+
+var _ = Describe("SetRetryAfter", func() {
+	It("rounds the delay up to the second", func() {
+		h := make(http.Header)
+		goa.SetRetryAfter(h, 1500*time.Millisecond)
+		Ω(h.Get("Retry-After")).Should(Equal("2"))
+	})
+
+	It("omits the header for non positive delays", func() {
+		h := make(http.Header)
+		goa.SetRetryAfter(h, 0)
+		Ω(h).ShouldNot(HaveKey("Retry-After"))
+	})
+})
+
+var _ = Describe("ParseRetryAfter", func() {
+	It("parses seconds", func() {
+		d, ok := goa.ParseRetryAfter("120")
+		Ω(ok).Should(BeTrue())
+		Ω(d).Should(Equal(2 * time.Minute))
+	})
+
+	It("parses HTTP dates", func() {
+		d, ok := goa.ParseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		Ω(ok).Should(BeTrue())
+		Ω(d).Should(BeNumerically(">", 59*time.Minute))
+	})
+
+	It("rejects invalid values", func() {
+		_, ok := goa.ParseRetryAfter("soon")
+		Ω(ok).Should(BeFalse())
+	})
+})
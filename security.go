@@ -0,0 +1,164 @@
+package goa
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// JWTSecurity describes a security scheme that authenticates requests with a JSON Web
+	// Token. goagen generates a function that returns the description of each JWT scheme
+	// defined in the design.
+	JWTSecurity struct {
+		// In is the location of the token, either "header" or "query".
+		In string
+		// Name is the name of the header or query string parameter holding the token.
+		Name string
+		// TokenURL is the URL of the endpoint issuing the tokens if any.
+		TokenURL string
+		// Scopes lists the scopes the tokens may grant indexed by name, the values are the
+		// scope descriptions.
+		Scopes map[string]string
+	}
+
+	// OIDCSecurity describes a security scheme that authenticates requests with an OpenID
+	// Connect ID token issued by an external provider.
+	OIDCSecurity struct {
+		// Issuer is the URL of the provider issuing the tokens.
+		Issuer string
+		// Audience is the audience the tokens must be issued for, empty means any.
+		Audience string
+		// In is the location of the token, either "header" or "query".
+		In string
+		// Name is the name of the header or query string parameter holding the token.
+		Name string
+		// Scopes lists the scopes the tokens may grant indexed by name, the values are the
+		// scope descriptions.
+		Scopes map[string]string
+	}
+
+	// APIKeySecurity describes a security scheme that authenticates requests with an API key.
+	APIKeySecurity struct {
+		// In is the location of the key, either "header" or "query".
+		In string
+		// Name is the name of the header or query string parameter holding the key.
+		Name string
+		// Scopes lists the scopes the keys may grant indexed by name if the service manages
+		// the keys, the values are the scope descriptions.
+		Scopes map[string]string
+	}
+
+	// BasicAuthSecurity describes a security scheme that authenticates requests with HTTP
+	// basic authentication.
+	BasicAuthSecurity struct {
+		// Realm is the realm given in the WWW-Authenticate header of the responses to
+		// unauthenticated requests, empty means the service name.
+		Realm string
+	}
+
+	// OAuth2Security describes a security scheme that authenticates requests with an OAuth2
+	// bearer access token.
+	OAuth2Security struct {
+		// Flow is the flow used to obtain the tokens, one of "accessCode", "implicit",
+		// "password" or "application".
+		Flow string
+		// TokenURL is the URL of the token endpoint if any.
+		TokenURL string
+		// AuthorizationURL is the URL of the authorization endpoint if any.
+		AuthorizationURL string
+		// Scopes lists the scopes the tokens may grant indexed by name, the values are the
+		// scope descriptions.
+		Scopes map[string]string
+	}
+
+	// HMACSecurity describes a security scheme that authenticates requests with a HMAC
+	// signature of the canonical request, see HMACCanonicalRequest.
+	HMACSecurity struct {
+		// Name is the name of the header holding the signature.
+		Name string
+		// Tolerance is the maximum difference between the request date and the server
+		// clock, zero means DefaultHMACTolerance.
+		Tolerance time.Duration
+	}
+
+	// MutualTLSSecurity describes a security scheme that authenticates requests with the
+	// certificate presented by the client during the TLS handshake. The TLS listeners must be
+	// configured to verify client certificates, see ServerConfig.ClientCAFile.
+	MutualTLSSecurity struct {
+		// ClientCA is the path to the PEM file containing the certificates of the
+		// authorities that issue the client certificates.
+		ClientCA string
+	}
+)
+
+// Secure returns a handler that enforces the security scheme with the given name before calling
+// h. The scheme is enforced by the middleware set with SetSecurityMiddleware, the scopes are the
+// scopes the request must be granted: the middleware retrieves them with ContextRequiredScopes.
+// The code generated by goagen calls Secure for each action that defines security requirements.
+// Requests made to actions whose scheme has no middleware are answered with a 500 response.
+func Secure(scheme string, h Handler, scopes ...string) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		m := RequestService(ctx).SecurityMiddleware(scheme)
+		if m == nil {
+			return ErrInternal("no middleware enforces security scheme %#v", scheme)
+		}
+		ctx = context.WithValue(ctx, requiredScopesKey, scopes)
+		return m(h)(ctx, rw, req)
+	}
+}
+
+// SetSecurityMiddleware sets the middleware that enforces the security scheme with the given
+// name. The middleware authenticates the request and returns an error built with ErrUnauthorized
+// if the credentials are missing or invalid or with ErrForbidden if they do not grant the
+// required scopes. The security package provides middleware for the JWT, OIDC, API key, basic
+// auth, OAuth2, HMAC and mutual TLS schemes.
+func (service *Service) SetSecurityMiddleware(scheme string, m Middleware) {
+	service.securityMu.Lock()
+	defer service.securityMu.Unlock()
+	if service.security == nil {
+		service.security = make(map[string]Middleware)
+	}
+	service.security[scheme] = m
+}
+
+// SecurityMiddleware returns the middleware that enforces the security scheme with the given
+// name, nil if there is none.
+func (service *Service) SecurityMiddleware(scheme string) Middleware {
+	service.securityMu.RLock()
+	defer service.securityMu.RUnlock()
+	return service.security[scheme]
+}
+
+// ContextRequiredScopes returns the scopes the request with the given context must be granted.
+func ContextRequiredScopes(ctx context.Context) []string {
+	if s := ctx.Value(requiredScopesKey); s != nil {
+		return s.([]string)
+	}
+	return nil
+}
+
+// ValidateScopes checks that the granted scopes include all the scopes required by the request
+// with the given context. It returns an error built with ErrForbidden listing the missing scopes
+// otherwise.
+func ValidateScopes(ctx context.Context, granted []string) error {
+	var missing []string
+	for _, r := range ContextRequiredScopes(ctx) {
+		found := false
+		for _, g := range granted {
+			if g == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, r)
+		}
+	}
+	if len(missing) > 0 {
+		return ErrForbidden("missing scopes: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package security
+
+import (
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// APIKeyValidator validates an API key. It returns the context given to the action handler,
+// typically the given context extended with the identity of the key owner, or an error if the
+// key is not valid.
+type APIKeyValidator func(ctx context.Context, key string) (context.Context, error)
+
+// APIKey returns a middleware that authenticates requests with the API key read from the header
+// or query string parameter described by the scheme.
+func APIKey(scheme *goa.APIKeySecurity, validate APIKeyValidator) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			key := credentials(scheme.In, scheme.Name, req)
+			if key == "" {
+				return goa.ErrUnauthorized("missing API key")
+			}
+			ctx, err := validate(ctx, key)
+			if err != nil {
+				return unauthorized(err)
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
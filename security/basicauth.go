@@ -0,0 +1,46 @@
+package security
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// BasicAuthValidator validates basic auth credentials. It returns the context given to the action
+// handler or an error if the credentials are not valid.
+type BasicAuthValidator func(ctx context.Context, user, pass string) (context.Context, error)
+
+// BasicAuth returns a middleware that authenticates requests with HTTP basic authentication.
+// The responses to unauthenticated requests include a WWW-Authenticate header with the scheme
+// realm. The user name is the request principal unless the validator sets another one with
+// goa.WithPrincipal.
+func BasicAuth(scheme *goa.BasicAuthSecurity, validate BasicAuthValidator) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			user, pass, ok := req.BasicAuth()
+			if !ok {
+				challenge(ctx, rw, scheme)
+				return goa.ErrUnauthorized("missing basic auth credentials")
+			}
+			ctx, err := validate(goa.WithPrincipal(ctx, user), user, pass)
+			if err != nil {
+				challenge(ctx, rw, scheme)
+				return unauthorized(err)
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// challenge sets the WWW-Authenticate header of the response.
+func challenge(ctx context.Context, rw http.ResponseWriter, scheme *goa.BasicAuthSecurity) {
+	realm := scheme.Realm
+	if realm == "" {
+		if service := goa.RequestService(ctx); service != nil {
+			realm = service.Name
+		}
+	}
+	rw.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", realm))
+}
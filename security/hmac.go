@@ -0,0 +1,72 @@
+package security
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// maxHMACBodySize is the maximum size of the request bodies read to verify their signature.
+const maxHMACBodySize = 10 << 20
+
+// HMACKeyFunc returns the secret of the key with the given ID. It returns an error if the key is
+// unknown or revoked.
+type HMACKeyFunc func(ctx context.Context, keyID string) ([]byte, error)
+
+// HMAC returns a middleware that authenticates requests signed with goa.SignHMACRequest. The
+// middleware recomputes the signature of the canonical request with the secret returned by keys
+// and rejects requests whose signature does not match or whose Date header differs from the
+// server clock by more than the scheme tolerance. The key is looked up before the body is read and
+// bodies larger than 10MB are rejected with a 413 response. The key ID is the request principal.
+func HMAC(scheme *goa.HMACSecurity, keys HMACKeyFunc) goa.Middleware {
+	tolerance := scheme.Tolerance
+	if tolerance == 0 {
+		tolerance = goa.DefaultHMACTolerance
+	}
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			val := req.Header.Get(scheme.Name)
+			if val == "" {
+				return goa.ErrUnauthorized("missing signature")
+			}
+			keyID, sig, err := goa.ParseHMACAuthorization(val)
+			if err != nil {
+				return goa.ErrUnauthorized(err)
+			}
+			date, err := http.ParseTime(req.Header.Get("Date"))
+			if err != nil {
+				return goa.ErrUnauthorized("missing or invalid Date header")
+			}
+			if skew := time.Since(date); skew > tolerance || skew < -tolerance {
+				return goa.ErrUnauthorized("request date is too far from the server clock")
+			}
+			secret, err := keys(ctx, keyID)
+			if err != nil {
+				return unauthorized(err)
+			}
+			var body []byte
+			if req.Body != nil {
+				body, err = ioutil.ReadAll(io.LimitReader(req.Body, maxHMACBodySize+1))
+				req.Body.Close()
+				if err != nil {
+					return goa.ErrBadRequest(err)
+				}
+				if len(body) > maxHMACBodySize {
+					return goa.ErrRequestTooLarge("signed request body exceeds %d bytes", maxHMACBodySize)
+				}
+				req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+			expected := goa.HMACSignature(secret, goa.HMACCanonicalRequest(req, body))
+			if !hmac.Equal([]byte(expected), []byte(sig)) {
+				return goa.ErrUnauthorized("invalid signature")
+			}
+			return h(goa.WithPrincipal(ctx, keyID), rw, req)
+		}
+	}
+}
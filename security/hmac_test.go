@@ -0,0 +1,82 @@
+package security_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/security"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("HMAC", func() {
+	var scheme *goa.HMACSecurity
+	var signer *goa.HMACSigner
+	var req *http.Request
+
+	keys := func(ctx context.Context, keyID string) ([]byte, error) {
+		if keyID != "k1" {
+			return nil, errors.New("unknown key")
+		}
+		return []byte("secret"), nil
+	}
+
+	BeforeEach(func() {
+		scheme = &goa.HMACSecurity{Name: "X-Signature", Tolerance: time.Minute}
+		signer = &goa.HMACSigner{Header: "X-Signature", KeyID: "k1", Secret: "secret"}
+		req, _ = http.NewRequest("POST", "/bottles?b=2&a=1", strings.NewReader(`{"name":"x"}`))
+	})
+
+	It("accepts signed requests and preserves the body", func() {
+		Ω(signer.Sign(req)).ShouldNot(HaveOccurred())
+		ctx, _, err := serve(security.HMAC(scheme, keys), req)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(goa.ContextPrincipal(ctx)).Should(Equal("k1"))
+		body, _ := ioutil.ReadAll(req.Body)
+		Ω(string(body)).Should(Equal(`{"name":"x"}`))
+	})
+
+	It("rejects requests whose body was tampered with", func() {
+		Ω(signer.Sign(req)).ShouldNot(HaveOccurred())
+		req.Body = ioutil.NopCloser(bytes.NewBufferString(`{"name":"y"}`))
+		_, _, err := serve(security.HMAC(scheme, keys), req)
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(401))
+	})
+
+	It("rejects requests signed with unknown keys", func() {
+		signer.KeyID = "k2"
+		Ω(signer.Sign(req)).ShouldNot(HaveOccurred())
+		_, _, err := serve(security.HMAC(scheme, keys), req)
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(401))
+	})
+
+	It("rejects requests whose body is too large", func() {
+		req, _ = http.NewRequest("POST", "/bottles", bytes.NewReader(make([]byte, 10<<20+1)))
+		Ω(signer.Sign(req)).ShouldNot(HaveOccurred())
+		_, _, err := serve(security.HMAC(scheme, keys), req)
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(413))
+	})
+
+	It("rejects stale requests", func() {
+		req.Header.Set("Date", time.Now().Add(-2*time.Minute).UTC().Format(http.TimeFormat))
+		Ω(signer.Sign(req)).ShouldNot(HaveOccurred())
+		_, _, err := serve(security.HMAC(scheme, keys), req)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring("server clock"))
+	})
+
+	It("rejects unsigned requests", func() {
+		_, _, err := serve(security.HMAC(scheme, keys), req)
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(401))
+	})
+})
@@ -0,0 +1,200 @@
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	// Register the hash functions used by the supported algorithms.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+type (
+	// claimsKey is the context key used to store the JWT claims.
+	claimsKey struct{}
+
+	// jwtHeader is the JOSE header of a JWT.
+	jwtHeader struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+
+	// keyFunc returns the keys that may verify the signature of a token given its header.
+	keyFunc func(h *jwtHeader) ([]interface{}, error)
+)
+
+// JWT returns a middleware that authenticates requests with the JSON Web Token read from the
+// header or query string parameter described by the scheme. The token signature is verified with
+// the given keys: []byte keys verify HS256, HS384 and HS512 signatures, *rsa.PublicKey keys verify
+// RS256, RS384 and RS512 signatures and *ecdsa.PublicKey keys verify ES256, ES384 and ES512
+// signatures. The keys are tried in order, the token is valid if one of them verifies the
+// signature. The "exp" and "nbf" claims are checked if present.
+// The scopes granted by the token are read from the "scope" claim (a space separated list) or the
+// "scopes" claim (a list). The middleware stores the token claims in the context given to the
+// action handler where ContextClaims retrieves them, the claims are also the request principal.
+func JWT(scheme *goa.JWTSecurity, keys ...interface{}) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			token := credentials(scheme.In, scheme.Name, req)
+			if scheme.In != "query" {
+				token = bearerToken(token)
+			}
+			if token == "" {
+				return goa.ErrUnauthorized("missing token")
+			}
+			claims, err := parseJWT(token, staticKeys(keys), time.Now())
+			if err != nil {
+				return goa.ErrUnauthorized("invalid token: %s", err)
+			}
+			if err := goa.ValidateScopes(ctx, claimScopes(claims)); err != nil {
+				return err
+			}
+			ctx = context.WithValue(ctx, claimsKey{}, claims)
+			ctx = goa.WithPrincipal(ctx, claims)
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// ContextClaims returns the claims of the JWT that authenticated the request with the given
+// context, nil if the request was not authenticated by the JWT middleware.
+func ContextClaims(ctx context.Context) map[string]interface{} {
+	if c := ctx.Value(claimsKey{}); c != nil {
+		return c.(map[string]interface{})
+	}
+	return nil
+}
+
+// staticKeys returns a key function that always returns the given keys.
+func staticKeys(keys []interface{}) keyFunc {
+	return func(*jwtHeader) ([]interface{}, error) { return keys, nil }
+}
+
+// parseJWT verifies the signature of the token with the keys returned by the given function,
+// checks its validity period and returns its claims.
+func parseJWT(token string, keyf keyFunc, now time.Time) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed header: %s", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature: %s", err)
+	}
+	keys, err := keyf(&header)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, parts[0]+"."+parts[1], sig, keys); err != nil {
+		return nil, err
+	}
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed claims: %s", err)
+	}
+	if exp, ok := claims["exp"].(float64); ok && now.Unix() >= int64(exp) {
+		return nil, errors.New("token is expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Unix() < int64(nbf) {
+		return nil, errors.New("token is not valid yet")
+	}
+	return claims, nil
+}
+
+// decodeSegment decodes a base64 URL encoded JSON token segment.
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// verifySignature checks that one of the keys verifies the signature of the signed content with
+// the given algorithm.
+func verifySignature(alg, signed string, sig []byte, keys []interface{}) error {
+	var hash crypto.Hash
+	if len(alg) == 5 {
+		switch alg[2:] {
+		case "256":
+			hash = crypto.SHA256
+		case "384":
+			hash = crypto.SHA384
+		case "512":
+			hash = crypto.SHA512
+		}
+	}
+	if hash == 0 {
+		return fmt.Errorf("unsupported algorithm %#v", alg)
+	}
+	for _, k := range keys {
+		switch key := k.(type) {
+		case []byte:
+			if alg[:2] != "HS" {
+				continue
+			}
+			mac := hmac.New(hash.New, key)
+			mac.Write([]byte(signed))
+			if hmac.Equal(mac.Sum(nil), sig) {
+				return nil
+			}
+		case *rsa.PublicKey:
+			if alg[:2] != "RS" {
+				continue
+			}
+			d := hash.New()
+			d.Write([]byte(signed))
+			if rsa.VerifyPKCS1v15(key, hash, d.Sum(nil), sig) == nil {
+				return nil
+			}
+		case *ecdsa.PublicKey:
+			size := (key.Curve.Params().BitSize + 7) / 8
+			if alg[:2] != "ES" || len(sig) != 2*size {
+				continue
+			}
+			d := hash.New()
+			d.Write([]byte(signed))
+			r := new(big.Int).SetBytes(sig[:size])
+			s := new(big.Int).SetBytes(sig[size:])
+			if ecdsa.Verify(key, d.Sum(nil), r, s) {
+				return nil
+			}
+		}
+	}
+	return errors.New("signature verification failed")
+}
+
+// claimScopes returns the scopes listed in the "scope" or "scopes" claims.
+func claimScopes(claims map[string]interface{}) []string {
+	var scopes []string
+	for _, name := range []string{"scope", "scopes"} {
+		switch v := claims[name].(type) {
+		case string:
+			scopes = append(scopes, strings.Fields(v)...)
+		case []interface{}:
+			for _, s := range v {
+				if str, ok := s.(string); ok {
+					scopes = append(scopes, str)
+				}
+			}
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,39 @@
+package security
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// clientCertKey is the context key used to store the verified client certificate.
+type clientCertKey struct{}
+
+// MutualTLS returns a middleware that authenticates requests with the client certificate verified
+// during the TLS handshake. The listeners serving the requests must verify the client
+// certificates, see goa.ServerConfig.ClientCAFile. The middleware rejects requests made without a
+// verified certificate, stores the certificate in the context and uses its subject common name as
+// request principal. Use ContextClientCertificate to retrieve the certificate.
+func MutualTLS(scheme *goa.MutualTLSSecurity) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+				return goa.ErrUnauthorized("missing client certificate")
+			}
+			cert := req.TLS.VerifiedChains[0][0]
+			ctx = context.WithValue(ctx, clientCertKey{}, cert)
+			return h(goa.WithPrincipal(ctx, cert.Subject.CommonName), rw, req)
+		}
+	}
+}
+
+// ContextClientCertificate returns the client certificate verified by the MutualTLS middleware,
+// nil if there is none.
+func ContextClientCertificate(ctx context.Context) *x509.Certificate {
+	if c, ok := ctx.Value(clientCertKey{}).(*x509.Certificate); ok {
+		return c
+	}
+	return nil
+}
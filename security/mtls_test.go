@@ -0,0 +1,44 @@
+package security_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/security"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MutualTLS", func() {
+	var scheme *goa.MutualTLSSecurity
+	var req *http.Request
+
+	BeforeEach(func() {
+		scheme = &goa.MutualTLSSecurity{ClientCA: "ca.pem"}
+		req, _ = http.NewRequest("GET", "https://example.com/", nil)
+	})
+
+	It("accepts requests made with a verified client certificate", func() {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "billing"}}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		ctx, _, err := serve(security.MutualTLS(scheme), req)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(security.ContextClientCertificate(ctx)).Should(Equal(cert))
+		Ω(goa.ContextPrincipal(ctx)).Should(Equal("billing"))
+	})
+
+	It("rejects requests made without a verified client certificate", func() {
+		req.TLS = &tls.ConnectionState{}
+		_, _, err := serve(security.MutualTLS(scheme), req)
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(401))
+	})
+
+	It("rejects cleartext requests", func() {
+		_, _, err := serve(security.MutualTLS(scheme), req)
+		Ω(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,38 @@
+package security
+
+import (
+	"net/http"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// OAuth2Validator validates an OAuth2 access token, typically by introspecting it with the
+// authorization server. It returns the context given to the action handler and the scopes granted
+// by the token or an error if the token is not valid.
+type OAuth2Validator func(ctx context.Context, token string) (context.Context, []string, error)
+
+// OAuth2 returns a middleware that authenticates requests with the OAuth2 bearer access token
+// read from the Authorization header and checks that the token grants the scopes required by the
+// action.
+func OAuth2(scheme *goa.OAuth2Security, validate OAuth2Validator) goa.Middleware {
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			token := bearerToken(req.Header.Get("Authorization"))
+			if token == "" {
+				rw.Header().Set("WWW-Authenticate", "Bearer")
+				return goa.ErrUnauthorized("missing access token")
+			}
+			ctx, scopes, err := validate(ctx, token)
+			if err != nil {
+				rw.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				return unauthorized(err)
+			}
+			if err := goa.ValidateScopes(ctx, scopes); err != nil {
+				rw.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+				return err
+			}
+			return h(ctx, rw, req)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+type (
+	// OIDCDiscovery is the discovery document of an OpenID Connect provider.
+	OIDCDiscovery struct {
+		Issuer                           string   `json:"issuer"`
+		AuthorizationEndpoint            string   `json:"authorization_endpoint,omitempty"`
+		TokenEndpoint                    string   `json:"token_endpoint,omitempty"`
+		UserinfoEndpoint                 string   `json:"userinfo_endpoint,omitempty"`
+		JWKSURI                          string   `json:"jwks_uri"`
+		ResponseTypesSupported           []string `json:"response_types_supported,omitempty"`
+		SubjectTypesSupported            []string `json:"subject_types_supported,omitempty"`
+		IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported,omitempty"`
+		ScopesSupported                  []string `json:"scopes_supported,omitempty"`
+		ClaimsSupported                  []string `json:"claims_supported,omitempty"`
+	}
+
+	// JWKS is a JSON Web Key Set.
+	JWKS struct {
+		Keys []*JWK `json:"keys"`
+	}
+
+	// JWK is a JSON Web Key holding a RSA or elliptic curve public key.
+	JWK struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid,omitempty"`
+		Use string `json:"use,omitempty"`
+		Alg string `json:"alg,omitempty"`
+		N   string `json:"n,omitempty"`
+		E   string `json:"e,omitempty"`
+		Crv string `json:"crv,omitempty"`
+		X   string `json:"x,omitempty"`
+		Y   string `json:"y,omitempty"`
+	}
+
+	// OIDCProvider retrieves and caches the discovery document and the signing keys of an
+	// OpenID Connect provider. The keys are retrieved again once RefreshInterval has elapsed
+	// and when a token is signed with an unknown key so that key rotations are picked up.
+	OIDCProvider struct {
+		// Issuer is the provider issuer URL.
+		Issuer string
+		// Client is the HTTP client used to retrieve the discovery document and the keys,
+		// http.DefaultClient if nil.
+		Client *http.Client
+		// RefreshInterval is the maximum time the keys are cached, one hour if zero.
+		RefreshInterval time.Duration
+		// MinRefreshInterval is the minimum time between two retrievals of the keys, one
+		// minute if zero. It prevents tokens signed with unknown keys from flooding the
+		// provider.
+		MinRefreshInterval time.Duration
+
+		mu        sync.Mutex
+		discovery *OIDCDiscovery
+		keys      map[string]interface{}
+		anonymous []interface{}
+		fetched   time.Time
+	}
+)
+
+// NewOIDCProvider returns a provider for the given issuer URL.
+func NewOIDCProvider(issuer string) *OIDCProvider {
+	return &OIDCProvider{Issuer: issuer}
+}
+
+// OIDC returns a middleware that authenticates requests with the OpenID Connect ID token read
+// from the header or query string parameter described by the scheme. The token signature is
+// verified with the keys published by the provider, the "iss" claim must match the scheme issuer
+// and the "aud" claim must include the scheme audience if not empty. The middleware uses a new
+// OIDCProvider for the scheme issuer if provider is nil.
+// Scopes are read from the "scope" or "scopes" claims as with JWT and the token claims are stored
+// in the context given to the action handler where ContextClaims retrieves them, the claims are
+// also the request principal.
+func OIDC(scheme *goa.OIDCSecurity, provider *OIDCProvider) goa.Middleware {
+	if provider == nil {
+		provider = NewOIDCProvider(scheme.Issuer)
+	}
+	keyf := func(h *jwtHeader) ([]interface{}, error) { return provider.Keys(h.Kid) }
+	return func(h goa.Handler) goa.Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			token := credentials(scheme.In, scheme.Name, req)
+			if scheme.In != "query" {
+				token = bearerToken(token)
+			}
+			if token == "" {
+				return goa.ErrUnauthorized("missing token")
+			}
+			claims, err := parseJWT(token, keyf, time.Now())
+			if err == nil {
+				err = checkOIDCClaims(claims, scheme)
+			}
+			if err != nil {
+				return goa.ErrUnauthorized("invalid token: %s", err)
+			}
+			if err := goa.ValidateScopes(ctx, claimScopes(claims)); err != nil {
+				return err
+			}
+			ctx = context.WithValue(ctx, claimsKey{}, claims)
+			ctx = goa.WithPrincipal(ctx, claims)
+			return h(ctx, rw, req)
+		}
+	}
+}
+
+// FetchOIDCDiscovery retrieves the discovery document of the provider with the given issuer URL
+// using the given client, http.DefaultClient if nil.
+func FetchOIDCDiscovery(client *http.Client, issuer string) (*OIDCDiscovery, error) {
+	var doc OIDCDiscovery
+	if err := getJSON(client, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, err
+	}
+	if doc.Issuer != issuer {
+		return nil, fmt.Errorf("discovery document issuer %#v does not match %#v", doc.Issuer, issuer)
+	}
+	return &doc, nil
+}
+
+// Discovery returns the provider discovery document, it is retrieved on first use.
+func (p *OIDCProvider) Discovery() (*OIDCDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.loadDiscovery()
+}
+
+// Keys returns the keys that may verify the signature of a token signed with the key with the
+// given ID. The keys are retrieved again if kid is unknown, an empty kid selects the keys that
+// have no ID.
+func (p *OIDCProvider) Keys(kid string) ([]interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	refresh := p.RefreshInterval
+	if refresh == 0 {
+		refresh = time.Hour
+	}
+	minRefresh := p.MinRefreshInterval
+	if minRefresh == 0 {
+		minRefresh = time.Minute
+	}
+	key, known := p.keys[kid]
+	stale := now.Sub(p.fetched) >= refresh
+	if p.keys == nil || stale || (!known && kid != "" && now.Sub(p.fetched) >= minRefresh) {
+		if err := p.loadKeys(now); err != nil {
+			if p.keys == nil {
+				return nil, err
+			}
+			goa.Error(goa.RootContext, "oidc keys", goa.KV{"issuer", p.Issuer}, goa.KV{"err", err})
+		}
+		key, known = p.keys[kid]
+	}
+	if kid == "" {
+		return p.anonymous, nil
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown key %#v", kid)
+	}
+	return []interface{}{key}, nil
+}
+
+// loadDiscovery retrieves the discovery document if not done yet.
+func (p *OIDCProvider) loadDiscovery() (*OIDCDiscovery, error) {
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+	doc, err := FetchOIDCDiscovery(p.Client, p.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	p.discovery = doc
+	return doc, nil
+}
+
+// loadKeys retrieves the provider keys. Keys of unsupported types are ignored.
+func (p *OIDCProvider) loadKeys(now time.Time) error {
+	// Record the attempt so that failures are rate limited as well.
+	p.fetched = now
+	doc, err := p.loadDiscovery()
+	if err != nil {
+		return err
+	}
+	var set JWKS
+	if err := getJSON(p.Client, doc.JWKSURI, &set); err != nil {
+		return err
+	}
+	keys := make(map[string]interface{})
+	var anonymous []interface{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.PublicKey()
+		if err != nil {
+			continue
+		}
+		if k.Kid == "" {
+			anonymous = append(anonymous, pub)
+		} else {
+			keys[k.Kid] = pub
+		}
+	}
+	p.keys, p.anonymous = keys, anonymous
+	return nil
+}
+
+// NewJWK returns the JWK representation of the given RSA or elliptic curve public key.
+func NewJWK(kid string, key interface{}) (*JWK, error) {
+	enc := base64.RawURLEncoding
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		e := big.NewInt(int64(k.E)).Bytes()
+		return &JWK{Kty: "RSA", Kid: kid, Use: "sig", N: enc.EncodeToString(k.N.Bytes()), E: enc.EncodeToString(e)}, nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return &JWK{
+			Kty: "EC",
+			Kid: kid,
+			Use: "sig",
+			Crv: k.Curve.Params().Name,
+			X:   enc.EncodeToString(padLeft(k.X.Bytes(), size)),
+			Y:   enc.EncodeToString(padLeft(k.Y.Bytes(), size)),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T", key)
+}
+
+// PublicKey returns the *rsa.PublicKey or *ecdsa.PublicKey represented by the JWK.
+func (k *JWK) PublicKey() (interface{}, error) {
+	dec := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err := dec.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := dec.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %#v", k.Crv)
+		}
+		x, err := dec.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := dec.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %#v", k.Kty)
+}
+
+// MountOIDCDiscovery mounts the discovery document and the key set with the public keys that
+// verify the tokens issued by the service on the service mux. The document is served at
+// "/.well-known/openid-configuration" and the key set at the path of its jwks_uri URL. The keys
+// are indexed by key ID.
+func MountOIDCDiscovery(service *goa.Service, doc *OIDCDiscovery, keys map[string]interface{}) error {
+	set := &JWKS{Keys: []*JWK{}}
+	for kid, key := range keys {
+		jwk, err := NewJWK(kid, key)
+		if err != nil {
+			return err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	jwksPath := doc.JWKSURI
+	if i := strings.Index(jwksPath, "://"); i >= 0 {
+		jwksPath = jwksPath[i+3:]
+		if j := strings.Index(jwksPath, "/"); j >= 0 {
+			jwksPath = jwksPath[j:]
+		}
+	}
+	serve := func(body interface{}) goa.MuxHandler {
+		return func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			rw.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(rw).Encode(body)
+		}
+	}
+	service.Mux.Handle("GET", "/.well-known/openid-configuration", serve(doc))
+	service.Mux.Handle("GET", jwksPath, serve(set))
+	goa.Info(goa.RootContext, "mount oidc", goa.KV{"discovery", "GET /.well-known/openid-configuration"}, goa.KV{"jwks", "GET " + jwksPath})
+	return nil
+}
+
+// checkOIDCClaims checks the issuer and audience of the token claims. OpenID Connect requires ID
+// tokens to expire so the "exp" claim must be present.
+func checkOIDCClaims(claims map[string]interface{}, scheme *goa.OIDCSecurity) error {
+	if iss, _ := claims["iss"].(string); iss != scheme.Issuer {
+		return fmt.Errorf("unexpected issuer %#v", claims["iss"])
+	}
+	if _, ok := claims["exp"].(float64); !ok {
+		return errors.New("missing expiry")
+	}
+	if scheme.Audience == "" {
+		return nil
+	}
+	switch aud := claims["aud"].(type) {
+	case string:
+		if aud == scheme.Audience {
+			return nil
+		}
+	case []interface{}:
+		for _, a := range aud {
+			if a == scheme.Audience {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("token was not issued for audience %#v", scheme.Audience)
+}
+
+// getJSON retrieves and decodes the JSON document at the given URL.
+func getJSON(client *http.Client, url string, v interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// padLeft pads b with zeros up to the given size.
+func padLeft(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
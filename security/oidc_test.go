@@ -0,0 +1,168 @@
+package security_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/security"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// rs256 returns a token with the given claims signed with key and carrying the given key ID.
+func rs256(key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	enc := base64.RawURLEncoding
+	h, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	c, _ := json.Marshal(claims)
+	signed := enc.EncodeToString(h) + "." + enc.EncodeToString(c)
+	sum := sha256.Sum256([]byte(signed))
+	sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	return signed + "." + enc.EncodeToString(sig)
+}
+
+var _ = Describe("OIDC", func() {
+	var key, rotated *rsa.PrivateKey
+	var keys map[string]interface{}
+	var server *httptest.Server
+	var scheme *goa.OIDCSecurity
+	var provider *security.OIDCProvider
+	var claims map[string]interface{}
+
+	BeforeEach(func() {
+		var err error
+		key, err = rsa.GenerateKey(rand.Reader, 1024)
+		Ω(err).ShouldNot(HaveOccurred())
+		rotated, err = rsa.GenerateKey(rand.Reader, 1024)
+		Ω(err).ShouldNot(HaveOccurred())
+		keys = map[string]interface{}{"k1": &key.PublicKey}
+		service := goa.New("idp")
+		server = httptest.NewServer(service.Mux)
+		doc := &security.OIDCDiscovery{Issuer: server.URL, JWKSURI: server.URL + "/keys"}
+		service.Mux.Handle("GET", "/.well-known/openid-configuration", func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			json.NewEncoder(rw).Encode(doc)
+		})
+		service.Mux.Handle("GET", "/keys", func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			set := &security.JWKS{}
+			for kid, k := range keys {
+				jwk, _ := security.NewJWK(kid, k)
+				set.Keys = append(set.Keys, jwk)
+			}
+			json.NewEncoder(rw).Encode(set)
+		})
+		scheme = &goa.OIDCSecurity{Issuer: server.URL, Audience: "cellar", In: "header", Name: "Authorization"}
+		provider = &security.OIDCProvider{Issuer: server.URL, MinRefreshInterval: time.Nanosecond}
+		claims = map[string]interface{}{
+			"iss":   server.URL,
+			"aud":   []string{"cellar", "other"},
+			"sub":   "alice",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": "api:read",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	request := func(token string) *http.Request {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	It("accepts ID tokens signed with the provider keys", func() {
+		ctx, _, err := serve(security.OIDC(scheme, provider), request(rs256(key, "k1", claims)), "api:read")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(security.ContextClaims(ctx)).Should(HaveKeyWithValue("sub", "alice"))
+	})
+
+	It("retrieves the discovery document", func() {
+		doc, err := provider.Discovery()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(doc.JWKSURI).Should(Equal(server.URL + "/keys"))
+	})
+
+	It("picks up rotated keys", func() {
+		_, _, err := serve(security.OIDC(scheme, provider), request(rs256(key, "k1", claims)))
+		Ω(err).ShouldNot(HaveOccurred())
+		keys = map[string]interface{}{"k2": &rotated.PublicKey}
+		_, _, err = serve(security.OIDC(scheme, provider), request(rs256(rotated, "k2", claims)))
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("rejects tokens signed with unknown keys", func() {
+		_, _, err := serve(security.OIDC(scheme, provider), request(rs256(rotated, "k1", claims)))
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(401))
+	})
+
+	It("rejects tokens issued for another audience", func() {
+		claims["aud"] = "other"
+		_, _, err := serve(security.OIDC(scheme, provider), request(rs256(key, "k1", claims)))
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(401))
+	})
+
+	It("rejects tokens issued by another issuer", func() {
+		claims["iss"] = "https://evil.example.com"
+		_, _, err := serve(security.OIDC(scheme, provider), request(rs256(key, "k1", claims)))
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("rejects tokens that do not expire", func() {
+		delete(claims, "exp")
+		_, _, err := serve(security.OIDC(scheme, provider), request(rs256(key, "k1", claims)))
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("rejects tokens missing required scopes", func() {
+		_, _, err := serve(security.OIDC(scheme, provider), request(rs256(key, "k1", claims)), "api:write")
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(403))
+	})
+})
+
+var _ = Describe("JWK", func() {
+	It("round trips RSA public keys", func() {
+		key, err := rsa.GenerateKey(rand.Reader, 1024)
+		Ω(err).ShouldNot(HaveOccurred())
+		jwk, err := security.NewJWK("k1", &key.PublicKey)
+		Ω(err).ShouldNot(HaveOccurred())
+		pub, err := jwk.PublicKey()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(pub).Should(Equal(&key.PublicKey))
+	})
+})
+
+var _ = Describe("MountOIDCDiscovery", func() {
+	It("serves the discovery document and the key set", func() {
+		key, err := rsa.GenerateKey(rand.Reader, 1024)
+		Ω(err).ShouldNot(HaveOccurred())
+		service := goa.New("idp")
+		doc := &security.OIDCDiscovery{Issuer: "https://idp.example.com", JWKSURI: "https://idp.example.com/oauth2/keys"}
+		err = security.MountOIDCDiscovery(service, doc, map[string]interface{}{"k1": &key.PublicKey})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		rw := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/oauth2/keys", nil)
+		service.Mux.ServeHTTP(rw, req)
+		var set security.JWKS
+		Ω(json.Unmarshal(rw.Body.Bytes(), &set)).ShouldNot(HaveOccurred())
+		Ω(set.Keys).Should(HaveLen(1))
+		Ω(set.Keys[0].Kid).Should(Equal("k1"))
+
+		rw = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/.well-known/openid-configuration", nil)
+		service.Mux.ServeHTTP(rw, req)
+		Ω(rw.Body.String()).Should(ContainSubstring(`"jwks_uri":"https://idp.example.com/oauth2/keys"`))
+	})
+})
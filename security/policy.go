@@ -0,0 +1,115 @@
+package security
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+type (
+	// Policy decides whether a request is allowed. Role based policies look at the request
+	// principal, attribute based policies may also look at the decoded parameters, the action
+	// metadata or any value stored in the context.
+	Policy func(ctx context.Context, req *goa.AuthorizationRequest) (bool, error)
+
+	// RolesFunc returns the roles granted to a principal.
+	RolesFunc func(principal interface{}) []string
+
+	// PolicyEngine is a goa.Authorizer that evaluates the policies registered by name. The
+	// policy names are the names given to Authorize in the design.
+	PolicyEngine struct {
+		mu       sync.RWMutex
+		policies map[string]Policy
+	}
+)
+
+// NewPolicyEngine returns a policy engine with no policy.
+func NewPolicyEngine() *PolicyEngine {
+	return &PolicyEngine{policies: make(map[string]Policy)}
+}
+
+// Register registers the policy with the given name, it replaces any policy previously registered
+// with the same name.
+func (e *PolicyEngine) Register(name string, p Policy) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.policies[name] = p
+}
+
+// Authorize evaluates the policy named by the request. Requests whose policy is not registered
+// produce an error so that a missing registration does not silently allow or deny requests.
+func (e *PolicyEngine) Authorize(ctx context.Context, req *goa.AuthorizationRequest) (bool, error) {
+	e.mu.RLock()
+	p, ok := e.policies[req.Policy]
+	e.mu.RUnlock()
+	if !ok {
+		return false, goa.ErrInternal("unknown policy %#v", req.Policy)
+	}
+	return p(ctx, req)
+}
+
+// RequireRole returns a policy that allows the requests whose principal has at least one of the
+// given roles. Unauthenticated requests are denied.
+func RequireRole(roles RolesFunc, allowed ...string) Policy {
+	return func(ctx context.Context, req *goa.AuthorizationRequest) (bool, error) {
+		if req.Principal == nil {
+			return false, nil
+		}
+		for _, r := range roles(req.Principal) {
+			if contains(allowed, r) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// ClaimRoles returns a RolesFunc that reads the roles from the given claim of the principals set
+// by the JWT and OIDC middleware. The claim value is either a list or a space separated string.
+func ClaimRoles(claim string) RolesFunc {
+	return func(principal interface{}) []string {
+		claims, ok := principal.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		switch v := claims[claim].(type) {
+		case string:
+			return strings.Fields(v)
+		case []interface{}:
+			roles := make([]string, 0, len(v))
+			for _, r := range v {
+				if s, ok := r.(string); ok {
+					roles = append(roles, s)
+				}
+			}
+			return roles
+		}
+		return nil
+	}
+}
+
+// AllOf returns a policy that allows the requests allowed by all the given policies.
+func AllOf(policies ...Policy) Policy {
+	return func(ctx context.Context, req *goa.AuthorizationRequest) (bool, error) {
+		for _, p := range policies {
+			if ok, err := p(ctx, req); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// AnyOf returns a policy that allows the requests allowed by at least one of the given policies.
+func AnyOf(policies ...Policy) Policy {
+	return func(ctx context.Context, req *goa.AuthorizationRequest) (bool, error) {
+		for _, p := range policies {
+			if ok, err := p(ctx, req); err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+}
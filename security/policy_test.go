@@ -0,0 +1,90 @@
+package security_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/security"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("PolicyEngine", func() {
+	var service *goa.Service
+	var principal interface{}
+	var policy string
+	var err error
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		engine := security.NewPolicyEngine()
+		engine.Register("bottle:admin", security.RequireRole(security.ClaimRoles("roles"), "admin"))
+		engine.Register("bottle:owner", security.AllOf(
+			security.RequireRole(security.ClaimRoles("roles"), "user", "admin"),
+			func(ctx context.Context, req *goa.AuthorizationRequest) (bool, error) {
+				return req.RawParams.Get("owner") == req.Principal.(map[string]interface{})["sub"], nil
+			},
+		))
+		service.SetAuthorizer(engine)
+		principal = map[string]interface{}{"sub": "joe", "roles": "user"}
+		policy = "bottle:admin"
+	})
+
+	JustBeforeEach(func() {
+		req, _ := http.NewRequest("GET", "/bottles/1?owner=joe", nil)
+		ctx := goa.NewContext(nil, service, httptest.NewRecorder(), req, req.URL.Query())
+		if principal != nil {
+			ctx = goa.WithPrincipal(ctx, principal)
+		}
+		err = service.Authorize(ctx, policy, nil, nil)
+	})
+
+	It("denies requests without the role", func() {
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(403))
+	})
+
+	Context("with a principal with the role", func() {
+		BeforeEach(func() {
+			principal = map[string]interface{}{"sub": "joe", "roles": []interface{}{"admin"}}
+		})
+
+		It("allows the request", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("with no principal", func() {
+		BeforeEach(func() {
+			principal = nil
+		})
+
+		It("denies the request", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(403))
+		})
+	})
+
+	Context("with an attribute based policy", func() {
+		BeforeEach(func() {
+			policy = "bottle:owner"
+		})
+
+		It("uses the request parameters", func() {
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Context("with an unknown policy", func() {
+		BeforeEach(func() {
+			policy = "unknown"
+		})
+
+		It("fails", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(500))
+		})
+	})
+})
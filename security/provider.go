@@ -0,0 +1,497 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+)
+
+// OAuth2 grant types accepted by the token endpoint.
+const (
+	// AuthorizationCodeGrant exchanges an authorization code for an access token.
+	AuthorizationCodeGrant = "authorization_code"
+	// PasswordGrant exchanges the resource owner credentials for an access token.
+	PasswordGrant = "password"
+	// ClientCredentialsGrant exchanges the client credentials for an access token.
+	ClientCredentialsGrant = "client_credentials"
+	// RefreshTokenGrant exchanges a refresh token for a new access token.
+	RefreshTokenGrant = "refresh_token"
+)
+
+// ErrAccessDenied is the error returned by OAuth2Authorizer functions when the resource owner
+// denies the authorization request.
+var ErrAccessDenied = errors.New("access denied")
+
+type (
+	// OAuth2Client is a client registered with the authorization server.
+	OAuth2Client struct {
+		// ID is the client identifier.
+		ID string
+		// Secret is the client secret, empty for public clients.
+		Secret string
+		// RedirectURIs lists the redirection endpoints registered by the client.
+		RedirectURIs []string
+		// Scopes lists the scopes the client may request, nil means any scope.
+		Scopes []string
+	}
+
+	// OAuth2Token is an access token issued by the authorization server.
+	OAuth2Token struct {
+		// AccessToken is the access token value.
+		AccessToken string
+		// RefreshToken is the refresh token value if any.
+		RefreshToken string
+		// ClientID is the identifier of the client the token was issued to.
+		ClientID string
+		// UserID identifies the resource owner, empty for client credentials tokens.
+		UserID string
+		// Scopes lists the scopes granted by the token.
+		Scopes []string
+		// ExpiresAt is the access token expiry time.
+		ExpiresAt time.Time
+	}
+
+	// OAuth2Code is an authorization code issued by the authorize endpoint.
+	OAuth2Code struct {
+		// Code is the authorization code value.
+		Code string
+		// ClientID is the identifier of the client the code was issued to.
+		ClientID string
+		// UserID identifies the resource owner who granted the authorization.
+		UserID string
+		// RedirectURI is the redirection URI given in the authorization request.
+		RedirectURI string
+		// Scopes lists the scopes granted by the resource owner.
+		Scopes []string
+		// ExpiresAt is the code expiry time.
+		ExpiresAt time.Time
+	}
+
+	// OAuth2ClientStore gives access to the registered clients.
+	OAuth2ClientStore interface {
+		// Client returns the client with the given ID, nil if there is none.
+		Client(ctx context.Context, id string) (*OAuth2Client, error)
+	}
+
+	// OAuth2TokenStore persists the tokens and authorization codes issued by the server.
+	OAuth2TokenStore interface {
+		// SaveToken persists the given token.
+		SaveToken(ctx context.Context, t *OAuth2Token) error
+		// Token returns the token with the given access token value, nil if there is none.
+		Token(ctx context.Context, access string) (*OAuth2Token, error)
+		// RefreshToken returns the token with the given refresh token value, nil if there is
+		// none.
+		RefreshToken(ctx context.Context, refresh string) (*OAuth2Token, error)
+		// RevokeToken deletes the given token.
+		RevokeToken(ctx context.Context, t *OAuth2Token) error
+		// SaveCode persists the given authorization code.
+		SaveCode(ctx context.Context, c *OAuth2Code) error
+		// ConsumeCode deletes and returns the authorization code with the given value, nil if
+		// there is none. A code may only be consumed once.
+		ConsumeCode(ctx context.Context, code string) (*OAuth2Code, error)
+	}
+
+	// OAuth2Authenticator checks the resource owner credentials given to the password grant and
+	// returns the resource owner identifier.
+	OAuth2Authenticator func(ctx context.Context, username, password string) (string, error)
+
+	// OAuth2Authorizer authenticates the resource owner making an authorization request and
+	// obtains their consent, typically by rendering a login and consent page. It returns the
+	// resource owner identifier or ErrAccessDenied if the owner denies the request. Returning
+	// an empty identifier and no error means the authorizer wrote the response itself, for
+	// example the login page.
+	OAuth2Authorizer func(ctx context.Context, rw http.ResponseWriter, req *http.Request, client *OAuth2Client, scopes []string) (string, error)
+
+	// OAuth2Provider implements the token and authorize endpoints of an OAuth2 authorization
+	// server hosted by the service. The grants it accepts depend on Flow: the access code flow
+	// accepts the authorization_code and refresh_token grants, the password flow the password
+	// and refresh_token grants, the application flow the client_credentials grant and the
+	// implicit flow issues tokens from the authorize endpoint only.
+	OAuth2Provider struct {
+		// Flow is the OAuth2 flow implemented by the provider: "accessCode", "implicit",
+		// "password" or "application".
+		Flow string
+		// Clients gives access to the registered clients.
+		Clients OAuth2ClientStore
+		// Tokens persists the issued tokens and codes.
+		Tokens OAuth2TokenStore
+		// Authenticate checks the resource owner credentials of the password grant.
+		Authenticate OAuth2Authenticator
+		// Authorize obtains the resource owner consent in the authorize endpoint.
+		Authorize OAuth2Authorizer
+		// TokenTTL is the lifetime of the access tokens, one hour if zero.
+		TokenTTL time.Duration
+		// CodeTTL is the lifetime of the authorization codes, ten minutes if zero.
+		CodeTTL time.Duration
+	}
+
+	// oauth2Error is the body of the error responses defined by RFC 6749.
+	oauth2Error struct {
+		status      int
+		Code        string `json:"error"`
+		Description string `json:"error_description,omitempty"`
+	}
+
+	// tokenKey is the context key used to store the validated OAuth2 token.
+	tokenKey struct{}
+)
+
+// Error returns the error description.
+func (e *oauth2Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// Mount registers the authorize and token endpoints used by the provider flow with the service
+// mux. The paths are the authorization and token URLs defined in the design.
+func (p *OAuth2Provider) Mount(service *goa.Service, authorizePath, tokenPath string) {
+	if authorizePath != "" && (p.Flow == "accessCode" || p.Flow == "implicit") {
+		service.Mux.Handle("GET", authorizePath, func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+			p.ServeAuthorize(goa.NewContext(nil, service, rw, req, params), rw, req)
+		})
+		goa.Info(goa.RootContext, "mount oauth2", goa.KV{"authorize", "GET " + authorizePath})
+	}
+	if tokenPath != "" && p.Flow != "implicit" {
+		service.Mux.Handle("POST", tokenPath, func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+			p.ServeToken(goa.NewContext(nil, service, rw, req, params), rw, req)
+		})
+		goa.Info(goa.RootContext, "mount oauth2", goa.KV{"token", "POST " + tokenPath})
+	}
+}
+
+// ServeToken serves a token endpoint request.
+func (p *OAuth2Provider) ServeToken(ctx context.Context, rw http.ResponseWriter, req *http.Request) {
+	token, err := p.token(ctx, req)
+	if err != nil {
+		e, ok := err.(*oauth2Error)
+		if !ok {
+			goa.Error(ctx, "oauth2 token", goa.KV{"err", err})
+			e = &oauth2Error{status: 500, Code: "server_error"}
+		}
+		if e.status == 401 {
+			rw.Header().Set("WWW-Authenticate", "Basic")
+		}
+		writeJSON(rw, e.status, e)
+		return
+	}
+	body := map[string]interface{}{
+		"access_token": token.AccessToken,
+		"token_type":   "bearer",
+		"expires_in":   int(p.tokenTTL().Seconds()),
+	}
+	if token.RefreshToken != "" {
+		body["refresh_token"] = token.RefreshToken
+	}
+	if len(token.Scopes) > 0 {
+		body["scope"] = strings.Join(token.Scopes, " ")
+	}
+	writeJSON(rw, 200, body)
+}
+
+// ServeAuthorize serves an authorize endpoint request. Invalid clients or redirection URIs
+// produce a 400 response, other errors are reported to the client redirection URI.
+func (p *OAuth2Provider) ServeAuthorize(ctx context.Context, rw http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+	client, err := p.Clients.Client(ctx, q.Get("client_id"))
+	if err != nil {
+		goa.Error(ctx, "oauth2 authorize", goa.KV{"err", err})
+		writeJSON(rw, 500, &oauth2Error{Code: "server_error"})
+		return
+	}
+	if client == nil {
+		writeJSON(rw, 400, &oauth2Error{Code: "invalid_request", Description: "unknown client"})
+		return
+	}
+	redirect := q.Get("redirect_uri")
+	if redirect == "" && len(client.RedirectURIs) == 1 {
+		redirect = client.RedirectURIs[0]
+	}
+	if !contains(client.RedirectURIs, redirect) {
+		writeJSON(rw, 400, &oauth2Error{Code: "invalid_request", Description: "invalid redirect URI"})
+		return
+	}
+	implicit := p.Flow == "implicit"
+	params := url.Values{}
+	if state := q.Get("state"); state != "" {
+		params.Set("state", state)
+	}
+	fail := func(code string) {
+		params.Set("error", code)
+		http.Redirect(rw, req, redirectURL(redirect, params, implicit), http.StatusFound)
+	}
+	if (implicit && q.Get("response_type") != "token") || (!implicit && q.Get("response_type") != "code") {
+		fail("unsupported_response_type")
+		return
+	}
+	scopes, ok := grantedScopes(strings.Fields(q.Get("scope")), client.Scopes)
+	if !ok {
+		fail("invalid_scope")
+		return
+	}
+	if p.Authorize == nil {
+		fail("server_error")
+		return
+	}
+	user, err := p.Authorize(ctx, rw, req, client, scopes)
+	if err == ErrAccessDenied {
+		fail("access_denied")
+		return
+	}
+	if err != nil {
+		goa.Error(ctx, "oauth2 authorize", goa.KV{"err", err})
+		fail("server_error")
+		return
+	}
+	if user == "" {
+		return
+	}
+	if implicit {
+		token, err := p.issue(ctx, client.ID, user, scopes, false)
+		if err != nil {
+			goa.Error(ctx, "oauth2 authorize", goa.KV{"err", err})
+			fail("server_error")
+			return
+		}
+		params.Set("access_token", token.AccessToken)
+		params.Set("token_type", "bearer")
+		params.Set("expires_in", fmt.Sprintf("%d", int(p.tokenTTL().Seconds())))
+		if len(scopes) > 0 {
+			params.Set("scope", strings.Join(scopes, " "))
+		}
+	} else {
+		ttl := p.CodeTTL
+		if ttl == 0 {
+			ttl = 10 * time.Minute
+		}
+		code := &OAuth2Code{
+			Code:        newSecret(),
+			ClientID:    client.ID,
+			UserID:      user,
+			RedirectURI: q.Get("redirect_uri"),
+			Scopes:      scopes,
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+		if err := p.Tokens.SaveCode(ctx, code); err != nil {
+			goa.Error(ctx, "oauth2 authorize", goa.KV{"err", err})
+			fail("server_error")
+			return
+		}
+		params.Set("code", code.Code)
+	}
+	http.Redirect(rw, req, redirectURL(redirect, params, implicit), http.StatusFound)
+}
+
+// Validate validates an access token issued by the provider, it can be given to the OAuth2
+// middleware so that the service both issues and accepts the tokens. The validated token is
+// stored in the context returned to the middleware where ContextOAuth2Token retrieves it.
+func (p *OAuth2Provider) Validate(ctx context.Context, access string) (context.Context, []string, error) {
+	token, err := p.Tokens.Token(ctx, access)
+	if err != nil {
+		return ctx, nil, err
+	}
+	if token == nil || time.Now().After(token.ExpiresAt) {
+		return ctx, nil, errors.New("invalid or expired access token")
+	}
+	return context.WithValue(ctx, tokenKey{}, token), token.Scopes, nil
+}
+
+// ContextOAuth2Token returns the token validated by OAuth2Provider.Validate for the request with
+// the given context, nil if there is none.
+func ContextOAuth2Token(ctx context.Context) *OAuth2Token {
+	if t := ctx.Value(tokenKey{}); t != nil {
+		return t.(*OAuth2Token)
+	}
+	return nil
+}
+
+// token authenticates the client and issues a token according to the request grant type.
+func (p *OAuth2Provider) token(ctx context.Context, req *http.Request) (*OAuth2Token, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, &oauth2Error{status: 400, Code: "invalid_request", Description: err.Error()}
+	}
+	id, secret, ok := req.BasicAuth()
+	if !ok {
+		id, secret = req.PostForm.Get("client_id"), req.PostForm.Get("client_secret")
+	}
+	client, err := p.Clients.Client(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || subtle.ConstantTimeCompare([]byte(client.Secret), []byte(secret)) != 1 {
+		return nil, &oauth2Error{status: 401, Code: "invalid_client"}
+	}
+	grant := req.PostForm.Get("grant_type")
+	if !p.accepts(grant) {
+		return nil, &oauth2Error{status: 400, Code: "unsupported_grant_type"}
+	}
+	requested := strings.Fields(req.PostForm.Get("scope"))
+	invalidGrant := &oauth2Error{status: 400, Code: "invalid_grant"}
+	switch grant {
+	case AuthorizationCodeGrant:
+		code, err := p.Tokens.ConsumeCode(ctx, req.PostForm.Get("code"))
+		if err != nil {
+			return nil, err
+		}
+		if code == nil || code.ClientID != client.ID || time.Now().After(code.ExpiresAt) ||
+			code.RedirectURI != req.PostForm.Get("redirect_uri") {
+			return nil, invalidGrant
+		}
+		return p.issue(ctx, client.ID, code.UserID, code.Scopes, true)
+	case PasswordGrant:
+		if p.Authenticate == nil {
+			return nil, &oauth2Error{status: 400, Code: "unsupported_grant_type"}
+		}
+		user, err := p.Authenticate(ctx, req.PostForm.Get("username"), req.PostForm.Get("password"))
+		if err != nil {
+			return nil, invalidGrant
+		}
+		scopes, ok := grantedScopes(requested, client.Scopes)
+		if !ok {
+			return nil, &oauth2Error{status: 400, Code: "invalid_scope"}
+		}
+		return p.issue(ctx, client.ID, user, scopes, true)
+	case ClientCredentialsGrant:
+		scopes, ok := grantedScopes(requested, client.Scopes)
+		if !ok {
+			return nil, &oauth2Error{status: 400, Code: "invalid_scope"}
+		}
+		return p.issue(ctx, client.ID, "", scopes, false)
+	default: // RefreshTokenGrant
+		old, err := p.Tokens.RefreshToken(ctx, req.PostForm.Get("refresh_token"))
+		if err != nil {
+			return nil, err
+		}
+		if old == nil || old.ClientID != client.ID {
+			return nil, invalidGrant
+		}
+		allowed := old.Scopes
+		if allowed == nil {
+			allowed = []string{}
+		}
+		scopes, ok := grantedScopes(requested, allowed)
+		if !ok {
+			return nil, &oauth2Error{status: 400, Code: "invalid_scope"}
+		}
+		if err := p.Tokens.RevokeToken(ctx, old); err != nil {
+			return nil, err
+		}
+		return p.issue(ctx, client.ID, old.UserID, scopes, true)
+	}
+}
+
+// accepts returns true if the provider flow accepts the given grant type.
+func (p *OAuth2Provider) accepts(grant string) bool {
+	switch p.Flow {
+	case "accessCode":
+		return grant == AuthorizationCodeGrant || grant == RefreshTokenGrant
+	case "password":
+		return grant == PasswordGrant || grant == RefreshTokenGrant
+	case "application":
+		return grant == ClientCredentialsGrant
+	}
+	return false
+}
+
+// issue creates and persists a new access token.
+func (p *OAuth2Provider) issue(ctx context.Context, client, user string, scopes []string, refresh bool) (*OAuth2Token, error) {
+	token := &OAuth2Token{
+		AccessToken: newSecret(),
+		ClientID:    client,
+		UserID:      user,
+		Scopes:      scopes,
+		ExpiresAt:   time.Now().Add(p.tokenTTL()),
+	}
+	if refresh {
+		token.RefreshToken = newSecret()
+	}
+	if err := p.Tokens.SaveToken(ctx, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// tokenTTL returns the lifetime of the access tokens.
+func (p *OAuth2Provider) tokenTTL() time.Duration {
+	if p.TokenTTL == 0 {
+		return time.Hour
+	}
+	return p.TokenTTL
+}
+
+// grantedScopes returns the requested scopes or the allowed scopes if none is requested. It
+// returns false if a requested scope is not allowed, nil allowed scopes means any scope.
+func grantedScopes(requested, allowed []string) ([]string, bool) {
+	if len(requested) == 0 {
+		return allowed, true
+	}
+	if allowed == nil {
+		return requested, true
+	}
+	for _, s := range requested {
+		if !contains(allowed, s) {
+			return nil, false
+		}
+	}
+	return requested, true
+}
+
+// redirectURL returns the given redirection URI with the given parameters added to its query
+// string or to its fragment if fragment is true.
+func redirectURL(uri string, params url.Values, fragment bool) string {
+	if fragment {
+		if i := strings.Index(uri, "#"); i >= 0 {
+			uri = uri[:i]
+		}
+		return uri + "#" + params.Encode()
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// writeJSON writes a token endpoint response.
+func writeJSON(rw http.ResponseWriter, status int, body interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(body)
+}
+
+// newSecret returns a random URL safe token or code value.
+func newSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // bug
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// contains returns true if vals contains val.
+func contains(vals []string, val string) bool {
+	for _, v := range vals {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package security_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/security"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+// memoryStore is a minimal implementation of the OAuth2 client and token stores.
+type memoryStore struct {
+	clients map[string]*security.OAuth2Client
+	tokens  map[string]*security.OAuth2Token
+	codes   map[string]*security.OAuth2Code
+}
+
+func newMemoryStore(clients ...*security.OAuth2Client) *memoryStore {
+	s := &memoryStore{
+		clients: make(map[string]*security.OAuth2Client),
+		tokens:  make(map[string]*security.OAuth2Token),
+		codes:   make(map[string]*security.OAuth2Code),
+	}
+	for _, c := range clients {
+		s.clients[c.ID] = c
+	}
+	return s
+}
+
+func (s *memoryStore) Client(ctx context.Context, id string) (*security.OAuth2Client, error) {
+	return s.clients[id], nil
+}
+
+func (s *memoryStore) SaveToken(ctx context.Context, t *security.OAuth2Token) error {
+	s.tokens[t.AccessToken] = t
+	return nil
+}
+
+func (s *memoryStore) Token(ctx context.Context, access string) (*security.OAuth2Token, error) {
+	return s.tokens[access], nil
+}
+
+func (s *memoryStore) RefreshToken(ctx context.Context, refresh string) (*security.OAuth2Token, error) {
+	for _, t := range s.tokens {
+		if t.RefreshToken == refresh {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) RevokeToken(ctx context.Context, t *security.OAuth2Token) error {
+	delete(s.tokens, t.AccessToken)
+	return nil
+}
+
+func (s *memoryStore) SaveCode(ctx context.Context, c *security.OAuth2Code) error {
+	s.codes[c.Code] = c
+	return nil
+}
+
+func (s *memoryStore) ConsumeCode(ctx context.Context, code string) (*security.OAuth2Code, error) {
+	c := s.codes[code]
+	delete(s.codes, code)
+	return c, nil
+}
+
+var _ = Describe("OAuth2Provider", func() {
+	client := &security.OAuth2Client{
+		ID:           "client",
+		Secret:       "secret",
+		RedirectURIs: []string{"https://client.example.com/callback"},
+		Scopes:       []string{"api:read"},
+	}
+	var provider *security.OAuth2Provider
+	var service *goa.Service
+
+	token := func(form url.Values) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req, _ := http.NewRequest("POST", "/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("client", "secret")
+		rw := httptest.NewRecorder()
+		provider.ServeToken(goa.NewContext(nil, service, rw, req, nil), rw, req)
+		var body map[string]interface{}
+		json.Unmarshal(rw.Body.Bytes(), &body)
+		return rw, body
+	}
+
+	BeforeEach(func() {
+		store := newMemoryStore(client)
+		provider = &security.OAuth2Provider{Clients: store, Tokens: store}
+		service = goa.New("test")
+	})
+
+	Context("with the application flow", func() {
+		BeforeEach(func() {
+			provider.Flow = "application"
+		})
+
+		It("issues tokens for the client credentials grant", func() {
+			rw, body := token(url.Values{"grant_type": {"client_credentials"}})
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Header().Get("Cache-Control")).Should(Equal("no-store"))
+			Ω(body).Should(HaveKeyWithValue("token_type", "bearer"))
+			Ω(body).Should(HaveKeyWithValue("scope", "api:read"))
+			Ω(body).ShouldNot(HaveKey("refresh_token"))
+			_, scopes, err := provider.Validate(context.Background(), body["access_token"].(string))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(scopes).Should(Equal([]string{"api:read"}))
+		})
+
+		It("rejects the scopes the client may not request", func() {
+			rw, body := token(url.Values{"grant_type": {"client_credentials"}, "scope": {"api:write"}})
+			Ω(rw.Code).Should(Equal(400))
+			Ω(body).Should(HaveKeyWithValue("error", "invalid_scope"))
+		})
+
+		It("rejects the grants of other flows", func() {
+			rw, body := token(url.Values{"grant_type": {"password"}})
+			Ω(rw.Code).Should(Equal(400))
+			Ω(body).Should(HaveKeyWithValue("error", "unsupported_grant_type"))
+		})
+	})
+
+	Context("with the access code flow", func() {
+		BeforeEach(func() {
+			provider.Flow = "accessCode"
+			provider.Authorize = func(ctx context.Context, rw http.ResponseWriter, req *http.Request, c *security.OAuth2Client, scopes []string) (string, error) {
+				return "joe", nil
+			}
+		})
+
+		It("exchanges the authorization codes once", func() {
+			req, _ := http.NewRequest("GET", "/authorize?response_type=code&client_id=client&state=xyz", nil)
+			rw := httptest.NewRecorder()
+			provider.ServeAuthorize(goa.NewContext(nil, service, rw, req, nil), rw, req)
+			Ω(rw.Code).Should(Equal(302))
+			loc, err := url.Parse(rw.Header().Get("Location"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(loc.Host).Should(Equal("client.example.com"))
+			Ω(loc.Query().Get("state")).Should(Equal("xyz"))
+
+			form := url.Values{"grant_type": {"authorization_code"}, "code": {loc.Query().Get("code")}}
+			rw, body := token(form)
+			Ω(rw.Code).Should(Equal(200))
+			Ω(body).Should(HaveKey("refresh_token"))
+
+			rw, body = token(form)
+			Ω(rw.Code).Should(Equal(400))
+			Ω(body).Should(HaveKeyWithValue("error", "invalid_grant"))
+		})
+
+		It("rejects unknown redirection URIs", func() {
+			req, _ := http.NewRequest("GET", "/authorize?response_type=code&client_id=client&redirect_uri=https://evil.example.com", nil)
+			rw := httptest.NewRecorder()
+			provider.ServeAuthorize(goa.NewContext(nil, service, rw, req, nil), rw, req)
+			Ω(rw.Code).Should(Equal(400))
+			Ω(rw.Header().Get("Location")).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,69 @@
+// Package security provides the middleware that enforce the security schemes defined in the
+// design: JWT, OpenID Connect, API key, basic auth, OAuth2, HMAC and mutual TLS. Each middleware
+// authenticates the requests and checks that their credentials grant the scopes required by the
+// action before calling the action handler.
+//
+// goagen generates a Use<Scheme>Middleware function for each security scheme defined in the
+// design, the main function uses it to mount the middleware built with this package:
+//
+//	app.UseJWTMiddleware(service, security.JWT(app.NewJWTSecurity(), key))
+//	app.UseAPIKeyMiddleware(service, security.APIKey(app.NewAPIKeySecurity(), validateKey))
+//
+// The middleware return errors built with goa.ErrUnauthorized when the credentials are missing or
+// invalid and with goa.ErrForbidden when they do not grant the required scopes.
+//
+// OAuth2Provider implements the token and authorize endpoints of the OAuth2 schemes hosted by the
+// service, the "goagen oauth2" command generates the code that builds and mounts the providers.
+//
+// The OIDC middleware validates the ID tokens issued by an OpenID Connect provider. OIDCProvider
+// retrieves the provider discovery document and caches its signing keys, the keys are retrieved
+// again periodically and when a token is signed with an unknown key:
+//
+//	provider := security.NewOIDCProvider("https://accounts.google.com")
+//	app.UseGoogleMiddleware(service, security.OIDC(app.NewGoogleSecurity(), provider))
+//
+// The claims of the validated token are mapped to the Principal type generated for the scheme,
+// actions retrieve it with the generated Context<Scheme>Principal function.
+//
+// The HMAC middleware verifies the signature of requests signed with goa.SignHMACRequest, the
+// generated clients sign the requests with goa.HMACSigner:
+//
+//	app.UseSignedMiddleware(service, security.HMAC(app.NewSignedSecurity(), lookupSecret))
+//
+// The MutualTLS middleware requires a client certificate verified by the TLS listeners configured
+// with goa.ServerConfig.ClientCAFile, actions retrieve the certificate with
+// ContextClientCertificate.
+package security
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/goadesign/goa"
+)
+
+// credentials returns the value of the header or query string parameter with the given name.
+func credentials(in, name string, req *http.Request) string {
+	if in == "query" {
+		return req.URL.Query().Get(name)
+	}
+	return req.Header.Get(name)
+}
+
+// bearerToken returns the token of a "Bearer" authorization header value, the value itself if
+// it does not use the bearer scheme.
+func bearerToken(val string) string {
+	if len(val) > 7 && strings.EqualFold(val[:7], "bearer ") {
+		return strings.TrimSpace(val[7:])
+	}
+	return val
+}
+
+// unauthorized returns err if it is a goa service error, an error built with
+// goa.ErrUnauthorized otherwise.
+func unauthorized(err error) error {
+	if _, ok := err.(goa.ServiceError); ok {
+		return err
+	}
+	return goa.ErrUnauthorized(err)
+}
@@ -0,0 +1,172 @@
+package security_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/security"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+// serve runs the handler secured by the given middleware with the given required scopes.
+func serve(m goa.Middleware, req *http.Request, scopes ...string) (context.Context, *httptest.ResponseRecorder, error) {
+	service := goa.New("test")
+	service.SetSecurityMiddleware("scheme", m)
+	rw := httptest.NewRecorder()
+	var hctx context.Context
+	h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		hctx = ctx
+		return nil
+	}
+	ctx := goa.NewContext(nil, service, rw, req, nil)
+	err := goa.Secure("scheme", h, scopes...)(ctx, rw, req)
+	return hctx, rw, err
+}
+
+// hs256 returns a JWT with the given claims signed with the given key.
+func hs256(key []byte, claims map[string]interface{}) string {
+	enc := base64.RawURLEncoding
+	c, _ := json.Marshal(claims)
+	signed := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString(c)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signed))
+	return signed + "." + enc.EncodeToString(mac.Sum(nil))
+}
+
+var _ = Describe("JWT", func() {
+	key := []byte("secret")
+	scheme := &goa.JWTSecurity{In: "header", Name: "Authorization"}
+	var claims map[string]interface{}
+	var token string
+
+	var ctx context.Context
+	var err error
+
+	BeforeEach(func() {
+		claims = map[string]interface{}{"sub": "joe", "scope": "api:read api:write"}
+		token = ""
+	})
+
+	JustBeforeEach(func() {
+		if token == "" {
+			token = hs256(key, claims)
+		}
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		ctx, _, err = serve(security.JWT(scheme, key), req, "api:read")
+	})
+
+	It("authenticates the request", func() {
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(security.ContextClaims(ctx)).Should(HaveKeyWithValue("sub", "joe"))
+	})
+
+	Context("with an invalid signature", func() {
+		BeforeEach(func() {
+			token = hs256([]byte("other"), claims)
+		})
+
+		It("rejects the request", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(goa.ErrorStatus(err)).Should(Equal(401))
+		})
+	})
+
+	Context("with an expired token", func() {
+		BeforeEach(func() {
+			claims["exp"] = time.Now().Add(-time.Minute).Unix()
+		})
+
+		It("rejects the request", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(ContainSubstring("expired"))
+		})
+	})
+
+	Context("with missing scopes", func() {
+		BeforeEach(func() {
+			claims["scope"] = "api:write"
+		})
+
+		It("forbids the request", func() {
+			Ω(err).Should(HaveOccurred())
+			Ω(goa.ErrorStatus(err)).Should(Equal(403))
+			Ω(err.Error()).Should(ContainSubstring("api:read"))
+		})
+	})
+})
+
+var _ = Describe("APIKey", func() {
+	scheme := &goa.APIKeySecurity{In: "query", Name: "key"}
+	validate := func(ctx context.Context, key string) (context.Context, error) {
+		if key != "valid" {
+			return ctx, errors.New("unknown key")
+		}
+		return ctx, nil
+	}
+
+	It("authenticates the requests", func() {
+		req, _ := http.NewRequest("GET", "/?key=valid", nil)
+		_, _, err := serve(security.APIKey(scheme, validate), req)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		req, _ = http.NewRequest("GET", "/?key=invalid", nil)
+		_, _, err = serve(security.APIKey(scheme, validate), req)
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(401))
+	})
+})
+
+var _ = Describe("BasicAuth", func() {
+	validate := func(ctx context.Context, user, pass string) (context.Context, error) {
+		if user != "joe" || pass != "secret" {
+			return ctx, errors.New("invalid credentials")
+		}
+		return ctx, nil
+	}
+
+	It("authenticates the requests", func() {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.SetBasicAuth("joe", "secret")
+		_, _, err := serve(security.BasicAuth(&goa.BasicAuthSecurity{}, validate), req)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("challenges unauthenticated requests", func() {
+		req, _ := http.NewRequest("GET", "/", nil)
+		_, rw, err := serve(security.BasicAuth(&goa.BasicAuthSecurity{}, validate), req)
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(401))
+		Ω(rw.Header().Get("WWW-Authenticate")).Should(Equal(`Basic realm="test"`))
+	})
+})
+
+var _ = Describe("OAuth2", func() {
+	validate := func(ctx context.Context, token string) (context.Context, []string, error) {
+		if token != "valid" {
+			return ctx, nil, errors.New("unknown token")
+		}
+		return ctx, []string{"api:read"}, nil
+	}
+
+	It("checks the scopes granted by the token", func() {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer valid")
+		_, _, err := serve(security.OAuth2(&goa.OAuth2Security{}, validate), req, "api:read")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, rw, err := serve(security.OAuth2(&goa.OAuth2Security{}, validate), req, "api:write")
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(403))
+		Ω(rw.Header().Get("WWW-Authenticate")).Should(ContainSubstring("insufficient_scope"))
+	})
+})
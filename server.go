@@ -0,0 +1,412 @@
+// +build !appengine
+
+package goa
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/http3"
+	"golang.org/x/net/context"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+type (
+	// ServerConfig describes the listeners started by a Server. The protocols served are
+	// listed in Schemes using the same names as the API design Scheme DSL:
+	//
+	// * "http" and "ws" serve HTTP/1.1 on Addr.
+	//
+	// * "h2c" serves HTTP/2 without TLS (and HTTP/1.1) on Addr. Clients may either upgrade
+	// HTTP/1.1 connections or use HTTP/2 directly (prior knowledge) as load balancers that
+	// forward HTTP/2 to the service in cleartext typically do.
+	//
+	// * "https" and "wss" serve HTTP/1.1 and HTTP/2 over TLS on TLSAddr.
+	//
+	// * "h3" serves HTTP/3 over QUIC on the UDP port of TLSAddr. It implies "https" as clients
+	// discover HTTP/3 endpoints through the Alt-Svc header added to the responses of the other
+	// listeners.
+	ServerConfig struct {
+		// Schemes lists the protocols served, defaults to "http".
+		Schemes []string
+		// Addr is the address of the cleartext listener, defaults to ":8080".
+		Addr string
+		// TLSAddr is the address of the TLS and QUIC listeners, defaults to ":8443".
+		TLSAddr string
+		// CertFile is the path to the TLS certificate file.
+		CertFile string
+		// KeyFile is the path to the TLS private key file.
+		KeyFile string
+		// TLSConfig is the base configuration of the TLS listeners, it may be nil. The
+		// certificate and key files are optional for the "https" and "wss" schemes if it sets
+		// Certificates or GetCertificate. The listeners use a copy of it.
+		TLSConfig *tls.Config
+		// ClientCAFile is the path to the PEM file containing the certificates of the
+		// authorities that issue client certificates. Setting it makes the TLS listeners
+		// request and verify client certificates. Requests made without a certificate are
+		// accepted, the mutual TLS security middleware rejects them for the actions that
+		// require one.
+		ClientCAFile string
+		// ReadTimeout is the maximum duration for reading entire requests.
+		ReadTimeout time.Duration
+		// WriteTimeout is the maximum duration before timing out writes of responses.
+		WriteTimeout time.Duration
+		// IdleTimeout is the maximum amount of time to wait for the next request on
+		// keep-alive and HTTP/2 connections.
+		IdleTimeout time.Duration
+		// MaxHeaderBytes is the maximum size of request headers, zero means the net/http
+		// default.
+		MaxHeaderBytes int
+		// MaxConcurrentStreams is the maximum number of concurrent HTTP/2 streams per
+		// connection, zero means the golang.org/x/net/http2 default.
+		MaxConcurrentStreams uint32
+		// ShutdownTimeout is the maximum amount of time given to in-flight requests to
+		// complete on shutdown, zero means no limit.
+		ShutdownTimeout time.Duration
+		// MaintenanceFile is the path to the JSON file holding the maintenance state of the
+		// service if any, see Service.LoadMaintenance. The file is loaded when the server
+		// starts and reloaded each time the process receives SIGHUP.
+		MaintenanceFile string
+	}
+
+	// Server serves a service on all the listeners described by its configuration and shuts
+	// them all down gracefully when one of them fails, when Shutdown is called or when the
+	// process receives one of the signals listed in InterruptSignals.
+	Server struct {
+		// Service is the service being served.
+		Service *Service
+		// Config describes the listeners.
+		Config *ServerConfig
+
+		mu        sync.Mutex
+		listeners []listener
+		done      chan struct{}
+	}
+
+	// listener is implemented by the HTTP and HTTP/3 servers started by a Server.
+	listener interface {
+		serve() error
+		shutdown(ctx context.Context) error
+	}
+
+	// httpListener serves HTTP/1.1, h2c or HTTPS requests.
+	httpListener struct {
+		server            *http.Server
+		scheme            string
+		certFile, keyFile string
+	}
+
+	// h3Listener serves HTTP/3 requests.
+	h3Listener struct {
+		server            *http3.Server
+		certFile, keyFile string
+	}
+)
+
+// NewServer returns a server that serves the given service using the given configuration.
+func NewServer(service *Service, conf *ServerConfig) *Server {
+	if conf == nil {
+		conf = &ServerConfig{}
+	}
+	return &Server{Service: service, Config: conf}
+}
+
+// Serve serves the service on the listeners described by conf until the process is interrupted.
+// See Server.
+func (service *Service) Serve(conf *ServerConfig) error {
+	if ServeFunc != nil {
+		return ServeFunc(service)
+	}
+	return NewServer(service, conf).ListenAndServe()
+}
+
+// ListenAndServe starts the listeners and blocks until the server shuts down. It returns the
+// error that caused the shutdown if any.
+func (s *Server) ListenAndServe() error {
+	if s.Config.MaintenanceFile != "" {
+		if err := s.Service.LoadMaintenance(s.Config.MaintenanceFile); err != nil {
+			return err
+		}
+		hupc := make(chan os.Signal, 1)
+		signal.Notify(hupc, syscall.SIGHUP)
+		defer signal.Stop(hupc)
+		stop := make(chan struct{})
+		defer close(stop)
+		go s.reloadMaintenance(hupc, stop)
+	}
+	listeners, err := s.Config.listeners(s.Service.Mux)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.done != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("server already started")
+	}
+	s.listeners = listeners
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+	for _, l := range listeners {
+		s.Service.track(l)
+	}
+
+	errc := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l listener) { errc <- l.serve() }(l)
+	}
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, InterruptSignals...)
+	defer signal.Stop(sigc)
+
+	select {
+	case err = <-errc:
+		err = ignoreClosed(err)
+	case sig := <-sigc:
+		s.Service.LogInfo("Received signal. Initiating graceful shutdown...", KV{"signal", sig})
+	case <-s.done:
+	}
+	if e := s.shutdown(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// reloadMaintenance reloads the maintenance file each time a signal is received on sigc until stop
+// is closed.
+func (s *Server) reloadMaintenance(sigc chan os.Signal, stop chan struct{}) {
+	for {
+		select {
+		case <-sigc:
+			if err := s.Service.LoadMaintenance(s.Config.MaintenanceFile); err != nil {
+				s.Service.LogError("maintenance reload", KV{"err", err})
+				continue
+			}
+			s.Service.LogInfo("maintenance reloaded", KV{"file", s.Config.MaintenanceFile})
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Shutdown gracefully shuts down all the listeners, ListenAndServe returns once they are closed.
+func (s *Server) Shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done == nil {
+		return
+	}
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
+
+// shutdown closes all the listeners, giving in-flight requests up to the configured shutdown
+// timeout to complete.
+func (s *Server) shutdown() error {
+	IncrCounter([]string{"goa", "server", "shutdown"}, 1.0)
+	s.Shutdown()
+	ctx := context.Background()
+	if s.Config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Config.ShutdownTimeout)
+		defer cancel()
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(s.listeners))
+	for i, l := range s.listeners {
+		wg.Add(1)
+		go func(i int, l listener) {
+			defer wg.Done()
+			errs[i] = l.shutdown(ctx)
+		}(i, l)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listeners creates the listeners described by the configuration.
+func (conf *ServerConfig) listeners(handler http.Handler) ([]listener, error) {
+	addr, tlsAddr := conf.Addr, conf.TLSAddr
+	if addr == "" {
+		addr = ":8080"
+	}
+	if tlsAddr == "" {
+		tlsAddr = ":8443"
+	}
+	schemes := conf.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"http"}
+	}
+	var cleartext, h2, secure, h3 bool
+	for _, s := range schemes {
+		switch s {
+		case "http", "ws":
+			cleartext = true
+		case "h2c":
+			cleartext, h2 = true, true
+		case "https", "wss":
+			secure = true
+		case "h3":
+			secure, h3 = true, true
+		default:
+			return nil, fmt.Errorf(`invalid scheme %#v, must be one of "http", "https", "ws", "wss", "h2c" or "h3"`, s)
+		}
+	}
+	noFiles := conf.CertFile == "" || conf.KeyFile == ""
+	if secure && noFiles && (h3 || !hasCertificates(conf.TLSConfig)) {
+		return nil, fmt.Errorf("missing TLS certificate or key file")
+	}
+	var tlsConf *tls.Config
+	if secure {
+		var err error
+		if tlsConf, err = conf.tlsConfig(); err != nil {
+			return nil, err
+		}
+	}
+	if h3 {
+		var err error
+		if handler, err = altSvcHandler(handler, tlsAddr); err != nil {
+			return nil, err
+		}
+	}
+	var listeners []listener
+	if cleartext {
+		scheme, h := "http", handler
+		if h2 {
+			scheme = "h2c"
+			h = h2c.NewHandler(handler, conf.http2Server())
+		}
+		listeners = append(listeners, &httpListener{server: conf.server(addr, h), scheme: scheme})
+	}
+	if secure {
+		srv := conf.server(tlsAddr, handler)
+		srv.TLSConfig = tlsConf
+		if err := http2.ConfigureServer(srv, conf.http2Server()); err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, &httpListener{
+			server:   srv,
+			scheme:   "https",
+			certFile: conf.CertFile,
+			keyFile:  conf.KeyFile,
+		})
+	}
+	if h3 {
+		srv := conf.server(tlsAddr, handler)
+		srv.TLSConfig = tlsConf
+		listeners = append(listeners, &h3Listener{
+			server:   &http3.Server{Server: srv},
+			certFile: conf.CertFile,
+			keyFile:  conf.KeyFile,
+		})
+	}
+	return listeners, nil
+}
+
+// server returns a HTTP server listening on addr and configured with the connection settings.
+func (conf *ServerConfig) server(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:           addr,
+		Handler:        handler,
+		ReadTimeout:    conf.ReadTimeout,
+		WriteTimeout:   conf.WriteTimeout,
+		IdleTimeout:    conf.IdleTimeout,
+		MaxHeaderBytes: conf.MaxHeaderBytes,
+	}
+}
+
+// http2Server returns the configuration of the HTTP/2 connections.
+func (conf *ServerConfig) http2Server() *http2.Server {
+	return &http2.Server{
+		IdleTimeout:          conf.IdleTimeout,
+		MaxConcurrentStreams: conf.MaxConcurrentStreams,
+	}
+}
+
+// tlsConfig returns the configuration of the TLS listeners: a copy of TLSConfig that verifies the
+// client certificates issued by the authorities listed in ClientCAFile if set.
+func (conf *ServerConfig) tlsConfig() (*tls.Config, error) {
+	tlsConf := &tls.Config{}
+	if conf.TLSConfig != nil {
+		tlsConf = conf.TLSConfig.Clone()
+	}
+	if conf.ClientCAFile == "" {
+		return tlsConf, nil
+	}
+	pem, err := ioutil.ReadFile(conf.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in client CA file %s", conf.ClientCAFile)
+	}
+	tlsConf.ClientCAs = pool
+	tlsConf.ClientAuth = tls.VerifyClientCertIfGiven
+	return tlsConf, nil
+}
+
+// hasCertificates returns true if the TLS configuration provides the server certificates.
+func hasCertificates(conf *tls.Config) bool {
+	return conf != nil && (len(conf.Certificates) > 0 || conf.GetCertificate != nil)
+}
+
+// altSvcHandler returns a handler that advertises the HTTP/3 endpoint listening on the UDP port
+// of addr before calling h.
+func altSvcHandler(h http.Handler, addr string) (http.Handler, error) {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	altSvc := fmt.Sprintf(`h3=":%s"; ma=86400`, port)
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Alt-Svc", altSvc)
+		h.ServeHTTP(rw, req)
+	}), nil
+}
+
+func (l *httpListener) serve() error {
+	if l.scheme == "https" {
+		Info(RootContext, "listen ssl", KV{"address", l.server.Addr})
+		return l.server.ListenAndServeTLS(l.certFile, l.keyFile)
+	}
+	Info(RootContext, "listen", KV{"address", l.server.Addr}, KV{"scheme", l.scheme})
+	return l.server.ListenAndServe()
+}
+
+func (l *httpListener) shutdown(ctx context.Context) error {
+	return l.server.Shutdown(ctx)
+}
+
+func (l *h3Listener) serve() error {
+	Info(RootContext, "listen h3", KV{"address", l.server.Addr})
+	return l.server.ListenAndServeTLS(l.certFile, l.keyFile)
+}
+
+func (l *h3Listener) shutdown(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() { done <- l.server.Close() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,201 @@
+package goa_test
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+	"golang.org/x/net/http2"
+)
+
+var _ = Describe("Server", func() {
+	var service *goa.Service
+	var conf *goa.ServerConfig
+	var server *goa.Server
+	var addr string
+	var errc chan error
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.Mux.Handle("GET", "/", func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			rw.Write([]byte(req.Proto))
+		})
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Ω(err).ShouldNot(HaveOccurred())
+		addr = l.Addr().String()
+		l.Close()
+		conf = &goa.ServerConfig{Addr: addr}
+	})
+
+	JustBeforeEach(func() {
+		server = goa.NewServer(service, conf)
+		errc = make(chan error, 1)
+		go func() { errc <- server.ListenAndServe() }()
+	})
+
+	AfterEach(func() {
+		server.Shutdown()
+	})
+
+	Context("with the h2c scheme", func() {
+		BeforeEach(func() {
+			conf.Schemes = []string{"h2c"}
+		})
+
+		It("serves HTTP/2 requests without TLS", func() {
+			client := &http.Client{Transport: &http2.Transport{
+				AllowHTTP: true,
+				DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+					return net.Dial(network, addr)
+				},
+			}}
+			var resp *http.Response
+			Eventually(func() error {
+				var err error
+				resp, err = client.Get("http://" + addr + "/")
+				return err
+			}).ShouldNot(HaveOccurred())
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(body)).Should(Equal("HTTP/2.0"))
+
+			server.Shutdown()
+			Eventually(errc).Should(Receive(BeNil()))
+		})
+	})
+
+	Context("with an invalid scheme", func() {
+		BeforeEach(func() {
+			conf.Schemes = []string{"ftp"}
+		})
+
+		It("returns an error", func() {
+			Eventually(errc).Should(Receive(HaveOccurred()))
+		})
+	})
+
+	Context("with the https scheme and a TLS configuration", func() {
+		var client *http.Client
+
+		BeforeEach(func() {
+			ts := httptest.NewTLSServer(http.NotFoundHandler())
+			tlsConf := ts.Client().Transport.(*http.Transport).TLSClientConfig
+			client = &http.Client{Transport: &http2.Transport{TLSClientConfig: tlsConf}}
+			conf.Schemes = []string{"https"}
+			conf.TLSAddr = addr
+			conf.TLSConfig = &tls.Config{Certificates: ts.TLS.Certificates}
+			ts.Close()
+		})
+
+		It("serves HTTPS requests with the configured certificates", func() {
+			var resp *http.Response
+			Eventually(func() error {
+				var err error
+				resp, err = client.Get("https://" + addr + "/")
+				return err
+			}).ShouldNot(HaveOccurred())
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(body)).Should(Equal("HTTP/2.0"))
+		})
+	})
+
+	Context("with the https scheme and no certificate", func() {
+		BeforeEach(func() {
+			conf.Schemes = []string{"https"}
+		})
+
+		It("returns an error", func() {
+			Eventually(errc).Should(Receive(HaveOccurred()))
+		})
+	})
+
+	Context("with the h3 scheme and no certificate", func() {
+		BeforeEach(func() {
+			conf.Schemes = []string{"h3"}
+		})
+
+		It("returns an error", func() {
+			Eventually(errc).Should(Receive(HaveOccurred()))
+		})
+	})
+})
+
+var _ = Describe("Service Shutdown", func() {
+	var service *goa.Service
+	var addr string
+	var started, release chan struct{}
+	var errc chan error
+	var respc chan *http.Response
+
+	BeforeEach(func() {
+		started, release = make(chan struct{}), make(chan struct{})
+		service = goa.New("test")
+		service.Mux.Handle("GET", "/", func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+			close(started)
+			<-release
+			rw.Write([]byte("done"))
+		})
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Ω(err).ShouldNot(HaveOccurred())
+		addr = l.Addr().String()
+		l.Close()
+		errc = make(chan error, 1)
+		go func() { errc <- service.ListenAndServe(addr) }()
+		respc = make(chan *http.Response, 1)
+		go func() {
+			defer GinkgoRecover()
+			var resp *http.Response
+			Eventually(func() error {
+				var err error
+				resp, err = http.Get("http://" + addr + "/")
+				return err
+			}).ShouldNot(HaveOccurred())
+			respc <- resp
+		}()
+		Eventually(started).Should(BeClosed())
+	})
+
+	It("stops accepting connections and drains the in-flight requests", func() {
+		shutdownc := make(chan error, 1)
+		go func() { shutdownc <- service.Shutdown(context.Background()) }()
+		Eventually(func() error {
+			conn, err := net.Dial("tcp", addr)
+			if err == nil {
+				conn.Close()
+			}
+			return err
+		}).Should(HaveOccurred())
+		Consistently(shutdownc).ShouldNot(Receive())
+		Ω(errc).ShouldNot(Receive())
+
+		close(release)
+		var resp *http.Response
+		Eventually(respc).Should(Receive(&resp))
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(body)).Should(Equal("done"))
+		Eventually(shutdownc).Should(Receive(BeNil()))
+		Eventually(errc).Should(Receive(BeNil()))
+	})
+
+	It("gives up once the context is done", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		Ω(service.Shutdown(ctx)).Should(Equal(context.DeadlineExceeded))
+		Eventually(errc).Should(Receive(BeNil()))
+		close(release)
+		Eventually(respc).Should(Receive())
+	})
+})
@@ -1,14 +1,19 @@
 package goa
 
 import (
+	"bytes"
+	"crypto/tls"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/net/context"
 )
@@ -36,8 +41,54 @@ type (
 		Name            string       // Service name
 		ErrorHandler    ErrorHandler // Service error handler
 		Middleware      []Middleware // Middleware chain
+		Logger          Logger       // Service logger, goa.Log is used if nil
+		TLSConfig       *tls.Config  // TLS configuration used by ListenAndServeTLS if not nil
 
-		versions map[string]*ServiceVersion // Versions by version string
+		versions    map[string]*ServiceVersion // Versions by version string
+		timeouts    map[string]time.Duration   // Action timeouts by controller and action names
+		timeoutsMu  sync.RWMutex               // Protects timeouts
+		redactions  map[string]map[string]bool // Names redacted from the logs by controller and action names
+		redactMu    sync.RWMutex               // Protects redactions
+		maxSize     int64                      // Default maximum request body size
+		maxSizes    map[string]int64           // Maximum request body sizes by controller and action names
+		maxSizesMu  sync.RWMutex               // Protects maxSize and maxSizes
+		quotas      map[string][]QuotaLimit    // Quota budgets by scope
+		quotaStore  QuotaStore                 // Usage of the quota budgets
+		quotasMu    sync.RWMutex               // Protects quotas and quotaStore
+		rateLimits  map[string]RateLimitPolicy // Rate limits by scope
+		rateStore   RateLimitStore             // Clients of the rate limits
+		rateMu      sync.RWMutex               // Protects rateLimits and rateStore
+		tenantHook  TenantHook                 // Per-tenant configuration hook
+		tenantMu    sync.RWMutex               // Protects tenantHook
+		networkACLs map[string]*NetworkACL     // Network ACLs by scope
+		aclMu       sync.RWMutex               // Protects networkACLs
+		maintenance *MaintenanceState          // Disabled actions if any
+		maintMu     sync.RWMutex               // Protects maintenance
+		detectors   []AbuseDetector            // Detectors of the abuse-sensitive actions
+		abuseStats  *abuseTracker              // Request counts of the principals
+		abuseMu     sync.RWMutex               // Protects detectors and abuseStats
+		retries     *retryTracker              // Consecutive rejections of the principals
+		security    map[string]Middleware      // Security middleware by scheme name
+		authorizer  Authorizer                 // Authorizer of the actions with a policy
+		roles       func(interface{}) []string // Roles of the request principals
+		auditor     *Auditor                   // Auditor of the actions marked for audit
+		replayCache ReplayCache                // Nonces of the replay protected requests
+		sessions    *SessionConfig             // Session cookies configuration
+		crypter     Crypter                    // Crypter of the encrypted attributes
+		securityMu  sync.RWMutex               // Protects security, authorizer, roles, auditor, replayCache, sessions and crypter
+		servers     []stopper                  // Servers started by the service, see Shutdown
+		serversMu   sync.Mutex                 // Protects servers
+	}
+
+	// stopper is implemented by the servers started by the service.
+	stopper interface {
+		shutdown(ctx context.Context) error
+	}
+
+	// httpStopper stops the HTTP servers started by ListenAndServe and ListenAndServeTLS.
+	httpStopper struct {
+		*http.Server
+		done chan struct{} // Closed once the server is shut down
 	}
 
 	// ServiceVersion represents a service version, identified by a version name. This is where
@@ -75,11 +126,18 @@ type (
 	DecodeFunc func(context.Context, io.ReadCloser, interface{}) error
 )
 
+// ServeFunc, if not nil, is called by the service ListenAndServe and ListenAndServeTLS methods
+// instead of starting a HTTP server. This makes it possible to serve the service by other means,
+// for example through the AWS Lambda runtime, without changing the main function generated by
+// goagen. The code generated by "goagen lambda" sets it when built with the "lambda" build tag.
+var ServeFunc func(service *Service) error
+
 // New instantiates an service with the given name and default decoders/encoders.
 func New(name string) *Service {
 	service := &Service{
 		Name:         name,
 		ErrorHandler: DefaultErrorHandler,
+		retries:      &retryTracker{rejections: make(map[string]*rejection), lastSweep: time.Now()},
 	}
 	service.ServiceVersion = &ServiceVersion{
 		Mux:                   NewMux(service),
@@ -98,16 +156,105 @@ func (service *Service) Use(m Middleware) {
 	service.Middleware = append(service.Middleware, m)
 }
 
+// LogInfo logs the given informational message and accompanying data with the service logger.
+// It is used to log events that are not tied to a request such as the mounting of controllers.
+func (service *Service) LogInfo(msg string, data ...KV) {
+	Info(service.logContext(), msg, data...)
+}
+
+// LogError logs the given error message and accompanying data with the service logger.
+func (service *Service) LogError(msg string, data ...KV) {
+	Error(service.logContext(), msg, data...)
+}
+
+// logContext returns a context derived from RootContext whose service is the receiver, the
+// messages logged with it go to the service logger.
+func (service *Service) logContext() context.Context {
+	return context.WithValue(RootContext, serviceKey, service)
+}
+
 // ListenAndServe starts a HTTP server and sets up a listener on the given host/port.
 func (service *Service) ListenAndServe(addr string) error {
-	Info(RootContext, "listen", KV{"address", addr})
-	return http.ListenAndServe(addr, service.Mux)
+	if ServeFunc != nil {
+		return ServeFunc(service)
+	}
+	service.LogInfo("listen", KV{"address", addr})
+	srv := &httpStopper{Server: &http.Server{Addr: addr, Handler: service.Mux}, done: make(chan struct{})}
+	service.track(srv)
+	return srv.wait(srv.ListenAndServe())
 }
 
-// ListenAndServeTLS starts a HTTPS server and sets up a listener on the given host/port.
+// ListenAndServeTLS starts a HTTPS server and sets up a listener on the given host/port. The server
+// uses a copy of the service TLSConfig if set, certFile and keyFile may be empty if the
+// configuration provides the certificates.
 func (service *Service) ListenAndServeTLS(addr, certFile, keyFile string) error {
-	Info(RootContext, "listen ssl", KV{"address", addr})
-	return http.ListenAndServeTLS(addr, certFile, keyFile, service.Mux)
+	if ServeFunc != nil {
+		return ServeFunc(service)
+	}
+	service.LogInfo("listen ssl", KV{"address", addr})
+	srv := &httpStopper{Server: &http.Server{Addr: addr, Handler: service.Mux}, done: make(chan struct{})}
+	if service.TLSConfig != nil {
+		srv.TLSConfig = service.TLSConfig.Clone()
+	}
+	service.track(srv)
+	return srv.wait(srv.ListenAndServeTLS(certFile, keyFile))
+}
+
+// Shutdown gracefully shuts down the servers started by the service: it closes their listeners so
+// that no new connection is accepted then waits for the in-flight requests to complete. It returns
+// the context error if ctx is done before all the requests complete, the servers then return but
+// the remaining requests keep running. The methods that started the servers return nil once
+// Shutdown returns.
+func (service *Service) Shutdown(ctx context.Context) error {
+	IncrCounter([]string{"goa", "service", "shutdown"}, 1.0)
+	service.serversMu.Lock()
+	servers := service.servers
+	service.servers = nil
+	service.serversMu.Unlock()
+	service.LogInfo("shutdown", KV{"servers", len(servers)})
+	errc := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv stopper) { errc <- srv.shutdown(ctx) }(srv)
+	}
+	var err error
+	for range servers {
+		if e := <-errc; err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// track records a server started by the service so that Shutdown may stop it.
+func (service *Service) track(srv stopper) {
+	service.serversMu.Lock()
+	defer service.serversMu.Unlock()
+	service.servers = append(service.servers, srv)
+}
+
+// shutdown implements stopper.
+func (s *httpStopper) shutdown(ctx context.Context) error {
+	defer close(s.done)
+	return s.Shutdown(ctx)
+}
+
+// wait returns the error returned by the server if it did not shut down, otherwise it waits for
+// the shutdown to complete and returns nil.
+func (s *httpStopper) wait(err error) error {
+	if err != http.ErrServerClosed {
+		return err
+	}
+	<-s.done
+	return nil
+}
+
+// ignoreClosed returns nil if err is the error returned by the servers once shut down, err
+// otherwise.
+func ignoreClosed(err error) error {
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
 }
 
 // ServeFiles replies to the request with the contents of the named file or directory. The logic
@@ -136,7 +283,7 @@ func (service *Service) ServeFiles(path, filename string) error {
 			}
 		}
 	}
-	Info(RootContext, "mount file", KV{"filename", rel}, KV{"path", fmt.Sprintf("GET %s", path)})
+	service.LogInfo("mount file", KV{"filename", rel}, KV{"path", fmt.Sprintf("GET %s", path)})
 	ctrl := service.NewController("FileServer")
 	var wc string
 	if idx := strings.Index(path, "*"); idx > -1 && idx < len(path)-1 {
@@ -150,7 +297,7 @@ func (service *Service) ServeFiles(path, filename string) error {
 				fullpath = filepath.Join(fullpath, m[0])
 			}
 		}
-		Info(RootContext, "serve", KV{"path", r.URL.Path}, KV{"filename", fullpath})
+		Info(ctx, "serve", KV{"path", r.URL.Path}, KV{"filename", fullpath})
 		http.ServeFile(Response(ctx), r.Request, fullpath)
 		return nil
 	}, nil)
@@ -211,10 +358,7 @@ func (ctrl *Controller) MiddlewareChain() []Middleware {
 // HandleError invokes the controller error handler or - if there isn't one - the service error
 // handler.
 func (ctrl *Controller) HandleError(ctx context.Context, rw http.ResponseWriter, req *http.Request, err error) {
-	status := 500
-	if _, ok := err.(*BadRequestError); ok {
-		status = 400
-	}
+	status := ErrorStatus(err)
 	go IncrCounter([]string{"goa", "handler", "error", strconv.Itoa(status)}, 1.0)
 	if ctrl.ErrorHandler != nil {
 		ctrl.ErrorHandler(ctx, rw, req, err)
@@ -245,19 +389,46 @@ func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) M
 	}
 	return func(rw http.ResponseWriter, req *http.Request, params url.Values) {
 		// Build context
-		ctx := NewLogContext(RootContext,
+		ctx := NewLogContext(WithLogFields(RootContext),
 			KV{"service", ctrl.Service.Name}, KV{"ctrl", ctrl.Name}, KV{"action", name})
+		ctx = context.WithValue(ctx, ctrlKey, ctrl.Name)
+		ctx = context.WithValue(ctx, actionKey, name)
 		ctx = NewContext(ctx, ctrl.Service, rw, req, params)
 
-		// Load body if any
+		// Reject requests made to disabled actions right away
+		rejected := ctrl.Service.maintenanceError(rw, ctrl.Name, name)
+
+		// Limit the size of the body, reject requests that announce a larger body right away
+		if limit := ctrl.Service.MaxRequestSize(ctrl.Name, name); rejected == nil && limit > 0 {
+			if req.ContentLength > limit {
+				rejected = ErrRequestTooLarge("request body exceeds %d bytes", limit)
+			} else {
+				req.Body = http.MaxBytesReader(rw, req.Body, limit)
+			}
+		}
+
+		// Load body if any, keep it readable by the middleware that verify signatures
 		var err error
-		if req.ContentLength > 0 && unm != nil {
-			err = unm(ctx, req)
+		if rejected == nil && req.ContentLength > 0 && unm != nil {
+			var body []byte
+			if body, err = ioutil.ReadAll(req.Body); err == nil {
+				req.Body = ioutil.NopCloser(bytes.NewReader(body))
+				err = unm(ctx, req)
+				req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
 		}
 
 		// Handle invalid payload
 		handler := middleware
-		if err != nil {
+		if rejected != nil {
+			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+				ctrl.HandleError(ctx, rw, req, rejected)
+				return nil
+			}
+			for i := range chain {
+				handler = chain[ml-i-1](handler)
+			}
+		} else if err != nil {
 			handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
 				msg := "invalid encoding: " + err.Error()
 				rw.Header().Set("Content-Type", "Service/json")
@@ -270,34 +441,46 @@ func (ctrl *Controller) MuxHandler(name string, hdlr Handler, unm Unmarshaler) M
 			}
 		}
 
-		// Invoke middleware chain, wrap writer to capture response status and length
-		handler(ctx, Response(ctx), req)
+		// Invoke middleware chain, wrap writer to capture response status and length. Render
+		// the errors returned by the middleware that did not write a response.
+		if err := handler(ctx, Response(ctx), req); err != nil && !Response(ctx).Written() {
+			ctrl.HandleError(ctx, rw, req, err)
+		}
 	}
 }
 
-// DefaultErrorHandler returns a 400 response for request validation errors (instances of
-// BadRequestError) and a 500 response for other errors. It writes the error message to the
-// response body in both cases.
+// DefaultErrorHandler returns a response whose status is given by the error if it implements
+// ServiceError (e.g. 400 for request validation errors, instances of BadRequestError) and a 500
+// response for other errors. HTTPError values are serialized into the response body, the error
+// message is written to the body for other errors. Internal errors (status 500 and above) are
+// logged.
 func DefaultErrorHandler(ctx context.Context, rw http.ResponseWriter, req *http.Request, e error) {
-	status := 500
-	if _, ok := e.(*BadRequestError); ok {
-		status = 400
-	} else {
-		Log.Error(ctx, e.Error())
+	status := ErrorStatus(e)
+	if status >= 500 {
+		if l := ContextLogger(ctx); l != nil {
+			l.Error(ctx, e.Error())
+		}
 	}
-	Response(ctx).Send(ctx, status, e.Error())
+	Response(ctx).Send(ctx, status, errorBody(e))
 }
 
 // TerseErrorHandler behaves like DefaultErrorHandler except that it does not write to the response
 // body for internal errors.
 func TerseErrorHandler(ctx context.Context, rw http.ResponseWriter, req *http.Request, e error) {
-	status := 500
+	status := ErrorStatus(e)
 	var body interface{}
-	if _, ok := e.(*BadRequestError); ok {
-		status = 400
-		body = e.Error()
-	} else {
-		Log.Error(ctx, e.Error())
+	if status < 500 {
+		body = errorBody(e)
+	} else if l := ContextLogger(ctx); l != nil {
+		l.Error(ctx, e.Error())
 	}
 	Response(ctx).Send(ctx, status, body)
 }
+
+// errorBody returns the response body that describes the given error.
+func errorBody(e error) interface{} {
+	if herr, ok := e.(*HTTPError); ok {
+		return herr
+	}
+	return e.Error()
+}
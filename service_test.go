@@ -165,6 +165,22 @@ var _ = Describe("Application", func() {
 						Ω(errorHandlerCalled).Should(BeTrue())
 					})
 				})
+
+				Context("by returning a classed error", func() {
+					BeforeEach(func() {
+						s.ErrorHandler = goa.DefaultErrorHandler
+						handler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+							return goa.ErrNotFound("no bottle with ID %d", 42)
+						}
+					})
+
+					It("uses the error status and serializes the error", func() {
+						tw := rw.(*TestResponseWriter)
+						Ω(tw.Status).Should(Equal(404))
+						Ω(string(tw.Body)).Should(ContainSubstring(`"code":"not_found"`))
+						Ω(string(tw.Body)).Should(ContainSubstring(`"detail":"no bottle with ID 42"`))
+					})
+				})
 			})
 
 			Context("with different payload types", func() {
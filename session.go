@@ -0,0 +1,254 @@
+package goa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// SessionConfig configures the session cookies of a service.
+	SessionConfig struct {
+		// Keys are the secret keys used to sign and encrypt the session cookies. The first
+		// key signs and encrypts new cookies, all the keys are tried when reading cookies so
+		// that keys may be rotated by prepending new keys.
+		Keys [][]byte
+		// Path is the path of the session cookies, "/" if empty.
+		Path string
+		// Domain is the domain of the session cookies if any.
+		Domain string
+		// Secure is true if the session cookies may only be sent over HTTPS.
+		Secure bool
+		// SameSite is the value of the SameSite attribute of the session cookies, "Lax" if
+		// empty.
+		SameSite string
+	}
+
+	// SessionCookie describes a session cookie. The code generated by goagen defines one
+	// SessionCookie per session defined in the design and uses it to implement the typed
+	// session accessors of the action contexts.
+	SessionCookie struct {
+		// Name is the cookie name.
+		Name string
+		// MaxAge is the cookie lifetime, zero means the cookie expires when the browser is
+		// closed.
+		MaxAge time.Duration
+		// Encrypt is true if the cookie content is encrypted in addition to being signed.
+		Encrypt bool
+	}
+
+	// sessionEnvelope is the content of the session cookies.
+	sessionEnvelope struct {
+		Expires int64           `json:"exp,omitempty"`
+		Data    json.RawMessage `json:"data"`
+	}
+)
+
+// ErrNoSessionKeys is the error returned when reading or writing session cookies using a service
+// whose session configuration does not define any key.
+var ErrNoSessionKeys = errors.New("no session keys configured, see Service.SetSessionConfig")
+
+// SetSessionConfig sets the configuration of the session cookies used by the service actions.
+func (service *Service) SetSessionConfig(c *SessionConfig) {
+	service.securityMu.Lock()
+	defer service.securityMu.Unlock()
+	service.sessions = c
+}
+
+// Load decodes the session state carried by the request with the given context into v. It
+// returns false if the request does not carry a valid session cookie, cookies that were tampered
+// with or that expired are ignored.
+func (c *SessionCookie) Load(ctx context.Context, v interface{}) (bool, error) {
+	conf, err := sessionConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	req := Request(ctx)
+	if req == nil {
+		return false, nil
+	}
+	cookie, err := req.Cookie(c.Name)
+	if err != nil {
+		return false, nil
+	}
+	var env *sessionEnvelope
+	for _, k := range conf.Keys {
+		if env = c.open(k, cookie.Value); env != nil {
+			break
+		}
+	}
+	if env == nil || (env.Expires > 0 && time.Now().Unix() >= env.Expires) {
+		return false, nil
+	}
+	if err := json.Unmarshal(env.Data, v); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// Save writes the session cookie holding the given session state to the response of the request
+// with the given context. It must be called before the response headers are written.
+func (c *SessionCookie) Save(ctx context.Context, v interface{}) error {
+	conf, err := sessionConfig(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	env := &sessionEnvelope{Data: data}
+	if c.MaxAge > 0 {
+		env.Expires = time.Now().Add(c.MaxAge).Unix()
+	}
+	value, err := c.seal(conf.Keys[0], env)
+	if err != nil {
+		return err
+	}
+	c.write(ctx, conf, value, int(c.MaxAge/time.Second))
+	return nil
+}
+
+// Clear deletes the session cookie by writing an expired cookie to the response of the request
+// with the given context.
+func (c *SessionCookie) Clear(ctx context.Context) error {
+	conf, err := sessionConfig(ctx)
+	if err != nil {
+		return err
+	}
+	c.write(ctx, conf, "", -1)
+	return nil
+}
+
+// seal returns the value of the cookie holding env signed and optionally encrypted with key.
+func (c *SessionCookie) seal(key []byte, env *sessionEnvelope) (string, error) {
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return "", err
+	}
+	if c.Encrypt {
+		aead, err := sessionAEAD(key)
+		if err != nil {
+			return "", err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		payload = aead.Seal(nonce, nonce, payload, []byte(c.Name))
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(key, encoded)), nil
+}
+
+// open verifies and decodes the given cookie value using key. It returns nil if the value was
+// not produced by seal with the same key.
+func (c *SessionCookie) open(key []byte, value string) *sessionEnvelope {
+	i := strings.LastIndex(value, ".")
+	if i < 0 {
+		return nil
+	}
+	encoded := value[:i]
+	sig, err := base64.RawURLEncoding.DecodeString(value[i+1:])
+	if err != nil || !hmac.Equal(sig, c.sign(key, encoded)) {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil
+	}
+	if c.Encrypt {
+		aead, err := sessionAEAD(key)
+		if err != nil || len(payload) < aead.NonceSize() {
+			return nil
+		}
+		n := aead.NonceSize()
+		if payload, err = aead.Open(nil, payload[:n], payload[n:], []byte(c.Name)); err != nil {
+			return nil
+		}
+	}
+	var env sessionEnvelope
+	if err := json.Unmarshal(payload, &env); err != nil {
+		return nil
+	}
+	return &env
+}
+
+// sign computes the signature of the given encoded cookie content. The signature covers the
+// cookie name so that the content of a session cookie cannot be used in another.
+func (c *SessionCookie) sign(key []byte, encoded string) []byte {
+	mac := hmac.New(sha256.New, deriveSessionKey(key, "signing"))
+	mac.Write([]byte(c.Name + "|" + encoded))
+	return mac.Sum(nil)
+}
+
+// write adds the session cookie with the given value and max age in seconds to the response.
+// The cookie is written manually so that the SameSite attribute may be set.
+func (c *SessionCookie) write(ctx context.Context, conf *SessionConfig, value string, maxAge int) {
+	resp := Response(ctx)
+	if resp == nil {
+		return
+	}
+	path := conf.Path
+	if path == "" {
+		path = "/"
+	}
+	cookie := &http.Cookie{
+		Name:     c.Name,
+		Value:    value,
+		Path:     path,
+		Domain:   conf.Domain,
+		MaxAge:   maxAge,
+		Secure:   conf.Secure,
+		HttpOnly: true,
+	}
+	sameSite := conf.SameSite
+	if sameSite == "" {
+		sameSite = "Lax"
+	}
+	resp.Header().Add("Set-Cookie", fmt.Sprintf("%s; SameSite=%s", cookie, sameSite))
+}
+
+// sessionConfig returns the session configuration of the service handling the request with the
+// given context.
+func sessionConfig(ctx context.Context) (*SessionConfig, error) {
+	service := RequestService(ctx)
+	if service == nil {
+		return nil, ErrNoSessionKeys
+	}
+	service.securityMu.RLock()
+	conf := service.sessions
+	service.securityMu.RUnlock()
+	if conf == nil || len(conf.Keys) == 0 || len(conf.Keys[0]) == 0 {
+		return nil, ErrNoSessionKeys
+	}
+	return conf, nil
+}
+
+// sessionAEAD returns the AES-GCM cipher used to encrypt the session cookies with the given key.
+func sessionAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveSessionKey(key, "encryption"))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveSessionKey derives the key used for the given purpose from a session key so that the
+// same key may be used to sign and encrypt the cookies.
+func deriveSessionKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("goa session " + purpose))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,174 @@
+package goa_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("SessionCookie", func() {
+	type state struct {
+		UserID int `json:"user_id"`
+	}
+
+	var service *goa.Service
+	var cookie *goa.SessionCookie
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.SetSessionConfig(&goa.SessionConfig{Keys: [][]byte{[]byte("secret")}})
+		cookie = &goa.SessionCookie{Name: "session", MaxAge: time.Hour}
+	})
+
+	// save saves the state and returns the Set-Cookie header of the response.
+	save := func(s *state) string {
+		req, _ := http.NewRequest("GET", "/", nil)
+		rw := &TestResponseWriter{ParentHeader: http.Header{}}
+		ctx := goa.NewContext(nil, service, rw, req, url.Values{})
+		Ω(cookie.Save(ctx, s)).ShouldNot(HaveOccurred())
+		return rw.ParentHeader.Get("Set-Cookie")
+	}
+
+	// load loads the state of a request carrying the given cookie value.
+	load := func(value string) (*state, bool) {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: "session", Value: value})
+		ctx := goa.NewContext(nil, service, &TestResponseWriter{ParentHeader: http.Header{}}, req, url.Values{})
+		var s state
+		ok, err := cookie.Load(ctx, &s)
+		Ω(err).ShouldNot(HaveOccurred())
+		return &s, ok
+	}
+
+	value := func(header string) string {
+		return strings.TrimPrefix(strings.SplitN(header, ";", 2)[0], "session=")
+	}
+
+	It("round trips the session state", func() {
+		header := save(&state{UserID: 42})
+		Ω(header).Should(ContainSubstring("HttpOnly"))
+		Ω(header).Should(ContainSubstring("Max-Age=3600"))
+		Ω(header).Should(ContainSubstring("SameSite=Lax"))
+		s, ok := load(value(header))
+		Ω(ok).Should(BeTrue())
+		Ω(s.UserID).Should(Equal(42))
+	})
+
+	It("ignores tampered cookies", func() {
+		v := value(save(&state{UserID: 42}))
+		_, ok := load("x" + v)
+		Ω(ok).Should(BeFalse())
+	})
+
+	It("accepts cookies signed with rotated keys", func() {
+		v := value(save(&state{UserID: 42}))
+		service.SetSessionConfig(&goa.SessionConfig{Keys: [][]byte{[]byte("new"), []byte("secret")}})
+		s, ok := load(v)
+		Ω(ok).Should(BeTrue())
+		Ω(s.UserID).Should(Equal(42))
+	})
+
+	Context("encrypted", func() {
+		BeforeEach(func() {
+			cookie.Encrypt = true
+		})
+
+		It("round trips the session state", func() {
+			v := value(save(&state{UserID: 42}))
+			Ω(v).ShouldNot(ContainSubstring("eyJ")) // base64 encoded JSON
+			s, ok := load(v)
+			Ω(ok).Should(BeTrue())
+			Ω(s.UserID).Should(Equal(42))
+		})
+	})
+
+	Context("without keys", func() {
+		BeforeEach(func() {
+			service.SetSessionConfig(nil)
+		})
+
+		It("fails", func() {
+			req, _ := http.NewRequest("GET", "/", nil)
+			ctx := goa.NewContext(nil, service, &TestResponseWriter{ParentHeader: http.Header{}}, req, url.Values{})
+			Ω(cookie.Save(ctx, &state{})).Should(Equal(goa.ErrNoSessionKeys))
+		})
+	})
+})
+
+var _ = Describe("CSRF", func() {
+	var service *goa.Service
+	var handler goa.Handler
+	var req *http.Request
+	var rw *TestResponseWriter
+	var token string
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		token = ""
+		handler = goa.CSRF(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			token = goa.CSRFToken(ctx)
+			return nil
+		})
+		rw = &TestResponseWriter{ParentHeader: http.Header{}}
+	})
+
+	serve := func() error {
+		ctx := goa.NewContext(nil, service, rw, req, url.Values{})
+		return handler(ctx, goa.Response(ctx), req)
+	}
+
+	It("issues tokens to new browsers", func() {
+		req, _ = http.NewRequest("GET", "/form", nil)
+		Ω(serve()).ShouldNot(HaveOccurred())
+		Ω(token).ShouldNot(BeEmpty())
+		Ω(rw.ParentHeader.Get("Set-Cookie")).Should(HavePrefix(goa.CSRFCookie + "=" + token))
+	})
+
+	It("rejects unsafe requests without a token", func() {
+		req, _ = http.NewRequest("POST", "/form", nil)
+		req.AddCookie(&http.Cookie{Name: goa.CSRFCookie, Value: "abc"})
+		err := serve()
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(403))
+		Ω(token).Should(BeEmpty())
+	})
+
+	It("accepts unsafe requests carrying the token in a header", func() {
+		req, _ = http.NewRequest("POST", "/form", nil)
+		req.AddCookie(&http.Cookie{Name: goa.CSRFCookie, Value: "abc"})
+		req.Header.Set(goa.CSRFHeader, "abc")
+		Ω(serve()).ShouldNot(HaveOccurred())
+		Ω(token).Should(Equal("abc"))
+		Ω(rw.ParentHeader.Get("Set-Cookie")).Should(BeEmpty())
+	})
+
+	It("accepts form submissions carrying the token in a field", func() {
+		req, _ = http.NewRequest("POST", "/form", strings.NewReader("name=x&csrf_token=abc"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: goa.CSRFCookie, Value: "abc"})
+		Ω(serve()).ShouldNot(HaveOccurred())
+		Ω(token).Should(Equal("abc"))
+		req.ParseForm()
+		Ω(req.PostForm.Get("name")).Should(Equal("x"))
+	})
+
+	It("does not look for the token in large form submissions", func() {
+		body := "csrf_token=abc&name=" + strings.Repeat("x", 1<<20)
+		req, _ = http.NewRequest("POST", "/form", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: goa.CSRFCookie, Value: "abc"})
+		err := serve()
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(403))
+		b, err := ioutil.ReadAll(req.Body)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(b)).Should(Equal(body))
+	})
+})
@@ -0,0 +1,311 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SOAPEnvelopeNamespace is the namespace of SOAP 1.1 envelopes.
+const SOAPEnvelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+
+// soapPayloadElement is the name of the SOAP request element holding the action payload.
+const soapPayloadElement = "payload"
+
+type (
+	// SOAPServer serves SOAP 1.1 requests by bridging them to the controllers mounted on a
+	// service, this makes it possible for legacy clients to consume the API without a separate
+	// service. Operations use the document/literal wrapped style: the name of the element
+	// wrapping the request body identifies the operation, its child elements named after the
+	// route wildcards are used to build the request path, the "payload" element if any provides
+	// the request body and the other elements are sent in the querystring. The action response
+	// body is sent back wrapped in the "<operation>Response" element, errors are sent as SOAP
+	// faults.
+	// The code generated by "goagen soap" registers the operations of all the actions defined in
+	// the design together with the WSDL describing them.
+	SOAPServer struct {
+		// Service is the service the requests are dispatched to.
+		Service *Service
+		// Namespace is the target namespace of the WSDL, it qualifies the response elements.
+		Namespace string
+		// WSDL is the WSDL document served in response to GET requests made to the server
+		// path with the "wsdl" querystring parameter.
+		WSDL string
+
+		operations map[string]*SOAPOperation
+	}
+
+	// SOAPOperation describes the action route a SOAP operation maps to.
+	SOAPOperation struct {
+		// Version is the name of the API version that defines the action, empty if none.
+		Version string
+		// Verb is the HTTP method of the route.
+		Verb string
+		// Path is the full path of the route including wildcards.
+		Path string
+		// NewParams returns the value the request element is decoded into. The value is then
+		// serialized to JSON to build the action request so the struct fields must use json
+		// tags named after the action parameters. NewParams may be nil if the action has no
+		// parameter and no payload.
+		NewParams func() interface{}
+	}
+
+	// SOAPFault is the fault sent to clients when an operation fails. It implements error.
+	SOAPFault struct {
+		// Code is the fault code, "soap:Client" if the request is invalid, "soap:Server"
+		// otherwise.
+		Code string
+		// String is the fault message.
+		String string
+		// Status is the status of the action response, zero if the action was not invoked.
+		Status int
+	}
+)
+
+// NewSOAPServer returns a SOAP server that dispatches requests to the given service.
+func NewSOAPServer(service *Service, namespace string) *SOAPServer {
+	return &SOAPServer{
+		Service:    service,
+		Namespace:  namespace,
+		operations: make(map[string]*SOAPOperation),
+	}
+}
+
+// Handle maps the SOAP operation with the given name to the given action route.
+func (s *SOAPServer) Handle(name string, op *SOAPOperation) {
+	s.operations[name] = op
+}
+
+// Mount registers the server with the service mux for POST requests made to the given path and
+// GET requests made to the same path to retrieve the WSDL. The path defaults to "/soap".
+func (s *SOAPServer) Mount(path string) {
+	if path == "" {
+		path = "/soap"
+	}
+	handler := func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+		s.ServeHTTP(rw, req)
+	}
+	s.Service.Mux.Handle("POST", path, handler)
+	s.Service.Mux.Handle("GET", path, handler)
+	s.Service.LogInfo("mount soap", KV{"path", fmt.Sprintf("POST %s", path)}, KV{"wsdl", fmt.Sprintf("GET %s?wsdl", path)})
+}
+
+// ServeHTTP serves a SOAP request or a WSDL request.
+func (s *SOAPServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case "GET":
+		if _, ok := req.URL.Query()["wsdl"]; !ok || s.WSDL == "" {
+			http.NotFound(rw, req)
+			return
+		}
+		rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		io.WriteString(rw, s.WSDL)
+	case "POST":
+		name, result, err := s.serve(req)
+		if err != nil {
+			fault, ok := err.(*SOAPFault)
+			if !ok {
+				fault = &SOAPFault{Code: "soap:Server", String: err.Error()}
+			}
+			writeSOAP(rw, http.StatusInternalServerError, fault.encode)
+			return
+		}
+		writeSOAP(rw, http.StatusOK, func(enc *xml.Encoder) error {
+			return encodeSOAPValue(enc, xml.Name{Local: "tns:" + name + "Response"}, s.namespaceAttrs(), result)
+		})
+	default:
+		rw.Header().Set("Allow", "GET, POST")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// serve dispatches the request to the action the operation maps to and returns the operation
+// name and the decoded action response body.
+func (s *SOAPServer) serve(req *http.Request) (string, interface{}, error) {
+	dec := xml.NewDecoder(req.Body)
+	start, err := soapBodyElement(dec)
+	if err != nil {
+		return "", nil, &SOAPFault{Code: "soap:Client", String: err.Error()}
+	}
+	name := start.Name.Local
+	op, ok := s.operations[name]
+	if !ok {
+		return name, nil, &SOAPFault{Code: "soap:Client", String: fmt.Sprintf("unknown operation %s", name)}
+	}
+	fields := make(map[string]interface{})
+	if op.NewParams != nil {
+		params := op.NewParams()
+		if err := dec.DecodeElement(params, start); err != nil {
+			return name, nil, &SOAPFault{Code: "soap:Client", String: err.Error()}
+		}
+		b, err := json.Marshal(params)
+		if err != nil {
+			return name, nil, err
+		}
+		jdec := json.NewDecoder(bytes.NewReader(b))
+		jdec.UseNumber()
+		if err := jdec.Decode(&fields); err != nil {
+			return name, nil, err
+		}
+	}
+	inner, err := bridgeRequest(req, op.Verb, op.Path, fields, soapPayloadElement)
+	if err != nil {
+		return name, nil, &SOAPFault{Code: "soap:Client", String: err.Error()}
+	}
+	inner.Header.Del("Soapaction")
+	rec := bridgeServe(s.Service, op.Version, inner)
+	if rec.failed() {
+		code := "soap:Server"
+		if rec.status < 500 {
+			code = "soap:Client"
+		}
+		return name, nil, &SOAPFault{Code: code, String: rec.errorMessage(), Status: rec.status}
+	}
+	body := bytes.TrimSpace(rec.body.Bytes())
+	if len(body) == 0 {
+		return name, nil, nil
+	}
+	if !strings.Contains(rec.header.Get("Content-Type"), "json") {
+		return name, string(body), nil
+	}
+	var result interface{}
+	jdec := json.NewDecoder(bytes.NewReader(body))
+	jdec.UseNumber()
+	if err := jdec.Decode(&result); err != nil {
+		return name, string(body), nil
+	}
+	return name, result, nil
+}
+
+// namespaceAttrs returns the attributes that declare the target namespace prefix.
+func (s *SOAPServer) namespaceAttrs() []xml.Attr {
+	return []xml.Attr{{Name: xml.Name{Local: "xmlns:tns"}, Value: s.Namespace}}
+}
+
+// Error returns the fault message.
+func (f *SOAPFault) Error() string {
+	return fmt.Sprintf("%s: %s", f.Code, f.String)
+}
+
+// encode writes the fault element.
+func (f *SOAPFault) encode(enc *xml.Encoder) error {
+	type detail struct {
+		Status int `xml:"status"`
+	}
+	fault := struct {
+		XMLName xml.Name `xml:"soap:Fault"`
+		Code    string   `xml:"faultcode"`
+		String  string   `xml:"faultstring"`
+		Detail  *detail  `xml:"detail,omitempty"`
+	}{Code: f.Code, String: f.String}
+	if f.Status != 0 {
+		fault.Detail = &detail{Status: f.Status}
+	}
+	return enc.Encode(&fault)
+}
+
+// soapBodyElement reads the envelope up to the first child element of the SOAP body and returns
+// it.
+func soapBodyElement(dec *xml.Decoder) (*xml.StartElement, error) {
+	inBody := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("missing SOAP body")
+			}
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if inBody {
+			return &start, nil
+		}
+		if start.Name.Local == "Body" && start.Name.Space == SOAPEnvelopeNamespace {
+			inBody = true
+		}
+	}
+}
+
+// encodeSOAPValue writes the element with the given name whose content is the XML representation
+// of the given JSON value: objects become sequences of child elements sorted by name, arrays
+// become repeated elements named after the enclosing element ("item" for top level arrays) and
+// other values become text.
+func encodeSOAPValue(enc *xml.Encoder, name xml.Name, attrs []xml.Attr, v interface{}) error {
+	start := xml.StartElement{Name: name, Attr: attrs}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch actual := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		keys := make([]string, 0, len(actual))
+		for k := range actual {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := encodeSOAPField(enc, k, actual[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, e := range actual {
+			if err := encodeSOAPValue(enc, xml.Name{Local: "item"}, nil, e); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(actual))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// encodeSOAPField writes the elements representing the field with the given name and value, null
+// values are omitted and arrays result in one element per item.
+func encodeSOAPField(enc *xml.Encoder, name string, v interface{}) error {
+	switch actual := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		for _, e := range actual {
+			if err := encodeSOAPValue(enc, xml.Name{Local: name}, nil, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return encodeSOAPValue(enc, xml.Name{Local: name}, nil, v)
+}
+
+// writeSOAP writes a SOAP envelope whose body content is written by the given function. A fault
+// is written instead if the function fails.
+func writeSOAP(rw http.ResponseWriter, status int, body func(*xml.Encoder) error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<soap:Envelope xmlns:soap="` + SOAPEnvelopeNamespace + `"><soap:Body>`)
+	enc := xml.NewEncoder(&buf)
+	err := body(enc)
+	if err == nil {
+		err = enc.Flush()
+	}
+	if err != nil {
+		fault := &SOAPFault{Code: "soap:Server", String: err.Error()}
+		writeSOAP(rw, http.StatusInternalServerError, fault.encode)
+		return
+	}
+	buf.WriteString(`</soap:Body></soap:Envelope>`)
+	rw.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	rw.WriteHeader(status)
+	rw.Write(buf.Bytes())
+}
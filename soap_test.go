@@ -0,0 +1,156 @@
+package goa_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SOAPServer", func() {
+	type bottlePayload struct {
+		Name string   `json:"name" xml:"name"`
+		Tags []string `json:"tags,omitempty" xml:"tags,omitempty"`
+	}
+	type createParams struct {
+		AccountID int            `json:"account_id" xml:"account_id"`
+		Payload   *bottlePayload `json:"payload" xml:"payload"`
+	}
+	type showParams struct {
+		AccountID int     `json:"account_id" xml:"account_id"`
+		ID        int     `json:"id" xml:"id"`
+		View      *string `json:"view,omitempty" xml:"view,omitempty"`
+	}
+	const envelope = `<?xml version="1.0"?>
+<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:c="urn:cellar">
+	<soapenv:Header/>
+	<soapenv:Body>%s</soapenv:Body>
+</soapenv:Envelope>`
+
+	var service *goa.Service
+	var server *goa.SOAPServer
+	var method, path, body string
+	var rw *httptest.ResponseRecorder
+
+	var gotPath string
+	var gotQuery url.Values
+	var gotBody []byte
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		server = goa.NewSOAPServer(service, "urn:cellar")
+		server.WSDL = "<definitions/>"
+		method, path = "POST", "/soap"
+		body = `<c:ShowBottle><account_id>1</account_id><id>42</id><view>tiny</view></c:ShowBottle>`
+		rw = httptest.NewRecorder()
+		gotPath, gotQuery, gotBody = "", nil, nil
+		server.Handle("ShowBottle", &goa.SOAPOperation{
+			Verb:      "GET",
+			Path:      "/accounts/:account_id/bottles/:id",
+			NewParams: func() interface{} { return new(showParams) },
+		})
+		server.Handle("CreateBottle", &goa.SOAPOperation{
+			Verb:      "POST",
+			Path:      "/accounts/:account_id/bottles",
+			NewParams: func() interface{} { return new(createParams) },
+		})
+		server.Handle("ListBottle", &goa.SOAPOperation{Verb: "GET", Path: "/bottles"})
+		handle := func(status int, body string) goa.MuxHandler {
+			return func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+				gotPath = req.URL.Path
+				gotQuery = req.URL.Query()
+				if req.Body != nil {
+					gotBody, _ = ioutil.ReadAll(req.Body)
+				}
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(status)
+				rw.Write([]byte(body))
+			}
+		}
+		service.Mux.Handle("GET", "/accounts/:account_id/bottles/:id", handle(200, `{"id":42,"name":"Number 8","tags":["red","dry"]}`))
+		service.Mux.Handle("POST", "/accounts/:account_id/bottles", handle(404, `{"id":1,"title":"not found","msg":"no account with id 1"}`))
+		service.Mux.Handle("GET", "/bottles", handle(200, `[{"id":1},{"id":2}]`))
+		server.Mount("")
+	})
+
+	JustBeforeEach(func() {
+		if method == "POST" {
+			body = fmt.Sprintf(envelope, body)
+		}
+		req, err := http.NewRequest(method, "http://localhost"+path, bytes.NewBufferString(body))
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Content-Type", "text/xml")
+		req.Header.Set("SOAPAction", "urn:cellar/ShowBottle")
+		service.Mux.ServeHTTP(rw, req)
+	})
+
+	It("bridges the request to the action", func() {
+		Ω(rw.Code).Should(Equal(200))
+		Ω(gotPath).Should(Equal("/accounts/1/bottles/42"))
+		Ω(gotQuery).Should(Equal(url.Values{"view": {"tiny"}}))
+		Ω(rw.Header().Get("Content-Type")).Should(Equal("text/xml; charset=utf-8"))
+		Ω(rw.Body.String()).Should(ContainSubstring(`<soap:Body><tns:ShowBottleResponse xmlns:tns="urn:cellar"><id>42</id><name>Number 8</name><tags>red</tags><tags>dry</tags></tns:ShowBottleResponse></soap:Body>`))
+	})
+
+	Context("with a payload", func() {
+		BeforeEach(func() {
+			body = `<c:CreateBottle><account_id>1</account_id><payload><name>Number 8</name><tags>red</tags></payload></c:CreateBottle>`
+		})
+
+		It("sends the payload in the body and returns the action error as a fault", func() {
+			Ω(gotPath).Should(Equal("/accounts/1/bottles"))
+			Ω(string(gotBody)).Should(MatchJSON(`{"name":"Number 8","tags":["red"]}`))
+			Ω(rw.Code).Should(Equal(500))
+			Ω(rw.Body.String()).Should(ContainSubstring(`<soap:Fault><faultcode>soap:Client</faultcode><faultstring>no account with id 1</faultstring><detail><status>404</status></detail></soap:Fault>`))
+		})
+	})
+
+	Context("with a collection response", func() {
+		BeforeEach(func() {
+			body = `<c:ListBottle/>`
+		})
+
+		It("renders the items", func() {
+			Ω(rw.Body.String()).Should(ContainSubstring(`<tns:ListBottleResponse xmlns:tns="urn:cellar"><item><id>1</id></item><item><id>2</id></item></tns:ListBottleResponse>`))
+		})
+	})
+
+	Context("with parameters of the wrong type", func() {
+		BeforeEach(func() {
+			body = `<c:ShowBottle><account_id>1</account_id><id>foo</id></c:ShowBottle>`
+		})
+
+		It("returns a client fault", func() {
+			Ω(gotPath).Should(BeEmpty())
+			Ω(rw.Code).Should(Equal(500))
+			Ω(rw.Body.String()).Should(ContainSubstring(`<faultcode>soap:Client</faultcode>`))
+		})
+	})
+
+	Context("with an unknown operation", func() {
+		BeforeEach(func() {
+			body = `<c:DeleteBottle/>`
+		})
+
+		It("returns a client fault", func() {
+			Ω(rw.Body.String()).Should(ContainSubstring(`<soap:Fault><faultcode>soap:Client</faultcode><faultstring>unknown operation DeleteBottle</faultstring></soap:Fault>`))
+		})
+	})
+
+	Context("requesting the WSDL", func() {
+		BeforeEach(func() {
+			method, path, body = "GET", "/soap?wsdl", ""
+		})
+
+		It("returns the WSDL", func() {
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Body.String()).Should(Equal("<definitions/>"))
+		})
+	})
+})
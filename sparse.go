@@ -0,0 +1,79 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// ParseFields parses the value of the fields query parameter of the actions that support sparse
+// fieldsets, a comma separated list of attribute names. It merges an invalid enum value error in
+// err for each name not listed in allowed. The code generated by goagen for the contexts of the
+// actions that use the SparseFields DSL uses ParseFields to initialize their Fields field.
+func ParseFields(raw string, allowed []string, err error) ([]string, error) {
+	legal := make(map[string]bool, len(allowed))
+	enum := make([]interface{}, len(allowed))
+	for i, a := range allowed {
+		legal[a] = true
+		enum[i] = a
+	}
+	var fields []string
+	for _, f := range strings.Split(raw, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !legal[f] {
+			err = InvalidEnumValueError("fields", f, enum, err)
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields, err
+}
+
+// SparseFields returns the representation of body that only contains the given attributes. body
+// is an object or an array of objects, it is returned as is if fields is empty. The attribute
+// names are the names used by the JSON encoding of body so the representation should be encoded
+// with an encoder that handles maps such as the JSON or msgpack encoders.
+func SparseFields(body interface{}, fields []string) interface{} {
+	if len(fields) == 0 || body == nil {
+		return body
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return body
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return body
+	}
+	keep := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		keep[f] = true
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return sparseObject(val, keep)
+	case []interface{}:
+		for i, e := range val {
+			if obj, ok := e.(map[string]interface{}); ok {
+				val[i] = sparseObject(obj, keep)
+			}
+		}
+		return val
+	}
+	return body
+}
+
+// sparseObject deletes the keys of obj that are not in keep.
+func sparseObject(obj map[string]interface{}, keep map[string]bool) map[string]interface{} {
+	for k := range obj {
+		if !keep[k] {
+			delete(obj, k)
+		}
+	}
+	return obj
+}
@@ -0,0 +1,54 @@
+package goa_test
+
+import (
+	"encoding/json"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseFields", func() {
+	allowed := []string{"id", "name", "vintage"}
+
+	It("parses the listed attributes", func() {
+		fields, err := goa.ParseFields("id, name,,", allowed, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(fields).Should(Equal([]string{"id", "name"}))
+	})
+
+	It("rejects unknown attributes", func() {
+		_, err := goa.ParseFields("id,color", allowed, nil)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring(`value of fields must be one of \"id\", \"name\", \"vintage\" but got value \"color\"`))
+	})
+})
+
+var _ = Describe("SparseFields", func() {
+	type bottle struct {
+		ID      int    `json:"id"`
+		Name    string `json:"name"`
+		Vintage *int   `json:"vintage,omitempty"`
+	}
+	vintage := 2012
+
+	render := func(body interface{}, fields ...string) string {
+		b, err := json.Marshal(goa.SparseFields(body, fields))
+		Ω(err).ShouldNot(HaveOccurred())
+		return string(b)
+	}
+
+	It("renders the listed attributes of objects", func() {
+		Ω(render(&bottle{ID: 1, Name: "Merlot", Vintage: &vintage}, "id", "vintage")).Should(Equal(`{"id":1,"vintage":2012}`))
+	})
+
+	It("renders the listed attributes of collections", func() {
+		bottles := []*bottle{{ID: 1, Name: "Merlot"}, {ID: 2, Name: "Syrah"}}
+		Ω(render(bottles, "name")).Should(Equal(`[{"name":"Merlot"},{"name":"Syrah"}]`))
+	})
+
+	It("returns the body as is if no attribute is listed", func() {
+		b := &bottle{ID: 1}
+		Ω(goa.SparseFields(b, nil)).Should(BeIdenticalTo(b))
+	})
+})
@@ -0,0 +1,240 @@
+package goa
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// List of supported streaming formats, see StreamOptions.
+const (
+	// StreamSSE writes each value as a server-sent event whose data is the value JSON
+	// representation.
+	StreamSSE = "sse"
+	// StreamJSON writes each value as a JSON document followed by a newline in a chunked
+	// response.
+	StreamJSON = "json"
+)
+
+type (
+	// StreamOptions configures how streaming responses are written.
+	StreamOptions struct {
+		// Format is one of StreamSSE or StreamJSON, default is StreamSSE.
+		Format string
+		// Heartbeat is the interval at which keep-alive messages are written while no value
+		// is sent, 0 disables heartbeats. Heartbeats are SSE comments or empty lines with
+		// the JSON format.
+		Heartbeat time.Duration
+		// FlushInterval is the maximum delay between the write of a value and the flush of
+		// the response, 0 flushes the response after each value.
+		FlushInterval time.Duration
+		// Event is the name of the server-sent events, the empty string omits the name.
+		Event string
+		// EventID computes the ID of the server-sent event carrying the given value. Clients
+		// send the ID of the last event they received in the Last-Event-ID header when they
+		// reconnect, see RequestData.LastEventID. Events have no ID if EventID is nil.
+		EventID func(v interface{}) string
+	}
+
+	// StreamEvent is a server-sent event written with Streamer.SendEvent.
+	StreamEvent struct {
+		// ID is the event ID if any.
+		ID string
+		// Event is the event name if any.
+		Event string
+		// Data is serialized into the event data using its JSON representation.
+		Data interface{}
+		// Retry is the delay clients should wait before reconnecting if not 0.
+		Retry time.Duration
+	}
+
+	// Streamer writes the values of a streaming response. Use ResponseData.NewStreamer to
+	// create streamers.
+	Streamer struct {
+		response *ResponseData
+		options  StreamOptions
+		flusher  http.Flusher
+		pending  bool
+	}
+)
+
+// WithDefaults returns a copy of the options whose unset format and heartbeat are set to the
+// given values. It may be called on nil options.
+func (o *StreamOptions) WithDefaults(format string, heartbeat time.Duration) *StreamOptions {
+	var res StreamOptions
+	if o != nil {
+		res = *o
+	}
+	if res.Format == "" {
+		res.Format = format
+	}
+	if res.Heartbeat == 0 {
+		res.Heartbeat = heartbeat
+	}
+	return &res
+}
+
+// LastEventID returns the value of the Last-Event-ID header sent by clients reconnecting to a
+// server-sent events stream, the empty string if none.
+func (r *RequestData) LastEventID() string {
+	return r.Header.Get("Last-Event-ID")
+}
+
+// NewStreamer writes the response headers of a streaming response with the given status code
+// and returns a streamer that writes the response values. opts may be nil.
+func (r *ResponseData) NewStreamer(code int, opts *StreamOptions) *Streamer {
+	s := &Streamer{response: r, options: *opts.WithDefaults(StreamSSE, 0)}
+	s.flusher, _ = r.ResponseWriter.(http.Flusher)
+	h := r.Header()
+	if s.options.Format == StreamJSON {
+		h.Set("Content-Type", "application/x-ndjson")
+	} else {
+		h.Set("Content-Type", "text/event-stream")
+	}
+	h.Set("Cache-Control", "no-cache")
+	r.WriteHeader(code)
+	s.Flush()
+	return s
+}
+
+// Stream writes a streaming response with the given status code containing the values received
+// on the values channel. Stream returns once the channel is closed, the context is done, the
+// client goes away or writing a value fails. opts may be nil.
+func (r *ResponseData) Stream(ctx context.Context, code int, opts *StreamOptions, values interface{}) error {
+	ch := reflect.ValueOf(values)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.RecvDir == 0 {
+		return fmt.Errorf("cannot stream values of type %T, must be a channel", values)
+	}
+	s := r.NewStreamer(code, opts)
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: ch},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		{Dir: reflect.SelectRecv},
+		{Dir: reflect.SelectRecv},
+		{Dir: reflect.SelectRecv},
+	}
+	if cn, ok := r.ResponseWriter.(http.CloseNotifier); ok {
+		cases[2].Chan = reflect.ValueOf(cn.CloseNotify())
+	}
+	var heartbeat *time.Timer
+	if d := s.options.Heartbeat; d > 0 {
+		heartbeat = time.NewTimer(d)
+		defer heartbeat.Stop()
+		cases[3].Chan = reflect.ValueOf(heartbeat.C)
+	}
+	if d := s.options.FlushInterval; d > 0 {
+		t := time.NewTicker(d)
+		defer t.Stop()
+		cases[4].Chan = reflect.ValueOf(t.C)
+	}
+	for {
+		chosen, v, ok := reflect.Select(cases)
+		switch chosen {
+		case 0:
+			if !ok {
+				s.Flush()
+				return nil
+			}
+			if err := s.Send(v.Interface()); err != nil {
+				return err
+			}
+			if heartbeat != nil {
+				// Keep-alive messages are only needed when no value is sent.
+				heartbeat.Reset(s.options.Heartbeat)
+			}
+		case 1:
+			s.Flush()
+			return ctx.Err()
+		case 2:
+			return nil
+		case 3:
+			if err := s.Heartbeat(); err != nil {
+				return err
+			}
+			heartbeat.Reset(s.options.Heartbeat)
+		case 4:
+			if s.pending {
+				s.Flush()
+			}
+		}
+	}
+}
+
+// Send writes the JSON representation of v.
+func (s *Streamer) Send(v interface{}) error {
+	ev := &StreamEvent{Event: s.options.Event, Data: v}
+	if s.options.EventID != nil {
+		ev.ID = s.options.EventID(v)
+	}
+	return s.SendEvent(ev)
+}
+
+// SendEvent writes a server-sent event. The event data is written using its JSON representation
+// with the StreamJSON format.
+func (s *Streamer) SendEvent(ev *StreamEvent) error {
+	b, err := json.Marshal(ev.Data)
+	if err != nil {
+		return err
+	}
+	if s.options.Format == StreamJSON {
+		return s.write(append(b, '\n'))
+	}
+	var msg []string
+	if ev.ID != "" {
+		msg = append(msg, "id: "+oneLine(ev.ID))
+	}
+	if ev.Event != "" {
+		msg = append(msg, "event: "+oneLine(ev.Event))
+	}
+	if ev.Retry > 0 {
+		msg = append(msg, "retry: "+strconv.FormatInt(int64(ev.Retry/time.Millisecond), 10))
+	}
+	msg = append(msg, "data: "+string(b))
+	return s.write([]byte(strings.Join(msg, "\n") + "\n\n"))
+}
+
+// Heartbeat writes a keep-alive message and flushes the response.
+func (s *Streamer) Heartbeat() error {
+	msg := ":\n\n"
+	if s.options.Format == StreamJSON {
+		msg = "\n"
+	}
+	if _, err := s.response.Write([]byte(msg)); err != nil {
+		return err
+	}
+	s.Flush()
+	return nil
+}
+
+// Flush sends the data written so far to the client if the underlying response writer supports
+// it.
+func (s *Streamer) Flush() {
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	s.pending = false
+}
+
+// write writes b and flushes the response unless the flush interval is set.
+func (s *Streamer) write(b []byte) error {
+	if _, err := s.response.Write(b); err != nil {
+		return err
+	}
+	if s.options.FlushInterval > 0 {
+		s.pending = true
+		return nil
+	}
+	s.Flush()
+	return nil
+}
+
+// oneLine replaces the line breaks of SSE field values with spaces.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,122 @@
+package goa_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Stream", func() {
+	var rw *httptest.ResponseRecorder
+	var ctx context.Context
+	var opts *goa.StreamOptions
+	var values chan *streamValue
+	var streamErr error
+
+	BeforeEach(func() {
+		rw = httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/bottles", nil)
+		ctx = goa.NewContext(nil, goa.New("test"), rw, req, nil)
+		opts = nil
+		values = make(chan *streamValue, 2)
+		values <- &streamValue{ID: 1}
+		values <- &streamValue{ID: 2}
+		close(values)
+	})
+
+	JustBeforeEach(func() {
+		streamErr = goa.Response(ctx).Stream(ctx, 200, opts, values)
+	})
+
+	Context("with the default options", func() {
+		It("writes server-sent events", func() {
+			Ω(streamErr).ShouldNot(HaveOccurred())
+			Ω(rw.Code).Should(Equal(200))
+			Ω(rw.Header().Get("Content-Type")).Should(Equal("text/event-stream"))
+			Ω(rw.Header().Get("Cache-Control")).Should(Equal("no-cache"))
+			Ω(rw.Body.String()).Should(Equal("data: {\"id\":1}\n\ndata: {\"id\":2}\n\n"))
+			Ω(rw.Flushed).Should(BeTrue())
+		})
+	})
+
+	Context("with event names and IDs", func() {
+		BeforeEach(func() {
+			opts = &goa.StreamOptions{
+				Event:   "bottle",
+				EventID: func(v interface{}) string { return fmt.Sprintf("%d", v.(*streamValue).ID) },
+			}
+		})
+
+		It("writes the event fields", func() {
+			Ω(streamErr).ShouldNot(HaveOccurred())
+			Ω(rw.Body.String()).Should(Equal("id: 1\nevent: bottle\ndata: {\"id\":1}\n\nid: 2\nevent: bottle\ndata: {\"id\":2}\n\n"))
+		})
+	})
+
+	Context("with the JSON format", func() {
+		BeforeEach(func() {
+			opts = &goa.StreamOptions{Format: goa.StreamJSON}
+		})
+
+		It("writes newline delimited JSON", func() {
+			Ω(streamErr).ShouldNot(HaveOccurred())
+			Ω(rw.Header().Get("Content-Type")).Should(Equal("application/x-ndjson"))
+			Ω(rw.Body.String()).Should(Equal("{\"id\":1}\n{\"id\":2}\n"))
+		})
+	})
+
+	Context("with a heartbeat", func() {
+		BeforeEach(func() {
+			opts = &goa.StreamOptions{Heartbeat: time.Millisecond}
+			values = make(chan *streamValue)
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				close(values)
+			}()
+		})
+
+		It("writes keep-alive comments while idle", func() {
+			Ω(streamErr).ShouldNot(HaveOccurred())
+			Ω(rw.Body.String()).Should(HavePrefix(":\n\n"))
+		})
+	})
+
+	Context("with a context that is done", func() {
+		BeforeEach(func() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithCancel(ctx)
+			cancel()
+			values = make(chan *streamValue)
+		})
+
+		It("returns the context error", func() {
+			Ω(streamErr).Should(Equal(context.Canceled))
+		})
+	})
+
+	Context("with a value that is not a channel", func() {
+		It("returns an error", func() {
+			err := goa.Response(ctx).Stream(ctx, 200, nil, []int{1})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("LastEventID", func() {
+	It("returns the Last-Event-ID header", func() {
+		req, _ := http.NewRequest("GET", "/bottles", nil)
+		req.Header.Set("Last-Event-ID", "42")
+		ctx := goa.NewContext(nil, goa.New("test"), httptest.NewRecorder(), req, nil)
+		Ω(goa.Request(ctx).LastEventID()).Should(Equal("42"))
+	})
+})
+
+type streamValue struct {
+	ID int `json:"id"`
+}
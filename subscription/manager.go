@@ -0,0 +1,280 @@
+package subscription
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/wshub"
+	"golang.org/x/net/context"
+)
+
+type (
+	// Manager records the subscriptions to the events of a resource and notifies the
+	// subscribers when the events are published.
+	Manager struct {
+		// Resource is the name of the subscribable resource.
+		Resource string
+		// Events lists the names of the subscribable events.
+		Events []string
+		// Path is the path of the subscription endpoints including the API base path, the
+		// subscription hrefs are built by appending the subscription IDs to it.
+		Path string
+		// Store records the subscriptions.
+		Store Store
+		// Dispatcher delivers the events to the callback URLs. Its Event field is set to the
+		// name of the event being delivered, the other fields - secret, signing scheme,
+		// retry policy etc. - apply to all the deliveries.
+		Dispatcher *goa.WebhookDispatcher
+		// Hub serves the WebSocket connections of the subscribers that stream the events.
+		Hub *wshub.Hub
+
+		mu      sync.RWMutex
+		streams map[string][]string // Events streamed on each connection indexed by ID
+	}
+
+	// Notification is the message sent on the WebSocket connections of the subscribers when an
+	// event is published.
+	Notification struct {
+		// Event is the name of the event.
+		Event string `json:"event"`
+		// Resource is the name of the subscribable resource.
+		Resource string `json:"resource"`
+		// Payload is the event payload.
+		Payload interface{} `json:"payload"`
+	}
+
+	// subscribeRequest is the body of the requests that create subscriptions.
+	subscribeRequest struct {
+		Events      []string `json:"events"`
+		CallbackURL string   `json:"callback_url"`
+	}
+)
+
+// NewManager returns a manager for the given events of resource that records the subscriptions in
+// store and whose subscription hrefs start with path. The manager dispatcher uses the encoders of
+// service and makes a single delivery attempt.
+func NewManager(service *goa.Service, resource, path string, store Store, events ...string) *Manager {
+	return &Manager{
+		Resource:   resource,
+		Events:     events,
+		Path:       strings.TrimSuffix(path, "/"),
+		Store:      store,
+		Dispatcher: goa.NewWebhookDispatcher(service, ""),
+		Hub:        wshub.New(),
+		streams:    make(map[string][]string),
+	}
+}
+
+// Subscribe records a subscription of the callback URL to the given events, all the events of the
+// manager if none is given.
+func (m *Manager) Subscribe(ctx context.Context, callbackURL string, events ...string) (*Subscription, error) {
+	events, err := m.validateEvents(events)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, goa.ErrBadRequest("invalid callback URL %#v, must be an absolute HTTP URL", callbackURL)
+	}
+	id := newSubscriptionID()
+	sub := &Subscription{
+		ID:          id,
+		Href:        m.Path + "/" + id,
+		Resource:    m.Resource,
+		Events:      events,
+		CallbackURL: callbackURL,
+		Created:     time.Now().UTC(),
+	}
+	if err := m.Store.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Publish notifies the subscribers of the event: it delivers the payload to the callback URLs of
+// the subscriptions for the event concurrently and sends a Notification to the WebSocket
+// connections streaming the event. Publish waits for the deliveries to complete and returns an
+// error describing the failures if any.
+func (m *Manager) Publish(ctx context.Context, event string, payload interface{}) error {
+	if !m.isEvent(event) {
+		return fmt.Errorf("unknown event %#v", event)
+	}
+	subs, err := m.Store.List(ctx)
+	if err != nil {
+		return err
+	}
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+		first  error
+	)
+	for _, sub := range subs {
+		if sub.CallbackURL == "" || !sub.Subscribed(event) {
+			continue
+		}
+		d := *m.Dispatcher
+		d.Event = event
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			if err := d.Deliver(ctx, url, payload); err != nil {
+				mu.Lock()
+				if first == nil {
+					first = err
+				}
+				failed++
+				mu.Unlock()
+			}
+		}(sub.CallbackURL)
+	}
+	n := &Notification{Event: event, Resource: m.Resource, Payload: payload}
+	if err := m.Hub.BroadcastTo(n, func(c *wshub.Conn) bool { return m.isStreamed(c.ID, event) }); err != nil {
+		goa.Error(ctx, "failed to stream event", goa.KV{"event", event}, goa.KV{"error", err.Error()})
+	}
+	wg.Wait()
+	goa.IncrCounter([]string{"goa", "subscription", m.Resource, event}, 1.0)
+	if failed > 0 {
+		return fmt.Errorf("failed to notify %d subscriber(s): %s", failed, first)
+	}
+	return nil
+}
+
+// Mount mounts the subscription endpoints on the service: POST requests made to "<path>" create
+// subscriptions, GET requests list them or - if they ask for a WebSocket upgrade - stream the
+// events given in the "events" querystring parameter (all events if absent). GET and DELETE
+// requests made to "<path>/:id" show and delete the subscription with the given ID.
+func (m *Manager) Mount(service *goa.Service) {
+	service.Mux.Handle("POST", m.Path, func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+		var body subscribeRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			m.respond(rw, 0, nil, goa.ErrBadRequest(err))
+			return
+		}
+		sub, err := m.Subscribe(req.Context(), body.CallbackURL, body.Events...)
+		if err == nil {
+			rw.Header().Set("Location", sub.Href)
+		}
+		m.respond(rw, http.StatusCreated, sub, err)
+	})
+	service.Mux.Handle("GET", m.Path, func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+		if strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+			m.stream(rw, req, params)
+			return
+		}
+		subs, err := m.Store.List(req.Context())
+		m.respond(rw, http.StatusOK, subs, err)
+	})
+	service.Mux.Handle("GET", m.Path+"/:id", func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+		sub, err := m.Store.Get(req.Context(), params.Get("id"))
+		m.respond(rw, http.StatusOK, sub, err)
+	})
+	service.Mux.Handle("DELETE", m.Path+"/:id", func(rw http.ResponseWriter, req *http.Request, params url.Values) {
+		err := m.Store.Delete(req.Context(), params.Get("id"))
+		m.respond(rw, http.StatusNoContent, nil, err)
+	})
+}
+
+// stream serves a WebSocket connection that streams the requested events until it is closed.
+func (m *Manager) stream(rw http.ResponseWriter, req *http.Request, params url.Values) {
+	var requested []string
+	if e := params.Get("events"); e != "" {
+		requested = strings.Split(e, ",")
+	}
+	events, err := m.validateEvents(requested)
+	if err != nil {
+		m.respond(rw, 0, nil, err)
+		return
+	}
+	m.Hub.Serve(req.Context(), rw, req, nil, func(c *wshub.Conn) error {
+		m.mu.Lock()
+		m.streams[c.ID] = events
+		m.mu.Unlock()
+		defer func() {
+			m.mu.Lock()
+			delete(m.streams, c.ID)
+			m.mu.Unlock()
+		}()
+		// Subscribers do not send messages, wait for the connection to close.
+		var msg interface{}
+		for {
+			if err := c.Receive(&msg); err != nil {
+				return nil
+			}
+		}
+	})
+}
+
+// isStreamed returns true if the connection with the given ID streams the event.
+func (m *Manager) isStreamed(id, event string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, e := range m.streams[id] {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// validateEvents checks that the given events are subscribable. It returns all the events of the
+// manager if none is given.
+func (m *Manager) validateEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return m.Events, nil
+	}
+	for _, e := range events {
+		if !m.isEvent(e) {
+			return nil, goa.ErrBadRequest("unknown event %#v, must be one of %s", e, strings.Join(m.Events, ", "))
+		}
+	}
+	return events, nil
+}
+
+// isEvent returns true if the event is subscribable.
+func (m *Manager) isEvent(event string) bool {
+	for _, e := range m.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// respond writes the response of the subscription endpoints. Errors are rendered with the
+// goa.HTTPError JSON representation.
+func (m *Manager) respond(rw http.ResponseWriter, status int, body interface{}, err error) {
+	if err != nil {
+		herr, ok := err.(*goa.HTTPError)
+		if !ok {
+			if err == ErrNotFound {
+				herr = goa.ErrNotFound(err)
+			} else {
+				herr = goa.ErrInternal(err)
+			}
+		}
+		status, body = herr.Status, herr
+	}
+	if body == nil {
+		rw.WriteHeader(status)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(body)
+}
+
+// newSubscriptionID returns a random subscription ID.
+func newSubscriptionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,105 @@
+package subscription_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/goadesign/goa"
+	"github.com/goadesign/goa/subscription"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+)
+
+var _ = Describe("Manager", func() {
+	var service *goa.Service
+	var manager *subscription.Manager
+	var callbacks *httptest.Server
+	var received chan string
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		service.SetEncoder(goa.JSONEncoderFactory(), true, "*/*")
+		manager = subscription.NewManager(service, "order", "/api/orders/subscriptions",
+			subscription.NewMemoryStore(), "OrderShipped", "OrderCanceled")
+		manager.Mount(service)
+		received = make(chan string, 10)
+		callbacks = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			received <- req.Header.Get("X-Webhook-Event")
+		}))
+	})
+
+	AfterEach(func() {
+		callbacks.Close()
+	})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, strings.NewReader(body))
+		Ω(err).ShouldNot(HaveOccurred())
+		rw := httptest.NewRecorder()
+		service.Mux.ServeHTTP(rw, req)
+		return rw
+	}
+
+	It("delivers the events to the subscribed callback URLs", func() {
+		sub, err := manager.Subscribe(context.Background(), callbacks.URL, "OrderShipped")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(sub.Href).Should(Equal("/api/orders/subscriptions/" + sub.ID))
+
+		Ω(manager.Publish(context.Background(), "OrderCanceled", map[string]string{"id": "1"})).ShouldNot(HaveOccurred())
+		Ω(manager.Publish(context.Background(), "OrderShipped", map[string]string{"id": "1"})).ShouldNot(HaveOccurred())
+		Ω(received).Should(Receive(Equal("OrderShipped")))
+		Ω(received).ShouldNot(Receive())
+		Ω(manager.Publish(context.Background(), "OrderLost", nil)).Should(HaveOccurred())
+	})
+
+	It("rejects invalid subscriptions", func() {
+		_, err := manager.Subscribe(context.Background(), callbacks.URL, "OrderLost")
+		Ω(err).Should(HaveOccurred())
+		Ω(goa.ErrorStatus(err)).Should(Equal(400))
+		_, err = manager.Subscribe(context.Background(), "/relative")
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("serves the subscription endpoints", func() {
+		rw := serve("POST", "/api/orders/subscriptions", `{"callback_url":"`+callbacks.URL+`"}`)
+		Ω(rw.Code).Should(Equal(201))
+		var sub subscription.Subscription
+		Ω(json.Unmarshal(rw.Body.Bytes(), &sub)).ShouldNot(HaveOccurred())
+		Ω(rw.Header().Get("Location")).Should(Equal(sub.Href))
+		Ω(sub.Events).Should(Equal([]string{"OrderShipped", "OrderCanceled"}))
+
+		rw = serve("GET", "/api/orders/subscriptions", "")
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Body.String()).Should(ContainSubstring(sub.ID))
+		Ω(serve("GET", sub.Href, "").Code).Should(Equal(200))
+		Ω(serve("DELETE", sub.Href, "").Code).Should(Equal(204))
+		rw = serve("GET", sub.Href, "")
+		Ω(rw.Code).Should(Equal(404))
+		Ω(rw.Body.String()).Should(ContainSubstring(`"code":"not_found"`))
+		Ω(serve("POST", "/api/orders/subscriptions", `{"events":["OrderLost"]}`).Code).Should(Equal(400))
+	})
+
+	It("streams the events on WebSocket connections", func() {
+		server := httptest.NewServer(service.Mux)
+		defer server.Close()
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/orders/subscriptions?events=OrderShipped"
+		ws, err := websocket.Dial(url, "", server.URL)
+		Ω(err).ShouldNot(HaveOccurred())
+		defer ws.Close()
+
+		var n subscription.Notification
+		Eventually(func() error {
+			Ω(manager.Publish(context.Background(), "OrderShipped", "1")).ShouldNot(HaveOccurred())
+			ws.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+			return websocket.JSON.Receive(ws, &n)
+		}).Should(Succeed())
+		Ω(n.Event).Should(Equal("OrderShipped"))
+		Ω(n.Resource).Should(Equal("order"))
+		Ω(n.Payload).Should(Equal("1"))
+	})
+})
@@ -0,0 +1,133 @@
+// Package subscription provides the runtime support for the subscribable resources of goa
+// services. Clients subscribe to the events of a resource either by registering a callback URL the
+// events are posted to or by opening a WebSocket connection the events are sent on. A Manager
+// records the subscriptions in a Store, serves the subscription endpoints and fans out the events
+// published by the service to the subscribers.
+//
+// The Mount<Resource>Subscriptions functions generated by goagen for the resources that define
+// subscribable events create and mount a manager:
+//
+//	orders := app.MountOrderSubscriptions(service, subscription.NewMemoryStore())
+//	orders.Dispatcher.Secret = []byte("secret")
+//
+//	func (c *OrderController) Ship(ctx *app.ShipOrderContext) error {
+//		// ...
+//		orders.Publish(ctx, "OrderShipped", shipped)
+//		return ctx.NoContent()
+//	}
+package subscription
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrNotFound is the error returned by stores when a subscription does not exist.
+var ErrNotFound = errors.New("subscription not found")
+
+type (
+	// Subscription describes the registration of a callback URL for a set of events.
+	Subscription struct {
+		// ID identifies the subscription.
+		ID string `json:"id"`
+		// Href is the API href of the subscription.
+		Href string `json:"href"`
+		// Resource is the name of the subscribable resource.
+		Resource string `json:"resource"`
+		// Events lists the names of the events the subscription is for.
+		Events []string `json:"events"`
+		// CallbackURL is the URL the events are posted to.
+		CallbackURL string `json:"callback_url"`
+		// Created is the subscription creation time.
+		Created time.Time `json:"created_at"`
+	}
+
+	// Store is implemented by the subscription stores.
+	Store interface {
+		// Create records a new subscription.
+		Create(ctx context.Context, s *Subscription) error
+		// Get returns the subscription with the given ID or ErrNotFound.
+		Get(ctx context.Context, id string) (*Subscription, error)
+		// List returns all the subscriptions in creation order.
+		List(ctx context.Context) ([]*Subscription, error)
+		// Delete deletes the subscription with the given ID or returns ErrNotFound.
+		Delete(ctx context.Context, id string) error
+	}
+
+	// MemoryStore is a Store that keeps the subscriptions in memory. It is suitable for services
+	// running a single instance.
+	MemoryStore struct {
+		mu   sync.RWMutex
+		subs map[string]Subscription
+		ids  []string
+	}
+)
+
+// Subscribed returns true if the subscription is for the given event.
+func (s *Subscription) Subscribed(event string) bool {
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{subs: make(map[string]Subscription)}
+}
+
+// Create implements Store.
+func (s *MemoryStore) Create(_ context.Context, sub *Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[sub.ID]; !ok {
+		s.ids = append(s.ids, sub.ID)
+	}
+	s.subs[sub.ID] = *sub
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, id string) (*Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sub, ok := s.subs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &sub, nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(_ context.Context) ([]*Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	subs := make([]*Subscription, len(s.ids))
+	for i, id := range s.ids {
+		sub := s.subs[id]
+		subs[i] = &sub
+	}
+	return subs, nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.subs, id)
+	for i, sid := range s.ids {
+		if sid == id {
+			s.ids = append(s.ids[:i], s.ids[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
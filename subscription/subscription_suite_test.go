@@ -0,0 +1,13 @@
+package subscription_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestSubscription(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Subscription Suite")
+}
@@ -0,0 +1,134 @@
+package goa
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// TenantSubdomain identifies the tenant with the first label of the request host.
+	TenantSubdomain = "subdomain"
+	// TenantHeader identifies the tenant with the value of a request header.
+	TenantHeader = "header"
+	// TenantParam identifies the tenant with the value of an API base path parameter.
+	TenantParam = "param"
+)
+
+// TenantHook is the per-tenant configuration hook invoked once the tenant of a request has been
+// identified. It returns the context used to handle the request, typically holding the tenant
+// configuration (database, feature flags etc.). It may also canonicalize the tenant with
+// WithTenant or reject unknown tenants by returning an error, e.g. ErrNotFound.
+type TenantHook func(ctx context.Context, tenant string) (context.Context, error)
+
+// SetTenantHook sets the hook invoked by the handlers created with ResolveTenant.
+func (service *Service) SetTenantHook(hook TenantHook) {
+	service.tenantMu.Lock()
+	defer service.tenantMu.Unlock()
+	service.tenantHook = hook
+}
+
+// ResolveTenant returns a handler that identifies the tenant of the request before calling h. The
+// tenant is read from the request host, header or API base path parameter depending on source, see
+// RequestTenant. Requests that do not identify a tenant are rejected with a 400 response. The
+// tenant is stored in the request context with WithTenant and the hook set on the service with
+// SetTenantHook, if any, is invoked prior to calling h. The code generated by goagen wraps the
+// handlers of the actions of multi-tenant APIs with ResolveTenant.
+func ResolveTenant(h Handler, source, name string) Handler {
+	return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+		var params url.Values
+		if r := Request(ctx); r != nil {
+			params = r.Params
+		}
+		tenant := RequestTenant(req, source, name, params)
+		if tenant == "" {
+			return ErrBadRequest("missing tenant")
+		}
+		ctx = WithTenant(ctx, tenant)
+		if service := RequestService(ctx); service != nil {
+			service.tenantMu.RLock()
+			hook := service.tenantHook
+			service.tenantMu.RUnlock()
+			if hook != nil {
+				var err error
+				if ctx, err = hook(ctx, tenant); err != nil {
+					return err
+				}
+			}
+		}
+		if source == TenantParam {
+			// Make the href factories use the resolved tenant.
+			ctx = WithBaseParams(ctx, url.Values{name: {ContextTenant(ctx)}})
+		}
+		return h(ctx, rw, req)
+	}
+}
+
+// RequestTenant returns the tenant identified by the given request, the empty string if there is
+// none. name is the name of the header for TenantHeader, the name of the base path parameter for
+// TenantParam and the parent domain of the tenant subdomains for TenantSubdomain. If the parent
+// domain is empty the first label of hosts made of at least three labels is used.
+func RequestTenant(req *http.Request, source, name string, params url.Values) string {
+	switch source {
+	case TenantHeader:
+		return req.Header.Get(name)
+	case TenantParam:
+		return params.Get(name)
+	case TenantSubdomain:
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if name != "" {
+			if !strings.HasSuffix(host, "."+name) {
+				return ""
+			}
+			sub := strings.TrimSuffix(host, "."+name)
+			if strings.Contains(sub, ".") {
+				return ""
+			}
+			return sub
+		}
+		if labels := strings.Split(host, "."); len(labels) > 2 {
+			return labels[0]
+		}
+	}
+	return ""
+}
+
+// SetRequestTenant sets the tenant of a client request made to a multi-tenant API, it does nothing
+// if tenant is empty. The generated clients use it to make requests on behalf of a tenant.
+// TenantParam tenants are part of the request path and are not handled by SetRequestTenant.
+func SetRequestTenant(req *http.Request, source, name, tenant string) error {
+	if tenant == "" {
+		return nil
+	}
+	switch source {
+	case TenantHeader:
+		req.Header.Set(name, tenant)
+	case TenantSubdomain:
+		// The client host is the parent domain of the tenant subdomains.
+		req.URL.Host = tenant + "." + req.URL.Host
+		req.Host = ""
+	}
+	return nil
+}
+
+// WithTenant returns a copy of ctx holding the given tenant. The tenant is attached to the request
+// log fields under the "tenant" key, see LogWith.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	LogWith(ctx, "tenant", tenant)
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// ContextTenant returns the tenant of the request with the given context, the empty string if the
+// request is not made on behalf of a tenant.
+func ContextTenant(ctx context.Context) string {
+	if t, ok := ctx.Value(tenantKey).(string); ok {
+		return t
+	}
+	return ""
+}
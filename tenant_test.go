@@ -0,0 +1,102 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("RequestTenant", func() {
+	newRequest := func(host string) *http.Request {
+		req, _ := http.NewRequest("GET", "http://"+host+"/projects", nil)
+		return req
+	}
+
+	It("reads the tenant from the subdomain", func() {
+		Ω(goa.RequestTenant(newRequest("acme.api.example.com:8080"), goa.TenantSubdomain, "", nil)).Should(Equal("acme"))
+		Ω(goa.RequestTenant(newRequest("acme.api.example.com"), goa.TenantSubdomain, "api.example.com", nil)).Should(Equal("acme"))
+		Ω(goa.RequestTenant(newRequest("a.b.api.example.com"), goa.TenantSubdomain, "api.example.com", nil)).Should(BeEmpty())
+		Ω(goa.RequestTenant(newRequest("example.com"), goa.TenantSubdomain, "", nil)).Should(BeEmpty())
+	})
+
+	It("reads the tenant from a header or a parameter", func() {
+		req := newRequest("api.example.com")
+		req.Header.Set("X-Tenant-ID", "acme")
+		Ω(goa.RequestTenant(req, goa.TenantHeader, "X-Tenant-ID", nil)).Should(Equal("acme"))
+		params := url.Values{"tenant": {"globex"}}
+		Ω(goa.RequestTenant(req, goa.TenantParam, "tenant", params)).Should(Equal("globex"))
+	})
+
+	It("sets the tenant of client requests", func() {
+		req := newRequest("api.example.com")
+		Ω(goa.SetRequestTenant(req, goa.TenantSubdomain, "", "acme")).ShouldNot(HaveOccurred())
+		Ω(req.URL.Host).Should(Equal("acme.api.example.com"))
+		Ω(goa.SetRequestTenant(req, goa.TenantHeader, "X-Tenant-ID", "acme")).ShouldNot(HaveOccurred())
+		Ω(req.Header.Get("X-Tenant-ID")).Should(Equal("acme"))
+	})
+})
+
+var _ = Describe("ResolveTenant", func() {
+	var service *goa.Service
+	var tenant string
+	var base url.Values
+	var handler goa.Handler
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		tenant, base = "", nil
+		ok := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			tenant = goa.ContextTenant(ctx)
+			base = goa.ContextBaseParams(ctx)
+			rw.WriteHeader(200)
+			return nil
+		}
+		handler = goa.ResolveTenant(ok, goa.TenantParam, "tenant")
+	})
+
+	serve := func(params url.Values) error {
+		req, _ := http.NewRequest("GET", "/", nil)
+		rw := httptest.NewRecorder()
+		ctx := goa.NewContext(context.Background(), service, rw, req, params)
+		return handler(ctx, rw, req)
+	}
+
+	It("stores the tenant in the context", func() {
+		Ω(serve(url.Values{"tenant": {"acme"}})).ShouldNot(HaveOccurred())
+		Ω(tenant).Should(Equal("acme"))
+	})
+
+	It("rejects requests without tenant", func() {
+		err := serve(nil)
+		Ω(err).Should(HaveOccurred())
+		Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(400))
+	})
+
+	Context("with a tenant hook", func() {
+		BeforeEach(func() {
+			service.SetTenantHook(func(ctx context.Context, t string) (context.Context, error) {
+				if t == "unknown" {
+					return nil, goa.ErrNotFound("unknown tenant")
+				}
+				return goa.WithTenant(ctx, "tenant-"+t), nil
+			})
+		})
+
+		It("canonicalizes the tenant", func() {
+			Ω(serve(url.Values{"tenant": {"acme"}})).ShouldNot(HaveOccurred())
+			Ω(tenant).Should(Equal("tenant-acme"))
+			Ω(base.Get("tenant")).Should(Equal("tenant-acme"))
+		})
+
+		It("rejects unknown tenants", func() {
+			err := serve(url.Values{"tenant": {"unknown"}})
+			Ω(err).Should(HaveOccurred())
+			Ω(err.(goa.ServiceError).ResponseStatus()).Should(Equal(404))
+		})
+	})
+})
@@ -0,0 +1,773 @@
+package goa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Thrift binary protocol wire types.
+const (
+	thriftStop   byte = 0
+	thriftBool   byte = 2
+	thriftByte   byte = 3
+	thriftDouble byte = 4
+	thriftI16    byte = 6
+	thriftI32    byte = 8
+	thriftI64    byte = 10
+	thriftString byte = 11
+	thriftStruct byte = 12
+	thriftMap    byte = 13
+	thriftSet    byte = 14
+	thriftList   byte = 15
+)
+
+// Thrift message types.
+const (
+	thriftCall      = 1
+	thriftReply     = 2
+	thriftException = 3
+	thriftOneway    = 4
+)
+
+// Thrift application exception types.
+const (
+	thriftUnknownMethod = 1
+	thriftInternalError = 6
+	thriftProtocolError = 7
+)
+
+const (
+	// ThriftContentType is the content type of the Thrift requests and responses.
+	ThriftContentType = "application/x-thrift"
+	// ThriftErrorStruct is the name of the exception struct thrown by the Thrift methods when
+	// the action fails, it has two fields: "status" (1: i32) and "message" (2: string).
+	ThriftErrorStruct = "ActionError"
+
+	// thriftPayloadArg is the name of the Thrift method argument holding the action payload.
+	thriftPayloadArg = "payload"
+	// thriftVersionMask is the mask of the version bits of strict binary protocol messages.
+	thriftVersionMask = 0xffff0000
+	// thriftVersion1 is the version of strict binary protocol messages.
+	thriftVersion1 = 0x80010000
+)
+
+type (
+	// ThriftServer serves Thrift requests made with the binary protocol over HTTP by bridging
+	// them to the controllers mounted on a service. Each method is mapped to the route of the
+	// corresponding action: the arguments named after the route wildcards are used to build
+	// the request path, the "payload" argument if any provides the request body and the other
+	// arguments are sent in the querystring. The action response body becomes the method result,
+	// action errors are thrown as ActionError exceptions.
+	// The code generated by "goagen thrift" registers the methods of all the actions defined in
+	// the design together with the structs they use. Method names are qualified with the
+	// service name as done by the Thrift multiplexed protocol ("BottleService:show").
+	ThriftServer struct {
+		// Service is the service the requests are dispatched to.
+		Service *Service
+		// Structs describes the fields of the structs used by the methods indexed by struct
+		// name.
+		Structs map[string][]ThriftField
+
+		methods map[string]*ThriftMethod
+	}
+
+	// ThriftMethod describes the action route a Thrift method maps to and the method arguments
+	// and result.
+	ThriftMethod struct {
+		// Version is the name of the API version that defines the action, empty if none.
+		Version string
+		// Verb is the HTTP method of the route.
+		Verb string
+		// Path is the full path of the route including wildcards.
+		Path string
+		// Args describes the method arguments.
+		Args []ThriftField
+		// Result is the type of the method result, empty for void methods.
+		Result string
+	}
+
+	// ThriftField describes a field of a Thrift struct or an argument of a Thrift method.
+	ThriftField struct {
+		// ID is the field identifier.
+		ID int16
+		// Name is the name of the attribute the field was built from.
+		Name string
+		// Type is the field type: "bool", "i32", "i64", "double", "string", "json" for values
+		// sent as JSON encoded strings, "list<T>", "map<K,V>" or the name of a struct.
+		Type string
+	}
+
+	// thriftType is a parsed Thrift field type.
+	thriftType struct {
+		wire   byte
+		json   bool
+		name   string
+		key    *thriftType
+		elem   *thriftType
+		fields []ThriftField
+	}
+
+	// thriftReader decodes values encoded with the binary protocol.
+	thriftReader struct {
+		r       io.Reader
+		structs map[string][]ThriftField
+	}
+
+	// thriftWriter encodes values with the binary protocol.
+	thriftWriter struct {
+		buf     bytes.Buffer
+		structs map[string][]ThriftField
+	}
+
+	// thriftAppError is a Thrift application exception.
+	thriftAppError struct {
+		kind int32
+		msg  string
+	}
+)
+
+// NewThriftServer returns a Thrift server that dispatches requests to the given service.
+func NewThriftServer(service *Service) *ThriftServer {
+	return &ThriftServer{
+		Service: service,
+		Structs: make(map[string][]ThriftField),
+		methods: make(map[string]*ThriftMethod),
+	}
+}
+
+// Handle maps the Thrift method with the given name to the given action route.
+func (s *ThriftServer) Handle(name string, method *ThriftMethod) {
+	s.methods[name] = method
+}
+
+// Mount registers the server with the service mux for POST requests made to the given path,
+// "/thrift" if empty.
+func (s *ThriftServer) Mount(path string) {
+	if path == "" {
+		path = "/thrift"
+	}
+	s.Service.Mux.Handle("POST", path, func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+		s.ServeHTTP(rw, req)
+	})
+	s.Service.LogInfo("mount thrift", KV{"path", fmt.Sprintf("POST %s", path)})
+}
+
+// ServeHTTP serves a Thrift request.
+func (s *ThriftServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		rw.Header().Set("Allow", "POST")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r := &thriftReader{r: bytes.NewReader(body), structs: s.Structs}
+	name, typ, seqID, err := r.readMessageBegin()
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w := &thriftWriter{structs: s.Structs}
+	result, err := s.call(req, r, name, typ)
+	if typ == thriftOneway {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	switch e := err.(type) {
+	case nil:
+		if err := w.writeReply(name, seqID, s.methods[name].Result, result); err != nil {
+			w = &thriftWriter{structs: s.Structs}
+			w.writeAppError(name, seqID, &thriftAppError{kind: thriftInternalError, msg: err.Error()})
+		}
+	case *thriftAppError:
+		w.writeAppError(name, seqID, e)
+	case *HTTPError:
+		w.writeActionError(name, seqID, e)
+	}
+	rw.Header().Set("Content-Type", ThriftContentType)
+	rw.WriteHeader(http.StatusOK)
+	rw.Write(w.buf.Bytes())
+}
+
+// call decodes the arguments of the method with the given name, invokes the corresponding action
+// and returns the decoded response body. The error is a *thriftAppError if the request is
+// invalid, a *HTTPError if the action failed.
+func (s *ThriftServer) call(req *http.Request, r *thriftReader, name string, typ int) (interface{}, error) {
+	if typ != thriftCall && typ != thriftOneway {
+		return nil, &thriftAppError{kind: thriftProtocolError, msg: fmt.Sprintf("invalid message type %d", typ)}
+	}
+	method, ok := s.methods[name]
+	if !ok {
+		return nil, &thriftAppError{kind: thriftUnknownMethod, msg: fmt.Sprintf("unknown method %s", name)}
+	}
+	args, err := r.readStruct(method.Args)
+	if err != nil {
+		return nil, &thriftAppError{kind: thriftProtocolError, msg: err.Error()}
+	}
+	inner, err := bridgeRequest(req, method.Verb, method.Path, args, thriftPayloadArg)
+	if err != nil {
+		return nil, ErrBadRequest(err)
+	}
+	rec := bridgeServe(s.Service, method.Version, inner)
+	if rec.failed() {
+		return nil, &HTTPError{Status: rec.status, Detail: rec.errorMessage()}
+	}
+	body := bytes.TrimSpace(rec.body.Bytes())
+	if method.Result == "" || len(body) == 0 {
+		return nil, nil
+	}
+	var result interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&result); err != nil {
+		result = string(body)
+	}
+	return result, nil
+}
+
+// Error returns the exception message.
+func (e *thriftAppError) Error() string {
+	return e.msg
+}
+
+// parseThriftType parses the given field type.
+func parseThriftType(t string, structs map[string][]ThriftField) (*thriftType, error) {
+	t = strings.TrimSpace(t)
+	switch t {
+	case "bool":
+		return &thriftType{wire: thriftBool}, nil
+	case "i32":
+		return &thriftType{wire: thriftI32}, nil
+	case "i64":
+		return &thriftType{wire: thriftI64}, nil
+	case "double":
+		return &thriftType{wire: thriftDouble}, nil
+	case "string":
+		return &thriftType{wire: thriftString}, nil
+	case "json":
+		return &thriftType{wire: thriftString, json: true}, nil
+	}
+	if strings.HasPrefix(t, "list<") && strings.HasSuffix(t, ">") {
+		elem, err := parseThriftType(t[5:len(t)-1], structs)
+		if err != nil {
+			return nil, err
+		}
+		return &thriftType{wire: thriftList, elem: elem}, nil
+	}
+	if strings.HasPrefix(t, "map<") && strings.HasSuffix(t, ">") {
+		inner := t[4 : len(t)-1]
+		depth := 0
+		for i, c := range inner {
+			switch c {
+			case '<':
+				depth++
+			case '>':
+				depth--
+			case ',':
+				if depth > 0 {
+					continue
+				}
+				key, err := parseThriftType(inner[:i], structs)
+				if err != nil {
+					return nil, err
+				}
+				elem, err := parseThriftType(inner[i+1:], structs)
+				if err != nil {
+					return nil, err
+				}
+				return &thriftType{wire: thriftMap, key: key, elem: elem}, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid map type %s", t)
+	}
+	if fields, ok := structs[t]; ok {
+		return &thriftType{wire: thriftStruct, name: t, fields: fields}, nil
+	}
+	return nil, fmt.Errorf("unknown type %s", t)
+}
+
+// readMessageBegin reads a message header encoded with either the strict or the old binary
+// protocol.
+func (r *thriftReader) readMessageBegin() (name string, typ int, seqID int32, err error) {
+	size, err := r.readI32()
+	if err != nil {
+		return
+	}
+	if size < 0 {
+		if uint32(size)&thriftVersionMask != thriftVersion1 {
+			err = fmt.Errorf("bad protocol version")
+			return
+		}
+		typ = int(size & 0xff)
+		if name, err = r.readString(); err != nil {
+			return
+		}
+	} else {
+		b := make([]byte, size)
+		if _, err = io.ReadFull(r.r, b); err != nil {
+			return
+		}
+		name = string(b)
+		var t byte
+		if t, err = r.readByte(); err != nil {
+			return
+		}
+		typ = int(t)
+	}
+	seqID, err = r.readI32()
+	return
+}
+
+// readStruct reads a struct with the given fields and returns the field values indexed by field
+// name. Unknown fields are skipped.
+func (r *thriftReader) readStruct(fields []ThriftField) (map[string]interface{}, error) {
+	vals := make(map[string]interface{})
+	for {
+		wire, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if wire == thriftStop {
+			return vals, nil
+		}
+		id, err := r.readI16()
+		if err != nil {
+			return nil, err
+		}
+		var field *ThriftField
+		for i, f := range fields {
+			if f.ID == id {
+				field = &fields[i]
+				break
+			}
+		}
+		if field == nil {
+			if err := r.skip(wire); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		t, err := parseThriftType(field.Type, r.structs)
+		if err != nil {
+			return nil, err
+		}
+		if t.wire != wire {
+			return nil, fmt.Errorf("invalid type for field %s", field.Name)
+		}
+		v, err := r.readValue(t)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %s", field.Name, err)
+		}
+		vals[field.Name] = v
+	}
+}
+
+// readValue reads a value of the given type.
+func (r *thriftReader) readValue(t *thriftType) (interface{}, error) {
+	switch t.wire {
+	case thriftBool:
+		b, err := r.readByte()
+		return b != 0, err
+	case thriftI32:
+		return r.readI32()
+	case thriftI64:
+		return r.readI64()
+	case thriftDouble:
+		v, err := r.readI64()
+		return math.Float64frombits(uint64(v)), err
+	case thriftString:
+		s, err := r.readString()
+		if err != nil || !t.json {
+			return s, err
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case thriftList:
+		elem, size, err := r.readCollectionBegin()
+		if err != nil {
+			return nil, err
+		}
+		if elem != t.elem.wire {
+			return nil, fmt.Errorf("invalid list element type")
+		}
+		vals := make([]interface{}, size)
+		for i := range vals {
+			if vals[i], err = r.readValue(t.elem); err != nil {
+				return nil, err
+			}
+		}
+		return vals, nil
+	case thriftMap:
+		key, err := r.readByte()
+		if err != nil {
+			return nil, err
+		}
+		elem, size, err := r.readCollectionBegin()
+		if err != nil {
+			return nil, err
+		}
+		if key != t.key.wire || elem != t.elem.wire {
+			return nil, fmt.Errorf("invalid map key or value type")
+		}
+		vals := make(map[string]interface{}, size)
+		for i := 0; i < size; i++ {
+			k, err := r.readValue(t.key)
+			if err != nil {
+				return nil, err
+			}
+			v, err := r.readValue(t.elem)
+			if err != nil {
+				return nil, err
+			}
+			vals[fmt.Sprint(k)] = v
+		}
+		return vals, nil
+	case thriftStruct:
+		return r.readStruct(t.fields)
+	}
+	return nil, fmt.Errorf("unsupported type")
+}
+
+// readCollectionBegin reads the element type and size of a list, set or map.
+func (r *thriftReader) readCollectionBegin() (byte, int, error) {
+	elem, err := r.readByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	size, err := r.readI32()
+	if err != nil {
+		return 0, 0, err
+	}
+	if size < 0 {
+		return 0, 0, fmt.Errorf("negative collection size")
+	}
+	return elem, int(size), nil
+}
+
+// skip reads and discards a value of the given wire type.
+func (r *thriftReader) skip(wire byte) error {
+	var n int
+	switch wire {
+	case thriftBool, thriftByte:
+		n = 1
+	case thriftI16:
+		n = 2
+	case thriftI32:
+		n = 4
+	case thriftI64, thriftDouble:
+		n = 8
+	case thriftString:
+		size, err := r.readI32()
+		if err != nil {
+			return err
+		}
+		n = int(size)
+	case thriftStruct:
+		for {
+			w, err := r.readByte()
+			if err != nil {
+				return err
+			}
+			if w == thriftStop {
+				return nil
+			}
+			if _, err := r.readI16(); err != nil {
+				return err
+			}
+			if err := r.skip(w); err != nil {
+				return err
+			}
+		}
+	case thriftMap:
+		key, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		elem, size, err := r.readCollectionBegin()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < size; i++ {
+			if err := r.skip(key); err != nil {
+				return err
+			}
+			if err := r.skip(elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case thriftSet, thriftList:
+		elem, size, err := r.readCollectionBegin()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < size; i++ {
+			if err := r.skip(elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown wire type %d", wire)
+	}
+	if n < 0 {
+		return fmt.Errorf("negative string size")
+	}
+	_, err := io.CopyN(ioutil.Discard, r.r, int64(n))
+	return err
+}
+
+// readByte reads a single byte.
+func (r *thriftReader) readByte() (byte, error) {
+	var b [1]byte
+	_, err := io.ReadFull(r.r, b[:])
+	return b[0], err
+}
+
+// readI16 reads a big endian 16-bit integer.
+func (r *thriftReader) readI16() (int16, error) {
+	var v int16
+	err := binary.Read(r.r, binary.BigEndian, &v)
+	return v, err
+}
+
+// readI32 reads a big endian 32-bit integer.
+func (r *thriftReader) readI32() (int32, error) {
+	var v int32
+	err := binary.Read(r.r, binary.BigEndian, &v)
+	return v, err
+}
+
+// readI64 reads a big endian 64-bit integer.
+func (r *thriftReader) readI64() (int64, error) {
+	var v int64
+	err := binary.Read(r.r, binary.BigEndian, &v)
+	return v, err
+}
+
+// readString reads a length prefixed string.
+func (r *thriftReader) readString() (string, error) {
+	size, err := r.readI32()
+	if err != nil {
+		return "", err
+	}
+	if size < 0 {
+		return "", fmt.Errorf("negative string size")
+	}
+	b := make([]byte, size)
+	_, err = io.ReadFull(r.r, b)
+	return string(b), err
+}
+
+// writeReply writes a reply message whose success field holds the given result.
+func (w *thriftWriter) writeReply(name string, seqID int32, result string, v interface{}) error {
+	w.writeMessageBegin(name, thriftReply, seqID)
+	if result != "" && v != nil {
+		t, err := parseThriftType(result, w.structs)
+		if err != nil {
+			return err
+		}
+		w.writeFieldBegin(t.wire, 0)
+		if err := w.writeValue(t, v); err != nil {
+			return err
+		}
+	}
+	w.buf.WriteByte(thriftStop)
+	return nil
+}
+
+// writeActionError writes a reply message whose first exception field holds an ActionError
+// describing the given error.
+func (w *thriftWriter) writeActionError(name string, seqID int32, e *HTTPError) {
+	w.writeMessageBegin(name, thriftReply, seqID)
+	w.writeFieldBegin(thriftStruct, 1)
+	w.writeFieldBegin(thriftI32, 1)
+	w.writeI32(int32(e.Status))
+	w.writeFieldBegin(thriftString, 2)
+	w.writeString(e.Detail)
+	w.buf.WriteByte(thriftStop)
+	w.buf.WriteByte(thriftStop)
+}
+
+// writeAppError writes an exception message.
+func (w *thriftWriter) writeAppError(name string, seqID int32, e *thriftAppError) {
+	w.writeMessageBegin(name, thriftException, seqID)
+	w.writeFieldBegin(thriftString, 1)
+	w.writeString(e.msg)
+	w.writeFieldBegin(thriftI32, 2)
+	w.writeI32(e.kind)
+	w.buf.WriteByte(thriftStop)
+}
+
+// writeMessageBegin writes a strict binary protocol message header.
+func (w *thriftWriter) writeMessageBegin(name string, typ int, seqID int32) {
+	w.writeI32(int32(uint32(thriftVersion1) | uint32(typ)))
+	w.writeString(name)
+	w.writeI32(seqID)
+}
+
+// writeFieldBegin writes a struct field header.
+func (w *thriftWriter) writeFieldBegin(wire byte, id int16) {
+	w.buf.WriteByte(wire)
+	binary.Write(&w.buf, binary.BigEndian, id)
+}
+
+// writeValue writes the JSON value v using the given type.
+func (w *thriftWriter) writeValue(t *thriftType, v interface{}) error {
+	switch t.wire {
+	case thriftBool:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("invalid boolean value %v", v)
+		}
+		if b {
+			w.buf.WriteByte(1)
+		} else {
+			w.buf.WriteByte(0)
+		}
+	case thriftI32, thriftI64:
+		i, err := thriftInt(v)
+		if err != nil {
+			return err
+		}
+		if t.wire == thriftI32 {
+			w.writeI32(int32(i))
+		} else {
+			binary.Write(&w.buf, binary.BigEndian, i)
+		}
+	case thriftDouble:
+		f, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number value %v", v)
+		}
+		binary.Write(&w.buf, binary.BigEndian, math.Float64bits(f))
+	case thriftString:
+		if t.json {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			w.writeString(string(b))
+		} else if s, ok := v.(string); ok {
+			w.writeString(s)
+		} else {
+			w.writeString(fmt.Sprint(v))
+		}
+	case thriftList:
+		vals, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("invalid list value %v", v)
+		}
+		var elems []interface{}
+		for _, e := range vals {
+			if e != nil {
+				elems = append(elems, e)
+			}
+		}
+		w.buf.WriteByte(t.elem.wire)
+		w.writeI32(int32(len(elems)))
+		for _, e := range elems {
+			if err := w.writeValue(t.elem, e); err != nil {
+				return err
+			}
+		}
+	case thriftMap:
+		vals, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid map value %v", v)
+		}
+		w.buf.WriteByte(t.key.wire)
+		w.buf.WriteByte(t.elem.wire)
+		var size int
+		for _, e := range vals {
+			if e != nil {
+				size++
+			}
+		}
+		w.writeI32(int32(size))
+		keys := make([]string, 0, len(vals))
+		for k := range vals {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if vals[k] == nil {
+				continue
+			}
+			var key interface{} = k
+			if t.key.wire != thriftString {
+				key = json.Number(k)
+				if t.key.wire == thriftBool {
+					key = k == "true"
+				}
+			}
+			if err := w.writeValue(t.key, key); err != nil {
+				return err
+			}
+			if err := w.writeValue(t.elem, vals[k]); err != nil {
+				return err
+			}
+		}
+	case thriftStruct:
+		vals, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("invalid %s value %v", t.name, v)
+		}
+		for _, f := range t.fields {
+			fv, ok := vals[f.Name]
+			if !ok || fv == nil {
+				continue
+			}
+			ft, err := parseThriftType(f.Type, w.structs)
+			if err != nil {
+				return err
+			}
+			w.writeFieldBegin(ft.wire, f.ID)
+			if err := w.writeValue(ft, fv); err != nil {
+				return fmt.Errorf("field %s: %s", f.Name, err)
+			}
+		}
+		w.buf.WriteByte(thriftStop)
+	}
+	return nil
+}
+
+// writeI32 writes a big endian 32-bit integer.
+func (w *thriftWriter) writeI32(v int32) {
+	binary.Write(&w.buf, binary.BigEndian, v)
+}
+
+// writeString writes a length prefixed string.
+func (w *thriftWriter) writeString(s string) {
+	w.writeI32(int32(len(s)))
+	w.buf.WriteString(s)
+}
+
+// thriftInt returns the integer value of the given JSON number.
+func thriftInt(v interface{}) (int64, error) {
+	if n, ok := v.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		if f, err := n.Float64(); err == nil {
+			return int64(f), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid integer value %v", v)
+}
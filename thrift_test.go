@@ -0,0 +1,202 @@
+package goa_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// thriftMessage builds Thrift binary protocol messages.
+type thriftMessage struct {
+	bytes.Buffer
+}
+
+func (m *thriftMessage) begin(name string, typ byte) *thriftMessage {
+	m.i32(int32(-0x7fff0000) | int32(typ))
+	m.str(name)
+	return m.i32(7)
+}
+
+func (m *thriftMessage) field(wire byte, id int16) *thriftMessage {
+	m.WriteByte(wire)
+	binary.Write(m, binary.BigEndian, id)
+	return m
+}
+
+func (m *thriftMessage) i32(v int32) *thriftMessage {
+	binary.Write(m, binary.BigEndian, v)
+	return m
+}
+
+func (m *thriftMessage) i64(v int64) *thriftMessage {
+	binary.Write(m, binary.BigEndian, v)
+	return m
+}
+
+func (m *thriftMessage) str(s string) *thriftMessage {
+	m.i32(int32(len(s)))
+	m.WriteString(s)
+	return m
+}
+
+func (m *thriftMessage) stop() *thriftMessage {
+	m.WriteByte(0)
+	return m
+}
+
+var _ = Describe("ThriftServer", func() {
+	var service *goa.Service
+	var server *goa.ThriftServer
+	var body *thriftMessage
+	var rw *httptest.ResponseRecorder
+
+	var gotPath string
+	var gotQuery url.Values
+	var gotBody []byte
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		server = goa.NewThriftServer(service)
+		server.Structs["Bottle"] = []goa.ThriftField{
+			{ID: 1, Name: "id", Type: "i64"},
+			{ID: 2, Name: "name", Type: "string"},
+			{ID: 3, Name: "tags", Type: "list<string>"},
+		}
+		server.Structs["CreateBottlePayload"] = []goa.ThriftField{
+			{ID: 1, Name: "name", Type: "string"},
+		}
+		server.Handle("BottleService:show", &goa.ThriftMethod{
+			Verb: "GET",
+			Path: "/accounts/:account_id/bottles/:id",
+			Args: []goa.ThriftField{
+				{ID: 1, Name: "account_id", Type: "i64"},
+				{ID: 2, Name: "id", Type: "i64"},
+				{ID: 3, Name: "view", Type: "string"},
+			},
+			Result: "Bottle",
+		})
+		server.Handle("BottleService:create", &goa.ThriftMethod{
+			Verb: "POST",
+			Path: "/accounts/:account_id/bottles",
+			Args: []goa.ThriftField{
+				{ID: 1, Name: "account_id", Type: "i64"},
+				{ID: 2, Name: "payload", Type: "CreateBottlePayload"},
+			},
+		})
+		body = new(thriftMessage)
+		body.begin("BottleService:show", 1).
+			field(10, 1).i64(1).
+			field(10, 2).i64(42).
+			field(11, 3).str("tiny").
+			field(8, 9).i32(3).
+			stop()
+		rw = httptest.NewRecorder()
+		gotPath, gotQuery, gotBody = "", nil, nil
+		handle := func(status int, body string) goa.MuxHandler {
+			return func(rw http.ResponseWriter, req *http.Request, _ url.Values) {
+				gotPath = req.URL.Path
+				gotQuery = req.URL.Query()
+				if req.Body != nil {
+					gotBody, _ = ioutil.ReadAll(req.Body)
+				}
+				rw.Header().Set("Content-Type", "application/json")
+				rw.WriteHeader(status)
+				rw.Write([]byte(body))
+			}
+		}
+		service.Mux.Handle("GET", "/accounts/:account_id/bottles/:id", handle(200, `{"id":42,"name":"Number 8","tags":["red"],"vintage":2012}`))
+		service.Mux.Handle("POST", "/accounts/:account_id/bottles", handle(404, `{"id":1,"title":"not found","msg":"no account with id 1"}`))
+		server.Mount("")
+	})
+
+	JustBeforeEach(func() {
+		req, err := http.NewRequest("POST", "http://localhost/thrift", bytes.NewReader(body.Bytes()))
+		Ω(err).ShouldNot(HaveOccurred())
+		req.Header.Set("Content-Type", goa.ThriftContentType)
+		service.Mux.ServeHTTP(rw, req)
+	})
+
+	It("bridges the call to the action", func() {
+		Ω(gotPath).Should(Equal("/accounts/1/bottles/42"))
+		Ω(gotQuery).Should(Equal(url.Values{"view": {"tiny"}}))
+		Ω(rw.Code).Should(Equal(200))
+		Ω(rw.Header().Get("Content-Type")).Should(Equal(goa.ThriftContentType))
+		expected := new(thriftMessage)
+		expected.begin("BottleService:show", 2).
+			field(12, 0).
+			field(10, 1).i64(42).
+			field(11, 2).str("Number 8").
+			field(15, 3).WriteByte(11)
+		expected.i32(1).str("red").
+			stop().
+			stop()
+		Ω(rw.Body.Bytes()).Should(Equal(expected.Bytes()))
+	})
+
+	Context("with a payload", func() {
+		BeforeEach(func() {
+			body = new(thriftMessage)
+			body.begin("BottleService:create", 1).
+				field(10, 1).i64(1).
+				field(12, 2).
+				field(11, 1).str("Number 8").
+				stop().
+				stop()
+		})
+
+		It("sends the payload in the body and throws the action error", func() {
+			Ω(gotPath).Should(Equal("/accounts/1/bottles"))
+			Ω(string(gotBody)).Should(MatchJSON(`{"name":"Number 8"}`))
+			expected := new(thriftMessage)
+			expected.begin("BottleService:create", 2).
+				field(12, 1).
+				field(8, 1).i32(404).
+				field(11, 2).str("no account with id 1").
+				stop().
+				stop()
+			Ω(rw.Body.Bytes()).Should(Equal(expected.Bytes()))
+		})
+	})
+
+	Context("with an argument of the wrong type", func() {
+		BeforeEach(func() {
+			body = new(thriftMessage)
+			body.begin("BottleService:show", 1).
+				field(11, 1).str("foo").
+				stop()
+		})
+
+		It("returns a protocol error", func() {
+			Ω(gotPath).Should(BeEmpty())
+			expected := new(thriftMessage)
+			expected.begin("BottleService:show", 3).
+				field(11, 1).str("invalid type for field account_id").
+				field(8, 2).i32(7).
+				stop()
+			Ω(rw.Body.Bytes()).Should(Equal(expected.Bytes()))
+		})
+	})
+
+	Context("with an unknown method", func() {
+		BeforeEach(func() {
+			body = new(thriftMessage)
+			body.begin("BottleService:delete", 1).stop()
+		})
+
+		It("returns an unknown method error", func() {
+			expected := new(thriftMessage)
+			expected.begin("BottleService:delete", 3).
+				field(11, 1).str("unknown method BottleService:delete").
+				field(8, 2).i32(1).
+				stop()
+			Ω(rw.Body.Bytes()).Should(Equal(expected.Bytes()))
+		})
+	})
+})
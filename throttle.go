@@ -0,0 +1,54 @@
+package goa
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// RateLimit returns a middleware that limits the number of requests handled by the service to
+// limit requests per period using a single token bucket kept in a MemoryRateLimitStore, see
+// TokenBucket for limiting each client separately. Requests in excess are rejected with a 429 (Too
+// Many Requests) response whose Retry-After header is computed by the given policy given the
+// number of consecutive rejections. The Retry-After header is set to the time left until the next
+// request is allowed if the policy is nil.
+func RateLimit(limit int, period time.Duration, policy *RetryAfterPolicy) Middleware {
+	store := NewMemoryRateLimitStore()
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			status, err := store.Take(ctx, "", limit, period)
+			return limitRate(ctx, rw, req, h, limit, policy, status, err)
+		}
+	}
+}
+
+// Overload returns a middleware that limits the number of requests being handled concurrently by
+// the service to max. Requests in excess are rejected with a 503 (Service Unavailable) response
+// whose Retry-After header is computed by the given policy given the number of requests in excess.
+// The Retry-After header is omitted if the policy is nil.
+func Overload(max int, policy *RetryAfterPolicy) Middleware {
+	var inflight int64
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			n := atomic.AddInt64(&inflight, 1)
+			defer atomic.AddInt64(&inflight, -1)
+			if n <= int64(max) {
+				return h(ctx, rw, req)
+			}
+			var delay time.Duration
+			if policy != nil {
+				delay = policy.Duration(ctx, int(n)-max-1)
+			}
+			reject(rw, http.StatusServiceUnavailable, delay)
+			return nil
+		}
+	}
+}
+
+// reject writes a response with the given status and Retry-After delay.
+func reject(rw http.ResponseWriter, status int, delay time.Duration) {
+	SetRetryAfter(rw.Header(), delay)
+	http.Error(rw, http.StatusText(status), status)
+}
@@ -0,0 +1,61 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("RateLimit", func() {
+	var handler goa.Handler
+
+	BeforeEach(func() {
+		policy := &goa.RetryAfterPolicy{Strategy: goa.RetryAfterExponential, Delay: time.Second}
+		ok := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.WriteHeader(200)
+			return nil
+		}
+		handler = goa.RateLimit(1, time.Hour, policy)(ok)
+	})
+
+	It("rejects the requests in excess", func() {
+		req, _ := http.NewRequest("GET", "/", nil)
+		statuses := make([]int, 3)
+		retries := make([]string, 3)
+		for i := range statuses {
+			rw := httptest.NewRecorder()
+			Ω(handler(context.Background(), rw, req)).ShouldNot(HaveOccurred())
+			statuses[i] = rw.Code
+			retries[i] = rw.Header().Get("Retry-After")
+		}
+		Ω(statuses).Should(Equal([]int{200, 429, 429}))
+		Ω(retries).Should(Equal([]string{"", "1", "2"}))
+	})
+})
+
+var _ = Describe("Overload", func() {
+	It("rejects requests in excess of the concurrency limit", func() {
+		policy := &goa.RetryAfterPolicy{Strategy: goa.RetryAfterFixed, Delay: 5 * time.Second}
+		var inner int
+		var handler goa.Handler
+		handler = goa.Overload(1, policy)(func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			inner++
+			if inner == 1 {
+				// Issue a nested request while the first one is in flight
+				return handler(ctx, rw, req)
+			}
+			return nil
+		})
+		req, _ := http.NewRequest("GET", "/", nil)
+		rw := httptest.NewRecorder()
+		Ω(handler(context.Background(), rw, req)).ShouldNot(HaveOccurred())
+		Ω(rw.Code).Should(Equal(503))
+		Ω(rw.Header().Get("Retry-After")).Should(Equal("5"))
+		Ω(inner).Should(Equal(1))
+	})
+})
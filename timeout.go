@@ -0,0 +1,135 @@
+package goa
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Timeout returns a middleware that limits the time spent handling a request to d. The middleware
+// cancels the request context once the budget is exhausted and returns an ErrServiceUnavailable
+// error that the error handler renders as a 503 (Service Unavailable) response. The handler keeps
+// running in the background until it returns: it should watch the context Done channel to stop
+// early. Its writes to the response fail with http.ErrHandlerTimeout after the timeout.
+// The budget of individual actions may be overridden with Service.SetActionTimeout, a budget of 0
+// disables the timeout for the action. The generated code calls SetActionTimeout for the actions
+// whose design defines the "goa:timeout" metadata.
+// The response written by the handler is buffered until it returns so that it can be discarded if
+// the timeout expires first, streaming actions should thus disable the timeout.
+func Timeout(d time.Duration) Middleware {
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			budget := d
+			if service := RequestService(ctx); service != nil {
+				if t, ok := service.ActionTimeout(ContextController(ctx), ContextAction(ctx)); ok {
+					budget = t
+				}
+			}
+			if budget <= 0 {
+				return h(ctx, rw, req)
+			}
+			ctx, cancel := context.WithTimeout(ctx, budget)
+			defer cancel()
+
+			// Run the handler with its own response so that nothing reaches the client
+			// until it returns.
+			tw := &timeoutWriter{header: make(http.Header)}
+			hctx := context.WithValue(ctx, respKey, &ResponseData{ResponseWriter: tw})
+			done := make(chan error, 1)
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						panicked <- r
+					}
+				}()
+				done <- h(hctx, Response(hctx), req)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case err := <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				for k, v := range tw.header {
+					rw.Header()[k] = v
+				}
+				if tw.status != 0 {
+					rw.WriteHeader(tw.status)
+				}
+				if tw.buf.Len() > 0 {
+					rw.Write(tw.buf.Bytes())
+				}
+				return err
+			case <-ctx.Done():
+				tw.mu.Lock()
+				tw.timedOut = true
+				tw.mu.Unlock()
+				Error(ctx, "request timed out", KV{"timeout", budget.String()})
+				return ErrServiceUnavailable("request timed out after %s", budget)
+			}
+		}
+	}
+}
+
+// SetActionTimeout overrides the budget of the Timeout middleware for the action with the given
+// name of the controller with the given name. A budget of 0 disables the timeout for the action.
+func (service *Service) SetActionTimeout(ctrl, action string, d time.Duration) {
+	service.timeoutsMu.Lock()
+	defer service.timeoutsMu.Unlock()
+	if service.timeouts == nil {
+		service.timeouts = make(map[string]time.Duration)
+	}
+	service.timeouts[ctrl+"#"+action] = d
+}
+
+// ActionTimeout returns the budget set with SetActionTimeout for the given controller action if
+// any.
+func (service *Service) ActionTimeout(ctrl, action string) (time.Duration, bool) {
+	service.timeoutsMu.RLock()
+	defer service.timeoutsMu.RUnlock()
+	d, ok := service.timeouts[ctrl+"#"+action]
+	return d, ok
+}
+
+// timeoutWriter is the response writer given to the handlers run by the Timeout middleware. It
+// buffers the response until the handler returns and rejects writes made after the timeout.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	status   int
+	buf      bytes.Buffer
+	timedOut bool
+}
+
+// Header returns the buffered response header.
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader records the response status.
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+// Write buffers the response body.
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
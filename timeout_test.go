@@ -0,0 +1,82 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Timeout", func() {
+	var service *goa.Service
+	var delay time.Duration
+	var writeErr, handledErr error
+	var rw *httptest.ResponseRecorder
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		delay = 0
+		writeErr, handledErr = nil, nil
+	})
+
+	JustBeforeEach(func() {
+		ctrl := service.NewController("bottle")
+		ctrl.Use(goa.Timeout(50 * time.Millisecond))
+		ctrl.ErrorHandler = func(ctx context.Context, rw http.ResponseWriter, req *http.Request, err error) {
+			handledErr = err
+			goa.DefaultErrorHandler(ctx, rw, req, err)
+		}
+		done := make(chan struct{})
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			defer close(done)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+			}
+			rw.Header().Set("Location", "/bottles/1")
+			rw.WriteHeader(201)
+			_, writeErr = rw.Write([]byte("ok"))
+			return nil
+		}
+		req, _ := http.NewRequest("GET", "/bottles/1", nil)
+		rw = httptest.NewRecorder()
+		ctrl.MuxHandler("Show", h, nil)(rw, req, nil)
+		<-done
+	})
+
+	It("writes the handler response", func() {
+		Ω(rw.Code).Should(Equal(201))
+		Ω(rw.Header().Get("Location")).Should(Equal("/bottles/1"))
+		Ω(rw.Body.String()).Should(Equal("ok"))
+		Ω(writeErr).ShouldNot(HaveOccurred())
+	})
+
+	Context("with a slow handler", func() {
+		BeforeEach(func() {
+			delay = 200 * time.Millisecond
+		})
+
+		It("cancels the request", func() {
+			Ω(goa.ErrorStatus(handledErr)).Should(Equal(503))
+			Ω(handledErr.Error()).Should(ContainSubstring("timed out"))
+			Ω(rw.Code).Should(Equal(503))
+			Ω(rw.Header().Get("Location")).Should(BeEmpty())
+			Ω(writeErr).Should(Equal(http.ErrHandlerTimeout))
+		})
+
+		Context("and an action timeout", func() {
+			BeforeEach(func() {
+				service.SetActionTimeout("bottle", "Show", 0)
+			})
+
+			It("uses the action timeout", func() {
+				Ω(rw.Code).Should(Equal(201))
+				Ω(rw.Body.String()).Should(Equal("ok"))
+			})
+		})
+	})
+})
@@ -0,0 +1,162 @@
+package goa
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Names of the Zipkin B3 propagation headers read and written by the Tracer middleware and the
+// client.
+const (
+	// TraceIDHeader is the header holding the ID of the trace.
+	TraceIDHeader = "X-B3-TraceId"
+	// SpanIDHeader is the header holding the ID of the span.
+	SpanIDHeader = "X-B3-SpanId"
+	// ParentSpanIDHeader is the header holding the ID of the parent span if any.
+	ParentSpanIDHeader = "X-B3-ParentSpanId"
+	// SampledHeader is the header holding the sampling decision, "1" or "0".
+	SampledHeader = "X-B3-Sampled"
+)
+
+type (
+	// Span describes the handling of a single request as part of a distributed trace.
+	Span struct {
+		// TraceID identifies the trace the span belongs to.
+		TraceID string
+		// ID identifies the span.
+		ID string
+		// ParentID identifies the parent span, empty for root spans.
+		ParentID string
+		// Name is the name of the span, "<resource>.<action>" for the spans created by the
+		// Tracer middleware.
+		Name string
+		// Sampled is true if the span is recorded.
+		Sampled bool
+		// Start is the time the request handling started.
+		Start time.Time
+		// Duration is the time it took to handle the request.
+		Duration time.Duration
+		// Status is the response HTTP status code.
+		Status int
+	}
+
+	// SpanRecorder is the interface implemented by the sinks that collect the spans, for
+	// example to report them to a Zipkin collector.
+	SpanRecorder interface {
+		// RecordSpan is called once per sampled request after the request has been handled.
+		RecordSpan(ctx context.Context, s *Span)
+	}
+
+	// SpanRecorderFunc is an adapter that makes it possible to use a function as a
+	// SpanRecorder.
+	SpanRecorderFunc func(ctx context.Context, s *Span)
+)
+
+// RecordSpan calls f(ctx, s).
+func (f SpanRecorderFunc) RecordSpan(ctx context.Context, s *Span) {
+	f(ctx, s)
+}
+
+// Tracer returns a middleware that creates a span for each request. The span joins the trace
+// described by the B3 headers of the request if any, a new trace is started otherwise. New traces
+// are sampled with the given rate between 0 and 1, incoming requests carrying a sampling decision
+// keep it. The span is named after the resource and action handling the request and is stored in
+// the request context where ContextSpan retrieves it. The middleware adds the trace and span IDs
+// to the log context and hands the sampled spans to recorder once the request has been handled.
+//
+// The goa client propagates the span of the context given to the generated client methods so that
+// the requests made while handling a request belong to the same trace.
+func Tracer(sampleRate float64, recorder SpanRecorder) Middleware {
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			span := &Span{
+				TraceID:  req.Header.Get(TraceIDHeader),
+				ID:       req.Header.Get(SpanIDHeader),
+				ParentID: req.Header.Get(ParentSpanIDHeader),
+				Name:     ContextController(ctx) + "." + ContextAction(ctx),
+				Start:    time.Now(),
+			}
+			sampled := req.Header.Get(SampledHeader)
+			if span.TraceID == "" || span.ID == "" {
+				span.TraceID = newSpanID()
+				span.ID = span.TraceID
+				span.ParentID = ""
+				sampled = ""
+			}
+			switch sampled {
+			case "1", "true", "d":
+				span.Sampled = true
+			case "0", "false":
+			default:
+				span.Sampled = sample(sampleRate)
+			}
+			ctx = context.WithValue(ctx, spanKey, span)
+			ctx = NewLogContext(ctx, KV{"trace_id", span.TraceID}, KV{"span_id", span.ID})
+			err := h(ctx, rw, req)
+			span.Duration = time.Since(span.Start)
+			span.Status = http.StatusOK
+			if resp := Response(ctx); resp != nil && resp.Status != 0 {
+				span.Status = resp.Status
+			}
+			if span.Sampled && recorder != nil {
+				recorder.RecordSpan(ctx, span)
+			}
+			return err
+		}
+	}
+}
+
+// ContextSpan returns the span of the request with the given context, nil if the request was not
+// handled by a Tracer middleware.
+func ContextSpan(ctx context.Context) *Span {
+	if s := ctx.Value(spanKey); s != nil {
+		return s.(*Span)
+	}
+	return nil
+}
+
+// InjectSpan sets the B3 headers of a request made while handling the request with the given
+// context so that the request belongs to the same trace. The headers describe a new span whose
+// parent is the span of the context. InjectSpan does nothing if the context has no span.
+func InjectSpan(ctx context.Context, header http.Header) {
+	span := ContextSpan(ctx)
+	if span == nil {
+		return
+	}
+	header.Set(TraceIDHeader, span.TraceID)
+	header.Set(SpanIDHeader, newSpanID())
+	header.Set(ParentSpanIDHeader, span.ID)
+	if span.Sampled {
+		header.Set(SampledHeader, "1")
+	} else {
+		header.Set(SampledHeader, "0")
+	}
+}
+
+// newSpanID returns a random 64-bit hex encoded span ID.
+func newSpanID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// sample returns true with the given probability.
+func sample(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	b := make([]byte, 8)
+	rand.Read(b)
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return float64(n>>11)/(1<<53) < rate
+}
@@ -0,0 +1,94 @@
+package goa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("Tracer", func() {
+	var header http.Header
+	var sampleRate float64
+	var span *goa.Span
+	var recorded []*goa.Span
+	var outgoing http.Header
+
+	BeforeEach(func() {
+		header = make(http.Header)
+		sampleRate = 1
+		span = nil
+		recorded = nil
+		outgoing = make(http.Header)
+	})
+
+	JustBeforeEach(func() {
+		service := goa.New("test")
+		ctrl := service.NewController("bottle")
+		recorder := goa.SpanRecorderFunc(func(ctx context.Context, s *goa.Span) {
+			recorded = append(recorded, s)
+		})
+		ctrl.Use(goa.Tracer(sampleRate, recorder))
+		h := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			span = goa.ContextSpan(ctx)
+			goa.InjectSpan(ctx, outgoing)
+			rw.WriteHeader(201)
+			return nil
+		}
+		req, _ := http.NewRequest("POST", "/bottles", nil)
+		req.Header = header
+		ctrl.MuxHandler("create", h, nil)(httptest.NewRecorder(), req, nil)
+	})
+
+	It("starts a new trace", func() {
+		Ω(span).ShouldNot(BeNil())
+		Ω(span.Name).Should(Equal("bottle.create"))
+		Ω(span.TraceID).Should(HaveLen(16))
+		Ω(span.ID).Should(Equal(span.TraceID))
+		Ω(span.ParentID).Should(BeEmpty())
+		Ω(recorded).Should(Equal([]*goa.Span{span}))
+		Ω(span.Status).Should(Equal(201))
+	})
+
+	It("propagates the span", func() {
+		Ω(outgoing.Get(goa.TraceIDHeader)).Should(Equal(span.TraceID))
+		Ω(outgoing.Get(goa.ParentSpanIDHeader)).Should(Equal(span.ID))
+		Ω(outgoing.Get(goa.SpanIDHeader)).ShouldNot(Equal(span.ID))
+		Ω(outgoing.Get(goa.SampledHeader)).Should(Equal("1"))
+	})
+
+	Context("with B3 headers", func() {
+		BeforeEach(func() {
+			header.Set(goa.TraceIDHeader, "463ac35c9f6413ad")
+			header.Set(goa.SpanIDHeader, "a2fb4a1d1a96d312")
+			header.Set(goa.ParentSpanIDHeader, "0020000000000001")
+			header.Set(goa.SampledHeader, "0")
+		})
+
+		It("joins the trace", func() {
+			Ω(span.TraceID).Should(Equal("463ac35c9f6413ad"))
+			Ω(span.ID).Should(Equal("a2fb4a1d1a96d312"))
+			Ω(span.ParentID).Should(Equal("0020000000000001"))
+		})
+
+		It("keeps the sampling decision", func() {
+			Ω(span.Sampled).Should(BeFalse())
+			Ω(recorded).Should(BeEmpty())
+			Ω(outgoing.Get(goa.SampledHeader)).Should(Equal("0"))
+		})
+	})
+
+	Context("with a zero sample rate", func() {
+		BeforeEach(func() {
+			sampleRate = 0
+		})
+
+		It("does not record the span", func() {
+			Ω(span.Sampled).Should(BeFalse())
+			Ω(recorded).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package goa
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type (
+	// Usage describes the resources consumed by a single request. Usage records are produced
+	// by the UsageAccounting middleware and handed to a UsageRecorder.
+	Usage struct {
+		// Service is the name of the service that handled the request.
+		Service string
+		// Controller is the name of the controller that handled the request.
+		Controller string
+		// Action is the name of the action that handled the request.
+		Action string
+		// Principal identifies the caller, empty if the principal func returned nothing.
+		Principal string
+		// RequestBytes is the size of the request body in bytes.
+		RequestBytes int64
+		// ResponseBytes is the size of the response body in bytes.
+		ResponseBytes int64
+		// Status is the response HTTP status code.
+		Status int
+		// Duration is the time it took to handle the request.
+		Duration time.Duration
+	}
+
+	// UsageRecorder is the interface implemented by the sinks that collect usage records, for
+	// example to implement usage based billing or capacity planning.
+	UsageRecorder interface {
+		// RecordUsage is called once per request after the request has been handled.
+		RecordUsage(ctx context.Context, u *Usage)
+	}
+
+	// UsageRecorderFunc is an adapter that makes it possible to use a function as a
+	// UsageRecorder.
+	UsageRecorderFunc func(ctx context.Context, u *Usage)
+
+	// PrincipalFunc returns the identity of the caller making the request with the given
+	// context, e.g. an API key or a user ID.
+	PrincipalFunc func(ctx context.Context) string
+
+	// countingReader wraps a reader and counts the number of bytes read.
+	countingReader struct {
+		io.ReadCloser
+		count int64
+	}
+)
+
+// RecordUsage calls f(ctx, u).
+func (f UsageRecorderFunc) RecordUsage(ctx context.Context, u *Usage) {
+	f(ctx, u)
+}
+
+// UsageAccounting returns a middleware that measures the request and response body sizes of each
+// request and reports them together with the controller, action and principal to the given
+// recorder. principal may be nil in which case the Principal field of the usage records is empty.
+//
+// The request size is the number of bytes read from the request body or the request
+// Content-Length if greater, this accounts for bodies read by the generated code prior to running
+// the middleware chain.
+func UsageAccounting(recorder UsageRecorder, principal PrincipalFunc) Middleware {
+	return func(h Handler) Handler {
+		return func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			startedAt := time.Now()
+			var body *countingReader
+			if req.Body != nil {
+				body = &countingReader{ReadCloser: req.Body}
+				req.Body = body
+			}
+			err := h(ctx, rw, req)
+			u := &Usage{
+				Controller: ContextController(ctx),
+				Action:     ContextAction(ctx),
+				Duration:   time.Since(startedAt),
+			}
+			if service := RequestService(ctx); service != nil {
+				u.Service = service.Name
+			}
+			if principal != nil {
+				u.Principal = principal(ctx)
+			}
+			if body != nil {
+				u.RequestBytes = body.count
+			}
+			if req.ContentLength > u.RequestBytes {
+				u.RequestBytes = req.ContentLength
+			}
+			if resp := Response(ctx); resp != nil {
+				u.ResponseBytes = int64(resp.Length)
+				u.Status = resp.Status
+			}
+			recorder.RecordUsage(ctx, u)
+			return err
+		}
+	}
+}
+
+// Read reads from the underlying reader and records the number of bytes read.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,75 @@
+package goa_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+var _ = Describe("UsageAccounting", func() {
+	var service *goa.Service
+	var records []*goa.Usage
+	var principal goa.PrincipalFunc
+	var req *http.Request
+
+	BeforeEach(func() {
+		records = nil
+		principal = func(ctx context.Context) string { return "alice" }
+		service = goa.New("billing")
+		service.SetDecoder(goa.JSONDecoderFactory(), true, "*/*")
+		service.SetEncoder(goa.JSONEncoderFactory(), true, "*/*")
+		var err error
+		req, err = http.NewRequest("POST", "/foo", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		body := []byte(`{"name":"foo"}`)
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	})
+
+	JustBeforeEach(func() {
+		recorder := goa.UsageRecorderFunc(func(ctx context.Context, u *goa.Usage) {
+			records = append(records, u)
+		})
+		service.Use(goa.UsageAccounting(recorder, principal))
+		ctrl := service.NewController("bottles")
+		handler := func(ctx context.Context, rw http.ResponseWriter, req *http.Request) error {
+			rw.WriteHeader(201)
+			rw.Write([]byte("created"))
+			return nil
+		}
+		unmarshaler := func(ctx context.Context, req *http.Request) error {
+			var payload interface{}
+			return goa.RequestService(ctx).DecodeRequest(req, &payload)
+		}
+		ctrl.MuxHandler("create", handler, unmarshaler)(new(TestResponseWriter), req, url.Values{})
+	})
+
+	It("records the request usage", func() {
+		Ω(records).Should(HaveLen(1))
+		u := records[0]
+		Ω(u.Service).Should(Equal("billing"))
+		Ω(u.Controller).Should(Equal("bottles"))
+		Ω(u.Action).Should(Equal("create"))
+		Ω(u.Principal).Should(Equal("alice"))
+		Ω(u.RequestBytes).Should(Equal(int64(14)))
+		Ω(u.ResponseBytes).Should(Equal(int64(7)))
+		Ω(u.Status).Should(Equal(201))
+	})
+
+	Context("with no principal func", func() {
+		BeforeEach(func() {
+			principal = nil
+		})
+
+		It("leaves the principal empty", func() {
+			Ω(records).Should(HaveLen(1))
+			Ω(records[0].Principal).Should(BeEmpty())
+		})
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"net/mail"
 	"net/url"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -39,14 +40,33 @@ const (
 
 	// FormatRegexp Regexp defines regular expression syntax accepted by RE2.
 	FormatRegexp = "regexp"
+
+	// FormatRegex defines regular expression syntax accepted by RE2, it is the name used by
+	// JSON schema for the FormatRegexp format.
+	FormatRegex = "regex"
+
+	// FormatUUID defines RFC4122 UUID values.
+	FormatUUID = "uuid"
+
+	// FormatURITemplate defines RFC6570 URI template values.
+	FormatURITemplate = "uri-template"
+
+	// FormatRFC1123 defines RFC1123 date time values, e.g. "Mon, 02 Jan 2006 15:04:05 MST".
+	FormatRFC1123 = "rfc1123"
 )
 
 var (
 	// Regular expression used to validate RFC1035 hostnames*/
-	hostnameRegex = regexp.MustCompile(`^[[:alnum:]][[:alnum:]\-]{0,61}[[:alnum:]]|[[:alpha:]]$`)
+	hostnameRegex = regexp.MustCompile(`^([[:alnum:]]([[:alnum:]\-]{0,61}[[:alnum:]])?\.)*[[:alnum:]]([[:alnum:]\-]{0,61}[[:alnum:]])?$`)
 
 	// Simple regular expression for IPv4 values, more rigorous checking is done via net.ParseIP
 	ipv4Regex = regexp.MustCompile(`^(?:[0-9]{1,3}\.){3}[0-9]{1,3}$`)
+
+	// Regular expression used to validate RFC4122 UUIDs
+	uuidRegex = regexp.MustCompile(`^[[:xdigit:]]{8}-[[:xdigit:]]{4}-[[:xdigit:]]{4}-[[:xdigit:]]{4}-[[:xdigit:]]{12}$`)
+
+	// Regular expression used to validate RFC6570 URI template expressions
+	uriTemplateExprRegex = regexp.MustCompile(`^[+#./;?&=,!@|]?[[:alnum:]_%.]+(:[1-9][0-9]{0,3}|\*)?(,[[:alnum:]_%.]+(:[1-9][0-9]{0,3}|\*)?)*$`)
 )
 
 // ValidateFormat validates a string against a standard format.
@@ -61,7 +81,10 @@ var (
 // - "uri": RFC3986 URI value
 // - "mac": IEEE 802 MAC-48, EUI-48 or EUI-64 MAC address value
 // - "cidr": RFC4632 and RFC4291 CIDR notation IP address value
-// - "regexp": Regular expression syntax accepted by RE2
+// - "regexp" and "regex": Regular expression syntax accepted by RE2
+// - "uuid": RFC4122 UUID value
+// - "uri-template": RFC6570 URI template value
+// - "rfc1123": RFC1123 date time value
 func ValidateFormat(f Format, val string) error {
 	var err error
 	switch f {
@@ -70,7 +93,7 @@ func ValidateFormat(f Format, val string) error {
 	case FormatEmail:
 		_, err = mail.ParseAddress(val)
 	case FormatHostname:
-		if !hostnameRegex.MatchString(val) {
+		if len(val) > 253 || !hostnameRegex.MatchString(val) {
 			err = fmt.Errorf("hostname value '%s' does not match %s",
 				val, hostnameRegex.String())
 		}
@@ -90,8 +113,16 @@ func ValidateFormat(f Format, val string) error {
 		_, err = net.ParseMAC(val)
 	case FormatCIDR:
 		_, _, err = net.ParseCIDR(val)
-	case FormatRegexp:
+	case FormatRegexp, FormatRegex:
 		_, err = regexp.Compile(val)
+	case FormatUUID:
+		if !uuidRegex.MatchString(val) {
+			err = fmt.Errorf("uuid value '%s' does not match %s", val, uuidRegex.String())
+		}
+	case FormatURITemplate:
+		err = validateURITemplate(val)
+	case FormatRFC1123:
+		_, err = time.Parse(time.RFC1123, val)
 	default:
 		return fmt.Errorf("unknown format %#v", f)
 	}
@@ -102,6 +133,34 @@ func ValidateFormat(f Format, val string) error {
 	return nil
 }
 
+// validateURITemplate returns an error if val is not a RFC6570 URI template, that is if it
+// contains unbalanced braces or invalid expressions.
+func validateURITemplate(val string) error {
+	rest := val
+	for {
+		open := strings.IndexAny(rest, "{}")
+		if open == -1 {
+			break
+		}
+		if rest[open] == '}' {
+			return fmt.Errorf("unexpected '}' in URI template '%s'", val)
+		}
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end == -1 || rest[open+1+end] == '{' {
+			return fmt.Errorf("unterminated expression in URI template '%s'", val)
+		}
+		expr := rest[open+1 : open+1+end]
+		if !uriTemplateExprRegex.MatchString(expr) {
+			return fmt.Errorf("invalid expression '{%s}' in URI template '%s'", expr, val)
+		}
+		rest = rest[open+end+2:]
+	}
+	if strings.ContainsAny(val, " \t\n\"<>\\^`") {
+		return fmt.Errorf("invalid character in URI template '%s'", val)
+	}
+	return nil
+}
+
 // knownPatterns records the compiled patterns.
 var knownPatterns = make(map[string]*regexp.Regexp)
 
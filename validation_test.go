@@ -261,4 +261,85 @@ var _ = Describe("ValidateFormat", func() {
 		})
 
 	})
+
+	Context("UUID", func() {
+		BeforeEach(func() {
+			f = goa.FormatUUID
+		})
+
+		Context("with an invalid value", func() {
+			BeforeEach(func() {
+				val = "6ba7b810-9dad-11d1-80b4"
+			})
+
+			It("does not validates", func() {
+				Ω(valErr).Should(HaveOccurred())
+			})
+		})
+
+		Context("with a valid value", func() {
+			BeforeEach(func() {
+				val = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+			})
+
+			It("validates", func() {
+				Ω(valErr).ShouldNot(HaveOccurred())
+			})
+		})
+
+	})
+
+	Context("URI template", func() {
+		BeforeEach(func() {
+			f = goa.FormatURITemplate
+		})
+
+		Context("with an invalid value", func() {
+			BeforeEach(func() {
+				val = "/bottles/{id"
+			})
+
+			It("does not validates", func() {
+				Ω(valErr).Should(HaveOccurred())
+			})
+		})
+
+		Context("with a valid value", func() {
+			BeforeEach(func() {
+				val = "/bottles/{id}{?fields,page}"
+			})
+
+			It("validates", func() {
+				Ω(valErr).ShouldNot(HaveOccurred())
+			})
+		})
+
+	})
+
+	Context("RFC1123", func() {
+		BeforeEach(func() {
+			f = goa.FormatRFC1123
+		})
+
+		Context("with an invalid value", func() {
+			BeforeEach(func() {
+				val = "2006-01-02T15:04:05Z"
+			})
+
+			It("does not validates", func() {
+				Ω(valErr).Should(HaveOccurred())
+			})
+		})
+
+		Context("with a valid value", func() {
+			BeforeEach(func() {
+				val = "Mon, 02 Jan 2006 15:04:05 MST"
+			})
+
+			It("validates", func() {
+				Ω(valErr).ShouldNot(HaveOccurred())
+			})
+		})
+
+	})
 })
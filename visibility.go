@@ -0,0 +1,56 @@
+package goa
+
+import "golang.org/x/net/context"
+
+// SetRoles sets the function that computes the roles granted to the principal of the requests,
+// see WithPrincipal and security.ClaimRoles.
+// The response helpers generated for media types whose fields are restricted with the VisibleTo
+// DSL only render the fields visible to these roles, see ContextRoles.
+func (service *Service) SetRoles(roles func(principal interface{}) []string) {
+	service.securityMu.Lock()
+	defer service.securityMu.Unlock()
+	service.roles = roles
+}
+
+// WithRoles returns a copy of ctx where the roles granted to the request principal are the given
+// roles regardless of the function set with SetRoles. It is mainly useful to test the rendering
+// of the responses for each role.
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey, roles)
+}
+
+// ContextRoles returns the roles granted to the principal of the request with the given context:
+// the roles given to WithRoles if any, the roles computed by the function set on the service
+// with SetRoles otherwise. It returns nil if the request is not authenticated or if the service
+// does not define how to compute the roles.
+func ContextRoles(ctx context.Context) []string {
+	if roles, ok := ctx.Value(rolesKey).([]string); ok {
+		return roles
+	}
+	service := RequestService(ctx)
+	if service == nil {
+		return nil
+	}
+	service.securityMu.RLock()
+	f := service.roles
+	service.securityMu.RUnlock()
+	principal := ContextPrincipal(ctx)
+	if f == nil || principal == nil {
+		return nil
+	}
+	return f(principal)
+}
+
+// VisibleTo returns true if roles includes at least one of the allowed roles. The code generated
+// for media types whose fields are restricted with the VisibleTo DSL uses it to decide which
+// fields to render.
+func VisibleTo(roles []string, allowed ...string) bool {
+	for _, r := range roles {
+		for _, a := range allowed {
+			if r == a {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package goa_test
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ContextRoles", func() {
+	var service *goa.Service
+	var ctx context.Context
+
+	BeforeEach(func() {
+		service = goa.New("test")
+		req, err := http.NewRequest("GET", "/accounts", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		ctx = goa.NewContext(nil, service, &TestResponseWriter{}, req, nil)
+	})
+
+	It("returns nil for anonymous requests", func() {
+		service.SetRoles(func(interface{}) []string { return []string{"admin"} })
+		Ω(goa.ContextRoles(ctx)).Should(BeNil())
+	})
+
+	It("computes the roles of the principal", func() {
+		service.SetRoles(func(p interface{}) []string { return []string{p.(string) + "-role"} })
+		ctx = goa.WithPrincipal(ctx, "alice")
+		Ω(goa.ContextRoles(ctx)).Should(Equal([]string{"alice-role"}))
+	})
+
+	It("gives precedence to the roles set with WithRoles", func() {
+		service.SetRoles(func(interface{}) []string { return []string{"admin"} })
+		ctx = goa.WithRoles(goa.WithPrincipal(ctx, "alice"), "auditor")
+		Ω(goa.ContextRoles(ctx)).Should(Equal([]string{"auditor"}))
+	})
+})
+
+var _ = Describe("VisibleTo", func() {
+	It("checks that one of the roles is allowed", func() {
+		Ω(goa.VisibleTo([]string{"user", "hr"}, "admin", "hr")).Should(BeTrue())
+		Ω(goa.VisibleTo([]string{"user"}, "admin", "hr")).Should(BeFalse())
+		Ω(goa.VisibleTo(nil, "admin")).Should(BeFalse())
+	})
+})
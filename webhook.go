@@ -0,0 +1,268 @@
+package goa
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// List of supported webhook signing schemes.
+const (
+	// SigningNone disables the signing of webhook requests.
+	SigningNone = "none"
+	// SigningHMACSHA1 signs webhook requests with HMAC SHA-1.
+	SigningHMACSHA1 = "hmac-sha1"
+	// SigningHMACSHA256 signs webhook requests with HMAC SHA-256.
+	SigningHMACSHA256 = "hmac-sha256"
+	// SigningHMACSHA512 signs webhook requests with HMAC SHA-512.
+	SigningHMACSHA512 = "hmac-sha512"
+)
+
+type (
+	// WebhookDispatcher delivers the requests of an outbound webhook (callback) to the URLs
+	// registered by clients. Request bodies are encoded with the service encoders and signed
+	// with the dispatcher secret. Failed deliveries are retried with an exponential backoff on
+	// network errors and on 408, 429 and 5xx responses. The dispatchers generated by goagen for
+	// the callbacks defined in the design are initialized with the settings of the design.
+	WebhookDispatcher struct {
+		// Service is the service whose encoders are used to encode the request bodies.
+		Service *Service
+		// Event is the name of the event sent in the X-Webhook-Event header.
+		Event string
+		// Method is the HTTP method of the requests, "POST" if empty.
+		Method string
+		// ContentType is the content type of the request bodies, "application/json" if empty.
+		ContentType string
+		// SigningScheme is one of SigningNone, SigningHMACSHA1, SigningHMACSHA256 or
+		// SigningHMACSHA512, SigningNone if empty.
+		SigningScheme string
+		// SignatureHeader is the name of the header holding the request signature.
+		SignatureHeader string
+		// Secret is the key used to sign the requests.
+		Secret []byte
+		// MaxAttempts is the maximum number of delivery attempts including the first one,
+		// one if zero.
+		MaxAttempts int
+		// Backoff is the delay before the first retry, it doubles with each retry.
+		Backoff time.Duration
+		// MaxBackoff caps the delay between retries if not zero.
+		MaxBackoff time.Duration
+		// Store records the delivery attempts if not nil.
+		Store DeliveryStore
+		// Client is the HTTP client used to make the requests, http.DefaultClient if nil.
+		Client *http.Client
+	}
+
+	// WebhookDelivery describes a delivery attempt.
+	WebhookDelivery struct {
+		// ID identifies the delivery, it is the same for all the attempts and is sent in the
+		// X-Webhook-Delivery header.
+		ID string
+		// Event is the webhook event name.
+		Event string
+		// URL is the URL the request was sent to.
+		URL string
+		// Attempt is the attempt number starting at 1.
+		Attempt int
+		// Status is the response status code, zero if no response was received.
+		Status int
+		// Error describes why the attempt failed if it did.
+		Error string
+		// Started is the time the attempt started.
+		Started time.Time
+		// Duration is the duration of the attempt.
+		Duration time.Duration
+	}
+
+	// DeliveryStore is implemented by the stores that keep track of webhook deliveries, for
+	// example to expose a delivery log to clients or to redeliver failed requests.
+	DeliveryStore interface {
+		// Record records a delivery attempt.
+		Record(ctx context.Context, d *WebhookDelivery) error
+	}
+)
+
+// NewWebhookDispatcher returns a dispatcher for the given event that uses the encoders of the
+// given service and makes a single attempt per delivery.
+func NewWebhookDispatcher(service *Service, event string) *WebhookDispatcher {
+	return &WebhookDispatcher{Service: service, Event: event, MaxAttempts: 1}
+}
+
+// Deliver encodes payload and sends it to url, retrying failed attempts according to the
+// dispatcher retry policy. It returns an error if all the attempts fail or if ctx is done
+// before the delivery succeeds.
+func (d *WebhookDispatcher) Deliver(ctx context.Context, url string, payload interface{}) error {
+	var buf bytes.Buffer
+	if err := d.Service.Encode(payload, &buf, d.ContentType); err != nil {
+		return fmt.Errorf("failed to encode %s webhook payload: %s", d.Event, err)
+	}
+	body := buf.Bytes()
+	var signature string
+	if d.SigningScheme != "" && d.SigningScheme != SigningNone {
+		var err error
+		if signature, err = SignWebhook(d.SigningScheme, d.Secret, body); err != nil {
+			return err
+		}
+	}
+	delivery := &WebhookDelivery{ID: newDeliveryID(), Event: d.Event, URL: url}
+	attempts := d.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for delivery.Attempt = 1; delivery.Attempt <= attempts; delivery.Attempt++ {
+		var retryAfter time.Duration
+		retryAfter, err = d.attempt(ctx, delivery, body, signature)
+		if err == nil {
+			IncrCounter([]string{"goa", "webhook", d.Event, "delivered"}, 1.0)
+			return nil
+		}
+		if retryAfter < 0 || delivery.Attempt == attempts {
+			break
+		}
+		delay := d.backoff(delivery.Attempt)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		select {
+		case <-time.After(delay):
+			continue
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		break
+	}
+	IncrCounter([]string{"goa", "webhook", d.Event, "failed"}, 1.0)
+	return fmt.Errorf("failed to deliver %s webhook to %s: %s", d.Event, url, err)
+}
+
+// attempt makes a single delivery attempt and records it. It returns the minimum delay before the
+// next attempt given by the Retry-After response header if any, or a negative delay if the attempt
+// failed and should not be retried.
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery *WebhookDelivery, body []byte, signature string) (time.Duration, error) {
+	delivery.Started = time.Now()
+	delivery.Status = 0
+	delivery.Error = ""
+	defer func() {
+		delivery.Duration = time.Since(delivery.Started)
+		if d.Store != nil {
+			attempt := *delivery
+			if err := d.Store.Record(ctx, &attempt); err != nil {
+				Error(ctx, "failed to record webhook delivery", KV{"id", delivery.ID}, KV{"error", err.Error()})
+			}
+		}
+	}()
+	method := d.Method
+	if method == "" {
+		method = "POST"
+	}
+	req, err := http.NewRequest(method, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return -1, err
+	}
+	contentType := d.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	if signature != "" {
+		req.Header.Set(d.SignatureHeader, signature)
+	}
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		delivery.Error = err.Error()
+		return 0, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	delivery.Status = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+	err = fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	delivery.Error = err.Error()
+	switch {
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode >= 500:
+		return 0, err
+	case resp.StatusCode == http.StatusTooManyRequests:
+		secs, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return time.Duration(secs) * time.Second, err
+	}
+	return -1, err
+}
+
+// backoff returns the delay before the retry following the given attempt.
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+	delay := d.Backoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if d.MaxBackoff > 0 && delay >= d.MaxBackoff {
+			return d.MaxBackoff
+		}
+	}
+	if d.MaxBackoff > 0 && delay > d.MaxBackoff {
+		return d.MaxBackoff
+	}
+	return delay
+}
+
+// SignWebhook returns the signature of the given webhook request body computed with the given
+// scheme and secret. The signature is the hex encoded HMAC prefixed with the name of the hash
+// algorithm, e.g. "sha256=8d2b...".
+func SignWebhook(scheme string, secret, body []byte) (string, error) {
+	var (
+		name string
+		h    func() hash.Hash
+	)
+	switch scheme {
+	case SigningHMACSHA1:
+		name, h = "sha1", sha1.New
+	case SigningHMACSHA256:
+		name, h = "sha256", sha256.New
+	case SigningHMACSHA512:
+		name, h = "sha512", sha512.New
+	default:
+		return "", fmt.Errorf("unknown webhook signing scheme %#v", scheme)
+	}
+	mac := hmac.New(h, secret)
+	mac.Write(body)
+	return name + "=" + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyWebhookSignature returns true if signature is the signature of the given webhook request
+// body computed with the given scheme and secret. It is meant to be used by webhook receivers.
+func VerifyWebhookSignature(scheme string, secret, body []byte, signature string) bool {
+	expected, err := SignWebhook(scheme, secret, body)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(strings.TrimSpace(signature)))
+}
+
+// newDeliveryID returns a random delivery identifier.
+func newDeliveryID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,106 @@
+package goa_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/goadesign/goa"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+)
+
+type memoryStore struct {
+	sync.Mutex
+	deliveries []*goa.WebhookDelivery
+}
+
+func (s *memoryStore) Record(_ context.Context, d *goa.WebhookDelivery) error {
+	s.Lock()
+	defer s.Unlock()
+	s.deliveries = append(s.deliveries, d)
+	return nil
+}
+
+var _ = Describe("WebhookDispatcher", func() {
+	var statuses []int
+	var bodies []string
+	var headers []http.Header
+	var server *httptest.Server
+	var store *memoryStore
+	var dispatcher *goa.WebhookDispatcher
+
+	BeforeEach(func() {
+		statuses = nil
+		bodies = nil
+		headers = nil
+		store = new(memoryStore)
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			b, _ := ioutil.ReadAll(req.Body)
+			bodies = append(bodies, string(b))
+			headers = append(headers, req.Header)
+			status := http.StatusNoContent
+			if len(statuses) > 0 {
+				status, statuses = statuses[0], statuses[1:]
+			}
+			rw.WriteHeader(status)
+		}))
+		service := goa.New("test")
+		service.SetEncoder(goa.JSONEncoderFactory(), true, "application/json")
+		dispatcher = goa.NewWebhookDispatcher(service, "OrderShipped")
+		dispatcher.SigningScheme = goa.SigningHMACSHA256
+		dispatcher.SignatureHeader = "X-Webhook-Signature"
+		dispatcher.Secret = []byte("secret")
+		dispatcher.MaxAttempts = 3
+		dispatcher.Backoff = time.Millisecond
+		dispatcher.Store = store
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("delivers signed requests", func() {
+		err := dispatcher.Deliver(context.Background(), server.URL, map[string]string{"id": "42"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(bodies).Should(HaveLen(1))
+		Ω(headers[0].Get("X-Webhook-Event")).Should(Equal("OrderShipped"))
+		Ω(headers[0].Get("X-Webhook-Delivery")).ShouldNot(BeEmpty())
+		sig := headers[0].Get("X-Webhook-Signature")
+		Ω(sig).Should(HavePrefix("sha256="))
+		Ω(goa.VerifyWebhookSignature(goa.SigningHMACSHA256, []byte("secret"), []byte(bodies[0]), sig)).Should(BeTrue())
+		Ω(goa.VerifyWebhookSignature(goa.SigningHMACSHA256, []byte("other"), []byte(bodies[0]), sig)).Should(BeFalse())
+		Ω(store.deliveries).Should(HaveLen(1))
+		Ω(store.deliveries[0].Status).Should(Equal(http.StatusNoContent))
+	})
+
+	It("retries failed deliveries", func() {
+		statuses = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+		err := dispatcher.Deliver(context.Background(), server.URL, "payload")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(bodies).Should(HaveLen(3))
+		Ω(headers[0].Get("X-Webhook-Delivery")).Should(Equal(headers[2].Get("X-Webhook-Delivery")))
+		Ω(store.deliveries).Should(HaveLen(3))
+		Ω(store.deliveries[0].Attempt).Should(Equal(1))
+		Ω(store.deliveries[0].Status).Should(Equal(http.StatusServiceUnavailable))
+		Ω(store.deliveries[2].Attempt).Should(Equal(3))
+		Ω(store.deliveries[2].Error).Should(BeEmpty())
+	})
+
+	It("gives up after the maximum number of attempts", func() {
+		statuses = []int{500, 500, 500, 500}
+		err := dispatcher.Deliver(context.Background(), server.URL, "payload")
+		Ω(err).Should(HaveOccurred())
+		Ω(bodies).Should(HaveLen(3))
+	})
+
+	It("does not retry client errors", func() {
+		statuses = []int{http.StatusBadRequest}
+		err := dispatcher.Deliver(context.Background(), server.URL, "payload")
+		Ω(err).Should(HaveOccurred())
+		Ω(bodies).Should(HaveLen(1))
+	})
+})
@@ -0,0 +1,72 @@
+package wshub
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+)
+
+// Conn is a WebSocket connection registered with a hub. Messages are encoded using JSON.
+type Conn struct {
+	// ID identifies the connection in the hub.
+	ID string
+	// Hub is the hub serving the connection.
+	Hub *Hub
+
+	ws  *websocket.Conn
+	ctx context.Context
+	mu  sync.Mutex // serializes writes
+}
+
+// Context returns the context of the request that was upgraded to the connection.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// SetContext replaces the connection context, middleware use it to make values available to the
+// handler.
+func (c *Conn) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// Request returns the request that was upgraded to the connection.
+func (c *Conn) Request() *http.Request {
+	return c.ws.Request()
+}
+
+// Subprotocol returns the subprotocol selected for the connection, the empty string if none.
+func (c *Conn) Subprotocol() string {
+	if p := c.ws.Config().Protocol; len(p) > 0 {
+		return p[0]
+	}
+	return ""
+}
+
+// Send sends the JSON representation of msg.
+func (c *Conn) Send(msg interface{}) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.send(b)
+}
+
+// Receive waits for the next message and decodes its JSON representation into msg.
+func (c *Conn) Receive(msg interface{}) error {
+	return websocket.JSON.Receive(c.ws, msg)
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.ws.Close()
+}
+
+// send writes a text frame containing b.
+func (c *Conn) send(b []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return websocket.Message.Send(c.ws, string(b))
+}
@@ -0,0 +1,196 @@
+// Package wshub provides the runtime support for the WebSocket endpoints of goa services. A hub
+// keeps track of the open connections, runs the per-connection middleware and makes it possible
+// to broadcast messages to all or a subset of the connections.
+//
+// The contexts generated for WebSocket actions expose an Upgrade method that serves the request
+// with a hub:
+//
+//	var chatHub = wshub.New()
+//
+//	func (c *RoomController) Chat(ctx *app.ChatRoomContext) error {
+//		return ctx.Upgrade(chatHub, func(conn *app.ChatRoomConn) error {
+//			for {
+//				msg, err := conn.Receive()
+//				if err != nil {
+//					return err
+//				}
+//				chatHub.Broadcast(&app.ChatEvent{Text: msg.Text})
+//			}
+//		})
+//	}
+package wshub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/goadesign/goa"
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+)
+
+type (
+	// Hub keeps track of the WebSocket connections it serves.
+	Hub struct {
+		mu         sync.RWMutex
+		conns      map[string]*Conn
+		middleware []Middleware
+		lastID     uint64
+	}
+
+	// Handler handles a WebSocket connection. The connection is closed when the handler
+	// returns.
+	Handler func(c *Conn) error
+
+	// Middleware wraps a connection handler, it can run code before the connection is handed
+	// to the handler and after it is closed.
+	Middleware func(Handler) Handler
+)
+
+// New returns a hub with no connection.
+func New() *Hub {
+	return &Hub{conns: make(map[string]*Conn)}
+}
+
+// Use adds a middleware to the hub. The middleware run in the order they are added, before the
+// connection handler.
+func (h *Hub) Use(m Middleware) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.middleware = append(h.middleware, m)
+}
+
+// Serve upgrades the request to a WebSocket connection, registers the connection with the hub and
+// runs the hub middleware and the given handler. Serve returns the error returned by the handler
+// once the connection is closed. If subprotocols is not empty then requests must either not ask
+// for a subprotocol or ask for one of the given subprotocols, the first one matching is selected.
+func (h *Hub) Serve(ctx context.Context, rw http.ResponseWriter, req *http.Request, subprotocols []string, handler Handler) error {
+	if rd, ok := rw.(*goa.ResponseData); ok {
+		rd.Status = http.StatusSwitchingProtocols
+		rw = rd.ResponseWriter
+	}
+	h.mu.RLock()
+	for i := len(h.middleware) - 1; i >= 0; i-- {
+		handler = h.middleware[i](handler)
+	}
+	h.mu.RUnlock()
+	var err error
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			return negotiate(config, subprotocols)
+		},
+		Handler: func(ws *websocket.Conn) {
+			c := &Conn{
+				ID:  strconv.FormatUint(atomic.AddUint64(&h.lastID, 1), 10),
+				Hub: h,
+				ws:  ws,
+				ctx: ctx,
+			}
+			h.register(c)
+			defer h.unregister(c)
+			defer ws.Close()
+			err = handler(c)
+		},
+	}
+	server.ServeHTTP(rw, req)
+	return err
+}
+
+// Len returns the number of open connections.
+func (h *Hub) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.conns)
+}
+
+// Conn returns the open connection with the given ID, nil if there is none.
+func (h *Hub) Conn(id string) *Conn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.conns[id]
+}
+
+// Broadcast sends the JSON representation of msg to all the open connections.
+func (h *Hub) Broadcast(msg interface{}) error {
+	return h.BroadcastTo(msg, nil)
+}
+
+// BroadcastTo sends the JSON representation of msg to the open connections for which filter
+// returns true, all the open connections if filter is nil. BroadcastTo attempts to send the
+// message to all the connections and returns an error describing the failures if any.
+func (h *Hub) BroadcastTo(msg interface{}, filter func(*Conn) bool) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	h.mu.RLock()
+	conns := make([]*Conn, 0, len(h.conns))
+	for _, c := range h.conns {
+		if filter == nil || filter(c) {
+			conns = append(conns, c)
+		}
+	}
+	h.mu.RUnlock()
+	var failed int
+	var first error
+	for _, c := range conns {
+		if err := c.send(b); err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to send message to %d connection(s): %s", failed, first)
+	}
+	return nil
+}
+
+// Close closes all the open connections.
+func (h *Hub) Close() {
+	h.mu.RLock()
+	conns := make([]*Conn, 0, len(h.conns))
+	for _, c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.RUnlock()
+	for _, c := range conns {
+		c.Close()
+	}
+}
+
+// register adds the connection to the hub.
+func (h *Hub) register(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.conns[c.ID] = c
+}
+
+// unregister removes the connection from the hub.
+func (h *Hub) unregister(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, c.ID)
+}
+
+// negotiate selects the subprotocol used by the connection.
+func negotiate(config *websocket.Config, subprotocols []string) error {
+	if len(subprotocols) == 0 || len(config.Protocol) == 0 {
+		config.Protocol = nil
+		return nil
+	}
+	for _, p := range config.Protocol {
+		for _, s := range subprotocols {
+			if p == s {
+				config.Protocol = []string{p}
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unsupported subprotocol(s) %v", config.Protocol)
+}
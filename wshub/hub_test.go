@@ -0,0 +1,146 @@
+package wshub_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/goadesign/goa/wshub"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/net/context"
+	"golang.org/x/net/websocket"
+)
+
+type message struct {
+	Text string `json:"text"`
+}
+
+var _ = Describe("Hub", func() {
+	var hub *wshub.Hub
+	var subprotocols []string
+	var server *httptest.Server
+	var handled chan *wshub.Conn
+	var served chan error
+
+	dial := func(protocol string) (*websocket.Conn, error) {
+		url := "ws" + strings.TrimPrefix(server.URL, "http")
+		return websocket.Dial(url, protocol, server.URL)
+	}
+
+	BeforeEach(func() {
+		hub = wshub.New()
+		subprotocols = nil
+		handled = make(chan *wshub.Conn, 1)
+		served = make(chan error, 1)
+	})
+
+	JustBeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			served <- hub.Serve(context.Background(), rw, req, subprotocols, func(c *wshub.Conn) error {
+				handled <- c
+				var msg message
+				for {
+					if err := c.Receive(&msg); err != nil {
+						return nil
+					}
+					if err := c.Send(&msg); err != nil {
+						return err
+					}
+				}
+			})
+		}))
+	})
+
+	AfterEach(func() {
+		hub.Close()
+		server.Close()
+	})
+
+	It("registers connections and echoes messages", func() {
+		ws, err := dial("")
+		Ω(err).ShouldNot(HaveOccurred())
+		c := <-handled
+		Ω(hub.Len()).Should(Equal(1))
+		Ω(hub.Conn(c.ID)).Should(Equal(c))
+
+		Ω(websocket.JSON.Send(ws, &message{Text: "hello"})).ShouldNot(HaveOccurred())
+		var msg message
+		Ω(websocket.JSON.Receive(ws, &msg)).ShouldNot(HaveOccurred())
+		Ω(msg.Text).Should(Equal("hello"))
+
+		ws.Close()
+		Eventually(served).Should(Receive(BeNil()))
+		Ω(hub.Len()).Should(Equal(0))
+	})
+
+	It("broadcasts to the filtered connections", func() {
+		ws1, err := dial("")
+		Ω(err).ShouldNot(HaveOccurred())
+		defer ws1.Close()
+		c1 := <-handled
+		ws2, err := dial("")
+		Ω(err).ShouldNot(HaveOccurred())
+		defer ws2.Close()
+		<-handled
+
+		Ω(hub.Broadcast(&message{Text: "all"})).ShouldNot(HaveOccurred())
+		Ω(hub.BroadcastTo(&message{Text: "one"}, func(c *wshub.Conn) bool { return c.ID == c1.ID })).ShouldNot(HaveOccurred())
+
+		var msg message
+		Ω(websocket.JSON.Receive(ws1, &msg)).ShouldNot(HaveOccurred())
+		Ω(msg.Text).Should(Equal("all"))
+		Ω(websocket.JSON.Receive(ws1, &msg)).ShouldNot(HaveOccurred())
+		Ω(msg.Text).Should(Equal("one"))
+		Ω(websocket.JSON.Receive(ws2, &msg)).ShouldNot(HaveOccurred())
+		Ω(msg.Text).Should(Equal("all"))
+	})
+
+	Context("with middleware", func() {
+		type key int
+
+		BeforeEach(func() {
+			hub.Use(func(h wshub.Handler) wshub.Handler {
+				return func(c *wshub.Conn) error {
+					c.SetContext(context.WithValue(c.Context(), key(0), "first"))
+					return h(c)
+				}
+			})
+			hub.Use(func(h wshub.Handler) wshub.Handler {
+				return func(c *wshub.Conn) error {
+					v := c.Context().Value(key(0)).(string)
+					c.SetContext(context.WithValue(c.Context(), key(0), v+",second"))
+					return h(c)
+				}
+			})
+		})
+
+		It("runs the middleware in order", func() {
+			ws, err := dial("")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer ws.Close()
+			c := <-handled
+			Ω(c.Context().Value(key(0))).Should(Equal("first,second"))
+		})
+	})
+
+	Context("with subprotocols", func() {
+		BeforeEach(func() {
+			subprotocols = []string{"chat.v1", "chat.v2"}
+		})
+
+		It("selects the requested subprotocol", func() {
+			ws, err := dial("chat.v2")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer ws.Close()
+			c := <-handled
+			Ω(c.Subprotocol()).Should(Equal("chat.v2"))
+		})
+
+		It("rejects unsupported subprotocols", func() {
+			_, err := dial("chat.v3")
+			Ω(err).Should(HaveOccurred())
+			Ω(hub.Len()).Should(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package wshub_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestWshub(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Wshub Suite")
+}